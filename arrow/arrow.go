@@ -0,0 +1,349 @@
+// Package arrow converts query results to the Apache Arrow columnar format,
+// so that they can be consumed by analytical tools such as DuckDB, pandas or Polars.
+//
+// Documents are grouped in record batches, which can be written
+// using the Arrow IPC streaming format:
+//
+//	res, err := db.Query("SELECT * FROM foo")
+//	...
+//	defer res.Close()
+//
+//	err = arrow.WriteStream(w, res, nil)
+//
+// The stream can then be read, for example with pyarrow:
+//
+//	table = pyarrow.ipc.open_stream("foo.arrows").read_all()
+//
+// Record batches can also be written as a Parquet file, one row group per batch:
+//
+//	err = arrow.WriteParquet(w, res, nil)
+//
+// Genji types are mapped to Arrow types as follows:
+//
+//	null      -> Null
+//	bool      -> Bool
+//	integer   -> Int64
+//	double    -> Float64
+//	text      -> Utf8
+//	blob      -> Binary
+//	vector    -> List<Float64>
+//	date      -> Utf8, i.e. 2023-01-02
+//	timestamp -> Utf8, as RFC 3339
+//	interval  -> Utf8, i.e. 1 day 02:00:00
+//	decimal   -> Utf8, i.e. 12.50
+//	uuid      -> Utf8, i.e. 6ba7b810-9dad-11d1-80b4-00c04fd430c8
+//	array     -> Utf8, encoded as JSON
+//	document  -> Utf8, encoded as JSON
+package arrow
+
+import (
+	"fmt"
+
+	"github.com/genjidb/genji/document"
+)
+
+// DefaultBatchSize is the default number of rows of the record batches.
+const DefaultBatchSize = 1024
+
+// A Field describes a column of a record batch.
+type Field struct {
+	Name string
+	// Type of the values of the column. Arrays, documents, dates, timestamps, intervals,
+	// decimals and uuids are stored as text, and fields that only contain null values have the type document.NullValue.
+	Type document.ValueType
+}
+
+// A Schema describes the columns of record batches.
+type Schema struct {
+	Fields []Field
+}
+
+// FieldIndex returns the index of the field with the given name, or -1 if there is none.
+func (s *Schema) FieldIndex(name string) int {
+	for i := range s.Fields {
+		if s.Fields[i].Name == name {
+			return i
+		}
+	}
+
+	return -1
+}
+
+// InferSchema returns a schema able to hold the given documents.
+// Fields are listed in order of appearance. The type of a field is the type
+// of its values. If they have different types, integers are converted to doubles,
+// and any other combination is stored as text.
+func InferSchema(docs []document.Document) (*Schema, error) {
+	var s Schema
+
+	for _, d := range docs {
+		err := d.Iterate(func(field string, v document.Value) error {
+			i := s.FieldIndex(field)
+			if i < 0 {
+				s.Fields = append(s.Fields, Field{Name: field, Type: document.NullValue})
+				i = len(s.Fields) - 1
+			}
+
+			s.Fields[i].Type = mergeTypes(s.Fields[i].Type, storageType(v.Type))
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return &s, nil
+}
+
+// storageType returns the type used to store values of type t.
+func storageType(t document.ValueType) document.ValueType {
+	if t == document.ArrayValue || t == document.DocumentValue || t.IsTime() || t == document.IntervalValue || t == document.DecimalValue || t == document.UUIDValue {
+		return document.TextValue
+	}
+
+	return t
+}
+
+func mergeTypes(a, b document.ValueType) document.ValueType {
+	switch {
+	case a == b, b == document.NullValue:
+		return a
+	case a == document.NullValue:
+		return b
+	case a.IsNumber() && b.IsNumber():
+		return document.DoubleValue
+	}
+
+	return document.TextValue
+}
+
+// A RecordBatch holds documents organized by columns.
+type RecordBatch struct {
+	Schema *Schema
+	// Len is the number of rows of the batch.
+	Len int
+	// Columns of the batch, in the order of the fields of the schema.
+	Columns []Column
+}
+
+// A Column holds the values of a field for every row of a record batch.
+type Column struct {
+	// Valid tells, for every row, whether the value is not null.
+	Valid []bool
+	// Values of the column, depending on the type of the field:
+	// []bool, []int64, []float64, []string, [][]byte or [][]float64.
+	// Null values are zero values. Columns of type null have no values.
+	Values interface{}
+}
+
+// NullCount returns the number of null values of the column.
+func (c *Column) NullCount() int {
+	var n int
+	for _, ok := range c.Valid {
+		if !ok {
+			n++
+		}
+	}
+
+	return n
+}
+
+// NewRecordBatch creates an empty record batch for the given schema.
+func NewRecordBatch(s *Schema) *RecordBatch {
+	b := RecordBatch{
+		Schema:  s,
+		Columns: make([]Column, len(s.Fields)),
+	}
+
+	for i, f := range s.Fields {
+		switch f.Type {
+		case document.BoolValue:
+			b.Columns[i].Values = []bool{}
+		case document.IntegerValue:
+			b.Columns[i].Values = []int64{}
+		case document.DoubleValue:
+			b.Columns[i].Values = []float64{}
+		case document.TextValue:
+			b.Columns[i].Values = []string{}
+		case document.BlobValue:
+			b.Columns[i].Values = [][]byte{}
+		case document.VectorValue:
+			b.Columns[i].Values = [][]float64{}
+		}
+	}
+
+	return &b
+}
+
+// Append adds a row to the batch. Values are converted to the type of their field
+// and missing fields are null. It returns an error if the document has fields
+// that are not part of the schema, or values that cannot be converted.
+func (b *RecordBatch) Append(d document.Document) error {
+	row := make([]document.Value, len(b.Schema.Fields))
+
+	err := d.Iterate(func(field string, v document.Value) error {
+		i := b.Schema.FieldIndex(field)
+		if i < 0 {
+			return fmt.Errorf("arrow: field %q is not part of the schema", field)
+		}
+
+		v, err := v.CastAs(b.Schema.Fields[i].Type)
+		if err != nil {
+			return fmt.Errorf("arrow: field %q: %w", field, err)
+		}
+
+		row[i] = v
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	for i := range row {
+		b.Columns[i].append(row[i])
+	}
+
+	b.Len++
+	return nil
+}
+
+func (c *Column) append(v document.Value) {
+	valid := v.Type != 0 && v.Type != document.NullValue
+	c.Valid = append(c.Valid, valid)
+
+	switch vs := c.Values.(type) {
+	case []bool:
+		var x bool
+		if valid {
+			x = v.V.(bool)
+		}
+		c.Values = append(vs, x)
+	case []int64:
+		var x int64
+		if valid {
+			x = v.V.(int64)
+		}
+		c.Values = append(vs, x)
+	case []float64:
+		var x float64
+		if valid {
+			x = v.V.(float64)
+		}
+		c.Values = append(vs, x)
+	case []string:
+		var x string
+		if valid {
+			x = v.V.(string)
+		}
+		c.Values = append(vs, x)
+	case [][]byte:
+		var x []byte
+		if valid {
+			x = v.V.([]byte)
+		}
+		c.Values = append(vs, x)
+	case [][]float64:
+		var x []float64
+		if valid {
+			x = v.V.([]float64)
+		}
+		c.Values = append(vs, x)
+	}
+}
+
+// Options of the conversion of documents to record batches.
+type Options struct {
+	// Maximum number of rows of every batch. Defaults to DefaultBatchSize.
+	BatchSize int
+	// Schema of the batches. If nil, it is inferred from the documents of the first batch.
+	Schema *Schema
+}
+
+// Batches groups the documents of the iterator in record batches and calls fn for each of them.
+// Batches are not reused after fn returns.
+func Batches(it document.Iterator, opts *Options, fn func(b *RecordBatch) error) error {
+	var o Options
+	if opts != nil {
+		o = *opts
+	}
+	if o.BatchSize <= 0 {
+		o.BatchSize = DefaultBatchSize
+	}
+
+	// documents of the first batch, used to infer the schema
+	var first []document.Document
+	var b *RecordBatch
+
+	err := it.Iterate(func(d document.Document) error {
+		if b == nil && o.Schema == nil {
+			// iterators may reuse documents
+			var fb document.FieldBuffer
+			err := fb.Copy(d)
+			if err != nil {
+				return err
+			}
+
+			first = append(first, &fb)
+			if len(first) < o.BatchSize {
+				return nil
+			}
+
+			b, err = inferBatch(first)
+			if err != nil {
+				return err
+			}
+			first = nil
+		} else {
+			if b == nil {
+				b = NewRecordBatch(o.Schema)
+			}
+
+			err := b.Append(d)
+			if err != nil {
+				return err
+			}
+		}
+
+		if b.Len < o.BatchSize {
+			return nil
+		}
+
+		err := fn(b)
+		b = NewRecordBatch(b.Schema)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	if len(first) > 0 {
+		b, err = inferBatch(first)
+		if err != nil {
+			return err
+		}
+	}
+
+	if b != nil && b.Len > 0 {
+		return fn(b)
+	}
+
+	return nil
+}
+
+// inferBatch creates a batch holding the documents, using the schema inferred from them.
+func inferBatch(docs []document.Document) (*RecordBatch, error) {
+	s, err := InferSchema(docs)
+	if err != nil {
+		return nil, err
+	}
+
+	b := NewRecordBatch(s)
+	for _, d := range docs {
+		err = b.Append(d)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return b, nil
+}
@@ -0,0 +1,122 @@
+package arrow_test
+
+import (
+	"testing"
+
+	"github.com/genjidb/genji"
+	"github.com/genjidb/genji/arrow"
+	"github.com/genjidb/genji/document"
+	"github.com/stretchr/testify/require"
+)
+
+func parseDocs(t *testing.T, jsons ...string) []document.Document {
+	t.Helper()
+
+	var docs []document.Document
+	for _, js := range jsons {
+		var fb document.FieldBuffer
+		err := fb.UnmarshalJSON([]byte(js))
+		require.NoError(t, err)
+		docs = append(docs, &fb)
+	}
+
+	return docs
+}
+
+func TestInferSchema(t *testing.T) {
+	docs := parseDocs(t,
+		`{"a": 1, "b": "foo", "c": null, "d": 1, "e": [1, 2]}`,
+		`{"a": 2, "b": null, "c": null, "d": 1.5, "e": {"a": 1}, "f": true}`,
+		`{"a": 3, "b": "bar", "c": null, "d": 2, "e": "baz"}`,
+	)
+
+	s, err := arrow.InferSchema(docs)
+	require.NoError(t, err)
+	require.Equal(t, []arrow.Field{
+		{Name: "a", Type: document.IntegerValue},
+		{Name: "b", Type: document.TextValue},
+		{Name: "c", Type: document.NullValue},
+		{Name: "d", Type: document.DoubleValue},
+		{Name: "e", Type: document.TextValue},
+		{Name: "f", Type: document.BoolValue},
+	}, s.Fields)
+}
+
+func TestBatches(t *testing.T) {
+	db, err := genji.Open(":memory:")
+	require.NoError(t, err)
+	defer db.Close()
+
+	err = db.Exec(`
+		CREATE TABLE foo(a INTEGER PRIMARY KEY, b TEXT, c DOUBLE);
+		INSERT INTO foo (a, b, c) VALUES (1, 'a', 1.5), (2, null, 2), (3, 'c', null), (4, 'd', 4), (5, 'e', 5);
+	`)
+	require.NoError(t, err)
+
+	t.Run("Inferred schema", func(t *testing.T) {
+		res, err := db.Query(`SELECT a, b, c FROM foo`)
+		require.NoError(t, err)
+		defer res.Close()
+
+		var batches []*arrow.RecordBatch
+		err = arrow.Batches(res, &arrow.Options{BatchSize: 2}, func(b *arrow.RecordBatch) error {
+			batches = append(batches, b)
+			return nil
+		})
+		require.NoError(t, err)
+		require.Len(t, batches, 3)
+
+		require.Equal(t, 2, batches[0].Len)
+		require.Equal(t, []arrow.Field{
+			{Name: "a", Type: document.IntegerValue},
+			{Name: "b", Type: document.TextValue},
+			{Name: "c", Type: document.DoubleValue},
+		}, batches[0].Schema.Fields)
+		require.Equal(t, []int64{1, 2}, batches[0].Columns[0].Values)
+		require.Equal(t, []string{"a", ""}, batches[0].Columns[1].Values)
+		require.Equal(t, []bool{true, false}, batches[0].Columns[1].Valid)
+		require.Equal(t, 1, batches[0].Columns[1].NullCount())
+
+		require.Equal(t, batches[0].Schema, batches[1].Schema)
+		require.Equal(t, []float64{0, 4}, batches[1].Columns[2].Values)
+		require.Equal(t, []bool{false, true}, batches[1].Columns[2].Valid)
+
+		require.Equal(t, 1, batches[2].Len)
+		require.Equal(t, []int64{5}, batches[2].Columns[0].Values)
+	})
+
+	t.Run("Given schema", func(t *testing.T) {
+		res, err := db.Query(`SELECT a, c FROM foo WHERE a < 3`)
+		require.NoError(t, err)
+		defer res.Close()
+
+		s := arrow.Schema{Fields: []arrow.Field{
+			{Name: "c", Type: document.TextValue},
+			{Name: "a", Type: document.DoubleValue},
+			{Name: "z", Type: document.IntegerValue},
+		}}
+
+		var batches []*arrow.RecordBatch
+		err = arrow.Batches(res, &arrow.Options{Schema: &s}, func(b *arrow.RecordBatch) error {
+			batches = append(batches, b)
+			return nil
+		})
+		require.NoError(t, err)
+		require.Len(t, batches, 1)
+		require.Equal(t, []string{"1.5", "2"}, batches[0].Columns[0].Values)
+		require.Equal(t, []float64{1, 2}, batches[0].Columns[1].Values)
+		require.Equal(t, []bool{false, false}, batches[0].Columns[2].Valid)
+	})
+
+	t.Run("Unknown field", func(t *testing.T) {
+		res, err := db.Query(`SELECT a, b FROM foo`)
+		require.NoError(t, err)
+		defer res.Close()
+
+		s := arrow.Schema{Fields: []arrow.Field{{Name: "a", Type: document.IntegerValue}}}
+		err = arrow.Batches(res, &arrow.Options{Schema: &s}, func(b *arrow.RecordBatch) error {
+			return nil
+		})
+		require.EqualError(t, err, `arrow: field "b" is not part of the schema`)
+	})
+}
@@ -0,0 +1,11 @@
+module github.com/genjidb/genji/arrow
+
+go 1.16
+
+require (
+	github.com/genjidb/genji v0.13.0
+	github.com/google/flatbuffers v1.12.0
+	github.com/stretchr/testify v1.7.0
+)
+
+replace github.com/genjidb/genji v0.13.0 => ../
@@ -0,0 +1,368 @@
+package arrow
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"io"
+	"math"
+
+	"github.com/genjidb/genji/document"
+	flatbuffers "github.com/google/flatbuffers/go"
+)
+
+// Identifiers of the Arrow format, as defined in
+// https://github.com/apache/arrow/blob/master/format/Schema.fbs
+// and https://github.com/apache/arrow/blob/master/format/Message.fbs.
+const (
+	metadataVersionV5 = 4
+
+	messageHeaderSchema      = 1
+	messageHeaderRecordBatch = 3
+
+	typeNull          = 1
+	typeInt           = 2
+	typeFloatingPoint = 3
+	typeBinary        = 4
+	typeUtf8          = 5
+	typeBool          = 6
+	typeList          = 12
+
+	precisionDouble = 2
+)
+
+// continuationMarker starts every message of a stream.
+const continuationMarker = 0xFFFFFFFF
+
+// WriteStream converts the documents of the iterator to record batches and writes them
+// to w using the Arrow IPC streaming format.
+func WriteStream(w io.Writer, it document.Iterator, opts *Options) error {
+	var o Options
+	if opts != nil {
+		o = *opts
+	}
+
+	sw := NewWriter(w, o.Schema)
+
+	err := Batches(it, &o, func(b *RecordBatch) error {
+		return sw.Write(b)
+	})
+	if err != nil {
+		return err
+	}
+
+	return sw.Close()
+}
+
+// A Writer writes record batches using the Arrow IPC streaming format.
+// See https://arrow.apache.org/docs/format/Columnar.html#ipc-streaming-format.
+type Writer struct {
+	w      io.Writer
+	schema *Schema
+	// set once the schema has been written
+	started bool
+}
+
+// NewWriter creates a writer of record batches. If schema is nil,
+// the schema of the first batch is used.
+func NewWriter(w io.Writer, schema *Schema) *Writer {
+	return &Writer{
+		w:      w,
+		schema: schema,
+	}
+}
+
+// Write a record batch to the stream. All the batches must have the same schema.
+func (w *Writer) Write(b *RecordBatch) error {
+	if w.schema == nil {
+		w.schema = b.Schema
+	}
+	if !sameSchema(w.schema, b.Schema) {
+		return errors.New("arrow: the schema of the batch differs from the schema of the stream")
+	}
+
+	err := w.writeSchema()
+	if err != nil {
+		return err
+	}
+
+	var body bodyBuilder
+	for i := range b.Columns {
+		body.addColumn(b.Schema.Fields[i].Type, &b.Columns[i], b.Len)
+	}
+
+	fb := flatbuffers.NewBuilder(1024)
+
+	fb.StartVector(16, len(body.nodes), 8)
+	for i := len(body.nodes) - 1; i >= 0; i-- {
+		fb.Prep(8, 16)
+		fb.PrependInt64(body.nodes[i].nullCount)
+		fb.PrependInt64(body.nodes[i].length)
+	}
+	nodes := fb.EndVector(len(body.nodes))
+
+	fb.StartVector(16, len(body.buffers), 8)
+	for i := len(body.buffers) - 1; i >= 0; i-- {
+		fb.Prep(8, 16)
+		fb.PrependInt64(body.buffers[i].length)
+		fb.PrependInt64(body.buffers[i].offset)
+	}
+	buffers := fb.EndVector(len(body.buffers))
+
+	fb.StartObject(4)
+	fb.PrependInt64Slot(0, int64(b.Len), 0)
+	fb.PrependUOffsetTSlot(1, nodes, 0)
+	fb.PrependUOffsetTSlot(2, buffers, 0)
+	header := fb.EndObject()
+
+	return w.writeMessage(fb, messageHeaderRecordBatch, header, body.buf.Bytes())
+}
+
+// Close ends the stream. It doesn't close the underlying writer.
+func (w *Writer) Close() error {
+	if w.schema == nil {
+		w.schema = new(Schema)
+	}
+
+	err := w.writeSchema()
+	if err != nil {
+		return err
+	}
+
+	var eos [8]byte
+	binary.LittleEndian.PutUint32(eos[:], continuationMarker)
+	_, err = w.w.Write(eos[:])
+	return err
+}
+
+func (w *Writer) writeSchema() error {
+	if w.started {
+		return nil
+	}
+	w.started = true
+
+	fb := flatbuffers.NewBuilder(1024)
+
+	fields := make([]flatbuffers.UOffsetT, len(w.schema.Fields))
+	for i, f := range w.schema.Fields {
+		fields[i] = buildField(fb, f.Name, f.Type)
+	}
+	vec := buildOffsetVector(fb, fields)
+
+	fb.StartObject(4)
+	fb.PrependUOffsetTSlot(1, vec, 0)
+	header := fb.EndObject()
+
+	return w.writeMessage(fb, messageHeaderSchema, header, nil)
+}
+
+// writeMessage writes an encapsulated message: a continuation marker,
+// the size of the metadata, the metadata padded to 8 bytes, then the body.
+func (w *Writer) writeMessage(fb *flatbuffers.Builder, headerType byte, header flatbuffers.UOffsetT, body []byte) error {
+	fb.StartObject(5)
+	fb.PrependInt16Slot(0, metadataVersionV5, 0)
+	fb.PrependByteSlot(1, headerType, 0)
+	fb.PrependUOffsetTSlot(2, header, 0)
+	fb.PrependInt64Slot(3, int64(len(body)), 0)
+	fb.Finish(fb.EndObject())
+
+	meta := fb.FinishedBytes()
+	padding := pad8(len(meta)) - len(meta)
+
+	var prefix [8]byte
+	binary.LittleEndian.PutUint32(prefix[:4], continuationMarker)
+	binary.LittleEndian.PutUint32(prefix[4:], uint32(len(meta)+padding))
+
+	for _, p := range [][]byte{prefix[:], meta, make([]byte, padding), body} {
+		_, err := w.w.Write(p)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// buildField adds the description of a field and its type.
+func buildField(fb *flatbuffers.Builder, name string, t document.ValueType) flatbuffers.UOffsetT {
+	var children []flatbuffers.UOffsetT
+	if t == document.VectorValue {
+		children = append(children, buildField(fb, "item", document.DoubleValue))
+	}
+	childrenVec := buildOffsetVector(fb, children)
+
+	nameOff := fb.CreateString(name)
+
+	var typeType byte
+	switch t {
+	case document.BoolValue:
+		typeType = typeBool
+		fb.StartObject(0)
+	case document.IntegerValue:
+		typeType = typeInt
+		fb.StartObject(2)
+		fb.PrependInt32Slot(0, 64, 0)
+		fb.PrependBoolSlot(1, true, false)
+	case document.DoubleValue:
+		typeType = typeFloatingPoint
+		fb.StartObject(1)
+		fb.PrependInt16Slot(0, precisionDouble, 0)
+	case document.TextValue:
+		typeType = typeUtf8
+		fb.StartObject(0)
+	case document.BlobValue:
+		typeType = typeBinary
+		fb.StartObject(0)
+	case document.VectorValue:
+		typeType = typeList
+		fb.StartObject(0)
+	default:
+		typeType = typeNull
+		fb.StartObject(0)
+	}
+	typeOff := fb.EndObject()
+
+	fb.StartObject(7)
+	fb.PrependUOffsetTSlot(0, nameOff, 0)
+	fb.PrependBoolSlot(1, true, false)
+	fb.PrependByteSlot(2, typeType, 0)
+	fb.PrependUOffsetTSlot(3, typeOff, 0)
+	fb.PrependUOffsetTSlot(5, childrenVec, 0)
+	return fb.EndObject()
+}
+
+func buildOffsetVector(fb *flatbuffers.Builder, offsets []flatbuffers.UOffsetT) flatbuffers.UOffsetT {
+	fb.StartVector(4, len(offsets), 4)
+	for i := len(offsets) - 1; i >= 0; i-- {
+		fb.PrependUOffsetT(offsets[i])
+	}
+	return fb.EndVector(len(offsets))
+}
+
+func sameSchema(a, b *Schema) bool {
+	if a == b {
+		return true
+	}
+	if len(a.Fields) != len(b.Fields) {
+		return false
+	}
+
+	for i := range a.Fields {
+		if a.Fields[i] != b.Fields[i] {
+			return false
+		}
+	}
+
+	return true
+}
+
+func pad8(n int) int {
+	return (n + 7) &^ 7
+}
+
+type fieldNode struct {
+	length, nullCount int64
+}
+
+type bufferSpec struct {
+	offset, length int64
+}
+
+// bodyBuilder lays out the buffers of the columns of a record batch.
+type bodyBuilder struct {
+	buf     bytes.Buffer
+	nodes   []fieldNode
+	buffers []bufferSpec
+}
+
+// addBuffer appends p to the body, aligned on 8 bytes.
+func (b *bodyBuilder) addBuffer(p []byte) {
+	off := b.buf.Len()
+	b.buf.Write(p)
+	b.buf.Write(make([]byte, pad8(len(p))-len(p)))
+
+	b.buffers = append(b.buffers, bufferSpec{offset: int64(off), length: int64(len(p))})
+}
+
+func (b *bodyBuilder) addColumn(t document.ValueType, c *Column, length int) {
+	nullCount := c.NullCount()
+	if t == document.NullValue || t == document.AnyType {
+		// null columns have no buffers
+		b.nodes = append(b.nodes, fieldNode{length: int64(length), nullCount: int64(length)})
+		return
+	}
+
+	b.nodes = append(b.nodes, fieldNode{length: int64(length), nullCount: int64(nullCount)})
+
+	// the validity bitmap can be omitted if there are no nulls
+	if nullCount == 0 {
+		b.addBuffer(nil)
+	} else {
+		b.addBuffer(bitmap(c.Valid))
+	}
+
+	switch vs := c.Values.(type) {
+	case []bool:
+		b.addBuffer(bitmap(vs))
+	case []int64:
+		buf := make([]byte, 8*len(vs))
+		for i, x := range vs {
+			binary.LittleEndian.PutUint64(buf[8*i:], uint64(x))
+		}
+		b.addBuffer(buf)
+	case []float64:
+		b.addBuffer(float64Bytes(vs))
+	case []string:
+		offsets := make([]byte, 4*(len(vs)+1))
+		var data []byte
+		for i, x := range vs {
+			data = append(data, x...)
+			binary.LittleEndian.PutUint32(offsets[4*(i+1):], uint32(len(data)))
+		}
+		b.addBuffer(offsets)
+		b.addBuffer(data)
+	case [][]byte:
+		offsets := make([]byte, 4*(len(vs)+1))
+		var data []byte
+		for i, x := range vs {
+			data = append(data, x...)
+			binary.LittleEndian.PutUint32(offsets[4*(i+1):], uint32(len(data)))
+		}
+		b.addBuffer(offsets)
+		b.addBuffer(data)
+	case [][]float64:
+		offsets := make([]byte, 4*(len(vs)+1))
+		var values []float64
+		for i, x := range vs {
+			values = append(values, x...)
+			binary.LittleEndian.PutUint32(offsets[4*(i+1):], uint32(len(values)))
+		}
+		b.addBuffer(offsets)
+
+		// the values are stored in a child array
+		b.nodes = append(b.nodes, fieldNode{length: int64(len(values))})
+		b.addBuffer(nil)
+		b.addBuffer(float64Bytes(values))
+	}
+}
+
+// bitmap packs the booleans, least significant bit first.
+func bitmap(bs []bool) []byte {
+	buf := make([]byte, (len(bs)+7)/8)
+	for i, ok := range bs {
+		if ok {
+			buf[i/8] |= 1 << (i % 8)
+		}
+	}
+
+	return buf
+}
+
+func float64Bytes(vs []float64) []byte {
+	buf := make([]byte, 8*len(vs))
+	for i, x := range vs {
+		binary.LittleEndian.PutUint64(buf[8*i:], math.Float64bits(x))
+	}
+
+	return buf
+}
@@ -0,0 +1,207 @@
+package arrow_test
+
+import (
+	"bytes"
+	"encoding/binary"
+	"math"
+	"testing"
+
+	"github.com/genjidb/genji"
+	"github.com/genjidb/genji/arrow"
+	flatbuffers "github.com/google/flatbuffers/go"
+	"github.com/stretchr/testify/require"
+)
+
+// message is a decoded encapsulated message of an Arrow stream.
+type message struct {
+	version    int16
+	headerType byte
+	header     flatbuffers.Table
+	body       []byte
+}
+
+// readMessages decodes the messages of a stream, following the specification
+// of the format, up to the end-of-stream marker.
+func readMessages(t *testing.T, data []byte) []message {
+	t.Helper()
+
+	var msgs []message
+	for {
+		require.Equal(t, uint32(0xFFFFFFFF), binary.LittleEndian.Uint32(data))
+		size := binary.LittleEndian.Uint32(data[4:])
+		data = data[8:]
+		if size == 0 {
+			require.Empty(t, data)
+			return msgs
+		}
+		require.Zero(t, size%8)
+
+		meta := data[:size]
+		data = data[size:]
+
+		tab := flatbuffers.Table{Bytes: meta, Pos: flatbuffers.GetUOffsetT(meta)}
+
+		var m message
+		m.version = tab.GetInt16Slot(4, 0)
+		m.headerType = tab.GetByteSlot(6, 0)
+		tab.Union(&m.header, flatbuffers.UOffsetT(tab.Offset(8)))
+		bodyLength := tab.GetInt64Slot(10, 0)
+		require.Zero(t, bodyLength%8)
+
+		m.body = data[:bodyLength]
+		data = data[bodyLength:]
+		msgs = append(msgs, m)
+	}
+}
+
+type field struct {
+	name     string
+	nullable bool
+	typeType byte
+	children []field
+}
+
+func readField(tab *flatbuffers.Table) field {
+	var f field
+	f.name = tab.String(flatbuffers.UOffsetT(tab.Offset(4)) + tab.Pos)
+	f.nullable = tab.GetBoolSlot(6, false)
+	f.typeType = tab.GetByteSlot(8, 0)
+
+	o := flatbuffers.UOffsetT(tab.Offset(14))
+	f.children = []field{}
+	for i := 0; i < tab.VectorLen(o); i++ {
+		x := tab.Indirect(tab.Vector(o) + flatbuffers.UOffsetT(i*4))
+		child := flatbuffers.Table{Bytes: tab.Bytes, Pos: x}
+		f.children = append(f.children, readField(&child))
+	}
+
+	return f
+}
+
+func readSchema(tab *flatbuffers.Table) []field {
+	o := flatbuffers.UOffsetT(tab.Offset(6))
+
+	fields := []field{}
+	for i := 0; i < tab.VectorLen(o); i++ {
+		x := tab.Indirect(tab.Vector(o) + flatbuffers.UOffsetT(i*4))
+		ft := flatbuffers.Table{Bytes: tab.Bytes, Pos: x}
+		fields = append(fields, readField(&ft))
+	}
+
+	return fields
+}
+
+// readStructs reads a vector of structs made of two longs.
+func readStructs(tab *flatbuffers.Table, slot flatbuffers.VOffsetT) [][2]int64 {
+	o := flatbuffers.UOffsetT(tab.Offset(slot))
+
+	var list [][2]int64
+	for i := 0; i < tab.VectorLen(o); i++ {
+		x := tab.Vector(o) + flatbuffers.UOffsetT(i*16)
+		list = append(list, [2]int64{tab.GetInt64(x), tab.GetInt64(x + 8)})
+	}
+
+	return list
+}
+
+func TestWriteStream(t *testing.T) {
+	db, err := genji.Open(":memory:")
+	require.NoError(t, err)
+	defer db.Close()
+
+	err = db.Exec(`
+		CREATE TABLE foo(a INTEGER PRIMARY KEY, b TEXT, c DOUBLE, d BOOL, e BLOB, f VECTOR(2));
+		INSERT INTO foo (a, b, c, d, e, f) VALUES
+			(1, 'hello', 1.5, true, ?, [1.0, 2.0]),
+			(2, null, 2.5, false, ?, [3.0, 4.0]),
+			(3, 'world', null, true, null, null);
+	`, []byte{0xaa}, []byte{0xbb, 0xcc})
+	require.NoError(t, err)
+
+	res, err := db.Query(`SELECT a, b, c, d, e, f, NULL AS g FROM foo`)
+	require.NoError(t, err)
+	defer res.Close()
+
+	var buf bytes.Buffer
+	err = arrow.WriteStream(&buf, res, &arrow.Options{BatchSize: 2})
+	require.NoError(t, err)
+
+	msgs := readMessages(t, buf.Bytes())
+	require.Len(t, msgs, 3)
+
+	// schema
+	require.Equal(t, int16(4), msgs[0].version)
+	require.Equal(t, byte(1), msgs[0].headerType)
+	require.Empty(t, msgs[0].body)
+	require.Equal(t, []field{
+		{name: "a", nullable: true, typeType: 2, children: []field{}},
+		{name: "b", nullable: true, typeType: 5, children: []field{}},
+		{name: "c", nullable: true, typeType: 3, children: []field{}},
+		{name: "d", nullable: true, typeType: 6, children: []field{}},
+		{name: "e", nullable: true, typeType: 4, children: []field{}},
+		{name: "f", nullable: true, typeType: 12, children: []field{
+			{name: "item", nullable: true, typeType: 3, children: []field{}},
+		}},
+		{name: "g", nullable: true, typeType: 1, children: []field{}},
+	}, readSchema(&msgs[0].header))
+
+	// first batch
+	batch := msgs[1]
+	require.Equal(t, byte(3), batch.headerType)
+	require.Equal(t, int64(2), batch.header.GetInt64Slot(4, 0))
+
+	nodes := readStructs(&batch.header, 6)
+	require.Equal(t, [][2]int64{{2, 0}, {2, 1}, {2, 0}, {2, 0}, {2, 0}, {2, 0}, {4, 0}, {2, 2}}, nodes)
+
+	buffers := readStructs(&batch.header, 8)
+	require.Len(t, buffers, 16)
+	get := func(i int) []byte {
+		return batch.body[buffers[i][0] : buffers[i][0]+buffers[i][1]]
+	}
+	for _, b := range buffers {
+		require.Zero(t, b[0]%8)
+	}
+
+	// a: no validity, int64 values
+	require.Empty(t, get(0))
+	require.Equal(t, uint64(1), binary.LittleEndian.Uint64(get(1)))
+	require.Equal(t, uint64(2), binary.LittleEndian.Uint64(get(1)[8:]))
+	// b: validity, offsets, data
+	require.Equal(t, []byte{0x01}, get(2))
+	require.Equal(t, []byte{0, 0, 0, 0, 5, 0, 0, 0, 5, 0, 0, 0}, get(3))
+	require.Equal(t, "hello", string(get(4)))
+	// c: double values
+	require.Equal(t, 2.5, math.Float64frombits(binary.LittleEndian.Uint64(get(6)[8:])))
+	// d: bit-packed booleans
+	require.Equal(t, []byte{0x01}, get(8))
+	// e: binary
+	require.Equal(t, []byte{0xaa, 0xbb, 0xcc}, get(11))
+	// f: list offsets, then child values. g has no buffers
+	require.Equal(t, []byte{0, 0, 0, 0, 2, 0, 0, 0, 4, 0, 0, 0}, get(13))
+	require.Empty(t, get(14))
+	require.Equal(t, 4.0, math.Float64frombits(binary.LittleEndian.Uint64(get(15)[24:])))
+
+	// second batch
+	require.Equal(t, int64(1), msgs[2].header.GetInt64Slot(4, 0))
+}
+
+func TestWriteStreamEmpty(t *testing.T) {
+	db, err := genji.Open(":memory:")
+	require.NoError(t, err)
+	defer db.Close()
+
+	err = db.Exec(`CREATE TABLE foo`)
+	require.NoError(t, err)
+
+	res, err := db.Query(`SELECT * FROM foo`)
+	require.NoError(t, err)
+	defer res.Close()
+
+	var buf bytes.Buffer
+	err = arrow.WriteStream(&buf, res, nil)
+	require.NoError(t, err)
+
+	msgs := readMessages(t, buf.Bytes())
+	require.Len(t, msgs, 1)
+	require.Equal(t, []field{}, readSchema(&msgs[0].header))
+}
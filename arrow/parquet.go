@@ -0,0 +1,528 @@
+package arrow
+
+import (
+	"encoding/binary"
+	"errors"
+	"io"
+	"math"
+
+	"github.com/genjidb/genji/document"
+)
+
+// Identifiers of the Parquet format, as defined in
+// https://github.com/apache/parquet-format/blob/master/src/main/thrift/parquet.thrift.
+const (
+	parquetMagic = "PAR1"
+
+	parquetBoolean   = 0
+	parquetInt32     = 1
+	parquetInt64     = 2
+	parquetDouble    = 5
+	parquetByteArray = 6
+
+	repetitionRequired = 0
+	repetitionOptional = 1
+	repetitionRepeated = 2
+
+	convertedUTF8 = 0
+	convertedList = 3
+
+	encodingPlain = 0
+	encodingRLE   = 3
+
+	pageTypeData = 0
+
+	codecUncompressed = 0
+)
+
+// WriteParquet converts the documents of the iterator to record batches and writes them
+// to w as a Parquet file, with one row group per batch.
+func WriteParquet(w io.Writer, it document.Iterator, opts *Options) error {
+	var o Options
+	if opts != nil {
+		o = *opts
+	}
+
+	pw := NewParquetWriter(w, o.Schema)
+
+	err := Batches(it, &o, func(b *RecordBatch) error {
+		return pw.Write(b)
+	})
+	if err != nil {
+		return err
+	}
+
+	return pw.Close()
+}
+
+// A ParquetWriter writes record batches to a Parquet file.
+// Columns are written uncompressed, using the plain encoding.
+// Arrow types are stored as follows:
+//
+//	Null          -> optional INT32, whose values are all null
+//	Bool          -> optional BOOLEAN
+//	Int64         -> optional INT64
+//	Float64       -> optional DOUBLE
+//	Utf8          -> optional BYTE_ARRAY, annotated as UTF8
+//	Binary        -> optional BYTE_ARRAY
+//	List<Float64> -> optional LIST of required DOUBLE
+//
+// See https://parquet.apache.org/docs/file-format/.
+type ParquetWriter struct {
+	w      io.Writer
+	schema *Schema
+	// number of bytes written so far
+	offset    int64
+	rowGroups []rowGroup
+	numRows   int64
+	// set once the magic number has been written
+	started bool
+}
+
+type rowGroup struct {
+	columns   []columnChunk
+	totalSize int64
+	numRows   int64
+}
+
+type columnChunk struct {
+	offset    int64
+	size      int64
+	numValues int64
+}
+
+// NewParquetWriter creates a writer of record batches. If schema is nil,
+// the schema of the first batch is used.
+func NewParquetWriter(w io.Writer, schema *Schema) *ParquetWriter {
+	return &ParquetWriter{
+		w:      w,
+		schema: schema,
+	}
+}
+
+// Write a record batch to the file, as a row group. All the batches must have the same schema.
+func (w *ParquetWriter) Write(b *RecordBatch) error {
+	if w.schema == nil {
+		w.schema = b.Schema
+	}
+	if !sameSchema(w.schema, b.Schema) {
+		return errors.New("arrow: the schema of the batch differs from the schema of the file")
+	}
+
+	err := w.start()
+	if err != nil {
+		return err
+	}
+
+	rg := rowGroup{numRows: int64(b.Len)}
+	for i := range b.Columns {
+		cc, err := w.writeColumn(&b.Columns[i])
+		if err != nil {
+			return err
+		}
+
+		rg.columns = append(rg.columns, cc)
+		rg.totalSize += cc.size
+	}
+
+	w.rowGroups = append(w.rowGroups, rg)
+	w.numRows += int64(b.Len)
+	return nil
+}
+
+// Close writes the footer of the file. It doesn't close the underlying writer.
+func (w *ParquetWriter) Close() error {
+	if w.schema == nil {
+		w.schema = new(Schema)
+	}
+
+	err := w.start()
+	if err != nil {
+		return err
+	}
+
+	footer := w.fileMetadata()
+
+	var tail [8]byte
+	binary.LittleEndian.PutUint32(tail[:], uint32(len(footer)))
+	copy(tail[4:], parquetMagic)
+
+	err = w.write(footer)
+	if err != nil {
+		return err
+	}
+
+	return w.write(tail[:])
+}
+
+func (w *ParquetWriter) start() error {
+	if w.started {
+		return nil
+	}
+	w.started = true
+
+	return w.write([]byte(parquetMagic))
+}
+
+func (w *ParquetWriter) write(p []byte) error {
+	n, err := w.w.Write(p)
+	w.offset += int64(n)
+	return err
+}
+
+// writeColumn writes the column as a single data page.
+func (w *ParquetWriter) writeColumn(c *Column) (columnChunk, error) {
+	var page []byte
+	var numValues int
+
+	if vs, ok := c.Values.([][]float64); ok {
+		// a null list is defined up to level 0, an empty list up to level 1,
+		// and every value of a list up to level 2, where the first one starts a new row
+		var rep, def []int
+		for i, ok := range c.Valid {
+			switch {
+			case !ok:
+				rep, def = append(rep, 0), append(def, 0)
+			case len(vs[i]) == 0:
+				rep, def = append(rep, 0), append(def, 1)
+			}
+			for j := range vs[i] {
+				if j == 0 {
+					rep = append(rep, 0)
+				} else {
+					rep = append(rep, 1)
+				}
+				def = append(def, 2)
+			}
+		}
+
+		page = appendLevels(page, rep, 1)
+		page = appendLevels(page, def, 2)
+		for _, x := range vs {
+			page = appendFloat64s(page, x)
+		}
+		numValues = len(def)
+	} else {
+		def := make([]int, len(c.Valid))
+		for i, ok := range c.Valid {
+			if ok {
+				def[i] = 1
+			}
+		}
+
+		page = appendLevels(page, def, 1)
+		page = appendPlainValues(page, c)
+		numValues = len(def)
+	}
+
+	var h thriftWriter
+	h.i32(1, pageTypeData)
+	h.i32(2, int32(len(page)))
+	h.i32(3, int32(len(page)))
+	h.beginStruct(5)
+	h.i32(1, int32(numValues))
+	h.i32(2, encodingPlain)
+	h.i32(3, encodingRLE)
+	h.i32(4, encodingRLE)
+	h.endStruct()
+	h.stop()
+
+	cc := columnChunk{
+		offset:    w.offset,
+		size:      int64(len(h.buf) + len(page)),
+		numValues: int64(numValues),
+	}
+
+	err := w.write(h.buf)
+	if err != nil {
+		return cc, err
+	}
+
+	return cc, w.write(page)
+}
+
+// appendPlainValues appends the non-null values of the column using the plain encoding.
+func appendPlainValues(buf []byte, c *Column) []byte {
+	switch vs := c.Values.(type) {
+	case []bool:
+		var bs []bool
+		for i, x := range vs {
+			if c.Valid[i] {
+				bs = append(bs, x)
+			}
+		}
+		buf = append(buf, bitmap(bs)...)
+	case []int64:
+		var b [8]byte
+		for i, x := range vs {
+			if c.Valid[i] {
+				binary.LittleEndian.PutUint64(b[:], uint64(x))
+				buf = append(buf, b[:]...)
+			}
+		}
+	case []float64:
+		for i, x := range vs {
+			if c.Valid[i] {
+				buf = appendFloat64s(buf, []float64{x})
+			}
+		}
+	case []string:
+		var b [4]byte
+		for i, x := range vs {
+			if c.Valid[i] {
+				binary.LittleEndian.PutUint32(b[:], uint32(len(x)))
+				buf = append(append(buf, b[:]...), x...)
+			}
+		}
+	case [][]byte:
+		var b [4]byte
+		for i, x := range vs {
+			if c.Valid[i] {
+				binary.LittleEndian.PutUint32(b[:], uint32(len(x)))
+				buf = append(append(buf, b[:]...), x...)
+			}
+		}
+	}
+
+	return buf
+}
+
+func appendFloat64s(buf []byte, vs []float64) []byte {
+	var b [8]byte
+	for _, x := range vs {
+		binary.LittleEndian.PutUint64(b[:], math.Float64bits(x))
+		buf = append(buf, b[:]...)
+	}
+
+	return buf
+}
+
+// appendLevels appends repetition or definition levels using the RLE encoding,
+// prefixed by their length.
+func appendLevels(buf []byte, levels []int, bitWidth int) []byte {
+	start := len(buf)
+	buf = append(buf, 0, 0, 0, 0)
+
+	byteWidth := (bitWidth + 7) / 8
+	for i := 0; i < len(levels); {
+		j := i + 1
+		for j < len(levels) && levels[j] == levels[i] {
+			j++
+		}
+
+		buf = appendUvarint(buf, uint64(j-i)<<1)
+		for k := 0; k < byteWidth; k++ {
+			buf = append(buf, byte(levels[i]>>(8*k)))
+		}
+		i = j
+	}
+
+	binary.LittleEndian.PutUint32(buf[start:], uint32(len(buf)-start-4))
+	return buf
+}
+
+// fileMetadata encodes the footer of the file.
+func (w *ParquetWriter) fileMetadata() []byte {
+	var m thriftWriter
+
+	m.i32(1, 1)
+
+	// the schema is a tree flattened in depth-first order, whose root holds the columns
+	n := len(w.schema.Fields)
+	for _, f := range w.schema.Fields {
+		if f.Type == document.VectorValue {
+			n += 2
+		}
+	}
+	m.listBegin(2, thriftStruct, n+1)
+	m.beginElem()
+	m.binary(4, []byte("schema"))
+	m.i32(5, int32(len(w.schema.Fields)))
+	m.endStruct()
+	for _, f := range w.schema.Fields {
+		if f.Type == document.VectorValue {
+			m.beginElem()
+			m.i32(3, repetitionOptional)
+			m.binary(4, []byte(f.Name))
+			m.i32(5, 1)
+			m.i32(6, convertedList)
+			m.endStruct()
+			m.beginElem()
+			m.i32(3, repetitionRepeated)
+			m.binary(4, []byte("list"))
+			m.i32(5, 1)
+			m.endStruct()
+			m.beginElem()
+			m.i32(1, parquetDouble)
+			m.i32(3, repetitionRequired)
+			m.binary(4, []byte("element"))
+			m.endStruct()
+			continue
+		}
+
+		m.beginElem()
+		m.i32(1, parquetType(f.Type))
+		m.i32(3, repetitionOptional)
+		m.binary(4, []byte(f.Name))
+		if f.Type == document.TextValue {
+			m.i32(6, convertedUTF8)
+		}
+		m.endStruct()
+	}
+
+	m.i64(3, w.numRows)
+
+	m.listBegin(4, thriftStruct, len(w.rowGroups))
+	for _, rg := range w.rowGroups {
+		m.beginElem()
+		m.listBegin(1, thriftStruct, len(rg.columns))
+		for i, cc := range rg.columns {
+			f := w.schema.Fields[i]
+			path := []string{f.Name}
+			if f.Type == document.VectorValue {
+				path = append(path, "list", "element")
+			}
+
+			m.beginElem()
+			m.i64(2, cc.offset)
+			m.beginStruct(3)
+			m.i32(1, parquetType(f.Type))
+			m.listBegin(2, thriftI32, 2)
+			m.listI32(encodingPlain)
+			m.listI32(encodingRLE)
+			m.listBegin(3, thriftBinary, len(path))
+			for _, p := range path {
+				m.listBinary([]byte(p))
+			}
+			m.i32(4, codecUncompressed)
+			m.i64(5, cc.numValues)
+			m.i64(6, cc.size)
+			m.i64(7, cc.size)
+			m.i64(9, cc.offset)
+			m.endStruct()
+			m.endStruct()
+		}
+		m.i64(2, rg.totalSize)
+		m.i64(3, rg.numRows)
+		m.endStruct()
+	}
+
+	m.binary(6, []byte("genji"))
+	m.stop()
+
+	return m.buf
+}
+
+// parquetType returns the physical type used to store values of type t.
+func parquetType(t document.ValueType) int32 {
+	switch t {
+	case document.BoolValue:
+		return parquetBoolean
+	case document.IntegerValue:
+		return parquetInt64
+	case document.DoubleValue, document.VectorValue:
+		return parquetDouble
+	case document.TextValue, document.BlobValue:
+		return parquetByteArray
+	}
+
+	return parquetInt32
+}
+
+// Types of the Thrift compact protocol.
+const (
+	thriftI32    = 5
+	thriftI64    = 6
+	thriftBinary = 8
+	thriftList   = 9
+	thriftStruct = 12
+)
+
+// thriftWriter encodes structures using the Thrift compact protocol, used by the
+// metadata of Parquet files.
+// See https://github.com/apache/thrift/blob/master/doc/specs/thrift-compact-protocol.md.
+type thriftWriter struct {
+	buf []byte
+	// identifier of the last field of the current struct
+	last int16
+	// identifiers of the last fields of the enclosing structs
+	stack []int16
+}
+
+func (t *thriftWriter) field(id int16, typ byte) {
+	if d := id - t.last; d > 0 && d <= 15 {
+		t.buf = append(t.buf, byte(d)<<4|typ)
+	} else {
+		t.buf = append(t.buf, typ)
+		t.varint(int64(id))
+	}
+	t.last = id
+}
+
+// varint appends a zigzag encoded integer.
+func (t *thriftWriter) varint(v int64) {
+	t.buf = appendUvarint(t.buf, uint64((v<<1)^(v>>63)))
+}
+
+func (t *thriftWriter) i32(id int16, v int32) {
+	t.field(id, thriftI32)
+	t.varint(int64(v))
+}
+
+func (t *thriftWriter) i64(id int16, v int64) {
+	t.field(id, thriftI64)
+	t.varint(v)
+}
+
+func (t *thriftWriter) binary(id int16, b []byte) {
+	t.field(id, thriftBinary)
+	t.listBinary(b)
+}
+
+func (t *thriftWriter) listBegin(id int16, elemType byte, n int) {
+	t.field(id, thriftList)
+	if n < 15 {
+		t.buf = append(t.buf, byte(n)<<4|elemType)
+	} else {
+		t.buf = append(t.buf, 0xF0|elemType)
+		t.buf = appendUvarint(t.buf, uint64(n))
+	}
+}
+
+func (t *thriftWriter) listI32(v int32) {
+	t.varint(int64(v))
+}
+
+func (t *thriftWriter) listBinary(b []byte) {
+	t.buf = appendUvarint(t.buf, uint64(len(b)))
+	t.buf = append(t.buf, b...)
+}
+
+// beginStruct starts a struct stored in the given field.
+func (t *thriftWriter) beginStruct(id int16) {
+	t.field(id, thriftStruct)
+	t.beginElem()
+}
+
+// beginElem starts a struct stored in a list.
+func (t *thriftWriter) beginElem() {
+	t.stack = append(t.stack, t.last)
+	t.last = 0
+}
+
+func (t *thriftWriter) endStruct() {
+	t.stop()
+	t.last = t.stack[len(t.stack)-1]
+	t.stack = t.stack[:len(t.stack)-1]
+}
+
+// stop ends the fields of a struct.
+func (t *thriftWriter) stop() {
+	t.buf = append(t.buf, 0)
+}
+
+func appendUvarint(buf []byte, v uint64) []byte {
+	var b [binary.MaxVarintLen64]byte
+	n := binary.PutUvarint(b[:], v)
+	return append(buf, b[:n]...)
+}
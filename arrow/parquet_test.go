@@ -0,0 +1,249 @@
+package arrow_test
+
+import (
+	"bytes"
+	"encoding/binary"
+	"math"
+	"testing"
+
+	"github.com/genjidb/genji"
+	"github.com/genjidb/genji/arrow"
+	"github.com/stretchr/testify/require"
+)
+
+// thriftReader decodes structures encoded with the Thrift compact protocol.
+// Structs are decoded as maps of field identifiers to values, integers as int64,
+// binaries as []byte and lists as []interface{}.
+type thriftReader struct {
+	t   *testing.T
+	buf []byte
+}
+
+func (r *thriftReader) uvarint() uint64 {
+	v, n := binary.Uvarint(r.buf)
+	require.Greater(r.t, n, 0)
+	r.buf = r.buf[n:]
+	return v
+}
+
+func (r *thriftReader) varint() int64 {
+	v := r.uvarint()
+	return int64(v>>1) ^ -int64(v&1)
+}
+
+func (r *thriftReader) value(typ byte) interface{} {
+	switch typ {
+	case 1, 2:
+		return typ == 1
+	case 5, 6:
+		return r.varint()
+	case 8:
+		n := r.uvarint()
+		b := r.buf[:n]
+		r.buf = r.buf[n:]
+		return b
+	case 9:
+		h := r.buf[0]
+		r.buf = r.buf[1:]
+		n := int(h >> 4)
+		if n == 15 {
+			n = int(r.uvarint())
+		}
+		l := make([]interface{}, n)
+		for i := range l {
+			l[i] = r.value(h & 0x0F)
+		}
+		return l
+	case 12:
+		return r.structure()
+	}
+
+	r.t.Fatalf("unexpected type %d", typ)
+	return nil
+}
+
+func (r *thriftReader) structure() map[int16]interface{} {
+	m := make(map[int16]interface{})
+	var last int16
+	for {
+		h := r.buf[0]
+		r.buf = r.buf[1:]
+		if h == 0 {
+			return m
+		}
+
+		id := last + int16(h>>4)
+		if h>>4 == 0 {
+			id = int16(r.varint())
+		}
+		m[id] = r.value(h & 0x0F)
+		last = id
+	}
+}
+
+// readLevels decodes levels made of RLE runs, prefixed by their length.
+func readLevels(t *testing.T, page []byte, byteWidth int) ([]int, []byte) {
+	t.Helper()
+
+	n := binary.LittleEndian.Uint32(page)
+	r := thriftReader{t: t, buf: page[4 : 4+n]}
+
+	var levels []int
+	for len(r.buf) > 0 {
+		h := r.uvarint()
+		require.Zero(t, h&1)
+
+		var v int
+		for k := 0; k < byteWidth; k++ {
+			v |= int(r.buf[k]) << (8 * k)
+		}
+		r.buf = r.buf[byteWidth:]
+
+		for i := uint64(0); i < h>>1; i++ {
+			levels = append(levels, v)
+		}
+	}
+
+	return levels, page[4+n:]
+}
+
+func TestWriteParquet(t *testing.T) {
+	db, err := genji.Open(":memory:")
+	require.NoError(t, err)
+	defer db.Close()
+
+	err = db.Exec(`
+		CREATE TABLE foo(a INTEGER PRIMARY KEY, b TEXT, c DOUBLE, d BOOL, v VECTOR);
+		INSERT INTO foo (a, b, c, d, v) VALUES (1, 'a', 1.5, true, [1, 2]), (2, null, 2, false, null), (3, 'c', null, null, [3]);
+	`)
+	require.NoError(t, err)
+
+	res, err := db.Query(`SELECT a, b, c, d, v, null AS n FROM foo`)
+	require.NoError(t, err)
+	defer res.Close()
+
+	var buf bytes.Buffer
+	err = arrow.WriteParquet(&buf, res, &arrow.Options{BatchSize: 2})
+	require.NoError(t, err)
+
+	data := buf.Bytes()
+	require.Equal(t, "PAR1", string(data[:4]))
+	require.Equal(t, "PAR1", string(data[len(data)-4:]))
+	n := binary.LittleEndian.Uint32(data[len(data)-8:])
+	r := thriftReader{t: t, buf: data[len(data)-8-int(n) : len(data)-8]}
+	meta := r.structure()
+	require.Empty(t, r.buf)
+
+	require.EqualValues(t, 3, meta[3])
+
+	// name, physical type, repetition and converted type of the schema elements
+	type element struct {
+		name                string
+		typ, rep, converted interface{}
+	}
+	var elements []element
+	for _, e := range meta[2].([]interface{}) {
+		m := e.(map[int16]interface{})
+		elements = append(elements, element{string(m[4].([]byte)), m[1], m[3], m[6]})
+	}
+	require.Equal(t, []element{
+		{"schema", nil, nil, nil},
+		{"a", int64(2), int64(1), nil},
+		{"b", int64(6), int64(1), int64(0)},
+		{"c", int64(5), int64(1), nil},
+		{"d", int64(0), int64(1), nil},
+		{"v", nil, int64(1), int64(3)},
+		{"list", nil, int64(2), nil},
+		{"element", int64(5), int64(0), nil},
+		{"n", int64(1), int64(1), nil},
+	}, elements)
+
+	// decode the pages of every column of every row group
+	rowGroups := meta[4].([]interface{})
+	require.Len(t, rowGroups, 2)
+
+	var a []int64
+	var b []string
+	var c []float64
+	var d []bool
+	var v [][]float64
+	var nulls int
+	for _, rg := range rowGroups {
+		cols := rg.(map[int16]interface{})[1].([]interface{})
+		require.Len(t, cols, 6)
+
+		for i, col := range cols {
+			cm := col.(map[int16]interface{})[3].(map[int16]interface{})
+			off := cm[9].(int64)
+
+			r := thriftReader{t: t, buf: data[off:]}
+			h := r.structure()
+			page := r.buf[:h[3].(int64)]
+			require.EqualValues(t, cm[5], h[5].(map[int16]interface{})[1])
+
+			if i == 4 {
+				rep, page := readLevels(t, page, 1)
+				def, page := readLevels(t, page, 1)
+				for j := range def {
+					switch {
+					case def[j] == 0:
+						v = append(v, nil)
+					case rep[j] == 0:
+						v = append(v, []float64{math.Float64frombits(binary.LittleEndian.Uint64(page))})
+						page = page[8:]
+					default:
+						v[len(v)-1] = append(v[len(v)-1], math.Float64frombits(binary.LittleEndian.Uint64(page)))
+						page = page[8:]
+					}
+				}
+				require.Empty(t, page)
+				continue
+			}
+
+			def, page := readLevels(t, page, 1)
+			var bits int
+			for _, l := range def {
+				if l == 0 {
+					switch i {
+					case 0:
+						a = append(a, -1)
+					case 1:
+						b = append(b, "<null>")
+					case 2:
+						c = append(c, -1)
+					case 5:
+						nulls++
+					}
+					continue
+				}
+
+				switch i {
+				case 0:
+					a = append(a, int64(binary.LittleEndian.Uint64(page)))
+					page = page[8:]
+				case 1:
+					l := binary.LittleEndian.Uint32(page)
+					b = append(b, string(page[4:4+l]))
+					page = page[4+l:]
+				case 2:
+					c = append(c, math.Float64frombits(binary.LittleEndian.Uint64(page)))
+					page = page[8:]
+				case 3:
+					d = append(d, page[bits/8]&(1<<(bits%8)) != 0)
+					bits++
+				}
+			}
+			if i == 3 {
+				page = page[(bits+7)/8:]
+			}
+			require.Empty(t, page)
+		}
+	}
+
+	require.Equal(t, []int64{1, 2, 3}, a)
+	require.Equal(t, []string{"a", "<null>", "c"}, b)
+	require.Equal(t, []float64{1.5, 2, -1}, c)
+	require.Equal(t, []bool{true, false}, d)
+	require.Equal(t, [][]float64{{1, 2}, nil, {3}}, v)
+	require.Equal(t, 3, nulls)
+}
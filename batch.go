@@ -0,0 +1,238 @@
+package genji
+
+import (
+	"github.com/genjidb/genji/document"
+	"github.com/genjidb/genji/internal/catalog"
+	"github.com/genjidb/genji/internal/database"
+	"github.com/genjidb/genji/internal/query/statement"
+	"github.com/genjidb/genji/internal/stringutil"
+)
+
+// Batch is a list of queries run together in a single transaction.
+// It is not safe for concurrent use.
+type Batch struct {
+	db    *DB
+	items []batchItem
+}
+
+type batchItem struct {
+	q    string
+	args []interface{}
+}
+
+// Batch creates an empty batch of queries.
+// Running many small writes as a batch is much faster than running them one by one:
+// queries are run in a single read-write transaction, committed once,
+// and queries that appear multiple times are only parsed and planned once.
+func (db *DB) Batch() *Batch {
+	return &Batch{db: db}
+}
+
+// Queue adds a query and its parameters to the batch.
+func (b *Batch) Queue(q string, args ...interface{}) {
+	b.items = append(b.items, batchItem{q: q, args: args})
+}
+
+// Len returns the number of queries of the batch.
+func (b *Batch) Len() int {
+	return len(b.items)
+}
+
+// Run executes the queries of the batch in the order they were queued, within the same transaction,
+// and returns their results in the same order. The documents of the results are kept in memory,
+// and the results don't need to be closed.
+// If any query fails, the transaction is rolled back and the returned error
+// tells which query failed.
+// Queries are planned when they are first run. If a query is queued multiple times,
+// later runs reuse that plan, unless the batch modified the structure of the database in between.
+func (b *Batch) Run() ([]*Result, error) {
+	tx, err := b.db.Begin(true)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	stmts := make(map[string]*batchStatement)
+	results := make([]*Result, len(b.items))
+	for i, it := range b.items {
+		results[i], err = b.run(tx, stmts, it)
+		if err != nil {
+			return nil, stringutil.Errorf("batch query %d: %w", i, err)
+		}
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return nil, err
+	}
+
+	return results, nil
+}
+
+// batchStatement is a query prepared by a batch, along with the number
+// of modifications of the catalog when it was prepared.
+type batchStatement struct {
+	stmt          *Statement
+	modifications uint64
+}
+
+func (b *Batch) run(tx *Tx, stmts map[string]*batchStatement, it batchItem) (*Result, error) {
+	var n uint64
+	cat, _ := b.db.db.Catalog.(*catalog.Catalog)
+	if cat != nil {
+		n, _ = cat.Modifications()
+	}
+
+	// plans prepared before the structure of the database changed may
+	// refer to tables or indexes that don't exist anymore
+	bs, ok := stmts[it.q]
+	if !ok || bs.modifications != n {
+		stmt, err := tx.Prepare(it.q)
+		if err != nil {
+			return nil, err
+		}
+		bs = &batchStatement{stmt: stmt, modifications: n}
+		stmts[it.q] = bs
+	}
+
+	res, err := bs.stmt.Query(it.args...)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Close()
+
+	docs, err := copyDocuments(res)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Result{
+		result: &statement.Result{Iterator: documents(docs)},
+		fields: res.Fields(),
+		types:  res.FieldTypes(),
+	}, nil
+}
+
+// copyDocuments returns a copy of all the documents of the iterator.
+func copyDocuments(it document.Iterator) ([]document.Document, error) {
+	var docs []document.Document
+	err := it.Iterate(func(d document.Document) error {
+		fb := document.NewFieldBuffer()
+		err := fb.Copy(d)
+		if err != nil {
+			return err
+		}
+
+		docs = append(docs, fb)
+		return nil
+	})
+
+	return docs, err
+}
+
+// defaultInsertChunkSize is the number of documents inserted by each transaction
+// of InsertBatch, by default.
+const defaultInsertChunkSize = 10000
+
+// InsertBatchOptions configures how InsertBatch inserts documents.
+type InsertBatchOptions struct {
+	// ChunkSize is the number of documents inserted and committed by each transaction.
+	// Defaults to 10000.
+	ChunkSize int
+}
+
+// InsertBatch inserts the documents into the given table and returns the number of inserted documents.
+// Documents are inserted in chunks, each of them within its own transaction, as described by Tx.InsertBatch.
+// If a document can't be inserted, the chunk containing it is rolled back, the previous chunks are kept,
+// and the returned error tells which document failed.
+func (db *DB) InsertBatch(tableName string, docs []document.Document, opts *InsertBatchOptions) (n int, err error) {
+	size := defaultInsertChunkSize
+	if opts != nil && opts.ChunkSize > 0 {
+		size = opts.ChunkSize
+	}
+
+	for n < len(docs) {
+		chunk := docs[n:]
+		if len(chunk) > size {
+			chunk = chunk[:size]
+		}
+
+		err = db.Update(func(tx *Tx) error {
+			return tx.insertBatch(tableName, chunk, n)
+		})
+		if err != nil {
+			return n, err
+		}
+
+		n += len(chunk)
+	}
+
+	return n, nil
+}
+
+// InsertBatch inserts the documents into the given table within the transaction.
+// It is much faster than running an INSERT statement for each document: documents are
+// inserted directly, without going through the query planner, and non-unique indexes are
+// updated in a single pass once all the documents are stored.
+// Constraints, row policies and privileges are enforced as with INSERT. If the table has
+// INSERT triggers, the documents are inserted one by one using INSERT so that the triggers fire.
+// If a document can't be inserted, the returned error tells which one.
+func (tx *Tx) InsertBatch(tableName string, docs []document.Document) error {
+	return tx.insertBatch(tableName, docs, 0)
+}
+
+// insertBatch inserts the documents into the table. first is the position of the first document
+// in the whole batch, used to report errors.
+func (tx *Tx) insertBatch(tableName string, docs []document.Document, first int) error {
+	catalog := tx.db.db.Catalog
+
+	if catalog.GetVirtualTable(tableName) != nil {
+		return stringutil.Errorf("cannot insert into virtual table %q", tableName)
+	}
+
+	if tx.session != nil && tx.session.User() != "" {
+		err := database.CheckPrivilege(tx.tx, catalog, tx.session.User(), tableName, database.InsertPrivilege)
+		if err != nil {
+			return err
+		}
+	}
+
+	for _, tr := range catalog.GetTableTriggers(tx.tx, tableName) {
+		if tr.Event == database.TriggerInsert {
+			return tx.insertEach(tableName, docs, first)
+		}
+	}
+
+	table, err := catalog.GetTable(tx.tx, tableName)
+	if err != nil {
+		return err
+	}
+
+	inserted, err := table.WithSessionSettings(tx.session).InsertBatch(docs)
+	if err != nil {
+		return stringutil.Errorf("document %d: %w", first+len(inserted)+1, err)
+	}
+
+	if tx.session != nil {
+		tx.session.RecordChanges(int64(len(docs)))
+	}
+
+	return nil
+}
+
+// insertEach inserts the documents one by one using an INSERT statement.
+func (tx *Tx) insertEach(tableName string, docs []document.Document, first int) error {
+	stmt, err := tx.Prepare("INSERT INTO " + stringutil.NormalizeIdentifier(tableName, '`') + " VALUES ?")
+	if err != nil {
+		return err
+	}
+
+	for i, d := range docs {
+		err = stmt.Exec(d)
+		if err != nil {
+			return stringutil.Errorf("document %d: %w", first+i+1, err)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,203 @@
+package genji_test
+
+import (
+	"strconv"
+	"testing"
+
+	"github.com/genjidb/genji"
+	"github.com/genjidb/genji/document"
+	"github.com/genjidb/genji/internal/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBatch(t *testing.T) {
+	setup := func(t *testing.T) *genji.DB {
+		t.Helper()
+
+		db, err := genji.Open(":memory:")
+		require.NoError(t, err)
+
+		err = db.Exec(`CREATE TABLE foo(a INTEGER PRIMARY KEY, b TEXT)`)
+		require.NoError(t, err)
+
+		return db
+	}
+
+	t.Run("Results", func(t *testing.T) {
+		db := setup(t)
+		defer db.Close()
+
+		b := db.Batch()
+		for i := 0; i < 100; i++ {
+			b.Queue(`INSERT INTO foo (a, b) VALUES (?, ?)`, i, "x")
+		}
+		b.Queue(`UPDATE foo SET b = 'y' WHERE a >= ?`, 98)
+		b.Queue(`SELECT a, b FROM foo WHERE a > ?`, 97)
+		b.Queue(`DELETE FROM foo WHERE a = 99`)
+		require.Equal(t, 103, b.Len())
+
+		results, err := b.Run()
+		require.NoError(t, err)
+		require.Len(t, results, 103)
+
+		testutil.RequireStreamEq(t, `{"a": 0, "b": "x"}`, results[0])
+		testutil.RequireStreamEq(t, `{"a": 98, "b": "y"} {"a": 99, "b": "y"}`, results[101])
+		require.Equal(t, []string{"a", "b"}, results[101].Fields())
+
+		d, err := db.QueryDocument(`SELECT COUNT(*) FROM foo`)
+		require.NoError(t, err)
+		testutil.RequireDocJSONEq(t, d, `{"COUNT(*)": 99}`)
+	})
+
+	t.Run("Planned once", func(t *testing.T) {
+		db := setup(t)
+		defer db.Close()
+
+		var plans int
+		db.AddPlanHook(func(p *genji.Plan) error {
+			plans++
+			return nil
+		})
+
+		b := db.Batch()
+		for i := 0; i < 10; i++ {
+			b.Queue(`INSERT INTO foo (a) VALUES (?)`, i)
+		}
+		b.Queue(`SELECT * FROM foo`)
+
+		_, err := b.Run()
+		require.NoError(t, err)
+		require.Equal(t, 2, plans)
+	})
+
+	t.Run("Replanned after DDL", func(t *testing.T) {
+		db := setup(t)
+		defer db.Close()
+
+		err := db.Exec(`
+			CREATE INDEX idx ON foo(b);
+			INSERT INTO foo (a, b) VALUES (1, 'x'), (2, 'y');
+		`)
+		require.NoError(t, err)
+
+		var plans int
+		db.AddPlanHook(func(p *genji.Plan) error {
+			plans++
+			return nil
+		})
+
+		b := db.Batch()
+		b.Queue(`SELECT a FROM foo WHERE b = ?`, "x")
+		b.Queue(`DROP INDEX idx`)
+		b.Queue(`SELECT a FROM foo WHERE b = ?`, "y")
+		b.Queue(`SELECT a FROM foo WHERE b = ?`, "x")
+
+		results, err := b.Run()
+		require.NoError(t, err)
+		testutil.RequireStreamEq(t, `{"a": 1}`, results[0])
+		testutil.RequireStreamEq(t, `{"a": 2}`, results[2])
+		testutil.RequireStreamEq(t, `{"a": 1}`, results[3])
+		require.Equal(t, 2, plans)
+	})
+
+	t.Run("Rollback", func(t *testing.T) {
+		db := setup(t)
+		defer db.Close()
+
+		b := db.Batch()
+		b.Queue(`INSERT INTO foo (a) VALUES (1)`)
+		b.Queue(`CREATE TABLE bar`)
+		b.Queue(`INSERT INTO foo (a) VALUES (1)`)
+
+		_, err := b.Run()
+		require.EqualError(t, err, `batch query 2: duplicate document`)
+
+		d, err := db.QueryDocument(`SELECT COUNT(*) FROM foo`)
+		require.NoError(t, err)
+		testutil.RequireDocJSONEq(t, d, `{"COUNT(*)": 0}`)
+
+		err = db.Exec(`CREATE TABLE bar`)
+		require.NoError(t, err)
+	})
+}
+
+func TestInsertBatch(t *testing.T) {
+	docs := func(from, to int) []document.Document {
+		var list []document.Document
+		for i := from; i < to; i++ {
+			list = append(list, document.NewFieldBuffer().
+				Add("a", document.NewIntegerValue(int64(i))).
+				Add("b", document.NewTextValue(strconv.Itoa(i%3))))
+		}
+		return list
+	}
+
+	setup := func(t *testing.T) *genji.DB {
+		t.Helper()
+
+		db, err := genji.Open(":memory:")
+		require.NoError(t, err)
+
+		err = db.Exec(`
+			CREATE TABLE foo(a INTEGER PRIMARY KEY, b TEXT NOT NULL);
+			CREATE INDEX foo_b ON foo(b);
+		`)
+		require.NoError(t, err)
+
+		return db
+	}
+
+	t.Run("Chunks", func(t *testing.T) {
+		db := setup(t)
+		defer db.Close()
+
+		n, err := db.InsertBatch("foo", docs(0, 100), &genji.InsertBatchOptions{ChunkSize: 30})
+		require.NoError(t, err)
+		require.Equal(t, 100, n)
+
+		d, err := db.QueryDocument(`SELECT COUNT(*) FROM foo WHERE b = '1'`)
+		require.NoError(t, err)
+		testutil.RequireDocJSONEq(t, d, `{"COUNT(*)": 33}`)
+	})
+
+	t.Run("Errors", func(t *testing.T) {
+		db := setup(t)
+		defer db.Close()
+
+		list := append(docs(0, 50), docs(10, 11)...)
+		n, err := db.InsertBatch("foo", list, &genji.InsertBatchOptions{ChunkSize: 20})
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "document 51")
+		require.Equal(t, 40, n)
+
+		// previous chunks are kept
+		d, err := db.QueryDocument(`SELECT COUNT(*) FROM foo`)
+		require.NoError(t, err)
+		testutil.RequireDocJSONEq(t, d, `{"COUNT(*)": 40}`)
+
+		err = db.Update(func(tx *genji.Tx) error {
+			return tx.InsertBatch("foo", []document.Document{document.NewFieldBuffer().Add("a", document.NewIntegerValue(100))})
+		})
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "document 1")
+	})
+
+	t.Run("Triggers", func(t *testing.T) {
+		db := setup(t)
+		defer db.Close()
+
+		err := db.Exec(`
+			CREATE TABLE log(a INTEGER);
+			CREATE TRIGGER foo_log AFTER INSERT ON foo BEGIN INSERT INTO log (a) VALUES (NEW.a); END;
+		`)
+		require.NoError(t, err)
+
+		n, err := db.InsertBatch("foo", docs(0, 10), nil)
+		require.NoError(t, err)
+		require.Equal(t, 10, n)
+
+		d, err := db.QueryDocument(`SELECT COUNT(*), ARRAY_AGG(a) FROM log`)
+		require.NoError(t, err)
+		testutil.RequireDocJSONEq(t, d, `{"COUNT(*)": 10, "ARRAY_AGG(a)": [0, 1, 2, 3, 4, 5, 6, 7, 8, 9]}`)
+	})
+}
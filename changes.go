@@ -0,0 +1,42 @@
+package genji
+
+import (
+	"github.com/genjidb/genji/document"
+	"github.com/genjidb/genji/internal/database"
+)
+
+// ChangeOp is the kind of write made to a document.
+type ChangeOp uint8
+
+const (
+	// ChangeInsert is reported for documents inserted in a table.
+	ChangeInsert ChangeOp = ChangeOp(database.ChangeInsert)
+	// ChangeUpdate is reported for documents updated or replaced,
+	// including by INSERT ... ON CONFLICT statements.
+	ChangeUpdate ChangeOp = ChangeOp(database.ChangeUpdate)
+	// ChangeDelete is reported for documents deleted from a table.
+	ChangeDelete ChangeOp = ChangeOp(database.ChangeDelete)
+)
+
+func (op ChangeOp) String() string {
+	return database.ChangeOp(op).String()
+}
+
+// Subscribe registers fn to be called with every document of the table inserted, updated
+// or deleted by a transaction, once the transaction is committed. The changes of a
+// transaction are delivered in the order they were made, and transactions in the order
+// they were committed. Changes of rolled back transactions are never delivered.
+// old is nil for inserted documents and new is nil for deleted documents. Both are copies
+// that can be kept after fn returns, and implement the document.Keyer interface.
+// Changes made by ALTER TABLE statements are not reported.
+//
+// fn is called once the transaction released its lock, and can run queries.
+// It is usually called by the goroutine committing the transaction, but may be called
+// by the goroutine of another transaction committed concurrently.
+// Subscriptions are not persisted and must be registered every time the database is opened.
+// Subscribe returns a function canceling the subscription.
+func (db *DB) Subscribe(tableName string, fn func(op ChangeOp, old, new document.Document)) (cancel func()) {
+	return db.db.Changes.Subscribe(tableName, func(c *database.Change) {
+		fn(ChangeOp(c.Op), c.Old, c.New)
+	})
+}
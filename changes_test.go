@@ -0,0 +1,171 @@
+package genji_test
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/genjidb/genji"
+	"github.com/genjidb/genji/document"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSubscribe(t *testing.T) {
+	db, err := genji.Open(":memory:")
+	require.NoError(t, err)
+	defer db.Close()
+
+	err = db.Exec(`
+		CREATE TABLE foo (a INT PRIMARY KEY, b TEXT);
+		CREATE TABLE bar (a INT);
+	`)
+	require.NoError(t, err)
+
+	var changes []string
+	format := func(d document.Document) string {
+		if d == nil {
+			return "nil"
+		}
+
+		data, err := document.MarshalJSON(d)
+		require.NoError(t, err)
+
+		k, err := d.(document.Keyer).Key()
+		require.NoError(t, err)
+		return fmt.Sprintf("%s:%s", k, data)
+	}
+	cancel := db.Subscribe("foo", func(op genji.ChangeOp, old, new document.Document) {
+		changes = append(changes, fmt.Sprintf("%s %s %s", op, format(old), format(new)))
+	})
+
+	err = db.Exec(`
+		INSERT INTO foo (a, b) VALUES (1, 'x'), (2, 'y');
+		INSERT INTO bar (a) VALUES (1);
+		UPDATE foo SET b = 'z' WHERE a = 2;
+		DELETE FROM foo WHERE a = 1;
+		INSERT INTO foo (a, b) VALUES (2, 'w') ON CONFLICT DO REPLACE;
+	`)
+	require.NoError(t, err)
+	require.Equal(t, []string{
+		`insert nil 1:{"a": 1, "b": "x"}`,
+		`insert nil 2:{"a": 2, "b": "y"}`,
+		`update 2:{"a": 2, "b": "y"} 2:{"a": 2, "b": "z"}`,
+		`delete 1:{"a": 1, "b": "x"} nil`,
+		`update 2:{"a": 2, "b": "z"} 2:{"a": 2, "b": "w"}`,
+	}, changes)
+
+	// changes are delivered on commit
+	changes = nil
+	tx, err := db.Begin(true)
+	require.NoError(t, err)
+	err = tx.Exec("INSERT INTO foo (a, b) VALUES (3, 'x')")
+	require.NoError(t, err)
+	require.Empty(t, changes)
+	err = tx.Commit()
+	require.NoError(t, err)
+	require.Equal(t, []string{`insert nil 3:{"a": 3, "b": "x"}`}, changes)
+
+	// and discarded on rollback
+	changes = nil
+	tx, err = db.Begin(true)
+	require.NoError(t, err)
+	err = tx.Exec("DELETE FROM foo")
+	require.NoError(t, err)
+	err = tx.Rollback()
+	require.NoError(t, err)
+	require.Empty(t, changes)
+
+	// failed statements don't report their changes
+	err = db.Exec("INSERT INTO foo (a, b) VALUES (4, 'x'), (3, 'y')")
+	require.Error(t, err)
+	require.Empty(t, changes)
+
+	// subscribers can run queries
+	var count int
+	cancelBar := db.Subscribe("bar", func(op genji.ChangeOp, old, new document.Document) {
+		d, err := db.QueryDocument("SELECT COUNT(*) FROM bar")
+		require.NoError(t, err)
+		require.NoError(t, document.Scan(d, &count))
+	})
+	defer cancelBar()
+
+	err = db.Exec("INSERT INTO bar (a) VALUES (2)")
+	require.NoError(t, err)
+	require.Equal(t, 2, count)
+
+	cancel()
+	err = db.Exec("DELETE FROM foo")
+	require.NoError(t, err)
+	require.Empty(t, changes)
+}
+
+func TestChangeLog(t *testing.T) {
+	db, err := genji.Open(":memory:")
+	require.NoError(t, err)
+	defer db.Close()
+
+	err = db.Exec(`
+		CREATE TABLE foo (a INT PRIMARY KEY, b TEXT);
+		INSERT INTO foo (a, b) VALUES (1, 'x');
+		PRAGMA change_log = 'on';
+	`)
+	require.NoError(t, err)
+
+	changesAfter := func(lsn int64) []string {
+		res, err := db.Query("SELECT lsn, table_name, op, pk, old, new FROM __genji_changes WHERE lsn > ?", lsn)
+		require.NoError(t, err)
+		defer res.Close()
+
+		var changes []string
+		err = res.Iterate(func(d document.Document) error {
+			data, err := document.MarshalJSON(d)
+			changes = append(changes, string(data))
+			return err
+		})
+		require.NoError(t, err)
+		return changes
+	}
+
+	// changes made before the change log was enabled are not logged
+	require.Empty(t, changesAfter(0))
+
+	err = db.Exec(`
+		INSERT INTO foo (a, b) VALUES (2, 'y');
+		UPDATE foo SET b = 'z' WHERE a = 1;
+	`)
+	require.NoError(t, err)
+	err = db.Exec(`DELETE FROM foo WHERE a = 2`)
+	require.NoError(t, err)
+
+	require.Equal(t, []string{
+		`{"lsn": 1, "table_name": "foo", "op": "insert", "pk": 2, "old": null, "new": {"a": 2, "b": "y"}}`,
+		`{"lsn": 2, "table_name": "foo", "op": "update", "pk": 1, "old": {"a": 1, "b": "x"}, "new": {"a": 1, "b": "z"}}`,
+		`{"lsn": 3, "table_name": "foo", "op": "delete", "pk": 2, "old": {"a": 2, "b": "y"}, "new": null}`,
+	}, changesAfter(0))
+	require.Len(t, changesAfter(2), 1)
+
+	// changes of rolled back transactions are not logged
+	tx, err := db.Begin(true)
+	require.NoError(t, err)
+	err = tx.Exec(`INSERT INTO foo (a, b) VALUES (3, 'x')`)
+	require.NoError(t, err)
+	err = tx.Rollback()
+	require.NoError(t, err)
+	require.Empty(t, changesAfter(3))
+
+	// the log can be pruned without resetting the sequence numbers
+	err = db.Exec(`
+		DELETE FROM __genji_changes WHERE lsn <= 3;
+		INSERT INTO foo (a, b) VALUES (3, 'x');
+	`)
+	require.NoError(t, err)
+	changes := changesAfter(0)
+	require.Len(t, changes, 1)
+	require.Contains(t, changes[0], `"lsn": 4`)
+
+	err = db.Exec(`
+		PRAGMA change_log = 'off';
+		INSERT INTO foo (a, b) VALUES (4, 'x');
+	`)
+	require.NoError(t, err)
+	require.Len(t, changesAfter(0), 1)
+}
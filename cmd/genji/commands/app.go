@@ -35,6 +35,8 @@ func NewApp() *cli.App {
 		NewVersionCommand(),
 		NewDumpCommand(),
 		NewRestoreCommand(),
+		NewGenerateCommand(),
+		NewBackupCommand(),
 	}
 
 	// Root command
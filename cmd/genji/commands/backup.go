@@ -0,0 +1,142 @@
+package commands
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/genjidb/genji/cmd/genji/dbutil"
+	"github.com/urfave/cli/v2"
+)
+
+// NewBackupCommand returns a cli.Command for "genji backup".
+func NewBackupCommand() *cli.Command {
+	cmd := cli.Command{
+		Name:      "backup",
+		Usage:     "Backup a database to a directory or an S3-compatible object storage.",
+		UsageText: `genji backup [options] dbpath`,
+		Description: `The backup command dumps a database and stores it in a destination,
+which can be a local directory or an S3 bucket:
+
+$ genji backup --to s3://bucket/prefix my.db
+s3://bucket/prefix/my-20210101T000000Z.sql
+
+Credentials and region are read from the AWS_ACCESS_KEY_ID, AWS_SECRET_ACCESS_KEY,
+AWS_SESSION_TOKEN and AWS_REGION environment variables.
+S3-compatible services, such as MinIO, are used by setting the endpoint:
+
+$ genji backup --to s3://bucket --endpoint http://localhost:9000 my.db
+
+Tags can be added to the uploaded backups, e.g. to let lifecycle rules expire them:
+
+$ genji backup --to s3://bucket --tag retention=30d my.db
+
+The backup can then be restored using genji restore:
+
+$ genji restore s3://bucket/prefix/my-20210101T000000Z.sql new.db`,
+		Flags: append([]cli.Flag{
+			&cli.StringFlag{
+				Name:  "to",
+				Usage: "directory or s3://bucket/prefix location to store the backup in.",
+				Value: ".",
+			},
+			&cli.StringFlag{
+				Name:  "name",
+				Usage: "name of the backup. Defaults to the name of the database followed by the current time.",
+			},
+			&cli.StringSliceFlag{
+				Name:  "tag",
+				Usage: "key=value tag added to the backup, S3 only.",
+			},
+			&cli.IntFlag{
+				Name:  "part-size",
+				Usage: "size in bytes of the parts of multipart uploads, S3 only.",
+				Value: dbutil.DefaultS3PartSize,
+			},
+			&cli.StringFlag{
+				Name:    "engine",
+				Aliases: []string{"e"},
+				Usage:   "name of the engine to use, options are 'bolt' or 'badger'",
+				Value:   "bolt",
+			},
+			&cli.StringFlag{
+				Name:    "encryption-key",
+				Aliases: []string{"k"},
+				Usage:   "encryption key, badger only",
+			},
+		}, s3Flags()...),
+	}
+
+	cmd.Action = func(c *cli.Context) error {
+		engine := c.String("engine")
+		dbPath := c.Args().First()
+		if dbPath == "" {
+			return errors.New(cmd.UsageText)
+		}
+
+		k := c.String("encryption-key")
+		if k != "" && engine != "badger" {
+			return cli.Exit("encryption key is only supported by the badger engine", 2)
+		}
+
+		opts := s3Options(c)
+		opts.PartSize = c.Int("part-size")
+		opts.Tags = make(map[string]string)
+		for _, tag := range c.StringSlice("tag") {
+			i := strings.IndexByte(tag, '=')
+			if i <= 0 {
+				return cli.Exit(fmt.Sprintf("invalid tag %q, expected key=value", tag), 2)
+			}
+			opts.Tags[tag[:i]] = tag[i+1:]
+		}
+
+		to := c.String("to")
+		dst, err := dbutil.ParseDestination(to, opts)
+		if err != nil {
+			return err
+		}
+
+		name := c.String("name")
+		if name == "" {
+			name = dbutil.BackupName(dbPath, time.Now())
+		}
+
+		db, err := dbutil.OpenDB(c.Context, dbPath, engine, dbutil.DBOptions{EncryptionKey: k})
+		if err != nil {
+			return err
+		}
+		defer db.Close()
+
+		err = dbutil.Backup(c.Context, db, dst, name)
+		if err != nil {
+			return err
+		}
+
+		fmt.Println(strings.TrimSuffix(to, "/") + "/" + name)
+		return nil
+	}
+
+	return &cmd
+}
+
+// s3Flags returns the flags configuring the access to S3.
+func s3Flags() []cli.Flag {
+	return []cli.Flag{
+		&cli.StringFlag{
+			Name:  "endpoint",
+			Usage: "endpoint of an S3-compatible service, e.g. http://localhost:9000. Defaults to AWS S3.",
+		},
+		&cli.StringFlag{
+			Name:  "region",
+			Usage: "region of the S3 bucket. Defaults to the AWS_REGION environment variable, or us-east-1.",
+		},
+	}
+}
+
+func s3Options(c *cli.Context) dbutil.S3Options {
+	return dbutil.S3Options{
+		Endpoint: c.String("endpoint"),
+		Region:   c.String("region"),
+	}
+}
@@ -0,0 +1,138 @@
+package commands
+
+import (
+	"errors"
+	"io"
+	"io/ioutil"
+	"os"
+
+	"github.com/genjidb/genji"
+	"github.com/genjidb/genji/cmd/genji/dbutil"
+	"github.com/urfave/cli/v2"
+)
+
+// NewGenerateCommand returns a cli.Command for "genji generate".
+func NewGenerateCommand() *cli.Command {
+	cmd := cli.Command{
+		Name:      "generate",
+		Usage:     "Generate Go code from the schema of a database.",
+		UsageText: `genji generate [options] [dbpath]`,
+		Description: `The generate command reads the tables of a database and generates,
+for each table, a Go struct, constants for the names of the table and its fields,
+and functions to insert, get and query documents.
+
+By default, the code is sent to the standard output:
+
+$ genji generate my.db
+
+The schema can also be read from a file containing CREATE statements,
+such as one created by genji dump:
+
+$ genji generate -s schema.sql -p models -f models/models_gen.go
+
+It is possible to specify a list of tables:
+
+$ genji generate -t foo -t bar my.db`,
+		Flags: []cli.Flag{
+			&cli.StringFlag{
+				Name:    "file",
+				Aliases: []string{"f"},
+				Usage:   "name of the file to output to. Defaults to STDOUT.",
+			},
+			&cli.StringFlag{
+				Name:    "schema",
+				Aliases: []string{"s"},
+				Usage:   "name of a SQL file to read the schema from, instead of a database.",
+			},
+			&cli.StringFlag{
+				Name:    "package",
+				Aliases: []string{"p"},
+				Usage:   "name of the package of the generated code.",
+				Value:   "models",
+			},
+			&cli.StringSliceFlag{
+				Name:    "table",
+				Aliases: []string{"t"},
+				Usage:   "name of the table, it must already exist. Defaults to all tables.",
+			},
+			&cli.StringFlag{
+				Name:    "engine",
+				Aliases: []string{"e"},
+				Usage:   "name of the engine to use, options are 'bolt' or 'badger'",
+				Value:   "bolt",
+			},
+			&cli.StringFlag{
+				Name:    "encryption-key",
+				Aliases: []string{"k"},
+				Usage:   "encryption key, badger only",
+			},
+		},
+	}
+
+	cmd.Action = func(c *cli.Context) error {
+		schema := c.String("schema")
+		dbPath := c.Args().First()
+		if (dbPath == "") == (schema == "") {
+			return errors.New(cmd.UsageText)
+		}
+
+		var db *genji.DB
+		var err error
+		if schema != "" {
+			db, err = openSchema(c, schema)
+		} else {
+			engine := c.String("engine")
+			k := c.String("encryption-key")
+			if k != "" && engine != "badger" {
+				return cli.Exit("encryption key is only supported by the badger engine", 2)
+			}
+
+			db, err = dbutil.OpenDB(c.Context, dbPath, engine, dbutil.DBOptions{EncryptionKey: k})
+		}
+		if err != nil {
+			return err
+		}
+		defer db.Close()
+
+		var w io.Writer = os.Stdout
+
+		if f := c.String("file"); f != "" {
+			file, err := os.Create(f)
+			if err != nil {
+				return err
+			}
+			defer file.Close()
+
+			w = file
+		}
+
+		return dbutil.Generate(c.Context, db, w, dbutil.GenerateOptions{
+			Package: c.String("package"),
+			Tables:  c.StringSlice("table"),
+		})
+	}
+
+	return &cmd
+}
+
+// openSchema creates an in-memory database from the statements of a SQL file.
+func openSchema(c *cli.Context, schema string) (*genji.DB, error) {
+	file, err := os.Open(schema)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	db, err := dbutil.OpenDB(c.Context, "", "memory", dbutil.DBOptions{})
+	if err != nil {
+		return nil, err
+	}
+
+	err = dbutil.ExecSQL(c.Context, db, file, ioutil.Discard)
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	return db, nil
+}
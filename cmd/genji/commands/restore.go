@@ -3,6 +3,7 @@ package commands
 import (
 	"errors"
 	"os"
+	"strings"
 
 	"github.com/genjidb/genji/cmd/genji/dbutil"
 	"github.com/urfave/cli/v2"
@@ -16,8 +17,12 @@ func NewRestoreCommand() (cmd *cli.Command) {
 		UsageText: `genji restore dumpFile dbPath`,
 		Description: `The restore command can restore a database from a text file.
 
-	$ genji restore dump.sql my.db`,
-		Flags: []cli.Flag{
+	$ genji restore dump.sql my.db
+
+The file can also be a backup stored in S3 by genji backup:
+
+	$ genji restore s3://bucket/prefix/my-20210101T000000Z.sql my.db`,
+		Flags: append([]cli.Flag{
 			&cli.StringFlag{
 				Name:    "engine",
 				Aliases: []string{"e"},
@@ -29,7 +34,7 @@ func NewRestoreCommand() (cmd *cli.Command) {
 				Aliases: []string{"k"},
 				Usage:   "encryption key, badger only",
 			},
-		},
+		}, s3Flags()...),
 		Action: func(c *cli.Context) error {
 			engine := c.String("engine")
 			k := c.String("encryption-key")
@@ -50,6 +55,22 @@ func NewRestoreCommand() (cmd *cli.Command) {
 				return errors.New("dump file expected")
 			}
 
+			if strings.HasPrefix(f, "s3://") {
+				loc, name := dbutil.SplitBackupLocation(f)
+				src, err := dbutil.ParseDestination(loc, s3Options(c))
+				if err != nil {
+					return err
+				}
+
+				db, err := dbutil.OpenDB(c.Context, dbPath, engine, dbutil.DBOptions{EncryptionKey: k})
+				if err != nil {
+					return err
+				}
+				defer db.Close()
+
+				return dbutil.Restore(c.Context, db, src, name)
+			}
+
 			file, err := os.Open(f)
 			if err != nil {
 				return err
@@ -62,7 +83,7 @@ func NewRestoreCommand() (cmd *cli.Command) {
 			}
 			defer db.Close()
 
-			return dbutil.ExecSQL(c.Context, db, file, os.Stdout)
+			return db.WithContext(c.Context).Restore(file)
 		},
 	}
 }
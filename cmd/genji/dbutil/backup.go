@@ -0,0 +1,121 @@
+package dbutil
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/genjidb/genji"
+)
+
+// A Destination stores backups created by Backup and reads them back for Restore.
+type Destination interface {
+	// Put stores the content of r under the given name, replacing any existing backup.
+	Put(ctx context.Context, name string, r io.Reader) error
+	// Get returns the content of the backup stored under the given name.
+	Get(ctx context.Context, name string) (io.ReadCloser, error)
+}
+
+// DirDestination stores backups as files of a local directory.
+type DirDestination string
+
+// Put writes r to the file with the given name, creating the directory if necessary.
+func (d DirDestination) Put(ctx context.Context, name string, r io.Reader) error {
+	p := filepath.Join(string(d), filepath.FromSlash(name))
+
+	err := os.MkdirAll(filepath.Dir(p), 0755)
+	if err != nil {
+		return err
+	}
+
+	f, err := os.Create(p)
+	if err != nil {
+		return err
+	}
+
+	_, err = io.Copy(f, r)
+	if err != nil {
+		f.Close()
+		return err
+	}
+
+	return f.Close()
+}
+
+// Get opens the file with the given name.
+func (d DirDestination) Get(ctx context.Context, name string) (io.ReadCloser, error) {
+	return os.Open(filepath.Join(string(d), filepath.FromSlash(name)))
+}
+
+// ParseDestination returns the destination described by uri.
+// Locations starting with s3:// are stored in an S3 bucket, using the
+// bucket and key prefix of the uri, e.g. s3://bucket/prefix.
+// Any other location is a local directory.
+func ParseDestination(uri string, opts S3Options) (Destination, error) {
+	if !strings.HasPrefix(uri, "s3://") {
+		return DirDestination(uri), nil
+	}
+
+	bucket := strings.TrimPrefix(uri, "s3://")
+	var prefix string
+	if i := strings.IndexByte(bucket, '/'); i >= 0 {
+		bucket, prefix = bucket[:i], strings.Trim(bucket[i+1:], "/")
+	}
+	if bucket == "" {
+		return nil, fmt.Errorf("invalid location %q: missing bucket name", uri)
+	}
+
+	return NewS3Destination(bucket, prefix, opts), nil
+}
+
+// SplitBackupLocation splits the location of a backup, as returned by Backup,
+// into the location of its destination and the name of the backup.
+func SplitBackupLocation(loc string) (dest, name string) {
+	if strings.HasPrefix(loc, "s3://") {
+		dest, name = path.Split(loc)
+	} else {
+		dest, name = filepath.Split(loc)
+	}
+
+	return strings.TrimSuffix(dest, "/"), name
+}
+
+// BackupName returns the default name of a backup of the database at dbPath created at t.
+func BackupName(dbPath string, t time.Time) string {
+	base := filepath.Base(dbPath)
+	base = strings.TrimSuffix(base, filepath.Ext(base))
+
+	return fmt.Sprintf("%s-%s.sql", base, t.UTC().Format("20060102T150405Z"))
+}
+
+// Backup dumps the database and stores it in the destination under the given name.
+// The dump is streamed to the destination as it is produced.
+func Backup(ctx context.Context, db *genji.DB, dst Destination, name string) error {
+	pr, pw := io.Pipe()
+
+	go func() {
+		pw.CloseWithError(Dump(ctx, db, pw))
+	}()
+
+	err := dst.Put(ctx, name, pr)
+	// unblock the dump if the destination stopped reading early
+	pr.CloseWithError(err)
+	return err
+}
+
+// Restore reads the backup stored in the destination under the given name
+// and executes it against the database.
+func Restore(ctx context.Context, db *genji.DB, dst Destination, name string) error {
+	r, err := dst.Get(ctx, name)
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+
+	return db.WithContext(ctx).Restore(r)
+}
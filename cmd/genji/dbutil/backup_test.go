@@ -0,0 +1,44 @@
+package dbutil
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/genjidb/genji"
+	"github.com/genjidb/genji/internal/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBackup(t *testing.T) {
+	db, err := genji.Open(":memory:")
+	require.NoError(t, err)
+	defer db.Close()
+
+	err = db.Exec(`
+		CREATE TABLE foo(a INTEGER PRIMARY KEY, b TEXT);
+		INSERT INTO foo (a, b) VALUES (1, 'a'), (2, 'b');
+	`)
+	require.NoError(t, err)
+
+	dst := DirDestination(t.TempDir())
+	err = Backup(context.Background(), db, dst, "backups/foo.sql")
+	require.NoError(t, err)
+
+	restored, err := genji.Open(":memory:")
+	require.NoError(t, err)
+	defer restored.Close()
+
+	err = Restore(context.Background(), restored, dst, "backups/foo.sql")
+	require.NoError(t, err)
+
+	res, err := restored.Query(`SELECT * FROM foo`)
+	require.NoError(t, err)
+	defer res.Close()
+	testutil.RequireStreamEq(t, `{"a": 1, "b": "a"} {"a": 2, "b": "b"}`, res)
+}
+
+func TestBackupName(t *testing.T) {
+	name := BackupName("/data/my.db", time.Date(2021, 1, 2, 3, 4, 5, 0, time.UTC))
+	require.Equal(t, "my-20210102T030405Z.sql", name)
+}
@@ -155,3 +155,71 @@ func TestDumpSchema(t *testing.T) {
 		})
 	}
 }
+
+func TestDumpSchemaViews(t *testing.T) {
+	db, err := genji.Open(":memory:")
+	require.NoError(t, err)
+	defer db.Close()
+
+	err = db.Exec(`
+		CREATE TABLE foo (a INTEGER);
+		CREATE VIEW zeta AS SELECT a FROM foo WHERE a > 1;
+		CREATE VIEW alpha AS SELECT * FROM zeta;
+	`)
+	require.NoError(t, err)
+
+	// views are listed after the views they read
+	want := `CREATE TABLE foo (a INTEGER);
+
+CREATE VIEW zeta AS SELECT a FROM foo WHERE a > 1;
+CREATE VIEW alpha AS SELECT * FROM zeta;
+`
+
+	var got bytes.Buffer
+	err = DumpSchema(context.Background(), db, &got)
+	require.NoError(t, err)
+	require.Equal(t, want, got.String())
+
+	// views are not dumped when selecting tables
+	got.Reset()
+	err = DumpSchema(context.Background(), db, &got, "foo")
+	require.NoError(t, err)
+	require.Equal(t, "CREATE TABLE foo (a INTEGER);\n", got.String())
+}
+
+func TestDumpTriggers(t *testing.T) {
+	db, err := genji.Open(":memory:")
+	require.NoError(t, err)
+	defer db.Close()
+
+	err = db.Exec(`
+		CREATE TABLE foo (a INTEGER);
+		CREATE TABLE bar (a INTEGER);
+		CREATE TRIGGER foo_insert AFTER INSERT ON foo BEGIN INSERT INTO bar (a) VALUES (new.a); END;
+		INSERT INTO foo (a) VALUES (1);
+	`)
+	require.NoError(t, err)
+
+	// triggers are created after the data is inserted
+	want := `BEGIN TRANSACTION;
+CREATE TABLE bar (a INTEGER);
+INSERT INTO bar VALUES {"a": 1};
+
+CREATE TABLE foo (a INTEGER);
+INSERT INTO foo VALUES {"a": 1};
+
+CREATE TRIGGER foo_insert AFTER INSERT ON foo BEGIN INSERT INTO bar (a) VALUES (new.a); END;
+COMMIT;
+`
+
+	var got bytes.Buffer
+	err = Dump(context.Background(), db, &got)
+	require.NoError(t, err)
+	require.Equal(t, want, got.String())
+
+	// only the triggers of the selected tables are dumped
+	got.Reset()
+	err = DumpSchema(context.Background(), db, &got, "bar")
+	require.NoError(t, err)
+	require.Equal(t, "CREATE TABLE bar (a INTEGER);\n", got.String())
+}
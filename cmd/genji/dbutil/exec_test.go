@@ -40,3 +40,34 @@ func TestExecSQL(t *testing.T) {
 	require.Equal(t, 1, res.A)
 	require.Equal(t, 2, res.B)
 }
+
+func TestExecSQLTransaction(t *testing.T) {
+	db, err := genji.Open(":memory:")
+	require.NoError(t, err)
+	defer db.Close()
+
+	var got bytes.Buffer
+	err = ExecSQL(context.Background(), db, strings.NewReader(`
+		CREATE TABLE test(a INT UNIQUE);
+		BEGIN;
+		INSERT INTO test (a) VALUES (1);
+	`), &got)
+	require.NoError(t, err)
+
+	// the transaction is kept open across calls
+	err = ExecSQL(context.Background(), db, strings.NewReader(`
+		INSERT INTO test (a) VALUES (2);
+		INSERT INTO test (a) VALUES (1);
+	`), &got)
+	require.Error(t, err)
+
+	err = ExecSQL(context.Background(), db, strings.NewReader(`ROLLBACK;`), &got)
+	require.NoError(t, err)
+
+	doc, err := db.QueryDocument("SELECT COUNT(*) FROM test")
+	require.NoError(t, err)
+	var n int
+	err = document.Scan(doc, &n)
+	require.NoError(t, err)
+	require.Equal(t, 0, n)
+}
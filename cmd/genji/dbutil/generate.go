@@ -0,0 +1,290 @@
+package dbutil
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"go/format"
+	"io"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/genjidb/genji"
+	"github.com/genjidb/genji/document"
+	"github.com/genjidb/genji/internal/database"
+	"github.com/genjidb/genji/internal/query/statement"
+	"github.com/genjidb/genji/internal/sql/parser"
+	"github.com/genjidb/genji/internal/stringutil"
+)
+
+// GenerateOptions configures the code generated by Generate.
+type GenerateOptions struct {
+	// Package name of the generated file. Defaults to "models".
+	Package string
+	// Tables to generate code for. Defaults to all tables.
+	Tables []string
+}
+
+// Generate reads the schema of the database and writes Go code to w:
+// a struct per table, constants for the names of the table and of its fields,
+// and functions to insert, get and query documents.
+func Generate(ctx context.Context, db *genji.DB, w io.Writer, opts GenerateOptions) error {
+	if opts.Package == "" {
+		opts.Package = "models"
+	}
+
+	var tables []*database.TableInfo
+	err := db.View(func(tx *genji.Tx) error {
+		return QueryTables(tx, opts.Tables, func(name, query string) error {
+			if strings.HasPrefix(name, database.InternalPrefix) {
+				return nil
+			}
+
+			q, err := parser.ParseQuery(query)
+			if err != nil {
+				return err
+			}
+
+			stmt, ok := q.Statements[0].(*statement.CreateTableStmt)
+			if !ok {
+				return fmt.Errorf("unexpected definition of table %q: %s", name, query)
+			}
+
+			tables = append(tables, &stmt.Info)
+			return nil
+		})
+	})
+	if err != nil {
+		return err
+	}
+
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, `// Code generated by genji generate. DO NOT EDIT.
+
+package %s
+
+import (
+	"github.com/genjidb/genji"
+	"github.com/genjidb/genji/document"
+)
+
+// Queryer runs queries. It is implemented by *genji.DB and *genji.Tx.
+type Queryer interface {
+	Exec(q string, args ...interface{}) error
+	Query(q string, args ...interface{}) (*genji.Result, error)
+	QueryDocument(q string, args ...interface{}) (document.Document, error)
+}
+`, opts.Package)
+
+	for _, ti := range tables {
+		generateTable(&buf, ti)
+	}
+
+	src, err := format.Source(buf.Bytes())
+	if err != nil {
+		return err
+	}
+
+	_, err = w.Write(src)
+	return err
+}
+
+// generateField describes a field of a generated struct.
+type generateField struct {
+	name   string
+	goName string
+	goType string
+}
+
+func generateTable(buf *bytes.Buffer, ti *database.TableInfo) {
+	name := goIdentifier(singular(ti.TableName))
+	plural := goIdentifier(ti.TableName)
+	if plural == name {
+		plural += "List"
+	}
+
+	var fields []generateField
+	seen := make(map[string]bool)
+	for _, fc := range ti.FieldConstraints {
+		field := fc.Path[0].FieldName
+		if field == "" || seen[field] {
+			continue
+		}
+		seen[field] = true
+
+		goType := "map[string]interface{}"
+		// constraints on nested fields only tell that the top-level field is a document
+		if len(fc.Path) == 1 {
+			goType = goTypeOf(fc)
+		}
+
+		fields = append(fields, generateField{
+			name:   field,
+			goName: goIdentifier(field),
+			goType: goType,
+		})
+	}
+
+	tableName := strconv.Quote(stringutil.NormalizeIdentifier(ti.TableName, '`'))
+	tableName = tableName[1 : len(tableName)-1]
+
+	fmt.Fprintf(buf, "\n// %sTable is the name of the %s table.\n", name, ti.TableName)
+	fmt.Fprintf(buf, "const %sTable = %q\n", name, ti.TableName)
+
+	if len(fields) > 0 {
+		fmt.Fprintf(buf, "\n// Fields of the %s table.\nconst (\n", ti.TableName)
+		for _, f := range fields {
+			fmt.Fprintf(buf, "\t%s%s = %q\n", name, f.goName, f.name)
+		}
+		buf.WriteString(")\n")
+	}
+
+	fmt.Fprintf(buf, "\n// %s is a document of the %s table.\ntype %s struct {\n", name, ti.TableName, name)
+	for _, f := range fields {
+		fmt.Fprintf(buf, "\t%s %s `genji:%q`\n", f.goName, f.goType, f.name)
+	}
+	buf.WriteString("}\n")
+
+	pkType := "int64"
+	if pk := ti.FieldConstraints.GetPrimaryKey(); pk != nil {
+		pkType = strings.TrimPrefix(goTypeOf(pk), "*")
+	}
+
+	fmt.Fprintf(buf, `
+// Insert%[1]s inserts v into the %[2]s table.
+func Insert%[1]s(q Queryer, v *%[1]s) error {
+	return q.Exec("INSERT INTO %[3]s VALUES ?", v)
+}
+
+// Get%[1]s returns the document of the %[2]s table whose primary key is equal to pk.
+// If there is none, it returns errors.ErrDocumentNotFound.
+func Get%[1]s(q Queryer, pk %[5]s) (*%[1]s, error) {
+	d, err := q.QueryDocument("SELECT * FROM %[3]s WHERE pk() = ?", pk)
+	if err != nil {
+		return nil, err
+	}
+
+	var v %[1]s
+	err = document.StructScan(d, &v)
+	if err != nil {
+		return nil, err
+	}
+
+	return &v, nil
+}
+
+// Query%[4]s returns the documents of the %[2]s table selected by the given clause,
+// appended to "SELECT * FROM %[2]s", e.g. "WHERE a > ? ORDER BY b LIMIT 10".
+func Query%[4]s(q Queryer, clause string, args ...interface{}) ([]%[1]s, error) {
+	res, err := q.Query("SELECT * FROM %[3]s "+clause, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Close()
+
+	var list []%[1]s
+	err = res.Iterate(func(d document.Document) error {
+		var v %[1]s
+		err := document.StructScan(d, &v)
+		if err != nil {
+			return err
+		}
+
+		list = append(list, v)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return list, nil
+}
+`, name, ti.TableName, tableName, plural, pkType)
+}
+
+// goTypeOf returns the Go type of the values of the field.
+// Fields that can be omitted when inserting documents are pointers,
+// unless their type can be nil already.
+func goTypeOf(fc *database.FieldConstraint) string {
+	var tp string
+	switch fc.Type {
+	case document.BoolValue:
+		tp = "bool"
+	case document.IntegerValue:
+		tp = "int64"
+	case document.DoubleValue:
+		tp = "float64"
+	case document.TextValue:
+		tp = "string"
+	case document.BlobValue:
+		return "[]byte"
+	case document.VectorValue:
+		return "[]float64"
+	case document.ArrayValue:
+		return "[]interface{}"
+	case document.DocumentValue:
+		return "map[string]interface{}"
+	default:
+		return "interface{}"
+	}
+
+	required := (fc.IsPrimaryKey || fc.IsNotNull) && fc.DefaultValue == nil && fc.Identity == nil
+	if required {
+		return tp
+	}
+
+	return "*" + tp
+}
+
+// common initialisms, as recommended by the Go code review comments.
+var initialisms = map[string]bool{
+	"ACL": true, "API": true, "ASCII": true, "CPU": true, "CSS": true, "DNS": true,
+	"EOF": true, "GUID": true, "HTML": true, "HTTP": true, "HTTPS": true, "ID": true,
+	"IP": true, "JSON": true, "LHS": true, "QPS": true, "RAM": true, "RHS": true,
+	"RPC": true, "SLA": true, "SMTP": true, "SQL": true, "SSH": true, "TCP": true,
+	"TLS": true, "TTL": true, "UDP": true, "UI": true, "UID": true, "UUID": true,
+	"URI": true, "URL": true, "UTF8": true, "VM": true, "XML": true,
+}
+
+// goIdentifier converts a table or field name to an exported Go identifier,
+// e.g. user_id becomes UserID.
+func goIdentifier(name string) string {
+	words := strings.FieldsFunc(name, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+
+	var sb strings.Builder
+	for _, w := range words {
+		if initialisms[strings.ToUpper(w)] {
+			sb.WriteString(strings.ToUpper(w))
+			continue
+		}
+
+		rs := []rune(w)
+		rs[0] = unicode.ToUpper(rs[0])
+		sb.WriteString(string(rs))
+	}
+
+	id := sb.String()
+	if id == "" || !unicode.IsLetter([]rune(id)[0]) {
+		id = "X" + id
+	}
+
+	return id
+}
+
+// singular returns the singular form of common English plurals,
+// e.g. users becomes user and categories becomes category.
+func singular(name string) string {
+	switch {
+	case strings.HasSuffix(name, "ies") && len(name) > 3:
+		return name[:len(name)-3] + "y"
+	case strings.HasSuffix(name, "ss"), strings.HasSuffix(name, "us"), strings.HasSuffix(name, "is"):
+		return name
+	case strings.HasSuffix(name, "s") && len(name) > 1:
+		return name[:len(name)-1]
+	}
+
+	return name
+}
@@ -0,0 +1,84 @@
+package dbutil
+
+import (
+	"bytes"
+	"context"
+	"go/parser"
+	"go/token"
+	"testing"
+
+	"github.com/genjidb/genji"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGenerate(t *testing.T) {
+	db, err := genji.Open(":memory:")
+	require.NoError(t, err)
+	defer db.Close()
+
+	err = db.Exec(`
+		CREATE TABLE users(
+			id INTEGER PRIMARY KEY,
+			name TEXT NOT NULL,
+			email TEXT,
+			created_at INTEGER DEFAULT 0,
+			address.city TEXT,
+			tags ARRAY
+		);
+		CREATE TABLE categories(slug TEXT PRIMARY KEY);
+		CREATE SEQUENCE seq;
+	`)
+	require.NoError(t, err)
+
+	t.Run("All tables", func(t *testing.T) {
+		var buf bytes.Buffer
+		err := Generate(context.Background(), db, &buf, GenerateOptions{})
+		require.NoError(t, err)
+
+		src := buf.String()
+		_, err = parser.ParseFile(token.NewFileSet(), "models.go", src, 0)
+		require.NoError(t, err)
+
+		require.Contains(t, src, "package models\n")
+		require.Contains(t, src, `const UserTable = "users"`)
+		require.Contains(t, src, `UserCreatedAt = "created_at"`)
+		require.Contains(t, src, "type User struct {\n"+
+			"\tID        int64                  `genji:\"id\"`\n"+
+			"\tName      string                 `genji:\"name\"`\n"+
+			"\tEmail     *string                `genji:\"email\"`\n"+
+			"\tCreatedAt *int64                 `genji:\"created_at\"`\n"+
+			"\tAddress   map[string]interface{} `genji:\"address\"`\n"+
+			"\tTags      []interface{}          `genji:\"tags\"`\n"+
+			"}")
+		require.Contains(t, src, "func GetUser(q Queryer, pk int64) (*User, error)")
+		require.Contains(t, src, "func QueryUsers(q Queryer, clause string, args ...interface{}) ([]User, error)")
+		require.Contains(t, src, "func GetCategory(q Queryer, pk string) (*Category, error)")
+		require.NotContains(t, src, "__genji")
+	})
+
+	t.Run("Selection of tables", func(t *testing.T) {
+		var buf bytes.Buffer
+		err := Generate(context.Background(), db, &buf, GenerateOptions{Package: "foo", Tables: []string{"categories"}})
+		require.NoError(t, err)
+
+		src := buf.String()
+		require.Contains(t, src, "package foo\n")
+		require.Contains(t, src, "type Category struct")
+		require.NotContains(t, src, "type User struct")
+	})
+}
+
+func TestGoIdentifier(t *testing.T) {
+	tests := map[string]string{
+		"name":       "Name",
+		"user_id":    "UserID",
+		"api-url":    "APIURL",
+		"createdAt":  "CreatedAt",
+		"2fa":        "X2fa",
+		"categories": "Categories",
+	}
+
+	for name, want := range tests {
+		require.Equal(t, want, goIdentifier(name))
+	}
+}
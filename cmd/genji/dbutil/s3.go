@@ -0,0 +1,407 @@
+package dbutil
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DefaultS3PartSize is the default size of the parts of multipart uploads.
+const DefaultS3PartSize = 8 << 20
+
+// minS3PartSize is the minimum size of every part of a multipart upload but the last one.
+const minS3PartSize = 5 << 20
+
+// S3Options configures the access to an S3-compatible object storage.
+type S3Options struct {
+	// Endpoint of the service, e.g. http://localhost:9000 for a local MinIO server.
+	// If empty, AWS S3 is used, and buckets are addressed using virtual-hosted style URLs.
+	// Otherwise, path-style URLs are used.
+	Endpoint string
+	// Region of the bucket. Defaults to the AWS_REGION environment variable, or us-east-1.
+	Region string
+	// Credentials. Default to the AWS_ACCESS_KEY_ID, AWS_SECRET_ACCESS_KEY
+	// and AWS_SESSION_TOKEN environment variables.
+	AccessKeyID     string
+	SecretAccessKey string
+	SessionToken    string
+	// Size of the parts of multipart uploads. Backups smaller than one part
+	// are uploaded in a single request. Defaults to DefaultS3PartSize.
+	PartSize int
+	// Tags added to the uploaded objects, e.g. to let lifecycle rules
+	// of the bucket expire old backups.
+	Tags map[string]string
+	// Client used to send requests. Defaults to http.DefaultClient.
+	Client *http.Client
+}
+
+// S3Destination stores backups as objects of an S3 bucket.
+type S3Destination struct {
+	bucket string
+	prefix string
+	opts   S3Options
+}
+
+// NewS3Destination returns a destination storing backups in the given bucket,
+// under the given key prefix.
+func NewS3Destination(bucket, prefix string, opts S3Options) *S3Destination {
+	if opts.Region == "" {
+		opts.Region = os.Getenv("AWS_REGION")
+	}
+	if opts.Region == "" {
+		opts.Region = "us-east-1"
+	}
+	if opts.AccessKeyID == "" && opts.SecretAccessKey == "" {
+		opts.AccessKeyID = os.Getenv("AWS_ACCESS_KEY_ID")
+		opts.SecretAccessKey = os.Getenv("AWS_SECRET_ACCESS_KEY")
+		opts.SessionToken = os.Getenv("AWS_SESSION_TOKEN")
+	}
+	if opts.PartSize == 0 {
+		opts.PartSize = DefaultS3PartSize
+	}
+	if opts.PartSize < minS3PartSize {
+		opts.PartSize = minS3PartSize
+	}
+	if opts.Client == nil {
+		opts.Client = http.DefaultClient
+	}
+
+	return &S3Destination{
+		bucket: bucket,
+		prefix: prefix,
+		opts:   opts,
+	}
+}
+
+// Put uploads the content of r. Content larger than the part size
+// is sent using a multipart upload, which is aborted if an error occurs.
+func (s *S3Destination) Put(ctx context.Context, name string, r io.Reader) error {
+	part := make([]byte, s.opts.PartSize)
+	n, err := io.ReadFull(r, part)
+	if err == io.EOF || err == io.ErrUnexpectedEOF {
+		_, err = s.do(ctx, http.MethodPut, name, nil, s.tagHeader(), part[:n])
+		return err
+	}
+	if err != nil {
+		return err
+	}
+
+	uploadID, err := s.createMultipartUpload(ctx, name)
+	if err != nil {
+		return err
+	}
+
+	err = s.uploadParts(ctx, name, uploadID, r, part)
+	if err != nil {
+		// the error of the upload is more relevant than the one of the abort
+		_, _ = s.do(ctx, http.MethodDelete, name, url.Values{"uploadId": {uploadID}}, nil, nil)
+		return err
+	}
+
+	return nil
+}
+
+// Get downloads the object with the given name.
+func (s *S3Destination) Get(ctx context.Context, name string) (io.ReadCloser, error) {
+	req, err := s.newRequest(ctx, http.MethodGet, name, nil, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := s.opts.Client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		defer resp.Body.Close()
+		return nil, s3ResponseError(resp)
+	}
+
+	return resp.Body, nil
+}
+
+type s3Part struct {
+	PartNumber int
+	ETag       string
+}
+
+// uploadParts uploads first and the rest of r as parts of the given upload,
+// then completes it.
+func (s *S3Destination) uploadParts(ctx context.Context, name, uploadID string, r io.Reader, first []byte) error {
+	var parts []s3Part
+
+	part := first
+	for {
+		query := url.Values{
+			"partNumber": {strconv.Itoa(len(parts) + 1)},
+			"uploadId":   {uploadID},
+		}
+		resp, err := s.do(ctx, http.MethodPut, name, query, nil, part)
+		if err != nil {
+			return err
+		}
+		parts = append(parts, s3Part{PartNumber: len(parts) + 1, ETag: resp.Header.Get("ETag")})
+
+		n, err := io.ReadFull(r, part)
+		if err == io.EOF {
+			break
+		}
+		if err != nil && err != io.ErrUnexpectedEOF {
+			return err
+		}
+		part = part[:n]
+	}
+
+	body, err := xml.Marshal(struct {
+		XMLName xml.Name `xml:"CompleteMultipartUpload"`
+		Parts   []s3Part `xml:"Part"`
+	}{Parts: parts})
+	if err != nil {
+		return err
+	}
+
+	resp, err := s.do(ctx, http.MethodPost, name, url.Values{"uploadId": {uploadID}}, nil, body)
+	if err != nil {
+		return err
+	}
+
+	// completing an upload can fail after the response status has been sent
+	var result struct {
+		XMLName xml.Name
+		s3Error
+	}
+	err = xml.Unmarshal(resp.body, &result)
+	if err == nil && result.XMLName.Local == "Error" {
+		return &result.s3Error
+	}
+
+	return nil
+}
+
+func (s *S3Destination) createMultipartUpload(ctx context.Context, name string) (string, error) {
+	resp, err := s.do(ctx, http.MethodPost, name, url.Values{"uploads": {""}}, s.tagHeader(), nil)
+	if err != nil {
+		return "", err
+	}
+
+	var result struct {
+		UploadID string `xml:"UploadId"`
+	}
+	err = xml.Unmarshal(resp.body, &result)
+	if err != nil {
+		return "", err
+	}
+
+	return result.UploadID, nil
+}
+
+func (s *S3Destination) tagHeader() http.Header {
+	if len(s.opts.Tags) == 0 {
+		return nil
+	}
+
+	tags := make(url.Values, len(s.opts.Tags))
+	for k, v := range s.opts.Tags {
+		tags.Set(k, v)
+	}
+
+	return http.Header{"X-Amz-Tagging": {tags.Encode()}}
+}
+
+type s3Response struct {
+	*http.Response
+	body []byte
+}
+
+// do sends a request and reads the response, returning an error if its status is not 2xx.
+func (s *S3Destination) do(ctx context.Context, method, name string, query url.Values, header http.Header, body []byte) (*s3Response, error) {
+	req, err := s.newRequest(ctx, method, name, query, header, body)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := s.opts.Client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode/100 != 2 {
+		return nil, s3ResponseError(resp)
+	}
+
+	b, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	return &s3Response{Response: resp, body: b}, nil
+}
+
+// newRequest creates a request for the object with the given name, signed with AWS Signature Version 4.
+func (s *S3Destination) newRequest(ctx context.Context, method, name string, query url.Values, header http.Header, body []byte) (*http.Request, error) {
+	key := name
+	if s.prefix != "" {
+		key = s.prefix + "/" + name
+	}
+
+	u, err := s.objectURL(key)
+	if err != nil {
+		return nil, err
+	}
+	u.RawQuery = s3Encode(query)
+
+	req, err := http.NewRequestWithContext(ctx, method, u.String(), bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.ContentLength = int64(len(body))
+	for k, v := range header {
+		req.Header[k] = v
+	}
+
+	s.sign(req, body, time.Now().UTC())
+	return req, nil
+}
+
+func (s *S3Destination) objectURL(key string) (*url.URL, error) {
+	var u *url.URL
+	if s.opts.Endpoint == "" {
+		u = &url.URL{
+			Scheme: "https",
+			Host:   fmt.Sprintf("%s.s3.%s.amazonaws.com", s.bucket, s.opts.Region),
+			Path:   "/" + key,
+		}
+	} else {
+		var err error
+		u, err = url.Parse(s.opts.Endpoint)
+		if err != nil {
+			return nil, err
+		}
+		u.Path = strings.TrimSuffix(u.Path, "/") + "/" + s.bucket + "/" + key
+	}
+
+	u.RawPath = s3EscapePath(u.Path)
+	return u, nil
+}
+
+// sign adds the headers authenticating the request.
+// See https://docs.aws.amazon.com/general/latest/gr/sigv4_signing.html.
+func (s *S3Destination) sign(req *http.Request, body []byte, t time.Time) {
+	amzDate := t.Format("20060102T150405Z")
+	date := t.Format("20060102")
+	payloadHash := sha256.Sum256(body)
+
+	req.Header.Set("X-Amz-Date", amzDate)
+	req.Header.Set("X-Amz-Content-Sha256", hex.EncodeToString(payloadHash[:]))
+	if s.opts.SessionToken != "" {
+		req.Header.Set("X-Amz-Security-Token", s.opts.SessionToken)
+	}
+
+	// sign the host and all the x-amz-* headers
+	headers := []string{"host"}
+	for k := range req.Header {
+		k = strings.ToLower(k)
+		if strings.HasPrefix(k, "x-amz-") {
+			headers = append(headers, k)
+		}
+	}
+	sort.Strings(headers)
+
+	var canonicalHeaders strings.Builder
+	for _, k := range headers {
+		v := req.Host
+		if v == "" {
+			v = req.URL.Host
+		}
+		if k != "host" {
+			v = strings.TrimSpace(req.Header.Get(k))
+		}
+		canonicalHeaders.WriteString(k + ":" + v + "\n")
+	}
+	signedHeaders := strings.Join(headers, ";")
+
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		req.URL.EscapedPath(),
+		req.URL.RawQuery,
+		canonicalHeaders.String(),
+		signedHeaders,
+		hex.EncodeToString(payloadHash[:]),
+	}, "\n")
+
+	scope := date + "/" + s.opts.Region + "/s3/aws4_request"
+	requestHash := sha256.Sum256([]byte(canonicalRequest))
+	stringToSign := "AWS4-HMAC-SHA256\n" + amzDate + "\n" + scope + "\n" + hex.EncodeToString(requestHash[:])
+
+	k := hmacSHA256([]byte("AWS4"+s.opts.SecretAccessKey), date)
+	k = hmacSHA256(k, s.opts.Region)
+	k = hmacSHA256(k, "s3")
+	k = hmacSHA256(k, "aws4_request")
+	signature := hex.EncodeToString(hmacSHA256(k, stringToSign))
+
+	req.Header.Set("Authorization", fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		s.opts.AccessKeyID, scope, signedHeaders, signature))
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	h := hmac.New(sha256.New, key)
+	h.Write([]byte(data))
+	return h.Sum(nil)
+}
+
+// s3Encode encodes the query sorted by key, escaping spaces as %20
+// as required by the signature.
+func s3Encode(query url.Values) string {
+	return strings.ReplaceAll(query.Encode(), "+", "%20")
+}
+
+// s3EscapePath escapes every byte of the path but the unreserved characters and slashes.
+func s3EscapePath(p string) string {
+	var sb strings.Builder
+	for i := 0; i < len(p); i++ {
+		c := p[i]
+		switch {
+		case 'a' <= c && c <= 'z', 'A' <= c && c <= 'Z', '0' <= c && c <= '9',
+			c == '-', c == '_', c == '.', c == '~', c == '/':
+			sb.WriteByte(c)
+		default:
+			fmt.Fprintf(&sb, "%%%02X", c)
+		}
+	}
+
+	return sb.String()
+}
+
+// s3Error is an error returned by the service.
+type s3Error struct {
+	Code    string `xml:"Code"`
+	Message string `xml:"Message"`
+}
+
+func (e *s3Error) Error() string {
+	return fmt.Sprintf("s3: %s: %s", e.Code, e.Message)
+}
+
+func s3ResponseError(resp *http.Response) error {
+	var e s3Error
+	b, _ := io.ReadAll(resp.Body)
+	if xml.Unmarshal(b, &e) != nil || e.Code == "" {
+		return fmt.Errorf("s3: unexpected status %s", resp.Status)
+	}
+
+	return &e
+}
@@ -23,13 +23,26 @@ func DocString(rawExpr string) (string, error) {
 		return "", ErrInvalid
 	}
 	s := scanner.NewScanner(strings.NewReader(rawExpr))
-	tok, _, _ := s.Scan()
+	tok, _, lit := s.Scan()
 	if tok == scanner.ILLEGAL {
 		return "", ErrInvalid
 	}
 	if tok == scanner.IDENT {
 		s.Unscan()
-		return scanFuncDocString(s)
+		docstr, err := scanFuncDocString(s)
+		if errors.Is(err, ErrNotFound) {
+			// some words are only keywords in a specific context, i.e. OVER
+			if docstr, ok := wordDocs[strings.ToUpper(lit)]; ok {
+				return docstr, nil
+			}
+		}
+		return docstr, err
+	}
+	// some keywords are also the names of builtin functions, i.e. VALUES and values()
+	if tok1, _, _ := s.Scan(); tok1 == scanner.LPAREN {
+		if docstr, err := funcDocString("", strings.ToLower(tok.String())); err == nil {
+			return docstr, nil
+		}
 	}
 	docstr, ok := tokenDocs[tok]
 	if ok {
@@ -2,6 +2,7 @@ package doc_test
 
 import (
 	"fmt"
+	"strings"
 	"testing"
 
 	"github.com/genjidb/genji/cmd/genji/doc"
@@ -59,6 +60,20 @@ func TestDocString(t *testing.T) {
 		require.NotEqual(t, "TODO", str)
 	})
 
+	t.Run("OK keyword used as a function", func(t *testing.T) {
+		str, err := doc.DocString("values(")
+		require.NoError(t, err)
+		require.True(t, strings.HasPrefix(str, "values(arg1):"))
+	})
+
+	t.Run("OK contextual keyword", func(t *testing.T) {
+		for _, word := range []string{"OVER", "partition", "GRANT", "revoke", "show", "pragma", "analyze"} {
+			str, err := doc.DocString(word)
+			require.NoError(t, err)
+			require.Contains(t, str, strings.ToUpper(word))
+		}
+	})
+
 	t.Run("NOK illegal input", func(t *testing.T) {
 		_, err := doc.DocString("😀")
 		require.Equal(t, doc.ErrInvalid, err)
@@ -4,18 +4,63 @@ type functionDocs map[string]string
 
 var packageDocs = map[string]functionDocs{
 	"math": mathDocs,
+	"time": timeDocs,
 	"":     builtinDocs,
 }
 
 var builtinDocs = functionDocs{
-	"pk":    "The pk() function returns the primary key for the current document",
-	"count": "Returns a count of the number of times that arg1 is not NULL in a group. The count(*) function (with no arguments) returns the total number of rows in the group.",
-	"min":   "Returns the minimum value in a group.",
-	"max":   "Returns the maximum value in a group.",
-	"sum":   "The sum function returns the sum of all values in a group.",
-	"avg":   "The avg function returns the average of all values in a group.",
+	"pk":              "The pk() function returns the primary key for the current document",
+	"count":           "Returns a count of the number of times that arg1 is not NULL in a group. The count(*) function (with no arguments) returns the total number of rows in the group.",
+	"min":             "Returns the minimum value in a group.",
+	"max":             "Returns the maximum value in a group.",
+	"sum":             "The sum function returns the sum of all values in a group.",
+	"avg":             "The avg function returns the average of all values in a group.",
+	"array_agg":       "Returns an array of all the values of arg1 in a group, including NULL. Aggregate functions accept the DISTINCT qualifier to ignore duplicate values, i.e. array_agg(DISTINCT arg1).",
+	"substr":          "Returns the part of the blob or text arg1 starting at the 1-based position arg2, of arg3 bytes or characters, or up to the end if arg3 is omitted.",
+	"substring":       "Returns the part of the blob or text arg1 starting at the 1-based position arg2, of arg3 bytes or characters, or up to the end if arg3 is omitted. Equivalent to substr.",
+	"position":        "Returns the 1-based position of the first occurrence of the blob arg2 in the blob arg1, or 0 if it is not found.",
+	"hex":             "Returns the hexadecimal representation of the blob arg1.",
+	"unhex":           "Returns the blob represented by the hexadecimal text arg1.",
+	"length":          "Returns the number of bytes of the blob arg1, or the number of characters of the text arg1.",
+	"fields":          "Returns an array of the field names of the document arg1, in order.",
+	"values":          "Returns an array of the values of the document arg1, in order.",
+	"entries":         "Returns an array of documents containing the key and the value of each field of the document arg1, i.e. [{\"key\": \"a\", \"value\": 1}].",
+	"sha1":            "Returns the SHA-1 digest of the blob or text arg1, as a blob.",
+	"sha256":          "Returns the SHA-256 digest of the blob or text arg1, as a blob.",
+	"md5":             "Returns the MD5 digest of the blob or text arg1, as a blob.",
+	"random":          "Returns a random integer, generated using a cryptographically secure random number generator.",
+	"randomblob":      "Returns a blob of arg1 random bytes, generated using a cryptographically secure random number generator.",
+	"uuid":            "Returns a random version 4 UUID.",
+	"uuid_v7":         "Returns a version 7 UUID, made of the current unix timestamp in milliseconds followed by random bits. Version 7 UUIDs are ordered by creation time.",
+	"version":         "Returns the version of Genji.",
+	"changes":         "Returns the number of documents inserted, updated or deleted by the most recently completed INSERT, UPDATE or DELETE statement.",
+	"total_changes":   "Returns the number of documents inserted, updated or deleted since the database was opened.",
+	"last_insert_key": "Returns the primary key of the last inserted document, or NULL if no document was inserted since the database was opened.",
+	"row_number":      "Returns the position of the current document in its partition, starting at 1. Must be used with an OVER clause.",
+	"rank":            "Returns the rank of the current document in its partition, with gaps. Documents with the same ORDER BY values have the same rank. Must be used with an OVER clause.",
+	"dense_rank":      "Returns the rank of the current document in its partition, without gaps. Documents with the same ORDER BY values have the same rank. Must be used with an OVER clause.",
+	"lag":             "Returns arg1 evaluated on the document arg2 documents before the current one in its partition, or arg3 if there is none. arg2 defaults to 1 and arg3 to NULL. Must be used with an OVER clause.",
+	"lead":            "Returns arg1 evaluated on the document arg2 documents after the current one in its partition, or arg3 if there is none. arg2 defaults to 1 and arg3 to NULL. Must be used with an OVER clause.",
+	"now":             "Returns the timestamp of the start of the current transaction, in UTC. Every call within the same transaction returns the same value.",
+	"date_trunc":      "Returns the date or timestamp arg2 truncated to the unit arg1, i.e. 'month'. Weeks start on Monday.",
+	"date_part":       "Returns the unit arg1, i.e. 'year', 'dow' or 'epoch', of the date, timestamp or interval arg2. EXTRACT(unit FROM arg2) is equivalent.",
+	"strftime":        "Returns the date or timestamp arg2 formatted according to arg1, using the SQLite substitutions %d, %f, %H, %j, %m, %M, %s, %S, %w, %W, %Y and %%.",
+	"age":             "Returns the interval between the timestamps arg1 and arg2, in years, months and days. If arg2 is omitted, returns the interval between midnight of the current date and arg1.",
+	"coalesce":        "Returns the first of its arguments arg1, ... that is not NULL, or NULL if all of them are NULL.",
+	"ifnull":          "Returns arg1 if it is not NULL, otherwise arg2. Equivalent to coalesce(arg1, arg2).",
+	"nullif":          "Returns NULL if arg1 is equal to arg2, otherwise arg1.",
+	"greatest":        "Returns the greatest of its arguments arg1, ..., ignoring NULL values. Values of types that cannot be compared together are ordered by type, like with max.",
+	"least":           "Returns the least of its arguments arg1, ..., ignoring NULL values. Values of types that cannot be compared together are ordered by type, like with min.",
+	"concat":          "Returns the concatenation of the text representations of its arguments arg1, ..., ignoring NULL values.",
 }
 
 var mathDocs = functionDocs{
-	"floor": "Returns the greatest integer value less than or equal to arg1.",
+	"floor":              "Returns the greatest integer value less than or equal to arg1.",
+	"cosine_distance":    "Returns the cosine distance between the vectors arg1 and arg2, ranging from 0 to 2.",
+	"euclidean_distance": "Returns the euclidean distance between the vectors arg1 and arg2.",
+}
+
+var timeDocs = functionDocs{
+	"to_utc":       "Returns the timestamp arg1 converted to UTC. If arg1 is an RFC 3339 text, the result is a text too.",
+	"at_time_zone": "Returns the timestamp arg1 converted to the time zone arg2, i.e. 'Europe/Paris', or to the time zone of the session if arg2 is omitted, as an RFC 3339 text.",
 }
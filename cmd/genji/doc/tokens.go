@@ -4,6 +4,18 @@ import "github.com/genjidb/genji/internal/sql/scanner"
 
 var tokenDocs map[scanner.Token]string
 
+// wordDocs documents the words that are only keywords in a specific context,
+// i.e. OVER after a function call, and that are scanned as identifiers.
+var wordDocs = map[string]string{
+	"ANALYZE":   "ANALYZE [TABLE] computes the statistics of the table [TABLE], or of every table if omitted",
+	"GRANT":     "GRANT [PRIVILEGES] ON [TABLE] TO [ROLE] grants privileges on a table to a role, GRANT [ROLE] TO [ROLE] makes a role member of another",
+	"OVER":      "[FUNCTION] OVER ([PARTITION BY ...] [ORDER BY ...]) evaluates a window function or an aggregate function over the documents of the partition of each document",
+	"PARTITION": "See OVER (PARTITION BY ...)",
+	"PRAGMA":    "PRAGMA [NAME] returns the value of the option [NAME] of the database, PRAGMA [NAME] = [VALUE] modifies it",
+	"REVOKE":    "REVOKE [PRIVILEGES] ON [TABLE] FROM [ROLE] revokes privileges on a table from a role, REVOKE [ROLE] FROM [ROLE] removes a role from the members of another",
+	"SHOW":      "SHOW [NAME] returns the value of the setting [NAME] of the session",
+}
+
 func init() {
 	tokenDocs = make(map[scanner.Token]string)
 	// let's make sure the doc doesn't suggest that a keyword doesn't exist because
@@ -14,4 +26,6 @@ func init() {
 
 	tokenDocs[scanner.BY] = "See GROUP BY, ORDER BY"
 	tokenDocs[scanner.FROM] = "FROM [TABLE] selects documents in the table named [TABLE]"
+	tokenDocs[scanner.SET] = "SET [NAME] = [VALUE] modifies a setting of the session, see also UPDATE [TABLE] SET"
+	tokenDocs[scanner.VALUES] = "See INSERT INTO [TABLE] VALUES, or values(arg1) for the function returning the values of the document arg1"
 }
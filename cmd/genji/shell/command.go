@@ -3,7 +3,6 @@ package shell
 import (
 	"bytes"
 	"context"
-	"encoding/csv"
 	"errors"
 	"fmt"
 	"io"
@@ -81,7 +80,7 @@ var commands = []command{
 		Name:        ".import",
 		Options:     "TYPE FILE table",
 		DisplayName: ".import",
-		Description: "Import data from a file. Only supported type is 'csv'",
+		Description: "Import data from a file. Supported types are 'csv' and 'json'",
 	},
 }
 
@@ -192,8 +191,9 @@ func runSaveCmd(ctx context.Context, db *genji.DB, engineName string, dbPath str
 }
 
 func runImportCmd(ctx context.Context, db *genji.DB, fileType, path, table string) error {
-	if strings.ToLower(fileType) != "csv" {
-		return errors.New("TYPE should be csv")
+	fileType = strings.ToLower(fileType)
+	if fileType != "csv" && fileType != "json" {
+		return errors.New("TYPE should be csv or json")
 	}
 
 	f, err := os.Open(path)
@@ -202,37 +202,11 @@ func runImportCmd(ctx context.Context, db *genji.DB, fileType, path, table strin
 	}
 	defer f.Close()
 
-	tx, err := db.Begin(true)
-	if err != nil {
-		return err
-	}
-	defer tx.Rollback()
-
-	r := csv.NewReader(f)
-
-	err = tx.Exec(fmt.Sprintf("CREATE TABLE IF NOT EXISTS %s", table))
-	if err != nil {
-		return err
-	}
-
-	headers, err := r.Read()
-	if err != nil {
+	if fileType == "json" {
+		_, err = db.WithContext(ctx).ImportJSON(table, f)
 		return err
 	}
 
-	for {
-		columns, err := r.Read()
-		if err == io.EOF {
-			break
-		}
-		if err != nil {
-			return err
-		}
-		err = tx.Exec("INSERT INTO "+table+" VALUES ?", document.NewFromCSV(headers, columns))
-		if err != nil {
-			return err
-		}
-	}
-
-	return tx.Commit()
+	_, err = db.WithContext(ctx).ImportCSV(table, f, nil)
+	return err
 }
@@ -0,0 +1,249 @@
+package genji
+
+import (
+	"encoding/csv"
+	"errors"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/genjidb/genji/document"
+	"github.com/genjidb/genji/internal/stringutil"
+)
+
+// CSVOptions configures how ImportCSV and ExportCSV read and write CSV.
+type CSVOptions struct {
+	// Delimiter separates the values of a record. Defaults to a comma.
+	Delimiter rune
+	// NoHeader indicates that the first record is not a header
+	// holding the names of the fields.
+	NoHeader bool
+	// Fields are the names of the fields of the columns, in order.
+	// When importing, they replace the names of the header, and are required if there is no header.
+	// When exporting, they select the fields of the documents to write.
+	Fields []string
+}
+
+func (o *CSVOptions) delimiter() rune {
+	if o == nil || o.Delimiter == 0 {
+		return ','
+	}
+
+	return o.Delimiter
+}
+
+// ImportCSV inserts the records read from r as documents of the given table,
+// within a single transaction, and returns the number of inserted documents.
+// The table is created if it doesn't exist.
+// Every column is mapped to a field named after the header, or after opts.Fields.
+// Empty values are skipped. Values of fields declared with a type are converted to that type,
+// while the type of the others is inferred: booleans, integers and doubles are recognized,
+// other values are inserted as text.
+// If opts is nil, the first record must be a header and values are separated by commas.
+func (db *DB) ImportCSV(tableName string, r io.Reader, opts *CSVOptions) (n int, err error) {
+	cr := csv.NewReader(r)
+	cr.Comma = opts.delimiter()
+	cr.FieldsPerRecord = -1
+	cr.ReuseRecord = true
+
+	var fields []string
+	if opts != nil {
+		fields = opts.Fields
+	}
+	if opts == nil || !opts.NoHeader {
+		header, err := cr.Read()
+		if err == io.EOF {
+			return 0, nil
+		}
+		if err != nil {
+			return 0, err
+		}
+		if fields == nil {
+			fields = append(fields, header...)
+		}
+	}
+	if len(fields) == 0 {
+		return 0, errors.New("field names required")
+	}
+
+	tx, err := db.Begin(true)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	name := stringutil.NormalizeIdentifier(tableName, '`')
+	err = tx.Exec("CREATE TABLE IF NOT EXISTS " + name)
+	if err != nil {
+		return 0, err
+	}
+
+	info, err := db.db.Catalog.GetTableInfo(tx.tx, tableName)
+	if err != nil {
+		return 0, err
+	}
+
+	// values of typed fields are converted by the table
+	typed := make([]bool, len(fields))
+	for i, f := range fields {
+		fc := info.FieldConstraints.Get(document.NewPath(f))
+		typed[i] = fc != nil && fc.Type != 0
+	}
+
+	stmt, err := tx.Prepare("INSERT INTO " + name + " VALUES ?")
+	if err != nil {
+		return 0, err
+	}
+
+	for {
+		record, err := cr.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return n, err
+		}
+
+		fb := document.NewFieldBuffer()
+		for i, s := range record {
+			if i >= len(fields) {
+				break
+			}
+			if s == "" {
+				continue
+			}
+
+			if typed[i] {
+				fb.Add(fields[i], document.NewTextValue(s))
+			} else {
+				fb.Add(fields[i], inferCSVValue(s))
+			}
+		}
+
+		err = stmt.Exec(fb)
+		if err != nil {
+			return n, stringutil.Errorf("record %d: %w", n+1, err)
+		}
+		n++
+	}
+
+	return n, tx.Commit()
+}
+
+// inferCSVValue returns the boolean, integer or double represented by s,
+// or s as text.
+func inferCSVValue(s string) document.Value {
+	switch strings.ToLower(s) {
+	case "true":
+		return document.NewBoolValue(true)
+	case "false":
+		return document.NewBoolValue(false)
+	}
+
+	if i, err := strconv.ParseInt(s, 10, 64); err == nil {
+		return document.NewIntegerValue(i)
+	}
+
+	if f, err := strconv.ParseFloat(s, 64); err == nil && !math.IsInf(f, 0) && !math.IsNaN(f) {
+		return document.NewDoubleValue(f)
+	}
+
+	return document.NewTextValue(s)
+}
+
+// ExportCSV runs the query and writes the documents it returns to w, as CSV records.
+// The columns are the fields selected by opts.Fields, or the fields projected by the query,
+// or else the fields of the first document. A header with the names of the fields
+// is written first, unless opts.NoHeader is set.
+// Missing fields and NULL values are written as empty values, documents and arrays as JSON.
+func (db *DB) ExportCSV(w io.Writer, q string, opts *CSVOptions, args ...interface{}) error {
+	res, err := db.Query(q, args...)
+	if err != nil {
+		return err
+	}
+	defer res.Close()
+
+	cw := csv.NewWriter(w)
+	cw.Comma = opts.delimiter()
+
+	var fields []string
+	if opts != nil {
+		fields = opts.Fields
+	}
+	if fields == nil {
+		fields = res.Fields()
+	}
+
+	var record []string
+	err = res.Iterate(func(d document.Document) error {
+		if record == nil {
+			if fields == nil {
+				err := d.Iterate(func(field string, _ document.Value) error {
+					fields = append(fields, field)
+					return nil
+				})
+				if err != nil {
+					return err
+				}
+			}
+
+			if opts == nil || !opts.NoHeader {
+				if err := cw.Write(fields); err != nil {
+					return err
+				}
+			}
+
+			record = make([]string, len(fields))
+		}
+
+		for i, f := range fields {
+			v, err := d.GetByField(f)
+			if err != nil && err != document.ErrFieldNotFound {
+				return err
+			}
+
+			record[i], err = formatCSVValue(v)
+			if err != nil {
+				return err
+			}
+		}
+
+		return cw.Write(record)
+	})
+	if err != nil {
+		return err
+	}
+
+	// write the header of empty results if the fields are known
+	if record == nil && fields != nil && (opts == nil || !opts.NoHeader) {
+		if err := cw.Write(fields); err != nil {
+			return err
+		}
+	}
+
+	cw.Flush()
+	return cw.Error()
+}
+
+// formatCSVValue returns the text of a CSV value.
+func formatCSVValue(v document.Value) (string, error) {
+	switch v.Type {
+	case 0, document.NullValue:
+		return "", nil
+	case document.TextValue:
+		return v.V.(string), nil
+	}
+
+	data, err := v.MarshalJSON()
+	if err != nil {
+		return "", err
+	}
+
+	// values represented as JSON strings, such as dates or blobs, are unquoted
+	if len(data) > 0 && data[0] == '"' {
+		return strconv.Unquote(string(data))
+	}
+
+	return string(data), nil
+}
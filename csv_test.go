@@ -0,0 +1,73 @@
+package genji_test
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/genjidb/genji"
+	"github.com/genjidb/genji/document"
+	"github.com/stretchr/testify/require"
+)
+
+func TestImportExportCSV(t *testing.T) {
+	db, err := genji.Open(":memory:")
+	require.NoError(t, err)
+	defer db.Close()
+
+	err = db.Exec("CREATE TABLE foo (zip TEXT)")
+	require.NoError(t, err)
+
+	n, err := db.ImportCSV("foo", strings.NewReader(strings.Join([]string{
+		"name,age,score,active,zip",
+		"alice,30,1.5,true,01234",
+		"bob,,2,FALSE,",
+		`"smith, j",x,NaN,yes,10`,
+	}, "\n")), nil)
+	require.NoError(t, err)
+	require.Equal(t, 3, n)
+
+	d, err := db.QueryDocument("SELECT * FROM foo WHERE name = 'alice'")
+	require.NoError(t, err)
+	data, err := document.MarshalJSON(d)
+	require.NoError(t, err)
+	require.JSONEq(t, `{"name": "alice", "age": 30, "score": 1.5, "active": true, "zip": "01234"}`, string(data))
+
+	// empty values are skipped
+	d, err = db.QueryDocument("SELECT * FROM foo WHERE name = 'bob'")
+	require.NoError(t, err)
+	data, err = document.MarshalJSON(d)
+	require.NoError(t, err)
+	require.JSONEq(t, `{"name": "bob", "score": 2, "active": false}`, string(data))
+
+	var buf bytes.Buffer
+	err = db.ExportCSV(&buf, "SELECT name, age, score, active, zip FROM foo ORDER BY name", nil)
+	require.NoError(t, err)
+	require.Equal(t, strings.Join([]string{
+		"name,age,score,active,zip",
+		"alice,30,1.5,true,01234",
+		"bob,,2,false,",
+		`"smith, j",x,NaN,yes,10`,
+		"",
+	}, "\n"), buf.String())
+
+	// custom delimiter and field names, without header
+	buf.Reset()
+	opts := genji.CSVOptions{Delimiter: ';', NoHeader: true, Fields: []string{"name", "zip"}}
+	err = db.ExportCSV(&buf, "SELECT * FROM foo WHERE age = ?", &opts, 30)
+	require.NoError(t, err)
+	require.Equal(t, "alice;01234\n", buf.String())
+
+	n, err = db.ImportCSV("bar", strings.NewReader("a;1\nb;2\n"), &genji.CSVOptions{Delimiter: ';', NoHeader: true, Fields: []string{"k", "v"}})
+	require.NoError(t, err)
+	require.Equal(t, 2, n)
+
+	d, err = db.QueryDocument("SELECT SUM(v) AS s FROM bar")
+	require.NoError(t, err)
+	var sum int
+	require.NoError(t, document.Scan(d, &sum))
+	require.Equal(t, 3, sum)
+
+	_, err = db.ImportCSV("bar", strings.NewReader("a;1\n"), &genji.CSVOptions{NoHeader: true})
+	require.Error(t, err)
+}
@@ -2,15 +2,18 @@ package genji
 
 import (
 	"context"
+	"errors"
+	"time"
 
 	"github.com/genjidb/genji/document"
 	"github.com/genjidb/genji/engine"
 	errs "github.com/genjidb/genji/errors"
 	"github.com/genjidb/genji/internal/database"
 	"github.com/genjidb/genji/internal/environment"
+	"github.com/genjidb/genji/internal/expr"
+	"github.com/genjidb/genji/internal/expr/functions"
 	"github.com/genjidb/genji/internal/query"
 	"github.com/genjidb/genji/internal/query/statement"
-	"github.com/genjidb/genji/internal/sql/parser"
 	"github.com/genjidb/genji/internal/stream"
 	"github.com/genjidb/genji/internal/stringutil"
 )
@@ -19,6 +22,25 @@ import (
 type DB struct {
 	db  *database.Database
 	ctx context.Context
+
+	// session used by the queries run on this handle.
+	// If nil, the default session of the database is used.
+	session *database.Session
+
+	// cache of query results, shared by every handle.
+	cache *queryCache
+
+	// cache of query plans, shared by every handle.
+	plans *planCache
+
+	// hooks called when preparing statements, shared by every handle.
+	hooks *planHooks
+
+	// set if the database is a replica opened with OpenReplica.
+	replica *replica
+
+	// maximum lag of the replicas served by ServeReplicas, shared by every handle.
+	maxReplicaLag *int64
 }
 
 func newDatabase(ctx context.Context, ng engine.Engine, opts database.Options) (*DB, error) {
@@ -27,9 +49,15 @@ func newDatabase(ctx context.Context, ng engine.Engine, opts database.Options) (
 		return nil, err
 	}
 
+	maxReplicaLag := int64(defaultMaxReplicaLag)
 	return &DB{
-		db:  db,
-		ctx: ctx,
+		db:    db,
+		ctx:   ctx,
+		cache: newQueryCache(),
+		plans: newPlanCache(),
+		hooks: new(planHooks),
+
+		maxReplicaLag: &maxReplicaLag,
 	}, nil
 }
 
@@ -39,24 +67,129 @@ func (db DB) WithContext(ctx context.Context) *DB {
 	return &db
 }
 
+// NewSession creates a new database handle with its own session.
+// The session starts with a copy of the settings of db, and settings
+// modified using the SET statement on the returned handle don't affect db.
+func (db DB) NewSession() *DB {
+	db.session = db.getSession().Fork()
+	return &db
+}
+
+// WithStatementTimeout creates a new database handle with its own session, like NewSession,
+// whose statements fail with errs.ErrStatementTimeout once they run for longer than d.
+// It is equivalent to running SET statement_timeout on the returned handle.
+// Zero means statements never time out.
+func (db DB) WithStatementTimeout(d time.Duration) *DB {
+	return db.withDuration("statement_timeout", d)
+}
+
+// WithTransactionTimeout creates a new database handle with its own session, like NewSession,
+// whose transactions fail with errs.ErrTransactionTimeout once they run for longer than d:
+// their remaining statements and their commit fail, and the changes they made are discarded.
+// It is equivalent to running SET transaction_timeout on the returned handle.
+// Zero means transactions never time out.
+func (db DB) WithTransactionTimeout(d time.Duration) *DB {
+	return db.withDuration("transaction_timeout", d)
+}
+
+func (db DB) withDuration(setting string, d time.Duration) *DB {
+	if d < 0 {
+		d = 0
+	}
+
+	ndb := db.NewSession()
+	// durations are always valid
+	_ = ndb.session.Set(setting, document.NewTextValue(d.String()))
+	return ndb
+}
+
+// Login authenticates a user created using CREATE USER and returns a new database handle
+// with its own session. Queries run by the returned handle are subject to the
+// privileges of the user, granted using GRANT.
+// Handles that are not authenticated are not subject to privileges, and are the only
+// ones allowed to manage users and roles.
+func (db *DB) Login(user, password string) (*DB, error) {
+	tx, err := db.db.BeginTx(db.ctx, &database.TxOptions{ReadOnly: true})
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	r, err := database.GetRole(tx, db.db.Catalog, user)
+	if err != nil && !errs.IsNotFoundError(err) {
+		return nil, err
+	}
+	if err != nil || !r.Login || !r.CheckPassword(password) {
+		return nil, errInvalidCredentials
+	}
+
+	udb := db.NewSession()
+	udb.session.SetUser(r.Name)
+	return udb, nil
+}
+
+var errInvalidCredentials = errors.New("invalid user or password")
+
+func (db *DB) getSession() *database.Session {
+	if db.session != nil {
+		return db.session
+	}
+
+	return db.db.Session
+}
+
 // Close the database.
 func (db *DB) Close() error {
+	if db.replica != nil {
+		db.replica.close()
+	}
+
 	return db.db.Close()
 }
 
 // Begin starts a new transaction.
 // The returned transaction must be closed either by calling Rollback or Commit.
+// The transaction has its own session, using a copy of the settings of db:
+// settings modified within the transaction are discarded when it ends.
+// It returns an error if a transaction was opened on the session of db using BEGIN.
+// Only one writable transaction runs at a time. Read-only transactions run concurrently
+// with it and see the database as it was when they started, unless it modifies
+// the schema: from its first DDL statement until it ends, the writable transaction
+// waits for the read-only transactions to end and blocks new ones.
 func (db *DB) Begin(writable bool) (*Tx, error) {
-	tx, err := db.db.BeginTx(db.ctx, &database.TxOptions{
+	return db.begin(&database.TxOptions{
 		ReadOnly: !writable,
 	})
+}
+
+// BeginOptimistic starts an optimistic read-write transaction.
+// Unlike the transactions started by Begin, which run one at a time, optimistic
+// transactions run concurrently: they read a snapshot of the database taken when they
+// start, and their writes are applied when they commit, unless another transaction
+// committed in the meantime wrote the same documents or index entries, or modified the schema.
+// Commit then fails with errs.ErrWriteConflict, and the transaction can be retried.
+// Optimistic transactions cannot modify the schema.
+func (db *DB) BeginOptimistic() (*Tx, error) {
+	return db.begin(&database.TxOptions{
+		Optimistic: true,
+	})
+}
+
+func (db *DB) begin(opts *database.TxOptions) (*Tx, error) {
+	if db.getSession().Tx() != nil {
+		return nil, errors.New("cannot open a transaction within a transaction")
+	}
+	opts.Timeout = db.getSession().TransactionTimeout()
+
+	tx, err := db.db.BeginTx(db.ctx, opts)
 	if err != nil {
 		return nil, err
 	}
 
 	return &Tx{
-		db: db,
-		tx: tx,
+		db:      db,
+		tx:      tx,
+		session: db.getSession().Fork(),
 	}, nil
 }
 
@@ -73,7 +206,11 @@ func (db *DB) View(fn func(tx *Tx) error) error {
 
 // Update starts a read-write transaction, runs fn and automatically commits it.
 func (db *DB) Update(fn func(tx *Tx) error) error {
-	tx, err := db.Begin(true)
+	return db.update(&database.TxOptions{}, fn)
+}
+
+func (db *DB) update(opts *database.TxOptions, fn func(tx *Tx) error) error {
+	tx, err := db.begin(opts)
 	if err != nil {
 		return err
 	}
@@ -87,6 +224,26 @@ func (db *DB) Update(fn func(tx *Tx) error) error {
 	return tx.Commit()
 }
 
+// maxWriteConflictRetries is the number of optimistic transactions
+// run by UpdateWithRetry before falling back to Update.
+const maxWriteConflictRetries = 10
+
+// UpdateWithRetry starts a transaction using BeginOptimistic, runs fn and automatically
+// commits it. If the commit fails with errs.ErrWriteConflict, fn is run again in a new
+// transaction. After 10 conflicts, fn is run using Update, whose transaction can't conflict.
+// fn may be called several times and must not have side effects other than the queries
+// it runs using tx.
+func (db *DB) UpdateWithRetry(fn func(tx *Tx) error) error {
+	for i := 0; i < maxWriteConflictRetries; i++ {
+		err := db.update(&database.TxOptions{Optimistic: true}, fn)
+		if err != errs.ErrWriteConflict {
+			return err
+		}
+	}
+
+	return db.Update(fn)
+}
+
 // Query the database and return the result.
 // The returned result must always be closed after usage.
 func (db *DB) Query(q string, args ...interface{}) (*Result, error) {
@@ -121,12 +278,7 @@ func (db *DB) Exec(q string, args ...interface{}) error {
 
 // Prepare parses the query and returns a prepared statement.
 func (db *DB) Prepare(q string) (*Statement, error) {
-	pq, err := parser.ParseQuery(q)
-	if err != nil {
-		return nil, err
-	}
-
-	err = pq.Prepare(newQueryContext(db, nil, nil))
+	pq, err := db.prepare(q, nil)
 	if err != nil {
 		return nil, err
 	}
@@ -142,8 +294,9 @@ func (db *DB) Prepare(q string) (*Statement, error) {
 // Tx is either read-only or read/write. Read-only can be used to read tables
 // and read/write can be used to read, create, delete and modify tables.
 type Tx struct {
-	db *DB
-	tx *database.Transaction
+	db      *DB
+	tx      *database.Transaction
+	session *database.Session
 }
 
 // Rollback the transaction. Can be used safely after commit.
@@ -191,12 +344,7 @@ func (tx *Tx) Exec(q string, args ...interface{}) (err error) {
 
 // Prepare parses the query and returns a prepared statement.
 func (tx *Tx) Prepare(q string) (*Statement, error) {
-	pq, err := parser.ParseQuery(q)
-	if err != nil {
-		return nil, err
-	}
-
-	err = pq.Prepare(newQueryContext(tx.db, tx, nil))
+	pq, err := tx.db.prepare(q, tx)
 	if err != nil {
 		return nil, err
 	}
@@ -216,6 +364,9 @@ type Statement struct {
 	pq query.Query
 	db *DB
 	tx *Tx
+
+	// operators added by Pipe.
+	ops []StreamOperator
 }
 
 // Query the database and return the result.
@@ -224,11 +375,20 @@ func (s *Statement) Query(args ...interface{}) (*Result, error) {
 	var r *statement.Result
 	var err error
 
-	r, err = s.pq.Run(newQueryContext(s.db, s.tx, argsToParams(args)))
+	params := argsToParams(args)
+	if key, ok := s.cacheKey(params); ok {
+		return s.queryCached(key, params)
+	}
+
+	r, err = s.pq.Run(newQueryContext(s.db, s.tx, params))
 	if err != nil {
 		return nil, err
 	}
 
+	if len(s.ops) > 0 {
+		s.pipe(r)
+	}
+
 	return &Result{result: r}, nil
 }
 
@@ -250,22 +410,85 @@ func (s *Statement) QueryDocument(args ...interface{}) (d document.Document, err
 }
 
 func scanDocument(iter document.Iterator) (document.Document, error) {
-	var d document.Document
+	var fb *document.FieldBuffer
 	err := iter.Iterate(func(doc document.Document) error {
-		d = doc
+		// the document is only valid during the iteration
+		fb = document.NewFieldBuffer()
+		err := fb.Copy(doc)
+		if err != nil {
+			return err
+		}
+
 		return stream.ErrStreamClosed
 	})
 	if err != nil {
 		return nil, err
 	}
 
-	if d == nil {
+	if fb == nil {
 		return nil, errs.ErrDocumentNotFound
 	}
 
-	fb := document.NewFieldBuffer()
-	err = fb.Copy(d)
-	return fb, err
+	return fb, nil
+}
+
+// WithContext returns a copy of the statement using the given context to run the query.
+func (s *Statement) WithContext(ctx context.Context) *Statement {
+	ss := *s
+	ss.db = s.db.WithContext(ctx)
+	return &ss
+}
+
+// Statements returns one statement per statement of the query, in order.
+// Each of them expects the same arguments as s.
+// If the query contains transaction statements, such as BEGIN or COMMIT, they
+// can't be run separately and s is returned as the only statement.
+func (s *Statement) Statements() []*Statement {
+	if len(s.pq.Statements) <= 1 {
+		return []*Statement{s}
+	}
+
+	for _, st := range s.pq.Statements {
+		switch st.(type) {
+		case query.BeginStmt, query.CommitStmt, query.RollbackStmt:
+			return []*Statement{s}
+		}
+	}
+
+	stmts := make([]*Statement, len(s.pq.Statements))
+	for i, st := range s.pq.Statements {
+		stmts[i] = &Statement{
+			pq: query.Query{
+				Statements: []statement.Statement{st},
+				NumParams:  s.pq.NumParams,
+				ParamNames: s.pq.ParamNames,
+			},
+			db: s.db,
+			tx: s.tx,
+		}
+	}
+
+	// operators added by Pipe transform the result of the last statement
+	stmts[len(stmts)-1].ops = s.ops
+
+	return stmts
+}
+
+// NumParams returns the number of parameters expected by the statement:
+// the number of positional parameters, or the number of distinct named parameters.
+func (s *Statement) NumParams() int {
+	if len(s.pq.ParamNames) > 0 {
+		return len(s.pq.ParamNames)
+	}
+
+	return s.pq.NumParams
+}
+
+// ParamNames returns the names of the named parameters of the statement,
+// without their $ or : prefix, in order of first appearance.
+// It returns nil if the statement uses positional parameters or no parameters at all.
+func (s *Statement) ParamNames() []string {
+	return append([]string(nil), s.pq.ParamNames...)
 }
 
 // Exec a query against the database without returning the result.
@@ -289,13 +512,171 @@ func (s *Statement) Exec(args ...interface{}) (err error) {
 // Result of a query.
 type Result struct {
 	result *statement.Result
+
+	// fields of the results returned by the query cache.
+	fields []string
+	types  []document.ValueType
+
+	// state of the iteration using Next.
+	it  *resultIterator
+	doc document.Document
+	err error
 }
 
+// Iterate calls fn for each document of the result.
+// The document is only valid during the call to fn.
 func (r *Result) Iterate(fn func(d document.Document) error) error {
 	return r.result.Iterate(fn)
 }
 
+// Next prepares the next document of the result, which is then returned by Document.
+// It returns false when there are no more documents, or if an error occurred,
+// which is then returned by Err.
+// If ctx is canceled while waiting for the next document, the iteration is aborted,
+// including within long scans, and Err returns the error of ctx.
+// Next and Iterate must not be both used on the same result.
+//
+//	for res.Next(ctx) {
+//		d := res.Document()
+//		...
+//	}
+//	if err := res.Err(); err != nil {
+//		...
+//	}
+func (r *Result) Next(ctx context.Context) bool {
+	if r.err != nil {
+		return false
+	}
+
+	if err := ctx.Err(); err != nil {
+		if r.it != nil {
+			r.it.stop()
+		}
+		r.err = err
+		return false
+	}
+
+	if r.it == nil {
+		r.it = newResultIterator(r.result)
+	} else if r.doc != nil {
+		// let the iteration move on
+		select {
+		case r.it.next <- struct{}{}:
+		case <-r.it.done:
+		}
+	}
+
+	r.doc = nil
+
+	select {
+	case d := <-r.it.docs:
+		r.doc = d
+		return true
+	case <-r.it.done:
+		r.err = r.it.err
+		if r.err == nil {
+			r.err = errEndOfResult
+		}
+	case <-ctx.Done():
+		r.it.stop()
+		r.err = ctx.Err()
+	}
+
+	return false
+}
+
+// Document returns the document prepared by Next.
+// It is only valid until the next call to Next or Close.
+func (r *Result) Document() document.Document {
+	return r.doc
+}
+
+// Err returns the error that stopped the iteration using Next, if any.
+func (r *Result) Err() error {
+	if r.err == errEndOfResult {
+		return nil
+	}
+
+	return r.err
+}
+
+// errEndOfResult marks the end of the iteration using Next.
+var errEndOfResult = errors.New("end of result")
+
+// resultIterator iterates over a result in a separate goroutine,
+// sending the documents one at a time.
+type resultIterator struct {
+	cancel func()
+	// each document is sent on docs, and the iteration is resumed
+	// when receiving on next.
+	docs chan document.Document
+	next chan struct{}
+	// closing is closed to end the iteration early.
+	closing chan struct{}
+	// done is closed once the iteration is over, err is then set.
+	done chan struct{}
+	err  error
+}
+
+func newResultIterator(res *statement.Result) *resultIterator {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	it := resultIterator{
+		cancel:  cancel,
+		docs:    make(chan document.Document),
+		next:    make(chan struct{}),
+		closing: make(chan struct{}),
+		done:    make(chan struct{}),
+	}
+
+	go func() {
+		defer close(it.done)
+
+		it.err = res.IterateContext(ctx, func(d document.Document) error {
+			select {
+			case it.docs <- d:
+			case <-it.closing:
+				return stream.ErrStreamClosed
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+
+			select {
+			case <-it.next:
+				return nil
+			case <-it.closing:
+				// stopping early is not an error
+				return stream.ErrStreamClosed
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		})
+	}()
+
+	return &it
+}
+
+// stop aborts the iteration and waits for the goroutine to return.
+func (it *resultIterator) stop() {
+	it.cancel()
+	<-it.done
+}
+
+// close ends the iteration without error and waits for the goroutine to return.
+func (it *resultIterator) close() {
+	close(it.closing)
+	<-it.done
+	it.cancel()
+}
+
+// Fields returns the names of the fields of the documents returned by the query,
+// or "*" if the documents are returned as is.
+// It returns nil if the query doesn't return documents.
 func (r *Result) Fields() []string {
+	if r.fields != nil {
+		return r.fields
+	}
+
 	if r.result.Iterator == nil {
 		return nil
 	}
@@ -305,8 +686,14 @@ func (r *Result) Fields() []string {
 		return nil
 	}
 
+	// streams writing documents without returning them don't have fields
+	switch stmt.Stream.Op.(type) {
+	case *stream.TableInsertOperator, *stream.TableReplaceOperator, *stream.TableDeleteOperator, *stream.TableUpsertOperator:
+		return nil
+	}
+
 	// Search for the ProjectOperator. If found, extract the projected expression list
-	for op := stmt.Stream.First(); op != nil; op = op.GetNext() {
+	for _, op := range streamOperators(stmt.Stream) {
 		if po, ok := op.(*stream.ProjectOperator); ok {
 			// if there are no projected expression, it's a wildcard
 			if len(po.Exprs) == 0 {
@@ -326,24 +713,125 @@ func (r *Result) Fields() []string {
 	return []string{"*"}
 }
 
+// FieldTypes returns the types of the fields returned by Fields, when they can be
+// determined before running the query: the types of literals, casts, counts,
+// and of fields of the scanned table declared with a type.
+// Unknown types are zero, and the type of "*" is document.DocumentValue.
+func (r *Result) FieldTypes() []document.ValueType {
+	if r.fields != nil {
+		return r.types
+	}
+
+	fields := r.Fields()
+	if fields == nil {
+		return nil
+	}
+
+	types := make([]document.ValueType, len(fields))
+	if len(fields) == 1 && fields[0] == "*" {
+		types[0] = document.DocumentValue
+		return types
+	}
+
+	stmt := r.result.Iterator.(*statement.StreamStmtIterator)
+	var po *stream.ProjectOperator
+	var info *database.TableInfo
+	for _, op := range streamOperators(stmt.Stream) {
+		var tableName string
+		switch t := op.(type) {
+		case *stream.ProjectOperator:
+			if po == nil {
+				po = t
+			}
+		case *stream.SeqScanOperator:
+			tableName = t.TableName
+		case *stream.PkScanOperator:
+			tableName = t.TableName
+		case *stream.IndexScanOperator:
+			if idx, err := stmt.Context.Catalog.GetIndexInfo(stmt.Context.Tx, t.IndexName); err == nil {
+				tableName = idx.TableName
+			}
+		}
+
+		if tableName != "" && info == nil {
+			info, _ = stmt.Context.Catalog.GetTableInfo(stmt.Context.Tx, tableName)
+		}
+	}
+
+	for i, e := range po.Exprs {
+		types[i] = exprType(e, info)
+	}
+
+	return types
+}
+
+// streamOperators returns the operators of the stream, including the ones applied by parallel scans
+// and batch operators.
+func streamOperators(s *stream.Stream) []stream.Operator {
+	var ops []stream.Operator
+	for op := s.First(); op != nil; op = op.GetNext() {
+		switch t := op.(type) {
+		case *stream.ParallelScanOperator:
+			ops = append(ops, streamOperators(t.Stream)...)
+		case *stream.BatchOperator:
+			ops = append(ops, streamOperators(t.Stream)...)
+		default:
+			ops = append(ops, op)
+		}
+	}
+
+	return ops
+}
+
+// exprType returns the type of the values returned by e, if it is known.
+func exprType(e expr.Expr, info *database.TableInfo) document.ValueType {
+	switch t := e.(type) {
+	case *expr.NamedExpr:
+		return exprType(t.Expr, info)
+	case expr.LiteralValue:
+		return t.Type
+	case functions.Cast:
+		return t.CastAs
+	case *functions.Count:
+		return document.IntegerValue
+	case expr.Path:
+		if info == nil {
+			return 0
+		}
+		if fc := info.FieldConstraints.Get(document.Path(t)); fc != nil {
+			return fc.Type
+		}
+	}
+
+	return 0
+}
+
 // Close the result stream.
 func (r *Result) Close() (err error) {
 	if r == nil {
 		return nil
 	}
 
+	// stop the iteration started by Next, if it is still running
+	if r.it != nil && r.err == nil {
+		r.it.close()
+	}
+
 	return r.result.Close()
 }
 
 func newQueryContext(db *DB, tx *Tx, params []environment.Param) *query.Context {
 	ctx := query.Context{
-		Ctx:    db.ctx,
-		DB:     db.db,
-		Params: params,
+		Ctx:      db.ctx,
+		DB:       db.db,
+		Session:  db.session,
+		Params:   params,
+		PlanHook: db.hooks.run,
 	}
 
 	if tx != nil {
 		ctx.Tx = tx.tx
+		ctx.Session = tx.session
 	}
 
 	return &ctx
@@ -2,13 +2,17 @@ package genji_test
 
 import (
 	"context"
+	"database/sql"
+	"errors"
 	"fmt"
 	"log"
+	"strings"
 	"testing"
 
 	"github.com/genjidb/genji"
 	"github.com/genjidb/genji/document"
 	errs "github.com/genjidb/genji/errors"
+	"github.com/genjidb/genji/internal/testutil"
 	"github.com/stretchr/testify/require"
 	"golang.org/x/sync/errgroup"
 )
@@ -152,6 +156,470 @@ func TestPrepareThreadSafe(t *testing.T) {
 	require.NoError(t, err)
 }
 
+func TestPrepareParams(t *testing.T) {
+	db, err := genji.Open(":memory:")
+	require.NoError(t, err)
+	defer db.Close()
+
+	err = db.Exec("CREATE TABLE test(a int, b text); INSERT INTO test(a, b) VALUES (1, 'a'), (2, 'b')")
+	require.NoError(t, err)
+
+	t.Run("Positional", func(t *testing.T) {
+		stmt, err := db.Prepare("SELECT * FROM test WHERE a > ? AND b != ?")
+		require.NoError(t, err)
+		require.Equal(t, 2, stmt.NumParams())
+		require.Nil(t, stmt.ParamNames())
+	})
+
+	t.Run("Named", func(t *testing.T) {
+		stmt, err := db.Prepare("SELECT b FROM test WHERE a >= :min AND a <= $max AND :min > 0")
+		require.NoError(t, err)
+		require.Equal(t, 2, stmt.NumParams())
+		require.Equal(t, []string{"min", "max"}, stmt.ParamNames())
+
+		d, err := stmt.QueryDocument(sql.Named("max", 5), sql.Named("min", 2))
+		require.NoError(t, err)
+		var b string
+		err = document.Scan(d, &b)
+		require.NoError(t, err)
+		require.Equal(t, "b", b)
+	})
+
+	t.Run("No params", func(t *testing.T) {
+		stmt, err := db.Prepare("SELECT * FROM test")
+		require.NoError(t, err)
+		require.Equal(t, 0, stmt.NumParams())
+		require.Nil(t, stmt.ParamNames())
+	})
+}
+
+func TestPrepareStatements(t *testing.T) {
+	db, err := genji.Open(":memory:")
+	require.NoError(t, err)
+	defer db.Close()
+
+	err = db.Exec("CREATE TABLE test(a int, b text)")
+	require.NoError(t, err)
+
+	stmt, err := db.Prepare("INSERT INTO test(a, b) VALUES (?, 'a'); SELECT a, b, a + 1 FROM test WHERE a = ?")
+	require.NoError(t, err)
+
+	stmts := stmt.Statements()
+	require.Len(t, stmts, 2)
+
+	res, err := stmts[0].Query(1, 1)
+	require.NoError(t, err)
+	require.Nil(t, res.Fields())
+	require.Nil(t, res.FieldTypes())
+	require.NoError(t, res.Close())
+
+	err = stmts[0].Exec(1, 1)
+	require.NoError(t, err)
+
+	res, err = stmts[1].Query(1, 1)
+	require.NoError(t, err)
+	require.Equal(t, []string{"a", "b", "a + 1"}, res.Fields())
+	require.Equal(t, []document.ValueType{document.IntegerValue, document.TextValue, 0}, res.FieldTypes())
+	require.NoError(t, res.Close())
+
+	d, err := stmts[1].QueryDocument(1, 1)
+	require.NoError(t, err)
+	require.JSONEq(t, `{"a": 1, "b": "a", "a + 1": 2}`, document.NewDocumentValue(d).String())
+
+	// transactions can't be split
+	stmt, err = db.Prepare("BEGIN; SELECT * FROM test; COMMIT")
+	require.NoError(t, err)
+	require.Len(t, stmt.Statements(), 1)
+}
+
+func TestResultNext(t *testing.T) {
+	db, err := genji.Open(":memory:")
+	require.NoError(t, err)
+	defer db.Close()
+
+	err = db.Exec("CREATE TABLE test(a int); INSERT INTO test(a) VALUES (1), (2), (3)")
+	require.NoError(t, err)
+
+	ctx := context.Background()
+
+	t.Run("All", func(t *testing.T) {
+		res, err := db.Query("SELECT a FROM test")
+		require.NoError(t, err)
+		defer res.Close()
+
+		var list []int
+		for res.Next(ctx) {
+			var a int
+			require.NoError(t, document.Scan(res.Document(), &a))
+			list = append(list, a)
+		}
+		require.NoError(t, res.Err())
+		require.Equal(t, []int{1, 2, 3}, list)
+		require.False(t, res.Next(ctx))
+	})
+
+	t.Run("Break early", func(t *testing.T) {
+		res, err := db.Query("INSERT INTO test(a) VALUES (4), (5) RETURNING a")
+		require.NoError(t, err)
+
+		require.True(t, res.Next(ctx))
+		require.NoError(t, res.Close())
+		require.NoError(t, res.Err())
+
+		// the documents inserted so far are committed
+		d, err := db.QueryDocument("SELECT COUNT(*) FROM test")
+		require.NoError(t, err)
+		var n int
+		require.NoError(t, document.Scan(d, &n))
+		require.Equal(t, 4, n)
+	})
+
+	t.Run("Canceled", func(t *testing.T) {
+		res, err := db.Query("SELECT a FROM test")
+		require.NoError(t, err)
+		defer res.Close()
+
+		ctx, cancel := context.WithCancel(ctx)
+		require.True(t, res.Next(ctx))
+		cancel()
+		require.False(t, res.Next(ctx))
+		require.Equal(t, context.Canceled, res.Err())
+	})
+
+	t.Run("Canceled handle", func(t *testing.T) {
+		// the transaction started by BEGIN doesn't use the context of the handle
+		sdb := db.NewSession()
+		err := sdb.Exec("BEGIN")
+		require.NoError(t, err)
+		defer sdb.Exec("ROLLBACK")
+
+		ctx, cancel := context.WithCancel(ctx)
+		res, err := sdb.WithContext(ctx).Query("SELECT a FROM test WHERE a > 10")
+		require.NoError(t, err)
+		defer res.Close()
+
+		// the scan stops even though no document is returned
+		cancel()
+		err = res.Iterate(func(d document.Document) error {
+			return nil
+		})
+		require.Equal(t, context.Canceled, err)
+	})
+}
+
+func TestSessionSettings(t *testing.T) {
+	db, err := genji.Open(":memory:")
+	require.NoError(t, err)
+	defer db.Close()
+
+	requireStrict := func(t *testing.T, q interface {
+		QueryDocument(string, ...interface{}) (document.Document, error)
+	}, expected bool) {
+		t.Helper()
+
+		d, err := q.QueryDocument("SHOW strict")
+		require.NoError(t, err)
+		v, err := d.GetByField("strict")
+		require.NoError(t, err)
+		require.Equal(t, document.NewBoolValue(expected), v)
+	}
+
+	err = db.Exec("SET strict = true")
+	require.NoError(t, err)
+
+	t.Run("Tx", func(t *testing.T) {
+		tx, err := db.Begin(false)
+		require.NoError(t, err)
+		defer tx.Rollback()
+
+		// transactions inherit the settings of the database
+		requireStrict(t, tx, true)
+
+		err = tx.Exec("SET strict = false")
+		require.NoError(t, err)
+		requireStrict(t, tx, false)
+		requireStrict(t, db, true)
+	})
+
+	t.Run("NewSession", func(t *testing.T) {
+		other := db.NewSession()
+		requireStrict(t, other, true)
+
+		err = other.Exec("SET strict = false")
+		require.NoError(t, err)
+		requireStrict(t, other, false)
+		requireStrict(t, db, true)
+	})
+}
+
+func TestSessionTransaction(t *testing.T) {
+	db, err := genji.Open(":memory:")
+	require.NoError(t, err)
+	defer db.Close()
+
+	err = db.Exec("CREATE TABLE test(a INT)")
+	require.NoError(t, err)
+
+	count := func(t *testing.T, db *genji.DB) int {
+		t.Helper()
+
+		d, err := db.QueryDocument("SELECT COUNT(*) FROM test")
+		require.NoError(t, err)
+		var n int
+		require.NoError(t, document.Scan(d, &n))
+		return n
+	}
+
+	t.Run("Rollback", func(t *testing.T) {
+		err := db.Exec("BEGIN")
+		require.NoError(t, err)
+		err = db.Exec("INSERT INTO test (a) VALUES (1)")
+		require.NoError(t, err)
+		require.Equal(t, 1, count(t, db))
+
+		// the Go API can't be used while the transaction is open
+		_, err = db.Begin(false)
+		require.Error(t, err)
+
+		err = db.Exec("ROLLBACK")
+		require.NoError(t, err)
+		require.Equal(t, 0, count(t, db))
+	})
+
+	t.Run("Sessions", func(t *testing.T) {
+		s1, s2 := db.NewSession(), db.NewSession()
+
+		err := s1.Exec("BEGIN READ ONLY")
+		require.NoError(t, err)
+
+		// s2 doesn't use the transaction of s1
+		require.Equal(t, 0, count(t, s2))
+		err = s2.Exec("COMMIT")
+		require.Error(t, err)
+
+		err = s1.Exec("COMMIT")
+		require.NoError(t, err)
+
+		err = s2.Exec("BEGIN; INSERT INTO test (a) VALUES (1), (2)")
+		require.NoError(t, err)
+		err = s2.Exec("COMMIT")
+		require.NoError(t, err)
+		require.Equal(t, 2, count(t, s1))
+	})
+}
+
+func TestOptimisticTransaction(t *testing.T) {
+	db, err := genji.Open(":memory:")
+	require.NoError(t, err)
+	defer db.Close()
+
+	err = db.Exec(`
+		CREATE TABLE test(id INT PRIMARY KEY, n INT);
+		INSERT INTO test (id, n) VALUES (1, 0), (2, 0);
+		CREATE TABLE nopk(a INT);
+		CREATE TABLE uniq(a INT, u TEXT UNIQUE);
+	`)
+	require.NoError(t, err)
+
+	get := func(q interface {
+		QueryDocument(string, ...interface{}) (document.Document, error)
+	}, id int) int {
+		var n int
+		d, err := q.QueryDocument("SELECT n FROM test WHERE id = ?", id)
+		require.NoError(t, err)
+		require.NoError(t, document.Scan(d, &n))
+		return n
+	}
+
+	t.Run("Isolation", func(t *testing.T) {
+		tx, err := db.BeginOptimistic()
+		require.NoError(t, err)
+		defer tx.Rollback()
+
+		err = tx.Exec("UPDATE test SET n = 10 WHERE id = 1; INSERT INTO test (id, n) VALUES (3, 3); DELETE FROM test WHERE id = 2")
+		require.NoError(t, err)
+
+		// the transaction sees its own writes, other transactions don't
+		require.Equal(t, 10, get(tx, 1))
+		d, err := tx.QueryDocument("SELECT COUNT(*) AS c, SUM(n) AS s FROM test")
+		require.NoError(t, err)
+		testutil.RequireDocJSONEq(t, d, `{"c": 2, "s": 13}`)
+		require.Equal(t, 0, get(db, 1))
+
+		// writers don't wait for optimistic transactions
+		err = db.Exec("INSERT INTO test (id, n) VALUES (4, 4)")
+		require.NoError(t, err)
+
+		require.NoError(t, tx.Commit())
+		d, err = db.QueryDocument("SELECT COUNT(*) AS c, SUM(n) AS s FROM test")
+		require.NoError(t, err)
+		testutil.RequireDocJSONEq(t, d, `{"c": 3, "s": 17}`)
+
+		err = db.Exec("DELETE FROM test WHERE id > 2; INSERT INTO test (id, n) VALUES (2, 0); UPDATE test SET n = 0")
+		require.NoError(t, err)
+	})
+
+	t.Run("Conflicts", func(t *testing.T) {
+		tests := []struct {
+			name, q, other string
+			fails          bool
+		}{
+			{"same document", "UPDATE test SET n = n + 1 WHERE id = 1", "UPDATE test SET n = n + 10 WHERE id = 1", true},
+			{"deleted document", "UPDATE test SET n = n + 1 WHERE id = 1", "DELETE FROM test WHERE id = 1", true},
+			{"same primary key", "INSERT INTO test (id, n) VALUES (3, 0)", "INSERT INTO test (id, n) VALUES (3, 1)", true},
+			{"same unique value", "INSERT INTO uniq (a, u) VALUES (1, 'a')", "INSERT INTO uniq (a, u) VALUES (2, 'a')", true},
+			{"other unique value", "INSERT INTO uniq (a, u) VALUES (1, 'a')", "INSERT INTO uniq (a, u) VALUES (2, 'b')", false},
+			{"other document", "UPDATE test SET n = n + 1 WHERE id = 1", "UPDATE test SET n = n + 10 WHERE id = 2", false},
+			{"other table", "INSERT INTO nopk (a) VALUES (1)", "INSERT INTO nopk (a) VALUES (2)", false},
+		}
+
+		for _, test := range tests {
+			t.Run(test.name, func(t *testing.T) {
+				db, err := genji.Open(":memory:")
+				require.NoError(t, err)
+				defer db.Close()
+
+				err = db.Exec(`
+					CREATE TABLE test(id INT PRIMARY KEY, n INT);
+					INSERT INTO test (id, n) VALUES (1, 0), (2, 0);
+					CREATE TABLE nopk(a INT);
+					CREATE TABLE uniq(a INT, u TEXT UNIQUE);
+				`)
+				require.NoError(t, err)
+
+				tx, err := db.BeginOptimistic()
+				require.NoError(t, err)
+				defer tx.Rollback()
+
+				err = tx.Exec(test.q)
+				require.NoError(t, err)
+
+				// the other transaction commits first
+				err = db.Exec(test.other)
+				require.NoError(t, err)
+
+				err = tx.Commit()
+				if test.fails {
+					require.Equal(t, errs.ErrWriteConflict, err)
+				} else {
+					require.NoError(t, err)
+				}
+
+				// the indexes were updated by the transaction that committed
+				err = db.Exec("INSERT INTO uniq (a, u) VALUES (3, 'a')")
+				require.Equal(t, strings.Contains(test.q+test.other, "uniq"), errors.Is(err, errs.ErrDuplicateDocument))
+			})
+		}
+
+		t.Run("schema", func(t *testing.T) {
+			db, err := genji.Open(":memory:")
+			require.NoError(t, err)
+			defer db.Close()
+
+			err = db.Exec("CREATE TABLE test(id INT PRIMARY KEY, n INT); INSERT INTO test (id, n) VALUES (1, 0)")
+			require.NoError(t, err)
+
+			tx, err := db.BeginOptimistic()
+			require.NoError(t, err)
+			defer tx.Rollback()
+
+			err = tx.Exec("UPDATE test SET n = n + 1 WHERE id = 1")
+			require.NoError(t, err)
+
+			// the schema can only be modified once the optimistic transaction
+			// stops reading, but the read/write transaction commits first
+			wtx, err := db.Begin(true)
+			require.NoError(t, err)
+			defer wtx.Rollback()
+
+			done := make(chan error)
+			go func() {
+				err := wtx.Exec("CREATE TABLE foo(a INT)")
+				if err == nil {
+					err = wtx.Commit()
+				}
+				done <- err
+			}()
+
+			require.Equal(t, errs.ErrWriteConflict, tx.Commit())
+			require.NoError(t, <-done)
+		})
+	})
+
+	t.Run("Schema", func(t *testing.T) {
+		err := db.UpdateWithRetry(func(tx *genji.Tx) error {
+			return tx.Exec("CREATE TABLE foo(a INT)")
+		})
+		require.EqualError(t, err, "cannot modify the schema within an optimistic transaction")
+	})
+
+	t.Run("UpdateWithRetry", func(t *testing.T) {
+		var attempts int
+		err := db.UpdateWithRetry(func(tx *genji.Tx) error {
+			attempts++
+			if attempts == 1 {
+				// conflicting write, committed before tx
+				err := db.Exec("UPDATE test SET n = n + 100 WHERE id = 1")
+				require.NoError(t, err)
+			}
+
+			n := get(tx, 1)
+			return tx.Exec("UPDATE test SET n = ? WHERE id = 1", n+1)
+		})
+		require.NoError(t, err)
+		require.Equal(t, 2, attempts)
+		require.Equal(t, 101, get(db, 1))
+
+		// falls back to a regular transaction
+		attempts = 0
+		err = db.UpdateWithRetry(func(tx *genji.Tx) error {
+			attempts++
+			if attempts <= 10 {
+				err := db.Exec("UPDATE test SET n = n + 100 WHERE id = 2")
+				require.NoError(t, err)
+			}
+
+			return tx.Exec("UPDATE test SET n = n + 1 WHERE id = 2")
+		})
+		require.NoError(t, err)
+		require.Equal(t, 11, attempts)
+		require.Equal(t, 1001, get(db, 2))
+	})
+
+	t.Run("Concurrent", func(t *testing.T) {
+		err := db.Exec("UPDATE test SET n = 0")
+		require.NoError(t, err)
+
+		var g errgroup.Group
+		for i := 0; i < 10; i++ {
+			g.Go(func() error {
+				for j := 0; j < 20; j++ {
+					err := db.UpdateWithRetry(func(tx *genji.Tx) error {
+						err := tx.Exec("INSERT INTO nopk (a) VALUES (1)")
+						if err != nil {
+							return err
+						}
+						return tx.Exec("UPDATE test SET n = n + 1 WHERE id = 1")
+					})
+					if err != nil {
+						return err
+					}
+				}
+				return nil
+			})
+		}
+		require.NoError(t, g.Wait())
+
+		// no update is lost
+		require.Equal(t, 200, get(db, 1))
+		d, err := db.QueryDocument("SELECT COUNT(*) FROM nopk")
+		require.NoError(t, err)
+		var n int
+		require.NoError(t, document.Scan(d, &n))
+		require.Equal(t, 200, n)
+	})
+}
+
 func BenchmarkSelect(b *testing.B) {
 	for size := 1; size <= 10000; size *= 10 {
 		b.Run(fmt.Sprintf("%.05d", size), func(b *testing.B) {
@@ -198,6 +666,56 @@ func BenchmarkSelectWhere(b *testing.B) {
 	}
 }
 
+func BenchmarkSelectProjection(b *testing.B) {
+	for size := 1; size <= 10000; size *= 10 {
+		b.Run(fmt.Sprintf("%.05d", size), func(b *testing.B) {
+			db, err := genji.Open(":memory:")
+			require.NoError(b, err)
+
+			err = db.Exec("CREATE TABLE foo")
+			require.NoError(b, err)
+
+			for i := 0; i < size; i++ {
+				err = db.Exec("INSERT INTO foo(a, b, c) VALUES (1, 2, 'foo');")
+				require.NoError(b, err)
+			}
+
+			var x, y int
+			b.ReportAllocs()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				res, _ := db.Query("SELECT a, b + 1 AS c FROM foo WHERE a > 0")
+				res.Iterate(func(d document.Document) error { return document.Scan(d, &x, &y) })
+			}
+		})
+	}
+}
+
+func BenchmarkSelectAggregate(b *testing.B) {
+	for size := 1; size <= 10000; size *= 10 {
+		b.Run(fmt.Sprintf("%.05d", size), func(b *testing.B) {
+			db, err := genji.Open(":memory:")
+			require.NoError(b, err)
+
+			err = db.Exec("CREATE TABLE foo")
+			require.NoError(b, err)
+
+			for i := 0; i < size; i++ {
+				err = db.Exec("INSERT INTO foo(a, b, c) VALUES (?, ?, 'foo');", i%10, float64(i))
+				require.NoError(b, err)
+			}
+
+			b.ReportAllocs()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				res, _ := db.Query("SELECT a, COUNT(*), SUM(b), AVG(b) FROM foo WHERE b > 0 GROUP BY a")
+				res.Iterate(func(d document.Document) error { return nil })
+				res.Close()
+			}
+		})
+	}
+}
+
 func BenchmarkPreparedSelectWhere(b *testing.B) {
 	for size := 1; size <= 10000; size *= 10 {
 		b.Run(fmt.Sprintf("%.05d", size), func(b *testing.B) {
@@ -244,3 +762,100 @@ func BenchmarkSelectPk(b *testing.B) {
 		})
 	}
 }
+
+func BenchmarkInsertValues(b *testing.B) {
+	for size := 10; size <= 10000; size *= 10 {
+		b.Run(fmt.Sprintf("%.05d", size), func(b *testing.B) {
+			db, err := genji.Open(":memory:")
+			require.NoError(b, err)
+			defer db.Close()
+
+			err = db.Exec("CREATE TABLE foo(a INT, b TEXT NOT NULL, c DOUBLE); CREATE INDEX foo_b ON foo(b)")
+			require.NoError(b, err)
+
+			var sb strings.Builder
+			sb.WriteString("INSERT INTO foo (a, b, c) VALUES ")
+			for i := 0; i < size; i++ {
+				if i > 0 {
+					sb.WriteString(", ")
+				}
+				fmt.Fprintf(&sb, "(%d, 'text %d', %d.5)", i, i%10, i)
+			}
+
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				b.StopTimer()
+				err = db.Exec("DELETE FROM foo")
+				require.NoError(b, err)
+				b.StartTimer()
+
+				err = db.Exec(sb.String())
+				require.NoError(b, err)
+			}
+		})
+	}
+}
+
+func TestConstraintViolationError(t *testing.T) {
+	db, err := genji.Open(":memory:")
+	require.NoError(t, err)
+	defer db.Close()
+
+	err = db.Exec(`
+		CREATE TABLE test (a INT UNIQUE, b INT, c TEXT, CONSTRAINT b_c_uniq UNIQUE (b, c));
+		CREATE UNIQUE INDEX idx_tags ON test (tags[*]);
+		INSERT INTO test (a, b, c, tags) VALUES (1, 1, 'x', ['foo', 'bar']);
+	`)
+	require.NoError(t, err)
+
+	violation := func(t *testing.T, q string) *errs.ConstraintViolationError {
+		t.Helper()
+
+		err := db.Exec(q)
+		require.ErrorIs(t, err, errs.ErrDuplicateDocument)
+		require.True(t, errs.IsConstraintViolationError(err))
+
+		var cerr *errs.ConstraintViolationError
+		require.True(t, errors.As(err, &cerr))
+		return cerr
+	}
+
+	cerr := violation(t, "INSERT INTO test (a, b, c) VALUES (1, 2, 'y')")
+	require.Equal(t, "test", cerr.TableName)
+	require.Equal(t, "test_a_idx", cerr.IndexName)
+	require.Equal(t, "", cerr.Constraint)
+	require.Equal(t, []document.Path{document.NewPath("a")}, cerr.Paths)
+	require.Equal(t, []document.Value{document.NewIntegerValue(1)}, cerr.Values)
+	require.EqualError(t, cerr, `duplicate document: unique index "test_a_idx" violated`)
+
+	cerr = violation(t, "INSERT INTO test (a, b, c) VALUES (2, 1, 'x')")
+	require.Equal(t, "b_c_uniq", cerr.IndexName)
+	require.Equal(t, "b_c_uniq", cerr.Constraint)
+	require.Equal(t, []document.Path{document.NewPath("b"), document.NewPath("c")}, cerr.Paths)
+	require.Equal(t, []document.Value{document.NewIntegerValue(1), document.NewTextValue("x")}, cerr.Values)
+	require.EqualError(t, cerr, `duplicate document: unique constraint "b_c_uniq" violated`)
+
+	// multi-key indexes report the conflicting element
+	cerr = violation(t, "INSERT INTO test (a, b, c, tags) VALUES (3, 3, 'z', ['baz', 'bar'])")
+	require.Equal(t, "idx_tags", cerr.IndexName)
+	require.Equal(t, []document.Path{document.NewPath("tags")}, cerr.Paths)
+	require.Equal(t, []document.Value{document.NewTextValue("bar")}, cerr.Values)
+
+	// conflicts not matching the target of ON CONFLICT
+	cerr = violation(t, "INSERT INTO test (a, b, c) VALUES (4, 1, 'x') ON CONFLICT (a) DO NOTHING")
+	require.Equal(t, "b_c_uniq", cerr.IndexName)
+
+	// duplicates found when creating an index
+	err = db.Exec("INSERT INTO test (a, b, c) VALUES (5, 2, 'x')")
+	require.NoError(t, err)
+	cerr = violation(t, "CREATE UNIQUE INDEX idx_c ON test (c)")
+	require.Equal(t, "idx_c", cerr.IndexName)
+	require.Equal(t, []document.Value{document.NewTextValue("x")}, cerr.Values)
+
+	// primary keys are not unique indexes
+	err = db.Exec("CREATE TABLE pk (a INT PRIMARY KEY); INSERT INTO pk (a) VALUES (1)")
+	require.NoError(t, err)
+	err = db.Exec("INSERT INTO pk (a) VALUES (1)")
+	require.Equal(t, errs.ErrDuplicateDocument, err)
+	require.False(t, errs.IsConstraintViolationError(err))
+}
@@ -3,6 +3,8 @@ package document
 import (
 	"encoding/base64"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/genjidb/genji/internal/stringutil"
 )
@@ -25,6 +27,18 @@ func (v Value) CastAs(t ValueType) (Value, error) {
 		return v.CastAsInteger()
 	case DoubleValue:
 		return v.CastAsDouble()
+	case DecimalValue:
+		return v.CastAsDecimal()
+	case VectorValue:
+		return v.CastAsVector()
+	case DateValue:
+		return v.CastAsDate()
+	case TimestampValue:
+		return v.CastAsTimestamp()
+	case IntervalValue:
+		return v.CastAsInterval()
+	case UUIDValue:
+		return v.CastAsUUID()
 	case BlobValue:
 		return v.CastAsBlob()
 	case TextValue:
@@ -39,7 +53,7 @@ func (v Value) CastAs(t ValueType) (Value, error) {
 }
 
 // CastAsBool casts according to the following rules:
-// Integer: true if truthy, otherwise false.
+// Integer, Decimal: true if truthy, otherwise false.
 // Text: uses strconv.Parsebool to determine the boolean value,
 // it fails if the text doesn't contain a valid boolean.
 // Any other type is considered an invalid cast.
@@ -49,6 +63,8 @@ func (v Value) CastAsBool() (Value, error) {
 		return v, nil
 	case IntegerValue:
 		return NewBoolValue(v.V.(int64) != 0), nil
+	case DecimalValue:
+		return NewBoolValue(v.V.(Decimal).Sign() != 0), nil
 	case TextValue:
 		b, err := strconv.ParseBool(v.V.(string))
 		if err != nil {
@@ -63,6 +79,8 @@ func (v Value) CastAsBool() (Value, error) {
 // CastAsInteger casts according to the following rules:
 // Bool: returns 1 if true, 0 if false.
 // Double: cuts off the decimal and remaining numbers.
+// Decimal: cuts off the decimal and remaining numbers,
+// it fails if the result doesn't fit in an integer.
 // Text: uses strconv.ParseInt to determine the integer value,
 // then casts it to an integer. If it fails uses strconv.ParseFloat
 // to determine the double value, then casts it to an integer
@@ -79,6 +97,12 @@ func (v Value) CastAsInteger() (Value, error) {
 		return NewIntegerValue(0), nil
 	case DoubleValue:
 		return NewIntegerValue(int64(v.V.(float64))), nil
+	case DecimalValue:
+		i, ok := v.V.(Decimal).Int64()
+		if !ok {
+			return Value{}, stringutil.Errorf("cannot cast %s as integer: out of range", v.V.(Decimal))
+		}
+		return NewIntegerValue(i), nil
 	case TextValue:
 		i, err := strconv.ParseInt(v.V.(string), 10, 64)
 		if err != nil {
@@ -97,6 +121,7 @@ func (v Value) CastAsInteger() (Value, error) {
 
 // CastAsDouble casts according to the following rules:
 // Integer: returns a double version of the integer.
+// Decimal: returns the closest double.
 // Text: uses strconv.ParseFloat to determine the double value,
 // it fails if the text doesn't contain a valid float value.
 // Any other type is considered an invalid cast.
@@ -106,6 +131,8 @@ func (v Value) CastAsDouble() (Value, error) {
 		return v, nil
 	case IntegerValue:
 		return NewDoubleValue(float64(v.V.(int64))), nil
+	case DecimalValue:
+		return NewDoubleValue(v.V.(Decimal).Float64()), nil
 	case TextValue:
 		f, err := strconv.ParseFloat(v.V.(string), 64)
 		if err != nil {
@@ -117,8 +144,145 @@ func (v Value) CastAsDouble() (Value, error) {
 	return Value{}, stringutil.Errorf("cannot cast %s as double", v.Type)
 }
 
+// CastAsDecimal casts according to the following rules:
+// Integer: returns the same number.
+// Double: returns the shortest decimal representing the double,
+// it fails if the double is not finite.
+// Text: parses a decimal, using ParseDecimal, otherwise fails.
+// Any other type is considered an invalid cast.
+func (v Value) CastAsDecimal() (Value, error) {
+	switch v.Type {
+	case DecimalValue:
+		return v, nil
+	case IntegerValue:
+		return NewDecimalValue(NewDecimal(v.V.(int64), 0)), nil
+	case DoubleValue:
+		d, err := NewDecimalFromFloat(v.V.(float64))
+		if err != nil {
+			return Value{}, stringutil.Errorf(`cannot cast %v as decimal: %w`, v.V, err)
+		}
+		return NewDecimalValue(d), nil
+	case TextValue:
+		d, err := ParseDecimal(strings.TrimSpace(v.V.(string)))
+		if err != nil {
+			return Value{}, stringutil.Errorf(`cannot cast %q as decimal: %w`, v.V, err)
+		}
+		return NewDecimalValue(d), nil
+	}
+
+	return Value{}, stringutil.Errorf("cannot cast %s as decimal", v.Type)
+}
+
+// CastAsVector casts according to the following rules:
+// Array: converts each element to a double, it fails if any of
+// the elements is not a number.
+// Text: decodes a JSON array of numbers, otherwise fails.
+// Any other type is considered an invalid cast.
+func (v Value) CastAsVector() (Value, error) {
+	switch v.Type {
+	case VectorValue:
+		return v, nil
+	case ArrayValue:
+		return arrayToVector(v.V.(Array))
+	case TextValue:
+		var vb ValueBuffer
+		err := vb.UnmarshalJSON([]byte(v.V.(string)))
+		if err != nil {
+			return Value{}, stringutil.Errorf(`cannot cast %q as vector: %w`, v.V, err)
+		}
+
+		return arrayToVector(&vb)
+	}
+
+	return Value{}, stringutil.Errorf("cannot cast %s as vector", v.Type)
+}
+
+func arrayToVector(a Array) (Value, error) {
+	var vec []float64
+
+	err := a.Iterate(func(i int, elem Value) error {
+		if !elem.Type.IsNumber() {
+			return stringutil.Errorf("cannot cast array containing %s values as vector", elem.Type)
+		}
+
+		elem, _ = elem.CastAsDouble()
+		vec = append(vec, elem.V.(float64))
+		return nil
+	})
+	if err != nil {
+		return Value{}, err
+	}
+
+	if vec == nil {
+		vec = []float64{}
+	}
+
+	return NewVectorValue(vec), nil
+}
+
+// CastAsTimestamp casts according to the following rules:
+// Date: returns the midnight UTC of the date.
+// Text: parses an RFC 3339 timestamp, using ParseTimestamp, otherwise fails.
+// Any other type is considered an invalid cast.
+func (v Value) CastAsTimestamp() (Value, error) {
+	switch v.Type {
+	case TimestampValue:
+		return v, nil
+	case DateValue:
+		return NewTimestampValue(v.V.(time.Time)), nil
+	case TextValue:
+		t, err := ParseTimestamp(v.V.(string))
+		if err != nil {
+			return Value{}, stringutil.Errorf(`cannot cast %q as timestamp: %w`, v.V, err)
+		}
+		return NewTimestampValue(t), nil
+	}
+
+	return Value{}, stringutil.Errorf("cannot cast %s as timestamp", v.Type)
+}
+
+// CastAsDate casts according to the following rules:
+// Timestamp: returns the date of the timestamp, in UTC.
+// Text: parses a date or a timestamp, using ParseDate, otherwise fails.
+// Any other type is considered an invalid cast.
+func (v Value) CastAsDate() (Value, error) {
+	switch v.Type {
+	case DateValue:
+		return v, nil
+	case TimestampValue:
+		return NewDateValue(v.V.(time.Time)), nil
+	case TextValue:
+		t, err := ParseDate(v.V.(string))
+		if err != nil {
+			return Value{}, stringutil.Errorf(`cannot cast %q as date: %w`, v.V, err)
+		}
+		return NewDateValue(t), nil
+	}
+
+	return Value{}, stringutil.Errorf("cannot cast %s as date", v.Type)
+}
+
+// CastAsInterval casts according to the following rules:
+// Text: parses an interval, using ParseInterval, otherwise fails.
+// Any other type is considered an invalid cast.
+func (v Value) CastAsInterval() (Value, error) {
+	switch v.Type {
+	case IntervalValue:
+		return v, nil
+	case TextValue:
+		i, err := ParseInterval(v.V.(string))
+		if err != nil {
+			return Value{}, stringutil.Errorf(`cannot cast %q as interval: %w`, v.V, err)
+		}
+		return NewIntervalValue(i), nil
+	}
+
+	return Value{}, stringutil.Errorf("cannot cast %s as interval", v.Type)
+}
+
 // CastAsText returns a JSON representation of v.
-// If the representation is a string, it gets unquoted.
+// If the representation is a string, like for blobs, dates,
+// timestamps, intervals and uuids, it gets unquoted.
 func (v Value) CastAsText() (Value, error) {
 	if v.Type == TextValue {
 		return v, nil
@@ -131,7 +295,7 @@ func (v Value) CastAsText() (Value, error) {
 
 	s := string(d)
 
-	if v.Type == BlobValue {
+	if v.Type == BlobValue || v.Type.IsTime() || v.Type == IntervalValue || v.Type == UUIDValue {
 		s, err = strconv.Unquote(s)
 		if err != nil {
 			return Value{}, err
@@ -141,14 +305,47 @@ func (v Value) CastAsText() (Value, error) {
 	return NewTextValue(s), nil
 }
 
+// CastAsUUID casts according to the following rules:
+// Text: parses a UUID, using ParseUUID, otherwise fails.
+// Blob: returns the UUID made of the 16 bytes of the blob, otherwise fails.
+// Any other type is considered an invalid cast.
+func (v Value) CastAsUUID() (Value, error) {
+	switch v.Type {
+	case UUIDValue:
+		return v, nil
+	case TextValue:
+		u, err := ParseUUID(strings.TrimSpace(v.V.(string)))
+		if err != nil {
+			return Value{}, stringutil.Errorf(`cannot cast %q as uuid: %w`, v.V, err)
+		}
+		return NewUUIDValue(u), nil
+	case BlobValue:
+		b := v.V.([]byte)
+		if len(b) != len(UUID{}) {
+			return Value{}, stringutil.Errorf("cannot cast blob of %d bytes as uuid", len(b))
+		}
+		var u UUID
+		copy(u[:], b)
+		return NewUUIDValue(u), nil
+	}
+
+	return Value{}, stringutil.Errorf("cannot cast %s as uuid", v.Type)
+}
+
 // CastAsBlob casts according to the following rules:
 // Text: decodes a base64 string, otherwise fails.
+// UUID: returns the 16 bytes of the UUID.
 // Any other type is considered an invalid cast.
 func (v Value) CastAsBlob() (Value, error) {
 	if v.Type == BlobValue {
 		return v, nil
 	}
 
+	if v.Type == UUIDValue {
+		u := v.V.(UUID)
+		return NewBlobValue(u[:]), nil
+	}
+
 	if v.Type == TextValue {
 		b, err := base64.StdEncoding.DecodeString(v.V.(string))
 		if err != nil {
@@ -163,12 +360,23 @@ func (v Value) CastAsBlob() (Value, error) {
 
 // CastAsArray casts according to the following rules:
 // Text: decodes a JSON array, otherwise fails.
+// Vector: returns an array of doubles.
 // Any other type is considered an invalid cast.
 func (v Value) CastAsArray() (Value, error) {
 	if v.Type == ArrayValue {
 		return v, nil
 	}
 
+	if v.Type == VectorValue {
+		vec := v.V.([]float64)
+		vb := NewValueBuffer()
+		for _, x := range vec {
+			vb.Append(NewDoubleValue(x))
+		}
+
+		return NewArrayValue(vb), nil
+	}
+
 	if v.Type == TextValue {
 		var vb ValueBuffer
 		err := vb.UnmarshalJSON([]byte(v.V.(string)))
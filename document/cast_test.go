@@ -1,7 +1,9 @@
 package document
 
 import (
+	"math"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/require"
 )
@@ -89,6 +91,9 @@ func TestCastAs(t *testing.T) {
 			{doubleV, NewTextValue("10.5"), false},
 			{textV, textV, false},
 			{blobV, NewTextValue("YWJj"), false},
+			{NewDateValue(time.Date(2021, 1, 2, 0, 0, 0, 0, time.UTC)), NewTextValue("2021-01-02"), false},
+			{NewTimestampValue(time.Date(2021, 1, 2, 10, 30, 0, 0, time.UTC)), NewTextValue("2021-01-02T10:30:00Z"), false},
+			{NewIntervalValue(Interval{Days: 1, Nanos: int64(time.Hour)}), NewTextValue("1 day 01:00:00"), false},
 			{arrayV, NewTextValue(`["bar", 10]`), false},
 			{docV,
 				NewTextValue(`{"a": 10, "b": "foo"}`),
@@ -122,6 +127,124 @@ func TestCastAs(t *testing.T) {
 		})
 	})
 
+	t.Run("vector", func(t *testing.T) {
+		vectorV := NewVectorValue([]float64{1, 2.5})
+		check(t, VectorValue, []test{
+			{boolV, Value{}, true},
+			{integerV, Value{}, true},
+			{doubleV, Value{}, true},
+			{NewTextValue(`[1, 2.5]`), vectorV, false},
+			{NewTextValue("abc"), Value{}, true},
+			{blobV, Value{}, true},
+			{NewArrayValue(NewValueBuffer(NewIntegerValue(1), NewDoubleValue(2.5))), vectorV, false},
+			{arrayV, Value{}, true},
+			{docV, Value{}, true},
+			{vectorV, vectorV, false},
+		})
+	})
+
+	t.Run("timestamp", func(t *testing.T) {
+		tsV := NewTimestampValue(time.Date(2021, 1, 2, 10, 30, 0, 0, time.UTC))
+		check(t, TimestampValue, []test{
+			{boolV, Value{}, true},
+			{integerV, Value{}, true},
+			{doubleV, Value{}, true},
+			{NewTextValue("2021-01-02T12:30:00+02:00"), tsV, false},
+			{NewTextValue("2021-01-02 10:30:00"), tsV, false},
+			{NewTextValue("2021-01-02"), NewTimestampValue(time.Date(2021, 1, 2, 0, 0, 0, 0, time.UTC)), false},
+			{textV, Value{}, true},
+			{NewDateValue(time.Date(2021, 1, 2, 0, 0, 0, 0, time.UTC)), NewTimestampValue(time.Date(2021, 1, 2, 0, 0, 0, 0, time.UTC)), false},
+			{tsV, tsV, false},
+			{blobV, Value{}, true},
+			{arrayV, Value{}, true},
+			{docV, Value{}, true},
+		})
+	})
+
+	t.Run("date", func(t *testing.T) {
+		dateV := NewDateValue(time.Date(2021, 1, 2, 0, 0, 0, 0, time.UTC))
+		check(t, DateValue, []test{
+			{boolV, Value{}, true},
+			{integerV, Value{}, true},
+			{NewTextValue("2021-01-02"), dateV, false},
+			{NewTextValue("2021-01-02T23:30:00Z"), dateV, false},
+			{textV, Value{}, true},
+			{NewTimestampValue(time.Date(2021, 1, 2, 10, 30, 0, 0, time.UTC)), dateV, false},
+			{dateV, dateV, false},
+			{blobV, Value{}, true},
+			{arrayV, Value{}, true},
+		})
+	})
+
+	t.Run("decimal", func(t *testing.T) {
+		decimalV := NewDecimalValue(NewDecimal(1050, 2))
+		check(t, DecimalValue, []test{
+			{boolV, Value{}, true},
+			{integerV, NewDecimalValue(NewDecimal(10, 0)), false},
+			{doubleV, NewDecimalValue(NewDecimal(105, 1)), false},
+			{NewDoubleValue(math.Inf(1)), Value{}, true},
+			{NewTextValue("10.50"), decimalV, false},
+			{NewTextValue(" 10.50 "), decimalV, false},
+			{textV, Value{}, true},
+			{decimalV, decimalV, false},
+			{blobV, Value{}, true},
+			{arrayV, Value{}, true},
+		})
+
+		// casting decimals to other types
+		v, err := decimalV.CastAsInteger()
+		require.NoError(t, err)
+		require.Equal(t, integerV, v)
+		v, err = decimalV.CastAsDouble()
+		require.NoError(t, err)
+		require.Equal(t, doubleV, v)
+		v, err = decimalV.CastAsText()
+		require.NoError(t, err)
+		require.Equal(t, NewTextValue("10.50"), v)
+		_, err = NewDecimalValue(NewDecimal(1, -30)).CastAsInteger()
+		require.Error(t, err)
+	})
+
+	t.Run("uuid", func(t *testing.T) {
+		u := UUID{0x01, 0x8b, 0x2c, 0x3d, 0x4e, 0x5f, 0x70, 0x81, 0x92, 0xa3, 0xb4, 0xc5, 0xd6, 0xe7, 0xf8, 0x09}
+		uuidV := NewUUIDValue(u)
+		check(t, UUIDValue, []test{
+			{boolV, Value{}, true},
+			{integerV, Value{}, true},
+			{NewTextValue("018b2c3d-4e5f-7081-92a3-b4c5d6e7f809"), uuidV, false},
+			{NewTextValue(" {018B2C3D-4E5F-7081-92A3-B4C5D6E7F809} "), uuidV, false},
+			{NewTextValue("018b2c3d4e5f708192a3b4c5d6e7f809"), uuidV, false},
+			{textV, Value{}, true},
+			{NewBlobValue(u[:]), uuidV, false},
+			{blobV, Value{}, true},
+			{uuidV, uuidV, false},
+			{arrayV, Value{}, true},
+		})
+
+		// casting uuids to other types
+		v, err := uuidV.CastAsText()
+		require.NoError(t, err)
+		require.Equal(t, NewTextValue("018b2c3d-4e5f-7081-92a3-b4c5d6e7f809"), v)
+		v, err = uuidV.CastAsBlob()
+		require.NoError(t, err)
+		require.Equal(t, NewBlobValue(u[:]), v)
+	})
+
+	t.Run("interval", func(t *testing.T) {
+		intervalV := NewIntervalValue(Interval{Months: 14, Days: 3, Nanos: 4 * int64(time.Hour)})
+		check(t, IntervalValue, []test{
+			{boolV, Value{}, true},
+			{integerV, Value{}, true},
+			{NewTextValue("1 year 2 months 3 days 04:00:00"), intervalV, false},
+			{NewTextValue("1 year 2 mons 3 days 4 hours"), intervalV, false},
+			{textV, Value{}, true},
+			{intervalV, intervalV, false},
+			{NewDateValue(time.Date(2021, 1, 2, 0, 0, 0, 0, time.UTC)), Value{}, true},
+			{blobV, Value{}, true},
+			{arrayV, Value{}, true},
+		})
+	})
+
 	t.Run("document", func(t *testing.T) {
 		check(t, DocumentValue, []test{
 			{boolV, Value{}, true},
@@ -3,6 +3,7 @@ package document
 import (
 	"bytes"
 	"strings"
+	"time"
 )
 
 type operator uint8
@@ -85,14 +86,50 @@ func compare(op operator, l, r Value) (bool, error) {
 	case r.Type == BlobValue && l.Type == BlobValue:
 		return compareBlobs(op, l.V.([]byte), r.V.([]byte)), nil
 
+	// compare uuids together
+	case l.Type == UUIDValue && r.Type == UUIDValue:
+		lu, ru := l.V.(UUID), r.V.(UUID)
+		return compareBlobs(op, lu[:], ru[:]), nil
+
 	// compare integers together
 	case l.Type == IntegerValue && r.Type == IntegerValue:
 		return compareIntegers(op, l.V.(int64), r.V.(int64)), nil
 
+	// compare decimals with decimals and integers exactly
+	case l.Type.IsNumber() && r.Type.IsNumber() && l.Type != DoubleValue && r.Type != DoubleValue:
+		return compareDecimals(op, l, r), nil
+
 	// compare numbers together
 	case l.Type.IsNumber() && r.Type.IsNumber():
 		return compareNumbers(op, l, r), nil
 
+	// compare dates and timestamps together
+	case l.Type.IsTime() && r.Type.IsTime():
+		return compareTimes(op, l.V.(time.Time), r.V.(time.Time)), nil
+
+	// compare texts with dates and timestamps as times,
+	// to match times stored as texts before the timestamp type existed
+	case l.Type.IsTime() && r.Type == TextValue:
+		rt, err := ParseTimestamp(r.V.(string))
+		if err != nil {
+			return false, nil
+		}
+		return compareTimes(op, l.V.(time.Time), rt), nil
+	case l.Type == TextValue && r.Type.IsTime():
+		lt, err := ParseTimestamp(l.V.(string))
+		if err != nil {
+			return false, nil
+		}
+		return compareTimes(op, lt, r.V.(time.Time)), nil
+
+	// compare intervals together
+	case l.Type == IntervalValue && r.Type == IntervalValue:
+		return compareIntervals(op, l.V.(Interval), r.V.(Interval)), nil
+
+	// compare vectors together
+	case l.Type == VectorValue && r.Type == VectorValue:
+		return compareVectors(op, l.V.([]float64), r.V.([]float64)), nil
+
 	// compare arrays together
 	case l.Type == ArrayValue && r.Type == ArrayValue:
 		return compareArrays(op, l.V.(Array), r.V.(Array))
@@ -209,6 +246,91 @@ func compareNumbers(op operator, l, r Value) bool {
 	return ok
 }
 
+func compareDecimals(op operator, l, r Value) bool {
+	l, _ = l.CastAsDecimal()
+	r, _ = r.CastAsDecimal()
+
+	cmp := l.V.(Decimal).Cmp(r.V.(Decimal))
+
+	switch op {
+	case operatorEq:
+		return cmp == 0
+	case operatorGt:
+		return cmp > 0
+	case operatorGte:
+		return cmp >= 0
+	case operatorLt:
+		return cmp < 0
+	case operatorLte:
+		return cmp <= 0
+	}
+
+	return false
+}
+
+func compareTimes(op operator, l, r time.Time) bool {
+	switch op {
+	case operatorEq:
+		return l.Equal(r)
+	case operatorGt:
+		return l.After(r)
+	case operatorGte:
+		return !l.Before(r)
+	case operatorLt:
+		return l.Before(r)
+	case operatorLte:
+		return !l.After(r)
+	}
+
+	return false
+}
+
+// compareIntervals compares the normalized values of intervals, i.e. 1 month = 30 days.
+func compareIntervals(op operator, l, r Interval) bool {
+	ld, ln := l.normalize()
+	rd, rn := r.normalize()
+
+	if ld == rd {
+		return compareIntegers(op, ln, rn)
+	}
+
+	return compareIntegers(op, ld, rd)
+}
+
+// compareVectors compares vectors component by component.
+// If a vector is the prefix of the other, the shortest one is the smallest.
+func compareVectors(op operator, l, r []float64) bool {
+	for i := 0; i < len(l) && i < len(r); i++ {
+		if l[i] == r[i] {
+			continue
+		}
+
+		switch op {
+		case operatorEq:
+			return false
+		case operatorGt, operatorGte:
+			return l[i] > r[i]
+		case operatorLt, operatorLte:
+			return l[i] < r[i]
+		}
+	}
+
+	switch op {
+	case operatorEq:
+		return len(l) == len(r)
+	case operatorGt:
+		return len(l) > len(r)
+	case operatorGte:
+		return len(l) >= len(r)
+	case operatorLt:
+		return len(l) < len(r)
+	case operatorLte:
+		return len(l) <= len(r)
+	}
+
+	return false
+}
+
 func compareArrays(op operator, l Array, r Array) (bool, error) {
 	var i, j int
 
@@ -41,6 +41,34 @@ func toBlob(t testing.TB, x string) document.Value {
 	return document.NewBlobValue([]byte(x))
 }
 
+func toUUID(t testing.TB, x string) document.Value {
+	v, err := document.NewTextValue(x).CastAsUUID()
+	require.NoError(t, err)
+
+	return v
+}
+
+func toTimestamp(t testing.TB, x string) document.Value {
+	v, err := document.NewTextValue(x).CastAsTimestamp()
+	require.NoError(t, err)
+
+	return v
+}
+
+func toDecimal(t testing.TB, x string) document.Value {
+	v, err := document.NewTextValue(x).CastAsDecimal()
+	require.NoError(t, err)
+
+	return v
+}
+
+func toInterval(t testing.TB, x string) document.Value {
+	v, err := document.NewTextValue(x).CastAsInterval()
+	require.NoError(t, err)
+
+	return v
+}
+
 func jsonToArray(t testing.TB, x string) document.Value {
 	var vb document.ValueBuffer
 	err := json.Unmarshal([]byte(x), &vb)
@@ -154,6 +182,41 @@ func TestCompare(t *testing.T) {
 		{"<=", "a", "b", true, toBlob},
 		{"<=", "b", "b", true, toBlob},
 
+		// timestamp
+		{"=", "2021-01-01T10:00:00Z", "2021-01-01T12:00:00+02:00", true, toTimestamp},
+		{"=", "2021-01-01T10:00:00Z", "2021-01-01T10:00:00.5Z", false, toTimestamp},
+		{"!=", "2021-01-01T10:00:00Z", "2021-01-01T10:00:00.5Z", true, toTimestamp},
+		{">", "2021-01-01T10:00:00.5Z", "2021-01-01T10:00:00Z", true, toTimestamp},
+		{">", "1960-01-01T00:00:00Z", "2021-01-01T10:00:00Z", false, toTimestamp},
+		{">=", "2021-01-01T10:00:00Z", "2021-01-01T12:00:00+02:00", true, toTimestamp},
+		{"<", "1960-01-01T00:00:00Z", "2021-01-01T10:00:00Z", true, toTimestamp},
+		{"<", "2021-01-01T10:00:00Z", "2021-01-01T10:00:00Z", false, toTimestamp},
+		{"<=", "2021-01-01T10:00:00Z", "2021-01-01T10:00:00Z", true, toTimestamp},
+
+		// decimal
+		{"=", "1.50", "1.5", true, toDecimal},
+		{"!=", "0.1", "0.10000000000000000001", true, toDecimal},
+		{">", "0.10000000000000000001", "0.1", true, toDecimal},
+		{">", "-1", "-1.5", true, toDecimal},
+		{">=", "2", "2.0", true, toDecimal},
+		{"<", "-1.5", "1", true, toDecimal},
+		{"<=", "123456789012345678901234567890", "123456789012345678901234567891", true, toDecimal},
+
+		// uuid
+		{"=", "018b2c3d-4e5f-7081-92a3-b4c5d6e7f809", "018B2C3D4E5F708192A3B4C5D6E7F809", true, toUUID},
+		{"!=", "018b2c3d-4e5f-7081-92a3-b4c5d6e7f809", "018b2c3d-4e5f-7081-92a3-b4c5d6e7f80a", true, toUUID},
+		{">", "018b2c3e-0000-7000-8000-000000000000", "018b2c3d-ffff-7fff-bfff-ffffffffffff", true, toUUID},
+		{"<", "00000000-0000-0000-0000-000000000000", "018b2c3d-4e5f-7081-92a3-b4c5d6e7f809", true, toUUID},
+
+		// interval
+		{"=", "1 month", "30 days", true, toInterval},
+		{"=", "1 day", "24 hours", true, toInterval},
+		{"!=", "1 day", "1 day 00:00:01", true, toInterval},
+		{">", "1 year", "11 months", true, toInterval},
+		{">", "-1 day", "1 hour", false, toInterval},
+		{"<", "-1 day", "1 hour", true, toInterval},
+		{"<=", "2 weeks", "14 days", true, toInterval},
+
 		// array
 		{"=", `[]`, `[]`, true, jsonToArray},
 		{"=", `[1]`, `[1]`, true, jsonToArray},
@@ -250,3 +313,58 @@ func TestCompare(t *testing.T) {
 		})
 	}
 }
+
+func TestCompareTimesWithTexts(t *testing.T) {
+	ts := toTimestamp(t, "2021-01-01T10:00:00Z")
+
+	tests := []struct {
+		op   string
+		text string
+		ok   bool
+	}{
+		{"=", "2021-01-01T10:00:00Z", true},
+		{"=", "2021-01-01T12:00:00+02:00", true},
+		{"=", "2021-01-01T10:00:00.5Z", false},
+		{"!=", "2021-01-01T10:00:00.5Z", true},
+		{">", "1960-01-01T00:00:00Z", true},
+		{"<=", "2021-01-01 10:00:00", true},
+		{"<", "2021-01-01T10:00:00Z", false},
+		// texts that are not times are never equal to times
+		{"=", "foo", false},
+		{">", "foo", false},
+	}
+
+	for _, test := range tests {
+		t.Run(fmt.Sprintf("%s %s %q", ts, test.op, test.text), func(t *testing.T) {
+			text := document.NewTextValue(test.text)
+
+			var ok, rok bool
+			var err error
+			switch test.op {
+			case "=":
+				ok, err = ts.IsEqual(text)
+				require.NoError(t, err)
+				rok, err = text.IsEqual(ts)
+			case "!=":
+				ok, err = ts.IsNotEqual(text)
+				require.NoError(t, err)
+				rok, err = text.IsNotEqual(ts)
+			case ">":
+				ok, err = ts.IsGreaterThan(text)
+				require.NoError(t, err)
+				rok, err = text.IsLesserThan(ts)
+			case "<":
+				ok, err = ts.IsLesserThan(text)
+				require.NoError(t, err)
+				rok, err = text.IsGreaterThan(ts)
+			case "<=":
+				ok, err = ts.IsLesserThanOrEqual(text)
+				require.NoError(t, err)
+				rok, err = text.IsGreaterThanOrEqual(ts)
+			}
+			require.NoError(t, err)
+			require.Equal(t, test.ok, ok)
+			require.Equal(t, test.ok, rok)
+		})
+	}
+}
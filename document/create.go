@@ -6,7 +6,6 @@ import (
 	"errors"
 	"math"
 	"reflect"
-	"strings"
 	"time"
 
 	"github.com/buger/jsonparser"
@@ -99,6 +98,9 @@ func (m mapDocument) MarshalJSON() ([]byte, error) {
 }
 
 // NewFromStruct creates a document from a struct using reflection.
+// Each exported field is mapped to a document field as described by StructField.
+// Nil pointers are skipped, and fields implementing Marshaler are converted using
+// their MarshalValue method.
 func NewFromStruct(s interface{}) (Document, error) {
 	ref := reflect.Indirect(reflect.ValueOf(s))
 
@@ -111,61 +113,37 @@ func NewFromStruct(s interface{}) (Document, error) {
 
 func newFromStruct(ref reflect.Value) (Document, error) {
 	var fb FieldBuffer
-	l := ref.NumField()
-	tp := ref.Type()
 
-	for i := 0; i < l; i++ {
-		f := ref.Field(i)
-		if !f.IsValid() {
+	for _, sf := range StructFields(ref.Type()) {
+		f, ok := fieldByIndex(ref, sf.Index, false)
+		if !ok || !f.IsValid() {
 			continue
 		}
 
-		if f.Kind() == reflect.Ptr {
-			if f.IsNil() {
-				continue
-			}
-
-			f = f.Elem()
+		if sf.OmitEmpty && f.IsZero() {
+			continue
 		}
 
-		sf := tp.Field(i)
-
-		isUnexported := sf.PkgPath != ""
-
-		if sf.Anonymous {
-			if isUnexported && f.Kind() != reflect.Struct {
-				continue
-			}
-			d, err := newFromStruct(f)
-			if err != nil {
-				return nil, err
-			}
-			err = d.Iterate(func(field string, value Value) error {
-				fb.Add(field, value)
-				return nil
-			})
-			if err != nil {
-				return nil, err
-			}
-			continue
-		} else if isUnexported {
+		// nil pointers are skipped
+		if f.Kind() == reflect.Ptr && f.IsNil() {
 			continue
 		}
 
-		v, err := NewValue(f.Interface())
+		v, ok, err := marshalValue(f)
 		if err != nil {
 			return nil, err
 		}
+		if ok {
+			fb.Add(sf.Name, v)
+			continue
+		}
 
-		field := strings.ToLower(sf.Name)
-		if gtag, ok := sf.Tag.Lookup("genji"); ok {
-			if gtag == "-" {
-				continue
-			}
-			field = gtag
+		v, err = NewValue(reflect.Indirect(f).Interface())
+		if err != nil {
+			return nil, err
 		}
 
-		fb.Add(field, v)
+		fb.Add(sf.Name, v)
 	}
 
 	return &fb, nil
@@ -175,10 +153,23 @@ func newFromStruct(ref reflect.Value) (Document, error) {
 func NewValue(x interface{}) (Value, error) {
 	// Attempt exact matches first:
 	switch v := x.(type) {
+	case Value:
+		return v, nil
+	case Marshaler:
+		if ref := reflect.ValueOf(v); ref.Kind() == reflect.Ptr && ref.IsNil() {
+			return NewNullValue(), nil
+		}
+		return v.MarshalValue()
 	case time.Duration:
 		return NewIntegerValue(v.Nanoseconds()), nil
 	case time.Time:
-		return NewTextValue(v.Format(time.RFC3339Nano)), nil
+		return NewTimestampValue(v), nil
+	case Interval:
+		return NewIntervalValue(v), nil
+	case Decimal:
+		return NewDecimalValue(v), nil
+	case UUID:
+		return NewUUIDValue(v), nil
 	case nil:
 		return NewNullValue(), nil
 	case Document:
@@ -0,0 +1,407 @@
+package document
+
+import (
+	"errors"
+	"math"
+	"math/big"
+	"strconv"
+	"strings"
+
+	"github.com/genjidb/genji/internal/binarysort"
+	"github.com/genjidb/genji/internal/stringutil"
+)
+
+// maxDecimalScale is the maximum number of digits after the decimal point,
+// and the maximum number of zeros added by a positive exponent.
+const maxDecimalScale = 16383
+
+// decimalDivisionScale is the minimum number of digits after the decimal point
+// of the result of a division, unless the result is exact with fewer digits.
+const decimalDivisionScale = 16
+
+// Decimal is an arbitrary-precision decimal number, equal to
+// an unscaled integer multiplied by 10^-scale.
+// The scale is kept as given so that numbers are displayed with
+// the same number of digits after the point, i.e. 1.50, but numbers that only
+// differ by trailing zeros are equal.
+// A Decimal must not be modified once created, the zero value is 0.
+type Decimal struct {
+	unscaled *big.Int
+	scale    int32
+}
+
+// NewDecimal returns the decimal equal to unscaled × 10^-scale.
+// A negative scale multiplies unscaled by a power of 10.
+func NewDecimal(unscaled int64, scale int32) Decimal {
+	u := big.NewInt(unscaled)
+	if scale < 0 {
+		u.Mul(u, pow10(int64(-scale)))
+		scale = 0
+	}
+
+	return Decimal{unscaled: u, scale: scale}
+}
+
+// NewDecimalFromFloat returns the shortest decimal representing f.
+func NewDecimalFromFloat(f float64) (Decimal, error) {
+	if math.IsNaN(f) || math.IsInf(f, 0) {
+		return Decimal{}, stringutil.Errorf("cannot represent %v as a decimal", f)
+	}
+
+	return ParseDecimal(strconv.FormatFloat(f, 'f', -1, 64))
+}
+
+// ParseDecimal parses a decimal number, with an optional sign and exponent, i.e. -1.5 or 15e-1.
+func ParseDecimal(s string) (Decimal, error) {
+	orig := s
+
+	var exp int64
+	if i := strings.IndexAny(s, "eE"); i >= 0 {
+		var err error
+		exp, err = strconv.ParseInt(s[i+1:], 10, 32)
+		if err != nil {
+			return Decimal{}, stringutil.Errorf("invalid decimal %q", orig)
+		}
+		s = s[:i]
+	}
+
+	var neg bool
+	if s != "" && (s[0] == '+' || s[0] == '-') {
+		neg = s[0] == '-'
+		s = s[1:]
+	}
+
+	intPart, fracPart := s, ""
+	if i := strings.IndexByte(s, '.'); i >= 0 {
+		intPart, fracPart = s[:i], s[i+1:]
+	}
+	if intPart == "" && fracPart == "" {
+		return Decimal{}, stringutil.Errorf("invalid decimal %q", orig)
+	}
+
+	digits := intPart + fracPart
+	for i := 0; i < len(digits); i++ {
+		if digits[i] < '0' || digits[i] > '9' {
+			return Decimal{}, stringutil.Errorf("invalid decimal %q", orig)
+		}
+	}
+
+	scale := int64(len(fracPart)) - exp
+	if scale > maxDecimalScale || scale < -maxDecimalScale {
+		return Decimal{}, stringutil.Errorf("decimal %q out of range", orig)
+	}
+
+	u, _ := new(big.Int).SetString(digits, 10)
+	if scale < 0 {
+		u.Mul(u, pow10(-scale))
+		scale = 0
+	}
+	if neg {
+		u.Neg(u)
+	}
+
+	return Decimal{unscaled: u, scale: int32(scale)}, nil
+}
+
+func pow10(n int64) *big.Int {
+	return new(big.Int).Exp(big.NewInt(10), big.NewInt(n), nil)
+}
+
+func (d Decimal) coef() *big.Int {
+	if d.unscaled == nil {
+		return new(big.Int)
+	}
+
+	return d.unscaled
+}
+
+// Scale returns the number of digits after the decimal point.
+func (d Decimal) Scale() int {
+	return int(d.scale)
+}
+
+// Precision returns the number of digits of d, including the digits after the decimal point.
+func (d Decimal) Precision() int {
+	c := d.coef()
+	if c.Sign() < 0 {
+		return len(c.String()) - 1
+	}
+
+	return len(c.String())
+}
+
+// Sign returns -1 if d is negative, 0 if d is zero and +1 if d is positive.
+func (d Decimal) Sign() int {
+	return d.coef().Sign()
+}
+
+// String returns the decimal representation of d, without exponent.
+func (d Decimal) String() string {
+	c := d.coef()
+	s := new(big.Int).Abs(c).String()
+
+	if n := int(d.scale); n > 0 {
+		if len(s) <= n {
+			s = strings.Repeat("0", n-len(s)+1) + s
+		}
+		s = s[:len(s)-n] + "." + s[len(s)-n:]
+	}
+
+	if c.Sign() < 0 {
+		s = "-" + s
+	}
+
+	return s
+}
+
+// Float64 returns the double closest to d.
+func (d Decimal) Float64() float64 {
+	f, _ := strconv.ParseFloat(d.String(), 64)
+	return f
+}
+
+// Int64 returns the integer part of d. It returns false if it doesn't fit in an int64.
+func (d Decimal) Int64() (int64, bool) {
+	q := new(big.Int).Quo(d.coef(), pow10(int64(d.scale)))
+	if !q.IsInt64() {
+		return 0, false
+	}
+
+	return q.Int64(), true
+}
+
+// Floor returns the greatest integer lower than or equal to d, as a decimal.
+func (d Decimal) Floor() Decimal {
+	// Div rounds towards negative infinity for positive divisors
+	return Decimal{unscaled: new(big.Int).Div(d.coef(), pow10(int64(d.scale)))}
+}
+
+// Rescale returns d with the given number of digits after the decimal point.
+// If some digits are removed, the result is rounded half away from zero.
+func (d Decimal) Rescale(scale int) Decimal {
+	if scale < 0 {
+		scale = 0
+	}
+
+	if int32(scale) >= d.scale {
+		return Decimal{
+			unscaled: new(big.Int).Mul(d.coef(), pow10(int64(scale)-int64(d.scale))),
+			scale:    int32(scale),
+		}
+	}
+
+	return Decimal{
+		unscaled: quoRound(d.coef(), pow10(int64(d.scale)-int64(scale))),
+		scale:    int32(scale),
+	}
+}
+
+// quoRound returns a / b rounded half away from zero.
+func quoRound(a, b *big.Int) *big.Int {
+	q, r := new(big.Int).QuoRem(a, b, new(big.Int))
+	if r.Sign() == 0 {
+		return q
+	}
+
+	r.Abs(r).Lsh(r, 1)
+	if r.CmpAbs(b) >= 0 {
+		if a.Sign() == b.Sign() {
+			q.Add(q, big.NewInt(1))
+		} else {
+			q.Sub(q, big.NewInt(1))
+		}
+	}
+
+	return q
+}
+
+// align returns the unscaled values of d and other with the same scale.
+func (d Decimal) align(other Decimal) (a, b *big.Int, scale int32) {
+	a, b = d.coef(), other.coef()
+
+	switch {
+	case d.scale < other.scale:
+		a = new(big.Int).Mul(a, pow10(int64(other.scale-d.scale)))
+		return a, b, other.scale
+	case d.scale > other.scale:
+		b = new(big.Int).Mul(b, pow10(int64(d.scale-other.scale)))
+	}
+
+	return a, b, d.scale
+}
+
+// Cmp compares d and other and returns -1 if d < other, 0 if d == other and +1 if d > other.
+func (d Decimal) Cmp(other Decimal) int {
+	a, b, _ := d.align(other)
+	return a.Cmp(b)
+}
+
+// Add returns d + other.
+func (d Decimal) Add(other Decimal) Decimal {
+	a, b, scale := d.align(other)
+	return Decimal{unscaled: new(big.Int).Add(a, b), scale: scale}
+}
+
+// Sub returns d - other.
+func (d Decimal) Sub(other Decimal) Decimal {
+	a, b, scale := d.align(other)
+	return Decimal{unscaled: new(big.Int).Sub(a, b), scale: scale}
+}
+
+// Mul returns d × other.
+func (d Decimal) Mul(other Decimal) Decimal {
+	return Decimal{unscaled: new(big.Int).Mul(d.coef(), other.coef()), scale: d.scale + other.scale}
+}
+
+// Quo returns d / other, rounded half away from zero to at least 16 digits after the decimal point,
+// or the scale of the operands if it is greater. Trailing zeros beyond the scale of the operands are removed.
+// It returns false if other is zero.
+func (d Decimal) Quo(other Decimal) (Decimal, bool) {
+	if other.Sign() == 0 {
+		return Decimal{}, false
+	}
+
+	minScale := d.scale
+	if other.scale > minScale {
+		minScale = other.scale
+	}
+	scale := minScale
+	if scale < decimalDivisionScale {
+		scale = decimalDivisionScale
+	}
+
+	// d / other × 10^scale = d.unscaled × 10^(scale - d.scale + other.scale) / other.unscaled
+	a := new(big.Int).Mul(d.coef(), pow10(int64(scale)-int64(d.scale)+int64(other.scale)))
+	q := quoRound(a, other.coef())
+
+	ten, r := big.NewInt(10), new(big.Int)
+	for scale > minScale {
+		nq, _ := new(big.Int).QuoRem(q, ten, r)
+		if r.Sign() != 0 {
+			break
+		}
+		q = nq
+		scale--
+	}
+
+	return Decimal{unscaled: q, scale: scale}, true
+}
+
+// Rem returns the remainder of d / other, which has the sign of d.
+// It returns false if other is zero.
+func (d Decimal) Rem(other Decimal) (Decimal, bool) {
+	if other.Sign() == 0 {
+		return Decimal{}, false
+	}
+
+	a, b, scale := d.align(other)
+	return Decimal{unscaled: new(big.Int).Rem(a, b), scale: scale}, true
+}
+
+// AppendDecimal appends the binary representation of d used to store decimals:
+// the scale, the sign and the absolute value of the unscaled integer.
+func AppendDecimal(buf []byte, d Decimal) []byte {
+	buf = binarysort.AppendInt64(buf, int64(d.scale))
+	buf = binarysort.AppendBool(buf, d.Sign() < 0)
+	return append(buf, d.coef().Bytes()...)
+}
+
+// DecodeDecimal decodes a decimal encoded with AppendDecimal.
+func DecodeDecimal(buf []byte) (Decimal, error) {
+	if len(buf) < 9 {
+		return Decimal{}, errors.New("cannot decode buffer to decimal")
+	}
+
+	scale, err := binarysort.DecodeInt64(buf)
+	if err != nil {
+		return Decimal{}, err
+	}
+	neg, err := binarysort.DecodeBool(buf[8:])
+	if err != nil {
+		return Decimal{}, err
+	}
+
+	u := new(big.Int).SetBytes(buf[9:])
+	if neg {
+		u.Neg(u)
+	}
+
+	return Decimal{unscaled: u, scale: int32(scale)}, nil
+}
+
+// appendDecimalKey appends a representation of d which preserves the order of decimals.
+// Numbers that only differ by trailing zeros have the same representation.
+// Non-zero numbers are written as 0.<digits> × 10^exponent: the exponent is
+// encoded first, followed by the significant digits and a terminator,
+// and both are inverted for negative numbers.
+func appendDecimalKey(buf []byte, d Decimal) []byte {
+	c := d.coef()
+	if c.Sign() == 0 {
+		return append(buf, 0x01)
+	}
+
+	digits := new(big.Int).Abs(c).String()
+	exp := int64(len(digits)) - int64(d.scale)
+	digits = strings.TrimRight(digits, "0")
+
+	if c.Sign() > 0 {
+		buf = append(buf, 0x02)
+		buf = binarysort.AppendInt64(buf, exp)
+		buf = append(buf, digits...)
+		// the terminator sorts before any digit, so that 0.12 < 0.123
+		return append(buf, 0x20)
+	}
+
+	buf = append(buf, 0x00)
+	buf = binarysort.AppendInt64(buf, -exp)
+	for i := 0; i < len(digits); i++ {
+		buf = append(buf, ^digits[i])
+	}
+	// the terminator sorts after any inverted digit, so that -0.123 < -0.12
+	return append(buf, 0xFF)
+}
+
+// calculateDecimals computes exact results for integers and decimals.
+// Bitwise operators are applied to the integer parts of the operands.
+func calculateDecimals(a, b Value, operator byte) (Value, error) {
+	da, err := a.CastAsDecimal()
+	if err != nil {
+		return NewNullValue(), nil
+	}
+	xa := da.V.(Decimal)
+
+	db, err := b.CastAsDecimal()
+	if err != nil {
+		return NewNullValue(), nil
+	}
+	xb := db.V.(Decimal)
+
+	switch operator {
+	case '+':
+		return NewDecimalValue(xa.Add(xb)), nil
+	case '-':
+		return NewDecimalValue(xa.Sub(xb)), nil
+	case '*':
+		return NewDecimalValue(xa.Mul(xb)), nil
+	case '/':
+		q, ok := xa.Quo(xb)
+		if !ok {
+			return NewNullValue(), nil
+		}
+		return NewDecimalValue(q), nil
+	case '%':
+		r, ok := xa.Rem(xb)
+		if !ok {
+			return NewNullValue(), nil
+		}
+		return NewDecimalValue(r), nil
+	}
+
+	ia, oka := xa.Int64()
+	ib, okb := xb.Int64()
+	if !oka || !okb {
+		return NewNullValue(), nil
+	}
+
+	return calculateIntegers(NewIntegerValue(ia), NewIntegerValue(ib), operator)
+}
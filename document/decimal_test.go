@@ -0,0 +1,160 @@
+package document_test
+
+import (
+	"bytes"
+	"sort"
+	"testing"
+
+	"github.com/genjidb/genji/document"
+	"github.com/stretchr/testify/require"
+)
+
+func parseDecimal(t testing.TB, s string) document.Decimal {
+	t.Helper()
+
+	d, err := document.ParseDecimal(s)
+	require.NoError(t, err)
+	return d
+}
+
+func TestParseDecimal(t *testing.T) {
+	tests := []struct {
+		s     string
+		str   string
+		fails bool
+	}{
+		{"0", "0", false},
+		{"10.50", "10.50", false},
+		{"-0.001", "-0.001", false},
+		{"+.5", "0.5", false},
+		{"5.", "5", false},
+		{"1.5e3", "1500", false},
+		{"15E-3", "0.015", false},
+		{"123456789012345678901234567890.123456789", "123456789012345678901234567890.123456789", false},
+		{"", "", true},
+		{".", "", true},
+		{"-", "", true},
+		{"1.2.3", "", true},
+		{"1e", "", true},
+		{"0x10", "", true},
+		{"1e100000", "", true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.s, func(t *testing.T) {
+			d, err := document.ParseDecimal(test.s)
+			if test.fails {
+				require.Error(t, err)
+				return
+			}
+
+			require.NoError(t, err)
+			require.Equal(t, test.str, d.String())
+		})
+	}
+}
+
+func TestDecimalArithmetic(t *testing.T) {
+	tests := []struct {
+		a, op, b string
+		expected string
+	}{
+		{"0.1", "+", "0.2", "0.3"},
+		{"10.50", "+", "1", "11.50"},
+		{"1", "-", "1.005", "-0.005"},
+		{"1.5", "*", "-2.25", "-3.375"},
+		{"1", "/", "3", "0.3333333333333333"},
+		{"2", "/", "3", "0.6666666666666667"},
+		{"-2", "/", "3", "-0.6666666666666667"},
+		{"10.00", "/", "4", "2.50"},
+		{"1", "/", "8", "0.125"},
+		{"7.5", "%", "2", "1.5"},
+		{"-7.5", "%", "2", "-1.5"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.a+test.op+test.b, func(t *testing.T) {
+			a, b := parseDecimal(t, test.a), parseDecimal(t, test.b)
+
+			var res document.Decimal
+			ok := true
+			switch test.op {
+			case "+":
+				res = a.Add(b)
+			case "-":
+				res = a.Sub(b)
+			case "*":
+				res = a.Mul(b)
+			case "/":
+				res, ok = a.Quo(b)
+			case "%":
+				res, ok = a.Rem(b)
+			}
+			require.True(t, ok)
+			require.Equal(t, test.expected, res.String())
+		})
+	}
+
+	t.Run("division by zero", func(t *testing.T) {
+		_, ok := parseDecimal(t, "1").Quo(parseDecimal(t, "0.00"))
+		require.False(t, ok)
+		_, ok = parseDecimal(t, "1").Rem(parseDecimal(t, "0"))
+		require.False(t, ok)
+	})
+}
+
+func TestDecimalRescale(t *testing.T) {
+	tests := []struct {
+		s        string
+		scale    int
+		expected string
+	}{
+		{"1.5", 2, "1.50"},
+		{"1.005", 2, "1.01"},
+		{"1.004", 2, "1.00"},
+		{"-1.005", 2, "-1.01"},
+		{"2.5", 0, "3"},
+		{"-2.5", 0, "-3"},
+		{"0.0049", 2, "0.00"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.s, func(t *testing.T) {
+			require.Equal(t, test.expected, parseDecimal(t, test.s).Rescale(test.scale).String())
+		})
+	}
+
+	require.Equal(t, "-2", parseDecimal(t, "-1.5").Floor().String())
+	require.Equal(t, "1", parseDecimal(t, "1.5").Floor().String())
+	require.Equal(t, 5, parseDecimal(t, "-123.45").Precision())
+}
+
+func TestDecimalKeyOrder(t *testing.T) {
+	values := []string{"-1000", "-10.5", "-10.05", "-1", "-0.5", "-0.05", "0", "0.001", "0.01", "0.1", "0.12", "0.123", "1", "9.99", "10", "10.5", "1e20"}
+
+	var keys [][]byte
+	for _, s := range values {
+		k, err := document.NewDecimalValue(parseDecimal(t, s)).MarshalBinary()
+		require.NoError(t, err)
+		keys = append(keys, k)
+	}
+
+	require.True(t, sort.SliceIsSorted(keys, func(i, j int) bool {
+		return bytes.Compare(keys[i], keys[j]) < 0
+	}))
+
+	// trailing zeros don't change the key
+	a, err := document.NewDecimalValue(parseDecimal(t, "1.5")).MarshalBinary()
+	require.NoError(t, err)
+	b, err := document.NewDecimalValue(parseDecimal(t, "1.500")).MarshalBinary()
+	require.NoError(t, err)
+	require.Equal(t, a, b)
+}
+
+func TestDecimalEncoding(t *testing.T) {
+	for _, s := range []string{"0", "10.50", "-0.001", "123456789012345678901234567890.5"} {
+		d, err := document.DecodeDecimal(document.AppendDecimal(nil, parseDecimal(t, s)))
+		require.NoError(t, err)
+		require.Equal(t, s, d.String())
+	}
+}
@@ -419,7 +419,7 @@ func TestNewFromStruct(t *testing.T) {
 			case 25:
 				require.EqualValues(t, document.IntegerValue, v.Type)
 			case 26:
-				require.EqualValues(t, document.TextValue, v.Type)
+				require.EqualValues(t, document.TimestampValue, v.Type)
 			default:
 				require.FailNowf(t, "", "unknown field %q", f)
 			}
@@ -34,3 +34,39 @@ type Decoder interface {
 
 	Reset([]byte)
 }
+
+// A PathsDecoder decodes the values found at several paths of a document
+// in one pass, skipping the encoded values of the other fields.
+type PathsDecoder interface {
+	// DecodePaths passes to fn the values found at the given paths, along with the position
+	// of their path. Paths that don't exist in the document are ignored.
+	// The values are not passed in any particular order.
+	DecodePaths(paths []document.Path, fn func(i int, v document.Value) error) error
+}
+
+// DecodePaths passes to fn the values found at the given paths of the document,
+// along with the position of their path. If d implements the PathsDecoder interface,
+// the values are decoded in one pass, otherwise each path is read separately.
+// Paths that don't exist in the document are ignored.
+func DecodePaths(d document.Document, paths []document.Path, fn func(i int, v document.Value) error) error {
+	if pd, ok := d.(PathsDecoder); ok {
+		return pd.DecodePaths(paths, fn)
+	}
+
+	for i, p := range paths {
+		v, err := p.GetValueFromDocument(d)
+		if err == document.ErrFieldNotFound {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+
+		err = fn(i, v)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
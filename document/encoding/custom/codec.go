@@ -5,6 +5,7 @@ import (
 	"encoding/binary"
 	"errors"
 	"io"
+	"time"
 
 	"github.com/genjidb/genji/document"
 	"github.com/genjidb/genji/document/encoding"
@@ -130,6 +131,17 @@ func EncodeValue(v document.Value) ([]byte, error) {
 		return encodeInt64(v.V.(int64)), nil
 	case document.DoubleValue:
 		return binarysort.AppendFloat64(nil, v.V.(float64)), nil
+	case document.VectorValue:
+		return encodeVector(v.V.([]float64)), nil
+	case document.DateValue, document.TimestampValue:
+		return binarysort.AppendTime(nil, v.V.(time.Time)), nil
+	case document.IntervalValue:
+		return document.AppendInterval(nil, v.V.(document.Interval)), nil
+	case document.DecimalValue:
+		return document.AppendDecimal(nil, v.V.(document.Decimal)), nil
+	case document.UUIDValue:
+		u := v.V.(document.UUID)
+		return u[:], nil
 	case document.NullValue:
 		return nil, nil
 	}
@@ -137,6 +149,31 @@ func EncodeValue(v document.Value) ([]byte, error) {
 	return nil, errors.New("unknown type")
 }
 
+func encodeVector(vec []float64) []byte {
+	buf := make([]byte, 0, len(vec)*8)
+	for _, x := range vec {
+		buf = binarysort.AppendFloat64(buf, x)
+	}
+	return buf
+}
+
+func decodeVector(data []byte) ([]float64, error) {
+	if len(data)%8 != 0 {
+		return nil, errors.New("cannot decode vector")
+	}
+
+	vec := make([]float64, len(data)/8)
+	for i := range vec {
+		x, err := binarysort.DecodeFloat64(data[i*8 : i*8+8])
+		if err != nil {
+			return nil, err
+		}
+		vec[i] = x
+	}
+
+	return vec, nil
+}
+
 func encodeInt64(x int64) []byte {
 	buf := make([]byte, binary.MaxVarintLen64)
 	n := binary.PutVarint(buf, x)
@@ -344,6 +381,43 @@ func DecodeValue(t document.ValueType, data []byte) (document.Value, error) {
 			return document.Value{}, err
 		}
 		return document.NewDoubleValue(x), nil
+	case document.VectorValue:
+		x, err := decodeVector(data)
+		if err != nil {
+			return document.Value{}, err
+		}
+		return document.NewVectorValue(x), nil
+	case document.DateValue:
+		x, err := binarysort.DecodeTime(data)
+		if err != nil {
+			return document.Value{}, err
+		}
+		return document.NewDateValue(x), nil
+	case document.TimestampValue:
+		x, err := binarysort.DecodeTime(data)
+		if err != nil {
+			return document.Value{}, err
+		}
+		return document.NewTimestampValue(x), nil
+	case document.IntervalValue:
+		x, err := document.DecodeInterval(data)
+		if err != nil {
+			return document.Value{}, err
+		}
+		return document.NewIntervalValue(x), nil
+	case document.DecimalValue:
+		x, err := document.DecodeDecimal(data)
+		if err != nil {
+			return document.Value{}, err
+		}
+		return document.NewDecimalValue(x), nil
+	case document.UUIDValue:
+		var u document.UUID
+		if len(data) != len(u) {
+			return document.Value{}, errors.New("cannot decode uuid")
+		}
+		copy(u[:], data)
+		return document.NewUUIDValue(u), nil
 	case document.NullValue:
 		return document.NewNullValue(), nil
 	}
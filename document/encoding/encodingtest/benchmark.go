@@ -1,3 +1,5 @@
+// +build !wasm
+
 package encodingtest
 
 import (
@@ -20,6 +22,7 @@ func BenchmarkCodec(b *testing.B, codecBuilder func() encoding.Codec) {
 		{"Codec/Encode", benchmarkEncodeDocument},
 		{"Codec/Decode", benchmarkDecodeDocument},
 		{"Codec/Document/GetByField", benchmarkDocumentGetByField},
+		{"Codec/Document/DecodePaths", benchmarkDocumentDecodePaths},
 		{"Codec/Document/Iterate", benchmarkDocumentIterate},
 		{"ComparedWithJSON/Encode", benchmarkEncodeDocumentJSON},
 		{"ComparedWithJSON/Decode", benchmarkDecodeDocumentJSON},
@@ -68,6 +71,32 @@ func benchmarkDocumentGetByField(b *testing.B, codecBuilder func() encoding.Code
 	}
 }
 
+func benchmarkDocumentDecodePaths(b *testing.B, codecBuilder func() encoding.Codec) {
+	var fb document.FieldBuffer
+
+	for i := int64(0); i < 100; i++ {
+		fb.Add(stringutil.Sprintf("name-%d", i), document.NewIntegerValue(i))
+	}
+
+	codec := codecBuilder()
+	var buf bytes.Buffer
+	err := codec.NewEncoder(&buf).EncodeDocument(&fb)
+	require.NoError(b, err)
+
+	paths := []document.Path{
+		document.NewPath("name-10"),
+		document.NewPath("name-50"),
+		document.NewPath("name-99"),
+	}
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		encoding.DecodePaths(codec.NewDecoder(buf.Bytes()), paths, func(i int, v document.Value) error {
+			return nil
+		})
+	}
+}
+
 func benchmarkDocumentIterate(b *testing.B, codecBuilder func() encoding.Codec) {
 	var fb document.FieldBuffer
 
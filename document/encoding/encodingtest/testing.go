@@ -1,9 +1,12 @@
+// +build !wasm
+
 // Package encodingtest provides a test suite for testing codec implementations.
 package encodingtest
 
 import (
 	"bytes"
 	"testing"
+	"time"
 
 	"github.com/genjidb/genji/document"
 	"github.com/genjidb/genji/document/encoding"
@@ -19,6 +22,7 @@ func TestCodec(t *testing.T, codecBuilder func() encoding.Codec) {
 		{"EncodeDecode", testEncodeDecode},
 		{"NewDocument", testDecodeDocument},
 		{"Document/GetByField", testDocumentGetByField},
+		{"Document/DecodePaths", testDocumentDecodePaths},
 		{"Array/GetByIndex", testArrayGetByIndex},
 	}
 
@@ -78,6 +82,34 @@ func testEncodeDecode(t *testing.T, codecBuilder func() encoding.Codec) {
 				Add("array", document.NewArrayValue(complexArray)),
 			`{"age": 10, "name": "john", "address": {"city": "Ajaccio", "country": "France"}, "array": [true, -40, -3.14, 3, "YmxvYg==", "hello", {"city": "Ajaccio", "country": "France"}, [11]]}`,
 		},
+		{
+			"Vector",
+			document.NewFieldBuffer().
+				Add("name", document.NewTextValue("john")).
+				Add("embedding", document.NewVectorValue([]float64{0.5, -1, 3.25})),
+			`{"name": "john", "embedding": [0.5, -1, 3.25]}`,
+		},
+		{
+			"Time",
+			document.NewFieldBuffer().
+				Add("created_at", document.NewTimestampValue(time.Date(2023, 1, 2, 10, 30, 0, 500, time.UTC))).
+				Add("birthday", document.NewDateValue(time.Date(1970, 5, 10, 0, 0, 0, 0, time.UTC))).
+				Add("ttl", document.NewIntervalValue(document.Interval{Months: 1, Days: -2, Nanos: 1500})),
+			`{"created_at": "2023-01-02T10:30:00.0000005Z", "birthday": "1970-05-10", "ttl": "1 month -2 days 00:00:00.0000015"}`,
+		},
+		{
+			"Decimal",
+			document.NewFieldBuffer().
+				Add("price", document.NewDecimalValue(document.NewDecimal(-1050, 2))).
+				Add("total", document.NewDecimalValue(document.NewDecimal(12345678901234, 4))),
+			`{"price": -10.50, "total": 1234567890.1234}`,
+		},
+		{
+			"UUID",
+			document.NewFieldBuffer().
+				Add("id", document.NewUUIDValue(document.UUID{0x01, 0x8b, 0x2c, 0x3d, 0x4e, 0x5f, 0x70, 0x81, 0x92, 0xa3, 0xb4, 0xc5, 0xd6, 0xe7, 0xf8, 0x09})),
+			`{"id": "018b2c3d-4e5f-7081-92a3-b4c5d6e7f809"}`,
+		},
 	}
 
 	var buf bytes.Buffer
@@ -129,6 +161,47 @@ func testDocumentGetByField(t *testing.T, codecBuilder func() encoding.Codec) {
 	require.Equal(t, document.ErrFieldNotFound, err)
 }
 
+func testDocumentDecodePaths(t *testing.T, codecBuilder func() encoding.Codec) {
+	codec := codecBuilder()
+
+	fb := document.NewFieldBuffer()
+	err := fb.UnmarshalJSON([]byte(`{"a": 10, "b": {"c": [1, {"d": true}], "e": "john"}, "f": [1, 2], "g": null}`))
+	require.NoError(t, err)
+
+	var buf bytes.Buffer
+
+	err = codec.NewEncoder(&buf).EncodeDocument(fb)
+	require.NoError(t, err)
+
+	paths := []document.Path{
+		document.NewPath("a"),
+		document.NewPath("b", "c", "1", "d"),
+		document.NewPath("b", "c"),
+		document.NewPath("f", "1"),
+		document.NewPath("g"),
+		document.NewPath("a", "x"),
+		document.NewPath("h"),
+	}
+
+	got := make(map[int]string)
+	err = encoding.DecodePaths(codec.NewDecoder(buf.Bytes()), paths, func(i int, v document.Value) error {
+		data, err := v.MarshalJSON()
+		require.NoError(t, err)
+		got[i] = string(data)
+		return nil
+	})
+	require.NoError(t, err)
+
+	// paths that don't exist are ignored
+	require.Equal(t, map[int]string{
+		0: "10",
+		1: "true",
+		2: `[1, {"d": true}]`,
+		3: "2",
+		4: "null",
+	}, got)
+}
+
 func testArrayGetByIndex(t *testing.T, codecBuilder func() encoding.Codec) {
 	codec := codecBuilder()
 
@@ -1,15 +1,31 @@
 package msgpack
 
 import (
+	"bytes"
+	"encoding/binary"
 	"io"
+	"math"
+	"sync"
+	"time"
 
 	"github.com/genjidb/genji/document"
 	"github.com/genjidb/genji/document/encoding"
+	"github.com/genjidb/genji/internal/binarysort"
 	"github.com/genjidb/genji/internal/stringutil"
 	"github.com/vmihailenco/msgpack/v5"
 	"github.com/vmihailenco/msgpack/v5/msgpcode"
 )
 
+// MessagePack extension types used to encode the types that have no MessagePack equivalent.
+const (
+	vectorExtID    int8 = 1
+	timestampExtID int8 = 2
+	dateExtID      int8 = 3
+	intervalExtID  int8 = 4
+	decimalExtID   int8 = 5
+	uuidExtID      int8 = 6
+)
+
 // A Codec is a MessagePack implementation of an encoding.Codec.
 type Codec struct{}
 
@@ -109,6 +125,10 @@ func (e *Encoder) EncodeArray(a document.Array) error {
 // - int32 -> int32
 // - int64 -> int64
 // - float64 -> float64
+// - vector -> ext (packed float64)
+// - timestamp -> ext (seconds and nanoseconds)
+// - date -> ext (seconds and nanoseconds)
+// - interval -> ext (months, days and nanoseconds)
 func (e *Encoder) EncodeValue(v document.Value) error {
 	switch v.Type {
 	case document.DocumentValue:
@@ -127,11 +147,56 @@ func (e *Encoder) EncodeValue(v document.Value) error {
 		return e.enc.EncodeInt(v.V.(int64))
 	case document.DoubleValue:
 		return e.enc.EncodeFloat64(v.V.(float64))
+	case document.VectorValue:
+		return e.encodeVector(v.V.([]float64))
+	case document.TimestampValue:
+		return e.encodeTime(timestampExtID, v.V.(time.Time))
+	case document.DateValue:
+		return e.encodeTime(dateExtID, v.V.(time.Time))
+	case document.IntervalValue:
+		return e.encodeExt(intervalExtID, document.AppendInterval(nil, v.V.(document.Interval)))
+	case document.DecimalValue:
+		return e.encodeExt(decimalExtID, document.AppendDecimal(nil, v.V.(document.Decimal)))
+	case document.UUIDValue:
+		u := v.V.(document.UUID)
+		return e.encodeExt(uuidExtID, u[:])
 	}
 
 	return e.enc.Encode(v.V)
 }
 
+func (e *Encoder) encodeVector(vec []float64) error {
+	err := e.enc.EncodeExtHeader(vectorExtID, len(vec)*8)
+	if err != nil {
+		return err
+	}
+
+	var buf [8]byte
+	for _, x := range vec {
+		binary.BigEndian.PutUint64(buf[:], math.Float64bits(x))
+		_, err = e.enc.Writer().Write(buf[:])
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (e *Encoder) encodeTime(id int8, t time.Time) error {
+	return e.encodeExt(id, binarysort.AppendTime(nil, t))
+}
+
+func (e *Encoder) encodeExt(id int8, buf []byte) error {
+	err := e.enc.EncodeExtHeader(id, len(buf))
+	if err != nil {
+		return err
+	}
+
+	_, err = e.enc.Writer().Write(buf)
+	return err
+}
+
 // Close puts the encoder into the pool for reuse.
 func (e *Encoder) Close() {
 	msgpack.PutEncoder(e.enc)
@@ -141,16 +206,42 @@ func (e *Encoder) Close() {
 // from MessagePack.
 type Decoder struct {
 	dec *msgpack.Decoder
+	// reader used by decoders reading from a byte slice
+	r bytes.Reader
+	// buffer used to read the payload of extensions
+	buf []byte
+	// buffers used by the pathsReader
+	names    []byte
+	selected []int
+	// field names decoded by decodeFieldName, by position
+	fields []string
+}
+
+// decoderPool reuses the decoders, which are created every time
+// an encoded document or array is read.
+var decoderPool = sync.Pool{
+	New: func() interface{} {
+		return new(Decoder)
+	},
 }
 
 // NewDecoder creates a Decoder that reads from the given reader.
 func NewDecoder(r io.Reader) *Decoder {
-	dec := msgpack.GetDecoder()
-	dec.Reset(r)
+	d := decoderPool.Get().(*Decoder)
+	d.dec = msgpack.GetDecoder()
+	d.dec.Reset(r)
 
-	return &Decoder{
-		dec: dec,
-	}
+	return d
+}
+
+// newBytesDecoder creates a Decoder that reads from the given byte slice.
+func newBytesDecoder(data []byte) *Decoder {
+	d := decoderPool.Get().(*Decoder)
+	d.r.Reset(data)
+	d.dec = msgpack.GetDecoder()
+	d.dec.Reset(&d.r)
+
+	return d
 }
 
 // DecodeValue reads one value from the reader and decodes it.
@@ -207,6 +298,11 @@ func (d *Decoder) DecodeValue() (v document.Value, err error) {
 		return
 	}
 
+	// decode extensions
+	if msgpcode.IsExt(c) {
+		return d.decodeExt()
+	}
+
 	// decode the rest
 	switch c {
 	case msgpcode.Nil:
@@ -249,6 +345,103 @@ func (d *Decoder) DecodeValue() (v document.Value, err error) {
 	panic(stringutil.Sprintf("unsupported type %v", c))
 }
 
+func (d *Decoder) decodeExt() (document.Value, error) {
+	id, l, err := d.dec.DecodeExtHeader()
+	if err != nil {
+		return document.Value{}, err
+	}
+
+	// the payload is read into the buffer of the decoder, the decoded values never refer to it
+	if cap(d.buf) < l {
+		d.buf = make([]byte, l)
+	}
+	buf := d.buf[:l]
+	err = d.dec.ReadFull(buf)
+	if err != nil {
+		return document.Value{}, err
+	}
+
+	switch id {
+	case vectorExtID:
+		if l%8 != 0 {
+			break
+		}
+
+		vec := make([]float64, l/8)
+		for i := range vec {
+			vec[i] = math.Float64frombits(binary.BigEndian.Uint64(buf[i*8:]))
+		}
+		return document.NewVectorValue(vec), nil
+	case timestampExtID, dateExtID:
+		t, err := binarysort.DecodeTime(buf)
+		if err != nil {
+			return document.Value{}, err
+		}
+		if id == dateExtID {
+			return document.NewDateValue(t), nil
+		}
+		return document.NewTimestampValue(t), nil
+	case intervalExtID:
+		i, err := document.DecodeInterval(buf)
+		if err != nil {
+			return document.Value{}, err
+		}
+		return document.NewIntervalValue(i), nil
+	case decimalExtID:
+		x, err := document.DecodeDecimal(buf)
+		if err != nil {
+			return document.Value{}, err
+		}
+		return document.NewDecimalValue(x), nil
+	case uuidExtID:
+		var u document.UUID
+		if len(buf) != len(u) {
+			break
+		}
+		copy(u[:], buf)
+		return document.NewUUIDValue(u), nil
+	}
+
+	return document.Value{}, stringutil.Errorf("unsupported extension type %d", id)
+}
+
+// decodeFieldName decodes the name of the i-th field of a document.
+// Since the documents of a table usually have the same fields, the name decoded
+// at the same position by this decoder is reused if it is the same, instead of
+// allocating a new string.
+func (d *Decoder) decodeFieldName(i int) (string, error) {
+	n, err := d.dec.DecodeBytesLen()
+	if err != nil {
+		return "", err
+	}
+	if n < 0 {
+		n = 0
+	}
+	if cap(d.names) < n {
+		d.names = make([]byte, n)
+	}
+	d.names = d.names[:n]
+
+	err = d.dec.ReadFull(d.names)
+	if err != nil {
+		return "", err
+	}
+
+	if i < len(d.fields) && d.fields[i] == string(d.names) {
+		return d.fields[i], nil
+	}
+
+	f := string(d.names)
+	switch {
+	case i < len(d.fields):
+		d.fields[i] = f
+	case i == len(d.fields):
+		d.fields = append(d.fields, f)
+	}
+
+	return f, nil
+}
+
 // DecodeDocument decodes one document from the reader.
 // If the document is malformed, it will not return an error.
 // However, calls to Iterate or GetByField will fail.
@@ -274,6 +467,10 @@ func (d *Decoder) DecodeArray() (document.Array, error) {
 }
 
 // Close puts the decoder into the pool for reuse.
+// The decoder must not be used afterwards.
 func (d *Decoder) Close() {
 	msgpack.PutDecoder(d.dec)
+	d.dec = nil
+	d.r.Reset(nil)
+	decoderPool.Put(d)
 }
@@ -150,7 +150,7 @@ func (e *EncodedDocument) Iterate(fn func(field string, value document.Value) er
 	}
 
 	for i := 0; i < l; i++ {
-		f, err := dec.dec.DecodeString()
+		f, err := dec.decodeFieldName(i)
 		if err != nil {
 			return err
 		}
@@ -169,6 +169,179 @@ func (e *EncodedDocument) Iterate(fn func(field string, value document.Value) er
 	return nil
 }
 
+// DecodePaths decodes the values found at the given paths in one pass and passes them to fn,
+// along with the position of their path. The fields that don't lead to any of the paths
+// are skipped without being decoded.
+// It implements the encoding.PathsDecoder interface.
+func (e *EncodedDocument) DecodePaths(paths []document.Path, fn func(i int, v document.Value) error) error {
+	_, err := e.reader.Seek(0, io.SeekStart)
+	if err != nil {
+		return err
+	}
+
+	dec := NewDecoder(&e.reader)
+	defer dec.Close()
+
+	// the buffers of the reader are kept by the decoder, which is pooled
+	selected := dec.selected[:0]
+	for i, p := range paths {
+		if len(p) > 0 {
+			selected = append(selected, i)
+		}
+	}
+
+	r := pathsReader{dec: dec, paths: paths, fn: fn, buf: dec.names, selected: selected}
+	err = r.read(selected, 0)
+	dec.names = r.buf
+	dec.selected = r.selected
+	return err
+}
+
+// A pathsReader decodes the values found at several paths of an encoded document,
+// skipping the values that don't lead to any of them.
+type pathsReader struct {
+	dec   *Decoder
+	paths []document.Path
+	fn    func(i int, v document.Value) error
+	// buffer used to read the field names
+	buf []byte
+	// stack holding the indexes of the paths selected at each depth,
+	// to avoid allocating a slice for every value read
+	selected []int
+}
+
+// read reads the document or the array at the current position of the decoder,
+// found at the given depth of the selected paths.
+func (r *pathsReader) read(selected []int, depth int) error {
+	c, err := r.dec.dec.PeekCode()
+	if err != nil {
+		return err
+	}
+
+	switch {
+	case msgpcode.IsFixedMap(c) || c == msgpcode.Map16 || c == msgpcode.Map32:
+		l, err := r.dec.dec.DecodeMapLen()
+		if err != nil {
+			return err
+		}
+
+		for i := 0; i < l; i++ {
+			// the field name is read into the buffer instead of being decoded as a string,
+			// which would allocate
+			n, err := r.dec.dec.DecodeBytesLen()
+			if err != nil {
+				return err
+			}
+			if n < 0 {
+				n = 0
+			}
+			if cap(r.buf) < n {
+				r.buf = make([]byte, n)
+			}
+			r.buf = r.buf[:n]
+
+			err = r.dec.dec.ReadFull(r.buf)
+			if err != nil {
+				return err
+			}
+
+			err = r.readValue(selected, depth, r.buf, 0)
+			if err != nil {
+				return err
+			}
+		}
+
+		return nil
+	case msgpcode.IsFixedArray(c) || c == msgpcode.Array16 || c == msgpcode.Array32:
+		l, err := r.dec.dec.DecodeArrayLen()
+		if err != nil {
+			return err
+		}
+
+		for i := 0; i < l; i++ {
+			err = r.readValue(selected, depth, nil, i)
+			if err != nil {
+				return err
+			}
+		}
+
+		return nil
+	}
+
+	// the paths can't go through other types of values
+	return r.dec.dec.Skip()
+}
+
+// readValue reads the value at the current position of the decoder, found either in the field
+// of a document if field is not nil, or at the given index of an array.
+// The value is decoded if one of the selected paths ends with it, otherwise the paths going
+// through it are read, or the value is skipped if none does.
+func (r *pathsReader) readValue(selected []int, depth int, field []byte, index int) error {
+	// the selected paths are pushed on the stack and popped once the value is read
+	top := len(r.selected)
+	defer func() {
+		r.selected = r.selected[:top]
+	}()
+
+	var ends bool
+	for _, i := range selected {
+		f := r.paths[i][depth]
+		if field != nil && (f.FieldName == "" || f.FieldName != string(field)) {
+			continue
+		}
+		if field == nil && (f.FieldName != "" || f.ArrayIndex != index) {
+			continue
+		}
+
+		r.selected = append(r.selected, i)
+		if len(r.paths[i]) == depth+1 {
+			ends = true
+		}
+	}
+	next := r.selected[top:]
+
+	if len(next) == 0 {
+		return r.dec.dec.Skip()
+	}
+
+	if !ends {
+		return r.read(next, depth+1)
+	}
+
+	// the value is decoded entirely, the longer paths going through it are read from the decoded value
+	v, err := r.dec.DecodeValue()
+	if err != nil {
+		return err
+	}
+
+	for _, i := range next {
+		pv := v
+		if rest := r.paths[i][depth+1:]; len(rest) > 0 {
+			switch v.Type {
+			case document.DocumentValue:
+				pv, err = rest.GetValueFromDocument(v.V.(document.Document))
+			case document.ArrayValue:
+				pv, err = rest.GetValueFromArray(v.V.(document.Array))
+			default:
+				continue
+			}
+			if err == document.ErrFieldNotFound {
+				continue
+			}
+			if err != nil {
+				return err
+			}
+		}
+
+		err = r.fn(i, pv)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
 // An EncodedArray implements the document.Array interface on top of an
 // encoded representation of an array.
 // It is useful for avoiding decoding the entire array when
@@ -179,7 +352,7 @@ type EncodedArray []byte
 // given function by passing each one of them.
 // If the given function returns an error, the iteration stops.
 func (e EncodedArray) Iterate(fn func(i int, value document.Value) error) error {
-	dec := NewDecoder(bytes.NewReader(e))
+	dec := newBytesDecoder(e)
 	defer dec.Close()
 
 	l, err := dec.dec.DecodeArrayLen()
@@ -204,7 +377,7 @@ func (e EncodedArray) Iterate(fn func(i int, value document.Value) error) error
 
 // GetByIndex returns a value by index of the array.
 func (e EncodedArray) GetByIndex(idx int) (v document.Value, err error) {
-	dec := NewDecoder(bytes.NewReader(e))
+	dec := newBytesDecoder(e)
 	defer dec.Close()
 
 	l, err := dec.dec.DecodeArrayLen()
@@ -3,6 +3,7 @@ package msgpack
 import (
 	"bytes"
 	"testing"
+	"time"
 
 	"github.com/genjidb/genji/document"
 	"github.com/genjidb/genji/document/encoding"
@@ -46,3 +47,35 @@ func BenchmarkCodec(b *testing.B) {
 		return NewCodec()
 	})
 }
+
+// Decoders are pooled and reuse the field names decoded previously,
+// which must not leak between documents with different fields.
+func TestDecoderReuse(t *testing.T) {
+	codec := NewCodec()
+
+	encode := func(d document.Document) []byte {
+		var buf bytes.Buffer
+		err := codec.NewEncoder(&buf).EncodeDocument(d)
+		require.NoError(t, err)
+		return buf.Bytes()
+	}
+
+	docs := [][]byte{
+		encode(document.NewFieldBuffer().Add("a", document.NewIntegerValue(1)).Add("b", document.NewTimestampValue(time.Unix(10, 0).UTC()))),
+		encode(document.NewFieldBuffer().Add("a", document.NewIntegerValue(2)).Add("bb", document.NewIntervalValue(document.Interval{Days: 2})).Add("c", document.NewBoolValue(true))),
+		encode(document.NewFieldBuffer().Add("b", document.NewIntegerValue(3))),
+	}
+	expected := []string{
+		`{"a": 1, "b": "1970-01-01T00:00:10Z"}`,
+		`{"a": 2, "bb": "2 days", "c": true}`,
+		`{"b": 3}`,
+	}
+
+	for i := 0; i < 3; i++ {
+		for j, data := range docs {
+			data, err := document.MarshalJSON(codec.NewDecoder(data))
+			require.NoError(t, err)
+			require.JSONEq(t, expected[j], string(data))
+		}
+	}
+}
@@ -0,0 +1,89 @@
+package document
+
+import (
+	"bufio"
+	"encoding/json"
+	"io"
+
+	"github.com/genjidb/genji/internal/stringutil"
+)
+
+// IterateJSON decodes the JSON objects read from r and calls fn with each of them.
+// r can either hold a stream of objects, such as JSON Lines, or an array of objects.
+// Objects are decoded one at a time, so that r doesn't need to fit in memory.
+// It returns nil if r is empty.
+func IterateJSON(r io.Reader, fn func(fb *FieldBuffer) error) error {
+	rd := bufio.NewReader(r)
+
+	// read the first non white space byte to determine
+	// whether we are reading a stream of objects or
+	// an array of objects.
+	c, err := readByteIgnoreWhitespace(rd)
+	if err == io.EOF {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if err := rd.UnreadByte(); err != nil {
+		return err
+	}
+
+	dec := json.NewDecoder(rd)
+
+	switch c {
+	case '{':
+		for {
+			var fb FieldBuffer
+			err := dec.Decode(&fb)
+			if err == io.EOF {
+				return nil
+			}
+			if err != nil {
+				return err
+			}
+
+			if err := fn(&fb); err != nil {
+				return err
+			}
+		}
+	case '[':
+		// consume the opening bracket
+		if _, err := dec.Token(); err != nil {
+			return err
+		}
+
+		for dec.More() {
+			var fb FieldBuffer
+			if err := dec.Decode(&fb); err != nil {
+				return err
+			}
+
+			if err := fn(&fb); err != nil {
+				return err
+			}
+		}
+
+		// consume the closing bracket
+		_, err := dec.Token()
+		if err == io.EOF {
+			return io.ErrUnexpectedEOF
+		}
+		return err
+	}
+
+	return stringutil.Errorf("found %q, but expected '{' or '['", c)
+}
+
+func readByteIgnoreWhitespace(r *bufio.Reader) (byte, error) {
+	for {
+		c, err := r.ReadByte()
+		if err != nil {
+			return c, err
+		}
+
+		if c != '\n' && c != '\r' && c != ' ' && c != '\t' {
+			return c, nil
+		}
+	}
+}
@@ -3,10 +3,9 @@
 package document
 
 import (
+	"database/sql"
 	"errors"
 	"reflect"
-	"strings"
-	"time"
 
 	"github.com/genjidb/genji/internal/stringutil"
 )
@@ -39,13 +38,11 @@ func Scan(d Document, targets ...interface{}) error {
 
 // StructScan scans d into t. t is expected to be a pointer to a struct.
 //
-// By default, each struct field name is lowercased and the document's GetByField method
-// is called with that name. If there is a match, the value is converted to the struct
-// field type when possible, otherwise an error is returned.
-// The decoding of each struct field can be customized by the format string stored
-// under the "genji" key stored in the struct field's tag.
-// The content of the format string is used instead of the struct field name and passed
-// to the GetByField method.
+// Each exported struct field is read from the document field it is mapped to,
+// as described by StructField, which is the mapping used by NewFromStruct.
+// If there is a match, the value is converted to the struct field type when possible,
+// otherwise an error is returned. Fields implementing Unmarshaler are set using
+// their UnmarshalValue method.
 func StructScan(d Document, t interface{}) error {
 	ref := reflect.ValueOf(t)
 
@@ -66,28 +63,20 @@ func structScan(d Document, ref reflect.Value) error {
 	}
 
 	sref := reflect.Indirect(ref)
-	stp := sref.Type()
-	l := sref.NumField()
-	for i := 0; i < l; i++ {
-		f := sref.Field(i)
-		sf := stp.Field(i)
-		var name string
-		if gtag, ok := sf.Tag.Lookup("genji"); ok {
-			if gtag == "-" {
-				continue
-			}
-
-			name = gtag
-		} else {
-			name = strings.ToLower(sf.Name)
-		}
-		v, err := d.GetByField(name)
+	for _, sf := range StructFields(sref.Type()) {
+		v, err := d.GetByField(sf.Name)
 		if err == ErrFieldNotFound {
 			v = NewNullValue()
 		} else if err != nil {
 			return err
 		}
 
+		// nil embedded pointers are only allocated if the document has a value for their fields
+		f, ok := fieldByIndex(sref, sf.Index, v.Type != NullValue)
+		if !ok {
+			continue
+		}
+
 		if err := scanValue(v, f); err != nil {
 			return err
 		}
@@ -217,11 +206,62 @@ func ScanValue(v Value, t interface{}) error {
 	return scanValue(v, reflect.ValueOf(t))
 }
 
+var sqlScannerType = reflect.TypeOf((*sql.Scanner)(nil)).Elem()
+
+// asSQLScanner returns the sql.Scanner implementation of the target, if any.
+func asSQLScanner(ref reflect.Value) (sql.Scanner, bool) {
+	sc, ok := asImplementer(ref, sqlScannerType)
+	if !ok {
+		return nil, false
+	}
+
+	return sc.(sql.Scanner), true
+}
+
+// asUnmarshaler returns the Unmarshaler implementation of the target, if any.
+func asUnmarshaler(ref reflect.Value) (Unmarshaler, bool) {
+	u, ok := asImplementer(ref, unmarshalerType)
+	if !ok {
+		return nil, false
+	}
+
+	return u.(Unmarshaler), true
+}
+
+// driverValue returns the representation of v expected by sql.Scanner implementations.
+// Documents and arrays are represented as JSON texts.
+func driverValue(v Value) (interface{}, error) {
+	switch v.Type {
+	case NullValue:
+		return nil, nil
+	case DocumentValue, ArrayValue, VectorValue:
+		v, err := v.CastAsText()
+		if err != nil {
+			return nil, err
+		}
+		return v.V, nil
+	}
+
+	return v.V, nil
+}
+
 func scanValue(v Value, ref reflect.Value) error {
 	if !ref.IsValid() {
 		return &ErrUnsupportedType{ref, "parameter is not a valid reference"}
 	}
 
+	if u, ok := asUnmarshaler(ref); ok {
+		return u.UnmarshalValue(v)
+	}
+
+	if sc, ok := asSQLScanner(ref); ok {
+		dv, err := driverValue(v)
+		if err != nil {
+			return err
+		}
+		return sc.Scan(dv)
+	}
+
 	if v.Type == NullValue {
 		if ref.Type().Kind() != reflect.Ptr {
 			return nil
@@ -263,6 +303,19 @@ func scanValue(v Value, ref reflect.Value) error {
 		return nil
 	}
 
+	// the target may have been allocated above
+	if u, ok := asUnmarshaler(ref); ok {
+		return u.UnmarshalValue(v)
+	}
+
+	if sc, ok := asSQLScanner(ref); ok {
+		dv, err := driverValue(v)
+		if err != nil {
+			return err
+		}
+		return sc.Scan(dv)
+	}
+
 	switch ref.Kind() {
 	case reflect.String:
 		v, err := v.CastAsText()
@@ -328,8 +381,42 @@ func scanValue(v Value, ref reflect.Value) error {
 	// test with supported stdlib types
 	switch ref.Type().String() {
 	case "time.Time":
-		if v.Type == TextValue {
-			parsed, err := time.Parse(time.RFC3339Nano, v.V.(string))
+		switch v.Type {
+		case DateValue, TimestampValue:
+			ref.Set(reflect.ValueOf(v.V))
+			return nil
+		case TextValue:
+			parsed, err := ParseTimestamp(v.V.(string))
+			if err != nil {
+				return err
+			}
+
+			ref.Set(reflect.ValueOf(parsed))
+			return nil
+		}
+	case "document.Decimal":
+		v, err := v.CastAsDecimal()
+		if err != nil {
+			return err
+		}
+
+		ref.Set(reflect.ValueOf(v.V))
+		return nil
+	case "document.UUID":
+		v, err := v.CastAsUUID()
+		if err != nil {
+			return err
+		}
+
+		ref.Set(reflect.ValueOf(v.V))
+		return nil
+	case "document.Interval":
+		switch v.Type {
+		case IntervalValue:
+			ref.Set(reflect.ValueOf(v.V))
+			return nil
+		case TextValue:
+			parsed, err := ParseInterval(v.V.(string))
 			if err != nil {
 				return err
 			}
@@ -2,6 +2,7 @@ package document_test
 
 import (
 	"bytes"
+	"database/sql"
 	"testing"
 	"time"
 
@@ -248,6 +249,43 @@ func TestScan(t *testing.T) {
 		require.NoError(t, err)
 		require.Equal(t, bar{}, b)
 	})
+
+	t.Run("sql.Scanner", func(t *testing.T) {
+		type bar struct {
+			A sql.NullString
+			B sql.NullInt64
+			C *sql.NullFloat64
+			D *sql.NullFloat64
+			E sql.NullBool
+		}
+
+		b := bar{
+			B: sql.NullInt64{Int64: 10, Valid: true},
+			D: &sql.NullFloat64{Float64: 1.5, Valid: true},
+		}
+
+		d := document.NewFieldBuffer().
+			Add("a", document.NewTextValue("foo")).
+			Add("b", document.NewNullValue()).
+			Add("c", document.NewDoubleValue(2.5)).
+			Add("d", document.NewNullValue())
+		err := document.StructScan(d, &b)
+		require.NoError(t, err)
+		require.Equal(t, bar{
+			A: sql.NullString{String: "foo", Valid: true},
+			C: &sql.NullFloat64{Float64: 2.5, Valid: true},
+		}, b)
+
+		var ns sql.NullString
+		err = document.Scan(document.NewFieldBuffer().Add("a", document.NewNullValue()), &ns)
+		require.NoError(t, err)
+		require.False(t, ns.Valid)
+
+		var ni sql.NullInt64
+		err = document.NewIntegerValue(42).Scan(&ni)
+		require.NoError(t, err)
+		require.Equal(t, sql.NullInt64{Int64: 42, Valid: true}, ni)
+	})
 }
 
 type documentScanner struct {
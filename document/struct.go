@@ -0,0 +1,250 @@
+// +build !wasm
+
+package document
+
+import (
+	"reflect"
+	"strings"
+	"sync"
+	"time"
+)
+
+// A Marshaler can convert itself into a value.
+// Types implementing Marshaler are converted by calling their MarshalValue method
+// instead of using reflection, for instance when they are fields of a struct.
+type Marshaler interface {
+	MarshalValue() (Value, error)
+}
+
+// An Unmarshaler can set itself from a value.
+// Types implementing Unmarshaler are scanned by calling their UnmarshalValue method,
+// including when the value is NULL.
+type Unmarshaler interface {
+	UnmarshalValue(v Value) error
+}
+
+var (
+	marshalerType   = reflect.TypeOf((*Marshaler)(nil)).Elem()
+	unmarshalerType = reflect.TypeOf((*Unmarshaler)(nil)).Elem()
+	timeType        = reflect.TypeOf(time.Time{})
+)
+
+// A StructField describes how a field of a struct is mapped to a document field.
+//
+// The mapping of each struct field can be customized using the "genji" key of its tag,
+// whose format is "name,option,...". The name replaces the lowercased name of the field,
+// and is optional. The following options are supported:
+//
+//	omitempty: the field is not stored if it holds the zero value of its type
+//	pk: the field is the primary key of the document
+//
+// Fields tagged with "-" are ignored.
+//
+// The fields of embedded structs are promoted to the document of the outer struct,
+// unless the embedded struct is tagged with a name, in which case it is stored as a document.
+// Fields of the outer struct take precedence over the promoted fields with the same name.
+type StructField struct {
+	// Name of the document field.
+	Name string
+	// Index of the struct field, as used by reflect.Value.FieldByIndex.
+	Index []int
+	// OmitEmpty reports whether the field is skipped when it holds a zero value.
+	OmitEmpty bool
+	// PrimaryKey reports whether the field is tagged as the primary key.
+	PrimaryKey bool
+}
+
+// cache of the fields of each struct type.
+var structFieldsCache sync.Map
+
+// StructFields returns the fields of the documents created from structs of type t,
+// in order. t must be a struct type.
+func StructFields(t reflect.Type) []StructField {
+	if fields, ok := structFieldsCache.Load(t); ok {
+		return fields.([]StructField)
+	}
+
+	fields := structFields(t, nil, 0)
+
+	// keep the shallowest field of each name
+	depths := make(map[string]int, len(fields))
+	for _, f := range fields {
+		if d, ok := depths[f.Name]; !ok || len(f.Index) < d {
+			depths[f.Name] = len(f.Index)
+		}
+	}
+	filtered := fields[:0]
+	for _, f := range fields {
+		if depths[f.Name] == len(f.Index) {
+			filtered = append(filtered, f)
+			// make sure a name is only used once at a given depth
+			depths[f.Name] = -1
+		}
+	}
+
+	cached, _ := structFieldsCache.LoadOrStore(t, filtered)
+	return cached.([]StructField)
+}
+
+func structFields(t reflect.Type, index []int, depth int) []StructField {
+	var fields []StructField
+
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+
+		name, opts := parseStructTag(sf.Tag.Get("genji"))
+		if name == "-" && opts == "" {
+			continue
+		}
+
+		idx := make([]int, len(index)+1)
+		copy(idx, index)
+		idx[len(index)] = i
+
+		ft := sf.Type
+		if ft.Kind() == reflect.Ptr {
+			ft = ft.Elem()
+		}
+
+		if sf.Anonymous && name == "" && isPromotable(ft) {
+			// avoid infinite recursion on self-embedding types
+			if depth < 16 {
+				fields = append(fields, structFields(ft, idx, depth+1)...)
+			}
+			continue
+		}
+
+		// unexported fields are ignored
+		if sf.PkgPath != "" {
+			continue
+		}
+
+		if name == "" {
+			name = strings.ToLower(sf.Name)
+		}
+
+		fields = append(fields, StructField{
+			Name:       name,
+			Index:      idx,
+			OmitEmpty:  hasTagOption(opts, "omitempty"),
+			PrimaryKey: hasTagOption(opts, "pk"),
+		})
+	}
+
+	return fields
+}
+
+// isPromotable reports whether the fields of an embedded field of type t
+// are promoted to the outer document.
+func isPromotable(t reflect.Type) bool {
+	if t.Kind() != reflect.Struct || t == timeType {
+		return false
+	}
+
+	return !t.Implements(marshalerType) && !reflect.PtrTo(t).Implements(marshalerType)
+}
+
+// parseStructTag splits a genji tag into a name and its options.
+func parseStructTag(tag string) (string, string) {
+	if i := strings.IndexByte(tag, ','); i >= 0 {
+		return tag[:i], tag[i+1:]
+	}
+
+	return tag, ""
+}
+
+func hasTagOption(opts, name string) bool {
+	for opts != "" {
+		var opt string
+		if i := strings.IndexByte(opts, ','); i >= 0 {
+			opt, opts = opts[:i], opts[i+1:]
+		} else {
+			opt, opts = opts, ""
+		}
+
+		if opt == name {
+			return true
+		}
+	}
+
+	return false
+}
+
+// StructPrimaryKey returns the name of the document field mapped to the field of s
+// tagged with the pk option. It returns false if there is none.
+// s must be a struct or a pointer to a struct.
+func StructPrimaryKey(s interface{}) (string, bool) {
+	t := reflect.TypeOf(s)
+	for t != nil && t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t == nil || t.Kind() != reflect.Struct {
+		return "", false
+	}
+
+	for _, f := range StructFields(t) {
+		if f.PrimaryKey {
+			return f.Name, true
+		}
+	}
+
+	return "", false
+}
+
+// fieldByIndex returns the field of ref at the given index.
+// If alloc is true, nil embedded pointers are allocated on the way, otherwise
+// fieldByIndex returns false if it encounters one, or if it can't be allocated.
+func fieldByIndex(ref reflect.Value, index []int, alloc bool) (reflect.Value, bool) {
+	for i, x := range index {
+		if i > 0 && ref.Kind() == reflect.Ptr {
+			if ref.IsNil() {
+				if !alloc || !ref.CanSet() {
+					return reflect.Value{}, false
+				}
+				ref.Set(reflect.New(ref.Type().Elem()))
+			}
+			ref = ref.Elem()
+		}
+
+		ref = ref.Field(x)
+	}
+
+	return ref, true
+}
+
+// asImplementer returns the implementation of the interface type it by ref or by its address, if any.
+// Settable pointers, such as pointer struct fields, are not considered implementers
+// so that they can be set to nil when scanning NULL values.
+func asImplementer(ref reflect.Value, it reflect.Type) (interface{}, bool) {
+	if ref.Kind() == reflect.Ptr {
+		if ref.CanSet() || ref.IsNil() || !ref.Type().Implements(it) {
+			return nil, false
+		}
+
+		return ref.Interface(), true
+	}
+
+	if !ref.CanAddr() || !ref.Addr().Type().Implements(it) {
+		return nil, false
+	}
+
+	return ref.Addr().Interface(), true
+}
+
+// marshalValue converts ref using its Marshaler implementation, if any.
+func marshalValue(ref reflect.Value) (Value, bool, error) {
+	var m Marshaler
+	if ref.Type().Implements(marshalerType) {
+		if ref.Kind() == reflect.Ptr && ref.IsNil() {
+			return NewNullValue(), true, nil
+		}
+		m = ref.Interface().(Marshaler)
+	} else if ref.CanAddr() && ref.Addr().Type().Implements(marshalerType) {
+		m = ref.Addr().Interface().(Marshaler)
+	} else {
+		return Value{}, false, nil
+	}
+
+	v, err := m.MarshalValue()
+	return v, true, err
+}
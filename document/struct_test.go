@@ -0,0 +1,131 @@
+package document_test
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/genjidb/genji/document"
+	"github.com/stretchr/testify/require"
+)
+
+// upper is stored as an uppercased text.
+type upper string
+
+func (u upper) MarshalValue() (document.Value, error) {
+	return document.NewTextValue(strings.ToUpper(string(u))), nil
+}
+
+func (u *upper) UnmarshalValue(v document.Value) error {
+	if v.Type == document.NullValue {
+		*u = ""
+		return nil
+	}
+
+	s, err := v.CastAsText()
+	if err != nil {
+		return err
+	}
+	*u = upper(strings.ToLower(s.V.(string)))
+	return nil
+}
+
+type Base struct {
+	ID      int64 `genji:"id,pk"`
+	Created time.Time
+}
+
+type Address struct {
+	City string `genji:"city,omitempty"`
+}
+
+type account struct {
+	Base
+	*Address `genji:"address"`
+	Name     upper
+	Email    string   `genji:",omitempty"`
+	Tags     []string `genji:"tags,omitempty"`
+	Secret   string   `genji:"-"`
+	Home     Address
+}
+
+func TestStructMapping(t *testing.T) {
+	now := time.Date(2021, 1, 2, 3, 4, 5, 0, time.UTC)
+	a := account{
+		Base:    Base{ID: 10, Created: now},
+		Address: &Address{City: "Lyon"},
+		Name:    "foo",
+		Secret:  "bar",
+	}
+
+	d, err := document.NewFromStruct(&a)
+	require.NoError(t, err)
+
+	data, err := document.MarshalJSON(d)
+	require.NoError(t, err)
+	require.JSONEq(t, `{
+		"id": 10,
+		"created": "2021-01-02T03:04:05Z",
+		"address": {"city": "Lyon"},
+		"name": "FOO",
+		"home": {}
+	}`, string(data))
+
+	var b account
+	err = document.StructScan(d, &b)
+	require.NoError(t, err)
+	a.Secret = ""
+	require.Equal(t, a, b)
+
+	t.Run("Fields", func(t *testing.T) {
+		fields := document.StructFields(reflect.TypeOf(a))
+		var names []string
+		for _, f := range fields {
+			names = append(names, f.Name)
+		}
+		require.Equal(t, []string{"id", "created", "address", "name", "email", "tags", "home"}, names)
+		require.True(t, fields[0].PrimaryKey)
+		require.Equal(t, []int{0, 0}, fields[0].Index)
+		require.True(t, fields[4].OmitEmpty)
+
+		pk, ok := document.StructPrimaryKey(&a)
+		require.True(t, ok)
+		require.Equal(t, "id", pk)
+		_, ok = document.StructPrimaryKey(Address{})
+		require.False(t, ok)
+	})
+
+	t.Run("Shadowing", func(t *testing.T) {
+		type s struct {
+			Base
+			ID string
+		}
+
+		d, err := document.NewFromStruct(s{Base: Base{ID: 1}, ID: "a"})
+		require.NoError(t, err)
+		v, err := d.GetByField("id")
+		require.NoError(t, err)
+		require.Equal(t, document.NewTextValue("a"), v)
+	})
+
+	t.Run("Nil embedded pointers", func(t *testing.T) {
+		type s struct {
+			*Base
+			Name string
+		}
+
+		d, err := document.NewFromStruct(s{Name: "a"})
+		require.NoError(t, err)
+		data, err := document.MarshalJSON(d)
+		require.NoError(t, err)
+		require.JSONEq(t, `{"name": "a"}`, string(data))
+
+		// embedded pointers are allocated when their fields are present
+		var x s
+		require.NoError(t, document.StructScan(d, &x))
+		require.Nil(t, x.Base)
+		require.NoError(t, document.StructScan(document.NewFieldBuffer().Add("id", document.NewIntegerValue(1)), &x))
+		require.Equal(t, &Base{ID: 1}, x.Base)
+	})
+}
@@ -0,0 +1,462 @@
+package document
+
+import (
+	"errors"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/genjidb/genji/internal/binarysort"
+	"github.com/genjidb/genji/internal/stringutil"
+)
+
+// DateLayout is the layout used to represent dates as text.
+const DateLayout = "2006-01-02"
+
+// timestampLayouts are the layouts accepted when parsing a timestamp from a text.
+// Timestamps without time zone are in UTC.
+var timestampLayouts = []string{
+	time.RFC3339Nano,
+	"2006-01-02T15:04:05.999999999",
+	"2006-01-02 15:04:05.999999999Z07:00",
+	"2006-01-02 15:04:05.999999999",
+	DateLayout,
+}
+
+// ParseTimestamp parses a timestamp represented as an RFC 3339 text, e.g. 2023-01-01T10:00:00Z.
+// A space can be used to separate the date and the time, and the time or the time zone
+// can be omitted, in which case the timestamp is in UTC.
+func ParseTimestamp(s string) (time.Time, error) {
+	for _, layout := range timestampLayouts {
+		t, err := time.Parse(layout, s)
+		if err == nil {
+			return t, nil
+		}
+	}
+
+	return time.Time{}, stringutil.Errorf("cannot parse %q as timestamp", s)
+}
+
+// ParseDate parses a date represented as a text, e.g. 2023-01-01.
+// Timestamps are accepted as well, only their date is kept.
+func ParseDate(s string) (time.Time, error) {
+	t, err := ParseTimestamp(s)
+	if err != nil {
+		return time.Time{}, stringutil.Errorf("cannot parse %q as date", s)
+	}
+
+	return toDate(t), nil
+}
+
+// toDate returns the midnight UTC of the date of t, in the location of t.
+func toDate(t time.Time) time.Time {
+	y, m, d := t.Date()
+	return time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+}
+
+const (
+	nanosPerSecond = int64(time.Second)
+	nanosPerMinute = int64(time.Minute)
+	nanosPerHour   = int64(time.Hour)
+	nanosPerDay    = 24 * nanosPerHour
+	// daysPerMonth is the number of days of a month when intervals
+	// are compared or divided, like in PostgreSQL.
+	daysPerMonth = 30
+)
+
+// An Interval is a duration expressed in months, days and nanoseconds.
+// Months and days are kept apart from the nanoseconds because their length varies:
+// adding one month to a timestamp moves it to the same day of the next month,
+// and adding one day moves it to the same time of the next day.
+type Interval struct {
+	Months int64
+	Days   int64
+	Nanos  int64
+}
+
+// intervalUnits associates the units accepted by ParseInterval with their length.
+var intervalUnits = map[string]Interval{
+	"year":         {Months: 12},
+	"years":        {Months: 12},
+	"month":        {Months: 1},
+	"months":       {Months: 1},
+	"mon":          {Months: 1},
+	"mons":         {Months: 1},
+	"week":         {Days: 7},
+	"weeks":        {Days: 7},
+	"day":          {Days: 1},
+	"days":         {Days: 1},
+	"hour":         {Nanos: nanosPerHour},
+	"hours":        {Nanos: nanosPerHour},
+	"minute":       {Nanos: nanosPerMinute},
+	"minutes":      {Nanos: nanosPerMinute},
+	"min":          {Nanos: nanosPerMinute},
+	"mins":         {Nanos: nanosPerMinute},
+	"second":       {Nanos: nanosPerSecond},
+	"seconds":      {Nanos: nanosPerSecond},
+	"sec":          {Nanos: nanosPerSecond},
+	"secs":         {Nanos: nanosPerSecond},
+	"millisecond":  {Nanos: int64(time.Millisecond)},
+	"milliseconds": {Nanos: int64(time.Millisecond)},
+	"ms":           {Nanos: int64(time.Millisecond)},
+	"microsecond":  {Nanos: int64(time.Microsecond)},
+	"microseconds": {Nanos: int64(time.Microsecond)},
+	"us":           {Nanos: int64(time.Microsecond)},
+}
+
+// ParseInterval parses an interval represented as a list of quantities followed by their unit,
+// e.g. 1 year 2 months 3 days, optionally followed by a time, e.g. 1 day 04:05:06.5.
+// Years, months, weeks and days must be integers while hours, minutes, seconds, milliseconds
+// and microseconds can have a fractional part.
+func ParseInterval(s string) (Interval, error) {
+	fields := strings.Fields(s)
+	if len(fields) == 0 {
+		return Interval{}, stringutil.Errorf("cannot parse %q as interval", s)
+	}
+
+	var i Interval
+	for len(fields) > 0 {
+		if strings.Contains(fields[0], ":") {
+			nanos, ok := parseClock(fields[0])
+			if !ok {
+				return Interval{}, stringutil.Errorf("cannot parse %q as interval", s)
+			}
+			i.Nanos += nanos
+			fields = fields[1:]
+			continue
+		}
+
+		if len(fields) < 2 {
+			return Interval{}, stringutil.Errorf("cannot parse %q as interval", s)
+		}
+
+		unit, ok := intervalUnits[strings.ToLower(fields[1])]
+		if !ok {
+			return Interval{}, stringutil.Errorf("cannot parse %q as interval: unknown unit %q", s, fields[1])
+		}
+
+		if n, err := strconv.ParseInt(fields[0], 10, 64); err == nil {
+			i.Months += n * unit.Months
+			i.Days += n * unit.Days
+			i.Nanos += n * unit.Nanos
+		} else {
+			f, err := strconv.ParseFloat(fields[0], 64)
+			if err != nil || unit.Nanos == 0 {
+				return Interval{}, stringutil.Errorf("cannot parse %q as interval", s)
+			}
+			i.Nanos += int64(math.Round(f * float64(unit.Nanos)))
+		}
+
+		fields = fields[2:]
+	}
+
+	return i, nil
+}
+
+// parseClock parses a duration of the form [-]HH:MM[:SS[.fraction]] and returns it in nanoseconds.
+func parseClock(s string) (int64, bool) {
+	neg := strings.HasPrefix(s, "-")
+	s = strings.TrimPrefix(s, "-")
+
+	parts := strings.Split(s, ":")
+	if len(parts) < 2 || len(parts) > 3 {
+		return 0, false
+	}
+
+	h, err := strconv.ParseUint(parts[0], 10, 32)
+	if err != nil {
+		return 0, false
+	}
+	m, err := strconv.ParseUint(parts[1], 10, 32)
+	if err != nil || m > 59 {
+		return 0, false
+	}
+
+	nanos := int64(h)*nanosPerHour + int64(m)*nanosPerMinute
+	if len(parts) == 3 {
+		sec, err := strconv.ParseFloat(parts[2], 64)
+		if err != nil || sec < 0 || sec >= 60 {
+			return 0, false
+		}
+		nanos += int64(math.Round(sec * float64(nanosPerSecond)))
+	}
+
+	if neg {
+		nanos = -nanos
+	}
+
+	return nanos, true
+}
+
+// String returns the representation of i parsed by ParseInterval, e.g. 1 year 2 months 3 days 04:05:06.5.
+func (i Interval) String() string {
+	var parts []string
+
+	if y := i.Months / 12; y != 0 {
+		parts = append(parts, formatIntervalUnit(y, "year"))
+	}
+	if m := i.Months % 12; m != 0 {
+		parts = append(parts, formatIntervalUnit(m, "month"))
+	}
+	if i.Days != 0 {
+		parts = append(parts, formatIntervalUnit(i.Days, "day"))
+	}
+	if i.Nanos != 0 || len(parts) == 0 {
+		parts = append(parts, formatClock(i.Nanos))
+	}
+
+	return strings.Join(parts, " ")
+}
+
+func formatIntervalUnit(n int64, unit string) string {
+	s := strconv.FormatInt(n, 10) + " " + unit
+	if n != 1 && n != -1 {
+		s += "s"
+	}
+
+	return s
+}
+
+// formatClock formats nanoseconds as [-]HH:MM:SS[.fraction].
+func formatClock(nanos int64) string {
+	var sb strings.Builder
+
+	if nanos < 0 {
+		sb.WriteByte('-')
+		nanos = -nanos
+	}
+
+	writePadded := func(n int64) {
+		if n < 10 {
+			sb.WriteByte('0')
+		}
+		sb.WriteString(strconv.FormatInt(n, 10))
+	}
+
+	writePadded(nanos / nanosPerHour)
+	sb.WriteByte(':')
+	writePadded(nanos % nanosPerHour / nanosPerMinute)
+	sb.WriteByte(':')
+	writePadded(nanos % nanosPerMinute / nanosPerSecond)
+
+	if frac := nanos % nanosPerSecond; frac != 0 {
+		s := strconv.FormatInt(frac+nanosPerSecond, 10)[1:]
+		sb.WriteByte('.')
+		sb.WriteString(strings.TrimRight(s, "0"))
+	}
+
+	return sb.String()
+}
+
+// normalize returns the number of days of i, counting 30 days per month,
+// and the remaining nanoseconds, between 0 and 24 hours.
+// Intervals with the same normalized value are equal.
+func (i Interval) normalize() (days, nanos int64) {
+	days = i.Months*daysPerMonth + i.Days + i.Nanos/nanosPerDay
+	nanos = i.Nanos % nanosPerDay
+	if nanos < 0 {
+		nanos += nanosPerDay
+		days--
+	}
+
+	return days, nanos
+}
+
+// Neg returns -i.
+func (i Interval) Neg() Interval {
+	return Interval{Months: -i.Months, Days: -i.Days, Nanos: -i.Nanos}
+}
+
+// scale multiplies i by f. The fractional parts of the months and of the days
+// are converted to days and nanoseconds respectively.
+func (i Interval) scale(f float64) Interval {
+	months := float64(i.Months) * f
+	wholeMonths := math.Trunc(months)
+	days := float64(i.Days)*f + (months-wholeMonths)*daysPerMonth
+	wholeDays := math.Trunc(days)
+	nanos := float64(i.Nanos)*f + (days-wholeDays)*float64(nanosPerDay)
+
+	return Interval{Months: int64(wholeMonths), Days: int64(wholeDays), Nanos: int64(math.Round(nanos))}
+}
+
+// AddInterval returns t + i. Months are added first, then days and nanoseconds.
+// If the day of the month of t doesn't exist in the resulting month, the last day
+// of that month is used, e.g. 2021-01-31 + 1 month is 2021-02-28.
+func AddInterval(t time.Time, i Interval) time.Time {
+	if i.Months != 0 {
+		y, m, d := t.Date()
+		months := int64(m) - 1 + i.Months
+		years := months / 12
+		if months%12 < 0 {
+			years--
+		}
+		y += int(years)
+		m = time.Month(months-years*12) + 1
+
+		if last := daysIn(y, m); d > last {
+			d = last
+		}
+		t = time.Date(y, m, d, t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), t.Location())
+	}
+
+	return t.AddDate(0, 0, int(i.Days)).Add(time.Duration(i.Nanos))
+}
+
+// daysIn returns the number of days of the month m of the year y.
+func daysIn(y int, m time.Month) int {
+	return time.Date(y, m+1, 0, 0, 0, 0, 0, time.UTC).Day()
+}
+
+// SubTimes returns the interval between a and b, as a number of days of 24 hours
+// and the remaining nanoseconds, both having the same sign.
+func SubTimes(a, b time.Time) Interval {
+	if a.Before(b) {
+		return SubTimes(b, a).Neg()
+	}
+
+	secs := a.Unix() - b.Unix()
+	nanos := int64(a.Nanosecond() - b.Nanosecond())
+	if nanos < 0 {
+		nanos += nanosPerSecond
+		secs--
+	}
+
+	return Interval{
+		Days:  secs / 86400,
+		Nanos: secs%86400*nanosPerSecond + nanos,
+	}
+}
+
+// Age returns a - b as a number of months, days and nanoseconds, like a person's age,
+// e.g. the age between 2021-03-15 and 2020-01-10 is 1 year 2 months 5 days.
+// Days are borrowed from the month of the earliest time.
+func Age(a, b time.Time) Interval {
+	if a.Before(b) {
+		return Age(b, a).Neg()
+	}
+
+	y1, m1, d1 := a.Date()
+	y2, m2, d2 := b.Date()
+
+	months := int64(y1-y2)*12 + int64(m1-m2)
+	days := int64(d1 - d2)
+	nanos := clockNanos(a) - clockNanos(b)
+
+	if nanos < 0 {
+		nanos += nanosPerDay
+		days--
+	}
+	if days < 0 {
+		days += int64(daysIn(y2, m2))
+		months--
+	}
+
+	return Interval{Months: months, Days: days, Nanos: nanos}
+}
+
+// clockNanos returns the number of nanoseconds elapsed since the beginning of the day of t.
+func clockNanos(t time.Time) int64 {
+	h, m, s := t.Clock()
+	return int64(h)*nanosPerHour + int64(m)*nanosPerMinute + int64(s)*nanosPerSecond + int64(t.Nanosecond())
+}
+
+// AppendInterval appends the binary representation of i used to store intervals.
+// Unlike the representation used in keys, the months, the days and the nanoseconds
+// are kept apart.
+func AppendInterval(buf []byte, i Interval) []byte {
+	buf = binarysort.AppendInt64(buf, i.Months)
+	buf = binarysort.AppendInt64(buf, i.Days)
+	return binarysort.AppendInt64(buf, i.Nanos)
+}
+
+// DecodeInterval decodes an interval encoded with AppendInterval.
+func DecodeInterval(buf []byte) (Interval, error) {
+	if len(buf) < 24 {
+		return Interval{}, errors.New("cannot decode buffer to interval")
+	}
+
+	var i Interval
+	var err error
+	i.Months, err = binarysort.DecodeInt64(buf)
+	if err != nil {
+		return Interval{}, err
+	}
+	i.Days, err = binarysort.DecodeInt64(buf[8:])
+	if err != nil {
+		return Interval{}, err
+	}
+	i.Nanos, err = binarysort.DecodeInt64(buf[16:])
+	if err != nil {
+		return Interval{}, err
+	}
+
+	return i, nil
+}
+
+// appendIntervalKey appends a sort-ordered representation of i,
+// based on its normalized value.
+func appendIntervalKey(buf []byte, i Interval) []byte {
+	days, nanos := i.normalize()
+	buf = binarysort.AppendInt64(buf, days)
+	return binarysort.AppendInt64(buf, nanos)
+}
+
+// calculateTimes adds intervals to dates and timestamps or subtracts them, subtracts
+// dates and timestamps from each other, adds or subtracts intervals, and multiplies
+// or divides intervals by numbers. Dates are converted to timestamps.
+// Any other operation returns NULL.
+func calculateTimes(a, b Value, operator byte) (Value, error) {
+	switch {
+	case a.Type.IsTime() && b.Type == IntervalValue:
+		switch operator {
+		case '+':
+			return NewTimestampValue(AddInterval(a.V.(time.Time), b.V.(Interval))), nil
+		case '-':
+			return NewTimestampValue(AddInterval(a.V.(time.Time), b.V.(Interval).Neg())), nil
+		}
+	case a.Type == IntervalValue && b.Type.IsTime():
+		if operator == '+' {
+			return NewTimestampValue(AddInterval(b.V.(time.Time), a.V.(Interval))), nil
+		}
+	case a.Type.IsTime() && b.Type.IsTime():
+		if operator == '-' {
+			return NewIntervalValue(SubTimes(a.V.(time.Time), b.V.(time.Time))), nil
+		}
+	case a.Type == IntervalValue && b.Type == IntervalValue:
+		x, y := a.V.(Interval), b.V.(Interval)
+		switch operator {
+		case '-':
+			y = y.Neg()
+			fallthrough
+		case '+':
+			return NewIntervalValue(Interval{Months: x.Months + y.Months, Days: x.Days + y.Days, Nanos: x.Nanos + y.Nanos}), nil
+		}
+	case a.Type == IntervalValue && b.Type.IsNumber(), a.Type.IsNumber() && b.Type == IntervalValue:
+		i, n := a, b
+		if b.Type == IntervalValue {
+			if operator != '*' {
+				break
+			}
+			i, n = b, a
+		}
+
+		x := i.V.(Interval)
+		switch operator {
+		case '*':
+			if n.Type == IntegerValue {
+				k := n.V.(int64)
+				return NewIntervalValue(Interval{Months: x.Months * k, Days: x.Days * k, Nanos: x.Nanos * k}), nil
+			}
+			return NewIntervalValue(x.scale(n.V.(float64))), nil
+		case '/':
+			f, err := n.CastAsDouble()
+			if err != nil || f.V.(float64) == 0 {
+				return NewNullValue(), nil
+			}
+			return NewIntervalValue(x.scale(1 / f.V.(float64))), nil
+		}
+	}
+
+	return NewNullValue(), nil
+}
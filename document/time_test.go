@@ -0,0 +1,63 @@
+package document_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/genjidb/genji/document"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseInterval(t *testing.T) {
+	tests := []struct {
+		s        string
+		expected document.Interval
+		str      string
+		fails    bool
+	}{
+		{"1 day", document.Interval{Days: 1}, "1 day", false},
+		{"2 years 3 mons", document.Interval{Months: 27}, "2 years 3 months", false},
+		{"1 week -2 days", document.Interval{Days: 5}, "5 days", false},
+		{"1.5 hours", document.Interval{Nanos: int64(90 * time.Minute)}, "01:30:00", false},
+		{"3 days 04:05:06.5", document.Interval{Days: 3, Nanos: int64(4*time.Hour + 5*time.Minute + 6500*time.Millisecond)}, "3 days 04:05:06.5", false},
+		{"-01:30", document.Interval{Nanos: -int64(90 * time.Minute)}, "-01:30:00", false},
+		{"10 ms 5 us", document.Interval{Nanos: int64(10*time.Millisecond + 5*time.Microsecond)}, "00:00:00.010005", false},
+		{"", document.Interval{}, "", true},
+		{"1.5 days", document.Interval{}, "", true},
+		{"1 fortnight", document.Interval{}, "", true},
+		{"day", document.Interval{}, "", true},
+		{"10:99", document.Interval{}, "", true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.s, func(t *testing.T) {
+			i, err := document.ParseInterval(test.s)
+			if test.fails {
+				require.Error(t, err)
+				return
+			}
+
+			require.NoError(t, err)
+			require.Equal(t, test.expected, i)
+			require.Equal(t, test.str, i.String())
+		})
+	}
+}
+
+func TestAge(t *testing.T) {
+	tests := []struct {
+		a, b     time.Time
+		expected string
+	}{
+		{time.Date(2021, 3, 15, 0, 0, 0, 0, time.UTC), time.Date(2020, 1, 10, 12, 0, 0, 0, time.UTC), "1 year 2 months 4 days 12:00:00"},
+		{time.Date(2021, 3, 1, 0, 0, 0, 0, time.UTC), time.Date(2021, 1, 31, 0, 0, 0, 0, time.UTC), "1 month 1 day"},
+		{time.Date(2020, 1, 10, 0, 0, 0, 0, time.UTC), time.Date(2021, 3, 15, 0, 0, 0, 0, time.UTC), "-1 year -2 months -5 days"},
+		{time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC), time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC), "00:00:00"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.expected, func(t *testing.T) {
+			require.Equal(t, test.expected, document.Age(test.a, test.b).String())
+		})
+	}
+}
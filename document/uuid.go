@@ -0,0 +1,64 @@
+package document
+
+import (
+	"encoding/hex"
+	"strings"
+
+	"github.com/genjidb/genji/internal/stringutil"
+)
+
+// UUID is a universally unique identifier, as defined by RFC 4122.
+// UUIDs are ordered by their bytes, which, for version 7 UUIDs,
+// orders them by creation time.
+type UUID [16]byte
+
+// ParseUUID parses a UUID in its canonical form, i.e. 123e4567-e89b-12d3-a456-426614174000,
+// with or without hyphens and optionally surrounded by braces.
+func ParseUUID(s string) (UUID, error) {
+	var u UUID
+
+	orig := s
+	if strings.HasPrefix(s, "{") && strings.HasSuffix(s, "}") {
+		s = s[1 : len(s)-1]
+	}
+
+	if len(s) == 36 {
+		if s[8] != '-' || s[13] != '-' || s[18] != '-' || s[23] != '-' {
+			return u, stringutil.Errorf("invalid UUID %q", orig)
+		}
+		s = s[:8] + s[9:13] + s[14:18] + s[19:23] + s[24:]
+	}
+
+	if len(s) != 32 {
+		return u, stringutil.Errorf("invalid UUID %q", orig)
+	}
+
+	_, err := hex.Decode(u[:], []byte(s))
+	if err != nil {
+		return u, stringutil.Errorf("invalid UUID %q", orig)
+	}
+
+	return u, nil
+}
+
+// String returns the canonical representation of u, in lower case.
+func (u UUID) String() string {
+	var buf [36]byte
+
+	hex.Encode(buf[0:8], u[0:4])
+	buf[8] = '-'
+	hex.Encode(buf[9:13], u[4:6])
+	buf[13] = '-'
+	hex.Encode(buf[14:18], u[6:8])
+	buf[18] = '-'
+	hex.Encode(buf[19:23], u[8:10])
+	buf[23] = '-'
+	hex.Encode(buf[24:], u[10:])
+
+	return string(buf[:])
+}
+
+// Version returns the version of u, stored in the 4 most significant bits of its 7th byte.
+func (u UUID) Version() int {
+	return int(u[6] >> 4)
+}
@@ -0,0 +1,40 @@
+package document_test
+
+import (
+	"testing"
+
+	"github.com/genjidb/genji/document"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseUUID(t *testing.T) {
+	tests := []struct {
+		s     string
+		fails bool
+	}{
+		{"123e4567-e89b-12d3-a456-426614174000", false},
+		{"123E4567-E89B-12D3-A456-426614174000", false},
+		{"123e4567e89b12d3a456426614174000", false},
+		{"{123e4567-e89b-12d3-a456-426614174000}", false},
+		{"", true},
+		{"123e4567-e89b-12d3-a456-42661417400", true},
+		{"123e4567-e89b-12d3-a456-4266141740000", true},
+		{"123e4567+e89b-12d3-a456-426614174000", true},
+		{"123e4567-e89b-12d3-a456-42661417400g", true},
+		{"{123e4567-e89b-12d3-a456-426614174000", true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.s, func(t *testing.T) {
+			u, err := document.ParseUUID(test.s)
+			if test.fails {
+				require.Error(t, err)
+				return
+			}
+
+			require.NoError(t, err)
+			require.Equal(t, "123e4567-e89b-12d3-a456-426614174000", u.String())
+			require.Equal(t, 1, u.Version())
+		})
+	}
+}
@@ -6,6 +6,8 @@ import (
 	"errors"
 	"math"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/buger/jsonparser"
 	"github.com/genjidb/genji/internal/binarysort"
@@ -36,17 +38,27 @@ const (
 
 	BoolValue ValueType = 0x81
 
+	// time family: 0x88 to 0x8F
+	DateValue      ValueType = 0x88
+	TimestampValue ValueType = 0x89
+	IntervalValue  ValueType = 0x8A
+
 	// integer family: 0x90 to 0x9F
 	IntegerValue ValueType = 0x90
 
 	// double family: 0xA0 to 0xAF
-	DoubleValue ValueType = 0xA0
+	DoubleValue  ValueType = 0xA0
+	DecimalValue ValueType = 0xA8
+
+	// vector family: 0xB0 to 0xBF
+	VectorValue ValueType = 0xB0
 
 	// string family: 0xC0 to 0xCF
 	TextValue ValueType = 0xC0
 
 	// blob family: 0xD0 to 0xDF
 	BlobValue ValueType = 0xD0
+	UUIDValue ValueType = 0xD8
 
 	// array family: 0xE0 to 0xEF
 	ArrayValue ValueType = 0xE0
@@ -61,12 +73,24 @@ func (t ValueType) String() string {
 		return "null"
 	case BoolValue:
 		return "bool"
+	case DateValue:
+		return "date"
+	case TimestampValue:
+		return "timestamp"
+	case IntervalValue:
+		return "interval"
 	case IntegerValue:
 		return "integer"
 	case DoubleValue:
 		return "double"
+	case DecimalValue:
+		return "decimal"
+	case VectorValue:
+		return "vector"
 	case BlobValue:
 		return "blob"
+	case UUIDValue:
+		return "uuid"
 	case TextValue:
 		return "text"
 	case ArrayValue:
@@ -78,9 +102,14 @@ func (t ValueType) String() string {
 	return ""
 }
 
-// IsNumber returns true if t is either an integer of a float.
+// IsNumber returns true if t is either an integer, a float or a decimal.
 func (t ValueType) IsNumber() bool {
-	return t == IntegerValue || t == DoubleValue
+	return t == IntegerValue || t == DoubleValue || t == DecimalValue
+}
+
+// IsTime returns true if t is either a date or a timestamp.
+func (t ValueType) IsTime() bool {
+	return t == DateValue || t == TimestampValue
 }
 
 // IsAny returns whether this is type is Any or a real type
@@ -126,6 +155,48 @@ func NewDoubleValue(x float64) Value {
 	}
 }
 
+// NewDecimalValue returns a value of type Decimal.
+func NewDecimalValue(x Decimal) Value {
+	return Value{
+		Type: DecimalValue,
+		V:    x,
+	}
+}
+
+// NewTimestampValue returns a value of type Timestamp.
+// Timestamps are stored in UTC, with a nanosecond precision.
+func NewTimestampValue(x time.Time) Value {
+	return Value{
+		Type: TimestampValue,
+		V:    x.UTC(),
+	}
+}
+
+// NewDateValue returns a value of type Date holding the date of x, in the location of x.
+// Dates are stored as the midnight UTC of that date.
+func NewDateValue(x time.Time) Value {
+	return Value{
+		Type: DateValue,
+		V:    toDate(x),
+	}
+}
+
+// NewIntervalValue returns a value of type Interval.
+func NewIntervalValue(x Interval) Value {
+	return Value{
+		Type: IntervalValue,
+		V:    x,
+	}
+}
+
+// NewVectorValue encodes x and returns a value.
+func NewVectorValue(x []float64) Value {
+	return Value{
+		Type: VectorValue,
+		V:    x,
+	}
+}
+
 // NewBlobValue encodes x and returns a value.
 func NewBlobValue(x []byte) Value {
 	return Value{
@@ -134,6 +205,14 @@ func NewBlobValue(x []byte) Value {
 	}
 }
 
+// NewUUIDValue returns a value of type UUID.
+func NewUUIDValue(x UUID) Value {
+	return Value{
+		Type: UUIDValue,
+		V:    x,
+	}
+}
+
 // NewTextValue encodes x and returns a value.
 func NewTextValue(x string) Value {
 	return Value{
@@ -178,8 +257,24 @@ func (v Value) IsZeroValue() (bool, error) {
 		return v.V == int64(0), nil
 	case DoubleValue:
 		return v.V == float64(0), nil
+	case DecimalValue:
+		return v.V.(Decimal).Sign() == 0, nil
+	case DateValue, TimestampValue:
+		return v.V.(time.Time).IsZero(), nil
+	case IntervalValue:
+		return v.V.(Interval) == Interval{}, nil
+	case VectorValue:
+		// The zero value of a vector is a vector whose components are all zero.
+		for _, x := range v.V.([]float64) {
+			if x != 0 {
+				return false, nil
+			}
+		}
+		return true, nil
 	case BlobValue:
 		return v.V == nil, nil
+	case UUIDValue:
+		return v.V == UUID{}, nil
 	case TextValue:
 		return v.V == "", nil
 	case ArrayValue:
@@ -223,22 +318,30 @@ func (v Value) MarshalJSON() ([]byte, error) {
 	case IntegerValue:
 		return strconv.AppendInt(nil, v.V.(int64), 10), nil
 	case DoubleValue:
-		f := v.V.(float64)
-		abs := math.Abs(f)
-		fmt := byte('f')
-		if abs != 0 {
-			if abs < 1e-6 || abs >= 1e21 {
-				fmt = 'e'
+		return appendJSONFloat(nil, v.V.(float64)), nil
+	case DecimalValue:
+		return []byte(v.V.(Decimal).String()), nil
+	case DateValue:
+		return []byte(strconv.Quote(v.V.(time.Time).Format(DateLayout))), nil
+	case TimestampValue:
+		return []byte(strconv.Quote(v.V.(time.Time).Format(time.RFC3339Nano))), nil
+	case IntervalValue:
+		return []byte(strconv.Quote(v.V.(Interval).String())), nil
+	case VectorValue:
+		vec := v.V.([]float64)
+		buf := make([]byte, 0, 2+len(vec)*8)
+		buf = append(buf, '[')
+		for i, x := range vec {
+			if i > 0 {
+				buf = append(buf, ',', ' ')
 			}
+			buf = appendJSONFloat(buf, x)
 		}
-
-		// By default the precision is -1 to use the smallest number of digits.
-		// See https://pkg.go.dev/strconv#FormatFloat
-		prec := -1
-
-		return strconv.AppendFloat(nil, v.V.(float64), fmt, prec, 64), nil
+		return append(buf, ']'), nil
 	case TextValue:
 		return []byte(strconv.Quote(v.V.(string))), nil
+	case UUIDValue:
+		return []byte(strconv.Quote(v.V.(UUID).String())), nil
 	case BlobValue:
 		src := v.V.([]byte)
 		dst := make([]byte, base64.StdEncoding.EncodedLen(len(src))+2)
@@ -255,6 +358,22 @@ func (v Value) MarshalJSON() ([]byte, error) {
 	}
 }
 
+func appendJSONFloat(buf []byte, f float64) []byte {
+	abs := math.Abs(f)
+	fmt := byte('f')
+	if abs != 0 {
+		if abs < 1e-6 || abs >= 1e21 {
+			fmt = 'e'
+		}
+	}
+
+	// By default the precision is -1 to use the smallest number of digits.
+	// See https://pkg.go.dev/strconv#FormatFloat
+	prec := -1
+
+	return strconv.AppendFloat(buf, f, fmt, prec, 64)
+}
+
 // String returns a string representation of the value. It implements the fmt.Stringer interface.
 func (v Value) String() string {
 	switch v.Type {
@@ -264,6 +383,12 @@ func (v Value) String() string {
 		return strconv.Quote(v.V.(string))
 	case BlobValue:
 		return stringutil.Sprintf("%v", v.V)
+	case DateValue, TimestampValue, IntervalValue, UUIDValue:
+		// use the syntax of date, timestamp, interval and uuid literals
+		d, _ := v.MarshalJSON()
+		return strings.ToUpper(v.Type.String()) + " " + string(d)
+	case DecimalValue:
+		return "DECIMAL " + strconv.Quote(v.V.(Decimal).String())
 	}
 
 	d, _ := v.MarshalJSON()
@@ -276,6 +401,9 @@ func (v Value) Append(buf []byte) ([]byte, error) {
 	switch v.Type {
 	case BlobValue:
 		return append(buf, v.V.([]byte)...), nil
+	case UUIDValue:
+		u := v.V.(UUID)
+		return append(buf, u[:]...), nil
 	case TextValue:
 		return append(buf, v.V.(string)...), nil
 	case BoolValue:
@@ -284,6 +412,17 @@ func (v Value) Append(buf []byte) ([]byte, error) {
 		return binarysort.AppendInt64(buf, v.V.(int64)), nil
 	case DoubleValue:
 		return binarysort.AppendFloat64(buf, v.V.(float64)), nil
+	case DecimalValue:
+		return appendDecimalKey(buf, v.V.(Decimal)), nil
+	case DateValue, TimestampValue:
+		return binarysort.AppendTime(buf, v.V.(time.Time)), nil
+	case IntervalValue:
+		return appendIntervalKey(buf, v.V.(Interval)), nil
+	case VectorValue:
+		for _, x := range v.V.([]float64) {
+			buf = binarysort.AppendFloat64(buf, x)
+		}
+		return buf, nil
 	case NullValue:
 		return buf, nil
 	case ArrayValue:
@@ -364,6 +503,23 @@ func (v Value) BitwiseXor(u Value) (res Value, err error) {
 	return calculateValues(v, u, '^')
 }
 
+// ShiftLeft calculates v << u and returns the result.
+// Only numeric values can be calculated together, doubles are converted
+// to integers first. Bits shifted past the 64th bit are discarded, shifting by
+// 64 or more returns 0 and shifting by a negative amount returns NULL.
+func (v Value) ShiftLeft(u Value) (res Value, err error) {
+	return calculateValues(v, u, '<')
+}
+
+// ShiftRight calculates v >> u and returns the result.
+// Only numeric values can be calculated together, doubles are converted
+// to integers first. The shift is arithmetic: the sign bit is preserved,
+// shifting by 64 or more returns 0 or -1 depending on the sign of v and
+// shifting by a negative amount returns NULL.
+func (v Value) ShiftRight(u Value) (res Value, err error) {
+	return calculateValues(v, u, '>')
+}
+
 func calculateValues(a, b Value, operator byte) (res Value, err error) {
 	if a.Type == NullValue || b.Type == NullValue {
 		return NewNullValue(), nil
@@ -373,11 +529,19 @@ func calculateValues(a, b Value, operator byte) (res Value, err error) {
 		return NewNullValue(), nil
 	}
 
+	if a.Type.IsTime() || b.Type.IsTime() || a.Type == IntervalValue || b.Type == IntervalValue {
+		return calculateTimes(a, b, operator)
+	}
+
 	if a.Type.IsNumber() && b.Type.IsNumber() {
 		if a.Type == DoubleValue || b.Type == DoubleValue {
 			return calculateFloats(a, b, operator)
 		}
 
+		if a.Type == DecimalValue || b.Type == DecimalValue {
+			return calculateDecimals(a, b, operator)
+		}
+
 		return calculateIntegers(a, b, operator)
 	}
 
@@ -446,6 +610,8 @@ func calculateIntegers(a, b Value, operator byte) (res Value, err error) {
 		return NewIntegerValue(xa | xb), nil
 	case '^':
 		return NewIntegerValue(xa ^ xb), nil
+	case '<', '>':
+		return shiftIntegers(xa, xb, operator)
 	default:
 		panic(stringutil.Sprintf("unknown operator %c", operator))
 	}
@@ -496,11 +662,29 @@ func calculateFloats(a, b Value, operator byte) (res Value, err error) {
 	case '^':
 		ia, ib := int64(xa), int64(xb)
 		return NewIntegerValue(ia ^ ib), nil
+	case '<', '>':
+		return shiftIntegers(int64(xa), int64(xb), operator)
 	default:
 		panic(stringutil.Sprintf("unknown operator %c", operator))
 	}
 }
 
+// shiftIntegers shifts a by n bits, to the left if operator is '<'
+// and to the right otherwise. Negative shift counts return NULL.
+func shiftIntegers(a, n int64, operator byte) (Value, error) {
+	if n < 0 {
+		return NewNullValue(), nil
+	}
+
+	// Go defines shifts larger than the width of the operand:
+	// a << n is 0 and a >> n is 0 or -1 depending on the sign of a.
+	if operator == '<' {
+		return NewIntegerValue(a << uint64(n)), nil
+	}
+
+	return NewIntegerValue(a >> uint64(n)), nil
+}
+
 func parseJSONValue(dataType jsonparser.ValueType, data []byte) (v Value, err error) {
 	switch dataType {
 	case jsonparser.Null:
@@ -3,6 +3,7 @@ package document
 import (
 	"errors"
 	"io"
+	"time"
 
 	"github.com/genjidb/genji/internal/binarysort"
 )
@@ -63,6 +64,9 @@ func (ve *ValueEncoder) appendValue(v Value) error {
 	switch v.Type {
 	case BlobValue:
 		ve.buf, err = binarysort.AppendBase64(ve.buf, v.V.([]byte))
+	case UUIDValue:
+		u := v.V.(UUID)
+		ve.buf = append(ve.buf, u[:]...)
 	case TextValue:
 		text := v.V.(string)
 		ve.buf, err = binarysort.AppendBase64(ve.buf, []byte(text))
@@ -72,6 +76,16 @@ func (ve *ValueEncoder) appendValue(v Value) error {
 		ve.buf = binarysort.AppendInt64(ve.buf, v.V.(int64))
 	case DoubleValue:
 		ve.buf = binarysort.AppendFloat64(ve.buf, v.V.(float64))
+	case DecimalValue:
+		ve.buf = appendDecimalKey(ve.buf, v.V.(Decimal))
+	case DateValue, TimestampValue:
+		ve.buf = binarysort.AppendTime(ve.buf, v.V.(time.Time))
+	case IntervalValue:
+		ve.buf = appendIntervalKey(ve.buf, v.V.(Interval))
+	case VectorValue:
+		for _, x := range v.V.([]float64) {
+			ve.buf = binarysort.AppendFloat64(ve.buf, x)
+		}
 	default:
 		return errors.New("cannot encode type " + v.Type.String() + " as key")
 	}
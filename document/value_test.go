@@ -25,6 +25,10 @@ func TestValueString(t *testing.T) {
 		{"big double", document.NewDoubleValue(1e21), "1e+21"},
 		{"document", document.NewDocumentValue(document.NewFieldBuffer().Add("a", document.NewIntegerValue(10))), "{\"a\": 10}"},
 		{"array", document.NewArrayValue(document.NewValueBuffer(document.NewIntegerValue(10))), "[10]"},
+		{"date", document.NewDateValue(time.Date(2021, 1, 2, 10, 0, 0, 0, time.UTC)), `DATE "2021-01-02"`},
+		{"timestamp", document.NewTimestampValue(time.Date(2021, 1, 2, 10, 0, 0, 5, time.FixedZone("", 3600))), `TIMESTAMP "2021-01-02T09:00:00.000000005Z"`},
+		{"interval", document.NewIntervalValue(document.Interval{Months: 13, Days: 2, Nanos: int64(90 * time.Minute)}), `INTERVAL "1 year 1 month 2 days 01:30:00"`},
+		{"decimal", document.NewDecimalValue(document.NewDecimal(-1050, 2)), `DECIMAL "-10.50"`},
 	}
 
 	for _, test := range tests {
@@ -70,7 +74,7 @@ func TestNewValue(t *testing.T) {
 		{"null", nil, nil},
 		{"document", document.NewFieldBuffer().Add("a", document.NewIntegerValue(10)), document.NewFieldBuffer().Add("a", document.NewIntegerValue(10))},
 		{"array", document.NewValueBuffer(document.NewIntegerValue(10)), document.NewValueBuffer(document.NewIntegerValue(10))},
-		{"time", now, now.Format(time.RFC3339Nano)},
+		{"time", now, now.UTC()},
 		{"bytes", myBytes("bar"), []byte("bar")},
 		{"string", myString("bar"), "bar"},
 		{"myUint", myUint(10), int64(10)},
@@ -114,6 +118,10 @@ func TestValueAdd(t *testing.T) {
 		{"text('120')+text('120')", document.NewTextValue("120"), document.NewTextValue("120"), document.NewNullValue(), false},
 		{"document+document", document.NewDocumentValue(document.NewFieldBuffer().Add("a", document.NewIntegerValue(10))), document.NewDocumentValue(document.NewFieldBuffer().Add("a", document.NewIntegerValue(10))), document.NewNullValue(), false},
 		{"array+array", document.NewArrayValue(document.NewValueBuffer(document.NewIntegerValue(10))), document.NewArrayValue(document.NewValueBuffer(document.NewIntegerValue(10))), document.NewNullValue(), false},
+		{"timestamp+interval", document.NewTimestampValue(time.Date(2021, 1, 31, 10, 0, 0, 0, time.UTC)), document.NewIntervalValue(document.Interval{Months: 1, Nanos: int64(time.Hour)}), document.NewTimestampValue(time.Date(2021, 2, 28, 11, 0, 0, 0, time.UTC)), false},
+		{"interval+date", document.NewIntervalValue(document.Interval{Days: 2}), document.NewDateValue(time.Date(2021, 2, 28, 0, 0, 0, 0, time.UTC)), document.NewTimestampValue(time.Date(2021, 3, 2, 0, 0, 0, 0, time.UTC)), false},
+		{"interval+interval", document.NewIntervalValue(document.Interval{Days: 1}), document.NewIntervalValue(document.Interval{Months: 1, Nanos: 5}), document.NewIntervalValue(document.Interval{Months: 1, Days: 1, Nanos: 5}), false},
+		{"timestamp+timestamp", document.NewTimestampValue(time.Date(2021, 1, 31, 10, 0, 0, 0, time.UTC)), document.NewTimestampValue(time.Date(2021, 1, 31, 10, 0, 0, 0, time.UTC)), document.NewNullValue(), false},
 	}
 
 	for _, test := range tests {
@@ -150,6 +158,10 @@ func TestValueSub(t *testing.T) {
 		{"text('120')-text('120')", document.NewTextValue("120"), document.NewTextValue("120"), document.NewNullValue(), false},
 		{"document-document", document.NewDocumentValue(document.NewFieldBuffer().Add("a", document.NewIntegerValue(10))), document.NewDocumentValue(document.NewFieldBuffer().Add("a", document.NewIntegerValue(10))), document.NewNullValue(), false},
 		{"array-array", document.NewArrayValue(document.NewValueBuffer(document.NewIntegerValue(10))), document.NewArrayValue(document.NewValueBuffer(document.NewIntegerValue(10))), document.NewNullValue(), false},
+		{"timestamp-interval", document.NewTimestampValue(time.Date(2021, 3, 31, 10, 0, 0, 0, time.UTC)), document.NewIntervalValue(document.Interval{Months: 1}), document.NewTimestampValue(time.Date(2021, 2, 28, 10, 0, 0, 0, time.UTC)), false},
+		{"timestamp-timestamp", document.NewTimestampValue(time.Date(2021, 3, 1, 12, 0, 0, 0, time.UTC)), document.NewTimestampValue(time.Date(2021, 1, 1, 10, 30, 0, 0, time.UTC)), document.NewIntervalValue(document.Interval{Days: 59, Nanos: int64(90 * time.Minute)}), false},
+		{"date-date", document.NewDateValue(time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)), document.NewDateValue(time.Date(2021, 1, 3, 0, 0, 0, 0, time.UTC)), document.NewIntervalValue(document.Interval{Days: -2}), false},
+		{"interval-timestamp", document.NewIntervalValue(document.Interval{Days: 1}), document.NewTimestampValue(time.Date(2021, 1, 1, 10, 30, 0, 0, time.UTC)), document.NewNullValue(), false},
 	}
 
 	for _, test := range tests {
@@ -184,6 +196,8 @@ func TestValueMult(t *testing.T) {
 		{"text('120')*text('120')", document.NewTextValue("120"), document.NewTextValue("120"), document.NewNullValue(), false},
 		{"document*document", document.NewDocumentValue(document.NewFieldBuffer().Add("a", document.NewIntegerValue(10))), document.NewDocumentValue(document.NewFieldBuffer().Add("a", document.NewIntegerValue(10))), document.NewNullValue(), false},
 		{"array*array", document.NewArrayValue(document.NewValueBuffer(document.NewIntegerValue(10))), document.NewArrayValue(document.NewValueBuffer(document.NewIntegerValue(10))), document.NewNullValue(), false},
+		{"interval*double(1.5)", document.NewIntervalValue(document.Interval{Days: 1}), document.NewDoubleValue(1.5), document.NewIntervalValue(document.Interval{Days: 1, Nanos: int64(12 * time.Hour)}), false},
+		{"integer(2)*interval", document.NewIntegerValue(2), document.NewIntervalValue(document.Interval{Months: 1, Nanos: 3}), document.NewIntervalValue(document.Interval{Months: 2, Nanos: 6}), false},
 	}
 
 	for _, test := range tests {
@@ -219,6 +233,8 @@ func TestValueDiv(t *testing.T) {
 		{"text('120')/text('120')", document.NewTextValue("120"), document.NewTextValue("120"), document.NewNullValue(), false},
 		{"document/document", document.NewDocumentValue(document.NewFieldBuffer().Add("a", document.NewIntegerValue(10))), document.NewDocumentValue(document.NewFieldBuffer().Add("a", document.NewIntegerValue(10))), document.NewNullValue(), false},
 		{"array/array", document.NewArrayValue(document.NewValueBuffer(document.NewIntegerValue(10))), document.NewArrayValue(document.NewValueBuffer(document.NewIntegerValue(10))), document.NewNullValue(), false},
+		{"interval/integer(3)", document.NewIntervalValue(document.Interval{Days: 1}), document.NewIntegerValue(3), document.NewIntervalValue(document.Interval{Nanos: int64(8 * time.Hour)}), false},
+		{"interval/integer(0)", document.NewIntervalValue(document.Interval{Days: 1}), document.NewIntegerValue(0), document.NewNullValue(), false},
 	}
 
 	for _, test := range tests {
@@ -369,3 +385,40 @@ func TestValueBitwiseXor(t *testing.T) {
 		})
 	}
 }
+
+func TestValueShift(t *testing.T) {
+	tests := []struct {
+		name           string
+		v, u, expected document.Value
+		left           bool
+	}{
+		{"null<<integer(1)", document.NewNullValue(), document.NewIntegerValue(1), document.NewNullValue(), true},
+		{"bool(true)<<integer(1)", document.NewBoolValue(true), document.NewIntegerValue(1), document.NewNullValue(), true},
+		{"text('1')<<integer(1)", document.NewTextValue("1"), document.NewIntegerValue(1), document.NewNullValue(), true},
+		{"integer(1)<<integer(4)", document.NewIntegerValue(1), document.NewIntegerValue(4), document.NewIntegerValue(16), true},
+		{"integer(1)<<integer(63)", document.NewIntegerValue(1), document.NewIntegerValue(63), document.NewIntegerValue(math.MinInt64), true},
+		{"integer(3)<<integer(63)", document.NewIntegerValue(3), document.NewIntegerValue(63), document.NewIntegerValue(math.MinInt64), true},
+		{"integer(1)<<integer(64)", document.NewIntegerValue(1), document.NewIntegerValue(64), document.NewIntegerValue(0), true},
+		{"integer(1)<<integer(-1)", document.NewIntegerValue(1), document.NewIntegerValue(-1), document.NewNullValue(), true},
+		{"double(2.5)<<double(1.9)", document.NewDoubleValue(2.5), document.NewDoubleValue(1.9), document.NewIntegerValue(4), true},
+		{"integer(256)>>integer(4)", document.NewIntegerValue(256), document.NewIntegerValue(4), document.NewIntegerValue(16), false},
+		{"integer(-256)>>integer(4)", document.NewIntegerValue(-256), document.NewIntegerValue(4), document.NewIntegerValue(-16), false},
+		{"integer(1)>>integer(64)", document.NewIntegerValue(1), document.NewIntegerValue(64), document.NewIntegerValue(0), false},
+		{"integer(-1)>>integer(64)", document.NewIntegerValue(-1), document.NewIntegerValue(64), document.NewIntegerValue(-1), false},
+		{"integer(1)>>integer(-1)", document.NewIntegerValue(1), document.NewIntegerValue(-1), document.NewNullValue(), false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var res document.Value
+			var err error
+			if test.left {
+				res, err = test.v.ShiftLeft(test.u)
+			} else {
+				res, err = test.v.ShiftRight(test.u)
+			}
+			require.NoError(t, err)
+			require.Equal(t, test.expected, res)
+		})
+	}
+}
@@ -0,0 +1,49 @@
+package document
+
+import (
+	"errors"
+	"math"
+
+	"github.com/genjidb/genji/internal/stringutil"
+)
+
+// ErrZeroVector is returned when computing the cosine distance
+// of a vector whose norm is zero.
+var ErrZeroVector = errors.New("cannot compute the cosine distance of a zero vector")
+
+// CosineDistance returns 1 minus the cosine similarity of a and b.
+// The result ranges from 0, for vectors pointing in the same direction,
+// to 2, for vectors pointing in opposite directions.
+func CosineDistance(a, b []float64) (float64, error) {
+	if len(a) != len(b) {
+		return 0, stringutil.Errorf("cannot compare vectors of dimension %d and %d", len(a), len(b))
+	}
+
+	var dot, na, nb float64
+	for i := range a {
+		dot += a[i] * b[i]
+		na += a[i] * a[i]
+		nb += b[i] * b[i]
+	}
+
+	if na == 0 || nb == 0 {
+		return 0, ErrZeroVector
+	}
+
+	return 1 - dot/(math.Sqrt(na)*math.Sqrt(nb)), nil
+}
+
+// EuclideanDistance returns the euclidean (L2) distance between a and b.
+func EuclideanDistance(a, b []float64) (float64, error) {
+	if len(a) != len(b) {
+		return 0, stringutil.Errorf("cannot compare vectors of dimension %d and %d", len(a), len(b))
+	}
+
+	var sum float64
+	for i := range a {
+		d := a[i] - b[i]
+		sum += d * d
+	}
+
+	return math.Sqrt(sum), nil
+}
@@ -0,0 +1,56 @@
+package document_test
+
+import (
+	"math"
+	"testing"
+
+	"github.com/genjidb/genji/document"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCosineDistance(t *testing.T) {
+	tests := []struct {
+		a, b  []float64
+		want  float64
+		fails bool
+	}{
+		{[]float64{1, 0}, []float64{2, 0}, 0, false},
+		{[]float64{1, 0}, []float64{0, 1}, 1, false},
+		{[]float64{1, 0}, []float64{-1, 0}, 2, false},
+		{[]float64{1, 0}, []float64{0, 0}, 0, true},
+		{[]float64{1, 0}, []float64{1, 0, 0}, 0, true},
+	}
+
+	for _, test := range tests {
+		got, err := document.CosineDistance(test.a, test.b)
+		if test.fails {
+			require.Error(t, err)
+			continue
+		}
+		require.NoError(t, err)
+		require.InDelta(t, test.want, got, 1e-9)
+	}
+}
+
+func TestEuclideanDistance(t *testing.T) {
+	tests := []struct {
+		a, b  []float64
+		want  float64
+		fails bool
+	}{
+		{[]float64{1, 0}, []float64{1, 0}, 0, false},
+		{[]float64{0, 0}, []float64{3, 4}, 5, false},
+		{[]float64{1, 1, 1}, []float64{0, 0, 0}, math.Sqrt(3), false},
+		{[]float64{1, 0}, []float64{1, 0, 0}, 0, true},
+	}
+
+	for _, test := range tests {
+		got, err := document.EuclideanDistance(test.a, test.b)
+		if test.fails {
+			require.Error(t, err)
+			continue
+		}
+		require.NoError(t, err)
+		require.InDelta(t, test.want, got, 1e-9)
+	}
+}
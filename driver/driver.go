@@ -1,3 +1,5 @@
+// +build !wasm
+
 package driver
 
 import (
@@ -6,8 +8,11 @@ import (
 	"database/sql/driver"
 	"errors"
 	"io"
+	"reflect"
 	"runtime"
+	"strings"
 	"sync"
+	"time"
 
 	"github.com/genjidb/genji"
 	"github.com/genjidb/genji/document"
@@ -59,8 +64,11 @@ type connector struct {
 	closeOnce sync.Once
 }
 
+// Connect returns a new connection to the database.
+// Each connection has its own session, whose settings can be
+// modified using the SET statement without affecting other connections.
 func (c *connector) Connect(ctx context.Context) (driver.Conn, error) {
-	return &conn{db: c.db}, nil
+	return &conn{db: c.db.NewSession()}, nil
 }
 
 func (c *connector) Driver() driver.Driver {
@@ -150,6 +158,13 @@ func (c *conn) Rollback() error {
 	return err
 }
 
+var (
+	_ driver.Stmt              = stmt{}
+	_ driver.StmtExecContext   = stmt{}
+	_ driver.StmtQueryContext  = stmt{}
+	_ driver.NamedValueChecker = stmt{}
+)
+
 // Stmt is a prepared statement. It is bound to a Conn and not
 // used by multiple goroutines concurrently.
 type stmt struct {
@@ -157,7 +172,7 @@ type stmt struct {
 }
 
 // NumInput returns the number of placeholder parameters.
-func (s stmt) NumInput() int { return -1 }
+func (s stmt) NumInput() int { return s.stmt.NumParams() }
 
 // Exec executes a query that doesn't return rows, such
 // as an INSERT or UPDATE.
@@ -188,7 +203,7 @@ func (s stmt) CheckNamedValue(nv *driver.NamedValue) error {
 }
 
 // ExecContext executes a query that doesn't return rows, such
-// as an INSERT or UPDATE.
+// as an INSERT or UPDATE. All the statements of the query are executed.
 func (s stmt) ExecContext(ctx context.Context, args []driver.NamedValue) (driver.Result, error) {
 	select {
 	case <-ctx.Done():
@@ -196,7 +211,7 @@ func (s stmt) ExecContext(ctx context.Context, args []driver.NamedValue) (driver
 	default:
 	}
 
-	return result{}, s.stmt.Exec(driverNamedValueToParams(args)...)
+	return result{}, s.stmt.WithContext(ctx).Exec(driverNamedValueToParams(args)...)
 }
 
 type result struct{}
@@ -216,7 +231,10 @@ func (s stmt) Query(args []driver.Value) (driver.Rows, error) {
 }
 
 // QueryContext executes a query that may return rows, such as a
-// SELECT.
+// SELECT. Each statement of the query returning documents produces a result set,
+// the others are executed when reaching them. The last statement always produces
+// a result set.
+// The context is used to cancel the query, including during the iteration of the rows.
 func (s stmt) QueryContext(ctx context.Context, args []driver.NamedValue) (driver.Rows, error) {
 	select {
 	case <-ctx.Done():
@@ -224,14 +242,18 @@ func (s stmt) QueryContext(ctx context.Context, args []driver.NamedValue) (drive
 	default:
 	}
 
-	res, err := s.stmt.Query(driverNamedValueToParams(args)...)
+	r := rows{
+		ctx:   ctx,
+		stmts: s.stmt.WithContext(ctx).Statements(),
+		args:  driverNamedValueToParams(args),
+	}
+
+	err := r.NextResultSet()
 	if err != nil {
 		return nil, err
 	}
 
-	rs := newRecordStream(res)
-	rs.fields = res.Fields()
-	return rs, nil
+	return &r, nil
 }
 
 func driverNamedValueToParams(args []driver.NamedValue) []interface{} {
@@ -251,105 +273,114 @@ func (s stmt) Close() error {
 	return nil
 }
 
-var errStop = errors.New("stop")
-
-type documentStream struct {
-	res      *genji.Result
-	cancelFn func()
-	c        chan doc
-	wg       sync.WaitGroup
-	fields   []string
-}
-
-type doc struct {
-	d   document.Document
-	err error
-}
-
-func newRecordStream(res *genji.Result) *documentStream {
-	ctx, cancel := context.WithCancel(context.Background())
+var (
+	_ driver.Rows                           = (*rows)(nil)
+	_ driver.RowsNextResultSet              = (*rows)(nil)
+	_ driver.RowsColumnTypeScanType         = (*rows)(nil)
+	_ driver.RowsColumnTypeDatabaseTypeName = (*rows)(nil)
+)
 
-	ds := documentStream{
-		res:      res,
-		cancelFn: cancel,
-		c:        make(chan doc),
+// rows iterates over the result sets of the statements of a query.
+type rows struct {
+	ctx context.Context
+	// result of the current result set.
+	res    *genji.Result
+	fields []string
+	types  []document.ValueType
+	// statements that haven't been run yet.
+	stmts []*genji.Statement
+	args  []interface{}
+}
+
+// HasNextResultSet reports whether there are statements left to run.
+func (r *rows) HasNextResultSet() bool {
+	return len(r.stmts) > 0
+}
+
+// NextResultSet closes the current result set and runs the following statements,
+// until one of them returns documents.
+// It returns io.EOF if none of them do.
+func (r *rows) NextResultSet() error {
+	if r.res != nil {
+		err := r.res.Close()
+		r.res = nil
+		if err != nil {
+			return err
+		}
 	}
-	ds.wg.Add(1)
-
-	go ds.iterate(ctx)
-
-	return &ds
-}
 
-func (rs *documentStream) iterate(ctx context.Context) {
-	defer rs.wg.Done()
-	defer close(rs.c)
+	for len(r.stmts) > 0 {
+		s := r.stmts[0]
+		r.stmts = r.stmts[1:]
 
-	select {
-	case <-ctx.Done():
-		return
-	case <-rs.c:
-	}
+		res, err := s.Query(r.args...)
+		if err != nil {
+			return err
+		}
 
-	err := rs.res.Iterate(func(d document.Document) error {
-		select {
-		case <-ctx.Done():
-			return errStop
-		case rs.c <- doc{
-			d: d,
-		}:
-
-			select {
-			case <-ctx.Done():
-				return errStop
-			case <-rs.c:
-				return nil
+		if res.Fields() == nil && len(r.stmts) > 0 {
+			err = res.Iterate(func(d document.Document) error { return nil })
+			if er := res.Close(); err == nil {
+				err = er
+			}
+			if err != nil {
+				return err
 			}
+			continue
 		}
-	})
 
-	if err == errStop || err == nil {
-		return
-	}
-	if err != nil {
-		rs.c <- doc{
-			err: err,
-		}
-		return
+		r.res = res
+		r.fields = res.Fields()
+		r.types = res.FieldTypes()
+		return nil
 	}
+
+	return io.EOF
 }
 
-// Columns returns the fields selected by the SELECT statement.
-func (rs *documentStream) Columns() []string {
-	return rs.res.Fields()
+// Columns returns the fields selected by the current statement.
+func (r *rows) Columns() []string {
+	return r.fields
 }
 
-// Close closes the rows iterator.
-func (rs *documentStream) Close() error {
-	rs.cancelFn()
-	return rs.res.Close()
+// Close closes the current result set, without running the remaining statements.
+func (r *rows) Close() error {
+	r.stmts = nil
+	if r.res == nil {
+		return nil
+	}
+
+	err := r.res.Close()
+	r.res = nil
+	return err
 }
 
-func (rs *documentStream) Next(dest []driver.Value) error {
-	rs.c <- doc{}
+// Next fetches the next document of the current result set.
+// If the context of the query is canceled, the iteration is aborted and
+// Next returns the error of the context.
+func (r *rows) Next(dest []driver.Value) error {
+	if !r.res.Next(r.ctx) {
+		if err := r.res.Err(); err != nil {
+			return err
+		}
 
-	doc, ok := <-rs.c
-	if !ok {
 		return io.EOF
 	}
 
-	if doc.err != nil {
-		return doc.err
-	}
-
-	for i := range rs.fields {
-		if rs.fields[i] == "*" {
-			dest[i] = doc.d
+	d := r.res.Document()
+	for i := range r.fields {
+		if r.fields[i] == "*" {
+			dest[i] = d
 
 			continue
 		}
 
-		f, err := doc.d.GetByField(rs.fields[i])
+		f, err := d.GetByField(r.fields[i])
+		if err == document.ErrFieldNotFound {
+			// missing fields are returned as NULL
+			dest[i] = nil
+			continue
+		}
 		if err != nil {
 			return err
 		}
@@ -360,6 +391,65 @@ func (rs *documentStream) Next(dest []driver.Value) error {
 	return nil
 }
 
+// ColumnTypeScanType returns the type of the Go values of the column,
+// or the empty interface type if it is unknown.
+func (r *rows) ColumnTypeScanType(index int) reflect.Type {
+	if r.fields[index] == "*" {
+		return reflect.TypeOf((*document.Document)(nil)).Elem()
+	}
+
+	var x interface{}
+	switch r.columnType(index) {
+	case document.BoolValue:
+		x = false
+	case document.IntegerValue:
+		x = int64(0)
+	case document.DoubleValue:
+		x = float64(0)
+	case document.DecimalValue:
+		x = document.Decimal{}
+	case document.TimestampValue, document.DateValue:
+		x = time.Time{}
+	case document.IntervalValue:
+		x = document.Interval{}
+	case document.VectorValue:
+		x = []float64(nil)
+	case document.BlobValue:
+		x = []byte(nil)
+	case document.UUIDValue:
+		x = document.UUID{}
+	case document.TextValue:
+		x = ""
+	case document.ArrayValue:
+		return reflect.TypeOf((*document.Array)(nil)).Elem()
+	case document.DocumentValue:
+		return reflect.TypeOf((*document.Document)(nil)).Elem()
+	default:
+		return reflect.TypeOf((*interface{})(nil)).Elem()
+	}
+
+	return reflect.TypeOf(x)
+}
+
+// ColumnTypeDatabaseTypeName returns the name of the type of the column,
+// such as "INTEGER" or "TEXT", or an empty string if it is unknown.
+func (r *rows) ColumnTypeDatabaseTypeName(index int) string {
+	tp := r.columnType(index)
+	if tp == 0 {
+		return ""
+	}
+
+	return strings.ToUpper(tp.String())
+}
+
+func (r *rows) columnType(index int) document.ValueType {
+	if index >= len(r.types) {
+		return 0
+	}
+
+	return r.types[index]
+}
+
 type valueScanner struct {
 	dest interface{}
 }
@@ -371,7 +461,7 @@ func (v valueScanner) Scan(src interface{}) error {
 	case document.Array:
 		return document.SliceScan(t, v.dest)
 	case document.Value:
-		return document.ScanValue(t, src)
+		return document.ScanValue(t, v.dest)
 	}
 
 	vv, err := document.NewValue(src)
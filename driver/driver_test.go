@@ -3,9 +3,11 @@ package driver
 import (
 	"context"
 	"database/sql"
+	"reflect"
 	"testing"
 	"time"
 
+	"github.com/genjidb/genji/document"
 	"github.com/stretchr/testify/require"
 )
 
@@ -70,6 +72,25 @@ func TestDriver(t *testing.T) {
 		require.Equal(t, 10, count)
 	})
 
+	t.Run("NULL values", func(t *testing.T) {
+		rows, err := db.Query("SELECT a, d FROM test")
+		require.NoError(t, err)
+		defer rows.Close()
+
+		var count int
+		var a sql.NullInt64
+		var d sql.NullString
+		for rows.Next() {
+			err = rows.Scan(&a, &d)
+			require.NoError(t, err)
+			require.Equal(t, sql.NullInt64{Int64: int64(count), Valid: true}, a)
+			require.False(t, d.Valid)
+			count++
+		}
+		require.NoError(t, rows.Err())
+		require.Equal(t, 10, count)
+	})
+
 	t.Run("Multiple fields with ORDER BY", func(t *testing.T) {
 		rows, err := db.Query("SELECT a, c FROM test ORDER BY a")
 		require.NoError(t, err)
@@ -182,6 +203,26 @@ func TestDriver(t *testing.T) {
 		require.Equal(t, 1, count)
 	})
 
+	t.Run("Colon Named Params", func(t *testing.T) {
+		stmt, err := db.Prepare("SELECT a FROM test WHERE a >= :min AND a < :min + 2")
+		require.NoError(t, err)
+		defer stmt.Close()
+
+		var a int
+		err = stmt.QueryRow(sql.Named("min", 5)).Scan(&a)
+		require.NoError(t, err)
+		require.Equal(t, 5, a)
+	})
+
+	t.Run("Wrong number of params", func(t *testing.T) {
+		stmt, err := db.Prepare("SELECT a FROM test WHERE a = ?")
+		require.NoError(t, err)
+		defer stmt.Close()
+
+		_, err = stmt.Query(1, 2)
+		require.Error(t, err)
+	})
+
 	t.Run("Transactions", func(t *testing.T) {
 		tx, err := db.Begin()
 		require.NoError(t, err)
@@ -212,16 +253,21 @@ func TestDriver(t *testing.T) {
 		require.NoError(t, err)
 		defer rows.Close()
 
-		var count int
-		var dt doctest
-		for rows.Next() {
-			err = rows.Scan(Scanner(&dt))
-			require.NoError(t, err)
-			require.Equal(t, doctest{count, []int{count + 1, count + 2, count + 3}, foo{Foo: "bar"}}, dt)
-			count++
+		// each SELECT returns a result set
+		for _, n := range []int{10, 11} {
+			var count int
+			var dt doctest
+			for rows.Next() {
+				err = rows.Scan(Scanner(&dt))
+				require.NoError(t, err)
+				require.Equal(t, doctest{count, []int{count + 1, count + 2, count + 3}, foo{Foo: "bar"}}, dt)
+				count++
+			}
+			require.NoError(t, rows.Err())
+			require.Equal(t, n, count)
+			require.Equal(t, n == 10, rows.NextResultSet())
 		}
 		require.NoError(t, rows.Err())
-		require.Equal(t, 11, count)
 	})
 
 	t.Run("Multiple queries in transaction", func(t *testing.T) {
@@ -237,16 +283,21 @@ func TestDriver(t *testing.T) {
 		require.NoError(t, err)
 		defer rows.Close()
 
-		var count int
-		var dt doctest
-		for rows.Next() {
-			err = rows.Scan(Scanner(&dt))
-			require.NoError(t, err)
-			require.Equal(t, doctest{count, []int{count + 1, count + 2, count + 3}, foo{Foo: "bar"}}, dt)
-			count++
+		// each SELECT returns a result set
+		for _, n := range []int{11, 12} {
+			var count int
+			var dt doctest
+			for rows.Next() {
+				err = rows.Scan(Scanner(&dt))
+				require.NoError(t, err)
+				require.Equal(t, doctest{count, []int{count + 1, count + 2, count + 3}, foo{Foo: "bar"}}, dt)
+				count++
+			}
+			require.NoError(t, rows.Err())
+			require.Equal(t, n, count)
+			require.Equal(t, n == 11, rows.NextResultSet())
 		}
 		require.NoError(t, rows.Err())
-		require.Equal(t, 12, count)
 	})
 
 	t.Run("Multiple queries in read only transaction", func(t *testing.T) {
@@ -254,12 +305,21 @@ func TestDriver(t *testing.T) {
 		require.NoError(t, err)
 		defer tx.Rollback()
 
-		_, err = tx.Query(`
+		rows, err := tx.Query(`
 			SELECT * FROM test;;;
 			INSERT INTO test (a, b, c) VALUES (12, 13, 14);
 			SELECT * FROM test;
 		`)
-		require.EqualError(t, err, "cannot increment sequence on read-only transaction")
+		require.NoError(t, err)
+		defer rows.Close()
+
+		for rows.Next() {
+		}
+		require.NoError(t, rows.Err())
+
+		// the INSERT fails when moving to the next result set
+		require.False(t, rows.NextResultSet())
+		require.EqualError(t, rows.Err(), "cannot increment sequence on read-only transaction")
 	})
 }
 
@@ -281,3 +341,109 @@ func TestDriverWithTimeValues(t *testing.T) {
 	require.NoError(t, err)
 	require.Equal(t, now, tt)
 }
+
+func TestDriverColumnTypes(t *testing.T) {
+	db, err := sql.Open("genji", ":memory:")
+	require.NoError(t, err)
+	defer db.Close()
+
+	_, err = db.Exec(`
+		CREATE TABLE test (a INTEGER, b TEXT, c TIMESTAMP);
+		INSERT INTO test (a, b, c, d) VALUES (1, 'foo', '2021-01-01T00:00:00Z', true);
+	`)
+	require.NoError(t, err)
+
+	rows, err := db.Query("SELECT a, b AS name, c, d, CAST(d AS TEXT), COUNT(*) FROM test")
+	require.NoError(t, err)
+	defer rows.Close()
+
+	types, err := rows.ColumnTypes()
+	require.NoError(t, err)
+
+	var names []string
+	var scanTypes []reflect.Type
+	for _, ct := range types {
+		names = append(names, ct.DatabaseTypeName())
+		scanTypes = append(scanTypes, ct.ScanType())
+	}
+	require.Equal(t, []string{"INTEGER", "TEXT", "TIMESTAMP", "", "TEXT", "INTEGER"}, names)
+	require.Equal(t, []reflect.Type{
+		reflect.TypeOf(int64(0)),
+		reflect.TypeOf(""),
+		reflect.TypeOf(time.Time{}),
+		reflect.TypeOf((*interface{})(nil)).Elem(),
+		reflect.TypeOf(""),
+		reflect.TypeOf(int64(0)),
+	}, scanTypes)
+
+	rows, err = db.Query("SELECT * FROM test")
+	require.NoError(t, err)
+	defer rows.Close()
+
+	types, err = rows.ColumnTypes()
+	require.NoError(t, err)
+	require.Len(t, types, 1)
+	require.Equal(t, "DOCUMENT", types[0].DatabaseTypeName())
+	require.Equal(t, reflect.TypeOf((*document.Document)(nil)).Elem(), types[0].ScanType())
+}
+
+func TestDriverCancel(t *testing.T) {
+	db, err := sql.Open("genji", ":memory:")
+	require.NoError(t, err)
+	defer db.Close()
+
+	// multiple statements are executed by Exec
+	_, err = db.Exec(`
+		CREATE TABLE test;
+		INSERT INTO test (a) VALUES (1), (2), (3);
+	`)
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	rows, err := db.QueryContext(ctx, "SELECT a FROM test")
+	require.NoError(t, err)
+	defer rows.Close()
+
+	require.True(t, rows.Next())
+	cancel()
+	for rows.Next() {
+	}
+	require.Equal(t, context.Canceled, rows.Err())
+
+	// the connection can still be used
+	var n int
+	err = db.QueryRow("SELECT COUNT(*) FROM test").Scan(&n)
+	require.NoError(t, err)
+	require.Equal(t, 3, n)
+}
+
+func TestDriverSessionSettings(t *testing.T) {
+	db, err := sql.Open("genji", ":memory:")
+	require.NoError(t, err)
+	defer db.Close()
+
+	ctx := context.Background()
+
+	c1, err := db.Conn(ctx)
+	require.NoError(t, err)
+	defer c1.Close()
+
+	c2, err := db.Conn(ctx)
+	require.NoError(t, err)
+	defer c2.Close()
+
+	_, err = c1.ExecContext(ctx, "SET time_zone = 'Europe/Paris'")
+	require.NoError(t, err)
+
+	var tz string
+	err = c1.QueryRowContext(ctx, "SHOW time_zone").Scan(&tz)
+	require.NoError(t, err)
+	require.Equal(t, "Europe/Paris", tz)
+
+	// settings are scoped to the connection
+	err = c2.QueryRowContext(ctx, "SHOW time_zone").Scan(&tz)
+	require.NoError(t, err)
+	require.Equal(t, "UTC", tz)
+}
@@ -0,0 +1,405 @@
+package genji
+
+import (
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/genjidb/genji/document"
+	"github.com/genjidb/genji/internal/database"
+	"github.com/genjidb/genji/internal/query"
+	"github.com/genjidb/genji/internal/sql/parser"
+	"github.com/genjidb/genji/internal/stringutil"
+)
+
+// Dump writes the content of the database to w as SQL statements recreating it,
+// within a single transaction: modified pragmas, sequences, tables with their indexes
+// and documents, views and triggers.
+// If tables are provided, only these tables, their indexes, documents and triggers are dumped,
+// and the tables that don't exist are ignored.
+// Internal tables, users and roles are never dumped.
+// The dump can be loaded into another database using Restore.
+func (db *DB) Dump(w io.Writer, tables ...string) error {
+	tx, err := db.Begin(false)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err = io.WriteString(w, "BEGIN TRANSACTION;\n"); err != nil {
+		return err
+	}
+
+	d := dumper{tx: tx, w: w, data: true}
+	err = d.dump(tables)
+	if err != nil {
+		_, _ = io.WriteString(w, "ROLLBACK;\n")
+		return err
+	}
+
+	_, err = io.WriteString(w, "COMMIT;\n")
+	return err
+}
+
+// DumpSchema writes the statements written by Dump to w, except the ones
+// inserting documents.
+func (db *DB) DumpSchema(w io.Writer, tables ...string) error {
+	tx, err := db.Begin(false)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	d := dumper{tx: tx, w: w}
+	return d.dump(tables)
+}
+
+// dumper writes statements in sections separated by blank lines.
+type dumper struct {
+	tx *Tx
+	w  io.Writer
+	// whether to dump the documents of the tables.
+	data bool
+
+	written, newSection bool
+}
+
+func (d *dumper) dump(tables []string) error {
+	catalog := d.tx.db.db.Catalog
+
+	all := len(tables) == 0
+	selected := make(map[string]bool, len(tables))
+	for _, name := range tables {
+		selected[name] = true
+	}
+
+	tables = tables[:0:0]
+	for _, name := range catalog.ListTables(d.tx.tx) {
+		if (all && !strings.HasPrefix(name, database.InternalPrefix)) || selected[name] {
+			tables = append(tables, name)
+		}
+	}
+
+	if all {
+		if err := d.dumpPragmas(); err != nil {
+			return err
+		}
+		if err := d.dumpSequences(); err != nil {
+			return err
+		}
+	}
+
+	for _, name := range tables {
+		if err := d.dumpTable(name); err != nil {
+			return err
+		}
+	}
+
+	if all {
+		if err := d.dumpViews(); err != nil {
+			return err
+		}
+	}
+
+	// triggers are created once the data is restored, so that they don't fire
+	return d.dumpTriggers(tables)
+}
+
+// section starts a new section, separated from the previous one by a blank line
+// if both have statements.
+func (d *dumper) section() {
+	d.newSection = true
+}
+
+// statement writes a statement followed by a semicolon.
+func (d *dumper) statement(s string) error {
+	if d.newSection && d.written {
+		if _, err := io.WriteString(d.w, "\n"); err != nil {
+			return err
+		}
+	}
+	d.newSection = false
+	d.written = true
+
+	_, err := io.WriteString(d.w, s+";\n")
+	return err
+}
+
+// dumpPragmas writes the pragmas whose value is not the default one.
+func (d *dumper) dumpPragmas() error {
+	names := make([]string, 0, len(database.Pragmas))
+	for name := range database.Pragmas {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	d.section()
+	for _, name := range names {
+		v, err := database.GetPragmaValue(d.tx.tx, d.tx.db.db.Catalog, name)
+		if err != nil {
+			return err
+		}
+
+		eq, err := v.IsEqual(database.Pragmas[name].Default)
+		if err != nil {
+			return err
+		}
+		if eq {
+			continue
+		}
+
+		err = d.statement(stringutil.Sprintf("PRAGMA %s = %s", name, v))
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// dumpSequences writes the sequences created with CREATE SEQUENCE,
+// starting where they are now.
+func (d *dumper) dumpSequences() error {
+	catalog := d.tx.db.db.Catalog
+
+	d.section()
+	for _, name := range catalog.ListSequences(d.tx.tx) {
+		seq, err := catalog.GetSequence(d.tx.tx, name)
+		if err != nil {
+			return err
+		}
+		if seq.Info.Owner.TableName != "" {
+			continue
+		}
+
+		info := seq.Info.Clone()
+		if seq.CurrentValue != nil {
+			next := *seq.CurrentValue + info.IncrementBy
+			if next >= info.Min && next <= info.Max {
+				info.Start = next
+			}
+		}
+
+		err = d.statement(info.String())
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// dumpTable writes the table, its indexes and, if required, its documents.
+func (d *dumper) dumpTable(tableName string) error {
+	catalog := d.tx.db.db.Catalog
+
+	info, err := catalog.GetTableInfo(d.tx.tx, tableName)
+	if err != nil {
+		return err
+	}
+
+	d.section()
+	err = d.statement(info.String())
+	if err != nil {
+		return err
+	}
+
+	// indexes created by constraints are created with the table
+	for _, name := range catalog.ListIndexes(d.tx.tx, tableName) {
+		idx, err := catalog.GetIndexInfo(d.tx.tx, name)
+		if err != nil {
+			return err
+		}
+		if idx.Owner.TableName != "" {
+			continue
+		}
+
+		err = d.statement(idx.String())
+		if err != nil {
+			return err
+		}
+	}
+
+	if !d.data {
+		return nil
+	}
+
+	res, err := d.tx.Query("SELECT * FROM " + stringutil.NormalizeIdentifier(tableName, '`'))
+	if err != nil {
+		return err
+	}
+	defer res.Close()
+
+	// generated fields are computed again when the documents are restored
+	var generated []document.Path
+	for _, fc := range info.FieldConstraints {
+		if fc.IsGenerated() {
+			generated = append(generated, fc.Path)
+		}
+	}
+
+	insert := "INSERT INTO " + stringutil.NormalizeIdentifier(tableName, '`') + " VALUES "
+	return res.Iterate(func(doc document.Document) error {
+		if len(generated) > 0 {
+			var fb document.FieldBuffer
+			err := fb.Copy(doc)
+			if err != nil {
+				return err
+			}
+			for _, p := range generated {
+				err = fb.Delete(p)
+				if err != nil && err != document.ErrFieldNotFound {
+					return err
+				}
+			}
+			doc = &fb
+		}
+
+		data, err := document.MarshalJSON(doc)
+		if err != nil {
+			return err
+		}
+
+		return d.statement(insert + string(data))
+	})
+}
+
+// dumpViews writes the views, making sure that a view is always
+// created after the views it reads.
+func (d *dumper) dumpViews() error {
+	catalog := d.tx.db.db.Catalog
+	names := catalog.ListViews(d.tx.tx)
+
+	views := make([]*database.ViewInfo, len(names))
+	for i, name := range names {
+		var err error
+		views[i], err = catalog.GetViewInfo(d.tx.tx, name)
+		if err != nil {
+			return err
+		}
+	}
+
+	d.section()
+	dumped := make(map[string]bool, len(views))
+	var dump func(v *database.ViewInfo) error
+	dump = func(v *database.ViewInfo) error {
+		if dumped[v.ViewName] {
+			return nil
+		}
+		dumped[v.ViewName] = true
+
+		for _, dep := range views {
+			if v.DependsOn(dep.ViewName) {
+				if err := dump(dep); err != nil {
+					return err
+				}
+			}
+		}
+
+		return d.statement(v.String())
+	}
+
+	for _, v := range views {
+		if err := dump(v); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// dumpTriggers writes the triggers of the given tables, sorted by name.
+func (d *dumper) dumpTriggers(tables []string) error {
+	var triggers []*database.TriggerInfo
+	for _, name := range tables {
+		triggers = append(triggers, d.tx.db.db.Catalog.GetTableTriggers(d.tx.tx, name)...)
+	}
+	sort.Slice(triggers, func(i, j int) bool {
+		return triggers[i].TriggerName < triggers[j].TriggerName
+	})
+
+	d.section()
+	for _, t := range triggers {
+		if err := d.statement(t.String()); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// restoreBatchSize is the number of statements run by Restore in each transaction.
+const restoreBatchSize = 1000
+
+// Restore runs the SQL statements read from r, such as a dump written by Dump.
+// Statements are parsed and run one at a time, so that large dumps don't need to fit
+// in memory, and are committed in batches of many statements. BEGIN, COMMIT and ROLLBACK
+// statements are ignored.
+// If a statement fails, Restore returns an error telling which one, and the statements
+// committed by previous batches are kept.
+func (db *DB) Restore(r io.Reader) error {
+	var tx *Tx
+	defer func() {
+		if tx != nil {
+			tx.Rollback()
+		}
+	}()
+
+	p := parser.NewParser(r)
+	var n, batched int
+	for {
+		stmt, err := p.NextStatement()
+		if err == io.EOF {
+			break
+		}
+		n++
+		if err != nil {
+			return stringutil.Errorf("statement %d: %w", n, err)
+		}
+
+		switch stmt.(type) {
+		case query.BeginStmt, query.CommitStmt, query.RollbackStmt:
+			continue
+		}
+
+		if tx == nil {
+			tx, err = db.Begin(true)
+			if err != nil {
+				return err
+			}
+		}
+
+		err = tx.exec(query.New(stmt))
+		if err != nil {
+			return stringutil.Errorf("statement %d: %w", n, err)
+		}
+
+		batched++
+		if batched == restoreBatchSize {
+			err = tx.Commit()
+			tx, batched = nil, 0
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	if tx == nil {
+		return nil
+	}
+
+	err := tx.Commit()
+	tx = nil
+	return err
+}
+
+// exec prepares and runs a parsed query within tx.
+func (tx *Tx) exec(pq query.Query) error {
+	err := pq.Prepare(newQueryContext(tx.db, tx, nil))
+	if err != nil {
+		return err
+	}
+
+	s := Statement{pq: pq, db: tx.db, tx: tx}
+	return s.Exec()
+}
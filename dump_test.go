@@ -0,0 +1,100 @@
+package genji_test
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/genjidb/genji"
+	"github.com/genjidb/genji/document"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDumpRestore(t *testing.T) {
+	db, err := genji.Open(":memory:")
+	require.NoError(t, err)
+	defer db.Close()
+
+	err = db.Exec(`
+		PRAGMA default_collation = 'nocase';
+		CREATE SEQUENCE seq INCREMENT BY 2;
+		CREATE TABLE foo (a INT PRIMARY KEY, b TEXT UNIQUE);
+		CREATE INDEX foo_b_a ON foo (b, a);
+		CREATE TABLE bar (a INT, b INT AS (a * 2) STORED);
+		CREATE VIEW vb AS SELECT a FROM foo;
+		CREATE VIEW va AS SELECT * FROM vb;
+		CREATE TRIGGER t AFTER INSERT ON foo BEGIN INSERT INTO bar (a) VALUES (new.a); END;
+		INSERT INTO foo (a, b) VALUES (1, 'a;b'), (2, "it's");
+	`)
+	require.NoError(t, err)
+	_, err = db.QueryDocument("SELECT NEXT VALUE FOR seq")
+	require.NoError(t, err)
+
+	var dump bytes.Buffer
+	err = db.Dump(&dump)
+	require.NoError(t, err)
+
+	require.Equal(t, strings.Join([]string{
+		`BEGIN TRANSACTION;`,
+		`PRAGMA default_collation = "nocase";`,
+		``,
+		`CREATE SEQUENCE seq INCREMENT BY 2 START WITH 3;`,
+		``,
+		`CREATE TABLE bar (a INTEGER, b INTEGER AS (a * 2) STORED);`,
+		`INSERT INTO bar VALUES {"a": 1};`,
+		`INSERT INTO bar VALUES {"a": 2};`,
+		``,
+		`CREATE TABLE foo (a INTEGER PRIMARY KEY, b TEXT UNIQUE);`,
+		`CREATE INDEX foo_b_a ON foo (b, a);`,
+		`INSERT INTO foo VALUES {"a": 1, "b": "a;b"};`,
+		`INSERT INTO foo VALUES {"a": 2, "b": "it's"};`,
+		``,
+		`CREATE VIEW vb AS SELECT a FROM foo;`,
+		`CREATE VIEW va AS SELECT * FROM vb;`,
+		``,
+		`CREATE TRIGGER t AFTER INSERT ON foo BEGIN INSERT INTO bar (a) VALUES (new.a); END;`,
+		`COMMIT;`,
+		``,
+	}, "\n"), dump.String())
+
+	restored, err := genji.Open(":memory:")
+	require.NoError(t, err)
+	defer restored.Close()
+
+	err = restored.Restore(bytes.NewReader(dump.Bytes()))
+	require.NoError(t, err)
+
+	// restoring the dump doesn't fire the triggers
+	var n int
+	d, err := restored.QueryDocument("SELECT COUNT(*) FROM bar")
+	require.NoError(t, err)
+	require.NoError(t, document.Scan(d, &n))
+	require.Equal(t, 2, n)
+
+	// generated fields are not dumped but computed again
+	d, err = restored.QueryDocument("SELECT SUM(b) FROM bar")
+	require.NoError(t, err)
+	require.NoError(t, document.Scan(d, &n))
+	require.Equal(t, 6, n)
+
+	var again bytes.Buffer
+	err = restored.Dump(&again)
+	require.NoError(t, err)
+	require.Equal(t, dump.String(), again.String())
+
+	// the schema alone
+	var schema bytes.Buffer
+	err = db.DumpSchema(&schema, "foo")
+	require.NoError(t, err)
+	require.Equal(t, strings.Join([]string{
+		`CREATE TABLE foo (a INTEGER PRIMARY KEY, b TEXT UNIQUE);`,
+		`CREATE INDEX foo_b_a ON foo (b, a);`,
+		``,
+		`CREATE TRIGGER t AFTER INSERT ON foo BEGIN INSERT INTO bar (a) VALUES (new.a); END;`,
+		``,
+	}, "\n"), schema.String())
+
+	// errors report the failing statement
+	err = restored.Restore(strings.NewReader("INSERT INTO bar (a) VALUES (3); INSERT INTO foo (a) VALUES (1);"))
+	require.EqualError(t, err, "statement 2: duplicate document")
+}
@@ -1,4 +1,9 @@
-// Package badgerengine implements a Badger engine.
+// Package badgerengine implements a Badger engine, suited for write heavy workloads.
+//
+// Badger has a single keyspace, stores are mapped onto it using key prefixes:
+// each store is registered under the "__genji.store" key followed by its name,
+// and its keys are prefixed by 's', the store name and a separator.
+// It is registered under the name "badger", i.e. genji.Open("badger:/path/to/dir").
 package badgerengine
 
 import (
@@ -15,6 +20,12 @@ const (
 	storePrefix      = 's'
 )
 
+func init() {
+	engine.Register("badger", func(path string) (engine.Engine, error) {
+		return NewEngine(badger.DefaultOptions(path))
+	})
+}
+
 // Engine represents a Badger engine.
 type Engine struct {
 	DB *badger.DB
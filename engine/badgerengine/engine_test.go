@@ -1,6 +1,7 @@
 package badgerengine_test
 
 import (
+	"context"
 	"io/ioutil"
 	"os"
 	"path/filepath"
@@ -45,3 +46,98 @@ func tempDir(t require.TestingT) (string, func()) {
 		os.RemoveAll(dir)
 	}
 }
+
+func TestRegister(t *testing.T) {
+	dir, cleanup := tempDir(t)
+	defer cleanup()
+
+	opener, ok := engine.Lookup("badger")
+	require.True(t, ok)
+
+	ng, err := opener(filepath.Join(dir, "badger"))
+	require.NoError(t, err)
+	require.IsType(t, &badgerengine.Engine{}, ng)
+	require.NoError(t, ng.Close())
+}
+
+func TestStorePrefixes(t *testing.T) {
+	ng, cleanup := builder(t)()
+	defer cleanup()
+	defer ng.Close()
+
+	tx, err := ng.Begin(context.Background(), engine.TxOptions{Writable: true})
+	require.NoError(t, err)
+	defer tx.Rollback()
+
+	// store names sharing a common prefix must not share keys
+	names := []string{"foo", "foobar", "fo"}
+	for _, name := range names {
+		require.NoError(t, tx.CreateStore([]byte(name)))
+		st, err := tx.GetStore([]byte(name))
+		require.NoError(t, err)
+		require.NoError(t, st.Put([]byte("a"), []byte(name)))
+		require.NoError(t, st.Put([]byte("b"), []byte(name)))
+	}
+
+	count := func(name string) int {
+		st, err := tx.GetStore([]byte(name))
+		require.NoError(t, err)
+
+		it := st.Iterator(engine.IteratorOptions{})
+		defer it.Close()
+
+		var n int
+		for it.Seek(nil); it.Valid(); it.Next() {
+			v, err := it.Item().ValueCopy(nil)
+			require.NoError(t, err)
+			require.Equal(t, name, string(v))
+			n++
+		}
+		require.NoError(t, it.Err())
+		return n
+	}
+
+	for _, name := range names {
+		require.Equal(t, 2, count(name))
+	}
+
+	st, err := tx.GetStore([]byte("foo"))
+	require.NoError(t, err)
+	require.NoError(t, st.Truncate())
+	require.Equal(t, 0, count("foo"))
+	require.Equal(t, 2, count("foobar"))
+	require.Equal(t, 2, count("fo"))
+
+	require.NoError(t, tx.DropStore([]byte("fo")))
+	require.Equal(t, 2, count("foobar"))
+	_, err = tx.GetStore([]byte("fo"))
+	require.Equal(t, engine.ErrStoreNotFound, err)
+}
+
+func TestIteratorSeekReversePivot(t *testing.T) {
+	ng, cleanup := builder(t)()
+	defer cleanup()
+	defer ng.Close()
+
+	tx, err := ng.Begin(context.Background(), engine.TxOptions{Writable: true})
+	require.NoError(t, err)
+	defer tx.Rollback()
+
+	require.NoError(t, tx.CreateStore([]byte("test")))
+	st, err := tx.GetStore([]byte("test"))
+	require.NoError(t, err)
+	require.NoError(t, st.Put([]byte("a"), []byte("a")))
+	require.NoError(t, st.Put([]byte("b"), []byte("b")))
+
+	// the pivot must not be modified, even if it has enough capacity
+	buf := []byte("bc")
+	pivot := buf[:1]
+
+	it := st.Iterator(engine.IteratorOptions{Reverse: true})
+	defer it.Close()
+
+	it.Seek(pivot)
+	require.True(t, it.Valid())
+	require.Equal(t, []byte("b"), it.Item().Key())
+	require.Equal(t, []byte("bc"), buf)
+}
@@ -115,16 +115,26 @@ func (s *Store) Truncate() error {
 	}
 
 	_, err := s.tx.Get(buildStoreKey(s.name))
-	if err == badger.ErrKeyNotFound {
-		return engine.ErrStoreNotFound
+	if err != nil {
+		if err == badger.ErrKeyNotFound {
+			return engine.ErrStoreNotFound
+		}
+
+		return err
 	}
 
-	it := s.tx.NewIterator(badger.DefaultIteratorOptions)
+	// only delete the keys of this store, not the ones
+	// of the stores whose name starts with the same bytes
+	prefix := buildKey(s.prefix, nil)
+
+	opt := badger.DefaultIteratorOptions
+	opt.Prefix = prefix
+	opt.PrefetchValues = false
+	it := s.tx.NewIterator(opt)
 	defer it.Close()
 
-	prefix := buildStorePrefixKey(s.name)
 	for it.Seek(prefix); it.ValidForPrefix(prefix); it.Next() {
-		err = s.tx.Delete(it.Item().Key())
+		err = s.tx.Delete(it.Item().KeyCopy(nil))
 		if err != nil {
 			return err
 		}
@@ -183,7 +193,7 @@ func (it *iterator) Seek(pivot []byte) {
 			seek = buildKey(it.storePrefix, pivot)
 			seek[len(seek)-1] = 255
 		} else {
-			seek = buildKey(it.storePrefix, append(pivot, 0xFF))
+			seek = append(buildKey(it.storePrefix, pivot), 0xFF)
 		}
 	}
 
@@ -1,4 +1,19 @@
-// Package boltengine implements a BoltDB engine.
+// +build !wasm
+
+// Package boltengine implements a BoltDB engine, backed by go.etcd.io/bbolt.
+// It is written in pure Go and can be used on every platform supported by bbolt,
+// including 386, arm and riscv64.
+// It is the default engine of genji.Open and is registered under the names
+// "bolt" and "bbolt", i.e. genji.Open("bbolt:/path/to/my.db").
+//
+// Read-only transactions read a snapshot of the database and run concurrently with
+// the read/write transaction, with one exception: BoltDB maps the database file in memory,
+// and growing the mapping waits for the running read-only transactions to end.
+// A read/write transaction whose commit grows the file past the mapped size
+// blocks until then, and never returns if the goroutine committing it holds one
+// of these read-only transactions.
+// To make it rare, the file is mapped with at least 256MiB, unless the options
+// passed to NewEngine set InitialMmapSize.
 package boltengine
 
 import (
@@ -10,19 +25,41 @@ import (
 	bolt "go.etcd.io/bbolt"
 )
 
+// defaultInitialMmapSize is the initial size of the memory mapping of the database file.
+// The mapping is grown as the file grows.
+const defaultInitialMmapSize = 256 << 20
+
 const (
 	// name of the bucket used to mark keys for deletion
 	binBucket = "__bin"
 )
 
+func init() {
+	opener := func(path string) (engine.Engine, error) {
+		return NewEngine(path, 0660, nil)
+	}
+
+	engine.Register("bolt", opener)
+	engine.Register("bbolt", opener)
+}
+
 // Engine represents a BoltDB engine. Each store is stored in a dedicated bucket.
 type Engine struct {
 	DB *bolt.DB
 }
 
 // NewEngine creates a BoltDB engine. It takes the same argument as Bolt's Open function.
+// If opts is nil or doesn't set InitialMmapSize, the file is mapped with 256MiB.
 func NewEngine(path string, mode os.FileMode, opts *bolt.Options) (*Engine, error) {
-	db, err := bolt.Open(path, mode, opts)
+	o := *bolt.DefaultOptions
+	if opts != nil {
+		o = *opts
+	}
+	if o.InitialMmapSize == 0 {
+		o.InitialMmapSize = defaultInitialMmapSize
+	}
+
+	db, err := bolt.Open(path, mode, &o)
 	if err != nil {
 		return nil, err
 	}
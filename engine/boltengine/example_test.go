@@ -25,6 +25,20 @@ func Example() {
 	defer db.Close()
 }
 
+func Example_prefix() {
+	dir, err := ioutil.TempDir("", "bolt")
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	db, err := genji.Open("bbolt:" + filepath.Join(dir, "my.db"))
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer db.Close()
+}
+
 func ExampleNewEngine() {
 	dir, err := ioutil.TempDir("", "bolt")
 	if err != nil {
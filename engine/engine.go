@@ -1,4 +1,24 @@
-// Package engine defines interfaces to be implemented by engines in order to be compatible with Genji.
+/*
+Package engine defines interfaces to be implemented by engines in order to be compatible with Genji.
+
+An engine is an ordered key value store, organized in named stores. Genji stores each table,
+index and sequence in its own store, and relies on the lexicographic order of the keys
+to scan them. Engines only deal with keys and values as byte slices, they don't need
+to know anything about documents.
+
+Genji provides engines based on BoltDB, Badger and an in-memory btree. Other engines can be
+plugged by implementing the Engine interface and by using it with genji.New, or by registering
+it with Register, so that it can be selected by name with genji.Open("name:path").
+
+Implementations can be checked against the semantics described in this package by
+running the enginetest.TestSuite test suite.
+
+Genji runs one read/write transaction at a time, concurrently with read-only transactions.
+Read-only transactions must see the stores as they were when they started, regardless of
+the changes committed since then, and must not block the read/write transaction.
+Engines are responsible for discarding the versions of the data that are no longer visible
+to any transaction.
+*/
 package engine
 
 import (
@@ -33,6 +53,7 @@ type Engine interface {
 	// Begin returns a read-only or read/write transaction depending on whether writable is set to false
 	// or true, respectively.
 	// The behaviour of opening a transaction when another one is already opened depends on the implementation.
+	// If ctx is canceled, the transaction and its iterators must stop and return ctx.Err().
 	Begin(ctx context.Context, opts TxOptions) (Transaction, error)
 	// Close the engine after ensuring all the transactions have completed.
 	Close() error
@@ -85,10 +106,13 @@ type IteratorOptions struct {
 	Reverse bool
 }
 
-// An Iterator iterates on keys of a store in lexicographic order.
+// An Iterator iterates on keys of a store in lexicographic order, or in reverse
+// lexicographic order if the Reverse option is set.
+// An iterator is only valid during the lifetime of the transaction that created it.
 type Iterator interface {
 	// Seek moves the iterator to the selected key. If the key doesn't exist, it must move to the
-	// next smallest key greater than k.
+	// next smallest key greater than k, or to the next greatest key lower than k in reverse order.
+	// If k is nil, it moves to the first key, or to the last one in reverse order.
 	Seek(k []byte)
 	// Next moves the iterator to the next item.
 	Next()
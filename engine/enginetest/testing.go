@@ -1,3 +1,5 @@
+// +build !wasm
+
 // Package enginetest defines a list of tests that can be used to test
 // a complete or partial engine implementation.
 package enginetest
@@ -35,6 +37,7 @@ func TestSuite(t *testing.T, builder Builder) {
 		{"Transaction/GetStore", TestTransactionGetStore},
 		{"Transaction/CreateStore", TestTransactionCreateStore},
 		{"Transaction/DropStore", TestTransactionDropStore},
+		{"Transaction/Snapshot", TestTransactionSnapshot},
 		{"Store/Iterator", TestStoreIterator},
 		{"Store/Put", TestStorePut},
 		{"Store/Get", TestStoreGet},
@@ -391,6 +394,79 @@ func TestTransactionCommitRollback(t *testing.T, builder Builder) {
 	})
 }
 
+// TestTransactionSnapshot verifies that read-only transactions see the stores
+// as of the moment they started, while a read/write transaction modifies them.
+func TestTransactionSnapshot(t *testing.T, builder Builder) {
+	ng, cleanup := builder()
+	defer cleanup()
+	defer func() {
+		require.NoError(t, ng.Close())
+	}()
+
+	update := func(fn func(tx engine.Transaction) error) {
+		tx, err := ng.Begin(context.Background(), engine.TxOptions{Writable: true})
+		require.NoError(t, err)
+		defer tx.Rollback()
+
+		require.NoError(t, fn(tx))
+		require.NoError(t, tx.Commit())
+	}
+
+	update(func(tx engine.Transaction) error {
+		err := tx.CreateStore([]byte("a"))
+		if err != nil {
+			return err
+		}
+		st, err := tx.GetStore([]byte("a"))
+		if err != nil {
+			return err
+		}
+		return st.Put([]byte("foo"), []byte("1"))
+	})
+
+	rtx, err := ng.Begin(context.Background(), engine.TxOptions{})
+	require.NoError(t, err)
+	defer rtx.Rollback()
+
+	update(func(tx engine.Transaction) error {
+		st, err := tx.GetStore([]byte("a"))
+		if err != nil {
+			return err
+		}
+		err = st.Put([]byte("foo"), []byte("2"))
+		if err != nil {
+			return err
+		}
+		err = st.Put([]byte("bar"), []byte("2"))
+		if err != nil {
+			return err
+		}
+		return tx.CreateStore([]byte("b"))
+	})
+
+	st, err := rtx.GetStore([]byte("a"))
+	require.NoError(t, err)
+	v, err := st.Get([]byte("foo"))
+	require.NoError(t, err)
+	require.Equal(t, []byte("1"), v)
+	_, err = st.Get([]byte("bar"))
+	require.Equal(t, engine.ErrKeyNotFound, err)
+	_, err = rtx.GetStore([]byte("b"))
+	require.Equal(t, engine.ErrStoreNotFound, err)
+	require.NoError(t, rtx.Rollback())
+
+	// new transactions see the changes
+	rtx, err = ng.Begin(context.Background(), engine.TxOptions{})
+	require.NoError(t, err)
+	defer rtx.Rollback()
+
+	st, err = rtx.GetStore([]byte("a"))
+	require.NoError(t, err)
+	v, err = st.Get([]byte("foo"))
+	require.NoError(t, err)
+	require.Equal(t, []byte("2"), v)
+}
+
 // TestTransactionCreateStore verifies CreateStore behaviour.
 func TestTransactionCreateStore(t *testing.T, builder Builder) {
 	t.Run("Should create a store", func(t *testing.T) {
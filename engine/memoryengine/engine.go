@@ -3,6 +3,7 @@ package memoryengine
 import (
 	"context"
 	"errors"
+	"sync"
 
 	"github.com/genjidb/genji/engine"
 	"github.com/google/btree"
@@ -14,11 +15,32 @@ import (
 // It may be improved after thorough testing.
 const btreeDegree = 12
 
+func init() {
+	// the path is ignored, each opened engine is empty
+	engine.Register("memory", func(string) (engine.Engine, error) {
+		return NewEngine(), nil
+	})
+}
+
 // Engine is a simple memory engine implementation that stores data in
-// an in-memory Btree. It is not thread safe.
+// in-memory Btrees.
+//
+// Committed Btrees are never modified: writable transactions work on
+// lazy copy-on-write clones of the Btrees they modify, which replace the
+// committed ones on commit. Read-only transactions read a snapshot of the
+// committed Btrees taken when they begin, which means they never block
+// nor are blocked by writable transactions. Writable transactions are
+// run one at a time.
 type Engine struct {
 	Closed bool
+
+	// protects Closed and stores
+	mu sync.Mutex
+	// committed stores. the map and the Btrees must not be modified,
+	// writable transactions replace the map on commit.
 	stores map[string]*btree.BTree
+	// ensures only one writable transaction runs at a time
+	writeMu sync.Mutex
 }
 
 // NewEngine creates an in-memory engine.
@@ -29,6 +51,7 @@ func NewEngine() *Engine {
 }
 
 // Begin creates a transaction.
+// If opts.Writable is true, it blocks until the current writable transaction, if any, is terminated.
 func (ng *Engine) Begin(ctx context.Context, opts engine.TxOptions) (engine.Transaction, error) {
 	select {
 	case <-ctx.Done():
@@ -36,15 +59,64 @@ func (ng *Engine) Begin(ctx context.Context, opts engine.TxOptions) (engine.Tran
 	default:
 	}
 
+	if opts.Writable {
+		ng.writeMu.Lock()
+	}
+
+	ng.mu.Lock()
+	defer ng.mu.Unlock()
+
 	if ng.Closed {
+		if opts.Writable {
+			ng.writeMu.Unlock()
+		}
 		return nil, errors.New("engine closed")
 	}
 
-	return &transaction{ctx: ctx, ng: ng, writable: opts.Writable}, nil
+	tx := transaction{ctx: ctx, ng: ng, writable: opts.Writable, stores: ng.stores}
+
+	// writable transactions work on a copy of the list of stores
+	if opts.Writable {
+		tx.stores = make(map[string]*btree.BTree, len(ng.stores))
+		for name, tr := range ng.stores {
+			tx.stores[name] = tr
+		}
+		tx.cloned = make(map[string]bool)
+	}
+
+	return &tx, nil
+}
+
+// Clone returns a new engine with the same content as ng.
+// The Btrees are cloned lazily, which makes it cheap to run isolated
+// tests against the same initial data.
+// It blocks until the current writable transaction, if any, is terminated.
+func (ng *Engine) Clone() (*Engine, error) {
+	// cloning a Btree modifies it, which must not happen
+	// while a writable transaction clones it too.
+	ng.writeMu.Lock()
+	defer ng.writeMu.Unlock()
+
+	ng.mu.Lock()
+	defer ng.mu.Unlock()
+
+	if ng.Closed {
+		return nil, errors.New("engine closed")
+	}
+
+	stores := make(map[string]*btree.BTree, len(ng.stores))
+	for name, tr := range ng.stores {
+		stores[name] = tr.Clone()
+	}
+
+	return &Engine{stores: stores}, nil
 }
 
 // Close the engine.
 func (ng *Engine) Close() error {
+	ng.mu.Lock()
+	defer ng.mu.Unlock()
+
 	if ng.Closed {
 		return errors.New("engine already closed")
 	}
@@ -55,18 +127,21 @@ func (ng *Engine) Close() error {
 
 // This implements the engine.Transaction type.
 type transaction struct {
-	ctx        context.Context
-	ng         *Engine
-	writable   bool
-	onRollback []func() // called during a rollback
-	onCommit   []func() // called during a commit
+	ctx      context.Context
+	ng       *Engine
+	writable bool
+	// snapshot of the stores, owned by the transaction if it is writable.
+	stores map[string]*btree.BTree
+	// stores whose Btree is owned by the transaction
+	// and can be modified in place.
+	cloned map[string]bool
+	// incremented every time the transaction modifies a store,
+	// used by iterators to detect changes.
+	version    uint64
 	terminated bool
 }
 
-// If the transaction is writable, rollback calls
-// every function stored in the onRollback slice
-// to undo every mutation done since the beginning
-// of the transaction.
+// Rollback discards the changes made by the transaction.
 func (tx *transaction) Rollback() error {
 	if tx.terminated {
 		return engine.ErrTransactionDiscarded
@@ -75,9 +150,7 @@ func (tx *transaction) Rollback() error {
 	tx.terminated = true
 
 	if tx.writable {
-		for _, undo := range tx.onRollback {
-			undo()
-		}
+		tx.ng.writeMu.Unlock()
 	}
 
 	select {
@@ -89,9 +162,7 @@ func (tx *transaction) Rollback() error {
 	return nil
 }
 
-// If the transaction is writable, Commit calls
-// every function stored in the onCommit slice
-// to finalize every mutation done since the beginning
+// Commit replaces the committed stores by the ones
 // of the transaction.
 func (tx *transaction) Commit() error {
 	if tx.terminated {
@@ -110,13 +181,37 @@ func (tx *transaction) Commit() error {
 
 	tx.terminated = true
 
-	for _, fn := range tx.onCommit {
-		fn()
-	}
+	tx.ng.mu.Lock()
+	tx.ng.stores = tx.stores
+	tx.ng.mu.Unlock()
+
+	tx.ng.writeMu.Unlock()
 
 	return nil
 }
 
+// tree returns the Btree of the given store.
+// If writable is true, the Btree is cloned, if it wasn't already,
+// so that it can be modified without affecting other transactions.
+func (tx *transaction) tree(name string, writable bool) (*btree.BTree, error) {
+	tr, ok := tx.stores[name]
+	if !ok {
+		return nil, engine.ErrStoreNotFound
+	}
+
+	if writable {
+		if !tx.cloned[name] {
+			tr = tr.Clone()
+			tx.stores[name] = tr
+			tx.cloned[name] = true
+		}
+
+		tx.version++
+	}
+
+	return tr, nil
+}
+
 func (tx *transaction) GetStore(name []byte) (engine.Store, error) {
 	select {
 	case <-tx.ctx.Done():
@@ -124,12 +219,12 @@ func (tx *transaction) GetStore(name []byte) (engine.Store, error) {
 	default:
 	}
 
-	tr, ok := tx.ng.stores[string(name)]
+	_, ok := tx.stores[string(name)]
 	if !ok {
 		return nil, engine.ErrStoreNotFound
 	}
 
-	return &storeTx{tx: tx, tr: tr, name: string(name)}, nil
+	return &storeTx{tx: tx, name: string(name)}, nil
 }
 
 func (tx *transaction) CreateStore(name []byte) error {
@@ -143,17 +238,14 @@ func (tx *transaction) CreateStore(name []byte) error {
 		return engine.ErrTransactionReadOnly
 	}
 
-	_, err := tx.GetStore(name)
-	if err == nil {
+	_, ok := tx.stores[string(name)]
+	if ok {
 		return engine.ErrStoreAlreadyExists
 	}
 
-	tx.ng.stores[string(name)] = btree.New(btreeDegree)
-
-	// on rollback, remove the btree from the list of stores
-	tx.onRollback = append(tx.onRollback, func() {
-		delete(tx.ng.stores, string(name))
-	})
+	tx.stores[string(name)] = btree.New(btreeDegree)
+	tx.cloned[string(name)] = true
+	tx.version++
 
 	return nil
 }
@@ -169,17 +261,14 @@ func (tx *transaction) DropStore(name []byte) error {
 		return engine.ErrTransactionReadOnly
 	}
 
-	rb, ok := tx.ng.stores[string(name)]
+	_, ok := tx.stores[string(name)]
 	if !ok {
 		return engine.ErrStoreNotFound
 	}
 
-	delete(tx.ng.stores, string(name))
-
-	// on rollback put back the btree to the list of stores
-	tx.onRollback = append(tx.onRollback, func() {
-		tx.ng.stores[string(name)] = rb
-	})
+	delete(tx.stores, string(name))
+	delete(tx.cloned, string(name))
+	tx.version++
 
 	return nil
 }
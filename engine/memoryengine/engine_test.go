@@ -1,11 +1,19 @@
 package memoryengine_test
 
 import (
+	"bytes"
+	"context"
+	"fmt"
+	"strconv"
+	"sync"
 	"testing"
 
+	"github.com/genjidb/genji"
 	"github.com/genjidb/genji/engine"
 	"github.com/genjidb/genji/engine/enginetest"
 	"github.com/genjidb/genji/engine/memoryengine"
+	"github.com/genjidb/genji/internal/testutil"
+	"github.com/stretchr/testify/require"
 )
 
 func builder() (engine.Engine, func()) {
@@ -24,3 +32,219 @@ func BenchmarkMemoryEngineStorePut(b *testing.B) {
 func BenchmarkMemoryEngineStoreScan(b *testing.B) {
 	enginetest.BenchmarkStoreScan(b, builder)
 }
+
+func TestExportImport(t *testing.T) {
+	ng := memoryengine.NewEngine()
+	db, err := genji.New(context.Background(), ng)
+	require.NoError(t, err)
+
+	err = db.Exec(`
+		CREATE TABLE foo(a INTEGER PRIMARY KEY, b TEXT);
+		CREATE INDEX idx_foo_b ON foo(b);
+		INSERT INTO foo (a, b) VALUES (1, 'a'), (2, 'b');
+	`)
+	require.NoError(t, err)
+
+	var buf bytes.Buffer
+	err = ng.Export(&buf)
+	require.NoError(t, err)
+	require.NoError(t, db.Close())
+
+	imported := memoryengine.NewEngine()
+	err = imported.Import(&buf)
+	require.NoError(t, err)
+
+	db, err = genji.New(context.Background(), imported)
+	require.NoError(t, err)
+	defer db.Close()
+
+	d, err := db.QueryDocument(`SELECT a FROM foo WHERE b = 'b'`)
+	require.NoError(t, err)
+	testutil.RequireDocJSONEq(t, d, `{"a": 2}`)
+
+	err = db.Exec(`INSERT INTO foo (a, b) VALUES (3, 'c')`)
+	require.NoError(t, err)
+
+	err = imported.Import(bytes.NewReader([]byte("foo")))
+	require.EqualError(t, err, "invalid export")
+}
+
+func TestSnapshots(t *testing.T) {
+	ng := memoryengine.NewEngine()
+	defer ng.Close()
+
+	ctx := context.Background()
+
+	put := func(k, v string) {
+		tx, err := ng.Begin(ctx, engine.TxOptions{Writable: true})
+		require.NoError(t, err)
+		defer tx.Rollback()
+
+		st, err := tx.GetStore([]byte("test"))
+		if err == engine.ErrStoreNotFound {
+			require.NoError(t, tx.CreateStore([]byte("test")))
+			st, err = tx.GetStore([]byte("test"))
+		}
+		require.NoError(t, err)
+		require.NoError(t, st.Put([]byte(k), []byte(v)))
+		require.NoError(t, tx.Commit())
+	}
+
+	get := func(tx engine.Transaction, k string) string {
+		st, err := tx.GetStore([]byte("test"))
+		require.NoError(t, err)
+		v, err := st.Get([]byte(k))
+		if err == engine.ErrKeyNotFound {
+			return ""
+		}
+		require.NoError(t, err)
+		return string(v)
+	}
+
+	put("a", "1")
+
+	t.Run("read-only transactions don't block writers", func(t *testing.T) {
+		ro, err := ng.Begin(ctx, engine.TxOptions{})
+		require.NoError(t, err)
+		defer ro.Rollback()
+
+		put("a", "2")
+		put("b", "2")
+
+		// the read-only transaction still reads its snapshot
+		require.Equal(t, "1", get(ro, "a"))
+		require.Equal(t, "", get(ro, "b"))
+
+		ro2, err := ng.Begin(ctx, engine.TxOptions{})
+		require.NoError(t, err)
+		defer ro2.Rollback()
+		require.Equal(t, "2", get(ro2, "a"))
+		require.Equal(t, "2", get(ro2, "b"))
+	})
+
+	t.Run("rollback", func(t *testing.T) {
+		tx, err := ng.Begin(ctx, engine.TxOptions{Writable: true})
+		require.NoError(t, err)
+
+		st, err := tx.GetStore([]byte("test"))
+		require.NoError(t, err)
+		require.NoError(t, st.Put([]byte("a"), []byte("3")))
+		require.NoError(t, st.Delete([]byte("b")))
+		require.NoError(t, tx.DropStore([]byte("test")))
+		require.NoError(t, tx.Rollback())
+
+		ro, err := ng.Begin(ctx, engine.TxOptions{})
+		require.NoError(t, err)
+		defer ro.Rollback()
+		require.Equal(t, "2", get(ro, "a"))
+		require.Equal(t, "2", get(ro, "b"))
+	})
+
+	t.Run("concurrent readers and writers", func(t *testing.T) {
+		var wg sync.WaitGroup
+
+		for i := 0; i < 10; i++ {
+			wg.Add(2)
+
+			go func(i int) {
+				defer wg.Done()
+				put(strconv.Itoa(i), strconv.Itoa(i))
+			}(i)
+
+			go func() {
+				defer wg.Done()
+
+				ro, err := ng.Begin(ctx, engine.TxOptions{})
+				require.NoError(t, err)
+				defer ro.Rollback()
+
+				st, err := ro.GetStore([]byte("test"))
+				require.NoError(t, err)
+
+				it := st.Iterator(engine.IteratorOptions{})
+				defer it.Close()
+				for it.Seek(nil); it.Valid(); it.Next() {
+				}
+				require.NoError(t, it.Err())
+			}()
+		}
+
+		wg.Wait()
+	})
+}
+
+func TestIteratorWhileModifying(t *testing.T) {
+	ng := memoryengine.NewEngine()
+	defer ng.Close()
+
+	tx, err := ng.Begin(context.Background(), engine.TxOptions{Writable: true})
+	require.NoError(t, err)
+	defer tx.Rollback()
+
+	require.NoError(t, tx.CreateStore([]byte("test")))
+	st, err := tx.GetStore([]byte("test"))
+	require.NoError(t, err)
+
+	// more than a batch of items
+	for i := 0; i < 200; i++ {
+		require.NoError(t, st.Put([]byte(fmt.Sprintf("%03d", i)), []byte("v")))
+	}
+
+	// delete the current and the next items while iterating
+	it := st.Iterator(engine.IteratorOptions{})
+	defer it.Close()
+
+	var seen []string
+	for it.Seek(nil); it.Valid(); it.Next() {
+		k := string(it.Item().Key())
+		seen = append(seen, k)
+
+		require.NoError(t, st.Delete([]byte(k)))
+		i, err := strconv.Atoi(k)
+		require.NoError(t, err)
+		if i%2 == 0 {
+			require.NoError(t, st.Delete([]byte(fmt.Sprintf("%03d", i+1))))
+		}
+	}
+	require.NoError(t, it.Err())
+	require.Len(t, seen, 100)
+
+	it2 := st.Iterator(engine.IteratorOptions{})
+	defer it2.Close()
+	it2.Seek(nil)
+	require.False(t, it2.Valid())
+}
+
+func TestClone(t *testing.T) {
+	ng := memoryengine.NewEngine()
+	db, err := genji.New(context.Background(), ng)
+	require.NoError(t, err)
+
+	err = db.Exec(`
+		CREATE TABLE foo(a INTEGER PRIMARY KEY);
+		INSERT INTO foo (a) VALUES (1), (2);
+	`)
+	require.NoError(t, err)
+	defer db.Close()
+
+	for i := 0; i < 2; i++ {
+		clone, err := ng.Clone()
+		require.NoError(t, err)
+
+		cdb, err := genji.New(context.Background(), clone)
+		require.NoError(t, err)
+
+		// changes made to a clone are not visible to the others
+		err = cdb.Exec(`INSERT INTO foo (a) VALUES (3)`)
+		require.NoError(t, err)
+
+		d, err := cdb.QueryDocument(`SELECT COUNT(*) AS n FROM foo`)
+		require.NoError(t, err)
+		testutil.RequireDocJSONEq(t, d, `{"n": 3}`)
+		require.NoError(t, cdb.Close())
+	}
+
+	d, err := db.QueryDocument(`SELECT COUNT(*) AS n FROM foo`)
+	require.NoError(t, err)
+	testutil.RequireDocJSONEq(t, d, `{"n": 2}`)
+}
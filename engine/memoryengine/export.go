@@ -0,0 +1,148 @@
+package memoryengine
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"io"
+	"sort"
+
+	"github.com/google/btree"
+)
+
+// exportMagic starts every export, followed by the version of the format.
+const exportMagic = "GENJIMEM"
+
+const exportVersion = 1
+
+// Export writes the content of every store to w, in a format readable by Import.
+// It allows in-memory databases to be persisted by the application,
+// for example in the storage of a browser when running as WebAssembly.
+// It exports the committed stores and can be called while transactions are running.
+func (ng *Engine) Export(w io.Writer) error {
+	ng.mu.Lock()
+	if ng.Closed {
+		ng.mu.Unlock()
+		return errors.New("engine closed")
+	}
+	// committed stores are never modified
+	stores := ng.stores
+	ng.mu.Unlock()
+
+	bw := bufio.NewWriter(w)
+	bw.WriteString(exportMagic)
+	bw.WriteByte(exportVersion)
+
+	names := make([]string, 0, len(stores))
+	for name := range stores {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	// bufio.Writer errors are sticky and returned by Flush
+	for _, name := range names {
+		writeBytes(bw, []byte(name))
+
+		stores[name].Ascend(func(i btree.Item) bool {
+			it := i.(*item)
+			writeBytes(bw, it.k)
+			writeBytes(bw, it.v)
+			return true
+		})
+
+		// keys are never empty, an empty one marks the end of the store
+		writeBytes(bw, nil)
+	}
+
+	// store names are never empty, an empty one marks the end of the export
+	writeBytes(bw, nil)
+
+	return bw.Flush()
+}
+
+// Import replaces the content of the engine by the stores read from r,
+// as written by Export.
+// It must be called before the engine is used to open a database,
+// since databases keep the catalog in memory.
+func (ng *Engine) Import(r io.Reader) error {
+	ng.mu.Lock()
+	closed := ng.Closed
+	ng.mu.Unlock()
+	if closed {
+		return errors.New("engine closed")
+	}
+
+	br := bufio.NewReader(r)
+
+	header := make([]byte, len(exportMagic)+1)
+	_, err := io.ReadFull(br, header)
+	if err != nil || string(header[:len(exportMagic)]) != exportMagic {
+		return errors.New("invalid export")
+	}
+	if header[len(exportMagic)] != exportVersion {
+		return errors.New("unsupported export version")
+	}
+
+	stores := make(map[string]*btree.BTree)
+	for {
+		name, err := readBytes(br)
+		if err != nil {
+			return err
+		}
+		if len(name) == 0 {
+			break
+		}
+
+		tr := btree.New(btreeDegree)
+		for {
+			k, err := readBytes(br)
+			if err != nil {
+				return err
+			}
+			if len(k) == 0 {
+				break
+			}
+
+			v, err := readBytes(br)
+			if err != nil {
+				return err
+			}
+
+			tr.ReplaceOrInsert(&item{k: k, v: v})
+		}
+
+		stores[string(name)] = tr
+	}
+
+	ng.mu.Lock()
+	ng.stores = stores
+	ng.mu.Unlock()
+	return nil
+}
+
+// writeBytes writes b prefixed by its length.
+func writeBytes(w *bufio.Writer, b []byte) {
+	var buf [binary.MaxVarintLen64]byte
+	n := binary.PutUvarint(buf[:], uint64(len(b)))
+
+	w.Write(buf[:n])
+	w.Write(b)
+}
+
+func readBytes(r *bufio.Reader) ([]byte, error) {
+	l, err := binary.ReadUvarint(r)
+	if err != nil {
+		if err == io.EOF {
+			err = io.ErrUnexpectedEOF
+		}
+		return nil, err
+	}
+
+	b := make([]byte, l)
+	_, err = io.ReadFull(r, b)
+	if err != nil {
+		return nil, err
+	}
+
+	return b, nil
+}
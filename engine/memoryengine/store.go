@@ -11,12 +11,10 @@ import (
 
 // item implements an engine.Item.
 // it is also used as a btree.Item.
+// items are shared by the clones of a btree
+// and must never be modified.
 type item struct {
 	k, v []byte
-	// set to true if the item has been deleted
-	// during the current transaction
-	// but before rollback or commit.
-	deleted bool
 }
 
 func (i *item) Key() []byte {
@@ -37,7 +35,6 @@ func (i *item) Less(than btree.Item) bool {
 
 // storeTx implements an engine.Store.
 type storeTx struct {
-	tr   *btree.BTree
 	tx   *transaction
 	name string
 }
@@ -61,33 +58,12 @@ func (s *storeTx) Put(k, v []byte) error {
 		return errors.New("empty values are forbidden")
 	}
 
-	it := &item{k: k}
-	// if there is an existing value, fetch it
-	// and overwrite it directly using the pointer.
-	if i := s.tr.Get(it); i != nil {
-		cur := i.(*item)
-
-		oldv, oldDeleted := cur.v, cur.deleted
-		cur.v = v
-		cur.deleted = false
-
-		// on rollback replace the new value by the old value
-		s.tx.onRollback = append(s.tx.onRollback, func() {
-			cur.v = oldv
-			cur.deleted = oldDeleted
-		})
-
-		return nil
+	tr, err := s.tx.tree(s.name, true)
+	if err != nil {
+		return err
 	}
 
-	it.v = v
-	s.tr.ReplaceOrInsert(it)
-
-	// on rollback delete the new item
-	s.tx.onRollback = append(s.tx.onRollback, func() {
-		s.tr.Delete(it)
-	})
-
+	tr.ReplaceOrInsert(&item{k: k, v: v})
 	return nil
 }
 
@@ -98,28 +74,20 @@ func (s *storeTx) Get(k []byte) ([]byte, error) {
 	default:
 	}
 
-	it := s.tr.Get(&item{k: k})
-
-	if it == nil {
-		return nil, engine.ErrKeyNotFound
+	tr, err := s.tx.tree(s.name, false)
+	if err != nil {
+		return nil, err
 	}
 
-	i := it.(*item)
-	// don't return items that have been deleted during
-	// this transaction.
-	if i.deleted {
+	it := tr.Get(&item{k: k})
+	if it == nil {
 		return nil, engine.ErrKeyNotFound
 	}
 
 	return it.(*item).v, nil
 }
 
-// Delete marks k for deletion. The item will be actually
-// deleted during the commit phase of the current transaction.
-// The deletion is delayed to avoid a rebalancing of the tree
-// every time we remove an item from it,
-// which causes iterators to behave incorrectly when looping
-// and deleting at the same time.
+// Delete removes k from the store.
 func (s *storeTx) Delete(k []byte) error {
 	select {
 	case <-s.tx.ctx.Done():
@@ -131,43 +99,26 @@ func (s *storeTx) Delete(k []byte) error {
 		return engine.ErrTransactionReadOnly
 	}
 
-	it := s.tr.Get(&item{k: k})
-	if it == nil {
-		return engine.ErrKeyNotFound
+	tr, err := s.tx.tree(s.name, false)
+	if err != nil {
+		return err
 	}
 
-	i := it.(*item)
-	// items that have been deleted during
-	// this transaction must be ignored.
-	if i.deleted {
+	// avoid cloning the tree if there is nothing to delete
+	if tr.Get(&item{k: k}) == nil {
 		return engine.ErrKeyNotFound
 	}
 
-	// set the deleted flag to true.
-	// this makes the item invisible during this
-	// transaction without actually deleting it
-	// from the tree.
-	// once the transaction is commited, actually
-	// remove it from the tree.
-	i.deleted = true
-
-	// on rollback set the deleted flag to false.
-	s.tx.onRollback = append(s.tx.onRollback, func() {
-		i.deleted = false
-	})
+	tr, err = s.tx.tree(s.name, true)
+	if err != nil {
+		return err
+	}
 
-	// on commit, remove the item from the tree.
-	s.tx.onCommit = append(s.tx.onCommit, func() {
-		if i.deleted {
-			s.tr.Delete(i)
-		}
-	})
+	tr.Delete(&item{k: k})
 	return nil
 }
 
-// Truncate replaces the current tree by a new
-// one. The current tree will be garbage collected
-// once the transaction is commited.
+// Truncate replaces the current tree by a new one.
 func (s *storeTx) Truncate() error {
 	select {
 	case <-s.tx.ctx.Done():
@@ -179,13 +130,13 @@ func (s *storeTx) Truncate() error {
 		return engine.ErrTransactionReadOnly
 	}
 
-	old := s.tr
-	s.tr = btree.New(btreeDegree)
+	if _, ok := s.tx.stores[s.name]; !ok {
+		return engine.ErrStoreNotFound
+	}
 
-	// on rollback replace the new tree by the old one.
-	s.tx.onRollback = append(s.tx.onRollback, func() {
-		s.tr = old
-	})
+	s.tx.stores[s.name] = btree.New(btreeDegree)
+	s.tx.cloned[s.name] = true
+	s.tx.version++
 
 	return nil
 }
@@ -195,7 +146,7 @@ func (s *storeTx) Iterator(opts engine.IteratorOptions) engine.Iterator {
 	return &iterator{
 		ctx:     s.tx.ctx,
 		tx:      s.tx,
-		tr:      s.tr,
+		name:    s.name,
 		buf:     make([]*item, 0, itBufSize),
 		reverse: opts.Reverse,
 	}
@@ -204,12 +155,14 @@ func (s *storeTx) Iterator(opts engine.IteratorOptions) engine.Iterator {
 const itBufSize = 64
 
 // iterator iterates over the btree in batches.
-
+// Since the store can be modified while iterating,
+// the batch is reloaded from the current key
+// whenever the transaction modifies a store.
 type iterator struct {
 	ctx     context.Context
 	tx      *transaction
+	name    string
 	reverse bool
-	tr      *btree.BTree
 
 	// buf stores a batch of itBufSize items
 	buf []*item
@@ -217,6 +170,9 @@ type iterator struct {
 	// cursor represents the current item in the batch
 	cursor int
 
+	// version of the transaction when the batch was loaded
+	version uint64
+
 	// seekBuf is used to avoid reallocating an item everytime
 	// we need to seek in the tree
 	seekBuf item
@@ -228,9 +184,21 @@ type iterator struct {
 
 // Seek seeks the pivot and reads a batch of items from the tree.
 func (it *iterator) Seek(pivot []byte) {
+	it.load(pivot, false)
+}
+
+// load reads a batch of items starting from the pivot.
+// If exclusive is true, the pivot itself is skipped.
+func (it *iterator) load(pivot []byte, exclusive bool) {
 	// reset the buffer and cursor
 	it.buf = it.buf[:0]
 	it.cursor = 0
+	it.version = it.tx.version
+
+	tr, ok := it.tx.stores[it.name]
+	if !ok {
+		return
+	}
 
 	// build the tree iterator so that it reads at most
 	// itBufSize items
@@ -245,17 +213,22 @@ func (it *iterator) Seek(pivot []byte) {
 	it.seekBuf.k = pivot
 	if it.reverse {
 		if len(pivot) == 0 {
-			it.tr.Descend(iter)
+			tr.Descend(iter)
 		} else {
-			it.tr.DescendLessOrEqual(&it.seekBuf, iter)
+			tr.DescendLessOrEqual(&it.seekBuf, iter)
 		}
 	} else {
 		if len(pivot) == 0 {
-			it.tr.Ascend(iter)
+			tr.Ascend(iter)
 		} else {
-			it.tr.AscendGreaterOrEqual(&it.seekBuf, iter)
+			tr.AscendGreaterOrEqual(&it.seekBuf, iter)
 		}
 	}
+
+	// only the first item can be equal to the pivot
+	if exclusive && len(it.buf) > 0 && bytes.Equal(it.buf[0].k, pivot) {
+		it.cursor++
+	}
 }
 
 func (it *iterator) Valid() bool {
@@ -266,19 +239,14 @@ func (it *iterator) Valid() bool {
 	select {
 	case <-it.ctx.Done():
 		it.err = it.ctx.Err()
+		return false
 	default:
 	}
 
-	// since the iterator can be used
-	// while deleting items
-	// we need to skip all deleted items
-	// until we find one that's not deleted
-	for it.cursor < len(it.buf) {
-		if !it.buf[it.cursor].deleted {
-			break
-		}
-
-		it.cursor++
+	// if the store was modified since the batch was loaded,
+	// reload it from the current item to take the changes into account
+	if it.cursor < len(it.buf) && it.version != it.tx.version {
+		it.load(it.buf[it.cursor].k, false)
 	}
 
 	// if we reached the end of the buffer
@@ -286,28 +254,10 @@ func (it *iterator) Valid() bool {
 	for it.cursor >= len(it.buf) && len(it.buf) == itBufSize {
 		// get the key of the last item of the buffer
 		// and preload from that key
-		pivot := it.buf[len(it.buf)-1].k
-		it.Seek(pivot)
-
-		// the pivot was part of the previous batch
-		// but is also part of the new batch, we need to
-		// skip it to avoid duplicate iteration
-		it.cursor++
-
-		// since the iterator can be used
-		// while deleting items
-		// we need to skip all deleted items
-		// until we find one that's not deleted
-		for it.cursor < len(it.buf) {
-			if !it.buf[it.cursor].deleted {
-				break
-			}
-
-			it.cursor++
-		}
+		it.load(it.buf[len(it.buf)-1].k, true)
 	}
 
-	return len(it.buf) > 0 && it.cursor < len(it.buf) && it.err == nil
+	return it.cursor < len(it.buf)
 }
 
 func (it *iterator) Next() {
@@ -322,7 +272,7 @@ func (it *iterator) Item() engine.Item {
 	return it.buf[it.cursor]
 }
 
-// Close the inner goroutine.
+// Close the iterator.
 func (it *iterator) Close() error {
 	return nil
 }
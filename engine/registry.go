@@ -0,0 +1,55 @@
+package engine
+
+import (
+	"sort"
+	"sync"
+)
+
+// An Opener opens the engine stored at the given path.
+// The format of the path depends on the engine.
+type Opener func(path string) (Engine, error)
+
+var (
+	openersMu sync.RWMutex
+	openers   = make(map[string]Opener)
+)
+
+// Register makes an engine available under the given name, so that
+// it can be selected when opening a database, i.e. genji.Open("name:path").
+// Engine packages usually call Register in their init function.
+// It panics if Register is called twice with the same name or if opener is nil.
+func Register(name string, opener Opener) {
+	openersMu.Lock()
+	defer openersMu.Unlock()
+
+	if opener == nil {
+		panic("engine: Register opener is nil")
+	}
+	if _, dup := openers[name]; dup {
+		panic("engine: Register called twice for engine " + name)
+	}
+
+	openers[name] = opener
+}
+
+// Lookup returns the opener of the engine registered under the given name.
+func Lookup(name string) (Opener, bool) {
+	openersMu.RLock()
+	defer openersMu.RUnlock()
+
+	opener, ok := openers[name]
+	return opener, ok
+}
+
+// Engines returns the sorted list of the names of the registered engines.
+func Engines() []string {
+	openersMu.RLock()
+	defer openersMu.RUnlock()
+
+	names := make([]string, 0, len(openers))
+	for name := range openers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
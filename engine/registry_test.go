@@ -0,0 +1,34 @@
+package engine_test
+
+import (
+	"testing"
+
+	"github.com/genjidb/genji/engine"
+	"github.com/genjidb/genji/engine/memoryengine"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRegister(t *testing.T) {
+	opener := func(string) (engine.Engine, error) {
+		return memoryengine.NewEngine(), nil
+	}
+
+	engine.Register("test-register", opener)
+	require.Contains(t, engine.Engines(), "test-register")
+
+	o, ok := engine.Lookup("test-register")
+	require.True(t, ok)
+	ng, err := o("")
+	require.NoError(t, err)
+	require.NotNil(t, ng)
+
+	_, ok = engine.Lookup("unknown")
+	require.False(t, ok)
+
+	require.Panics(t, func() {
+		engine.Register("test-register", opener)
+	})
+	require.Panics(t, func() {
+		engine.Register("test-nil", nil)
+	})
+}
@@ -3,6 +3,7 @@ package errors
 import (
 	"errors"
 
+	"github.com/genjidb/genji/document"
 	"github.com/genjidb/genji/internal/stringutil"
 )
 
@@ -13,6 +14,20 @@ var (
 	// ErrDuplicateDocument is returned when another document is already associated with a given key, primary key,
 	// or if there is a unique index violation.
 	ErrDuplicateDocument = errors.New("duplicate document")
+
+	// ErrStatementTimeout is returned when a statement runs for longer than
+	// the statement_timeout setting of the session.
+	ErrStatementTimeout = errors.New("statement timeout")
+
+	// ErrTransactionTimeout is returned when a transaction runs for longer than
+	// the transaction_timeout setting of the session it was started with.
+	ErrTransactionTimeout = errors.New("transaction timeout")
+
+	// ErrWriteConflict is returned when committing an optimistic transaction that
+	// wrote a document, or an index entry, modified by another transaction committed
+	// since it started, or if the schema was modified in between.
+	// The transaction is not committed and can be retried.
+	ErrWriteConflict = errors.New("write conflict")
 )
 
 // AlreadyExistsError is returned when to create a table, an index or a sequence
@@ -44,3 +59,40 @@ func IsNotFoundError(err error) bool {
 	_, ok := err.(NotFoundError)
 	return ok
 }
+
+// ConstraintViolationError is returned when a document violates a unique index,
+// i.e. when another document of the table has the same values at the paths of the index.
+// Unique indexes are created by CREATE UNIQUE INDEX, or by the UNIQUE field and table constraints.
+// It wraps ErrDuplicateDocument.
+type ConstraintViolationError struct {
+	// Name of the table the document was written to.
+	TableName string
+	// Name of the violated unique index.
+	IndexName string
+	// Name of the table constraint enforced by the index, if any,
+	// i.e. CONSTRAINT name UNIQUE (a, b).
+	Constraint string
+	// Paths of the index, and values of the document at these paths.
+	// For multi-key indexes, the value of the multi-key path is the conflicting element of the array.
+	Paths  []document.Path
+	Values []document.Value
+}
+
+func (c *ConstraintViolationError) Error() string {
+	if c.Constraint != "" {
+		return stringutil.Sprintf("%s: unique constraint %q violated", ErrDuplicateDocument, c.Constraint)
+	}
+
+	return stringutil.Sprintf("%s: unique index %q violated", ErrDuplicateDocument, c.IndexName)
+}
+
+// Unwrap returns ErrDuplicateDocument.
+func (c *ConstraintViolationError) Unwrap() error {
+	return ErrDuplicateDocument
+}
+
+// IsConstraintViolationError returns true if err is or wraps a *ConstraintViolationError.
+func IsConstraintViolationError(err error) bool {
+	var c *ConstraintViolationError
+	return errors.As(err, &c)
+}
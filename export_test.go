@@ -0,0 +1,50 @@
+package genji
+
+import (
+	"github.com/genjidb/genji/internal/catalog"
+	"github.com/genjidb/genji/internal/database"
+	"github.com/genjidb/genji/internal/query/statement"
+)
+
+// QueryCacheLen returns the number of results kept by the query cache.
+func QueryCacheLen(db *DB) int {
+	db.cache.mu.Lock()
+	defer db.cache.mu.Unlock()
+
+	return db.cache.lru.len()
+}
+
+// IsQueryCached returns whether the result of the query is in the query cache and up to date.
+func IsQueryCached(db *DB, q string, args ...interface{}) bool {
+	stmt, err := db.Prepare(q)
+	if err != nil {
+		return false
+	}
+
+	key, ok := stmt.cacheKey(argsToParams(args))
+	if !ok {
+		return false
+	}
+
+	_, ok = db.cache.get(db.db, key)
+	return ok
+}
+
+// PlanCacheLen returns the number of plans kept by the plan cache.
+func PlanCacheLen(db *DB) int {
+	db.plans.mu.Lock()
+	defer db.plans.mu.Unlock()
+
+	return db.plans.lru.len()
+}
+
+// CachedPlan returns the plan of the query kept by the plan cache, if it is up to date.
+func CachedPlan(db *DB, q string) (string, bool) {
+	n, _ := db.db.Catalog.(*catalog.Catalog).Modifications()
+	pq, ok := db.plans.get(q, n, database.StatisticsVersion())
+	if !ok {
+		return "", false
+	}
+
+	return pq.Statements[0].(*statement.StreamStmt).PreparedStream.String(), true
+}
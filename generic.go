@@ -0,0 +1,178 @@
+//go:build go1.18 && !wasm
+// +build go1.18,!wasm
+
+// The generic helpers rely on document.Scan and document.StructScan,
+// which use reflection and are not built under wasm.
+
+package genji
+
+import (
+	"database/sql"
+	"reflect"
+	"time"
+
+	"github.com/genjidb/genji/document"
+)
+
+// Queryer runs queries. It is implemented by *DB and *Tx.
+type Queryer interface {
+	Query(q string, args ...interface{}) (*Result, error)
+	QueryDocument(q string, args ...interface{}) (document.Document, error)
+}
+
+var (
+	_ Queryer = (*DB)(nil)
+	_ Queryer = (*Tx)(nil)
+)
+
+// QueryAs runs the query and returns the documents it returns, each scanned into a value of type T.
+// Documents are scanned into structs using document.StructScan, and into maps using document.MapScan.
+// Other types, such as integers or strings, receive the first field of each document, which is
+// useful for queries projecting a single field:
+//
+//	users, err := genji.QueryAs[User](db, "SELECT * FROM users WHERE age > ?", 18)
+//	names, err := genji.QueryAs[string](db, "SELECT name FROM users")
+//
+// This function requires Go 1.18 or later.
+func QueryAs[T any](q Queryer, query string, args ...interface{}) ([]T, error) {
+	res, err := q.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Close()
+
+	var list []T
+	err = res.Iterate(func(d document.Document) error {
+		v, err := scanAs[T](d)
+		if err != nil {
+			return err
+		}
+
+		list = append(list, v)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return list, nil
+}
+
+// GetAs runs the query and returns the first document, scanned into a value of type T
+// following the rules of QueryAs.
+// If the query returns no document, GetAs returns errs.ErrDocumentNotFound.
+//
+// This function requires Go 1.18 or later.
+func GetAs[T any](q Queryer, query string, args ...interface{}) (T, error) {
+	d, err := q.QueryDocument(query, args...)
+	if err != nil {
+		var zero T
+		return zero, err
+	}
+
+	return scanAs[T](d)
+}
+
+// Null is a value of type T that may be NULL. It can be used as a scan target,
+// a struct field or a query parameter:
+//
+//	var name genji.Null[string]
+//	err := document.Scan(d, &name)
+//	if name.Valid {
+//		fmt.Println(name.V)
+//	}
+//
+// Missing fields are scanned as NULL.
+// This type requires Go 1.18 or later.
+type Null[T any] struct {
+	V     T
+	Valid bool
+}
+
+// NewNull returns a valid Null holding v.
+func NewNull[T any](v T) Null[T] {
+	return Null[T]{V: v, Valid: true}
+}
+
+// UnmarshalValue implements the document.Unmarshaler interface.
+func (n *Null[T]) UnmarshalValue(v document.Value) error {
+	var zero T
+	n.V, n.Valid = zero, false
+
+	if v.Type == 0 || v.Type == document.NullValue {
+		return nil
+	}
+
+	err := document.ScanValue(v, &n.V)
+	if err != nil {
+		return err
+	}
+
+	n.Valid = true
+	return nil
+}
+
+// MarshalValue implements the document.Marshaler interface.
+func (n Null[T]) MarshalValue() (document.Value, error) {
+	if !n.Valid {
+		return document.NewNullValue(), nil
+	}
+
+	return document.NewValue(n.V)
+}
+
+var (
+	documentType    = reflect.TypeOf((*document.Document)(nil)).Elem()
+	unmarshalerType = reflect.TypeOf((*document.Unmarshaler)(nil)).Elem()
+	sqlScannerType  = reflect.TypeOf((*sql.Scanner)(nil)).Elem()
+	timeType        = reflect.TypeOf(time.Time{})
+)
+
+// scanAs scans d into a new value of type T.
+func scanAs[T any](d document.Document) (T, error) {
+	var v T
+
+	ref := reflect.ValueOf(&v).Elem()
+	if ref.Kind() == reflect.Interface && documentType.AssignableTo(ref.Type()) {
+		// documents are only valid during the iteration
+		fb := document.NewFieldBuffer()
+		err := fb.Copy(d)
+		if err != nil {
+			return v, err
+		}
+
+		ref.Set(reflect.ValueOf(fb))
+		return v, nil
+	}
+
+	// allocate pointers to structs and maps
+	target := ref
+	for target.Kind() == reflect.Ptr {
+		target.Set(reflect.New(target.Type().Elem()))
+		target = target.Elem()
+	}
+
+	var err error
+	switch {
+	case target.Kind() == reflect.Map:
+		err = document.MapScan(d, target.Addr().Interface())
+	case target.Kind() == reflect.Struct && isDocumentTarget(target.Type()):
+		err = document.StructScan(d, target.Addr().Interface())
+	default:
+		ref.Set(reflect.Zero(ref.Type()))
+		err = document.Scan(d, &v)
+	}
+
+	return v, err
+}
+
+// isDocumentTarget reports whether documents are scanned into values of the struct type t
+// field by field, rather than being values themselves.
+func isDocumentTarget(t reflect.Type) bool {
+	if t == timeType {
+		return false
+	}
+
+	pt := reflect.PtrTo(t)
+	return !pt.Implements(unmarshalerType) && !pt.Implements(sqlScannerType)
+}
@@ -0,0 +1,158 @@
+//go:build go1.18 && !wasm
+// +build go1.18,!wasm
+
+package genji_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/genjidb/genji"
+	"github.com/genjidb/genji/document"
+	errs "github.com/genjidb/genji/errors"
+	"github.com/genjidb/genji/internal/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+func TestQueryAs(t *testing.T) {
+	db, err := genji.Open(":memory:")
+	require.NoError(t, err)
+	defer db.Close()
+
+	err = db.Exec(`
+		CREATE TABLE users(id INTEGER PRIMARY KEY, name TEXT, created TIMESTAMP);
+		INSERT INTO users (id, name, created) VALUES (1, 'foo', '2021-01-01T00:00:00Z'), (2, 'bar', '2021-01-02T00:00:00Z');
+	`)
+	require.NoError(t, err)
+
+	type user struct {
+		ID   int64
+		Name string
+	}
+
+	t.Run("Structs", func(t *testing.T) {
+		users, err := genji.QueryAs[user](db, "SELECT id, name FROM users WHERE id >= ?", 1)
+		require.NoError(t, err)
+		require.Equal(t, []user{{1, "foo"}, {2, "bar"}}, users)
+
+		ptrs, err := genji.QueryAs[*user](db, "SELECT id, name FROM users WHERE id = 2")
+		require.NoError(t, err)
+		require.Equal(t, []*user{{2, "bar"}}, ptrs)
+
+		empty, err := genji.QueryAs[user](db, "SELECT * FROM users WHERE id > 10")
+		require.NoError(t, err)
+		require.Empty(t, empty)
+	})
+
+	t.Run("Single fields", func(t *testing.T) {
+		names, err := genji.QueryAs[string](db, "SELECT name FROM users ORDER BY name")
+		require.NoError(t, err)
+		require.Equal(t, []string{"bar", "foo"}, names)
+
+		dates, err := genji.QueryAs[time.Time](db, "SELECT created FROM users")
+		require.NoError(t, err)
+		require.Len(t, dates, 2)
+		require.Equal(t, time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC), dates[0].UTC())
+	})
+
+	t.Run("Maps and documents", func(t *testing.T) {
+		maps, err := genji.QueryAs[map[string]interface{}](db, "SELECT name FROM users WHERE id = 1")
+		require.NoError(t, err)
+		require.Equal(t, []map[string]interface{}{{"name": "foo"}}, maps)
+
+		docs, err := genji.QueryAs[document.Document](db, "SELECT name FROM users")
+		require.NoError(t, err)
+		require.Len(t, docs, 2)
+		v, err := docs[1].GetByField("name")
+		require.NoError(t, err)
+		require.Equal(t, document.NewTextValue("bar"), v)
+	})
+
+	t.Run("GetAs", func(t *testing.T) {
+		u, err := genji.GetAs[user](db, "SELECT id, name FROM users WHERE id = ?", 2)
+		require.NoError(t, err)
+		require.Equal(t, user{2, "bar"}, u)
+
+		n, err := genji.GetAs[int](db, "SELECT COUNT(*) FROM users")
+		require.NoError(t, err)
+		require.Equal(t, 2, n)
+
+		_, err = genji.GetAs[user](db, "SELECT * FROM users WHERE id = 10")
+		require.Equal(t, errs.ErrDocumentNotFound, err)
+
+		// within a transaction
+		err = db.View(func(tx *genji.Tx) error {
+			name, err := genji.GetAs[string](tx, "SELECT name FROM users WHERE id = 1")
+			require.Equal(t, "foo", name)
+			return err
+		})
+		require.NoError(t, err)
+	})
+}
+
+func TestNull(t *testing.T) {
+	db, err := genji.Open(":memory:")
+	require.NoError(t, err)
+	defer db.Close()
+
+	err = db.Exec(`
+		CREATE TABLE users(id INTEGER PRIMARY KEY, name TEXT, age INTEGER);
+		INSERT INTO users (id, name, age) VALUES (1, 'foo', 10), (2, NULL, NULL);
+		INSERT INTO users (id) VALUES (3);
+	`)
+	require.NoError(t, err)
+
+	t.Run("Scan", func(t *testing.T) {
+		d, err := db.QueryDocument("SELECT name, age FROM users WHERE id = 1")
+		require.NoError(t, err)
+
+		var name genji.Null[string]
+		var age genji.Null[int]
+		err = document.Scan(d, &name, &age)
+		require.NoError(t, err)
+		require.Equal(t, genji.NewNull("foo"), name)
+		require.Equal(t, genji.NewNull(10), age)
+
+		d, err = db.QueryDocument("SELECT name, age FROM users WHERE id = 2")
+		require.NoError(t, err)
+		err = document.Scan(d, &name, &age)
+		require.NoError(t, err)
+		require.Equal(t, genji.Null[string]{}, name)
+		require.Equal(t, genji.Null[int]{}, age)
+
+		// values that cannot be converted are reported
+		d, err = db.QueryDocument("SELECT name FROM users WHERE id = 1")
+		require.NoError(t, err)
+		err = document.Scan(d, &age)
+		require.Error(t, err)
+	})
+
+	t.Run("Structs", func(t *testing.T) {
+		type user struct {
+			ID   int64
+			Name genji.Null[string]
+			Age  genji.Null[int64]
+		}
+
+		users, err := genji.QueryAs[user](db, "SELECT * FROM users ORDER BY id")
+		require.NoError(t, err)
+		require.Equal(t, []user{
+			{1, genji.NewNull("foo"), genji.NewNull(int64(10))},
+			{2, genji.Null[string]{}, genji.Null[int64]{}},
+			// missing fields are NULL
+			{3, genji.Null[string]{}, genji.Null[int64]{}},
+		}, users)
+
+		err = db.Exec("INSERT INTO users VALUES ?", &user{ID: 4, Age: genji.NewNull(int64(40))})
+		require.NoError(t, err)
+		d, err := db.QueryDocument("SELECT * FROM users WHERE id = 4")
+		require.NoError(t, err)
+		testutil.RequireDocJSONEq(t, d, `{"id": 4, "name": null, "age": 40}`)
+	})
+
+	t.Run("Params", func(t *testing.T) {
+		names, err := genji.QueryAs[genji.Null[string]](db, "SELECT ? AS name UNION ALL SELECT ?", genji.NewNull("bar"), genji.Null[string]{})
+		require.NoError(t, err)
+		require.Equal(t, []genji.Null[string]{genji.NewNull("bar"), {}}, names)
+	})
+}
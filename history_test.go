@@ -0,0 +1,105 @@
+package genji_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/genjidb/genji"
+	"github.com/genjidb/genji/internal/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAsOf(t *testing.T) {
+	db, err := genji.Open(":memory:")
+	require.NoError(t, err)
+	defer db.Close()
+
+	requireDocs := func(t *testing.T, expected string, q string, args ...interface{}) {
+		t.Helper()
+
+		res, err := db.Query(q, args...)
+		require.NoError(t, err)
+		defer res.Close()
+
+		testutil.RequireStreamEq(t, expected, res)
+	}
+
+	err = db.Exec(`
+		CREATE TABLE foo (a INT PRIMARY KEY, b TEXT);
+		CREATE INDEX idx_foo_b ON foo (b);
+	`)
+	require.NoError(t, err)
+
+	// versions are only retained once the pragma is set
+	err = db.Exec(`SELECT * FROM foo AS OF '-1m'`)
+	require.EqualError(t, err, "AS OF requires the version_retention pragma to be set")
+
+	err = db.Exec(`
+		PRAGMA version_retention = '1h';
+		INSERT INTO foo (a, b) VALUES (1, 'x'), (2, 'y'), (4, 'w');
+	`)
+	require.NoError(t, err)
+	t1 := time.Now()
+
+	err = db.Exec(`
+		UPDATE foo SET b = 'z' WHERE a = 1;
+		DELETE FROM foo WHERE a = 2;
+		INSERT INTO foo (a, b) VALUES (3, 't');
+	`)
+	require.NoError(t, err)
+	t2 := time.Now()
+
+	err = db.Exec(`UPDATE foo SET b = 'v'`)
+	require.NoError(t, err)
+
+	requireDocs(t, `{"a": 1, "b": "v"} {"a": 3, "b": "v"} {"a": 4, "b": "v"}`, `SELECT * FROM foo`)
+	requireDocs(t, `{"a": 1, "b": "x"} {"a": 2, "b": "y"} {"a": 4, "b": "w"}`, `SELECT * FROM foo AS OF ?`, t1)
+	requireDocs(t, `{"a": 1, "b": "z"} {"a": 3, "b": "t"} {"a": 4, "b": "w"}`, `SELECT * FROM foo AS OF ?`, t2)
+
+	// filters, aliases and ordering apply to the previous versions, without using indexes
+	requireDocs(t, `{"f.b": "y"}`, `SELECT f.b FROM foo AS OF ? AS f WHERE f.a = 2`, t1)
+	requireDocs(t, `{"a": 2}`, `SELECT a FROM foo AS OF ? WHERE b = 'y'`, t1)
+	requireDocs(t, `{"a": 4} {"a": 2} {"a": 1}`, `SELECT a FROM foo AS OF ? ORDER BY a DESC`, t1)
+	requireDocs(t, `{"a": 3} {"a": 4}`, `SELECT a FROM foo AS OF ? ORDER BY b LIMIT 2`, t2)
+
+	// durations are relative to the current time
+	requireDocs(t, `{"COUNT(*)": 0}`, `SELECT COUNT(*) FROM foo AS OF '-1m'`)
+
+	err = db.Exec(`SELECT * FROM foo AS OF '-2h'`)
+	require.Error(t, err)
+	err = db.Exec(`CREATE VIEW v AS SELECT * FROM foo; SELECT * FROM v AS OF '-1m'`)
+	require.Error(t, err)
+
+	t.Run("Pruning", func(t *testing.T) {
+		db, err := genji.Open(":memory:")
+		require.NoError(t, err)
+		defer db.Close()
+
+		err = db.Exec(`
+			CREATE TABLE foo (a INT PRIMARY KEY);
+			PRAGMA version_retention = '20ms';
+			INSERT INTO foo (a) VALUES (1), (2);
+		`)
+		require.NoError(t, err)
+		time.Sleep(20 * time.Millisecond)
+
+		// changes older than the retention are deleted by the next commits
+		err = db.Exec(`INSERT INTO foo (a) VALUES (3)`)
+		require.NoError(t, err)
+
+		d, err := db.QueryDocument(`SELECT COUNT(*) FROM __genji_changes`)
+		require.NoError(t, err)
+		testutil.RequireDocJSONEq(t, d, `{"COUNT(*)": 1}`)
+
+		// unless the change log is on
+		err = db.Exec(`PRAGMA change_log = 'on'`)
+		require.NoError(t, err)
+		time.Sleep(20 * time.Millisecond)
+		err = db.Exec(`INSERT INTO foo (a) VALUES (4)`)
+		require.NoError(t, err)
+
+		d, err = db.QueryDocument(`SELECT COUNT(*) FROM __genji_changes`)
+		require.NoError(t, err)
+		testutil.RequireDocJSONEq(t, d, `{"COUNT(*)": 2}`)
+	})
+}
@@ -15,10 +15,15 @@ type Relation interface {
 	GenerateBaseName() string
 }
 
+// catalogCache holds the relations of the catalog.
+// Once published by a commit, a catalogCache is never modified again:
+// transactions modifying the catalog work on a clone.
 type catalogCache struct {
 	tables    map[string]Relation
 	indexes   map[string]Relation
 	sequences map[string]Relation
+	views     map[string]Relation
+	triggers  map[string]Relation
 }
 
 func newCatalogCache() *catalogCache {
@@ -26,10 +31,12 @@ func newCatalogCache() *catalogCache {
 		tables:    make(map[string]Relation),
 		indexes:   make(map[string]Relation),
 		sequences: make(map[string]Relation),
+		views:     make(map[string]Relation),
+		triggers:  make(map[string]Relation),
 	}
 }
 
-func (c *catalogCache) load(tables []database.TableInfo, indexes []database.IndexInfo, sequences []database.Sequence) {
+func (c *catalogCache) load(tables []database.TableInfo, indexes []database.IndexInfo, sequences []database.Sequence, views []database.ViewInfo, triggers []database.TriggerInfo) {
 	for i := range tables {
 		c.tables[tables[i].TableName] = &tables[i]
 	}
@@ -41,9 +48,18 @@ func (c *catalogCache) load(tables []database.TableInfo, indexes []database.Inde
 	for i := range sequences {
 		c.sequences[sequences[i].Info.Name] = &sequences[i]
 	}
+
+	for i := range views {
+		c.views[views[i].ViewName] = &views[i]
+	}
+
+	for i := range triggers {
+		c.triggers[triggers[i].TriggerName] = &triggers[i]
+	}
 }
 
-// TODO put in tests
+// Clone returns a copy of the cache. Relations are shared
+// and must be replaced rather than modified.
 func (c *catalogCache) Clone() *catalogCache {
 	clone := newCatalogCache()
 
@@ -56,6 +72,12 @@ func (c *catalogCache) Clone() *catalogCache {
 	for k, v := range c.sequences {
 		clone.sequences[k] = v
 	}
+	for k, v := range c.views {
+		clone.views[k] = v
+	}
+	for k, v := range c.triggers {
+		clone.triggers[k] = v
+	}
 
 	return clone
 }
@@ -76,6 +98,16 @@ func (c *catalogCache) objectExists(name string) bool {
 		return true
 	}
 
+	// checking if view exists with the same name
+	if _, ok := c.views[name]; ok {
+		return true
+	}
+
+	// checking if trigger exists with the same name
+	if _, ok := c.triggers[name]; ok {
+		return true
+	}
+
 	return false
 }
 
@@ -102,12 +134,16 @@ func (c *catalogCache) getMapByType(tp string) map[string]Relation {
 		return c.indexes
 	case RelationSequenceType:
 		return c.sequences
+	case RelationViewType:
+		return c.views
+	case RelationTriggerType:
+		return c.triggers
 	}
 
 	panic(stringutil.Sprintf("unknown catalog object type %q", tp))
 }
 
-func (c *catalogCache) Add(tx *database.Transaction, o Relation) error {
+func (c *catalogCache) Add(o Relation) error {
 	name := o.Name()
 
 	// if name is provided, ensure it's not duplicated
@@ -124,31 +160,22 @@ func (c *catalogCache) Add(tx *database.Transaction, o Relation) error {
 	m := c.getMapByType(o.Type())
 	m[name] = o
 
-	tx.OnRollbackHooks = append(tx.OnRollbackHooks, func() {
-		delete(m, name)
-	})
-
 	return nil
 }
 
-func (c *catalogCache) Replace(tx *database.Transaction, o Relation) error {
+func (c *catalogCache) Replace(o Relation) error {
 	m := c.getMapByType(o.Type())
 
-	old, ok := m[o.Name()]
-	if !ok {
+	if _, ok := m[o.Name()]; !ok {
 		return errs.NotFoundError{Name: o.Name()}
 	}
 
 	m[o.Name()] = o
 
-	tx.OnRollbackHooks = append(tx.OnRollbackHooks, func() {
-		m[o.Name()] = old
-	})
-
 	return nil
 }
 
-func (c *catalogCache) Delete(tx *database.Transaction, tp, name string) (Relation, error) {
+func (c *catalogCache) Delete(tp, name string) (Relation, error) {
 	m := c.getMapByType(tp)
 
 	o, ok := m[name]
@@ -158,10 +185,6 @@ func (c *catalogCache) Delete(tx *database.Transaction, tp, name string) (Relati
 
 	delete(m, name)
 
-	tx.OnRollbackHooks = append(tx.OnRollbackHooks, func() {
-		m[name] = o
-	})
-
 	return o, nil
 }
 
@@ -200,3 +223,34 @@ func (c *catalogCache) GetTableIndexes(tableName string) []*database.IndexInfo {
 
 	return indexes
 }
+
+// GetDependentViews returns the names of the views reading the given table or view,
+// sorted lexicographically.
+func (c *catalogCache) GetDependentViews(name string) []string {
+	var views []string
+	for _, o := range c.views {
+		v := o.(*database.ViewInfo)
+		if v.DependsOn(name) {
+			views = append(views, v.ViewName)
+		}
+	}
+
+	sort.Strings(views)
+	return views
+}
+
+// GetTableTriggers returns the triggers of the given table, sorted by name.
+func (c *catalogCache) GetTableTriggers(tableName string) []*database.TriggerInfo {
+	var triggers []*database.TriggerInfo
+	for _, o := range c.triggers {
+		t := o.(*database.TriggerInfo)
+		if t.TableName == tableName {
+			triggers = append(triggers, t)
+		}
+	}
+
+	sort.Slice(triggers, func(i, j int) bool {
+		return triggers[i].TriggerName < triggers[j].TriggerName
+	})
+	return triggers
+}
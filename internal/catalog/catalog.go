@@ -1,14 +1,18 @@
 package catalog
 
 import (
+	"bytes"
 	"encoding/binary"
 	"errors"
 	"math"
 	"sort"
+	"sync"
+	"sync/atomic"
 
 	"github.com/genjidb/genji/document"
 	errs "github.com/genjidb/genji/errors"
 	"github.com/genjidb/genji/internal/database"
+	"github.com/genjidb/genji/internal/expr"
 	"github.com/genjidb/genji/internal/stringutil"
 )
 
@@ -17,20 +21,148 @@ const (
 	RelationTableType    = "table"
 	RelationIndexType    = "index"
 	RelationSequenceType = "sequence"
+	RelationViewType     = "view"
+	RelationTriggerType  = "trigger"
 	StoreSequence        = database.InternalPrefix + "store_seq"
 )
 
-// Catalog manages all database objects such as tables, indexes and sequences.
+// Catalog manages all database objects such as tables, indexes, sequences, views and triggers.
 // It stores all these objects in memory for fast access. Any modification
 // is persisted into the __genji_catalog table.
+//
+// The in-memory catalog is copy-on-write: the first time a read-write transaction
+// modifies it, the transaction gets its own copy, which replaces the committed snapshot
+// when the transaction commits and is simply dropped when it rolls back.
+// Other transactions keep reading the committed snapshot, which is never modified,
+// without taking any lock. Read-only transactions keep reading the snapshot that
+// was committed when they started, even if another one was committed since.
 type Catalog struct {
-	Cache        *catalogCache
 	CatalogTable *CatalogTable
+
+	// snapshot of the catalog as of the last commit.
+	committed atomic.Value // *catalogCache
+	// copy of the catalog modified by the running read-write transaction, if any.
+	pending atomic.Value // *pendingCache
+	// row policies are kept in memory only and
+	// must be registered every time the database is opened.
+	policiesMu sync.RWMutex
+	policies   map[string]*database.RowPolicy
+
+	// virtual tables are kept in memory only as well.
+	virtualTablesMu sync.RWMutex
+	virtualTables   map[string]database.VirtualTable
 }
 
+// modifications is the number of modifications made to a catalog.
+// It is shared by all catalogs, which only causes spurious changes to be reported
+// by Modifications, and keeps catalogs comparable.
+var modifications uint64
+
 func New() *Catalog {
-	return &Catalog{
-		Cache: newCatalogCache(),
+	var c Catalog
+	c.committed.Store(newCatalogCache())
+	c.pending.Store((*pendingCache)(nil))
+	return &c
+}
+
+// Clone returns a catalog sharing the committed snapshot of c.
+// Modifications made to either catalog are not visible to the other one.
+func (c *Catalog) Clone() *Catalog {
+	clone := New()
+	clone.CatalogTable = c.CatalogTable
+	clone.committed.Store(c.committed.Load())
+	return clone
+}
+
+// pendingCache is the copy of the catalog modified by a read-write transaction.
+type pendingCache struct {
+	tx    *database.Transaction
+	cache *catalogCache
+}
+
+// snapshot returns the catalog as seen by tx: the copy it modified, if any,
+// the snapshot committed when it started if it is read-only, or the snapshot of the last commit.
+// If tx is nil, it returns the snapshot of the last commit.
+func (c *Catalog) snapshot(tx *database.Transaction) *catalogCache {
+	if tx == nil {
+		return c.committed.Load().(*catalogCache)
+	}
+
+	if p := c.pending.Load().(*pendingCache); p != nil && p.tx == tx {
+		return p.cache
+	}
+
+	if cc, ok := tx.CatalogSnapshot.(*catalogCache); ok {
+		return cc
+	}
+
+	return c.committed.Load().(*catalogCache)
+}
+
+// Snapshot returns the snapshot of the last commit. It is pinned by the read-only
+// transactions when they start, which then read it in place of the newer ones.
+func (c *Catalog) Snapshot() interface{} {
+	return c.committed.Load().(*catalogCache)
+}
+
+// IsStale reports whether tx reads a snapshot of the catalog older than the last commit.
+func (c *Catalog) IsStale(tx *database.Transaction) bool {
+	if tx == nil || tx.CatalogSnapshot == nil {
+		return false
+	}
+
+	return tx.CatalogSnapshot != c.Snapshot()
+}
+
+// writable returns the copy of the catalog modified by tx, creating it
+// the first time tx modifies the catalog.
+// The copy is published when tx commits, and discarded if tx rolls back.
+// Every call counts as a modification of the catalog.
+func (c *Catalog) writable(tx *database.Transaction) (*catalogCache, error) {
+	if p := c.pending.Load().(*pendingCache); p != nil && p.tx == tx {
+		atomic.AddUint64(&modifications, 1)
+		return p.cache, nil
+	}
+
+	// the copy is visible to all transactions
+	err := tx.LockCatalog()
+	if err != nil {
+		return nil, err
+	}
+
+	p := pendingCache{
+		tx:    tx,
+		cache: c.committed.Load().(*catalogCache).Clone(),
+	}
+	atomic.AddUint64(&modifications, 1)
+	c.pending.Store(&p)
+
+	tx.OnCommitHooks = append(tx.OnCommitHooks, func() {
+		c.committed.Store(p.cache)
+		c.release(&p)
+	})
+	tx.OnRollbackHooks = append(tx.OnRollbackHooks, func() {
+		c.release(&p)
+	})
+
+	return p.cache, nil
+}
+
+// Modifications returns the number of modifications made to the catalog,
+// whether their transactions committed or not, and reports whether a transaction
+// modifying it is still running.
+// Transactions modifying other catalogs may be counted as well.
+// If it returns the same number twice and no running transaction, the catalog
+// didn't change in between.
+func (c *Catalog) Modifications() (n uint64, pending bool) {
+	n = atomic.LoadUint64(&modifications)
+	return n, c.pending.Load().(*pendingCache) != nil
+}
+
+// release forgets the copy of the catalog once its transaction has ended.
+func (c *Catalog) release(p *pendingCache) {
+	if c.pending.Load().(*pendingCache) == p {
+		c.pending.Store((*pendingCache)(nil))
 	}
 }
 
@@ -70,19 +202,36 @@ func (c *Catalog) Load(tx *database.Transaction) error {
 }
 
 func (c *Catalog) loadCatalog(tx *database.Transaction) error {
-	tables, indexes, sequences, err := c.CatalogTable.Load(tx)
+	tables, indexes, sequences, views, triggers, err := c.CatalogTable.Load(tx)
 	if err != nil {
 		return err
 	}
 
 	for _, tb := range tables {
-		// bind default values with catalog
+		// bind default values and generated fields with catalog
 		for _, fc := range tb.FieldConstraints {
-			if fc.DefaultValue == nil {
-				continue
+			if fc.DefaultValue != nil {
+				fc.DefaultValue.Bind(c)
+			}
+			if fc.Generated != nil {
+				fc.Generated.Bind(c)
+			}
+		}
+	}
+
+	// types of indexes are not stored and must be inferred
+	// from the tables, like when the indexes were created
+	for i := range indexes {
+		for j := range tables {
+			if tables[j].TableName == indexes[i].TableName {
+				indexes[i].Types = indexTypes(&tables[j], &indexes[i])
+				break
 			}
+		}
 
-			fc.DefaultValue.Bind(c)
+		// bind the predicates of partial indexes with catalog
+		if indexes[i].Predicate != nil {
+			indexes[i].Predicate.Bind(c)
 		}
 	}
 
@@ -93,8 +242,13 @@ func (c *Catalog) loadCatalog(tx *database.Transaction) error {
 	ti.ReadOnly = true
 	tables = append(tables, *ti)
 
-	// load tables and indexes first
-	c.Cache.load(tables, indexes, nil)
+	cache, err := c.writable(tx)
+	if err != nil {
+		return err
+	}
+
+	// load tables, indexes, views and triggers first
+	cache.load(tables, indexes, nil, views, triggers)
 
 	if len(sequences) > 0 {
 		var seqList []database.Sequence
@@ -103,7 +257,7 @@ func (c *Catalog) loadCatalog(tx *database.Transaction) error {
 			return err
 		}
 
-		c.Cache.load(nil, nil, seqList)
+		cache.load(nil, nil, seqList, nil, nil)
 	}
 
 	return nil
@@ -141,7 +295,7 @@ func (c *Catalog) loadSequences(tx *database.Transaction, info []database.Sequen
 }
 
 func (c *Catalog) generateStoreName(tx *database.Transaction) ([]byte, error) {
-	seq, err := c.GetSequence(StoreSequence)
+	seq, err := c.GetSequence(tx, StoreSequence)
 	if err != nil {
 		return nil, err
 	}
@@ -156,7 +310,7 @@ func (c *Catalog) generateStoreName(tx *database.Transaction) ([]byte, error) {
 }
 
 func (c *Catalog) GetTable(tx *database.Transaction, tableName string) (*database.Table, error) {
-	o, err := c.Cache.Get(RelationTableType, tableName)
+	o, err := c.snapshot(tx).Get(RelationTableType, tableName)
 	if err != nil {
 		return nil, err
 	}
@@ -168,17 +322,81 @@ func (c *Catalog) GetTable(tx *database.Transaction, tableName string) (*databas
 		return nil, err
 	}
 
-	return &database.Table{
+	tx.ReadTracker.AddTable(tableName)
+	// documents expire without the table being modified
+	if ti.TTLField != nil {
+		tx.ReadTracker.SetVolatile()
+	}
+
+	t := database.Table{
 		Tx:      tx,
 		Store:   s,
 		Info:    ti,
 		Catalog: c,
-	}, nil
+	}
+	t.Policy = database.WithTombstones(&t, database.WithTTL(ti, c.GetRowPolicy(tableName)))
+	return &t, nil
+}
+
+// SetRowPolicy registers the row policy of the given table, replacing any existing one.
+// If p is nil, the policy of the table is removed.
+func (c *Catalog) SetRowPolicy(tableName string, p *database.RowPolicy) {
+	c.policiesMu.Lock()
+	defer c.policiesMu.Unlock()
+
+	if p == nil {
+		delete(c.policies, tableName)
+		return
+	}
+
+	if c.policies == nil {
+		c.policies = make(map[string]*database.RowPolicy)
+	}
+	c.policies[tableName] = p
+}
+
+// GetRowPolicy returns the row policy of the given table, or nil if it has none.
+func (c *Catalog) GetRowPolicy(tableName string) *database.RowPolicy {
+	c.policiesMu.RLock()
+	defer c.policiesMu.RUnlock()
+
+	return c.policies[tableName]
+}
+
+// RegisterVirtualTable registers a virtual table under the given name, replacing any existing one.
+// If t is nil, the virtual table is removed.
+// It returns errs.AlreadyExistsError if a table, an index or a sequence has the same name.
+func (c *Catalog) RegisterVirtualTable(tableName string, t database.VirtualTable) error {
+	c.virtualTablesMu.Lock()
+	defer c.virtualTablesMu.Unlock()
+
+	if t == nil {
+		delete(c.virtualTables, tableName)
+		return nil
+	}
+
+	if c.snapshot(nil).objectExists(tableName) {
+		return errs.AlreadyExistsError{Name: tableName}
+	}
+
+	if c.virtualTables == nil {
+		c.virtualTables = make(map[string]database.VirtualTable)
+	}
+	c.virtualTables[tableName] = t
+	return nil
+}
+
+// GetVirtualTable returns the virtual table registered under the given name, or nil if there is none.
+func (c *Catalog) GetVirtualTable(tableName string) database.VirtualTable {
+	c.virtualTablesMu.RLock()
+	defer c.virtualTablesMu.RUnlock()
+
+	return c.virtualTables[tableName]
 }
 
 // GetTableInfo returns the table info for the given table name.
-func (c *Catalog) GetTableInfo(tableName string) (*database.TableInfo, error) {
-	r, err := c.Cache.Get(RelationTableType, tableName)
+func (c *Catalog) GetTableInfo(tx *database.Transaction, tableName string) (*database.TableInfo, error) {
+	r, err := c.snapshot(tx).Get(RelationTableType, tableName)
 	if err != nil {
 		return nil, err
 	}
@@ -202,7 +420,7 @@ func (c *Catalog) CreateTable(tx *database.Transaction, tableName string, info *
 	if err != nil && !errs.IsNotFoundError(err) {
 		return err
 	}
-	if err == nil {
+	if err == nil || c.GetVirtualTable(tableName) != nil {
 		return errs.AlreadyExistsError{Name: tableName}
 	}
 
@@ -212,6 +430,11 @@ func (c *Catalog) CreateTable(tx *database.Transaction, tableName string, info *
 		return err
 	}
 
+	err = info.ValidateTTLField()
+	if err != nil {
+		return err
+	}
+
 	if info.StoreName == nil {
 		info.StoreName, err = c.generateStoreName(tx)
 		if err != nil {
@@ -219,13 +442,25 @@ func (c *Catalog) CreateTable(tx *database.Transaction, tableName string, info *
 		}
 	}
 
-	// bind default values with catalog
+	// bind default values and generated fields with catalog
 	for _, fc := range info.FieldConstraints {
-		if fc.DefaultValue == nil {
-			continue
+		if fc.DefaultValue != nil {
+			fc.DefaultValue.Bind(c)
+		}
+		if fc.Generated != nil {
+			fc.Generated.Bind(c)
 		}
+	}
 
-		fc.DefaultValue.Bind(c)
+	// name unnamed unique constraints after the index enforcing them
+	cache, err := c.writable(tx)
+	if err != nil {
+		return err
+	}
+	for _, uc := range info.UniqueConstraints {
+		if uc.Name == "" {
+			uc.Name = cache.generateUnusedName(uc.IndexInfo(tableName).GenerateBaseName())
+		}
 	}
 
 	err = c.CatalogTable.Insert(tx, info)
@@ -238,12 +473,24 @@ func (c *Catalog) CreateTable(tx *database.Transaction, tableName string, info *
 		return stringutil.Errorf("failed to create table %q: %w", tableName, err)
 	}
 
-	return c.Cache.Add(tx, info)
+	if info.SoftDelete {
+		err = tx.Tx.CreateStore(info.TombstoneStoreName())
+		if err != nil {
+			return stringutil.Errorf("failed to create table %q: %w", tableName, err)
+		}
+	}
+
+	return cache.Add(info)
 }
 
 // DropTable deletes a table from the catalog
 func (c *Catalog) DropTable(tx *database.Transaction, tableName string) error {
-	o, err := c.Cache.Get(RelationTableType, tableName)
+	cache, err := c.writable(tx)
+	if err != nil {
+		return err
+	}
+
+	o, err := cache.Get(RelationTableType, tableName)
 	if err != nil {
 		return err
 	}
@@ -253,8 +500,17 @@ func (c *Catalog) DropTable(tx *database.Transaction, tableName string) error {
 		return errors.New("cannot write to read-only table")
 	}
 
-	for _, idx := range c.Cache.GetTableIndexes(tableName) {
-		_, err = c.Cache.Delete(tx, RelationIndexType, idx.IndexName)
+	if views := cache.GetDependentViews(tableName); len(views) > 0 {
+		return stringutil.Errorf("cannot drop table %q: view %q depends on it", tableName, views[0])
+	}
+
+	err = database.DeleteTableStatistics(tx, c, tableName)
+	if err != nil {
+		return err
+	}
+
+	for _, idx := range cache.GetTableIndexes(tableName) {
+		_, err = cache.Delete(RelationIndexType, idx.IndexName)
 		if err != nil {
 			return err
 		}
@@ -265,7 +521,14 @@ func (c *Catalog) DropTable(tx *database.Transaction, tableName string) error {
 		}
 	}
 
-	_, err = c.Cache.Delete(tx, RelationTableType, tableName)
+	for _, t := range cache.GetTableTriggers(tableName) {
+		err = c.dropTrigger(tx, cache, t.TriggerName)
+		if err != nil {
+			return err
+		}
+	}
+
+	_, err = cache.Delete(RelationTableType, tableName)
 	if err != nil {
 		return err
 	}
@@ -275,30 +538,34 @@ func (c *Catalog) DropTable(tx *database.Transaction, tableName string) error {
 		return err
 	}
 
+	if ti.SoftDelete {
+		err = tx.Tx.DropStore(ti.TombstoneStoreName())
+		if err != nil {
+			return err
+		}
+	}
+
 	return tx.Tx.DropStore(ti.StoreName)
 }
 
-// CreateIndex creates an index with the given name.
-// If it already exists, returns errs.ErrIndexAlreadyExists.
-func (c *Catalog) CreateIndex(tx *database.Transaction, info *database.IndexInfo) error {
-	// get the associated table
-	o, err := c.Cache.Get(RelationTableType, info.TableName)
-	if err != nil {
-		return err
-	}
-	ti := o.(*database.TableInfo)
-
-	// if the index is created on a field on which we know the type then create a typed index.
-	// if the given info contained existing types, they are overriden.
-	info.Types = nil
+// indexTypes returns the types of the values of an index on the given paths of a table.
+// If the index is created on a field on which we know the type then create a typed index.
+func indexTypes(ti *database.TableInfo, info *database.IndexInfo) []document.ValueType {
+	var types []document.ValueType
 
 OUTER:
-	for _, path := range info.Paths {
+	for i, path := range info.Paths {
+		// multi-key paths index the elements of arrays, whatever their type
+		if i < len(info.MultiKey) && info.MultiKey[i] {
+			types = append(types, document.ValueType(0))
+			continue
+		}
+
 		for _, fc := range ti.FieldConstraints {
 			if fc.Path.IsEqual(path) {
 				// a constraint may or may not enforce a type
 				if fc.Type != 0 {
-					info.Types = append(info.Types, document.ValueType(fc.Type))
+					types = append(types, document.ValueType(fc.Type))
 				}
 
 				continue OUTER
@@ -306,7 +573,42 @@ OUTER:
 		}
 
 		// no type was inferred for that path, add it to the index as untyped
-		info.Types = append(info.Types, document.ValueType(0))
+		types = append(types, document.ValueType(0))
+	}
+
+	return types
+}
+
+// CreateIndex creates an index with the given name.
+// If it already exists, returns errs.ErrIndexAlreadyExists.
+func (c *Catalog) CreateIndex(tx *database.Transaction, info *database.IndexInfo) error {
+	cache, err := c.writable(tx)
+	if err != nil {
+		return err
+	}
+
+	// get the associated table
+	o, err := cache.Get(RelationTableType, info.TableName)
+	if err != nil {
+		return err
+	}
+	ti := o.(*database.TableInfo)
+
+	var multiKey int
+	for _, ok := range info.MultiKey {
+		if ok {
+			multiKey++
+		}
+	}
+	if multiKey > 1 {
+		return stringutil.Errorf("index %q can only have one multi-key path", info.IndexName)
+	}
+
+	// if the given info contained existing types, they are overriden.
+	info.Types = indexTypes(ti, info)
+
+	if info.Predicate != nil {
+		info.Predicate.Bind(c)
 	}
 
 	if info.StoreName == nil {
@@ -316,7 +618,7 @@ OUTER:
 		}
 	}
 
-	err = c.Cache.Add(tx, info)
+	err = cache.Add(info)
 	if err != nil {
 		return err
 	}
@@ -331,6 +633,11 @@ OUTER:
 		return err
 	}
 
+	// indexes created concurrently are built by IndexDocuments
+	if info.Building {
+		return nil
+	}
+
 	tb, err := c.GetTable(tx, info.TableName)
 	if err != nil {
 		return err
@@ -342,7 +649,7 @@ OUTER:
 
 // GetIndex returns an index by name.
 func (c *Catalog) GetIndex(tx *database.Transaction, indexName string) (*database.Index, error) {
-	r, err := c.Cache.Get(RelationIndexType, indexName)
+	r, err := c.snapshot(tx).Get(RelationIndexType, indexName)
 	if err != nil {
 		return nil, err
 	}
@@ -352,8 +659,8 @@ func (c *Catalog) GetIndex(tx *database.Transaction, indexName string) (*databas
 }
 
 // GetIndexInfo returns an index info by name.
-func (c *Catalog) GetIndexInfo(indexName string) (*database.IndexInfo, error) {
-	r, err := c.Cache.Get(RelationIndexType, indexName)
+func (c *Catalog) GetIndexInfo(tx *database.Transaction, indexName string) (*database.IndexInfo, error) {
+	r, err := c.snapshot(tx).Get(RelationIndexType, indexName)
 	if err != nil {
 		return nil, err
 	}
@@ -363,13 +670,15 @@ func (c *Catalog) GetIndexInfo(indexName string) (*database.IndexInfo, error) {
 // ListIndexes returns all indexes for a given table name. If tableName is empty
 // if returns a list of all indexes.
 // The returned list of indexes is sorted lexicographically.
-func (c *Catalog) ListIndexes(tableName string) []string {
+func (c *Catalog) ListIndexes(tx *database.Transaction, tableName string) []string {
+	cache := c.snapshot(tx)
+
 	if tableName == "" {
-		list := c.Cache.ListObjects(RelationIndexType)
+		list := cache.ListObjects(RelationIndexType)
 		sort.Strings(list)
 		return list
 	}
-	idxs := c.Cache.GetTableIndexes(tableName)
+	idxs := cache.GetTableIndexes(tableName)
 	list := make([]string, 0, len(idxs))
 	for _, idx := range idxs {
 		list = append(list, idx.IndexName)
@@ -381,8 +690,13 @@ func (c *Catalog) ListIndexes(tableName string) []string {
 
 // DropIndex deletes an index from the database.
 func (c *Catalog) DropIndex(tx *database.Transaction, name string) error {
+	cache, err := c.writable(tx)
+	if err != nil {
+		return err
+	}
+
 	// check if the index exists
-	r, err := c.Cache.Get(RelationIndexType, name)
+	r, err := cache.Get(RelationIndexType, name)
 	if err != nil {
 		return err
 	}
@@ -393,8 +707,11 @@ func (c *Catalog) DropIndex(tx *database.Transaction, name string) error {
 	if info.Owner.Path != nil {
 		return stringutil.Errorf("cannot drop index %s because constraint on %s(%s) requires it", info.IndexName, info.TableName, info.Owner.Path)
 	}
+	if info.Owner.Constraint != "" {
+		return stringutil.Errorf("cannot drop index %s because constraint %s on %s requires it", info.IndexName, info.Owner.Constraint, info.TableName)
+	}
 
-	_, err = c.Cache.Delete(tx, RelationIndexType, name)
+	_, err = cache.Delete(RelationIndexType, name)
 	if err != nil {
 		return err
 	}
@@ -413,7 +730,12 @@ func (c *Catalog) dropIndex(tx *database.Transaction, name string) error {
 
 // AddFieldConstraint adds a field constraint to a table.
 func (c *Catalog) AddFieldConstraint(tx *database.Transaction, tableName string, fc database.FieldConstraint) error {
-	r, err := c.Cache.Get(RelationTableType, tableName)
+	cache, err := c.writable(tx)
+	if err != nil {
+		return err
+	}
+
+	r, err := cache.Get(RelationTableType, tableName)
 	if err != nil {
 		return err
 	}
@@ -425,7 +747,7 @@ func (c *Catalog) AddFieldConstraint(tx *database.Transaction, tableName string,
 		return err
 	}
 
-	err = c.Cache.Replace(tx, clone)
+	err = cache.Replace(clone)
 	if err != nil {
 		return err
 	}
@@ -433,54 +755,99 @@ func (c *Catalog) AddFieldConstraint(tx *database.Transaction, tableName string,
 	return c.CatalogTable.Replace(tx, tableName, clone)
 }
 
-// RenameTable renames a table.
-// If it doesn't exist, it returns errs.ErrTableNotFound.
-func (c *Catalog) RenameTable(tx *database.Transaction, oldName, newName string) error {
-	// Delete the old table info.
-	err := c.CatalogTable.Delete(tx, oldName)
-	if err == errs.ErrDocumentNotFound {
-		return errs.NotFoundError{Name: oldName}
-	}
+// AlterFieldConstraint replaces the constraint of the field at fc.Path, or adds it
+// if the field has no constraint. The existing documents are validated against the new
+// definition of the table and, if the type of the field changed, converted and rewritten.
+// The indexes whose types changed are rebuilt.
+func (c *Catalog) AlterFieldConstraint(tx *database.Transaction, tableName string, fc database.FieldConstraint) error {
+	cache, err := c.writable(tx)
 	if err != nil {
 		return err
 	}
 
-	o, err := c.Cache.Delete(tx, RelationTableType, oldName)
+	r, err := cache.Get(RelationTableType, tableName)
 	if err != nil {
 		return err
 	}
+	ti := r.(*database.TableInfo)
+
+	if ti.ReadOnly {
+		return errors.New("cannot write to read-only table")
+	}
 
-	ti := o.(*database.TableInfo)
+	// rebuild the list of constraints from the user-defined ones,
+	// copying them to avoid modifying the current definition of the table
+	// when inferring the others. The constraint is removed if it no longer
+	// enforces anything, and documents must be converted if the type changed.
+	fc.IsInferred = false
+	fc.InferredBy = nil
+	convert := !fc.Type.IsAny()
+	found := false
+	fcs := make(database.FieldConstraints, 0, len(ti.FieldConstraints))
+	for _, old := range ti.FieldConstraints {
+		if old.Path.IsEqual(fc.Path) {
+			convert = old.Type != fc.Type || old.MaxLength != fc.MaxLength || old.Dimension != fc.Dimension ||
+				old.Precision != fc.Precision || old.Scale != fc.Scale
+			found = true
+			if !fc.IsEmpty() {
+				fcs = append(fcs, &fc)
+			}
+			continue
+		}
+		if old.IsInferred {
+			continue
+		}
+
+		cp := *old
+		fcs = append(fcs, &cp)
+	}
+	if !found && !fc.IsEmpty() {
+		fcs = append(fcs, &fc)
+	}
 
 	clone := ti.Clone()
-	clone.TableName = newName
+	clone.FieldConstraints, err = fcs.Infer()
+	if err != nil {
+		return err
+	}
 
-	err = c.CatalogTable.Insert(tx, clone)
+	err = clone.ValidateTTLField()
 	if err != nil {
 		return err
 	}
 
-	err = c.Cache.Add(tx, clone)
+	err = c.rewriteTable(tx, tableName, convert, func(d document.Document) (*document.FieldBuffer, error) {
+		return clone.ValidateDocument(tx, d)
+	})
 	if err != nil {
 		return err
 	}
 
-	for _, idx := range c.Cache.GetTableIndexes(oldName) {
-		r, err := c.Cache.Delete(tx, RelationIndexType, idx.IndexName)
-		if err != nil {
-			return err
+	err = cache.Replace(clone)
+	if err != nil {
+		return err
+	}
+
+	err = c.CatalogTable.Replace(tx, tableName, clone)
+	if err != nil {
+		return err
+	}
+
+	// types of indexes depend on the types of the fields
+	for _, info := range cache.GetTableIndexes(tableName) {
+		types := indexTypes(clone, info)
+		if typesEqual(info.Types, types) {
+			continue
 		}
-		info := r.(*database.IndexInfo)
 
 		idxClone := info.Clone()
-		idxClone.TableName = clone.TableName
-
-		err = c.Cache.Add(tx, idxClone)
+		idxClone.Types = types
+		err = cache.Replace(idxClone)
 		if err != nil {
 			return err
 		}
 
-		err = c.CatalogTable.Replace(tx, idx.IndexName, idx)
+		err = c.ReIndex(tx, info.IndexName)
 		if err != nil {
 			return err
 		}
@@ -489,94 +856,616 @@ func (c *Catalog) RenameTable(tx *database.Transaction, oldName, newName string)
 	return nil
 }
 
-// ReIndex truncates and recreates selected index from scratch.
-func (c *Catalog) ReIndex(tx *database.Transaction, indexName string) error {
-	idx, err := c.GetIndex(tx, indexName)
+// rewriteTable calls fn on every document of the table, stopping at the first error, then
+// replaces the documents by those returned by fn if rewrite is true.
+// No document is replaced if fn fails for any of them. Indexes are not updated.
+func (c *Catalog) rewriteTable(tx *database.Transaction, tableName string, rewrite bool, fn func(d document.Document) (*document.FieldBuffer, error)) error {
+	tb, err := c.GetTable(tx, tableName)
 	if err != nil {
 		return err
 	}
 
-	tb, err := c.GetTable(tx, idx.Info.TableName)
-	if err != nil {
+	// check every document before rewriting any of them
+	err = tb.Iterate(tx.Context(), func(d document.Document) error {
+		_, err := fn(d)
 		return err
-	}
-
-	err = idx.Truncate()
-	if err != nil {
+	})
+	if err != nil || !rewrite {
 		return err
 	}
 
-	return c.buildIndex(tx, idx, tb)
-}
-
-func (c *Catalog) buildIndex(tx *database.Transaction, idx *database.Index, table *database.Table) error {
-	return table.Iterate(func(d document.Document) error {
-		var err error
-		values := make([]document.Value, len(idx.Info.Paths))
-		for i, path := range idx.Info.Paths {
-			values[i], err = path.GetValueFromDocument(d)
-			if err == document.ErrFieldNotFound {
-				return nil
-			}
-			if err != nil {
-				return err
-			}
+	return tb.Iterate(tx.Context(), func(d document.Document) error {
+		fb, err := fn(d)
+		if err != nil {
+			return err
 		}
 
-		err = idx.Set(values, d.(document.Keyer).RawKey())
+		// stores may keep a reference to the encoded document
+		var buf bytes.Buffer
+		enc := tx.Codec.NewEncoder(&buf)
+		err = enc.EncodeDocument(fb)
+		enc.Close()
 		if err != nil {
-			return stringutil.Errorf("error while building the index: %w", err)
+			return stringutil.Errorf("failed to encode document: %w", err)
 		}
 
-		return nil
+		return tb.Store.Put(d.(document.Keyer).RawKey(), buf.Bytes())
 	})
 }
 
-// ReIndexAll truncates and recreates all indexes of the database from scratch.
-func (c *Catalog) ReIndexAll(tx *database.Transaction) error {
-	indexes := c.Cache.ListObjects(RelationIndexType)
+func typesEqual(a, b []document.ValueType) bool {
+	if len(a) != len(b) {
+		return false
+	}
 
-	for _, indexName := range indexes {
-		err := c.ReIndex(tx, indexName)
-		if err != nil {
-			return err
+	for i := range a {
+		if a[i] != b[i] {
+			return false
 		}
 	}
 
-	return nil
+	return true
 }
 
-func (c *Catalog) GetSequence(name string) (*database.Sequence, error) {
-	r, err := c.Cache.Get(RelationSequenceType, name)
+// RenameField renames the field at oldPath to newPath, which must only differ by their last fragment.
+// The constraints, indexes and sequences referring to the field or to the fields it contains are updated,
+// and the existing documents are rewritten.
+func (c *Catalog) RenameField(tx *database.Transaction, tableName string, oldPath, newPath document.Path) error {
+	cache, err := c.writable(tx)
 	if err != nil {
-		return nil, err
+		return err
 	}
 
-	return r.(*database.Sequence), nil
-}
-
-// CreateSequence creates a sequence with the given name.
-func (c *Catalog) CreateSequence(tx *database.Transaction, info *database.SequenceInfo) error {
-	if info == nil {
-		info = new(database.SequenceInfo)
+	r, err := cache.Get(RelationTableType, tableName)
+	if err != nil {
+		return err
 	}
+	ti := r.(*database.TableInfo)
 
-	if info.Name == "" && info.Owner.TableName == "" {
-		return errors.New("sequence name not provided")
+	if ti.ReadOnly {
+		return errors.New("cannot write to read-only table")
 	}
 
-	seq := database.Sequence{
-		Info: info,
+	fcs := make(database.FieldConstraints, 0, len(ti.FieldConstraints))
+	for _, fc := range ti.FieldConstraints {
+		if hasPathPrefix(fc.Path, newPath) {
+			return errs.AlreadyExistsError{Name: newPath.String()}
+		}
+		if fc.IsInferred {
+			continue
+		}
+
+		cp := *fc
+		cp.Path, _ = renamePath(fc.Path, oldPath, newPath)
+		fcs = append(fcs, &cp)
 	}
 
-	err := c.Cache.Add(tx, &seq)
+	clone := ti.Clone()
+	clone.FieldConstraints, err = fcs.Infer()
 	if err != nil {
 		return err
 	}
 
-	err = c.CatalogTable.Insert(tx, &seq)
-	if err != nil {
-		return err
+	clone.UniqueConstraints = nil
+	for _, uc := range ti.UniqueConstraints {
+		cp := *uc
+		cp.Paths = renamePaths(uc.Paths, oldPath, newPath)
+		clone.UniqueConstraints = append(clone.UniqueConstraints, &cp)
+	}
+
+	if ti.TTLField != nil {
+		clone.TTLField, _ = renamePath(ti.TTLField, oldPath, newPath)
+	}
+
+	// the predicates of partial indexes are not rewritten
+	for _, info := range cache.GetTableIndexes(tableName) {
+		if info.Predicate != nil && readsPath(info.Predicate, oldPath) {
+			return stringutil.Errorf("cannot rename field %q: it is used by the predicate of index %q", oldPath, info.IndexName)
+		}
+	}
+
+	err = c.rewriteTable(tx, tableName, true, func(d document.Document) (*document.FieldBuffer, error) {
+		fb := document.NewFieldBuffer()
+		err := fb.Copy(d)
+		if err != nil {
+			return nil, err
+		}
+
+		v, err := oldPath.GetValueFromDocument(fb)
+		if err == document.ErrFieldNotFound {
+			return fb, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		if _, err := newPath.GetValueFromDocument(fb); err != document.ErrFieldNotFound {
+			if err == nil {
+				err = stringutil.Errorf("cannot rename field %q: a document already has a field %q", oldPath, newPath)
+			}
+			return nil, err
+		}
+
+		err = fb.Delete(oldPath)
+		if err != nil {
+			return nil, err
+		}
+
+		return fb, fb.Set(newPath, v)
+	})
+	if err != nil {
+		return err
+	}
+
+	err = cache.Replace(clone)
+	if err != nil {
+		return err
+	}
+
+	err = c.CatalogTable.Replace(tx, tableName, clone)
+	if err != nil {
+		return err
+	}
+
+	// indexed values don't change, only the paths of the indexes do
+	for _, info := range cache.GetTableIndexes(tableName) {
+		idxClone := info.Clone()
+		idxClone.Paths = renamePaths(info.Paths, oldPath, newPath)
+		if idxClone.Owner.Path != nil {
+			idxClone.Owner.Path, _ = renamePath(info.Owner.Path, oldPath, newPath)
+		}
+
+		err = cache.Replace(idxClone)
+		if err != nil {
+			return err
+		}
+
+		err = c.CatalogTable.Replace(tx, idxClone.IndexName, idxClone)
+		if err != nil {
+			return err
+		}
+	}
+
+	// sequences of SERIAL fields are owned by the field
+	for _, name := range cache.ListObjects(RelationSequenceType) {
+		r, err := cache.Get(RelationSequenceType, name)
+		if err != nil {
+			return err
+		}
+		seq := r.(*database.Sequence)
+		if seq.Info.Owner.TableName != tableName || seq.Info.Owner.Path == nil {
+			continue
+		}
+		p, ok := renamePath(seq.Info.Owner.Path, oldPath, newPath)
+		if !ok {
+			continue
+		}
+
+		seqClone := *seq
+		seqClone.Info = seq.Info.Clone()
+		seqClone.Info.Owner.Path = p
+
+		err = cache.Replace(&seqClone)
+		if err != nil {
+			return err
+		}
+
+		err = c.CatalogTable.Replace(tx, name, &seqClone)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// hasPathPrefix returns true if p is prefix or refers to a field it contains.
+func hasPathPrefix(p, prefix document.Path) bool {
+	return len(p) >= len(prefix) && p[:len(prefix)].IsEqual(prefix)
+}
+
+// readsPath returns true if the expression reads p, one of the fields it contains
+// or one of its parents.
+func readsPath(te database.TableExpression, p document.Path) bool {
+	ce, ok := te.(*expr.ConstraintExpr)
+	if !ok {
+		return true
+	}
+
+	var found bool
+	var walk func(e expr.Expr) bool
+	walk = func(e expr.Expr) bool {
+		switch t := e.(type) {
+		case expr.Path:
+			found = hasPathPrefix(document.Path(t), p) || hasPathPrefix(p, document.Path(t))
+		case *expr.BetweenOperator:
+			expr.Walk(t.X, walk)
+		}
+		return !found
+	}
+	expr.Walk(ce.Expr, walk)
+
+	return found
+}
+
+// renamePath replaces the prefix of p by newPrefix if p starts with prefix,
+// and reports whether it did.
+func renamePath(p, prefix, newPrefix document.Path) (document.Path, bool) {
+	if !hasPathPrefix(p, prefix) {
+		return p, false
+	}
+
+	renamed := make(document.Path, 0, len(newPrefix)+len(p)-len(prefix))
+	renamed = append(renamed, newPrefix...)
+	renamed = append(renamed, p[len(prefix):]...)
+	return renamed, true
+}
+
+// renamePaths calls renamePath on every path of the list.
+func renamePaths(paths []document.Path, prefix, newPrefix document.Path) []document.Path {
+	renamed := make([]document.Path, len(paths))
+	for i, p := range paths {
+		renamed[i], _ = renamePath(p, prefix, newPrefix)
+	}
+
+	return renamed
+}
+
+// DropTableConstraint removes a table constraint and the index enforcing it.
+func (c *Catalog) DropTableConstraint(tx *database.Transaction, tableName, name string) error {
+	cache, err := c.writable(tx)
+	if err != nil {
+		return err
+	}
+
+	r, err := cache.Get(RelationTableType, tableName)
+	if err != nil {
+		return err
+	}
+	ti := r.(*database.TableInfo)
+
+	clone := ti.Clone()
+	found := -1
+	for i, uc := range clone.UniqueConstraints {
+		if uc.Name == name {
+			found = i
+			break
+		}
+	}
+	if found == -1 {
+		return errs.NotFoundError{Name: name}
+	}
+	clone.UniqueConstraints = append(clone.UniqueConstraints[:found:found], clone.UniqueConstraints[found+1:]...)
+
+	err = cache.Replace(clone)
+	if err != nil {
+		return err
+	}
+
+	err = c.CatalogTable.Replace(tx, tableName, clone)
+	if err != nil {
+		return err
+	}
+
+	_, err = cache.Delete(RelationIndexType, name)
+	if err != nil {
+		return err
+	}
+
+	return c.dropIndex(tx, name)
+}
+
+// RenameTable renames a table.
+// If it doesn't exist, it returns errs.ErrTableNotFound.
+func (c *Catalog) RenameTable(tx *database.Transaction, oldName, newName string) error {
+	if c.GetVirtualTable(newName) != nil {
+		return errs.AlreadyExistsError{Name: newName}
+	}
+
+	cache, err := c.writable(tx)
+	if err != nil {
+		return err
+	}
+
+	// the queries of views are not rewritten
+	if views := cache.GetDependentViews(oldName); len(views) > 0 {
+		return stringutil.Errorf("cannot rename table %q: view %q depends on it", oldName, views[0])
+	}
+
+	// Delete the old table info.
+	err = c.CatalogTable.Delete(tx, oldName)
+	if err == errs.ErrDocumentNotFound {
+		return errs.NotFoundError{Name: oldName}
+	}
+	if err != nil {
+		return err
+	}
+
+	o, err := cache.Delete(RelationTableType, oldName)
+	if err != nil {
+		return err
+	}
+
+	ti := o.(*database.TableInfo)
+
+	clone := ti.Clone()
+	clone.TableName = newName
+
+	err = c.CatalogTable.Insert(tx, clone)
+	if err != nil {
+		return err
+	}
+
+	err = cache.Add(clone)
+	if err != nil {
+		return err
+	}
+
+	for _, idx := range cache.GetTableIndexes(oldName) {
+		r, err := cache.Delete(RelationIndexType, idx.IndexName)
+		if err != nil {
+			return err
+		}
+		info := r.(*database.IndexInfo)
+
+		idxClone := info.Clone()
+		idxClone.TableName = clone.TableName
+		if idxClone.Owner.TableName == oldName {
+			idxClone.Owner.TableName = newName
+		}
+
+		err = cache.Add(idxClone)
+		if err != nil {
+			return err
+		}
+
+		err = c.CatalogTable.Replace(tx, idx.IndexName, idxClone)
+		if err != nil {
+			return err
+		}
+	}
+
+	// sequences of SERIAL fields are owned by the table
+	for _, name := range cache.ListObjects(RelationSequenceType) {
+		r, err := cache.Get(RelationSequenceType, name)
+		if err != nil {
+			return err
+		}
+		seq := r.(*database.Sequence)
+		if seq.Info.Owner.TableName != oldName {
+			continue
+		}
+
+		seqClone := *seq
+		seqClone.Info = seq.Info.Clone()
+		seqClone.Info.Owner.TableName = newName
+
+		err = cache.Replace(&seqClone)
+		if err != nil {
+			return err
+		}
+
+		err = c.CatalogTable.Replace(tx, name, &seqClone)
+		if err != nil {
+			return err
+		}
+	}
+
+	// the statements of triggers are not rewritten
+	for _, t := range cache.GetTableTriggers(oldName) {
+		tClone := t.Clone()
+		tClone.TableName = newName
+
+		err = cache.Replace(tClone)
+		if err != nil {
+			return err
+		}
+
+		err = c.CatalogTable.Replace(tx, tClone.TriggerName, tClone)
+		if err != nil {
+			return err
+		}
+	}
+
+	return database.RenameTableStatistics(tx, c, oldName, newName)
+}
+
+// ReIndex truncates and recreates selected index from scratch.
+func (c *Catalog) ReIndex(tx *database.Transaction, indexName string) error {
+	idx, err := c.GetIndex(tx, indexName)
+	if err != nil {
+		return err
+	}
+
+	tb, err := c.GetTable(tx, idx.Info.TableName)
+	if err != nil {
+		return err
+	}
+
+	err = idx.Truncate()
+	if err != nil {
+		return err
+	}
+
+	err = c.buildIndex(tx, idx, tb)
+	if err != nil {
+		return err
+	}
+
+	// reindexing completes an interrupted concurrent build
+	if idx.Info.Building {
+		return c.FinishIndexBuild(tx, indexName)
+	}
+
+	return nil
+}
+
+func (c *Catalog) buildIndex(tx *database.Transaction, idx *database.Index, table *database.Table) error {
+	return table.Iterate(tx.Context(), func(d document.Document) error {
+		return indexDocument(tx, idx, d)
+	})
+}
+
+// indexDocument adds the entries of a document to an index being built.
+// Entries already associated with the key of the document are left as is.
+func indexDocument(tx *database.Transaction, idx *database.Index, d document.Document) error {
+	ok, err := idx.Info.Covers(tx, d)
+	if err != nil || !ok {
+		return err
+	}
+
+	values := make([]document.Value, len(idx.Info.Paths))
+	for i, path := range idx.Info.Paths {
+		values[i], err = path.GetValueFromDocument(d)
+		if err == document.ErrFieldNotFound {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	entries, err := idx.Entries(values)
+	if err != nil {
+		return err
+	}
+
+	key := d.(document.Keyer).RawKey()
+	for _, vs := range entries {
+		// unique indexes reject values that are already indexed, even for the same key
+		if idx.Info.Unique {
+			ok, k, err := idx.Exists(vs)
+			if err != nil {
+				return err
+			}
+			if ok && bytes.Equal(k, key) {
+				continue
+			}
+		}
+
+		err = idx.Set(vs, key)
+		if err == database.ErrIndexDuplicateValue {
+			err = database.UniqueViolationError(idx.Info, vs)
+		}
+		if err != nil {
+			return stringutil.Errorf("error while building the index: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// IndexDocuments adds the current version of the documents with the given keys
+// to an index created with CREATE INDEX CONCURRENTLY.
+// Documents deleted since their keys were read are skipped, and entries
+// added by the writes made to the table during the build are left as is.
+func (c *Catalog) IndexDocuments(tx *database.Transaction, indexName string, keys [][]byte) error {
+	idx, err := c.GetIndex(tx, indexName)
+	if err != nil {
+		return err
+	}
+
+	tb, err := c.GetTable(tx, idx.Info.TableName)
+	if err != nil {
+		return err
+	}
+
+	for _, key := range keys {
+		d, err := tb.GetDocument(key)
+		if err == errs.ErrDocumentNotFound {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+
+		err = indexDocument(tx, idx, d)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// FinishIndexBuild marks an index created with CREATE INDEX CONCURRENTLY
+// as built, allowing queries to use it.
+func (c *Catalog) FinishIndexBuild(tx *database.Transaction, indexName string) error {
+	cache, err := c.writable(tx)
+	if err != nil {
+		return err
+	}
+
+	r, err := cache.Get(RelationIndexType, indexName)
+	if err != nil {
+		return err
+	}
+	info := r.(*database.IndexInfo)
+	if !info.Building {
+		return nil
+	}
+
+	clone := info.Clone()
+	clone.Building = false
+
+	err = cache.Replace(clone)
+	if err != nil {
+		return err
+	}
+
+	return c.CatalogTable.Replace(tx, indexName, clone)
+}
+
+// ReIndexAll truncates and recreates all indexes of the database from scratch.
+func (c *Catalog) ReIndexAll(tx *database.Transaction) error {
+	indexes := c.snapshot(tx).ListObjects(RelationIndexType)
+
+	for _, indexName := range indexes {
+		err := c.ReIndex(tx, indexName)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (c *Catalog) GetSequence(tx *database.Transaction, name string) (*database.Sequence, error) {
+	r, err := c.snapshot(tx).Get(RelationSequenceType, name)
+	if err != nil {
+		return nil, err
+	}
+
+	return r.(*database.Sequence), nil
+}
+
+// CreateSequence creates a sequence with the given name.
+func (c *Catalog) CreateSequence(tx *database.Transaction, info *database.SequenceInfo) error {
+	if info == nil {
+		info = new(database.SequenceInfo)
+	}
+
+	if info.Name == "" && info.Owner.TableName == "" {
+		return errors.New("sequence name not provided")
+	}
+
+	seq := database.Sequence{
+		Info: info,
+	}
+
+	cache, err := c.writable(tx)
+	if err != nil {
+		return err
+	}
+
+	err = cache.Add(&seq)
+	if err != nil {
+		return err
+	}
+
+	err = c.CatalogTable.Insert(tx, &seq)
+	if err != nil {
+		return err
 	}
 
 	return seq.Init(tx, c)
@@ -584,7 +1473,12 @@ func (c *Catalog) CreateSequence(tx *database.Transaction, info *database.Sequen
 
 // DropSequence deletes a sequence from the catalog.
 func (c *Catalog) DropSequence(tx *database.Transaction, name string) error {
-	r, err := c.Cache.Delete(tx, RelationSequenceType, name)
+	cache, err := c.writable(tx)
+	if err != nil {
+		return err
+	}
+
+	r, err := cache.Delete(RelationSequenceType, name)
 	if err != nil {
 		return err
 	}
@@ -598,7 +1492,237 @@ func (c *Catalog) DropSequence(tx *database.Transaction, name string) error {
 	return c.CatalogTable.Delete(tx, name)
 }
 
+// GetViewInfo returns the view info for the given view name.
+func (c *Catalog) GetViewInfo(tx *database.Transaction, viewName string) (*database.ViewInfo, error) {
+	r, err := c.snapshot(tx).Get(RelationViewType, viewName)
+	if err != nil {
+		return nil, err
+	}
+
+	return r.(*database.ViewInfo), nil
+}
+
+// CreateView creates a view with the given name.
+// The tables and views read by its query must exist.
+// If a table, an index, a sequence or a view has the same name, it returns errs.AlreadyExistsError.
+func (c *Catalog) CreateView(tx *database.Transaction, info *database.ViewInfo) error {
+	if info.ViewName == "" {
+		return errors.New("view name required")
+	}
+
+	if c.GetVirtualTable(info.ViewName) != nil {
+		return errs.AlreadyExistsError{Name: info.ViewName}
+	}
+
+	cache, err := c.writable(tx)
+	if err != nil {
+		return err
+	}
+
+	err = c.checkViewRelations(cache, info)
+	if err != nil {
+		return err
+	}
+
+	err = cache.Add(info)
+	if err != nil {
+		return err
+	}
+
+	return c.CatalogTable.Insert(tx, info)
+}
+
+// ReplaceView replaces the query of an existing view.
+// The new query cannot read the view, directly or through other views.
+func (c *Catalog) ReplaceView(tx *database.Transaction, info *database.ViewInfo) error {
+	cache, err := c.writable(tx)
+	if err != nil {
+		return err
+	}
+
+	_, err = cache.Get(RelationViewType, info.ViewName)
+	if err != nil {
+		return err
+	}
+
+	err = c.checkViewRelations(cache, info)
+	if err != nil {
+		return err
+	}
+
+	err = cache.Replace(info)
+	if err != nil {
+		return err
+	}
+
+	return c.CatalogTable.Replace(tx, info.ViewName, info)
+}
+
+// checkViewRelations returns an error if a relation read by the query of the view
+// doesn't exist or if the query reads the view itself.
+func (c *Catalog) checkViewRelations(cache *catalogCache, info *database.ViewInfo) error {
+	for _, name := range info.Query.Relations() {
+		if name == info.ViewName || viewReads(cache, name, info.ViewName) {
+			return stringutil.Errorf("view %q cannot read itself", info.ViewName)
+		}
+
+		if _, err := cache.Get(RelationTableType, name); err == nil {
+			continue
+		}
+		if _, err := cache.Get(RelationViewType, name); err == nil {
+			continue
+		}
+		if c.GetVirtualTable(name) != nil {
+			continue
+		}
+
+		return errs.NotFoundError{Name: name}
+	}
+
+	return nil
+}
+
+// viewReads reports whether the given view reads the relation, directly or through other views.
+func viewReads(cache *catalogCache, viewName, relation string) bool {
+	r, err := cache.Get(RelationViewType, viewName)
+	if err != nil {
+		return false
+	}
+
+	for _, name := range r.(*database.ViewInfo).Query.Relations() {
+		if name == relation || viewReads(cache, name, relation) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// DropView deletes a view from the catalog.
+// If cascade is true, the views reading it are deleted as well,
+// otherwise it returns an error if there are any.
+func (c *Catalog) DropView(tx *database.Transaction, viewName string, cascade bool) error {
+	cache, err := c.writable(tx)
+	if err != nil {
+		return err
+	}
+
+	_, err = cache.Get(RelationViewType, viewName)
+	if err != nil {
+		return err
+	}
+
+	for {
+		views := cache.GetDependentViews(viewName)
+		if len(views) == 0 {
+			break
+		}
+		if !cascade {
+			return stringutil.Errorf("cannot drop view %q: view %q depends on it", viewName, views[0])
+		}
+
+		// dropping a view may drop other dependent views as well
+		err = c.DropView(tx, views[0], true)
+		if err != nil {
+			return err
+		}
+	}
+
+	_, err = cache.Delete(RelationViewType, viewName)
+	if err != nil {
+		return err
+	}
+
+	return c.CatalogTable.Delete(tx, viewName)
+}
+
+// GetDependentViews returns the names of the views reading the given table or view,
+// sorted lexicographically.
+func (c *Catalog) GetDependentViews(tx *database.Transaction, name string) []string {
+	return c.snapshot(tx).GetDependentViews(name)
+}
+
+// ListViews returns all view names sorted lexicographically.
+func (c *Catalog) ListViews(tx *database.Transaction) []string {
+	return c.snapshot(tx).ListObjects(RelationViewType)
+}
+
+// GetTriggerInfo returns the trigger info for the given trigger name.
+func (c *Catalog) GetTriggerInfo(tx *database.Transaction, triggerName string) (*database.TriggerInfo, error) {
+	r, err := c.snapshot(tx).Get(RelationTriggerType, triggerName)
+	if err != nil {
+		return nil, err
+	}
+
+	return r.(*database.TriggerInfo), nil
+}
+
+// CreateTrigger creates a trigger on an existing table.
+// If a table, an index, a sequence, a view or a trigger has the same name, it returns errs.AlreadyExistsError.
+func (c *Catalog) CreateTrigger(tx *database.Transaction, info *database.TriggerInfo) error {
+	if info.TriggerName == "" {
+		return errors.New("trigger name required")
+	}
+
+	if c.GetVirtualTable(info.TriggerName) != nil {
+		return errs.AlreadyExistsError{Name: info.TriggerName}
+	}
+
+	if c.GetVirtualTable(info.TableName) != nil {
+		return stringutil.Errorf("cannot create trigger on virtual table %q", info.TableName)
+	}
+
+	cache, err := c.writable(tx)
+	if err != nil {
+		return err
+	}
+
+	o, err := cache.Get(RelationTableType, info.TableName)
+	if err != nil {
+		return err
+	}
+	if o.(*database.TableInfo).ReadOnly {
+		return errors.New("cannot create trigger on read-only table")
+	}
+
+	err = cache.Add(info)
+	if err != nil {
+		return err
+	}
+
+	return c.CatalogTable.Insert(tx, info)
+}
+
+// DropTrigger deletes a trigger from the catalog.
+func (c *Catalog) DropTrigger(tx *database.Transaction, triggerName string) error {
+	cache, err := c.writable(tx)
+	if err != nil {
+		return err
+	}
+
+	return c.dropTrigger(tx, cache, triggerName)
+}
+
+func (c *Catalog) dropTrigger(tx *database.Transaction, cache *catalogCache, triggerName string) error {
+	_, err := cache.Delete(RelationTriggerType, triggerName)
+	if err != nil {
+		return err
+	}
+
+	return c.CatalogTable.Delete(tx, triggerName)
+}
+
+// GetTableTriggers returns the triggers of the given table, sorted by name.
+func (c *Catalog) GetTableTriggers(tx *database.Transaction, tableName string) []*database.TriggerInfo {
+	return c.snapshot(tx).GetTableTriggers(tableName)
+}
+
+// ListTables returns all table names sorted lexicographically.
+func (c *Catalog) ListTables(tx *database.Transaction) []string {
+	return c.snapshot(tx).ListObjects(RelationTableType)
+}
+
 // ListSequences returns all sequence names sorted lexicographically.
-func (c *Catalog) ListSequences() []string {
-	return c.Cache.ListObjects(RelationSequenceType)
+func (c *Catalog) ListSequences(tx *database.Transaction) []string {
+	return c.snapshot(tx).ListObjects(RelationSequenceType)
 }
@@ -2,15 +2,23 @@ package catalog_test
 
 import (
 	"bytes"
+	"context"
 	"errors"
 	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
 	"testing"
 
 	"github.com/genjidb/genji"
 	"github.com/genjidb/genji/document"
+	"github.com/genjidb/genji/document/encoding/msgpack"
+	"github.com/genjidb/genji/engine/boltengine"
 	errs "github.com/genjidb/genji/errors"
 	"github.com/genjidb/genji/internal/catalog"
 	"github.com/genjidb/genji/internal/database"
+	"github.com/genjidb/genji/internal/expr"
+	"github.com/genjidb/genji/internal/sql/parser"
 	"github.com/genjidb/genji/internal/testutil"
 	"github.com/stretchr/testify/require"
 )
@@ -36,14 +44,7 @@ func update(t testing.TB, db *database.Database, fn func(tx *database.Transactio
 }
 
 func cloneCatalog(c database.Catalog) database.Catalog {
-	orig := c.(*catalog.Catalog)
-
-	var clone catalog.Catalog
-
-	clone.CatalogTable = orig.CatalogTable
-	clone.Cache = orig.Cache.Clone()
-
-	return &clone
+	return c.(*catalog.Catalog).Clone()
 }
 
 // TestCatalogTable tests all basic operations on tables:
@@ -150,7 +151,7 @@ func TestCatalogTable(t *testing.T) {
 			require.Equal(t, ti.FieldConstraints, tb.Info.FieldConstraints)
 
 			// Check that the indexes have been updated as well.
-			idxs := catalog.ListIndexes(tb.Info.Name())
+			idxs := catalog.ListIndexes(tx, tb.Info.Name())
 			require.Len(t, idxs, 2)
 			for _, name := range idxs {
 				idx, err := catalog.GetIndex(tx, name)
@@ -332,7 +333,7 @@ func TestCatalogCreateIndex(t *testing.T) {
 			require.NotNil(t, idx)
 
 			var i int
-			err = idx.AscendGreaterOrEqual(values(document.Value{Type: document.DoubleValue}), func(v, k []byte) error {
+			err = idx.AscendGreaterOrEqual(context.Background(), values(document.Value{Type: document.DoubleValue}), func(v, k []byte) error {
 				var buf bytes.Buffer
 				err = document.NewValueEncoder(&buf).Encode(document.NewDoubleValue(float64(i)))
 				require.NoError(t, err)
@@ -575,7 +576,7 @@ func TestCatalogReIndex(t *testing.T) {
 			require.NoError(t, err)
 
 			var i int
-			err = idx.AscendGreaterOrEqual([]document.Value{{Type: document.DoubleValue}}, func(v, k []byte) error {
+			err = idx.AscendGreaterOrEqual(context.Background(), []document.Value{{Type: document.DoubleValue}}, func(v, k []byte) error {
 				var buf bytes.Buffer
 				err = document.NewValueEncoder(&buf).Encode(document.NewDoubleValue(float64(i)))
 				require.NoError(t, err)
@@ -670,7 +671,7 @@ func TestReIndexAll(t *testing.T) {
 			require.NoError(t, err)
 
 			var i int
-			err = idx.AscendGreaterOrEqual([]document.Value{{Type: document.DoubleValue}}, func(v, k []byte) error {
+			err = idx.AscendGreaterOrEqual(context.Background(), []document.Value{{Type: document.DoubleValue}}, func(v, k []byte) error {
 				var buf bytes.Buffer
 				err = document.NewValueEncoder(&buf).Encode(document.NewDoubleValue(float64(i)))
 				require.NoError(t, err)
@@ -686,7 +687,7 @@ func TestReIndexAll(t *testing.T) {
 			require.NoError(t, err)
 
 			i = 0
-			err = idx.AscendGreaterOrEqual([]document.Value{{Type: document.DoubleValue}}, func(v, k []byte) error {
+			err = idx.AscendGreaterOrEqual(context.Background(), []document.Value{{Type: document.DoubleValue}}, func(v, k []byte) error {
 				var buf bytes.Buffer
 				err = document.NewValueEncoder(&buf).Encode(document.NewDoubleValue(float64(i)))
 				require.NoError(t, err)
@@ -754,7 +755,7 @@ func TestCatalogCreateSequence(t *testing.T) {
 				return err
 			}
 
-			seq, err := clog.GetSequence("test1")
+			seq, err := clog.GetSequence(tx, "test1")
 			require.NoError(t, err)
 			require.NotNil(t, seq)
 
@@ -776,7 +777,7 @@ func TestCatalogCreateSequence(t *testing.T) {
 			if err != nil {
 				return err
 			}
-			seq, err := catalog.GetSequence("test2")
+			seq, err := catalog.GetSequence(tx, "test2")
 			require.NoError(t, err)
 			require.NotNil(t, seq)
 
@@ -826,3 +827,178 @@ func TestCatalogCreateSequence(t *testing.T) {
 		})
 	})
 }
+
+func TestCatalogSnapshot(t *testing.T) {
+	db, cleanup := testutil.NewTestDB(t)
+	defer cleanup()
+
+	c := db.Catalog.(*catalog.Catalog)
+
+	tx, err := db.Begin(true)
+	require.NoError(t, err)
+	defer tx.Rollback()
+
+	err = c.CreateTable(tx, "test", nil)
+	require.NoError(t, err)
+
+	// the transaction sees its own modifications
+	_, err = c.GetTable(tx, "test")
+	require.NoError(t, err)
+
+	_, err = c.GetTableInfo(tx, "test")
+	require.NoError(t, err)
+
+	// the committed snapshot is left untouched,
+	// other transactions don't see the uncommitted table
+	_, err = c.Clone().GetTableInfo(nil, "test")
+	require.True(t, errs.IsNotFoundError(err))
+	_, err = c.GetTableInfo(nil, "test")
+	require.True(t, errs.IsNotFoundError(err))
+	require.NotContains(t, c.ListTables(nil), "test")
+
+	err = tx.Rollback()
+	require.NoError(t, err)
+
+	_, err = c.GetTableInfo(nil, "test")
+	require.True(t, errs.IsNotFoundError(err))
+
+	update(t, db, func(tx *database.Transaction, catalog *catalog.Catalog) error {
+		return catalog.CreateTable(tx, "test", nil)
+	})
+
+	_, err = c.Clone().GetTableInfo(nil, "test")
+	require.NoError(t, err)
+	_, err = c.GetTableInfo(nil, "test")
+	require.NoError(t, err)
+}
+
+func TestCatalogReopen(t *testing.T) {
+	dir, err := ioutil.TempDir("", "genji")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	open := func() *database.Database {
+		ng, err := boltengine.NewEngine(filepath.Join(dir, "test.db"), 0600, nil)
+		require.NoError(t, err)
+
+		db, err := database.New(context.Background(), ng, database.Options{
+			Codec:   msgpack.NewCodec(),
+			Catalog: catalog.New(),
+		})
+		require.NoError(t, err)
+		return db
+	}
+
+	db := open()
+	update(t, db, func(tx *database.Transaction, catalog *catalog.Catalog) error {
+		err := catalog.CreateTable(tx, "test", &database.TableInfo{
+			FieldConstraints: database.FieldConstraints{
+				{Path: testutil.ParseDocumentPath(t, "a"), Type: document.IntegerValue, IsPrimaryKey: true},
+			},
+		})
+		if err != nil {
+			return err
+		}
+
+		err = catalog.CreateTable(tx, "sessions", &database.TableInfo{
+			FieldConstraints: database.FieldConstraints{
+				{Path: testutil.ParseDocumentPath(t, "expires"), Type: document.TimestampValue},
+			},
+			TTLField: testutil.ParseDocumentPath(t, "expires"),
+		})
+		if err != nil {
+			return err
+		}
+
+		err = catalog.CreateIndex(tx, &database.IndexInfo{
+			IndexName: "idx_ab", TableName: "test", Paths: []document.Path{
+				testutil.ParseDocumentPath(t, "a"),
+				testutil.ParseDocumentPath(t, "b"),
+			},
+		})
+		if err != nil {
+			return err
+		}
+
+		err = catalog.CreateIndex(tx, &database.IndexInfo{
+			IndexName: "idx_partial", TableName: "test", Paths: []document.Path{
+				testutil.ParseDocumentPath(t, "b"),
+			},
+			Predicate: expr.Constraint(parser.MustParseExpr("a > 10")),
+		})
+		if err != nil {
+			return err
+		}
+
+		err = catalog.CreateIndex(tx, &database.IndexInfo{
+			IndexName: "idx_multi", TableName: "test", Paths: []document.Path{
+				testutil.ParseDocumentPath(t, "a"),
+				testutil.ParseDocumentPath(t, "c"),
+			},
+			MultiKey: []bool{false, true},
+		})
+		if err != nil {
+			return err
+		}
+
+		return catalog.CreateIndex(tx, &database.IndexInfo{
+			IndexName: "idx_building", TableName: "test", Paths: []document.Path{
+				testutil.ParseDocumentPath(t, "b"),
+			},
+			Building: true,
+		})
+	})
+	require.NoError(t, db.Close())
+
+	// the types of the index are inferred from the table when the catalog is loaded
+	db = open()
+	defer db.Close()
+
+	info, err := db.Catalog.GetIndexInfo(nil, "idx_ab")
+	require.NoError(t, err)
+	require.Equal(t, []document.ValueType{document.IntegerValue, 0}, info.Types)
+
+	// the predicates of partial indexes are loaded and bound to the catalog
+	info, err = db.Catalog.GetIndexInfo(nil, "idx_partial")
+	require.NoError(t, err)
+	require.Equal(t, "CREATE INDEX idx_partial ON test (b) WHERE a > 10", info.String())
+
+	// multi-key paths are restored from the definition of the index
+	info, err = db.Catalog.GetIndexInfo(nil, "idx_multi")
+	require.NoError(t, err)
+	require.Equal(t, []bool{false, true}, info.MultiKey)
+	require.Equal(t, "CREATE INDEX idx_multi ON test (a, c[*])", info.String())
+
+	// the ttl field is restored from the table options
+	ti, err := db.Catalog.GetTableInfo(nil, "sessions")
+	require.NoError(t, err)
+	require.Equal(t, testutil.ParseDocumentPath(t, "expires"), ti.TTLField)
+	require.Equal(t, `CREATE TABLE sessions (expires TIMESTAMP) WITH ttl_field = "expires"`, ti.String())
+
+	// indexes being built stay so until their build is done
+	info, err = db.Catalog.GetIndexInfo(nil, "idx_building")
+	require.NoError(t, err)
+	require.True(t, info.Building)
+
+	update(t, db, func(tx *database.Transaction, catalog *catalog.Catalog) error {
+		tb, err := catalog.GetTable(tx, "test")
+		require.NoError(t, err)
+
+		for _, a := range []int64{5, 15} {
+			_, err = tb.Insert(document.NewFieldBuffer().
+				Add("a", document.NewIntegerValue(a)).
+				Add("b", document.NewIntegerValue(a)))
+			require.NoError(t, err)
+		}
+
+		require.Len(t, testutil.GetIndexContent(t, tx, catalog, "idx_partial"), 1)
+
+		// writes maintain indexes being built, reindexing them completes their build
+		require.Len(t, testutil.GetIndexContent(t, tx, catalog, "idx_building"), 2)
+		require.NoError(t, catalog.ReIndex(tx, "idx_building"))
+		info, err := catalog.GetIndexInfo(tx, "idx_building")
+		require.NoError(t, err)
+		require.False(t, info.Building)
+		return nil
+	})
+}
@@ -20,6 +20,10 @@ func relationToDocument(r Relation) document.Document {
 		return indexInfoToDocument(t)
 	case *database.Sequence:
 		return sequenceInfoToDocument(t.Info)
+	case *database.ViewInfo:
+		return viewInfoToDocument(t)
+	case *database.TriggerInfo:
+		return triggerInfoToDocument(t)
 	}
 
 	panic(stringutil.Sprintf("objectToDocument: unknown type %q", r.Type()))
@@ -78,6 +82,9 @@ func indexInfoToDocument(i *database.IndexInfo) document.Document {
 	if i.Owner.TableName != "" {
 		buf.Add("owner", document.NewDocumentValue(ownerToDocument(&i.Owner)))
 	}
+	if i.Building {
+		buf.Add("building", document.NewBoolValue(true))
+	}
 
 	return buf
 }
@@ -113,6 +120,14 @@ func indexInfoFromDocument(d document.Document) (*database.IndexInfo, error) {
 		i.Owner = *owner
 	}
 
+	v, err = d.GetByField("building")
+	if err != nil && err != document.ErrFieldNotFound {
+		return nil, err
+	}
+	if err == nil {
+		i.Building = v.V.(bool)
+	}
+
 	return &i, nil
 }
 
@@ -162,11 +177,63 @@ func sequenceInfoFromDocument(d document.Document) (*database.SequenceInfo, erro
 	return &i, nil
 }
 
+func viewInfoToDocument(v *database.ViewInfo) document.Document {
+	buf := document.NewFieldBuffer()
+	buf.Add("name", document.NewTextValue(v.ViewName))
+	buf.Add("type", document.NewTextValue(RelationViewType))
+	buf.Add("sql", document.NewTextValue(v.String()))
+
+	return buf
+}
+
+func viewInfoFromDocument(d document.Document) (*database.ViewInfo, error) {
+	s, err := d.GetByField("sql")
+	if err != nil {
+		return nil, err
+	}
+
+	stmt, err := parser.NewParser(strings.NewReader(s.V.(string))).ParseStatement()
+	if err != nil {
+		return nil, err
+	}
+
+	v := stmt.(*statement.CreateViewStmt).Info
+	return &v, nil
+}
+
+func triggerInfoToDocument(t *database.TriggerInfo) document.Document {
+	buf := document.NewFieldBuffer()
+	buf.Add("name", document.NewTextValue(t.TriggerName))
+	buf.Add("type", document.NewTextValue(RelationTriggerType))
+	buf.Add("table_name", document.NewTextValue(t.TableName))
+	buf.Add("sql", document.NewTextValue(t.String()))
+
+	return buf
+}
+
+func triggerInfoFromDocument(d document.Document) (*database.TriggerInfo, error) {
+	s, err := d.GetByField("sql")
+	if err != nil {
+		return nil, err
+	}
+
+	stmt, err := parser.NewParser(strings.NewReader(s.V.(string))).ParseStatement()
+	if err != nil {
+		return nil, err
+	}
+
+	t := stmt.(*statement.CreateTriggerStmt).Info
+	return &t, nil
+}
+
 func ownerToDocument(owner *database.Owner) document.Document {
 	buf := document.NewFieldBuffer().Add("table_name", document.NewTextValue(owner.TableName))
 	if owner.Path != nil {
 		buf.Add("path", document.NewTextValue(owner.Path.String()))
 	}
+	if owner.Constraint != "" {
+		buf.Add("constraint", document.NewTextValue(owner.Constraint))
+	}
 
 	return buf
 }
@@ -192,6 +259,14 @@ func ownerFromDocument(d document.Document) (*database.Owner, error) {
 		}
 	}
 
+	v, err = d.GetByField("constraint")
+	if err != nil && err != document.ErrFieldNotFound {
+		return nil, err
+	}
+	if err == nil {
+		owner.Constraint = v.V.(string)
+	}
+
 	return &owner, nil
 }
 
@@ -262,10 +337,10 @@ func (s *CatalogTable) Init(tx *database.Transaction) error {
 	return err
 }
 
-func (s *CatalogTable) Load(tx *database.Transaction) (tables []database.TableInfo, indexes []database.IndexInfo, sequences []database.SequenceInfo, err error) {
+func (s *CatalogTable) Load(tx *database.Transaction) (tables []database.TableInfo, indexes []database.IndexInfo, sequences []database.SequenceInfo, views []database.ViewInfo, triggers []database.TriggerInfo, err error) {
 	tb := s.Table(tx)
 
-	err = tb.AscendGreaterOrEqual(document.Value{}, func(d document.Document) error {
+	err = tb.AscendGreaterOrEqual(tx.Context(), document.Value{}, func(d document.Document) error {
 		tp, err := d.GetByField("type")
 		if err != nil {
 			return err
@@ -291,6 +366,18 @@ func (s *CatalogTable) Load(tx *database.Transaction) (tables []database.TableIn
 				return err
 			}
 			sequences = append(sequences, *i)
+		case RelationViewType:
+			v, err := viewInfoFromDocument(d)
+			if err != nil {
+				return err
+			}
+			views = append(views, *v)
+		case RelationTriggerType:
+			t, err := triggerInfoFromDocument(d)
+			if err != nil {
+				return err
+			}
+			triggers = append(triggers, *t)
 		}
 
 		return nil
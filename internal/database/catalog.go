@@ -1,22 +1,54 @@
 package database
 
+import "github.com/genjidb/genji/document"
+
 type Catalog interface {
 	Load(tx *Transaction) error
 	GetTable(tx *Transaction, tableName string) (*Table, error)
-	GetTableInfo(tableName string) (*TableInfo, error)
+	GetTableInfo(tx *Transaction, tableName string) (*TableInfo, error)
 	CreateTable(tx *Transaction, tableName string, info *TableInfo) error
 	DropTable(tx *Transaction, tableName string) error
 	RenameTable(tx *Transaction, oldName, newName string) error
 	AddFieldConstraint(tx *Transaction, tableName string, fc FieldConstraint) error
+	AlterFieldConstraint(tx *Transaction, tableName string, fc FieldConstraint) error
+	DropTableConstraint(tx *Transaction, tableName, name string) error
+	RenameField(tx *Transaction, tableName string, oldPath, newPath document.Path) error
 	GetIndex(tx *Transaction, indexName string) (*Index, error)
-	GetIndexInfo(indexName string) (*IndexInfo, error)
-	ListIndexes(tableName string) []string
+	GetIndexInfo(tx *Transaction, indexName string) (*IndexInfo, error)
+	ListIndexes(tx *Transaction, tableName string) []string
 	CreateIndex(tx *Transaction, info *IndexInfo) error
 	DropIndex(tx *Transaction, name string) error
 	ReIndex(tx *Transaction, indexName string) error
 	ReIndexAll(tx *Transaction) error
-	GetSequence(name string) (*Sequence, error)
+	IndexDocuments(tx *Transaction, indexName string, keys [][]byte) error
+	FinishIndexBuild(tx *Transaction, indexName string) error
+	GetSequence(tx *Transaction, name string) (*Sequence, error)
 	CreateSequence(tx *Transaction, info *SequenceInfo) error
 	DropSequence(tx *Transaction, name string) error
-	ListSequences() []string
+	ListSequences(tx *Transaction) []string
+	ListTables(tx *Transaction) []string
+	GetViewInfo(tx *Transaction, viewName string) (*ViewInfo, error)
+	CreateView(tx *Transaction, info *ViewInfo) error
+	ReplaceView(tx *Transaction, info *ViewInfo) error
+	DropView(tx *Transaction, viewName string, cascade bool) error
+	GetDependentViews(tx *Transaction, name string) []string
+	ListViews(tx *Transaction) []string
+	GetTriggerInfo(tx *Transaction, triggerName string) (*TriggerInfo, error)
+	CreateTrigger(tx *Transaction, info *TriggerInfo) error
+	DropTrigger(tx *Transaction, triggerName string) error
+	GetTableTriggers(tx *Transaction, tableName string) []*TriggerInfo
+	SetRowPolicy(tableName string, p *RowPolicy)
+	GetRowPolicy(tableName string) *RowPolicy
+	RegisterVirtualTable(tableName string, t VirtualTable) error
+	GetVirtualTable(tableName string) VirtualTable
+}
+
+// catalogSnapshot returns the snapshot of the catalog read by the transactions
+// starting now, or nil if the catalog doesn't support snapshots.
+func catalogSnapshot(c Catalog) interface{} {
+	if s, ok := c.(interface{ Snapshot() interface{} }); ok {
+		return s.Snapshot()
+	}
+
+	return nil
 }
@@ -0,0 +1,232 @@
+package database
+
+import (
+	"sync"
+
+	"github.com/genjidb/genji/document"
+)
+
+// ChangeOp is the kind of write made to a document.
+type ChangeOp uint8
+
+const (
+	ChangeInsert ChangeOp = iota + 1
+	ChangeUpdate
+	ChangeDelete
+)
+
+func (op ChangeOp) String() string {
+	switch op {
+	case ChangeInsert:
+		return "insert"
+	case ChangeUpdate:
+		return "update"
+	case ChangeDelete:
+		return "delete"
+	}
+
+	return ""
+}
+
+// A Change is a document of a table inserted, updated or deleted by a transaction.
+// Old is nil for inserts and New is nil for deletes.
+type Change struct {
+	TableName string
+	Op        ChangeOp
+	Old, New  document.Document
+
+	// whether the change must be written to the change log.
+	logged bool
+}
+
+// A ChangeFeed delivers the changes committed by transactions to subscribers.
+// Changes are only recorded for the tables that have subscribers, and are
+// delivered in the order they were committed.
+// It also delivers the writes made to the engine by every committed transaction
+// to replicas.
+// It is safe for concurrent use.
+type ChangeFeed struct {
+	mu        sync.RWMutex
+	subs      map[string][]*subscription
+	writeSubs []*writeSubscription
+	nextID    uint64
+
+	// transactions committed but not delivered yet.
+	queueMu    sync.Mutex
+	queue      []committedTx
+	committed  uint64
+	delivering bool
+}
+
+type subscription struct {
+	id uint64
+	fn func(c *Change)
+}
+
+type writeSubscription struct {
+	id uint64
+	fn func(writes []Write)
+	// transactions committed before the subscription
+	// are not delivered.
+	from uint64
+}
+
+// committedTx holds what a committed transaction delivers.
+type committedTx struct {
+	seq     uint64
+	changes []Change
+	writes  []Write
+}
+
+// Subscribe registers fn to be called with every change of the table committed
+// from now on. It returns a function canceling the subscription.
+func (f *ChangeFeed) Subscribe(tableName string, fn func(c *Change)) (cancel func()) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.subs == nil {
+		f.subs = make(map[string][]*subscription)
+	}
+
+	f.nextID++
+	s := subscription{id: f.nextID, fn: fn}
+	f.subs[tableName] = append(f.subs[tableName], &s)
+
+	return func() {
+		f.mu.Lock()
+		defer f.mu.Unlock()
+
+		list := f.subs[tableName]
+		for i := range list {
+			if list[i].id == s.id {
+				// copy the list, which may be read by a running delivery
+				list = append(list[:i:i], list[i+1:]...)
+				break
+			}
+		}
+
+		if len(list) == 0 {
+			delete(f.subs, tableName)
+		} else {
+			f.subs[tableName] = list
+		}
+	}
+}
+
+// subscribeWrites registers fn to be called with the writes of every transaction
+// committed from now on. It returns a function canceling the subscription.
+func (f *ChangeFeed) subscribeWrites(fn func(writes []Write)) (cancel func()) {
+	f.queueMu.Lock()
+	from := f.committed
+	f.queueMu.Unlock()
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.nextID++
+	s := writeSubscription{id: f.nextID, fn: fn, from: from}
+	f.writeSubs = append(f.writeSubs, &s)
+
+	return func() {
+		f.mu.Lock()
+		defer f.mu.Unlock()
+
+		for i := range f.writeSubs {
+			if f.writeSubs[i].id == s.id {
+				f.writeSubs = append(f.writeSubs[:i:i], f.writeSubs[i+1:]...)
+				break
+			}
+		}
+	}
+}
+
+// replicated reports whether the writes of transactions have subscribers.
+func (f *ChangeFeed) replicated() bool {
+	if f == nil {
+		return false
+	}
+
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+
+	return len(f.writeSubs) > 0
+}
+
+// watched reports whether the changes of the table have subscribers.
+func (f *ChangeFeed) watched(tableName string) bool {
+	if f == nil {
+		return false
+	}
+
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+
+	return len(f.subs[tableName]) > 0
+}
+
+// enqueue adds the changes and the writes of a committed transaction to the ones to deliver.
+// It must be called while no other transaction can commit, to preserve their order.
+func (f *ChangeFeed) enqueue(changes []Change, writes []Write) {
+	f.queueMu.Lock()
+	f.committed++
+	f.queue = append(f.queue, committedTx{seq: f.committed, changes: changes, writes: writes})
+	f.queueMu.Unlock()
+}
+
+// deliver calls the subscribers with the changes enqueued, until there are none left.
+// If changes are already being delivered, possibly by a subscriber committing a transaction,
+// it returns immediately and the changes are delivered by the running delivery.
+func (f *ChangeFeed) deliver() {
+	f.queueMu.Lock()
+	if f.delivering {
+		f.queueMu.Unlock()
+		return
+	}
+	f.delivering = true
+	f.queueMu.Unlock()
+
+	// let the next commit deliver the changes if a subscriber panics
+	var done bool
+	defer func() {
+		if !done {
+			f.queueMu.Lock()
+			f.delivering = false
+			f.queueMu.Unlock()
+		}
+	}()
+
+	for {
+		f.queueMu.Lock()
+		if len(f.queue) == 0 {
+			f.delivering = false
+			f.queueMu.Unlock()
+			done = true
+			return
+		}
+		c := f.queue[0]
+		f.queue = f.queue[1:]
+		f.queueMu.Unlock()
+
+		for i := range c.changes {
+			f.mu.RLock()
+			subs := f.subs[c.changes[i].TableName]
+			f.mu.RUnlock()
+
+			for _, s := range subs {
+				s.fn(&c.changes[i])
+			}
+		}
+
+		if len(c.writes) > 0 {
+			f.mu.RLock()
+			subs := f.writeSubs
+			f.mu.RUnlock()
+
+			for _, s := range subs {
+				if c.seq > s.from {
+					s.fn(c.writes)
+				}
+			}
+		}
+	}
+}
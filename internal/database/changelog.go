@@ -0,0 +1,321 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/genjidb/genji/document"
+	errs "github.com/genjidb/genji/errors"
+)
+
+const (
+	// ChangesTableName is the name of the table storing the change log,
+	// when the change_log pragma is on or the version_retention pragma is set.
+	// Every document inserted, updated or deleted is logged with the log sequence number
+	// (lsn) and the commit time (ts) of its transaction, the name of its table (table_name),
+	// the kind of write (op), its primary key (pk) and its content before (old) and after (new) the write.
+	ChangesTableName = InternalPrefix + "changes"
+	// ChangesSequenceName is the name of the sequence generating the
+	// log sequence numbers of the change log.
+	ChangesSequenceName = InternalPrefix + "changes_seq"
+
+	changesIndexName         = InternalPrefix + "changes_lsn_idx"
+	changesDocidSequenceName = InternalPrefix + "changes_docid_seq"
+)
+
+var changesTableInfo = &TableInfo{
+	TableName:         ChangesTableName,
+	StoreName:         []byte(ChangesTableName),
+	DocidSequenceName: changesDocidSequenceName,
+	FieldConstraints: []*FieldConstraint{
+		{
+			Path: document.Path{
+				document.PathFragment{
+					FieldName: "lsn",
+				},
+			},
+			Type:      document.IntegerValue,
+			IsNotNull: true,
+		},
+		{
+			Path: document.Path{
+				document.PathFragment{
+					FieldName: "ts",
+				},
+			},
+			Type: document.TimestampValue,
+		},
+		{
+			Path: document.Path{
+				document.PathFragment{
+					FieldName: "table_name",
+				},
+			},
+			Type:      document.TextValue,
+			IsNotNull: true,
+		},
+		{
+			Path: document.Path{
+				document.PathFragment{
+					FieldName: "op",
+				},
+			},
+			Type:      document.TextValue,
+			IsNotNull: true,
+		},
+		{
+			Path: document.Path{
+				document.PathFragment{
+					FieldName: "old",
+				},
+			},
+			Type: document.DocumentValue,
+		},
+		{
+			Path: document.Path{
+				document.PathFragment{
+					FieldName: "new",
+				},
+			},
+			Type: document.DocumentValue,
+		},
+	},
+}
+
+// pruneBatchSize is the maximum number of entries older than the version retention
+// deleted from the change log by each transaction.
+var pruneBatchSize = 1000
+
+// changeLogEnabled reports whether the changes made to the tables must be
+// written to the change log, either because the change_log pragma is on or because
+// versions are retained. The pragmas are read once per transaction.
+func (tx *Transaction) changeLogEnabled(catalog Catalog) (bool, error) {
+	if tx.changeLog != nil {
+		return *tx.changeLog, nil
+	}
+
+	v, err := GetPragmaValue(tx, catalog, "change_log")
+	if err != nil {
+		return false, err
+	}
+
+	enabled := v.V.(string) == "on"
+	if !enabled {
+		retention, err := VersionRetention(tx, catalog)
+		if err != nil {
+			return false, err
+		}
+		enabled = retention > 0
+	}
+
+	tx.changeLog = &enabled
+	return enabled, nil
+}
+
+// VersionRetention returns the duration for which the previous versions of the documents
+// are kept in the change log, as set by the version_retention pragma.
+func VersionRetention(tx *Transaction, catalog Catalog) (time.Duration, error) {
+	v, err := GetPragmaValue(tx, catalog, "version_retention")
+	if err != nil {
+		return 0, err
+	}
+
+	// integers stored in the pragma table are read back as doubles
+	v, err = v.CastAsInteger()
+	if err != nil {
+		return 0, err
+	}
+
+	return time.Duration(v.V.(int64)) * time.Millisecond, nil
+}
+
+// createChangeLog creates the change log table, its index and the sequence
+// generating its log sequence numbers, if they don't exist.
+// The sequence is not dropped with the table, so that log sequence numbers
+// keep increasing if the change log is dropped and enabled again.
+func createChangeLog(tx *Transaction, catalog Catalog) error {
+	_, err := catalog.GetTable(tx, ChangesTableName)
+	if err == nil {
+		return nil
+	}
+	if !errs.IsNotFoundError(err) {
+		return err
+	}
+
+	// documents are stored in the order they are logged
+	err = catalog.CreateSequence(tx, &SequenceInfo{
+		Name:        changesDocidSequenceName,
+		IncrementBy: 1,
+		Min:         1, Max: math.MaxInt64,
+		Start: 1,
+		Cache: 64,
+		Owner: Owner{
+			TableName: ChangesTableName,
+		},
+	})
+	if _, ok := err.(errs.AlreadyExistsError); err != nil && !ok {
+		return err
+	}
+
+	err = catalog.CreateTable(tx, ChangesTableName, changesTableInfo.Clone())
+	if err != nil {
+		return err
+	}
+
+	err = catalog.CreateIndex(tx, &IndexInfo{
+		TableName: ChangesTableName,
+		IndexName: changesIndexName,
+		Paths:     []document.Path{document.NewPath("lsn")},
+	})
+	if err != nil {
+		return err
+	}
+
+	err = catalog.CreateSequence(tx, &SequenceInfo{
+		Name:        ChangesSequenceName,
+		IncrementBy: 1,
+		Min:         1, Max: math.MaxInt64,
+		Start: 1,
+		Cache: 16,
+		Owner: Owner{
+			TableName: ChangesTableName,
+		},
+	})
+	if _, ok := err.(errs.AlreadyExistsError); ok {
+		return nil
+	}
+	return err
+}
+
+// writeChangeLog appends the changes made by the transaction to the change log,
+// all under the same new log sequence number.
+// Log sequence numbers increase with every commit but are not contiguous.
+func writeChangeLog(tx *Transaction, catalog Catalog) error {
+	var logged bool
+	for i := range tx.changes {
+		logged = logged || tx.changes[i].logged
+	}
+	if !logged {
+		return nil
+	}
+
+	// the change log may have been dropped by the transaction
+	err := createChangeLog(tx, catalog)
+	if err != nil {
+		return err
+	}
+
+	tb, err := catalog.GetTable(tx, ChangesTableName)
+	if err != nil {
+		return err
+	}
+
+	seq, err := catalog.GetSequence(tx, ChangesSequenceName)
+	if err != nil {
+		return err
+	}
+
+	lsn, err := seq.Next(tx, catalog)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+
+	// inserting into the change log may record changes if it has subscribers
+	changes := tx.changes[:len(tx.changes):len(tx.changes)]
+	for i := range changes {
+		c := &changes[i]
+		if !c.logged {
+			continue
+		}
+
+		fb := document.NewFieldBuffer().
+			Add("lsn", document.NewIntegerValue(lsn)).
+			Add("ts", document.NewTimestampValue(now)).
+			Add("table_name", document.NewTextValue(c.TableName)).
+			Add("op", document.NewTextValue(c.Op.String()))
+
+		d := c.New
+		if d == nil {
+			d = c.Old
+		}
+		k, err := d.(document.Keyer).Key()
+		if err != nil {
+			return err
+		}
+		fb.Add("pk", k)
+
+		if c.Old != nil {
+			fb.Add("old", document.NewDocumentValue(c.Old))
+		}
+		if c.New != nil {
+			fb.Add("new", document.NewDocumentValue(c.New))
+		}
+
+		_, err = tb.Insert(fb)
+		if err != nil {
+			return err
+		}
+	}
+
+	return pruneChangeLog(tx, catalog, tb, now)
+}
+
+// errPruneDone stops the iteration over the change log once pruning is done.
+var errPruneDone = errors.New("prune done")
+
+// pruneChangeLog deletes the oldest entries of the change log that are older than the
+// version retention, unless the change_log pragma is on, in which case the log is kept whole.
+// At most pruneBatchSize entries are deleted at once, the next commits delete the rest.
+func pruneChangeLog(tx *Transaction, catalog Catalog, tb *Table, now time.Time) error {
+	v, err := GetPragmaValue(tx, catalog, "change_log")
+	if err != nil || v.V.(string) == "on" {
+		return err
+	}
+
+	retention, err := VersionRetention(tx, catalog)
+	if err != nil || retention == 0 {
+		return err
+	}
+	limit := now.Add(-retention)
+
+	// entries are stored in the order they are logged
+	var keys [][]byte
+	err = tb.Iterate(context.Background(), func(d document.Document) error {
+		ts, err := d.GetByField("ts")
+		if err == nil && ts.Type == document.TimestampValue && !ts.V.(time.Time).Before(limit) {
+			return errPruneDone
+		}
+		if err != nil && err != document.ErrFieldNotFound {
+			return err
+		}
+
+		keys = append(keys, append([]byte{}, d.(document.Keyer).RawKey()...))
+		if len(keys) == pruneBatchSize {
+			return errPruneDone
+		}
+		return nil
+	})
+	if err != nil && err != errPruneDone {
+		return err
+	}
+
+	for _, key := range keys {
+		err = tb.Delete(key)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// isLoggedTable reports whether the changes of the table can be written
+// to the change log. Changes of internal tables are never logged.
+func isLoggedTable(tableName string) bool {
+	return !strings.HasPrefix(tableName, InternalPrefix)
+}
@@ -2,11 +2,12 @@ package database
 
 import (
 	"github.com/genjidb/genji/document"
+	errs "github.com/genjidb/genji/errors"
 )
 
 // OnInsertConflictAction is a function triggered when trying to insert a document that already exists.
 // This function is triggered if the key is duplicated or if there is a unique constraint violation on one
-// of the fields of the document.
+// of the fields of the document, in which case err is a *ConflictError.
 type OnInsertConflictAction func(t *Table, key []byte, d document.Document, err error) (document.Document, error)
 
 // OnInsertConflictDoNothing ignores the duplicate error and returns nothing.
@@ -31,3 +32,109 @@ func OnInsertConflictDoReplace(t *Table, key []byte, d document.Document, err er
 		pk:       t.Info.FieldConstraints.GetPrimaryKey(),
 	}, nil
 }
+
+// A ConflictError describes the constraint violated by a document
+// conflicting with an existing one.
+type ConflictError struct {
+	// Paths of the primary key or of the unique index.
+	// It is empty if the document conflicts with a document inserted without primary key.
+	Paths []document.Path
+	// Violation describes the violated unique index, it is nil for primary keys.
+	Violation *errs.ConstraintViolationError
+}
+
+func (e *ConflictError) Error() string {
+	if e.Violation != nil {
+		return e.Violation.Error()
+	}
+
+	return errs.ErrDuplicateDocument.Error()
+}
+
+// Unwrap returns the violation of the unique index, or errs.ErrDuplicateDocument for primary keys.
+func (e *ConflictError) Unwrap() error {
+	if e.Violation != nil {
+		return e.Violation
+	}
+
+	return errs.ErrDuplicateDocument
+}
+
+// UniqueViolationError returns the error describing the violation of the given unique index
+// by a document having the given values at the paths of the index.
+func UniqueViolationError(info *IndexInfo, vs []document.Value) *errs.ConstraintViolationError {
+	return &errs.ConstraintViolationError{
+		TableName:  info.TableName,
+		IndexName:  info.IndexName,
+		Constraint: info.Owner.Constraint,
+		Paths:      info.Paths,
+		Values:     vs,
+	}
+}
+
+// Matches returns true if the conflicting constraint is defined on the given paths, in any order.
+func (e *ConflictError) Matches(paths []document.Path) bool {
+	if len(paths) != len(e.Paths) {
+		return false
+	}
+
+	for _, p := range paths {
+		var found bool
+		for _, other := range e.Paths {
+			if p.IsEqual(other) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+
+	return true
+}
+
+// OnInsertConflictTarget returns a conflict resolution function that only resolves the conflicts
+// caused by the primary key or the unique index defined on the target paths, using action.
+// Any other conflict returns an error.
+func OnInsertConflictTarget(target []document.Path, action OnInsertConflictAction) OnInsertConflictAction {
+	return func(t *Table, key []byte, d document.Document, err error) (document.Document, error) {
+		ce, ok := err.(*ConflictError)
+		if !ok {
+			return nil, err
+		}
+		if !ce.Matches(target) {
+			if ce.Violation != nil {
+				return nil, ce.Violation
+			}
+			return nil, errs.ErrDuplicateDocument
+		}
+
+		return action(t, key, d, err)
+	}
+}
+
+// HasConflictTarget returns whether the given paths are those of the primary key
+// or of a unique index of the table, in any order.
+func (t *Table) HasConflictTarget(paths []document.Path) (bool, error) {
+	if pk := t.Info.FieldConstraints.GetPrimaryKey(); pk != nil {
+		ce := ConflictError{Paths: []document.Path{pk.Path}}
+		if ce.Matches(paths) {
+			return true, nil
+		}
+	}
+
+	indexes, err := t.GetIndexes()
+	if err != nil {
+		return false, err
+	}
+
+	for _, idx := range indexes {
+		ce := ConflictError{Paths: idx.Info.Paths}
+		if idx.Info.Unique && ce.Matches(paths) {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
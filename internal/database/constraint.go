@@ -24,9 +24,23 @@ type FieldConstraint struct {
 	IsNotNull    bool
 	IsUnique     bool
 	DefaultValue TableExpression
-	Identity     *FieldConstraintIdentity
-	IsInferred   bool
-	InferredBy   []document.Path
+	// Expression computing the value of generated fields,
+	// evaluated against the document each time it is written.
+	Generated  TableExpression
+	Identity   *FieldConstraintIdentity
+	IsInferred bool
+	InferredBy []document.Path
+	// Dimension of vector fields. 0 means that
+	// vectors of any dimension are accepted.
+	Dimension int
+	// Maximum number of characters of text fields,
+	// i.e. VARCHAR(255). 0 means there is no limit.
+	MaxLength int
+	// Total number of digits and number of digits after the decimal point
+	// of decimal fields, i.e. DECIMAL(10, 2). A precision of 0 means that
+	// decimals are stored as given.
+	Precision int
+	Scale     int
 }
 
 // IsEqual compares f with other member by member.
@@ -40,6 +54,18 @@ func (f *FieldConstraint) IsEqual(other *FieldConstraint) bool {
 		return false
 	}
 
+	if f.Dimension != other.Dimension {
+		return false
+	}
+
+	if f.MaxLength != other.MaxLength {
+		return false
+	}
+
+	if f.Precision != other.Precision || f.Scale != other.Scale {
+		return false
+	}
+
 	if f.IsPrimaryKey != other.IsPrimaryKey {
 		return false
 	}
@@ -58,6 +84,16 @@ func (f *FieldConstraint) IsEqual(other *FieldConstraint) bool {
 		}
 	}
 
+	if f.IsGenerated() != other.IsGenerated() {
+		return false
+	}
+
+	if f.IsGenerated() {
+		if !f.Generated.IsEqual(other.Generated) {
+			return false
+		}
+	}
+
 	if !f.Identity.IsEqual(other.Identity) {
 		return false
 	}
@@ -70,7 +106,16 @@ func (f *FieldConstraint) String() string {
 
 	s.WriteString(f.Path.String())
 	s.WriteString(" ")
-	s.WriteString(strings.ToUpper(f.Type.String()))
+	switch {
+	case f.MaxLength > 0:
+		stringutil.Fprintf(&s, "VARCHAR(%d)", f.MaxLength)
+	case f.Dimension > 0:
+		stringutil.Fprintf(&s, "%s(%d)", strings.ToUpper(f.Type.String()), f.Dimension)
+	case f.Precision > 0:
+		stringutil.Fprintf(&s, "DECIMAL(%d, %d)", f.Precision, f.Scale)
+	default:
+		s.WriteString(strings.ToUpper(f.Type.String()))
+	}
 
 	if f.IsNotNull {
 		s.WriteString(" NOT NULL")
@@ -89,6 +134,12 @@ func (f *FieldConstraint) String() string {
 		s.WriteString(f.DefaultValue.String())
 	}
 
+	if f.IsGenerated() {
+		s.WriteString(" AS (")
+		s.WriteString(f.Generated.String())
+		s.WriteString(") STORED")
+	}
+
 	return s.String()
 }
 
@@ -114,6 +165,16 @@ func (f *FieldConstraint) HasDefaultValue() bool {
 	return f.DefaultValue != nil
 }
 
+// IsGenerated returns true if the value of the field is computed from the other fields.
+func (f *FieldConstraint) IsGenerated() bool {
+	return f.Generated != nil
+}
+
+// IsEmpty returns true if the constraint doesn't enforce anything on the field.
+func (f *FieldConstraint) IsEmpty() bool {
+	return f.Type.IsAny() && !f.IsPrimaryKey && !f.IsNotNull && !f.IsUnique && !f.HasDefaultValue() && !f.IsGenerated() && f.Identity == nil
+}
+
 // FieldConstraints is a list of field constraints.
 type FieldConstraints []*FieldConstraint
 
@@ -148,14 +209,17 @@ func (f FieldConstraints) GetPrimaryKey() *FieldConstraint {
 
 // Infer additional constraints based on user defined ones.
 // For example, given the following table:
-//   CREATE TABLE foo (a.b[0] TEXT)
+//
+//	CREATE TABLE foo (a.b[0] TEXT)
+//
 // this function will return a TableInfo that behaves as if the table
 // had been created like this:
-//   CREATE TABLE foo(
-//      a DOCUMENT
-//      a.b ARRAY
-//      a.b[0] TEXT
-//   )
+//
+//	CREATE TABLE foo(
+//	   a DOCUMENT
+//	   a.b ARRAY
+//	   a.b[0] TEXT
+//	)
 func (f FieldConstraints) Infer() (FieldConstraints, error) {
 	newConstraints := make(FieldConstraints, 0, len(f))
 
@@ -220,6 +284,7 @@ func (f *FieldConstraints) Add(newFc *FieldConstraint) error {
 
 			// the inferred one may have less constraints that the user-defined one
 			inferredFc.DefaultValue = nonInferredFc.DefaultValue
+			inferredFc.Generated = nonInferredFc.Generated
 			inferredFc.IsNotNull = nonInferredFc.IsNotNull
 			inferredFc.IsPrimaryKey = nonInferredFc.IsPrimaryKey
 
@@ -257,7 +322,7 @@ func (f *FieldConstraints) Add(newFc *FieldConstraint) error {
 	// ensure default value type is compatible
 	if newFc.DefaultValue != nil && !newFc.Type.IsAny() {
 		// first, try to evaluate the default value
-		v, err := newFc.DefaultValue.Eval(nil)
+		v, err := newFc.DefaultValue.Eval(nil, nil)
 		// if there is no error, check if the default value can be converted to the type of the constraint
 		if err == nil {
 			_, err = v.CastAs(newFc.Type)
@@ -267,9 +332,9 @@ func (f *FieldConstraints) Add(newFc *FieldConstraint) error {
 		} else {
 			// if there is an error, we know we are using a function that returns an integer (NEXT VALUE FOR)
 			// which is the only one compatible for the moment.
-			// Integers can be converted to other integers, doubles, texts and bools.
+			// Integers can be converted to other integers, doubles, decimals, texts and bools.
 			switch newFc.Type {
-			case document.IntegerValue, document.DoubleValue, document.TextValue, document.BoolValue:
+			case document.IntegerValue, document.DoubleValue, document.DecimalValue, document.TextValue, document.BoolValue:
 			default:
 				return stringutil.Errorf("default value %q cannot be converted to type %q", newFc.DefaultValue, newFc.Type)
 			}
@@ -280,8 +345,8 @@ func (f *FieldConstraints) Add(newFc *FieldConstraint) error {
 	return nil
 }
 
-// ValidateDocument calls Convert then ensures the document validates against the field constraints.
-func (f FieldConstraints) ValidateDocument(tx *Transaction, d document.Document) (*document.FieldBuffer, error) {
+// ValidateDocument converts the document using conversionFn then ensures the document validates against the field constraints.
+func (f FieldConstraints) ValidateDocument(tx *Transaction, d document.Document, conversionFn ConversionFunc) (*document.FieldBuffer, error) {
 	fb := document.NewFieldBuffer()
 	err := fb.Copy(d)
 	if err != nil {
@@ -290,6 +355,15 @@ func (f FieldConstraints) ValidateDocument(tx *Transaction, d document.Document)
 
 	// generate default values for all fields
 	for _, fc := range f {
+		// values of generated fields are always computed
+		if fc.IsGenerated() {
+			err = fb.Delete(fc.Path)
+			if err != nil && err != document.ErrFieldNotFound {
+				return nil, err
+			}
+			continue
+		}
+
 		if fc.DefaultValue == nil {
 			continue
 		}
@@ -303,7 +377,8 @@ func (f FieldConstraints) ValidateDocument(tx *Transaction, d document.Document)
 			return nil, err
 		}
 
-		v, err := fc.DefaultValue.Eval(tx)
+		// default values may refer to the other fields of the document being inserted
+		v, err := fc.DefaultValue.Eval(tx, fb)
 		if err != nil {
 			return nil, err
 		}
@@ -313,11 +388,33 @@ func (f FieldConstraints) ValidateDocument(tx *Transaction, d document.Document)
 		}
 	}
 
-	fb, err = f.ConvertDocument(fb)
+	fb, err = f.convertDocumentAtPath(nil, fb, conversionFn)
 	if err != nil {
 		return nil, err
 	}
 
+	// compute generated fields from the converted document
+	for _, fc := range f {
+		if !fc.IsGenerated() {
+			continue
+		}
+
+		v, err := fc.Generated.Eval(tx, fb)
+		if err != nil {
+			return nil, err
+		}
+
+		v, err = f.ConvertValueAtPath(fc.Path, v, conversionFn)
+		if err != nil {
+			return nil, err
+		}
+
+		err = fb.Set(fc.Path, v)
+		if err != nil {
+			return nil, err
+		}
+	}
+
 	// ensure no field is missing
 	for _, fc := range f {
 		if !fc.IsNotNull {
@@ -368,11 +465,42 @@ func CastConversion(v document.Value, path document.Path, targetType document.Va
 	return newV, nil
 }
 
+// StrictConversion is a ConversionFunc that rejects values whose type differs from the target type.
+// Only integers are accepted in double and decimal fields, as the conversion is lossless.
+func StrictConversion(v document.Value, path document.Path, targetType document.ValueType) (document.Value, error) {
+	// null values always remain null
+	if v.Type == targetType || v.Type == document.NullValue {
+		return v, nil
+	}
+
+	if v.Type == document.IntegerValue && (targetType == document.DoubleValue || targetType == document.DecimalValue) {
+		return v.CastAs(targetType)
+	}
+
+	return v, stringutil.Errorf("field %q must be of type %q, got %q", path, targetType, v.Type)
+}
+
+// UntypedConversion is a ConversionFunc that casts the value to the target type
+// and returns the original value if the cast is not possible.
+func UntypedConversion(v document.Value, path document.Path, targetType document.ValueType) (document.Value, error) {
+	newV, err := v.CastAs(targetType)
+	if err != nil {
+		return v, nil
+	}
+
+	return newV, nil
+}
+
 // ConvertValueAtPath converts the value using the field constraints that are applicable
 // at the given path.
 func (f FieldConstraints) ConvertValueAtPath(path document.Path, v document.Value, conversionFn ConversionFunc) (document.Value, error) {
 	switch v.Type {
 	case document.ArrayValue:
+		// arrays stored in vector fields are converted as a whole
+		if fc := f.Get(path); fc != nil && fc.Type == document.VectorValue {
+			return f.convertScalarAtPath(path, v, conversionFn)
+		}
+
 		vb, err := f.convertArrayAtPath(path, v.V.(document.Array), conversionFn)
 		return document.NewArrayValue(vb), err
 	case document.DocumentValue:
@@ -399,6 +527,20 @@ func (f FieldConstraints) convertScalarAtPath(path document.Path, v document.Val
 				return v, err
 			}
 
+			// ensure vectors have the expected dimension
+			if fc.Dimension > 0 && newV.Type == document.VectorValue && len(newV.V.([]float64)) != fc.Dimension {
+				return v, stringutil.Errorf("field %q must be a vector of dimension %d, got %d", fc.Path, fc.Dimension, len(newV.V.([]float64)))
+			}
+
+			// round decimals to the scale of the field and ensure they fit its precision
+			if fc.Precision > 0 && newV.Type == document.DecimalValue {
+				d := newV.V.(document.Decimal).Rescale(fc.Scale)
+				if d.Precision() > fc.Precision {
+					return v, stringutil.Errorf("field %q must have at most %d digits before the decimal point", fc.Path, fc.Precision-fc.Scale)
+				}
+				newV = document.NewDecimalValue(d)
+			}
+
 			return newV, nil
 		}
 		break
@@ -462,9 +604,13 @@ func (f *FieldConstraintIdentity) IsEqual(other *FieldConstraintIdentity) bool {
 	return f.SequenceName == other.SequenceName && f.Always == other.Always
 }
 
+// A TableExpression is an expression stored in the definition of a table,
+// like default values and generated fields.
 type TableExpression interface {
 	Bind(catalog Catalog)
-	Eval(tx *Transaction) (document.Value, error)
+	// Eval evaluates the expression against the document being written.
+	// d is nil when the expression is evaluated outside of a write.
+	Eval(tx *Transaction, d document.Document) (document.Value, error)
 	IsEqual(other TableExpression) bool
 	String() string
 }
@@ -306,6 +306,20 @@ func TestFieldConstraintsConvert(t *testing.T) {
 			document.NewTextValue("foo"),
 			true,
 		},
+		{
+			database.FieldConstraints{{Path: document.NewPath("a"), Type: document.VectorValue, Dimension: 2}},
+			document.NewPath("a"),
+			document.NewArrayValue(testutil.MakeArray(t, `[1, 2.5]`)),
+			document.NewVectorValue([]float64{1, 2.5}),
+			false,
+		},
+		{
+			database.FieldConstraints{{Path: document.NewPath("a"), Type: document.VectorValue, Dimension: 3}},
+			document.NewPath("a"),
+			document.NewArrayValue(testutil.MakeArray(t, `[1, 2.5]`)),
+			document.NewArrayValue(testutil.MakeArray(t, `[1, 2.5]`)),
+			true,
+		},
 		{
 			database.FieldConstraints{{Path: document.NewPath("a"), DefaultValue: expr.Constraint(testutil.IntegerValue(10))}},
 			document.NewPath("a"),
@@ -5,6 +5,7 @@ import (
 	"context"
 	"errors"
 	"sync"
+	"time"
 
 	"github.com/genjidb/genji/document/encoding"
 	"github.com/genjidb/genji/engine"
@@ -18,33 +19,73 @@ type Database struct {
 	ng      engine.Engine
 	Catalog Catalog
 
-	// If this is non-nil, the user is running an explicit transaction
-	// using the BEGIN statement.
-	// Only one attached transaction can be run at a time and any calls to DB.Begin()
-	// will cause an error until that transaction is rolled back or commited.
-	attachedTransaction *Transaction
-	attachedTxMu        sync.Mutex
+	// transactions opened using the BEGIN statement, each of them
+	// attached to a session until it is rolled back or commited.
+	attachedTxs  map[*Transaction]struct{}
+	attachedTxMu sync.Mutex
 
 	// Codec used to encode documents. Defaults to MessagePack.
 	Codec encoding.Codec
 
-	// This controls concurrency on read-only and read/write transactions.
+	// Read-only transactions read a snapshot of the engine and of the catalog, and run concurrently
+	// with the read/write transaction, which is the only one allowed to modify the catalog.
+	// txmu is read-locked by read-only transactions, and locked when the database is closed.
 	txmu *sync.RWMutex
+	// ensures only one read/write transaction runs at a time.
+	writemu *sync.Mutex
+	// ensures read-only transactions don't start while a read/write transaction
+	// commits modifications of the catalog. See Transaction.LockCatalog.
+	catalogmu *sync.RWMutex
+
+	// number of committed transactions that modified each table.
+	tableVersionsMu sync.RWMutex
+	tableVersions   map[string]uint64
+
+	// Session is the default session of the database. It keeps track of
+	// the changes made by the statements run against this database, and
+	// holds the settings used by queries that don't use a session of their own.
+	Session *Session
+
+	// Changes delivers the changes committed to the tables to subscribers.
+	Changes *ChangeFeed
+
+	// if set, only read-only transactions can be opened.
+	readOnly bool
+
+	// deletes expired documents in the background, if started.
+	reaper *ttlReaper
 }
 
 type Options struct {
 	Codec   encoding.Codec
 	Catalog Catalog
+	// ReadOnly prevents read/write transactions from being opened,
+	// once the database is loaded. Replicas are read-only.
+	ReadOnly bool
+	// Interval at which the documents of tables having a TTL field are checked
+	// and deleted once expired. Zero means DefaultTTLReapInterval, and a negative
+	// interval disables the deletion, expired documents being only hidden.
+	// Expired documents are never deleted by read-only databases.
+	TTLReapInterval time.Duration
 }
 
 // TxOptions are passed to Begin to configure transactions.
 type TxOptions struct {
 	// Open a read-only transaction.
 	ReadOnly bool
-	// Set the transaction as global at the database level.
-	// Any queries run by the database will use that transaction until it is
+	// Open an optimistic read/write transaction, which reads a snapshot of the database
+	// and buffers its writes until it commits. Unlike the other read/write transactions,
+	// optimistic transactions run concurrently, but they cannot modify the schema and their
+	// commit fails with errs.ErrWriteConflict if another transaction committed since they started
+	// wrote the same documents or index entries, or modified the schema.
+	Optimistic bool
+	// Maximum duration of the transaction. Once exceeded, its statements
+	// and its commit fail with errs.ErrTransactionTimeout. Zero means no limit.
+	Timeout time.Duration
+	// Session the transaction is attached to, if any.
+	// Any queries run using the session will use that transaction until it is
 	// rolled back or commited.
-	Attached bool
+	Session *Session
 }
 
 // New initializes the DB using the given engine.
@@ -57,10 +98,14 @@ func New(ctx context.Context, ng engine.Engine, opts Options) (*Database, error)
 	}
 
 	db := Database{
-		ng:      ng,
-		Codec:   opts.Codec,
-		Catalog: opts.Catalog,
-		txmu:    &sync.RWMutex{},
+		ng:        ng,
+		Codec:     opts.Codec,
+		Catalog:   opts.Catalog,
+		txmu:      &sync.RWMutex{},
+		writemu:   &sync.Mutex{},
+		catalogmu: &sync.RWMutex{},
+		Session:   NewSession(),
+		Changes:   new(ChangeFeed),
 	}
 
 	tx, err := db.Begin(true)
@@ -79,16 +124,32 @@ func New(ctx context.Context, ng engine.Engine, opts Options) (*Database, error)
 		return nil, err
 	}
 
+	db.readOnly = opts.ReadOnly
+
+	interval := opts.TTLReapInterval
+	if interval == 0 {
+		interval = DefaultTTLReapInterval
+	}
+	if !db.readOnly && interval > 0 {
+		db.startReaper(interval)
+	}
+
 	return &db, nil
 }
 
 // Close the database.
 func (db *Database) Close() error {
-	// If there is an attached transaction
-	// it must be rolled back before closing the engine.
-	if tx := db.GetAttachedTx(); tx != nil {
+	if db.reaper != nil {
+		db.reaper.stop()
+	}
+
+	// If there are attached transactions
+	// they must be rolled back before closing the engine.
+	for _, tx := range db.attachedTransactions() {
 		_ = tx.Rollback()
 	}
+	db.writemu.Lock()
+	defer db.writemu.Unlock()
 	db.txmu.Lock()
 	defer db.txmu.Unlock()
 
@@ -99,8 +160,8 @@ func (db *Database) Close() error {
 	}
 	defer tx.Tx.Rollback()
 
-	for _, seqName := range db.Catalog.ListSequences() {
-		seq, err := db.Catalog.GetSequence(seqName)
+	for _, seqName := range db.Catalog.ListSequences(tx) {
+		seq, err := db.Catalog.GetSequence(tx, seqName)
 		if err != nil {
 			return err
 		}
@@ -119,14 +180,17 @@ func (db *Database) Close() error {
 	return db.ng.Close()
 }
 
-// GetAttachedTx returns the transaction attached to the database. It returns nil if there is no
-// such transaction.
-// The returned transaction is not thread safe.
-func (db *Database) GetAttachedTx() *Transaction {
+// attachedTransactions returns the transactions attached to sessions.
+func (db *Database) attachedTransactions() []*Transaction {
 	db.attachedTxMu.Lock()
 	defer db.attachedTxMu.Unlock()
 
-	return db.attachedTransaction
+	txs := make([]*Transaction, 0, len(db.attachedTxs))
+	for tx := range db.attachedTxs {
+		txs = append(txs, tx)
+	}
+
+	return txs
 }
 
 // Begin starts a new transaction with default options.
@@ -140,28 +204,83 @@ func (db *Database) Begin(writable bool) (*Transaction, error) {
 // BeginTx starts a new transaction with the given options.
 // If opts is empty, it will use the default options.
 // The returned transaction must be closed either by calling Rollback or Commit.
-// If the Attached option is passed, it opens a database level transaction, which gets
-// attached to the database and prevents any other transaction to be opened afterwards
-// until it gets rolled back or commited.
+// If the Session option is passed, the transaction gets attached to the session,
+// which can't open another one until it gets rolled back or commited.
 func (db *Database) BeginTx(ctx context.Context, opts *TxOptions) (*Transaction, error) {
 	if opts == nil {
 		opts = new(TxOptions)
 	}
 
+	if !opts.ReadOnly && db.readOnly {
+		return nil, errors.New("cannot write to a read-only database")
+	}
+
+	// waiting for the lock would never end if the session holds it
+	if opts.Session != nil && opts.Session.Tx() != nil {
+		return nil, errors.New("cannot open a transaction within a transaction")
+	}
+
+	if opts.Optimistic {
+		return db.beginOptimisticTx(ctx, opts)
+	}
+
 	if !opts.ReadOnly {
-		db.txmu.Lock()
+		db.writemu.Lock()
 	} else {
 		db.txmu.RLock()
 	}
 
-	db.attachedTxMu.Lock()
-	defer db.attachedTxMu.Unlock()
+	tx, err := db.beginTx(ctx, opts)
+	if err != nil {
+		if !opts.ReadOnly {
+			db.writemu.Unlock()
+		} else {
+			db.txmu.RUnlock()
+		}
+		return nil, err
+	}
 
-	if db.attachedTransaction != nil {
-		return nil, errors.New("cannot open a transaction within a transaction")
+	return tx, nil
+}
+
+// beginOptimisticTx starts an optimistic transaction. Like read-only transactions,
+// it read-locks the database until it commits, so that the schema isn't modified
+// while it runs.
+func (db *Database) beginOptimisticTx(ctx context.Context, opts *TxOptions) (*Transaction, error) {
+	if opts.ReadOnly {
+		return nil, errors.New("optimistic transactions cannot be read-only")
+	}
+
+	db.txmu.RLock()
+	modifications := catalogModifications(db.Catalog)
+
+	ropts := *opts
+	ropts.ReadOnly = true
+	ropts.Session = nil
+	tx, err := db.beginTx(ctx, &ropts)
+	if err != nil {
+		db.txmu.RUnlock()
+		return nil, err
+	}
+
+	otx := optimisticTx{
+		Transaction:          tx.Tx,
+		ctx:                  ctx,
+		db:                   db,
+		writes:               &tx.writes,
+		catalogModifications: modifications,
+		stores:               make(map[string]*optimisticStore),
+		readLocked:           true,
+	}
+	tx.Tx = &otx
+	tx.optimistic = &otx
+	db.setupWritableTx(tx)
+
+	if opts.Session != nil {
+		db.attachTx(tx, opts.Session)
 	}
 
-	return db.beginTx(ctx, opts)
+	return tx, nil
 }
 
 // beginTx creates a transaction without locks.
@@ -170,6 +289,13 @@ func (db *Database) beginTx(ctx context.Context, opts *TxOptions) (*Transaction,
 		opts = &TxOptions{}
 	}
 
+	// the snapshots of the engine and of the catalog
+	// must be taken between the same commits
+	if opts.ReadOnly {
+		db.catalogmu.RLock()
+		defer db.catalogmu.RUnlock()
+	}
+
 	ntx, err := db.ng.Begin(ctx, engine.TxOptions{
 		Writable: !opts.ReadOnly,
 	})
@@ -178,26 +304,99 @@ func (db *Database) beginTx(ctx context.Context, opts *TxOptions) (*Transaction,
 	}
 
 	tx := Transaction{
-		Tx:       ntx,
-		Writable: !opts.ReadOnly,
-		DBMu:     db.txmu,
-		Codec:    db.Codec,
+		Tx:        ntx,
+		Writable:  !opts.ReadOnly,
+		DBMu:      db.txmu,
+		writeMu:   db.writemu,
+		catalogMu: db.catalogmu,
+		Codec:     db.Codec,
+		Ctx:       ctx,
+		StartTime: time.Now(),
+	}
+	if opts.Timeout > 0 {
+		tx.Deadline = tx.StartTime.Add(opts.Timeout)
 	}
 
-	if opts.Attached {
-		db.attachedTransaction = &tx
-		tx.OnRollbackHooks = append(tx.OnRollbackHooks, db.releaseAttachedTx)
-		tx.OnCommitHooks = append(tx.OnCommitHooks, db.releaseAttachedTx)
+	if opts.ReadOnly {
+		tx.CatalogSnapshot = catalogSnapshot(db.Catalog)
+	}
+
+	if tx.Writable {
+		db.setupWritableTx(&tx)
+
+		if db.Changes.replicated() {
+			tx.Tx = &recordingTx{Transaction: ntx, writes: &tx.writes}
+		}
+	}
+
+	if opts.Session != nil {
+		db.attachTx(&tx, opts.Session)
 	}
 
 	return &tx, nil
 }
 
-func (db *Database) releaseAttachedTx() {
+// setupWritableTx adds the hooks of the read/write transactions to tx.
+func (db *Database) setupWritableTx(tx *Transaction) {
+	tx.Writable = true
+	tx.OnBeforeCommitHooks = append(tx.OnBeforeCommitHooks, func() error {
+		return writeChangeLog(tx, db.Catalog)
+	}, func() error {
+		return RefreshStatistics(tx, db.Catalog)
+	})
+	tx.OnCommitHooks = append(tx.OnCommitHooks, func() {
+		db.incrementTableVersions(tx.modifications)
+		if len(tx.changes) > 0 || len(tx.writes) > 0 {
+			db.Changes.enqueue(tx.changes, tx.writes)
+		}
+	})
+	tx.OnAfterCommitHooks = append(tx.OnAfterCommitHooks, db.Changes.deliver)
+	tx.changeFeed = db.Changes
+}
+
+// TableVersion returns the number of committed transactions that modified the given table.
+// It can be used to detect that a table was modified since it was last read.
+func (db *Database) TableVersion(tableName string) uint64 {
+	db.tableVersionsMu.RLock()
+	defer db.tableVersionsMu.RUnlock()
+
+	return db.tableVersions[tableName]
+}
+
+func (db *Database) incrementTableVersions(modifications map[string]int64) {
+	if len(modifications) == 0 {
+		return
+	}
+
+	db.tableVersionsMu.Lock()
+	defer db.tableVersionsMu.Unlock()
+
+	if db.tableVersions == nil {
+		db.tableVersions = make(map[string]uint64)
+	}
+	for tableName := range modifications {
+		db.tableVersions[tableName]++
+	}
+}
+
+// attachTx attaches tx to the session until it is rolled back or commited.
+func (db *Database) attachTx(tx *Transaction, session *Session) {
 	db.attachedTxMu.Lock()
-	defer db.attachedTxMu.Unlock()
+	if db.attachedTxs == nil {
+		db.attachedTxs = make(map[*Transaction]struct{})
+	}
+	db.attachedTxs[tx] = struct{}{}
+	db.attachedTxMu.Unlock()
+
+	session.attachTx(tx)
+
+	release := func() {
+		session.detachTx(tx)
 
-	if db.attachedTransaction != nil {
-		db.attachedTransaction = nil
+		db.attachedTxMu.Lock()
+		delete(db.attachedTxs, tx)
+		db.attachedTxMu.Unlock()
 	}
+	tx.OnRollbackHooks = append(tx.OnRollbackHooks, release)
+	tx.OnCommitHooks = append(tx.OnCommitHooks, release)
 }
@@ -5,6 +5,7 @@ import (
 	"time"
 
 	"github.com/genjidb/genji"
+	"github.com/genjidb/genji/document"
 	"github.com/stretchr/testify/require"
 )
 
@@ -67,3 +68,99 @@ func TestConcurrentTransactionManagement(t *testing.T) {
 		t.Fatal("deadlock")
 	}
 }
+
+func TestSnapshotIsolation(t *testing.T) {
+	db, err := genji.Open(":memory:")
+	require.NoError(t, err)
+	defer db.Close()
+
+	err = db.Exec("CREATE TABLE test(a INT); INSERT INTO test (a) VALUES (1)")
+	require.NoError(t, err)
+
+	count := func(q interface {
+		QueryDocument(string, ...interface{}) (document.Document, error)
+	}) int {
+		var n int
+		d, err := q.QueryDocument("SELECT COUNT(*) FROM test")
+		require.NoError(t, err)
+		require.NoError(t, document.Scan(d, &n))
+		return n
+	}
+
+	// exec runs q in a new goroutine and returns a channel closed once it's done
+	exec := func(q string) chan struct{} {
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+			require.NoError(t, db.Exec(q))
+		}()
+		return done
+	}
+
+	tx, err := db.Begin(false)
+	require.NoError(t, err)
+	defer tx.Rollback()
+	require.Equal(t, 1, count(tx))
+
+	// writers don't wait for readers
+	select {
+	case <-exec("INSERT INTO test (a) VALUES (2)"):
+	case <-time.After(time.Second):
+		t.Fatal("the writer is blocked by the reader")
+	}
+
+	// which keep reading the database as it was when they started
+	require.Equal(t, 1, count(tx))
+	require.Equal(t, 2, count(db))
+	require.NoError(t, tx.Rollback())
+
+	// neither do writers modifying the schema
+	tx, err = db.Begin(false)
+	require.NoError(t, err)
+	defer tx.Rollback()
+
+	select {
+	case <-exec("CREATE TABLE foo(a INT); DROP TABLE test"):
+	case <-time.After(time.Second):
+		t.Fatal("the writer modifying the schema is blocked by the reader")
+	}
+
+	// and readers keep reading the schema as it was when they started
+	require.Equal(t, 2, count(tx))
+	_, err = tx.QueryDocument("SELECT * FROM foo")
+	require.Error(t, err)
+	require.NoError(t, tx.Rollback())
+
+	_, err = db.QueryDocument("SELECT * FROM test")
+	require.Error(t, err)
+
+	// readers don't wait for the writers modifying the schema,
+	// and don't see their uncommitted modifications
+	wtx, err := db.Begin(true)
+	require.NoError(t, err)
+	defer wtx.Rollback()
+	err = wtx.Exec("CREATE TABLE bar(a INT); INSERT INTO bar (a) VALUES (1)")
+	require.NoError(t, err)
+
+	started := make(chan struct{})
+	go func() {
+		defer close(started)
+
+		tx, err := db.Begin(false)
+		require.NoError(t, err)
+		defer tx.Rollback()
+
+		_, err = tx.QueryDocument("SELECT * FROM bar")
+		require.Error(t, err)
+	}()
+
+	select {
+	case <-started:
+	case <-time.After(time.Second):
+		t.Fatal("the reader is blocked by the writer modifying the schema")
+	}
+
+	require.NoError(t, wtx.Commit())
+	_, err = db.QueryDocument("SELECT * FROM bar")
+	require.NoError(t, err)
+}
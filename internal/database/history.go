@@ -0,0 +1,168 @@
+package database
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"sort"
+	"time"
+
+	"github.com/genjidb/genji/document"
+	errs "github.com/genjidb/genji/errors"
+	"github.com/genjidb/genji/internal/stringutil"
+)
+
+// errHistoryDone stops the iteration over the change log once the changes
+// made after the requested time are all read.
+var errHistoryDone = errors.New("history done")
+
+// IterateAsOf iterates over the documents of the table as they were at the given time,
+// in key order, or in reverse order if reverse is true.
+// Previous versions of the documents are read from the change log, which requires the
+// version_retention pragma to be set, and at to be within the retention window.
+// Changes made before the pragma was set are not known, and documents they modified
+// are returned as they are now.
+func (t *Table) IterateAsOf(ctx context.Context, at time.Time, reverse bool, fn func(d document.Document) error) error {
+	retention, err := VersionRetention(t.Tx, t.Catalog)
+	if err != nil {
+		return err
+	}
+	if retention == 0 {
+		return stringutil.Errorf("AS OF requires the version_retention pragma to be set")
+	}
+	if at.Before(time.Now().Add(-retention)) {
+		return stringutil.Errorf("AS OF %s is older than the version retention of %s", at.Format(time.RFC3339Nano), retention)
+	}
+
+	versions, err := t.versionsAsOf(ctx, at)
+	if err != nil {
+		return err
+	}
+
+	keys := make([]string, 0, len(versions))
+	for k := range versions {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	if reverse {
+		sort.Sort(sort.Reverse(sort.StringSlice(keys)))
+	}
+
+	// before reports whether the key a comes before b in the order of the iteration
+	before := func(a, b []byte) bool {
+		if reverse {
+			return bytes.Compare(a, b) > 0
+		}
+		return bytes.Compare(a, b) < 0
+	}
+
+	// previous versions of the documents replace the current ones,
+	// and documents that didn't exist yet are skipped
+	emit := func(key string) error {
+		if d := versions[key]; d != nil {
+			return fn(d)
+		}
+		return nil
+	}
+
+	iterator := t.AscendGreaterOrEqual
+	if reverse {
+		iterator = t.DescendLessOrEqual
+	}
+
+	err = iterator(ctx, document.Value{}, func(d document.Document) error {
+		key := d.(document.Keyer).RawKey()
+		for len(keys) > 0 && before([]byte(keys[0]), key) {
+			if err := emit(keys[0]); err != nil {
+				return err
+			}
+			keys = keys[1:]
+		}
+
+		if len(keys) > 0 && keys[0] == string(key) {
+			k := keys[0]
+			keys = keys[1:]
+			return emit(k)
+		}
+
+		return fn(d)
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, k := range keys {
+		if err := emit(k); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// versionsAsOf returns the version of the documents of the table modified after the given time,
+// indexed by key, as they were at that time. Documents that didn't exist are mapped to nil.
+func (t *Table) versionsAsOf(ctx context.Context, at time.Time) (map[string]document.Document, error) {
+	versions := make(map[string]document.Document)
+
+	changes, err := t.Catalog.GetTable(t.Tx, ChangesTableName)
+	if errs.IsNotFoundError(err) {
+		return versions, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	pk := t.Info.FieldConstraints.GetPrimaryKey()
+
+	// the change log is read from the most recent change, and the oldest change
+	// made to a document after the given time holds its version at that time
+	err = changes.DescendLessOrEqual(ctx, document.Value{}, func(d document.Document) error {
+		ts, err := d.GetByField("ts")
+		if err != nil && err != document.ErrFieldNotFound {
+			return err
+		}
+		if err == document.ErrFieldNotFound || ts.Type != document.TimestampValue || !ts.V.(time.Time).After(at) {
+			return errHistoryDone
+		}
+
+		tableName, err := d.GetByField("table_name")
+		if err != nil {
+			return err
+		}
+		if tableName.V.(string) != t.Info.TableName {
+			return nil
+		}
+
+		v, err := d.GetByField("pk")
+		if err != nil {
+			return err
+		}
+		key, err := t.EncodeValue(v)
+		if err != nil {
+			return err
+		}
+
+		old, err := d.GetByField("old")
+		if err == document.ErrFieldNotFound || (err == nil && old.Type == document.NullValue) {
+			versions[string(key)] = nil
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		// the change log isn't typed, the values are converted back to the types of the table
+		fb, err := t.Info.FieldConstraints.convertDocumentAtPath(nil, old.V.(document.Document), UntypedConversion)
+		if err != nil {
+			return err
+		}
+		versions[string(key)] = documentWithKey{Document: fb, key: key, pk: pk}
+		return nil
+	})
+	if err != nil && err != errHistoryDone {
+		return nil, err
+	}
+
+	return versions, nil
+}
@@ -2,12 +2,15 @@ package database
 
 import (
 	"bytes"
+	"context"
 	"encoding/binary"
 	"errors"
 	"io"
+	"sort"
 
 	"github.com/genjidb/genji/document"
 	"github.com/genjidb/genji/engine"
+	"github.com/genjidb/genji/internal/binarysort"
 	"github.com/genjidb/genji/internal/stringutil"
 )
 
@@ -169,6 +172,14 @@ func (idx *Index) Set(vs []document.Value, k []byte) error {
 		if ok {
 			return ErrIndexDuplicateValue
 		}
+
+		// optimistic transactions ensure the value is still unique when they commit
+		if ost, ok := st.(*optimisticStore); ok {
+			err = ost.trackPrefix(storeKey)
+			if err != nil {
+				return err
+			}
+		}
 	}
 
 	// we append the pk at the end of the encoded value
@@ -181,6 +192,45 @@ func (idx *Index) Set(vs []document.Value, k []byte) error {
 	return st.Put(storeKey, storeValue)
 }
 
+// Entries returns the lists of values stored in the index for a document,
+// given the values of the document at the paths of the index.
+// Multi-key indexes have one entry per distinct element of the array found at their
+// multi-key path, and none if there is no array at that path.
+// Other indexes have exactly one entry, made of the given values.
+func (idx *Index) Entries(vs []document.Value) ([][]document.Value, error) {
+	pos := idx.Info.MultiKeyPosition()
+	if pos < 0 {
+		return [][]document.Value{vs}, nil
+	}
+
+	if vs[pos].Type != document.ArrayValue {
+		return nil, nil
+	}
+
+	var entries [][]document.Value
+	seen := make(map[string]struct{})
+	err := vs[pos].V.(document.Array).Iterate(func(_ int, v document.Value) error {
+		entry := make([]document.Value, len(vs))
+		copy(entry, vs)
+		entry[pos] = v
+
+		// elements present more than once in the array share the same entry
+		enc, err := idx.EncodeValueBuffer(document.NewValueBuffer(entry...))
+		if err != nil {
+			return err
+		}
+		if _, ok := seen[string(enc)]; ok {
+			return nil
+		}
+		seen[string(enc)] = struct{}{}
+
+		entries = append(entries, entry)
+		return nil
+	})
+
+	return entries, err
+}
+
 func (idx *Index) Exists(vs []document.Value) (bool, []byte, error) {
 	if len(vs) != idx.Arity() {
 		return false, nil, stringutil.Errorf("required arity of %d", len(idx.Info.Types))
@@ -233,7 +283,7 @@ func (idx *Index) Delete(vs []document.Value, k []byte) error {
 	}
 
 	var buf []byte
-	err = idx.iterate(st, vs, false, func(item engine.Item) error {
+	err = idx.iterate(context.Background(), st, vs, false, func(item engine.Item) error {
 		buf, err = item.ValueCopy(buf)
 		if err != nil {
 			return err
@@ -260,6 +310,12 @@ func (idx *Index) Delete(vs []document.Value, k []byte) error {
 		return err
 	}
 
+	// documents written before an index started being built
+	// may not have been indexed yet
+	if idx.Info.Building {
+		return nil
+	}
+
 	return engine.ErrKeyNotFound
 }
 
@@ -303,6 +359,7 @@ func (pivot Pivot) IsAny() bool {
 // AscendGreaterOrEqual seeks for the pivot and then goes through all the subsequent key value pairs in increasing order and calls the given function for each pair.
 // If the given function returns an error, the iteration stops and returns that error.
 // If the pivot(s) is/are empty, starts from the beginning.
+// The iteration stops with the error of ctx as soon as it is canceled.
 //
 // Valid pivots are:
 // - zero value pivot
@@ -316,13 +373,14 @@ func (pivot Pivot) IsAny() bool {
 // - a single element with a type but nil value: will iterate on everything of that type
 //
 // Any other variation of a pivot are invalid and will panic.
-func (idx *Index) AscendGreaterOrEqual(pivot Pivot, fn func(val, key []byte) error) error {
-	return idx.iterateOnStore(pivot, false, fn)
+func (idx *Index) AscendGreaterOrEqual(ctx context.Context, pivot Pivot, fn func(val, key []byte) error) error {
+	return idx.iterateOnStore(ctx, pivot, false, fn)
 }
 
 // DescendLessOrEqual seeks for the pivot and then goes through all the subsequent key value pairs in descreasing order and calls the given function for each pair.
 // If the given function returns an error, the iteration stops and returns that error.
 // If the pivot(s) is/are empty, starts from the end.
+// The iteration stops with the error of ctx as soon as it is canceled.
 //
 // Valid pivots are:
 // - zero value pivot
@@ -336,11 +394,86 @@ func (idx *Index) AscendGreaterOrEqual(pivot Pivot, fn func(val, key []byte) err
 // - a single element with a type but nil value: will iterate on everything of that type
 //
 // Any other variation of a pivot are invalid and will panic.
-func (idx *Index) DescendLessOrEqual(pivot Pivot, fn func(val, key []byte) error) error {
-	return idx.iterateOnStore(pivot, true, fn)
+func (idx *Index) DescendLessOrEqual(ctx context.Context, pivot Pivot, fn func(val, key []byte) error) error {
+	return idx.iterateOnStore(ctx, pivot, true, fn)
+}
+
+// Nearest returns the keys associated with the k vectors that are the closest to target,
+// ordered from the closest to the farthest, according to the given distance function.
+// It only operates on indexes of arity 1. Indexed values that are not vectors, or vectors
+// whose dimension differs from the one of target, are ignored.
+//
+// The search is exhaustive: every vector of the index is compared with the target,
+// unless ctx is canceled.
+func (idx *Index) Nearest(ctx context.Context, target []float64, k int, dist func(a, b []float64) (float64, error)) ([][]byte, error) {
+	if idx.IsComposite() {
+		return nil, errors.New("cannot search nearest vectors on a composite index")
+	}
+
+	if idx.Info.Order(0) != (IndexOrder{}) {
+		return nil, errors.New("cannot search nearest vectors on an index with a custom order")
+	}
+
+	if k <= 0 {
+		return nil, nil
+	}
+
+	type candidate struct {
+		key  []byte
+		dist float64
+	}
+
+	var candidates []candidate
+	err := idx.AscendGreaterOrEqual(ctx, Pivot{document.Value{Type: document.VectorValue}}, func(val, key []byte) error {
+		// untyped indexes prepend the type to the value
+		if idx.Info.Types[0].IsAny() {
+			val = val[1:]
+		}
+
+		if len(val) != len(target)*8 {
+			return nil
+		}
+
+		vec := make([]float64, len(target))
+		for i := range vec {
+			x, err := binarysort.DecodeFloat64(val[i*8 : i*8+8])
+			if err != nil {
+				return err
+			}
+			vec[i] = x
+		}
+
+		d, err := dist(target, vec)
+		if err != nil {
+			return err
+		}
+
+		// keep the candidates sorted by distance and only retain the k closest
+		i := sort.Search(len(candidates), func(i int) bool { return candidates[i].dist > d })
+		if i >= k {
+			return nil
+		}
+		if len(candidates) < k {
+			candidates = append(candidates, candidate{})
+		}
+		copy(candidates[i+1:], candidates[i:])
+		candidates[i] = candidate{key: append([]byte{}, key...), dist: d}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	keys := make([][]byte, len(candidates))
+	for i := range candidates {
+		keys[i] = candidates[i].key
+	}
+
+	return keys, nil
 }
 
-func (idx *Index) iterateOnStore(pivot Pivot, reverse bool, fn func(val, key []byte) error) error {
+func (idx *Index) iterateOnStore(ctx context.Context, pivot Pivot, reverse bool, fn func(val, key []byte) error) error {
 	pivot.validate(idx)
 
 	// If index and pivot values are typed but not of the same type, return no results.
@@ -359,7 +492,7 @@ func (idx *Index) iterateOnStore(pivot Pivot, reverse bool, fn func(val, key []b
 	}
 
 	var buf []byte
-	return idx.iterate(st, pivot, reverse, func(item engine.Item) error {
+	return idx.iterate(ctx, st, pivot, reverse, func(item engine.Item) error {
 		var err error
 
 		record := item.Key()
@@ -403,12 +536,31 @@ func (idx *Index) EncodeValueBuffer(vb *document.ValueBuffer) ([]byte, error) {
 	var buf bytes.Buffer
 
 	err := vb.Iterate(func(i int, value document.Value) error {
+		o := idx.Info.Order(i)
+		if hasNullsMarker(o) {
+			buf.WriteByte(nullsMarker(value.Type, o))
+		}
+
+		start := buf.Len()
 		enc := &indexValueEncoder{typ: idx.Info.Types[i], w: &buf}
 		err := enc.EncodeValue(value)
 		if err != nil {
 			return err
 		}
 
+		// descending values are complemented to reverse their order and followed by
+		// a terminator, which sorts values after the longer values they prefix.
+		// Pivots with a type but no value are not terminated, to match all the values of their type.
+		if o.Desc {
+			b := buf.Bytes()[start:]
+			for j := range b {
+				b[j] = ^b[j]
+			}
+			if value.V != nil || value.Type == document.NullValue {
+				buf.WriteByte(descTerminator)
+			}
+		}
+
 		// if it's not the last value, append the seperator
 		if i < vb.Len()-1 {
 			err = buf.WriteByte(document.ArrayValueDelim)
@@ -427,6 +579,192 @@ func (idx *Index) EncodeValueBuffer(vb *document.ValueBuffer) ([]byte, error) {
 	return buf.Bytes(), nil
 }
 
+const (
+	// markers prepended to the values of paths whose NULL values are not stored
+	// at their default position
+	nullsFirstMarker byte = 0x00
+	valueMarker      byte = 0x01
+	nullsLastMarker  byte = 0x02
+
+	// descTerminator follows the values of descending paths
+	descTerminator byte = 0xFF
+)
+
+// hasNullsMarker returns true if the values stored with the given order are prefixed
+// with a marker. By default, NULL values, whose type is the smallest one, come
+// first in ascending order and last in descending order.
+func hasNullsMarker(o IndexOrder) bool {
+	return o.IsNullsLast() != o.Desc
+}
+
+// nullsMarker returns the marker prepended to values of type t.
+func nullsMarker(t document.ValueType, o IndexOrder) byte {
+	if t != document.NullValue {
+		return valueMarker
+	}
+
+	if o.IsNullsLast() {
+		return nullsLastMarker
+	}
+
+	return nullsFirstMarker
+}
+
+// typePrefix returns the bytes starting the values of type t stored at the first path of an untyped index.
+func (idx *Index) typePrefix(t document.ValueType) []byte {
+	var prefix []byte
+
+	o := idx.Info.Order(0)
+	if hasNullsMarker(o) {
+		prefix = append(prefix, nullsMarker(t, o))
+	}
+
+	if o.Desc {
+		return append(prefix, ^byte(t))
+	}
+
+	return append(prefix, byte(t))
+}
+
+// DecodeValues decodes the values of an index entry, as passed to the iteration functions.
+// It returns false if one of the values can't be decoded exactly, either because its encoding
+// loses information, like for decimals, or because its length can't be determined, like for
+// texts that are not the last value of the index.
+func (idx *Index) DecodeValues(val []byte) ([]document.Value, bool, error) {
+	values := make([]document.Value, 0, idx.Arity())
+
+	for i, typ := range idx.Info.Types {
+		o := idx.Info.Order(i)
+		if hasNullsMarker(o) {
+			if len(val) == 0 || val[0] != valueMarker {
+				return nil, false, nil
+			}
+			val = val[1:]
+		}
+
+		// untyped indexes prepend the type to the value
+		if typ.IsAny() {
+			if len(val) == 0 {
+				return nil, false, nil
+			}
+			typ = document.ValueType(val[0])
+			if o.Desc {
+				typ = ^typ
+			}
+			val = val[1:]
+		}
+
+		last := i == idx.Arity()-1
+		n := encodedValueLen(typ)
+		if n < 0 && last {
+			n = len(val)
+			if o.Desc {
+				n--
+			}
+		}
+		// typed indexes encode null values as empty values, which can't
+		// be distinguished from empty texts and blobs
+		if n <= 0 || n > len(val) {
+			return nil, false, nil
+		}
+
+		b := val[:n]
+		if o.Desc {
+			b = make([]byte, n)
+			for j := range b {
+				b[j] = ^val[j]
+			}
+		}
+
+		v, ok, err := decodeKeyValue(typ, b)
+		if err != nil || !ok {
+			return nil, ok, err
+		}
+		values = append(values, v)
+		val = val[n:]
+
+		if o.Desc {
+			if len(val) == 0 || val[0] != descTerminator {
+				return nil, false, nil
+			}
+			val = val[1:]
+		}
+
+		if !last {
+			if len(val) == 0 || val[0] != document.ArrayValueDelim {
+				return nil, false, nil
+			}
+			val = val[1:]
+		}
+	}
+
+	return values, true, nil
+}
+
+// encodedValueLen returns the length of the values of the given type encoded without
+// type information, or -1 if it depends on the value.
+func encodedValueLen(t document.ValueType) int {
+	switch t {
+	case document.BoolValue:
+		return 1
+	case document.IntegerValue, document.DoubleValue:
+		return 8
+	case document.TimestampValue, document.DateValue:
+		return 12
+	case document.UUIDValue:
+		return 16
+	}
+
+	return -1
+}
+
+// isDecodableKeyType returns true if values of type t can be decoded exactly
+// from their binary representation, as returned by document.Value.MarshalBinary.
+func isDecodableKeyType(t document.ValueType) bool {
+	switch t {
+	case document.BoolValue, document.IntegerValue, document.DoubleValue,
+		document.TimestampValue, document.DateValue, document.UUIDValue,
+		document.TextValue, document.BlobValue:
+		return true
+	}
+
+	return false
+}
+
+// decodeKeyValue decodes a value of type t encoded with document.Value.MarshalBinary.
+// It returns false if the encoding of the type loses information.
+func decodeKeyValue(t document.ValueType, buf []byte) (document.Value, bool, error) {
+	if !isDecodableKeyType(t) {
+		return document.Value{}, false, nil
+	}
+
+	switch t {
+	case document.BoolValue:
+		x, err := binarysort.DecodeBool(buf)
+		return document.NewBoolValue(x), err == nil, err
+	case document.IntegerValue:
+		x, err := binarysort.DecodeInt64(buf)
+		return document.NewIntegerValue(x), err == nil, err
+	case document.DoubleValue:
+		x, err := binarysort.DecodeFloat64(buf)
+		return document.NewDoubleValue(x), err == nil, err
+	case document.TimestampValue:
+		x, err := binarysort.DecodeTime(buf)
+		return document.NewTimestampValue(x), err == nil, err
+	case document.DateValue:
+		x, err := binarysort.DecodeTime(buf)
+		return document.NewDateValue(x), err == nil, err
+	case document.UUIDValue:
+		var u document.UUID
+		copy(u[:], buf)
+		return document.NewUUIDValue(u), true, nil
+	case document.TextValue:
+		return document.NewTextValue(string(buf)), true, nil
+	}
+
+	return document.NewBlobValue(append([]byte{}, buf...)), true, nil
+}
+
 func getOrCreateStore(tx engine.Transaction, name []byte) (engine.Store, error) {
 	st, err := tx.GetStore(name)
 	if err == nil {
@@ -458,7 +796,7 @@ func (idx *Index) buildSeek(pivot Pivot, reverse bool) ([]byte, error) {
 	// if the index is without type and the first pivot is valueless but typed, iterate but filter out the types we don't want,
 	// but just for the first pivot; subsequent pivot values cannot be filtered this way.
 	if idx.Info.Types[0].IsAny() && !pivot[0].Type.IsAny() && pivot[0].V == nil {
-		seek = []byte{byte(pivot[0].Type)}
+		seek = idx.typePrefix(pivot[0].Type)
 
 		if reverse {
 			seek = append(seek, 0xFF)
@@ -481,7 +819,7 @@ func (idx *Index) buildSeek(pivot Pivot, reverse bool) ([]byte, error) {
 	return seek, nil
 }
 
-func (idx *Index) iterate(st engine.Store, pivot Pivot, reverse bool, fn func(item engine.Item) error) error {
+func (idx *Index) iterate(ctx context.Context, st engine.Store, pivot Pivot, reverse bool, fn func(item engine.Item) error) error {
 	var err error
 
 	seek, err := idx.buildSeek(pivot, reverse)
@@ -489,14 +827,26 @@ func (idx *Index) iterate(st engine.Store, pivot Pivot, reverse bool, fn func(it
 		return err
 	}
 
+	// If index is untyped and pivot first element is typed, only iterate on values with the same type as the first pivot
+	var prefix []byte
+	if len(pivot) > 0 && idx.Info.Types[0].IsAny() && !pivot[0].Type.IsAny() {
+		prefix = idx.typePrefix(pivot[0].Type)
+	}
+
 	it := st.Iterator(engine.IteratorOptions{Reverse: reverse})
 	defer it.Close()
 
+	done := ctx.Done()
 	for it.Seek(seek); it.Valid(); it.Next() {
+		select {
+		case <-done:
+			return ctx.Err()
+		default:
+		}
+
 		itm := it.Item()
 
-		// If index is untyped and pivot first element is typed, only iterate on values with the same type as the first pivot
-		if len(pivot) > 0 && idx.Info.Types[0].IsAny() && !pivot[0].Type.IsAny() && itm.Key()[0] != byte(pivot[0].Type) {
+		if prefix != nil && !bytes.HasPrefix(itm.Key(), prefix) {
 			return nil
 		}
 
@@ -127,7 +127,7 @@ func TestIndexDelete(t *testing.T) {
 
 		pivot := values(document.NewIntegerValue(10))
 		i := 0
-		err := idx.AscendGreaterOrEqual(pivot, func(v, k []byte) error {
+		err := idx.AscendGreaterOrEqual(context.Background(), pivot, func(v, k []byte) error {
 			if i == 0 {
 				requireEqualBinary(t, testutil.MakeArrayValue(t, 10), v)
 				require.Equal(t, "other-key", string(k))
@@ -157,7 +157,7 @@ func TestIndexDelete(t *testing.T) {
 
 		pivot := values(document.NewIntegerValue(10), document.NewIntegerValue(10))
 		i := 0
-		err := idx.AscendGreaterOrEqual(pivot, func(v, k []byte) error {
+		err := idx.AscendGreaterOrEqual(context.Background(), pivot, func(v, k []byte) error {
 			if i == 0 {
 				expected := document.NewArrayValue(document.NewValueBuffer(
 					document.NewIntegerValue(10),
@@ -193,7 +193,7 @@ func TestIndexDelete(t *testing.T) {
 		require.NoError(t, idx.Delete(values(document.NewDoubleValue(11)), []byte("key2")))
 
 		i := 0
-		err := idx.AscendGreaterOrEqual(values(document.Value{Type: document.IntegerValue}), func(v, k []byte) error {
+		err := idx.AscendGreaterOrEqual(context.Background(), values(document.Value{Type: document.IntegerValue}), func(v, k []byte) error {
 			switch i {
 			case 0:
 				requireEqualBinary(t, testutil.MakeArrayValue(t, 10), v)
@@ -225,7 +225,7 @@ func TestIndexDelete(t *testing.T) {
 		// this will break until the [v, int] case is supported
 		// pivot := values(document.NewIntegerValue(0), document.Value{Type: document.IntegerValue})
 		pivot := values(document.NewIntegerValue(0), document.NewIntegerValue(0))
-		err := idx.AscendGreaterOrEqual(pivot, func(v, k []byte) error {
+		err := idx.AscendGreaterOrEqual(context.Background(), pivot, func(v, k []byte) error {
 			switch i {
 			case 0:
 				expected := document.NewArrayValue(document.NewValueBuffer(
@@ -331,7 +331,7 @@ func TestIndexAscendGreaterThan(t *testing.T) {
 			defer cleanup()
 
 			i := 0
-			err := idx.AscendGreaterOrEqual(values(document.Value{Type: document.IntegerValue}), func(val, key []byte) error {
+			err := idx.AscendGreaterOrEqual(context.Background(), values(document.Value{Type: document.IntegerValue}), func(val, key []byte) error {
 				i++
 				return errors.New("should not iterate")
 			})
@@ -339,6 +339,29 @@ func TestIndexAscendGreaterThan(t *testing.T) {
 			require.Equal(t, 0, i)
 		})
 
+		t.Run(text+"Should stop if ctx is canceled", func(t *testing.T) {
+			idx, cleanup := getIndex(t, unique)
+			defer cleanup()
+
+			for i := 0; i < 10; i++ {
+				require.NoError(t, idx.Set(values(document.NewIntegerValue(int64(i))), []byte{'a' + byte(i)}))
+			}
+
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			i := 0
+			err := idx.AscendGreaterOrEqual(ctx, nil, func(val, key []byte) error {
+				i++
+				if i == 3 {
+					cancel()
+				}
+				return nil
+			})
+			require.Equal(t, context.Canceled, err)
+			require.Equal(t, 3, i)
+		})
+
 		t.Run(text+"Should iterate through documents in order, ", func(t *testing.T) {
 			noiseBlob := func(i int) []document.Value {
 				t.Helper()
@@ -989,7 +1012,7 @@ func TestIndexAscendGreaterThan(t *testing.T) {
 					var i uint8
 					var count int
 					fn := func() error {
-						return idx.AscendGreaterOrEqual(test.pivot, func(val, rid []byte) error {
+						return idx.AscendGreaterOrEqual(context.Background(), test.pivot, func(val, rid []byte) error {
 							test.expectedEq(t, i, rid, val)
 							i++
 							count++
@@ -1023,7 +1046,7 @@ func TestIndexDescendLessOrEqual(t *testing.T) {
 			defer cleanup()
 
 			i := 0
-			err := idx.AscendGreaterOrEqual(values(document.Value{Type: document.IntegerValue}), func(val, key []byte) error {
+			err := idx.AscendGreaterOrEqual(context.Background(), values(document.Value{Type: document.IntegerValue}), func(val, key []byte) error {
 				i++
 				return errors.New("should not iterate")
 			})
@@ -1720,7 +1743,7 @@ func TestIndexDescendLessOrEqual(t *testing.T) {
 
 					fn := func() error {
 						t.Helper()
-						return idx.DescendLessOrEqual(test.pivot, func(val, rid []byte) error {
+						return idx.DescendLessOrEqual(context.Background(), test.pivot, func(val, rid []byte) error {
 							test.expectedEq(t, uint8(total-1)-i, rid, val)
 							i++
 							count++
@@ -1782,7 +1805,7 @@ func BenchmarkIndexIteration(b *testing.B) {
 
 			b.ResetTimer()
 			for i := 0; i < b.N; i++ {
-				_ = idx.AscendGreaterOrEqual(values(document.Value{Type: document.TextValue}), func(_, _ []byte) error {
+				_ = idx.AscendGreaterOrEqual(context.Background(), values(document.Value{Type: document.TextValue}), func(_, _ []byte) error {
 					return nil
 				})
 			}
@@ -1826,7 +1849,7 @@ func BenchmarkCompositeIndexIteration(b *testing.B) {
 
 			b.ResetTimer()
 			for i := 0; i < b.N; i++ {
-				_ = idx.AscendGreaterOrEqual(values(document.NewTextValue(""), document.NewTextValue("")), func(_, _ []byte) error {
+				_ = idx.AscendGreaterOrEqual(context.Background(), values(document.NewTextValue(""), document.NewTextValue("")), func(_, _ []byte) error {
 					return nil
 				})
 			}
@@ -1834,3 +1857,108 @@ func BenchmarkCompositeIndexIteration(b *testing.B) {
 		})
 	}
 }
+
+func TestIndexNearest(t *testing.T) {
+	vectors := [][]float64{
+		{0, 0},
+		{1, 1},
+		{5, 5},
+		{2, 2},
+	}
+
+	for _, typ := range []document.ValueType{document.AnyType, document.VectorValue} {
+		t.Run("Type: "+typ.String(), func(t *testing.T) {
+			idx, cleanup := getIndex(t, false, typ)
+			defer cleanup()
+
+			for i, v := range vectors {
+				require.NoError(t, idx.Set(values(document.NewVectorValue(v)), []byte{'a' + byte(i)}))
+			}
+			if typ.IsAny() {
+				// non-vector values and vectors of a different dimension are ignored
+				require.NoError(t, idx.Set(values(document.NewIntegerValue(10)), []byte("z")))
+				require.NoError(t, idx.Set(values(document.NewVectorValue([]float64{1, 1, 1})), []byte("y")))
+			}
+
+			keys, err := idx.Nearest(context.Background(), []float64{1.2, 1.2}, 3, document.EuclideanDistance)
+			require.NoError(t, err)
+			require.Equal(t, [][]byte{[]byte("b"), []byte("d"), []byte("a")}, keys)
+
+			keys, err = idx.Nearest(context.Background(), []float64{1.2, 1.2}, 10, document.EuclideanDistance)
+			require.NoError(t, err)
+			require.Len(t, keys, 4)
+
+			keys, err = idx.Nearest(context.Background(), []float64{1.2, 1.2}, 0, document.EuclideanDistance)
+			require.NoError(t, err)
+			require.Empty(t, keys)
+		})
+	}
+
+	t.Run("Composite index fails", func(t *testing.T) {
+		idx, cleanup := getIndex(t, false, document.AnyType, document.AnyType)
+		defer cleanup()
+
+		_, err := idx.Nearest(context.Background(), []float64{1, 1}, 1, document.EuclideanDistance)
+		require.Error(t, err)
+	})
+}
+
+func TestIndexOrders(t *testing.T) {
+	tests := []struct {
+		name     string
+		typ      document.ValueType
+		order    database.IndexOrder
+		values   []document.Value
+		expected []document.Value
+	}{
+		{"Integers DESC", document.IntegerValue, database.IndexOrder{Desc: true},
+			values(document.NewIntegerValue(1), document.NewIntegerValue(3), document.NewIntegerValue(-2)),
+			values(document.NewIntegerValue(3), document.NewIntegerValue(1), document.NewIntegerValue(-2)),
+		},
+		{"Texts DESC", document.TextValue, database.IndexOrder{Desc: true},
+			values(document.NewTextValue("a"), document.NewTextValue("b"), document.NewTextValue("ab")),
+			values(document.NewTextValue("b"), document.NewTextValue("ab"), document.NewTextValue("a")),
+		},
+		{"Untyped DESC", document.AnyType, database.IndexOrder{Desc: true},
+			values(document.NewNullValue(), document.NewTextValue("a"), document.NewIntegerValue(1)),
+			values(document.NewTextValue("a"), document.NewIntegerValue(1), document.NewNullValue()),
+		},
+		{"Untyped NULLS LAST", document.AnyType, database.IndexOrder{NullsLast: true},
+			values(document.NewNullValue(), document.NewTextValue("a"), document.NewIntegerValue(1)),
+			values(document.NewIntegerValue(1), document.NewTextValue("a"), document.NewNullValue()),
+		},
+		{"Untyped DESC NULLS FIRST", document.AnyType, database.IndexOrder{Desc: true, NullsFirst: true},
+			values(document.NewTextValue("a"), document.NewIntegerValue(1), document.NewNullValue()),
+			values(document.NewNullValue(), document.NewTextValue("a"), document.NewIntegerValue(1)),
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			idx, cleanup := getIndex(t, false, test.typ)
+			defer cleanup()
+			idx.Info.Orders = []database.IndexOrder{test.order}
+
+			for i, v := range test.values {
+				require.NoError(t, idx.Set(values(v), []byte{'a' + byte(i)}))
+			}
+
+			var got []document.Value
+			err := idx.AscendGreaterOrEqual(context.Background(), nil, func(val, key []byte) error {
+				vs, ok, err := idx.DecodeValues(val)
+				if err != nil {
+					return err
+				}
+
+				// null values can't be decoded
+				if !ok {
+					vs = values(document.NewNullValue())
+				}
+				got = append(got, vs[0])
+				return nil
+			})
+			require.NoError(t, err)
+			require.Equal(t, test.expected, got)
+		})
+	}
+}
@@ -3,6 +3,7 @@ package database
 import (
 	"math"
 	"strings"
+	"unicode/utf8"
 
 	"github.com/genjidb/genji/document"
 	"github.com/genjidb/genji/internal/stringutil"
@@ -18,8 +19,70 @@ type TableInfo struct {
 
 	FieldConstraints FieldConstraints
 
+	// Unique constraints over one or more paths, defined
+	// at the table level, i.e. UNIQUE (a, b).
+	UniqueConstraints []*UniqueConstraint
+
 	// Name of the docid sequence if any.
 	DocidSequenceName string
+
+	// If set to true, text values exceeding the maximum length
+	// of their field are truncated instead of being rejected.
+	TruncateText bool
+
+	// Determines how values whose type doesn't match
+	// the type of their field are handled.
+	ConversionPolicy ConversionPolicy
+
+	// Path of the TIMESTAMP field holding the expiration time of the documents, if any.
+	// Expired documents are invisible to statements and get deleted in the background.
+	TTLField document.Path
+
+	// If set to true, DELETE statements write tombstones hiding the documents
+	// instead of removing them, until they are purged.
+	SoftDelete bool
+}
+
+// ConversionPolicy determines how a table handles values whose type
+// doesn't match the type of their field constraint.
+type ConversionPolicy uint8
+
+const (
+	// ConvertPolicy casts the values to the type of the field,
+	// and rejects them if the cast is not possible.
+	ConvertPolicy ConversionPolicy = iota
+	// RejectPolicy rejects any value whose type differs
+	// from the type of the field.
+	RejectPolicy
+	// UntypedPolicy casts the values to the type of the field,
+	// and stores them as is if the cast is not possible.
+	UntypedPolicy
+)
+
+// String returns the name of the policy, as used in the WITH clause of CREATE TABLE.
+func (p ConversionPolicy) String() string {
+	switch p {
+	case ConvertPolicy:
+		return "convert"
+	case RejectPolicy:
+		return "reject"
+	case UntypedPolicy:
+		return "untyped"
+	}
+
+	panic(stringutil.Sprintf("unknown conversion policy %d", p))
+}
+
+// ConversionFunc returns the function used to convert values according to the policy.
+func (p ConversionPolicy) ConversionFunc() ConversionFunc {
+	switch p {
+	case RejectPolicy:
+		return StrictConversion
+	case UntypedPolicy:
+		return UntypedConversion
+	}
+
+	return CastConversion
 }
 
 func (ti *TableInfo) Type() string {
@@ -43,7 +106,7 @@ func (ti *TableInfo) String() string {
 	var s strings.Builder
 
 	stringutil.Fprintf(&s, "CREATE TABLE %s", stringutil.NormalizeIdentifier(ti.TableName, '`'))
-	if len(ti.FieldConstraints) > 0 {
+	if len(ti.FieldConstraints) > 0 || len(ti.UniqueConstraints) > 0 {
 		s.WriteString(" (")
 	}
 
@@ -59,21 +122,159 @@ func (ti *TableInfo) String() string {
 		s.WriteString(fc.String())
 	}
 
-	if len(ti.FieldConstraints) > 0 {
+	for i, uc := range ti.UniqueConstraints {
+		if i > 0 || len(ti.FieldConstraints) > 0 {
+			s.WriteString(", ")
+		}
+
+		s.WriteString(uc.String())
+	}
+
+	if len(ti.FieldConstraints) > 0 || len(ti.UniqueConstraints) > 0 {
 		s.WriteString(")")
 	}
 
+	var opts []string
+	if ti.TruncateText {
+		opts = append(opts, "text_overflow = truncate")
+	}
+	if ti.ConversionPolicy != ConvertPolicy {
+		opts = append(opts, "type_conversion = "+ti.ConversionPolicy.String())
+	}
+	if ti.TTLField != nil {
+		opts = append(opts, "ttl_field = "+document.NewTextValue(ti.TTLField.String()).String())
+	}
+	if ti.SoftDelete {
+		opts = append(opts, "delete_mode = soft")
+	}
+	if len(opts) > 0 {
+		s.WriteString(" WITH ")
+		s.WriteString(strings.Join(opts, ", "))
+	}
+
 	return s.String()
 }
 
+// ValidateDocument validates the document against the field constraints of the table,
+// converting values according to the conversion policy, and enforces the maximum length of text fields.
+func (ti *TableInfo) ValidateDocument(tx *Transaction, d document.Document) (*document.FieldBuffer, error) {
+	fb, err := ti.FieldConstraints.ValidateDocument(tx, d, ti.ConversionPolicy.ConversionFunc())
+	if err != nil {
+		return nil, err
+	}
+
+	for _, fc := range ti.FieldConstraints {
+		if fc.MaxLength == 0 {
+			continue
+		}
+
+		v, err := fc.Path.GetValueFromDocument(fb)
+		if err == document.ErrFieldNotFound {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		if v.Type != document.TextValue || utf8.RuneCountInString(v.V.(string)) <= fc.MaxLength {
+			continue
+		}
+
+		if !ti.TruncateText {
+			return nil, stringutil.Errorf("field %q must not be longer than %d characters", fc.Path, fc.MaxLength)
+		}
+
+		err = fb.Set(fc.Path, document.NewTextValue(truncateText(v.V.(string), fc.MaxLength)))
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return fb, nil
+}
+
+// truncateText returns the first n characters of s.
+func truncateText(s string, n int) string {
+	var i int
+	for pos := range s {
+		if i == n {
+			return s[:pos]
+		}
+		i++
+	}
+
+	return s
+}
+
+// IsKeyDecodable returns true if the primary key of the documents can be decoded
+// from their encoded key, without reading them.
+func (ti *TableInfo) IsKeyDecodable() bool {
+	pk := ti.FieldConstraints.GetPrimaryKey()
+	if pk == nil {
+		return true
+	}
+
+	return !pk.Type.IsAny() && isDecodableKeyType(pk.Type)
+}
+
 // Clone creates another tableInfo with the same values.
 func (ti *TableInfo) Clone() *TableInfo {
 	cp := *ti
 	cp.FieldConstraints = nil
 	cp.FieldConstraints = append(cp.FieldConstraints, ti.FieldConstraints...)
+	cp.UniqueConstraints = nil
+	cp.UniqueConstraints = append(cp.UniqueConstraints, ti.UniqueConstraints...)
+	if ti.TTLField != nil {
+		cp.TTLField = ti.TTLField.Clone()
+	}
 	return &cp
 }
 
+// A UniqueConstraint ensures that no two documents of a table
+// have the same values for all of its paths.
+// It is enforced by a unique index owned by the constraint.
+type UniqueConstraint struct {
+	// Name of the constraint, which is also the name of its index.
+	// If empty, it is generated when the table is created.
+	Name  string
+	Paths []document.Path
+}
+
+// IndexInfo returns the information of the index enforcing the constraint.
+func (u *UniqueConstraint) IndexInfo(tableName string) *IndexInfo {
+	return &IndexInfo{
+		TableName: tableName,
+		IndexName: u.Name,
+		Paths:     u.Paths,
+		Unique:    true,
+		Owner: Owner{
+			TableName:  tableName,
+			Constraint: u.Name,
+		},
+	}
+}
+
+// String returns a SQL representation.
+func (u *UniqueConstraint) String() string {
+	var s strings.Builder
+
+	if u.Name != "" {
+		stringutil.Fprintf(&s, "CONSTRAINT %s ", stringutil.NormalizeIdentifier(u.Name, '`'))
+	}
+
+	s.WriteString("UNIQUE (")
+	for i, p := range u.Paths {
+		if i > 0 {
+			s.WriteString(", ")
+		}
+
+		s.WriteString(p.String())
+	}
+	s.WriteString(")")
+
+	return s.String()
+}
+
 // IndexInfo holds the configuration of an index.
 type IndexInfo struct {
 	TableName string
@@ -92,6 +293,97 @@ type IndexInfo struct {
 	// i.e CREATE TABLE tbl(a INT UNIQUE)
 	// The path refers to the path this index is related to.
 	Owner Owner
+
+	// If set, only the documents for which the predicate is true are indexed,
+	// i.e CREATE INDEX idx ON tbl(a) WHERE deleted = false
+	Predicate TableExpression
+
+	// If set, MultiKey[i] reports whether the arrays found at Paths[i] are indexed
+	// element by element, with one entry per distinct element,
+	// i.e CREATE INDEX idx ON tbl(tags[*])
+	// At most one path of an index can be multi-key.
+	MultiKey []bool
+
+	// If set, Orders[i] is the order of the values of Paths[i],
+	// i.e CREATE INDEX idx ON tbl(a DESC, b NULLS LAST)
+	// Paths without order are stored in ascending order.
+	Orders []IndexOrder
+
+	// If set to true, the index is being built by CREATE INDEX CONCURRENTLY:
+	// it is maintained by writes but not used by queries until the build is done.
+	Building bool
+}
+
+// IndexOrder is the order in which the values of a path are stored in an index.
+type IndexOrder struct {
+	// If set to true, the values are stored in descending order.
+	Desc bool
+
+	// NullsFirst and NullsLast are set by the NULLS FIRST and NULLS LAST clauses.
+	// By default, NULL values come first in ascending order and last in descending order,
+	// like when sorting documents.
+	NullsFirst, NullsLast bool
+}
+
+// IsNullsLast returns true if NULL values are stored after the other values.
+func (o IndexOrder) IsNullsLast() bool {
+	if o.NullsFirst || o.NullsLast {
+		return o.NullsLast
+	}
+
+	return o.Desc
+}
+
+// Order returns the order of the values of the path at position pos.
+func (i *IndexInfo) Order(pos int) IndexOrder {
+	if pos < len(i.Orders) {
+		return i.Orders[pos]
+	}
+
+	return IndexOrder{}
+}
+
+// MultiKeyPosition returns the position of the multi-key path of the index,
+// or -1 if the index is not a multi-key index.
+func (i *IndexInfo) MultiKeyPosition() int {
+	for pos, ok := range i.MultiKey {
+		if ok && pos < len(i.Paths) {
+			return pos
+		}
+	}
+
+	return -1
+}
+
+// Covers returns true if the document must be indexed, i.e. if the index
+// has no predicate or if the predicate is true for the document.
+func (i *IndexInfo) Covers(tx *Transaction, d document.Document) (bool, error) {
+	if i.Predicate == nil {
+		return true, nil
+	}
+
+	v, err := i.Predicate.Eval(tx, d)
+	if err != nil {
+		return false, err
+	}
+
+	return v.IsTruthy()
+}
+
+// IsDecodable returns true if the values at position i of the index can be decoded
+// from its entries. For untyped indexes, it depends on the type of each value.
+// Multi-key paths are never decodable, their entries only contain one element of the array.
+func (i *IndexInfo) IsDecodable(pos int) bool {
+	if pos < len(i.MultiKey) && i.MultiKey[pos] {
+		return false
+	}
+
+	typ := i.Types[pos]
+	if typ.IsAny() {
+		return true
+	}
+
+	return isDecodableKeyType(typ) && (encodedValueLen(typ) >= 0 || pos == len(i.Types)-1)
 }
 
 func (i *IndexInfo) Type() string {
@@ -135,17 +427,36 @@ func (i *IndexInfo) String() string {
 
 	stringutil.Fprintf(&s, "INDEX %s ON %s (", stringutil.NormalizeIdentifier(i.IndexName, '`'), stringutil.NormalizeIdentifier(i.TableName, '`'))
 
-	for i, p := range i.Paths {
-		if i > 0 {
+	for j, p := range i.Paths {
+		if j > 0 {
 			s.WriteString(", ")
 		}
 
 		// Path
 		s.WriteString(p.String())
+
+		if j < len(i.MultiKey) && i.MultiKey[j] {
+			s.WriteString("[*]")
+		}
+
+		o := i.Order(j)
+		if o.Desc {
+			s.WriteString(" DESC")
+		}
+		if o.NullsFirst {
+			s.WriteString(" NULLS FIRST")
+		}
+		if o.NullsLast {
+			s.WriteString(" NULLS LAST")
+		}
 	}
 
 	s.WriteString(")")
 
+	if i.Predicate != nil {
+		stringutil.Fprintf(&s, " WHERE %s", i.Predicate)
+	}
+
 	return s.String()
 }
 
@@ -161,6 +472,16 @@ func (i IndexInfo) Clone() *IndexInfo {
 	c.Types = make([]document.ValueType, len(i.Types))
 	copy(c.Types, i.Types)
 
+	if i.MultiKey != nil {
+		c.MultiKey = make([]bool, len(i.MultiKey))
+		copy(c.MultiKey, i.MultiKey)
+	}
+
+	if i.Orders != nil {
+		c.Orders = make([]IndexOrder, len(i.Orders))
+		copy(c.Orders, i.Orders)
+	}
+
 	return &c
 }
 
@@ -221,7 +542,10 @@ func (s SequenceInfo) Clone() *SequenceInfo {
 // only the TableName is filled.
 // If it has been created by a field constraint (for identities for example), the
 // path must also be filled.
+// If it has been created by a table constraint (for multi-path unique indexes for example),
+// the name of the constraint must be filled instead of the path.
 type Owner struct {
-	TableName string
-	Path      document.Path
+	TableName  string
+	Path       document.Path
+	Constraint string
 }
@@ -0,0 +1,564 @@
+package database
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"sort"
+
+	"github.com/genjidb/genji/engine"
+	errs "github.com/genjidb/genji/errors"
+	"github.com/google/btree"
+)
+
+// errOptimisticSchema is returned when an optimistic transaction
+// attempts to drop a store.
+var errOptimisticSchema = errors.New("cannot modify the schema within an optimistic transaction")
+
+// optimisticTx is the engine transaction of an optimistic transaction.
+// It reads a snapshot of the engine, without locking the database, and buffers
+// its writes in memory. On commit, it waits for the running read/write transaction,
+// if any, ensures that none of the keys it wrote was modified since the snapshot
+// was taken, and writes them using a read/write engine transaction.
+type optimisticTx struct {
+	// read-only snapshot of the engine.
+	engine.Transaction
+
+	ctx context.Context
+	db  *Database
+	// writes made to the engine on commit, recorded for replicas.
+	writes *[]Write
+	// number of modifications of the catalog when the transaction started.
+	catalogModifications uint64
+
+	stores map[string]*optimisticStore
+
+	// set while the transaction holds the read lock or the write lock of the database.
+	readLocked, writeLocked bool
+	terminated              bool
+}
+
+// catalogModifications returns the number of modifications made to the catalog,
+// if the catalog reports them.
+func catalogModifications(c Catalog) uint64 {
+	if m, ok := c.(interface{ Modifications() (uint64, bool) }); ok {
+		n, _ := m.Modifications()
+		return n
+	}
+
+	return 0
+}
+
+func (tx *optimisticTx) GetStore(name []byte) (engine.Store, error) {
+	if st, ok := tx.stores[string(name)]; ok {
+		return st, nil
+	}
+
+	st, err := tx.Transaction.GetStore(name)
+	if err != nil {
+		return nil, err
+	}
+
+	return tx.newStore(st, name), nil
+}
+
+// CreateStore creates the store on commit, unless another transaction created it in the meantime.
+// It is used to create the stores of the indexes, which are created lazily.
+func (tx *optimisticTx) CreateStore(name []byte) error {
+	_, err := tx.GetStore(name)
+	if err == nil {
+		return engine.ErrStoreAlreadyExists
+	}
+	if err != engine.ErrStoreNotFound {
+		return err
+	}
+
+	tx.newStore(emptyStore{}, name).created = true
+	return nil
+}
+
+func (tx *optimisticTx) newStore(st engine.Store, name []byte) *optimisticStore {
+	s := optimisticStore{
+		Store:    st,
+		name:     append([]byte(nil), name...),
+		writes:   btree.New(btreeDegree),
+		original: make(map[string]originalValue),
+		prefixes: make(map[string][][]byte),
+	}
+	tx.stores[string(name)] = &s
+	return &s
+}
+
+func (tx *optimisticTx) DropStore(name []byte) error {
+	return errOptimisticSchema
+}
+
+// Rollback discards the snapshot and the buffered writes.
+func (tx *optimisticTx) Rollback() error {
+	if tx.terminated {
+		return engine.ErrTransactionDiscarded
+	}
+
+	tx.terminated = true
+	return tx.Transaction.Rollback()
+}
+
+// Commit writes the buffered writes to the engine, or returns errs.ErrWriteConflict
+// if one of the keys was modified since the snapshot was taken.
+// On success, the write lock of the database is held until the transaction is released,
+// so that the commit hooks run before the next read/write transaction starts.
+func (tx *optimisticTx) Commit() error {
+	if tx.terminated {
+		return engine.ErrTransactionDiscarded
+	}
+	tx.terminated = true
+
+	err := tx.commit()
+	if err != nil {
+		// other transactions must not wait for the transaction to be rolled back
+		tx.unlock()
+	}
+	return err
+}
+
+func (tx *optimisticTx) commit() error {
+
+	// the snapshot must not be open while committing: engines like BoltDB
+	// may wait for the read-only transactions to end before growing their files.
+	err := tx.Transaction.Rollback()
+	if err != nil {
+		return err
+	}
+
+	// the read lock is released before waiting for the write lock, otherwise
+	// a read/write transaction waiting to modify the catalog would wait for it forever.
+	tx.unlock()
+	tx.db.writemu.Lock()
+	tx.writeLocked = true
+
+	if catalogModifications(tx.db.Catalog) != tx.catalogModifications {
+		return errs.ErrWriteConflict
+	}
+
+	ntx, err := tx.db.ng.Begin(tx.ctx, engine.TxOptions{Writable: true})
+	if err != nil {
+		return err
+	}
+	defer ntx.Rollback()
+
+	var etx engine.Transaction = ntx
+	if tx.db.Changes.replicated() {
+		etx = &recordingTx{Transaction: ntx, writes: tx.writes}
+	}
+
+	names := make([]string, 0, len(tx.stores))
+	for name := range tx.stores {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		err = tx.stores[name].apply(etx)
+		if err != nil {
+			return err
+		}
+	}
+
+	return ntx.Commit()
+}
+
+// unlock releases the locks held by the transaction.
+func (tx *optimisticTx) unlock() {
+	if tx.readLocked {
+		tx.readLocked = false
+		tx.db.txmu.RUnlock()
+	}
+
+	if tx.writeLocked {
+		tx.writeLocked = false
+		tx.db.writemu.Unlock()
+	}
+}
+
+// originalValue is the value of a key in the snapshot,
+// recorded the first time the key is written.
+type originalValue struct {
+	v     []byte
+	found bool
+}
+
+// optimisticStore buffers the writes made to a store by an optimistic transaction.
+type optimisticStore struct {
+	// store of the snapshot.
+	engine.Store
+
+	name []byte
+	// set if the store doesn't exist in the snapshot.
+	created bool
+	// buffered writes, sorted by key.
+	writes *btree.BTree
+	// values of the written keys in the snapshot.
+	original map[string]originalValue
+	// keys of the snapshot starting with the prefixes whose content
+	// must not change until the transaction commits, like the values of unique indexes.
+	prefixes map[string][][]byte
+}
+
+// write is a buffered write. Deleted keys are kept until the transaction commits.
+type write struct {
+	k, v    []byte
+	deleted bool
+}
+
+func (w *write) Key() []byte {
+	return w.k
+}
+
+func (w *write) ValueCopy(buf []byte) ([]byte, error) {
+	return append(buf[:0], w.v...), nil
+}
+
+func (w *write) Less(than btree.Item) bool {
+	return bytes.Compare(w.k, than.(*write).k) < 0
+}
+
+// btreeDegree is the degree of the btrees buffering the writes.
+const btreeDegree = 12
+
+func (s *optimisticStore) Get(k []byte) ([]byte, error) {
+	if it := s.writes.Get(&write{k: k}); it != nil {
+		w := it.(*write)
+		if w.deleted {
+			return nil, engine.ErrKeyNotFound
+		}
+		return w.v, nil
+	}
+
+	return s.Store.Get(k)
+}
+
+func (s *optimisticStore) Put(k, v []byte) error {
+	if len(k) == 0 {
+		return errors.New("empty keys are forbidden")
+	}
+	if len(v) == 0 {
+		return errors.New("empty values are forbidden")
+	}
+
+	err := s.recordOriginal(k)
+	if err != nil {
+		return err
+	}
+
+	s.writes.ReplaceOrInsert(&write{
+		k: append([]byte(nil), k...),
+		v: append([]byte(nil), v...),
+	})
+	return nil
+}
+
+func (s *optimisticStore) Delete(k []byte) error {
+	_, err := s.Get(k)
+	if err != nil {
+		return err
+	}
+
+	err = s.recordOriginal(k)
+	if err != nil {
+		return err
+	}
+
+	s.writes.ReplaceOrInsert(&write{k: append([]byte(nil), k...), deleted: true})
+	return nil
+}
+
+// Truncate deletes the keys of the store one by one, so that the keys
+// written by other transactions since the snapshot are left untouched.
+func (s *optimisticStore) Truncate() error {
+	var keys [][]byte
+	it := s.Iterator(engine.IteratorOptions{})
+	for it.Seek(nil); it.Valid(); it.Next() {
+		keys = append(keys, append([]byte(nil), it.Item().Key()...))
+	}
+	err := it.Err()
+	it.Close()
+	if err != nil {
+		return err
+	}
+
+	for _, k := range keys {
+		err = s.Delete(k)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// recordOriginal records the value of k in the snapshot, if it wasn't already.
+func (s *optimisticStore) recordOriginal(k []byte) error {
+	if _, ok := s.original[string(k)]; ok {
+		return nil
+	}
+
+	v, err := s.Store.Get(k)
+	if err != nil && err != engine.ErrKeyNotFound {
+		return err
+	}
+
+	s.original[string(k)] = originalValue{
+		v:     append([]byte(nil), v...),
+		found: err == nil,
+	}
+	return nil
+}
+
+// trackPrefix records the keys of the snapshot starting with prefix, to ensure
+// no key starting with prefix is added or removed by another transaction until commit.
+func (s *optimisticStore) trackPrefix(prefix []byte) error {
+	if _, ok := s.prefixes[string(prefix)]; ok {
+		return nil
+	}
+
+	keys, err := prefixKeys(s.Store, prefix)
+	if err != nil {
+		return err
+	}
+
+	s.prefixes[string(prefix)] = keys
+	return nil
+}
+
+// prefixKeys returns the keys of st starting with prefix.
+func prefixKeys(st engine.Store, prefix []byte) ([][]byte, error) {
+	var keys [][]byte
+
+	it := st.Iterator(engine.IteratorOptions{})
+	defer it.Close()
+
+	for it.Seek(prefix); it.Valid(); it.Next() {
+		k := it.Item().Key()
+		if !bytes.HasPrefix(k, prefix) {
+			break
+		}
+		keys = append(keys, append([]byte(nil), k...))
+	}
+
+	return keys, it.Err()
+}
+
+// apply ensures the written keys weren't modified since the snapshot was taken,
+// then writes them using tx.
+func (s *optimisticStore) apply(tx engine.Transaction) error {
+	st, err := tx.GetStore(s.name)
+	if err == engine.ErrStoreNotFound && s.created {
+		err = tx.CreateStore(s.name)
+		if err != nil {
+			return err
+		}
+		st, err = tx.GetStore(s.name)
+	}
+	if err == engine.ErrStoreNotFound {
+		return errs.ErrWriteConflict
+	}
+	if err != nil {
+		return err
+	}
+
+	for k, o := range s.original {
+		v, err := st.Get([]byte(k))
+		if err != nil && err != engine.ErrKeyNotFound {
+			return err
+		}
+		if (err == nil) != o.found || !bytes.Equal(v, o.v) {
+			return errs.ErrWriteConflict
+		}
+	}
+
+	for prefix, keys := range s.prefixes {
+		current, err := prefixKeys(st, []byte(prefix))
+		if err != nil {
+			return err
+		}
+		if len(current) != len(keys) {
+			return errs.ErrWriteConflict
+		}
+		for i := range keys {
+			if !bytes.Equal(keys[i], current[i]) {
+				return errs.ErrWriteConflict
+			}
+		}
+	}
+
+	s.writes.Ascend(func(i btree.Item) bool {
+		w := i.(*write)
+		switch {
+		case !w.deleted:
+			err = st.Put(w.k, w.v)
+		case s.original[string(w.k)].found:
+			err = st.Delete(w.k)
+		}
+		return err == nil
+	})
+	return err
+}
+
+func (s *optimisticStore) Iterator(opts engine.IteratorOptions) engine.Iterator {
+	return &optimisticIterator{
+		st:      s,
+		it:      s.Store.Iterator(opts),
+		reverse: opts.Reverse,
+	}
+}
+
+// optimisticIterator merges the keys of the snapshot with the buffered writes.
+// Buffered writes are looked up at every step, so that writes made while iterating,
+// like the documents replaced by an UPDATE, are taken into account.
+type optimisticIterator struct {
+	st      *optimisticStore
+	it      engine.Iterator
+	reverse bool
+
+	// current item, either the item of the snapshot iterator or a buffered write.
+	item engine.Item
+	// buffer used to look up the buffered writes.
+	pivot write
+	err   error
+}
+
+func (it *optimisticIterator) Seek(pivot []byte) {
+	it.it.Seek(pivot)
+	it.resolve(pivot, true)
+}
+
+func (it *optimisticIterator) Next() {
+	if it.item == nil {
+		return
+	}
+
+	k := append([]byte(nil), it.item.Key()...)
+	for it.it.Valid() && !it.before(k, it.it.Item().Key()) {
+		it.it.Next()
+	}
+	it.resolve(k, false)
+}
+
+// before reports whether a comes before b in the order of the iterator.
+func (it *optimisticIterator) before(a, b []byte) bool {
+	if it.reverse {
+		return bytes.Compare(a, b) > 0
+	}
+	return bytes.Compare(a, b) < 0
+}
+
+// nextWrite returns the first buffered write starting from the pivot, included or not.
+func (it *optimisticIterator) nextWrite(pivot []byte, inclusive bool) *write {
+	var found *write
+	iter := func(i btree.Item) bool {
+		w := i.(*write)
+		if !inclusive && bytes.Equal(w.k, pivot) {
+			return true
+		}
+		found = w
+		return false
+	}
+
+	it.pivot.k = pivot
+	switch {
+	case it.reverse && len(pivot) == 0:
+		it.st.writes.Descend(iter)
+	case it.reverse:
+		it.st.writes.DescendLessOrEqual(&it.pivot, iter)
+	case len(pivot) == 0:
+		it.st.writes.Ascend(iter)
+	default:
+		it.st.writes.AscendGreaterOrEqual(&it.pivot, iter)
+	}
+
+	return found
+}
+
+// resolve selects the next item starting from the pivot, skipping the deleted keys.
+func (it *optimisticIterator) resolve(pivot []byte, inclusive bool) {
+	it.item = nil
+
+	for {
+		var sk []byte
+		if it.it.Valid() {
+			sk = it.it.Item().Key()
+		} else if err := it.it.Err(); err != nil {
+			it.err = err
+			return
+		}
+
+		w := it.nextWrite(pivot, inclusive)
+		if w == nil && sk == nil {
+			return
+		}
+
+		if w == nil || (sk != nil && it.before(sk, w.k)) {
+			it.item = it.it.Item()
+			return
+		}
+
+		if !w.deleted {
+			it.item = w
+			return
+		}
+
+		// skip the deleted key, in the snapshot as well
+		if sk != nil && bytes.Equal(sk, w.k) {
+			it.it.Next()
+		}
+		pivot, inclusive = w.k, false
+	}
+}
+
+func (it *optimisticIterator) Valid() bool {
+	return it.err == nil && it.item != nil
+}
+
+func (it *optimisticIterator) Err() error {
+	return it.err
+}
+
+func (it *optimisticIterator) Item() engine.Item {
+	return it.item
+}
+
+func (it *optimisticIterator) Close() error {
+	return it.it.Close()
+}
+
+// emptyStore is the snapshot of the stores created by optimistic transactions.
+type emptyStore struct{}
+
+func (emptyStore) Get(k []byte) ([]byte, error) {
+	return nil, engine.ErrKeyNotFound
+}
+
+func (emptyStore) Put(k, v []byte) error {
+	return errors.New("cannot write to the snapshot")
+}
+
+func (emptyStore) Delete(k []byte) error {
+	return engine.ErrKeyNotFound
+}
+
+func (emptyStore) Truncate() error {
+	return nil
+}
+
+func (emptyStore) Iterator(opts engine.IteratorOptions) engine.Iterator {
+	return emptyIterator{}
+}
+
+type emptyIterator struct{}
+
+func (emptyIterator) Seek(k []byte)     {}
+func (emptyIterator) Next()             {}
+func (emptyIterator) Err() error        { return nil }
+func (emptyIterator) Valid() bool       { return false }
+func (emptyIterator) Item() engine.Item { return nil }
+func (emptyIterator) Close() error      { return nil }
@@ -0,0 +1,48 @@
+package database
+
+import (
+	"context"
+
+	"github.com/genjidb/genji/document"
+	"github.com/genjidb/genji/internal/stringutil"
+)
+
+// A RowPredicate reports whether a document of a table can be accessed.
+// The context is the one the transaction was started with.
+type RowPredicate func(ctx context.Context, d document.Document) (bool, error)
+
+// A RowPolicy restricts the documents of a table that statements can read and write.
+type RowPolicy struct {
+	// Read filters the documents returned when scanning the table.
+	// Documents it rejects are invisible to SELECT, UPDATE and DELETE statements.
+	Read RowPredicate
+	// Write validates the documents inserted or updated in the table.
+	// Documents it rejects cause the statement to fail.
+	Write RowPredicate
+}
+
+// CanRead reports whether d can be read by the statements run by tx.
+func (p *RowPolicy) CanRead(tx *Transaction, d document.Document) (bool, error) {
+	if p == nil || p.Read == nil {
+		return true, nil
+	}
+
+	return p.Read(tx.Context(), d)
+}
+
+// CheckWrite returns an error if d can't be written by the statements run by tx.
+func (p *RowPolicy) CheckWrite(tx *Transaction, tableName string, d document.Document) error {
+	if p == nil || p.Write == nil {
+		return nil
+	}
+
+	ok, err := p.Write(tx.Context(), d)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return stringutil.Errorf("document violates the row policy of table %q", tableName)
+	}
+
+	return nil
+}
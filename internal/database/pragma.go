@@ -0,0 +1,176 @@
+package database
+
+import (
+	"strings"
+
+	"github.com/genjidb/genji/document"
+	errs "github.com/genjidb/genji/errors"
+	"github.com/genjidb/genji/internal/stringutil"
+)
+
+const (
+	PragmaTableName = "__genji_pragma"
+)
+
+var pragmaTableInfo = &TableInfo{
+	TableName: PragmaTableName,
+	StoreName: []byte(PragmaTableName),
+	FieldConstraints: []*FieldConstraint{
+		{
+			Path: document.Path{
+				document.PathFragment{
+					FieldName: "name",
+				},
+			},
+			Type:         document.TextValue,
+			IsPrimaryKey: true,
+		},
+	},
+}
+
+// Pragmas lists the options of the database that can be read and modified
+// using the PRAGMA statement. Unlike session settings, pragmas are stored
+// in the __genji_pragma table and survive restarts.
+var Pragmas = map[string]*Setting{
+	"durability":        newEnumPragma("durability", "full", "normal", "off"),
+	"default_collation": newEnumPragma("default_collation", "binary", "nocase"),
+	"auto_vacuum":       newEnumPragma("auto_vacuum", "none", "full", "incremental"),
+	// when on, the changes committed to the tables are appended to the __genji_changes table.
+	"change_log": newEnumPragma("change_log", "off", "on"),
+	// number of milliseconds for which the previous versions of the documents are retained in the
+	// __genji_changes table, and can be read using SELECT ... AS OF. Zero disables the retention.
+	"version_retention": {
+		Name:    "version_retention",
+		Default: document.NewIntegerValue(0),
+		Check:   checkDuration("version_retention"),
+	},
+	// fraction of the documents of an analyzed table that must be modified
+	// before it is analyzed again automatically. Zero disables automatic analysis.
+	"auto_analyze_threshold": {
+		Name:    "auto_analyze_threshold",
+		Default: document.NewDoubleValue(0.1),
+		Check: func(v document.Value) (document.Value, error) {
+			if v.Type.IsNumber() {
+				d, err := v.CastAsDouble()
+				if err == nil && d.V.(float64) >= 0 {
+					return d, nil
+				}
+			}
+
+			return v, stringutil.Errorf("auto_analyze_threshold expects a positive number, got %v", v)
+		},
+	},
+}
+
+// newEnumPragma returns a pragma accepting one of the given values, case insensitively.
+// The first value is the default.
+func newEnumPragma(name string, values ...string) *Setting {
+	return &Setting{
+		Name:    name,
+		Default: document.NewTextValue(values[0]),
+		Check: func(v document.Value) (document.Value, error) {
+			if v.Type == document.TextValue {
+				s := strings.ToLower(v.V.(string))
+				for _, value := range values {
+					if s == value {
+						return document.NewTextValue(s), nil
+					}
+				}
+			}
+
+			return v, stringutil.Errorf("%s expects one of %s, got %v", name, strings.Join(values, ", "), v)
+		},
+	}
+}
+
+// GetPragma returns the pragma with the given name.
+// Pragma names are case insensitive.
+func GetPragma(name string) (*Setting, error) {
+	p, ok := Pragmas[strings.ToLower(name)]
+	if !ok {
+		return nil, stringutil.Errorf("unknown pragma %q", name)
+	}
+
+	return p, nil
+}
+
+// GetPragmaValue returns the value of the given pragma stored in the database,
+// or its default value if it was never modified.
+func GetPragmaValue(tx *Transaction, catalog Catalog, name string) (document.Value, error) {
+	p, err := GetPragma(name)
+	if err != nil {
+		return document.Value{}, err
+	}
+
+	tb, err := catalog.GetTable(tx, PragmaTableName)
+	if err != nil {
+		if errs.IsNotFoundError(err) {
+			return p.Default, nil
+		}
+		return document.Value{}, err
+	}
+
+	d, err := tb.GetDocument([]byte(p.Name))
+	if err != nil {
+		if err == errs.ErrDocumentNotFound {
+			return p.Default, nil
+		}
+		return document.Value{}, err
+	}
+
+	return d.GetByField("value")
+}
+
+// SetPragmaValue validates v and stores it as the new value of the given pragma.
+func SetPragmaValue(tx *Transaction, catalog Catalog, name string, v document.Value) error {
+	p, err := GetPragma(name)
+	if err != nil {
+		return err
+	}
+
+	v, err = p.Check(v)
+	if err != nil {
+		return err
+	}
+
+	tb, err := catalog.GetTable(tx, PragmaTableName)
+	if err != nil {
+		if !errs.IsNotFoundError(err) {
+			return err
+		}
+
+		err = catalog.CreateTable(tx, PragmaTableName, pragmaTableInfo.Clone())
+		if err != nil {
+			return err
+		}
+
+		tb, err = catalog.GetTable(tx, PragmaTableName)
+		if err != nil {
+			return err
+		}
+	}
+
+	d := document.NewFieldBuffer().
+		Add("name", document.NewTextValue(p.Name)).
+		Add("value", v)
+
+	_, err = tb.Replace([]byte(p.Name), d)
+	if err == errs.ErrDocumentNotFound {
+		_, err = tb.Insert(d)
+	}
+	if err != nil {
+		return err
+	}
+
+	switch p.Name {
+	case "change_log", "version_retention":
+		tx.changeLog = nil
+		enabled, err := tx.changeLogEnabled(catalog)
+		if err != nil || !enabled {
+			return err
+		}
+		return createChangeLog(tx, catalog)
+	}
+
+	return nil
+}
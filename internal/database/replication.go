@@ -0,0 +1,323 @@
+package database
+
+import (
+	"context"
+
+	"github.com/genjidb/genji/engine"
+)
+
+// catalogStoreName is the name of the store of the catalog table.
+const catalogStoreName = InternalPrefix + "catalog"
+
+// snapshotBatchSize is the number of writes passed at once to the
+// snapshot function of Replicate.
+const snapshotBatchSize = 1000
+
+// WriteOp is the kind of write made to the engine.
+type WriteOp uint8
+
+const (
+	WriteCreateStore WriteOp = iota + 1
+	WriteDropStore
+	WritePut
+	WriteDelete
+	WriteTruncate
+)
+
+// A Write is a write made to the engine by a transaction.
+// Key and Value are only set for puts and deletes.
+type Write struct {
+	Op         WriteOp
+	Store      []byte
+	Key, Value []byte
+}
+
+// ApplyWrites makes the given writes using tx.
+func ApplyWrites(tx engine.Transaction, writes []Write) error {
+	for _, w := range writes {
+		var err error
+
+		switch w.Op {
+		case WriteCreateStore:
+			err = tx.CreateStore(w.Store)
+		case WriteDropStore:
+			err = tx.DropStore(w.Store)
+		default:
+			var st engine.Store
+			st, err = tx.GetStore(w.Store)
+			if err != nil {
+				return err
+			}
+
+			switch w.Op {
+			case WritePut:
+				err = st.Put(w.Key, w.Value)
+			case WriteDelete:
+				err = st.Delete(w.Key)
+			case WriteTruncate:
+				err = st.Truncate()
+			}
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// recordingTx records the writes made by an engine transaction,
+// so that they can be sent to replicas.
+type recordingTx struct {
+	engine.Transaction
+
+	writes *[]Write
+}
+
+func (tx *recordingTx) GetStore(name []byte) (engine.Store, error) {
+	st, err := tx.Transaction.GetStore(name)
+	if err != nil {
+		return nil, err
+	}
+
+	return &recordingStore{Store: st, name: name, writes: tx.writes}, nil
+}
+
+func (tx *recordingTx) CreateStore(name []byte) error {
+	err := tx.Transaction.CreateStore(name)
+	if err == nil {
+		recordWrite(tx.writes, Write{Op: WriteCreateStore, Store: name})
+	}
+	return err
+}
+
+func (tx *recordingTx) DropStore(name []byte) error {
+	err := tx.Transaction.DropStore(name)
+	if err == nil {
+		recordWrite(tx.writes, Write{Op: WriteDropStore, Store: name})
+	}
+	return err
+}
+
+// recordWrite appends w to writes, copying its byte slices which
+// may be reused by the caller.
+func recordWrite(writes *[]Write, w Write) {
+	w.Store = append([]byte(nil), w.Store...)
+	if w.Key != nil {
+		w.Key = append([]byte(nil), w.Key...)
+	}
+	if w.Value != nil {
+		w.Value = append([]byte(nil), w.Value...)
+	}
+
+	*writes = append(*writes, w)
+}
+
+type recordingStore struct {
+	engine.Store
+
+	name   []byte
+	writes *[]Write
+}
+
+func (s *recordingStore) Put(k, v []byte) error {
+	err := s.Store.Put(k, v)
+	if err == nil {
+		recordWrite(s.writes, Write{Op: WritePut, Store: s.name, Key: k, Value: v})
+	}
+	return err
+}
+
+func (s *recordingStore) Delete(k []byte) error {
+	err := s.Store.Delete(k)
+	if err == nil {
+		recordWrite(s.writes, Write{Op: WriteDelete, Store: s.name, Key: k})
+	}
+	return err
+}
+
+func (s *recordingStore) Truncate() error {
+	err := s.Store.Truncate()
+	if err == nil {
+		recordWrite(s.writes, Write{Op: WriteTruncate, Store: s.name})
+	}
+	return err
+}
+
+// Replicate sends the content of the database to a replica, then the writes of
+// every transaction committed afterwards.
+// snapshot is called with batches of writes recreating every store of the database,
+// while transactions can read the database but not write to it.
+// Then fn is called with the writes of every committed transaction, in the order they
+// were committed, the same way changes are delivered to the subscribers of the change feed.
+// It returns a function stopping the replication.
+func (db *Database) Replicate(snapshot func(writes []Write) error, fn func(writes []Write)) (cancel func(), err error) {
+	tx, err := db.BeginTx(context.Background(), &TxOptions{ReadOnly: true})
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	// no transaction can commit until the snapshot is taken,
+	// the replica will receive the ones committed afterwards
+	cancel = db.Changes.subscribeWrites(fn)
+
+	err = db.snapshot(tx, snapshot)
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+
+	return cancel, nil
+}
+
+// snapshot calls fn with the writes recreating the stores of every table and index.
+func (db *Database) snapshot(tx *Transaction, fn func(writes []Write) error) error {
+	var stores [][]byte
+	for _, tableName := range db.Catalog.ListTables(tx) {
+		info, err := db.Catalog.GetTableInfo(tx, tableName)
+		if err != nil {
+			return err
+		}
+		stores = append(stores, info.StoreName)
+		if info.SoftDelete {
+			stores = append(stores, info.TombstoneStoreName())
+		}
+
+		for _, indexName := range db.Catalog.ListIndexes(tx, tableName) {
+			idx, err := db.Catalog.GetIndexInfo(tx, indexName)
+			if err != nil {
+				return err
+			}
+			stores = append(stores, idx.StoreName)
+		}
+	}
+
+	var writes []Write
+	flush := func(force bool) error {
+		if len(writes) == 0 || (!force && len(writes) < snapshotBatchSize) {
+			return nil
+		}
+
+		err := fn(writes)
+		writes = nil
+		return err
+	}
+
+	for _, name := range stores {
+		st, err := tx.Tx.GetStore(name)
+		if err != nil {
+			return err
+		}
+
+		writes = append(writes, Write{Op: WriteCreateStore, Store: name})
+
+		it := st.Iterator(engine.IteratorOptions{})
+		for it.Seek(nil); it.Valid(); it.Next() {
+			item := it.Item()
+			v, err := item.ValueCopy(nil)
+			if err != nil {
+				it.Close()
+				return err
+			}
+
+			writes = append(writes, Write{
+				Op:    WritePut,
+				Store: name,
+				Key:   append([]byte(nil), item.Key()...),
+				Value: v,
+			})
+
+			if err := flush(false); err != nil {
+				it.Close()
+				return err
+			}
+		}
+		err = it.Err()
+		it.Close()
+		if err != nil {
+			return err
+		}
+	}
+
+	return flush(true)
+}
+
+// Apply makes the writes of a transaction committed by the primary of a replica,
+// in a single transaction.
+func (db *Database) Apply(writes []Write) error {
+	if len(writes) == 0 {
+		return nil
+	}
+
+	db.writemu.Lock()
+	tx, err := db.beginTx(context.Background(), nil)
+	if err != nil {
+		db.writemu.Unlock()
+		return err
+	}
+	defer tx.Rollback()
+
+	// written stores are mapped to tables using the catalog before and after the writes,
+	// to report the modifications of tables that were dropped or created
+	tables := db.storeTables(tx)
+
+	err = ApplyWrites(tx.Tx, writes)
+	if err != nil {
+		return err
+	}
+
+	for _, w := range writes {
+		if string(w.Store) == catalogStoreName {
+			err = db.Catalog.Load(tx)
+			if err != nil {
+				return err
+			}
+
+			for name, tableName := range db.storeTables(tx) {
+				tables[name] = tableName
+			}
+			break
+		}
+	}
+
+	// the statistics of the tables are replicated as well, only the versions
+	// of the modified tables must be incremented
+	modified := make(map[string]int64)
+	for _, w := range writes {
+		if tableName, ok := tables[string(w.Store)]; ok {
+			modified[tableName]++
+		}
+	}
+	tx.OnCommitHooks = append(tx.OnCommitHooks, func() {
+		db.incrementTableVersions(modified)
+	})
+
+	return tx.Commit()
+}
+
+// storeTables returns the name of the table of every table and index store, as seen by tx.
+func (db *Database) storeTables(tx *Transaction) map[string]string {
+	m := make(map[string]string)
+
+	for _, tableName := range db.Catalog.ListTables(tx) {
+		info, err := db.Catalog.GetTableInfo(tx, tableName)
+		if err != nil {
+			continue
+		}
+		m[string(info.StoreName)] = tableName
+		if info.SoftDelete {
+			m[string(info.TombstoneStoreName())] = tableName
+		}
+
+		for _, indexName := range db.Catalog.ListIndexes(tx, tableName) {
+			idx, err := db.Catalog.GetIndexInfo(tx, indexName)
+			if err != nil {
+				continue
+			}
+			m[string(idx.StoreName)] = tableName
+		}
+	}
+
+	return m
+}
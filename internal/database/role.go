@@ -0,0 +1,450 @@
+package database
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/binary"
+	"sort"
+	"strings"
+
+	"github.com/genjidb/genji/document"
+	errs "github.com/genjidb/genji/errors"
+	"github.com/genjidb/genji/internal/stringutil"
+)
+
+const (
+	RoleTableName = "__genji_role"
+
+	// AllTables is the name used to grant privileges on every table,
+	// including the ones created after the privileges are granted.
+	AllTables = "*"
+
+	passwordSaltSize   = 16
+	passwordKeySize    = 32
+	passwordIterations = 10000
+)
+
+var roleTableInfo = &TableInfo{
+	TableName: RoleTableName,
+	StoreName: []byte(RoleTableName),
+	FieldConstraints: []*FieldConstraint{
+		{
+			Path: document.Path{
+				document.PathFragment{
+					FieldName: "name",
+				},
+			},
+			Type:         document.TextValue,
+			IsPrimaryKey: true,
+		},
+	},
+}
+
+// A Privilege allows a role to run a certain kind of statement on a table.
+type Privilege string
+
+// List of privileges.
+const (
+	SelectPrivilege Privilege = "SELECT"
+	InsertPrivilege Privilege = "INSERT"
+	UpdatePrivilege Privilege = "UPDATE"
+	DeletePrivilege Privilege = "DELETE"
+	// DDLPrivilege allows creating, altering, reindexing and dropping tables
+	// and their indexes. DDL on all tables is required to manage sequences.
+	DDLPrivilege Privilege = "DDL"
+)
+
+// AllPrivileges lists every privilege, as granted by GRANT ALL.
+var AllPrivileges = []Privilege{SelectPrivilege, InsertPrivilege, UpdatePrivilege, DeletePrivilege, DDLPrivilege}
+
+// A Role is a set of privileges. Roles that can log in are users.
+// Roles can be granted to other roles, which inherit their privileges.
+type Role struct {
+	Name  string
+	Login bool
+	// Salt and PasswordHash are nil if the role has no password.
+	Salt         []byte
+	PasswordHash []byte
+	// Roles this role is member of.
+	MemberOf []string
+	// Privileges by table name.
+	Privileges map[string][]Privilege
+}
+
+// SetPassword stores a salted hash of the password.
+func (r *Role) SetPassword(password string) error {
+	r.Salt = make([]byte, passwordSaltSize)
+	_, err := rand.Read(r.Salt)
+	if err != nil {
+		return err
+	}
+
+	r.PasswordHash = hashPassword(password, r.Salt)
+	return nil
+}
+
+// CheckPassword reports whether password is the password of the role.
+// It always returns false for roles without password.
+func (r *Role) CheckPassword(password string) bool {
+	if r.PasswordHash == nil {
+		return false
+	}
+
+	return subtle.ConstantTimeCompare(hashPassword(password, r.Salt), r.PasswordHash) == 1
+}
+
+// hashPassword derives a key from the password using PBKDF2 with HMAC-SHA256.
+func hashPassword(password string, salt []byte) []byte {
+	prf := hmac.New(sha256.New, []byte(password))
+
+	var key []byte
+	var buf [4]byte
+	for block := uint32(1); len(key) < passwordKeySize; block++ {
+		prf.Reset()
+		prf.Write(salt)
+		binary.BigEndian.PutUint32(buf[:], block)
+		prf.Write(buf[:])
+		u := prf.Sum(nil)
+
+		t := make([]byte, len(u))
+		copy(t, u)
+		for i := 1; i < passwordIterations; i++ {
+			prf.Reset()
+			prf.Write(u)
+			u = prf.Sum(u[:0])
+			for j := range t {
+				t[j] ^= u[j]
+			}
+		}
+
+		key = append(key, t...)
+	}
+
+	return key[:passwordKeySize]
+}
+
+// Grant adds the privileges on the given table to the role.
+func (r *Role) Grant(tableName string, privileges ...Privilege) {
+	if r.Privileges == nil {
+		r.Privileges = make(map[string][]Privilege)
+	}
+
+	for _, p := range privileges {
+		if !r.hasPrivilege(tableName, p) {
+			r.Privileges[tableName] = append(r.Privileges[tableName], p)
+		}
+	}
+}
+
+// Revoke removes the privileges on the given table from the role.
+func (r *Role) Revoke(tableName string, privileges ...Privilege) {
+	var kept []Privilege
+	for _, p := range r.Privileges[tableName] {
+		if !containsPrivilege(privileges, p) {
+			kept = append(kept, p)
+		}
+	}
+
+	if len(kept) == 0 {
+		delete(r.Privileges, tableName)
+		return
+	}
+
+	r.Privileges[tableName] = kept
+}
+
+// AddMembership makes the role a member of the given role.
+func (r *Role) AddMembership(role string) {
+	for _, m := range r.MemberOf {
+		if m == role {
+			return
+		}
+	}
+
+	r.MemberOf = append(r.MemberOf, role)
+}
+
+// RemoveMembership removes the role from the members of the given role.
+func (r *Role) RemoveMembership(role string) {
+	for i, m := range r.MemberOf {
+		if m == role {
+			r.MemberOf = append(r.MemberOf[:i], r.MemberOf[i+1:]...)
+			return
+		}
+	}
+}
+
+func (r *Role) hasPrivilege(tableName string, p Privilege) bool {
+	return containsPrivilege(r.Privileges[tableName], p)
+}
+
+func containsPrivilege(privileges []Privilege, p Privilege) bool {
+	for _, pp := range privileges {
+		if pp == p {
+			return true
+		}
+	}
+
+	return false
+}
+
+// ToDocument returns a document representation of the role,
+// as stored in the __genji_role table.
+func (r *Role) ToDocument() document.Document {
+	buf := document.NewFieldBuffer()
+	buf.Add("name", document.NewTextValue(r.Name))
+	buf.Add("login", document.NewBoolValue(r.Login))
+	if r.PasswordHash != nil {
+		buf.Add("salt", document.NewBlobValue(r.Salt))
+		buf.Add("password", document.NewBlobValue(r.PasswordHash))
+	}
+
+	if len(r.MemberOf) > 0 {
+		vb := document.NewValueBuffer()
+		for _, m := range r.MemberOf {
+			vb.Append(document.NewTextValue(m))
+		}
+		buf.Add("member_of", document.NewArrayValue(vb))
+	}
+
+	if len(r.Privileges) > 0 {
+		tables := make([]string, 0, len(r.Privileges))
+		for t := range r.Privileges {
+			tables = append(tables, t)
+		}
+		sort.Strings(tables)
+
+		privileges := document.NewFieldBuffer()
+		for _, t := range tables {
+			vb := document.NewValueBuffer()
+			for _, p := range r.Privileges[t] {
+				vb.Append(document.NewTextValue(string(p)))
+			}
+			privileges.Add(t, document.NewArrayValue(vb))
+		}
+		buf.Add("privileges", document.NewDocumentValue(privileges))
+	}
+
+	return buf
+}
+
+// roleFromDocument decodes a role stored in the __genji_role table.
+func roleFromDocument(d document.Document) (*Role, error) {
+	var r Role
+
+	err := d.Iterate(func(field string, v document.Value) error {
+		var err error
+
+		switch field {
+		case "name":
+			r.Name = v.V.(string)
+		case "login":
+			r.Login = v.V.(bool)
+		case "salt":
+			r.Salt = v.V.([]byte)
+		case "password":
+			r.PasswordHash = v.V.([]byte)
+		case "member_of":
+			err = v.V.(document.Array).Iterate(func(i int, v document.Value) error {
+				r.MemberOf = append(r.MemberOf, v.V.(string))
+				return nil
+			})
+		case "privileges":
+			err = v.V.(document.Document).Iterate(func(table string, v document.Value) error {
+				return v.V.(document.Array).Iterate(func(i int, v document.Value) error {
+					r.Grant(table, Privilege(v.V.(string)))
+					return nil
+				})
+			})
+		}
+
+		return err
+	})
+
+	return &r, err
+}
+
+// ParsePrivilege returns the privilege with the given name, case insensitively.
+func ParsePrivilege(name string) (Privilege, error) {
+	p := Privilege(strings.ToUpper(name))
+	if !containsPrivilege(AllPrivileges, p) {
+		return "", stringutil.Errorf("unknown privilege %q", name)
+	}
+
+	return p, nil
+}
+
+// GetRole returns the role with the given name.
+// It returns errs.NotFoundError if the role doesn't exist.
+func GetRole(tx *Transaction, catalog Catalog, name string) (*Role, error) {
+	tb, err := catalog.GetTable(tx, RoleTableName)
+	if err != nil {
+		if errs.IsNotFoundError(err) {
+			return nil, errs.NotFoundError{Name: name}
+		}
+		return nil, err
+	}
+
+	d, err := tb.GetDocument([]byte(name))
+	if err != nil {
+		if err == errs.ErrDocumentNotFound {
+			return nil, errs.NotFoundError{Name: name}
+		}
+		return nil, err
+	}
+
+	return roleFromDocument(d)
+}
+
+// CreateRole stores a new role.
+// It returns errs.AlreadyExistsError if a role with the same name exists.
+func CreateRole(tx *Transaction, catalog Catalog, r *Role) error {
+	tb, err := catalog.GetTable(tx, RoleTableName)
+	if err != nil {
+		if !errs.IsNotFoundError(err) {
+			return err
+		}
+
+		err = catalog.CreateTable(tx, RoleTableName, roleTableInfo.Clone())
+		if err != nil {
+			return err
+		}
+
+		tb, err = catalog.GetTable(tx, RoleTableName)
+		if err != nil {
+			return err
+		}
+	}
+
+	_, err = tb.Insert(r.ToDocument())
+	if err == errs.ErrDuplicateDocument {
+		return errs.AlreadyExistsError{Name: r.Name}
+	}
+
+	return err
+}
+
+// ReplaceRole replaces an existing role.
+func ReplaceRole(tx *Transaction, catalog Catalog, r *Role) error {
+	tb, err := catalog.GetTable(tx, RoleTableName)
+	if err != nil {
+		return err
+	}
+
+	_, err = tb.Replace([]byte(r.Name), r.ToDocument())
+	return err
+}
+
+// DropRole deletes a role. Roles that were members of
+// the deleted role lose the privileges it granted them.
+func DropRole(tx *Transaction, catalog Catalog, name string) error {
+	tb, err := catalog.GetTable(tx, RoleTableName)
+	if err != nil {
+		if errs.IsNotFoundError(err) {
+			return errs.NotFoundError{Name: name}
+		}
+		return err
+	}
+
+	err = tb.Delete([]byte(name))
+	if err == errs.ErrDocumentNotFound {
+		return errs.NotFoundError{Name: name}
+	}
+	if err != nil {
+		return err
+	}
+
+	var members []*Role
+	err = tb.AscendGreaterOrEqual(tx.Context(), document.Value{}, func(d document.Document) error {
+		r, err := roleFromDocument(d)
+		if err != nil {
+			return err
+		}
+
+		for _, m := range r.MemberOf {
+			if m == name {
+				members = append(members, r)
+				break
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, r := range members {
+		r.RemoveMembership(name)
+		err = ReplaceRole(tx, catalog, r)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// CheckPrivilege returns an error if the role doesn't have the privilege
+// on the given table, either directly or through the roles it is member of.
+// Privileges granted on AllTables apply to every table.
+func CheckPrivilege(tx *Transaction, catalog Catalog, role string, tableName string, p Privilege) error {
+	visited := make(map[string]bool)
+
+	ok, err := hasPrivilege(tx, catalog, role, tableName, p, visited)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return &PermissionDeniedError{Role: role, Privilege: p, TableName: tableName}
+	}
+
+	return nil
+}
+
+func hasPrivilege(tx *Transaction, catalog Catalog, role string, tableName string, p Privilege, visited map[string]bool) (bool, error) {
+	if visited[role] {
+		return false, nil
+	}
+	visited[role] = true
+
+	r, err := GetRole(tx, catalog, role)
+	if err != nil {
+		// roles removed after being granted have no privileges
+		if errs.IsNotFoundError(err) {
+			return false, nil
+		}
+		return false, err
+	}
+
+	if r.hasPrivilege(tableName, p) || r.hasPrivilege(AllTables, p) {
+		return true, nil
+	}
+
+	for _, m := range r.MemberOf {
+		ok, err := hasPrivilege(tx, catalog, m, tableName, p, visited)
+		if err != nil || ok {
+			return ok, err
+		}
+	}
+
+	return false, nil
+}
+
+// PermissionDeniedError is returned when a role runs a statement
+// it doesn't have the privileges for.
+type PermissionDeniedError struct {
+	Role      string
+	Privilege Privilege
+	TableName string
+}
+
+func (e *PermissionDeniedError) Error() string {
+	if e.TableName == AllTables {
+		return stringutil.Sprintf("permission denied: %q requires %s on all tables", e.Role, e.Privilege)
+	}
+
+	return stringutil.Sprintf("permission denied: %q requires %s on table %q", e.Role, e.Privilege, e.TableName)
+}
@@ -3,6 +3,7 @@ package database
 import (
 	"errors"
 	"strings"
+	"sync"
 
 	"github.com/genjidb/genji/document"
 	errs "github.com/genjidb/genji/errors"
@@ -37,8 +38,12 @@ var sequenceTableInfo = &TableInfo{
 	},
 }
 
+// sequencesMu serializes the calls to Sequence.Next, which can be made
+// concurrently by optimistic transactions and the read/write transaction.
+var sequencesMu sync.Mutex
+
 // A Sequence manages a sequence of numbers.
-// It is not thread safe.
+// Next can be called concurrently, the other methods are not thread safe.
 type Sequence struct {
 	Info *SequenceInfo
 
@@ -91,6 +96,9 @@ func (s *Sequence) Next(tx *Transaction, catalog Catalog) (int64, error) {
 		return 0, errors.New("cannot increment sequence on read-only transaction")
 	}
 
+	sequencesMu.Lock()
+	defer sequencesMu.Unlock()
+
 	var newValue int64
 	if s.CurrentValue == nil {
 		newValue = s.Info.Start
@@ -205,7 +205,7 @@ func TestSequence(t *testing.T) {
 		})
 		require.NoError(t, err)
 
-		seq, err := db.Catalog.GetSequence("a")
+		seq, err := db.Catalog.GetSequence(tx, "a")
 		require.NoError(t, err)
 
 		// each call must increase the lease by 1 and store it in the table
@@ -243,7 +243,7 @@ func TestSequence(t *testing.T) {
 		})
 		require.NoError(t, err)
 
-		seq, err := db.Catalog.GetSequence("a")
+		seq, err := db.Catalog.GetSequence(tx, "a")
 		require.NoError(t, err)
 
 		// first call to next must increase the lease to 2 and store it in the table
@@ -290,7 +290,7 @@ func TestSequence(t *testing.T) {
 		})
 		require.NoError(t, err)
 
-		seq, err := db.Catalog.GetSequence("a")
+		seq, err := db.Catalog.GetSequence(tx, "a")
 		require.NoError(t, err)
 
 		// first call to next must decrease the lease to 3 and store it in the table
@@ -345,7 +345,7 @@ func TestSequence(t *testing.T) {
 		require.NoError(t, err)
 		defer tx.Rollback()
 
-		seq, err := db.Catalog.GetSequence("a")
+		seq, err := db.Catalog.GetSequence(tx, "a")
 		require.NoError(t, err)
 
 		_, err = seq.Next(tx, db.Catalog)
@@ -369,7 +369,7 @@ func TestSequence(t *testing.T) {
 		})
 		require.NoError(t, err)
 
-		seq, err := db.Catalog.GetSequence("a")
+		seq, err := db.Catalog.GetSequence(tx, "a")
 		require.NoError(t, err)
 
 		next(seq, tx, db.Catalog, 3, 7)
@@ -388,7 +388,7 @@ func TestSequence(t *testing.T) {
 
 		db.Catalog = c
 
-		seq, err = db.Catalog.GetSequence("a")
+		seq, err = db.Catalog.GetSequence(tx, "a")
 		require.NoError(t, err)
 
 		got, err = getLease(t, tx, db.Catalog, "a")
@@ -0,0 +1,268 @@
+package database
+
+import (
+	"sync"
+	"time"
+
+	"github.com/genjidb/genji/document"
+)
+
+// A Session keeps track of information about the statements run
+// against a database, like the number of documents they modified,
+// and holds the settings used to run them.
+// It is safe for concurrent use.
+type Session struct {
+	// counters are shared between a session and its forks.
+	counters *sessionCounters
+
+	mu       sync.Mutex
+	settings map[string]document.Value
+	// user the session is authenticated as.
+	// Sessions without user are not subject to privileges.
+	user string
+	// transaction opened by a BEGIN statement, used by the
+	// queries of the session until it is committed or rolled back.
+	tx *Transaction
+}
+
+type sessionCounters struct {
+	mu            sync.Mutex
+	changes       int64
+	totalChanges  int64
+	lastInsertKey document.Value
+}
+
+// NewSession returns a session using the default value of every setting.
+func NewSession() *Session {
+	return &Session{
+		counters: &sessionCounters{},
+	}
+}
+
+// Fork returns a new session with a copy of the settings of s.
+// Settings modified on the returned session don't affect s, but both
+// sessions keep track of the same changes.
+func (s *Session) Fork() *Session {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	fork := Session{
+		counters: s.counters,
+		user:     s.user,
+	}
+
+	if len(s.settings) > 0 {
+		fork.settings = make(map[string]document.Value, len(s.settings))
+		for k, v := range s.settings {
+			fork.settings[k] = v
+		}
+	}
+
+	return &fork
+}
+
+// User returns the name of the user the session is authenticated as,
+// or an empty string if the session is not authenticated.
+func (s *Session) User() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.user
+}
+
+// SetUser authenticates the session as the given user.
+// It is the responsibility of the caller to verify the credentials of the user.
+func (s *Session) SetUser(name string) {
+	s.mu.Lock()
+	s.user = name
+	s.mu.Unlock()
+}
+
+// Tx returns the transaction attached to the session using BEGIN,
+// or nil if there is none.
+func (s *Session) Tx() *Transaction {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.tx
+}
+
+// attachTx attaches tx to the session until it is committed or rolled back.
+func (s *Session) attachTx(tx *Transaction) {
+	s.mu.Lock()
+	s.tx = tx
+	s.mu.Unlock()
+}
+
+func (s *Session) detachTx(tx *Transaction) {
+	s.mu.Lock()
+	if s.tx == tx {
+		s.tx = nil
+	}
+	s.mu.Unlock()
+}
+
+// Get returns the value of the given setting.
+func (s *Session) Get(name string) (document.Value, error) {
+	st, err := GetSetting(name)
+	if err != nil {
+		return document.Value{}, err
+	}
+
+	return s.get(st), nil
+}
+
+func (s *Session) get(st *Setting) document.Value {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if v, ok := s.settings[st.Name]; ok {
+		return v
+	}
+
+	return st.Default
+}
+
+// Set validates v and uses it as the new value of the given setting.
+func (s *Session) Set(name string, v document.Value) error {
+	st, err := GetSetting(name)
+	if err != nil {
+		return err
+	}
+
+	v, err = st.Check(v)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	if s.settings == nil {
+		s.settings = make(map[string]document.Value)
+	}
+	s.settings[st.Name] = v
+	s.mu.Unlock()
+
+	return nil
+}
+
+// Settings returns the settings modified on the session, by name.
+func (s *Session) Settings() map[string]document.Value {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	settings := make(map[string]document.Value, len(s.settings))
+	for k, v := range s.settings {
+		settings[k] = v
+	}
+
+	return settings
+}
+
+// Reset restores the default value of the given setting.
+func (s *Session) Reset(name string) error {
+	st, err := GetSetting(name)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	delete(s.settings, st.Name)
+	s.mu.Unlock()
+
+	return nil
+}
+
+// StatementTimeout returns the maximum duration of a statement.
+// Zero means statements never time out.
+func (s *Session) StatementTimeout() time.Duration {
+	v := s.get(Settings["statement_timeout"])
+	return time.Duration(v.V.(int64)) * time.Millisecond
+}
+
+// TransactionTimeout returns the maximum duration of the transactions started using the session.
+// Zero means transactions never time out.
+func (s *Session) TransactionTimeout() time.Duration {
+	v := s.get(Settings["transaction_timeout"])
+	return time.Duration(v.V.(int64)) * time.Millisecond
+}
+
+// Strict reports whether values whose type differs from the type of their field
+// must be rejected, regardless of the conversion policy of the table.
+func (s *Session) Strict() bool {
+	return s.get(Settings["strict"]).V.(bool)
+}
+
+// WorkMem returns the amount of memory, in bytes, operators such as sorting or hash aggregation
+// may use before writing their data to temporary files. Zero means no limit.
+func (s *Session) WorkMem() int64 {
+	return s.get(Settings["work_mem"]).V.(int64)
+}
+
+// MaxParallelWorkers returns the number of goroutines parallel scans may use
+// to process the documents they read. Zero or one disables parallel scans.
+func (s *Session) MaxParallelWorkers() int {
+	return int(s.get(Settings["max_parallel_workers"]).V.(int64))
+}
+
+// BatchSize returns the number of documents processed at once by the operators
+// executing queries in batches. Zero disables batch execution.
+func (s *Session) BatchSize() int {
+	return int(s.get(Settings["batch_size"]).V.(int64))
+}
+
+// TimeZone returns the time zone timestamps are displayed in.
+func (s *Session) TimeZone() *time.Location {
+	// the setting was validated when it was set
+	loc, err := LoadTimeZone(s.get(Settings["time_zone"]).V.(string))
+	if err != nil {
+		return time.UTC
+	}
+
+	return loc
+}
+
+// RecordChanges must be called once a statement modifying the database
+// completes, with the number of documents it inserted, updated or deleted.
+func (s *Session) RecordChanges(n int64) {
+	s.counters.mu.Lock()
+	s.counters.changes = n
+	s.counters.totalChanges += n
+	s.counters.mu.Unlock()
+}
+
+// Changes returns the number of documents modified by the
+// most recently completed INSERT, UPDATE or DELETE statement.
+func (s *Session) Changes() int64 {
+	s.counters.mu.Lock()
+	defer s.counters.mu.Unlock()
+
+	return s.counters.changes
+}
+
+// TotalChanges returns the number of documents modified since the database was opened.
+func (s *Session) TotalChanges() int64 {
+	s.counters.mu.Lock()
+	defer s.counters.mu.Unlock()
+
+	return s.counters.totalChanges
+}
+
+// SetLastInsertKey stores the key of the last inserted document.
+func (s *Session) SetLastInsertKey(k document.Value) {
+	s.counters.mu.Lock()
+	s.counters.lastInsertKey = k
+	s.counters.mu.Unlock()
+}
+
+// LastInsertKey returns the key of the last inserted document,
+// or NULL if no document was inserted since the database was opened.
+func (s *Session) LastInsertKey() document.Value {
+	s.counters.mu.Lock()
+	defer s.counters.mu.Unlock()
+
+	if s.counters.lastInsertKey.Type.IsAny() {
+		return document.NewNullValue()
+	}
+
+	return s.counters.lastInsertKey
+}
@@ -0,0 +1,146 @@
+package database
+
+import (
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/genjidb/genji/document"
+	"github.com/genjidb/genji/internal/stringutil"
+)
+
+// A Setting is a parameter of a session that can be read
+// using the SHOW statement and modified using the SET statement.
+type Setting struct {
+	Name    string
+	Default document.Value
+	// Check validates a new value of the setting and
+	// returns it converted to the type of the setting.
+	Check func(v document.Value) (document.Value, error)
+}
+
+// Settings lists the settings supported by sessions.
+var Settings = map[string]*Setting{
+	"statement_timeout": {
+		Name:    "statement_timeout",
+		Default: document.NewIntegerValue(0),
+		Check:   checkDuration("statement_timeout"),
+	},
+	"strict": {
+		Name:    "strict",
+		Default: document.NewBoolValue(false),
+		Check: func(v document.Value) (document.Value, error) {
+			if v.Type != document.BoolValue {
+				return v, stringutil.Errorf("strict expects a boolean, got %v", v)
+			}
+
+			return v, nil
+		},
+	},
+	"work_mem": {
+		Name:    "work_mem",
+		Default: document.NewIntegerValue(0),
+		Check: func(v document.Value) (document.Value, error) {
+			if v.Type != document.IntegerValue || v.V.(int64) < 0 {
+				return v, stringutil.Errorf("work_mem expects a positive number of bytes, got %v", v)
+			}
+
+			return v, nil
+		},
+	},
+	"max_parallel_workers": {
+		Name:    "max_parallel_workers",
+		Default: document.NewIntegerValue(0),
+		Check: func(v document.Value) (document.Value, error) {
+			if v.Type != document.IntegerValue || v.V.(int64) < 0 {
+				return v, stringutil.Errorf("max_parallel_workers expects a positive number of workers, got %v", v)
+			}
+
+			return v, nil
+		},
+	},
+	"batch_size": {
+		Name:    "batch_size",
+		Default: document.NewIntegerValue(0),
+		Check: func(v document.Value) (document.Value, error) {
+			if v.Type != document.IntegerValue || v.V.(int64) < 0 {
+				return v, stringutil.Errorf("batch_size expects a positive number of documents, got %v", v)
+			}
+
+			return v, nil
+		},
+	},
+	"transaction_timeout": {
+		Name:    "transaction_timeout",
+		Default: document.NewIntegerValue(0),
+		Check:   checkDuration("transaction_timeout"),
+	},
+	"time_zone": {
+		Name:    "time_zone",
+		Default: document.NewTextValue("UTC"),
+		Check: func(v document.Value) (document.Value, error) {
+			if v.Type != document.TextValue {
+				return v, stringutil.Errorf("time_zone expects a time zone name, got %v", v)
+			}
+
+			_, err := LoadTimeZone(v.V.(string))
+			return v, err
+		},
+	},
+}
+
+// checkDuration validates the value of a setting expressed in milliseconds.
+// The value is either a number of milliseconds or a text parsed by time.ParseDuration,
+// like '5s', rounded up to the millisecond.
+func checkDuration(name string) func(v document.Value) (document.Value, error) {
+	return func(v document.Value) (document.Value, error) {
+		if v.Type == document.TextValue {
+			d, err := time.ParseDuration(v.V.(string))
+			if err != nil || d < 0 {
+				return v, stringutil.Errorf("%s expects a positive duration, got %v", name, v)
+			}
+
+			return document.NewIntegerValue(int64((d + time.Millisecond - 1) / time.Millisecond)), nil
+		}
+
+		if v.Type != document.IntegerValue || v.V.(int64) < 0 {
+			return v, stringutil.Errorf("%s expects a positive number of milliseconds, got %v", name, v)
+		}
+
+		return v, nil
+	}
+}
+
+// timeZones caches the time zones loaded by LoadTimeZone.
+var timeZones sync.Map
+
+// LoadTimeZone returns the time zone with the given IANA name, i.e. Europe/Paris.
+// The local time zone depends on the machine and is not accepted.
+func LoadTimeZone(name string) (*time.Location, error) {
+	if loc, ok := timeZones.Load(name); ok {
+		return loc.(*time.Location), nil
+	}
+
+	if name == "" || name == "Local" {
+		return nil, stringutil.Errorf("unknown time zone %q", name)
+	}
+
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		return nil, stringutil.Errorf("unknown time zone %q", name)
+	}
+
+	timeZones.Store(name, loc)
+	return loc, nil
+}
+
+// GetSetting returns the setting with the given name.
+// Setting names are case insensitive.
+func GetSetting(name string) (*Setting, error) {
+	s, ok := Settings[strings.ToLower(name)]
+	if !ok {
+		return nil, stringutil.Errorf("unknown setting %q", name)
+	}
+
+	return s, nil
+}
@@ -0,0 +1,147 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/genjidb/genji/document"
+	"github.com/genjidb/genji/engine"
+)
+
+// TombstoneStoreName returns the name of the store holding the tombstones of the
+// soft deleted documents of the table. Generated store names are varints,
+// whose last byte never has its most significant bit set, so it can't clash with them.
+func (ti *TableInfo) TombstoneStoreName() []byte {
+	return append(append([]byte{}, ti.StoreName...), 0xff)
+}
+
+// tombstones returns the tombstone store of the table.
+// If create is false and the store doesn't exist, it returns nil.
+func (t *Table) tombstones(create bool) (engine.Store, error) {
+	if t.tombstoneStore != nil {
+		return t.tombstoneStore, nil
+	}
+
+	var err error
+	if create {
+		t.tombstoneStore, err = getOrCreateStore(t.Tx.Tx, t.Info.TombstoneStoreName())
+	} else {
+		t.tombstoneStore, err = t.Tx.Tx.GetStore(t.Info.TombstoneStoreName())
+		if err == engine.ErrStoreNotFound {
+			return nil, nil
+		}
+	}
+
+	return t.tombstoneStore, err
+}
+
+// IsDeleted reports whether the document stored under key was soft deleted.
+func (t *Table) IsDeleted(key []byte) (bool, error) {
+	if !t.Info.SoftDelete {
+		return false, nil
+	}
+
+	st, err := t.tombstones(false)
+	if err != nil || st == nil {
+		return false, err
+	}
+
+	_, err = st.Get(key)
+	if err == engine.ErrKeyNotFound {
+		return false, nil
+	}
+
+	return err == nil, err
+}
+
+// SoftDelete writes a tombstone for the document stored under key, which hides it
+// from statements without removing it from the table and its indexes.
+// Subscribers and the change log see it as deleted.
+func (t *Table) SoftDelete(key []byte) error {
+	if t.Info.ReadOnly {
+		return errors.New("cannot write to read-only table")
+	}
+
+	d, err := t.GetDocument(key)
+	if err != nil {
+		return err
+	}
+
+	st, err := t.tombstones(true)
+	if err != nil {
+		return err
+	}
+
+	err = t.Tx.recordChange(t, ChangeDelete, key, d, nil)
+	if err != nil {
+		return err
+	}
+
+	// the tombstone holds the deletion time
+	ts, err := document.NewTimestampValue(time.Now()).MarshalBinary()
+	if err != nil {
+		return err
+	}
+
+	err = st.Put(key, ts)
+	if err != nil {
+		return err
+	}
+
+	t.Tx.recordModification(t.Info.TableName)
+	return nil
+}
+
+// Purge permanently deletes the document stored under key if it was soft deleted,
+// and reports whether it did.
+func (t *Table) Purge(key []byte) (bool, error) {
+	deleted, err := t.IsDeleted(key)
+	if err != nil || !deleted {
+		return false, err
+	}
+
+	// the deletion was already recorded by SoftDelete
+	err = t.delete(key, false)
+	if err != nil {
+		return false, err
+	}
+
+	st, err := t.tombstones(true)
+	if err != nil {
+		return false, err
+	}
+
+	return true, st.Delete(key)
+}
+
+// WithTombstones returns a row policy hiding the soft deleted documents of the table,
+// in addition to the documents hidden by p, unless the table shows them.
+// It returns p if the table doesn't soft delete its documents.
+func WithTombstones(t *Table, p *RowPolicy) *RowPolicy {
+	if !t.Info.SoftDelete {
+		return p
+	}
+
+	var tp RowPolicy
+	if p != nil {
+		tp = *p
+	}
+
+	read := tp.Read
+	tp.Read = func(ctx context.Context, d document.Document) (bool, error) {
+		if !t.ShowDeleted {
+			deleted, err := t.IsDeleted(d.(document.Keyer).RawKey())
+			if err != nil || deleted {
+				return false, err
+			}
+		}
+		if read == nil {
+			return true, nil
+		}
+
+		return read(ctx, d)
+	}
+
+	return &tp
+}
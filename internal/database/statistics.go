@@ -0,0 +1,497 @@
+package database
+
+import (
+	"bytes"
+	"sort"
+	"strings"
+	"sync/atomic"
+
+	"github.com/genjidb/genji/document"
+	errs "github.com/genjidb/genji/errors"
+	"github.com/genjidb/genji/internal/stringutil"
+)
+
+const (
+	StatisticsTableName = InternalPrefix + "stat"
+
+	// maximum number of buckets of the histogram of an index.
+	histogramBuckets = 64
+)
+
+// statisticsVersion is incremented every time a table is analyzed
+// and when the transaction analyzing it ends.
+var statisticsVersion uint64
+
+// StatisticsVersion returns a number that changes every time a table is analyzed,
+// and once more when the transaction analyzing it commits or rolls back.
+// Plans relying on statistics are valid as long as it doesn't change.
+func StatisticsVersion() uint64 {
+	return atomic.LoadUint64(&statisticsVersion)
+}
+
+var statisticsTableInfo = &TableInfo{
+	TableName: StatisticsTableName,
+	StoreName: []byte(StatisticsTableName),
+	FieldConstraints: []*FieldConstraint{
+		{
+			Path: document.Path{
+				document.PathFragment{
+					FieldName: "table_name",
+				},
+			},
+			Type:         document.TextValue,
+			IsPrimaryKey: true,
+		},
+		{
+			Path: document.Path{
+				document.PathFragment{
+					FieldName: "row_count",
+				},
+			},
+			Type: document.IntegerValue,
+		},
+		{
+			Path: document.Path{
+				document.PathFragment{
+					FieldName: "modifications",
+				},
+			},
+			Type: document.IntegerValue,
+		},
+	},
+}
+
+// TableStatistics describes the content of a table at the time it was analyzed.
+// Statistics are computed by the ANALYZE statement and stored in the __genji_stat table.
+type TableStatistics struct {
+	TableName string
+	// Number of documents in the table.
+	RowCount int64
+	// Statistics of each index of the table.
+	Indexes map[string]*IndexStatistics
+	// Number of documents inserted, updated or deleted since the table was analyzed.
+	Modifications int64
+}
+
+// IndexStatistics describes the content of an index at the time its table was analyzed.
+type IndexStatistics struct {
+	// Number of entries of the index.
+	Entries int64
+	// Number of distinct values of the index.
+	DistinctValues int64
+	// Equi-depth histogram of the encoded values of the index,
+	// ordered by upper bound.
+	Histogram []HistogramBucket
+}
+
+// HistogramBucket counts the entries of an index whose encoded value is greater
+// than the upper bound of the previous bucket and lower than or equal to its own.
+type HistogramBucket struct {
+	UpperBound []byte
+	Count      int64
+}
+
+// EstimateRange returns the estimated number of entries whose encoded value is
+// greater than or equal to min and lower than or equal to max, or prefixed by max.
+// Nil boundaries are ignored.
+// Buckets partially covered by the range are assumed to be half matched.
+func (s *IndexStatistics) EstimateRange(min, max []byte) float64 {
+	// values greater than or equal to min
+	afterMin := func(v []byte) bool {
+		return min == nil || bytes.Compare(v, min) >= 0
+	}
+	// values lower than or equal to max
+	beforeMax := func(v []byte) bool {
+		return max == nil || bytes.Compare(v, max) <= 0 || bytes.HasPrefix(v, max)
+	}
+
+	var n float64
+	for i, b := range s.Histogram {
+		var lower []byte
+		if i > 0 {
+			lower = s.Histogram[i-1].UpperBound
+		}
+
+		if !afterMin(b.UpperBound) {
+			continue
+		}
+
+		// the bucket only contains values greater than its lower bound
+		if lower != nil && max != nil && bytes.Compare(lower, max) >= 0 && (bytes.Equal(lower, max) || !bytes.HasPrefix(lower, max)) {
+			continue
+		}
+
+		if beforeMax(b.UpperBound) && (min == nil || (lower != nil && afterMin(lower))) {
+			n += float64(b.Count)
+		} else {
+			n += float64(b.Count) / 2
+		}
+	}
+
+	return n
+}
+
+// EstimateEqual returns the estimated number of entries equal to a given value,
+// assuming values are uniformly distributed.
+func (s *IndexStatistics) EstimateEqual() float64 {
+	if s.DistinctValues == 0 {
+		return 0
+	}
+
+	return float64(s.Entries) / float64(s.DistinctValues)
+}
+
+func (s *IndexStatistics) toDocument() document.Document {
+	histogram := document.NewValueBuffer()
+	for _, b := range s.Histogram {
+		bucket := document.NewFieldBuffer()
+		bucket.Add("upper_bound", document.NewBlobValue(b.UpperBound))
+		bucket.Add("count", document.NewIntegerValue(b.Count))
+		histogram.Append(document.NewDocumentValue(bucket))
+	}
+
+	buf := document.NewFieldBuffer()
+	buf.Add("entries", document.NewIntegerValue(s.Entries))
+	buf.Add("distinct_values", document.NewIntegerValue(s.DistinctValues))
+	buf.Add("histogram", document.NewArrayValue(histogram))
+	return buf
+}
+
+// indexStatisticsFromValue decodes the statistics of an index.
+// Statistics computed by previous versions only contain the number
+// of distinct values of the index.
+func indexStatisticsFromValue(v document.Value) (*IndexStatistics, error) {
+	var s IndexStatistics
+
+	// numbers of nested fields are stored as doubles
+	toInt := func(v document.Value) (int64, error) {
+		v, err := v.CastAsInteger()
+		if err != nil {
+			return 0, err
+		}
+		return v.V.(int64), nil
+	}
+
+	if v.Type != document.DocumentValue {
+		n, err := toInt(v)
+		s.DistinctValues = n
+		return &s, err
+	}
+
+	err := v.V.(document.Document).Iterate(func(field string, v document.Value) (err error) {
+		switch field {
+		case "entries":
+			s.Entries, err = toInt(v)
+		case "distinct_values":
+			s.DistinctValues, err = toInt(v)
+		case "histogram":
+			err = v.V.(document.Array).Iterate(func(i int, v document.Value) error {
+				d := v.V.(document.Document)
+
+				ub, err := d.GetByField("upper_bound")
+				if err != nil {
+					return err
+				}
+				c, err := d.GetByField("count")
+				if err != nil {
+					return err
+				}
+				count, err := toInt(c)
+				if err != nil {
+					return err
+				}
+
+				s.Histogram = append(s.Histogram, HistogramBucket{UpperBound: ub.V.([]byte), Count: count})
+				return nil
+			})
+		}
+
+		return err
+	})
+
+	return &s, err
+}
+
+// ToDocument returns a document representation of the statistics,
+// as stored in the __genji_stat table.
+func (s *TableStatistics) ToDocument() document.Document {
+	buf := document.NewFieldBuffer()
+	buf.Add("table_name", document.NewTextValue(s.TableName))
+	buf.Add("row_count", document.NewIntegerValue(s.RowCount))
+	buf.Add("modifications", document.NewIntegerValue(s.Modifications))
+
+	if len(s.Indexes) > 0 {
+		names := make([]string, 0, len(s.Indexes))
+		for name := range s.Indexes {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+
+		indexes := document.NewFieldBuffer()
+		for _, name := range names {
+			indexes.Add(name, document.NewDocumentValue(s.Indexes[name].toDocument()))
+		}
+		buf.Add("indexes", document.NewDocumentValue(indexes))
+	}
+
+	return buf
+}
+
+// statisticsFromDocument decodes statistics stored in the __genji_stat table.
+func statisticsFromDocument(d document.Document) (*TableStatistics, error) {
+	var s TableStatistics
+
+	err := d.Iterate(func(field string, v document.Value) error {
+		switch field {
+		case "table_name":
+			s.TableName = v.V.(string)
+		case "row_count":
+			s.RowCount = v.V.(int64)
+		case "modifications":
+			s.Modifications = v.V.(int64)
+		case "indexes":
+			s.Indexes = make(map[string]*IndexStatistics)
+			return v.V.(document.Document).Iterate(func(name string, v document.Value) error {
+				is, err := indexStatisticsFromValue(v)
+				if err != nil {
+					return err
+				}
+
+				s.Indexes[name] = is
+				return nil
+			})
+		}
+
+		return nil
+	})
+
+	return &s, err
+}
+
+// GetTableStatistics returns the statistics of the given table.
+// It returns errs.NotFoundError if the table was never analyzed.
+func GetTableStatistics(tx *Transaction, catalog Catalog, tableName string) (*TableStatistics, error) {
+	tb, err := catalog.GetTable(tx, StatisticsTableName)
+	if err != nil {
+		if errs.IsNotFoundError(err) {
+			return nil, errs.NotFoundError{Name: tableName}
+		}
+		return nil, err
+	}
+
+	d, err := tb.GetDocument([]byte(tableName))
+	if err != nil {
+		if err == errs.ErrDocumentNotFound {
+			return nil, errs.NotFoundError{Name: tableName}
+		}
+		return nil, err
+	}
+
+	return statisticsFromDocument(d)
+}
+
+// storeTableStatistics inserts or replaces the statistics of a table.
+func storeTableStatistics(tx *Transaction, catalog Catalog, s *TableStatistics) error {
+	tb, err := catalog.GetTable(tx, StatisticsTableName)
+	if err != nil {
+		if !errs.IsNotFoundError(err) {
+			return err
+		}
+
+		err = catalog.CreateTable(tx, StatisticsTableName, statisticsTableInfo.Clone())
+		if err != nil {
+			return err
+		}
+
+		tb, err = catalog.GetTable(tx, StatisticsTableName)
+		if err != nil {
+			return err
+		}
+	}
+
+	d := s.ToDocument()
+	_, err = tb.Replace([]byte(s.TableName), d)
+	if err == errs.ErrDocumentNotFound {
+		_, err = tb.Insert(d)
+	}
+	return err
+}
+
+// AnalyzeTable computes the statistics of the given table and stores them.
+func AnalyzeTable(tx *Transaction, catalog Catalog, tableName string) (*TableStatistics, error) {
+	tb, err := catalog.GetTable(tx, tableName)
+	if err != nil {
+		return nil, err
+	}
+
+	s := TableStatistics{
+		TableName: tableName,
+	}
+
+	err = tb.Iterate(tx.Context(), func(d document.Document) error {
+		s.RowCount++
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	for _, name := range catalog.ListIndexes(tx, tableName) {
+		idx, err := catalog.GetIndex(tx, name)
+		if err != nil {
+			return nil, err
+		}
+
+		is, err := analyzeIndex(tx, idx)
+		if err != nil {
+			return nil, err
+		}
+
+		if s.Indexes == nil {
+			s.Indexes = make(map[string]*IndexStatistics)
+		}
+		s.Indexes[name] = is
+	}
+
+	// plans prepared using the previous statistics must be discarded,
+	// as well as plans prepared using uncommitted ones.
+	atomic.AddUint64(&statisticsVersion, 1)
+	bump := func() {
+		atomic.AddUint64(&statisticsVersion, 1)
+	}
+	tx.OnCommitHooks = append(tx.OnCommitHooks, bump)
+	tx.OnRollbackHooks = append(tx.OnRollbackHooks, bump)
+
+	return &s, storeTableStatistics(tx, catalog, &s)
+}
+
+// analyzeIndex computes the statistics of an index.
+// Values are sorted and equal values are next to each other: the index is read once
+// to count its entries, and once more to build an equi-depth histogram whose buckets
+// never split equal values.
+func analyzeIndex(tx *Transaction, idx *Index) (*IndexStatistics, error) {
+	var s IndexStatistics
+	var prev []byte
+
+	err := idx.AscendGreaterOrEqual(tx.Context(), nil, func(val, key []byte) error {
+		if s.Entries == 0 || !bytes.Equal(prev, val) {
+			s.DistinctValues++
+			prev = append(prev[:0], val...)
+		}
+		s.Entries++
+		return nil
+	})
+	if err != nil || s.Entries == 0 {
+		return &s, err
+	}
+
+	depth := (s.Entries + histogramBuckets - 1) / histogramBuckets
+	var count int64
+	prev = prev[:0]
+
+	err = idx.AscendGreaterOrEqual(tx.Context(), nil, func(val, key []byte) error {
+		if count >= depth && !bytes.Equal(prev, val) {
+			s.Histogram = append(s.Histogram, HistogramBucket{UpperBound: append([]byte{}, prev...), Count: count})
+			count = 0
+		}
+
+		count++
+		prev = append(prev[:0], val...)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	s.Histogram = append(s.Histogram, HistogramBucket{UpperBound: prev, Count: count})
+	return &s, nil
+}
+
+// DeleteTableStatistics removes the statistics of the given table, if any.
+func DeleteTableStatistics(tx *Transaction, catalog Catalog, tableName string) error {
+	tb, err := catalog.GetTable(tx, StatisticsTableName)
+	if err != nil {
+		if errs.IsNotFoundError(err) {
+			return nil
+		}
+		return err
+	}
+
+	err = tb.Delete([]byte(tableName))
+	if err == errs.ErrDocumentNotFound {
+		return nil
+	}
+	return err
+}
+
+// RenameTableStatistics moves the statistics of a renamed table, if any.
+func RenameTableStatistics(tx *Transaction, catalog Catalog, oldName, newName string) error {
+	s, err := GetTableStatistics(tx, catalog, oldName)
+	if err != nil {
+		if errs.IsNotFoundError(err) {
+			return nil
+		}
+		return err
+	}
+
+	err = DeleteTableStatistics(tx, catalog, oldName)
+	if err != nil {
+		return err
+	}
+
+	s.TableName = newName
+	return storeTableStatistics(tx, catalog, s)
+}
+
+// RefreshStatistics adds the modifications made by the transaction to the statistics
+// of the user tables that were analyzed, and analyzes again the tables whose number of modifications
+// since they were last analyzed exceeds the fraction of their documents
+// set by the auto_analyze_threshold pragma.
+// Tables that were never analyzed are ignored.
+func RefreshStatistics(tx *Transaction, catalog Catalog) error {
+	if len(tx.modifications) == 0 {
+		return nil
+	}
+
+	tableNames := make([]string, 0, len(tx.modifications))
+	for name := range tx.modifications {
+		if !strings.HasPrefix(name, InternalPrefix) {
+			tableNames = append(tableNames, name)
+		}
+	}
+	sort.Strings(tableNames)
+
+	var threshold *float64
+
+	for _, tableName := range tableNames {
+		s, err := GetTableStatistics(tx, catalog, tableName)
+		if err != nil {
+			if errs.IsNotFoundError(err) {
+				continue
+			}
+			return err
+		}
+
+		if threshold == nil {
+			v, err := GetPragmaValue(tx, catalog, "auto_analyze_threshold")
+			if err != nil {
+				return err
+			}
+			t := v.V.(float64)
+			threshold = &t
+		}
+
+		s.Modifications += tx.modifications[tableName]
+
+		if *threshold > 0 && float64(s.Modifications) >= *threshold*float64(s.RowCount) {
+			_, err = AnalyzeTable(tx, catalog, tableName)
+		} else {
+			err = storeTableStatistics(tx, catalog, s)
+		}
+		if err != nil {
+			return stringutil.Errorf("failed to refresh the statistics of table %q: %w", tableName, err)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,73 @@
+package database_test
+
+import (
+	"testing"
+
+	"github.com/genjidb/genji/document"
+	"github.com/genjidb/genji/internal/database"
+	"github.com/genjidb/genji/internal/environment"
+	"github.com/genjidb/genji/internal/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAnalyzeTable(t *testing.T) {
+	db, tx, cleanup := testutil.NewTestTx(t)
+	defer cleanup()
+
+	testutil.MustExec(t, db, tx, `
+		CREATE TABLE test(a INTEGER);
+		CREATE INDEX idx_a ON test(a);
+	`)
+	// 100 distinct values, inserted 10 times each
+	for i := 0; i < 1000; i++ {
+		testutil.MustExec(t, db, tx, "INSERT INTO test (a) VALUES (?)", environment.Param{Value: i / 10})
+	}
+
+	s, err := database.AnalyzeTable(tx, db.Catalog, "test")
+	require.NoError(t, err)
+	require.EqualValues(t, 1000, s.RowCount)
+
+	is := s.Indexes["idx_a"]
+	require.EqualValues(t, 1000, is.Entries)
+	require.EqualValues(t, 100, is.DistinctValues)
+	require.Equal(t, 10.0, is.EstimateEqual())
+
+	// buckets contain at least 1000 / 64 entries, without splitting equal values
+	require.Len(t, is.Histogram, 50)
+	for _, b := range is.Histogram {
+		require.EqualValues(t, 20, b.Count)
+	}
+
+	// the statistics are stored
+	stored, err := database.GetTableStatistics(tx, db.Catalog, "test")
+	require.NoError(t, err)
+	require.Equal(t, s, stored)
+
+	idx, err := db.Catalog.GetIndex(tx, "idx_a")
+	require.NoError(t, err)
+	encode := func(v int64) []byte {
+		enc, err := idx.EncodeValueBuffer(document.NewValueBuffer(document.NewIntegerValue(v)))
+		require.NoError(t, err)
+		return enc
+	}
+
+	// buckets partially covered by a range are counted by half
+	tests := []struct {
+		name     string
+		min, max []byte
+		expected float64
+	}{
+		{"all", nil, nil, 1000},
+		{">= 50", encode(50), nil, 490},
+		{"<= 49", nil, encode(49), 500},
+		{"[10, 29]", encode(10), encode(29), 190},
+		{"= 10", encode(10), encode(10), 10},
+		{"> 99", encode(100), nil, 0},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			require.Equal(t, test.expected, is.EstimateRange(test.min, test.max))
+		})
+	}
+}
@@ -2,6 +2,7 @@ package database
 
 import (
 	"bytes"
+	"context"
 	"encoding/binary"
 	"errors"
 
@@ -28,10 +29,32 @@ type Table struct {
 
 	Catalog Catalog
 	Codec   encoding.Codec
+
+	// Policy restricts the documents statements can read and write.
+	// Read restrictions are enforced by the operators scanning the table.
+	Policy *RowPolicy
+
+	// ShowDeleted makes the soft deleted documents visible to the policy
+	// of the table, i.e. SELECT * FROM foo WITH DELETED.
+	ShowDeleted bool
+
+	// store of the tombstones of the soft deleted documents, loaded on first use
+	tombstoneStore engine.Store
 }
 
-// Truncate deletes all the documents from the table.
+// Truncate deletes all the documents from the table, including the soft deleted ones.
 func (t *Table) Truncate() error {
+	st, err := t.tombstones(false)
+	if err != nil {
+		return err
+	}
+	if st != nil {
+		err = st.Truncate()
+		if err != nil {
+			return err
+		}
+	}
+
 	return t.Store.Truncate()
 }
 
@@ -57,7 +80,12 @@ func (t *Table) InsertWithConflictResolution(d document.Document, onConflict OnI
 		return nil, errors.New("cannot write to read-only table")
 	}
 
-	fb, err := t.Info.FieldConstraints.ValidateDocument(t.Tx, d)
+	err := t.checkGeneratedFields(d)
+	if err != nil {
+		return nil, err
+	}
+
+	fb, err := t.Info.ValidateDocument(t.Tx, d)
 	if err != nil {
 		if onConflict != nil {
 			if ce, ok := err.(*ConstraintViolationError); ok && ce.Constraint == "NOT NULL" {
@@ -67,16 +95,34 @@ func (t *Table) InsertWithConflictResolution(d document.Document, onConflict OnI
 		return nil, err
 	}
 
-	key, err := t.generateKey(t.Info, d)
+	err = t.Policy.CheckWrite(t.Tx, t.Info.TableName, fb)
+	if err != nil {
+		return nil, err
+	}
+
+	key, err := t.generateKey(t.Info, fb)
 	if err != nil {
 		return nil, err
 	}
 
 	// ensure the key is not already present in the table
-	_, err = t.Store.Get(key)
-	if err == nil {
-		if onConflict != nil {
-			return onConflict(t, key, d, err)
+	exists, err := t.exists(key)
+	if err != nil {
+		return nil, err
+	}
+	if exists {
+		// soft deleted documents can't be updated or replaced
+		deleted, err := t.IsDeleted(key)
+		if err != nil {
+			return nil, err
+		}
+
+		if onConflict != nil && !deleted {
+			var ce ConflictError
+			if pk := t.Info.FieldConstraints.GetPrimaryKey(); pk != nil {
+				ce.Paths = []document.Path{pk.Path}
+			}
+			return onConflict(t, key, fb, &ce)
 		}
 
 		return nil, errs.ErrDuplicateDocument
@@ -86,6 +132,10 @@ func (t *Table) InsertWithConflictResolution(d document.Document, onConflict OnI
 	if err != nil {
 		return nil, err
 	}
+	indexes, err = t.indexesCovering(indexes, fb)
+	if err != nil {
+		return nil, err
+	}
 
 	// ensure there is no index violation
 	for _, idx := range indexes {
@@ -94,27 +144,36 @@ func (t *Table) InsertWithConflictResolution(d document.Document, onConflict OnI
 			continue
 		}
 
-		vs := make([]document.Value, 0, len(idx.Info.Paths))
+		entries, err := indexEntries(idx, fb)
+		if err != nil {
+			return nil, err
+		}
 
-		for _, path := range idx.Info.Paths {
-			v, err := path.GetValueFromDocument(fb)
+		for _, vs := range entries {
+			duplicate, dKey, err := idx.Exists(vs)
 			if err != nil {
-				v = document.NewNullValue()
+				return nil, err
+			}
+			if duplicate {
+				removed, err := t.removeExpired(dKey)
+				if err != nil {
+					return nil, err
+				}
+				duplicate = !removed
 			}
+			if duplicate {
+				deleted, err := t.IsDeleted(dKey)
+				if err != nil {
+					return nil, err
+				}
 
-			vs = append(vs, v)
-		}
+				violation := UniqueViolationError(idx.Info, vs)
+				if onConflict != nil && !deleted {
+					return onConflict(t, dKey, fb, &ConflictError{Paths: idx.Info.Paths, Violation: violation})
+				}
 
-		duplicate, dKey, err := idx.Exists(vs)
-		if err != nil {
-			return nil, err
-		}
-		if duplicate {
-			if onConflict != nil {
-				return onConflict(t, dKey, d, err)
+				return nil, violation
 			}
-
-			return nil, errs.ErrDuplicateDocument
 		}
 	}
 
@@ -134,28 +193,316 @@ func (t *Table) InsertWithConflictResolution(d document.Document, onConflict OnI
 
 	// update indexes
 	for _, idx := range indexes {
-		vs := make([]document.Value, 0, len(idx.Info.Paths))
+		err = setIndexEntries(idx, fb, key)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	t.Tx.recordModification(t.Info.TableName)
+
+	err = t.Tx.recordChange(t, ChangeInsert, key, nil, fb)
+	if err != nil {
+		return nil, err
+	}
+
+	return documentWithKey{
+		Document: fb,
+		key:      key,
+		pk:       t.Info.FieldConstraints.GetPrimaryKey(),
+	}, nil
+}
+
+// InsertBatch inserts the documents into the table, in order, and returns the inserted
+// documents, which implement the document.Keyer interface.
+// It is faster than inserting the documents one by one: indexes are loaded once,
+// the same encoder is used for all the documents, and non-unique indexes are updated
+// in a single pass, once all the documents are stored.
+// Unlike Insert, there is no conflict resolution: if a document can't be inserted,
+// InsertBatch stops and returns the documents inserted before it along with the error.
+func (t *Table) InsertBatch(docs []document.Document) ([]document.Document, error) {
+	if t.Info.ReadOnly {
+		return nil, errors.New("cannot write to read-only table")
+	}
+
+	indexes, err := t.GetIndexes()
+	if err != nil {
+		return nil, err
+	}
+
+	var unique, others Indexes
+	for _, idx := range indexes {
+		if idx.Info.Unique {
+			unique = append(unique, idx)
+		} else {
+			others = append(others, idx)
+		}
+	}
+
+	var buf bytes.Buffer
+	enc := t.Tx.Codec.NewEncoder(&buf)
+	defer enc.Close()
+
+	pk := t.Info.FieldConstraints.GetPrimaryKey()
+	inserted := make([]document.Document, 0, len(docs))
+	for _, d := range docs {
+		fb, key, err := t.insertBatched(d, unique, enc, &buf)
+		if err != nil {
+			// keep the indexes consistent with the documents already stored
+			if ierr := t.updateIndexes(others, inserted); ierr != nil {
+				return nil, ierr
+			}
+			return inserted, err
+		}
+
+		inserted = append(inserted, documentWithKey{
+			Document: fb,
+			key:      key,
+			pk:       pk,
+		})
+	}
+
+	err = t.updateIndexes(others, inserted)
+	if err != nil {
+		return nil, err
+	}
 
-		for _, path := range idx.Info.Paths {
-			v, err := path.GetValueFromDocument(fb)
+	return inserted, nil
+}
+
+// checkGeneratedFields returns an error if the document to insert
+// sets the value of a generated field, including to NULL.
+func (t *Table) checkGeneratedFields(d document.Document) error {
+	for _, fc := range t.Info.FieldConstraints {
+		if !fc.IsGenerated() {
+			continue
+		}
+
+		_, err := fc.Path.GetValueFromDocument(d)
+		if err == document.ErrFieldNotFound {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+
+		return stringutil.Errorf("cannot insert a value into generated field %q", fc.Path)
+	}
+
+	return nil
+}
+
+// updateIndexes adds the documents returned by InsertBatch to the given indexes.
+func (t *Table) updateIndexes(indexes Indexes, docs []document.Document) error {
+	for _, idx := range indexes {
+		for _, d := range docs {
+			dk := d.(documentWithKey)
+			ok, err := idx.Info.Covers(t.Tx, dk.Document)
 			if err != nil {
-				v = document.NewNullValue()
+				return err
+			}
+			if !ok {
+				continue
 			}
 
-			vs = append(vs, v)
+			err = setIndexEntries(idx, dk.Document, dk.key)
+			if err != nil {
+				return err
+			}
 		}
+	}
 
-		err = idx.Set(vs, key)
+	return nil
+}
+
+// indexesCovering returns the indexes in which the document must be stored,
+// leaving out the partial indexes whose predicate is not true for the document.
+func (t *Table) indexesCovering(indexes Indexes, d document.Document) (Indexes, error) {
+	var covering Indexes
+	for i, idx := range indexes {
+		ok, err := idx.Info.Covers(t.Tx, d)
 		if err != nil {
 			return nil, err
 		}
+
+		switch {
+		case !ok && covering == nil:
+			// the list is only copied if one of the indexes doesn't cover the document
+			covering = append(make(Indexes, 0, len(indexes)), indexes[:i]...)
+		case ok && covering != nil:
+			covering = append(covering, idx)
+		}
 	}
 
-	return documentWithKey{
-		Document: fb,
-		key:      key,
-		pk:       t.Info.FieldConstraints.GetPrimaryKey(),
-	}, nil
+	if covering == nil {
+		return indexes, nil
+	}
+
+	return covering, nil
+}
+
+// insertBatched stores a document of a batch and updates the given unique indexes.
+func (t *Table) insertBatched(d document.Document, unique Indexes, enc encoding.Encoder, buf *bytes.Buffer) (*document.FieldBuffer, []byte, error) {
+	err := t.checkGeneratedFields(d)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	fb, err := t.Info.ValidateDocument(t.Tx, d)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	err = t.Policy.CheckWrite(t.Tx, t.Info.TableName, fb)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	key, err := t.generateKey(t.Info, fb)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	exists, err := t.exists(key)
+	if err != nil {
+		return nil, nil, err
+	}
+	if exists {
+		return nil, nil, errs.ErrDuplicateDocument
+	}
+
+	unique, err = t.indexesCovering(unique, fb)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// unique indexes are updated right away, so that documents
+	// of the batch are checked against each other
+	for _, idx := range unique {
+		entries, err := indexEntries(idx, fb)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		for _, vs := range entries {
+			duplicate, dKey, err := idx.Exists(vs)
+			if err != nil {
+				return nil, nil, err
+			}
+			if duplicate {
+				removed, err := t.removeExpired(dKey)
+				if err != nil {
+					return nil, nil, err
+				}
+				duplicate = !removed
+			}
+			if duplicate {
+				return nil, nil, UniqueViolationError(idx.Info, vs)
+			}
+
+			err = idx.Set(vs, key)
+			if err != nil {
+				return nil, nil, err
+			}
+		}
+	}
+
+	buf.Reset()
+	err = enc.EncodeDocument(fb)
+	if err != nil {
+		return nil, nil, stringutil.Errorf("failed to encode document: %w", err)
+	}
+
+	// the engine may keep the value until the end of the transaction
+	data := make([]byte, buf.Len())
+	copy(data, buf.Bytes())
+	err = t.Store.Put(key, data)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	t.Tx.recordModification(t.Info.TableName)
+
+	err = t.Tx.recordChange(t, ChangeInsert, key, nil, fb)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return fb, key, nil
+}
+
+// indexValues returns the values of d indexed by idx, NULL for missing fields.
+func indexValues(idx *Index, d document.Document) []document.Value {
+	vs := make([]document.Value, 0, len(idx.Info.Paths))
+
+	for _, path := range idx.Info.Paths {
+		v, err := path.GetValueFromDocument(d)
+		if err != nil {
+			v = document.NewNullValue()
+		}
+
+		vs = append(vs, v)
+	}
+
+	return vs
+}
+
+// indexEntries returns the entries of d in idx.
+func indexEntries(idx *Index, d document.Document) ([][]document.Value, error) {
+	return idx.Entries(indexValues(idx, d))
+}
+
+// setIndexEntries associates the entries of d with the key in the index.
+func setIndexEntries(idx *Index, d document.Document, key []byte) error {
+	entries, err := indexEntries(idx, d)
+	if err != nil {
+		return err
+	}
+
+	for _, vs := range entries {
+		err = idx.Set(vs, key)
+		if err == ErrIndexDuplicateValue {
+			return UniqueViolationError(idx.Info, vs)
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// deleteIndexEntries removes the entries of d associated with the key from the index.
+func deleteIndexEntries(idx *Index, d document.Document, key []byte) error {
+	entries, err := indexEntries(idx, d)
+	if err != nil {
+		return err
+	}
+
+	for _, vs := range entries {
+		err = idx.Delete(vs, key)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// WithSessionSettings returns a copy of the table that validates
+// documents according to the settings of the session.
+// In strict mode, tables converting values reject them instead.
+func (t *Table) WithSessionSettings(session *Session) *Table {
+	if session == nil || !session.Strict() || t.Info.ConversionPolicy != ConvertPolicy {
+		return t
+	}
+
+	info := *t.Info
+	info.ConversionPolicy = RejectPolicy
+
+	tt := *t
+	tt.Info = &info
+	return &tt
 }
 
 // GetIndexes returns all indexes of the table.
@@ -164,7 +511,7 @@ func (t *Table) GetIndexes() (Indexes, error) {
 		return t.Indexes, nil
 	}
 
-	names := t.Catalog.ListIndexes(t.Info.TableName)
+	names := t.Catalog.ListIndexes(t.Tx, t.Info.TableName)
 	indexes := make(Indexes, 0, len(names))
 	for _, idxName := range names {
 		idx, err := t.Catalog.GetIndex(t.Tx, idxName)
@@ -181,6 +528,12 @@ func (t *Table) GetIndexes() (Indexes, error) {
 // Delete a document by key.
 // Indexes are automatically updated.
 func (t *Table) Delete(key []byte) error {
+	return t.delete(key, true)
+}
+
+// delete removes the document stored under key, and records
+// the deletion if record is true.
+func (t *Table) delete(key []byte, record bool) error {
 	if t.Info.ReadOnly {
 		return errors.New("cannot write to read-only table")
 	}
@@ -194,29 +547,33 @@ func (t *Table) Delete(key []byte) error {
 	if err != nil {
 		return err
 	}
+	indexes, err = t.indexesCovering(indexes, d)
+	if err != nil {
+		return err
+	}
 
 	for _, idx := range indexes {
-		vs := make([]document.Value, 0, len(idx.Info.Paths))
-		for _, path := range idx.Info.Paths {
-			v, err := path.GetValueFromDocument(d)
-			if err != nil {
-				if err == document.ErrFieldNotFound {
-					v = document.NewNullValue()
-				} else {
-					return err
-				}
-			}
-
-			vs = append(vs, v)
+		err = deleteIndexEntries(idx, d, key)
+		if err != nil {
+			return err
 		}
+	}
 
-		err = idx.Delete(vs, key)
+	// the document must be recorded while it can still be read
+	if record {
+		err = t.Tx.recordChange(t, ChangeDelete, key, d, nil)
 		if err != nil {
 			return err
 		}
 	}
 
-	return t.Store.Delete(key)
+	err = t.Store.Delete(key)
+	if err != nil {
+		return err
+	}
+
+	t.Tx.recordModification(t.Info.TableName)
+	return nil
 }
 
 // Replace a document by key.
@@ -227,12 +584,17 @@ func (t *Table) Replace(key []byte, d document.Document) (document.Document, err
 		return nil, errors.New("cannot write to read-only table")
 	}
 
-	d, err := t.Info.FieldConstraints.ValidateDocument(t.Tx, d)
+	fb, err := t.Info.ValidateDocument(t.Tx, d)
+	if err != nil {
+		return nil, err
+	}
+
+	err = t.Policy.CheckWrite(t.Tx, t.Info.TableName, fb)
 	if err != nil {
 		return nil, err
 	}
 
-	return d, t.replace(key, d)
+	return fb, t.replace(key, fb)
 }
 
 func (t *Table) replace(key []byte, d document.Document) error {
@@ -246,24 +608,29 @@ func (t *Table) replace(key []byte, d document.Document) error {
 	if err != nil {
 		return err
 	}
+	oldIndexes, err := t.indexesCovering(indexes, old)
+	if err != nil {
+		return err
+	}
+	indexes, err = t.indexesCovering(indexes, d)
+	if err != nil {
+		return err
+	}
 
 	// remove key from indexes
-	for _, idx := range indexes {
-		vs := make([]document.Value, 0, len(idx.Info.Paths))
-		for _, path := range idx.Info.Paths {
-			v, err := path.GetValueFromDocument(old)
-			if err != nil {
-				v = document.NewNullValue()
-			}
-			vs = append(vs, v)
-		}
-
-		err := idx.Delete(vs, key)
+	for _, idx := range oldIndexes {
+		err := deleteIndexEntries(idx, old, key)
 		if err != nil {
 			return err
 		}
 	}
 
+	// the old document must be recorded while it can still be read
+	err = t.Tx.recordChange(t, ChangeUpdate, key, old, d)
+	if err != nil {
+		return err
+	}
+
 	// encode new document
 	var buf bytes.Buffer
 	enc := t.Tx.Codec.NewEncoder(&buf)
@@ -281,26 +648,13 @@ func (t *Table) replace(key []byte, d document.Document) error {
 
 	// update indexes
 	for _, idx := range indexes {
-		vs := make([]document.Value, 0, len(idx.Info.Paths))
-		for _, path := range idx.Info.Paths {
-			v, err := path.GetValueFromDocument(d)
-			if err != nil {
-				v = document.NewNullValue()
-			}
-
-			vs = append(vs, v)
-		}
-
-		err = idx.Set(vs, key)
+		err = setIndexEntries(idx, d, key)
 		if err != nil {
-			if err == ErrIndexDuplicateValue {
-				return errs.ErrDuplicateDocument
-			}
-
 			return err
 		}
 	}
 
+	t.Tx.recordModification(t.Info.TableName)
 	return nil
 }
 
@@ -319,6 +673,11 @@ func (e documentWithKey) RawKey() []byte {
 	return e.key
 }
 
+// DecodePaths implements the encoding.PathsDecoder interface.
+func (e documentWithKey) DecodePaths(paths []document.Path, fn func(i int, v document.Value) error) error {
+	return encoding.DecodePaths(e.Document, paths, fn)
+}
+
 func (e documentWithKey) Key() (document.Value, error) {
 	if e.pk == nil {
 		docid, _ := binary.Uvarint(e.key)
@@ -344,41 +703,33 @@ type lazilyDecodedDocument struct {
 }
 
 func (d *lazilyDecodedDocument) GetByField(field string) (v document.Value, err error) {
-	if d.dirty {
-		d.dirty = false
-		err = d.copyFromItem()
-		if err != nil {
-			return
-		}
-
-		if d.decoder == nil {
-			d.decoder = d.codec.NewDecoder(d.buf)
-		} else {
-			d.decoder.Reset(d.buf)
-		}
+	err = d.decode()
+	if err != nil {
+		return
 	}
 
 	return d.decoder.GetByField(field)
 }
 
 func (d *lazilyDecodedDocument) Iterate(fn func(field string, value document.Value) error) error {
-	if d.dirty {
-		d.dirty = false
-		err := d.copyFromItem()
-		if err != nil {
-			return err
-		}
-
-		if d.decoder == nil {
-			d.decoder = d.codec.NewDecoder(d.buf)
-		} else {
-			d.decoder.Reset(d.buf)
-		}
+	err := d.decode()
+	if err != nil {
+		return err
 	}
 
 	return d.decoder.Iterate(fn)
 }
 
+// DecodePaths implements the encoding.PathsDecoder interface.
+func (d *lazilyDecodedDocument) DecodePaths(paths []document.Path, fn func(i int, v document.Value) error) error {
+	err := d.decode()
+	if err != nil {
+		return err
+	}
+
+	return encoding.DecodePaths(d.decoder, paths, fn)
+}
+
 func (d *lazilyDecodedDocument) RawKey() []byte {
 	return d.item.Key()
 }
@@ -398,6 +749,28 @@ func (d *lazilyDecodedDocument) Reset() {
 	d.item = nil
 }
 
+// decode copies the value of the item and prepares the decoder
+// the first time the document is read.
+func (d *lazilyDecodedDocument) decode() error {
+	if !d.dirty {
+		return nil
+	}
+
+	d.dirty = false
+	err := d.copyFromItem()
+	if err != nil {
+		return err
+	}
+
+	if d.decoder == nil {
+		d.decoder = d.codec.NewDecoder(d.buf)
+	} else {
+		d.decoder.Reset(d.buf)
+	}
+
+	return nil
+}
+
 func (d *lazilyDecodedDocument) copyFromItem() error {
 	var err error
 	d.buf, err = d.item.ValueCopy(d.buf)
@@ -411,8 +784,9 @@ func (d *lazilyDecodedDocument) MarshalJSON() ([]byte, error) {
 
 // Iterate goes through all the documents of the table and calls the given function by passing each one of them.
 // If the given function returns an error, the iteration stops.
-func (t *Table) Iterate(fn func(d document.Document) error) error {
-	return t.AscendGreaterOrEqual(document.Value{}, fn)
+// The iteration stops with the error of ctx as soon as it is canceled.
+func (t *Table) Iterate(ctx context.Context, fn func(d document.Document) error) error {
+	return t.AscendGreaterOrEqual(ctx, document.Value{}, fn)
 }
 
 // EncodeValue encodes a value following primary key constraints.
@@ -471,23 +845,44 @@ func (t *Table) encodeValueToKey(info *TableInfo, v document.Value) ([]byte, err
 	return buf.Bytes(), nil
 }
 
+// DecodeKey decodes the primary key of a document from its encoded key.
+// Documents of tables without primary key are identified by their docid.
+// It returns false if the key can't be decoded exactly, for example because
+// the primary key is not typed.
+func (t *Table) DecodeKey(key []byte) (document.Value, bool, error) {
+	pk := t.Info.FieldConstraints.GetPrimaryKey()
+	if pk == nil {
+		docid, _ := binary.Uvarint(key)
+		return document.NewIntegerValue(int64(docid)), true, nil
+	}
+
+	// untyped primary keys convert integers to doubles
+	if pk.Type.IsAny() {
+		return document.Value{}, false, nil
+	}
+
+	return decodeKeyValue(pk.Type, key)
+}
+
 // AscendGreaterOrEqual iterates over the documents of the table whose key
 // is greater than or equal to the pivot.
 // The pivot is converted to the type of the primary key, if any, prior to iteration.
 // If the pivot is empty, it iterates from the beginning of the table.
-func (t *Table) AscendGreaterOrEqual(pivot document.Value, fn func(d document.Document) error) error {
-	return t.iterate(pivot, false, fn)
+// The iteration stops with the error of ctx as soon as it is canceled.
+func (t *Table) AscendGreaterOrEqual(ctx context.Context, pivot document.Value, fn func(d document.Document) error) error {
+	return t.iterate(ctx, pivot, false, fn)
 }
 
 // DescendLessOrEqual iterates over the documents of the table whose key
 // is less than or equal to the pivot, in reverse order.
 // The pivot is converted to the type of the primary key, if any, prior to iteration.
 // If the pivot is empty, it iterates from the end of the table in reverse order.
-func (t *Table) DescendLessOrEqual(pivot document.Value, fn func(d document.Document) error) error {
-	return t.iterate(pivot, true, fn)
+// The iteration stops with the error of ctx as soon as it is canceled.
+func (t *Table) DescendLessOrEqual(ctx context.Context, pivot document.Value, fn func(d document.Document) error) error {
+	return t.iterate(ctx, pivot, true, fn)
 }
 
-func (t *Table) iterate(pivot document.Value, reverse bool, fn func(d document.Document) error) error {
+func (t *Table) iterate(ctx context.Context, pivot document.Value, reverse bool, fn func(d document.Document) error) error {
 	var seek []byte
 
 	// if there is a pivot, convert it to the right type
@@ -510,7 +905,14 @@ func (t *Table) iterate(pivot document.Value, reverse bool, fn func(d document.D
 	it := t.Store.Iterator(engine.IteratorOptions{Reverse: reverse})
 	defer it.Close()
 
+	done := ctx.Done()
 	for it.Seek(seek); it.Valid(); it.Next() {
+		select {
+		case <-done:
+			return ctx.Err()
+		default:
+		}
+
 		d.Reset()
 		d.item = it.Item()
 		// d must be passed as pointer, not value,
@@ -528,14 +930,54 @@ func (t *Table) iterate(pivot document.Value, reverse bool, fn func(d document.D
 	return nil
 }
 
+// ReadBatch returns at most n documents of the table, in key order, starting after the given
+// encoded key, or from the first document if after is nil.
+// The documents are copied from the store, and stay valid after the transaction moves on,
+// which allows decoding them on other goroutines.
+func (t *Table) ReadBatch(ctx context.Context, after []byte, reverse bool, n int) ([]document.Document, error) {
+	pk := t.Info.FieldConstraints.GetPrimaryKey()
+
+	it := t.Store.Iterator(engine.IteratorOptions{Reverse: reverse})
+	defer it.Close()
+
+	docs := make([]document.Document, 0, n)
+	done := ctx.Done()
+	for it.Seek(after); it.Valid() && len(docs) < n; it.Next() {
+		select {
+		case <-done:
+			return nil, ctx.Err()
+		default:
+		}
+
+		item := it.Item()
+		k := item.Key()
+		if after != nil && bytes.Equal(k, after) {
+			continue
+		}
+
+		v, err := item.ValueCopy(nil)
+		if err != nil {
+			return nil, err
+		}
+
+		var d documentWithKey
+		d.Document = t.Tx.Codec.NewDecoder(v)
+		d.key = append([]byte{}, k...)
+		d.pk = pk
+		docs = append(docs, &d)
+	}
+	if err := it.Err(); err != nil {
+		return nil, err
+	}
+
+	return docs, nil
+}
+
 // GetDocument returns one document by key.
 func (t *Table) GetDocument(key []byte) (document.Document, error) {
-	v, err := t.Store.Get(key)
+	v, err := t.get(key)
 	if err != nil {
-		if err == engine.ErrKeyNotFound {
-			return nil, errs.ErrDocumentNotFound
-		}
-		return nil, stringutil.Errorf("failed to fetch document %q: %w", key, err)
+		return nil, err
 	}
 
 	var d documentWithKey
@@ -545,6 +987,54 @@ func (t *Table) GetDocument(key []byte) (document.Document, error) {
 	return &d, err
 }
 
+func (t *Table) get(key []byte) ([]byte, error) {
+	v, err := t.Store.Get(key)
+	if err != nil {
+		if err == engine.ErrKeyNotFound {
+			return nil, errs.ErrDocumentNotFound
+		}
+		return nil, stringutil.Errorf("failed to fetch document %q: %w", key, err)
+	}
+
+	return v, nil
+}
+
+// A DocumentFetcher fetches the documents of a table by key, like GetDocument,
+// but reuses the same document and decoder for every document.
+// It is used by the scans fetching many documents one after the other.
+type DocumentFetcher struct {
+	table   *Table
+	d       documentWithKey
+	decoder encoding.Decoder
+}
+
+// NewDocumentFetcher creates a DocumentFetcher for the table.
+func (t *Table) NewDocumentFetcher() *DocumentFetcher {
+	return &DocumentFetcher{
+		table: t,
+		d:     documentWithKey{pk: t.Info.FieldConstraints.GetPrimaryKey()},
+	}
+}
+
+// GetDocument returns one document by key.
+// The document is only valid until the next call.
+func (f *DocumentFetcher) GetDocument(key []byte) (document.Document, error) {
+	v, err := f.table.get(key)
+	if err != nil {
+		return nil, err
+	}
+
+	if f.decoder == nil {
+		f.decoder = f.table.Tx.Codec.NewDecoder(v)
+	} else {
+		f.decoder.Reset(v)
+	}
+
+	f.d.Document = f.decoder
+	f.d.key = key
+	return &f.d, nil
+}
+
 // generate a key for d based on the table configuration.
 // if the table has a primary key, it extracts the field from
 // the document, converts it to the targeted type and returns
@@ -577,7 +1067,7 @@ func (t *Table) generateKey(info *TableInfo, d document.Document) ([]byte, error
 		return buf.Bytes(), nil
 	}
 
-	seq, err := t.Catalog.GetSequence(t.Info.DocidSequenceName)
+	seq, err := t.Catalog.GetSequence(t.Tx, t.Info.DocidSequenceName)
 	if err != nil {
 		return nil, err
 	}
@@ -91,7 +91,7 @@ func TestTableIterate(t *testing.T) {
 		defer cleanup()
 
 		i := 0
-		err := tb.Iterate(func(d document.Document) error {
+		err := tb.Iterate(context.Background(), func(d document.Document) error {
 			i++
 			return nil
 		})
@@ -109,7 +109,7 @@ func TestTableIterate(t *testing.T) {
 		}
 
 		m := make(map[string]int)
-		err := tb.Iterate(func(d document.Document) error {
+		err := tb.Iterate(context.Background(), func(d document.Document) error {
 			m[string(d.(document.Keyer).RawKey())]++
 			return nil
 		})
@@ -130,7 +130,7 @@ func TestTableIterate(t *testing.T) {
 		}
 
 		i := 0
-		err := tb.Iterate(func(_ document.Document) error {
+		err := tb.Iterate(context.Background(), func(_ document.Document) error {
 			i++
 			if i >= 5 {
 				return errors.New("some error")
@@ -140,6 +140,30 @@ func TestTableIterate(t *testing.T) {
 		require.EqualError(t, err, "some error")
 		require.Equal(t, 5, i)
 	})
+
+	t.Run("Should stop if ctx is canceled", func(t *testing.T) {
+		tb, cleanup := newTestTable(t)
+		defer cleanup()
+
+		for i := 0; i < 10; i++ {
+			_, err := tb.Insert(newDocument())
+			require.NoError(t, err)
+		}
+
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		i := 0
+		err := tb.Iterate(ctx, func(_ document.Document) error {
+			i++
+			if i == 5 {
+				cancel()
+			}
+			return nil
+		})
+		require.Equal(t, context.Canceled, err)
+		require.Equal(t, 5, i)
+	})
 }
 
 // TestTableGetDocument verifies GetDocument behaviour.
@@ -175,6 +199,36 @@ func TestTableGetDocument(t *testing.T) {
 		require.NoError(t, err)
 		require.Equal(t, vc, fc)
 	})
+
+	t.Run("Should reuse the document with a fetcher", func(t *testing.T) {
+		tb, cleanup := newTestTable(t)
+		defer cleanup()
+
+		doc1 := newDocument()
+		doc1.Add("fieldc", document.NewDoubleValue(40))
+		d1, err := tb.Insert(doc1)
+		require.NoError(t, err)
+		d2, err := tb.Insert(newDocument())
+		require.NoError(t, err)
+
+		f := tb.NewDocumentFetcher()
+
+		_, err = f.GetDocument([]byte("id"))
+		require.Equal(t, errs.ErrDocumentNotFound, err)
+
+		res1, err := f.GetDocument(d1.(document.Keyer).RawKey())
+		require.NoError(t, err)
+		testutil.RequireDocEqual(t, doc1, res1)
+
+		res2, err := f.GetDocument(d2.(document.Keyer).RawKey())
+		require.NoError(t, err)
+		require.Equal(t, d2.(document.Keyer).RawKey(), res2.(document.Keyer).RawKey())
+		_, err = res2.GetByField("fieldc")
+		require.Equal(t, document.ErrFieldNotFound, err)
+
+		// the same document is returned every time
+		require.True(t, res1 == res2)
+	})
 }
 
 // TestTableInsert verifies Insert behaviour.
@@ -364,7 +418,7 @@ func TestTableInsert(t *testing.T) {
 		require.NoError(t, err)
 
 		var count int
-		err = idx.AscendGreaterOrEqual([]document.Value{{}}, func(val, k []byte) error {
+		err = idx.AscendGreaterOrEqual(context.Background(), []document.Value{{}}, func(val, k []byte) error {
 			switch count {
 			case 0:
 				// key2, which doesn't countain the field must appear first in the next,
@@ -387,8 +441,8 @@ func TestTableInsert(t *testing.T) {
 		tb := createTable(t, tx, db.Catalog, database.TableInfo{
 			TableName: "test",
 			FieldConstraints: []*database.FieldConstraint{
-				{testutil.ParseDocumentPath(t, "foo"), document.DocumentValue, false, false, false, nil, nil, true, []document.Path{testutil.ParseDocumentPath(t, "foo.bar")}},
-				{testutil.ParseDocumentPath(t, "foo.bar"), document.IntegerValue, false, false, false, nil, nil, true, []document.Path{testutil.ParseDocumentPath(t, "foo")}},
+				{testutil.ParseDocumentPath(t, "foo"), document.DocumentValue, false, false, false, nil, nil, nil, true, []document.Path{testutil.ParseDocumentPath(t, "foo.bar")}, 0, 0, 0, 0},
+				{testutil.ParseDocumentPath(t, "foo.bar"), document.IntegerValue, false, false, false, nil, nil, nil, true, []document.Path{testutil.ParseDocumentPath(t, "foo")}, 0, 0, 0, 0},
 			},
 		})
 
@@ -429,7 +483,7 @@ func TestTableInsert(t *testing.T) {
 
 		err := db.Catalog.CreateTable(tx, "test", &database.TableInfo{
 			FieldConstraints: []*database.FieldConstraint{
-				{testutil.ParseDocumentPath(t, "foo"), document.DoubleValue, false, false, false, nil, nil, false, nil},
+				{testutil.ParseDocumentPath(t, "foo"), document.DoubleValue, false, false, false, nil, nil, nil, false, nil, 0, 0, 0, 0},
 			},
 		})
 		require.NoError(t, err)
@@ -452,7 +506,7 @@ func TestTableInsert(t *testing.T) {
 		tb1 := createTable(t, tx, db.Catalog, database.TableInfo{
 			TableName: "test1",
 			FieldConstraints: []*database.FieldConstraint{
-				{testutil.ParseDocumentPath(t, "foo"), 0, false, true, false, nil, nil, false, nil},
+				{testutil.ParseDocumentPath(t, "foo"), 0, false, true, false, nil, nil, nil, false, nil, 0, 0, 0, 0},
 			},
 		})
 
@@ -460,7 +514,7 @@ func TestTableInsert(t *testing.T) {
 		tb2 := createTable(t, tx, db.Catalog, database.TableInfo{
 			TableName: "test2",
 			FieldConstraints: []*database.FieldConstraint{
-				{testutil.ParseDocumentPath(t, "foo"), document.IntegerValue, false, true, false, nil, nil, false, nil},
+				{testutil.ParseDocumentPath(t, "foo"), document.IntegerValue, false, true, false, nil, nil, nil, false, nil, 0, 0, 0, 0},
 			},
 		})
 
@@ -503,7 +557,7 @@ func TestTableInsert(t *testing.T) {
 		tb1 := createTable(t, tx, db.Catalog, database.TableInfo{
 			TableName: "test1",
 			FieldConstraints: []*database.FieldConstraint{
-				{testutil.ParseDocumentPath(t, "foo"), 0, false, true, false, expr.Constraint(testutil.IntegerValue(42)), nil, false, nil},
+				{testutil.ParseDocumentPath(t, "foo"), 0, false, true, false, expr.Constraint(testutil.IntegerValue(42)), nil, nil, false, nil, 0, 0, 0, 0},
 			},
 		})
 
@@ -511,7 +565,7 @@ func TestTableInsert(t *testing.T) {
 		tb2 := createTable(t, tx, db.Catalog, database.TableInfo{
 			TableName: "test2",
 			FieldConstraints: []*database.FieldConstraint{
-				{testutil.ParseDocumentPath(t, "foo"), document.IntegerValue, false, true, false, expr.Constraint(testutil.IntegerValue(42)), nil, false, nil},
+				{testutil.ParseDocumentPath(t, "foo"), document.IntegerValue, false, true, false, expr.Constraint(testutil.IntegerValue(42)), nil, nil, false, nil, 0, 0, 0, 0},
 			},
 		})
 
@@ -565,7 +619,7 @@ func TestTableInsert(t *testing.T) {
 		tb := createTable(t, tx, db.Catalog, database.TableInfo{
 			TableName: "test1",
 			FieldConstraints: []*database.FieldConstraint{
-				{testutil.ParseDocumentPath(t, "foo[1]"), 0, false, true, false, nil, nil, false, nil},
+				{testutil.ParseDocumentPath(t, "foo[1]"), 0, false, true, false, nil, nil, nil, false, nil, 0, 0, 0, 0},
 			},
 		})
 
@@ -586,7 +640,7 @@ func TestTableInsert(t *testing.T) {
 		tb := createTable(t, tx, db.Catalog, database.TableInfo{
 			TableName: "test",
 			FieldConstraints: []*database.FieldConstraint{
-				{testutil.ParseDocumentPath(t, "foo"), 0, true, true, false, nil, nil, false, nil},
+				{testutil.ParseDocumentPath(t, "foo"), 0, true, true, false, nil, nil, nil, false, nil, 0, 0, 0, 0},
 			}})
 
 		doc := document.NewFieldBuffer().
@@ -608,7 +662,7 @@ func TestTableInsert(t *testing.T) {
 		tb := createTable(t, tx, db.Catalog, database.TableInfo{
 			TableName: "test",
 			FieldConstraints: []*database.FieldConstraint{
-				{testutil.ParseDocumentPath(t, "foo"), 0, true, true, false, nil, nil, false, nil},
+				{testutil.ParseDocumentPath(t, "foo"), 0, true, true, false, nil, nil, nil, false, nil, 0, 0, 0, 0},
 			}})
 
 		doc := document.NewFieldBuffer().
@@ -656,7 +710,14 @@ func TestTableInsert(t *testing.T) {
 
 		// insert again, should fail
 		_, err = tb.Insert(doc)
-		require.Equal(t, errs.ErrDuplicateDocument, err)
+		require.ErrorIs(t, err, errs.ErrDuplicateDocument)
+		require.Equal(t, &errs.ConstraintViolationError{
+			TableName: "test",
+			IndexName: "idx_test_foo",
+			Paths:     []document.Path{document.NewPath("foo")},
+			Values:    []document.Value{document.NewDoubleValue(10)},
+		}, err)
+		require.EqualError(t, err, `duplicate document: unique index "idx_test_foo" violated`)
 	})
 
 	t.Run("Should run the onConflict function if there is a unique constraint violation", func(t *testing.T) {
@@ -702,7 +763,7 @@ func TestTableInsert(t *testing.T) {
 		tb := createTable(t, tx, db.Catalog, database.TableInfo{
 			TableName: "test",
 			FieldConstraints: []*database.FieldConstraint{
-				{testutil.ParseDocumentPath(t, "foo"), 0, false, true, false, nil, nil, false, nil},
+				{testutil.ParseDocumentPath(t, "foo"), 0, false, true, false, nil, nil, nil, false, nil, 0, 0, 0, 0},
 			},
 		})
 
@@ -727,7 +788,7 @@ func TestTableInsert(t *testing.T) {
 
 		err := db.Catalog.CreateTable(tx, "test", &database.TableInfo{
 			FieldConstraints: []*database.FieldConstraint{
-				{testutil.ParseDocumentPath(t, "foo"), 0, true, true, false, nil, nil, false, nil},
+				{testutil.ParseDocumentPath(t, "foo"), 0, true, true, false, nil, nil, nil, false, nil, 0, 0, 0, 0},
 			}})
 		require.NoError(t, err)
 
@@ -753,7 +814,7 @@ func TestTableInsert(t *testing.T) {
 
 		err := db.Catalog.CreateTable(tx, "test", &database.TableInfo{
 			FieldConstraints: []*database.FieldConstraint{
-				{testutil.ParseDocumentPath(t, "foo"), 0, false, true, false, nil, nil, false, nil},
+				{testutil.ParseDocumentPath(t, "foo"), 0, false, true, false, nil, nil, nil, false, nil, 0, 0, 0, 0},
 			}})
 		require.NoError(t, err)
 
@@ -767,6 +828,79 @@ func TestTableInsert(t *testing.T) {
 		_, err = tb.InsertWithConflictResolution(doc, database.OnInsertConflictDoReplace)
 		require.Error(t, err)
 	})
+
+	t.Run("Should enforce the maximum length of text fields", func(t *testing.T) {
+		for _, truncate := range []bool{false, true} {
+			db, tx, cleanup := newTestTx(t)
+			defer cleanup()
+
+			tb := createTable(t, tx, db.Catalog, database.TableInfo{
+				TableName: "test",
+				FieldConstraints: []*database.FieldConstraint{
+					{Path: testutil.ParseDocumentPath(t, "foo"), Type: document.TextValue, MaxLength: 3},
+				},
+				TruncateText: truncate,
+			})
+
+			d, err := tb.Insert(document.NewFieldBuffer().Add("foo", document.NewTextValue("héé")))
+			require.NoError(t, err)
+			v, err := d.GetByField("foo")
+			require.NoError(t, err)
+			require.Equal(t, document.NewTextValue("héé"), v)
+
+			d, err = tb.Insert(document.NewFieldBuffer().Add("foo", document.NewTextValue("héllo")))
+			if !truncate {
+				require.Error(t, err)
+				continue
+			}
+			require.NoError(t, err)
+			v, err = d.GetByField("foo")
+			require.NoError(t, err)
+			require.Equal(t, document.NewTextValue("hél"), v)
+		}
+	})
+
+	t.Run("Should convert values according to the conversion policy", func(t *testing.T) {
+		tests := []struct {
+			policy database.ConversionPolicy
+			in     document.Value
+			want   document.Value
+			fails  bool
+		}{
+			{database.ConvertPolicy, document.NewTextValue("10"), document.NewIntegerValue(10), false},
+			{database.ConvertPolicy, document.NewTextValue("foo"), document.Value{}, true},
+			{database.RejectPolicy, document.NewIntegerValue(10), document.NewIntegerValue(10), false},
+			{database.RejectPolicy, document.NewTextValue("10"), document.Value{}, true},
+			{database.RejectPolicy, document.NewNullValue(), document.NewNullValue(), false},
+			{database.UntypedPolicy, document.NewTextValue("10"), document.NewIntegerValue(10), false},
+			{database.UntypedPolicy, document.NewTextValue("foo"), document.NewTextValue("foo"), false},
+		}
+
+		for _, test := range tests {
+			t.Run(test.policy.String()+"/"+test.in.String(), func(t *testing.T) {
+				db, tx, cleanup := newTestTx(t)
+				defer cleanup()
+
+				tb := createTable(t, tx, db.Catalog, database.TableInfo{
+					TableName: "test",
+					FieldConstraints: []*database.FieldConstraint{
+						{Path: testutil.ParseDocumentPath(t, "foo"), Type: document.IntegerValue},
+					},
+					ConversionPolicy: test.policy,
+				})
+
+				d, err := tb.Insert(document.NewFieldBuffer().Add("foo", test.in))
+				if test.fails {
+					require.Error(t, err)
+					return
+				}
+				require.NoError(t, err)
+				v, err := d.GetByField("foo")
+				require.NoError(t, err)
+				require.Equal(t, test.want, v)
+			})
+		}
+	})
 }
 
 // TestTableDelete verifies Delete behaviour.
@@ -917,7 +1051,8 @@ func TestTableReplace(t *testing.T) {
 		_, err = tb.Replace(d1.(document.Keyer).RawKey(), testutil.MakeDocument(t, `{"a": 3, "b": 3}`))
 
 		// index should be the same as before
-		require.Equal(t, errs.ErrDuplicateDocument, err)
+		require.ErrorIs(t, err, errs.ErrDuplicateDocument)
+		require.True(t, errs.IsConstraintViolationError(err))
 
 		// --- x, y
 		tb, err = db.Catalog.GetTable(tx, "test2")
@@ -949,7 +1084,8 @@ func TestTableReplace(t *testing.T) {
 		_, err = tb.Replace(dc1.(document.Keyer).RawKey(), testutil.MakeDocument(t, `{"x": 3, "y": 3, "z": 3}`))
 
 		// index should be the same as before
-		require.Equal(t, errs.ErrDuplicateDocument, err)
+		require.ErrorIs(t, err, errs.ErrDuplicateDocument)
+		require.Equal(t, []document.Path{document.NewPath("x"), document.NewPath("y")}, err.(*errs.ConstraintViolationError).Paths)
 	})
 }
 
@@ -979,7 +1115,7 @@ func TestTableTruncate(t *testing.T) {
 		err = tb.Truncate()
 		require.NoError(t, err)
 
-		err = tb.Iterate(func(_ document.Document) error {
+		err = tb.Iterate(context.Background(), func(_ document.Document) error {
 			return errors.New("should not iterate")
 		})
 
@@ -1091,7 +1227,7 @@ func BenchmarkTableScan(b *testing.B) {
 
 			b.ResetTimer()
 			for i := 0; i < b.N; i++ {
-				tb.Iterate(func(document.Document) error {
+				tb.Iterate(context.Background(), func(document.Document) error {
 					return nil
 				})
 			}
@@ -0,0 +1,65 @@
+package database
+
+import (
+	"sort"
+	"sync"
+)
+
+// A ReadTracker records the tables read by a transaction and whether the statements
+// it ran evaluated non-deterministic expressions, such as random().
+// It is used to determine if the result of a query can be cached, and which
+// modifications must invalidate it.
+// Its methods can be called on a nil ReadTracker, in which case nothing is recorded.
+type ReadTracker struct {
+	mu       sync.Mutex
+	tables   map[string]struct{}
+	volatile bool
+}
+
+// AddTable records that the given table was read.
+func (t *ReadTracker) AddTable(tableName string) {
+	if t == nil {
+		return
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.tables == nil {
+		t.tables = make(map[string]struct{})
+	}
+	t.tables[tableName] = struct{}{}
+}
+
+// SetVolatile records that a non-deterministic expression was evaluated.
+func (t *ReadTracker) SetVolatile() {
+	if t == nil {
+		return
+	}
+
+	t.mu.Lock()
+	t.volatile = true
+	t.mu.Unlock()
+}
+
+// Tables returns the names of the tables read, sorted lexicographically.
+func (t *ReadTracker) Tables() []string {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	names := make([]string, 0, len(t.tables))
+	for name := range t.tables {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	return names
+}
+
+// Volatile reports whether a non-deterministic expression was evaluated.
+func (t *ReadTracker) Volatile() bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	return t.volatile
+}
@@ -1,10 +1,15 @@
 package database
 
 import (
+	"context"
+	"errors"
 	"sync"
+	"time"
 
+	"github.com/genjidb/genji/document"
 	"github.com/genjidb/genji/document/encoding"
 	"github.com/genjidb/genji/engine"
+	errs "github.com/genjidb/genji/errors"
 )
 
 // Transaction represents a database transaction. It provides methods for managing the
@@ -14,56 +19,230 @@ import (
 type Transaction struct {
 	Tx       engine.Transaction
 	Writable bool
-	DBMu     *sync.RWMutex
-	Codec    encoding.Codec
+	// DBMu is read-locked by read-only transactions until they end.
+	DBMu  *sync.RWMutex
+	Codec encoding.Codec
+	// Ctx is the context the transaction was started with.
+	Ctx context.Context
+	// StartTime is the time the transaction was started at.
+	StartTime time.Time
+	// Deadline is the time after which the statements and the commit of the
+	// transaction fail with errs.ErrTransactionTimeout, if set.
+	Deadline time.Time
 
+	// these functions are run before committing. If one of them
+	// returns an error, the transaction is not committed.
+	OnBeforeCommitHooks []func() error
 	// these functions are run after a successful rollback.
 	OnRollbackHooks []func()
 	// these functions are run after a successful commit.
 	OnCommitHooks []func()
+	// these functions are run after a successful commit, once the
+	// transaction released its lock and other transactions can start.
+	OnAfterCommitHooks []func()
+
+	// CatalogSnapshot is the catalog as of the moment the transaction started,
+	// read by read-only and optimistic transactions. It is set by the catalog, if it supports it.
+	CatalogSnapshot interface{}
+
+	// ReadTracker, if set, records the tables read by the transaction.
+	ReadTracker *ReadTracker
+
+	// number of documents written to each table by the transaction.
+	modifications map[string]int64
+
+	// changes recorded for the subscribers of the feed
+	// and for the change log.
+	changeFeed *ChangeFeed
+	changes    []Change
+	// cached value of the change_log pragma, nil if not read yet.
+	changeLog *bool
+	// writes made to the engine, recorded for replicas.
+	writes []Write
+	// set once the transaction is committed or rolled back,
+	// and its lock released.
+	terminated bool
+	// locked by read/write transactions.
+	writeMu *sync.Mutex
+	// locked by read/write transactions modifying the catalog while they commit,
+	// and read-locked by read-only transactions while they start.
+	catalogMu *sync.RWMutex
+	// set if the transaction modifies the catalog. See LockCatalog.
+	catalogLocked bool
+	// set if the transaction is optimistic, in which case it is also Tx.
+	optimistic *optimisticTx
+}
+
+// LockCatalog must be called by read/write transactions before modifying the catalog.
+// Read/write transactions run one at a time, which serializes the modifications of the catalog.
+// Read-only transactions keep running concurrently with the transaction and read the
+// catalog as of the moment they started, along with the matching snapshot of the engine.
+// Only the commit of the transaction, which publishes the modified catalog, waits for
+// read-only transactions to be done starting, and delays the new ones.
+// Optimistic transactions cannot modify the catalog.
+func (tx *Transaction) LockCatalog() error {
+	if tx.optimistic != nil {
+		return errors.New("cannot modify the schema within an optimistic transaction")
+	}
+
+	if tx.Writable {
+		tx.catalogLocked = true
+	}
+
+	return nil
+}
+
+// recordModification increments the number of documents written to the given table.
+func (tx *Transaction) recordModification(tableName string) {
+	if tx.modifications == nil {
+		tx.modifications = make(map[string]int64)
+	}
+	tx.modifications[tableName]++
+}
+
+// recordChange records a write to a document of the table if the table has subscribers
+// or if the change log is enabled.
+// Documents are copied, as they may not be readable once the transaction ends.
+func (tx *Transaction) recordChange(t *Table, op ChangeOp, key []byte, old, new document.Document) error {
+	var logged bool
+	if t.Catalog != nil && isLoggedTable(t.Info.TableName) {
+		var err error
+		logged, err = tx.changeLogEnabled(t.Catalog)
+		if err != nil {
+			return err
+		}
+	}
+
+	if !logged && !tx.changeFeed.watched(t.Info.TableName) {
+		return nil
+	}
+
+	c := Change{TableName: t.Info.TableName, Op: op, logged: logged}
+	k := append([]byte(nil), key...)
+	pk := t.Info.FieldConstraints.GetPrimaryKey()
+
+	for _, d := range []struct {
+		src document.Document
+		dst *document.Document
+	}{{old, &c.Old}, {new, &c.New}} {
+		if d.src == nil {
+			continue
+		}
+
+		var fb document.FieldBuffer
+		err := fb.Copy(d.src)
+		if err != nil {
+			return err
+		}
+		*d.dst = documentWithKey{Document: &fb, key: k, pk: pk}
+	}
+
+	tx.changes = append(tx.changes, c)
+	return nil
+}
+
+// Context returns the context the transaction was started with.
+func (tx *Transaction) Context() context.Context {
+	if tx.Ctx == nil {
+		return context.Background()
+	}
+
+	return tx.Ctx
 }
 
 // Rollback the transaction. Can be used safely after commit.
+// The lock of the transaction is released even if the engine returns an error,
+// as it does if the context of the transaction is canceled, or if a failed commit
+// already discarded the transaction.
 func (tx *Transaction) Rollback() error {
 	err := tx.Tx.Rollback()
-	if err != nil {
+	if tx.terminated {
 		return err
 	}
 
-	defer func() {
-		if tx.Writable {
-			tx.DBMu.Unlock()
-		} else {
-			tx.DBMu.RUnlock()
-		}
-	}()
+	defer tx.release()
 
 	for i := len(tx.OnRollbackHooks) - 1; i >= 0; i-- {
 		tx.OnRollbackHooks[i]()
 	}
 
+	return err
+}
+
+// release releases the lock of the transaction.
+func (tx *Transaction) release() {
+	tx.terminated = true
+
+	if tx.optimistic != nil {
+		tx.optimistic.unlock()
+		return
+	}
+
+	if !tx.Writable {
+		tx.DBMu.RUnlock()
+		return
+	}
+
+	tx.writeMu.Unlock()
+}
+
+// CheckDeadline returns errs.ErrTransactionTimeout if the deadline of the transaction is exceeded.
+func (tx *Transaction) CheckDeadline() error {
+	if !tx.Deadline.IsZero() && time.Now().After(tx.Deadline) {
+		return errs.ErrTransactionTimeout
+	}
+
 	return nil
 }
 
 // Commit the transaction. Calling this method on read-only transactions
 // will return an error.
+// Transactions whose deadline is exceeded are rolled back instead.
 func (tx *Transaction) Commit() error {
+	if err := tx.CheckDeadline(); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	for _, fn := range tx.OnBeforeCommitHooks {
+		err := fn()
+		if err != nil {
+			return err
+		}
+	}
+
+	// read-only transactions must not start between the commit of the engine
+	// and the publication of the modified catalog
+	if tx.catalogLocked {
+		tx.catalogMu.Lock()
+	}
+
 	err := tx.Tx.Commit()
 	if err != nil {
+		if tx.catalogLocked {
+			tx.catalogMu.Unlock()
+		}
 		return err
 	}
 
-	defer func() {
-		if tx.Writable {
-			tx.DBMu.Unlock()
-		} else {
-			tx.DBMu.RUnlock()
-		}
-	}()
+	tx.runCommitHooks()
 
-	for i := len(tx.OnCommitHooks) - 1; i >= 0; i-- {
-		tx.OnCommitHooks[i]()
+	if tx.catalogLocked {
+		tx.catalogMu.Unlock()
+	}
+
+	for _, fn := range tx.OnAfterCommitHooks {
+		fn()
 	}
 
 	return nil
 }
+
+// runCommitHooks runs the OnCommitHooks and releases the lock of the transaction.
+func (tx *Transaction) runCommitHooks() {
+	defer tx.release()
+
+	for i := len(tx.OnCommitHooks) - 1; i >= 0; i-- {
+		tx.OnCommitHooks[i]()
+	}
+}
@@ -38,3 +38,21 @@ func newTestTx(t testing.TB) (*database.Database, *database.Transaction, func())
 		cleanup()
 	}
 }
+
+func TestTransactionCanceled(t *testing.T) {
+	db, cleanup := newTestDB(t)
+	defer cleanup()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	tx, err := db.BeginTx(ctx, &database.TxOptions{})
+	require.NoError(t, err)
+	cancel()
+
+	// the engine discards the transaction, but the lock must be released
+	require.Equal(t, context.Canceled, tx.Commit())
+	require.Error(t, tx.Rollback())
+
+	tx, err = db.Begin(true)
+	require.NoError(t, err)
+	require.NoError(t, tx.Rollback())
+}
@@ -0,0 +1,95 @@
+package database
+
+import (
+	"github.com/genjidb/genji/internal/stringutil"
+)
+
+// TriggerTiming determines whether a trigger runs before or after
+// the document is written.
+type TriggerTiming uint8
+
+const (
+	TriggerBefore TriggerTiming = iota + 1
+	TriggerAfter
+)
+
+func (t TriggerTiming) String() string {
+	switch t {
+	case TriggerBefore:
+		return "BEFORE"
+	case TriggerAfter:
+		return "AFTER"
+	}
+
+	return ""
+}
+
+// TriggerEvent is the kind of write firing a trigger.
+type TriggerEvent uint8
+
+const (
+	TriggerInsert TriggerEvent = iota + 1
+	TriggerUpdate
+	TriggerDelete
+)
+
+func (e TriggerEvent) String() string {
+	switch e {
+	case TriggerInsert:
+		return "INSERT"
+	case TriggerUpdate:
+		return "UPDATE"
+	case TriggerDelete:
+		return "DELETE"
+	}
+
+	return ""
+}
+
+// TriggerInfo holds the configuration of a trigger: statements run
+// for every document of a table inserted, updated or deleted.
+type TriggerInfo struct {
+	TriggerName string
+	TableName   string
+	Timing      TriggerTiming
+	Event       TriggerEvent
+	Program     TriggerProgram
+}
+
+// A TriggerProgram is the optional condition and the statements of a trigger.
+type TriggerProgram interface {
+	// String returns the program as it was written,
+	// starting with the WHEN or the BEGIN keyword.
+	String() string
+}
+
+func (t *TriggerInfo) Type() string {
+	return "trigger"
+}
+
+func (t *TriggerInfo) Name() string {
+	return t.TriggerName
+}
+
+func (t *TriggerInfo) SetName(name string) {
+	t.TriggerName = name
+}
+
+func (t *TriggerInfo) GenerateBaseName() string {
+	return t.TriggerName
+}
+
+// String returns a SQL representation.
+func (t *TriggerInfo) String() string {
+	return stringutil.Sprintf("CREATE TRIGGER %s %s %s ON %s %s",
+		stringutil.NormalizeIdentifier(t.TriggerName, '`'),
+		t.Timing, t.Event,
+		stringutil.NormalizeIdentifier(t.TableName, '`'),
+		t.Program)
+}
+
+// Clone returns a copy of the trigger information.
+// The program is shared.
+func (t TriggerInfo) Clone() *TriggerInfo {
+	return &t
+}
@@ -0,0 +1,237 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/genjidb/genji/document"
+	"github.com/genjidb/genji/internal/stringutil"
+)
+
+// DefaultTTLReapInterval is the interval at which expired documents
+// are deleted, unless configured otherwise using Options.
+const DefaultTTLReapInterval = time.Minute
+
+// ttlReapBatchSize is the maximum number of expired documents
+// deleted by each transaction of the reaper.
+var ttlReapBatchSize = 100
+
+// IsExpired reports whether d has expired at the given time, i.e. whether
+// its TTL field holds a timestamp that is not after now.
+// Documents of tables without TTL field, or whose TTL field is NULL, never expire.
+func (ti *TableInfo) IsExpired(d document.Document, now time.Time) (bool, error) {
+	if ti.TTLField == nil {
+		return false, nil
+	}
+
+	v, err := ti.TTLField.GetValueFromDocument(d)
+	if err == document.ErrFieldNotFound {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	if v.Type != document.TimestampValue {
+		return false, nil
+	}
+
+	return !v.V.(time.Time).After(now), nil
+}
+
+// ValidateTTLField returns an error if the TTL field of the table, if any,
+// is not declared as a TIMESTAMP field.
+func (ti *TableInfo) ValidateTTLField() error {
+	if ti.TTLField == nil {
+		return nil
+	}
+
+	fc := ti.FieldConstraints.Get(ti.TTLField)
+	if fc == nil || fc.Type != document.TimestampValue {
+		return stringutil.Errorf("ttl field %q must be a TIMESTAMP field", ti.TTLField)
+	}
+
+	return nil
+}
+
+// WithTTL returns a row policy hiding the expired documents of the table,
+// in addition to the documents hidden by p. It returns p if the table has no TTL field.
+func WithTTL(ti *TableInfo, p *RowPolicy) *RowPolicy {
+	if ti.TTLField == nil {
+		return p
+	}
+
+	var ttl RowPolicy
+	if p != nil {
+		ttl = *p
+	}
+
+	read := ttl.Read
+	ttl.Read = func(ctx context.Context, d document.Document) (bool, error) {
+		expired, err := ti.IsExpired(d, time.Now())
+		if err != nil || expired {
+			return false, err
+		}
+		if read == nil {
+			return true, nil
+		}
+
+		return read(ctx, d)
+	}
+
+	return &ttl
+}
+
+// exists reports whether a document is stored under key.
+// Expired documents are deleted and reported as missing.
+func (t *Table) exists(key []byte) (bool, error) {
+	_, err := t.Store.Get(key)
+	if err != nil {
+		// like before the table had a TTL, lookup errors mean the key is free
+		return false, nil
+	}
+
+	removed, err := t.removeExpired(key)
+	return !removed, err
+}
+
+// removeExpired deletes the document stored under key if it has expired,
+// and reports whether it did. It lets new documents take the primary key
+// or the unique values of documents the reaper hasn't deleted yet.
+// Soft deleted documents keep them until they are purged.
+func (t *Table) removeExpired(key []byte) (bool, error) {
+	if t.Info.TTLField == nil {
+		return false, nil
+	}
+
+	d, err := t.GetDocument(key)
+	if err != nil {
+		return false, err
+	}
+
+	expired, err := t.Info.IsExpired(d, time.Now())
+	if err != nil || !expired {
+		return false, err
+	}
+
+	return true, t.Delete(key)
+}
+
+// errReapBatchFull stops the iteration over a table once a batch of expired documents is collected.
+var errReapBatchFull = errors.New("reap batch full")
+
+// DeleteExpired deletes at most n documents of the table that have expired at the given time,
+// in a transaction of its own, and returns the number of deleted documents.
+func (db *Database) DeleteExpired(ctx context.Context, tableName string, now time.Time, n int) (int, error) {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	tb, err := db.Catalog.GetTable(tx, tableName)
+	if err != nil {
+		return 0, err
+	}
+	if tb.Info.TTLField == nil {
+		return 0, nil
+	}
+
+	var keys [][]byte
+	err = tb.Iterate(ctx, func(d document.Document) error {
+		expired, err := tb.Info.IsExpired(d, now)
+		if err != nil || !expired {
+			return err
+		}
+
+		keys = append(keys, append([]byte{}, d.(document.Keyer).RawKey()...))
+		if len(keys) == n {
+			return errReapBatchFull
+		}
+		return nil
+	})
+	if err != nil && err != errReapBatchFull {
+		return 0, err
+	}
+	if len(keys) == 0 {
+		return 0, nil
+	}
+
+	for _, key := range keys {
+		err = tb.Delete(key)
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	return len(keys), tx.Commit()
+}
+
+// ttlReaper periodically deletes the expired documents of the tables having a TTL field.
+type ttlReaper struct {
+	cancel func()
+	// done is closed once the reaper is stopped.
+	done chan struct{}
+}
+
+func (db *Database) startReaper(interval time.Duration) {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	db.reaper = &ttlReaper{
+		cancel: cancel,
+		done:   make(chan struct{}),
+	}
+
+	go db.reaper.run(ctx, db, interval)
+}
+
+func (r *ttlReaper) run(ctx context.Context, db *Database, interval time.Duration) {
+	defer close(r.done)
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		// failures are retried on the next tick
+		_ = db.reapExpired(ctx, time.Now())
+	}
+}
+
+// reapExpired deletes the documents expired at the given time from every table having a TTL field,
+// in small transactions to let other transactions write in between.
+func (db *Database) reapExpired(ctx context.Context, now time.Time) error {
+	for _, tableName := range db.Catalog.ListTables(nil) {
+		info, err := db.Catalog.GetTableInfo(nil, tableName)
+		if err != nil || info.TTLField == nil {
+			continue
+		}
+
+		for {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+
+			n, err := db.DeleteExpired(ctx, tableName, now, ttlReapBatchSize)
+			if err != nil {
+				return err
+			}
+			if n < ttlReapBatchSize {
+				break
+			}
+		}
+	}
+
+	return nil
+}
+
+// stop stops the reaper and waits for the current pass to end.
+func (r *ttlReaper) stop() {
+	r.cancel()
+	<-r.done
+}
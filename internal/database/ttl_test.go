@@ -0,0 +1,82 @@
+package database_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/genjidb/genji/document"
+	"github.com/genjidb/genji/internal/database"
+	"github.com/genjidb/genji/internal/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDeleteExpired(t *testing.T) {
+	db, cleanup := testutil.NewTestDB(t)
+	defer cleanup()
+
+	update(t, db, func(tx *database.Transaction) error {
+		return testutil.Exec(db, tx, `
+			CREATE TABLE sessions (id TEXT PRIMARY KEY, expires TIMESTAMP) WITH (ttl_field = 'expires');
+			CREATE INDEX idx_id ON sessions (id);
+			INSERT INTO sessions (id, expires) VALUES
+				('a', '2000-01-01T00:00:00Z'),
+				('b', '2100-01-01T00:00:00Z'),
+				('c', '2000-01-02T00:00:00Z'),
+				('d', '2000-01-03T00:00:00Z'),
+				('e', NULL);
+		`)
+	})
+
+	// keys returns the keys of the documents stored in the table, expired or not.
+	keys := func(t *testing.T) []string {
+		t.Helper()
+
+		tx, err := db.Begin(false)
+		require.NoError(t, err)
+		defer tx.Rollback()
+
+		tb, err := db.Catalog.GetTable(tx, "sessions")
+		require.NoError(t, err)
+
+		var keys []string
+		err = tb.Iterate(context.Background(), func(d document.Document) error {
+			v, err := d.GetByField("id")
+			if err != nil {
+				return err
+			}
+			keys = append(keys, v.V.(string))
+			return nil
+		})
+		require.NoError(t, err)
+		return keys
+	}
+
+	ctx := context.Background()
+	n, err := db.DeleteExpired(ctx, "sessions", time.Date(2000, 1, 1, 12, 0, 0, 0, time.UTC), 10)
+	require.NoError(t, err)
+	require.Equal(t, 1, n)
+	require.Equal(t, []string{"b", "c", "d", "e"}, keys(t))
+
+	// at most n documents are deleted at once
+	n, err = db.DeleteExpired(ctx, "sessions", time.Now(), 1)
+	require.NoError(t, err)
+	require.Equal(t, 1, n)
+	require.Equal(t, []string{"b", "d", "e"}, keys(t))
+
+	n, err = db.DeleteExpired(ctx, "sessions", time.Now(), 10)
+	require.NoError(t, err)
+	require.Equal(t, 1, n)
+
+	n, err = db.DeleteExpired(ctx, "sessions", time.Now(), 10)
+	require.NoError(t, err)
+	require.Zero(t, n)
+	require.Equal(t, []string{"b", "e"}, keys(t))
+
+	// the index entries of the deleted documents are removed
+	update(t, db, func(tx *database.Transaction) error {
+		entries := testutil.GetIndexContent(t, tx, db.Catalog, "idx_id")
+		require.Len(t, entries, 2)
+		return nil
+	})
+}
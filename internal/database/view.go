@@ -0,0 +1,52 @@
+package database
+
+import (
+	"github.com/genjidb/genji/internal/stringutil"
+)
+
+// ViewInfo holds the configuration of a view: a SELECT statement
+// stored under a name and read as if it were a table.
+type ViewInfo struct {
+	ViewName string
+	Query    ViewQuery
+}
+
+// A ViewQuery is the SELECT statement defining a view.
+type ViewQuery interface {
+	// Relations returns the names of the tables and views read by the query.
+	Relations() []string
+	// String returns the query as it was written.
+	String() string
+}
+
+func (v *ViewInfo) Type() string {
+	return "view"
+}
+
+func (v *ViewInfo) Name() string {
+	return v.ViewName
+}
+
+func (v *ViewInfo) SetName(name string) {
+	v.ViewName = name
+}
+
+func (v *ViewInfo) GenerateBaseName() string {
+	return v.ViewName
+}
+
+// DependsOn reports whether the query of the view reads the given table or view.
+func (v *ViewInfo) DependsOn(name string) bool {
+	for _, r := range v.Query.Relations() {
+		if r == name {
+			return true
+		}
+	}
+
+	return false
+}
+
+// String returns a SQL representation.
+func (v *ViewInfo) String() string {
+	return stringutil.Sprintf("CREATE VIEW %s AS %s", stringutil.NormalizeIdentifier(v.ViewName, '`'), v.Query)
+}
@@ -0,0 +1,37 @@
+package database
+
+import (
+	"context"
+
+	"github.com/genjidb/genji/document"
+)
+
+// A VirtualTable is a table whose documents are provided by the application
+// instead of being stored by the database, such as the rows of a CSV file
+// or the results of an HTTP API.
+// Virtual tables are kept in memory only and must be registered every time the database is opened.
+// The context is the one the transaction was started with.
+type VirtualTable interface {
+	// Iterate calls fn for every document of the table.
+	Iterate(ctx context.Context, fn func(d document.Document) error) error
+}
+
+// A VirtualTableInserter is a virtual table accepting new documents.
+type VirtualTableInserter interface {
+	VirtualTable
+
+	// Insert adds d to the table.
+	Insert(ctx context.Context, d document.Document) error
+}
+
+// A VirtualTableIndexer is a virtual table able to efficiently select
+// documents by the value of some of their fields.
+// Queries filtering such a field using the = operator delegate the search to the table.
+type VirtualTableIndexer interface {
+	VirtualTable
+
+	// Indexed reports whether the documents can be selected by the value of the field at path.
+	Indexed(path document.Path) bool
+	// Lookup calls fn for every document whose field at path is equal to v.
+	Lookup(ctx context.Context, path document.Path, v document.Value, fn func(d document.Document) error) error
+}
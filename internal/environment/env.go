@@ -1,6 +1,8 @@
 package environment
 
 import (
+	"context"
+
 	"github.com/genjidb/genji/document"
 	"github.com/genjidb/genji/internal/database"
 	"github.com/genjidb/genji/internal/stringutil"
@@ -23,8 +25,20 @@ type Environment struct {
 	Doc     document.Document
 	Catalog database.Catalog
 	Tx      *database.Transaction
+	Session *database.Session
+	// Ctx is used to cancel the iteration of streams.
+	Ctx context.Context
 
 	Outer *Environment
+
+	// set if Outer is the environment of the query containing a subquery
+	outerQuery bool
+	// name of the table read by the outer query, if its documents
+	// aren't stored in a field named after the table or its alias
+	outerQueryTable string
+
+	// number of nested triggers running, set on the environments of triggers
+	triggerDepth int
 }
 
 func New(d document.Document, params ...Param) *Environment {
@@ -44,6 +58,48 @@ func (e *Environment) SetOuter(env *Environment) {
 	e.Outer = env
 }
 
+// SetOuterQuery sets the environment of the query containing a subquery,
+// and the name of the table it reads if its documents aren't stored in a field
+// named after the table or its alias.
+// The documents of the outer query can be referred to by the subquery,
+// see GetOuterQuery.
+func (e *Environment) SetOuterQuery(env *Environment, tableName string) {
+	e.Outer = env
+	e.outerQuery = true
+	e.outerQueryTable = tableName
+}
+
+// GetOuterQuery returns the environment of the query containing
+// the current query, if the current query is a subquery, and the name
+// of the table it reads, if set by SetOuterQuery.
+func (e *Environment) GetOuterQuery() (*Environment, string) {
+	for env := e; env != nil; env = env.Outer {
+		if env.outerQuery {
+			return env.Outer, env.outerQueryTable
+		}
+	}
+
+	return nil, ""
+}
+
+// SetTriggerDepth marks the environment as the one of a trigger,
+// run by depth - 1 other triggers.
+func (e *Environment) SetTriggerDepth(depth int) {
+	e.triggerDepth = depth
+}
+
+// GetTriggerDepth returns the number of nested triggers running,
+// or 0 if the environment isn't the one of a trigger.
+func (e *Environment) GetTriggerDepth() int {
+	for env := e; env != nil; env = env.Outer {
+		if env.triggerDepth > 0 {
+			return env.triggerDepth
+		}
+	}
+
+	return 0
+}
+
 func (e *Environment) Get(path document.Path) (v document.Value, ok bool) {
 	if e.Vars != nil {
 		v, err := path.GetValueFromDocument(e.Vars)
@@ -130,6 +186,22 @@ func (e *Environment) GetTx() *database.Transaction {
 	return nil
 }
 
+// GetContext returns the context of the environment or of its outer environments.
+// It defaults to the context of the transaction, if any.
+func (e *Environment) GetContext() context.Context {
+	for env := e; env != nil; env = env.Outer {
+		if env.Ctx != nil {
+			return env.Ctx
+		}
+	}
+
+	if tx := e.GetTx(); tx != nil {
+		return tx.Context()
+	}
+
+	return context.Background()
+}
+
 func (e *Environment) GetCatalog() database.Catalog {
 	if e.Catalog != nil {
 		return e.Catalog
@@ -141,12 +213,27 @@ func (e *Environment) GetCatalog() database.Catalog {
 	return nil
 }
 
+func (e *Environment) GetSession() *database.Session {
+	if e.Session != nil {
+		return e.Session
+	}
+	if outer := e.GetOuter(); outer != nil {
+		return outer.GetSession()
+	}
+
+	return nil
+}
+
 func (e *Environment) Clone() (*Environment, error) {
 	var newEnv Environment
 
 	newEnv.Params = e.Params
 	newEnv.Tx = e.Tx
 	newEnv.Catalog = e.Catalog
+	newEnv.Session = e.Session
+	newEnv.Ctx = e.Ctx
+	newEnv.outerQuery = e.outerQuery
+	newEnv.triggerDepth = e.triggerDepth
 
 	if e.Doc != nil {
 		fb := document.NewFieldBuffer()
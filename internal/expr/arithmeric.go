@@ -7,7 +7,7 @@ import (
 )
 
 // IsArithmeticOperator returns true if e is one of
-// +, -, *, /, %, &, |, or ^ operators.
+// +, -, *, /, %, &, |, ^, << or >> operators.
 func IsArithmeticOperator(op Operator) bool {
 	_, ok := op.(*arithmeticOperator)
 	return ok
@@ -36,6 +36,10 @@ func (op *arithmeticOperator) Eval(env *environment.Environment) (document.Value
 			return a.BitwiseOr(b)
 		case scanner.BITWISEXOR:
 			return a.BitwiseXor(b)
+		case scanner.SHIFTLEFT:
+			return a.ShiftLeft(b)
+		case scanner.SHIFTRIGHT:
+			return a.ShiftRight(b)
 		}
 
 		panic("unknown arithmetic token")
@@ -81,3 +85,13 @@ func BitwiseOr(a, b Expr) Expr {
 func BitwiseXor(a, b Expr) Expr {
 	return &arithmeticOperator{&simpleOperator{a, b, scanner.BITWISEXOR}}
 }
+
+// ShiftLeft creates an expression thats evaluates to the result of a << b.
+func ShiftLeft(a, b Expr) Expr {
+	return &arithmeticOperator{&simpleOperator{a, b, scanner.SHIFTLEFT}}
+}
+
+// ShiftRight creates an expression thats evaluates to the result of a >> b.
+func ShiftRight(a, b Expr) Expr {
+	return &arithmeticOperator{&simpleOperator{a, b, scanner.SHIFTRIGHT}}
+}
@@ -1,6 +1,8 @@
 package expr
 
 import (
+	"errors"
+
 	"github.com/genjidb/genji/document"
 	"github.com/genjidb/genji/internal/environment"
 	"github.com/genjidb/genji/internal/sql/scanner"
@@ -127,10 +129,10 @@ func (op *BetweenOperator) String() string {
 }
 
 // IsComparisonOperator returns true if e is one of
-// =, !=, >, >=, <, <=, IS, IS NOT, IN, or NOT IN operators.
+// =, !=, >, >=, <, <=, IS, IS NOT, IN, NOT IN or CONTAINS operators.
 func IsComparisonOperator(op Operator) bool {
 	switch op.(type) {
-	case *cmpOp, *IsOperator, *IsNotOperator, *InOperator, *NotInOperator, *LikeOperator, *NotLikeOperator, *BetweenOperator:
+	case *cmpOp, *IsOperator, *IsNotOperator, *InOperator, *NotInOperator, *ContainsOperator, *LikeOperator, *NotLikeOperator, *BetweenOperator:
 		return true
 	}
 
@@ -185,6 +187,38 @@ func (op *NotInOperator) String() string {
 	return stringutil.Sprintf("%v NOT IN %v", op.a, op.b)
 }
 
+type ContainsOperator struct {
+	*simpleOperator
+}
+
+// Contains creates an expression that evaluates to the result of a CONTAINS b,
+// which is true if the array a contains b, like b IN a.
+func Contains(a, b Expr) Expr {
+	return &ContainsOperator{&simpleOperator{a, b, scanner.CONTAINS}}
+}
+
+func (op *ContainsOperator) Eval(env *environment.Environment) (document.Value, error) {
+	return op.simpleOperator.eval(env, func(a, b document.Value) (document.Value, error) {
+		if a.Type == document.NullValue || b.Type == document.NullValue {
+			return NullLiteral, nil
+		}
+
+		if a.Type != document.ArrayValue {
+			return FalseLiteral, nil
+		}
+
+		ok, err := document.ArrayContains(a.V.(document.Array), b)
+		if err != nil {
+			return NullLiteral, err
+		}
+
+		if ok {
+			return TrueLiteral, nil
+		}
+		return FalseLiteral, nil
+	})
+}
+
 type IsOperator struct {
 	*simpleOperator
 }
@@ -234,3 +268,102 @@ func (op *IsNotOperator) Eval(env *environment.Environment) (document.Value, err
 func (op *IsNotOperator) String() string {
 	return stringutil.Sprintf("%v IS NOT %v", op.a, op.b)
 }
+
+// errStop is used to stop iterating over an array
+// once the result of a quantified comparison is known.
+var errStop = errors.New("stop")
+
+// A QuantifiedOperator compares an expression with all the elements of an array
+// using a comparison operator, e.g. a > ALL b or a = ANY b.
+type QuantifiedOperator struct {
+	*simpleOperator
+
+	// Op is the comparison operator token.
+	Op scanner.Token
+}
+
+// Any creates a function that creates an operator which returns true if
+// comparing a with at least one of the elements of the array b using op returns true.
+func Any(op scanner.Token) func(a, b Expr) Expr {
+	return func(a, b Expr) Expr {
+		return &QuantifiedOperator{&simpleOperator{a, b, scanner.ANY}, op}
+	}
+}
+
+// All creates a function that creates an operator which returns true if
+// comparing a with all of the elements of the array b using op returns true.
+func All(op scanner.Token) func(a, b Expr) Expr {
+	return func(a, b Expr) Expr {
+		return &QuantifiedOperator{&simpleOperator{a, b, scanner.ALL}, op}
+	}
+}
+
+// Precedence returns the precedence of the comparison operator.
+func (op *QuantifiedOperator) Precedence() int {
+	return op.Op.Precedence()
+}
+
+// Eval compares a with every element of b.
+// For ANY, the result is true if any comparison is true, for ALL, it is false if any comparison is false.
+// Otherwise, the result is NULL if any comparison is NULL.
+// If b is empty, ANY returns false and ALL returns true.
+func (op *QuantifiedOperator) Eval(env *environment.Environment) (document.Value, error) {
+	return op.simpleOperator.eval(env, func(a, b document.Value) (document.Value, error) {
+		if b.Type == document.NullValue {
+			return NullLiteral, nil
+		}
+		if b.Type != document.ArrayValue {
+			return NullLiteral, stringutil.Errorf("%v operand must be an array or a subquery, got %s", op.Tok, b.Type)
+		}
+
+		cmp := newCmpOp(nil, nil, op.Op)
+		// ANY stops at the first true comparison, ALL at the first false one.
+		stopOn := op.Tok == scanner.ANY
+		var stopped, hasNull bool
+
+		err := b.V.(document.Array).Iterate(func(i int, v document.Value) error {
+			if a.Type == document.NullValue || v.Type == document.NullValue {
+				hasNull = true
+				return nil
+			}
+
+			ok, err := cmp.compare(a, v)
+			if err != nil {
+				return err
+			}
+			if ok == stopOn {
+				stopped = true
+				return errStop
+			}
+
+			return nil
+		})
+		if err != nil && err != errStop {
+			return NullLiteral, err
+		}
+
+		switch {
+		case stopped:
+			return document.NewBoolValue(stopOn), nil
+		case hasNull:
+			return NullLiteral, nil
+		default:
+			return document.NewBoolValue(!stopOn), nil
+		}
+	})
+}
+
+// IsEqual compares this expression with the other expression and returns
+// true if they are equal.
+func (op *QuantifiedOperator) IsEqual(other Expr) bool {
+	o, ok := other.(*QuantifiedOperator)
+	if !ok {
+		return false
+	}
+
+	return op.Op == o.Op && op.simpleOperator.IsEqual(o)
+}
+
+func (op *QuantifiedOperator) String() string {
+	return stringutil.Sprintf("%v %v %v %v", op.a, op.Op, op.Tok, op.b)
+}
@@ -1,6 +1,7 @@
 package expr_test
 
 import (
+	"path/filepath"
 	"testing"
 
 	"github.com/genjidb/genji/document"
@@ -90,6 +91,29 @@ func TestComparisonNOTINExpr(t *testing.T) {
 	}
 }
 
+func TestComparisonCONTAINSExpr(t *testing.T) {
+	tests := []struct {
+		expr  string
+		res   document.Value
+		fails bool
+	}{
+		{"[] CONTAINS 1", document.NewBoolValue(false), false},
+		{"[1, 2, 3] CONTAINS 1", document.NewBoolValue(true), false},
+		{"[2.1, 2.2, 2.0] CONTAINS 2", document.NewBoolValue(true), false},
+		{"[1, 2, 3] CONTAINS [1]", document.NewBoolValue(false), false},
+		{"[[1], [2], [3]] CONTAINS [1]", document.NewBoolValue(true), false},
+		{"1 CONTAINS 1", document.NewBoolValue(false), false},
+		{"NULL CONTAINS 1", nullLiteral, false},
+		{"[1, 2, NULL] CONTAINS NULL", nullLiteral, false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.expr, func(t *testing.T) {
+			testutil.TestExpr(t, test.expr, envWithDoc, test.res, test.fails)
+		})
+	}
+}
+
 func TestComparisonISExpr(t *testing.T) {
 	tests := []struct {
 		expr  string
@@ -183,3 +207,7 @@ func TestComparisonBetweenExpr(t *testing.T) {
 		})
 	}
 }
+
+func TestQuantifiedComparison(t *testing.T) {
+	testutil.ExprRunner(t, filepath.Join("testdata", "quantified.sql"))
+}
@@ -19,10 +19,11 @@ func Constraint(e Expr) *ConstraintExpr {
 	}
 }
 
-func (t *ConstraintExpr) Eval(tx *database.Transaction) (document.Value, error) {
+func (t *ConstraintExpr) Eval(tx *database.Transaction, d document.Document) (document.Value, error) {
 	var env environment.Environment
 	env.Catalog = t.Catalog
 	env.Tx = tx
+	env.Doc = d
 
 	if t.Expr == nil {
 		return NullLiteral, errors.New("missing expression")
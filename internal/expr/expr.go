@@ -111,6 +111,16 @@ type Function interface {
 	Params() []Expr
 }
 
+// A Subquery is an expression that runs a query when it is evaluated.
+// Since subqueries can refer to the documents of the query containing them,
+// they must be evaluated for each document.
+type Subquery interface {
+	Expr
+
+	// IsSubquery is a marker method.
+	IsSubquery()
+}
+
 // A Aggregator is an expression that aggregates documents into one result.
 type Aggregator interface {
 	Expr
@@ -143,17 +153,53 @@ func Walk(e Expr, fn func(Expr) bool) bool {
 		}
 	case *NamedExpr:
 		return Walk(t.Expr, fn)
+	case Parentheses:
+		return Walk(t.E, fn)
+	case LiteralExprList:
+		for _, e := range t {
+			if !Walk(e, fn) {
+				return false
+			}
+		}
 	case Function:
 		for _, p := range t.Params() {
 			if !Walk(p, fn) {
 				return false
 			}
 		}
+	case *WindowExpr:
+		if !Walk(t.Func, fn) {
+			return false
+		}
+		for _, e := range t.Window.PartitionBy {
+			if !Walk(e, fn) {
+				return false
+			}
+		}
+		for _, term := range t.Window.OrderBy {
+			if !Walk(term.E, fn) {
+				return false
+			}
+		}
 	}
 
 	return true
 }
 
+// An OrderTerm is an expression of an ORDER BY clause and its direction.
+type OrderTerm struct {
+	E    Expr
+	Desc bool
+}
+
+func (t OrderTerm) String() string {
+	if t.Desc {
+		return stringutil.Sprintf("%s DESC", t.E)
+	}
+
+	return t.E.String()
+}
+
 type NextValueFor struct {
 	SeqName string
 }
@@ -167,7 +213,9 @@ func (n NextValueFor) Eval(env *environment.Environment) (document.Value, error)
 		return NullLiteral, stringutil.Errorf(`NEXT VALUE FOR cannot be evaluated`)
 	}
 
-	seq, err := catalog.GetSequence(n.SeqName)
+	tx.ReadTracker.SetVolatile()
+
+	seq, err := catalog.GetSequence(tx, n.SeqName)
 	if err != nil {
 		return NullLiteral, err
 	}
@@ -196,5 +244,5 @@ func (n NextValueFor) IsEqual(other Expr) bool {
 }
 
 func (n NextValueFor) String() string {
-	return stringutil.Sprintf("NEXT VALUE FOR %s", n.SeqName)
+	return stringutil.Sprintf("NEXT VALUE FOR %s", stringutil.NormalizeIdentifier(n.SeqName, '`'))
 }
@@ -0,0 +1,162 @@
+package functions
+
+import (
+	"bytes"
+	"encoding/hex"
+	"unicode/utf8"
+
+	"github.com/genjidb/genji/document"
+	"github.com/genjidb/genji/internal/stringutil"
+)
+
+var substrFunc = &ScalarDefinition{
+	name:     "substr",
+	arity:    3,
+	optional: 1,
+	callFn: func(args ...document.Value) (document.Value, error) {
+		return substr("substr(arg1, arg2[, arg3])", args...)
+	},
+}
+
+var substringFunc = &ScalarDefinition{
+	name:     "substring",
+	arity:    3,
+	optional: 1,
+	callFn: func(args ...document.Value) (document.Value, error) {
+		return substr("substring(arg1, arg2[, arg3])", args...)
+	},
+}
+
+// substr returns the part of the blob or the text args[0] starting at the 1-based position args[1],
+// of args[2] bytes or characters if present, or up to the end otherwise.
+func substr(fn string, args ...document.Value) (document.Value, error) {
+	if anyNull(args...) {
+		return document.NewNullValue(), nil
+	}
+
+	if args[0].Type != document.BlobValue && args[0].Type != document.TextValue {
+		return document.Value{}, stringutil.Errorf("%s expects arg1 to be a blob or a text", fn)
+	}
+	for _, a := range args[1:] {
+		if a.Type != document.IntegerValue {
+			return document.Value{}, stringutil.Errorf("%s expects arg2 and arg3 to be integers", fn)
+		}
+	}
+
+	start, length := args[1].V.(int64), int64(-1)
+	if len(args) > 2 {
+		length = args[2].V.(int64)
+		if length < 0 {
+			return document.Value{}, stringutil.Errorf("%s expects arg2 to be positive and arg3 not to be negative", fn)
+		}
+	}
+	if start < 1 {
+		return document.Value{}, stringutil.Errorf("%s expects arg2 to be positive and arg3 not to be negative", fn)
+	}
+
+	if args[0].Type == document.TextValue {
+		// positions and lengths of texts are in characters
+		r := []rune(args[0].V.(string))
+		i, j := substrBounds(int64(len(r)), start, length)
+		return document.NewTextValue(string(r[i:j])), nil
+	}
+
+	b := args[0].V.([]byte)
+	i, j := substrBounds(int64(len(b)), start, length)
+	return document.NewBlobValue(append([]byte{}, b[i:j]...)), nil
+}
+
+// substrBounds returns the 0-based bounds of the part of a sequence of n elements
+// starting at the 1-based position start, of length elements or up to the end if length is negative.
+func substrBounds(n, start, length int64) (int64, int64) {
+	if start > n {
+		return n, n
+	}
+	end := n
+	if length >= 0 && start-1+length < n {
+		end = start - 1 + length
+	}
+
+	return start - 1, end
+}
+
+var positionFunc = &ScalarDefinition{
+	name:  "position",
+	arity: 2,
+	callFn: func(args ...document.Value) (document.Value, error) {
+		if anyNull(args...) {
+			return document.NewNullValue(), nil
+		}
+
+		if args[0].Type != document.BlobValue || args[1].Type != document.BlobValue {
+			return document.Value{}, stringutil.Errorf("position(arg1, arg2) expects arg1 and arg2 to be blobs")
+		}
+
+		// positions are 1-based, 0 means the pattern was not found
+		return document.NewIntegerValue(int64(bytes.Index(args[0].V.([]byte), args[1].V.([]byte)) + 1)), nil
+	},
+}
+
+var hexFunc = &ScalarDefinition{
+	name:  "hex",
+	arity: 1,
+	callFn: func(args ...document.Value) (document.Value, error) {
+		switch args[0].Type {
+		case document.NullValue:
+			return args[0], nil
+		case document.BlobValue:
+			return document.NewTextValue(hex.EncodeToString(args[0].V.([]byte))), nil
+		}
+
+		return document.Value{}, stringutil.Errorf("hex(arg1) expects arg1 to be a blob")
+	},
+}
+
+var unhexFunc = &ScalarDefinition{
+	name:  "unhex",
+	arity: 1,
+	callFn: func(args ...document.Value) (document.Value, error) {
+		switch args[0].Type {
+		case document.NullValue:
+			return args[0], nil
+		case document.TextValue:
+			b, err := hex.DecodeString(args[0].V.(string))
+			if err != nil {
+				return document.Value{}, stringutil.Errorf("unhex(arg1) expects arg1 to be a valid hexadecimal string")
+			}
+			return document.NewBlobValue(b), nil
+		}
+
+		return document.Value{}, stringutil.Errorf("unhex(arg1) expects arg1 to be a text")
+	},
+}
+
+var lengthFunc = &ScalarDefinition{
+	name:  "length",
+	arity: 1,
+	callFn: func(args ...document.Value) (document.Value, error) {
+		switch args[0].Type {
+		case document.NullValue:
+			return args[0], nil
+		case document.BlobValue:
+			// length of blobs is the number of bytes
+			return document.NewIntegerValue(int64(len(args[0].V.([]byte)))), nil
+		case document.TextValue:
+			// length of texts is the number of characters
+			return document.NewIntegerValue(int64(utf8.RuneCountInString(args[0].V.(string)))), nil
+		}
+
+		return document.Value{}, stringutil.Errorf("length(arg1) expects arg1 to be a blob or a text")
+	},
+}
+
+// anyNull returns true if any of the values is NULL.
+func anyNull(values ...document.Value) bool {
+	for _, v := range values {
+		if v.Type == document.NullValue {
+			return true
+		}
+	}
+
+	return false
+}
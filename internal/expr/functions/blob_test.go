@@ -0,0 +1,12 @@
+package functions_test
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/genjidb/genji/internal/testutil"
+)
+
+func TestBlobFunctions(t *testing.T) {
+	testutil.ExprRunner(t, filepath.Join("testdata", "blob_functions.sql"))
+}
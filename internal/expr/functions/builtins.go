@@ -1,7 +1,9 @@
 package functions
 
 import (
+	"bytes"
 	"errors"
+	"math"
 
 	"github.com/genjidb/genji/document"
 	"github.com/genjidb/genji/internal/environment"
@@ -52,6 +54,49 @@ var builtinFunctions = Definitions{
 			return &Avg{Expr: args[0]}, nil
 		},
 	},
+	"array_agg": &definition{
+		name:  "array_agg",
+		arity: 1,
+		constructorFn: func(args ...expr.Expr) (expr.Function, error) {
+			return &ArrayAgg{Expr: args[0]}, nil
+		},
+	},
+	"substr":          substrFunc,
+	"substring":       substringFunc,
+	"position":        positionFunc,
+	"hex":             hexFunc,
+	"unhex":           unhexFunc,
+	"length":          lengthFunc,
+	"fields":          fieldsFunc,
+	"values":          valuesFunc,
+	"entries":         entriesFunc,
+	"sha1":            sha1Func,
+	"sha256":          sha256Func,
+	"md5":             md5Func,
+	"random":          randomFunc,
+	"randomblob":      randomBlobFunc,
+	"uuid":            uuidFunc,
+	"uuid_v7":         uuidV7Func,
+	"version":         versionFunc,
+	"changes":         changesFunc,
+	"total_changes":   totalChangesFunc,
+	"last_insert_key": lastInsertKeyFunc,
+	"row_number":      rowNumberFunc,
+	"rank":            rankFunc,
+	"dense_rank":      denseRankFunc,
+	"lag":             lagFunc,
+	"lead":            leadFunc,
+	"now":             nowFunc,
+	"date_trunc":      dateTruncFunc,
+	"date_part":       datePartFunc,
+	"strftime":        strftimeFunc,
+	"age":             ageFunc,
+	"coalesce":        coalesceFunc,
+	"ifnull":          ifnullFunc,
+	"nullif":          nullifFunc,
+	"greatest":        greatestFunc,
+	"least":           leastFunc,
+	"concat":          concatFunc,
 }
 
 // BuiltinDefinitions returns a map of builtin functions.
@@ -144,6 +189,7 @@ var _ expr.AggregatorBuilder = (*Count)(nil)
 type Count struct {
 	Expr     expr.Expr
 	Wildcard bool
+	Distinct bool
 	Count    int64
 }
 
@@ -172,7 +218,7 @@ func (c *Count) IsEqual(other expr.Expr) bool {
 		return c.Expr == nil && o.Expr == nil
 	}
 
-	return expr.Equal(c.Expr, o.Expr)
+	return c.Distinct == o.Distinct && expr.Equal(c.Expr, o.Expr)
 }
 
 func (c *Count) Params() []expr.Expr { return []expr.Expr{c.Expr} }
@@ -182,7 +228,13 @@ func (c *Count) String() string {
 		return "COUNT(*)"
 	}
 
-	return stringutil.Sprintf("COUNT(%v)", c.Expr)
+	return aggregateString("COUNT", c.Distinct, c.Expr)
+}
+
+// SetDistinct makes the function count distinct values only.
+// It implements the DistinctAggregatorBuilder interface.
+func (c *Count) SetDistinct() {
+	c.Distinct = true
 }
 
 // Aggregator returns a CountAggregator. It implements the AggregatorBuilder interface.
@@ -196,6 +248,7 @@ func (c *Count) Aggregator() expr.Aggregator {
 type CountAggregator struct {
 	Fn    *Count
 	Count int64
+	seen  distinctValues
 }
 
 // Aggregate increments the counter if the count expression evaluates to a non-null value.
@@ -209,10 +262,24 @@ func (c *CountAggregator) Aggregate(env *environment.Environment) error {
 	if err != nil && err != document.ErrFieldNotFound {
 		return err
 	}
-	if v != expr.NullLiteral {
-		c.Count++
+
+	return c.AggregateValue(v)
+}
+
+// AggregateValue increments the counter if v is not null.
+func (c *CountAggregator) AggregateValue(v document.Value) error {
+	if v == expr.NullLiteral {
+		return nil
+	}
+
+	if c.Fn.Distinct {
+		ok, err := c.seen.Add(v)
+		if err != nil || !ok {
+			return err
+		}
 	}
 
+	c.Count++
 	return nil
 }
 
@@ -227,7 +294,8 @@ func (c *CountAggregator) String() string {
 
 // Min is the MIN aggregator function.
 type Min struct {
-	Expr expr.Expr
+	Expr     expr.Expr
+	Distinct bool
 }
 
 // Eval extracts the min value from the given document and returns it.
@@ -252,7 +320,7 @@ func (m *Min) IsEqual(other expr.Expr) bool {
 		return false
 	}
 
-	return expr.Equal(m.Expr, o.Expr)
+	return m.Distinct == o.Distinct && expr.Equal(m.Expr, o.Expr)
 }
 
 func (m *Min) Params() []expr.Expr { return []expr.Expr{m.Expr} }
@@ -260,7 +328,13 @@ func (m *Min) Params() []expr.Expr { return []expr.Expr{m.Expr} }
 // String returns the alias if non-zero, otherwise it returns a string representation
 // of the count expression.
 func (m *Min) String() string {
-	return stringutil.Sprintf("MIN(%v)", m.Expr)
+	return aggregateString("MIN", m.Distinct, m.Expr)
+}
+
+// SetDistinct makes the function aggregate distinct values only.
+// It implements the DistinctAggregatorBuilder interface.
+func (m *Min) SetDistinct() {
+	m.Distinct = true
 }
 
 // Aggregator returns a MinAggregator. It implements the AggregatorBuilder interface.
@@ -283,6 +357,12 @@ func (m *MinAggregator) Aggregate(env *environment.Environment) error {
 	if err != nil && err != document.ErrFieldNotFound {
 		return err
 	}
+
+	return m.AggregateValue(v)
+}
+
+// AggregateValue stores v if it is the minimum non-null value.
+func (m *MinAggregator) AggregateValue(v document.Value) error {
 	if v == expr.NullLiteral {
 		return nil
 	}
@@ -325,7 +405,8 @@ func (m *MinAggregator) String() string {
 
 // Max is the MAX aggregator function.
 type Max struct {
-	Expr expr.Expr
+	Expr     expr.Expr
+	Distinct bool
 }
 
 // Eval extracts the max value from the given document and returns it.
@@ -350,7 +431,7 @@ func (m *Max) IsEqual(other expr.Expr) bool {
 		return false
 	}
 
-	return expr.Equal(m.Expr, o.Expr)
+	return m.Distinct == o.Distinct && expr.Equal(m.Expr, o.Expr)
 }
 
 func (m *Max) Params() []expr.Expr { return []expr.Expr{m.Expr} }
@@ -358,7 +439,13 @@ func (m *Max) Params() []expr.Expr { return []expr.Expr{m.Expr} }
 // String returns the alias if non-zero, otherwise it returns a string representation
 // of the count expression.
 func (m *Max) String() string {
-	return stringutil.Sprintf("MAX(%v)", m.Expr)
+	return aggregateString("MAX", m.Distinct, m.Expr)
+}
+
+// SetDistinct makes the function aggregate distinct values only.
+// It implements the DistinctAggregatorBuilder interface.
+func (m *Max) SetDistinct() {
+	m.Distinct = true
 }
 
 // Aggregator returns a MaxAggregator. It implements the AggregatorBuilder interface.
@@ -381,6 +468,12 @@ func (m *MaxAggregator) Aggregate(env *environment.Environment) error {
 	if err != nil && err != document.ErrFieldNotFound {
 		return err
 	}
+
+	return m.AggregateValue(v)
+}
+
+// AggregateValue stores v if it is the maximum non-null value.
+func (m *MaxAggregator) AggregateValue(v document.Value) error {
 	if v == expr.NullLiteral {
 		return nil
 	}
@@ -424,7 +517,8 @@ func (m *MaxAggregator) String() string {
 
 // Sum is the SUM aggregator function.
 type Sum struct {
-	Expr expr.Expr
+	Expr     expr.Expr
+	Distinct bool
 }
 
 // Eval extracts the sum value from the given document and returns it.
@@ -449,7 +543,7 @@ func (s *Sum) IsEqual(other expr.Expr) bool {
 		return false
 	}
 
-	return expr.Equal(s.Expr, o.Expr)
+	return s.Distinct == o.Distinct && expr.Equal(s.Expr, o.Expr)
 }
 
 func (s *Sum) Params() []expr.Expr { return []expr.Expr{s.Expr} }
@@ -457,7 +551,13 @@ func (s *Sum) Params() []expr.Expr { return []expr.Expr{s.Expr} }
 // String returns the alias if non-zero, otherwise it returns a string representation
 // of the count expression.
 func (s *Sum) String() string {
-	return stringutil.Sprintf("SUM(%v)", s.Expr)
+	return aggregateString("SUM", s.Distinct, s.Expr)
+}
+
+// SetDistinct makes the function aggregate distinct values only.
+// It implements the DistinctAggregatorBuilder interface.
+func (s *Sum) SetDistinct() {
+	s.Distinct = true
 }
 
 // Aggregator returns a Sum. It implements the AggregatorBuilder interface.
@@ -472,26 +572,39 @@ type SumAggregator struct {
 	Fn   *Sum
 	SumI *int64
 	SumF *float64
+	SumD *document.Decimal
+	seen distinctValues
 }
 
 // Aggregate stores the sum of all non-NULL numeric values in the group.
 // The result is an integer value if all summed values are integers.
-// If any of the value is a double, the returned result will be a double.
+// If any of the value is a double, the returned result will be a double,
+// otherwise if any of the value is a decimal, the result will be an exact decimal.
 func (s *SumAggregator) Aggregate(env *environment.Environment) error {
 	v, err := s.Fn.Expr.Eval(env)
 	if err != nil && err != document.ErrFieldNotFound {
 		return err
 	}
-	if v.Type != document.IntegerValue && v.Type != document.DoubleValue {
+
+	return s.AggregateValue(v)
+}
+
+// AggregateValue adds v to the sum if it is a number.
+func (s *SumAggregator) AggregateValue(v document.Value) error {
+	if !v.Type.IsNumber() {
 		return nil
 	}
 
-	if s.SumF != nil {
-		if v.Type == document.IntegerValue {
-			*s.SumF += float64(v.V.(int64))
-		} else {
-			*s.SumF += float64(v.V.(float64))
+	if s.Fn.Distinct {
+		ok, err := s.seen.Add(v)
+		if err != nil || !ok {
+			return err
 		}
+	}
+
+	if s.SumF != nil {
+		f, _ := v.CastAsDouble()
+		*s.SumF += f.V.(float64)
 
 		return nil
 	}
@@ -501,12 +614,30 @@ func (s *SumAggregator) Aggregate(env *environment.Environment) error {
 		if s.SumI != nil {
 			sumF = float64(*s.SumI)
 		}
+		if s.SumD != nil {
+			sumF = s.SumD.Float64()
+		}
 		s.SumF = &sumF
 		*s.SumF += float64(v.V.(float64))
 
 		return nil
 	}
 
+	if v.Type == document.DecimalValue || s.SumD != nil {
+		if s.SumD == nil {
+			var sumD document.Decimal
+			if s.SumI != nil {
+				sumD = document.NewDecimal(*s.SumI, 0)
+			}
+			s.SumD = &sumD
+		}
+
+		d, _ := v.CastAsDecimal()
+		*s.SumD = s.SumD.Add(d.V.(document.Decimal))
+
+		return nil
+	}
+
 	if s.SumI == nil {
 		var sumI int64
 		s.SumI = &sumI
@@ -521,6 +652,9 @@ func (s *SumAggregator) Eval(env *environment.Environment) (document.Value, erro
 	if s.SumF != nil {
 		return document.NewDoubleValue(*s.SumF), nil
 	}
+	if s.SumD != nil {
+		return document.NewDecimalValue(*s.SumD), nil
+	}
 	if s.SumI != nil {
 		return document.NewIntegerValue(*s.SumI), nil
 	}
@@ -534,7 +668,8 @@ func (s *SumAggregator) String() string {
 
 // Avg is the AVG aggregator function.
 type Avg struct {
-	Expr expr.Expr
+	Expr     expr.Expr
+	Distinct bool
 }
 
 // Eval extracts the average value from the given document and returns it.
@@ -559,7 +694,7 @@ func (s *Avg) IsEqual(other expr.Expr) bool {
 		return false
 	}
 
-	return expr.Equal(s.Expr, o.Expr)
+	return s.Distinct == o.Distinct && expr.Equal(s.Expr, o.Expr)
 }
 
 func (s *Avg) Params() []expr.Expr { return []expr.Expr{s.Expr} }
@@ -567,7 +702,13 @@ func (s *Avg) Params() []expr.Expr { return []expr.Expr{s.Expr} }
 // String returns the alias if non-zero, otherwise it returns a string representation
 // of the average expression.
 func (s *Avg) String() string {
-	return stringutil.Sprintf("AVG(%v)", s.Expr)
+	return aggregateString("AVG", s.Distinct, s.Expr)
+}
+
+// SetDistinct makes the function aggregate distinct values only.
+// It implements the DistinctAggregatorBuilder interface.
+func (s *Avg) SetDistinct() {
+	s.Distinct = true
 }
 
 // Aggregator returns a Avg. It implements the AggregatorBuilder interface.
@@ -582,6 +723,7 @@ type AvgAggregator struct {
 	Fn      *Avg
 	Avg     float64
 	Counter int64
+	seen    distinctValues
 }
 
 // Aggregate stores the average value of all non-NULL numeric values in the group.
@@ -591,11 +733,25 @@ func (s *AvgAggregator) Aggregate(env *environment.Environment) error {
 		return err
 	}
 
+	return s.AggregateValue(v)
+}
+
+// AggregateValue adds v to the average if it is a number.
+func (s *AvgAggregator) AggregateValue(v document.Value) error {
+	if s.Fn.Distinct && v.Type.IsNumber() {
+		ok, err := s.seen.Add(v)
+		if err != nil || !ok {
+			return err
+		}
+	}
+
 	switch v.Type {
 	case document.IntegerValue:
 		s.Avg += float64(v.V.(int64))
 	case document.DoubleValue:
 		s.Avg += v.V.(float64)
+	case document.DecimalValue:
+		s.Avg += v.V.(document.Decimal).Float64()
 	default:
 		return nil
 	}
@@ -616,3 +772,148 @@ func (s *AvgAggregator) Eval(env *environment.Environment) (document.Value, erro
 func (s *AvgAggregator) String() string {
 	return s.Fn.String()
 }
+
+// ArrayAgg is the ARRAY_AGG aggregator function.
+type ArrayAgg struct {
+	Expr     expr.Expr
+	Distinct bool
+}
+
+// Eval extracts the aggregated array from the given document and returns it.
+func (a *ArrayAgg) Eval(env *environment.Environment) (document.Value, error) {
+	d, ok := env.GetDocument()
+	if !ok {
+		return document.Value{}, errors.New("misuse of aggregation function ARRAY_AGG()")
+	}
+
+	return d.GetByField(a.String())
+}
+
+// IsEqual compares this expression with the other expression and returns
+// true if they are equal.
+func (a *ArrayAgg) IsEqual(other expr.Expr) bool {
+	if other == nil {
+		return false
+	}
+
+	o, ok := other.(*ArrayAgg)
+	if !ok {
+		return false
+	}
+
+	return a.Distinct == o.Distinct && expr.Equal(a.Expr, o.Expr)
+}
+
+func (a *ArrayAgg) Params() []expr.Expr { return []expr.Expr{a.Expr} }
+
+func (a *ArrayAgg) String() string {
+	return aggregateString("ARRAY_AGG", a.Distinct, a.Expr)
+}
+
+// SetDistinct makes the function aggregate distinct values only.
+// It implements the DistinctAggregatorBuilder interface.
+func (a *ArrayAgg) SetDistinct() {
+	a.Distinct = true
+}
+
+// Aggregator returns an ArrayAggAggregator. It implements the AggregatorBuilder interface.
+func (a *ArrayAgg) Aggregator() expr.Aggregator {
+	return &ArrayAggAggregator{
+		Fn: a,
+	}
+}
+
+// ArrayAggAggregator is an aggregator that collects all values, including NULL, into an array.
+type ArrayAggAggregator struct {
+	Fn     *ArrayAgg
+	Values *document.ValueBuffer
+	seen   distinctValues
+}
+
+// Aggregate appends the value of the expression to the array.
+func (a *ArrayAggAggregator) Aggregate(env *environment.Environment) error {
+	v, err := a.Fn.Expr.Eval(env)
+	if err != nil && err != document.ErrFieldNotFound {
+		return err
+	}
+
+	if a.Fn.Distinct {
+		ok, err := a.seen.Add(v)
+		if err != nil || !ok {
+			return err
+		}
+	}
+
+	if a.Values == nil {
+		a.Values = document.NewValueBuffer()
+	}
+	a.Values.Append(v)
+
+	return nil
+}
+
+// Eval returns the aggregated array, or NULL if no values were aggregated.
+func (a *ArrayAggAggregator) Eval(env *environment.Environment) (document.Value, error) {
+	if a.Values == nil {
+		return document.NewNullValue(), nil
+	}
+
+	return document.NewArrayValue(a.Values), nil
+}
+
+func (a *ArrayAggAggregator) String() string {
+	return a.Fn.String()
+}
+
+// A DistinctAggregatorBuilder is an aggregator builder that accepts
+// the DISTINCT qualifier, e.g. COUNT(DISTINCT a).
+type DistinctAggregatorBuilder interface {
+	expr.AggregatorBuilder
+
+	SetDistinct()
+}
+
+// aggregateString returns the string representation of an aggregate function call.
+func aggregateString(name string, distinct bool, e expr.Expr) string {
+	if distinct {
+		return stringutil.Sprintf("%s(DISTINCT %v)", name, e)
+	}
+
+	return stringutil.Sprintf("%s(%v)", name, e)
+}
+
+// distinctValues is a set of values used by aggregators to ignore duplicates
+// when the DISTINCT qualifier is used. It is local to each group.
+type distinctValues struct {
+	buf bytes.Buffer
+	m   map[string]struct{}
+}
+
+// Add adds v to the set and returns false if it was already present.
+func (d *distinctValues) Add(v document.Value) (bool, error) {
+	// integers and doubles are compared by value,
+	// so integral doubles are stored as integers.
+	if v.Type == document.DoubleValue {
+		f := v.V.(float64)
+		if f == math.Trunc(f) && f >= math.MinInt64 && f < math.MaxInt64 {
+			v = document.NewIntegerValue(int64(f))
+		}
+	}
+
+	d.buf.Reset()
+	err := document.NewValueEncoder(&d.buf).Encode(v)
+	if err != nil {
+		return false, err
+	}
+
+	if d.m == nil {
+		d.m = make(map[string]struct{})
+	}
+
+	if _, ok := d.m[d.buf.String()]; ok {
+		return false, nil
+	}
+	d.m[d.buf.String()] = struct{}{}
+
+	return true, nil
+}
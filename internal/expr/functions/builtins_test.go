@@ -5,7 +5,10 @@ import (
 
 	"github.com/genjidb/genji/document"
 	"github.com/genjidb/genji/internal/environment"
+	"github.com/genjidb/genji/internal/expr"
+	"github.com/genjidb/genji/internal/expr/functions"
 	"github.com/genjidb/genji/internal/testutil"
+	"github.com/stretchr/testify/require"
 )
 
 var doc document.Document = func() document.Document {
@@ -52,3 +55,34 @@ func TestPk(t *testing.T) {
 		})
 	}
 }
+
+func TestDistinctAggregators(t *testing.T) {
+	docs := []string{`{"a": 1}`, `{"a": 1.0}`, `{"a": 2}`, `{"a": null}`, `{"b": 1}`, `{"a": 2.5}`}
+
+	tests := []struct {
+		name string
+		fn   expr.AggregatorBuilder
+		res  document.Value
+	}{
+		{"COUNT", &functions.Count{Expr: expr.Path(document.NewPath("a")), Distinct: true}, document.NewIntegerValue(3)},
+		{"SUM", &functions.Sum{Expr: expr.Path(document.NewPath("a")), Distinct: true}, document.NewDoubleValue(5.5)},
+		{"AVG", &functions.Avg{Expr: expr.Path(document.NewPath("a")), Distinct: true}, document.NewDoubleValue(5.5 / 3)},
+		{"ARRAY_AGG", &functions.ArrayAgg{Expr: expr.Path(document.NewPath("a")), Distinct: true}, document.NewArrayValue(
+			document.NewValueBuffer(document.NewIntegerValue(1), document.NewIntegerValue(2), document.NewNullValue(), document.NewDoubleValue(2.5)),
+		)},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			agg := test.fn.Aggregator()
+			for _, d := range docs {
+				err := agg.Aggregate(environment.New(document.NewFromJSON([]byte(d))))
+				require.NoError(t, err)
+			}
+
+			v, err := agg.Eval(&environment.Environment{})
+			require.NoError(t, err)
+			require.Equal(t, test.res, v)
+		})
+	}
+}
@@ -0,0 +1,107 @@
+package functions
+
+import (
+	"github.com/genjidb/genji/document"
+)
+
+var coalesceFunc = &ScalarDefinition{
+	name:     "coalesce",
+	arity:    1,
+	variadic: true,
+	callFn: func(args ...document.Value) (document.Value, error) {
+		for _, a := range args {
+			if a.Type != document.NullValue {
+				return a, nil
+			}
+		}
+
+		return document.NewNullValue(), nil
+	},
+}
+
+var ifnullFunc = &ScalarDefinition{
+	name:  "ifnull",
+	arity: 2,
+	callFn: func(args ...document.Value) (document.Value, error) {
+		if args[0].Type != document.NullValue {
+			return args[0], nil
+		}
+
+		return args[1], nil
+	},
+}
+
+var nullifFunc = &ScalarDefinition{
+	name:  "nullif",
+	arity: 2,
+	callFn: func(args ...document.Value) (document.Value, error) {
+		ok, err := args[0].IsEqual(args[1])
+		if err != nil {
+			return document.Value{}, err
+		}
+		if ok {
+			return document.NewNullValue(), nil
+		}
+
+		return args[0], nil
+	},
+}
+
+var greatestFunc = &ScalarDefinition{
+	name:     "greatest",
+	arity:    1,
+	variadic: true,
+	callFn: func(args ...document.Value) (document.Value, error) {
+		return extremum(args, true)
+	},
+}
+
+var leastFunc = &ScalarDefinition{
+	name:     "least",
+	arity:    1,
+	variadic: true,
+	callFn: func(args ...document.Value) (document.Value, error) {
+		return extremum(args, false)
+	},
+}
+
+// extremum returns the greatest or the least non-null value of args,
+// or NULL if all the values are NULL.
+// Like MIN and MAX, values of types that cannot be compared together
+// are ordered by type.
+func extremum(args []document.Value, greatest bool) (document.Value, error) {
+	res := document.NewNullValue()
+
+	for _, a := range args {
+		if a.Type == document.NullValue {
+			continue
+		}
+		if res.Type == document.NullValue {
+			res = a
+			continue
+		}
+
+		if a.Type != res.Type && !(a.Type.IsNumber() && res.Type.IsNumber()) && !(a.Type.IsTime() && res.Type.IsTime()) {
+			if (a.Type > res.Type) == greatest {
+				res = a
+			}
+			continue
+		}
+
+		var ok bool
+		var err error
+		if greatest {
+			ok, err = a.IsGreaterThan(res)
+		} else {
+			ok, err = a.IsLesserThan(res)
+		}
+		if err != nil {
+			return document.Value{}, err
+		}
+		if ok {
+			res = a
+		}
+	}
+
+	return res, nil
+}
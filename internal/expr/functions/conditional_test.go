@@ -0,0 +1,12 @@
+package functions_test
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/genjidb/genji/internal/testutil"
+)
+
+func TestConditionalFunctions(t *testing.T) {
+	testutil.ExprRunner(t, filepath.Join("testdata", "conditional_functions.sql"))
+}
@@ -0,0 +1,88 @@
+package functions
+
+import (
+	"crypto/md5"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/sha256"
+	"encoding/binary"
+	"hash"
+
+	"github.com/genjidb/genji/document"
+	"github.com/genjidb/genji/internal/stringutil"
+)
+
+// maxRandomBlobSize is the maximum number of bytes randomblob can generate.
+const maxRandomBlobSize = 1 << 20
+
+var sha1Func = newHashDefinition("sha1", sha1.New)
+var sha256Func = newHashDefinition("sha256", sha256.New)
+var md5Func = newHashDefinition("md5", md5.New)
+
+// newHashDefinition returns a function returning the digest of a blob or a text
+// as a blob, using the hash returned by newHash.
+func newHashDefinition(name string, newHash func() hash.Hash) *ScalarDefinition {
+	return &ScalarDefinition{
+		name:  name,
+		arity: 1,
+		callFn: func(args ...document.Value) (document.Value, error) {
+			h := newHash()
+
+			switch args[0].Type {
+			case document.NullValue:
+				return args[0], nil
+			case document.BlobValue:
+				h.Write(args[0].V.([]byte))
+			case document.TextValue:
+				h.Write([]byte(args[0].V.(string)))
+			default:
+				return document.Value{}, stringutil.Errorf("%s(arg1) expects arg1 to be a blob or a text", name)
+			}
+
+			return document.NewBlobValue(h.Sum(nil)), nil
+		},
+	}
+}
+
+var randomFunc = &ScalarDefinition{
+	name:     "random",
+	arity:    0,
+	volatile: true,
+	callFn: func(args ...document.Value) (document.Value, error) {
+		var buf [8]byte
+		_, err := rand.Read(buf[:])
+		if err != nil {
+			return document.Value{}, err
+		}
+
+		return document.NewIntegerValue(int64(binary.BigEndian.Uint64(buf[:]))), nil
+	},
+}
+
+var randomBlobFunc = &ScalarDefinition{
+	name:     "randomblob",
+	arity:    1,
+	volatile: true,
+	callFn: func(args ...document.Value) (document.Value, error) {
+		switch args[0].Type {
+		case document.NullValue:
+			return args[0], nil
+		case document.IntegerValue:
+		default:
+			return document.Value{}, stringutil.Errorf("randomblob(arg1) expects arg1 to be an integer")
+		}
+
+		n := args[0].V.(int64)
+		if n < 1 || n > maxRandomBlobSize {
+			return document.Value{}, stringutil.Errorf("randomblob(arg1) expects arg1 to be between 1 and %d", maxRandomBlobSize)
+		}
+
+		b := make([]byte, n)
+		_, err := rand.Read(b)
+		if err != nil {
+			return document.Value{}, err
+		}
+
+		return document.NewBlobValue(b), nil
+	},
+}
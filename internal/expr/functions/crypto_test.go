@@ -0,0 +1,42 @@
+package functions_test
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/genjidb/genji/document"
+	"github.com/genjidb/genji/internal/environment"
+	"github.com/genjidb/genji/internal/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCryptoFunctions(t *testing.T) {
+	testutil.ExprRunner(t, filepath.Join("testdata", "crypto_functions.sql"))
+}
+
+func TestRandom(t *testing.T) {
+	e := testutil.FunctionExpr(t, "random")
+
+	seen := make(map[int64]bool)
+	for i := 0; i < 10; i++ {
+		v, err := e.Eval(&environment.Environment{})
+		require.NoError(t, err)
+		require.Equal(t, document.IntegerValue, v.Type)
+		seen[v.V.(int64)] = true
+	}
+
+	require.Len(t, seen, 10)
+}
+
+func TestRandomBlob(t *testing.T) {
+	e := testutil.FunctionExpr(t, "randomblob", testutil.IntegerValue(32))
+
+	a, err := e.Eval(&environment.Environment{})
+	require.NoError(t, err)
+	b, err := e.Eval(&environment.Environment{})
+	require.NoError(t, err)
+
+	require.Equal(t, document.BlobValue, a.Type)
+	require.Len(t, a.V.([]byte), 32)
+	require.NotEqual(t, a, b)
+}
@@ -25,6 +25,7 @@ func DefaultPackages() Packages {
 	return Packages{
 		"":     BuiltinDefinitions(),
 		"math": MathFunctions(),
+		"time": TimeFunctions(),
 	}
 }
 
@@ -46,8 +47,12 @@ func (t Packages) GetFunc(pkg string, fname string) (Definition, error) {
 
 // A definition is the most basic version of a function definition.
 type definition struct {
-	name          string
-	arity         int
+	name  string
+	arity int
+	// number of trailing arguments that can be omitted
+	optional int
+	// variadic functions take arity or more arguments
+	variadic      bool
 	constructorFn func(...expr.Expr) (expr.Function, error)
 }
 
@@ -56,20 +61,56 @@ func (fd *definition) Name() string {
 }
 
 func (fd *definition) Function(args ...expr.Expr) (expr.Function, error) {
-	if len(args) != fd.arity {
-		return nil, stringutil.Errorf("%s() takes %d argument(s), not %d", fd.name, fd.arity, len(args))
+	if err := checkArity(fd.name+"()", fd.arity, fd.optional, fd.variadic, len(args)); err != nil {
+		return nil, err
 	}
 	return fd.constructorFn(args...)
 }
 
 func (fd *definition) String() string {
-	args := make([]string, 0, fd.arity)
-	for i := 0; i < fd.arity; i++ {
-		args = append(args, stringutil.Sprintf("arg%d", i+1))
-	}
-	return stringutil.Sprintf("%s(%s)", fd.name, strings.Join(args, ", "))
+	return stringutil.Sprintf("%s(%s)", fd.name, formatArgs(fd.arity, fd.optional, fd.variadic))
 }
 
 func (fd *definition) Arity() int {
 	return fd.arity
 }
+
+// checkArity returns an error if the function fn, which takes arity arguments,
+// cannot be called with n arguments.
+// The last optional arguments can be omitted and variadic functions
+// take any number of arguments after the first arity ones.
+func checkArity(fn string, arity, optional int, variadic bool, n int) error {
+	switch {
+	case variadic && n < arity:
+		return stringutil.Errorf("%s takes at least %d argument(s), not %d", fn, arity, n)
+	case !variadic && optional > 0 && (n < arity-optional || n > arity):
+		return stringutil.Errorf("%s takes %d to %d argument(s), not %d", fn, arity-optional, arity, n)
+	case !variadic && optional == 0 && n != arity:
+		return stringutil.Errorf("%s takes %d argument(s), not %d", fn, arity, n)
+	}
+
+	return nil
+}
+
+// formatArgs returns the list of arguments of a function,
+// i.e. "arg1, arg2[, arg3]" or "arg1, ...".
+func formatArgs(arity, optional int, variadic bool) string {
+	var sb strings.Builder
+	for i := 0; i < arity; i++ {
+		if i > 0 && i >= arity-optional {
+			sb.WriteString("[")
+		}
+		if i > 0 {
+			sb.WriteString(", ")
+		}
+		sb.WriteString(stringutil.Sprintf("arg%d", i+1))
+	}
+	if optional > 0 {
+		sb.WriteString(strings.Repeat("]", optional))
+	}
+	if variadic {
+		sb.WriteString(", ...")
+	}
+
+	return sb.String()
+}
@@ -29,6 +29,50 @@ func TestDefinitions(t *testing.T) {
 	})
 }
 
+func TestDefinitionsArity(t *testing.T) {
+	packages := functions.DefaultPackages()
+	a := expr.Path(document.NewPath("a"))
+
+	tests := []struct {
+		name  string
+		str   string
+		arity int
+		ok    []int
+		nok   []int
+	}{
+		{"lag", "lag(arg1[, arg2[, arg3]])", 3, []int{1, 2, 3}, []int{0, 4}},
+		{"substr", "substr(arg1, arg2[, arg3])", 3, []int{2, 3}, []int{1, 4}},
+		{"concat", "concat(arg1, ...)", 1, []int{1, 2, 10}, []int{0}},
+		{"ifnull", "ifnull(arg1, arg2)", 2, []int{2}, []int{1, 3}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			def, err := packages.GetFunc("", test.name)
+			require.NoError(t, err)
+			require.Equal(t, test.str, def.String())
+			require.Equal(t, test.arity, def.Arity())
+
+			args := func(n int) []expr.Expr {
+				var args []expr.Expr
+				for i := 0; i < n; i++ {
+					args = append(args, a)
+				}
+				return args
+			}
+
+			for _, n := range test.ok {
+				_, err := def.Function(args(n)...)
+				require.NoError(t, err, "%d argument(s)", n)
+			}
+			for _, n := range test.nok {
+				_, err := def.Function(args(n)...)
+				require.Error(t, err, "%d argument(s)", n)
+			}
+		})
+	}
+}
+
 func TestPackages(t *testing.T) {
 	table := functions.DefaultPackages()
 
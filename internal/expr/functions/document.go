@@ -0,0 +1,62 @@
+package functions
+
+import (
+	"github.com/genjidb/genji/document"
+	"github.com/genjidb/genji/internal/stringutil"
+)
+
+var fieldsFunc = &ScalarDefinition{
+	name:  "fields",
+	arity: 1,
+	callFn: func(args ...document.Value) (document.Value, error) {
+		return iterateDocument("fields", args[0], func(vb *document.ValueBuffer, field string, v document.Value) {
+			vb.Append(document.NewTextValue(field))
+		})
+	},
+}
+
+var valuesFunc = &ScalarDefinition{
+	name:  "values",
+	arity: 1,
+	callFn: func(args ...document.Value) (document.Value, error) {
+		return iterateDocument("values", args[0], func(vb *document.ValueBuffer, field string, v document.Value) {
+			vb.Append(v)
+		})
+	},
+}
+
+var entriesFunc = &ScalarDefinition{
+	name:  "entries",
+	arity: 1,
+	callFn: func(args ...document.Value) (document.Value, error) {
+		return iterateDocument("entries", args[0], func(vb *document.ValueBuffer, field string, v document.Value) {
+			entry := document.NewFieldBuffer().
+				Add("key", document.NewTextValue(field)).
+				Add("value", v)
+			vb.Append(document.NewDocumentValue(entry))
+		})
+	},
+}
+
+// iterateDocument calls fn for every field of the document v, in order,
+// and returns the array built by fn. If v is NULL, it returns NULL.
+func iterateDocument(name string, v document.Value, fn func(vb *document.ValueBuffer, field string, v document.Value)) (document.Value, error) {
+	switch v.Type {
+	case document.NullValue:
+		return v, nil
+	case document.DocumentValue:
+	default:
+		return document.Value{}, stringutil.Errorf("%s(arg1) expects arg1 to be a document", name)
+	}
+
+	vb := document.NewValueBuffer()
+	err := v.V.(document.Document).Iterate(func(field string, v document.Value) error {
+		fn(vb, field, v)
+		return nil
+	})
+	if err != nil {
+		return document.Value{}, err
+	}
+
+	return document.NewArrayValue(vb), nil
+}
@@ -13,7 +13,9 @@ func MathFunctions() Definitions {
 }
 
 var mathFunctions = Definitions{
-	"floor": floorFunc,
+	"floor":              floorFunc,
+	"cosine_distance":    cosineDistanceFunc,
+	"euclidean_distance": euclideanDistanceFunc,
 }
 
 var floorFunc = &ScalarDefinition{
@@ -23,6 +25,8 @@ var floorFunc = &ScalarDefinition{
 		switch args[0].Type {
 		case document.DoubleValue:
 			return document.NewDoubleValue(math.Floor(args[0].V.(float64))), nil
+		case document.DecimalValue:
+			return document.NewDecimalValue(args[0].V.(document.Decimal).Floor()), nil
 		case document.IntegerValue:
 			return args[0], nil
 		default:
@@ -30,3 +34,43 @@ var floorFunc = &ScalarDefinition{
 		}
 	},
 }
+
+var cosineDistanceFunc = &ScalarDefinition{
+	name:  "cosine_distance",
+	arity: 2,
+	callFn: func(args ...document.Value) (document.Value, error) {
+		return vectorDistance("cosine_distance", document.CosineDistance, args[0], args[1])
+	},
+}
+
+var euclideanDistanceFunc = &ScalarDefinition{
+	name:  "euclidean_distance",
+	arity: 2,
+	callFn: func(args ...document.Value) (document.Value, error) {
+		return vectorDistance("euclidean_distance", document.EuclideanDistance, args[0], args[1])
+	},
+}
+
+// vectorDistance converts both arguments to vectors and computes their distance using fn.
+// If any of the arguments is NULL, it returns NULL.
+func vectorDistance(name string, fn func(a, b []float64) (float64, error), a, b document.Value) (document.Value, error) {
+	if a.Type == document.NullValue || b.Type == document.NullValue {
+		return document.NewNullValue(), nil
+	}
+
+	a, err := a.CastAsVector()
+	if err != nil {
+		return document.Value{}, stringutil.Errorf("%s(arg1, arg2) expects arg1 to be a vector", name)
+	}
+	b, err = b.CastAsVector()
+	if err != nil {
+		return document.Value{}, stringutil.Errorf("%s(arg1, arg2) expects arg2 to be a vector", name)
+	}
+
+	d, err := fn(a.V.([]float64), b.V.([]float64))
+	if err != nil {
+		return document.Value{}, err
+	}
+
+	return document.NewDoubleValue(d), nil
+}
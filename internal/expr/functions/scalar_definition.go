@@ -18,6 +18,12 @@ type ScalarDefinition struct {
 	name   string
 	arity  int
 	callFn func(...document.Value) (document.Value, error)
+	// volatile functions may return different results when called with the same arguments.
+	volatile bool
+	// number of trailing arguments that can be omitted, they are not passed to callFn.
+	optional int
+	// variadic functions take arity or more arguments.
+	variadic bool
 }
 
 func NewScalarDefinition(name string, arity int, callFn func(...document.Value) (document.Value, error)) *ScalarDefinition {
@@ -31,17 +37,13 @@ func (fd *ScalarDefinition) Name() string {
 
 // String returns the defined function name and its arguments.
 func (fd *ScalarDefinition) String() string {
-	args := make([]string, 0, fd.arity)
-	for i := 0; i < fd.arity; i++ {
-		args = append(args, stringutil.Sprintf("arg%d", i+1))
-	}
-	return stringutil.Sprintf("%s(%s)", fd.name, strings.Join(args, ", "))
+	return stringutil.Sprintf("%s(%s)", fd.name, formatArgs(fd.arity, fd.optional, fd.variadic))
 }
 
 // Function returns a Function expr node.
 func (fd *ScalarDefinition) Function(args ...expr.Expr) (expr.Function, error) {
-	if len(args) != fd.arity {
-		return nil, stringutil.Errorf("%s takes %d argument(s), not %d", fd.String(), fd.arity, len(args))
+	if err := checkArity(fd.String(), fd.arity, fd.optional, fd.variadic, len(args)); err != nil {
+		return nil, err
 	}
 	return &ScalarFunction{
 		params: args,
@@ -50,10 +52,20 @@ func (fd *ScalarDefinition) Function(args ...expr.Expr) (expr.Function, error) {
 }
 
 // Arity returns the arity of the defined function.
+// Functions with optional arguments take at most that many arguments,
+// variadic functions take at least that many arguments.
 func (fd *ScalarDefinition) Arity() int {
 	return fd.arity
 }
 
+// markVolatile records that the statement evaluated in env calls a non-deterministic function,
+// which prevents its result from being cached.
+func markVolatile(env *environment.Environment) {
+	if tx := env.GetTx(); tx != nil {
+		tx.ReadTracker.SetVolatile()
+	}
+}
+
 // A ScalarFunction is a function which operates on scalar values in contrast to other SQL functions
 // such as the SUM aggregator wich operates on expressions instead.
 type ScalarFunction struct {
@@ -61,9 +73,18 @@ type ScalarFunction struct {
 	params []expr.Expr
 }
 
+// Name returns the name of the function.
+func (sf *ScalarFunction) Name() string {
+	return sf.def.name
+}
+
 // Eval returns a document.Value based on the given environment and the underlying function
 // definition.
 func (sf *ScalarFunction) Eval(env *environment.Environment) (document.Value, error) {
+	if sf.def.volatile {
+		markVolatile(env)
+	}
+
 	args, err := sf.evalParams(env)
 	if err != nil {
 		return document.Value{}, err
@@ -86,7 +107,12 @@ func (sf *ScalarFunction) evalParams(env *environment.Environment) ([]document.V
 
 // String returns a string represention of the function expression and its arguments.
 func (sf *ScalarFunction) String() string {
-	return stringutil.Sprintf("%s(%v)", sf.def.name, sf.params)
+	params := make([]string, len(sf.params))
+	for i, p := range sf.params {
+		params[i] = p.String()
+	}
+
+	return stringutil.Sprintf("%s(%s)", sf.def.name, strings.Join(params, ", "))
 }
 
 // Params return the function arguments.
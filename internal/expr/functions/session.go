@@ -0,0 +1,105 @@
+package functions
+
+import (
+	"runtime/debug"
+
+	"github.com/genjidb/genji/document"
+	"github.com/genjidb/genji/internal/database"
+	"github.com/genjidb/genji/internal/environment"
+	"github.com/genjidb/genji/internal/expr"
+)
+
+const genjiModulePath = "github.com/genjidb/genji"
+
+var versionFunc = &ScalarDefinition{
+	name:  "version",
+	arity: 0,
+	callFn: func(args ...document.Value) (document.Value, error) {
+		return document.NewTextValue(genjiVersion()), nil
+	},
+}
+
+// genjiVersion returns the version of the Genji module the program was built with.
+func genjiVersion() string {
+	info, ok := debug.ReadBuildInfo()
+	if !ok {
+		return "(devel)"
+	}
+
+	if info.Main.Path == genjiModulePath {
+		return info.Main.Version
+	}
+
+	for _, mod := range info.Deps {
+		if mod.Path != genjiModulePath {
+			continue
+		}
+
+		// if a replace directive is set, Genji is in development mode
+		if mod.Replace != nil {
+			break
+		}
+
+		return mod.Version
+	}
+
+	return "(devel)"
+}
+
+var changesFunc = newSessionDefinition("changes", func(s *database.Session) document.Value {
+	return document.NewIntegerValue(s.Changes())
+})
+
+var totalChangesFunc = newSessionDefinition("total_changes", func(s *database.Session) document.Value {
+	return document.NewIntegerValue(s.TotalChanges())
+})
+
+var lastInsertKeyFunc = newSessionDefinition("last_insert_key", func(s *database.Session) document.Value {
+	return s.LastInsertKey()
+})
+
+func newSessionDefinition(name string, fn func(s *database.Session) document.Value) *definition {
+	return &definition{
+		name:  name,
+		arity: 0,
+		constructorFn: func(args ...expr.Expr) (expr.Function, error) {
+			return &SessionFunction{Name: name, fn: fn}, nil
+		},
+	}
+}
+
+// A SessionFunction returns information about the session the
+// statement is run in, like the number of documents modified by the last statement.
+type SessionFunction struct {
+	Name string
+	fn   func(s *database.Session) document.Value
+}
+
+// Eval returns NULL if the environment is not attached to a session.
+func (f *SessionFunction) Eval(env *environment.Environment) (document.Value, error) {
+	markVolatile(env)
+
+	s := env.GetSession()
+	if s == nil {
+		return expr.NullLiteral, nil
+	}
+
+	return f.fn(s), nil
+}
+
+func (*SessionFunction) Params() []expr.Expr { return nil }
+
+// IsEqual compares this expression with the other expression and returns
+// true if they are equal.
+func (f *SessionFunction) IsEqual(other expr.Expr) bool {
+	o, ok := other.(*SessionFunction)
+	if !ok {
+		return false
+	}
+
+	return f.Name == o.Name
+}
+
+func (f *SessionFunction) String() string {
+	return f.Name + "()"
+}
@@ -0,0 +1,78 @@
+package functions_test
+
+import (
+	"testing"
+
+	"github.com/genjidb/genji"
+	"github.com/genjidb/genji/document"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSessionFunctions(t *testing.T) {
+	db, err := genji.Open(":memory:")
+	require.NoError(t, err)
+	defer db.Close()
+
+	query := func(q string) document.Value {
+		t.Helper()
+
+		d, err := db.QueryDocument(q)
+		require.NoError(t, err)
+
+		var v document.Value
+		err = d.Iterate(func(field string, value document.Value) error {
+			v = value
+			return nil
+		})
+		require.NoError(t, err)
+		return v
+	}
+
+	require.Equal(t, document.NewIntegerValue(0), query("SELECT changes()"))
+	require.Equal(t, document.NewIntegerValue(0), query("SELECT total_changes()"))
+	require.Equal(t, document.NewNullValue(), query("SELECT last_insert_key()"))
+	require.Equal(t, document.TextValue, query("SELECT version()").Type)
+
+	err = db.Exec(`
+		CREATE TABLE test(a INT PRIMARY KEY, b INT);
+		INSERT INTO test (a, b) VALUES (1, 1), (2, 1), (3, 2);
+	`)
+	require.NoError(t, err)
+	require.Equal(t, document.NewIntegerValue(3), query("SELECT changes()"))
+	require.Equal(t, document.NewIntegerValue(3), query("SELECT total_changes()"))
+	require.Equal(t, document.NewIntegerValue(3), query("SELECT last_insert_key()"))
+
+	// ignored documents are not counted
+	err = db.Exec("INSERT INTO test (a, b) VALUES (1, 1), (4, 2) ON CONFLICT DO NOTHING")
+	require.NoError(t, err)
+	require.Equal(t, document.NewIntegerValue(1), query("SELECT changes()"))
+	require.Equal(t, document.NewIntegerValue(4), query("SELECT last_insert_key()"))
+
+	err = db.Exec("UPDATE test SET b = 3 WHERE b = 1")
+	require.NoError(t, err)
+	require.Equal(t, document.NewIntegerValue(2), query("SELECT changes()"))
+
+	err = db.Exec("DELETE FROM test WHERE b = 10")
+	require.NoError(t, err)
+	require.Equal(t, document.NewIntegerValue(0), query("SELECT changes()"))
+
+	err = db.Exec("DELETE FROM test")
+	require.NoError(t, err)
+	require.Equal(t, document.NewIntegerValue(4), query("SELECT changes()"))
+
+	// read-only statements don't reset the counters
+	require.Equal(t, document.NewIntegerValue(4), query("SELECT changes()"))
+	require.Equal(t, document.NewIntegerValue(10), query("SELECT total_changes()"))
+
+	// failed statements are not counted
+	err = db.Exec("INSERT INTO test (a) VALUES (10), (10)")
+	require.Error(t, err)
+	require.Equal(t, document.NewIntegerValue(4), query("SELECT changes()"))
+	require.Equal(t, document.NewIntegerValue(10), query("SELECT total_changes()"))
+	require.Equal(t, document.NewIntegerValue(4), query("SELECT last_insert_key()"))
+
+	// tables without primary keys use the generated key
+	err = db.Exec("CREATE TABLE nopk; INSERT INTO nopk (a) VALUES (1)")
+	require.NoError(t, err)
+	require.Equal(t, document.NewIntegerValue(1), query("SELECT last_insert_key()"))
+}
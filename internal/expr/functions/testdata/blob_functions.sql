@@ -0,0 +1,98 @@
+-- test: hex
+> hex(CAST('YWJj' AS BLOB))
+'616263'
+
+> hex(NULL)
+NULL
+
+! hex('abc')
+'hex(arg1) expects arg1 to be a blob'
+
+-- test: unhex
+> unhex('616263')
+CAST('YWJj' AS BLOB)
+
+> unhex('')
+CAST('' AS BLOB)
+
+> unhex(NULL)
+NULL
+
+! unhex('zz')
+'unhex(arg1) expects arg1 to be a valid hexadecimal string'
+
+! unhex(10)
+'unhex(arg1) expects arg1 to be a text'
+
+-- test: substr
+> substr(unhex('0102030405'), 2, 3)
+unhex('020304')
+
+> substr(unhex('0102030405'), 4, 10)
+unhex('0405')
+
+> substr(unhex('0102030405'), 10, 2)
+unhex('')
+
+> substr(NULL, 1, 2)
+NULL
+
+> substr(unhex('0102030405'), 3)
+unhex('030405')
+
+> substr('héllo', 2, 3)
+'éll'
+
+> substr('héllo', 3)
+'llo'
+
+> substr('abc', 5)
+''
+
+! substr(unhex('0102'), 0, 2)
+'substr(arg1, arg2[, arg3]) expects arg2 to be positive and arg3 not to be negative'
+
+! substr(unhex('0102'), 1, -1)
+'substr(arg1, arg2[, arg3]) expects arg2 to be positive and arg3 not to be negative'
+
+! substr(1, 1, 2)
+'substr(arg1, arg2[, arg3]) expects arg1 to be a blob or a text'
+
+! substr('abc', 1.5)
+'substr(arg1, arg2[, arg3]) expects arg2 and arg3 to be integers'
+
+-- test: substring
+> substring('hello world', 7)
+'world'
+
+> substring('hello world', 1, 5)
+'hello'
+
+> substring(NULL, 1)
+NULL
+
+-- test: position
+> position(unhex('0102030405'), unhex('0304'))
+3
+
+> position(unhex('0102030405'), unhex('0600'))
+0
+
+> position(unhex('0102'), NULL)
+NULL
+
+! position(unhex('0102'), 'a')
+'position(arg1, arg2) expects arg1 and arg2 to be blobs'
+
+-- test: length
+> length(unhex('0102030405'))
+5
+
+> length('héllo')
+5
+
+> length(NULL)
+NULL
+
+! length(10)
+'length(arg1) expects arg1 to be a blob or a text'
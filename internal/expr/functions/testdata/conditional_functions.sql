@@ -0,0 +1,73 @@
+-- test: coalesce
+> coalesce(NULL, 1, 2)
+1
+
+> coalesce(NULL, NULL, 'a')
+'a'
+
+> coalesce(NULL)
+NULL
+
+> coalesce(true)
+true
+
+-- test: ifnull
+> ifnull(NULL, 10)
+10
+
+> ifnull(1.5, 10)
+1.5
+
+> ifnull(NULL, NULL)
+NULL
+
+-- test: nullif
+> nullif(1, 1)
+NULL
+
+> nullif(1, 1.0)
+NULL
+
+> nullif(1, 2)
+1
+
+> nullif('a', 1)
+'a'
+
+> nullif(NULL, 1)
+NULL
+
+-- test: greatest
+> greatest(1, 3, 2)
+3
+
+> greatest(1, 2.5, NULL)
+2.5
+
+> greatest('a', 'c', 'b')
+'c'
+
+> greatest(DATE '2021-01-02', TIMESTAMP '2021-01-01T10:00:00Z')
+DATE '2021-01-02'
+
+> greatest(1, 'a', true)
+'a'
+
+> greatest(NULL, NULL)
+NULL
+
+-- test: least
+> least(1, 3, 2)
+1
+
+> least(1, -2.5, NULL)
+-2.5
+
+> least('a', 'c', 'b')
+'a'
+
+> least(1, 'a', true)
+true
+
+> least(NULL)
+NULL
@@ -0,0 +1,71 @@
+-- test: sha1
+> hex(sha1('abc'))
+'a9993e364706816aba3e25717850c26c9cd0d89d'
+
+> hex(sha1(CAST('YWJj' AS BLOB)))
+'a9993e364706816aba3e25717850c26c9cd0d89d'
+
+> sha1(NULL)
+NULL
+
+! sha1(1)
+'sha1(arg1) expects arg1 to be a blob or a text'
+
+-- test: sha256
+> hex(sha256('abc'))
+'ba7816bf8f01cfea414140de5dae2223b00361a396177a9cb410ff61f20015ad'
+
+> hex(sha256(''))
+'e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855'
+
+> sha256(NULL)
+NULL
+
+! sha256([1])
+'sha256(arg1) expects arg1 to be a blob or a text'
+
+-- test: md5
+> hex(md5('abc'))
+'900150983cd24fb0d6963f7d28e17f72'
+
+> md5(NULL)
+NULL
+
+! md5(true)
+'md5(arg1) expects arg1 to be a blob or a text'
+
+-- test: randomblob
+> length(randomblob(16))
+16
+
+> randomblob(NULL)
+NULL
+
+! randomblob(0)
+'randomblob(arg1) expects arg1 to be between 1 and 1048576'
+
+! randomblob('16')
+'randomblob(arg1) expects arg1 to be an integer'
+
+-- test: uuid
+> length(CAST(uuid() AS BLOB))
+16
+
+> length(CAST(uuid() AS TEXT))
+36
+
+> substr(CAST(uuid() AS TEXT), 15, 1)
+'4'
+
+> uuid() = uuid()
+false
+
+-- test: uuid_v7
+> substr(CAST(uuid_v7() AS TEXT), 15, 1)
+'7'
+
+> uuid_v7() < uuid_v7()
+true
+
+> UUID '018b2c3d-4e5f-7081-92a3-b4c5d6e7f809' < uuid_v7()
+true
@@ -0,0 +1,38 @@
+-- test: fields
+> fields({a: 1, b: 'foo', c: {d: true}})
+['a', 'b', 'c']
+
+> fields({}) = []
+true
+
+> fields(NULL)
+NULL
+
+! fields([1, 2])
+'fields(arg1) expects arg1 to be a document'
+
+-- test: values
+> values({a: 1, b: 'foo', c: {d: true}})
+[1, 'foo', {d: true}]
+
+> values({}) = []
+true
+
+> values(NULL)
+NULL
+
+! values('foo')
+'values(arg1) expects arg1 to be a document'
+
+-- test: entries
+> entries({a: 1, b: [1, 2]})
+[{"key": 'a', "value": 1}, {"key": 'b', "value": [1, 2]}]
+
+> entries({}) = []
+true
+
+> entries(NULL)
+NULL
+
+! entries(1)
+'entries(arg1) expects arg1 to be a document'
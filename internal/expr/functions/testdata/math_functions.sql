@@ -5,5 +5,37 @@
 > math.floor(2)
 2
 
+> CAST(math.floor(DECIMAL '-2.5') AS TEXT)
+'-3'
+
 ! math.floor('a')
 'floor(arg1) expects arg1 to be a number'
+
+-- test: math.cosine_distance
+> math.cosine_distance([1, 0], [2, 0])
+0.0
+
+> math.cosine_distance([1, 0], [0, 1])
+1.0
+
+> math.cosine_distance(CAST([1, 0] AS VECTOR), [-1, 0])
+2.0
+
+> math.cosine_distance(NULL, [1, 0])
+NULL
+
+! math.cosine_distance('a', [1, 0])
+'cosine_distance(arg1, arg2) expects arg1 to be a vector'
+
+! math.cosine_distance([1, 0], [0, 0])
+'cannot compute the cosine distance of a zero vector'
+
+-- test: math.euclidean_distance
+> math.euclidean_distance([0, 0], [3, 4])
+5.0
+
+> math.euclidean_distance(CAST([1, 2] AS VECTOR), [1, 2])
+0.0
+
+! math.euclidean_distance([1, 2], [1, 2, 3])
+'cannot compare vectors of dimension 2 and 3'
@@ -0,0 +1,18 @@
+-- test: concat
+> concat('a', 'b', 'c')
+'abc'
+
+> concat('a')
+'a'
+
+> concat('a', NULL, 'b')
+'ab'
+
+> concat(NULL)
+''
+
+> concat('n=', 10, ', ok=', true)
+'n=10, ok=true'
+
+> concat(DATE '2021-01-02', ' ', [1, 2])
+'2021-01-02 [1, 2]'
@@ -0,0 +1,153 @@
+-- test: time.to_utc
+> time.to_utc('2021-06-01T12:30:00+02:00')
+'2021-06-01T10:30:00Z'
+
+> time.to_utc('2021-06-01T12:30:00.5Z')
+'2021-06-01T12:30:00.5Z'
+
+> time.to_utc(TIMESTAMP '2021-06-01T12:30:00+02:00')
+TIMESTAMP '2021-06-01T10:30:00Z'
+
+> time.to_utc(NULL)
+NULL
+
+! time.to_utc('foo')
+'to_utc(arg1) expects arg1 to be a timestamp'
+
+-- test: time.at_time_zone
+> time.at_time_zone('2021-06-01T10:30:00Z', 'Europe/Paris')
+'2021-06-01T12:30:00+02:00'
+
+> time.at_time_zone('2021-01-01T10:30:00Z', 'America/New_York')
+'2021-01-01T05:30:00-05:00'
+
+> time.at_time_zone('2021-06-01T12:30:00+02:00', 'UTC')
+'2021-06-01T10:30:00Z'
+
+> time.at_time_zone(TIMESTAMP '2021-06-01T10:30:00Z', 'Europe/Paris')
+'2021-06-01T12:30:00+02:00'
+
+> time.at_time_zone(DATE '2021-06-01', 'Europe/Paris')
+'2021-06-01T02:00:00+02:00'
+
+> time.at_time_zone(NULL, 'UTC')
+NULL
+
+> time.at_time_zone('2021-06-01T10:30:00Z')
+'2021-06-01T10:30:00Z'
+
+! time.at_time_zone('2021-06-01T10:30:00Z', 'Mars/Olympus')
+'unknown time zone "Mars/Olympus"'
+
+! time.at_time_zone('2021-06-01T10:30:00Z', 'Local')
+'unknown time zone "Local"'
+
+! time.at_time_zone(10, 'UTC')
+'at_time_zone(arg1, arg2) expects arg1 to be a timestamp'
+
+-- test: date_trunc
+> date_trunc('month', TIMESTAMP '2021-06-03T10:30:05.5Z')
+TIMESTAMP '2021-06-01T00:00:00Z'
+
+> date_trunc('week', '2021-06-03T10:30:05Z')
+TIMESTAMP '2021-05-31T00:00:00Z'
+
+> date_trunc('hours', TIMESTAMP '2021-06-03T10:30:05Z')
+TIMESTAMP '2021-06-03T10:00:00Z'
+
+> date_trunc('century', DATE '2021-06-03')
+DATE '2001-01-01'
+
+> date_trunc('year', NULL)
+NULL
+
+! date_trunc('fortnight', TIMESTAMP '2021-06-03T10:30:05Z')
+'date_trunc(arg1, arg2): unknown unit "fortnight"'
+
+! date_trunc('dow', TIMESTAMP '2021-06-03T10:30:05Z')
+'date_trunc(arg1, arg2): unit "dow" is not supported'
+
+! date_trunc(1, TIMESTAMP '2021-06-03T10:30:05Z')
+'date_trunc(arg1, arg2) expects arg1 to be a unit'
+
+-- test: date_part
+> date_part('year', TIMESTAMP '2021-06-03T10:30:05.5Z')
+2021
+
+> date_part('second', TIMESTAMP '2021-06-03T10:30:05.5Z')
+5.5
+
+> date_part('dow', DATE '2021-06-06')
+0
+
+> date_part('isodow', DATE '2021-06-06')
+7
+
+> date_part('week', DATE '2021-01-01')
+53
+
+> date_part('epoch', TIMESTAMP '2021-06-01T10:30:00.5Z')
+1622543400.5
+
+> date_part('day', INTERVAL '1 month 3 days')
+3
+
+> date_part('hour', INTERVAL '1 day 04:00:00')
+4
+
+> date_part('epoch', INTERVAL '1 day 00:00:01')
+86401.0
+
+> date_part('day', NULL)
+NULL
+
+! date_part('dow', INTERVAL '1 day')
+'date_part(arg1, arg2): unit "dow" is not supported for intervals'
+
+! date_part('day', 'foo')
+'date_part(arg1, arg2) expects arg2 to be a timestamp'
+
+-- test: extract
+> EXTRACT(YEAR FROM TIMESTAMP '2021-06-03T10:30:05Z')
+2021
+
+> EXTRACT(doy FROM DATE '2021-02-01')
+32
+
+> extract(month FROM INTERVAL '1 year 2 months')
+2
+
+> EXTRACT(YEAR FROM NULL)
+NULL
+
+-- test: strftime
+> strftime('%Y-%m-%d %H:%M:%f', TIMESTAMP '2021-06-03T10:30:05.123Z')
+'2021-06-03 10:30:05.123'
+
+> strftime('%j %w %W %s %%', DATE '2021-06-03')
+'154 4 22 1622678400 %'
+
+> strftime('%Y', NULL)
+NULL
+
+! strftime('%Q', DATE '2021-06-03')
+'strftime(arg1, arg2): unknown substitution %Q'
+
+! strftime('%Y%', DATE '2021-06-03')
+'strftime(arg1, arg2): incomplete substitution at the end of arg1'
+
+! strftime(1, DATE '2021-06-03')
+'strftime(arg1, arg2) expects arg1 to be a text'
+
+-- test: age
+> age(TIMESTAMP '2021-03-15T00:00:00Z', TIMESTAMP '2020-01-10T12:00:00Z')
+INTERVAL '1 year 2 months 4 days 12:00:00'
+
+> age(DATE '2021-03-15', '2020-01-10')
+INTERVAL '1 year 2 months 5 days'
+
+> age(NULL, DATE '2021-03-15')
+NULL
+
+! age('foo', DATE '2021-03-15')
+'age(arg1, arg2) expects arg1 to be a timestamp'
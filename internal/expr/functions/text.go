@@ -0,0 +1,31 @@
+package functions
+
+import (
+	"strings"
+
+	"github.com/genjidb/genji/document"
+)
+
+var concatFunc = &ScalarDefinition{
+	name:     "concat",
+	arity:    1,
+	variadic: true,
+	callFn: func(args ...document.Value) (document.Value, error) {
+		var sb strings.Builder
+
+		for _, a := range args {
+			// NULL values are ignored
+			if a.Type == document.NullValue {
+				continue
+			}
+
+			t, err := a.CastAsText()
+			if err != nil {
+				return document.Value{}, err
+			}
+			sb.WriteString(t.V.(string))
+		}
+
+		return document.NewTextValue(sb.String()), nil
+	},
+}
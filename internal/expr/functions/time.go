@@ -0,0 +1,634 @@
+package functions
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/genjidb/genji/document"
+	"github.com/genjidb/genji/internal/database"
+	"github.com/genjidb/genji/internal/environment"
+	"github.com/genjidb/genji/internal/expr"
+	"github.com/genjidb/genji/internal/stringutil"
+)
+
+// TimeFunctions returns all time package functions.
+// Timestamps are either timestamp values or RFC 3339 texts.
+func TimeFunctions() Definitions {
+	return timeFunctions
+}
+
+var timeFunctions = Definitions{
+	"to_utc":       toUTCFunc,
+	"at_time_zone": atTimeZoneFunc,
+}
+
+var toUTCFunc = &ScalarDefinition{
+	name:  "to_utc",
+	arity: 1,
+	callFn: func(args ...document.Value) (document.Value, error) {
+		if args[0].Type == document.NullValue {
+			return args[0], nil
+		}
+
+		t, err := parseTimestamp("to_utc(arg1)", "arg1", args[0])
+		if err != nil {
+			return document.Value{}, err
+		}
+
+		if args[0].Type.IsTime() {
+			return document.NewTimestampValue(t), nil
+		}
+
+		return document.NewTextValue(t.UTC().Format(time.RFC3339Nano)), nil
+	},
+}
+
+var atTimeZoneFunc = &definition{
+	name:     "at_time_zone",
+	arity:    2,
+	optional: 1,
+	constructorFn: func(args ...expr.Expr) (expr.Function, error) {
+		a := AtTimeZone{T: args[0]}
+		if len(args) > 1 {
+			a.Zone = args[1]
+		}
+		return &a, nil
+	},
+}
+
+// AtTimeZone is the AT_TIME_ZONE(t [, zone]) function. It returns the timestamp t
+// as an RFC 3339 text in the given time zone, or in the time zone of the session if zone is omitted.
+type AtTimeZone struct {
+	T    expr.Expr
+	Zone expr.Expr
+}
+
+// Eval returns T in the time zone Zone.
+func (a *AtTimeZone) Eval(env *environment.Environment) (document.Value, error) {
+	t, err := a.T.Eval(env)
+	if err != nil {
+		return document.Value{}, err
+	}
+
+	var loc *time.Location
+	if a.Zone != nil {
+		zone, err := a.Zone.Eval(env)
+		if err != nil {
+			return document.Value{}, err
+		}
+		if anyNull(t, zone) {
+			return document.NewNullValue(), nil
+		}
+
+		if zone.Type != document.TextValue {
+			return document.Value{}, stringutil.Errorf("at_time_zone(arg1, arg2) expects arg2 to be a time zone name")
+		}
+
+		loc, err = database.LoadTimeZone(zone.V.(string))
+		if err != nil {
+			return document.Value{}, err
+		}
+	} else {
+		if anyNull(t) {
+			return document.NewNullValue(), nil
+		}
+
+		loc = time.UTC
+		if s := env.GetSession(); s != nil {
+			loc = s.TimeZone()
+		}
+	}
+
+	tt, err := parseTimestamp("at_time_zone(arg1, arg2)", "arg1", t)
+	if err != nil {
+		return document.Value{}, err
+	}
+
+	return document.NewTextValue(tt.In(loc).Format(time.RFC3339Nano)), nil
+}
+
+// IsEqual compares this expression with the other expression and returns
+// true if they are equal.
+func (a *AtTimeZone) IsEqual(other expr.Expr) bool {
+	o, ok := other.(*AtTimeZone)
+	return ok && expr.Equal(a.T, o.T) && equalOrNil(a.Zone, o.Zone)
+}
+
+func (a *AtTimeZone) Params() []expr.Expr {
+	if a.Zone == nil {
+		return []expr.Expr{a.T}
+	}
+
+	return []expr.Expr{a.T, a.Zone}
+}
+
+func (a *AtTimeZone) String() string {
+	if a.Zone == nil {
+		return stringutil.Sprintf("at_time_zone(%s)", a.T)
+	}
+
+	return stringutil.Sprintf("at_time_zone(%s, %s)", a.T, a.Zone)
+}
+
+// parseTimestamp returns the time of a date or a timestamp, or parses a timestamp using document.ParseTimestamp.
+func parseTimestamp(fn, arg string, v document.Value) (time.Time, error) {
+	if v.Type.IsTime() {
+		return v.V.(time.Time), nil
+	}
+
+	if v.Type != document.TextValue {
+		return time.Time{}, stringutil.Errorf("%s expects %s to be a timestamp", fn, arg)
+	}
+
+	t, err := document.ParseTimestamp(v.V.(string))
+	if err != nil {
+		return time.Time{}, stringutil.Errorf("%s expects %s to be a timestamp", fn, arg)
+	}
+
+	return t, nil
+}
+
+var nowFunc = &definition{
+	name:  "now",
+	arity: 0,
+	constructorFn: func(args ...expr.Expr) (expr.Function, error) {
+		return &Now{}, nil
+	},
+}
+
+// Now is the NOW() function. It returns the time the current transaction
+// started at, so that every call made during a transaction returns the same timestamp.
+type Now struct{}
+
+// Eval returns the start time of the transaction, or the current time
+// if the environment is not attached to a transaction.
+func (n *Now) Eval(env *environment.Environment) (document.Value, error) {
+	markVolatile(env)
+
+	return document.NewTimestampValue(now(env)), nil
+}
+
+// now returns the start time of the transaction or the current time.
+func now(env *environment.Environment) time.Time {
+	if tx := env.GetTx(); tx != nil && !tx.StartTime.IsZero() {
+		return tx.StartTime
+	}
+
+	return time.Now()
+}
+
+// IsEqual compares this expression with the other expression and returns
+// true if they are equal.
+func (n *Now) IsEqual(other expr.Expr) bool {
+	_, ok := other.(*Now)
+	return ok
+}
+
+func (n *Now) Params() []expr.Expr { return nil }
+
+func (n *Now) String() string {
+	return "NOW()"
+}
+
+// timeUnits associates the units accepted by DATE_TRUNC, DATE_PART and EXTRACT
+// with their canonical name.
+var timeUnits = map[string]string{
+	"microsecond":  "microsecond",
+	"microseconds": "microsecond",
+	"millisecond":  "millisecond",
+	"milliseconds": "millisecond",
+	"second":       "second",
+	"seconds":      "second",
+	"minute":       "minute",
+	"minutes":      "minute",
+	"hour":         "hour",
+	"hours":        "hour",
+	"day":          "day",
+	"days":         "day",
+	"week":         "week",
+	"weeks":        "week",
+	"month":        "month",
+	"months":       "month",
+	"quarter":      "quarter",
+	"year":         "year",
+	"years":        "year",
+	"decade":       "decade",
+	"century":      "century",
+	"millennium":   "millennium",
+	"dow":          "dow",
+	"isodow":       "isodow",
+	"doy":          "doy",
+	"isoyear":      "isoyear",
+	"epoch":        "epoch",
+}
+
+// parseTimeUnit returns the canonical name of the unit v.
+func parseTimeUnit(fn string, v document.Value) (string, error) {
+	if v.Type != document.TextValue {
+		return "", stringutil.Errorf("%s expects arg1 to be a unit, i.e. 'day'", fn)
+	}
+
+	unit, ok := timeUnits[strings.ToLower(v.V.(string))]
+	if !ok {
+		return "", stringutil.Errorf("%s: unknown unit %q", fn, v.V)
+	}
+
+	return unit, nil
+}
+
+var dateTruncFunc = &ScalarDefinition{
+	name:  "date_trunc",
+	arity: 2,
+	callFn: func(args ...document.Value) (document.Value, error) {
+		if anyNull(args...) {
+			return document.NewNullValue(), nil
+		}
+
+		unit, err := parseTimeUnit("date_trunc(arg1, arg2)", args[0])
+		if err != nil {
+			return document.Value{}, err
+		}
+
+		t, err := parseTimestamp("date_trunc(arg1, arg2)", "arg2", args[1])
+		if err != nil {
+			return document.Value{}, err
+		}
+
+		t, ok := truncTime(unit, t)
+		if !ok {
+			return document.Value{}, stringutil.Errorf("date_trunc(arg1, arg2): unit %q is not supported", unit)
+		}
+
+		if args[1].Type == document.DateValue {
+			return document.NewDateValue(t), nil
+		}
+
+		return document.NewTimestampValue(t), nil
+	},
+}
+
+// truncTime truncates t to the given unit. Weeks start on monday, and centuries
+// and millenniums on their first year, i.e. 2001 for the 21st century.
+func truncTime(unit string, t time.Time) (time.Time, bool) {
+	y, m, d := t.Date()
+	h, min, sec := t.Clock()
+	ns := t.Nanosecond()
+
+	switch unit {
+	case "microsecond":
+		ns -= ns % 1000
+	case "millisecond":
+		ns -= ns % 1000000
+	case "second":
+		ns = 0
+	case "minute":
+		sec, ns = 0, 0
+	case "hour":
+		min, sec, ns = 0, 0, 0
+	case "day":
+		h, min, sec, ns = 0, 0, 0, 0
+	case "week":
+		d -= (int(t.Weekday()) + 6) % 7
+		h, min, sec, ns = 0, 0, 0, 0
+	case "month":
+		d, h, min, sec, ns = 1, 0, 0, 0, 0
+	case "quarter":
+		m = (m-1)/3*3 + 1
+		d, h, min, sec, ns = 1, 0, 0, 0, 0
+	case "year":
+		m, d, h, min, sec, ns = 1, 1, 0, 0, 0, 0
+	case "decade":
+		y -= y % 10
+		m, d, h, min, sec, ns = 1, 1, 0, 0, 0, 0
+	case "century":
+		y = (y-1)/100*100 + 1
+		m, d, h, min, sec, ns = 1, 1, 0, 0, 0, 0
+	case "millennium":
+		y = (y-1)/1000*1000 + 1
+		m, d, h, min, sec, ns = 1, 1, 0, 0, 0, 0
+	default:
+		return t, false
+	}
+
+	return time.Date(y, m, d, h, min, sec, ns, t.Location()), true
+}
+
+var datePartFunc = &ScalarDefinition{
+	name:  "date_part",
+	arity: 2,
+	callFn: func(args ...document.Value) (document.Value, error) {
+		if anyNull(args...) {
+			return document.NewNullValue(), nil
+		}
+
+		unit, err := parseTimeUnit("date_part(arg1, arg2)", args[0])
+		if err != nil {
+			return document.Value{}, err
+		}
+
+		return datePart("date_part(arg1, arg2)", unit, args[1])
+	},
+}
+
+// datePart returns the given unit of a date, a timestamp or an interval.
+// Seconds, milliseconds and epochs are doubles, other units are integers.
+func datePart(fn, unit string, v document.Value) (document.Value, error) {
+	if v.Type == document.IntervalValue {
+		return intervalPart(fn, unit, v.V.(document.Interval))
+	}
+
+	t, err := parseTimestamp(fn, "arg2", v)
+	if err != nil {
+		return document.Value{}, err
+	}
+
+	var n int
+	switch unit {
+	case "microsecond":
+		return document.NewIntegerValue(int64(t.Second())*1e6 + int64(t.Nanosecond()/1e3)), nil
+	case "millisecond":
+		return document.NewDoubleValue(float64(t.Second())*1e3 + float64(t.Nanosecond())/1e6), nil
+	case "second":
+		return document.NewDoubleValue(float64(t.Second()) + float64(t.Nanosecond())/1e9), nil
+	case "epoch":
+		return document.NewDoubleValue(float64(t.Unix()) + float64(t.Nanosecond())/1e9), nil
+	case "minute":
+		n = t.Minute()
+	case "hour":
+		n = t.Hour()
+	case "day":
+		n = t.Day()
+	case "week":
+		_, n = t.ISOWeek()
+	case "month":
+		n = int(t.Month())
+	case "quarter":
+		n = (int(t.Month())-1)/3 + 1
+	case "year":
+		n = t.Year()
+	case "decade":
+		n = t.Year() / 10
+	case "century":
+		n = (t.Year()-1)/100 + 1
+	case "millennium":
+		n = (t.Year()-1)/1000 + 1
+	case "dow":
+		n = int(t.Weekday())
+	case "isodow":
+		n = (int(t.Weekday())+6)%7 + 1
+	case "doy":
+		n = t.YearDay()
+	case "isoyear":
+		n, _ = t.ISOWeek()
+	}
+
+	return document.NewIntegerValue(int64(n)), nil
+}
+
+// intervalPart returns the given unit of an interval.
+func intervalPart(fn, unit string, i document.Interval) (document.Value, error) {
+	const nanosPerMinute = int64(time.Minute)
+
+	switch unit {
+	case "microsecond":
+		return document.NewIntegerValue(i.Nanos % nanosPerMinute / 1e3), nil
+	case "millisecond":
+		return document.NewDoubleValue(float64(i.Nanos%nanosPerMinute) / 1e6), nil
+	case "second":
+		return document.NewDoubleValue(float64(i.Nanos%nanosPerMinute) / 1e9), nil
+	case "epoch":
+		days := float64(i.Months*30 + i.Days)
+		return document.NewDoubleValue(days*86400 + float64(i.Nanos)/1e9), nil
+	case "minute":
+		return document.NewIntegerValue(i.Nanos % int64(time.Hour) / nanosPerMinute), nil
+	case "hour":
+		return document.NewIntegerValue(i.Nanos / int64(time.Hour)), nil
+	case "day":
+		return document.NewIntegerValue(i.Days), nil
+	case "month":
+		return document.NewIntegerValue(i.Months % 12), nil
+	case "year":
+		return document.NewIntegerValue(i.Months / 12), nil
+	}
+
+	return document.Value{}, stringutil.Errorf("%s: unit %q is not supported for intervals", fn, unit)
+}
+
+// Extract is the EXTRACT(unit FROM expr) function.
+// It returns the same value as DATE_PART('unit', expr).
+type Extract struct {
+	Unit string
+	Expr expr.Expr
+}
+
+// NewExtract returns an EXTRACT function.
+func NewExtract(unit string, e expr.Expr) (*Extract, error) {
+	u, ok := timeUnits[strings.ToLower(unit)]
+	if !ok {
+		return nil, stringutil.Errorf("extract(): unknown unit %q", unit)
+	}
+
+	return &Extract{Unit: u, Expr: e}, nil
+}
+
+// Eval returns the unit of the date, timestamp or interval returned by Expr.
+func (e *Extract) Eval(env *environment.Environment) (document.Value, error) {
+	v, err := e.Expr.Eval(env)
+	if err != nil || v.Type == document.NullValue {
+		return v, err
+	}
+
+	return datePart("extract()", e.Unit, v)
+}
+
+// IsEqual compares this expression with the other expression and returns
+// true if they are equal.
+func (e *Extract) IsEqual(other expr.Expr) bool {
+	o, ok := other.(*Extract)
+	return ok && e.Unit == o.Unit && expr.Equal(e.Expr, o.Expr)
+}
+
+func (e *Extract) Params() []expr.Expr { return []expr.Expr{e.Expr} }
+
+func (e *Extract) String() string {
+	return stringutil.Sprintf("EXTRACT(%s FROM %s)", strings.ToUpper(e.Unit), e.Expr)
+}
+
+var strftimeFunc = &ScalarDefinition{
+	name:  "strftime",
+	arity: 2,
+	callFn: func(args ...document.Value) (document.Value, error) {
+		if anyNull(args...) {
+			return document.NewNullValue(), nil
+		}
+
+		if args[0].Type != document.TextValue {
+			return document.Value{}, stringutil.Errorf("strftime(arg1, arg2) expects arg1 to be a text")
+		}
+
+		t, err := parseTimestamp("strftime(arg1, arg2)", "arg2", args[1])
+		if err != nil {
+			return document.Value{}, err
+		}
+
+		s, err := strftime(args[0].V.(string), t)
+		if err != nil {
+			return document.Value{}, err
+		}
+
+		return document.NewTextValue(s), nil
+	},
+}
+
+// strftime formats t using the substitutions of SQLite:
+//
+//	%d day of month: 01-31
+//	%f fractional seconds: SS.SSS
+//	%H hour: 00-23
+//	%j day of year: 001-366
+//	%m month: 01-12
+//	%M minute: 00-59
+//	%s seconds since 1970-01-01
+//	%S seconds: 00-59
+//	%w day of week 0-6 with Sunday==0
+//	%W week of year: 00-53, the first monday being the first day of week 01
+//	%Y year: 0000-9999
+//	%% %
+func strftime(format string, t time.Time) (string, error) {
+	var sb strings.Builder
+
+	pad := func(n, width int) {
+		s := strconv.Itoa(n)
+		for i := len(s); i < width; i++ {
+			sb.WriteByte('0')
+		}
+		sb.WriteString(s)
+	}
+
+	for i := 0; i < len(format); i++ {
+		c := format[i]
+		if c != '%' {
+			sb.WriteByte(c)
+			continue
+		}
+
+		i++
+		if i == len(format) {
+			return "", errors.New("strftime(arg1, arg2): incomplete substitution at the end of arg1")
+		}
+
+		switch format[i] {
+		case 'd':
+			pad(t.Day(), 2)
+		case 'f':
+			pad(t.Second(), 2)
+			sb.WriteByte('.')
+			pad(t.Nanosecond()/1e6, 3)
+		case 'H':
+			pad(t.Hour(), 2)
+		case 'j':
+			pad(t.YearDay(), 3)
+		case 'm':
+			pad(int(t.Month()), 2)
+		case 'M':
+			pad(t.Minute(), 2)
+		case 's':
+			sb.WriteString(strconv.FormatInt(t.Unix(), 10))
+		case 'S':
+			pad(t.Second(), 2)
+		case 'w':
+			pad(int(t.Weekday()), 1)
+		case 'W':
+			pad((t.YearDay()+6-(int(t.Weekday())+6)%7)/7, 2)
+		case 'Y':
+			pad(t.Year(), 4)
+		case '%':
+			sb.WriteByte('%')
+		default:
+			return "", stringutil.Errorf("strftime(arg1, arg2): unknown substitution %%%c", format[i])
+		}
+	}
+
+	return sb.String(), nil
+}
+
+var ageFunc = &definition{
+	name:     "age",
+	arity:    2,
+	optional: 1,
+	constructorFn: func(args ...expr.Expr) (expr.Function, error) {
+		a := Age{A: args[0]}
+		if len(args) > 1 {
+			a.B = args[1]
+		}
+		return &a, nil
+	},
+}
+
+// Age is the AGE(a [, b]) function. It returns a - b as an interval of years, months and days,
+// i.e. AGE(TIMESTAMP '2021-03-15', TIMESTAMP '2020-01-10') returns 1 year 2 months 5 days.
+// If b is omitted, it returns the age of a at the midnight UTC of the current date.
+type Age struct {
+	A expr.Expr
+	B expr.Expr
+}
+
+// Eval returns the age of A at B.
+func (a *Age) Eval(env *environment.Environment) (document.Value, error) {
+	va, err := a.A.Eval(env)
+	if err != nil {
+		return document.Value{}, err
+	}
+
+	var vb document.Value
+	if a.B != nil {
+		vb, err = a.B.Eval(env)
+		if err != nil {
+			return document.Value{}, err
+		}
+	} else {
+		// AGE(a) returns the age of a at the current date
+		markVolatile(env)
+		va, vb = document.NewDateValue(now(env)), va
+	}
+
+	if anyNull(va, vb) {
+		return document.NewNullValue(), nil
+	}
+
+	ta, err := parseTimestamp("age(arg1, arg2)", "arg1", va)
+	if err != nil {
+		return document.Value{}, err
+	}
+	tb, err := parseTimestamp("age(arg1, arg2)", "arg2", vb)
+	if err != nil {
+		return document.Value{}, err
+	}
+
+	return document.NewIntervalValue(document.Age(ta, tb)), nil
+}
+
+// IsEqual compares this expression with the other expression and returns
+// true if they are equal.
+func (a *Age) IsEqual(other expr.Expr) bool {
+	o, ok := other.(*Age)
+	return ok && expr.Equal(a.A, o.A) && equalOrNil(a.B, o.B)
+}
+
+func (a *Age) Params() []expr.Expr {
+	if a.B == nil {
+		return []expr.Expr{a.A}
+	}
+
+	return []expr.Expr{a.A, a.B}
+}
+
+func (a *Age) String() string {
+	if a.B == nil {
+		return stringutil.Sprintf("AGE(%s)", a.A)
+	}
+
+	return stringutil.Sprintf("AGE(%s, %s)", a.A, a.B)
+}
@@ -0,0 +1,77 @@
+package functions
+
+import (
+	"crypto/rand"
+	"encoding/binary"
+	"sync"
+	"time"
+
+	"github.com/genjidb/genji/document"
+)
+
+// uuidFunc returns a random version 4 UUID.
+var uuidFunc = &ScalarDefinition{
+	name:     "uuid",
+	arity:    0,
+	volatile: true,
+	callFn: func(args ...document.Value) (document.Value, error) {
+		var u document.UUID
+		_, err := rand.Read(u[:])
+		if err != nil {
+			return document.Value{}, err
+		}
+
+		setUUIDVersion(&u, 4)
+		return document.NewUUIDValue(u), nil
+	},
+}
+
+// uuidV7Func returns a version 7 UUID, whose first 48 bits are the unix timestamp in milliseconds.
+// The following 12 bits store the sub-millisecond fraction of the timestamp, incremented if necessary
+// to keep UUIDs generated by the same process strictly ordered, and the remaining bits are random.
+var uuidV7Func = &ScalarDefinition{
+	name:     "uuid_v7",
+	arity:    0,
+	volatile: true,
+	callFn: func(args ...document.Value) (document.Value, error) {
+		var u document.UUID
+		_, err := rand.Read(u[8:])
+		if err != nil {
+			return document.Value{}, err
+		}
+
+		ts := uuidV7Clock.next()
+		binary.BigEndian.PutUint64(u[:8], ts>>12<<16|ts&0xfff)
+		setUUIDVersion(&u, 7)
+		return document.NewUUIDValue(u), nil
+	},
+}
+
+var uuidV7Clock v7Clock
+
+// v7Clock returns strictly increasing timestamps, made of the unix timestamp in milliseconds
+// followed by 12 bits of sub-millisecond precision.
+type v7Clock struct {
+	mu   sync.Mutex
+	last uint64
+}
+
+func (c *v7Clock) next() uint64 {
+	ns := time.Now().UnixNano()
+	ts := uint64(ns/int64(time.Millisecond))<<12 | uint64((ns%int64(time.Millisecond))*4096/int64(time.Millisecond))
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if ts <= c.last {
+		ts = c.last + 1
+	}
+	c.last = ts
+	return ts
+}
+
+// setUUIDVersion sets the version and the RFC 4122 variant bits of u.
+func setUUIDVersion(u *document.UUID, version byte) {
+	u[6] = u[6]&0x0f | version<<4
+	u[8] = u[8]&0x3f | 0x80
+}
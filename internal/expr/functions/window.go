@@ -0,0 +1,258 @@
+package functions
+
+import (
+	"strings"
+
+	"github.com/genjidb/genji/document"
+	"github.com/genjidb/genji/internal/environment"
+	"github.com/genjidb/genji/internal/expr"
+	"github.com/genjidb/genji/internal/stringutil"
+)
+
+var rowNumberFunc = &definition{
+	name:  "row_number",
+	arity: 0,
+	constructorFn: func(args ...expr.Expr) (expr.Function, error) {
+		return &RowNumber{}, nil
+	},
+}
+
+var rankFunc = &definition{
+	name:  "rank",
+	arity: 0,
+	constructorFn: func(args ...expr.Expr) (expr.Function, error) {
+		return &Rank{}, nil
+	},
+}
+
+var denseRankFunc = &definition{
+	name:  "dense_rank",
+	arity: 0,
+	constructorFn: func(args ...expr.Expr) (expr.Function, error) {
+		return &Rank{Dense: true}, nil
+	},
+}
+
+var lagFunc = &definition{
+	name:     "lag",
+	arity:    3,
+	optional: 2,
+	constructorFn: func(args ...expr.Expr) (expr.Function, error) {
+		return newLag(false, args...), nil
+	},
+}
+
+var leadFunc = &definition{
+	name:     "lead",
+	arity:    3,
+	optional: 2,
+	constructorFn: func(args ...expr.Expr) (expr.Function, error) {
+		return newLag(true, args...), nil
+	},
+}
+
+// misuseOfWindowFunction is returned when a window function is evaluated without an OVER clause.
+func misuseOfWindowFunction(fn expr.Expr) error {
+	return stringutil.Errorf("misuse of window function %s, it must be used with an OVER clause", fn)
+}
+
+var _ expr.WindowFunc = (*RowNumber)(nil)
+
+// RowNumber is the ROW_NUMBER() window function. It returns the position
+// of the document in its partition, starting at 1.
+type RowNumber struct{}
+
+// Eval returns an error, ROW_NUMBER() is only valid in an OVER clause.
+func (r *RowNumber) Eval(env *environment.Environment) (document.Value, error) {
+	return document.Value{}, misuseOfWindowFunction(r)
+}
+
+// EvalWindow implements the expr.WindowFunc interface.
+func (r *RowNumber) EvalWindow(p *expr.Partition, i int) (document.Value, error) {
+	return document.NewIntegerValue(int64(i + 1)), nil
+}
+
+// IsEqual compares this expression with the other expression and returns
+// true if they are equal.
+func (r *RowNumber) IsEqual(other expr.Expr) bool {
+	_, ok := other.(*RowNumber)
+	return ok
+}
+
+func (r *RowNumber) Params() []expr.Expr { return nil }
+
+func (r *RowNumber) String() string {
+	return "ROW_NUMBER()"
+}
+
+var _ expr.WindowFunc = (*Rank)(nil)
+
+// Rank is the RANK() and DENSE_RANK() window functions. They return the rank
+// of the document in its partition, starting at 1, documents with the same ORDER BY
+// values having the same rank.
+// RANK() leaves gaps after peers while DENSE_RANK() doesn't.
+type Rank struct {
+	Dense bool
+}
+
+// Eval returns an error, RANK() is only valid in an OVER clause.
+func (r *Rank) Eval(env *environment.Environment) (document.Value, error) {
+	return document.Value{}, misuseOfWindowFunction(r)
+}
+
+// EvalWindow implements the expr.WindowFunc interface.
+func (r *Rank) EvalWindow(p *expr.Partition, i int) (document.Value, error) {
+	if r.Dense {
+		return document.NewIntegerValue(int64(p.Peers[i] + 1)), nil
+	}
+
+	return document.NewIntegerValue(int64(p.PeersStart(i) + 1)), nil
+}
+
+// IsEqual compares this expression with the other expression and returns
+// true if they are equal.
+func (r *Rank) IsEqual(other expr.Expr) bool {
+	o, ok := other.(*Rank)
+	return ok && r.Dense == o.Dense
+}
+
+func (r *Rank) Params() []expr.Expr { return nil }
+
+func (r *Rank) String() string {
+	if r.Dense {
+		return "DENSE_RANK()"
+	}
+
+	return "RANK()"
+}
+
+var _ expr.WindowFunc = (*Lag)(nil)
+
+// Lag is the LAG(expr [, offset [, default]]) and LEAD(expr [, offset [, default]]) window functions.
+// LAG evaluates expr on the document located offset documents before the current one in its partition,
+// LEAD on the document located offset documents after it.
+// The offset defaults to 1. If there is no such document, default is returned, or NULL if it is omitted.
+type Lag struct {
+	Expr    expr.Expr
+	Offset  expr.Expr
+	Default expr.Expr
+	// Lead is true for LEAD.
+	Lead bool
+}
+
+func newLag(lead bool, args ...expr.Expr) *Lag {
+	l := Lag{Expr: args[0], Lead: lead}
+	if len(args) > 1 {
+		l.Offset = args[1]
+	}
+	if len(args) > 2 {
+		l.Default = args[2]
+	}
+
+	return &l
+}
+
+// Eval returns an error, LAG() is only valid in an OVER clause.
+func (l *Lag) Eval(env *environment.Environment) (document.Value, error) {
+	return document.Value{}, misuseOfWindowFunction(l)
+}
+
+// EvalWindow implements the expr.WindowFunc interface.
+func (l *Lag) EvalWindow(p *expr.Partition, i int) (document.Value, error) {
+	env := p.Envs[i]
+
+	offset := int64(1)
+	if l.Offset != nil {
+		v, err := l.Offset.Eval(env)
+		if err != nil {
+			return document.Value{}, err
+		}
+		if v.Type != document.IntegerValue || v.V.(int64) < 0 {
+			return document.Value{}, stringutil.Errorf("%s expects the offset to be a positive integer", l.name())
+		}
+		offset = v.V.(int64)
+	}
+
+	j := int64(i) - offset
+	if l.Lead {
+		j = int64(i) + offset
+	}
+
+	if j < 0 || j >= int64(p.Len()) {
+		if l.Default == nil {
+			return expr.NullLiteral, nil
+		}
+		return evalOrNull(l.Default, env)
+	}
+
+	return evalOrNull(l.Expr, p.Envs[j])
+}
+
+// evalOrNull evaluates e and returns NULL if it refers to a missing field.
+func evalOrNull(e expr.Expr, env *environment.Environment) (document.Value, error) {
+	v, err := e.Eval(env)
+	if err == document.ErrFieldNotFound {
+		return expr.NullLiteral, nil
+	}
+
+	return v, err
+}
+
+// IsEqual compares this expression with the other expression and returns
+// true if they are equal.
+func (l *Lag) IsEqual(other expr.Expr) bool {
+	o, ok := other.(*Lag)
+	if !ok || l.Lead != o.Lead {
+		return false
+	}
+
+	return expr.Equal(l.Expr, o.Expr) && equalOrNil(l.Offset, o.Offset) && equalOrNil(l.Default, o.Default)
+}
+
+func equalOrNil(a, b expr.Expr) bool {
+	if a == nil || b == nil {
+		return a == nil && b == nil
+	}
+
+	return expr.Equal(a, b)
+}
+
+func (l *Lag) Params() []expr.Expr {
+	params := []expr.Expr{l.Expr}
+	if l.Offset != nil {
+		params = append(params, l.Offset)
+	}
+	if l.Default != nil {
+		params = append(params, l.Default)
+	}
+
+	return params
+}
+
+func (l *Lag) name() string {
+	if l.Lead {
+		return "LEAD()"
+	}
+
+	return "LAG()"
+}
+
+func (l *Lag) String() string {
+	var sb strings.Builder
+
+	if l.Lead {
+		sb.WriteString("LEAD(")
+	} else {
+		sb.WriteString("LAG(")
+	}
+
+	for i, p := range l.Params() {
+		if i > 0 {
+			sb.WriteString(", ")
+		}
+		sb.WriteString(p.String())
+	}
+	sb.WriteString(")")
+
+	return sb.String()
+}
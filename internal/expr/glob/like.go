@@ -58,9 +58,16 @@ func equalFold(sr, tr rune) bool {
 // MatchLike reports whether string s matches the SQL LIKE-style glob pattern.
 // Supported wildcards are '_' (match any one character) and '%' (match zero
 // or more characters). They can be escaped by '\' (escape character).
+// The comparison is case-insensitive.
 //
 // MatchLike requires pattern to match whole string, not just a substring.
 func MatchLike(pattern, s string) bool {
+	return MatchLikeWith(pattern, s, matchEsc, true)
+}
+
+// MatchLikeWith is like MatchLike but wildcards are escaped by the esc character
+// and the comparison is only case-insensitive if ignoreCase is true.
+func MatchLikeWith(pattern, s string, esc rune, ignoreCase bool) bool {
 	var prevEscape bool
 
 	var w, t string // backtracking state
@@ -78,7 +85,7 @@ func MatchLike(pattern, s string) bool {
 		//
 		// 1. p is an unescaped matchAll character “%”,
 		// 2. p is an unescaped matchOne character “_”,
-		// 3. p is an unescaped matchEsc character, or
+		// 3. p is an unescaped escape character, or
 		// 4. p is to be handled as an ordinary character
 		//
 		if p == matchAll && !prevEscape {
@@ -126,7 +133,7 @@ func MatchLike(pattern, s string) bool {
 			// That is, we are guaranteed to have input at this point.
 			//
 			s = skipRune(s)
-		} else if p == matchEsc && !prevEscape {
+		} else if p == esc && !prevEscape {
 			// Case 3.
 			//
 			// We can’t reach this case from backtracking to matchAll.
@@ -144,7 +151,7 @@ func MatchLike(pattern, s string) bool {
 
 			var r rune
 			r, s = readRune(s)
-			if !equalFold(p, r) {
+			if p != r && (!ignoreCase || !equalFold(p, r)) {
 				goto backtrack
 			}
 		}
@@ -169,19 +176,50 @@ func MatchLike(pattern, s string) bool {
 	}
 
 	// Check that the rest of the pattern is matchAll.
-	for i := 0; i < len(pattern); i++ {
-		if pattern[i] == matchAll {
+	for len(pattern) != 0 {
+		var p rune
+		p, pattern = readRune(pattern)
+		if p == matchAll {
 			continue
 		}
 
 		// Allow escaping end of string.
-		if i+1 == len(pattern) {
-			if pattern[i] == matchEsc {
-				return true
+		return p == esc && len(pattern) == 0
+	}
+	return true
+}
+
+// LiteralPrefix returns the part of the pattern that precedes the first
+// unescaped wildcard, with escape characters removed.
+// Any string matched by the pattern starts with that prefix.
+// If ignoreCase is true, the prefix stops before the first character
+// that has other case variants.
+func LiteralPrefix(pattern string, esc rune, ignoreCase bool) string {
+	var prefix []byte
+	var prevEscape bool
+
+	for len(pattern) != 0 {
+		var p rune
+		rest := pattern
+		p, pattern = readRune(pattern)
+
+		if !prevEscape {
+			if p == matchAll || p == matchOne {
+				break
+			}
+			if p == esc {
+				prevEscape = true
+				continue
 			}
 		}
+		prevEscape = false
+
+		if ignoreCase && unicode.SimpleFold(p) != p {
+			break
+		}
 
-		return false
+		prefix = append(prefix, rest[:len(rest)-len(pattern)]...)
 	}
-	return true
+
+	return string(prefix)
 }
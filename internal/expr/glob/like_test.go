@@ -200,3 +200,66 @@ func TestMatchLike(t *testing.T) {
 		}
 	}
 }
+
+func TestMatchLikeWith(t *testing.T) {
+	tests := []struct {
+		s, pattern string
+		esc        rune
+		ignoreCase bool
+		want       bool
+	}{
+		// Case sensitivity
+		{"bLah", "blah", '\\', false, false},
+		{"bLah", "blah", '\\', true, true},
+		{"bLah", "bL%", '\\', false, true},
+		{"bLah", "bl%", '\\', false, false},
+
+		// Custom escape character
+		{"10%", "10!%", '!', false, true},
+		{"100", "10!%", '!', false, false},
+		{"a_b", "a!_b", '!', false, true},
+		{"axb", "a!_b", '!', false, false},
+		{"a!b", "a!!b", '!', false, true},
+		{"a\\b", "a\\b", '!', false, true},
+		{"", "!", '!', false, true},
+		{"x", "%!", '!', false, true},
+		{"€", "é€", 'é', false, true},
+	}
+
+	for _, test := range tests {
+		if got := MatchLikeWith(test.pattern, test.s, test.esc, test.ignoreCase); got != test.want {
+			t.Errorf(
+				"MatchLikeWith(%#v, %#v, %q, %v): expected %#v, got %#v",
+				test.pattern, test.s, test.esc, test.ignoreCase, test.want, got,
+			)
+		}
+	}
+}
+
+func TestLiteralPrefix(t *testing.T) {
+	tests := []struct {
+		pattern    string
+		esc        rune
+		ignoreCase bool
+		want       string
+	}{
+		{"", '\\', false, ""},
+		{"%", '\\', false, ""},
+		{"abc", '\\', false, "abc"},
+		{"abc%", '\\', false, "abc"},
+		{"ab_c%", '\\', false, "ab"},
+		{"ab\\%c%", '\\', false, "ab%c"},
+		{"ab!_c%", '!', false, "ab_c"},
+		{"Abc%", '\\', true, ""},
+		{"12-ab%", '\\', true, "12-"},
+	}
+
+	for _, test := range tests {
+		if got := LiteralPrefix(test.pattern, test.esc, test.ignoreCase); got != test.want {
+			t.Errorf(
+				"LiteralPrefix(%#v, %q, %v): expected %#v, got %#v",
+				test.pattern, test.esc, test.ignoreCase, test.want, got,
+			)
+		}
+	}
+}
@@ -1,6 +1,8 @@
 package expr
 
 import (
+	"unicode/utf8"
+
 	"github.com/genjidb/genji/document"
 	"github.com/genjidb/genji/internal/environment"
 	"github.com/genjidb/genji/internal/expr/glob"
@@ -8,26 +10,73 @@ import (
 	"github.com/genjidb/genji/internal/stringutil"
 )
 
-func like(pattern, text string) bool {
-	return glob.MatchLike(pattern, text)
-}
+// DefaultLikeEscape is the character used to escape wildcards
+// when no ESCAPE clause is specified.
+const DefaultLikeEscape = '\\'
 
 type LikeOperator struct {
 	*simpleOperator
+
+	// Escape evaluates to the character used to escape wildcards in the pattern.
+	// If nil, DefaultLikeEscape is used.
+	Escape Expr
 }
 
 // Like creates an expression that evaluates to the result of a LIKE b.
+// The comparison is case-sensitive.
 func Like(a, b Expr) Expr {
-	return &LikeOperator{&simpleOperator{a, b, scanner.LIKE}}
+	return &LikeOperator{simpleOperator: &simpleOperator{a, b, scanner.LIKE}}
+}
+
+// ILike creates an expression that evaluates to the result of a ILIKE b.
+// The comparison is case-insensitive.
+func ILike(a, b Expr) Expr {
+	return &LikeOperator{simpleOperator: &simpleOperator{a, b, scanner.ILIKE}}
+}
+
+// SetEscape sets the expression evaluating to the escape character.
+func (op *LikeOperator) SetEscape(e Expr) {
+	op.Escape = e
+}
+
+// IgnoreCase returns true if the operator is ILIKE.
+func (op *LikeOperator) IgnoreCase() bool {
+	return op.Tok == scanner.ILIKE
+}
+
+// EscapeRune evaluates the escape expression and returns the escape character.
+func (op *LikeOperator) EscapeRune(env *environment.Environment) (rune, error) {
+	if op.Escape == nil {
+		return DefaultLikeEscape, nil
+	}
+
+	v, err := op.Escape.Eval(env)
+	if err != nil {
+		return 0, err
+	}
+
+	if v.Type == document.TextValue {
+		s := v.V.(string)
+		if r, size := utf8.DecodeRuneInString(s); r != utf8.RuneError && size == len(s) {
+			return r, nil
+		}
+	}
+
+	return 0, stringutil.Errorf("invalid escape character %v: must be a single character", v)
 }
 
 func (op *LikeOperator) Eval(env *environment.Environment) (document.Value, error) {
+	esc, err := op.EscapeRune(env)
+	if err != nil {
+		return NullLiteral, err
+	}
+
 	return op.simpleOperator.eval(env, func(a, b document.Value) (document.Value, error) {
 		if a.Type != document.TextValue || b.Type != document.TextValue {
 			return NullLiteral, nil
 		}
 
-		if like(b.V.(string), a.V.(string)) {
+		if glob.MatchLikeWith(b.V.(string), a.V.(string), esc, op.IgnoreCase()) {
 			return TrueLiteral, nil
 		}
 
@@ -35,19 +84,59 @@ func (op *LikeOperator) Eval(env *environment.Environment) (document.Value, erro
 	})
 }
 
+// IsEqual compares this expression with the other expression and returns
+// true if they are equal.
+func (op *LikeOperator) IsEqual(other Expr) bool {
+	o, ok := other.(*LikeOperator)
+	if !ok {
+		return false
+	}
+
+	return op.simpleOperator.IsEqual(o) && Equal(op.Escape, o.Escape)
+}
+
+func (op *LikeOperator) String() string {
+	return op.format("%v %v %v")
+}
+
+func (op *LikeOperator) format(layout string) string {
+	s := stringutil.Sprintf(layout, op.a, op.Tok, op.b)
+	if op.Escape != nil {
+		s += stringutil.Sprintf(" ESCAPE %v", op.Escape)
+	}
+
+	return s
+}
+
 type NotLikeOperator struct {
 	LikeOperator
 }
 
 // NotLike creates an expression that evaluates to the result of a NOT LIKE b.
 func NotLike(a, b Expr) Expr {
-	return &NotLikeOperator{LikeOperator{&simpleOperator{a, b, scanner.LIKE}}}
+	return &NotLikeOperator{LikeOperator{simpleOperator: &simpleOperator{a, b, scanner.LIKE}}}
+}
+
+// NotILike creates an expression that evaluates to the result of a NOT ILIKE b.
+func NotILike(a, b Expr) Expr {
+	return &NotLikeOperator{LikeOperator{simpleOperator: &simpleOperator{a, b, scanner.ILIKE}}}
 }
 
 func (op *NotLikeOperator) Eval(env *environment.Environment) (document.Value, error) {
 	return invertBoolResult(op.LikeOperator.Eval)(env)
 }
 
+// IsEqual compares this expression with the other expression and returns
+// true if they are equal.
+func (op *NotLikeOperator) IsEqual(other Expr) bool {
+	o, ok := other.(*NotLikeOperator)
+	if !ok {
+		return false
+	}
+
+	return op.LikeOperator.IsEqual(&o.LikeOperator)
+}
+
 func (op *NotLikeOperator) String() string {
-	return stringutil.Sprintf("%v NOT LIKE %v", op.a, op.b)
+	return op.format("%v NOT %v %v")
 }
@@ -0,0 +1,12 @@
+package expr_test
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/genjidb/genji/internal/testutil"
+)
+
+func TestLike(t *testing.T) {
+	testutil.ExprRunner(t, filepath.Join("testdata", "like.sql"))
+}
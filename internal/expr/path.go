@@ -30,12 +30,52 @@ func (p Path) Eval(env *environment.Environment) (document.Value, error) {
 
 	v, err := dp.GetValueFromDocument(d)
 	if err == document.ErrFieldNotFound {
-		return NullLiteral, nil
+		return evalOuterQueryPath(env, dp, d)
 	}
 
 	return v, err
 }
 
+// evalOuterQueryPath evaluates paths referring to the documents of the queries
+// containing a subquery, e.g. u.id in "SELECT * FROM users AS u WHERE 0 < (SELECT COUNT(*) FROM orders AS o WHERE o.user_id = u.id)".
+// Only paths with multiple fragments whose first field is missing from the current document
+// are looked up in the outer queries, starting from the closest one.
+// The first field is either the alias of an outer table, or the name of the table
+// read by an outer query without alias nor join, e.g. users in
+// "SELECT * FROM users WHERE 0 < (SELECT COUNT(*) FROM orders WHERE user_id = users.id)".
+func evalOuterQueryPath(env *environment.Environment, p document.Path, d document.Document) (document.Value, error) {
+	if len(p) < 2 || p[0].FieldName == "" {
+		return NullLiteral, nil
+	}
+	if _, err := d.GetByField(p[0].FieldName); err != document.ErrFieldNotFound {
+		return NullLiteral, nil
+	}
+
+	outer, tableName := env.GetOuterQuery()
+	for outer != nil {
+		od, ok := outer.GetDocument()
+		if ok {
+			path := p
+			_, err := od.GetByField(p[0].FieldName)
+			if err != nil && p[0].FieldName == tableName {
+				path, err = p[1:], nil
+			}
+
+			if err == nil {
+				v, err := path.GetValueFromDocument(od)
+				if err == document.ErrFieldNotFound {
+					return NullLiteral, nil
+				}
+				return v, err
+			}
+		}
+
+		outer, tableName = outer.GetOuterQuery()
+	}
+
+	return NullLiteral, nil
+}
+
 // IsEqual compares this expression with the other expression and returns
 // true if they are equal.
 func (p Path) IsEqual(other Expr) bool {
@@ -47,6 +47,43 @@ NULL
 > 1 ^ NULL
 NULL
 
+> 1 << 4
+16
+
+> 1 << NULL
+NULL
+
+> 256 >> 4
+16
+
+> -256 >> 4
+-16
+
+> 1 >> NULL
+NULL
+
+-- test: bit shift overflow
+> 1 << 63
+-9223372036854775808
+
+> 3 << 63
+-9223372036854775808
+
+> 1 << 64
+0
+
+> -1 >> 64
+-1
+
+> 1 >> 64
+0
+
+> 1 << -1
+NULL
+
+> 2.5 << 1
+4
+
 -- test: divide by zero
 > 1 / 0
 NULL
@@ -75,3 +112,9 @@ NULL
 
 ! 1 ^ a
 'field not found'
+
+! 1 << a
+'field not found'
+
+! 1 >> a
+'field not found'
@@ -0,0 +1,47 @@
+-- test: LIKE
+> 'foo' LIKE 'f%'
+true
+
+> 'foo' LIKE 'F%'
+false
+
+> 'foo' NOT LIKE 'F%'
+true
+
+> 'foo' LIKE NULL
+NULL
+
+> 1 LIKE '1'
+NULL
+
+-- test: ILIKE
+> 'foo' ILIKE 'F%'
+true
+
+> 'foo' ILIKE 'B%'
+false
+
+> 'foo' NOT ILIKE 'F_O'
+false
+
+-- test: ESCAPE
+> '10%' LIKE '10!%' ESCAPE '!'
+true
+
+> '100' LIKE '10!%' ESCAPE '!'
+false
+
+> '10%' LIKE '10\\%'
+true
+
+> '10%' NOT LIKE '10!%' ESCAPE '!'
+false
+
+> 'A_b' ILIKE 'a#_B' ESCAPE '#'
+true
+
+! 'foo' LIKE 'f%' ESCAPE '!!'
+'invalid escape character "!!": must be a single character'
+
+! 'foo' LIKE 'f%' ESCAPE 1
+'invalid escape character 1: must be a single character'
@@ -0,0 +1,59 @@
+-- test: ANY
+> 1 = ANY [1, 2]
+true
+
+> 3 = SOME [1, 2]
+false
+
+> 3 > ANY [1, 4]
+true
+
+> 1 = ANY []
+false
+
+> 1 = ANY [NULL, 2]
+NULL
+
+> 1 = ANY [NULL, 1]
+true
+
+> NULL = ANY [1]
+NULL
+
+> NULL = ANY []
+false
+
+> 1 = ANY NULL
+NULL
+
+! 1 = ANY 1
+'ANY operand must be an array or a subquery, got integer'
+
+-- test: ALL
+> 3 > ALL [1, 2]
+true
+
+> 2 > ALL [1, 2]
+false
+
+> 1 != ALL [2, 3]
+true
+
+> 1 = ALL []
+true
+
+> 3 > ALL [NULL, 2]
+NULL
+
+> 1 > ALL [NULL, 2]
+false
+
+> NULL > ALL []
+true
+
+-- test: precedence
+> 1 + 1 = ANY [2] AND 1 < ALL [2, 3]
+true
+
+> NOT 1 = ANY [2]
+true
@@ -0,0 +1,216 @@
+package expr
+
+import (
+	"strings"
+
+	"github.com/genjidb/genji/document"
+	"github.com/genjidb/genji/internal/environment"
+	"github.com/genjidb/genji/internal/stringutil"
+)
+
+// A WindowFunc is a function evaluated for each document of a partition,
+// based on the position of the document in the partition, i.e. ROW_NUMBER().
+type WindowFunc interface {
+	Expr
+
+	// EvalWindow returns the value of the function for the i-th document of the partition.
+	EvalWindow(p *Partition, i int) (document.Value, error)
+}
+
+// A Partition holds the documents sharing the same PARTITION BY values,
+// sorted by the ORDER BY clause of a window.
+type Partition struct {
+	Envs []*environment.Environment
+	// Peers holds the index of the peer group of each document.
+	// Documents with the same ORDER BY values are peers and belong
+	// to the same group. If the window has no ORDER BY clause, all
+	// the documents are peers.
+	Peers []int
+}
+
+// Len returns the number of documents of the partition.
+func (p *Partition) Len() int {
+	return len(p.Envs)
+}
+
+// PeersStart returns the position of the first peer of the i-th document.
+func (p *Partition) PeersStart(i int) int {
+	for i > 0 && p.Peers[i-1] == p.Peers[i] {
+		i--
+	}
+
+	return i
+}
+
+// PeersEnd returns the position following the last peer of the i-th document.
+func (p *Partition) PeersEnd(i int) int {
+	for i+1 < len(p.Peers) && p.Peers[i+1] == p.Peers[i] {
+		i++
+	}
+
+	return i + 1
+}
+
+// A WindowSpec defines the partitions and the order of the documents
+// a window function is evaluated on.
+type WindowSpec struct {
+	PartitionBy []Expr
+	OrderBy     []OrderTerm
+}
+
+// IsEqual returns true if both windows have the same definition.
+func (w *WindowSpec) IsEqual(other *WindowSpec) bool {
+	if len(w.PartitionBy) != len(other.PartitionBy) || len(w.OrderBy) != len(other.OrderBy) {
+		return false
+	}
+
+	for i := range w.PartitionBy {
+		if !Equal(w.PartitionBy[i], other.PartitionBy[i]) {
+			return false
+		}
+	}
+
+	for i := range w.OrderBy {
+		if w.OrderBy[i].Desc != other.OrderBy[i].Desc || !Equal(w.OrderBy[i].E, other.OrderBy[i].E) {
+			return false
+		}
+	}
+
+	return true
+}
+
+func (w *WindowSpec) String() string {
+	var sb strings.Builder
+
+	if len(w.PartitionBy) > 0 {
+		sb.WriteString("PARTITION BY ")
+		for i, e := range w.PartitionBy {
+			if i > 0 {
+				sb.WriteString(", ")
+			}
+			sb.WriteString(e.String())
+		}
+	}
+
+	if len(w.OrderBy) > 0 {
+		if sb.Len() > 0 {
+			sb.WriteString(" ")
+		}
+		sb.WriteString("ORDER BY ")
+		for i, t := range w.OrderBy {
+			if i > 0 {
+				sb.WriteString(", ")
+			}
+			sb.WriteString(t.String())
+		}
+	}
+
+	return sb.String()
+}
+
+// A WindowExpr is a window function or an aggregate function
+// evaluated over a window: FUNC(...) OVER (PARTITION BY ... ORDER BY ...).
+// Its value is computed by the window operator of the stream,
+// which stores it in a variable named after the expression.
+type WindowExpr struct {
+	// Func is either a WindowFunc or an AggregatorBuilder.
+	Func   Expr
+	Window WindowSpec
+}
+
+// Eval returns the value computed for the current document by the window operator.
+func (w *WindowExpr) Eval(env *environment.Environment) (document.Value, error) {
+	v, ok := env.Get(document.Path{document.PathFragment{FieldName: w.String()}})
+	if !ok {
+		return NullLiteral, stringutil.Errorf("misuse of window function %s", w.Func)
+	}
+
+	return v, nil
+}
+
+// EvalPartition returns the value of the function for each document of the partition.
+// Aggregate functions are evaluated over the documents of the partition
+// from the first one to the last peer of the current document,
+// or over the whole partition if the window has no ORDER BY clause.
+func (w *WindowExpr) EvalPartition(p *Partition) ([]document.Value, error) {
+	values := make([]document.Value, p.Len())
+
+	switch t := w.Func.(type) {
+	case WindowFunc:
+		for i := range values {
+			v, err := t.EvalWindow(p, i)
+			if err != nil {
+				return nil, err
+			}
+			values[i] = v
+		}
+	case AggregatorBuilder:
+		agg := t.Aggregator()
+
+		for i := 0; i < p.Len(); {
+			end := p.PeersEnd(i)
+			for j := i; j < end; j++ {
+				err := agg.Aggregate(p.Envs[j])
+				if err != nil {
+					return nil, err
+				}
+			}
+
+			v, err := agg.Eval(p.Envs[i])
+			if err != nil {
+				return nil, err
+			}
+			// the aggregator may keep modifying the returned value, i.e. ARRAY_AGG
+			v, err = copyValue(v)
+			if err != nil {
+				return nil, err
+			}
+			for j := i; j < end; j++ {
+				values[j] = v
+			}
+
+			i = end
+		}
+	default:
+		return nil, stringutil.Errorf("%s is not a window function", w.Func)
+	}
+
+	return values, nil
+}
+
+// IsEqual compares this expression with the other expression and returns
+// true if they are equal.
+func (w *WindowExpr) IsEqual(other Expr) bool {
+	o, ok := other.(*WindowExpr)
+	if !ok {
+		return false
+	}
+
+	return Equal(w.Func, o.Func) && w.Window.IsEqual(&o.Window)
+}
+
+func (w *WindowExpr) String() string {
+	return stringutil.Sprintf("%s OVER (%s)", w.Func, w.Window.String())
+}
+
+// copyValue deep copies arrays and documents.
+func copyValue(v document.Value) (document.Value, error) {
+	switch v.Type {
+	case document.ArrayValue:
+		var vb document.ValueBuffer
+		err := vb.Copy(v.V.(document.Array))
+		if err != nil {
+			return v, err
+		}
+		return document.NewArrayValue(&vb), nil
+	case document.DocumentValue:
+		var fb document.FieldBuffer
+		err := fb.Copy(v.V.(document.Document))
+		if err != nil {
+			return v, err
+		}
+		return document.NewDocumentValue(&fb), nil
+	}
+
+	return v, nil
+}
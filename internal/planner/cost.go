@@ -0,0 +1,112 @@
+package planner
+
+import (
+	errs "github.com/genjidb/genji/errors"
+	"github.com/genjidb/genji/internal/database"
+	"github.com/genjidb/genji/internal/stream"
+)
+
+// indexLookupCost is the cost of reading a document using an index entry,
+// relatively to reading it while scanning the table.
+const indexLookupCost = 2
+
+// statisticsCatalog is the catalog passed to the optimizer rules by Optimize.
+// It gives access to the transaction preparing the query, to read the statistics of the tables.
+type statisticsCatalog struct {
+	database.Catalog
+	tx *database.Transaction
+}
+
+// planTx returns the transaction preparing the query, or nil if the catalog
+// wasn't passed by Optimize.
+func planTx(catalog database.Catalog) *database.Transaction {
+	if sc, ok := catalog.(*statisticsCatalog); ok {
+		return sc.tx
+	}
+
+	return nil
+}
+
+// getTableStatistics returns the statistics of the table, or nil if the table was never analyzed
+// or if the statistics can't be read.
+func getTableStatistics(catalog database.Catalog, tableName string) (*database.TableStatistics, error) {
+	sc, ok := catalog.(*statisticsCatalog)
+	if !ok || sc.tx == nil {
+		return nil, nil
+	}
+
+	s, err := database.GetTableStatistics(sc.tx, sc.Catalog, tableName)
+	if err != nil {
+		if errs.IsNotFoundError(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return s, nil
+}
+
+// selectCandidateUsingStatistics estimates the cost of each candidate using the statistics
+// of the table, and returns the cheapest one, or nil if scanning the whole table is cheaper.
+// If two candidates have the same cost, the one replacing the most filter nodes is selected.
+// It returns false if one of the indexes used by the candidates wasn't analyzed.
+func selectCandidateUsingStatistics(catalog database.Catalog, tableName string, stats *database.TableStatistics, candidates []*candidate) (*candidate, bool, error) {
+	var selected *candidate
+	// reading every document of the table
+	minCost := float64(stats.RowCount)
+
+	for _, cd := range candidates {
+		cost, ok, err := scanCost(catalog, tableName, stats, cd.newOp)
+		if err != nil || !ok {
+			return nil, false, err
+		}
+
+		if cost < minCost || (selected != nil && cost == minCost && len(cd.filterOps) > len(selected.filterOps)) {
+			selected = cd
+			minCost = cost
+		}
+	}
+
+	return selected, true, nil
+}
+
+// scanCost estimates the cost of reading the documents returned by the scan.
+// It returns false if one of the indexes used by the scan wasn't analyzed.
+func scanCost(catalog database.Catalog, tableName string, stats *database.TableStatistics, op stream.Operator) (float64, bool, error) {
+	sc := catalog.(*statisticsCatalog)
+
+	switch op := op.(type) {
+	case *stream.PkScanOperator:
+		return op.Ranges.EstimateRows(stats.RowCount), true, nil
+	case *stream.IndexScanOperator:
+		is, ok := stats.Indexes[op.IndexName]
+		if !ok {
+			return 0, false, nil
+		}
+
+		tb, err := sc.Catalog.GetTable(sc.tx, tableName)
+		if err != nil {
+			return 0, false, err
+		}
+
+		idx, err := sc.Catalog.GetIndex(sc.tx, op.IndexName)
+		if err != nil {
+			return 0, false, err
+		}
+
+		return op.Ranges.EstimateRows(idx, tb, is) * indexLookupCost, true, nil
+	case *stream.UnionScanOperator:
+		var total float64
+		for _, s := range op.Streams {
+			cost, ok, err := scanCost(catalog, tableName, stats, s.First())
+			if err != nil || !ok {
+				return 0, false, err
+			}
+			total += cost
+		}
+
+		return total, true, nil
+	}
+
+	return 0, false, nil
+}
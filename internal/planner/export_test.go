@@ -0,0 +1,9 @@
+package planner
+
+import "github.com/genjidb/genji/internal/database"
+
+// TxCatalog returns the catalog passed to the rules by Optimize
+// when preparing a query within the given transaction.
+func TxCatalog(catalog database.Catalog, tx *database.Transaction) database.Catalog {
+	return &statisticsCatalog{Catalog: catalog, tx: tx}
+}
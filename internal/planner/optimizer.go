@@ -2,10 +2,12 @@ package planner
 
 import (
 	"github.com/genjidb/genji/document"
+	errs "github.com/genjidb/genji/errors"
 	"github.com/genjidb/genji/internal/database"
 	"github.com/genjidb/genji/internal/environment"
 	"github.com/genjidb/genji/internal/expr"
 	"github.com/genjidb/genji/internal/expr/functions"
+	"github.com/genjidb/genji/internal/expr/glob"
 	"github.com/genjidb/genji/internal/sql/scanner"
 	"github.com/genjidb/genji/internal/stream"
 	"github.com/genjidb/genji/internal/stringutil"
@@ -13,36 +15,122 @@ import (
 
 var optimizerRules = []func(s *stream.Stream, catalog database.Catalog) (*stream.Stream, error){
 	SplitANDConditionRule,
+	UseVirtualTableRule,
 	RemoveUnnecessaryProjection,
 	RemoveUnnecessaryDistinctNodeRule,
 	RemoveUnnecessaryFilterNodesRule,
+	AddLikePrefixRangeRule,
 	UseIndexBasedOnFilterNodeRule,
+	UseIndexUnionRule,
 	PrecalculateExprRule,
+	UseIndexForNearestRule,
+	UseIndexForOrderByRule,
+	UseStreamAggregateRule,
+	PushDownFilterRule,
+	PushDownLimitRule,
+	UseCoveringIndexRule,
+	UsePartialDecodingRule,
+	UseBatchExecutionRule,
+	UseParallelScanRule,
+}
+
+// unionOptimizerRules are applied to the streams reading the documents
+// matching each operand of an OR operator, to determine if they can use an index.
+var unionOptimizerRules = []func(s *stream.Stream, catalog database.Catalog) (*stream.Stream, error){
+	SplitANDConditionRule,
+	RemoveUnnecessaryFilterNodesRule,
+	AddLikePrefixRangeRule,
+	UseIndexBasedOnFilterNodeRule,
+	PrecalculateExprRule,
+}
+
+// joinOptimizerRules are applied to streams joining multiple tables.
+// The paths of joined documents are prefixed by the name of a table, so rules matching
+// paths against the fields of the table being scanned don't apply.
+var joinOptimizerRules = []func(s *stream.Stream, catalog database.Catalog) (*stream.Stream, error){
+	SplitANDConditionRule,
+	RemoveUnnecessaryProjection,
+	RemoveUnnecessaryFilterNodesRule,
+	PrecalculateExprRule,
+	UseIndexBasedOnJoinRule,
 }
 
 // Optimize takes a tree, applies a list of optimization rules
 // and returns an optimized tree.
 // Depending on the rule, the tree may be modified in place or
 // replaced by a new one.
-func Optimize(s *stream.Stream, catalog database.Catalog) (*stream.Stream, error) {
+// If tx is not nil, the statistics of the tables are read from it
+// to estimate the cost of using indexes.
+func Optimize(s *stream.Stream, catalog database.Catalog, tx *database.Transaction) (*stream.Stream, error) {
+	return optimize(s, &statisticsCatalog{Catalog: catalog, tx: tx})
+}
+
+func optimize(s *stream.Stream, catalog database.Catalog) (*stream.Stream, error) {
 	var err error
 
-	if firstNode, ok := s.First().(*stream.ConcatOperator); ok {
-		// If the first operation is a concat, optimize both streams individually.
-		s1, err := Optimize(firstNode.S1, catalog)
+	// If the first operation combines two streams, optimize both streams individually.
+	switch firstNode := s.First().(type) {
+	case *stream.ConcatOperator:
+		firstNode.S1, firstNode.S2, err = optimizeStreams(firstNode.S1, firstNode.S2, catalog)
 		if err != nil {
 			return nil, err
 		}
-		s2, err := Optimize(firstNode.S2, catalog)
+		return s, nil
+	case *stream.IntersectOperator:
+		firstNode.S1, firstNode.S2, err = optimizeStreams(firstNode.S1, firstNode.S2, catalog)
 		if err != nil {
 			return nil, err
 		}
-
-		firstNode.S1, firstNode.S2 = s1, s2
 		return s, nil
+	case *stream.ExceptOperator:
+		firstNode.S1, firstNode.S2, err = optimizeStreams(firstNode.S1, firstNode.S2, catalog)
+		if err != nil {
+			return nil, err
+		}
+		return s, nil
+	}
+
+	if st, ok := s.First().(*stream.SeqScanOperator); ok {
+		err = checkIndexHint(st, catalog)
+		if err != nil {
+			return nil, err
+		}
+
+		err = checkHiddenDocuments(s, st, catalog)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	err = checkGeneratedFields(s, catalog)
+	if err != nil {
+		return nil, err
+	}
+
+	s, err = expandView(s, catalog)
+	if err != nil {
+		return nil, err
+	}
+
+	// streams read by subqueries and joins are optimized individually.
+	for n := s.Op; n != nil; n = n.GetPrev() {
+		switch t := n.(type) {
+		case *stream.SubqueryOperator:
+			t.S, err = optimize(t.S, catalog)
+		case *stream.JoinOperator:
+			t.Right, err = optimize(t.Right, catalog)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	rules := optimizerRules
+	if hasJoin(s) {
+		rules = joinOptimizerRules
 	}
 
-	for _, rule := range optimizerRules {
+	for _, rule := range rules {
 		s, err = rule(s, catalog)
 		if err != nil {
 			return nil, err
@@ -55,6 +143,20 @@ func Optimize(s *stream.Stream, catalog database.Catalog) (*stream.Stream, error
 	return s, nil
 }
 
+// optimizeStreams optimizes two streams individually.
+func optimizeStreams(s1, s2 *stream.Stream, catalog database.Catalog) (*stream.Stream, *stream.Stream, error) {
+	s1, err := optimize(s1, catalog)
+	if err != nil {
+		return nil, nil, err
+	}
+	s2, err = optimize(s2, catalog)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return s1, s2, nil
+}
+
 // SplitANDConditionRule splits any filter node whose condition
 // is one or more AND operators into one or more filter nodes.
 // The condition won't be split if the expression tree contains an OR
@@ -80,6 +182,7 @@ func SplitANDConditionRule(s *stream.Stream, _ database.Catalog) (*stream.Stream
 				if op, ok := cond.(expr.Operator); ok && op.Token() == scanner.AND {
 					exprs := splitANDExpr(cond)
 
+					last := n == s.Op
 					cur := n.GetPrev()
 					s.Remove(n)
 
@@ -87,7 +190,7 @@ func SplitANDConditionRule(s *stream.Stream, _ database.Catalog) (*stream.Stream
 						cur = stream.InsertAfter(cur, stream.Filter(e))
 					}
 
-					if s.Op == nil {
+					if s.Op == nil || last {
 						s.Op = cur
 					}
 				}
@@ -100,6 +203,142 @@ func SplitANDConditionRule(s *stream.Stream, _ database.Catalog) (*stream.Stream
 	return s, nil
 }
 
+// UseVirtualTableRule replaces the seq scan of a virtual table by a virtual scan.
+// If the table can look up documents by the value of a field,
+// the first filter node comparing that field to a constant or a parameter
+// using the = operator is removed and the lookup is delegated to the table.
+// Example:
+//   this:
+//     seqScan(foo) | filter(a = 10)
+//   becomes this:
+//     virtualLookup(foo, a, 10)
+func UseVirtualTableRule(s *stream.Stream, catalog database.Catalog) (*stream.Stream, error) {
+	st, ok := s.First().(*stream.SeqScanOperator)
+	if !ok {
+		return s, nil
+	}
+
+	t := catalog.GetVirtualTable(st.TableName)
+	if t == nil {
+		return s, nil
+	}
+
+	newOp := stream.VirtualScan(st.TableName)
+	next := st.GetNext()
+	st.SetNext(nil)
+	newOp.SetNext(next)
+	if next != nil {
+		next.SetPrev(newOp)
+	} else {
+		s.Op = newOp
+	}
+
+	idx, ok := t.(database.VirtualTableIndexer)
+	if !ok {
+		return s, nil
+	}
+
+	for n := s.Op; n != nil; n = n.GetPrev() {
+		f, ok := n.(*stream.FilterOperator)
+		if !ok || f.E == nil {
+			continue
+		}
+
+		op, ok := f.E.(expr.Operator)
+		if !ok || op.Token() != scanner.EQ {
+			continue
+		}
+
+		ok, path, e := operatorCanUseIndex(op)
+		if !ok || !idx.Indexed(path) {
+			continue
+		}
+
+		if _, ok := e.(expr.LiteralValue); !ok && !isParam(e) {
+			continue
+		}
+
+		newOp.Path, newOp.Value = path, e
+		s.Remove(n)
+		break
+	}
+
+	return s, nil
+}
+
+// A ViewQuery is the query of a view, able to create a new stream
+// every time the view is read, since optimizing a stream modifies it.
+type ViewQuery interface {
+	database.ViewQuery
+
+	Stream() (*stream.Stream, error)
+}
+
+// expandView replaces the seq scan of a view by a subquery reading the stream of the query of the view.
+// The stream of the query is then optimized like any other subquery.
+// Example, given a view v defined as SELECT a FROM foo WHERE b > 1:
+//   this:
+//     seqScan(v) | filter(a = 10)
+//   becomes this:
+//     subquery(seqScan(foo) | filter(b > 1) | project(a)) | filter(a = 10)
+func expandView(s *stream.Stream, catalog database.Catalog) (*stream.Stream, error) {
+	// views are read-only
+	for n := s.Op; n != nil; n = n.GetPrev() {
+		var name string
+		switch t := n.(type) {
+		case *stream.TableInsertOperator:
+			name = t.Name
+		case *stream.TableUpsertOperator:
+			name = t.Name
+		case *stream.TableReplaceOperator:
+			name = t.Name
+		case *stream.TableDeleteOperator:
+			name = t.Name
+		default:
+			continue
+		}
+
+		if _, err := catalog.GetViewInfo(planTx(catalog), name); err == nil {
+			return nil, stringutil.Errorf("cannot write to view %q", name)
+		}
+	}
+
+	st, ok := s.First().(*stream.SeqScanOperator)
+	if !ok {
+		return s, nil
+	}
+
+	info, err := catalog.GetViewInfo(planTx(catalog), st.TableName)
+	if errs.IsNotFoundError(err) {
+		return s, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	q, ok := info.Query.(ViewQuery)
+	if !ok {
+		return nil, stringutil.Errorf("cannot read view %q", info.ViewName)
+	}
+
+	vs, err := q.Stream()
+	if err != nil {
+		return nil, err
+	}
+
+	newOp := stream.Subquery(vs)
+	next := st.GetNext()
+	st.SetNext(nil)
+	newOp.SetNext(next)
+	if next != nil {
+		next.SetPrev(newOp)
+	} else {
+		s.Op = newOp
+	}
+
+	return s, nil
+}
+
 // splitANDExpr takes an expression and splits it by AND operator.
 func splitANDExpr(cond expr.Expr) (exprs []expr.Expr) {
 	op, ok := cond.(expr.Operator)
@@ -138,6 +377,22 @@ func PrecalculateExprRule(s *stream.Stream, _ database.Catalog) (*stream.Stream,
 					return nil, err
 				}
 			}
+		case *stream.JoinOperator:
+			if t.On != nil {
+				t.On, err = precalculateExpr(t.On)
+				if err != nil {
+					return nil, err
+				}
+			}
+		case *stream.ExprsOperator:
+			// documents of VALUES clauses made of constants are
+			// built once instead of every time the query runs
+			for i, e := range t.Exprs {
+				t.Exprs[i], err = precalculateExpr(e)
+				if err != nil {
+					return nil, err
+				}
+			}
 		}
 
 		n = n.GetPrev()
@@ -217,10 +472,10 @@ func precalculateExpr(e expr.Expr) (expr.Expr, error) {
 		_, rightIsLit := rh.(expr.LiteralValue)
 		// if both operands are literals, we can precalculate them now
 		if leftIsLit && rightIsLit {
+			// errors, like an invalid ESCAPE character, would be returned by every evaluation
 			v, err := t.Eval(&environment.Environment{})
-			// any error encountered here is unexpected
 			if err != nil {
-				panic(err)
+				return nil, err
 			}
 			// we replace this expression with the result of its evaluation
 			return expr.LiteralValue(v), nil
@@ -273,6 +528,9 @@ func RemoveUnnecessaryFilterNodesRule(s *stream.Stream, _ database.Catalog) (*st
 							return &stream.Stream{}, nil
 						}
 					}
+					if el, ok := t.RightHand().(expr.LiteralExprList); ok && len(el) == 0 {
+						return &stream.Stream{}, nil
+					}
 				}
 			}
 		}
@@ -299,105 +557,1468 @@ func RemoveUnnecessaryProjection(s *stream.Stream, _ database.Catalog) (*stream.
 			}
 		}
 
-		n = n.GetPrev()
+		n = n.GetPrev()
+	}
+
+	return s, nil
+}
+
+// RemoveUnnecessaryDistinctNodeRule removes any Dedup nodes
+// where projection is already unique.
+func RemoveUnnecessaryDistinctNodeRule(s *stream.Stream, catalog database.Catalog) (*stream.Stream, error) {
+	n := s.Op
+
+	// we assume that if we are reading from a table, the first
+	// operator of the stream has to be a SeqScanOperator
+	firstNode := s.First()
+	if firstNode == nil {
+		return s, nil
+	}
+	st, ok := firstNode.(*stream.SeqScanOperator)
+	if !ok {
+		return s, nil
+	}
+
+	info, err := catalog.GetTableInfo(planTx(catalog), st.TableName)
+	if err != nil {
+		return nil, err
+	}
+
+	// this optimization applies to project operators that immediately follow distinct
+	for n != nil {
+		if d, ok := n.(*stream.DistinctOperator); ok {
+			prev := d.GetPrev()
+			if prev != nil {
+				pn, ok := prev.(*stream.ProjectOperator)
+				if ok {
+					var indexes []*database.IndexInfo
+					for _, name := range catalog.ListIndexes(planTx(catalog), st.TableName) {
+						idx, err := catalog.GetIndexInfo(planTx(catalog), name)
+						if err != nil {
+							return nil, err
+						}
+						// indexes being built don't enforce uniqueness on existing documents yet
+						if idx.Building {
+							continue
+						}
+						indexes = append(indexes, idx)
+					}
+
+					// if the projection is unique, we remove the node from the tree
+					if isProjectionUnique(indexes, pn, info.FieldConstraints.GetPrimaryKey()) {
+						s.Remove(n)
+						n = prev
+						continue
+					}
+				}
+			}
+		}
+
+		n = n.GetPrev()
+	}
+
+	return s, nil
+}
+
+func isProjectionUnique(indexes []*database.IndexInfo, po *stream.ProjectOperator, pk *database.FieldConstraint) bool {
+	for _, field := range po.Exprs {
+		_, ok := field.(*expr.NamedExpr)
+		if ok {
+			return false
+		}
+
+		switch v := field.(type) {
+		case expr.Path:
+			if pk != nil && pk.Path.IsEqual(document.Path(v)) {
+				continue
+			}
+
+			p := document.Path(v)
+			var found *database.IndexInfo
+
+			for _, info := range indexes {
+				// partial and multi-key indexes are only unique among the documents they contain
+				if info.Predicate == nil && info.MultiKeyPosition() < 0 && info.Paths[0].IsEqual(p) {
+					found = info
+					break
+				}
+			}
+
+			if found != nil && found.Unique {
+				continue
+			}
+		case *functions.PK:
+			continue
+		}
+
+		return false // if one field is not unique, so projection is not unique too.
+	}
+
+	return true
+}
+
+// AddLikePrefixRangeRule looks for filter nodes whose condition is a LIKE or ILIKE
+// operator matching an indexed path against a literal pattern.
+// If the pattern starts with a literal prefix, it inserts filter nodes restricting the path
+// to the range of texts starting with that prefix, which can then be used by
+// UseIndexBasedOnFilterNodeRule. The original filter node is kept to match the rest of the pattern.
+// ILIKE prefixes stop before the first character that has other case variants.
+// Example, given an index on a:
+//   this:
+//     filter(a LIKE 'abc%')
+//   becomes this:
+//     filter(a < 'abd')
+//     filter(a >= 'abc')
+//     filter(a LIKE 'abc%')
+func AddLikePrefixRangeRule(s *stream.Stream, catalog database.Catalog) (*stream.Stream, error) {
+	firstNode := s.First()
+	if firstNode == nil {
+		return s, nil
+	}
+	st, ok := firstNode.(*stream.SeqScanOperator)
+	if !ok {
+		return s, nil
+	}
+	info, err := catalog.GetTableInfo(planTx(catalog), st.TableName)
+	if err != nil {
+		return nil, err
+	}
+
+	isIndexed := func(path document.Path) (bool, error) {
+		if pk := info.FieldConstraints.GetPrimaryKey(); pk != nil && pk.Path.IsEqual(path) {
+			return true, nil
+		}
+
+		for _, name := range scanIndexes(st, catalog) {
+			idx, err := catalog.GetIndexInfo(planTx(catalog), name)
+			if err != nil {
+				return false, err
+			}
+			if !idx.Building && idx.Paths[0].IsEqual(path) {
+				return true, nil
+			}
+		}
+
+		return false, nil
+	}
+
+	for n := s.Op; n != nil; n = n.GetPrev() {
+		f, ok := n.(*stream.FilterOperator)
+		if !ok {
+			continue
+		}
+
+		op, ok := f.E.(*expr.LikeOperator)
+		if !ok {
+			continue
+		}
+
+		path, ok := op.LeftHand().(expr.Path)
+		if !ok {
+			continue
+		}
+		pattern, ok := op.RightHand().(expr.LiteralValue)
+		if !ok || pattern.Type != document.TextValue {
+			continue
+		}
+		if op.Escape != nil {
+			if _, ok := op.Escape.(expr.LiteralValue); !ok {
+				continue
+			}
+		}
+		// invalid escape characters are reported when evaluating the filter
+		esc, err := op.EscapeRune(&environment.Environment{})
+		if err != nil {
+			continue
+		}
+
+		prefix := glob.LiteralPrefix(pattern.V.(string), esc, op.IgnoreCase())
+		if prefix == "" {
+			continue
+		}
+
+		ok, err = isIndexed(document.Path(path))
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			continue
+		}
+
+		if upper, ok := prefixUpperBound(prefix); ok {
+			stream.InsertBefore(f, stream.Filter(expr.Lt(path, expr.LiteralValue(document.NewTextValue(upper)))))
+		}
+		stream.InsertBefore(f, stream.Filter(expr.Gte(path, expr.LiteralValue(document.NewTextValue(prefix)))))
+	}
+
+	return s, nil
+}
+
+// UseIndexForNearestRule looks for streams sorting all the documents of a table
+// by their distance to a constant vector, and only keeping the first ones.
+// If the vectors are stored in an indexed VECTOR(n) NOT NULL field, it replaces the
+// sequential scan and the sort by a search of the nearest vectors of the index.
+// Example, given an index on v:
+//   this:
+//     seqScan(foo) | project(*) | sort(cosine_distance(v, ?)) | take(3)
+//   becomes this:
+//     indexNearest("idx_foo_v", cosine_distance, ?, 3) | project(*) | take(3)
+func UseIndexForNearestRule(s *stream.Stream, catalog database.Catalog) (*stream.Stream, error) {
+	st, ok := s.First().(*stream.SeqScanOperator)
+	if !ok || st.Reverse {
+		return s, nil
+	}
+
+	var po *stream.ProjectOperator
+	var so *stream.SortOperator
+	var skip *stream.SkipOperator
+	var take *stream.TakeOperator
+	for n := st.GetNext(); n != nil; n = n.GetNext() {
+		switch t := n.(type) {
+		case *stream.ProjectOperator:
+			if po != nil || so != nil {
+				return s, nil
+			}
+			po = t
+		case *stream.SortOperator:
+			if so != nil {
+				return s, nil
+			}
+			so = t
+		case *stream.SkipOperator:
+			if so == nil || skip != nil || take != nil {
+				return s, nil
+			}
+			skip = t
+		case *stream.TakeOperator:
+			if so == nil || take != nil {
+				return s, nil
+			}
+			take = t
+		default:
+			// any other operator, like a filter, changes the documents to sort
+			return s, nil
+		}
+	}
+	if so == nil || take == nil || len(so.Terms) != 1 || so.Terms[0].Desc {
+		return s, nil
+	}
+
+	f, ok := so.Terms[0].E.(*functions.ScalarFunction)
+	if !ok || (f.Name() != "cosine_distance" && f.Name() != "euclidean_distance") {
+		return s, nil
+	}
+
+	// one of the arguments is the indexed path, the other one the target
+	params := f.Params()
+	path, ok := params[0].(expr.Path)
+	target := params[1]
+	if !ok {
+		path, ok = params[1].(expr.Path)
+		target = params[0]
+	}
+	if !ok {
+		return s, nil
+	}
+	if _, ok := target.(expr.LiteralValue); !ok && !isParam(target) {
+		return s, nil
+	}
+
+	// the path must not refer to a projected field
+	if po != nil {
+		for _, e := range po.Exprs {
+			if ne, ok := e.(*expr.NamedExpr); ok && ne.ExprName == path[0].FieldName && !expr.Equal(ne.Expr, path) {
+				return s, nil
+			}
+		}
+	}
+
+	info, err := catalog.GetTableInfo(planTx(catalog), st.TableName)
+	if err != nil {
+		return nil, err
+	}
+
+	// documents without vectors, or with vectors of another dimension,
+	// are not returned by the index
+	fc := info.FieldConstraints.Get(document.Path(path))
+	if fc == nil || fc.Type != document.VectorValue || !fc.IsNotNull || fc.Dimension == 0 {
+		return s, nil
+	}
+
+	var indexName string
+	for _, name := range scanIndexes(st, catalog) {
+		idx, err := catalog.GetIndexInfo(planTx(catalog), name)
+		if err != nil {
+			return nil, err
+		}
+		if len(idx.Paths) == 1 && idx.MultiKeyPosition() < 0 && idx.Order(0) == (database.IndexOrder{}) &&
+			idx.Paths[0].IsEqual(document.Path(path)) && isIndexUsable(idx, scanFilters(st)) {
+			indexName = name
+			break
+		}
+	}
+	if indexName == "" {
+		return s, nil
+	}
+
+	k := take.N
+	if skip != nil {
+		k += skip.N
+	}
+
+	stream.InsertBefore(st, stream.IndexNearest(indexName, f.Name(), target, k))
+	s.Remove(st)
+	s.Remove(so)
+
+	return s, nil
+}
+
+// UseIndexForOrderByRule looks for streams sorting the documents of a table by one or more paths.
+// If the documents are already read in that order or in the reverse order, because the paths are
+// the primary key or a prefix of the paths of an index stored in the directions of the sort or in the
+// opposite directions, the sort is removed and the table or the index is traversed forward or backward instead.
+// Paths of a composite index matched exactly by the range of the scan can be skipped.
+// Example, given an index on a:
+//   this:
+//     seqScan(foo) | project(*) | sortReverse(a) | take(3)
+//   becomes this:
+//     indexScanReverse("idx_foo_a") | project(*) | take(3)
+func UseIndexForOrderByRule(s *stream.Stream, catalog database.Catalog) (*stream.Stream, error) {
+	first := s.First()
+	if first == nil {
+		return s, nil
+	}
+
+	var po *stream.ProjectOperator
+	var so *stream.SortOperator
+	for n := first.GetNext(); n != nil && so == nil; n = n.GetNext() {
+		switch t := n.(type) {
+		case *stream.FilterOperator, *stream.DistinctOperator:
+			// documents are filtered without changing their order
+		case *stream.ProjectOperator:
+			if po != nil {
+				return s, nil
+			}
+			po = t
+		case *stream.SortOperator:
+			so = t
+		default:
+			return s, nil
+		}
+	}
+	if so == nil {
+		return s, nil
+	}
+
+	for _, t := range so.Terms {
+		p, ok := t.E.(expr.Path)
+		if !ok {
+			return s, nil
+		}
+
+		// the path must not refer to a projected field
+		if po != nil {
+			for _, e := range po.Exprs {
+				if ne, ok := e.(*expr.NamedExpr); ok && ne.ExprName == p[0].FieldName && !expr.Equal(ne.Expr, p) {
+					return s, nil
+				}
+			}
+		}
+	}
+
+	ok, reverse, err := scanOrderedBy(first, so.Terms, catalog)
+	if err != nil {
+		return nil, err
+	}
+	if ok {
+		setScanReverse(first, reverse)
+		s.Remove(so)
+		return s, nil
+	}
+
+	st, ok := first.(*stream.SeqScanOperator)
+	if !ok || st.Reverse {
+		return s, nil
+	}
+
+	name, reverse, err := indexOnPaths(st, so.Terms, catalog)
+	if err != nil || name == "" {
+		return s, err
+	}
+
+	is := stream.IndexScan(name)
+	is.Reverse = reverse
+	stream.InsertBefore(st, is)
+	s.Remove(st)
+	s.Remove(so)
+
+	return s, nil
+}
+
+// UseStreamAggregateRule looks for streams grouping the documents of a table by a path
+// whose documents are read in the order of that path, because it is the primary key
+// or a path of the index being scanned. The hash aggregation is replaced by a stream
+// aggregation, which only keeps one group in memory at a time.
+// If the groups are then sorted by that path, the table is read in the order of the sort,
+// using an index on the path if necessary, and the sort is removed.
+// Example, given an index on a:
+//   this:
+//     seqScan(foo) | groupBy(a) | hashAggregate(COUNT(*)) | project(a, COUNT(*)) | sortReverse(a)
+//   becomes this:
+//     indexScanReverse("idx_foo_a") | groupBy(a) | streamAggregate(COUNT(*)) | project(a, COUNT(*))
+func UseStreamAggregateRule(s *stream.Stream, catalog database.Catalog) (*stream.Stream, error) {
+	first := s.First()
+	if first == nil {
+		return s, nil
+	}
+
+	var gb *stream.GroupByOperator
+	var ha *stream.HashAggregateOperator
+	var po *stream.ProjectOperator
+	var so *stream.SortOperator
+loop:
+	for n := first.GetNext(); n != nil; n = n.GetNext() {
+		switch t := n.(type) {
+		case *stream.FilterOperator:
+		case *stream.GroupByOperator:
+			if gb != nil {
+				return s, nil
+			}
+			gb = t
+		case *stream.HashAggregateOperator:
+			if gb == nil || ha != nil {
+				return s, nil
+			}
+			ha = t
+		case *stream.ProjectOperator:
+			if ha == nil || po != nil {
+				return s, nil
+			}
+			po = t
+		case *stream.DistinctOperator:
+			if ha == nil {
+				return s, nil
+			}
+		case *stream.SortOperator:
+			so = t
+			break loop
+		default:
+			break loop
+		}
+	}
+	if ha == nil {
+		return s, nil
+	}
+
+	p, ok := gb.E.(expr.Path)
+	if !ok {
+		return s, nil
+	}
+
+	// the groups are sorted by the path, which is the name of the group field
+	// unless it is shadowed by a projected field
+	sorted := so != nil && len(so.Terms) == 1 && len(p) == 1 && expr.Equal(so.Terms[0].E, p)
+	if sorted && po != nil {
+		for _, e := range po.Exprs {
+			if ne, ok := e.(*expr.NamedExpr); ok && ne.ExprName == p[0].FieldName && !expr.Equal(ne.Expr, p) {
+				sorted = false
+			}
+		}
+	}
+
+	// the groups are read in the order of the sort, if any
+	terms := []expr.OrderTerm{{E: p}}
+	if sorted {
+		terms = so.Terms
+	}
+
+	ok, reverse, err := scanOrderedBy(first, terms, catalog)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		// reading the table using an index is only worth it
+		// if it also avoids sorting the groups
+		st, ok := first.(*stream.SeqScanOperator)
+		if !ok || !sorted {
+			return s, nil
+		}
+
+		var name string
+		name, reverse, err = indexOnPaths(st, terms, catalog)
+		if err != nil || name == "" {
+			return s, err
+		}
+
+		is := stream.IndexScan(name)
+		stream.InsertBefore(st, is)
+		s.Remove(st)
+		first = is
+	}
+
+	stream.InsertBefore(ha, stream.StreamAggregate(ha.Builders...))
+	s.Remove(ha)
+
+	if sorted {
+		setScanReverse(first, reverse)
+		s.Remove(so)
+	}
+
+	return s, nil
+}
+
+// PushDownLimitRule moves the skip and take nodes of the stream before the projections,
+// which don't change the number of documents, and merges them into the scan reading the
+// table if nothing else is between them, to stop reading the table as soon as possible.
+// Example:
+//   this:
+//     seqScan(foo) | project(a) | skip(20) | take(10)
+//   becomes this:
+//     seqScan(foo, offset 20, limit 10) | project(a)
+func PushDownLimitRule(s *stream.Stream, _ database.Catalog) (*stream.Stream, error) {
+	var skip *stream.SkipOperator
+	var take *stream.TakeOperator
+	for n := s.First(); n != nil && skip == nil && take == nil; n = n.GetNext() {
+		switch t := n.(type) {
+		case *stream.SkipOperator:
+			skip = t
+			take, _ = t.GetNext().(*stream.TakeOperator)
+		case *stream.TakeOperator:
+			take = t
+		}
+	}
+	if skip == nil && take == nil {
+		return s, nil
+	}
+
+	var ops []stream.Operator
+	if skip != nil {
+		ops = append(ops, skip)
+	}
+	if take != nil {
+		ops = append(ops, take)
+	}
+
+	// projections evaluating expressions with side effects, like NEXT VALUE FOR,
+	// must still be evaluated for the skipped documents
+	var po stream.Operator
+	for n := ops[0].GetPrev(); n != nil; n = n.GetPrev() {
+		p, ok := n.(*stream.ProjectOperator)
+		if !ok || hasSideEffects(p.Exprs) {
+			break
+		}
+		po = p
+	}
+
+	if po != nil {
+		for _, op := range ops {
+			s.Remove(op)
+			stream.InsertBefore(po, op)
+		}
+	}
+
+	prev := ops[0].GetPrev()
+	if prev == nil || prev.GetPrev() != nil {
+		return s, nil
+	}
+
+	var offset, limit *int64
+	switch t := prev.(type) {
+	case *stream.SeqScanOperator:
+		offset, limit = &t.Offset, &t.Limit
+	case *stream.PkScanOperator:
+		offset, limit = &t.Offset, &t.Limit
+	case *stream.IndexScanOperator:
+		offset, limit = &t.Offset, &t.Limit
+	default:
+		return s, nil
+	}
+
+	if skip != nil {
+		if skip.N > 0 {
+			*offset = skip.N
+		}
+		s.Remove(skip)
+	}
+
+	// a limit of 0 returns every document
+	if take != nil && take.N > 0 {
+		*limit = take.N
+		s.Remove(take)
+	}
+
+	return s, nil
+}
+
+// PushDownFilterRule moves the filters following the scan of a table into the scan if they only
+// read the primary key of the documents, or the indexed paths and the primary key in the case
+// of index scans. These filters are evaluated with the values decoded from the keys returned by
+// the iterator, which avoids reading and decoding the documents that don't match.
+// Example, with an index on a and b:
+//   this:
+//     indexScan("idx_a_b", [1, -1, true]) | filter(b = 2) | project(*)
+//   becomes this:
+//     indexScan("idx_a_b", [1, -1, true], filter(b = 2)) | project(*)
+func PushDownFilterRule(s *stream.Stream, catalog database.Catalog) (*stream.Stream, error) {
+	var tableName string
+	var idxInfo *database.IndexInfo
+	var filters *[]expr.Expr
+	switch t := s.First().(type) {
+	case *stream.SeqScanOperator:
+		tableName, filters = t.TableName, &t.Filters
+	case *stream.PkScanOperator:
+		tableName, filters = t.TableName, &t.Filters
+	case *stream.IndexScanOperator:
+		var err error
+		idxInfo, err = catalog.GetIndexInfo(planTx(catalog), t.IndexName)
+		if err != nil {
+			return nil, err
+		}
+		tableName, filters = idxInfo.TableName, &t.Filters
+	default:
+		return s, nil
+	}
+
+	tb, err := catalog.GetTableInfo(planTx(catalog), tableName)
+	if err != nil {
+		return nil, err
+	}
+
+	paths, ok := keyPaths(tb, idxInfo)
+	if !ok {
+		return s, nil
+	}
+
+	n := s.First().GetNext()
+	for n != nil {
+		f, ok := n.(*stream.FilterOperator)
+		if !ok {
+			break
+		}
+
+		n = n.GetNext()
+		if !hasSideEffects([]expr.Expr{f.E}) && isCoveredBy(f.E, paths) {
+			*filters = append(*filters, f.E)
+			s.Remove(f)
+		}
+	}
+
+	return s, nil
+}
+
+// hasSideEffects returns true if evaluating one of the expressions modifies the database.
+func hasSideEffects(exprs []expr.Expr) bool {
+	var found bool
+	for _, e := range exprs {
+		expr.Walk(e, func(e expr.Expr) bool {
+			if _, ok := e.(expr.NextValueFor); ok {
+				found = true
+			}
+			return !found
+		})
+	}
+
+	return found
+}
+
+// UseCoveringIndexRule marks index scans as covering if the stream only reads the indexed
+// paths and the primary key of the documents, up to the projection or the aggregation.
+// Covering scans build the documents from the index entries instead of reading the table.
+// Example, with an index on a:
+//   this:
+//     indexScan("idx_a", [10, -1, true]) | project(a, k)
+//   becomes this:
+//     indexScan("idx_a", [10, -1, true], covering) | project(a, k)
+func UseCoveringIndexRule(s *stream.Stream, catalog database.Catalog) (*stream.Stream, error) {
+	is, ok := s.First().(*stream.IndexScanOperator)
+	if !ok {
+		return s, nil
+	}
+
+	idxInfo, err := catalog.GetIndexInfo(planTx(catalog), is.IndexName)
+	if err != nil {
+		return nil, err
+	}
+
+	tb, err := catalog.GetTableInfo(planTx(catalog), idxInfo.TableName)
+	if err != nil {
+		return nil, err
+	}
+
+	paths, ok := keyPaths(tb, idxInfo)
+	if !ok {
+		return s, nil
+	}
+
+	for n := is.GetNext(); n != nil; n = n.GetNext() {
+		switch t := n.(type) {
+		case *stream.FilterOperator:
+			if !isCoveredBy(t.E, paths) {
+				return s, nil
+			}
+		case *stream.GroupByOperator:
+			if !isCoveredBy(t.E, paths) {
+				return s, nil
+			}
+		case *stream.SkipOperator, *stream.TakeOperator:
+		case *stream.ProjectOperator:
+			for _, e := range t.Exprs {
+				if !isCoveredBy(e, paths) {
+					return s, nil
+				}
+			}
+			is.Covering = true
+			return s, nil
+		case *stream.HashAggregateOperator:
+			if !aggregatorsCoveredBy(t.Builders, paths) {
+				return s, nil
+			}
+			is.Covering = true
+			return s, nil
+		case *stream.StreamAggregateOperator:
+			if !aggregatorsCoveredBy(t.Builders, paths) {
+				return s, nil
+			}
+			is.Covering = true
+			return s, nil
+		default:
+			return s, nil
+		}
+	}
+
+	// the documents are returned as is
+	return s, nil
+}
+
+// keyPaths returns the paths of the values that can be decoded from the keys read by a scan:
+// the primary key, and the indexed paths if idx is not nil.
+// It returns false if some of these values can't be decoded.
+func keyPaths(tb *database.TableInfo, idx *database.IndexInfo) ([]document.Path, bool) {
+	if !tb.IsKeyDecodable() {
+		return nil, false
+	}
+
+	var paths []document.Path
+	if idx != nil {
+		for i, p := range idx.Paths {
+			if !idx.IsDecodable(i) || !isFieldPath(p) {
+				return nil, false
+			}
+			paths = append(paths, p)
+		}
+	}
+	if pk := tb.FieldConstraints.GetPrimaryKey(); pk != nil {
+		if !isFieldPath(pk.Path) {
+			return nil, false
+		}
+		paths = append(paths, pk.Path)
+	}
+
+	return paths, true
+}
+
+// isFieldPath returns true if the path only refers to fields, not to array indexes.
+func isFieldPath(p document.Path) bool {
+	for _, f := range p {
+		if f.FieldName == "" {
+			return false
+		}
+	}
+
+	return true
+}
+
+// isCoveredBy returns true if the expression only refers to the given paths of the documents.
+func isCoveredBy(e expr.Expr, paths []document.Path) bool {
+	covered := true
+	expr.Walk(e, func(e expr.Expr) bool {
+		switch t := e.(type) {
+		case expr.Path:
+			covered = false
+			for _, p := range paths {
+				if p.IsEqual(document.Path(t)) {
+					covered = true
+					break
+				}
+			}
+		case *expr.BetweenOperator:
+			covered = isCoveredBy(t.X, paths)
+		case expr.Wildcard, *expr.KVPairs, expr.Subquery:
+			// these refer to the whole document or can't be inspected
+			covered = false
+		}
+		return covered
+	})
+
+	return covered
+}
+
+func aggregatorsCoveredBy(builders []expr.AggregatorBuilder, paths []document.Path) bool {
+	for _, b := range builders {
+		if !isCoveredBy(b, paths) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// UsePartialDecodingRule sets the fields read by the stream on the scan reading the table, if the stream
+// projects or aggregates the documents. The scan then decodes these fields in one pass and returns documents
+// only containing them, instead of each operator decoding the values it reads from the encoded documents.
+// Operators following the projection, like sort, can read the fields of the original documents
+// and are taken into account as well.
+// Only top-level fields are selected, nested documents and arrays being decoded lazily, so that
+// the documents returned by the scan keep the same fields as the original ones.
+// Example:
+//   this:
+//     seqScan(foo) | filter(a > 1) | project(b, c.d)
+//   becomes this:
+//     seqScan(foo, paths(a, b, c)) | filter(a > 1) | project(b, c.d)
+func UsePartialDecodingRule(s *stream.Stream, _ database.Catalog) (*stream.Stream, error) {
+	var paths *[]document.Path
+	switch t := s.First().(type) {
+	case *stream.SeqScanOperator:
+		paths = &t.Paths
+	case *stream.PkScanOperator:
+		paths = &t.Paths
+	case *stream.IndexScanOperator:
+		// covering scans don't read the documents
+		if t.Covering {
+			return s, nil
+		}
+		paths = &t.Paths
+	default:
+		return s, nil
+	}
+
+	var exprs []expr.Expr
+	var projected bool
+	for n := s.First().GetNext(); n != nil; n = n.GetNext() {
+		switch t := n.(type) {
+		case *stream.FilterOperator:
+			exprs = append(exprs, t.E)
+		case *stream.GroupByOperator:
+			exprs = append(exprs, t.E)
+		case *stream.ProjectOperator:
+			exprs = append(exprs, t.Exprs...)
+			projected = true
+		case *stream.HashAggregateOperator:
+			exprs = append(exprs, aggregatorExprs(t.Builders)...)
+			projected = true
+		case *stream.StreamAggregateOperator:
+			exprs = append(exprs, aggregatorExprs(t.Builders)...)
+			projected = true
+		case *stream.SortOperator:
+			for _, term := range t.Terms {
+				exprs = append(exprs, term.E)
+			}
+		case *stream.SkipOperator, *stream.TakeOperator, *stream.DistinctOperator:
+		default:
+			return s, nil
+		}
+	}
+
+	// otherwise, the documents are returned as is
+	if projected {
+		*paths = readFields(exprs)
+	}
+
+	return s, nil
+}
+
+// readFields returns the top-level fields read by the expressions, as paths.
+// It returns nil if one of the expressions reads the whole document.
+func readFields(exprs []expr.Expr) []document.Path {
+	var fields []document.Path
+	add := func(p document.Path) {
+		for _, f := range fields {
+			if f[0].FieldName == p[0].FieldName {
+				return
+			}
+		}
+		fields = append(fields, document.Path{p[0]})
+	}
+
+	whole := false
+	var walk func(e expr.Expr) bool
+	walk = func(e expr.Expr) bool {
+		switch t := e.(type) {
+		case expr.Path:
+			if len(t) > 0 && t[0].FieldName != "" {
+				add(document.Path(t))
+			}
+		case *expr.BetweenOperator:
+			expr.Walk(t.X, walk)
+		case expr.Wildcard, *expr.KVPairs, expr.Subquery:
+			// these refer to the whole document or can't be inspected
+			whole = true
+		}
+		return !whole
+	}
+
+	for _, e := range exprs {
+		expr.Walk(e, walk)
+	}
+	if whole {
+		return nil
+	}
+
+	return fields
+}
+
+func aggregatorExprs(builders []expr.AggregatorBuilder) []expr.Expr {
+	exprs := make([]expr.Expr, len(builders))
+	for i, b := range builders {
+		exprs[i] = b
+	}
+
+	return exprs
+}
+
+// UseBatchExecutionRule replaces the seq scan, filters and aggregation of queries computing aggregates
+// over a table by a batch operator, which executes them on batches of documents.
+// This is only possible if the filters compare top-level fields with constants and if the aggregation
+// computes COUNT, MIN, MAX, SUM or AVG of top-level fields, optionally grouped by a top-level field.
+// Example:
+//   this:
+//     seqScan(foo) | filter(a > 1) | groupBy(b) | hashAggregate(b, COUNT(*)) | project(b, COUNT(*))
+//   becomes this:
+//     batch(seqScan(foo) | filter(a > 1) | groupBy(b) | hashAggregate(b, COUNT(*))) | project(b, COUNT(*))
+func UseBatchExecutionRule(s *stream.Stream, _ database.Catalog) (*stream.Stream, error) {
+	st, ok := s.First().(*stream.SeqScanOperator)
+	if !ok || readsHiddenDocuments(st) {
+		return s, nil
+	}
+
+	ops := []stream.Operator{st}
+loop:
+	for n := st.GetNext(); n != nil; n = n.GetNext() {
+		ops = append(ops, n)
+		switch n.(type) {
+		case *stream.HashAggregateOperator, *stream.StreamAggregateOperator:
+			break loop
+		}
+	}
+	if !stream.CanBatch(ops) {
+		return s, nil
+	}
+
+	scan := stream.SeqScan(st.TableName)
+	scan.Reverse = st.Reverse
+	scan.Filters = st.Filters
+	scan.Paths = st.Paths
+	inner := stream.New(scan)
+	for _, op := range ops[1:] {
+		s.Remove(op)
+		inner.Pipe(op)
+	}
+
+	stream.InsertBefore(st, stream.Batch(inner))
+	s.Remove(st)
+
+	return s, nil
+}
+
+// parallelScanMinRows is the number of documents a table must contain, according to its statistics,
+// for its seq scans to be run in parallel.
+const parallelScanMinRows = 10000
+
+// UseParallelScanRule replaces the seq scan of large tables and the filters and projection following it
+// by a parallel scan, which applies them on multiple goroutines.
+// Tables that were never analyzed are not considered large.
+// Example:
+//   this:
+//     seqScan(foo) | filter(a > 1) | project(a + 1)
+//   becomes this:
+//     parallelScan(seqScan(foo) | filter(a > 1) | project(a + 1))
+func UseParallelScanRule(s *stream.Stream, catalog database.Catalog) (*stream.Stream, error) {
+	st, ok := s.First().(*stream.SeqScanOperator)
+	if !ok || st.Offset > 0 || st.Limit > 0 || readsHiddenDocuments(st) {
+		return s, nil
+	}
+
+	var ops []stream.Operator
+loop:
+	for n := st.GetNext(); n != nil; n = n.GetNext() {
+		switch t := n.(type) {
+		case *stream.FilterOperator:
+			if !isParallelSafe(t.E) {
+				break loop
+			}
+			ops = append(ops, t)
+		case *stream.ProjectOperator:
+			for _, e := range t.Exprs {
+				if !isParallelSafe(e) {
+					break loop
+				}
+			}
+			ops = append(ops, t)
+			break loop
+		default:
+			break loop
+		}
+	}
+	if len(ops) == 0 && len(st.Filters) == 0 {
+		return s, nil
+	}
+
+	stats, err := getTableStatistics(catalog, st.TableName)
+	if err != nil {
+		return nil, err
+	}
+	if stats == nil || stats.RowCount < parallelScanMinRows {
+		return s, nil
+	}
+
+	scan := stream.SeqScan(st.TableName)
+	scan.Reverse = st.Reverse
+	scan.Filters = st.Filters
+	scan.Paths = st.Paths
+	inner := stream.New(scan)
+	for _, op := range ops {
+		s.Remove(op)
+		inner.Pipe(op)
+	}
+
+	stream.InsertBefore(st, stream.ParallelScan(inner))
+	s.Remove(st)
+
+	return s, nil
+}
+
+// isParallelSafe returns true if the expression can be evaluated on multiple goroutines.
+// Subqueries read the transaction, which can't be used concurrently, and expressions
+// with side effects modify it.
+func isParallelSafe(e expr.Expr) bool {
+	safe := true
+	expr.Walk(e, func(e expr.Expr) bool {
+		switch e.(type) {
+		case expr.Subquery, expr.NextValueFor:
+			safe = false
+		}
+		return safe
+	})
+
+	return safe
+}
+
+// scanOrderedBy returns true if the documents read by the scan are ordered by the given terms,
+// when the scan is read forward or, if reverse is true, backward.
+func scanOrderedBy(scan stream.Operator, terms []expr.OrderTerm, catalog database.Catalog) (ok bool, reverse bool, err error) {
+	var tableName string
+	switch t := scan.(type) {
+	case *stream.SeqScanOperator:
+		tableName = t.TableName
+	case *stream.PkScanOperator:
+		if len(t.Ranges) > 1 {
+			return false, false, nil
+		}
+		tableName = t.TableName
+	case *stream.IndexScanOperator:
+		// documents read from several ranges are not sorted globally
+		if len(t.Ranges) > 1 {
+			return false, false, nil
+		}
+
+		idx, err := catalog.GetIndexInfo(planTx(catalog), t.IndexName)
+		if err != nil {
+			return false, false, err
+		}
+
+		// paths matched exactly by the range can be skipped
+		var fixed int
+		if len(t.Ranges) == 1 {
+			fixed = fixedPrefixLen(&t.Ranges[0])
+		}
+
+		for i := 0; i <= fixed; i++ {
+			if ok, reverse := indexOrderedBy(idx, terms, i); ok {
+				return true, reverse, nil
+			}
+		}
+
+		return false, false, nil
+	default:
+		return false, false, nil
+	}
+
+	if len(terms) != 1 {
+		return false, false, nil
+	}
+
+	// the table is sorted by primary key
+	info, err := catalog.GetTableInfo(planTx(catalog), tableName)
+	if err != nil {
+		return false, false, err
+	}
+
+	p, ok := terms[0].E.(expr.Path)
+	pk := info.FieldConstraints.GetPrimaryKey()
+	return ok && pk != nil && pk.Path.IsEqual(document.Path(p)), terms[0].Desc, nil
+}
+
+// setScanReverse sets the direction of a scan returned by scanOrderedBy.
+func setScanReverse(scan stream.Operator, reverse bool) {
+	switch t := scan.(type) {
+	case *stream.SeqScanOperator:
+		t.Reverse = reverse
+	case *stream.PkScanOperator:
+		t.Reverse = reverse
+	case *stream.IndexScanOperator:
+		t.Reverse = reverse
+	}
+}
+
+// scanIndexes returns the names of the indexes that can replace the seq scan,
+// according to its index hint.
+func scanIndexes(st *stream.SeqScanOperator, catalog database.Catalog) []string {
+	if readsHiddenDocuments(st) {
+		return nil
+	}
+
+	var names []string
+	for _, name := range catalog.ListIndexes(planTx(catalog), st.TableName) {
+		if st.IndexHint.Allows(name) {
+			names = append(names, name)
+		}
+	}
+
+	return names
+}
+
+// prefersIndexes returns true if the seq scan has a USE INDEX hint, whose indexes
+// are used even if the statistics estimate that scanning the table is cheaper.
+func prefersIndexes(st *stream.SeqScanOperator) bool {
+	return st.IndexHint != nil && !st.IndexHint.Ignore
+}
+
+// checkGeneratedFields returns an error if the stream updates the value of a generated field,
+// which is only computed from the other fields of the document.
+func checkGeneratedFields(s *stream.Stream, catalog database.Catalog) error {
+	var tableName string
+	var paths []document.Path
+
+	switch t := s.Op.(type) {
+	case *stream.TableReplaceOperator:
+		tableName = t.Name
+		for op := t.GetPrev(); op != nil; op = op.GetPrev() {
+			switch o := op.(type) {
+			case *stream.SetOperator:
+				paths = append(paths, o.Path)
+			case *stream.UnsetOperator:
+				paths = append(paths, document.NewPath(o.Field))
+			}
+		}
+	case *stream.TableUpsertOperator:
+		tableName = t.Name
+		for _, pair := range t.Set {
+			paths = append(paths, pair.Path)
+		}
+	}
+
+	if len(paths) == 0 {
+		return nil
+	}
+
+	info, err := catalog.GetTableInfo(planTx(catalog), tableName)
+	if err != nil {
+		// writing to something else than a table fails when the stream is run
+		return nil
+	}
+
+	for _, fc := range info.FieldConstraints {
+		if !fc.IsGenerated() {
+			continue
+		}
+
+		for _, p := range paths {
+			if len(p) >= len(fc.Path) && p[:len(fc.Path)].IsEqual(fc.Path) {
+				return stringutil.Errorf("cannot update generated field %q", fc.Path)
+			}
+		}
+	}
+
+	return nil
+}
+
+// checkIndexHint returns an error if the index hint of the seq scan
+// refers to indexes that don't exist or that belong to another table.
+func checkIndexHint(st *stream.SeqScanOperator, catalog database.Catalog) error {
+	if st.IndexHint == nil {
+		return nil
+	}
+
+	for _, name := range st.IndexHint.Indexes {
+		info, err := catalog.GetIndexInfo(planTx(catalog), name)
+		if err != nil {
+			return err
+		}
+		if info.TableName != st.TableName {
+			return stringutil.Errorf("index %q is not an index of table %q", name, st.TableName)
+		}
+	}
+
+	return nil
+}
+
+// readsHiddenDocuments reports whether the seq scan reads documents that other scans can't find:
+// the previous versions of the documents, which are not indexed, or the soft deleted documents,
+// which are hidden from the other scans. Such a seq scan can't be replaced.
+func readsHiddenDocuments(st *stream.SeqScanOperator) bool {
+	return st.AsOf != nil || st.WithDeleted
+}
+
+// checkHiddenDocuments returns an error if the seq scan of the stream reads the previous versions
+// or the soft deleted documents of something else than a table.
+func checkHiddenDocuments(s *stream.Stream, st *stream.SeqScanOperator, catalog database.Catalog) error {
+	var clause string
+	switch {
+	case st.AsOf != nil:
+		clause = "AS OF"
+	case st.WithDeleted:
+		clause = "WITH DELETED"
+		// the scan of PURGE reads the soft deleted documents as well
+		if op, ok := s.Op.(*stream.TableDeleteOperator); ok && op.Purge {
+			clause = "PURGE"
+		}
+	default:
+		return nil
+	}
+
+	_, err := catalog.GetTableInfo(planTx(catalog), st.TableName)
+	if errs.IsNotFoundError(err) {
+		return stringutil.Errorf("%s can only be used on tables, %q is not a table", clause, st.TableName)
+	}
+	return err
+}
+
+// indexOnPaths returns the name of an index of the table whose first paths
+// are ordered by the given terms, if any, and which contains every document returned by the seq scan
+// exactly once. It also returns whether the index must be read backward.
+func indexOnPaths(st *stream.SeqScanOperator, terms []expr.OrderTerm, catalog database.Catalog) (string, bool, error) {
+	for _, name := range scanIndexes(st, catalog) {
+		idx, err := catalog.GetIndexInfo(planTx(catalog), name)
+		if err != nil {
+			return "", false, err
+		}
+
+		if idx.MultiKeyPosition() >= 0 || !isIndexUsable(idx, scanFilters(st)) {
+			continue
+		}
+
+		if ok, reverse := indexOrderedBy(idx, terms, 0); ok {
+			return name, reverse, nil
+		}
 	}
 
-	return s, nil
+	return "", false, nil
 }
 
-// RemoveUnnecessaryDistinctNodeRule removes any Dedup nodes
-// where projection is already unique.
-func RemoveUnnecessaryDistinctNodeRule(s *stream.Stream, catalog database.Catalog) (*stream.Stream, error) {
-	n := s.Op
+// indexOrderedBy returns true if the paths of the index starting at position from are the paths
+// of the terms, stored in the directions of the terms or, if reverse is true, in the opposite directions.
+// Sorts put NULL values first in ascending order and last in descending order,
+// which excludes the paths of the index storing them elsewhere.
+func indexOrderedBy(idx *database.IndexInfo, terms []expr.OrderTerm, from int) (ok bool, reverse bool) {
+	if from+len(terms) > len(idx.Paths) {
+		return false, false
+	}
 
-	// we assume that if we are reading from a table, the first
-	// operator of the stream has to be a SeqScanOperator
-	firstNode := s.First()
-	if firstNode == nil {
-		return s, nil
+	for i, t := range terms {
+		p, ok := t.E.(expr.Path)
+		if !ok || !idx.Paths[from+i].IsEqual(document.Path(p)) {
+			return false, false
+		}
+
+		o := idx.Order(from + i)
+		if o.IsNullsLast() != o.Desc {
+			return false, false
+		}
+
+		r := o.Desc != t.Desc
+		if i > 0 && r != reverse {
+			return false, false
+		}
+		reverse = r
 	}
-	st, ok := firstNode.(*stream.SeqScanOperator)
-	if !ok {
-		return s, nil
+
+	return true, reverse
+}
+
+// fixedPrefixLen returns the number of leading paths of the range
+// which are matched with a single value.
+func fixedPrefixLen(r *stream.IndexRange) int {
+	if r.In {
+		return 0
 	}
 
-	info, err := catalog.GetTableInfo(st.TableName)
-	if err != nil {
-		return nil, err
+	if r.Exact {
+		return len(r.Min)
 	}
 
-	// this optimization applies to project operators that immediately follow distinct
-	for n != nil {
-		if d, ok := n.(*stream.DistinctOperator); ok {
-			prev := d.GetPrev()
-			if prev != nil {
-				pn, ok := prev.(*stream.ProjectOperator)
-				if ok {
-					var indexes []*database.IndexInfo
-					for _, name := range catalog.ListIndexes(st.TableName) {
-						idx, err := catalog.GetIndexInfo(name)
-						if err != nil {
-							return nil, err
-						}
-						indexes = append(indexes, idx)
-					}
+	var n int
+	for n < len(r.Min) && n < len(r.Max) && expr.Equal(r.Min[n], r.Max[n]) {
+		n++
+	}
 
-					// if the projection is unique, we remove the node from the tree
-					if isProjectionUnique(indexes, pn, info.FieldConstraints.GetPrimaryKey()) {
-						s.Remove(n)
-						n = prev
-						continue
-					}
-				}
-			}
-		}
+	return n
+}
 
-		n = n.GetPrev()
+// prefixUpperBound returns the smallest text greater than
+// any text starting with prefix, if any.
+func prefixUpperBound(prefix string) (string, bool) {
+	b := []byte(prefix)
+	for i := len(b) - 1; i >= 0; i-- {
+		if b[i] < 0xff {
+			b[i]++
+			return string(b[:i+1]), true
+		}
 	}
 
-	return s, nil
+	return "", false
 }
 
-func isProjectionUnique(indexes []*database.IndexInfo, po *stream.ProjectOperator, pk *database.FieldConstraint) bool {
-	for _, field := range po.Exprs {
-		_, ok := field.(*expr.NamedExpr)
-		if ok {
-			return false
+// UseIndexBasedOnJoinRule looks for join operators whose right stream is a seq scan,
+// and whose condition compares a path of the joined table with a path of another table,
+// a literal or a parameter, using the = operator.
+// If the path is the primary key or the first path of an index of the joined table,
+// the seq scan is replaced by a lookup of the matching documents, evaluated for each document
+// of the left side, turning the nested loop join into an index join.
+// The condition is kept to filter the documents that are looked up.
+// Example, given an index on b.y:
+//   this:
+//     seqScan(a) | join(a, b, seqScan(b), a.x = b.y)
+//   becomes this:
+//     seqScan(a) | join(a, b, indexScan("idx_b_y", [a.x]), a.x = b.y)
+func UseIndexBasedOnJoinRule(s *stream.Stream, catalog database.Catalog) (*stream.Stream, error) {
+	for n := s.Op; n != nil; n = n.GetPrev() {
+		j, ok := n.(*stream.JoinOperator)
+		if !ok || j.On == nil || j.Right == nil {
+			continue
 		}
 
-		switch v := field.(type) {
-		case expr.Path:
-			if pk != nil && pk.Path.IsEqual(document.Path(v)) {
+		st, ok := j.Right.Op.(*stream.SeqScanOperator)
+		if !ok || st.GetPrev() != nil {
+			continue
+		}
+
+		if catalog.GetVirtualTable(st.TableName) != nil {
+			continue
+		}
+
+		info, err := catalog.GetTableInfo(planTx(catalog), st.TableName)
+		if err != nil {
+			return nil, err
+		}
+
+		var indexes []*database.IndexInfo
+		for _, name := range scanIndexes(st, catalog) {
+			idx, err := catalog.GetIndexInfo(planTx(catalog), name)
+			if err != nil {
+				return nil, err
+			}
+			// multi-key indexes can't look up whole arrays
+			if idx.MultiKeyPosition() < 0 && isIndexUsable(idx, scanFilters(st)) {
+				indexes = append(indexes, idx)
+			}
+		}
+
+		var newOp stream.Operator
+		var priority int
+		for _, e := range splitANDExpr(j.On) {
+			path, other, ok := joinOperandsCanUseIndex(e, j.RightName)
+			if !ok {
 				continue
 			}
 
-			p := document.Path(v)
-			var found *database.IndexInfo
+			if pk := info.FieldConstraints.GetPrimaryKey(); pk != nil && pk.Path.IsEqual(path) {
+				newOp = stream.PkScan(st.TableName, stream.ValueRange{Min: other, Exact: true})
+				break
+			}
 
-			for _, info := range indexes {
-				if info.Paths[0].IsEqual(p) {
-					found = info
-					break
+			for _, idx := range indexes {
+				p := 1
+				if idx.Unique {
+					p = 2
+				}
+				if p <= priority || !idx.Paths[0].IsEqual(path) {
+					continue
 				}
-			}
 
-			if found != nil && found.Unique {
-				continue
+				newOp = stream.IndexScan(idx.IndexName, stream.IndexRange{
+					Min:   expr.LiteralExprList{other},
+					Paths: []document.Path{path},
+					Exact: true,
+				})
+				priority = p
 			}
-		case *functions.PK:
-			continue
 		}
 
-		return false // if one field is not unique, so projection is not unique too.
+		if newOp != nil {
+			j.Right = stream.New(newOp)
+		}
 	}
 
-	return true
+	return s, nil
+}
+
+// joinOperandsCanUseIndex returns the path of the joined table and the expression it is compared to,
+// if e is an = operator comparing a path of the table named rightName with a path of another table,
+// a literal or a parameter.
+func joinOperandsCanUseIndex(e expr.Expr, rightName string) (document.Path, expr.Expr, bool) {
+	op, ok := e.(expr.Operator)
+	if !ok || op.Token() != scanner.EQ {
+		return nil, nil, false
+	}
+
+	isRight := func(e expr.Expr) bool {
+		p, ok := e.(expr.Path)
+		return ok && len(p) > 1 && p[0].FieldName == rightName
+	}
+
+	// the expression is evaluated before reading the joined table:
+	// only paths of the other tables, literals and parameters can be used.
+	canLookup := func(e expr.Expr) bool {
+		switch t := e.(type) {
+		case expr.Path:
+			return len(t) > 0 && t[0].FieldName != rightName
+		case expr.LiteralValue:
+			return true
+		}
+		return isParam(e)
+	}
+
+	lh, rh := op.LeftHand(), op.RightHand()
+	if isRight(rh) {
+		lh, rh = rh, lh
+	}
+	if !isRight(lh) || !canLookup(rh) {
+		return nil, nil, false
+	}
+
+	return document.Path(lh.(expr.Path)[1:]), rh, true
+}
+
+// hasJoin returns true if the stream joins multiple tables,
+// or if its documents are named after a table alias.
+func hasJoin(s *stream.Stream) bool {
+	for n := s.Op; n != nil; n = n.GetPrev() {
+		switch n.(type) {
+		case *stream.JoinOperator, *stream.WrapOperator:
+			return true
+		}
+	}
+
+	return false
 }
 
 type filterNode struct {
 	path document.Path
 	e    expr.Expr
 	f    *stream.FilterOperator
+	// if true, the filter selects the documents whose array at path contains e,
+	// i.e. e IN path or path CONTAINS e, which can only be read from multi-key indexes.
+	element bool
 }
 
 // UseIndexBasedOnFilterNodeRule scans the tree for filter nodes whose conditions are
@@ -408,6 +2029,8 @@ type filterNode struct {
 //
 // If one or many are found, it will replace the input node by an indexInputNode using this index,
 // removing the now irrelevant filter nodes.
+// If the table was analyzed, the index is chosen by estimating the number of documents
+// it reads, and the table is scanned if it is cheaper than using any index.
 //
 // TODO(asdine): add support for ORDER BY
 // TODO(jh): clarify cost code in composite indexes case
@@ -421,10 +2044,10 @@ func UseIndexBasedOnFilterNodeRule(s *stream.Stream, catalog database.Catalog) (
 		return s, nil
 	}
 	st, ok := firstNode.(*stream.SeqScanOperator)
-	if !ok {
+	if !ok || readsHiddenDocuments(st) {
 		return s, nil
 	}
-	info, err := catalog.GetTableInfo(st.TableName)
+	info, err := catalog.GetTableInfo(planTx(catalog), st.TableName)
 	if err != nil {
 		return nil, err
 	}
@@ -445,6 +2068,11 @@ func UseIndexBasedOnFilterNodeRule(s *stream.Stream, catalog database.Catalog) (
 				continue
 			}
 
+			if path, e, ok := elementOperands(op); ok {
+				filterNodes = append(filterNodes, filterNode{path: path, e: e, f: f, element: true})
+				continue
+			}
+
 			if !expr.OperatorIsIndexCompatible(op) {
 				continue
 			}
@@ -488,9 +2116,9 @@ func UseIndexBasedOnFilterNodeRule(s *stream.Stream, catalog database.Catalog) (
 		}
 	}
 
-	findByPath := func(path document.Path) *filterNode {
+	findByPath := func(path document.Path, element bool) *filterNode {
 		for _, fno := range filterNodes {
-			if fno.path.IsEqual(path) {
+			if fno.element == element && fno.path.IsEqual(path) {
 				return &fno
 			}
 		}
@@ -498,28 +2126,36 @@ func UseIndexBasedOnFilterNodeRule(s *stream.Stream, catalog database.Catalog) (
 		return nil
 	}
 
+	// array elements are matched exactly
 	isNodeEq := func(fno *filterNode) bool {
 		op := fno.f.E.(expr.Operator)
-		return op.Token() == scanner.EQ || op.Token() == scanner.IN
+		return fno.element || op.Token() == scanner.EQ || op.Token() == scanner.IN
 	}
 	isNodeComp := func(fno *filterNode) bool {
 		op := fno.f.E.(expr.Operator)
-		return expr.IsComparisonOperator(op)
+		return fno.element || expr.IsComparisonOperator(op)
 	}
 
 	// iterate on all indexes for that table, checking for each of them if its paths are matching
 	// the filter nodes of the given query. The resulting nodes are ordered like the index paths.
 outer:
 
-	for _, idxName := range catalog.ListIndexes(st.TableName) {
-		idxInfo, err := catalog.GetIndexInfo(idxName)
+	for _, idxName := range scanIndexes(st, catalog) {
+		idxInfo, err := catalog.GetIndexInfo(planTx(catalog), idxName)
 		if err != nil {
 			return nil, err
 		}
+		if !isIndexUsable(idxInfo, scanFilters(st)) {
+			continue
+		}
+
+		// the multi-key path of an index can only be matched by filters on the elements of arrays
+		multiKey := idxInfo.MultiKeyPosition()
+
 		// order filter nodes by how the index paths order them; if absent, nil in still inserted
 		found := make([]*filterNode, len(idxInfo.Paths))
 		for i, path := range idxInfo.Paths {
-			fno := findByPath(path)
+			fno := findByPath(path, i == multiKey)
 
 			if fno != nil {
 				// mark this path from the index as found
@@ -581,6 +2217,12 @@ outer:
 			continue outer
 		}
 
+		// a multi-key index returns documents once per element of their arrays,
+		// unless the element is fixed by the filters
+		if multiKey >= 0 && len(usableFilterNodes) <= multiKey {
+			continue outer
+		}
+
 		cd := candidate{
 			filterOps: fops,
 			isIndex:   true,
@@ -604,7 +2246,23 @@ outer:
 		candidates = append(candidates, &cd)
 	}
 
-	// determine which index is the most interesting and replace it in the tree.
+	// if the table was analyzed, select the candidate reading the fewest documents,
+	// or keep scanning the table if it is cheaper, unless the indexes were hinted.
+	stats, err := getTableStatistics(catalog, st.TableName)
+	if err != nil {
+		return nil, err
+	}
+	if stats != nil && len(candidates) > 0 {
+		selectedCandidate, ok, err := selectCandidateUsingStatistics(catalog, st.TableName, stats, candidates)
+		if err != nil {
+			return nil, err
+		}
+		if ok && (selectedCandidate != nil || !prefersIndexes(st)) {
+			return replaceSeqScan(s, selectedCandidate), nil
+		}
+	}
+
+	// otherwise, determine which index is the most interesting and replace it in the tree.
 	// we will assume that unique indexes are more interesting than list indexes
 	// because they usually have less elements.
 	var selectedCandidate *candidate
@@ -643,10 +2301,124 @@ outer:
 		}
 	}
 
-	if selectedCandidate == nil {
+	return replaceSeqScan(s, selectedCandidate), nil
+}
+
+// UseIndexUnionRule looks for filter nodes whose condition is made of OR operators,
+// when the documents of the table are read sequentially.
+// If the documents matching each operand of the OR operators can be read using an index
+// or the primary key, the seq scan is replaced by a union of the scans of each operand,
+// which returns each document only once, and the filter node is removed.
+// If the table was analyzed, the union is only used if it is cheaper than the seq scan.
+// Example, given an index on a and another on b:
+//   this:
+//     seqScan(foo) | filter(a = 1 OR b > 2)
+//   becomes this:
+//     unionScan(indexScan("idx_foo_a", 1), indexScan("idx_foo_b", [2, -1, true]))
+func UseIndexUnionRule(s *stream.Stream, catalog database.Catalog) (*stream.Stream, error) {
+	st, ok := s.First().(*stream.SeqScanOperator)
+	if !ok || st.Reverse || readsHiddenDocuments(st) {
+		return s, nil
+	}
+
+	for n := st.GetNext(); n != nil; n = n.GetNext() {
+		f, ok := n.(*stream.FilterOperator)
+		if !ok {
+			// filters of operators reading other documents can't be replaced
+			break
+		}
+
+		if f.E == nil {
+			continue
+		}
+
+		operands := splitORExpr(f.E)
+		if len(operands) < 2 {
+			continue
+		}
+
+		// each operand is optimized as if it was the only condition
+		streams := make([]*stream.Stream, 0, len(operands))
+		for _, e := range operands {
+			scan := stream.SeqScan(st.TableName)
+			scan.IndexHint = st.IndexHint
+			os := stream.New(scan).Pipe(stream.Filter(e))
+			for _, rule := range unionOptimizerRules {
+				var err error
+				os, err = rule(os, catalog)
+				if err != nil {
+					return nil, err
+				}
+				if os.Op == nil {
+					break
+				}
+			}
+
+			// the operand never matches
+			if os.Op == nil {
+				continue
+			}
+
+			if _, ok := os.First().(*stream.SeqScanOperator); ok {
+				streams = nil
+				break
+			}
+			streams = append(streams, os)
+		}
+		if len(streams) == 0 {
+			continue
+		}
+
+		us := stream.UnionScan(st.TableName, streams...)
+
+		stats, err := getTableStatistics(catalog, st.TableName)
+		if err != nil {
+			return nil, err
+		}
+		if stats != nil && !prefersIndexes(st) {
+			cost, ok, err := scanCost(catalog, st.TableName, stats, us)
+			if err != nil {
+				return nil, err
+			}
+			if ok && cost >= float64(stats.RowCount) {
+				continue
+			}
+		}
+
+		stream.InsertBefore(st, us)
+		s.Remove(st)
+		s.Remove(f)
 		return s, nil
 	}
 
+	return s, nil
+}
+
+// splitORExpr takes an expression and splits it by OR operator.
+func splitORExpr(cond expr.Expr) (exprs []expr.Expr) {
+	if p, ok := cond.(expr.Parentheses); ok {
+		return splitORExpr(p.E)
+	}
+
+	op, ok := cond.(expr.Operator)
+	if ok && op.Token() == scanner.OR {
+		exprs = append(exprs, splitORExpr(op.LeftHand())...)
+		exprs = append(exprs, splitORExpr(op.RightHand())...)
+		return
+	}
+
+	exprs = append(exprs, cond)
+	return
+}
+
+// replaceSeqScan replaces the seq scan node of the stream and the filter nodes
+// of the candidate by the scan node of the candidate.
+// The stream is left untouched if the candidate is nil.
+func replaceSeqScan(s *stream.Stream, selectedCandidate *candidate) *stream.Stream {
+	if selectedCandidate == nil {
+		return s
+	}
+
 	// remove the selection node from the tree
 	for _, f := range selectedCandidate.filterOps {
 		s.Remove(f)
@@ -657,7 +2429,7 @@ outer:
 
 	s.Remove(s.First().GetNext())
 
-	return s, nil
+	return s
 }
 
 type candidate struct {
@@ -677,7 +2449,40 @@ type candidate struct {
 	priority int
 }
 
+// elementOperands returns the path and the element of e IN path and path CONTAINS e
+// operators, if e is a literal value other than NULL or a parameter.
+// The documents they select can be read from multi-key indexes.
+func elementOperands(op expr.Operator) (document.Path, expr.Expr, bool) {
+	var p, e expr.Expr
+	switch op.(type) {
+	case *expr.InOperator:
+		p, e = op.RightHand(), op.LeftHand()
+	case *expr.ContainsOperator:
+		p, e = op.LeftHand(), op.RightHand()
+	default:
+		return nil, nil, false
+	}
+
+	path, ok := p.(expr.Path)
+	if !ok {
+		return nil, nil, false
+	}
+
+	// NULL is never contained in an array
+	if lv, ok := e.(expr.LiteralValue); (ok && lv.Type != document.NullValue) || isParam(e) {
+		return document.Path(path), e, true
+	}
+
+	return nil, nil, false
+}
+
 func operatorCanUseIndex(op expr.Operator) (bool, document.Path, expr.Expr) {
+	// subqueries may refer to the document being filtered,
+	// they can't be evaluated before reading the table.
+	if hasSubquery(op) {
+		return false, nil, nil
+	}
+
 	lf, leftIsPath := op.LeftHand().(expr.Path)
 	rf, rightIsPath := op.RightHand().(expr.Path)
 
@@ -687,8 +2492,9 @@ func operatorCanUseIndex(op expr.Operator) (bool, document.Path, expr.Expr) {
 	if op.Token() == scanner.IN {
 		if leftIsPath && !rightIsPath {
 			rh := op.RightHand()
-			// The IN operator can use indexes only if the right hand side is an expression list.
-			if _, ok := rh.(expr.LiteralExprList); !ok {
+			// The IN operator can use indexes only if the right hand side is an expression list
+			// or a parameter, whose values are only known at execution time.
+			if _, ok := rh.(expr.LiteralExprList); !ok && !isParam(rh) {
 				return false, nil, nil
 			}
 			return true, document.Path(lf), rh
@@ -710,25 +2516,52 @@ func operatorCanUseIndex(op expr.Operator) (bool, document.Path, expr.Expr) {
 	return false, nil, nil
 }
 
+// hasSubquery returns true if e contains a subquery.
+func hasSubquery(e expr.Expr) bool {
+	var found bool
+	expr.Walk(e, func(e expr.Expr) bool {
+		if _, ok := e.(expr.Subquery); ok {
+			found = true
+		}
+		return !found
+	})
+
+	return found
+}
+
+func isParam(e expr.Expr) bool {
+	switch e.(type) {
+	case expr.NamedParam, expr.PositionalParam:
+		return true
+	}
+
+	return false
+}
+
 func getRangesFromFilterNodes(fnodes []*filterNode) (stream.IndexRanges, error) {
-	var ranges stream.IndexRanges
 	var el expr.LiteralExprList
-	// store IN operands with their position (in the index paths) as a key
-	inOperands := make(map[int]expr.LiteralExprList)
+	// positions (in the index paths) of the IN operators whose operand is an expression list
+	var inPositions []int
+	// positions of the IN operators whose operand is a parameter
+	var inParamPositions []int
 
 	for i, fno := range fnodes {
 		op := fno.f.E.(expr.Operator)
 		e := fno.e
 
 		switch {
+		case fno.element:
+			el = append(el, e)
+		case op.Token() == scanner.IN && isParam(e):
+			// the values of the parameter are only known at execution time:
+			// the ranges will be expanded when they are evaluated.
+			inParamPositions = append(inParamPositions, i)
+			el = append(el, e)
 		case op.Token() == scanner.IN:
-			// mark where the IN operator values are supposed to go is in the buffer
-			// and what are the value needed to generate the ranges.
-			// operatorCanUseIndex made sure v is an array.
-			inOperands[i] = e.(expr.LiteralExprList)
-
-			// placeholder for when we'll explode the IN operands in multiple ranges
-			el = append(el, expr.LiteralValue{})
+			// mark where the IN operator values are supposed to go is in the buffer.
+			// operatorCanUseIndex made sure e is an expression list.
+			inPositions = append(inPositions, i)
+			el = append(el, e)
 		case expr.IsComparisonOperator(op):
 			el = append(el, e)
 		default:
@@ -736,13 +2569,16 @@ func getRangesFromFilterNodes(fnodes []*filterNode) (stream.IndexRanges, error)
 		}
 	}
 
-	if len(inOperands) > 1 {
-		// TODO FEATURE https://github.com/genjidb/genji/issues/392
-		panic("unsupported operation: multiple IN operators on a composite index")
+	// the last node is the only one that can be a comparison operator, so
+	// it's the one setting the range behaviour
+	last := fnodes[len(fnodes)-1]
+	tok := last.f.E.(expr.Operator).Token()
+	if last.element {
+		tok = scanner.EQ
 	}
 
 	// a small helper func to create a range based on an operator type
-	buildRange := func(op expr.Operator, el expr.LiteralExprList) stream.IndexRange {
+	buildRange := func(el expr.LiteralExprList) stream.IndexRange {
 		var paths []document.Path
 		for i := range el {
 			paths = append(paths, fnodes[i].path)
@@ -751,7 +2587,17 @@ func getRangesFromFilterNodes(fnodes []*filterNode) (stream.IndexRanges, error)
 			Paths: paths,
 		}
 
-		switch op.Token() {
+		if len(inParamPositions) > 0 {
+			rng.In = true
+			rng.InPositions = inParamPositions
+		}
+
+		// the values preceding the last one are matched exactly:
+		// they are the prefix of the other boundary of the range.
+		// i.e. a = 1 AND b > 2 selects the values between [1, 2] and [1].
+		prefix := el[:len(el)-1]
+
+		switch tok {
 		case scanner.EQ, scanner.IN:
 			rng.Exact = true
 			rng.Min = el
@@ -767,42 +2613,44 @@ func getRangesFromFilterNodes(fnodes []*filterNode) (stream.IndexRanges, error)
 			rng.Max = el
 		}
 
+		if len(prefix) > 0 && !rng.Exact {
+			if rng.Min == nil {
+				rng.Min = prefix
+			} else {
+				rng.Max = prefix
+			}
+		}
+
 		return rng
 	}
 
-	// explode the IN operator values in multiple ranges
-	for pos, operands := range inOperands {
-		for i := range operands {
-			newVB := make(expr.LiteralExprList, len(el))
-			copy(newVB, el)
-
-			// insert IN operand at the right position, replacing the placeholder value
-			newVB[pos] = operands[i]
-
-			// the last node is the only one that can be a comparison operator, so
-			// it's the one setting the range behaviour
-			last := fnodes[len(fnodes)-1]
-			op := last.f.E.(expr.Operator)
-
-			rng := buildRange(op, newVB)
-
-			ranges = ranges.Append(rng)
+	// explode the values of the IN operators in multiple ranges,
+	// one per combination of the values of each operator.
+	// i.e. a IN [1, 2] AND b IN [3, 4] selects [1, 3], [1, 4], [2, 3] and [2, 4].
+	combinations := []expr.LiteralExprList{el}
+	for _, pos := range inPositions {
+		operands := el[pos].(expr.LiteralExprList)
+
+		var next []expr.LiteralExprList
+		for _, c := range combinations {
+			for i := range operands {
+				newVB := make(expr.LiteralExprList, len(c))
+				copy(newVB, c)
+
+				// insert IN operand at the right position, replacing the list
+				newVB[pos] = operands[i]
+				next = append(next, newVB)
+			}
 		}
+		combinations = next
 	}
 
-	// Were there any IN operators requiring multiple ranges?
-	// If yes, we're done here.
-	if len(ranges) > 0 {
-		return ranges, nil
+	var ranges stream.IndexRanges
+	for _, c := range combinations {
+		ranges = ranges.Append(buildRange(c))
 	}
 
-	// the last node is the only one that can be a comparison operator, so
-	// it's the one setting the range behaviour
-	last := fnodes[len(fnodes)-1]
-	op := last.f.E.(expr.Operator)
-	rng := buildRange(op, el)
-
-	return stream.IndexRanges{rng}, nil
+	return ranges, nil
 }
 
 func getRangesFromOp(op expr.Operator, e expr.Expr) (stream.ValueRanges, error) {
@@ -833,6 +2681,15 @@ func getRangesFromOp(op expr.Operator, e expr.Expr) (stream.ValueRanges, error)
 			Max: e,
 		})
 	case scanner.IN:
+		if isParam(e) {
+			ranges = ranges.Append(stream.ValueRange{
+				Min:   e,
+				Exact: true,
+				In:    true,
+			})
+			break
+		}
+
 		// operatorCanUseIndex made sure e is a expression list.
 		el := e.(expr.LiteralExprList)
 		for i := range el {
@@ -1,10 +1,16 @@
 package planner_test
 
 import (
+	"context"
+	"fmt"
+	"strings"
 	"testing"
 
 	"github.com/genjidb/genji/document"
+	"github.com/genjidb/genji/internal/database"
+	"github.com/genjidb/genji/internal/environment"
 	"github.com/genjidb/genji/internal/expr"
+	"github.com/genjidb/genji/internal/expr/functions"
 	"github.com/genjidb/genji/internal/planner"
 	"github.com/genjidb/genji/internal/sql/parser"
 	st "github.com/genjidb/genji/internal/stream"
@@ -79,6 +85,8 @@ func TestSplitANDConditionRule(t *testing.T) {
 			res, err := planner.SplitANDConditionRule(test.in, nil)
 			require.NoError(t, err)
 			require.Equal(t, res.String(), test.expected.String())
+			// the stream must still end with its last operator
+			require.Equal(t, test.expected.Op.String(), res.Op.String())
 		})
 	}
 }
@@ -171,6 +179,25 @@ func TestPrecalculateExprRule(t *testing.T) {
 	}
 }
 
+func TestPrecalculateExprRuleValues(t *testing.T) {
+	s := st.New(st.Expressions(
+		&expr.KVPairs{Pairs: []expr.KVPair{
+			{K: "a", V: expr.Add(testutil.IntegerValue(1), testutil.IntegerValue(2))},
+		}},
+		&expr.KVPairs{Pairs: []expr.KVPair{
+			{K: "a", V: expr.PositionalParam(1)},
+		}},
+	)).Pipe(st.TableInsert("foo", nil))
+
+	res, err := planner.PrecalculateExprRule(s, nil)
+	require.NoError(t, err)
+
+	exprs := res.First().(*st.ExprsOperator).Exprs
+	require.Equal(t, expr.LiteralValue(document.NewDocumentValue(document.NewFieldBuffer().
+		Add("a", document.NewIntegerValue(3)))), exprs[0])
+	require.IsType(t, &expr.KVPairs{}, exprs[1])
+}
+
 func TestRemoveUnnecessarySelectionNodesRule(t *testing.T) {
 	tests := []struct {
 		name           string
@@ -267,16 +294,874 @@ func TestRemoveUnnecessaryDedupNodeRule(t *testing.T) {
 			db, tx, cleanup := testutil.NewTestTx(t)
 			defer cleanup()
 
-			testutil.MustExec(t, db, tx, `
-				CREATE TABLE foo(a integer PRIMARY KEY, b integer, c integer);
-				CREATE UNIQUE INDEX idx_foo_idx ON foo(c);
-				INSERT INTO foo (a, b, c) VALUES
-					(1, 1, 1),
-					(2, 2, 2),
-					(3, 3, 3)
-			`)
+			testutil.MustExec(t, db, tx, `
+				CREATE TABLE foo(a integer PRIMARY KEY, b integer, c integer);
+				CREATE UNIQUE INDEX idx_foo_idx ON foo(c);
+				INSERT INTO foo (a, b, c) VALUES
+					(1, 1, 1),
+					(2, 2, 2),
+					(3, 3, 3)
+			`)
+
+			res, err := planner.RemoveUnnecessaryDistinctNodeRule(test.root, planner.TxCatalog(db.Catalog, tx))
+			require.NoError(t, err)
+			require.Equal(t, test.expected.String(), res.String())
+		})
+	}
+}
+
+func TestAddLikePrefixRangeRule(t *testing.T) {
+	tests := []struct {
+		name           string
+		root, expected *st.Stream
+	}{
+		{
+			"non-indexed path",
+			st.New(st.SeqScan("foo")).Pipe(st.Filter(parser.MustParseExpr("d LIKE 'abc%'"))),
+			st.New(st.SeqScan("foo")).Pipe(st.Filter(parser.MustParseExpr("d LIKE 'abc%'"))),
+		},
+		{
+			"LIKE with literal prefix",
+			st.New(st.SeqScan("foo")).Pipe(st.Filter(parser.MustParseExpr("a LIKE 'abc%'"))),
+			st.New(st.SeqScan("foo")).
+				Pipe(st.Filter(parser.MustParseExpr("a < 'abd'"))).
+				Pipe(st.Filter(parser.MustParseExpr("a >= 'abc'"))).
+				Pipe(st.Filter(parser.MustParseExpr("a LIKE 'abc%'"))),
+		},
+		{
+			"LIKE on primary key",
+			st.New(st.SeqScan("foo")).Pipe(st.Filter(parser.MustParseExpr("k LIKE 'ab_d'"))),
+			st.New(st.SeqScan("foo")).
+				Pipe(st.Filter(parser.MustParseExpr("k < 'ac'"))).
+				Pipe(st.Filter(parser.MustParseExpr("k >= 'ab'"))).
+				Pipe(st.Filter(parser.MustParseExpr("k LIKE 'ab_d'"))),
+		},
+		{
+			"LIKE with escaped wildcard",
+			st.New(st.SeqScan("foo")).Pipe(st.Filter(parser.MustParseExpr("a LIKE '10!%%' ESCAPE '!'"))),
+			st.New(st.SeqScan("foo")).
+				Pipe(st.Filter(parser.MustParseExpr("a < '10&'"))).
+				Pipe(st.Filter(parser.MustParseExpr("a >= '10%'"))).
+				Pipe(st.Filter(parser.MustParseExpr("a LIKE '10!%%' ESCAPE '!'"))),
+		},
+		{
+			"LIKE without prefix",
+			st.New(st.SeqScan("foo")).Pipe(st.Filter(parser.MustParseExpr("a LIKE '%abc'"))),
+			st.New(st.SeqScan("foo")).Pipe(st.Filter(parser.MustParseExpr("a LIKE '%abc'"))),
+		},
+		{
+			"NOT LIKE",
+			st.New(st.SeqScan("foo")).Pipe(st.Filter(parser.MustParseExpr("a NOT LIKE 'abc%'"))),
+			st.New(st.SeqScan("foo")).Pipe(st.Filter(parser.MustParseExpr("a NOT LIKE 'abc%'"))),
+		},
+		{
+			"ILIKE with caseless prefix",
+			st.New(st.SeqScan("foo")).Pipe(st.Filter(parser.MustParseExpr("a ILIKE '12-ab%'"))),
+			st.New(st.SeqScan("foo")).
+				Pipe(st.Filter(parser.MustParseExpr("a < '12.'"))).
+				Pipe(st.Filter(parser.MustParseExpr("a >= '12-'"))).
+				Pipe(st.Filter(parser.MustParseExpr("a ILIKE '12-ab%'"))),
+		},
+		{
+			"ILIKE with cased prefix",
+			st.New(st.SeqScan("foo")).Pipe(st.Filter(parser.MustParseExpr("a ILIKE 'abc%'"))),
+			st.New(st.SeqScan("foo")).Pipe(st.Filter(parser.MustParseExpr("a ILIKE 'abc%'"))),
+		},
+		{
+			"parameter",
+			st.New(st.SeqScan("foo")).Pipe(st.Filter(parser.MustParseExpr("a LIKE ?"))),
+			st.New(st.SeqScan("foo")).Pipe(st.Filter(parser.MustParseExpr("a LIKE ?"))),
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			db, tx, cleanup := testutil.NewTestTx(t)
+			defer cleanup()
+
+			testutil.MustExec(t, db, tx, `
+				CREATE TABLE foo (k TEXT PRIMARY KEY);
+				CREATE INDEX idx_foo_a ON foo(a);
+			`)
+
+			res, err := planner.AddLikePrefixRangeRule(test.root, planner.TxCatalog(db.Catalog, tx))
+			require.NoError(t, err)
+			require.Equal(t, test.expected.String(), res.String())
+		})
+	}
+}
+
+func TestUseIndexForOrderByRule(t *testing.T) {
+	desc := func(e string) expr.OrderTerm {
+		return expr.OrderTerm{E: parser.MustParseExpr(e), Desc: true}
+	}
+	asc := func(e string) expr.OrderTerm {
+		return expr.OrderTerm{E: parser.MustParseExpr(e)}
+	}
+
+	tests := []struct {
+		name           string
+		root, expected *st.Stream
+	}{
+		{
+			"non-indexed path",
+			st.New(st.SeqScan("foo")).Pipe(st.Sort(parser.MustParseExpr("d"))),
+			st.New(st.SeqScan("foo")).Pipe(st.Sort(parser.MustParseExpr("d"))),
+		},
+		{
+			"primary key",
+			st.New(st.SeqScan("foo")).Pipe(st.Sort(parser.MustParseExpr("k"))).Pipe(st.Take(10)),
+			st.New(st.SeqScan("foo")).Pipe(st.Take(10)),
+		},
+		{
+			"primary key desc",
+			st.New(st.PkScan("foo", st.ValueRange{Min: testutil.IntegerValue(1)})).Pipe(st.SortReverse(parser.MustParseExpr("k"))),
+			st.New(st.PkScanReverse("foo", st.ValueRange{Min: testutil.IntegerValue(1)})),
+		},
+		{
+			"indexed path",
+			st.New(st.SeqScan("foo")).
+				Pipe(st.Filter(parser.MustParseExpr("d > 1"))).
+				Pipe(st.Project(parser.MustParseExpr("a"))).
+				Pipe(st.SortReverse(parser.MustParseExpr("a"))),
+			st.New(st.IndexScanReverse("idx_foo_a")).
+				Pipe(st.Filter(parser.MustParseExpr("d > 1"))).
+				Pipe(st.Project(parser.MustParseExpr("a"))),
+		},
+		{
+			"prefix of a composite index",
+			st.New(st.SeqScan("foo")).Pipe(st.SortBy(asc("b"), asc("c"))),
+			st.New(st.IndexScan("idx_foo_b_c")),
+		},
+		{
+			"mixed directions",
+			st.New(st.SeqScan("foo")).Pipe(st.SortBy(asc("b"), desc("c"))),
+			st.New(st.SeqScan("foo")).Pipe(st.SortBy(asc("b"), desc("c"))),
+		},
+		{
+			"directions of the index",
+			st.New(st.SeqScan("foo")).Pipe(st.SortBy(desc("x"), asc("y"))),
+			st.New(st.IndexScan("idx_foo_x_y")),
+		},
+		{
+			"opposite directions of the index",
+			st.New(st.SeqScan("foo")).Pipe(st.SortBy(asc("x"), desc("y"))),
+			st.New(st.IndexScanReverse("idx_foo_x_y")),
+		},
+		{
+			"NULL values stored last",
+			st.New(st.SeqScan("foo")).Pipe(st.Sort(parser.MustParseExpr("z"))),
+			st.New(st.SeqScan("foo")).Pipe(st.Sort(parser.MustParseExpr("z"))),
+		},
+		{
+			"path after an exact prefix",
+			st.New(st.IndexScan("idx_foo_b_c", st.IndexRange{Min: testutil.ExprList(t, `[1]`), Exact: true})).Pipe(st.SortReverse(parser.MustParseExpr("c"))),
+			st.New(st.IndexScanReverse("idx_foo_b_c", st.IndexRange{Min: testutil.ExprList(t, `[1]`), Exact: true})),
+		},
+		{
+			"path after a range",
+			st.New(st.IndexScan("idx_foo_b_c", st.IndexRange{Min: testutil.ExprList(t, `[1]`)})).Pipe(st.Sort(parser.MustParseExpr("c"))),
+			st.New(st.IndexScan("idx_foo_b_c", st.IndexRange{Min: testutil.ExprList(t, `[1]`)})).Pipe(st.Sort(parser.MustParseExpr("c"))),
+		},
+		{
+			"another index",
+			st.New(st.IndexScan("idx_foo_b_c", st.IndexRange{Min: testutil.ExprList(t, `[1]`), Exact: true})).Pipe(st.Sort(parser.MustParseExpr("a"))),
+			st.New(st.IndexScan("idx_foo_b_c", st.IndexRange{Min: testutil.ExprList(t, `[1]`), Exact: true})).Pipe(st.Sort(parser.MustParseExpr("a"))),
+		},
+		{
+			"several ranges",
+			st.New(st.IndexScan("idx_foo_a", st.IndexRange{Min: testutil.ExprList(t, `[1]`), Exact: true}, st.IndexRange{Min: testutil.ExprList(t, `[2]`), Exact: true})).Pipe(st.Sort(parser.MustParseExpr("a"))),
+			st.New(st.IndexScan("idx_foo_a", st.IndexRange{Min: testutil.ExprList(t, `[1]`), Exact: true}, st.IndexRange{Min: testutil.ExprList(t, `[2]`), Exact: true})).Pipe(st.Sort(parser.MustParseExpr("a"))),
+		},
+		{
+			"aggregation",
+			st.New(st.SeqScan("foo")).Pipe(st.GroupBy(parser.MustParseExpr("a"))).Pipe(st.HashAggregate()).Pipe(st.Sort(parser.MustParseExpr("a"))),
+			st.New(st.SeqScan("foo")).Pipe(st.GroupBy(parser.MustParseExpr("a"))).Pipe(st.HashAggregate()).Pipe(st.Sort(parser.MustParseExpr("a"))),
+		},
+		{
+			"projected alias",
+			st.New(st.SeqScan("foo")).Pipe(st.Project(&expr.NamedExpr{ExprName: "a", Expr: parser.MustParseExpr("d")})).Pipe(st.Sort(parser.MustParseExpr("a"))),
+			st.New(st.SeqScan("foo")).Pipe(st.Project(&expr.NamedExpr{ExprName: "a", Expr: parser.MustParseExpr("d")})).Pipe(st.Sort(parser.MustParseExpr("a"))),
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			db, tx, cleanup := testutil.NewTestTx(t)
+			defer cleanup()
+
+			testutil.MustExec(t, db, tx, `
+				CREATE TABLE foo (k INTEGER PRIMARY KEY);
+				CREATE INDEX idx_foo_a ON foo(a);
+				CREATE INDEX idx_foo_b_c ON foo(b, c);
+				CREATE INDEX idx_foo_x_y ON foo(x DESC, y);
+				CREATE INDEX idx_foo_z ON foo(z NULLS LAST);
+			`)
+
+			res, err := planner.UseIndexForOrderByRule(test.root, planner.TxCatalog(db.Catalog, tx))
+			require.NoError(t, err)
+			require.Equal(t, test.expected.String(), res.String())
+		})
+	}
+}
+
+func TestUseStreamAggregateRule(t *testing.T) {
+	count := &functions.Count{Wildcard: true}
+	project := func() *st.ProjectOperator {
+		return st.Project(
+			&expr.NamedExpr{ExprName: "a", Expr: parser.MustParseExpr("a")},
+			&expr.NamedExpr{ExprName: "COUNT(*)", Expr: count},
+		)
+	}
+
+	tests := []struct {
+		name           string
+		root, expected *st.Stream
+	}{
+		{
+			"unsorted",
+			st.New(st.SeqScan("foo")).Pipe(st.GroupBy(parser.MustParseExpr("a"))).Pipe(st.HashAggregate(count)),
+			st.New(st.SeqScan("foo")).Pipe(st.GroupBy(parser.MustParseExpr("a"))).Pipe(st.HashAggregate(count)),
+		},
+		{
+			"primary key",
+			st.New(st.SeqScan("foo")).Pipe(st.GroupBy(parser.MustParseExpr("k"))).Pipe(st.HashAggregate(count)),
+			st.New(st.SeqScan("foo")).Pipe(st.GroupBy(parser.MustParseExpr("k"))).Pipe(st.StreamAggregate(count)),
+		},
+		{
+			"index scan",
+			st.New(st.IndexScan("idx_foo_a", st.IndexRange{Min: testutil.ExprList(t, `[1]`)})).
+				Pipe(st.Filter(parser.MustParseExpr("d > 1"))).
+				Pipe(st.GroupBy(parser.MustParseExpr("a"))).
+				Pipe(st.HashAggregate(count)),
+			st.New(st.IndexScan("idx_foo_a", st.IndexRange{Min: testutil.ExprList(t, `[1]`)})).
+				Pipe(st.Filter(parser.MustParseExpr("d > 1"))).
+				Pipe(st.GroupBy(parser.MustParseExpr("a"))).
+				Pipe(st.StreamAggregate(count)),
+		},
+		{
+			"path after an exact prefix",
+			st.New(st.IndexScan("idx_foo_b_c", st.IndexRange{Min: testutil.ExprList(t, `[1]`), Exact: true})).Pipe(st.GroupBy(parser.MustParseExpr("c"))).Pipe(st.HashAggregate(count)),
+			st.New(st.IndexScan("idx_foo_b_c", st.IndexRange{Min: testutil.ExprList(t, `[1]`), Exact: true})).Pipe(st.GroupBy(parser.MustParseExpr("c"))).Pipe(st.StreamAggregate(count)),
+		},
+		{
+			"sorted groups",
+			st.New(st.SeqScan("foo")).Pipe(st.GroupBy(parser.MustParseExpr("a"))).Pipe(st.HashAggregate(count)).Pipe(project()).Pipe(st.SortReverse(parser.MustParseExpr("a"))),
+			st.New(st.IndexScanReverse("idx_foo_a")).Pipe(st.GroupBy(parser.MustParseExpr("a"))).Pipe(st.StreamAggregate(count)).Pipe(project()),
+		},
+		{
+			"groups sorted by another path",
+			st.New(st.SeqScan("foo")).Pipe(st.GroupBy(parser.MustParseExpr("k"))).Pipe(st.HashAggregate(count)).Pipe(st.Sort(parser.MustParseExpr("a"))),
+			st.New(st.SeqScan("foo")).Pipe(st.GroupBy(parser.MustParseExpr("k"))).Pipe(st.StreamAggregate(count)).Pipe(st.Sort(parser.MustParseExpr("a"))),
+		},
+		{
+			"projected alias",
+			st.New(st.SeqScan("foo")).
+				Pipe(st.GroupBy(parser.MustParseExpr("a"))).
+				Pipe(st.HashAggregate(count)).
+				Pipe(st.Project(&expr.NamedExpr{ExprName: "a", Expr: count})).
+				Pipe(st.Sort(parser.MustParseExpr("a"))),
+			st.New(st.SeqScan("foo")).
+				Pipe(st.GroupBy(parser.MustParseExpr("a"))).
+				Pipe(st.HashAggregate(count)).
+				Pipe(st.Project(&expr.NamedExpr{ExprName: "a", Expr: count})).
+				Pipe(st.Sort(parser.MustParseExpr("a"))),
+		},
+		{
+			"expression",
+			st.New(st.SeqScan("foo")).Pipe(st.GroupBy(parser.MustParseExpr("k + 1"))).Pipe(st.HashAggregate(count)),
+			st.New(st.SeqScan("foo")).Pipe(st.GroupBy(parser.MustParseExpr("k + 1"))).Pipe(st.HashAggregate(count)),
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			db, tx, cleanup := testutil.NewTestTx(t)
+			defer cleanup()
+
+			testutil.MustExec(t, db, tx, `
+				CREATE TABLE foo (k INTEGER PRIMARY KEY);
+				CREATE INDEX idx_foo_a ON foo(a);
+				CREATE INDEX idx_foo_b_c ON foo(b, c);
+			`)
+
+			res, err := planner.UseStreamAggregateRule(test.root, planner.TxCatalog(db.Catalog, tx))
+			require.NoError(t, err)
+			require.Equal(t, test.expected.String(), res.String())
+		})
+	}
+}
+
+func TestPushDownLimitRule(t *testing.T) {
+	tests := []struct {
+		name           string
+		root, expected *st.Stream
+	}{
+		{
+			"seq scan",
+			st.New(st.SeqScan("foo")).Pipe(st.Project(parser.MustParseExpr("a"))).Pipe(st.Skip(20)).Pipe(st.Take(10)),
+			st.New(&st.SeqScanOperator{TableName: "foo", Offset: 20, Limit: 10}).Pipe(st.Project(parser.MustParseExpr("a"))),
+		},
+		{
+			"index scan",
+			st.New(st.IndexScan("idx_foo_a", st.IndexRange{Min: exprList(testutil.IntegerValue(1))})).Pipe(st.Take(5)),
+			st.New(&st.IndexScanOperator{IndexName: "idx_foo_a", Ranges: st.IndexRanges{{Min: exprList(testutil.IntegerValue(1))}}, Limit: 5}),
+		},
+		{
+			"filter",
+			st.New(st.SeqScan("foo")).Pipe(st.Filter(parser.MustParseExpr("a > 1"))).Pipe(st.Project(parser.MustParseExpr("a"))).Pipe(st.Take(5)),
+			st.New(st.SeqScan("foo")).Pipe(st.Filter(parser.MustParseExpr("a > 1"))).Pipe(st.Take(5)).Pipe(st.Project(parser.MustParseExpr("a"))),
+		},
+		{
+			"sort",
+			st.New(st.SeqScan("foo")).Pipe(st.Project(parser.MustParseExpr("a"))).Pipe(st.Sort(parser.MustParseExpr("a"))).Pipe(st.Take(5)),
+			st.New(st.SeqScan("foo")).Pipe(st.Project(parser.MustParseExpr("a"))).Pipe(st.Sort(parser.MustParseExpr("a"))).Pipe(st.Take(5)),
+		},
+		{
+			"limit 0",
+			st.New(st.SeqScan("foo")).Pipe(st.Skip(2)).Pipe(st.Take(0)),
+			st.New(&st.SeqScanOperator{TableName: "foo", Offset: 2}).Pipe(st.Take(0)),
+		},
+		{
+			"side effects",
+			st.New(st.SeqScan("foo")).Pipe(st.Project(expr.NextValueFor{SeqName: "seq"})).Pipe(st.Skip(2)),
+			st.New(st.SeqScan("foo")).Pipe(st.Project(expr.NextValueFor{SeqName: "seq"})).Pipe(st.Skip(2)),
+		},
+		{
+			"delete",
+			st.New(st.PkScan("foo", st.ValueRange{Min: testutil.IntegerValue(1)})).Pipe(st.Take(3)).Pipe(st.TableDelete("foo")),
+			st.New(&st.PkScanOperator{TableName: "foo", Ranges: st.ValueRanges{{Min: testutil.IntegerValue(1)}}, Limit: 3}).Pipe(st.TableDelete("foo")),
+		},
+		{
+			"union scan",
+			st.New(st.UnionScan("foo", st.New(st.PkScan("foo")))).Pipe(st.Project(parser.MustParseExpr("a"))).Pipe(st.Take(3)),
+			st.New(st.UnionScan("foo", st.New(st.PkScan("foo")))).Pipe(st.Take(3)).Pipe(st.Project(parser.MustParseExpr("a"))),
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			res, err := planner.PushDownLimitRule(test.root, nil)
+			require.NoError(t, err)
+			require.Equal(t, test.expected.String(), res.String())
+		})
+	}
+}
+
+func TestUseCoveringIndexRule(t *testing.T) {
+	scan := func(name string) *st.IndexScanOperator {
+		return st.IndexScan(name, st.IndexRange{Min: exprList(testutil.IntegerValue(1))})
+	}
+	covering := func(name string) *st.IndexScanOperator {
+		op := scan(name)
+		op.Covering = true
+		return op
+	}
+	count := &functions.Count{Wildcard: true}
+
+	tests := []struct {
+		name           string
+		root, expected *st.Stream
+	}{
+		{
+			"indexed path and primary key",
+			st.New(scan("idx_foo_a")).Pipe(st.Project(parser.MustParseExpr("a"), parser.MustParseExpr("k + 1"))),
+			st.New(covering("idx_foo_a")).Pipe(st.Project(parser.MustParseExpr("a"), parser.MustParseExpr("k + 1"))),
+		},
+		{
+			"filter and limit",
+			st.New(scan("idx_foo_b_c")).Pipe(st.Filter(parser.MustParseExpr("c BETWEEN 1 AND k"))).Pipe(st.Take(2)).Pipe(st.Project(parser.MustParseExpr("b"))),
+			st.New(covering("idx_foo_b_c")).Pipe(st.Filter(parser.MustParseExpr("c BETWEEN 1 AND k"))).Pipe(st.Take(2)).Pipe(st.Project(parser.MustParseExpr("b"))),
+		},
+		{
+			"aggregation",
+			st.New(scan("idx_foo_a")).Pipe(st.GroupBy(parser.MustParseExpr("a"))).Pipe(st.HashAggregate(count)).Pipe(st.Project(parser.MustParseExpr("d"))),
+			st.New(covering("idx_foo_a")).Pipe(st.GroupBy(parser.MustParseExpr("a"))).Pipe(st.HashAggregate(count)).Pipe(st.Project(parser.MustParseExpr("d"))),
+		},
+		{
+			"non-indexed path",
+			st.New(scan("idx_foo_a")).Pipe(st.Filter(parser.MustParseExpr("d > 1"))).Pipe(st.Project(parser.MustParseExpr("a"))),
+			st.New(scan("idx_foo_a")).Pipe(st.Filter(parser.MustParseExpr("d > 1"))).Pipe(st.Project(parser.MustParseExpr("a"))),
+		},
+		{
+			"between non-indexed path",
+			st.New(scan("idx_foo_a")).Pipe(st.Filter(parser.MustParseExpr("d BETWEEN a AND k"))).Pipe(st.Project(parser.MustParseExpr("a"))),
+			st.New(scan("idx_foo_a")).Pipe(st.Filter(parser.MustParseExpr("d BETWEEN a AND k"))).Pipe(st.Project(parser.MustParseExpr("a"))),
+		},
+		{
+			"wildcard",
+			st.New(scan("idx_foo_a")).Pipe(st.Project(expr.Wildcard{})),
+			st.New(scan("idx_foo_a")).Pipe(st.Project(expr.Wildcard{})),
+		},
+		{
+			"no projection",
+			st.New(scan("idx_foo_a")).Pipe(st.TableDelete("foo")),
+			st.New(scan("idx_foo_a")).Pipe(st.TableDelete("foo")),
+		},
+		{
+			"text before the last value",
+			st.New(scan("idx_foo_e_a")).Pipe(st.Project(parser.MustParseExpr("a"))),
+			st.New(scan("idx_foo_e_a")).Pipe(st.Project(parser.MustParseExpr("a"))),
+		},
+		{
+			"decimal",
+			st.New(scan("idx_foo_f")).Pipe(st.Project(parser.MustParseExpr("f"))),
+			st.New(scan("idx_foo_f")).Pipe(st.Project(parser.MustParseExpr("f"))),
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			db, tx, cleanup := testutil.NewTestTx(t)
+			defer cleanup()
+
+			testutil.MustExec(t, db, tx, `
+				CREATE TABLE foo (k INTEGER PRIMARY KEY, e TEXT, f DECIMAL);
+				CREATE INDEX idx_foo_a ON foo(a);
+				CREATE INDEX idx_foo_b_c ON foo(b, c);
+				CREATE INDEX idx_foo_e_a ON foo(e, a);
+				CREATE INDEX idx_foo_f ON foo(f);
+			`)
+
+			res, err := planner.UseCoveringIndexRule(test.root, planner.TxCatalog(db.Catalog, tx))
+			require.NoError(t, err)
+			require.Equal(t, test.expected.String(), res.String())
+		})
+	}
+
+	t.Run("untyped primary key", func(t *testing.T) {
+		db, tx, cleanup := testutil.NewTestTx(t)
+		defer cleanup()
+
+		testutil.MustExec(t, db, tx, `
+			CREATE TABLE foo (k PRIMARY KEY);
+			CREATE INDEX idx_foo_a ON foo(a);
+		`)
+
+		s := st.New(scan("idx_foo_a")).Pipe(st.Project(parser.MustParseExpr("a")))
+		res, err := planner.UseCoveringIndexRule(s, planner.TxCatalog(db.Catalog, tx))
+		require.NoError(t, err)
+		require.Equal(t, st.New(scan("idx_foo_a")).Pipe(st.Project(parser.MustParseExpr("a"))).String(), res.String())
+	})
+}
+
+func TestPushDownFilterRule(t *testing.T) {
+	indexScan := func(name string, filters ...string) *st.IndexScanOperator {
+		op := st.IndexScan(name, st.IndexRange{Min: exprList(testutil.IntegerValue(1))})
+		for _, f := range filters {
+			op.Filters = append(op.Filters, parser.MustParseExpr(f))
+		}
+		return op
+	}
+	seqScan := func(filters ...string) *st.SeqScanOperator {
+		op := st.SeqScan("foo")
+		for _, f := range filters {
+			op.Filters = append(op.Filters, parser.MustParseExpr(f))
+		}
+		return op
+	}
+	pkScan := func(filters ...string) *st.PkScanOperator {
+		op := st.PkScan("foo", st.ValueRange{Min: testutil.IntegerValue(1)})
+		for _, f := range filters {
+			op.Filters = append(op.Filters, parser.MustParseExpr(f))
+		}
+		return op
+	}
+
+	tests := []struct {
+		name           string
+		root, expected *st.Stream
+	}{
+		{
+			"indexed paths and primary key",
+			st.New(indexScan("idx_foo_b_c")).Pipe(st.Filter(parser.MustParseExpr("c = 2"))).Pipe(st.Filter(parser.MustParseExpr("k % 2 = 0"))).Pipe(st.Project(expr.Wildcard{})),
+			st.New(indexScan("idx_foo_b_c", "c = 2", "k % 2 = 0")).Pipe(st.Project(expr.Wildcard{})),
+		},
+		{
+			"non-indexed path",
+			st.New(indexScan("idx_foo_b_c")).Pipe(st.Filter(parser.MustParseExpr("d = 1"))).Pipe(st.Filter(parser.MustParseExpr("c = 2"))).Pipe(st.Project(expr.Wildcard{})),
+			st.New(indexScan("idx_foo_b_c", "c = 2")).Pipe(st.Filter(parser.MustParseExpr("d = 1"))).Pipe(st.Project(expr.Wildcard{})),
+		},
+		{
+			"filter after another operator",
+			st.New(indexScan("idx_foo_b_c")).Pipe(st.Project(expr.Wildcard{})).Pipe(st.Filter(parser.MustParseExpr("c = 2"))),
+			st.New(indexScan("idx_foo_b_c")).Pipe(st.Project(expr.Wildcard{})).Pipe(st.Filter(parser.MustParseExpr("c = 2"))),
+		},
+		{
+			"text before the last value",
+			st.New(indexScan("idx_foo_e_a")).Pipe(st.Filter(parser.MustParseExpr("a = 2"))),
+			st.New(indexScan("idx_foo_e_a")).Pipe(st.Filter(parser.MustParseExpr("a = 2"))),
+		},
+		{
+			"seq scan",
+			st.New(seqScan()).Pipe(st.Filter(parser.MustParseExpr("k % 2 = 0"))).Pipe(st.Filter(parser.MustParseExpr("a = 1"))),
+			st.New(seqScan("k % 2 = 0")).Pipe(st.Filter(parser.MustParseExpr("a = 1"))),
+		},
+		{
+			"pk scan",
+			st.New(pkScan()).Pipe(st.Filter(parser.MustParseExpr("k != 3"))),
+			st.New(pkScan("k != 3")),
+		},
+		{
+			"side effects",
+			st.New(seqScan()).Pipe(st.Filter(parser.MustParseExpr("k > NEXT VALUE FOR seq"))),
+			st.New(seqScan()).Pipe(st.Filter(parser.MustParseExpr("k > NEXT VALUE FOR seq"))),
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			db, tx, cleanup := testutil.NewTestTx(t)
+			defer cleanup()
+
+			testutil.MustExec(t, db, tx, `
+				CREATE TABLE foo (k INTEGER PRIMARY KEY, e TEXT);
+				CREATE INDEX idx_foo_b_c ON foo(b, c);
+				CREATE INDEX idx_foo_e_a ON foo(e, a);
+			`)
+
+			res, err := planner.PushDownFilterRule(test.root, planner.TxCatalog(db.Catalog, tx))
+			require.NoError(t, err)
+			require.Equal(t, test.expected.String(), res.String())
+		})
+	}
+
+	t.Run("untyped primary key", func(t *testing.T) {
+		db, tx, cleanup := testutil.NewTestTx(t)
+		defer cleanup()
+
+		testutil.MustExec(t, db, tx, `
+			CREATE TABLE foo (k PRIMARY KEY);
+			CREATE INDEX idx_foo_b_c ON foo(b, c);
+		`)
+
+		s := st.New(indexScan("idx_foo_b_c")).Pipe(st.Filter(parser.MustParseExpr("c = 2")))
+		res, err := planner.PushDownFilterRule(s, planner.TxCatalog(db.Catalog, tx))
+		require.NoError(t, err)
+		require.Equal(t, st.New(indexScan("idx_foo_b_c")).Pipe(st.Filter(parser.MustParseExpr("c = 2"))).String(), res.String())
+	})
+}
+
+func TestUsePartialDecodingRule(t *testing.T) {
+	withPaths := func(fields ...string) *st.SeqScanOperator {
+		op := st.SeqScan("foo")
+		for _, f := range fields {
+			op.Paths = append(op.Paths, document.NewPath(f))
+		}
+		return op
+	}
+	covering := st.IndexScan("idx_foo_a")
+	covering.Covering = true
+
+	tests := []struct {
+		name           string
+		root, expected *st.Stream
+	}{
+		{
+			"filter and projection",
+			st.New(st.SeqScan("foo")).Pipe(st.Filter(parser.MustParseExpr("a > 1"))).Pipe(st.Project(parser.MustParseExpr("b"), parser.MustParseExpr("c.d + a"))),
+			st.New(withPaths("a", "b", "c")).Pipe(st.Filter(parser.MustParseExpr("a > 1"))).Pipe(st.Project(parser.MustParseExpr("b"), parser.MustParseExpr("c.d + a"))),
+		},
+		{
+			"sort after the projection",
+			st.New(st.SeqScan("foo")).Pipe(st.Project(parser.MustParseExpr("a"))).Pipe(st.Sort(parser.MustParseExpr("b"))),
+			st.New(withPaths("a", "b")).Pipe(st.Project(parser.MustParseExpr("a"))).Pipe(st.Sort(parser.MustParseExpr("b"))),
+		},
+		{
+			"aggregation",
+			st.New(st.SeqScan("foo")).Pipe(st.GroupBy(parser.MustParseExpr("a"))).Pipe(st.HashAggregate(&functions.Sum{Expr: parser.MustParseExpr("b")})).Pipe(st.Project(parser.MustParseExpr("a"))),
+			st.New(withPaths("a", "b")).Pipe(st.GroupBy(parser.MustParseExpr("a"))).Pipe(st.HashAggregate(&functions.Sum{Expr: parser.MustParseExpr("b")})).Pipe(st.Project(parser.MustParseExpr("a"))),
+		},
+		{
+			"between",
+			st.New(st.SeqScan("foo")).Pipe(st.Filter(parser.MustParseExpr("a BETWEEN b AND 10"))).Pipe(st.Project(parser.MustParseExpr("c"))),
+			st.New(withPaths("a", "b", "c")).Pipe(st.Filter(parser.MustParseExpr("a BETWEEN b AND 10"))).Pipe(st.Project(parser.MustParseExpr("c"))),
+		},
+		{
+			"wildcard",
+			st.New(st.SeqScan("foo")).Pipe(st.Project(expr.Wildcard{})),
+			st.New(st.SeqScan("foo")).Pipe(st.Project(expr.Wildcard{})),
+		},
+		{
+			"no projection",
+			st.New(st.SeqScan("foo")).Pipe(st.Filter(parser.MustParseExpr("a > 1"))),
+			st.New(st.SeqScan("foo")).Pipe(st.Filter(parser.MustParseExpr("a > 1"))),
+		},
+		{
+			"unknown operator",
+			st.New(st.SeqScan("foo")).Pipe(st.Project(parser.MustParseExpr("a"))).Pipe(st.TableInsert("bar", nil)),
+			st.New(st.SeqScan("foo")).Pipe(st.Project(parser.MustParseExpr("a"))).Pipe(st.TableInsert("bar", nil)),
+		},
+		{
+			"covering index",
+			st.New(covering).Pipe(st.Project(parser.MustParseExpr("a"))),
+			st.New(covering).Pipe(st.Project(parser.MustParseExpr("a"))),
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			res, err := planner.UsePartialDecodingRule(test.root, nil)
+			require.NoError(t, err)
+			require.Equal(t, test.expected.String(), res.String())
+		})
+	}
+}
+
+func TestUseBatchExecutionRule(t *testing.T) {
+	sum := func(e string) *functions.Sum { return &functions.Sum{Expr: parser.MustParseExpr(e)} }
+
+	tests := []struct {
+		name           string
+		root, expected *st.Stream
+	}{
+		{
+			"filters and aggregation",
+			st.New(st.SeqScan("foo")).Pipe(st.Filter(parser.MustParseExpr("a > 1"))).Pipe(st.Filter(parser.MustParseExpr("10 >= b"))).Pipe(st.HashAggregate(sum("c"), &functions.Count{Wildcard: true})).Pipe(st.Project(parser.MustParseExpr("SUM(c)"))),
+			st.New(st.Batch(st.New(st.SeqScan("foo")).Pipe(st.Filter(parser.MustParseExpr("a > 1"))).Pipe(st.Filter(parser.MustParseExpr("10 >= b"))).Pipe(st.HashAggregate(sum("c"), &functions.Count{Wildcard: true})))).Pipe(st.Project(parser.MustParseExpr("SUM(c)"))),
+		},
+		{
+			"group by",
+			st.New(st.SeqScan("foo")).Pipe(st.GroupBy(parser.MustParseExpr("a"))).Pipe(st.HashAggregate(&functions.Avg{Expr: parser.MustParseExpr("b")})).Pipe(st.Project(parser.MustParseExpr("a"))),
+			st.New(st.Batch(st.New(st.SeqScan("foo")).Pipe(st.GroupBy(parser.MustParseExpr("a"))).Pipe(st.HashAggregate(&functions.Avg{Expr: parser.MustParseExpr("b")})))).Pipe(st.Project(parser.MustParseExpr("a"))),
+		},
+		{
+			"params",
+			st.New(st.SeqScan("foo")).Pipe(st.Filter(parser.MustParseExpr("a = ? AND b != ?"))).Pipe(st.HashAggregate(&functions.Max{Expr: parser.MustParseExpr("c")})),
+			st.New(st.Batch(st.New(st.SeqScan("foo")).Pipe(st.Filter(parser.MustParseExpr("a = ? AND b != ?"))).Pipe(st.HashAggregate(&functions.Max{Expr: parser.MustParseExpr("c")})))),
+		},
+		{
+			"no aggregation",
+			st.New(st.SeqScan("foo")).Pipe(st.Filter(parser.MustParseExpr("a > 1"))).Pipe(st.Project(parser.MustParseExpr("a"))),
+			st.New(st.SeqScan("foo")).Pipe(st.Filter(parser.MustParseExpr("a > 1"))).Pipe(st.Project(parser.MustParseExpr("a"))),
+		},
+		{
+			"non constant filter",
+			st.New(st.SeqScan("foo")).Pipe(st.Filter(parser.MustParseExpr("a > b"))).Pipe(st.HashAggregate(sum("c"))),
+			st.New(st.SeqScan("foo")).Pipe(st.Filter(parser.MustParseExpr("a > b"))).Pipe(st.HashAggregate(sum("c"))),
+		},
+		{
+			"nested field",
+			st.New(st.SeqScan("foo")).Pipe(st.HashAggregate(sum("c.d"))),
+			st.New(st.SeqScan("foo")).Pipe(st.HashAggregate(sum("c.d"))),
+		},
+		{
+			"distinct",
+			st.New(st.SeqScan("foo")).Pipe(st.HashAggregate(&functions.Count{Expr: parser.MustParseExpr("a"), Distinct: true})),
+			st.New(st.SeqScan("foo")).Pipe(st.HashAggregate(&functions.Count{Expr: parser.MustParseExpr("a"), Distinct: true})),
+		},
+		{
+			"sorted groups",
+			st.New(st.SeqScan("foo")).Pipe(st.GroupBy(parser.MustParseExpr("a"))).Pipe(st.StreamAggregate(sum("b"))),
+			st.New(st.SeqScan("foo")).Pipe(st.GroupBy(parser.MustParseExpr("a"))).Pipe(st.StreamAggregate(sum("b"))),
+		},
+		{
+			"index scan",
+			st.New(st.IndexScan("idx_foo_a")).Pipe(st.HashAggregate(sum("b"))),
+			st.New(st.IndexScan("idx_foo_a")).Pipe(st.HashAggregate(sum("b"))),
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			res, err := planner.UseBatchExecutionRule(test.root, nil)
+			require.NoError(t, err)
+			require.Equal(t, test.expected.String(), res.String())
+		})
+	}
+}
+
+func TestUseIndexUnionRule(t *testing.T) {
+	tests := []struct {
+		name           string
+		root, expected *st.Stream
+	}{
+		{
+			"indexed paths",
+			st.New(st.SeqScan("foo")).Pipe(st.Filter(parser.MustParseExpr("a = 1 OR b = 2"))),
+			st.New(st.UnionScan("foo",
+				st.New(st.IndexScan("idx_foo_a", st.IndexRange{Min: exprList(testutil.IntegerValue(1)), Exact: true})),
+				st.New(st.IndexScan("idx_foo_b", st.IndexRange{Min: exprList(testutil.IntegerValue(2)), Exact: true})),
+			)),
+		},
+		{
+			"same path",
+			st.New(st.SeqScan("foo")).Pipe(st.Filter(parser.MustParseExpr("a = 1 OR a > 5 OR a = 3"))),
+			st.New(st.UnionScan("foo",
+				st.New(st.IndexScan("idx_foo_a", st.IndexRange{Min: exprList(testutil.IntegerValue(1)), Exact: true})),
+				st.New(st.IndexScan("idx_foo_a", st.IndexRange{Min: exprList(testutil.IntegerValue(5)), Exclusive: true})),
+				st.New(st.IndexScan("idx_foo_a", st.IndexRange{Min: exprList(testutil.IntegerValue(3)), Exact: true})),
+			)),
+		},
+		{
+			"AND operands",
+			st.New(st.SeqScan("foo")).
+				Pipe(st.Filter(parser.MustParseExpr("c > 1"))).
+				Pipe(st.Filter(parser.MustParseExpr("(a = 1 AND d > 2) OR k = 3"))),
+			st.New(st.UnionScan("foo",
+				st.New(st.IndexScan("idx_foo_a", st.IndexRange{Min: exprList(testutil.IntegerValue(1)), Exact: true})).Pipe(st.Filter(parser.MustParseExpr("d > 2"))),
+				st.New(st.PkScan("foo", st.ValueRange{Min: testutil.IntegerValue(3), Exact: true})),
+			)).Pipe(st.Filter(parser.MustParseExpr("c > 1"))),
+		},
+		{
+			"non-indexed path",
+			st.New(st.SeqScan("foo")).Pipe(st.Filter(parser.MustParseExpr("a = 1 OR d = 2"))),
+			st.New(st.SeqScan("foo")).Pipe(st.Filter(parser.MustParseExpr("a = 1 OR d = 2"))),
+		},
+		{
+			"already using an index",
+			st.New(st.IndexScan("idx_foo_a", st.IndexRange{Min: exprList(testutil.IntegerValue(1)), Exact: true})).Pipe(st.Filter(parser.MustParseExpr("b = 1 OR k = 2"))),
+			st.New(st.IndexScan("idx_foo_a", st.IndexRange{Min: exprList(testutil.IntegerValue(1)), Exact: true})).Pipe(st.Filter(parser.MustParseExpr("b = 1 OR k = 2"))),
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			db, tx, cleanup := testutil.NewTestTx(t)
+			defer cleanup()
+
+			testutil.MustExec(t, db, tx, `
+				CREATE TABLE foo (k INTEGER PRIMARY KEY);
+				CREATE INDEX idx_foo_a ON foo(a);
+				CREATE INDEX idx_foo_b ON foo(b);
+			`)
+
+			res, err := planner.UseIndexUnionRule(test.root, planner.TxCatalog(db.Catalog, tx))
+			require.NoError(t, err)
+			require.Equal(t, test.expected.String(), res.String())
+		})
+	}
+}
+
+func TestUseIndexBasedOnJoinRule(t *testing.T) {
+	join := func(right *st.Stream, on string) *st.Stream {
+		return st.New(st.SeqScan("foo")).Pipe(st.Wrap("foo")).Pipe(st.Join("bar", right, parser.MustParseExpr(on)))
+	}
+
+	tests := []struct {
+		name           string
+		root, expected *st.Stream
+	}{
+		{
+			"non-indexed path",
+			join(st.New(st.SeqScan("bar")), "foo.a = bar.d"),
+			join(st.New(st.SeqScan("bar")), "foo.a = bar.d"),
+		},
+		{
+			"primary key",
+			join(st.New(st.SeqScan("bar")), "foo.a = bar.k"),
+			join(st.New(st.PkScan("bar", st.ValueRange{Min: parser.MustParseExpr("foo.a"), Exact: true})), "foo.a = bar.k"),
+		},
+		{
+			"index",
+			join(st.New(st.SeqScan("bar")), "bar.b = foo.a"),
+			join(st.New(st.IndexScan("idx_bar_b", st.IndexRange{Min: testutil.ExprList(t, `[foo.a]`), Exact: true})), "bar.b = foo.a"),
+		},
+		{
+			"unique index first",
+			join(st.New(st.SeqScan("bar")), "bar.b = foo.a AND bar.c = foo.b"),
+			join(st.New(st.IndexScan("idx_bar_c", st.IndexRange{Min: testutil.ExprList(t, `[foo.b]`), Exact: true})), "bar.b = foo.a AND bar.c = foo.b"),
+		},
+		{
+			"both sides on the joined table",
+			join(st.New(st.SeqScan("bar")), "bar.b = bar.c"),
+			join(st.New(st.SeqScan("bar")), "bar.b = bar.c"),
+		},
+		{
+			"not an equality",
+			join(st.New(st.SeqScan("bar")), "bar.k > foo.a"),
+			join(st.New(st.SeqScan("bar")), "bar.k > foo.a"),
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			db, tx, cleanup := testutil.NewTestTx(t)
+			defer cleanup()
+
+			testutil.MustExec(t, db, tx, `
+				CREATE TABLE foo;
+				CREATE TABLE bar (k INTEGER PRIMARY KEY);
+				CREATE INDEX idx_bar_b ON bar(b);
+				CREATE UNIQUE INDEX idx_bar_c ON bar(c);
+			`)
+
+			res, err := planner.UseIndexBasedOnJoinRule(test.root, planner.TxCatalog(db.Catalog, tx))
+			require.NoError(t, err)
+			require.Equal(t, test.expected.String(), res.String())
+		})
+	}
+}
+
+type indexedTable struct{}
+
+func (indexedTable) Iterate(ctx context.Context, fn func(d document.Document) error) error {
+	return nil
+}
+
+func (indexedTable) Indexed(path document.Path) bool {
+	return path.String() == "a"
+}
+
+func (indexedTable) Lookup(ctx context.Context, path document.Path, v document.Value, fn func(d document.Document) error) error {
+	return nil
+}
+
+func TestUseVirtualTableRule(t *testing.T) {
+	tests := []struct {
+		name           string
+		root, expected *st.Stream
+	}{
+		{
+			"non-virtual table",
+			st.New(st.SeqScan("foo")).Pipe(st.Filter(parser.MustParseExpr("a = 1"))),
+			st.New(st.SeqScan("foo")).Pipe(st.Filter(parser.MustParseExpr("a = 1"))),
+		},
+		{
+			"scan",
+			st.New(st.SeqScan("virt")).Pipe(st.Filter(parser.MustParseExpr("b = 1"))),
+			st.New(st.VirtualScan("virt")).Pipe(st.Filter(parser.MustParseExpr("b = 1"))),
+		},
+		{
+			"no filter",
+			st.New(st.SeqScan("virt")),
+			st.New(st.VirtualScan("virt")),
+		},
+		{
+			"lookup",
+			st.New(st.SeqScan("virt")).
+				Pipe(st.Filter(parser.MustParseExpr("b = 1"))).
+				Pipe(st.Filter(parser.MustParseExpr("a = 2"))),
+			st.New(st.VirtualLookup("virt", document.NewPath("a"), parser.MustParseExpr("2"))).
+				Pipe(st.Filter(parser.MustParseExpr("b = 1"))),
+		},
+		{
+			"parameter",
+			st.New(st.SeqScan("virt")).Pipe(st.Filter(parser.MustParseExpr("a = ?"))),
+			st.New(st.VirtualLookup("virt", document.NewPath("a"), parser.MustParseExpr("?"))),
+		},
+		{
+			"non-constant expression",
+			st.New(st.SeqScan("virt")).Pipe(st.Filter(parser.MustParseExpr("a = b"))),
+			st.New(st.VirtualScan("virt")).Pipe(st.Filter(parser.MustParseExpr("a = b"))),
+		},
+		{
+			"other operators",
+			st.New(st.SeqScan("virt")).Pipe(st.Filter(parser.MustParseExpr("a > 1"))),
+			st.New(st.VirtualScan("virt")).Pipe(st.Filter(parser.MustParseExpr("a > 1"))),
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			db, tx, cleanup := testutil.NewTestTx(t)
+			defer cleanup()
+
+			testutil.MustExec(t, db, tx, `CREATE TABLE foo`)
+			err := db.Catalog.RegisterVirtualTable("virt", indexedTable{})
+			require.NoError(t, err)
 
-			res, err := planner.RemoveUnnecessaryDistinctNodeRule(test.root, db.Catalog)
+			res, err := planner.UseVirtualTableRule(test.root, planner.TxCatalog(db.Catalog, tx))
 			require.NoError(t, err)
 			require.Equal(t, test.expected.String(), res.String())
 		})
@@ -302,6 +1187,11 @@ func TestUseIndexBasedOnSelectionNodeRule_Simple(t *testing.T) {
 			st.New(st.SeqScan("foo")).Pipe(st.Filter(parser.MustParseExpr("a = 1"))),
 			st.New(st.IndexScan("idx_foo_a", st.IndexRange{Min: exprList(testutil.IntegerValue(1)), Exact: true})),
 		},
+		{
+			"FROM foo WHERE a = (SELECT b FROM bar)",
+			st.New(st.SeqScan("foo")).Pipe(st.Filter(parser.MustParseExpr("a = (SELECT b FROM bar)"))),
+			st.New(st.SeqScan("foo")).Pipe(st.Filter(parser.MustParseExpr("a = (SELECT b FROM bar)"))),
+		},
 		{
 			"FROM foo WHERE a = 1 AND b = 2",
 			st.New(st.SeqScan("foo")).
@@ -366,6 +1256,21 @@ func TestUseIndexBasedOnSelectionNodeRule_Simple(t *testing.T) {
 			)),
 			st.New(st.IndexScan("idx_foo_a", st.IndexRange{Min: exprList(testutil.IntegerValue(1)), Exact: true}, st.IndexRange{Min: exprList(testutil.IntegerValue(2)), Exact: true})),
 		},
+		{
+			"FROM foo WHERE a IN ?",
+			st.New(st.SeqScan("foo")).Pipe(st.Filter(parser.MustParseExpr("a IN ?"))),
+			st.New(st.IndexScan("idx_foo_a", st.IndexRange{Min: exprList(expr.PositionalParam(1)), Exact: true, In: true, InPositions: []int{0}})),
+		},
+		{
+			"FROM foo WHERE k IN $ids",
+			st.New(st.SeqScan("foo")).Pipe(st.Filter(parser.MustParseExpr("k IN $ids"))),
+			st.New(st.PkScan("foo", st.ValueRange{Min: expr.NamedParam("ids"), Exact: true, In: true})),
+		},
+		{
+			"FROM foo WHERE a IN b",
+			st.New(st.SeqScan("foo")).Pipe(st.Filter(parser.MustParseExpr("a IN b"))),
+			st.New(st.SeqScan("foo")).Pipe(st.Filter(parser.MustParseExpr("a IN b"))),
+		},
 		{
 			"FROM foo WHERE 1 IN a",
 			st.New(st.SeqScan("foo")).Pipe(st.Filter(parser.MustParseExpr("1 IN a"))),
@@ -436,7 +1341,7 @@ func TestUseIndexBasedOnSelectionNodeRule_Simple(t *testing.T) {
 					(3, 3, 3, 3, 3)
 			`)
 
-			res, err := planner.UseIndexBasedOnFilterNodeRule(test.root, db.Catalog)
+			res, err := planner.UseIndexBasedOnFilterNodeRule(test.root, planner.TxCatalog(db.Catalog, tx))
 			require.NoError(t, err)
 			require.Equal(t, test.expected.String(), res.String())
 		})
@@ -494,10 +1399,10 @@ func TestUseIndexBasedOnSelectionNodeRule_Simple(t *testing.T) {
 						([3, 3], [3, 3], [3, 3])
 				`)
 
-				res, err := planner.PrecalculateExprRule(test.root, db.Catalog)
+				res, err := planner.PrecalculateExprRule(test.root, planner.TxCatalog(db.Catalog, tx))
 				require.NoError(t, err)
 
-				res, err = planner.UseIndexBasedOnFilterNodeRule(res, db.Catalog)
+				res, err = planner.UseIndexBasedOnFilterNodeRule(res, planner.TxCatalog(db.Catalog, tx))
 				require.NoError(t, err)
 				require.Equal(t, test.expected.String(), res.String())
 			})
@@ -522,28 +1427,28 @@ func TestUseIndexBasedOnSelectionNodeRule_Composite(t *testing.T) {
 			st.New(st.SeqScan("foo")).
 				Pipe(st.Filter(parser.MustParseExpr("a = 1"))).
 				Pipe(st.Filter(parser.MustParseExpr("d > 2"))),
-			st.New(st.IndexScan("idx_foo_a_d", st.IndexRange{Min: testutil.ExprList(t, `[1, 2]`), Exclusive: true})),
+			st.New(st.IndexScan("idx_foo_a_d", st.IndexRange{Min: testutil.ExprList(t, `[1, 2]`), Max: testutil.ExprList(t, `[1]`), Exclusive: true})),
 		},
 		{
 			"FROM foo WHERE a = 1 AND d < 2",
 			st.New(st.SeqScan("foo")).
 				Pipe(st.Filter(parser.MustParseExpr("a = 1"))).
 				Pipe(st.Filter(parser.MustParseExpr("d < 2"))),
-			st.New(st.IndexScan("idx_foo_a_d", st.IndexRange{Max: testutil.ExprList(t, `[1, 2]`), Exclusive: true})),
+			st.New(st.IndexScan("idx_foo_a_d", st.IndexRange{Max: testutil.ExprList(t, `[1, 2]`), Min: testutil.ExprList(t, `[1]`), Exclusive: true})),
 		},
 		{
 			"FROM foo WHERE a = 1 AND d <= 2",
 			st.New(st.SeqScan("foo")).
 				Pipe(st.Filter(parser.MustParseExpr("a = 1"))).
 				Pipe(st.Filter(parser.MustParseExpr("d <= 2"))),
-			st.New(st.IndexScan("idx_foo_a_d", st.IndexRange{Max: testutil.ExprList(t, `[1, 2]`)})),
+			st.New(st.IndexScan("idx_foo_a_d", st.IndexRange{Max: testutil.ExprList(t, `[1, 2]`), Min: testutil.ExprList(t, `[1]`)})),
 		},
 		{
 			"FROM foo WHERE a = 1 AND d >= 2",
 			st.New(st.SeqScan("foo")).
 				Pipe(st.Filter(parser.MustParseExpr("a = 1"))).
 				Pipe(st.Filter(parser.MustParseExpr("d >= 2"))),
-			st.New(st.IndexScan("idx_foo_a_d", st.IndexRange{Min: testutil.ExprList(t, `[1, 2]`)})),
+			st.New(st.IndexScan("idx_foo_a_d", st.IndexRange{Min: testutil.ExprList(t, `[1, 2]`), Max: testutil.ExprList(t, `[1]`)})),
 		},
 		{
 			"FROM foo WHERE a > 1 AND d > 2",
@@ -581,14 +1486,14 @@ func TestUseIndexBasedOnSelectionNodeRule_Composite(t *testing.T) {
 			st.New(st.SeqScan("foo")).
 				Pipe(st.Filter(parser.MustParseExpr("a = 1"))).
 				Pipe(st.Filter(parser.MustParseExpr("b > 2"))),
-			st.New(st.IndexScan("idx_foo_a_b_c", st.IndexRange{Min: testutil.ExprList(t, `[1, 2]`), Exclusive: true})),
+			st.New(st.IndexScan("idx_foo_a_b_c", st.IndexRange{Min: testutil.ExprList(t, `[1, 2]`), Max: testutil.ExprList(t, `[1]`), Exclusive: true})),
 		},
 		{
 			"FROM foo WHERE a = 1 AND b < 2", // c is omitted, but it can still use idx_foo_a_b_c, with > b
 			st.New(st.SeqScan("foo")).
 				Pipe(st.Filter(parser.MustParseExpr("a = 1"))).
 				Pipe(st.Filter(parser.MustParseExpr("b < 2"))),
-			st.New(st.IndexScan("idx_foo_a_b_c", st.IndexRange{Max: testutil.ExprList(t, `[1, 2]`), Exclusive: true})),
+			st.New(st.IndexScan("idx_foo_a_b_c", st.IndexRange{Max: testutil.ExprList(t, `[1, 2]`), Min: testutil.ExprList(t, `[1]`), Exclusive: true})),
 		},
 		{
 			"FROM foo WHERE a = 1 AND b = 2 and k = 3", // c is omitted, but it can still use idx_foo_a_b_c
@@ -679,8 +1584,8 @@ func TestUseIndexBasedOnSelectionNodeRule_Composite(t *testing.T) {
 				Pipe(st.Filter(parser.MustParseExpr("b = 3"))).
 				Pipe(st.Filter(parser.MustParseExpr("c > 4"))),
 			st.New(st.IndexScan("idx_foo_a_b_c",
-				st.IndexRange{Min: testutil.ExprList(t, `[1, 3, 4]`), Exclusive: true},
-				st.IndexRange{Min: testutil.ExprList(t, `[2, 3, 4]`), Exclusive: true},
+				st.IndexRange{Min: testutil.ExprList(t, `[1, 3, 4]`), Max: testutil.ExprList(t, `[1, 3]`), Exclusive: true},
+				st.IndexRange{Min: testutil.ExprList(t, `[2, 3, 4]`), Max: testutil.ExprList(t, `[2, 3]`), Exclusive: true},
 			)),
 		},
 		{
@@ -695,33 +1600,49 @@ func TestUseIndexBasedOnSelectionNodeRule_Composite(t *testing.T) {
 				Pipe(st.Filter(parser.MustParseExpr("b = 3"))).
 				Pipe(st.Filter(parser.MustParseExpr("c < 4"))),
 			st.New(st.IndexScan("idx_foo_a_b_c",
-				st.IndexRange{Max: testutil.ExprList(t, `[1, 3, 4]`), Exclusive: true},
-				st.IndexRange{Max: testutil.ExprList(t, `[2, 3, 4]`), Exclusive: true},
+				st.IndexRange{Max: testutil.ExprList(t, `[1, 3, 4]`), Min: testutil.ExprList(t, `[1, 3]`), Exclusive: true},
+				st.IndexRange{Max: testutil.ExprList(t, `[2, 3, 4]`), Min: testutil.ExprList(t, `[2, 3]`), Exclusive: true},
+			)),
+		},
+		{
+			"FROM foo WHERE a IN [1, 2] AND b IN [3, 4] AND c > 5",
+			st.New(st.SeqScan("foo")).
+				Pipe(st.Filter(
+					expr.In(
+						parser.MustParseExpr("a"),
+						testutil.ExprList(t, `[1, 2]`),
+					),
+				)).
+				Pipe(st.Filter(
+					expr.In(
+						parser.MustParseExpr("b"),
+						testutil.ExprList(t, `[3, 4]`),
+					),
+				)).
+				Pipe(st.Filter(parser.MustParseExpr("c > 5"))),
+			st.New(st.IndexScan("idx_foo_a_b_c",
+				st.IndexRange{Min: testutil.ExprList(t, `[1, 3, 5]`), Max: testutil.ExprList(t, `[1, 3]`), Exclusive: true},
+				st.IndexRange{Min: testutil.ExprList(t, `[1, 4, 5]`), Max: testutil.ExprList(t, `[1, 4]`), Exclusive: true},
+				st.IndexRange{Min: testutil.ExprList(t, `[2, 3, 5]`), Max: testutil.ExprList(t, `[2, 3]`), Exclusive: true},
+				st.IndexRange{Min: testutil.ExprList(t, `[2, 4, 5]`), Max: testutil.ExprList(t, `[2, 4]`), Exclusive: true},
+			)),
+		},
+		{
+			"FROM foo WHERE a IN ? AND b IN [3, 4] AND c IN ?",
+			st.New(st.SeqScan("foo")).
+				Pipe(st.Filter(parser.MustParseExpr("a IN ?"))).
+				Pipe(st.Filter(
+					expr.In(
+						parser.MustParseExpr("b"),
+						testutil.ExprList(t, `[3, 4]`),
+					),
+				)).
+				Pipe(st.Filter(parser.MustParseExpr("c IN ?"))),
+			st.New(st.IndexScan("idx_foo_a_b_c",
+				st.IndexRange{Min: exprList(expr.PositionalParam(1), testutil.IntegerValue(3), expr.PositionalParam(2)), Exact: true, In: true, InPositions: []int{0, 2}},
+				st.IndexRange{Min: exprList(expr.PositionalParam(1), testutil.IntegerValue(4), expr.PositionalParam(2)), Exact: true, In: true, InPositions: []int{0, 2}},
 			)),
 		},
-		// {
-		// 	"FROM foo WHERE a IN [1, 2] AND b IN [3, 4] AND c > 5",
-		// 	st.New(st.SeqScan("foo")).
-		// 		Pipe(st.Filter(
-		// 			expr.In(
-		// 				parser.MustParseExpr("a"),
-		// 				testutil.ArrayValue(document.NewValueBuffer(document.NewIntegerValue(1), document.NewIntegerValue(2))),
-		// 			),
-		// 		)).
-		// 		Pipe(st.Filter(
-		// 			expr.In(
-		// 				parser.MustParseExpr("b"),
-		// 				testutil.ArrayValue(document.NewValueBuffer(document.NewIntegerValue(3), document.NewIntegerValue(4))),
-		// 			),
-		// 		)).
-		// 		Pipe(st.Filter(parser.MustParseExpr("c < 5"))),
-		// 	st.New(st.IndexScan("idx_foo_a_b_c",
-		// 		st.IndexRange{Max: testutil.ExprList(t, `[1, 3, 5]`), Exclusive: true},
-		// 		st.IndexRange{Max: testutil.ExprList(t, `[2, 3, 5]`), Exclusive: true},
-		// 		st.IndexRange{Max: testutil.ExprList(t, `[1, 4, 5]`), Exclusive: true},
-		// 		st.IndexRange{Max: testutil.ExprList(t, `[2, 4, 5]`), Exclusive: true},
-		// 	)),
-		// },
 		{
 			"FROM foo WHERE 1 IN a AND d = 2",
 			st.New(st.SeqScan("foo")).
@@ -752,7 +1673,7 @@ func TestUseIndexBasedOnSelectionNodeRule_Composite(t *testing.T) {
 					(3, 3, 3, 3, 3)
 			`)
 
-			res, err := planner.UseIndexBasedOnFilterNodeRule(test.root, db.Catalog)
+			res, err := planner.UseIndexBasedOnFilterNodeRule(test.root, planner.TxCatalog(db.Catalog, tx))
 			require.NoError(t, err)
 			require.Equal(t, test.expected.String(), res.String())
 		})
@@ -779,6 +1700,7 @@ func TestUseIndexBasedOnSelectionNodeRule_Composite(t *testing.T) {
 					Pipe(st.Filter(parser.MustParseExpr("b > [2, 2]"))),
 				st.New(st.IndexScan("idx_foo_a_b", st.IndexRange{
 					Min:       testutil.ExprList(t, `[[1, 1], [2, 2]]`),
+					Max:       testutil.ExprList(t, `[[1, 1]]`),
 					Exclusive: true})),
 			},
 		}
@@ -801,10 +1723,10 @@ func TestUseIndexBasedOnSelectionNodeRule_Composite(t *testing.T) {
 							([3, 3], [3, 3], [3, 3])
 	`)
 
-				res, err := planner.PrecalculateExprRule(test.root, db.Catalog)
+				res, err := planner.PrecalculateExprRule(test.root, planner.TxCatalog(db.Catalog, tx))
 				require.NoError(t, err)
 
-				res, err = planner.UseIndexBasedOnFilterNodeRule(res, db.Catalog)
+				res, err = planner.UseIndexBasedOnFilterNodeRule(res, planner.TxCatalog(db.Catalog, tx))
 				require.NoError(t, err)
 				require.Equal(t, test.expected.String(), res.String())
 			})
@@ -812,6 +1734,427 @@ func TestUseIndexBasedOnSelectionNodeRule_Composite(t *testing.T) {
 	})
 }
 
+func TestUseIndexBasedOnFilterNodeRule_Statistics(t *testing.T) {
+	tests := []struct {
+		name           string
+		analyze        bool
+		root, expected *st.Stream
+	}{
+		{
+			"not analyzed",
+			false,
+			st.New(st.SeqScan("foo")).Pipe(st.Filter(parser.MustParseExpr("a = 1"))),
+			st.New(st.IndexScan("idx_foo_a", st.IndexRange{Min: exprList(testutil.IntegerValue(1)), Exact: true})),
+		},
+		{
+			"FROM foo WHERE a = 1",
+			true,
+			st.New(st.SeqScan("foo")).Pipe(st.Filter(parser.MustParseExpr("a = 1"))),
+			st.New(st.SeqScan("foo")).Pipe(st.Filter(parser.MustParseExpr("a = 1"))),
+		},
+		{
+			"FROM foo WHERE b = 5",
+			true,
+			st.New(st.SeqScan("foo")).Pipe(st.Filter(parser.MustParseExpr("b = 5"))),
+			st.New(st.IndexScan("idx_foo_b", st.IndexRange{Min: exprList(testutil.IntegerValue(5)), Exact: true})),
+		},
+		{
+			"FROM foo WHERE b = ?",
+			true,
+			st.New(st.SeqScan("foo")).Pipe(st.Filter(parser.MustParseExpr("b = ?"))),
+			st.New(st.IndexScan("idx_foo_b", st.IndexRange{Min: exprList(expr.PositionalParam(1)), Exact: true})),
+		},
+		{
+			"FROM foo WHERE a = 1 AND b = 5",
+			true,
+			st.New(st.SeqScan("foo")).
+				Pipe(st.Filter(parser.MustParseExpr("a = 1"))).
+				Pipe(st.Filter(parser.MustParseExpr("b = 5"))),
+			st.New(st.IndexScan("idx_foo_b", st.IndexRange{Min: exprList(testutil.IntegerValue(5)), Exact: true})).
+				Pipe(st.Filter(parser.MustParseExpr("a = 1"))),
+		},
+		{
+			"FROM foo WHERE b > 95",
+			true,
+			st.New(st.SeqScan("foo")).Pipe(st.Filter(parser.MustParseExpr("b > 95"))),
+			st.New(st.IndexScan("idx_foo_b", st.IndexRange{Min: exprList(testutil.IntegerValue(95)), Exclusive: true})),
+		},
+		{
+			"FROM foo WHERE b > 5",
+			true,
+			st.New(st.SeqScan("foo")).Pipe(st.Filter(parser.MustParseExpr("b > 5"))),
+			st.New(st.SeqScan("foo")).Pipe(st.Filter(parser.MustParseExpr("b > 5"))),
+		},
+		{
+			"FROM foo WHERE k = 5",
+			true,
+			st.New(st.SeqScan("foo")).Pipe(st.Filter(parser.MustParseExpr("k = 5"))),
+			st.New(st.PkScan("foo", st.ValueRange{Min: testutil.IntegerValue(5), Exact: true})),
+		},
+		{
+			"FROM foo WHERE b = 5 OR k = 7",
+			true,
+			st.New(st.SeqScan("foo")).Pipe(st.Filter(parser.MustParseExpr("b = 5 OR k = 7"))),
+			st.New(st.UnionScan("foo",
+				st.New(st.IndexScan("idx_foo_b", st.IndexRange{Min: exprList(testutil.IntegerValue(5)), Exact: true})),
+				st.New(st.PkScan("foo", st.ValueRange{Min: testutil.IntegerValue(7), Exact: true})),
+			)),
+		},
+		{
+			"FROM foo WHERE b > 70 OR b < 40",
+			true,
+			st.New(st.SeqScan("foo")).Pipe(st.Filter(parser.MustParseExpr("b > 70 OR b < 40"))),
+			st.New(st.SeqScan("foo")).Pipe(st.Filter(parser.MustParseExpr("b > 70 OR b < 40"))),
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			db, tx, cleanup := testutil.NewTestTx(t)
+			defer cleanup()
+
+			testutil.MustExec(t, db, tx, `
+				CREATE TABLE foo (k INT PRIMARY KEY, a INT, b INT);
+				CREATE INDEX idx_foo_a ON foo(a);
+				CREATE INDEX idx_foo_b ON foo(b);
+			`)
+			for i := 0; i < 100; i++ {
+				testutil.MustExec(t, db, tx, "INSERT INTO foo (k, a, b) VALUES (?, ?, ?)",
+					environment.Param{Value: i}, environment.Param{Value: i % 2}, environment.Param{Value: i})
+			}
+			if test.analyze {
+				testutil.MustExec(t, db, tx, "ANALYZE foo")
+			}
+
+			res, err := planner.Optimize(test.root, db.Catalog, tx)
+			require.NoError(t, err)
+			require.Equal(t, test.expected.String(), res.String())
+		})
+	}
+}
+
+func TestPartialIndexes(t *testing.T) {
+	tests := []struct {
+		name      string
+		root      *st.Stream
+		usesIndex bool
+	}{
+		{"same predicate", st.New(st.SeqScan("foo")).Pipe(st.Filter(parser.MustParseExpr("a = 1 AND b > 10 AND c IS NOT NULL"))), true},
+		{"split filters", st.New(st.SeqScan("foo")).
+			Pipe(st.Filter(parser.MustParseExpr("a = 1"))).
+			Pipe(st.Filter(parser.MustParseExpr("c IS NOT NULL"))).
+			Pipe(st.Filter(parser.MustParseExpr("b > 10"))), true},
+		{"narrower range", st.New(st.SeqScan("foo")).Pipe(st.Filter(parser.MustParseExpr("a = 1 AND b >= 20 AND c = 'x'"))), true},
+		{"flipped comparison", st.New(st.SeqScan("foo")).Pipe(st.Filter(parser.MustParseExpr("a = 1 AND 15 < b AND c < 3"))), true},
+		{"equality", st.New(st.SeqScan("foo")).Pipe(st.Filter(parser.MustParseExpr("a = 1 AND b = 11.5 AND c IN (1, 2)"))), true},
+		{"wider range", st.New(st.SeqScan("foo")).Pipe(st.Filter(parser.MustParseExpr("a = 1 AND b >= 10 AND c = 'x'"))), false},
+		{"missing term", st.New(st.SeqScan("foo")).Pipe(st.Filter(parser.MustParseExpr("a = 1 AND b > 20"))), false},
+		{"different types", st.New(st.SeqScan("foo")).Pipe(st.Filter(parser.MustParseExpr("a = 1 AND b > 'x' AND c = 1"))), false},
+		{"no filter", st.New(st.SeqScan("foo")).Pipe(st.Filter(parser.MustParseExpr("a = 1"))), false},
+		{"disjunction", st.New(st.SeqScan("foo")).Pipe(st.Filter(parser.MustParseExpr("a = 1 AND (b > 10 OR c IS NOT NULL)"))), false},
+		{"order by", st.New(st.SeqScan("foo")).
+			Pipe(st.Filter(parser.MustParseExpr("b > 10 AND c = 1"))).
+			Pipe(st.Sort(parser.MustParseExpr("a"))), true},
+		{"order by without filter", st.New(st.SeqScan("foo")).Pipe(st.Sort(parser.MustParseExpr("a"))), false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			db, tx, cleanup := testutil.NewTestTx(t)
+			defer cleanup()
+
+			testutil.MustExec(t, db, tx, `
+				CREATE TABLE foo (k INTEGER PRIMARY KEY);
+				CREATE INDEX idx_foo_a ON foo(a) WHERE b > 10 AND c IS NOT NULL;
+			`)
+
+			res, err := planner.Optimize(test.root, db.Catalog, tx)
+			require.NoError(t, err)
+			require.Equal(t, test.usesIndex, strings.Contains(res.String(), "idx_foo_a"), res.String())
+		})
+	}
+}
+
+func TestMultiKeyIndexes(t *testing.T) {
+	tests := []struct {
+		name      string
+		root      *st.Stream
+		usesIndex bool
+	}{
+		{"IN", st.New(st.SeqScan("foo")).Pipe(st.Filter(parser.MustParseExpr("a = 1 AND 3 IN tags"))), true},
+		{"CONTAINS", st.New(st.SeqScan("foo")).Pipe(st.Filter(parser.MustParseExpr("a = 1 AND tags CONTAINS 'x'"))), true},
+		{"param", st.New(st.SeqScan("foo")).Pipe(st.Filter(parser.MustParseExpr("a = 1 AND tags CONTAINS ?"))), true},
+		{"prefix only", st.New(st.SeqScan("foo")).Pipe(st.Filter(parser.MustParseExpr("a = 1"))), false},
+		{"whole array", st.New(st.SeqScan("foo")).Pipe(st.Filter(parser.MustParseExpr("a = 1 AND tags = [3]"))), false},
+		{"NULL element", st.New(st.SeqScan("foo")).Pipe(st.Filter(parser.MustParseExpr("a = 1 AND NULL IN tags"))), false},
+		{"IN list", st.New(st.SeqScan("foo")).Pipe(st.Filter(parser.MustParseExpr("a = 1 AND tags IN ([1], [2])"))), false},
+		{"order by", st.New(st.SeqScan("foo")).Pipe(st.Sort(parser.MustParseExpr("a"))), false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			db, tx, cleanup := testutil.NewTestTx(t)
+			defer cleanup()
+
+			testutil.MustExec(t, db, tx, `
+				CREATE TABLE foo (k INTEGER PRIMARY KEY);
+				CREATE INDEX idx_foo_a_tags ON foo(a, tags[*]);
+			`)
+
+			res, err := planner.Optimize(test.root, db.Catalog, tx)
+			require.NoError(t, err)
+			require.Equal(t, test.usesIndex, strings.Contains(res.String(), "idx_foo_a_tags"), res.String())
+		})
+	}
+}
+
+func TestIndexesBeingBuilt(t *testing.T) {
+	tests := []struct {
+		name string
+		root *st.Stream
+	}{
+		{"filter", st.New(st.SeqScan("foo")).Pipe(st.Filter(parser.MustParseExpr("a = 1")))},
+		{"order by", st.New(st.SeqScan("foo")).Pipe(st.Sort(parser.MustParseExpr("a")))},
+		{"distinct", st.New(st.SeqScan("foo")).Pipe(st.Project(parser.MustParseExpr("a"))).Pipe(st.Distinct())},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			db, tx, cleanup := testutil.NewTestTx(t)
+			defer cleanup()
+
+			testutil.MustExec(t, db, tx, `
+				CREATE TABLE foo (k INTEGER PRIMARY KEY);
+			`)
+			err := db.Catalog.CreateIndex(tx, &database.IndexInfo{
+				IndexName: "idx_foo_a", TableName: "foo", Paths: []document.Path{document.NewPath("a")},
+				Unique: true, Building: true,
+			})
+			require.NoError(t, err)
+
+			res, err := planner.Optimize(test.root, db.Catalog, tx)
+			require.NoError(t, err)
+			require.NotContains(t, res.String(), "idx_foo_a")
+			require.Equal(t, test.name == "distinct", strings.Contains(res.String(), "distinct"), res.String())
+		})
+	}
+}
+
+func TestIndexHints(t *testing.T) {
+	hinted := func(ignore bool, indexes ...string) *st.SeqScanOperator {
+		return &st.SeqScanOperator{TableName: "foo", IndexHint: &st.IndexHint{Ignore: ignore, Indexes: indexes}}
+	}
+
+	tests := []struct {
+		name           string
+		root, expected *st.Stream
+		fails          bool
+	}{
+		{
+			"USE INDEX cheaper to scan",
+			st.New(hinted(false, "idx_foo_a")).Pipe(st.Filter(parser.MustParseExpr("a = 1"))),
+			st.New(st.IndexScan("idx_foo_a", st.IndexRange{Min: exprList(testutil.IntegerValue(1)), Exact: true})),
+			false,
+		},
+		{
+			"USE INDEX over a better index",
+			st.New(hinted(false, "idx_foo_a")).
+				Pipe(st.Filter(parser.MustParseExpr("a = 1"))).
+				Pipe(st.Filter(parser.MustParseExpr("b = 5"))),
+			st.New(st.IndexScan("idx_foo_a", st.IndexRange{Min: exprList(testutil.IntegerValue(1)), Exact: true})).
+				Pipe(st.Filter(parser.MustParseExpr("b = 5"))),
+			false,
+		},
+		{
+			"USE INDEX with the primary key",
+			st.New(hinted(false, "idx_foo_a")).Pipe(st.Filter(parser.MustParseExpr("k = 5"))),
+			st.New(st.PkScan("foo", st.ValueRange{Min: testutil.IntegerValue(5), Exact: true})),
+			false,
+		},
+		{
+			"USE INDEX ORDER BY another path",
+			st.New(hinted(false, "idx_foo_a")).Pipe(st.Sort(parser.MustParseExpr("b"))),
+			st.New(st.SeqScan("foo")).Pipe(st.Sort(parser.MustParseExpr("b"))),
+			false,
+		},
+		{
+			"IGNORE INDEX",
+			st.New(hinted(true, "idx_foo_b")).Pipe(st.Filter(parser.MustParseExpr("b = 5"))),
+			st.New(st.SeqScan("foo")).Pipe(st.Filter(parser.MustParseExpr("b = 5"))),
+			false,
+		},
+		{
+			"IGNORE INDEX with OR",
+			st.New(hinted(true, "idx_foo_b")).Pipe(st.Filter(parser.MustParseExpr("b = 5 OR k = 7"))),
+			st.New(st.SeqScan("foo")).Pipe(st.Filter(parser.MustParseExpr("b = 5 OR k = 7"))),
+			false,
+		},
+		{
+			"IGNORE INDEX ORDER BY",
+			st.New(hinted(true, "idx_foo_a")).Pipe(st.Sort(parser.MustParseExpr("b"))),
+			st.New(st.IndexScan("idx_foo_b")),
+			false,
+		},
+		{"unknown index", st.New(hinted(false, "idx_foo_c")), nil, true},
+		{"index of another table", st.New(hinted(true, "idx_bar_a")), nil, true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			db, tx, cleanup := testutil.NewTestTx(t)
+			defer cleanup()
+
+			testutil.MustExec(t, db, tx, `
+				CREATE TABLE foo (k INT PRIMARY KEY, a INT, b INT);
+				CREATE INDEX idx_foo_a ON foo(a);
+				CREATE INDEX idx_foo_b ON foo(b);
+				CREATE TABLE bar (a INT);
+				CREATE INDEX idx_bar_a ON bar(a);
+			`)
+			for i := 0; i < 100; i++ {
+				testutil.MustExec(t, db, tx, "INSERT INTO foo (k, a, b) VALUES (?, ?, ?)",
+					environment.Param{Value: i}, environment.Param{Value: i % 2}, environment.Param{Value: i})
+			}
+			testutil.MustExec(t, db, tx, "ANALYZE foo")
+
+			res, err := planner.Optimize(test.root, db.Catalog, tx)
+			if test.fails {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, test.expected.String(), res.String())
+		})
+	}
+}
+
+func TestHiddenDocuments(t *testing.T) {
+	asOf := func() *st.SeqScanOperator {
+		return &st.SeqScanOperator{TableName: "foo", AsOf: parser.MustParseExpr("'-5m'")}
+	}
+
+	tests := []struct {
+		name           string
+		root, expected *st.Stream
+		fails          bool
+	}{
+		{
+			"index",
+			st.New(asOf()).Pipe(st.Filter(parser.MustParseExpr("a = 1"))),
+			st.New(asOf()).Pipe(st.Filter(parser.MustParseExpr("a = 1"))),
+			false,
+		},
+		{
+			"primary key",
+			st.New(asOf()).Pipe(st.Filter(parser.MustParseExpr("k = 5 OR a = 1"))),
+			st.New(asOf()).Pipe(st.Filter(parser.MustParseExpr("k = 5 OR a = 1"))),
+			false,
+		},
+		{
+			"ORDER BY",
+			st.New(asOf()).Pipe(st.Sort(parser.MustParseExpr("a"))),
+			st.New(asOf()).Pipe(st.Sort(parser.MustParseExpr("a"))),
+			false,
+		},
+		{"view", st.New(&st.SeqScanOperator{TableName: "v", AsOf: parser.MustParseExpr("'-5m'")}), nil, true},
+		{
+			"WITH DELETED index",
+			st.New(&st.SeqScanOperator{TableName: "foo", WithDeleted: true}).Pipe(st.Filter(parser.MustParseExpr("a = 1"))),
+			st.New(&st.SeqScanOperator{TableName: "foo", WithDeleted: true}).Pipe(st.Filter(parser.MustParseExpr("a = 1"))),
+			false,
+		},
+		{"WITH DELETED view", st.New(&st.SeqScanOperator{TableName: "v", WithDeleted: true}), nil, true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			db, tx, cleanup := testutil.NewTestTx(t)
+			defer cleanup()
+
+			testutil.MustExec(t, db, tx, `
+				CREATE TABLE foo (k INT PRIMARY KEY, a INT);
+				CREATE INDEX idx_foo_a ON foo(a);
+				CREATE VIEW v AS SELECT * FROM foo;
+			`)
+
+			// indexes and primary key ranges only find the current version of the documents
+			res, err := planner.Optimize(test.root, db.Catalog, tx)
+			if test.fails {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, test.expected.String(), res.String())
+		})
+	}
+}
+
+func TestUseParallelScanRule(t *testing.T) {
+	db, tx, cleanup := testutil.NewTestTx(t)
+	defer cleanup()
+
+	testutil.MustExec(t, db, tx, `
+		CREATE TABLE foo (k INT PRIMARY KEY, a INT);
+		CREATE TABLE bar (k INT PRIMARY KEY, a INT);
+		CREATE SEQUENCE seq;
+		INSERT INTO bar (k, a) VALUES (1, 1), (2, 2);
+	`)
+	// seq scans of tables with at least 10000 documents are run in parallel
+	values := make([]string, 10000)
+	for i := range values {
+		values[i] = fmt.Sprintf("(%d, %d)", i, i%10)
+	}
+	testutil.MustExec(t, db, tx, "INSERT INTO foo (k, a) VALUES "+strings.Join(values, ", "))
+	testutil.MustExec(t, db, tx, "ANALYZE foo; ANALYZE bar")
+
+	// the scans only decode the fields read by the stream
+	paths := []document.Path{document.NewPath("a")}
+
+	tests := []struct {
+		name           string
+		root, expected *st.Stream
+	}{
+		{
+			"filter and projection",
+			st.New(st.SeqScan("foo")).Pipe(st.Filter(parser.MustParseExpr("a > 1"))).Pipe(st.Project(parser.MustParseExpr("a + 1"))),
+			st.New(st.ParallelScan(st.New(&st.SeqScanOperator{TableName: "foo", Paths: paths}).Pipe(st.Filter(parser.MustParseExpr("a > 1"))).Pipe(st.Project(parser.MustParseExpr("a + 1"))))),
+		},
+		{
+			"aggregation",
+			st.New(st.SeqScanReverse("foo")).Pipe(st.Filter(parser.MustParseExpr("a > 1"))).Pipe(st.GroupBy(parser.MustParseExpr("a"))),
+			st.New(st.ParallelScan(st.New(st.SeqScanReverse("foo")).Pipe(st.Filter(parser.MustParseExpr("a > 1"))))).Pipe(st.GroupBy(parser.MustParseExpr("a"))),
+		},
+		{
+			"side effects",
+			st.New(st.SeqScan("foo")).Pipe(st.Filter(parser.MustParseExpr("a > 1"))).Pipe(st.Project(expr.NextValueFor{SeqName: "seq"})),
+			st.New(st.ParallelScan(st.New(&st.SeqScanOperator{TableName: "foo", Paths: paths}).Pipe(st.Filter(parser.MustParseExpr("a > 1"))))).Pipe(st.Project(expr.NextValueFor{SeqName: "seq"})),
+		},
+		{
+			"no filter",
+			st.New(st.SeqScan("foo")).Pipe(st.GroupBy(parser.MustParseExpr("a"))),
+			st.New(st.SeqScan("foo")).Pipe(st.GroupBy(parser.MustParseExpr("a"))),
+		},
+		{
+			"limit",
+			st.New(st.SeqScan("foo")).Pipe(st.Project(parser.MustParseExpr("a"))).Pipe(st.Take(10)),
+			st.New(&st.SeqScanOperator{TableName: "foo", Paths: paths, Limit: 10}).Pipe(st.Project(parser.MustParseExpr("a"))),
+		},
+		{
+			"small table",
+			st.New(st.SeqScan("bar")).Pipe(st.Filter(parser.MustParseExpr("a > 1"))),
+			st.New(st.SeqScan("bar")).Pipe(st.Filter(parser.MustParseExpr("a > 1"))),
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			res, err := planner.Optimize(test.root, db.Catalog, tx)
+			require.NoError(t, err)
+			require.Equal(t, test.expected.String(), res.String())
+		})
+	}
+}
+
 func TestOptimize(t *testing.T) {
 	t.Run("concat operator operands are optimized", func(t *testing.T) {
 		t.Run("PrecalculateExprRule", func(t *testing.T) {
@@ -827,7 +2170,7 @@ func TestOptimize(t *testing.T) {
 					st.New(st.SeqScan("foo")).Pipe(st.Filter(parser.MustParseExpr("a = 1 + 2"))),
 					st.New(st.SeqScan("bar")).Pipe(st.Filter(parser.MustParseExpr("b = 1 + 2"))),
 				)),
-				db.Catalog)
+				db.Catalog, tx)
 
 			want := st.New(st.Concat(
 				st.New(st.SeqScan("foo")).Pipe(st.Filter(parser.MustParseExpr("a = 3"))),
@@ -854,7 +2197,7 @@ func TestOptimize(t *testing.T) {
 						st.New(st.SeqScan("bar")).Pipe(st.Filter(parser.MustParseExpr("12"))),
 					)),
 				)),
-				db.Catalog)
+				db.Catalog, tx)
 
 			want := st.New(st.Concat(
 				st.New(st.SeqScan("foo")),
@@ -885,12 +2228,12 @@ func TestOptimize(t *testing.T) {
 						Pipe(st.Project(parser.MustParseExpr("a"))).
 						Pipe(st.Distinct()),
 				)),
-				db.Catalog)
+				db.Catalog, tx)
 
 			want := st.New(st.Concat(
-				st.New(st.SeqScan("foo")).
+				st.New(&st.SeqScanOperator{TableName: "foo", Paths: []document.Path{document.NewPath("a")}}).
 					Pipe(st.Project(parser.MustParseExpr("a"))),
-				st.New(st.SeqScan("bar")).
+				st.New(&st.SeqScanOperator{TableName: "bar", Paths: []document.Path{document.NewPath("a")}}).
 					Pipe(st.Project(parser.MustParseExpr("a"))),
 			))
 
@@ -918,7 +2261,7 @@ func TestOptimize(t *testing.T) {
 					Pipe(st.Filter(parser.MustParseExpr("a = 1"))).
 					Pipe(st.Filter(parser.MustParseExpr("d = 2"))),
 			)),
-			db.Catalog)
+			db.Catalog, tx)
 
 		want := st.New(st.Concat(
 			st.New(st.IndexScan("idx_foo_a_d", st.IndexRange{Min: testutil.ExprList(t, `[1, 2]`), Exact: true})),
@@ -0,0 +1,216 @@
+package planner
+
+import (
+	"github.com/genjidb/genji/document"
+	"github.com/genjidb/genji/internal/database"
+	"github.com/genjidb/genji/internal/expr"
+	"github.com/genjidb/genji/internal/sql/scanner"
+	"github.com/genjidb/genji/internal/stream"
+)
+
+// scanFilters returns the conditions every document returned by the scan must satisfy:
+// the filters of the scan and the ones directly following it.
+func scanFilters(scan stream.Operator) []expr.Expr {
+	var filters []expr.Expr
+	if st, ok := scan.(*stream.SeqScanOperator); ok {
+		filters = append(filters, st.Filters...)
+	}
+
+	for n := scan.GetNext(); n != nil; n = n.GetNext() {
+		f, ok := n.(*stream.FilterOperator)
+		if !ok {
+			break
+		}
+		filters = append(filters, f.E)
+	}
+
+	return filters
+}
+
+// isIndexUsable returns true if the index contains every document matching the filters,
+// i.e. if it is built and if it is not a partial index or the filters imply its predicate.
+func isIndexUsable(info *database.IndexInfo, filters []expr.Expr) bool {
+	if info.Building {
+		return false
+	}
+
+	if info.Predicate == nil {
+		return true
+	}
+
+	ce, ok := info.Predicate.(*expr.ConstraintExpr)
+	if !ok {
+		return false
+	}
+
+	return implies(filters, ce.Expr)
+}
+
+// implies returns true if the predicate is true for every document for which the filters are true.
+// Each term of the predicate, if it is a conjunction, must be implied by one of the filters.
+// This only detects simple implications: terms equal to one of the filters, comparisons
+// of a path with a constant implied by a comparison of the same path, like a > 10 implying a >= 5,
+// and IS NOT NULL conditions implied by comparisons.
+func implies(filters []expr.Expr, predicate expr.Expr) bool {
+	var terms []expr.Expr
+	for _, f := range filters {
+		terms = append(terms, splitANDExpr(f)...)
+	}
+
+outer:
+	for _, p := range splitANDExpr(predicate) {
+		for _, t := range terms {
+			if termImplies(t, p) {
+				continue outer
+			}
+		}
+
+		return false
+	}
+
+	return true
+}
+
+// termImplies returns true if p is true whenever t is true.
+func termImplies(t, p expr.Expr) bool {
+	if expr.Equal(t, p) {
+		return true
+	}
+
+	// comparisons are false or NULL if the path is NULL
+	if isNot, ok := p.(*expr.IsNotOperator); ok {
+		path, ok := isNot.LeftHand().(expr.Path)
+		lv, isLiteral := isNot.RightHand().(expr.LiteralValue)
+		if !ok || !isLiteral || lv.Type != document.NullValue {
+			return false
+		}
+
+		op, ok := t.(expr.Operator)
+		if !ok || !expr.IsComparisonOperator(op) {
+			return false
+		}
+		switch op.(type) {
+		case *expr.IsOperator, *expr.IsNotOperator:
+			return false
+		}
+
+		tp, ok := op.LeftHand().(expr.Path)
+		return ok && expr.Equal(tp, path)
+	}
+
+	tPath, tTok, tv, ok := pathComparison(t)
+	if !ok {
+		return false
+	}
+	pPath, pTok, pv, ok := pathComparison(p)
+	if !ok || !expr.Equal(tPath, pPath) {
+		return false
+	}
+
+	// if t is an equality, p is implied if it is true for the value of t
+	if tTok == scanner.EQ {
+		return compareConstants(pTok, tv, pv)
+	}
+
+	switch pTok {
+	case scanner.GT, scanner.GTE:
+		if tTok != scanner.GT && tTok != scanner.GTE {
+			return false
+		}
+		// a >= 10 implies a > 5 but not a > 10
+		if tTok == scanner.GTE && pTok == scanner.GT {
+			return compareConstants(scanner.GT, tv, pv)
+		}
+		return compareConstants(scanner.GTE, tv, pv)
+	case scanner.LT, scanner.LTE:
+		if tTok != scanner.LT && tTok != scanner.LTE {
+			return false
+		}
+		if tTok == scanner.LTE && pTok == scanner.LT {
+			return compareConstants(scanner.LT, tv, pv)
+		}
+		return compareConstants(scanner.LTE, tv, pv)
+	case scanner.NEQ:
+		// a > 10 implies a != 5
+		switch tTok {
+		case scanner.GT:
+			return compareConstants(scanner.GTE, tv, pv)
+		case scanner.GTE:
+			return compareConstants(scanner.GT, tv, pv)
+		case scanner.LT:
+			return compareConstants(scanner.LTE, tv, pv)
+		case scanner.LTE:
+			return compareConstants(scanner.LT, tv, pv)
+		case scanner.NEQ:
+			return compareConstants(scanner.EQ, tv, pv)
+		}
+	}
+
+	return false
+}
+
+// pathComparison returns the path, the operator and the constant of comparisons
+// between a path and a literal value, normalized so that the path is on the left.
+func pathComparison(e expr.Expr) (expr.Path, scanner.Token, document.Value, bool) {
+	op, ok := e.(expr.Operator)
+	if !ok || !expr.IsComparisonOperator(op) {
+		return nil, 0, document.Value{}, false
+	}
+
+	tok := op.Token()
+	switch tok {
+	case scanner.EQ, scanner.NEQ, scanner.GT, scanner.GTE, scanner.LT, scanner.LTE:
+	default:
+		return nil, 0, document.Value{}, false
+	}
+
+	path, ok := op.LeftHand().(expr.Path)
+	lv, isLiteral := op.RightHand().(expr.LiteralValue)
+	if !ok || !isLiteral {
+		// the constant is on the left, i.e. 10 < a, which is the same as a > 10
+		path, ok = op.RightHand().(expr.Path)
+		lv, isLiteral = op.LeftHand().(expr.LiteralValue)
+		switch tok {
+		case scanner.GT:
+			tok = scanner.LT
+		case scanner.GTE:
+			tok = scanner.LTE
+		case scanner.LT:
+			tok = scanner.GT
+		case scanner.LTE:
+			tok = scanner.GTE
+		}
+	}
+	if !ok || !isLiteral || lv.Type == document.NullValue {
+		return nil, 0, document.Value{}, false
+	}
+
+	return path, tok, document.Value(lv), true
+}
+
+// compareConstants returns true if a op b is true. Values of different types
+// can't be compared and are never considered to imply each other.
+func compareConstants(tok scanner.Token, a, b document.Value) bool {
+	if a.Type != b.Type && !(a.Type.IsNumber() && b.Type.IsNumber()) {
+		return false
+	}
+
+	var ok bool
+	var err error
+	switch tok {
+	case scanner.EQ:
+		ok, err = a.IsEqual(b)
+	case scanner.NEQ:
+		ok, err = a.IsNotEqual(b)
+	case scanner.GT:
+		ok, err = a.IsGreaterThan(b)
+	case scanner.GTE:
+		ok, err = a.IsGreaterThanOrEqual(b)
+	case scanner.LT:
+		ok, err = a.IsLesserThan(b)
+	case scanner.LTE:
+		ok, err = a.IsLesserThanOrEqual(b)
+	}
+
+	return ok && err == nil
+}
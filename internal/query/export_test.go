@@ -0,0 +1,9 @@
+package query
+
+// SetIndexBuildBatchSize sets the number of documents indexed by each transaction
+// of CREATE INDEX CONCURRENTLY, and returns a function restoring the previous value.
+func SetIndexBuildBatchSize(n int) func() {
+	prev := indexBuildBatchSize
+	indexBuildBatchSize = n
+	return func() { indexBuildBatchSize = prev }
+}
@@ -0,0 +1,352 @@
+/*
+* CODE GENERATED AUTOMATICALLY WITH github.com/genjidb/genji/dev/gensqltest
+* THIS FILE SHOULD NOT BE EDITED BY HAND
+ */
+package query_test
+
+import (
+	"testing"
+
+	"github.com/genjidb/genji"
+	"github.com/genjidb/genji/internal/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGenSelectWithLike(t *testing.T) {
+	setup := func(t *testing.T, db *genji.DB) {
+		t.Helper()
+
+		q := `
+CREATE TABLE test(a TEXT, b TEXT);
+CREATE INDEX test_a ON test(a);
+INSERT INTO test (a, b) VALUES
+("foo", "foo"),
+("foobar", "foobar"),
+("fo", "fo"),
+("fop", "fop"),
+("Foo", "Foo"),
+("fo_o", "fo_o"),
+("fo%o", "fo%o"),
+("12ab", "12ab"),
+("12AB", "12AB"),
+("été", "été"),
+("", "");
+`
+		err := db.Exec(q)
+		require.NoError(t, err)
+	}
+
+	// --------------------------------------------------------------------------
+	t.Run("prefix with index", func(t *testing.T) {
+		db, err := genji.Open(":memory:")
+		require.NoError(t, err)
+		defer db.Close()
+
+		setup(t, db)
+
+		t.Run(`SELECT a FROM test WHERE a LIKE 'foo%' ORDER BY a;`, func(t *testing.T) {
+			q := `
+SELECT a FROM test WHERE a LIKE 'foo%' ORDER BY a;
+`
+			res, err := db.Query(q)
+			require.NoError(t, err)
+			defer res.Close()
+			raw := `
+{ "a": "foo"}
+{ "a": "foobar"}
+`
+			testutil.RequireStreamEq(t, raw, res)
+		})
+
+	})
+
+	// --------------------------------------------------------------------------
+	t.Run("prefix without index", func(t *testing.T) {
+		db, err := genji.Open(":memory:")
+		require.NoError(t, err)
+		defer db.Close()
+
+		setup(t, db)
+
+		t.Run(`SELECT b FROM test WHERE b LIKE 'foo%' ORDER BY b;`, func(t *testing.T) {
+			q := `
+SELECT b FROM test WHERE b LIKE 'foo%' ORDER BY b;
+`
+			res, err := db.Query(q)
+			require.NoError(t, err)
+			defer res.Close()
+			raw := `
+{ "b": "foo"}
+{ "b": "foobar"}
+`
+			testutil.RequireStreamEq(t, raw, res)
+		})
+
+	})
+
+	// --------------------------------------------------------------------------
+	t.Run("single character wildcard", func(t *testing.T) {
+		db, err := genji.Open(":memory:")
+		require.NoError(t, err)
+		defer db.Close()
+
+		setup(t, db)
+
+		t.Run(`SELECT a FROM test WHERE a LIKE 'fo_' ORDER BY a;`, func(t *testing.T) {
+			q := `
+SELECT a FROM test WHERE a LIKE 'fo_' ORDER BY a;
+`
+			res, err := db.Query(q)
+			require.NoError(t, err)
+			defer res.Close()
+			raw := `
+{ "a": "foo"}
+{ "a": "fop"}
+`
+			testutil.RequireStreamEq(t, raw, res)
+		})
+
+	})
+
+	// --------------------------------------------------------------------------
+	t.Run("escaped wildcard", func(t *testing.T) {
+		db, err := genji.Open(":memory:")
+		require.NoError(t, err)
+		defer db.Close()
+
+		setup(t, db)
+
+		t.Run(`SELECT a FROM test WHERE a LIKE 'fo\\_%' ORDER BY a;`, func(t *testing.T) {
+			q := `
+SELECT a FROM test WHERE a LIKE 'fo\\_%' ORDER BY a;
+`
+			res, err := db.Query(q)
+			require.NoError(t, err)
+			defer res.Close()
+			raw := `
+{ "a": "fo_o"}
+`
+			testutil.RequireStreamEq(t, raw, res)
+		})
+
+	})
+
+	// --------------------------------------------------------------------------
+	t.Run("custom escape character", func(t *testing.T) {
+		db, err := genji.Open(":memory:")
+		require.NoError(t, err)
+		defer db.Close()
+
+		setup(t, db)
+
+		t.Run(`SELECT a FROM test WHERE a LIKE 'fo!%%' ESCAPE '!' ORDER BY a;`, func(t *testing.T) {
+			q := `
+SELECT a FROM test WHERE a LIKE 'fo!%%' ESCAPE '!' ORDER BY a;
+`
+			res, err := db.Query(q)
+			require.NoError(t, err)
+			defer res.Close()
+			raw := `
+{ "a": "fo%o"}
+`
+			testutil.RequireStreamEq(t, raw, res)
+		})
+
+	})
+
+	// --------------------------------------------------------------------------
+	t.Run("multi-byte prefix", func(t *testing.T) {
+		db, err := genji.Open(":memory:")
+		require.NoError(t, err)
+		defer db.Close()
+
+		setup(t, db)
+
+		t.Run(`SELECT a FROM test WHERE a LIKE 'ét%';`, func(t *testing.T) {
+			q := `
+SELECT a FROM test WHERE a LIKE 'ét%';
+`
+			res, err := db.Query(q)
+			require.NoError(t, err)
+			defer res.Close()
+			raw := `
+{ "a": "été"}
+`
+			testutil.RequireStreamEq(t, raw, res)
+		})
+
+	})
+
+	// --------------------------------------------------------------------------
+	t.Run("empty pattern", func(t *testing.T) {
+		db, err := genji.Open(":memory:")
+		require.NoError(t, err)
+		defer db.Close()
+
+		setup(t, db)
+
+		t.Run(`SELECT a FROM test WHERE a LIKE '';`, func(t *testing.T) {
+			q := `
+SELECT a FROM test WHERE a LIKE '';
+`
+			res, err := db.Query(q)
+			require.NoError(t, err)
+			defer res.Close()
+			raw := `
+{ "a": ""}
+`
+			testutil.RequireStreamEq(t, raw, res)
+		})
+
+	})
+
+	// --------------------------------------------------------------------------
+	t.Run("ilike with index", func(t *testing.T) {
+		db, err := genji.Open(":memory:")
+		require.NoError(t, err)
+		defer db.Close()
+
+		setup(t, db)
+
+		t.Run(`SELECT a FROM test WHERE a ILIKE '12ab%' ORDER BY a;`, func(t *testing.T) {
+			q := `
+SELECT a FROM test WHERE a ILIKE '12ab%' ORDER BY a;
+`
+			res, err := db.Query(q)
+			require.NoError(t, err)
+			defer res.Close()
+			raw := `
+{ "a": "12AB"}
+{ "a": "12ab"}
+`
+			testutil.RequireStreamEq(t, raw, res)
+		})
+
+	})
+
+	// --------------------------------------------------------------------------
+	t.Run("ilike", func(t *testing.T) {
+		db, err := genji.Open(":memory:")
+		require.NoError(t, err)
+		defer db.Close()
+
+		setup(t, db)
+
+		t.Run(`SELECT a FROM test WHERE a ILIKE 'FOO' ORDER BY a;`, func(t *testing.T) {
+			q := `
+SELECT a FROM test WHERE a ILIKE 'FOO' ORDER BY a;
+`
+			res, err := db.Query(q)
+			require.NoError(t, err)
+			defer res.Close()
+			raw := `
+{ "a": "Foo"}
+{ "a": "foo"}
+`
+			testutil.RequireStreamEq(t, raw, res)
+		})
+
+	})
+
+	// --------------------------------------------------------------------------
+	t.Run("not like", func(t *testing.T) {
+		db, err := genji.Open(":memory:")
+		require.NoError(t, err)
+		defer db.Close()
+
+		setup(t, db)
+
+		t.Run(`SELECT a FROM test WHERE a NOT LIKE '%o%' ORDER BY a;`, func(t *testing.T) {
+			q := `
+SELECT a FROM test WHERE a NOT LIKE '%o%' ORDER BY a;
+`
+			res, err := db.Query(q)
+			require.NoError(t, err)
+			defer res.Close()
+			raw := `
+{ "a": ""}
+{ "a": "12AB"}
+{ "a": "12ab"}
+{ "a": "été"}
+`
+			testutil.RequireStreamEq(t, raw, res)
+		})
+
+	})
+
+	// --------------------------------------------------------------------------
+	t.Run("prefix uses the index", func(t *testing.T) {
+		db, err := genji.Open(":memory:")
+		require.NoError(t, err)
+		defer db.Close()
+
+		setup(t, db)
+
+		t.Run(`EXPLAIN SELECT a FROM test WHERE a LIKE 'foo%';`, func(t *testing.T) {
+			q := `
+EXPLAIN SELECT a FROM test WHERE a LIKE 'foo%';
+`
+			res, err := db.Query(q)
+			require.NoError(t, err)
+			defer res.Close()
+			raw := `
+{
+    "plan": 'indexScan("test_a", ["foo", -1], filter(a < "fop"), filter(a LIKE "foo%"), covering) | project(a)'
+}
+`
+			testutil.RequireStreamEq(t, raw, res)
+		})
+
+	})
+
+	// --------------------------------------------------------------------------
+	t.Run("ilike prefix stops at cased characters", func(t *testing.T) {
+		db, err := genji.Open(":memory:")
+		require.NoError(t, err)
+		defer db.Close()
+
+		setup(t, db)
+
+		t.Run(`EXPLAIN SELECT a FROM test WHERE a ILIKE '12ab%';`, func(t *testing.T) {
+			q := `
+EXPLAIN SELECT a FROM test WHERE a ILIKE '12ab%';
+`
+			res, err := db.Query(q)
+			require.NoError(t, err)
+			defer res.Close()
+			raw := `
+{
+    "plan": 'indexScan("test_a", ["12", -1], filter(a < "13"), filter(a ILIKE "12ab%"), covering) | project(a)'
+}
+`
+			testutil.RequireStreamEq(t, raw, res)
+		})
+
+	})
+
+	// --------------------------------------------------------------------------
+	t.Run("leading wildcard cannot use the index", func(t *testing.T) {
+		db, err := genji.Open(":memory:")
+		require.NoError(t, err)
+		defer db.Close()
+
+		setup(t, db)
+
+		t.Run(`EXPLAIN SELECT a FROM test WHERE a LIKE '%foo';`, func(t *testing.T) {
+			q := `
+EXPLAIN SELECT a FROM test WHERE a LIKE '%foo';
+`
+			res, err := db.Query(q)
+			require.NoError(t, err)
+			defer res.Close()
+			raw := `
+{
+    "plan": 'seqScan(test, paths(a)) | filter(a LIKE "%foo") | project(a)'
+}
+`
+			testutil.RequireStreamEq(t, raw, res)
+		})
+
+	})
+
+}
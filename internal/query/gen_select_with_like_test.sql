@@ -0,0 +1,107 @@
+-- setup:
+CREATE TABLE test(a TEXT, b TEXT);
+CREATE INDEX test_a ON test(a);
+INSERT INTO test (a, b) VALUES
+    ("foo", "foo"),
+    ("foobar", "foobar"),
+    ("fo", "fo"),
+    ("fop", "fop"),
+    ("Foo", "Foo"),
+    ("fo_o", "fo_o"),
+    ("fo%o", "fo%o"),
+    ("12ab", "12ab"),
+    ("12AB", "12AB"),
+    ("été", "été"),
+    ("", "");
+
+-- test: prefix with index
+SELECT a FROM test WHERE a LIKE 'foo%' ORDER BY a;
+/* result:
+{ "a": "foo"}
+{ "a": "foobar"}
+*/
+
+-- test: prefix without index
+SELECT b FROM test WHERE b LIKE 'foo%' ORDER BY b;
+/* result:
+{ "b": "foo"}
+{ "b": "foobar"}
+*/
+
+-- test: single character wildcard
+SELECT a FROM test WHERE a LIKE 'fo_' ORDER BY a;
+/* result:
+{ "a": "foo"}
+{ "a": "fop"}
+*/
+
+-- test: escaped wildcard
+SELECT a FROM test WHERE a LIKE 'fo\\_%' ORDER BY a;
+/* result:
+{ "a": "fo_o"}
+*/
+
+-- test: custom escape character
+SELECT a FROM test WHERE a LIKE 'fo!%%' ESCAPE '!' ORDER BY a;
+/* result:
+{ "a": "fo%o"}
+*/
+
+-- test: multi-byte prefix
+SELECT a FROM test WHERE a LIKE 'ét%';
+/* result:
+{ "a": "été"}
+*/
+
+-- test: empty pattern
+SELECT a FROM test WHERE a LIKE '';
+/* result:
+{ "a": ""}
+*/
+
+-- test: ilike with index
+SELECT a FROM test WHERE a ILIKE '12ab%' ORDER BY a;
+/* result:
+{ "a": "12AB"}
+{ "a": "12ab"}
+*/
+
+-- test: ilike
+SELECT a FROM test WHERE a ILIKE 'FOO' ORDER BY a;
+/* result:
+{ "a": "Foo"}
+{ "a": "foo"}
+*/
+
+-- test: not like
+SELECT a FROM test WHERE a NOT LIKE '%o%' ORDER BY a;
+/* result:
+{ "a": ""}
+{ "a": "12AB"}
+{ "a": "12ab"}
+{ "a": "été"}
+*/
+
+-- test: prefix uses the index
+EXPLAIN SELECT a FROM test WHERE a LIKE 'foo%';
+/* result:
+{
+    "plan": 'indexScan("test_a", ["foo", -1], filter(a < "fop"), filter(a LIKE "foo%"), covering) | project(a)'
+}
+*/
+
+-- test: ilike prefix stops at cased characters
+EXPLAIN SELECT a FROM test WHERE a ILIKE '12ab%';
+/* result:
+{
+    "plan": 'indexScan("test_a", ["12", -1], filter(a < "13"), filter(a ILIKE "12ab%"), covering) | project(a)'
+}
+*/
+
+-- test: leading wildcard cannot use the index
+EXPLAIN SELECT a FROM test WHERE a LIKE '%foo';
+/* result:
+{
+    "plan": 'seqScan(test, paths(a)) | filter(a LIKE "%foo") | project(a)'
+}
+*/
@@ -0,0 +1,183 @@
+/*
+* CODE GENERATED AUTOMATICALLY WITH github.com/genjidb/genji/dev/gensqltest
+* THIS FILE SHOULD NOT BE EDITED BY HAND
+ */
+package query_test
+
+import (
+	"testing"
+
+	"github.com/genjidb/genji"
+	"github.com/genjidb/genji/internal/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGenSelectWithOrderBy(t *testing.T) {
+	setup := func(t *testing.T, db *genji.DB) {
+		t.Helper()
+
+		q := `
+CREATE TABLE test;
+INSERT INTO test (a, b, c) VALUES (1, 2, "x"), (2, 1, "y"), (1, 1, "z"), (2, 2, "w");
+`
+		err := db.Exec(q)
+		require.NoError(t, err)
+	}
+
+	// --------------------------------------------------------------------------
+	t.Run("single expression", func(t *testing.T) {
+		db, err := genji.Open(":memory:")
+		require.NoError(t, err)
+		defer db.Close()
+
+		setup(t, db)
+
+		t.Run(`SELECT c FROM test ORDER BY b DESC;`, func(t *testing.T) {
+			q := `
+SELECT c FROM test ORDER BY b DESC;
+`
+			res, err := db.Query(q)
+			require.NoError(t, err)
+			defer res.Close()
+			raw := `
+{ "c": "x"}
+{ "c": "w"}
+{ "c": "y"}
+{ "c": "z"}
+`
+			testutil.RequireStreamEq(t, raw, res)
+		})
+
+	})
+
+	// --------------------------------------------------------------------------
+	t.Run("multiple expressions", func(t *testing.T) {
+		db, err := genji.Open(":memory:")
+		require.NoError(t, err)
+		defer db.Close()
+
+		setup(t, db)
+
+		t.Run(`SELECT a, b FROM test ORDER BY a, b;`, func(t *testing.T) {
+			q := `
+SELECT a, b FROM test ORDER BY a, b;
+`
+			res, err := db.Query(q)
+			require.NoError(t, err)
+			defer res.Close()
+			raw := `
+{ "a": 1.0, "b": 1.0}
+{ "a": 1.0, "b": 2.0}
+{ "a": 2.0, "b": 1.0}
+{ "a": 2.0, "b": 2.0}
+`
+			testutil.RequireStreamEq(t, raw, res)
+		})
+
+	})
+
+	// --------------------------------------------------------------------------
+	t.Run("mixed directions", func(t *testing.T) {
+		db, err := genji.Open(":memory:")
+		require.NoError(t, err)
+		defer db.Close()
+
+		setup(t, db)
+
+		t.Run(`SELECT a, b FROM test ORDER BY a DESC, b ASC;`, func(t *testing.T) {
+			q := `
+SELECT a, b FROM test ORDER BY a DESC, b ASC;
+`
+			res, err := db.Query(q)
+			require.NoError(t, err)
+			defer res.Close()
+			raw := `
+{ "a": 2.0, "b": 1.0}
+{ "a": 2.0, "b": 2.0}
+{ "a": 1.0, "b": 1.0}
+{ "a": 1.0, "b": 2.0}
+`
+			testutil.RequireStreamEq(t, raw, res)
+		})
+
+	})
+
+	// --------------------------------------------------------------------------
+	t.Run("expression", func(t *testing.T) {
+		db, err := genji.Open(":memory:")
+		require.NoError(t, err)
+		defer db.Close()
+
+		setup(t, db)
+
+		t.Run(`SELECT c FROM test ORDER BY a * 10 - b DESC;`, func(t *testing.T) {
+			q := `
+SELECT c FROM test ORDER BY a * 10 - b DESC;
+`
+			res, err := db.Query(q)
+			require.NoError(t, err)
+			defer res.Close()
+			raw := `
+{ "c": "y"}
+{ "c": "w"}
+{ "c": "z"}
+{ "c": "x"}
+`
+			testutil.RequireStreamEq(t, raw, res)
+		})
+
+	})
+
+	// --------------------------------------------------------------------------
+	t.Run("alias", func(t *testing.T) {
+		db, err := genji.Open(":memory:")
+		require.NoError(t, err)
+		defer db.Close()
+
+		setup(t, db)
+
+		t.Run(`SELECT a AS x, c FROM test ORDER BY x DESC, c;`, func(t *testing.T) {
+			q := `
+SELECT a AS x, c FROM test ORDER BY x DESC, c;
+`
+			res, err := db.Query(q)
+			require.NoError(t, err)
+			defer res.Close()
+			raw := `
+{ "x": 2.0, "c": "w"}
+{ "x": 2.0, "c": "y"}
+{ "x": 1.0, "c": "x"}
+{ "x": 1.0, "c": "z"}
+`
+			testutil.RequireStreamEq(t, raw, res)
+		})
+
+	})
+
+	// --------------------------------------------------------------------------
+	t.Run("field not projected", func(t *testing.T) {
+		db, err := genji.Open(":memory:")
+		require.NoError(t, err)
+		defer db.Close()
+
+		setup(t, db)
+
+		t.Run(`SELECT c FROM test ORDER BY b, a DESC;`, func(t *testing.T) {
+			q := `
+SELECT c FROM test ORDER BY b, a DESC;
+`
+			res, err := db.Query(q)
+			require.NoError(t, err)
+			defer res.Close()
+			raw := `
+{ "c": "y"}
+{ "c": "z"}
+{ "c": "w"}
+{ "c": "x"}
+`
+			testutil.RequireStreamEq(t, raw, res)
+		})
+
+	})
+
+}
@@ -0,0 +1,57 @@
+-- setup:
+CREATE TABLE test;
+INSERT INTO test (a, b, c) VALUES (1, 2, "x"), (2, 1, "y"), (1, 1, "z"), (2, 2, "w");
+
+-- test: single expression
+SELECT c FROM test ORDER BY b DESC;
+/* result:
+{ "c": "x"}
+{ "c": "w"}
+{ "c": "y"}
+{ "c": "z"}
+*/
+
+-- test: multiple expressions
+SELECT a, b FROM test ORDER BY a, b;
+/* result:
+{ "a": 1.0, "b": 1.0}
+{ "a": 1.0, "b": 2.0}
+{ "a": 2.0, "b": 1.0}
+{ "a": 2.0, "b": 2.0}
+*/
+
+-- test: mixed directions
+SELECT a, b FROM test ORDER BY a DESC, b ASC;
+/* result:
+{ "a": 2.0, "b": 1.0}
+{ "a": 2.0, "b": 2.0}
+{ "a": 1.0, "b": 1.0}
+{ "a": 1.0, "b": 2.0}
+*/
+
+-- test: expression
+SELECT c FROM test ORDER BY a * 10 - b DESC;
+/* result:
+{ "c": "y"}
+{ "c": "w"}
+{ "c": "z"}
+{ "c": "x"}
+*/
+
+-- test: alias
+SELECT a AS x, c FROM test ORDER BY x DESC, c;
+/* result:
+{ "x": 2.0, "c": "w"}
+{ "x": 2.0, "c": "y"}
+{ "x": 1.0, "c": "x"}
+{ "x": 1.0, "c": "z"}
+*/
+
+-- test: field not projected
+SELECT c FROM test ORDER BY b, a DESC;
+/* result:
+{ "c": "y"}
+{ "c": "z"}
+{ "c": "w"}
+{ "c": "x"}
+*/
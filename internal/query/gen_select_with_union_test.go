@@ -181,8 +181,202 @@ SELECT * FROM foo
 UNION
 SELECT * FROM bar;
 `
-			err := db.Exec(q)
-			require.Errorf(t, err, "expected\n%s\nto raise an error but got none", q)
+			res, err := db.Query(q)
+			require.NoError(t, err)
+			defer res.Close()
+			raw := `
+{ "a": 1.0, "b": 1.0}
+{ "a": 2.0, "b": 2.0}
+{ "a": 3.0, "b": 3.0}
+{ "a": 4.0, "b": 4.0}
+`
+			testutil.RequireStreamEq(t, raw, res)
+		})
+
+	})
+
+	// --------------------------------------------------------------------------
+	t.Run("union removes duplicates", func(t *testing.T) {
+		db, err := genji.Open(":memory:")
+		require.NoError(t, err)
+		defer db.Close()
+
+		setup(t, db)
+
+		t.Run(`SELECT * FROM foo`, func(t *testing.T) {
+			q := `
+SELECT * FROM foo
+UNION
+SELECT * FROM foo;
+`
+			res, err := db.Query(q)
+			require.NoError(t, err)
+			defer res.Close()
+			raw := `
+{ "a": 1.0, "b": 1.0}
+{ "a": 2.0, "b": 2.0}
+`
+			testutil.RequireStreamEq(t, raw, res)
+		})
+
+	})
+
+	// --------------------------------------------------------------------------
+	t.Run("union all with limit", func(t *testing.T) {
+		db, err := genji.Open(":memory:")
+		require.NoError(t, err)
+		defer db.Close()
+
+		setup(t, db)
+
+		t.Run(`SELECT * FROM foo LIMIT 1`, func(t *testing.T) {
+			q := `
+SELECT * FROM foo LIMIT 1
+UNION ALL
+SELECT * FROM bar;
+`
+			res, err := db.Query(q)
+			require.NoError(t, err)
+			defer res.Close()
+			raw := `
+{ "a": 1.0, "b": 1.0}
+{ "a": 3.0, "b": 3.0}
+{ "a": 4.0, "b": 4.0}
+`
+			testutil.RequireStreamEq(t, raw, res)
+		})
+
+	})
+
+	// --------------------------------------------------------------------------
+	t.Run("intersect", func(t *testing.T) {
+		db, err := genji.Open(":memory:")
+		require.NoError(t, err)
+		defer db.Close()
+
+		setup(t, db)
+
+		t.Run(`SELECT a FROM foo`, func(t *testing.T) {
+			q := `
+SELECT a FROM foo
+INTERSECT
+SELECT a FROM foo WHERE a > 1;
+`
+			res, err := db.Query(q)
+			require.NoError(t, err)
+			defer res.Close()
+			raw := `
+{ "a": 2.0}
+`
+			testutil.RequireStreamEq(t, raw, res)
+		})
+
+	})
+
+	// --------------------------------------------------------------------------
+	t.Run("intersect all", func(t *testing.T) {
+		db, err := genji.Open(":memory:")
+		require.NoError(t, err)
+		defer db.Close()
+
+		setup(t, db)
+
+		t.Run(`SELECT b FROM foo UNION ALL SELECT b FROM foo`, func(t *testing.T) {
+			q := `
+SELECT b FROM foo UNION ALL SELECT b FROM foo
+INTERSECT ALL
+SELECT a AS b FROM foo UNION ALL SELECT 2.0 AS b FROM foo WHERE a = 1;
+`
+			res, err := db.Query(q)
+			require.NoError(t, err)
+			defer res.Close()
+			raw := `
+{ "b": 1.0}
+{ "b": 2.0}
+{ "b": 2.0}
+`
+			testutil.RequireStreamEq(t, raw, res)
+		})
+
+	})
+
+	// --------------------------------------------------------------------------
+	t.Run("except", func(t *testing.T) {
+		db, err := genji.Open(":memory:")
+		require.NoError(t, err)
+		defer db.Close()
+
+		setup(t, db)
+
+		t.Run(`SELECT * FROM foo UNION ALL SELECT * FROM bar`, func(t *testing.T) {
+			q := `
+SELECT * FROM foo UNION ALL SELECT * FROM bar
+EXCEPT
+SELECT * FROM foo;
+`
+			res, err := db.Query(q)
+			require.NoError(t, err)
+			defer res.Close()
+			raw := `
+{ "a": 3.0, "b": 3.0}
+{ "a": 4.0, "b": 4.0}
+`
+			testutil.RequireStreamEq(t, raw, res)
+		})
+
+	})
+
+	// --------------------------------------------------------------------------
+	t.Run("except all", func(t *testing.T) {
+		db, err := genji.Open(":memory:")
+		require.NoError(t, err)
+		defer db.Close()
+
+		setup(t, db)
+
+		t.Run(`SELECT a FROM foo UNION ALL SELECT a FROM foo`, func(t *testing.T) {
+			q := `
+SELECT a FROM foo UNION ALL SELECT a FROM foo
+EXCEPT ALL
+SELECT a FROM foo WHERE a = 1;
+`
+			res, err := db.Query(q)
+			require.NoError(t, err)
+			defer res.Close()
+			raw := `
+{ "a": 2.0}
+{ "a": 1.0}
+{ "a": 2.0}
+`
+			testutil.RequireStreamEq(t, raw, res)
+		})
+
+	})
+
+	// --------------------------------------------------------------------------
+	t.Run("set operations are evaluated from left to right", func(t *testing.T) {
+		db, err := genji.Open(":memory:")
+		require.NoError(t, err)
+		defer db.Close()
+
+		setup(t, db)
+
+		t.Run(`SELECT * FROM foo`, func(t *testing.T) {
+			q := `
+SELECT * FROM foo
+EXCEPT
+SELECT * FROM foo
+UNION ALL
+SELECT * FROM bar;
+`
+			res, err := db.Query(q)
+			require.NoError(t, err)
+			defer res.Close()
+			raw := `
+{ "a": 3.0, "b": 3.0}
+{ "a": 4.0, "b": 4.0}
+`
+			testutil.RequireStreamEq(t, raw, res)
 		})
 
 	})
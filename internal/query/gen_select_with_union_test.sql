@@ -64,5 +64,76 @@ UNION ALL SELECT * FROM baz;
 SELECT * FROM foo
 UNION
 SELECT * FROM bar;
--- error:
--- not implemented for now
+/* result:
+{ "a": 1.0, "b": 1.0}
+{ "a": 2.0, "b": 2.0}
+{ "a": 3.0, "b": 3.0}
+{ "a": 4.0, "b": 4.0}
+*/
+
+-- test: union removes duplicates
+SELECT * FROM foo
+UNION
+SELECT * FROM foo;
+/* result:
+{ "a": 1.0, "b": 1.0}
+{ "a": 2.0, "b": 2.0}
+*/
+
+-- test: union all with limit
+SELECT * FROM foo LIMIT 1
+UNION ALL
+SELECT * FROM bar;
+/* result:
+{ "a": 1.0, "b": 1.0}
+{ "a": 3.0, "b": 3.0}
+{ "a": 4.0, "b": 4.0}
+*/
+
+-- test: intersect
+SELECT a FROM foo
+INTERSECT
+SELECT a FROM foo WHERE a > 1;
+/* result:
+{ "a": 2.0}
+*/
+
+-- test: intersect all
+SELECT b FROM foo UNION ALL SELECT b FROM foo
+INTERSECT ALL
+SELECT a AS b FROM foo UNION ALL SELECT 2.0 AS b FROM foo WHERE a = 1;
+/* result:
+{ "b": 1.0}
+{ "b": 2.0}
+{ "b": 2.0}
+*/
+
+-- test: except
+SELECT * FROM foo UNION ALL SELECT * FROM bar
+EXCEPT
+SELECT * FROM foo;
+/* result:
+{ "a": 3.0, "b": 3.0}
+{ "a": 4.0, "b": 4.0}
+*/
+
+-- test: except all
+SELECT a FROM foo UNION ALL SELECT a FROM foo
+EXCEPT ALL
+SELECT a FROM foo WHERE a = 1;
+/* result:
+{ "a": 2.0}
+{ "a": 1.0}
+{ "a": 2.0}
+*/
+
+-- test: set operations are evaluated from left to right
+SELECT * FROM foo
+EXCEPT
+SELECT * FROM foo
+UNION ALL
+SELECT * FROM bar;
+/* result:
+{ "a": 3.0, "b": 3.0}
+{ "a": 4.0, "b": 4.0}
+*/
@@ -0,0 +1,361 @@
+/*
+* CODE GENERATED AUTOMATICALLY WITH github.com/genjidb/genji/dev/gensqltest
+* THIS FILE SHOULD NOT BE EDITED BY HAND
+ */
+package query_test
+
+import (
+	"testing"
+
+	"github.com/genjidb/genji"
+	"github.com/genjidb/genji/internal/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGenSelectWithWindow(t *testing.T) {
+	setup := func(t *testing.T, db *genji.DB) {
+		t.Helper()
+
+		q := `
+CREATE TABLE emp (id INTEGER PRIMARY KEY, dept TEXT, salary INTEGER);
+INSERT INTO emp (id, dept, salary) VALUES (1, 'a', 10), (2, 'b', 20), (3, 'a', 30), (4, 'b', 20), (5, 'a', 10);
+`
+		err := db.Exec(q)
+		require.NoError(t, err)
+	}
+
+	// --------------------------------------------------------------------------
+	t.Run("row_number", func(t *testing.T) {
+		db, err := genji.Open(":memory:")
+		require.NoError(t, err)
+		defer db.Close()
+
+		setup(t, db)
+
+		t.Run(`SELECT id, ROW_NUMBER() OVER (ORDER BY salary DESC, id) AS rn FROM emp;`, func(t *testing.T) {
+			q := `
+SELECT id, ROW_NUMBER() OVER (ORDER BY salary DESC, id) AS rn FROM emp;
+`
+			res, err := db.Query(q)
+			require.NoError(t, err)
+			defer res.Close()
+			raw := `
+{"id": 3, "rn": 1}
+{"id": 2, "rn": 2}
+{"id": 4, "rn": 3}
+{"id": 1, "rn": 4}
+{"id": 5, "rn": 5}
+`
+			testutil.RequireStreamEq(t, raw, res)
+		})
+
+	})
+
+	// --------------------------------------------------------------------------
+	t.Run("row_number with partition", func(t *testing.T) {
+		db, err := genji.Open(":memory:")
+		require.NoError(t, err)
+		defer db.Close()
+
+		setup(t, db)
+
+		t.Run(`SELECT id, ROW_NUMBER() OVER (PARTITION BY dept ORDER BY salary DESC) AS rn FROM emp;`, func(t *testing.T) {
+			q := `
+SELECT id, ROW_NUMBER() OVER (PARTITION BY dept ORDER BY salary DESC) AS rn FROM emp;
+`
+			res, err := db.Query(q)
+			require.NoError(t, err)
+			defer res.Close()
+			raw := `
+{"id": 3, "rn": 1}
+{"id": 1, "rn": 2}
+{"id": 5, "rn": 3}
+{"id": 2, "rn": 1}
+{"id": 4, "rn": 2}
+`
+			testutil.RequireStreamEq(t, raw, res)
+		})
+
+	})
+
+	// --------------------------------------------------------------------------
+	t.Run("row_number without order", func(t *testing.T) {
+		db, err := genji.Open(":memory:")
+		require.NoError(t, err)
+		defer db.Close()
+
+		setup(t, db)
+
+		t.Run(`SELECT id, ROW_NUMBER() OVER () AS rn FROM emp WHERE id > 3;`, func(t *testing.T) {
+			q := `
+SELECT id, ROW_NUMBER() OVER () AS rn FROM emp WHERE id > 3;
+`
+			res, err := db.Query(q)
+			require.NoError(t, err)
+			defer res.Close()
+			raw := `
+{"id": 4, "rn": 1}
+{"id": 5, "rn": 2}
+`
+			testutil.RequireStreamEq(t, raw, res)
+		})
+
+	})
+
+	// --------------------------------------------------------------------------
+	t.Run("rank and dense_rank", func(t *testing.T) {
+		db, err := genji.Open(":memory:")
+		require.NoError(t, err)
+		defer db.Close()
+
+		setup(t, db)
+
+		t.Run(`SELECT id, RANK() OVER (ORDER BY salary) AS r, DENSE_RANK() OVER (ORDER BY salary) AS dr FROM emp;`, func(t *testing.T) {
+			q := `
+SELECT id, RANK() OVER (ORDER BY salary) AS r, DENSE_RANK() OVER (ORDER BY salary) AS dr FROM emp;
+`
+			res, err := db.Query(q)
+			require.NoError(t, err)
+			defer res.Close()
+			raw := `
+{"id": 1, "r": 1, "dr": 1}
+{"id": 5, "r": 1, "dr": 1}
+{"id": 2, "r": 3, "dr": 2}
+{"id": 4, "r": 3, "dr": 2}
+{"id": 3, "r": 5, "dr": 3}
+`
+			testutil.RequireStreamEq(t, raw, res)
+		})
+
+	})
+
+	// --------------------------------------------------------------------------
+	t.Run("lag and lead", func(t *testing.T) {
+		db, err := genji.Open(":memory:")
+		require.NoError(t, err)
+		defer db.Close()
+
+		setup(t, db)
+
+		t.Run(`SELECT id, LAG(salary) OVER (ORDER BY id) AS prev, LEAD(salary, 2, 0) OVER (ORDER BY id) AS nxt FROM emp;`, func(t *testing.T) {
+			q := `
+SELECT id, LAG(salary) OVER (ORDER BY id) AS prev, LEAD(salary, 2, 0) OVER (ORDER BY id) AS nxt FROM emp;
+`
+			res, err := db.Query(q)
+			require.NoError(t, err)
+			defer res.Close()
+			raw := `
+{"id": 1, "prev": null, "nxt": 30}
+{"id": 2, "prev": 10, "nxt": 20}
+{"id": 3, "prev": 20, "nxt": 10}
+{"id": 4, "prev": 30, "nxt": 0}
+{"id": 5, "prev": 20, "nxt": 0}
+`
+			testutil.RequireStreamEq(t, raw, res)
+		})
+
+	})
+
+	// --------------------------------------------------------------------------
+	t.Run("lag with partition", func(t *testing.T) {
+		db, err := genji.Open(":memory:")
+		require.NoError(t, err)
+		defer db.Close()
+
+		setup(t, db)
+
+		t.Run(`SELECT id, LAG(id) OVER (PARTITION BY dept ORDER BY id) AS prev FROM emp;`, func(t *testing.T) {
+			q := `
+SELECT id, LAG(id) OVER (PARTITION BY dept ORDER BY id) AS prev FROM emp;
+`
+			res, err := db.Query(q)
+			require.NoError(t, err)
+			defer res.Close()
+			raw := `
+{"id": 1, "prev": null}
+{"id": 3, "prev": 1}
+{"id": 5, "prev": 3}
+{"id": 2, "prev": null}
+{"id": 4, "prev": 2}
+`
+			testutil.RequireStreamEq(t, raw, res)
+		})
+
+	})
+
+	// --------------------------------------------------------------------------
+	t.Run("running sum", func(t *testing.T) {
+		db, err := genji.Open(":memory:")
+		require.NoError(t, err)
+		defer db.Close()
+
+		setup(t, db)
+
+		t.Run(`SELECT id, SUM(salary) OVER (PARTITION BY dept ORDER BY salary) AS total FROM emp ORDER BY id;`, func(t *testing.T) {
+			q := `
+SELECT id, SUM(salary) OVER (PARTITION BY dept ORDER BY salary) AS total FROM emp ORDER BY id;
+`
+			res, err := db.Query(q)
+			require.NoError(t, err)
+			defer res.Close()
+			raw := `
+{"id": 1, "total": 20}
+{"id": 2, "total": 40}
+{"id": 3, "total": 50}
+{"id": 4, "total": 40}
+{"id": 5, "total": 20}
+`
+			testutil.RequireStreamEq(t, raw, res)
+		})
+
+	})
+
+	// --------------------------------------------------------------------------
+	t.Run("aggregate over the whole partition", func(t *testing.T) {
+		db, err := genji.Open(":memory:")
+		require.NoError(t, err)
+		defer db.Close()
+
+		setup(t, db)
+
+		t.Run(`SELECT id, SUM(salary) OVER () AS total, COUNT(*) OVER (PARTITION BY dept) AS n FROM emp ORDER BY id;`, func(t *testing.T) {
+			q := `
+SELECT id, SUM(salary) OVER () AS total, COUNT(*) OVER (PARTITION BY dept) AS n FROM emp ORDER BY id;
+`
+			res, err := db.Query(q)
+			require.NoError(t, err)
+			defer res.Close()
+			raw := `
+{"id": 1, "total": 90, "n": 3}
+{"id": 2, "total": 90, "n": 2}
+{"id": 3, "total": 90, "n": 3}
+{"id": 4, "total": 90, "n": 2}
+{"id": 5, "total": 90, "n": 3}
+`
+			testutil.RequireStreamEq(t, raw, res)
+		})
+
+	})
+
+	// --------------------------------------------------------------------------
+	t.Run("array_agg", func(t *testing.T) {
+		db, err := genji.Open(":memory:")
+		require.NoError(t, err)
+		defer db.Close()
+
+		setup(t, db)
+
+		t.Run(`SELECT id, ARRAY_AGG(id) OVER (ORDER BY id) AS ids FROM emp WHERE id < 4;`, func(t *testing.T) {
+			q := `
+SELECT id, ARRAY_AGG(id) OVER (ORDER BY id) AS ids FROM emp WHERE id < 4;
+`
+			res, err := db.Query(q)
+			require.NoError(t, err)
+			defer res.Close()
+			raw := `
+{"id": 1, "ids": [1]}
+{"id": 2, "ids": [1, 2]}
+{"id": 3, "ids": [1, 2, 3]}
+`
+			testutil.RequireStreamEq(t, raw, res)
+		})
+
+	})
+
+	// --------------------------------------------------------------------------
+	t.Run("window function in expression", func(t *testing.T) {
+		db, err := genji.Open(":memory:")
+		require.NoError(t, err)
+		defer db.Close()
+
+		setup(t, db)
+
+		t.Run(`SELECT id, ROW_NUMBER() OVER (ORDER BY id) * 10 AS rn FROM emp WHERE id < 3;`, func(t *testing.T) {
+			q := `
+SELECT id, ROW_NUMBER() OVER (ORDER BY id) * 10 AS rn FROM emp WHERE id < 3;
+`
+			res, err := db.Query(q)
+			require.NoError(t, err)
+			defer res.Close()
+			raw := `
+{"id": 1, "rn": 10}
+{"id": 2, "rn": 20}
+`
+			testutil.RequireStreamEq(t, raw, res)
+		})
+
+	})
+
+	// --------------------------------------------------------------------------
+	t.Run("misuse", func(t *testing.T) {
+		db, err := genji.Open(":memory:")
+		require.NoError(t, err)
+		defer db.Close()
+
+		setup(t, db)
+
+		t.Run(`SELECT ROW_NUMBER() FROM emp;`, func(t *testing.T) {
+			q := `
+SELECT ROW_NUMBER() FROM emp;
+`
+			err := db.Exec(q)
+			require.Errorf(t, err, "expected\n%s\nto raise an error but got none", q)
+		})
+
+	})
+
+	// --------------------------------------------------------------------------
+	t.Run("with aggregate", func(t *testing.T) {
+		db, err := genji.Open(":memory:")
+		require.NoError(t, err)
+		defer db.Close()
+
+		setup(t, db)
+
+		t.Run(`SELECT COUNT(*), ROW_NUMBER() OVER () FROM emp;`, func(t *testing.T) {
+			q := `
+SELECT COUNT(*), ROW_NUMBER() OVER () FROM emp;
+`
+			err := db.Exec(q)
+			require.Errorf(t, err, "expected\n%s\nto raise an error but got none", q)
+		})
+
+	})
+
+	// --------------------------------------------------------------------------
+	t.Run("with group by", func(t *testing.T) {
+		db, err := genji.Open(":memory:")
+		require.NoError(t, err)
+		defer db.Close()
+
+		setup(t, db)
+
+		t.Run(`SELECT dept, RANK() OVER (ORDER BY dept) FROM emp GROUP BY dept;`, func(t *testing.T) {
+			q := `
+SELECT dept, RANK() OVER (ORDER BY dept) FROM emp GROUP BY dept;
+`
+			err := db.Exec(q)
+			require.Errorf(t, err, "expected\n%s\nto raise an error but got none", q)
+		})
+
+	})
+
+	// --------------------------------------------------------------------------
+	t.Run("not a window function", func(t *testing.T) {
+		db, err := genji.Open(":memory:")
+		require.NoError(t, err)
+		defer db.Close()
+
+		setup(t, db)
+
+		t.Run(`SELECT LENGTH(dept) OVER () FROM emp;`, func(t *testing.T) {
+			q := `
+SELECT LENGTH(dept) OVER () FROM emp;
+`
+			err := db.Exec(q)
+			require.Errorf(t, err, "expected\n%s\nto raise an error but got none", q)
+		})
+
+	})
+
+}
@@ -0,0 +1,111 @@
+-- setup:
+CREATE TABLE emp (id INTEGER PRIMARY KEY, dept TEXT, salary INTEGER);
+INSERT INTO emp (id, dept, salary) VALUES (1, 'a', 10), (2, 'b', 20), (3, 'a', 30), (4, 'b', 20), (5, 'a', 10);
+
+-- test: row_number
+SELECT id, ROW_NUMBER() OVER (ORDER BY salary DESC, id) AS rn FROM emp;
+/* result:
+{"id": 3, "rn": 1}
+{"id": 2, "rn": 2}
+{"id": 4, "rn": 3}
+{"id": 1, "rn": 4}
+{"id": 5, "rn": 5}
+*/
+
+-- test: row_number with partition
+SELECT id, ROW_NUMBER() OVER (PARTITION BY dept ORDER BY salary DESC) AS rn FROM emp;
+/* result:
+{"id": 3, "rn": 1}
+{"id": 1, "rn": 2}
+{"id": 5, "rn": 3}
+{"id": 2, "rn": 1}
+{"id": 4, "rn": 2}
+*/
+
+-- test: row_number without order
+SELECT id, ROW_NUMBER() OVER () AS rn FROM emp WHERE id > 3;
+/* result:
+{"id": 4, "rn": 1}
+{"id": 5, "rn": 2}
+*/
+
+-- test: rank and dense_rank
+SELECT id, RANK() OVER (ORDER BY salary) AS r, DENSE_RANK() OVER (ORDER BY salary) AS dr FROM emp;
+/* result:
+{"id": 1, "r": 1, "dr": 1}
+{"id": 5, "r": 1, "dr": 1}
+{"id": 2, "r": 3, "dr": 2}
+{"id": 4, "r": 3, "dr": 2}
+{"id": 3, "r": 5, "dr": 3}
+*/
+
+-- test: lag and lead
+SELECT id, LAG(salary) OVER (ORDER BY id) AS prev, LEAD(salary, 2, 0) OVER (ORDER BY id) AS nxt FROM emp;
+/* result:
+{"id": 1, "prev": null, "nxt": 30}
+{"id": 2, "prev": 10, "nxt": 20}
+{"id": 3, "prev": 20, "nxt": 10}
+{"id": 4, "prev": 30, "nxt": 0}
+{"id": 5, "prev": 20, "nxt": 0}
+*/
+
+-- test: lag with partition
+SELECT id, LAG(id) OVER (PARTITION BY dept ORDER BY id) AS prev FROM emp;
+/* result:
+{"id": 1, "prev": null}
+{"id": 3, "prev": 1}
+{"id": 5, "prev": 3}
+{"id": 2, "prev": null}
+{"id": 4, "prev": 2}
+*/
+
+-- test: running sum
+SELECT id, SUM(salary) OVER (PARTITION BY dept ORDER BY salary) AS total FROM emp ORDER BY id;
+/* result:
+{"id": 1, "total": 20}
+{"id": 2, "total": 40}
+{"id": 3, "total": 50}
+{"id": 4, "total": 40}
+{"id": 5, "total": 20}
+*/
+
+-- test: aggregate over the whole partition
+SELECT id, SUM(salary) OVER () AS total, COUNT(*) OVER (PARTITION BY dept) AS n FROM emp ORDER BY id;
+/* result:
+{"id": 1, "total": 90, "n": 3}
+{"id": 2, "total": 90, "n": 2}
+{"id": 3, "total": 90, "n": 3}
+{"id": 4, "total": 90, "n": 2}
+{"id": 5, "total": 90, "n": 3}
+*/
+
+-- test: array_agg
+SELECT id, ARRAY_AGG(id) OVER (ORDER BY id) AS ids FROM emp WHERE id < 4;
+/* result:
+{"id": 1, "ids": [1]}
+{"id": 2, "ids": [1, 2]}
+{"id": 3, "ids": [1, 2, 3]}
+*/
+
+-- test: window function in expression
+SELECT id, ROW_NUMBER() OVER (ORDER BY id) * 10 AS rn FROM emp WHERE id < 3;
+/* result:
+{"id": 1, "rn": 10}
+{"id": 2, "rn": 20}
+*/
+
+-- test: misuse
+SELECT ROW_NUMBER() FROM emp;
+-- error:
+
+-- test: with aggregate
+SELECT COUNT(*), ROW_NUMBER() OVER () FROM emp;
+-- error:
+
+-- test: with group by
+SELECT dept, RANK() OVER (ORDER BY dept) FROM emp GROUP BY dept;
+-- error:
+
+-- test: not a window function
+SELECT LENGTH(dept) OVER () FROM emp;
+-- error:
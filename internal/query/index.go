@@ -0,0 +1,159 @@
+package query
+
+import (
+	"errors"
+
+	"github.com/genjidb/genji/document"
+	errs "github.com/genjidb/genji/errors"
+	"github.com/genjidb/genji/internal/database"
+	"github.com/genjidb/genji/internal/query/statement"
+)
+
+// indexBuildBatchSize is the number of documents indexed by each
+// transaction of CREATE INDEX CONCURRENTLY.
+var indexBuildBatchSize = 1000
+
+// CreateIndexConcurrentlyStmt is a statement that creates an index without blocking
+// writes to its table for the duration of the build.
+//
+// The index is first registered as being built: from then on, writes to the table
+// maintain it but queries don't use it. The keys of the table are then read from a snapshot
+// and the current version of their documents is indexed in batches, each batch in its own
+// transaction, which lets other transactions write to the table between batches.
+// Once every batch is done, the index is marked as built and queries can use it.
+type CreateIndexConcurrentlyStmt struct {
+	statement.CreateIndexStmt
+}
+
+// alterQuery builds the index in several transactions of its own,
+// which is why it cannot run within a transaction.
+func (stmt CreateIndexConcurrentlyStmt) alterQuery(c *Context, q *Query) error {
+	if q.tx != nil {
+		return errors.New("cannot create an index concurrently within a transaction")
+	}
+
+	info := stmt.Info.Clone()
+	info.Building = true
+
+	created, err := stmt.register(c, info)
+	if err != nil || !created {
+		return err
+	}
+
+	err = stmt.build(c, info)
+	if err != nil {
+		// don't leave a partially built index behind
+		_ = stmt.update(c, func(tx *database.Transaction) error {
+			return c.DB.Catalog.DropIndex(tx, info.IndexName)
+		})
+		return err
+	}
+
+	return nil
+}
+
+// register adds the index to the catalog, without building it.
+// It returns false if the index already exists and IF NOT EXISTS was used.
+func (stmt CreateIndexConcurrentlyStmt) register(c *Context, info *database.IndexInfo) (bool, error) {
+	created := true
+
+	err := stmt.update(c, func(tx *database.Transaction) error {
+		err := statement.CheckPrivileges(&statement.Context{
+			Ctx:     c.Ctx,
+			Tx:      tx,
+			Catalog: c.DB.Catalog,
+			Session: c.GetSession(),
+		}, &stmt.CreateIndexStmt)
+		if err != nil {
+			return err
+		}
+
+		err = c.DB.Catalog.CreateIndex(tx, info)
+		if _, ok := err.(errs.AlreadyExistsError); ok && stmt.IfNotExists {
+			created = false
+			return nil
+		}
+		return err
+	})
+
+	return created, err
+}
+
+// build indexes the documents of the table in batches, then marks the index as built.
+func (stmt CreateIndexConcurrentlyStmt) build(c *Context, info *database.IndexInfo) error {
+	keys, err := stmt.readKeys(c, info.TableName)
+	if err != nil {
+		return err
+	}
+
+	for len(keys) > 0 {
+		n := indexBuildBatchSize
+		if n > len(keys) {
+			n = len(keys)
+		}
+
+		err = stmt.update(c, func(tx *database.Transaction) error {
+			return c.DB.Catalog.IndexDocuments(tx, info.IndexName, keys[:n])
+		})
+		if err != nil {
+			return err
+		}
+
+		keys = keys[n:]
+	}
+
+	return stmt.update(c, func(tx *database.Transaction) error {
+		return c.DB.Catalog.FinishIndexBuild(tx, info.IndexName)
+	})
+}
+
+// readKeys returns the keys of the documents of the table, read from a snapshot.
+// Documents written after the snapshot are indexed by the writes themselves.
+func (stmt CreateIndexConcurrentlyStmt) readKeys(c *Context, tableName string) ([][]byte, error) {
+	tx, err := c.DB.BeginTx(c.Ctx, &database.TxOptions{
+		ReadOnly: true,
+	})
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	tb, err := c.DB.Catalog.GetTable(tx, tableName)
+	if err != nil {
+		return nil, err
+	}
+
+	var keys [][]byte
+	err = tb.Iterate(c.Ctx, func(d document.Document) error {
+		keys = append(keys, append([]byte{}, d.(document.Keyer).RawKey()...))
+		return nil
+	})
+
+	return keys, err
+}
+
+// update runs fn in a read/write transaction and commits it.
+func (stmt CreateIndexConcurrentlyStmt) update(c *Context, fn func(tx *database.Transaction) error) error {
+	tx, err := c.DB.BeginTx(c.Ctx, &database.TxOptions{
+		Timeout: c.GetSession().TransactionTimeout(),
+	})
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	err = fn(tx)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (stmt CreateIndexConcurrentlyStmt) IsReadOnly() bool {
+	return false
+}
+
+func (stmt CreateIndexConcurrentlyStmt) Run(ctx *statement.Context) (statement.Result, error) {
+	return statement.Result{}, errors.New("cannot create an index concurrently within a transaction")
+}
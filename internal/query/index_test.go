@@ -0,0 +1,155 @@
+package query_test
+
+import (
+	"bytes"
+	"sync"
+	"testing"
+
+	"github.com/genjidb/genji"
+	"github.com/genjidb/genji/internal/query"
+	"github.com/genjidb/genji/internal/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCreateIndexConcurrently(t *testing.T) {
+	defer query.SetIndexBuildBatchSize(10)()
+
+	queryJSON := func(t *testing.T, db *genji.DB, q string) string {
+		t.Helper()
+
+		res, err := db.Query(q)
+		require.NoError(t, err)
+		defer res.Close()
+
+		var buf bytes.Buffer
+		err = testutil.IteratorToJSONArray(&buf, res)
+		require.NoError(t, err)
+		return buf.String()
+	}
+
+	setup := func(t *testing.T) *genji.DB {
+		t.Helper()
+
+		db, err := genji.Open(":memory:")
+		require.NoError(t, err)
+
+		err = db.Exec("CREATE TABLE test (k INTEGER PRIMARY KEY, a INTEGER)")
+		require.NoError(t, err)
+		for i := 0; i < 100; i++ {
+			err = db.Exec("INSERT INTO test (k, a) VALUES (?, ?)", i, i%10)
+			require.NoError(t, err)
+		}
+
+		return db
+	}
+
+	t.Run("OK", func(t *testing.T) {
+		db := setup(t)
+		defer db.Close()
+
+		err := db.Exec("CREATE INDEX CONCURRENTLY idx_a ON test (a)")
+		require.NoError(t, err)
+
+		require.JSONEq(t, `[{"sql": "CREATE INDEX idx_a ON test (a)"}]`,
+			queryJSON(t, db, "SELECT sql FROM __genji_catalog WHERE type = 'index'"))
+		require.JSONEq(t, `[{"plan": "indexScan(\"idx_a\", 3, covering) | project(k)"}]`,
+			queryJSON(t, db, "EXPLAIN SELECT k FROM test WHERE a = 3"))
+		require.JSONEq(t, `[{"k": 3}, {"k": 13}, {"k": 23}, {"k": 33}, {"k": 43}, {"k": 53}, {"k": 63}, {"k": 73}, {"k": 83}, {"k": 93}]`,
+			queryJSON(t, db, "SELECT k FROM test WHERE a = 3"))
+	})
+
+	t.Run("Within a transaction", func(t *testing.T) {
+		db := setup(t)
+		defer db.Close()
+
+		err := db.Exec("BEGIN")
+		require.NoError(t, err)
+		defer db.Exec("ROLLBACK")
+
+		err = db.Exec("CREATE INDEX CONCURRENTLY idx_a ON test (a)")
+		require.EqualError(t, err, "cannot create an index concurrently within a transaction")
+	})
+
+	t.Run("If not exists", func(t *testing.T) {
+		db := setup(t)
+		defer db.Close()
+
+		err := db.Exec("CREATE INDEX idx_a ON test (k)")
+		require.NoError(t, err)
+
+		err = db.Exec("CREATE INDEX CONCURRENTLY idx_a ON test (a)")
+		require.Error(t, err)
+
+		err = db.Exec("CREATE INDEX CONCURRENTLY IF NOT EXISTS idx_a ON test (a)")
+		require.NoError(t, err)
+
+		require.JSONEq(t, `[{"sql": "CREATE INDEX idx_a ON test (k)"}]`,
+			queryJSON(t, db, "SELECT sql FROM __genji_catalog WHERE type = 'index'"))
+	})
+
+	t.Run("Unique violation", func(t *testing.T) {
+		db := setup(t)
+		defer db.Close()
+
+		// the duplicates are found by the batches, the index is dropped
+		err := db.Exec("CREATE UNIQUE INDEX CONCURRENTLY idx_a ON test (a)")
+		require.Error(t, err)
+
+		require.JSONEq(t, `[]`, queryJSON(t, db, "SELECT sql FROM __genji_catalog WHERE type = 'index'"))
+
+		err = db.Exec("INSERT INTO test (k, a) VALUES (100, 1)")
+		require.NoError(t, err)
+	})
+
+	t.Run("Concurrent writes", func(t *testing.T) {
+		db := setup(t)
+		defer db.Close()
+
+		// one transaction per document gives the writes more room between the batches
+		defer query.SetIndexBuildBatchSize(1)()
+
+		started, done := make(chan struct{}), make(chan struct{})
+		var wg sync.WaitGroup
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			for i := 0; ; i++ {
+				select {
+				case <-done:
+					return
+				default:
+				}
+
+				var err error
+				switch i % 3 {
+				case 0:
+					err = db.Exec("INSERT INTO test (k, a) VALUES (?, ?)", 100+i, i%10)
+				case 1:
+					err = db.Exec("UPDATE test SET a = a + 1 WHERE k = ?", i%100)
+				case 2:
+					err = db.Exec("DELETE FROM test WHERE k = ?", (i*7)%100)
+				}
+				if err != nil {
+					t.Error(err)
+					return
+				}
+				if i == 0 {
+					close(started)
+				}
+			}
+		}()
+		<-started
+
+		err := db.Exec("CREATE INDEX CONCURRENTLY idx_a ON test (a)")
+		close(done)
+		wg.Wait()
+		require.NoError(t, err)
+
+		// the index contains the same documents as the table
+		require.JSONEq(t, `[{"plan": "indexScan(\"idx_a\", [-1, -1, true], covering) | project(k)"}]`,
+			queryJSON(t, db, "EXPLAIN SELECT k FROM test WHERE a > -1"))
+		require.Equal(t, queryJSON(t, db, "SELECT k FROM test WHERE a + 0 > -1 ORDER BY k"),
+			queryJSON(t, db, "SELECT k FROM test WHERE a > -1 ORDER BY k"))
+	})
+}
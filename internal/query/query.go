@@ -14,6 +14,11 @@ import (
 // Results are returned as streams.
 type Query struct {
 	Statements []statement.Statement
+	// NumParams is the number of positional parameters used by the statements.
+	NumParams int
+	// ParamNames are the names of the named parameters used by the statements,
+	// in order of first appearance.
+	ParamNames []string
 	tx         *database.Transaction
 	autoCommit bool
 }
@@ -24,18 +29,32 @@ func New(statements ...statement.Statement) Query {
 }
 
 type Context struct {
-	Ctx    context.Context
-	DB     *database.Database
-	Tx     *database.Transaction
-	Params []environment.Param
+	Ctx      context.Context
+	DB       *database.Database
+	Tx       *database.Transaction
+	Session  *database.Session
+	Params   []environment.Param
+	PlanHook statement.PlanHook
 }
 
+// GetTx returns the transaction of the query, or the transaction
+// attached to the session by a BEGIN statement, if any.
 func (c *Context) GetTx() *database.Transaction {
 	if c.Tx != nil {
 		return c.Tx
 	}
 
-	return c.DB.GetAttachedTx()
+	return c.GetSession().Tx()
+}
+
+// GetSession returns the session of the query, or
+// the default session of the database if it is nil.
+func (c *Context) GetSession() *database.Session {
+	if c.Session != nil {
+		return c.Session
+	}
+
+	return c.DB.Session
 }
 
 // Run executes all the statements in their own transaction and returns the last result.
@@ -61,7 +80,7 @@ func (q Query) Run(context *Context) (*statement.Result, error) {
 		res = statement.Result{}
 
 		if qa, ok := stmt.(queryAlterer); ok {
-			err = qa.alterQuery(ctx, context.DB, &q)
+			err = qa.alterQuery(context, &q)
 			if err != nil {
 				if tx := context.GetTx(); tx != nil {
 					tx.Rollback()
@@ -75,17 +94,29 @@ func (q Query) Run(context *Context) (*statement.Result, error) {
 		if q.tx == nil {
 			q.tx, err = context.DB.BeginTx(ctx, &database.TxOptions{
 				ReadOnly: stmt.IsReadOnly(),
+				Timeout:  context.GetSession().TransactionTimeout(),
 			})
 			if err != nil {
 				return nil, err
 			}
 		}
 
-		res, err = stmt.Run(&statement.Context{
-			Tx:      q.tx,
-			Catalog: context.DB.Catalog,
-			Params:  context.Params,
-		})
+		stmtCtx := statement.Context{
+			Ctx:      ctx,
+			Tx:       q.tx,
+			Catalog:  context.DB.Catalog,
+			Session:  context.GetSession(),
+			Params:   context.Params,
+			PlanHook: context.PlanHook,
+		}
+
+		err = q.tx.CheckDeadline()
+		if err == nil {
+			err = statement.CheckPrivileges(&stmtCtx, stmt)
+		}
+		if err == nil {
+			res, err = stmt.Run(&stmtCtx)
+		}
 		if err != nil {
 			if q.autoCommit {
 				q.tx.Rollback()
@@ -136,7 +167,7 @@ func (q Query) Run(context *Context) (*statement.Result, error) {
 }
 
 type queryAlterer interface {
-	alterQuery(ctx context.Context, db *database.Database, q *Query) error
+	alterQuery(c *Context, q *Query) error
 }
 
 // Prepare the statements by calling their Prepare methods.
@@ -173,8 +204,11 @@ func (q Query) Prepare(context *Context) error {
 		}
 
 		err = p.Prepare(&statement.Context{
-			Tx:      tx,
-			Catalog: context.DB.Catalog,
+			Ctx:      ctx,
+			Tx:       tx,
+			Catalog:  context.DB.Catalog,
+			Session:  context.GetSession(),
+			PlanHook: context.PlanHook,
 		})
 		if err != nil {
 			return err
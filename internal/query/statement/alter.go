@@ -3,8 +3,11 @@ package statement
 import (
 	"errors"
 
+	"github.com/genjidb/genji/document"
 	errs "github.com/genjidb/genji/errors"
 	"github.com/genjidb/genji/internal/database"
+	"github.com/genjidb/genji/internal/expr"
+	"github.com/genjidb/genji/internal/stringutil"
 )
 
 // AlterStmt is a DSL that allows creating a full ALTER TABLE query.
@@ -62,6 +65,191 @@ func (stmt AlterTableAddField) Run(ctx *Context) (Result, error) {
 		return res, errors.New("missing field name")
 	}
 
+	// existing documents would not have a value for the field
+	if stmt.Constraint.IsGenerated() {
+		return res, stringutil.Errorf("cannot add generated field %q to an existing table", stmt.Constraint.Path)
+	}
+
 	err := ctx.Catalog.AddFieldConstraint(ctx.Tx, stmt.TableName, stmt.Constraint)
 	return res, err
 }
+
+// AlterTableAlterField changes the constraints of a field of an existing table.
+// Existing documents are validated against the new constraints, and converted if the type changes.
+type AlterTableAlterField struct {
+	TableName string
+	Path      document.Path
+
+	// SetNotNull and DropNotNull add or remove the NOT NULL constraint.
+	SetNotNull  bool
+	DropNotNull bool
+
+	// If SetType is true, the type of the field is replaced by Type,
+	// alongside its parameters, i.e. the maximum length of VARCHAR(255).
+	SetType   bool
+	Type      document.ValueType
+	MaxLength int
+	Dimension int
+	Precision int
+	Scale     int
+}
+
+// IsReadOnly always returns false. It implements the Statement interface.
+func (stmt AlterTableAlterField) IsReadOnly() bool {
+	return false
+}
+
+// Run runs the ALTER TABLE ALTER FIELD statement in the given transaction.
+// It implements the Statement interface.
+func (stmt AlterTableAlterField) Run(ctx *Context) (Result, error) {
+	var res Result
+
+	if stmt.TableName == "" {
+		return res, errors.New("missing table name")
+	}
+
+	if stmt.Path == nil {
+		return res, errors.New("missing field name")
+	}
+
+	ti, err := ctx.Catalog.GetTableInfo(ctx.Tx, stmt.TableName)
+	if err != nil {
+		return res, err
+	}
+
+	fc := database.FieldConstraint{Path: stmt.Path}
+	if existing := ti.FieldConstraints.Get(stmt.Path); existing != nil {
+		fc = *existing
+	}
+
+	switch {
+	case stmt.SetNotNull:
+		fc.IsNotNull = true
+	case stmt.DropNotNull:
+		if fc.IsPrimaryKey {
+			return res, stringutil.Errorf("cannot drop the NOT NULL constraint of primary key %q", fc.Path)
+		}
+		fc.IsNotNull = false
+	case stmt.SetType:
+		// documents are stored by primary key, and identities are generated by integer sequences
+		if fc.IsPrimaryKey {
+			return res, stringutil.Errorf("cannot change the type of primary key %q", fc.Path)
+		}
+		if fc.Identity != nil {
+			return res, stringutil.Errorf("cannot change the type of SERIAL field %q", fc.Path)
+		}
+
+		fc.Type = stmt.Type
+		fc.MaxLength = stmt.MaxLength
+		fc.Dimension = stmt.Dimension
+		fc.Precision = stmt.Precision
+		fc.Scale = stmt.Scale
+	}
+
+	err = ctx.Catalog.AlterFieldConstraint(ctx.Tx, stmt.TableName, fc)
+	return res, err
+}
+
+// AlterTableDropConstraint removes a named table constraint, i.e. ALTER TABLE t DROP CONSTRAINT c,
+// alongside the index enforcing it.
+type AlterTableDropConstraint struct {
+	TableName      string
+	ConstraintName string
+}
+
+// IsReadOnly always returns false. It implements the Statement interface.
+func (stmt AlterTableDropConstraint) IsReadOnly() bool {
+	return false
+}
+
+// Run runs the ALTER TABLE DROP CONSTRAINT statement in the given transaction.
+// It implements the Statement interface.
+func (stmt AlterTableDropConstraint) Run(ctx *Context) (Result, error) {
+	var res Result
+
+	if stmt.TableName == "" {
+		return res, errors.New("missing table name")
+	}
+
+	if stmt.ConstraintName == "" {
+		return res, errors.New("missing constraint name")
+	}
+
+	err := ctx.Catalog.DropTableConstraint(ctx.Tx, stmt.TableName, stmt.ConstraintName)
+	return res, err
+}
+
+// AlterTableRenameField renames a field of an existing table, i.e. ALTER TABLE t RENAME FIELD a.b TO c.
+// The stored documents are rewritten, and the constraints and indexes refer to the new name.
+type AlterTableRenameField struct {
+	TableName string
+	Path      document.Path
+	NewName   string
+}
+
+// IsReadOnly always returns false. It implements the Statement interface.
+func (stmt AlterTableRenameField) IsReadOnly() bool {
+	return false
+}
+
+// Run runs the ALTER TABLE RENAME FIELD statement in the given transaction.
+// It implements the Statement interface.
+func (stmt AlterTableRenameField) Run(ctx *Context) (Result, error) {
+	var res Result
+
+	if stmt.TableName == "" {
+		return res, errors.New("missing table name")
+	}
+
+	if len(stmt.Path) == 0 || stmt.NewName == "" {
+		return res, errors.New("missing field name")
+	}
+
+	last := stmt.Path[len(stmt.Path)-1]
+	if last.FieldName == "" {
+		return res, stringutil.Errorf("cannot rename array element %q", stmt.Path)
+	}
+	if last.FieldName == stmt.NewName {
+		return res, errs.AlreadyExistsError{Name: stmt.Path.String()}
+	}
+
+	newPath := stmt.Path.Clone()
+	newPath[len(newPath)-1].FieldName = stmt.NewName
+
+	ti, err := ctx.Catalog.GetTableInfo(ctx.Tx, stmt.TableName)
+	if err != nil {
+		return res, err
+	}
+
+	// expressions of the table are not rewritten
+	for _, fc := range ti.FieldConstraints {
+		for _, te := range []database.TableExpression{fc.DefaultValue, fc.Generated} {
+			if te != nil && refersToPath(te.(*expr.ConstraintExpr).Expr, stmt.Path) {
+				return res, stringutil.Errorf("cannot rename field %q: it is referred to by the definition of field %q", stmt.Path, fc.Path)
+			}
+		}
+	}
+
+	err = ctx.Catalog.RenameField(ctx.Tx, stmt.TableName, stmt.Path, newPath)
+	return res, err
+}
+
+// refersToPath returns true if e refers to p, to a field it contains or to a field containing it.
+func refersToPath(e expr.Expr, p document.Path) bool {
+	var found bool
+	expr.Walk(e, func(e expr.Expr) bool {
+		ep, ok := e.(expr.Path)
+		if !ok {
+			return true
+		}
+
+		n := len(ep)
+		if len(p) < n {
+			n = len(p)
+		}
+		found = document.Path(ep[:n]).IsEqual(p[:n])
+		return !found
+	})
+
+	return found
+}
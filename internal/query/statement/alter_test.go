@@ -44,4 +44,184 @@ func TestAlterTable(t *testing.T) {
 	// Renaming a read-only table should fail
 	err = db.Exec("ALTER TABLE __genji_catalog RENAME TO bar")
 	require.Error(t, err)
+
+	// Generated fields cannot be added to existing tables
+	err = db.Exec("ALTER TABLE bar ADD FIELD older INT AS (age + 1)")
+	require.EqualError(t, err, `cannot add generated field "older" to an existing table`)
+}
+
+func TestAlterTableAlterField(t *testing.T) {
+	db, err := genji.Open(":memory:")
+	require.NoError(t, err)
+	defer db.Close()
+
+	err = db.Exec(`
+		CREATE TABLE foo (id INT PRIMARY KEY, a TEXT, b DOUBLE);
+		CREATE INDEX foo_b_idx ON foo (b);
+		INSERT INTO foo (id, a, b) VALUES (1, 'x', 1.0), (2, null, 2.0), (3, 'z', 3.0);
+	`)
+	require.NoError(t, err)
+
+	// existing documents must validate against the new constraint
+	err = db.Exec("ALTER TABLE foo ALTER FIELD a SET NOT NULL")
+	require.Error(t, err)
+
+	err = db.Exec("UPDATE foo SET a = 'y' WHERE id = 2")
+	require.NoError(t, err)
+	err = db.Exec("ALTER TABLE foo ALTER FIELD a SET NOT NULL")
+	require.NoError(t, err)
+
+	err = db.Exec("INSERT INTO foo (id, b) VALUES (4, 4.0)")
+	require.Error(t, err)
+
+	// documents are converted when the type changes
+	err = db.Exec("ALTER TABLE foo ALTER FIELD b TYPE INTEGER")
+	require.NoError(t, err)
+
+	// integer division
+	d, err := db.QueryDocument("SELECT b, b / 4 AS q FROM foo WHERE id = 2")
+	require.NoError(t, err)
+	data, err := document.MarshalJSON(d)
+	require.NoError(t, err)
+	require.JSONEq(t, `{"b": 2, "q": 0}`, string(data))
+
+	// indexes are rebuilt with the new type
+	d, err = db.QueryDocument("SELECT id FROM foo WHERE b = 3")
+	require.NoError(t, err)
+	data, err = document.MarshalJSON(d)
+	require.NoError(t, err)
+	require.JSONEq(t, `{"id": 3}`, string(data))
+
+	// values that cannot be converted are rejected
+	err = db.Exec("ALTER TABLE foo ALTER FIELD a TYPE INTEGER")
+	require.Error(t, err)
+
+	err = db.Exec("ALTER TABLE foo ALTER FIELD a DROP NOT NULL")
+	require.NoError(t, err)
+	err = db.Exec("INSERT INTO foo (id, b) VALUES (4, 4)")
+	require.NoError(t, err)
+
+	d, err = db.QueryDocument("SELECT sql FROM __genji_catalog WHERE name = 'foo'")
+	require.NoError(t, err)
+	data, err = document.MarshalJSON(d)
+	require.NoError(t, err)
+	require.JSONEq(t, `{"sql": "CREATE TABLE foo (id INTEGER PRIMARY KEY, a TEXT, b INTEGER)"}`, string(data))
+
+	// primary keys cannot be altered
+	err = db.Exec("ALTER TABLE foo ALTER FIELD id TYPE TEXT")
+	require.Error(t, err)
+}
+
+func TestAlterTableDropConstraint(t *testing.T) {
+	db, err := genji.Open(":memory:")
+	require.NoError(t, err)
+	defer db.Close()
+
+	err = db.Exec(`
+		CREATE TABLE foo (a INT, b INT, CONSTRAINT foo_uniq UNIQUE (a, b));
+		INSERT INTO foo (a, b) VALUES (1, 1);
+	`)
+	require.NoError(t, err)
+
+	err = db.Exec("INSERT INTO foo (a, b) VALUES (1, 1)")
+	require.True(t, errors.Is(err, errs.ErrDuplicateDocument))
+
+	err = db.Exec("ALTER TABLE foo DROP CONSTRAINT foo_uniq")
+	require.NoError(t, err)
+
+	err = db.Exec("INSERT INTO foo (a, b) VALUES (1, 1)")
+	require.NoError(t, err)
+
+	d, err := db.QueryDocument("SELECT COUNT(*) AS n FROM __genji_catalog WHERE name = 'foo_uniq'")
+	require.NoError(t, err)
+	data, err := document.MarshalJSON(d)
+	require.NoError(t, err)
+	require.JSONEq(t, `{"n": 0}`, string(data))
+
+	err = db.Exec("ALTER TABLE foo DROP CONSTRAINT foo_uniq")
+	require.True(t, errs.IsNotFoundError(err))
+}
+
+func TestAlterTableRenameField(t *testing.T) {
+	db, err := genji.Open(":memory:")
+	require.NoError(t, err)
+	defer db.Close()
+
+	err = db.Exec(`
+		CREATE TABLE foo (id SERIAL PRIMARY KEY, a.b TEXT UNIQUE, c INT, d INT AS (c + 1), UNIQUE (a.b, c));
+		CREATE INDEX foo_c_idx ON foo (c);
+		INSERT INTO foo (a, c) VALUES ({b: 'x', e: 1}, 1), ({b: 'y'}, 2);
+	`)
+	require.NoError(t, err)
+
+	err = db.Exec("ALTER TABLE foo RENAME FIELD a TO z")
+	require.NoError(t, err)
+
+	// documents are rewritten
+	d, err := db.QueryDocument("SELECT * FROM foo WHERE id = 1")
+	require.NoError(t, err)
+	data, err := document.MarshalJSON(d)
+	require.NoError(t, err)
+	require.JSONEq(t, `{"id": 1, "c": 1, "d": 2, "z": {"b": "x", "e": 1}}`, string(data))
+
+	// constraints and indexes use the new name
+	d, err = db.QueryDocument("SELECT sql FROM __genji_catalog WHERE name = 'foo'")
+	require.NoError(t, err)
+	data, err = document.MarshalJSON(d)
+	require.NoError(t, err)
+	require.JSONEq(t, `{"sql": "CREATE TABLE foo (id INTEGER PRIMARY KEY DEFAULT NEXT VALUE FOR foo_id_seq, z.b TEXT UNIQUE, c INTEGER, d INTEGER AS (c + 1) STORED, CONSTRAINT `+"`foo_a.b_c_idx`"+` UNIQUE (z.b, c))"}`, string(data))
+
+	err = db.Exec("INSERT INTO foo (z, c) VALUES ({b: 'x'}, 4)")
+	require.True(t, errors.Is(err, errs.ErrDuplicateDocument))
+
+	d, err = db.QueryDocument("SELECT id FROM foo WHERE z.b = 'y' AND c = 2")
+	require.NoError(t, err)
+	data, err = document.MarshalJSON(d)
+	require.NoError(t, err)
+	require.JSONEq(t, `{"id": 2}`, string(data))
+
+	// fields referred to by generated fields cannot be renamed
+	err = db.Exec("ALTER TABLE foo RENAME FIELD c TO y")
+	require.Error(t, err)
+
+	// fields cannot be renamed to an existing field
+	err = db.Exec("ALTER TABLE foo RENAME FIELD z.e TO b")
+	require.Error(t, err)
+}
+
+func TestAlterTableRenameWithSerial(t *testing.T) {
+	db, err := genji.Open(":memory:")
+	require.NoError(t, err)
+	defer db.Close()
+
+	err = db.Exec(`
+		CREATE TABLE foo(id SERIAL PRIMARY KEY, a TEXT UNIQUE);
+		INSERT INTO foo (a) VALUES ('a');
+		ALTER TABLE foo RENAME TO bar;
+		INSERT INTO bar (a) VALUES ('b');
+	`)
+	require.NoError(t, err)
+
+	// the sequence is now owned by the renamed table
+	d, err := db.QueryDocument("SELECT owner.table_name FROM __genji_catalog WHERE name = 'foo_id_seq'")
+	require.NoError(t, err)
+	data, err := document.MarshalJSON(d)
+	require.NoError(t, err)
+	require.JSONEq(t, `{"owner.table_name": "bar"}`, string(data))
+
+	// and dropped along with it
+	err = db.Exec("DROP TABLE bar")
+	require.NoError(t, err)
+
+	res, err := db.Query("SELECT name FROM __genji_catalog WHERE name = 'foo_id_seq' OR name = 'foo_a_idx'")
+	require.NoError(t, err)
+	defer res.Close()
+
+	var n int
+	err = res.Iterate(func(d document.Document) error {
+		n++
+		return nil
+	})
+	require.NoError(t, err)
+	require.Zero(t, n)
 }
@@ -0,0 +1,42 @@
+package statement
+
+import (
+	"strings"
+
+	"github.com/genjidb/genji/internal/database"
+)
+
+// AnalyzeStmt is a DSL that allows creating a full ANALYZE statement.
+type AnalyzeStmt struct {
+	TableName string
+}
+
+// IsReadOnly always returns false. It implements the Statement interface.
+func (stmt AnalyzeStmt) IsReadOnly() bool {
+	return false
+}
+
+// Run computes the statistics of the selected table, or of every table
+// if no table was selected.
+// It implements the Statement interface.
+func (stmt AnalyzeStmt) Run(ctx *Context) (Result, error) {
+	var res Result
+
+	if stmt.TableName != "" {
+		_, err := database.AnalyzeTable(ctx.Tx, ctx.Catalog, stmt.TableName)
+		return res, err
+	}
+
+	for _, tableName := range ctx.Catalog.ListTables(ctx.Tx) {
+		if strings.HasPrefix(tableName, database.InternalPrefix) {
+			continue
+		}
+
+		_, err := database.AnalyzeTable(ctx.Tx, ctx.Catalog, tableName)
+		if err != nil {
+			return res, err
+		}
+	}
+
+	return res, nil
+}
@@ -0,0 +1,129 @@
+package statement_test
+
+import (
+	"testing"
+
+	"github.com/genjidb/genji"
+	"github.com/genjidb/genji/internal/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAnalyze(t *testing.T) {
+	requireStats := func(t *testing.T, db *genji.DB, tableName string, expected string) {
+		d, err := db.QueryDocument(`
+			SELECT table_name, row_count, modifications, indexes.idx_a.distinct_values AS idx_a
+			FROM __genji_stat WHERE table_name = ?`, tableName)
+		require.NoError(t, err)
+		testutil.RequireDocJSONEq(t, d, expected)
+	}
+
+	setup := func(t *testing.T) *genji.DB {
+		db, err := genji.Open(":memory:")
+		require.NoError(t, err)
+
+		err = db.Exec(`
+			CREATE TABLE test(a INTEGER);
+			CREATE INDEX idx_a ON test(a);
+			INSERT INTO test (a) VALUES (1), (1), (2), (3);
+			CREATE TABLE other;
+		`)
+		require.NoError(t, err)
+		return db
+	}
+
+	t.Run("Table", func(t *testing.T) {
+		db := setup(t)
+		defer db.Close()
+
+		err := db.Exec("ANALYZE test")
+		require.NoError(t, err)
+
+		requireStats(t, db, "test", `{"table_name": "test", "row_count": 4, "modifications": 0, "idx_a": 3}`)
+
+		_, err = db.QueryDocument("SELECT * FROM __genji_stat WHERE table_name = 'other'")
+		require.Error(t, err)
+	})
+
+	t.Run("Histogram", func(t *testing.T) {
+		db := setup(t)
+		defer db.Close()
+
+		err := db.Exec("ANALYZE test")
+		require.NoError(t, err)
+
+		// equal values are in the same bucket
+		d, err := db.QueryDocument(`
+			SELECT indexes.idx_a.entries, indexes.idx_a.histogram[0].count AS b0,
+				indexes.idx_a.histogram[1].count AS b1, indexes.idx_a.histogram[2].count AS b2,
+				indexes.idx_a.histogram[3] AS b3
+			FROM __genji_stat WHERE table_name = 'test'`)
+		require.NoError(t, err)
+		testutil.RequireDocJSONEq(t, d, `{"indexes.idx_a.entries": 4, "b0": 2, "b1": 1, "b2": 1, "b3": null}`)
+	})
+
+	t.Run("All tables", func(t *testing.T) {
+		db := setup(t)
+		defer db.Close()
+
+		err := db.Exec("ANALYZE")
+		require.NoError(t, err)
+
+		requireStats(t, db, "other", `{"table_name": "other", "row_count": 0, "modifications": 0, "idx_a": null}`)
+	})
+
+	t.Run("Unknown table", func(t *testing.T) {
+		db := setup(t)
+		defer db.Close()
+
+		err := db.Exec("ANALYZE foo")
+		require.Error(t, err)
+	})
+
+	t.Run("Automatic refresh", func(t *testing.T) {
+		db := setup(t)
+		defer db.Close()
+
+		err := db.Exec("PRAGMA auto_analyze_threshold = 0.5; ANALYZE test")
+		require.NoError(t, err)
+
+		// 1 modification out of 4 documents is below the threshold
+		err = db.Exec("INSERT INTO test (a) VALUES (4)")
+		require.NoError(t, err)
+		requireStats(t, db, "test", `{"table_name": "test", "row_count": 4, "modifications": 1, "idx_a": 3}`)
+
+		// rolled back modifications are ignored
+		tx, err := db.Begin(true)
+		require.NoError(t, err)
+		err = tx.Exec("DELETE FROM test")
+		require.NoError(t, err)
+		require.NoError(t, tx.Rollback())
+		requireStats(t, db, "test", `{"table_name": "test", "row_count": 4, "modifications": 1, "idx_a": 3}`)
+
+		// 2 modifications out of 4 documents reach the threshold
+		err = db.Exec("UPDATE test SET a = 5 WHERE a = 4")
+		require.NoError(t, err)
+		requireStats(t, db, "test", `{"table_name": "test", "row_count": 5, "modifications": 0, "idx_a": 4}`)
+	})
+
+	t.Run("Disabled", func(t *testing.T) {
+		db := setup(t)
+		defer db.Close()
+
+		err := db.Exec("PRAGMA auto_analyze_threshold = 0; ANALYZE test; DELETE FROM test")
+		require.NoError(t, err)
+		requireStats(t, db, "test", `{"table_name": "test", "row_count": 4, "modifications": 4, "idx_a": 3}`)
+	})
+
+	t.Run("Drop and rename", func(t *testing.T) {
+		db := setup(t)
+		defer db.Close()
+
+		err := db.Exec("ANALYZE; ALTER TABLE test RENAME TO test2; DROP TABLE other")
+		require.NoError(t, err)
+
+		requireStats(t, db, "test2", `{"table_name": "test2", "row_count": 4, "modifications": 0, "idx_a": 3}`)
+
+		_, err = db.QueryDocument("SELECT * FROM __genji_stat WHERE table_name IN ['test', 'other']")
+		require.Error(t, err)
+	})
+}
@@ -0,0 +1,82 @@
+package statement
+
+import (
+	"bufio"
+	"os"
+
+	"github.com/genjidb/genji/document"
+	"github.com/genjidb/genji/internal/stream"
+)
+
+// CopyStmt copies the documents of a JSON file into a table, or
+// the documents of a table or returned by a query into a JSON Lines file.
+type CopyStmt struct {
+	TableName string
+	// Query is the statement whose documents are copied to the file,
+	// if the documents of a table are copied to the file, it scans the table.
+	Query *StreamStmt
+	Path  string
+	// To is set if the documents are copied to the file.
+	To bool
+}
+
+// IsReadOnly reports whether the statement copies documents to a file.
+// It implements the Statement interface.
+func (stmt *CopyStmt) IsReadOnly() bool {
+	return stmt.To
+}
+
+// Prepare prepares the query whose documents are copied to the file.
+// It implements the Preparer interface.
+func (stmt *CopyStmt) Prepare(ctx *Context) error {
+	if !stmt.To {
+		return nil
+	}
+
+	return stmt.Query.Prepare(ctx)
+}
+
+// Run copies the documents.
+// Documents copied from a file are inserted as the result is iterated,
+// whereas documents copied to a file are all written before Run returns.
+// It implements the Statement interface.
+func (stmt *CopyStmt) Run(ctx *Context) (Result, error) {
+	if !stmt.To {
+		s := StreamStmt{
+			Stream: stream.New(stream.JSONFile(stmt.Path)).Pipe(stream.TableInsert(stmt.TableName, nil)),
+		}
+		return s.Run(ctx)
+	}
+
+	res, err := stmt.Query.Run(ctx)
+	if err != nil {
+		return Result{}, err
+	}
+
+	f, err := os.Create(stmt.Path)
+	if err != nil {
+		return Result{}, err
+	}
+	defer f.Close()
+
+	w := bufio.NewWriter(f)
+	err = res.Iterate(func(d document.Document) error {
+		data, err := document.MarshalJSON(d)
+		if err != nil {
+			return err
+		}
+
+		if _, err = w.Write(data); err != nil {
+			return err
+		}
+		return w.WriteByte('\n')
+	})
+	if err == nil {
+		err = w.Flush()
+	}
+	if err == nil {
+		err = f.Close()
+	}
+
+	return Result{}, err
+}
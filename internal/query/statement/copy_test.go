@@ -0,0 +1,53 @@
+package statement_test
+
+import (
+	"bytes"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	"github.com/genjidb/genji/internal/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCopy(t *testing.T) {
+	db, tx, cleanup := testutil.NewTestTx(t)
+	defer cleanup()
+
+	dir := t.TempDir()
+	path := filepath.Join(dir, "foo.json")
+
+	testutil.MustExec(t, db, tx, `
+		CREATE TABLE foo (a INT PRIMARY KEY);
+		CREATE TABLE bar (a INT PRIMARY KEY);
+		INSERT INTO foo (a, b) VALUES (1, 'x'), (2, [1, 2]), (3, {c: true});
+	`)
+
+	testutil.MustExec(t, db, tx, "COPY foo TO '"+path+"'")
+	data, err := ioutil.ReadFile(path)
+	require.NoError(t, err)
+	require.Equal(t, `{"a": 1, "b": "x"}
+{"a": 2, "b": [1, 2]}
+{"a": 3, "b": {"c": true}}
+`, string(data))
+
+	testutil.MustExec(t, db, tx, "COPY bar FROM '"+path+"'")
+	res := testutil.MustQuery(t, db, tx, "SELECT * FROM bar")
+	var buf bytes.Buffer
+	err = testutil.IteratorToJSONArray(&buf, res)
+	require.NoError(t, err)
+	require.NoError(t, res.Close())
+	require.JSONEq(t, `[{"a": 1, "b": "x"}, {"a": 2, "b": [1, 2]}, {"a": 3, "b": {"c": true}}]`, buf.String())
+
+	// the constraints of the table are enforced
+	err = testutil.Exec(db, tx, "COPY bar FROM '"+path+"'")
+	require.Error(t, err)
+
+	testutil.MustExec(t, db, tx, "COPY (SELECT a + 10 AS a FROM foo WHERE a > 1) TO '"+path+"'")
+	data, err = ioutil.ReadFile(path)
+	require.NoError(t, err)
+	require.Equal(t, "{\"a\": 12}\n{\"a\": 13}\n", string(data))
+
+	err = testutil.Exec(db, tx, "COPY bar FROM '"+filepath.Join(dir, "missing.json")+"'")
+	require.Error(t, err)
+}
@@ -6,6 +6,7 @@ import (
 	"github.com/genjidb/genji/document"
 	errs "github.com/genjidb/genji/errors"
 	"github.com/genjidb/genji/internal/database"
+	"github.com/genjidb/genji/internal/expr"
 )
 
 // CreateTableStmt represents a parsed CREATE TABLE statement.
@@ -24,6 +25,13 @@ func (stmt *CreateTableStmt) IsReadOnly() bool {
 func (stmt *CreateTableStmt) Run(ctx *Context) (Result, error) {
 	var res Result
 
+	// return early to avoid creating the sequences of an existing table
+	if stmt.IfNotExists {
+		if _, err := ctx.Catalog.GetTableInfo(ctx.Tx, stmt.Info.TableName); err == nil {
+			return res, nil
+		}
+	}
+
 	// if there is no primary key, create a docid sequence
 	if stmt.Info.FieldConstraints.GetPrimaryKey() == nil {
 		seq := database.SequenceInfo{
@@ -43,12 +51,41 @@ func (stmt *CreateTableStmt) Run(ctx *Context) (Result, error) {
 		stmt.Info.DocidSequenceName = seq.Name
 	}
 
+	// create a sequence for every SERIAL field
+	// and use it to generate its default value
+	for _, fc := range stmt.Info.FieldConstraints {
+		if fc.Identity == nil || fc.Identity.SequenceName != "" {
+			continue
+		}
+
+		seq := database.SequenceInfo{
+			IncrementBy: 1,
+			Min:         1, Max: math.MaxInt64,
+			Start: 1,
+			Cache: 64,
+			Owner: database.Owner{
+				TableName: stmt.Info.TableName,
+				Path:      fc.Path,
+			},
+		}
+		err := ctx.Catalog.CreateSequence(ctx.Tx, &seq)
+		if err != nil {
+			return res, err
+		}
+
+		fc.Identity.SequenceName = seq.Name
+		fc.DefaultValue = expr.Constraint(expr.NextValueFor{SeqName: seq.Name})
+	}
+
 	err := ctx.Catalog.CreateTable(ctx.Tx, stmt.Info.TableName, &stmt.Info)
 	if stmt.IfNotExists {
 		if _, ok := err.(errs.AlreadyExistsError); ok {
 			return res, nil
 		}
 	}
+	if err != nil {
+		return res, err
+	}
 
 	// create a unique index for every unique constraint
 	for _, fc := range stmt.Info.FieldConstraints {
@@ -69,6 +106,14 @@ func (stmt *CreateTableStmt) Run(ctx *Context) (Result, error) {
 		}
 	}
 
+	// create a unique index for every table-level unique constraint
+	for _, uc := range stmt.Info.UniqueConstraints {
+		err = ctx.Catalog.CreateIndex(ctx.Tx, uc.IndexInfo(stmt.Info.TableName))
+		if err != nil {
+			return res, err
+		}
+	}
+
 	return res, err
 }
 
@@ -126,3 +171,61 @@ func (stmt *CreateSequenceStmt) Run(ctx *Context) (Result, error) {
 	}
 	return res, err
 }
+
+// CreateViewStmt represents a parsed CREATE VIEW statement.
+type CreateViewStmt struct {
+	IfNotExists bool
+	// OrReplace replaces the query of the view if it already exists.
+	OrReplace bool
+	Info      database.ViewInfo
+}
+
+// IsReadOnly always returns false. It implements the Statement interface.
+func (stmt *CreateViewStmt) IsReadOnly() bool {
+	return false
+}
+
+// Run the statement in the given transaction.
+// It implements the Statement interface.
+func (stmt *CreateViewStmt) Run(ctx *Context) (Result, error) {
+	var res Result
+
+	if stmt.OrReplace {
+		if _, err := ctx.Catalog.GetViewInfo(ctx.Tx, stmt.Info.ViewName); err == nil {
+			return res, ctx.Catalog.ReplaceView(ctx.Tx, &stmt.Info)
+		}
+	}
+
+	err := ctx.Catalog.CreateView(ctx.Tx, &stmt.Info)
+	if stmt.IfNotExists {
+		if _, ok := err.(errs.AlreadyExistsError); ok {
+			return res, nil
+		}
+	}
+	return res, err
+}
+
+// CreateTriggerStmt represents a parsed CREATE TRIGGER statement.
+type CreateTriggerStmt struct {
+	IfNotExists bool
+	Info        database.TriggerInfo
+}
+
+// IsReadOnly always returns false. It implements the Statement interface.
+func (stmt *CreateTriggerStmt) IsReadOnly() bool {
+	return false
+}
+
+// Run the statement in the given transaction.
+// It implements the Statement interface.
+func (stmt *CreateTriggerStmt) Run(ctx *Context) (Result, error) {
+	var res Result
+
+	err := ctx.Catalog.CreateTrigger(ctx.Tx, &stmt.Info)
+	if stmt.IfNotExists {
+		if _, ok := err.(errs.AlreadyExistsError); ok {
+			return res, nil
+		}
+	}
+	return res, err
+}
@@ -1,9 +1,17 @@
 package statement_test
 
 import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
 	"testing"
 
+	"github.com/genjidb/genji"
 	"github.com/genjidb/genji/document"
+	errs "github.com/genjidb/genji/errors"
 	"github.com/genjidb/genji/internal/database"
 	"github.com/genjidb/genji/internal/expr"
 	"github.com/genjidb/genji/internal/sql/parser"
@@ -170,7 +178,7 @@ func TestCreateTable(t *testing.T) {
 					InferredBy: []document.Path{
 						parsePath(t, "foo.a[1][2]"),
 					}},
-				{Path: parsePath(t, "foo.a[1][2]"), Type: document.TextValue, IsNotNull: true},
+				{Path: parsePath(t, "foo.a[1][2]"), Type: document.TextValue, IsNotNull: true, MaxLength: 255},
 				{Path: parsePath(t, "bar"), Type: document.ArrayValue, IsInferred: true,
 					InferredBy: []document.Path{
 						parsePath(t, "bar[4][0].bat"),
@@ -190,7 +198,7 @@ func TestCreateTable(t *testing.T) {
 				{Path: parsePath(t, "m"), Type: document.IntegerValue},
 				{Path: parsePath(t, "eight"), Type: document.IntegerValue},
 				{Path: parsePath(t, "ii"), Type: document.IntegerValue},
-				{Path: parsePath(t, "c"), Type: document.TextValue},
+				{Path: parsePath(t, "c"), Type: document.TextValue, MaxLength: 64},
 			}, tb.Info.FieldConstraints)
 			require.NoError(t, err)
 		})
@@ -276,6 +284,180 @@ func TestCreateTable(t *testing.T) {
 			require.True(t, idx.Info.Unique)
 			require.NoError(t, err)
 		})
+
+		t.Run("serial", func(t *testing.T) {
+			db, tx, cleanup := testutil.NewTestTx(t)
+			defer cleanup()
+
+			testutil.MustExec(t, db, tx, "CREATE TABLE test (id SERIAL PRIMARY KEY, a TEXT)")
+
+			tb, err := db.Catalog.GetTable(tx, "test")
+			require.NoError(t, err)
+			fc := tb.Info.FieldConstraints.Get(parsePath(t, "id"))
+			require.NotNil(t, fc)
+			require.Equal(t, document.IntegerValue, fc.Type)
+			require.True(t, fc.IsPrimaryKey)
+			require.Equal(t, "NEXT VALUE FOR test_id_seq", fc.DefaultValue.String())
+
+			seq, err := db.Catalog.GetSequence(tx, "test_id_seq")
+			require.NoError(t, err)
+			require.Equal(t, database.Owner{TableName: "test", Path: parsePath(t, "id")}, seq.Info.Owner)
+
+			testutil.MustExec(t, db, tx, "INSERT INTO test (a) VALUES ('foo'), ('bar')")
+			res := testutil.MustQuery(t, db, tx, "SELECT id FROM test")
+			var buf bytes.Buffer
+			err = testutil.IteratorToJSONArray(&buf, res)
+			require.NoError(t, err)
+			require.NoError(t, res.Close())
+			require.JSONEq(t, `[{"id": 1}, {"id": 2}]`, buf.String())
+
+			// SERIAL fields cannot have a default value
+			err = testutil.Exec(db, tx, "CREATE TABLE test2 (id SERIAL DEFAULT 10)")
+			require.Error(t, err)
+		})
+
+		t.Run("generated", func(t *testing.T) {
+			db, tx, cleanup := testutil.NewTestTx(t)
+			defer cleanup()
+
+			testutil.MustExec(t, db, tx, `
+				CREATE TABLE test (a INT, b INT AS (a * 2) STORED, c TEXT GENERATED ALWAYS AS (d || '!'), d TEXT);
+				CREATE INDEX test_b_idx ON test(b);
+			`)
+
+			tb, err := db.Catalog.GetTable(tx, "test")
+			require.NoError(t, err)
+			require.Equal(t, "CREATE TABLE test (a INTEGER, b INTEGER AS (a * 2) STORED, c TEXT AS (d || \"!\") STORED, d TEXT)", tb.Info.String())
+
+			// generated values are computed on insert and update
+			testutil.MustExec(t, db, tx, `
+				INSERT INTO test (a, d) VALUES (1, 'foo');
+				INSERT INTO test (a, d) VALUES (2, 'bar');
+				UPDATE test SET a = 5 WHERE a = 1;
+			`)
+
+			// explicit values cannot be inserted into generated fields
+			err = testutil.Exec(db, tx, "INSERT INTO test (a, b, d) VALUES (3, 100, 'baz')")
+			require.EqualError(t, err, `cannot insert a value into generated field "b"`)
+			err = testutil.Exec(db, tx, "INSERT INTO test (a, c) VALUES (3, NULL)")
+			require.EqualError(t, err, `cannot insert a value into generated field "c"`)
+			err = testutil.Exec(db, tx, "INSERT INTO test VALUES {a: 3, b: 6}")
+			require.EqualError(t, err, `cannot insert a value into generated field "b"`)
+			err = testutil.Exec(db, tx, "INSERT INTO test (a, b) VALUES (3, 6) ON CONFLICT DO NOTHING")
+			require.EqualError(t, err, `cannot insert a value into generated field "b"`)
+
+			// nor set by updates
+			err = testutil.Exec(db, tx, "UPDATE test SET c = 'baz' WHERE a = 5")
+			require.EqualError(t, err, `cannot update generated field "c"`)
+			err = testutil.Exec(db, tx, "UPDATE test SET a = 6, b = 3")
+			require.EqualError(t, err, `cannot update generated field "b"`)
+			err = testutil.Exec(db, tx, "UPDATE test UNSET b")
+			require.EqualError(t, err, `cannot update generated field "b"`)
+			err = testutil.Exec(db, tx, "INSERT INTO test (a) VALUES (5) ON CONFLICT DO UPDATE SET b = 1")
+			require.EqualError(t, err, `cannot update generated field "b"`)
+
+			res := testutil.MustQuery(t, db, tx, "SELECT a, b, c FROM test WHERE b > 5")
+			var buf bytes.Buffer
+			err = testutil.IteratorToJSONArray(&buf, res)
+			require.NoError(t, err)
+			require.NoError(t, res.Close())
+			require.JSONEq(t, `[{"a": 5, "b": 10, "c": "foo!"}]`, buf.String())
+
+			// generated fields are indexed
+			res = testutil.MustQuery(t, db, tx, "EXPLAIN SELECT a FROM test WHERE b = 4")
+			buf.Reset()
+			err = testutil.IteratorToJSONArray(&buf, res)
+			require.NoError(t, err)
+			require.NoError(t, res.Close())
+			require.JSONEq(t, `[{"plan": "indexScan(\"test_b_idx\", 4, paths(a)) | project(a)"}]`, buf.String())
+
+			// generated fields cannot have a default value nor refer to other generated fields
+			err = testutil.Exec(db, tx, "CREATE TABLE test2 (a INT, b INT AS (a + 1) DEFAULT 10)")
+			require.Error(t, err)
+			err = testutil.Exec(db, tx, "CREATE TABLE test2 (a INT, b INT AS (a + 1), c INT AS (b + 1))")
+			require.Error(t, err)
+		})
+
+		t.Run("unique constraints", func(t *testing.T) {
+			db, tx, cleanup := testutil.NewTestTx(t)
+			defer cleanup()
+
+			testutil.MustExec(t, db, tx, `
+				CREATE TABLE test (a INT, b TEXT, c INT, UNIQUE (a, b), CONSTRAINT c_uniq UNIQUE (c));
+			`)
+
+			tb, err := db.Catalog.GetTable(tx, "test")
+			require.NoError(t, err)
+			require.Equal(t, "CREATE TABLE test (a INTEGER, b TEXT, c INTEGER, CONSTRAINT test_a_b_idx UNIQUE (a, b), CONSTRAINT c_uniq UNIQUE (c))", tb.Info.String())
+
+			// the table definition can be parsed back
+			stmt, err := parser.ParseQuery(tb.Info.String())
+			require.NoError(t, err)
+			require.Len(t, stmt.Statements, 1)
+
+			// each constraint is enforced by a unique index
+			idx, err := db.Catalog.GetIndexInfo(tx, "test_a_b_idx")
+			require.NoError(t, err)
+			require.True(t, idx.Unique)
+			require.Equal(t, []document.Path{parsePath(t, "a"), parsePath(t, "b")}, idx.Paths)
+			require.Equal(t, "test_a_b_idx", idx.Owner.Constraint)
+			_, err = db.Catalog.GetIndexInfo(tx, "c_uniq")
+			require.NoError(t, err)
+
+			testutil.MustExec(t, db, tx, `
+				INSERT INTO test (a, b, c) VALUES (1, 'a', 1), (1, 'b', 2), (2, 'a', 3);
+			`)
+
+			// violations name the constraint
+			err = testutil.Exec(db, tx, "INSERT INTO test (a, b, c) VALUES (1, 'a', 4)")
+			require.EqualError(t, err, `duplicate document: unique constraint "test_a_b_idx" violated`)
+			require.True(t, errors.Is(err, errs.ErrDuplicateDocument))
+			err = testutil.Exec(db, tx, "UPDATE test SET c = 1 WHERE c = 2")
+			require.EqualError(t, err, `duplicate document: unique constraint "c_uniq" violated`)
+
+			// constraints can be used as conflict targets
+			testutil.MustExec(t, db, tx, "INSERT INTO test (a, b, c) VALUES (1, 'a', 5) ON CONFLICT (b, a) DO UPDATE SET c = 10")
+			res := testutil.MustQuery(t, db, tx, "SELECT c FROM test WHERE a = 1 AND b = 'a'")
+			var buf bytes.Buffer
+			err = testutil.IteratorToJSONArray(&buf, res)
+			require.NoError(t, err)
+			require.NoError(t, res.Close())
+			require.JSONEq(t, `[{"c": 10}]`, buf.String())
+
+			// indexes owned by constraints cannot be dropped
+			err = testutil.Exec(db, tx, "DROP INDEX test_a_b_idx")
+			require.Error(t, err)
+		})
+
+		t.Run("default expressions", func(t *testing.T) {
+			db, tx, cleanup := testutil.NewTestTx(t)
+			defer cleanup()
+
+			testutil.MustExec(t, db, tx, `
+				CREATE TABLE test (a TEXT, b TEXT DEFAULT (substr(a, 1, 2) || '!'), c INT DEFAULT (1 + 2 * 3));
+			`)
+
+			tb, err := db.Catalog.GetTable(tx, "test")
+			require.NoError(t, err)
+			require.Equal(t, "CREATE TABLE test (a TEXT, b TEXT DEFAULT (substr(a, 1, 2) || \"!\"), c INTEGER DEFAULT (1 + 2 * 3))", tb.Info.String())
+
+			// default values are evaluated against the document being inserted
+			testutil.MustExec(t, db, tx, `
+				INSERT INTO test (a) VALUES ('FOO');
+				INSERT INTO test (a, b) VALUES ('BAR', 'bar');
+				INSERT INTO test (c) VALUES (10);
+			`)
+			res := testutil.MustQuery(t, db, tx, "SELECT a, b, c FROM test")
+			var buf bytes.Buffer
+			err = testutil.IteratorToJSONArray(&buf, res)
+			require.NoError(t, err)
+			require.NoError(t, res.Close())
+			require.JSONEq(t, `[{"a": "FOO", "b": "FO!", "c": 7}, {"a": "BAR", "b": "bar", "c": 7}, {"a": null, "b": null, "c": 10}]`, buf.String())
+
+			// only parenthesized default values can refer to other fields
+			err = testutil.Exec(db, tx, "CREATE TABLE test2 (a TEXT, b TEXT DEFAULT substr(a, 1, 2))")
+			require.Error(t, err)
+		})
 	})
 }
 
@@ -313,6 +495,162 @@ func TestCreateIndex(t *testing.T) {
 	}
 }
 
+func TestCreatePartialIndex(t *testing.T) {
+	db, tx, cleanup := testutil.NewTestTx(t)
+	defer cleanup()
+
+	testutil.MustExec(t, db, tx, `
+		CREATE TABLE test (k INTEGER PRIMARY KEY, a INTEGER, deleted BOOL);
+		INSERT INTO test (k, a, deleted) VALUES (1, 10, false), (2, 10, true), (3, 20, false);
+		CREATE UNIQUE INDEX idx_a ON test (a) WHERE deleted = false;
+		INSERT INTO test (k, a) VALUES (4, 10);
+	`)
+
+	query := func(q string) string {
+		t.Helper()
+
+		res := testutil.MustQuery(t, db, tx, q)
+		var buf bytes.Buffer
+		err := testutil.IteratorToJSONArray(&buf, res)
+		require.NoError(t, err)
+		require.NoError(t, res.Close())
+		return buf.String()
+	}
+
+	// only the documents matching the predicate are indexed
+	require.Len(t, testutil.GetIndexContent(t, tx, db.Catalog, "idx_a"), 2)
+
+	// the index is only used by queries whose filters imply the predicate
+	require.JSONEq(t, `[{"plan": "indexScan(\"idx_a\", 10, paths(deleted, k)) | filter(deleted = false) | project(k)"}]`,
+		query("EXPLAIN SELECT k FROM test WHERE a = 10 AND deleted = false"))
+	require.JSONEq(t, `[{"k": 1}]`, query("SELECT k FROM test WHERE a = 10 AND deleted = false"))
+	require.JSONEq(t, `[{"plan": "seqScan(test, paths(a, k)) | filter(a = 10) | project(k)"}]`,
+		query("EXPLAIN SELECT k FROM test WHERE a = 10"))
+	require.JSONEq(t, `[{"k": 1}, {"k": 2}, {"k": 4}]`, query("SELECT k FROM test WHERE a = 10"))
+
+	// uniqueness is only enforced among the indexed documents
+	testutil.MustExec(t, db, tx, "INSERT INTO test (k, a, deleted) VALUES (5, 10, true)")
+	err := testutil.Exec(db, tx, "INSERT INTO test (k, a, deleted) VALUES (6, 10, false)")
+	require.True(t, errors.Is(err, errs.ErrDuplicateDocument))
+
+	// updates add documents to the index and remove them from it
+	testutil.MustExec(t, db, tx, `
+		UPDATE test SET deleted = true WHERE k = 1;
+		UPDATE test SET deleted = false WHERE k = 2;
+	`)
+	require.JSONEq(t, `[{"k": 2}]`, query("SELECT k FROM test WHERE a = 10 AND deleted = false"))
+
+	testutil.MustExec(t, db, tx, "DELETE FROM test WHERE k = 2")
+	require.JSONEq(t, `[]`, query("SELECT k FROM test WHERE a = 10 AND deleted = false"))
+	require.Len(t, testutil.GetIndexContent(t, tx, db.Catalog, "idx_a"), 1)
+
+	// the fields read by the predicate can't be renamed
+	err = testutil.Exec(db, tx, "ALTER TABLE test RENAME FIELD deleted TO removed")
+	require.Error(t, err)
+}
+
+func TestCreateMultiKeyIndex(t *testing.T) {
+	db, tx, cleanup := testutil.NewTestTx(t)
+	defer cleanup()
+
+	testutil.MustExec(t, db, tx, `
+		CREATE TABLE test (k INTEGER PRIMARY KEY, tags ARRAY);
+		INSERT INTO test (k, tags) VALUES (1, [1, 2.5, 'a']), (2, [2, 1, 1]), (3, []);
+		CREATE INDEX idx_tags ON test (tags[*]);
+		INSERT INTO test (k) VALUES (4);
+	`)
+
+	query := func(q string) string {
+		t.Helper()
+
+		res := testutil.MustQuery(t, db, tx, q)
+		var buf bytes.Buffer
+		err := testutil.IteratorToJSONArray(&buf, res)
+		require.NoError(t, err)
+		require.NoError(t, res.Close())
+		return buf.String()
+	}
+
+	// each distinct element is indexed once, empty and missing arrays are not indexed
+	require.Len(t, testutil.GetIndexContent(t, tx, db.Catalog, "idx_tags"), 5)
+
+	require.JSONEq(t, `[{"plan": "indexScan(\"idx_tags\", 1, paths(k)) | project(k)"}]`,
+		query("EXPLAIN SELECT k FROM test WHERE 1 IN tags"))
+	require.JSONEq(t, `[{"k": 1}, {"k": 2}]`, query("SELECT k FROM test WHERE 1 IN tags"))
+	require.JSONEq(t, `[{"k": 1}]`, query("SELECT k FROM test WHERE tags CONTAINS 'a'"))
+	require.JSONEq(t, `[{"k": 2}]`, query("SELECT k FROM test WHERE tags CONTAINS 2.0"))
+
+	// whole arrays can't be looked up
+	require.JSONEq(t, `[{"plan": "seqScan(test, paths(tags, k)) | filter(tags = [1]) | project(k)"}]`,
+		query("EXPLAIN SELECT k FROM test WHERE tags = [1]"))
+
+	// updates and deletions remove the elements of the previous array
+	testutil.MustExec(t, db, tx, `
+		UPDATE test SET tags = ['b'] WHERE k = 1;
+		DELETE FROM test WHERE k = 2;
+	`)
+	require.JSONEq(t, `[]`, query("SELECT k FROM test WHERE 1 IN tags"))
+	require.JSONEq(t, `[{"k": 1}]`, query("SELECT k FROM test WHERE 'b' IN tags"))
+	require.Len(t, testutil.GetIndexContent(t, tx, db.Catalog, "idx_tags"), 1)
+
+	// unique multi-key indexes forbid sharing elements between documents
+	testutil.MustExec(t, db, tx, "CREATE UNIQUE INDEX idx_tags_unique ON test (tags[*])")
+	testutil.MustExec(t, db, tx, "INSERT INTO test (k, tags) VALUES (5, ['c', 'c'])")
+	err := testutil.Exec(db, tx, "INSERT INTO test (k, tags) VALUES (6, ['d', 'b'])")
+	require.True(t, errors.Is(err, errs.ErrDuplicateDocument))
+}
+
+func TestCreateIndexOrders(t *testing.T) {
+	db, tx, cleanup := testutil.NewTestTx(t)
+	defer cleanup()
+
+	testutil.MustExec(t, db, tx, `
+		CREATE TABLE test (k INTEGER PRIMARY KEY, a INTEGER, b INTEGER);
+		CREATE INDEX idx_a_b ON test (a DESC, b);
+		CREATE INDEX idx_c ON test (c ASC NULLS LAST);
+		INSERT INTO test (k, a, b, c) VALUES (1, 1, 1, 'x'), (2, 2, 1, 1), (3, 2, 2, NULL), (4, 3, 1, 'y');
+	`)
+
+	query := func(q string) string {
+		t.Helper()
+
+		res := testutil.MustQuery(t, db, tx, q)
+		var buf bytes.Buffer
+		err := testutil.IteratorToJSONArray(&buf, res)
+		require.NoError(t, err)
+		require.NoError(t, res.Close())
+		return buf.String()
+	}
+
+	// the order of the paths is part of the definition of the index
+	require.JSONEq(t, `[{"sql": "CREATE INDEX idx_a_b ON test (a DESC, b)"}, {"sql": "CREATE INDEX idx_c ON test (c NULLS LAST)"}]`,
+		query("SELECT sql FROM __genji_catalog WHERE type = 'index' ORDER BY name"))
+
+	// ranges on descending paths are read in descending order
+	require.JSONEq(t, `[{"k": 4}, {"k": 2}, {"k": 3}]`, query("SELECT k FROM test WHERE a > 1"))
+	require.JSONEq(t, `[{"k": 2}, {"k": 3}, {"k": 1}]`, query("SELECT k FROM test WHERE a >= 1 AND a < 3"))
+	require.JSONEq(t, `[{"k": 3}]`, query("SELECT k FROM test WHERE a = 2 AND b > 1"))
+	require.JSONEq(t, `[{"a": 2, "b": 1}]`, query("SELECT a, b FROM test WHERE a = 2 AND b < 2"))
+
+	// sorts in the directions of the index, or in the opposite directions, use the index
+	require.JSONEq(t, `[{"plan": "indexScan(\"idx_a_b\", covering) | project(k)"}]`,
+		query("EXPLAIN SELECT k FROM test ORDER BY a DESC, b"))
+	require.JSONEq(t, `[{"k": 4}, {"k": 2}, {"k": 3}, {"k": 1}]`, query("SELECT k FROM test ORDER BY a DESC, b"))
+	require.JSONEq(t, `[{"plan": "indexScanReverse(\"idx_a_b\", covering) | project(k)"}]`,
+		query("EXPLAIN SELECT k FROM test ORDER BY a, b DESC"))
+	require.JSONEq(t, `[{"k": 1}, {"k": 3}, {"k": 2}, {"k": 4}]`, query("SELECT k FROM test ORDER BY a, b DESC"))
+	require.JSONEq(t, `[{"plan": "seqScan(test, paths(k, a, b)) | project(k) | sort(a, b)"}]`,
+		query("EXPLAIN SELECT k FROM test ORDER BY a, b"))
+
+	// NULL values are stored after the other values
+	require.JSONEq(t, `[{"k": 1}, {"k": 4}]`, query("SELECT k FROM test WHERE c >= ''"))
+	require.JSONEq(t, `[{"k": 3}]`, query("SELECT k FROM test WHERE c IS NULL"))
+
+	// sorts put NULL values first, they can't use the index
+	require.JSONEq(t, `[{"plan": "seqScan(test, paths(k, c)) | project(k) | sort(c)"}]`,
+		query("EXPLAIN SELECT k FROM test ORDER BY c"))
+}
+
 func TestCreateSequence(t *testing.T) {
 	tests := []struct {
 		name  string
@@ -396,3 +734,181 @@ func TestCreateSequence(t *testing.T) {
 		})
 	}
 }
+
+func TestCreateView(t *testing.T) {
+	dir, err := ioutil.TempDir("", "genji")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	db, err := genji.Open(filepath.Join(dir, "test.db"))
+	require.NoError(t, err)
+
+	err = db.Exec(`
+		CREATE TABLE foo (a INT PRIMARY KEY, b TEXT);
+		INSERT INTO foo (a, b) VALUES (1, 'x'), (2, 'y'), (3, 'z');
+		CREATE VIEW v AS SELECT a, b FROM foo WHERE a > 1;
+		CREATE VIEW w AS SELECT a FROM v WHERE b != 'z';
+	`)
+	require.NoError(t, err)
+
+	// views are read like tables
+	doc, err := db.QueryDocument("SELECT COUNT(*) AS n FROM v")
+	require.NoError(t, err)
+	testutil.RequireDocJSONEq(t, doc, `{"n": 2}`)
+
+	doc, err = db.QueryDocument("SELECT * FROM w")
+	require.NoError(t, err)
+	testutil.RequireDocJSONEq(t, doc, `{"a": 2}`)
+
+	doc, err = db.QueryDocument("SELECT foo.b AS b FROM foo JOIN w ON foo.a = w.a")
+	require.NoError(t, err)
+	testutil.RequireDocJSONEq(t, doc, `{"b": "y"}`)
+
+	// the definition of views is stored in the catalog
+	err = db.Close()
+	require.NoError(t, err)
+
+	db, err = genji.Open(filepath.Join(dir, "test.db"))
+	require.NoError(t, err)
+	defer db.Close()
+
+	doc, err = db.QueryDocument("SELECT sql FROM __genji_catalog WHERE name = 'v'")
+	require.NoError(t, err)
+	testutil.RequireDocJSONEq(t, doc, `{"sql": "CREATE VIEW v AS SELECT a, b FROM foo WHERE a > 1"}`)
+
+	doc, err = db.QueryDocument("SELECT * FROM w")
+	require.NoError(t, err)
+	testutil.RequireDocJSONEq(t, doc, `{"a": 2}`)
+
+	// views are read-only
+	err = db.Exec("INSERT INTO v (a, b) VALUES (4, 'a')")
+	require.Error(t, err)
+	err = db.Exec("DELETE FROM v")
+	require.Error(t, err)
+
+	// names are shared with tables
+	err = db.Exec("CREATE VIEW foo AS SELECT 1")
+	require.True(t, errs.IsAlreadyExistsError(err))
+	err = db.Exec("CREATE TABLE v")
+	require.True(t, errs.IsAlreadyExistsError(err))
+	err = db.Exec("CREATE VIEW IF NOT EXISTS v AS SELECT 1")
+	require.NoError(t, err)
+
+	// views can only read existing relations
+	err = db.Exec("CREATE VIEW x AS SELECT * FROM bar")
+	require.True(t, errs.IsNotFoundError(err))
+
+	// OR REPLACE
+	err = db.Exec("CREATE OR REPLACE VIEW v AS SELECT a, b FROM foo WHERE a < 3")
+	require.NoError(t, err)
+	doc, err = db.QueryDocument("SELECT * FROM w")
+	require.NoError(t, err)
+	testutil.RequireDocJSONEq(t, doc, `{"a": 1}`)
+
+	err = db.Exec("CREATE OR REPLACE VIEW v AS SELECT * FROM w")
+	require.Error(t, err)
+
+	err = db.Exec("CREATE OR REPLACE VIEW x AS SELECT a FROM foo WHERE a = 1")
+	require.NoError(t, err)
+	doc, err = db.QueryDocument("SELECT * FROM x")
+	require.NoError(t, err)
+	testutil.RequireDocJSONEq(t, doc, `{"a": 1}`)
+}
+
+func TestCreateTrigger(t *testing.T) {
+	dir, err := ioutil.TempDir("", "genji")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	db, err := genji.Open(filepath.Join(dir, "test.db"))
+	require.NoError(t, err)
+
+	err = db.Exec(`
+		CREATE TABLE foo (a INT PRIMARY KEY, b TEXT);
+		CREATE TABLE audit (op TEXT, a INT, b TEXT);
+		CREATE TABLE counts (name TEXT PRIMARY KEY, n INT);
+		INSERT INTO counts (name, n) VALUES ('foo', 0);
+
+		CREATE TRIGGER foo_insert AFTER INSERT ON foo BEGIN
+			INSERT INTO audit (op, a, b) VALUES ('insert', new.a, new.b);
+			UPDATE counts SET n = n + 1 WHERE name = 'foo';
+		END;
+		-- NEW and OLD are case insensitive
+		CREATE TRIGGER foo_update BEFORE UPDATE ON foo WHEN NEW.b != OLD.b BEGIN
+			INSERT INTO audit (op, a, b) VALUES ('update', Old.a, old.b || ' -> ' || NEW.b);
+		END;
+		CREATE TRIGGER foo_delete AFTER DELETE ON foo FOR EACH ROW BEGIN
+			INSERT INTO audit (op, a, b) VALUES ('delete', old.a, old.b);
+			UPDATE counts SET n = n - 1 WHERE name = 'foo';
+		END;
+	`)
+	require.NoError(t, err)
+
+	// the definition of triggers is stored in the catalog
+	err = db.Close()
+	require.NoError(t, err)
+
+	db, err = genji.Open(filepath.Join(dir, "test.db"))
+	require.NoError(t, err)
+	defer db.Close()
+
+	doc, err := db.QueryDocument("SELECT table_name, sql FROM __genji_catalog WHERE name = 'foo_update'")
+	require.NoError(t, err)
+	testutil.RequireDocJSONEq(t, doc, `{"table_name": "foo", "sql": "CREATE TRIGGER foo_update BEFORE UPDATE ON foo WHEN NEW.b != OLD.b BEGIN\n\t\t\tINSERT INTO audit (op, a, b) VALUES ('update', Old.a, old.b || ' -> ' || NEW.b);\n\t\tEND"}`)
+
+	err = db.Exec(`
+		INSERT INTO foo (a, b) VALUES (1, 'x'), (2, 'y'), (3, 'z');
+		UPDATE foo SET b = 'w' WHERE a = 2;
+		UPDATE foo SET b = 'z' WHERE a = 3;
+		DELETE FROM foo WHERE a = 1;
+	`)
+	require.NoError(t, err)
+
+	var ops []string
+	res, err := db.Query("SELECT op, a, b FROM audit")
+	require.NoError(t, err)
+	err = res.Iterate(func(d document.Document) error {
+		var op, b string
+		var a int
+		err := document.Scan(d, &op, &a, &b)
+		ops = append(ops, fmt.Sprintf("%s %d %s", op, a, b))
+		return err
+	})
+	require.NoError(t, err)
+	require.NoError(t, res.Close())
+	require.Equal(t, []string{"insert 1 x", "insert 2 y", "insert 3 z", "update 2 y -> w", "delete 1 x"}, ops)
+
+	doc, err = db.QueryDocument("SELECT n FROM counts")
+	require.NoError(t, err)
+	testutil.RequireDocJSONEq(t, doc, `{"n": 2}`)
+
+	// the write fails if a trigger fails
+	err = db.Exec("CREATE TRIGGER foo_fail AFTER INSERT ON foo BEGIN INSERT INTO counts (name, n) VALUES ('foo', 0); END")
+	require.NoError(t, err)
+	err = db.Exec("INSERT INTO foo (a, b) VALUES (4, 'a')")
+	require.Error(t, err)
+	doc, err = db.QueryDocument("SELECT COUNT(*) AS n FROM foo")
+	require.NoError(t, err)
+	testutil.RequireDocJSONEq(t, doc, `{"n": 2}`)
+
+	// triggers can't fire each other forever
+	err = db.Exec(`
+		DROP TRIGGER foo_fail;
+		CREATE TRIGGER foo_loop AFTER DELETE ON audit BEGIN INSERT INTO audit (op) VALUES ('loop'); DELETE FROM audit WHERE op = 'loop'; END;
+	`)
+	require.NoError(t, err)
+	err = db.Exec("DELETE FROM audit WHERE op = 'delete'")
+	require.Error(t, err)
+
+	// names are shared with tables
+	err = db.Exec("CREATE TRIGGER foo AFTER INSERT ON foo BEGIN DELETE FROM audit; END")
+	require.True(t, errs.IsAlreadyExistsError(err))
+	err = db.Exec("CREATE TRIGGER IF NOT EXISTS foo_insert AFTER INSERT ON foo BEGIN DELETE FROM audit; END")
+	require.NoError(t, err)
+
+	// triggers are only created on existing tables
+	err = db.Exec("CREATE TRIGGER bar_insert AFTER INSERT ON bar BEGIN DELETE FROM audit; END")
+	require.True(t, errs.IsNotFoundError(err))
+	err = db.Exec("CREATE TRIGGER catalog_insert AFTER INSERT ON __genji_catalog BEGIN DELETE FROM audit; END")
+	require.Error(t, err)
+}
@@ -3,34 +3,29 @@ package statement
 import (
 	"github.com/genjidb/genji/internal/environment"
 	"github.com/genjidb/genji/internal/expr"
-	"github.com/genjidb/genji/internal/sql/scanner"
 	"github.com/genjidb/genji/internal/stream"
 	"github.com/genjidb/genji/internal/stringutil"
 )
 
 // DeleteConfig holds DELETE configuration.
 type DeleteStmt struct {
-	TableName        string
-	WhereExpr        expr.Expr
-	OffsetExpr       expr.Expr
-	OrderBy          expr.Path
-	LimitExpr        expr.Expr
-	OrderByDirection scanner.Token
+	TableName  string
+	WhereExpr  expr.Expr
+	OffsetExpr expr.Expr
+	OrderBy    []expr.OrderTerm
+	LimitExpr  expr.Expr
 }
 
 func (stmt *DeleteStmt) ToStream() (*StreamStmt, error) {
 	s := stream.New(stream.SeqScan(stmt.TableName))
 
+	setOuterTable(stmt.TableName, stmt.WhereExpr)
 	if stmt.WhereExpr != nil {
 		s = s.Pipe(stream.Filter(stmt.WhereExpr))
 	}
 
-	if stmt.OrderBy != nil {
-		if stmt.OrderByDirection == scanner.DESC {
-			s = s.Pipe(stream.SortReverse(stmt.OrderBy))
-		} else {
-			s = s.Pipe(stream.Sort(stmt.OrderBy))
-		}
+	if len(stmt.OrderBy) > 0 {
+		s = s.Pipe(stream.SortBy(stmt.OrderBy...))
 	}
 
 	if stmt.OffsetExpr != nil {
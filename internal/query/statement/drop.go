@@ -11,6 +11,8 @@ import (
 type DropTableStmt struct {
 	TableName string
 	IfExists  bool
+	// Cascade drops the views reading the table as well.
+	Cascade bool
 }
 
 // IsReadOnly always returns false. It implements the Statement interface.
@@ -36,6 +38,13 @@ func (stmt DropTableStmt) Run(ctx *Context) (Result, error) {
 		return res, err
 	}
 
+	if stmt.Cascade {
+		err = dropDependentViews(ctx, stmt.TableName)
+		if err != nil {
+			return res, err
+		}
+	}
+
 	err = ctx.Catalog.DropTable(ctx.Tx, stmt.TableName)
 	if err != nil {
 		return res, err
@@ -49,6 +58,23 @@ func (stmt DropTableStmt) Run(ctx *Context) (Result, error) {
 		}
 	}
 
+	// drop the sequences owned by the fields of the table, i.e. SERIAL fields
+	for _, name := range ctx.Catalog.ListSequences(ctx.Tx) {
+		seq, err := ctx.Catalog.GetSequence(ctx.Tx, name)
+		if err != nil {
+			return res, err
+		}
+
+		if seq.Info.Owner.TableName != stmt.TableName || seq.Info.Owner.Path == nil {
+			continue
+		}
+
+		err = ctx.Catalog.DropSequence(ctx.Tx, name)
+		if err != nil {
+			return res, err
+		}
+	}
+
 	return res, err
 }
 
@@ -100,7 +126,7 @@ func (stmt DropSequenceStmt) Run(ctx *Context) (Result, error) {
 		return res, errors.New("missing index name")
 	}
 
-	seq, err := ctx.Catalog.GetSequence(stmt.SequenceName)
+	seq, err := ctx.Catalog.GetSequence(ctx.Tx, stmt.SequenceName)
 	if err != nil {
 		if errs.IsNotFoundError(err) && stmt.IfExists {
 			err = nil
@@ -119,3 +145,75 @@ func (stmt DropSequenceStmt) Run(ctx *Context) (Result, error) {
 
 	return res, err
 }
+
+// DropViewStmt is a DSL that allows creating a DROP VIEW query.
+type DropViewStmt struct {
+	ViewName string
+	IfExists bool
+	// Cascade drops the views reading the view as well.
+	Cascade bool
+}
+
+// IsReadOnly always returns false. It implements the Statement interface.
+func (stmt DropViewStmt) IsReadOnly() bool {
+	return false
+}
+
+// Run runs the DropView statement in the given transaction.
+// It implements the Statement interface.
+func (stmt DropViewStmt) Run(ctx *Context) (Result, error) {
+	var res Result
+
+	if stmt.ViewName == "" {
+		return res, errors.New("missing view name")
+	}
+
+	err := ctx.Catalog.DropView(ctx.Tx, stmt.ViewName, stmt.Cascade)
+	if errs.IsNotFoundError(err) && stmt.IfExists {
+		err = nil
+	}
+
+	return res, err
+}
+
+// DropTriggerStmt is a DSL that allows creating a DROP TRIGGER query.
+type DropTriggerStmt struct {
+	TriggerName string
+	IfExists    bool
+}
+
+// IsReadOnly always returns false. It implements the Statement interface.
+func (stmt DropTriggerStmt) IsReadOnly() bool {
+	return false
+}
+
+// Run runs the DropTrigger statement in the given transaction.
+// It implements the Statement interface.
+func (stmt DropTriggerStmt) Run(ctx *Context) (Result, error) {
+	var res Result
+
+	if stmt.TriggerName == "" {
+		return res, errors.New("missing trigger name")
+	}
+
+	err := ctx.Catalog.DropTrigger(ctx.Tx, stmt.TriggerName)
+	if errs.IsNotFoundError(err) && stmt.IfExists {
+		err = nil
+	}
+
+	return res, err
+}
+
+// dropDependentViews drops the views reading the given table,
+// and the views reading them.
+func dropDependentViews(ctx *Context, tableName string) error {
+	for _, name := range ctx.Catalog.GetDependentViews(ctx.Tx, tableName) {
+		err := ctx.Catalog.DropView(ctx.Tx, name, true)
+		// the view may have been dropped along with another one
+		if err != nil && !errs.IsNotFoundError(err) {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -58,6 +58,12 @@ func TestDropTable(t *testing.T) {
 	_, err = db.QueryDocument("SELECT 1 FROM __genji_sequence WHERE name = 'test1_seq'")
 	require.Error(t, err)
 
+	// Assert the sequences owned by SERIAL fields are dropped with the table.
+	err = db.Exec("CREATE TABLE test4(id SERIAL PRIMARY KEY); DROP TABLE test4")
+	require.NoError(t, err)
+	_, err = db.QueryDocument("SELECT 1 FROM __genji_catalog WHERE name = 'test4_id_seq'")
+	require.Error(t, err)
+
 	// Dropping a read-only table should fail.
 	err = db.Exec("DROP TABLE __genji_catalog")
 	require.Error(t, err)
@@ -75,7 +81,7 @@ func TestDropIndex(t *testing.T) {
 	testutil.MustExec(t, db, tx, "DROP INDEX idx_test2_bar")
 
 	// Assert that the good index has been dropped.
-	indexes := db.Catalog.ListIndexes("")
+	indexes := db.Catalog.ListIndexes(tx, "")
 	require.Len(t, indexes, 2)
 	require.Equal(t, "idx_test1_foo", indexes[0])
 	require.Equal(t, "test1_bar_idx", indexes[1])
@@ -102,9 +108,9 @@ func TestDropSequence(t *testing.T) {
 	testutil.MustExec(t, db, tx, "DROP SEQUENCE seq1")
 
 	// Assert that the good index has been dropped.
-	_, err := db.Catalog.GetSequence("seq1")
+	_, err := db.Catalog.GetSequence(tx, "seq1")
 	require.IsType(t, errs.NotFoundError{}, err)
-	_, err = db.Catalog.GetSequence("seq2")
+	_, err = db.Catalog.GetSequence(tx, "seq2")
 	require.NoError(t, err)
 
 	// Dropping a non existing sequence with IF EXISTS should not fail.
@@ -115,3 +121,95 @@ func TestDropSequence(t *testing.T) {
 	err = testutil.Exec(db, tx, "DROP SEQUENCE test1_seq")
 	require.Error(t, err)
 }
+
+func TestDropView(t *testing.T) {
+	db, err := genji.Open(":memory:")
+	require.NoError(t, err)
+	defer db.Close()
+
+	err = db.Exec(`
+		CREATE TABLE foo (a INT);
+		CREATE TABLE bar (a INT);
+		CREATE VIEW v1 AS SELECT * FROM foo;
+		CREATE VIEW v2 AS SELECT * FROM v1;
+		CREATE VIEW v3 AS SELECT * FROM v1 UNION ALL SELECT * FROM bar;
+		CREATE VIEW v4 AS SELECT * FROM bar;
+	`)
+	require.NoError(t, err)
+
+	// relations read by views can't be dropped or renamed
+	err = db.Exec("DROP TABLE foo")
+	require.Error(t, err)
+	err = db.Exec("DROP VIEW v1")
+	require.Error(t, err)
+	err = db.Exec("DROP VIEW v1 RESTRICT")
+	require.Error(t, err)
+	err = db.Exec("ALTER TABLE foo RENAME TO baz")
+	require.Error(t, err)
+
+	err = db.Exec("DROP VIEW v4")
+	require.NoError(t, err)
+	err = db.Exec("DROP VIEW IF EXISTS v4")
+	require.NoError(t, err)
+	err = db.Exec("DROP VIEW v4")
+	require.True(t, errs.IsNotFoundError(err))
+
+	// CASCADE drops the views reading the relation
+	err = db.Exec("DROP TABLE foo CASCADE")
+	require.NoError(t, err)
+
+	var views []string
+	res, err := db.Query("SELECT name FROM __genji_catalog WHERE type = 'view' OR name = 'foo'")
+	require.NoError(t, err)
+	err = res.Iterate(func(d document.Document) error {
+		var name string
+		err := document.Scan(d, &name)
+		views = append(views, name)
+		return err
+	})
+	require.NoError(t, err)
+	require.NoError(t, res.Close())
+	require.Empty(t, views)
+
+	err = db.Exec("DROP TABLE bar")
+	require.NoError(t, err)
+}
+
+func TestDropTrigger(t *testing.T) {
+	db, err := genji.Open(":memory:")
+	require.NoError(t, err)
+	defer db.Close()
+
+	err = db.Exec(`
+		CREATE TABLE foo (a INT);
+		CREATE TABLE bar (a INT);
+		CREATE TRIGGER foo_insert AFTER INSERT ON foo BEGIN INSERT INTO bar (a) VALUES (new.a); END;
+		CREATE TRIGGER foo_delete AFTER DELETE ON foo BEGIN DELETE FROM bar WHERE a = old.a; END;
+	`)
+	require.NoError(t, err)
+
+	err = db.Exec("DROP TRIGGER foo_insert")
+	require.NoError(t, err)
+	err = db.Exec("DROP TRIGGER IF EXISTS foo_insert")
+	require.NoError(t, err)
+	err = db.Exec("DROP TRIGGER foo_insert")
+	require.True(t, errs.IsNotFoundError(err))
+
+	err = db.Exec("INSERT INTO foo (a) VALUES (1)")
+	require.NoError(t, err)
+	doc, err := db.QueryDocument("SELECT COUNT(*) AS n FROM bar")
+	require.NoError(t, err)
+	testutil.RequireDocJSONEq(t, doc, `{"n": 0}`)
+
+	// triggers follow their table when renamed and are dropped with it
+	err = db.Exec("ALTER TABLE foo RENAME TO baz")
+	require.NoError(t, err)
+	doc, err = db.QueryDocument("SELECT table_name FROM __genji_catalog WHERE name = 'foo_delete'")
+	require.NoError(t, err)
+	testutil.RequireDocJSONEq(t, doc, `{"table_name": "baz"}`)
+
+	err = db.Exec("DROP TABLE baz")
+	require.NoError(t, err)
+	err = db.Exec("DROP TRIGGER foo_delete")
+	require.True(t, errs.IsNotFoundError(err))
+}
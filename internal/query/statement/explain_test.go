@@ -17,25 +17,49 @@ func TestExplainStmt(t *testing.T) {
 		{"EXPLAIN SELECT * FROM noexist", true, ``},
 		{"EXPLAIN SELECT * FROM test", false, `"seqScan(test)"`},
 		{"EXPLAIN SELECT *, a FROM test", false, `"seqScan(test) | project(*, a)"`},
-		{"EXPLAIN SELECT a + 1 FROM test", false, `"seqScan(test) | project(a + 1)"`},
-		{"EXPLAIN SELECT a + 1 FROM test WHERE c > 10", false, `"seqScan(test) | filter(c > 10) | project(a + 1)"`},
-		{"EXPLAIN SELECT a + 1 FROM test WHERE c > 10 AND d > 20", false, `"seqScan(test) | filter(c > 10) | filter(d > 20) | project(a + 1)"`},
-		{"EXPLAIN SELECT a + 1 FROM test WHERE c > 10 OR d > 20", false, `"seqScan(test) | filter(c > 10 OR d > 20) | project(a + 1)"`},
-		{"EXPLAIN SELECT a + 1 FROM test WHERE c IN [1 + 1, 2 + 2]", false, `"seqScan(test) | filter(c IN [2, 4]) | project(a + 1)"`},
-		{"EXPLAIN SELECT a + 1 FROM test WHERE a > 10", false, `"indexScan(\"idx_a\", [10, -1, true]) | project(a + 1)"`},
-		{"EXPLAIN SELECT a + 1 FROM test WHERE x = 10 AND y > 5", false, `"indexScan(\"idx_x_y\", [[10, 5], -1, true]) | project(a + 1)"`},
-		{"EXPLAIN SELECT a + 1 FROM test WHERE a > 10 AND b > 20 AND c > 30", false, `"indexScan(\"idx_b\", [20, -1, true]) | filter(a > 10) | filter(c > 30) | project(a + 1)"`},
-		{"EXPLAIN SELECT a + 1 FROM test WHERE c > 30 ORDER BY d LIMIT 10 OFFSET 20", false, `"seqScan(test) | filter(c > 30) | project(a + 1) | sort(d) | skip(20) | take(10)"`},
-		{"EXPLAIN SELECT a + 1 FROM test WHERE c > 30 ORDER BY d DESC LIMIT 10 OFFSET 20", false, `"seqScan(test) | filter(c > 30) | project(a + 1) | sortReverse(d) | skip(20) | take(10)"`},
-		// {"EXPLAIN SELECT a + 1 FROM test WHERE c > 30 ORDER BY a DESC LIMIT 10 OFFSET 20", false, `"indexScanReverse(\"idx_a\") | filter(c > 30) | project(a + 1) | skip(20) | take(10)"`},
-		{"EXPLAIN SELECT a + 1 FROM test WHERE c > 30 ORDER BY a DESC LIMIT 10 OFFSET 20", false, `"seqScan(test) | filter(c > 30) | project(a + 1) | sortReverse(a) | skip(20) | take(10)"`},
-		{"EXPLAIN SELECT a + 1 FROM test WHERE c > 30 GROUP BY a + 1 ORDER BY a DESC LIMIT 10 OFFSET 20", false, `"seqScan(test) | filter(c > 30) | groupBy(a + 1) | hashAggregate() | project(a + 1) | sortReverse(a) | skip(20) | take(10)"`},
+		{"EXPLAIN SELECT a + 1 FROM test", false, `"seqScan(test, paths(a)) | project(a + 1)"`},
+		{"EXPLAIN SELECT a + 1 FROM test WHERE c > 10", false, `"seqScan(test, paths(c, a)) | filter(c > 10) | project(a + 1)"`},
+		{"EXPLAIN SELECT a + 1 FROM test WHERE c > 10 AND d > 20", false, `"seqScan(test, paths(c, d, a)) | filter(c > 10) | filter(d > 20) | project(a + 1)"`},
+		{"EXPLAIN SELECT a + 1 FROM test WHERE c > 10 OR d > 20", false, `"seqScan(test, paths(c, d, a)) | filter(c > 10 OR d > 20) | project(a + 1)"`},
+		{"EXPLAIN SELECT a + 1 FROM test WHERE c IN [1 + 1, 2 + 2]", false, `"seqScan(test, paths(c, a)) | filter(c IN [2, 4]) | project(a + 1)"`},
+		{"EXPLAIN SELECT a + 1 FROM test WHERE a > 10", false, `"indexScan(\"idx_a\", [10, -1, true], covering) | project(a + 1)"`},
+		{"EXPLAIN SELECT a + 1 FROM test WHERE x = 10 AND y > 5", false, `"indexScan(\"idx_x_y\", [[10, 5], 10, true], paths(a)) | project(a + 1)"`},
+		{"EXPLAIN SELECT a + 1 FROM test WHERE a > 10 AND b > 20 AND c > 30", false, `"indexScan(\"idx_b\", [20, -1, true], paths(a, c)) | filter(a > 10) | filter(c > 30) | project(a + 1)"`},
+		{"EXPLAIN SELECT a + 1 FROM test WHERE a LIKE 'foo%'", false, `"indexScan(\"idx_a\", [\"foo\", -1], filter(a < \"fop\"), filter(a LIKE \"foo%\"), covering) | project(a + 1)"`},
+		{"EXPLAIN SELECT a + 1 FROM test WHERE c LIKE 'foo%'", false, `"seqScan(test, paths(c, a)) | filter(c LIKE \"foo%\") | project(a + 1)"`},
+		{"EXPLAIN SELECT a + 1 FROM test WHERE c > 30 ORDER BY d LIMIT 10 OFFSET 20", false, `"seqScan(test, paths(c, a, d)) | filter(c > 30) | project(a + 1) | sort(d) | skip(20) | take(10)"`},
+		{"EXPLAIN SELECT a + 1 FROM test WHERE c > 30 ORDER BY d DESC LIMIT 10 OFFSET 20", false, `"seqScan(test, paths(c, a, d)) | filter(c > 30) | project(a + 1) | sortReverse(d) | skip(20) | take(10)"`},
+		{"EXPLAIN SELECT a + 1 FROM test WHERE c > 30 ORDER BY a DESC LIMIT 10 OFFSET 20", false, `"indexScanReverse(\"idx_a\", paths(c, a)) | filter(c > 30) | skip(20) | take(10) | project(a + 1)"`},
+		{"EXPLAIN SELECT * FROM test ORDER BY k DESC LIMIT 10", false, `"seqScanReverse(test, limit 10)"`},
+		{"EXPLAIN SELECT * FROM test WHERE k > 10 ORDER BY k DESC", false, `"pkScanReverse(\"test\", [10, -1, true])"`},
+		{"EXPLAIN SELECT * FROM test ORDER BY k LIMIT 10", false, `"seqScan(test, limit 10)"`},
+		{"EXPLAIN SELECT a FROM test WHERE k > 10 LIMIT 10 OFFSET 5", false, `"pkScan(\"test\", [10, -1, true], paths(a), offset 5, limit 10) | project(a)"`},
+		{"EXPLAIN SELECT * FROM test ORDER BY x, y LIMIT 10", false, `"indexScan(\"idx_x_y\", limit 10)"`},
+		{"EXPLAIN SELECT * FROM test WHERE x = 1 ORDER BY y DESC", false, `"indexScanReverse(\"idx_x_y\", 1)"`},
+		{"EXPLAIN SELECT * FROM test ORDER BY x, a", false, `"seqScan(test) | sort(x, a)"`},
+		{"EXPLAIN SELECT * FROM test ORDER BY x, y DESC", false, `"seqScan(test) | sort(x, y DESC)"`},
+		{"EXPLAIN SELECT b AS a FROM test ORDER BY a", false, `"seqScan(test, paths(b, a)) | project(b) | sort(a)"`},
+		{"EXPLAIN SELECT a + 1 FROM test WHERE c > 30 GROUP BY a + 1 ORDER BY a DESC LIMIT 10 OFFSET 20", false, `"seqScan(test, paths(c, a)) | filter(c > 30) | groupBy(a + 1) | hashAggregate() | project(a + 1) | sortReverse(a) | skip(20) | take(10)"`},
+		{"EXPLAIN SELECT a, COUNT(*) FROM test GROUP BY a ORDER BY a DESC", false, `"indexScanReverse(\"idx_a\", covering) | groupBy(a) | streamAggregate(COUNT(*)) | project(a, COUNT(*))"`},
+		{"EXPLAIN SELECT k, COUNT(*) FROM test GROUP BY k", false, `"seqScan(test, paths(k)) | groupBy(k) | streamAggregate(COUNT(*)) | project(k, COUNT(*))"`},
+		{"EXPLAIN SELECT c, COUNT(*) FROM test GROUP BY c", false, `"batch(seqScan(test, paths(c)) | groupBy(c) | hashAggregate(COUNT(*))) | project(c, COUNT(*))"`},
+		{"EXPLAIN SELECT * FROM test WHERE a = 1 OR b = 2", false, `"unionScan(indexScan(\"idx_a\", 1), indexScan(\"idx_b\", 2))"`},
+		{"EXPLAIN SELECT * FROM test WHERE (a = 1 OR k > 2) AND c = 3", false, `"unionScan(indexScan(\"idx_a\", 1), pkScan(\"test\", [2, -1, true])) | filter(c = 3)"`},
+		{"EXPLAIN SELECT * FROM test WHERE a = 1 OR c = 2", false, `"seqScan(test) | filter(a = 1 OR c = 2)"`},
+		{"EXPLAIN SELECT * FROM test WHERE a IN (1, 2, 3)", false, `"indexScan(\"idx_a\", 1, 2, 3)"`},
+		{"EXPLAIN SELECT * FROM test WHERE x IN (1, 2) AND y IN (3, 4)", false, `"indexScan(\"idx_x_y\", [1, 3], [1, 4], [2, 3], [2, 4])"`},
+		{"EXPLAIN SELECT * FROM test WHERE x IN ? AND y IN ?", false, `"indexScan(\"idx_x_y\", IN [?, ?])"`},
+		{"EXPLAIN SELECT * FROM test AS t1 JOIN test AS t2 ON t1.c = t2.c WHERE t1.a > 10", false, `"seqScan(test) | wrap(t1) | join(t2, seqScan(test), t1.c = t2.c) | filter(t1.a > 10)"`},
+		{"EXPLAIN SELECT * FROM test AS t1 LEFT JOIN test AS t2 ON t1.c = t2.a", false, `"seqScan(test) | wrap(t1) | leftJoin(t2, indexScan(\"idx_a\", t1.c), t1.c = t2.a)"`},
+		{"EXPLAIN SELECT * FROM test AS t1 JOIN test AS t2 ON t2.k = t1.c + 1", false, `"seqScan(test) | wrap(t1) | join(t2, seqScan(test), t2.k = t1.c + 1)"`},
+		{"EXPLAIN SELECT * FROM test AS t1 JOIN test AS t2 ON t2.k = t1.c", false, `"seqScan(test) | wrap(t1) | join(t2, pkScan(\"test\", t1.c), t2.k = t1.c)"`},
 		{"EXPLAIN UPDATE test SET a = 10", false, `"seqScan(test) | set(a, 10) | tableReplace('test')"`},
 		{"EXPLAIN UPDATE test SET a = 10 WHERE c > 10", false, `"seqScan(test) | filter(c > 10) | set(a, 10) | tableReplace('test')"`},
 		{"EXPLAIN UPDATE test SET a = 10 WHERE a > 10", false, `"indexScan(\"idx_a\", [10, -1, true]) | set(a, 10) | tableReplace('test')"`},
 		{"EXPLAIN DELETE FROM test", false, `"seqScan(test) | tableDelete('test')"`},
 		{"EXPLAIN DELETE FROM test WHERE c > 10", false, `"seqScan(test) | filter(c > 10) | tableDelete('test')"`},
 		{"EXPLAIN DELETE FROM test WHERE a > 10", false, `"indexScan(\"idx_a\", [10, -1, true]) | tableDelete('test')"`},
+		{"EXPLAIN DELETE FROM test WHERE a > 10 LIMIT 2", false, `"indexScan(\"idx_a\", [10, -1, true], limit 2) | tableDelete('test')"`},
 	}
 
 	for _, test := range tests {
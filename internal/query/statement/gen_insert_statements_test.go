@@ -904,6 +904,161 @@ INSERT INTO test_oc (b, c) VALUES (1, 1) ON CONFLICT DO REPLACE;
 
 	})
 
+	// --------------------------------------------------------------------------
+	t.Run("insert with on conflict with target do nothing", func(t *testing.T) {
+		db, err := genji.Open(":memory:")
+		require.NoError(t, err)
+		defer db.Close()
+
+		setup(t, db)
+
+		t.Run(`CREATE TABLE test_oc(a INTEGER UNIQUE, b INTEGER PRIMARY KEY);`, func(t *testing.T) {
+			q := `
+CREATE TABLE test_oc(a INTEGER UNIQUE, b INTEGER PRIMARY KEY);
+INSERT INTO test_oc (a, b) VALUES (1, 1);
+INSERT INTO test_oc (a, b) VALUES (2, 1) ON CONFLICT (b) DO NOTHING;
+SELECT * FROM test_oc;
+`
+			res, err := db.Query(q)
+			require.NoError(t, err)
+			defer res.Close()
+			raw := `
+{
+  a: 1,
+  b: 1
+}
+`
+			testutil.RequireStreamEq(t, raw, res)
+		})
+
+	})
+
+	// --------------------------------------------------------------------------
+	t.Run("insert with on conflict with another target", func(t *testing.T) {
+		db, err := genji.Open(":memory:")
+		require.NoError(t, err)
+		defer db.Close()
+
+		setup(t, db)
+
+		t.Run(`CREATE TABLE test_oc(a INTEGER UNIQUE, b INTEGER PRIMARY KEY);`, func(t *testing.T) {
+			q := `
+CREATE TABLE test_oc(a INTEGER UNIQUE, b INTEGER PRIMARY KEY);
+INSERT INTO test_oc (a, b) VALUES (1, 1);
+INSERT INTO test_oc (a, b) VALUES (1, 2) ON CONFLICT (b) DO NOTHING;
+`
+			err := db.Exec(q)
+			require.Errorf(t, err, "expected\n%s\nto raise an error but got none", q)
+		})
+
+	})
+
+	// --------------------------------------------------------------------------
+	t.Run("insert with on conflict with invalid target", func(t *testing.T) {
+		db, err := genji.Open(":memory:")
+		require.NoError(t, err)
+		defer db.Close()
+
+		setup(t, db)
+
+		t.Run(`CREATE TABLE test_oc(a INTEGER, b INTEGER PRIMARY KEY);`, func(t *testing.T) {
+			q := `
+CREATE TABLE test_oc(a INTEGER, b INTEGER PRIMARY KEY);
+INSERT INTO test_oc (a, b) VALUES (1, 1) ON CONFLICT (a) DO NOTHING;
+`
+			err := db.Exec(q)
+			require.Errorf(t, err, "expected\n%s\nto raise an error but got none", q)
+		})
+
+	})
+
+	// --------------------------------------------------------------------------
+	t.Run("insert with on conflict do update", func(t *testing.T) {
+		db, err := genji.Open(":memory:")
+		require.NoError(t, err)
+		defer db.Close()
+
+		setup(t, db)
+
+		t.Run(`CREATE TABLE test_oc(a INTEGER PRIMARY KEY, b INTEGER, c INTEGER DEFAULT 10);`, func(t *testing.T) {
+			q := `
+CREATE TABLE test_oc(a INTEGER PRIMARY KEY, b INTEGER, c INTEGER DEFAULT 10);
+INSERT INTO test_oc (a, b) VALUES (1, 1);
+INSERT INTO test_oc (a, b) VALUES (1, 5), (2, 2) ON CONFLICT (a) DO UPDATE SET b = b + excluded.b, c = excluded.c + 1;
+SELECT * FROM test_oc;
+`
+			res, err := db.Query(q)
+			require.NoError(t, err)
+			defer res.Close()
+			raw := `
+{
+  a: 1,
+  b: 6,
+  c: 11
+}
+{
+  a: 2,
+  b: 2,
+  c: 10
+}
+`
+			testutil.RequireStreamEq(t, raw, res)
+		})
+
+	})
+
+	// --------------------------------------------------------------------------
+	t.Run("insert with on conflict do update where", func(t *testing.T) {
+		db, err := genji.Open(":memory:")
+		require.NoError(t, err)
+		defer db.Close()
+
+		setup(t, db)
+
+		t.Run(`CREATE TABLE test_oc(a INTEGER UNIQUE, b INTEGER);`, func(t *testing.T) {
+			q := `
+CREATE TABLE test_oc(a INTEGER UNIQUE, b INTEGER);
+INSERT INTO test_oc (a, b) VALUES (1, 1), (2, 2);
+INSERT INTO test_oc (a, b) VALUES (1, 10), (2, 20) ON CONFLICT DO UPDATE SET b = excluded.b WHERE a > 1;
+SELECT a, b FROM test_oc;
+`
+			res, err := db.Query(q)
+			require.NoError(t, err)
+			defer res.Close()
+			raw := `
+{
+  a: 1,
+  b: 1
+}
+{
+  a: 2,
+  b: 20
+}
+`
+			testutil.RequireStreamEq(t, raw, res)
+		})
+
+	})
+
+	// --------------------------------------------------------------------------
+	t.Run("insert with on conflict do update, not null", func(t *testing.T) {
+		db, err := genji.Open(":memory:")
+		require.NoError(t, err)
+		defer db.Close()
+
+		setup(t, db)
+
+		t.Run(`CREATE TABLE test_oc(a INTEGER PRIMARY KEY, b INTEGER NOT NULL);`, func(t *testing.T) {
+			q := `
+CREATE TABLE test_oc(a INTEGER PRIMARY KEY, b INTEGER NOT NULL);
+INSERT INTO test_oc (a) VALUES (1) ON CONFLICT DO UPDATE SET b = 1;
+`
+			err := db.Exec(q)
+			require.Errorf(t, err, "expected\n%s\nto raise an error but got none", q)
+		})
+
+	})
+
 	// --------------------------------------------------------------------------
 	t.Run("insert with NEXT VALUE FOR", func(t *testing.T) {
 		db, err := genji.Open(":memory:")
@@ -345,6 +345,68 @@ CREATE TABLE test_oc(a INTEGER NOT NULL);
 INSERT INTO test_oc (b, c) VALUES (1, 1) ON CONFLICT DO REPLACE;
 -- error:
 
+-- test: insert with on conflict with target do nothing
+CREATE TABLE test_oc(a INTEGER UNIQUE, b INTEGER PRIMARY KEY);
+INSERT INTO test_oc (a, b) VALUES (1, 1);
+INSERT INTO test_oc (a, b) VALUES (2, 1) ON CONFLICT (b) DO NOTHING;
+SELECT * FROM test_oc;
+/* result:
+{
+  a: 1,
+  b: 1
+}
+*/
+
+-- test: insert with on conflict with another target
+CREATE TABLE test_oc(a INTEGER UNIQUE, b INTEGER PRIMARY KEY);
+INSERT INTO test_oc (a, b) VALUES (1, 1);
+INSERT INTO test_oc (a, b) VALUES (1, 2) ON CONFLICT (b) DO NOTHING;
+-- error:
+
+-- test: insert with on conflict with invalid target
+CREATE TABLE test_oc(a INTEGER, b INTEGER PRIMARY KEY);
+INSERT INTO test_oc (a, b) VALUES (1, 1) ON CONFLICT (a) DO NOTHING;
+-- error:
+
+-- test: insert with on conflict do update
+CREATE TABLE test_oc(a INTEGER PRIMARY KEY, b INTEGER, c INTEGER DEFAULT 10);
+INSERT INTO test_oc (a, b) VALUES (1, 1);
+INSERT INTO test_oc (a, b) VALUES (1, 5), (2, 2) ON CONFLICT (a) DO UPDATE SET b = b + excluded.b, c = excluded.c + 1;
+SELECT * FROM test_oc;
+/* result:
+{
+  a: 1,
+  b: 6,
+  c: 11
+}
+{
+  a: 2,
+  b: 2,
+  c: 10
+}
+*/
+
+-- test: insert with on conflict do update where
+CREATE TABLE test_oc(a INTEGER UNIQUE, b INTEGER);
+INSERT INTO test_oc (a, b) VALUES (1, 1), (2, 2);
+INSERT INTO test_oc (a, b) VALUES (1, 10), (2, 20) ON CONFLICT DO UPDATE SET b = excluded.b WHERE a > 1;
+SELECT a, b FROM test_oc;
+/* result:
+{
+  a: 1,
+  b: 1
+}
+{
+  a: 2,
+  b: 20
+}
+*/
+
+-- test: insert with on conflict do update, not null
+CREATE TABLE test_oc(a INTEGER PRIMARY KEY, b INTEGER NOT NULL);
+INSERT INTO test_oc (a) VALUES (1) ON CONFLICT DO UPDATE SET b = 1;
+-- error:
+
 -- test: insert with NEXT VALUE FOR
 CREATE TABLE test_oc(a INTEGER UNIQUE);
 CREATE SEQUENCE test_seq1;
@@ -888,6 +888,82 @@ INSERT INTO test_e {};
 			q := `
 CREATE TABLE test_e (a CHARACTER(64) NOT NULL);
 INSERT INTO test_e {};
+`
+			err := db.Exec(q)
+			require.Errorf(t, err, "expected\n%s\nto raise an error but got none", q)
+		})
+
+	})
+
+	// --------------------------------------------------------------------------
+	t.Run("timestamp", func(t *testing.T) {
+		db, err := genji.Open(":memory:")
+		require.NoError(t, err)
+		defer db.Close()
+
+		setup(t, db)
+
+		t.Run(`CREATE TABLE test_e (a TIMESTAMP);`, func(t *testing.T) {
+			q := `
+CREATE TABLE test_e (a TIMESTAMP);
+INSERT INTO test_e {a: "foo"};
+`
+			err := db.Exec(q)
+			require.Errorf(t, err, "expected\n%s\nto raise an error but got none", q)
+		})
+
+	})
+
+	// --------------------------------------------------------------------------
+	t.Run("timestamp / not null with non-respected type constraint", func(t *testing.T) {
+		db, err := genji.Open(":memory:")
+		require.NoError(t, err)
+		defer db.Close()
+
+		setup(t, db)
+
+		t.Run(`CREATE TABLE test_e (a TIMESTAMP NOT NULL);`, func(t *testing.T) {
+			q := `
+CREATE TABLE test_e (a TIMESTAMP NOT NULL);
+INSERT INTO test_e {a: 42};
+`
+			err := db.Exec(q)
+			require.Errorf(t, err, "expected\n%s\nto raise an error but got none", q)
+		})
+
+	})
+
+	// --------------------------------------------------------------------------
+	t.Run("date", func(t *testing.T) {
+		db, err := genji.Open(":memory:")
+		require.NoError(t, err)
+		defer db.Close()
+
+		setup(t, db)
+
+		t.Run(`CREATE TABLE test_e (a DATE);`, func(t *testing.T) {
+			q := `
+CREATE TABLE test_e (a DATE);
+INSERT INTO test_e {a: "2021-13-01"};
+`
+			err := db.Exec(q)
+			require.Errorf(t, err, "expected\n%s\nto raise an error but got none", q)
+		})
+
+	})
+
+	// --------------------------------------------------------------------------
+	t.Run("date / not null with type constraint", func(t *testing.T) {
+		db, err := genji.Open(":memory:")
+		require.NoError(t, err)
+		defer db.Close()
+
+		setup(t, db)
+
+		t.Run(`CREATE TABLE test_e (a DATE NOT NULL);`, func(t *testing.T) {
+			q := `
+CREATE TABLE test_e (a DATE NOT NULL);
+INSERT INTO test_e {};
 `
 			err := db.Exec(q)
 			require.Errorf(t, err, "expected\n%s\nto raise an error but got none", q)
@@ -227,3 +227,23 @@ INSERT INTO test_e {};
 CREATE TABLE test_e (a CHARACTER(64) NOT NULL);
 INSERT INTO test_e {};
 -- error:
+
+-- test: timestamp
+CREATE TABLE test_e (a TIMESTAMP);
+INSERT INTO test_e {a: "foo"};
+-- error:
+
+-- test: timestamp / not null with non-respected type constraint
+CREATE TABLE test_e (a TIMESTAMP NOT NULL);
+INSERT INTO test_e {a: 42};
+-- error:
+
+-- test: date
+CREATE TABLE test_e (a DATE);
+INSERT INTO test_e {a: "2021-13-01"};
+-- error:
+
+-- test: date / not null with type constraint
+CREATE TABLE test_e (a DATE NOT NULL);
+INSERT INTO test_e {};
+-- error:
@@ -43,6 +43,48 @@ INSERT INTO foo SELECT * FROM foo;
 
 	})
 
+	// --------------------------------------------------------------------------
+	t.Run("same table in set operation", func(t *testing.T) {
+		db, err := genji.Open(":memory:")
+		require.NoError(t, err)
+		defer db.Close()
+
+		setup(t, db)
+
+		t.Run(`INSERT INTO foo SELECT * FROM bar UNION SELECT * FROM foo;`, func(t *testing.T) {
+			q := `
+INSERT INTO foo SELECT * FROM bar UNION SELECT * FROM foo;
+`
+			err := db.Exec(q)
+			require.Errorf(t, err, "expected\n%s\nto raise an error but got none", q)
+		})
+
+	})
+
+	// --------------------------------------------------------------------------
+	t.Run("union", func(t *testing.T) {
+		db, err := genji.Open(":memory:")
+		require.NoError(t, err)
+		defer db.Close()
+
+		setup(t, db)
+
+		t.Run(`INSERT INTO foo SELECT * FROM bar UNION SELECT * FROM bar;`, func(t *testing.T) {
+			q := `
+INSERT INTO foo SELECT * FROM bar UNION SELECT * FROM bar;
+SELECT pk(), * FROM foo;
+`
+			res, err := db.Query(q)
+			require.NoError(t, err)
+			defer res.Close()
+			raw := `
+{"pk()":1, "a":1.0, "b":10.0}
+`
+			testutil.RequireStreamEq(t, raw, res)
+		})
+
+	})
+
 	// --------------------------------------------------------------------------
 	t.Run("No fields / No projection", func(t *testing.T) {
 		db, err := genji.Open(":memory:")
@@ -7,6 +7,17 @@ INSERT INTO bar (a, b) VALUES (1, 10);
 INSERT INTO foo SELECT * FROM foo;
 -- error:
 
+-- test: same table in set operation
+INSERT INTO foo SELECT * FROM bar UNION SELECT * FROM foo;
+-- error:
+
+-- test: union
+INSERT INTO foo SELECT * FROM bar UNION SELECT * FROM bar;
+SELECT pk(), * FROM foo;
+/* result:
+{"pk()":1, "a":1.0, "b":10.0}
+*/
+
 -- test: No fields / No projection
 INSERT INTO foo SELECT * FROM bar;
 SELECT pk(), * FROM foo;
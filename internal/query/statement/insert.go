@@ -3,6 +3,7 @@ package statement
 import (
 	"errors"
 
+	"github.com/genjidb/genji/document"
 	"github.com/genjidb/genji/internal/database"
 	"github.com/genjidb/genji/internal/expr"
 	"github.com/genjidb/genji/internal/stream"
@@ -16,6 +17,17 @@ type InsertStmt struct {
 	SelectStmt *StreamStmt
 	Returning  []expr.Expr
 	OnConflict database.OnInsertConflictAction
+
+	// OnConflictTarget lists the paths of the primary key or of the unique index
+	// whose conflicts are resolved, i.e. ON CONFLICT (a, b).
+	OnConflictTarget []document.Path
+	// OnConflictDoNothing is set if conflicting documents are ignored (ON CONFLICT (a) DO NOTHING).
+	// It is only used along with a conflict target, otherwise OnConflict is used.
+	OnConflictDoNothing bool
+	// OnConflictSet holds the pairs of the ON CONFLICT DO UPDATE SET clause, and
+	// OnConflictWhere its optional WHERE clause.
+	OnConflictSet   []UpdateSetPair
+	OnConflictWhere expr.Expr
 }
 
 func (stmt *InsertStmt) ToStream() (*StreamStmt, error) {
@@ -27,7 +39,7 @@ func (stmt *InsertStmt) ToStream() (*StreamStmt, error) {
 		s = stmt.SelectStmt.Stream
 
 		// ensure we are not reading and writing to the same table.
-		if s.First().(*stream.SeqScanOperator).TableName == stmt.TableName {
+		if readsTable(s, stmt.TableName) {
 			return nil, errors.New("cannot read and write to the same table")
 		}
 
@@ -36,7 +48,18 @@ func (stmt *InsertStmt) ToStream() (*StreamStmt, error) {
 		}
 	}
 
-	s = s.Pipe(stream.TableInsert(stmt.TableName, stmt.OnConflict))
+	switch {
+	case len(stmt.OnConflictSet) > 0:
+		pairs := make([]stream.UpsertSetPair, len(stmt.OnConflictSet))
+		for i, pair := range stmt.OnConflictSet {
+			pairs[i] = stream.UpsertSetPair{Path: pair.Path, E: pair.E}
+		}
+		s = s.Pipe(stream.TableUpsertUpdate(stmt.TableName, stmt.OnConflictTarget, pairs, stmt.OnConflictWhere))
+	case stmt.OnConflictDoNothing:
+		s = s.Pipe(stream.TableUpsert(stmt.TableName, stmt.OnConflictTarget))
+	default:
+		s = s.Pipe(stream.TableInsert(stmt.TableName, stmt.OnConflict))
+	}
 
 	if len(stmt.Returning) > 0 {
 		s = s.Pipe(stream.Project(stmt.Returning...))
@@ -47,3 +70,38 @@ func (stmt *InsertStmt) ToStream() (*StreamStmt, error) {
 		ReadOnly: false,
 	}, nil
 }
+
+// readsTable returns true if the stream, one of its joins, subqueries in FROM
+// or combined streams scans the given table.
+func readsTable(s *stream.Stream, tableName string) bool {
+	for op := s.Op; op != nil; op = op.GetPrev() {
+		switch t := op.(type) {
+		case *stream.SeqScanOperator:
+			if t.TableName == tableName {
+				return true
+			}
+		case *stream.JoinOperator:
+			if readsTable(t.Right, tableName) {
+				return true
+			}
+		case *stream.SubqueryOperator:
+			if readsTable(t.S, tableName) {
+				return true
+			}
+		case *stream.ConcatOperator:
+			if readsTable(t.S1, tableName) || readsTable(t.S2, tableName) {
+				return true
+			}
+		case *stream.IntersectOperator:
+			if readsTable(t.S1, tableName) || readsTable(t.S2, tableName) {
+				return true
+			}
+		case *stream.ExceptOperator:
+			if readsTable(t.S1, tableName) || readsTable(t.S2, tableName) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
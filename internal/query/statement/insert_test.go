@@ -3,6 +3,8 @@ package statement_test
 import (
 	"bytes"
 	"database/sql"
+	"fmt"
+	"strings"
 	"testing"
 
 	"github.com/genjidb/genji"
@@ -23,6 +25,8 @@ func TestInsertStmt(t *testing.T) {
 		{"Values / Invalid params", "INSERT INTO test (a, b, c) VALUES ('d', ?)", true, "", []interface{}{'e'}},
 		{"Documents / Named Params", "INSERT INTO test VALUES {a: $a, b: 2.3, c: $c}", false, `[{"pk()":1,"a":1,"b":2.3,"c":true}]`, []interface{}{sql.Named("c", true), sql.Named("a", 1)}},
 		{"Documents / List ", "INSERT INTO test VALUES {a: [1, 2, 3]}", false, `[{"pk()":1,"a":[1,2,3]}]`, nil},
+		{"Values / Multiple rows", "INSERT INTO test (a, b, c) VALUES ('d', 1 + 1, true), (?, 'e', NULL)", false, `[{"pk()":1,"a":"d","b":2,"c":true},{"pk()":2,"a":"g","b":"e","c":null}]`, []interface{}{"g"}},
+		{"Values / Multiple rows with invalid ESCAPE", "INSERT INTO test (a) VALUES ('a' LIKE 'b' ESCAPE 'xx'), (2)", true, ``, nil},
 		{"Select / same table", "INSERT INTO test SELECT * FROM test", true, ``, nil},
 	}
 
@@ -122,6 +126,40 @@ func TestInsertStmt(t *testing.T) {
 		testutil.RequireStreamEq(t, ``, res)
 	})
 
+	t.Run("many rows", func(t *testing.T) {
+		db, err := genji.Open(":memory:")
+		require.NoError(t, err)
+		defer db.Close()
+
+		err = db.Exec(`CREATE TABLE test(a int unique, b int); CREATE INDEX test_b ON test(b)`)
+		require.NoError(t, err)
+
+		// enough rows to be inserted in several batches
+		var sb strings.Builder
+		sb.WriteString("INSERT INTO test (a, b) VALUES ")
+		for i := 0; i < 2500; i++ {
+			if i > 0 {
+				sb.WriteString(", ")
+			}
+			fmt.Fprintf(&sb, "(%d, %d)", i, i%10)
+		}
+
+		err = db.Exec(sb.String())
+		require.NoError(t, err)
+
+		d, err := db.QueryDocument("SELECT COUNT(*) FROM test WHERE b = 3")
+		require.NoError(t, err)
+		testutil.RequireDocJSONEq(t, d, `{"COUNT(*)": 250}`)
+
+		// a duplicate in the last batch rolls back the whole statement
+		err = db.Exec(strings.Replace(sb.String(), "(2499, 9)", "(0, 9)", 1))
+		require.Error(t, err)
+
+		d, err = db.QueryDocument("SELECT COUNT(*) FROM test")
+		require.NoError(t, err)
+		testutil.RequireDocJSONEq(t, d, `{"COUNT(*)": 2500}`)
+	})
+
 	t.Run("with NEXT VALUE FOR", func(t *testing.T) {
 		db, err := genji.Open(":memory:")
 		require.NoError(t, err)
@@ -210,3 +248,29 @@ func TestInsertSelect(t *testing.T) {
 		})
 	}
 }
+
+func TestInsertOnConflictDoUpdate(t *testing.T) {
+	db, err := genji.Open(":memory:")
+	require.NoError(t, err)
+	defer db.Close()
+
+	err = db.Exec(`
+		CREATE TABLE counters(name TEXT PRIMARY KEY, n INTEGER);
+		INSERT INTO counters (name, n) VALUES ('a', 1);
+	`)
+	require.NoError(t, err)
+
+	// the returned documents are the inserted or updated ones
+	st, err := db.Query(`
+		INSERT INTO counters (name, n) VALUES ('a', ?), ('b', ?)
+		ON CONFLICT (name) DO UPDATE SET n = n + excluded.n
+		RETURNING name, n
+	`, 5, 2)
+	require.NoError(t, err)
+	defer st.Close()
+
+	var buf bytes.Buffer
+	err = testutil.IteratorToJSONArray(&buf, st)
+	require.NoError(t, err)
+	require.JSONEq(t, `[{"name": "a", "n": 6}, {"name": "b", "n": 2}]`, buf.String())
+}
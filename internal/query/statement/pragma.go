@@ -0,0 +1,61 @@
+package statement
+
+import (
+	"github.com/genjidb/genji/internal/database"
+	"github.com/genjidb/genji/internal/environment"
+	"github.com/genjidb/genji/internal/expr"
+	"github.com/genjidb/genji/internal/stream"
+)
+
+// PragmaStmt is a statement that reads or modifies an option of the database.
+type PragmaStmt struct {
+	Name string
+	// New value of the pragma. If nil, the statement returns the current value.
+	Value expr.Expr
+}
+
+// IsReadOnly returns true if the statement only reads the value of the pragma.
+func (stmt *PragmaStmt) IsReadOnly() bool {
+	return stmt.Value == nil
+}
+
+// Run stores the new value of the pragma if there is one,
+// otherwise it returns a document containing its current value.
+func (stmt *PragmaStmt) Run(ctx *Context) (Result, error) {
+	p, err := database.GetPragma(stmt.Name)
+	if err != nil {
+		return Result{}, err
+	}
+
+	if stmt.Value != nil {
+		var env environment.Environment
+		env.Tx = ctx.Tx
+		env.Catalog = ctx.Catalog
+		env.Session = ctx.Session
+		env.SetParams(ctx.Params)
+
+		v, err := stmt.Value.Eval(&env)
+		if err != nil {
+			return Result{}, err
+		}
+
+		return Result{}, database.SetPragmaValue(ctx.Tx, ctx.Catalog, p.Name, v)
+	}
+
+	v, err := database.GetPragmaValue(ctx.Tx, ctx.Catalog, p.Name)
+	if err != nil {
+		return Result{}, err
+	}
+
+	newStatement := StreamStmt{
+		PreparedStream: &stream.Stream{
+			Op: stream.Project(
+				&expr.NamedExpr{
+					ExprName: p.Name,
+					Expr:     expr.LiteralValue(v),
+				}),
+		},
+		ReadOnly: true,
+	}
+	return newStatement.Run(ctx)
+}
@@ -0,0 +1,92 @@
+package statement_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/genjidb/genji"
+	"github.com/genjidb/genji/internal/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPragma(t *testing.T) {
+	tests := []struct {
+		name     string
+		query    string
+		expected string
+		fails    bool
+	}{
+		{"Default", `PRAGMA durability`, `{"durability": "full"}`, false},
+		{"Set", `PRAGMA durability = 'normal'; PRAGMA durability`, `{"durability": "normal"}`, false},
+		{"Identifier", `PRAGMA auto_vacuum = incremental; PRAGMA auto_vacuum`, `{"auto_vacuum": "incremental"}`, false},
+		{"Case insensitive", `PRAGMA Default_Collation = 'NOCASE'; PRAGMA DEFAULT_COLLATION`, `{"default_collation": "nocase"}`, false},
+		{"Overwrite", `PRAGMA durability = off; PRAGMA durability = normal; PRAGMA durability`, `{"durability": "normal"}`, false},
+		{"Number", `PRAGMA auto_analyze_threshold = 1; PRAGMA auto_analyze_threshold`, `{"auto_analyze_threshold": 1.0}`, false},
+		{"Negative number", `PRAGMA auto_analyze_threshold = -0.5`, ``, true},
+		{"Duration", `PRAGMA version_retention = '2s'; PRAGMA version_retention`, `{"version_retention": 2000.0}`, false},
+		{"Negative duration", `PRAGMA version_retention = '-2s'`, ``, true},
+		{"Unknown pragma", `PRAGMA foo`, ``, true},
+		{"Invalid value", `PRAGMA durability = 'sometimes'`, ``, true},
+		{"Invalid type", `PRAGMA durability = 1`, ``, true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			db, err := genji.Open(":memory:")
+			require.NoError(t, err)
+			defer db.Close()
+
+			d, err := db.QueryDocument(test.query)
+			if test.fails {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			testutil.RequireDocJSONEq(t, d, test.expected)
+		})
+	}
+
+	t.Run("Persistence", func(t *testing.T) {
+		dir, err := ioutil.TempDir("", "genji")
+		require.NoError(t, err)
+		defer os.RemoveAll(dir)
+
+		db, err := genji.Open(filepath.Join(dir, "test.db"))
+		require.NoError(t, err)
+
+		err = db.Exec(`PRAGMA durability = off`)
+		require.NoError(t, err)
+
+		err = db.Close()
+		require.NoError(t, err)
+
+		db, err = genji.Open(filepath.Join(dir, "test.db"))
+		require.NoError(t, err)
+		defer db.Close()
+
+		d, err := db.QueryDocument(`PRAGMA durability`)
+		require.NoError(t, err)
+		testutil.RequireDocJSONEq(t, d, `{"durability": "off"}`)
+	})
+
+	t.Run("Rollback", func(t *testing.T) {
+		db, err := genji.Open(":memory:")
+		require.NoError(t, err)
+		defer db.Close()
+
+		tx, err := db.Begin(true)
+		require.NoError(t, err)
+
+		err = tx.Exec(`PRAGMA durability = off`)
+		require.NoError(t, err)
+
+		err = tx.Rollback()
+		require.NoError(t, err)
+
+		d, err := db.QueryDocument(`PRAGMA durability`)
+		require.NoError(t, err)
+		testutil.RequireDocJSONEq(t, d, `{"durability": "full"}`)
+	})
+}
@@ -0,0 +1,209 @@
+package statement
+
+import (
+	"github.com/genjidb/genji/internal/database"
+	"github.com/genjidb/genji/internal/stream"
+)
+
+// CheckPrivileges returns an error if the user the session is authenticated as
+// is not allowed to run the statement.
+// Privileges of statements using streams are checked when the stream is iterated.
+func CheckPrivileges(ctx *Context, stmt Statement) error {
+	if ctx.Session == nil || ctx.Session.User() == "" {
+		return nil
+	}
+
+	var tableName string
+
+	switch t := stmt.(type) {
+	case *CreateTableStmt:
+		tableName = t.Info.TableName
+	case *CreateIndexStmt:
+		tableName = t.Info.TableName
+	case *CreateSequenceStmt, DropSequenceStmt, *CreateViewStmt, DropViewStmt:
+		tableName = database.AllTables
+	case *CreateTriggerStmt, DropTriggerStmt:
+		// the statements of a trigger may write to any table
+		tableName = database.AllTables
+	case DropTableStmt:
+		tableName = t.TableName
+	case DropIndexStmt:
+		info, err := ctx.Catalog.GetIndexInfo(ctx.Tx, t.IndexName)
+		if err != nil {
+			// let the statement report the missing index
+			return nil
+		}
+		tableName = info.TableName
+	case AlterStmt:
+		tableName = t.TableName
+	case AlterTableAddField:
+		tableName = t.TableName
+	case AlterTableAlterField:
+		tableName = t.TableName
+	case AlterTableDropConstraint:
+		tableName = t.TableName
+	case AlterTableRenameField:
+		tableName = t.TableName
+	case ReIndexStmt:
+		tableName = t.TableOrIndexName
+		if tableName == "" {
+			tableName = database.AllTables
+		} else if info, err := ctx.Catalog.GetIndexInfo(ctx.Tx, tableName); err == nil {
+			tableName = info.TableName
+		}
+	case AnalyzeStmt:
+		tableName = t.TableName
+		if tableName == "" {
+			tableName = database.AllTables
+		}
+	case *CopyStmt:
+		// the files of the server may hold anything
+		tableName = database.AllTables
+	case *PragmaStmt:
+		if t.Value == nil {
+			return nil
+		}
+		tableName = database.AllTables
+	default:
+		return nil
+	}
+
+	return database.CheckPrivilege(ctx.Tx, ctx.Catalog, ctx.Session.User(), tableName, database.DDLPrivilege)
+}
+
+// checkStreamPrivileges returns an error if the user the session is authenticated as
+// is not allowed to read or write the tables used by the stream.
+// Tables scanned to be updated or deleted from only require the privilege to update or delete them.
+func checkStreamPrivileges(session *database.Session, tx *database.Transaction, catalog database.Catalog, s *stream.Stream) error {
+	if session == nil || session.User() == "" {
+		return nil
+	}
+
+	return checkStreamOperators(session.User(), tx, catalog, s)
+}
+
+func checkStreamOperators(user string, tx *database.Transaction, catalog database.Catalog, s *stream.Stream) error {
+	if s == nil || s.Op == nil {
+		return nil
+	}
+
+	// determine which table is modified by the stream, if any
+	var writtenTable string
+	var writePrivilege database.Privilege
+	switch t := s.Op.(type) {
+	case *stream.TableInsertOperator:
+		writtenTable, writePrivilege = t.Name, database.InsertPrivilege
+	case *stream.TableUpsertOperator:
+		writtenTable, writePrivilege = t.Name, database.InsertPrivilege
+	case *stream.TableReplaceOperator:
+		writtenTable, writePrivilege = t.Name, database.UpdatePrivilege
+	case *stream.TableDeleteOperator:
+		writtenTable, writePrivilege = t.Name, database.DeletePrivilege
+	}
+
+	for op := s.First(); op != nil; op = op.GetNext() {
+		var tableName string
+		var p database.Privilege
+
+		switch t := op.(type) {
+		case *stream.SeqScanOperator:
+			tableName, p = t.TableName, database.SelectPrivilege
+		case *stream.PkScanOperator:
+			tableName, p = t.TableName, database.SelectPrivilege
+		case *stream.IndexScanOperator:
+			info, err := catalog.GetIndexInfo(tx, t.IndexName)
+			if err != nil {
+				return err
+			}
+			tableName, p = info.TableName, database.SelectPrivilege
+		case *stream.UnionScanOperator:
+			tableName, p = t.TableName, database.SelectPrivilege
+		case *stream.ParallelScanOperator:
+			st, ok := t.Stream.First().(*stream.SeqScanOperator)
+			if !ok {
+				err := checkStreamOperators(user, tx, catalog, t.Stream)
+				if err != nil {
+					return err
+				}
+				continue
+			}
+			tableName, p = st.TableName, database.SelectPrivilege
+		case *stream.BatchOperator:
+			st, ok := t.Stream.First().(*stream.SeqScanOperator)
+			if !ok {
+				err := checkStreamOperators(user, tx, catalog, t.Stream)
+				if err != nil {
+					return err
+				}
+				continue
+			}
+			tableName, p = st.TableName, database.SelectPrivilege
+		case *stream.TableInsertOperator, *stream.TableReplaceOperator, *stream.TableDeleteOperator:
+			tableName, p = writtenTable, writePrivilege
+		case *stream.TableUpsertOperator:
+			// updating the conflicting documents also requires the update privilege
+			if len(t.Set) > 0 {
+				err := database.CheckPrivilege(tx, catalog, user, t.Name, database.UpdatePrivilege)
+				if err != nil {
+					return err
+				}
+			}
+			tableName, p = t.Name, database.InsertPrivilege
+		case *stream.ConcatOperator:
+			err := checkStreamOperators(user, tx, catalog, t.S1)
+			if err != nil {
+				return err
+			}
+			err = checkStreamOperators(user, tx, catalog, t.S2)
+			if err != nil {
+				return err
+			}
+			continue
+		case *stream.IntersectOperator:
+			err := checkStreamOperators(user, tx, catalog, t.S1)
+			if err != nil {
+				return err
+			}
+			err = checkStreamOperators(user, tx, catalog, t.S2)
+			if err != nil {
+				return err
+			}
+			continue
+		case *stream.ExceptOperator:
+			err := checkStreamOperators(user, tx, catalog, t.S1)
+			if err != nil {
+				return err
+			}
+			err = checkStreamOperators(user, tx, catalog, t.S2)
+			if err != nil {
+				return err
+			}
+			continue
+		case *stream.JoinOperator:
+			err := checkStreamOperators(user, tx, catalog, t.Right)
+			if err != nil {
+				return err
+			}
+			continue
+		case *stream.SubqueryOperator:
+			err := checkStreamOperators(user, tx, catalog, t.S)
+			if err != nil {
+				return err
+			}
+			continue
+		default:
+			continue
+		}
+
+		if p == database.SelectPrivilege && tableName == writtenTable && writePrivilege != database.InsertPrivilege {
+			p = writePrivilege
+		}
+
+		err := database.CheckPrivilege(tx, catalog, user, tableName, p)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
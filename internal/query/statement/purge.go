@@ -0,0 +1,31 @@
+package statement
+
+import (
+	"github.com/genjidb/genji/internal/expr"
+	"github.com/genjidb/genji/internal/stream"
+)
+
+// PurgeStmt permanently deletes the soft deleted documents of a table.
+type PurgeStmt struct {
+	TableName string
+	WhereExpr expr.Expr
+}
+
+func (stmt *PurgeStmt) ToStream() (*StreamStmt, error) {
+	scan := stream.SeqScan(stmt.TableName)
+	scan.WithDeleted = true
+	s := stream.New(scan)
+
+	if stmt.WhereExpr != nil {
+		s = s.Pipe(stream.Filter(stmt.WhereExpr))
+	}
+
+	op := stream.TableDelete(stmt.TableName)
+	op.Purge = true
+	s = s.Pipe(op)
+
+	return &StreamStmt{
+		Stream:   s,
+		ReadOnly: false,
+	}, nil
+}
@@ -25,7 +25,7 @@ func (stmt ReIndexStmt) Run(ctx *Context) (Result, error) {
 
 	_, err := ctx.Catalog.GetTable(ctx.Tx, stmt.TableOrIndexName)
 	if err == nil {
-		for _, idxName := range ctx.Catalog.ListIndexes(stmt.TableOrIndexName) {
+		for _, idxName := range ctx.Catalog.ListIndexes(ctx.Tx, stmt.TableOrIndexName) {
 			err = ctx.Catalog.ReIndex(ctx.Tx, idxName)
 			if err != nil {
 				return res, err
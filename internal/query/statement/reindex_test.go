@@ -1,6 +1,7 @@
 package statement_test
 
 import (
+	"context"
 	"testing"
 
 	"github.com/genjidb/genji/internal/testutil"
@@ -41,7 +42,7 @@ func TestReIndex(t *testing.T) {
 
 			// truncate all indexes
 			c := db.Catalog
-			for _, idxName := range c.ListIndexes("") {
+			for _, idxName := range c.ListIndexes(tx, "") {
 				idx, err := c.GetIndex(tx, idxName)
 				require.NoError(t, err)
 				err = idx.Truncate()
@@ -55,7 +56,7 @@ func TestReIndex(t *testing.T) {
 			}
 			require.NoError(t, err)
 
-			for _, idxName := range db.Catalog.ListIndexes("") {
+			for _, idxName := range db.Catalog.ListIndexes(tx, "") {
 				idx, err := db.Catalog.GetIndex(tx, idxName)
 				require.NoError(t, err)
 
@@ -68,7 +69,7 @@ func TestReIndex(t *testing.T) {
 				}
 
 				i := 0
-				err = idx.AscendGreaterOrEqual(nil, func(val []byte, key []byte) error {
+				err = idx.AscendGreaterOrEqual(context.Background(), nil, func(val []byte, key []byte) error {
 					i++
 					return nil
 				})
@@ -0,0 +1,169 @@
+package statement
+
+import (
+	"errors"
+
+	"github.com/genjidb/genji/document"
+	errs "github.com/genjidb/genji/errors"
+	"github.com/genjidb/genji/internal/database"
+	"github.com/genjidb/genji/internal/environment"
+	"github.com/genjidb/genji/internal/expr"
+)
+
+// errRoleManagement is returned when an authenticated session tries to manage roles.
+var errRoleManagement = errors.New("permission denied: roles can only be managed by unauthenticated sessions")
+
+// CreateRoleStmt is a DSL that allows creating a full CREATE USER or CREATE ROLE statement.
+type CreateRoleStmt struct {
+	Name        string
+	IfNotExists bool
+	// Login is true for users.
+	Login bool
+	// Password of the role. Optional.
+	Password expr.Expr
+}
+
+// IsReadOnly always returns false. It implements the Statement interface.
+func (stmt *CreateRoleStmt) IsReadOnly() bool {
+	return false
+}
+
+// Run runs the CREATE USER or CREATE ROLE statement in the given transaction.
+// It implements the Statement interface.
+func (stmt *CreateRoleStmt) Run(ctx *Context) (Result, error) {
+	var res Result
+
+	if ctx.Session != nil && ctx.Session.User() != "" {
+		return res, errRoleManagement
+	}
+
+	r := database.Role{
+		Name:  stmt.Name,
+		Login: stmt.Login,
+	}
+
+	if stmt.Password != nil {
+		var env environment.Environment
+		env.Tx = ctx.Tx
+		env.Catalog = ctx.Catalog
+		env.SetParams(ctx.Params)
+
+		v, err := stmt.Password.Eval(&env)
+		if err != nil {
+			return res, err
+		}
+		if v.Type != document.TextValue {
+			return res, errors.New("password must be a text")
+		}
+
+		err = r.SetPassword(v.V.(string))
+		if err != nil {
+			return res, err
+		}
+	}
+
+	err := database.CreateRole(ctx.Tx, ctx.Catalog, &r)
+	if stmt.IfNotExists && errs.IsAlreadyExistsError(err) {
+		err = nil
+	}
+
+	return res, err
+}
+
+// DropRoleStmt is a DSL that allows creating a DROP USER or DROP ROLE statement.
+type DropRoleStmt struct {
+	Name     string
+	IfExists bool
+}
+
+// IsReadOnly always returns false. It implements the Statement interface.
+func (stmt DropRoleStmt) IsReadOnly() bool {
+	return false
+}
+
+// Run runs the DROP USER or DROP ROLE statement in the given transaction.
+// It implements the Statement interface.
+func (stmt DropRoleStmt) Run(ctx *Context) (Result, error) {
+	var res Result
+
+	if ctx.Session != nil && ctx.Session.User() != "" {
+		return res, errRoleManagement
+	}
+
+	err := database.DropRole(ctx.Tx, ctx.Catalog, stmt.Name)
+	if stmt.IfExists && errs.IsNotFoundError(err) {
+		err = nil
+	}
+
+	return res, err
+}
+
+// GrantStmt is a DSL that allows creating a GRANT or a REVOKE statement.
+// It either grants privileges on a table, or makes the grantee member of other roles.
+type GrantStmt struct {
+	// Revoke the privileges or the memberships instead of granting them.
+	Revoke bool
+
+	Privileges []database.Privilege
+	// TableName is database.AllTables to grant privileges on all tables.
+	TableName string
+
+	Roles []string
+
+	Grantee string
+}
+
+// IsReadOnly always returns false. It implements the Statement interface.
+func (stmt *GrantStmt) IsReadOnly() bool {
+	return false
+}
+
+// Run runs the GRANT or REVOKE statement in the given transaction.
+// It implements the Statement interface.
+func (stmt *GrantStmt) Run(ctx *Context) (Result, error) {
+	var res Result
+
+	if ctx.Session != nil && ctx.Session.User() != "" {
+		return res, errRoleManagement
+	}
+
+	r, err := database.GetRole(ctx.Tx, ctx.Catalog, stmt.Grantee)
+	if err != nil {
+		return res, err
+	}
+
+	if len(stmt.Privileges) > 0 {
+		if stmt.TableName != database.AllTables {
+			_, err = ctx.Catalog.GetTableInfo(ctx.Tx, stmt.TableName)
+			if err != nil {
+				return res, err
+			}
+		}
+
+		if stmt.Revoke {
+			r.Revoke(stmt.TableName, stmt.Privileges...)
+		} else {
+			r.Grant(stmt.TableName, stmt.Privileges...)
+		}
+	}
+
+	for _, name := range stmt.Roles {
+		if stmt.Revoke {
+			r.RemoveMembership(name)
+			continue
+		}
+
+		if name == r.Name {
+			return res, errors.New("cannot grant a role to itself")
+		}
+
+		_, err = database.GetRole(ctx.Tx, ctx.Catalog, name)
+		if err != nil {
+			return res, err
+		}
+
+		r.AddMembership(name)
+	}
+
+	return res, database.ReplaceRole(ctx.Tx, ctx.Catalog, r)
+}
@@ -0,0 +1,159 @@
+package statement_test
+
+import (
+	"testing"
+
+	"github.com/genjidb/genji"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRoles(t *testing.T) {
+	setup := func(t *testing.T) *genji.DB {
+		t.Helper()
+
+		db, err := genji.Open(":memory:")
+		require.NoError(t, err)
+
+		err = db.Exec(`
+			CREATE TABLE test(a INTEGER);
+			CREATE TABLE other(a INTEGER);
+			CREATE INDEX test_a_idx ON test(a);
+			INSERT INTO test (a) VALUES (1), (2);
+			INSERT INTO other (a) VALUES (1);
+			CREATE USER alice WITH PASSWORD 'secret';
+			CREATE ROLE readers;
+		`)
+		require.NoError(t, err)
+		return db
+	}
+
+	t.Run("Login", func(t *testing.T) {
+		db := setup(t)
+		defer db.Close()
+
+		_, err := db.Login("alice", "secret")
+		require.NoError(t, err)
+
+		_, err = db.Login("alice", "wrong")
+		require.Error(t, err)
+
+		_, err = db.Login("unknown", "secret")
+		require.Error(t, err)
+
+		// roles created using CREATE ROLE can't log in
+		err = db.Exec(`CREATE ROLE bob WITH PASSWORD 'secret'`)
+		require.NoError(t, err)
+		_, err = db.Login("bob", "secret")
+		require.Error(t, err)
+	})
+
+	t.Run("Privileges", func(t *testing.T) {
+		db := setup(t)
+		defer db.Close()
+
+		alice, err := db.Login("alice", "secret")
+		require.NoError(t, err)
+
+		queries := []string{
+			`SELECT * FROM test`,
+			`SELECT * FROM test WHERE a = 1`,
+			`SELECT COUNT(*) FROM test WHERE b > 1`,
+			`INSERT INTO test (a) VALUES (3)`,
+			`UPDATE test SET a = 10 WHERE a = 3`,
+			`DELETE FROM test WHERE a = 10`,
+			`CREATE INDEX test_a2_idx ON test(a)`,
+			`SELECT * FROM other`,
+		}
+
+		for _, q := range queries {
+			err = alice.Exec(q)
+			require.Error(t, err, q)
+		}
+
+		err = db.Exec(`GRANT SELECT, INSERT, UPDATE, DELETE, DDL ON test TO alice`)
+		require.NoError(t, err)
+
+		for _, q := range queries[:len(queries)-1] {
+			err = alice.Exec(q)
+			require.NoError(t, err, q)
+		}
+
+		// reading another table requires the privilege on that table
+		err = alice.Exec(`INSERT INTO test SELECT * FROM other`)
+		require.Error(t, err)
+		err = alice.Exec(`SELECT * FROM test WHERE a IN (SELECT a FROM other)`)
+		require.Error(t, err)
+
+		err = db.Exec(`REVOKE SELECT ON test FROM alice`)
+		require.NoError(t, err)
+		err = alice.Exec(`SELECT * FROM test`)
+		require.Error(t, err)
+
+		// the privilege to delete from a table is enough to delete filtered documents
+		err = alice.Exec(`DELETE FROM test WHERE a = 2`)
+		require.NoError(t, err)
+	})
+
+	t.Run("Membership", func(t *testing.T) {
+		db := setup(t)
+		defer db.Close()
+
+		alice, err := db.Login("alice", "secret")
+		require.NoError(t, err)
+
+		err = db.Exec(`GRANT SELECT ON * TO readers; GRANT readers TO alice`)
+		require.NoError(t, err)
+
+		err = alice.Exec(`SELECT * FROM test; SELECT * FROM other`)
+		require.NoError(t, err)
+		err = alice.Exec(`INSERT INTO test (a) VALUES (3)`)
+		require.Error(t, err)
+
+		err = db.Exec(`REVOKE readers FROM alice`)
+		require.NoError(t, err)
+		err = alice.Exec(`SELECT * FROM test`)
+		require.Error(t, err)
+
+		err = db.Exec(`GRANT readers TO alice; DROP ROLE readers`)
+		require.NoError(t, err)
+		err = alice.Exec(`SELECT * FROM test`)
+		require.Error(t, err)
+	})
+
+	t.Run("Role management", func(t *testing.T) {
+		db := setup(t)
+		defer db.Close()
+
+		err := db.Exec(`GRANT ALL ON * TO alice`)
+		require.NoError(t, err)
+
+		alice, err := db.Login("alice", "secret")
+		require.NoError(t, err)
+
+		err = alice.Exec(`CREATE TABLE foo; DROP TABLE foo`)
+		require.NoError(t, err)
+
+		err = alice.Exec(`CREATE USER bob`)
+		require.Error(t, err)
+		err = alice.Exec(`GRANT readers TO alice`)
+		require.Error(t, err)
+
+		err = db.Exec(`CREATE USER alice`)
+		require.Error(t, err)
+		err = db.Exec(`CREATE USER IF NOT EXISTS alice`)
+		require.NoError(t, err)
+		err = db.Exec(`GRANT SELECT ON unknown TO alice`)
+		require.Error(t, err)
+		err = db.Exec(`GRANT SELECT ON test TO unknown`)
+		require.Error(t, err)
+		err = db.Exec(`GRANT alice TO alice`)
+		require.Error(t, err)
+
+		err = db.Exec(`DROP USER alice`)
+		require.NoError(t, err)
+		err = db.Exec(`DROP USER alice`)
+		require.Error(t, err)
+		err = db.Exec(`DROP USER IF EXISTS alice`)
+		require.NoError(t, err)
+	})
+}
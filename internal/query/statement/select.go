@@ -12,19 +12,44 @@ import (
 
 // SelectStmt holds SELECT configuration.
 type SelectStmt struct {
-	TableName        string
-	Distinct         bool
-	WhereExpr        expr.Expr
-	GroupByExpr      expr.Expr
-	OrderBy          expr.Path
-	OrderByDirection scanner.Token
-	OffsetExpr       expr.Expr
-	LimitExpr        expr.Expr
-	ProjectionExprs  []expr.Expr
-	Union            struct {
-		All        bool
-		SelectStmt *StreamStmt
+	TableName string
+	// Subquery is set instead of TableName when reading from a subquery.
+	Subquery        *StreamStmt
+	TableAlias      string
+	IndexHint       *stream.IndexHint
+	Joins           []Join
+	Distinct        bool
+	WhereExpr       expr.Expr
+	GroupByExpr     expr.Expr
+	OrderBy         []expr.OrderTerm
+	OffsetExpr      expr.Expr
+	LimitExpr       expr.Expr
+	ProjectionExprs []expr.Expr
+	// AsOf, if set, reads the table as it was at the time it evaluates to.
+	AsOf expr.Expr
+	// WithDeleted also reads the soft deleted documents of the table.
+	WithDeleted bool
+}
+
+// Join holds the configuration of a table joined in a SELECT statement.
+type Join struct {
+	TableName string
+	// Subquery is set instead of TableName when joining a subquery.
+	Subquery  *StreamStmt
+	Alias     string
+	IndexHint *stream.IndexHint
+	// Left is true for LEFT JOIN.
+	Left bool
+	On   expr.Expr
+}
+
+// name returns the name used to refer to the joined table.
+func (j *Join) name() string {
+	if j.Alias != "" {
+		return j.Alias
 	}
+
+	return j.TableName
 }
 
 func (stmt *SelectStmt) ToStream() (*StreamStmt, error) {
@@ -32,8 +57,38 @@ func (stmt *SelectStmt) ToStream() (*StreamStmt, error) {
 
 	var s *stream.Stream
 
-	if stmt.TableName != "" {
-		s = stream.New(stream.SeqScan(stmt.TableName))
+	switch {
+	case stmt.Subquery != nil:
+		s = stream.New(stream.Subquery(stmt.Subquery.Stream))
+		isReadOnly = stmt.Subquery.ReadOnly
+	case stmt.TableName != "":
+		scan := stream.SeqScan(stmt.TableName)
+		scan.IndexHint = stmt.IndexHint
+		scan.AsOf = stmt.AsOf
+		scan.WithDeleted = stmt.WithDeleted
+		s = stream.New(scan)
+
+		if len(stmt.Joins) == 0 && stmt.TableAlias == "" {
+			setOuterTable(stmt.TableName, stmt.WhereExpr, stmt.GroupByExpr)
+			setOuterTable(stmt.TableName, stmt.ProjectionExprs...)
+			for _, t := range stmt.OrderBy {
+				setOuterTable(stmt.TableName, t.E)
+			}
+		}
+	}
+
+	if len(stmt.Joins) > 0 || stmt.TableAlias != "" {
+		var err error
+		s, err = stmt.pipeJoins(s)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, j := range stmt.Joins {
+			if j.Subquery != nil && !j.Subquery.ReadOnly {
+				isReadOnly = false
+			}
+		}
 	}
 
 	if stmt.WhereExpr != nil {
@@ -100,10 +155,29 @@ func (stmt *SelectStmt) ToStream() (*StreamStmt, error) {
 		if len(aggregators) > 0 {
 			s = s.Pipe(stream.HashAggregate(aggregators...))
 		}
+
+		// window functions are evaluated before the projection,
+		// using one window node per window definition
+		windows, err := stmt.windows()
+		if err != nil {
+			return nil, err
+		}
+		if len(windows) > 0 {
+			if len(aggregators) > 0 {
+				return nil, errors.New("window functions cannot be used with aggregate functions")
+			}
+			if stmt.TableName == "" && stmt.Subquery == nil {
+				return nil, errors.New("window functions require a FROM clause")
+			}
+
+			for _, funcs := range windows {
+				s = s.Pipe(stream.Window(funcs...))
+			}
+		}
 	}
 
 	// If there is no FROM clause ensure there is no wildcard or path
-	if stmt.TableName == "" {
+	if stmt.TableName == "" && stmt.Subquery == nil {
 		var err error
 
 		for _, e := range stmt.ProjectionExprs {
@@ -141,12 +215,8 @@ func (stmt *SelectStmt) ToStream() (*StreamStmt, error) {
 		s = s.Pipe(stream.Distinct())
 	}
 
-	if stmt.OrderBy != nil {
-		if stmt.OrderByDirection == scanner.DESC {
-			s = s.Pipe(stream.SortReverse(stmt.OrderBy))
-		} else {
-			s = s.Pipe(stream.Sort(stmt.OrderBy))
-		}
+	if len(stmt.OrderBy) > 0 {
+		s = s.Pipe(stream.SortBy(stmt.OrderBy...))
 	}
 
 	if stmt.OffsetExpr != nil {
@@ -185,10 +255,6 @@ func (stmt *SelectStmt) ToStream() (*StreamStmt, error) {
 		s = s.Pipe(stream.Take(v.V.(int64)))
 	}
 
-	if stmt.Union.SelectStmt != nil {
-		s = stream.New(stream.Concat(s, stmt.Union.SelectStmt.Stream))
-	}
-
 	// SELECT is read-only most of the time, unless it's using some expressions
 	// that require write access and that are allowed to be run, such as NEXT VALUE FOR
 	for _, e := range stmt.ProjectionExprs {
@@ -208,3 +274,135 @@ func (stmt *SelectStmt) ToStream() (*StreamStmt, error) {
 		ReadOnly: isReadOnly,
 	}, nil
 }
+
+// windows returns the window functions of the projection, grouped by window definition,
+// in the order they appear.
+// It returns an error if a window function is used without an OVER clause.
+func (stmt *SelectStmt) windows() ([][]*expr.WindowExpr, error) {
+	var windows [][]*expr.WindowExpr
+	var misused expr.Expr
+
+	for _, pe := range stmt.ProjectionExprs {
+		// the function of a WindowExpr is visited right after it,
+		// any other window function is used without an OVER clause
+		var over expr.Expr
+		expr.Walk(pe, func(e expr.Expr) bool {
+			if f, ok := e.(expr.WindowFunc); ok {
+				if f != over {
+					misused = f
+				}
+				over = nil
+				return true
+			}
+
+			w, ok := e.(*expr.WindowExpr)
+			if !ok {
+				return true
+			}
+			over = w.Func
+
+			for i := range windows {
+				if windows[i][0].Window.IsEqual(&w.Window) {
+					windows[i] = append(windows[i], w)
+					return true
+				}
+			}
+
+			windows = append(windows, []*expr.WindowExpr{w})
+			return true
+		})
+	}
+
+	if misused != nil {
+		return nil, stringutil.Errorf("misuse of window function %s, it must be used with an OVER clause", misused)
+	}
+
+	return windows, nil
+}
+
+// pipeJoins names the documents read by the FROM clause after the table or its alias,
+// then adds one join operator per joined table.
+// The documents of each table are stored in a field named after the table or its alias,
+// and the tables are read in the order of the FROM clause.
+func (stmt *SelectStmt) pipeJoins(s *stream.Stream) (*stream.Stream, error) {
+	name := stmt.TableAlias
+	if name == "" {
+		name = stmt.TableName
+	}
+	if name == "" {
+		return nil, errors.New("subqueries in FROM must have an alias to be joined")
+	}
+	names := map[string]bool{name: true}
+
+	s = s.Pipe(stream.Wrap(name))
+
+	for i := range stmt.Joins {
+		j := &stmt.Joins[i]
+
+		name := j.name()
+		if name == "" {
+			return nil, errors.New("subqueries in FROM must have an alias to be joined")
+		}
+		if names[name] {
+			return nil, stringutil.Errorf("table name %q specified more than once, use an alias", name)
+		}
+		names[name] = true
+
+		var right *stream.Stream
+		if j.Subquery != nil {
+			right = stream.New(stream.Subquery(j.Subquery.Stream))
+		} else {
+			scan := stream.SeqScan(j.TableName)
+			scan.IndexHint = j.IndexHint
+			right = stream.New(scan)
+		}
+
+		op := stream.Join(name, right, j.On)
+		op.Left = j.Left
+		s = s.Pipe(op)
+	}
+
+	return s, nil
+}
+
+// SetOperationStmt combines the documents returned by two SELECT statements
+// using UNION, INTERSECT or EXCEPT.
+type SetOperationStmt struct {
+	// Operator is one of scanner.UNION, scanner.INTERSECT or scanner.EXCEPT.
+	Operator scanner.Token
+	// All keeps duplicate documents.
+	All   bool
+	Left  *StreamStmt
+	Right *StreamStmt
+}
+
+func (stmt *SetOperationStmt) ToStream() (*StreamStmt, error) {
+	var s *stream.Stream
+
+	switch stmt.Operator {
+	case scanner.UNION:
+		s = stream.New(stream.Concat(stmt.Left.Stream, stmt.Right.Stream))
+		if !stmt.All {
+			s = s.Pipe(stream.Distinct())
+		}
+	case scanner.INTERSECT:
+		if stmt.All {
+			s = stream.New(stream.IntersectAll(stmt.Left.Stream, stmt.Right.Stream))
+		} else {
+			s = stream.New(stream.Intersect(stmt.Left.Stream, stmt.Right.Stream))
+		}
+	case scanner.EXCEPT:
+		if stmt.All {
+			s = stream.New(stream.ExceptAll(stmt.Left.Stream, stmt.Right.Stream))
+		} else {
+			s = stream.New(stream.Except(stmt.Left.Stream, stmt.Right.Stream))
+		}
+	default:
+		return nil, stringutil.Errorf("unsupported set operation %s", stmt.Operator)
+	}
+
+	return &StreamStmt{
+		Stream:   s,
+		ReadOnly: stmt.Left.ReadOnly && stmt.Right.ReadOnly,
+	}, nil
+}
@@ -6,6 +6,7 @@ import (
 	"encoding/json"
 	"strconv"
 	"testing"
+	"time"
 
 	"github.com/genjidb/genji"
 	"github.com/genjidb/genji/document"
@@ -80,6 +81,8 @@ func TestSelectStmt(t *testing.T) {
 		{"With offset then limit", "SELECT * FROM test WHERE size = 10 OFFSET 1 LIMIT 1", true, "", nil},
 		{"With positional params", "SELECT * FROM test WHERE color = ? OR height = ?", false, `[{"k":1,"color":"red","size":10,"shape":"square"},{"k":3,"height":100,"weight":200}]`, []interface{}{"red", 100}},
 		{"With named params", "SELECT * FROM test WHERE color = $a OR height = $d", false, `[{"k":1,"color":"red","size":10,"shape":"square"},{"k":3,"height":100,"weight":200}]`, []interface{}{sql.Named("a", "red"), sql.Named("d", 100)}},
+		{"With slice param", "SELECT * FROM test WHERE k IN ? OR color IN ? ORDER BY k", false, `[{"k":1,"color":"red","size":10,"shape":"square"},{"k":3,"height":100,"weight":200}]`, []interface{}{[]int{3, 4}, []string{"red"}}},
+		{"With slice param and index", "SELECT * FROM test WHERE color IN ?", false, `[{"k":1,"color":"red","size":10,"shape":"square"}]`, []interface{}{[]string{"red", "green", "red"}}},
 		{"With pk()", "SELECT pk(), color FROM test", false, `[{"pk()":1,"color":"red"},{"pk()":2,"color":"blue"},{"pk()":3,"color":null}]`, []interface{}{sql.Named("a", "red"), sql.Named("d", 100)}},
 		{"With pk in cond, gt", "SELECT * FROM test WHERE k > 0 AND weight = 100", false, `[{"k":2,"color":"blue","size":10,"weight":100,"k":2}]`, nil},
 		{"With pk in cond, =", "SELECT * FROM test WHERE k = 2.0 AND weight = 100", false, `[{"k":2,"color":"blue","size":10,"weight":100,"k":2}]`, nil},
@@ -92,6 +95,18 @@ func TestSelectStmt(t *testing.T) {
 		{"With multiple maxs", "SELECT MAX(color), MAX(weight) FROM test", false, `[{"MAX(color)": "red", "MAX(weight)": 200}]`, nil},
 		{"With sum", "SELECT SUM(k) FROM test", false, `[{"SUM(k)": 6}]`, nil},
 		{"With multiple sums", "SELECT SUM(color), SUM(weight) FROM test", false, `[{"SUM(color)": null, "SUM(weight)": 300}]`, nil},
+		{"With count distinct", "SELECT COUNT(DISTINCT size), COUNT(size) FROM test", false, `[{"COUNT(DISTINCT size)": 1, "COUNT(size)": 2}]`, nil},
+		{"With sum distinct", "SELECT SUM(DISTINCT size) FROM test", false, `[{"SUM(DISTINCT size)": 10}]`, nil},
+		{"With avg distinct", "SELECT AVG(DISTINCT size) FROM test", false, `[{"AVG(DISTINCT size)": 10.0}]`, nil},
+		{"With array_agg", "SELECT ARRAY_AGG(size) FROM test", false, `[{"ARRAY_AGG(size)": [10, 10, null]}]`, nil},
+		{"With array_agg distinct", "SELECT ARRAY_AGG(DISTINCT size) FROM test", false, `[{"ARRAY_AGG(DISTINCT size)": [10, null]}]`, nil},
+		{"With group by and count distinct", "SELECT COUNT(DISTINCT color) FROM test GROUP BY size", false, `[{"COUNT(DISTINCT color)":2},{"COUNT(DISTINCT color)":0}]`, nil},
+		{"With distinct in non aggregate function", "SELECT pk(DISTINCT k) FROM test", true, ``, nil},
+		{"With = ANY array", "SELECT k FROM test WHERE color = ANY ['red', 'green']", false, `[{"k":1}]`, nil},
+		{"With = ANY subquery", "SELECT k FROM test WHERE size = ANY (SELECT size FROM test WHERE k = 2)", false, `[{"k":1},{"k":2}]`, nil},
+		{"With > ALL subquery", "SELECT k FROM test WHERE weight > ALL (SELECT weight FROM test WHERE k < 3)", false, `[]`, nil},
+		{"With >= ALL subquery", "SELECT k FROM test WHERE weight >= ALL (SELECT weight FROM test WHERE weight IS NOT NULL)", false, `[{"k":3}]`, nil},
+		{"With < SOME empty subquery", "SELECT k FROM test WHERE k < SOME (SELECT k FROM test WHERE k > 10)", false, `[]`, nil},
 		{"With two non existing idents, =", "SELECT * FROM test WHERE z = y", false, `[]`, nil},
 		{"With two non existing idents, >", "SELECT * FROM test WHERE z > y", false, `[]`, nil},
 		{"With two non existing idents, !=", "SELECT * FROM test WHERE z != y", false, `[]`, nil},
@@ -102,6 +117,8 @@ func TestSelectStmt(t *testing.T) {
 		{"Invalid use of MAX() aggregator", "SELECT * FROM test LIMIT max(0)", true, ``, nil},
 		{"Invalid use of SUM() aggregator", "SELECT * FROM test LIMIT sum(0)", true, ``, nil},
 		{"Invalid use of AVG() aggregator", "SELECT * FROM test LIMIT avg(0)", true, ``, nil},
+		{"With constant LIKE and invalid ESCAPE", "SELECT * FROM test WHERE 'a' LIKE 'b' ESCAPE 'xx'", true, ``, nil},
+		{"With constant ILIKE and empty ESCAPE", "SELECT * FROM test WHERE 'a' ILIKE 'b' ESCAPE ''", true, ``, nil},
 	}
 
 	for _, test := range tests {
@@ -178,6 +195,264 @@ func TestSelectStmt(t *testing.T) {
 		require.JSONEq(t, `[{"foo": 2, "bar": "b"},{"foo": 3, "bar": "c"},{"foo": 4, "bar": "d"}]`, buf.String())
 	})
 
+	t.Run("with sorted groups", func(t *testing.T) {
+		db, err := genji.Open(":memory:")
+		require.NoError(t, err)
+		defer db.Close()
+
+		err = db.Exec(`
+			CREATE TABLE test (k INTEGER PRIMARY KEY, a INTEGER);
+			CREATE INDEX idx_a ON test (a);
+			INSERT INTO test (k, a) VALUES (1, 2), (2, 1), (3, 2), (4, 3), (5, 1), (6, 2);
+		`)
+		require.NoError(t, err)
+
+		tests := []struct {
+			query, plan, expected string
+		}{
+			{"SELECT a, COUNT(*) AS n FROM test GROUP BY a ORDER BY a", `indexScan("idx_a", covering) | groupBy(a) | streamAggregate(COUNT(*)) | project(a, COUNT(*))`, `[{"a": 1, "n": 2}, {"a": 2, "n": 3}, {"a": 3, "n": 1}]`},
+			{"SELECT a, SUM(k) AS s FROM test WHERE a > 1 GROUP BY a ORDER BY a DESC", `indexScanReverse("idx_a", [1, -1, true], covering) | groupBy(a) | streamAggregate(SUM(k)) | project(a, SUM(k))`, `[{"a": 3, "s": 4}, {"a": 2, "s": 10}]`},
+			{"SELECT k, COUNT(*) AS n FROM test GROUP BY k ORDER BY k DESC LIMIT 2", `seqScanReverse(test, paths(k)) | groupBy(k) | streamAggregate(COUNT(*)) | take(2) | project(k, COUNT(*))`, `[{"k": 6, "n": 1}, {"k": 5, "n": 1}]`},
+			{"SELECT a, COUNT(*) AS n FROM test WHERE k > 3 GROUP BY a ORDER BY a", `pkScan("test", [3, -1, true], paths(a)) | groupBy(a) | hashAggregate(COUNT(*)) | project(a, COUNT(*)) | sort(a)`, `[{"a": 1, "n": 1}, {"a": 2, "n": 1}, {"a": 3, "n": 1}]`},
+		}
+
+		for _, test := range tests {
+			t.Run(test.query, func(t *testing.T) {
+				d, err := db.QueryDocument("EXPLAIN " + test.query)
+				require.NoError(t, err)
+				testutil.RequireDocJSONEq(t, d, `{"plan": `+strconv.Quote(test.plan)+`}`)
+
+				st, err := db.Query(test.query)
+				require.NoError(t, err)
+				defer st.Close()
+
+				var buf bytes.Buffer
+				err = testutil.IteratorToJSONArray(&buf, st)
+				require.NoError(t, err)
+				require.JSONEq(t, test.expected, buf.String())
+			})
+		}
+	})
+
+	t.Run("with OR conditions", func(t *testing.T) {
+		db, err := genji.Open(":memory:")
+		require.NoError(t, err)
+		defer db.Close()
+
+		err = db.Exec(`
+			CREATE TABLE test (k INTEGER PRIMARY KEY, a INTEGER, b INTEGER);
+			CREATE INDEX idx_a ON test (a);
+			CREATE INDEX idx_b ON test (b);
+			INSERT INTO test (k, a, b) VALUES (1, 1, 1), (2, 1, 2), (3, 2, 2), (4, 3, 3), (5, 5, 1);
+		`)
+		require.NoError(t, err)
+
+		tests := []struct {
+			query, plan, expected string
+		}{
+			{"SELECT k FROM test WHERE a = 1 OR a = 5", `unionScan(indexScan("idx_a", 1), indexScan("idx_a", 5)) | project(k)`, `[{"k": 1}, {"k": 2}, {"k": 5}]`},
+			{"SELECT k FROM test WHERE a = 1 OR b = 2", `unionScan(indexScan("idx_a", 1), indexScan("idx_b", 2)) | project(k)`, `[{"k": 1}, {"k": 2}, {"k": 3}]`},
+			{"SELECT k FROM test WHERE (a = 1 AND b > 1) OR k >= 4 ORDER BY k DESC", `unionScan(indexScan("idx_a", 1) | filter(b > 1), pkScan("test", [4, -1])) | project(k) | sortReverse(k)`, `[{"k": 5}, {"k": 4}, {"k": 2}]`},
+		}
+
+		for _, test := range tests {
+			t.Run(test.query, func(t *testing.T) {
+				d, err := db.QueryDocument("EXPLAIN " + test.query)
+				require.NoError(t, err)
+				testutil.RequireDocJSONEq(t, d, `{"plan": `+strconv.Quote(test.plan)+`}`)
+
+				st, err := db.Query(test.query)
+				require.NoError(t, err)
+				defer st.Close()
+
+				var buf bytes.Buffer
+				err = testutil.IteratorToJSONArray(&buf, st)
+				require.NoError(t, err)
+				require.JSONEq(t, test.expected, buf.String())
+			})
+		}
+	})
+
+	t.Run("with IN conditions", func(t *testing.T) {
+		db, err := genji.Open(":memory:")
+		require.NoError(t, err)
+		defer db.Close()
+
+		err = db.Exec(`
+			CREATE TABLE test (k INTEGER PRIMARY KEY, a INTEGER, b INTEGER);
+			CREATE INDEX idx_a ON test (a);
+			CREATE INDEX idx_a_b ON test (a, b);
+			INSERT INTO test (k, a, b) VALUES (1, 1, 1), (2, 1, 2), (3, 2, 2), (4, 3, 3), (5, 5, 1);
+		`)
+		require.NoError(t, err)
+
+		tests := []struct {
+			query    string
+			params   []interface{}
+			plan     string
+			expected string
+		}{
+			{"SELECT k FROM test WHERE a IN (1, 5)", nil, `indexScan("idx_a", 1, 5, covering) | project(k)`, `[{"k": 1}, {"k": 2}, {"k": 5}]`},
+			{"SELECT k FROM test WHERE a IN (1, ?)", []interface{}{1}, `indexScan("idx_a", 1, ?, covering) | project(k)`, `[{"k": 1}, {"k": 2}]`},
+			{"SELECT k FROM test WHERE a IN ?", []interface{}{[]int{3, 1, 3}}, `indexScan("idx_a", IN ?, covering) | project(k)`, `[{"k": 4}, {"k": 1}, {"k": 2}]`},
+			{"SELECT k FROM test WHERE a IN (1, 2) AND b IN (2, 3)", nil, `indexScan("idx_a_b", [1, 2], [1, 3], [2, 2], [2, 3], covering) | project(k)`, `[{"k": 2}, {"k": 3}]`},
+			{"SELECT k FROM test WHERE a IN ? AND b IN ?", []interface{}{[]int{1, 5}, []int{1}}, `indexScan("idx_a_b", IN [?, ?], covering) | project(k)`, `[{"k": 1}, {"k": 5}]`},
+			{"SELECT k FROM test WHERE k IN (1, ?)", []interface{}{1}, `pkScan("test", 1, ?, paths(k)) | project(k)`, `[{"k": 1}]`},
+		}
+
+		for _, test := range tests {
+			t.Run(test.query, func(t *testing.T) {
+				d, err := db.QueryDocument("EXPLAIN "+test.query, test.params...)
+				require.NoError(t, err)
+				testutil.RequireDocJSONEq(t, d, `{"plan": `+strconv.Quote(test.plan)+`}`)
+
+				st, err := db.Query(test.query, test.params...)
+				require.NoError(t, err)
+				defer st.Close()
+
+				var buf bytes.Buffer
+				err = testutil.IteratorToJSONArray(&buf, st)
+				require.NoError(t, err)
+				require.JSONEq(t, test.expected, buf.String())
+			})
+		}
+	})
+
+	t.Run("with covering indexes", func(t *testing.T) {
+		db, err := genji.Open(":memory:")
+		require.NoError(t, err)
+		defer db.Close()
+
+		err = db.Exec(`
+			CREATE TABLE test (k TEXT PRIMARY KEY, a INTEGER, b DOUBLE, c TEXT, d TIMESTAMP, e DECIMAL);
+			CREATE INDEX idx_a_b_c ON test (a, b, c);
+			CREATE INDEX idx_d ON test (d);
+			CREATE INDEX idx_e ON test (e);
+			INSERT INTO test (k, a, b, c, d, e) VALUES
+				('x', 1, 1.5, 'foo', '2021-01-01T10:00:00Z', 1.25),
+				('y', 2, -2.5, '', '2021-06-01T10:00:00Z', 2.5),
+				('z', 3, 3, 'bar', '2022-01-01T10:00:00Z', 3);
+			CREATE TABLE nopk (a INTEGER);
+			CREATE INDEX idx_nopk_a ON nopk (a);
+			INSERT INTO nopk (a) VALUES (5), (4);
+		`)
+		require.NoError(t, err)
+
+		tests := []struct {
+			query    string
+			plan     string
+			expected string
+		}{
+			{"SELECT k, a, b, c FROM test WHERE a > 1", `indexScan("idx_a_b_c", [1, -1, true], covering) | project(k, a, b, c)`, `[{"k": "y", "a": 2, "b": -2.5, "c": ""}, {"k": "z", "a": 3, "b": 3.0, "c": "bar"}]`},
+			{"SELECT c FROM test WHERE a = 1 AND b = 1.5", `indexScan("idx_a_b_c", [1, 1.5], covering) | project(c)`, `[{"c": "foo"}]`},
+			{"SELECT k, d FROM test WHERE d >= CAST('2021-06-01' AS TIMESTAMP)", `indexScan("idx_d", [CAST("2021-06-01" AS timestamp), -1], covering) | project(k, d)`, `[{"k": "y", "d": "2021-06-01T10:00:00Z"}, {"k": "z", "d": "2022-01-01T10:00:00Z"}]`},
+			{"SELECT COUNT(*) FROM test WHERE a < 3", `indexScan("idx_a_b_c", [-1, 3, true], covering) | hashAggregate(COUNT(*)) | project(COUNT(*))`, `[{"COUNT(*)": 2}]`},
+			{"SELECT e FROM test WHERE e > 2", `indexScan("idx_e", [2, -1, true], paths(e)) | project(e)`, `[{"e": 2.5}, {"e": 3.0}]`},
+			{"SELECT pk(), a FROM nopk WHERE a > 0", `indexScan("idx_nopk_a", [0, -1, true], covering) | project(pk(), a)`, `[{"pk()": 2, "a": 4}, {"pk()": 1, "a": 5}]`},
+		}
+
+		for _, test := range tests {
+			t.Run(test.query, func(t *testing.T) {
+				d, err := db.QueryDocument("EXPLAIN " + test.query)
+				require.NoError(t, err)
+				testutil.RequireDocJSONEq(t, d, `{"plan": `+strconv.Quote(test.plan)+`}`)
+
+				st, err := db.Query(test.query)
+				require.NoError(t, err)
+				defer st.Close()
+
+				var buf bytes.Buffer
+				err = testutil.IteratorToJSONArray(&buf, st)
+				require.NoError(t, err)
+				require.JSONEq(t, test.expected, buf.String())
+			})
+		}
+	})
+
+	t.Run("with filters evaluated on the keys", func(t *testing.T) {
+		db, err := genji.Open(":memory:")
+		require.NoError(t, err)
+		defer db.Close()
+
+		err = db.Exec(`
+			CREATE TABLE test (k INTEGER PRIMARY KEY, a INTEGER, b INTEGER, c TEXT);
+			CREATE INDEX idx_a_b ON test (a, b);
+			INSERT INTO test (k, a, b, c) VALUES (1, 1, 2, 'foo'), (2, 2, 2, 'bar'), (3, 2, 1, 'baz'), (4, 2, 3, 'qux');
+		`)
+		require.NoError(t, err)
+
+		tests := []struct {
+			query, plan, expected string
+		}{
+			{"SELECT * FROM test WHERE a = 2 AND b != 1", `indexScan("idx_a_b", 2, filter(b != 1))`, `[{"k": 2, "a": 2, "b": 2, "c": "bar"}, {"k": 4, "a": 2, "b": 3, "c": "qux"}]`},
+			{"SELECT c FROM test WHERE a = 2 AND b != 1 AND c != 'bar'", `indexScan("idx_a_b", 2, filter(b != 1), paths(c)) | filter(c != "bar") | project(c)`, `[{"c": "qux"}]`},
+			{"SELECT c FROM test WHERE k % 2 = 1 LIMIT 1 OFFSET 1", `seqScan(test, filter(k % 2 = 1), paths(c), offset 1, limit 1) | project(c)`, `[{"c": "baz"}]`},
+		}
+
+		for _, test := range tests {
+			t.Run(test.query, func(t *testing.T) {
+				d, err := db.QueryDocument("EXPLAIN " + test.query)
+				require.NoError(t, err)
+				testutil.RequireDocJSONEq(t, d, `{"plan": `+strconv.Quote(test.plan)+`}`)
+
+				st, err := db.Query(test.query)
+				require.NoError(t, err)
+				defer st.Close()
+
+				var buf bytes.Buffer
+				err = testutil.IteratorToJSONArray(&buf, st)
+				require.NoError(t, err)
+				require.JSONEq(t, test.expected, buf.String())
+			})
+		}
+	})
+
+	t.Run("with composite index", func(t *testing.T) {
+		db, err := genji.Open(":memory:")
+		require.NoError(t, err)
+		defer db.Close()
+
+		err = db.Exec(`
+			CREATE TABLE test (a TEXT, b INTEGER);
+			CREATE INDEX idx_a_b ON test (a, b);
+			INSERT INTO test (a, b) VALUES ('a', 1), ('ab', 1), ('a', 2), ('b', 3), ('a', 3), ('ab', 4);
+		`)
+		require.NoError(t, err)
+
+		tests := []struct {
+			query, plan, expected string
+		}{
+			{"SELECT b FROM test WHERE a = 'a'", `indexScan("idx_a_b", "a", paths(b)) | project(b)`, `[{"b": 1}, {"b": 2}, {"b": 3}]`},
+			{"SELECT b FROM test WHERE a = 'a' AND b > 1", `indexScan("idx_a_b", [["a", 1], "a", true], paths(b)) | project(b)`, `[{"b": 2}, {"b": 3}]`},
+			{"SELECT b FROM test WHERE a = 'a' AND b >= 2", `indexScan("idx_a_b", [["a", 2], "a"], paths(b)) | project(b)`, `[{"b": 2}, {"b": 3}]`},
+			{"SELECT b FROM test WHERE a = 'a' AND b < 3", `indexScan("idx_a_b", ["a", ["a", 3], true], paths(b)) | project(b)`, `[{"b": 1}, {"b": 2}]`},
+			{"SELECT b FROM test WHERE b <= 2 AND a = 'ab'", `indexScan("idx_a_b", ["ab", ["ab", 2]], paths(b)) | project(b)`, `[{"b": 1}]`},
+			{"SELECT a, b FROM test WHERE a IN ['a', 'b'] AND b > 2", `indexScan("idx_a_b", [["a", 2], "a", true], [["b", 2], "b", true], paths(a, b)) | project(a, b)`, `[{"a": "a", "b": 3}, {"a": "b", "b": 3}]`},
+			{"SELECT b FROM test WHERE a > 'a'", `indexScan("idx_a_b", ["a", -1, true], paths(b)) | project(b)`, `[{"b": 1}, {"b": 4}, {"b": 3}]`},
+			{"SELECT a, b FROM test ORDER BY a, b LIMIT 3", `indexScan("idx_a_b", paths(a, b), limit 3) | project(a, b)`, `[{"a": "a", "b": 1}, {"a": "a", "b": 2}, {"a": "a", "b": 3}]`},
+			{"SELECT b FROM test WHERE a = 'a' ORDER BY b DESC", `indexScanReverse("idx_a_b", "a", paths(b)) | project(b)`, `[{"b": 3}, {"b": 2}, {"b": 1}]`},
+			{"SELECT b FROM test WHERE a = 'a' AND b > 1 ORDER BY b DESC LIMIT 1", `indexScanReverse("idx_a_b", [["a", 1], "a", true], paths(b), limit 1) | project(b)`, `[{"b": 3}]`},
+			{"SELECT a, b FROM test WHERE a IN ['a', 'b'] AND b > 2 ORDER BY b DESC", `indexScan("idx_a_b", [["a", 2], "a", true], [["b", 2], "b", true], paths(a, b)) | project(a, b) | sortReverse(b)`, `[{"a": "a", "b": 3}, {"a": "b", "b": 3}]`},
+		}
+
+		for _, test := range tests {
+			t.Run(test.query, func(t *testing.T) {
+				d, err := db.QueryDocument("EXPLAIN " + test.query)
+				require.NoError(t, err)
+				testutil.RequireDocJSONEq(t, d, `{"plan": `+strconv.Quote(test.plan)+`}`)
+
+				st, err := db.Query(test.query)
+				require.NoError(t, err)
+				defer st.Close()
+
+				var buf bytes.Buffer
+				err = testutil.IteratorToJSONArray(&buf, st)
+				require.NoError(t, err)
+				require.JSONEq(t, test.expected, buf.String())
+			})
+		}
+	})
+
 	t.Run("with documents", func(t *testing.T) {
 		db, err := genji.Open(":memory:")
 		require.NoError(t, err)
@@ -307,6 +582,58 @@ func TestSelectStmt(t *testing.T) {
 		check()
 	})
 
+	t.Run("nearest vectors", func(t *testing.T) {
+		db, err := genji.Open(":memory:")
+		require.NoError(t, err)
+		defer db.Close()
+
+		err = db.Exec(`
+			CREATE TABLE test (k INTEGER PRIMARY KEY, v VECTOR(2) NOT NULL);
+			INSERT INTO test (k, v) VALUES (1, [1, 0]), (2, [0, 1]), (3, [1, 1]), (4, [-1, 0]), (5, [2, 0.1]);
+		`)
+		require.NoError(t, err)
+
+		queries := []struct {
+			q        string
+			expected string
+		}{
+			{"SELECT k FROM test ORDER BY math.cosine_distance(v, ?) LIMIT 3", `[{"k": 1}, {"k": 5}, {"k": 3}]`},
+			{"SELECT k FROM test ORDER BY math.euclidean_distance(?, v) LIMIT 2 OFFSET 1", `[{"k": 3}, {"k": 5}]`},
+		}
+
+		check := func(plan string) {
+			t.Helper()
+
+			for _, q := range queries {
+				st, err := db.Query(q.q, []float64{1, 0})
+				require.NoError(t, err)
+
+				var buf bytes.Buffer
+				err = testutil.IteratorToJSONArray(&buf, st)
+				require.NoError(t, err)
+				require.NoError(t, st.Close())
+				require.JSONEq(t, q.expected, buf.String())
+			}
+
+			d, err := db.QueryDocument("EXPLAIN " + queries[0].q)
+			require.NoError(t, err)
+			v, err := d.GetByField("plan")
+			require.NoError(t, err)
+			require.Equal(t, plan, v.V.(string))
+		}
+
+		check(`seqScan(test, paths(k, v)) | project(k) | sort(cosine_distance(v, ?)) | take(3)`)
+
+		err = db.Exec("CREATE INDEX idx_v ON test (v)")
+		require.NoError(t, err)
+
+		check(`indexNearest("idx_v", cosine_distance, ?, 3) | take(3) | project(k)`)
+
+		// the target must have the dimension of the field
+		_, err = db.QueryDocument(queries[0].q, []float64{1, 0, 0})
+		require.Error(t, err)
+	})
+
 	t.Run("using sequences in SELECT must open read-write transaction instead of read-only", func(t *testing.T) {
 		db, err := genji.Open(":memory:")
 		require.NoError(t, err)
@@ -415,3 +742,461 @@ func TestDistinct(t *testing.T) {
 		})
 	}
 }
+
+func TestSelectQuantifiedSubquery(t *testing.T) {
+	db, err := genji.Open(":memory:")
+	require.NoError(t, err)
+	defer db.Close()
+
+	err = db.Exec(`
+		CREATE TABLE foo(a INTEGER, b INTEGER);
+		INSERT INTO foo (a, b) VALUES (1, 10), (2, 20), (3, NULL);
+	`)
+	require.NoError(t, err)
+
+	t.Run("NULL values", func(t *testing.T) {
+		d, err := db.QueryDocument("SELECT 30 > ALL (SELECT b FROM foo) AS x, 10 = ANY (SELECT b FROM foo) AS y FROM foo WHERE a = 1")
+		require.NoError(t, err)
+
+		enc, err := json.Marshal(d)
+		require.NoError(t, err)
+		require.JSONEq(t, `{"x": null, "y": true}`, string(enc))
+	})
+
+	t.Run("params", func(t *testing.T) {
+		d, err := db.QueryDocument("SELECT COUNT(*) FROM foo WHERE a = ANY (SELECT a FROM foo WHERE b >= ?)", 20)
+		require.NoError(t, err)
+
+		enc, err := json.Marshal(d)
+		require.NoError(t, err)
+		require.JSONEq(t, `{"COUNT(*)": 1}`, string(enc))
+	})
+
+	t.Run("multiple fields", func(t *testing.T) {
+		_, err := db.QueryDocument("SELECT a FROM foo WHERE a = ALL (SELECT a, b FROM foo)")
+		require.EqualError(t, err, "subquery must return only one field")
+	})
+}
+
+func TestSelectJoin(t *testing.T) {
+	tests := []struct {
+		name     string
+		query    string
+		expected string
+	}{
+		{"Inner", "SELECT u.name, o.total FROM users AS u JOIN orders AS o ON u.id = o.user_id ORDER BY o.id",
+			`[{"u.name": "a", "o.total": 10}, {"u.name": "a", "o.total": 20}, {"u.name": "b", "o.total": 30}]`},
+		{"Inner keyword", "SELECT orders.id FROM orders INNER JOIN users ON users.id = orders.user_id WHERE users.name = 'b'",
+			`[{"orders.id": 3}]`},
+		{"Left", "SELECT u.name, o.total FROM users AS u LEFT JOIN orders AS o ON o.user_id = u.id ORDER BY u.name",
+			`[{"u.name": "a", "o.total": 10}, {"u.name": "a", "o.total": 20}, {"u.name": "b", "o.total": 30}, {"u.name": "c", "o.total": null}]`},
+		{"Left outer", "SELECT u.name FROM users AS u LEFT OUTER JOIN orders AS o ON o.user_id = u.id WHERE o IS NULL",
+			`[{"u.name": "c"}]`},
+		{"Wildcard", "SELECT * FROM users JOIN orders ON users.id = orders.user_id AND orders.total > 25",
+			`[{"users": {"id": 2, "name": "b"}, "orders": {"id": 3, "user_id": 2, "total": 30.0}}]`},
+		{"Self join", "SELECT a.id, b.id FROM users AS a JOIN users AS b ON a.id < b.id ORDER BY a.id",
+			`[{"a.id": 1, "b.id": 2}, {"a.id": 1, "b.id": 3}, {"a.id": 2, "b.id": 3}]`},
+		{"Three tables", "SELECT u.name, i.label FROM users AS u JOIN orders AS o ON o.user_id = u.id JOIN items AS i ON i.order_id = o.id",
+			`[{"u.name": "a", "i.label": "x"}, {"u.name": "b", "i.label": "y"}]`},
+		{"Aggregate", "SELECT COUNT(*) FROM users AS u JOIN orders AS o ON o.user_id = u.id GROUP BY u.name",
+			`[{"COUNT(*)": 2}, {"COUNT(*)": 1}]`},
+		{"Limit", "SELECT o.id FROM users AS u JOIN orders AS o ON o.user_id = u.id LIMIT 1",
+			`[{"o.id": 1}]`},
+	}
+
+	for _, withIndex := range []bool{false, true} {
+		db, err := genji.Open(":memory:")
+		require.NoError(t, err)
+		defer db.Close()
+
+		err = db.Exec(`
+			CREATE TABLE users(id INTEGER PRIMARY KEY, name TEXT);
+			CREATE TABLE orders(id INTEGER PRIMARY KEY, user_id INTEGER, total DOUBLE);
+			CREATE TABLE items(order_id INTEGER, label TEXT);
+			INSERT INTO users (id, name) VALUES (1, 'a'), (2, 'b'), (3, 'c');
+			INSERT INTO orders (id, user_id, total) VALUES (1, 1, 10), (2, 1, 20), (3, 2, 30);
+			INSERT INTO items (order_id, label) VALUES (1, 'x'), (3, 'y'), (4, 'z');
+		`)
+		require.NoError(t, err)
+
+		name := "No Index/"
+		if withIndex {
+			name = "With Index/"
+			err = db.Exec(`CREATE INDEX ON orders(user_id); CREATE INDEX ON items(order_id)`)
+			require.NoError(t, err)
+		}
+
+		for _, test := range tests {
+			t.Run(name+test.name, func(t *testing.T) {
+				st, err := db.Query(test.query)
+				require.NoError(t, err)
+				defer st.Close()
+
+				var buf bytes.Buffer
+				err = testutil.IteratorToJSONArray(&buf, st)
+				require.NoError(t, err)
+				require.JSONEq(t, test.expected, buf.String())
+			})
+		}
+	}
+
+	t.Run("errors", func(t *testing.T) {
+		db, err := genji.Open(":memory:")
+		require.NoError(t, err)
+		defer db.Close()
+
+		err = db.Exec("CREATE TABLE foo; CREATE TABLE bar")
+		require.NoError(t, err)
+
+		err = db.Exec("SELECT * FROM foo JOIN foo ON foo.a = foo.b")
+		require.EqualError(t, err, `table name "foo" specified more than once, use an alias`)
+
+		err = db.Exec("SELECT * FROM (SELECT * FROM foo) JOIN bar ON bar.a = 1")
+		require.EqualError(t, err, `subqueries in FROM must have an alias to be joined`)
+
+		err = db.Exec("SELECT * FROM foo JOIN baz ON foo.a = baz.a")
+		require.Error(t, err)
+	})
+}
+
+func TestSelectSubquery(t *testing.T) {
+	db, err := genji.Open(":memory:")
+	require.NoError(t, err)
+	defer db.Close()
+
+	err = db.Exec(`
+		CREATE TABLE users(id INTEGER PRIMARY KEY, name TEXT);
+		CREATE TABLE orders(id INTEGER PRIMARY KEY, user_id INTEGER, total DOUBLE);
+		INSERT INTO users (id, name) VALUES (1, 'a'), (2, 'b'), (3, 'c');
+		INSERT INTO orders (id, user_id, total) VALUES (1, 1, 10), (2, 1, 20), (3, 2, 30);
+	`)
+	require.NoError(t, err)
+
+	tests := []struct {
+		name     string
+		query    string
+		expected string
+	}{
+		{"IN", "SELECT name FROM users WHERE id IN (SELECT user_id FROM orders)",
+			`[{"name": "a"}, {"name": "b"}]`},
+		{"NOT IN", "SELECT name FROM users WHERE id NOT IN (SELECT user_id FROM orders WHERE total > 15)",
+			`[{"name": "c"}]`},
+		{"Scalar", "SELECT id, (SELECT MAX(total) FROM orders) AS max FROM users WHERE id = 1",
+			`[{"id": 1, "max": 30.0}]`},
+		{"Scalar without result", "SELECT (SELECT total FROM orders WHERE id = 10) AS total FROM users WHERE id = 1",
+			`[{"total": null}]`},
+		{"Scalar in arithmetic", "SELECT (SELECT COUNT(*) FROM users) + 1 AS n FROM users WHERE id = 1",
+			`[{"n": 4}]`},
+		{"Correlated", "SELECT u.name, (SELECT COUNT(*) FROM orders AS o WHERE o.user_id = u.id) AS n FROM users AS u",
+			`[{"u.name": "a", "n": 2}, {"u.name": "b", "n": 1}, {"u.name": "c", "n": 0}]`},
+		{"Correlated in WHERE", "SELECT u.name FROM users AS u WHERE (SELECT SUM(o.total) FROM orders AS o WHERE o.user_id = u.id) > 25",
+			`[{"u.name": "a"}, {"u.name": "b"}]`},
+		{"Derived table", "SELECT total FROM (SELECT * FROM orders WHERE total > 15)",
+			`[{"total": 20.0}, {"total": 30.0}]`},
+		{"Derived table with limit", "SELECT id FROM (SELECT id FROM orders ORDER BY id DESC LIMIT 2) LIMIT 1",
+			`[{"id": 3}]`},
+		{"Derived table with alias", "SELECT o.id FROM (SELECT id, user_id FROM orders) AS o WHERE o.user_id = 1",
+			`[{"o.id": 1}, {"o.id": 2}]`},
+		{"Join with derived table", "SELECT u.name, t.total FROM users AS u JOIN (SELECT user_id, total FROM orders WHERE total >= 20) AS t ON t.user_id = u.id",
+			`[{"u.name": "a", "t.total": 20.0}, {"u.name": "b", "t.total": 30.0}]`},
+		{"Alias without join", "SELECT u.name FROM users AS u WHERE u.id = 2",
+			`[{"u.name": "b"}]`},
+		{"Correlated by table name", "SELECT name, (SELECT COUNT(*) FROM orders WHERE user_id = users.id) AS n FROM users",
+			`[{"name": "a", "n": 2}, {"name": "b", "n": 1}, {"name": "c", "n": 0}]`},
+		{"Correlated by table name in WHERE", "SELECT name FROM users WHERE (SELECT SUM(o.total) FROM orders AS o WHERE o.user_id = users.id) > 25",
+			`[{"name": "a"}, {"name": "b"}]`},
+		{"Scalar without alias", "SELECT id, (SELECT MAX(total) FROM orders) FROM users WHERE id = 1",
+			`[{"id": 1, "(SELECT MAX(total) FROM orders)": 30.0}]`},
+		{"Scalar in arithmetic without alias", "SELECT ( SELECT COUNT(*) FROM users ) + 1 FROM users WHERE id = 1",
+			`[{"( SELECT COUNT(*) FROM users ) + 1": 4}]`},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			st, err := db.Query(test.query)
+			require.NoError(t, err)
+			defer st.Close()
+
+			var buf bytes.Buffer
+			err = testutil.IteratorToJSONArray(&buf, st)
+			require.NoError(t, err)
+			require.JSONEq(t, test.expected, buf.String())
+		})
+	}
+
+	t.Run("errors", func(t *testing.T) {
+		_, err := db.QueryDocument("SELECT (SELECT id FROM orders) AS id FROM users")
+		require.EqualError(t, err, "subquery used as an expression must return at most one document")
+
+		_, err = db.QueryDocument("SELECT (SELECT id, user_id FROM orders WHERE id = 1) AS id FROM users")
+		require.EqualError(t, err, "subquery must return only one field")
+
+		err = db.Exec("INSERT INTO orders SELECT * FROM (SELECT * FROM orders)")
+		require.EqualError(t, err, "cannot read and write to the same table")
+	})
+
+	t.Run("Correlated by table name in DELETE", func(t *testing.T) {
+		tx, err := db.Begin(true)
+		require.NoError(t, err)
+		defer tx.Rollback()
+
+		err = tx.Exec("DELETE FROM users WHERE (SELECT COUNT(*) FROM orders WHERE user_id = users.id) = 0")
+		require.NoError(t, err)
+
+		d, err := tx.QueryDocument("SELECT COUNT(*) AS n FROM users")
+		require.NoError(t, err)
+		testutil.RequireDocJSONEq(t, d, `{"n": 2}`)
+	})
+
+	t.Run("Table created by the transaction", func(t *testing.T) {
+		tx, err := db.Begin(true)
+		require.NoError(t, err)
+		defer tx.Rollback()
+
+		err = tx.Exec("CREATE TABLE discounts(user_id INTEGER, rate DOUBLE); INSERT INTO discounts (user_id, rate) VALUES (1, 0.5)")
+		require.NoError(t, err)
+
+		d, err := tx.QueryDocument("SELECT name, (SELECT rate FROM discounts WHERE user_id = 1) AS rate FROM users WHERE id = 1")
+		require.NoError(t, err)
+		testutil.RequireDocJSONEq(t, d, `{"name": "a", "rate": 0.5}`)
+	})
+}
+
+func TestSelectTime(t *testing.T) {
+	db, err := genji.Open(":memory:")
+	require.NoError(t, err)
+	defer db.Close()
+
+	err = db.Exec(`
+		CREATE TABLE events(id INTEGER PRIMARY KEY, at TIMESTAMP, day DATE);
+		CREATE INDEX on_at ON events(at);
+		INSERT INTO events (id, at, day) VALUES
+			(1, '2021-06-01T12:30:00+02:00', '2021-06-01'),
+			(2, TIMESTAMP '1960-01-01T00:00:00Z', '1960-01-01T23:00:00Z'),
+			(3, '2021-06-01 10:30:00.5', DATE '2021-06-02');
+	`)
+	require.NoError(t, err)
+
+	tests := []struct {
+		name     string
+		query    string
+		expected string
+	}{
+		{"Converted by the constraints", "SELECT * FROM events WHERE id = 2",
+			`[{"id": 2, "at": "1960-01-01T00:00:00Z", "day": "1960-01-01"}]`},
+		{"Order", "SELECT id FROM events ORDER BY at DESC",
+			`[{"id": 3}, {"id": 1}, {"id": 2}]`},
+		{"Comparison with literal", "SELECT id FROM events WHERE at > TIMESTAMP '2021-06-01T10:30:00Z'",
+			`[{"id": 3}]`},
+		{"Comparison between dates and timestamps", "SELECT id FROM events WHERE day >= at",
+			`[{"id": 2}, {"id": 3}]`},
+		{"Comparison with text", "SELECT id FROM events WHERE at = '2021-06-01T10:30:00Z'",
+			`[{"id": 1}]`},
+		{"Comparison with text without index", "SELECT id FROM events WHERE day < '2021-06-01T10:30:00Z'",
+			`[{"id": 1}, {"id": 2}]`},
+		{"Cast", "SELECT CAST(at AS DATE) AS d, CAST(day AS TEXT) AS t FROM events WHERE id = 1",
+			`[{"d": "2021-06-01", "t": "2021-06-01"}]`},
+		{"Interval arithmetic", "SELECT at + INTERVAL '1 month' AS next_month, day - DATE '2021-05-01' AS since FROM events WHERE id = 1",
+			`[{"next_month": "2021-07-01T10:30:00Z", "since": "31 days"}]`},
+		{"Extract", "SELECT EXTRACT(YEAR FROM at) AS y, date_part('doy', day) AS doy FROM events WHERE id = 2",
+			`[{"y": 1960, "doy": 1}]`},
+		{"Now is stable", "SELECT NOW() = NOW() AS ok, NOW() > at AS after FROM events WHERE id = 1",
+			`[{"ok": true, "after": true}]`},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			st, err := db.Query(test.query)
+			require.NoError(t, err)
+			defer st.Close()
+
+			var buf bytes.Buffer
+			err = testutil.IteratorToJSONArray(&buf, st)
+			require.NoError(t, err)
+			require.JSONEq(t, test.expected, buf.String())
+		})
+	}
+
+	t.Run("Scan", func(t *testing.T) {
+		d, err := db.QueryDocument("SELECT at FROM events WHERE id = 1")
+		require.NoError(t, err)
+
+		var at time.Time
+		err = document.Scan(d, &at)
+		require.NoError(t, err)
+		require.Equal(t, time.Date(2021, 6, 1, 10, 30, 0, 0, time.UTC), at)
+	})
+
+	// time.Time values used to be stored as texts, in UTC
+	t.Run("Times stored as texts", func(t *testing.T) {
+		err := db.Exec(`
+			CREATE TABLE old(id INTEGER PRIMARY KEY, typed TEXT, unindexed TEXT);
+			CREATE INDEX old_typed ON old(typed);
+			CREATE INDEX old_untyped ON old(untyped);
+			INSERT INTO old (id, typed, untyped, unindexed) VALUES
+				(1, '2021-06-01T10:30:00Z', '2021-06-01T10:30:00Z', '2021-06-01T10:30:00Z'),
+				(2, '2021-06-02T10:30:00.5Z', '2021-06-02T10:30:00.5Z', '2021-06-02T10:30:00.5Z');
+			INSERT INTO old (id, typed, untyped, unindexed) VALUES (3, '1999-01-01T00:00:00Z', TIMESTAMP '2021-06-01T10:30:00Z', 'foo');
+		`)
+		require.NoError(t, err)
+
+		at := time.Date(2021, 6, 1, 12, 30, 0, 0, time.FixedZone("CEST", 2*3600))
+
+		tests := []struct {
+			name     string
+			query    string
+			expected string
+		}{
+			{"Text index", "SELECT id FROM old WHERE typed = ?", `[{"id": 1}]`},
+			{"Text index range", "SELECT id FROM old WHERE typed > ?", `[{"id": 2}]`},
+			{"Untyped index", "SELECT id FROM old WHERE untyped = ? ORDER BY id", `[{"id": 1}, {"id": 3}]`},
+			{"Untyped index with IN", "SELECT id FROM old WHERE untyped IN (?, '2021-06-02T10:30:00.5Z') ORDER BY id", `[{"id": 1}, {"id": 2}, {"id": 3}]`},
+			{"No index", "SELECT id FROM old WHERE unindexed = ?", `[{"id": 1}]`},
+			{"No index range", "SELECT id FROM old WHERE unindexed >= ?", `[{"id": 1}, {"id": 2}]`},
+		}
+
+		for _, test := range tests {
+			t.Run(test.name, func(t *testing.T) {
+				st, err := db.Query(test.query, at)
+				require.NoError(t, err)
+				defer st.Close()
+
+				var buf bytes.Buffer
+				err = testutil.IteratorToJSONArray(&buf, st)
+				require.NoError(t, err)
+				require.JSONEq(t, test.expected, buf.String())
+			})
+		}
+
+		d, err := db.QueryDocument("SELECT id FROM old WHERE typed = TIMESTAMP '2021-06-01T10:30:00Z'")
+		require.NoError(t, err)
+		testutil.RequireDocJSONEq(t, d, `{"id": 1}`)
+	})
+
+	t.Run("Type names as field names", func(t *testing.T) {
+		err := db.Exec(`
+			CREATE TABLE t(date TEXT, timestamp INTEGER, interval INTERVAL, decimal DECIMAL(4, 2), numeric NUMERIC, vector VECTOR(2));
+			INSERT INTO t (date, timestamp, interval, decimal, numeric, vector) VALUES ('today', 1, INTERVAL '1 day', DECIMAL '1.5', NUMERIC '2', [1, 2]);
+		`)
+		require.NoError(t, err)
+
+		d, err := db.QueryDocument("SELECT date, timestamp, interval, decimal, numeric, vector FROM t WHERE date = 'today'")
+		require.NoError(t, err)
+		testutil.RequireDocJSONEq(t, d, `{"date": "today", "timestamp": 1, "interval": "1 day", "decimal": 1.50, "numeric": 2, "vector": [1, 2]}`)
+	})
+}
+
+func TestSelectDecimal(t *testing.T) {
+	db, err := genji.Open(":memory:")
+	require.NoError(t, err)
+	defer db.Close()
+
+	err = db.Exec(`
+		CREATE TABLE items(id INTEGER PRIMARY KEY, price DECIMAL(10, 2), rate NUMERIC);
+		CREATE INDEX on_price ON items(price);
+		INSERT INTO items (id, price, rate) VALUES
+			(1, 0.1, '0.3333333333333333333333'),
+			(2, '0.2', 2),
+			(3, 19.995, DECIMAL '1.5'),
+			(4, 10, NULL);
+	`)
+	require.NoError(t, err)
+
+	tests := []struct {
+		name     string
+		query    string
+		expected string
+	}{
+		{"Rounded to the scale", "SELECT CAST(price AS TEXT) AS p FROM items ORDER BY id",
+			`[{"p": "0.10"}, {"p": "0.20"}, {"p": "20.00"}, {"p": "10.00"}]`},
+		{"Exact sum", "SELECT SUM(price) AS s FROM items WHERE id < 3",
+			`[{"s": 0.3}]`},
+		{"Exact arithmetic", "SELECT CAST(price + rate AS TEXT) AS a, CAST(price * 3 AS TEXT) AS m, CAST(price / 3 AS TEXT) AS d FROM items WHERE id = 1",
+			`[{"a": "0.4333333333333333333333", "m": "0.30", "d": "0.0333333333333333"}]`},
+		{"Comparison with double", "SELECT id FROM items WHERE price + 0.2 = 0.30000000000000004",
+			`[{"id": 1}]`},
+		{"Comparison with decimal", "SELECT id FROM items WHERE price + DECIMAL '0.2' = 0.3",
+			`[{"id": 1}]`},
+		{"Order", "SELECT id FROM items ORDER BY price DESC",
+			`[{"id": 3}, {"id": 4}, {"id": 2}, {"id": 1}]`},
+		{"Index lookup with integer", "SELECT id FROM items WHERE price = 10",
+			`[{"id": 4}]`},
+		{"Index range with double", "SELECT id FROM items WHERE price > 0.15 AND price < 15.5",
+			`[{"id": 2}, {"id": 4}]`},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			st, err := db.Query(test.query)
+			require.NoError(t, err)
+			defer st.Close()
+
+			var buf bytes.Buffer
+			err = testutil.IteratorToJSONArray(&buf, st)
+			require.NoError(t, err)
+			require.JSONEq(t, test.expected, buf.String())
+		})
+	}
+
+	t.Run("Precision", func(t *testing.T) {
+		err := db.Exec("INSERT INTO items (id, price) VALUES (5, 123456789)")
+		require.EqualError(t, err, `field "price" must have at most 8 digits before the decimal point`)
+	})
+
+	t.Run("Scan", func(t *testing.T) {
+		d, err := db.QueryDocument("SELECT price FROM items WHERE id = 3")
+		require.NoError(t, err)
+
+		var price document.Decimal
+		err = document.Scan(d, &price)
+		require.NoError(t, err)
+		require.Equal(t, "20.00", price.String())
+	})
+}
+
+func TestSelectUUID(t *testing.T) {
+	db, err := genji.Open(":memory:")
+	require.NoError(t, err)
+	defer db.Close()
+
+	err = db.Exec(`
+		CREATE TABLE events(id UUID PRIMARY KEY DEFAULT uuid_v7(), n INTEGER, ref UUID);
+		CREATE INDEX on_ref ON events(ref);
+		INSERT INTO events (n, ref) VALUES (1, uuid()), (2, uuid()), (3, uuid());
+		INSERT INTO events (n, ref) VALUES (4, '018b2c3d-4e5f-7081-92a3-b4c5d6e7f809');
+	`)
+	require.NoError(t, err)
+
+	tests := []struct {
+		name     string
+		query    string
+		expected string
+	}{
+		{"Ordered by creation", "SELECT n FROM events ORDER BY id",
+			`[{"n": 1}, {"n": 2}, {"n": 3}, {"n": 4}]`},
+		{"Text representation", "SELECT ref, CAST(ref AS TEXT) AS t FROM events WHERE n = 4",
+			`[{"ref": "018b2c3d-4e5f-7081-92a3-b4c5d6e7f809", "t": "018b2c3d-4e5f-7081-92a3-b4c5d6e7f809"}]`},
+		{"Index lookup", "SELECT n FROM events WHERE ref = UUID '018B2C3D-4E5F-7081-92A3-B4C5D6E7F809'",
+			`[{"n": 4}]`},
+		{"Cast from text", "SELECT n FROM events WHERE ref = CAST('018b2c3d4e5f708192a3b4c5d6e7f809' AS UUID)",
+			`[{"n": 4}]`},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			st, err := db.Query(test.query)
+			require.NoError(t, err)
+			defer st.Close()
+
+			var buf bytes.Buffer
+			err = testutil.IteratorToJSONArray(&buf, st)
+			require.NoError(t, err)
+			require.JSONEq(t, test.expected, buf.String())
+		})
+	}
+
+	t.Run("Invalid uuid", func(t *testing.T) {
+		err := db.Exec(`INSERT INTO events (n, ref) VALUES (5, 'foo')`)
+		require.Error(t, err)
+	})
+}
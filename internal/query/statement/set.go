@@ -0,0 +1,86 @@
+package statement
+
+import (
+	"errors"
+
+	"github.com/genjidb/genji/internal/database"
+	"github.com/genjidb/genji/internal/environment"
+	"github.com/genjidb/genji/internal/expr"
+	"github.com/genjidb/genji/internal/stream"
+)
+
+// SetStmt is a statement that modifies a setting of the session.
+type SetStmt struct {
+	Name string
+	// Value of the setting. If nil, the setting is reset to its default value.
+	Value expr.Expr
+}
+
+// IsReadOnly always returns true. Settings only affect the session
+// and don't modify the database.
+func (stmt *SetStmt) IsReadOnly() bool {
+	return true
+}
+
+// Run evaluates the value and stores it in the session.
+func (stmt *SetStmt) Run(ctx *Context) (Result, error) {
+	if ctx.Session == nil {
+		return Result{}, errors.New("cannot modify settings without a session")
+	}
+
+	if stmt.Value == nil {
+		return Result{}, ctx.Session.Reset(stmt.Name)
+	}
+
+	var env environment.Environment
+	env.Tx = ctx.Tx
+	env.Catalog = ctx.Catalog
+	env.Session = ctx.Session
+	env.SetParams(ctx.Params)
+
+	v, err := stmt.Value.Eval(&env)
+	if err != nil {
+		return Result{}, err
+	}
+
+	return Result{}, ctx.Session.Set(stmt.Name, v)
+}
+
+// ShowStmt is a statement that returns the value of a setting of the session
+// in a single document.
+type ShowStmt struct {
+	Name string
+}
+
+// IsReadOnly always returns true.
+func (stmt *ShowStmt) IsReadOnly() bool {
+	return true
+}
+
+// Run returns a document containing the name and the value of the setting.
+func (stmt *ShowStmt) Run(ctx *Context) (Result, error) {
+	st, err := database.GetSetting(stmt.Name)
+	if err != nil {
+		return Result{}, err
+	}
+
+	v := st.Default
+	if ctx.Session != nil {
+		v, err = ctx.Session.Get(st.Name)
+		if err != nil {
+			return Result{}, err
+		}
+	}
+
+	newStatement := StreamStmt{
+		PreparedStream: &stream.Stream{
+			Op: stream.Project(
+				&expr.NamedExpr{
+					ExprName: st.Name,
+					Expr:     expr.LiteralValue(v),
+				}),
+		},
+		ReadOnly: true,
+	}
+	return newStatement.Run(ctx)
+}
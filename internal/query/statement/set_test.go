@@ -0,0 +1,346 @@
+package statement_test
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/genjidb/genji"
+	"github.com/genjidb/genji/document"
+	errs "github.com/genjidb/genji/errors"
+	"github.com/genjidb/genji/internal/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSetAndShow(t *testing.T) {
+	tests := []struct {
+		name     string
+		query    string
+		expected string
+		fails    bool
+	}{
+		{"Default", `SHOW strict`, `{"strict": false}`, false},
+		{"Set", `SET strict = true; SHOW strict`, `{"strict": true}`, false},
+		{"Set TO", `SET time_zone TO 'Europe/Paris'; SHOW time_zone`, `{"time_zone": "Europe/Paris"}`, false},
+		{"Case insensitive", `SET Statement_Timeout = 10; SHOW STATEMENT_TIMEOUT`, `{"statement_timeout": 10}`, false},
+		{"Work mem", `SET work_mem = 1024; SHOW work_mem`, `{"work_mem": 1024}`, false},
+		{"Max parallel workers", `SET max_parallel_workers = 4; SHOW max_parallel_workers`, `{"max_parallel_workers": 4}`, false},
+		{"Negative parallel workers", `SET max_parallel_workers = -1`, ``, true},
+		{"Batch size", `SET batch_size = 0; SHOW batch_size`, `{"batch_size": 0}`, false},
+		{"Negative batch size", `SET batch_size = -1`, ``, true},
+		{"Reset", `SET strict = true; SET strict = DEFAULT; SHOW strict`, `{"strict": false}`, false},
+		{"Unknown setting", `SET foo = 1`, ``, true},
+		{"Show unknown setting", `SHOW foo`, ``, true},
+		{"Invalid type", `SET strict = 1`, ``, true},
+		{"Negative timeout", `SET statement_timeout = -1`, ``, true},
+		{"Duration", `SET statement_timeout = '1.5s'; SHOW statement_timeout`, `{"statement_timeout": 1500}`, false},
+		{"Rounded duration", `SET transaction_timeout = '1us'; SHOW transaction_timeout`, `{"transaction_timeout": 1}`, false},
+		{"Invalid duration", `SET transaction_timeout = '5 seconds'`, ``, true},
+		{"Negative duration", `SET statement_timeout = '-5s'`, ``, true},
+		{"Unknown time zone", `SET time_zone = 'Mars/Olympus'`, ``, true},
+		{"Local time zone", `SET time_zone = 'Local'`, ``, true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			db, err := genji.Open(":memory:")
+			require.NoError(t, err)
+			defer db.Close()
+
+			d, err := db.QueryDocument(test.query)
+			if test.fails {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			testutil.RequireDocJSONEq(t, d, test.expected)
+		})
+	}
+
+	t.Run("With params", func(t *testing.T) {
+		db, err := genji.Open(":memory:")
+		require.NoError(t, err)
+		defer db.Close()
+
+		err = db.Exec(`SET statement_timeout = ?`, 100)
+		require.NoError(t, err)
+
+		d, err := db.QueryDocument(`SHOW statement_timeout`)
+		require.NoError(t, err)
+		testutil.RequireDocJSONEq(t, d, `{"statement_timeout": 100}`)
+	})
+}
+
+func TestStrictSetting(t *testing.T) {
+	db, err := genji.Open(":memory:")
+	require.NoError(t, err)
+	defer db.Close()
+
+	err = db.Exec(`CREATE TABLE test(a INTEGER); CREATE TABLE untyped(a INTEGER) WITH type_conversion = untyped`)
+	require.NoError(t, err)
+
+	err = db.Exec(`INSERT INTO test (a) VALUES (1.0)`)
+	require.NoError(t, err)
+
+	err = db.Exec(`SET strict = true`)
+	require.NoError(t, err)
+
+	err = db.Exec(`INSERT INTO test (a) VALUES (2.0)`)
+	require.Error(t, err)
+
+	err = db.Exec(`UPDATE test SET a = 3.0`)
+	require.Error(t, err)
+
+	err = db.Exec(`INSERT INTO test (a) VALUES (2)`)
+	require.NoError(t, err)
+
+	// tables with an explicit conversion policy are not affected
+	err = db.Exec(`INSERT INTO untyped (a) VALUES ('foo')`)
+	require.NoError(t, err)
+}
+
+func TestStatementTimeoutSetting(t *testing.T) {
+	db, err := genji.Open(":memory:")
+	require.NoError(t, err)
+	defer db.Close()
+
+	err = db.Exec(`CREATE TABLE test; INSERT INTO test (a) VALUES (1), (2), (3)`)
+	require.NoError(t, err)
+
+	err = db.Exec(`SET statement_timeout = 10`)
+	require.NoError(t, err)
+
+	res, err := db.Query(`SELECT * FROM test`)
+	require.NoError(t, err)
+	defer res.Close()
+
+	var count int
+	err = res.Iterate(func(d document.Document) error {
+		count++
+		time.Sleep(20 * time.Millisecond)
+		return nil
+	})
+	require.Equal(t, errs.ErrStatementTimeout, err)
+	require.Equal(t, 1, count)
+}
+
+func TestTransactionTimeoutSetting(t *testing.T) {
+	db, err := genji.Open(":memory:")
+	require.NoError(t, err)
+	defer db.Close()
+
+	err = db.Exec(`CREATE TABLE test; INSERT INTO test (a) VALUES (1), (2), (3)`)
+	require.NoError(t, err)
+
+	t.Run("Scan", func(t *testing.T) {
+		tx, err := db.WithTransactionTimeout(10 * time.Millisecond).Begin(false)
+		require.NoError(t, err)
+		defer tx.Rollback()
+
+		res, err := tx.Query(`SELECT * FROM test WHERE a > 10`)
+		require.NoError(t, err)
+		defer res.Close()
+
+		time.Sleep(20 * time.Millisecond)
+
+		// the scan is aborted even though no document is returned
+		err = res.Iterate(func(d document.Document) error {
+			return nil
+		})
+		require.Equal(t, errs.ErrTransactionTimeout, err)
+	})
+
+	t.Run("Statements", func(t *testing.T) {
+		tdb := db.NewSession()
+		err := tdb.Exec(`SET transaction_timeout = '10ms'; BEGIN; INSERT INTO test (a) VALUES (4)`)
+		require.NoError(t, err)
+
+		time.Sleep(20 * time.Millisecond)
+
+		err = tdb.Exec(`INSERT INTO test (a) VALUES (5)`)
+		require.Equal(t, errs.ErrTransactionTimeout, err)
+
+		// the transaction is rolled back instead of being committed
+		err = tdb.Exec(`COMMIT`)
+		require.Equal(t, errs.ErrTransactionTimeout, err)
+
+		d, err := db.QueryDocument(`SELECT COUNT(*) AS n FROM test`)
+		require.NoError(t, err)
+		testutil.RequireDocJSONEq(t, d, `{"n": 3}`)
+	})
+
+	t.Run("Commit", func(t *testing.T) {
+		tx, err := db.WithTransactionTimeout(10 * time.Millisecond).Begin(true)
+		require.NoError(t, err)
+		defer tx.Rollback()
+
+		err = tx.Exec(`INSERT INTO test (a) VALUES (4)`)
+		require.NoError(t, err)
+
+		time.Sleep(20 * time.Millisecond)
+		require.Equal(t, errs.ErrTransactionTimeout, tx.Commit())
+
+		d, err := db.QueryDocument(`SELECT COUNT(*) AS n FROM test`)
+		require.NoError(t, err)
+		testutil.RequireDocJSONEq(t, d, `{"n": 3}`)
+	})
+
+	t.Run("Statement timeout first", func(t *testing.T) {
+		tx, err := db.WithTransactionTimeout(time.Hour).WithStatementTimeout(10 * time.Millisecond).Begin(false)
+		require.NoError(t, err)
+		defer tx.Rollback()
+
+		res, err := tx.Query(`SELECT * FROM test`)
+		require.NoError(t, err)
+		defer res.Close()
+
+		err = res.Iterate(func(d document.Document) error {
+			time.Sleep(20 * time.Millisecond)
+			return nil
+		})
+		require.Equal(t, errs.ErrStatementTimeout, err)
+	})
+}
+
+func TestWorkMemSetting(t *testing.T) {
+	db, err := genji.Open(":memory:")
+	require.NoError(t, err)
+	defer db.Close()
+
+	err = db.Exec(`
+		SET work_mem = 1;
+		CREATE TABLE test;
+		INSERT INTO test (a, b) VALUES (1, 1), (2, 2), (1, 3), (3, 4), (2, 5), (1, 6);
+	`)
+	require.NoError(t, err)
+
+	t.Run("Group by", func(t *testing.T) {
+		res, err := db.Query("SELECT a, COUNT(*) AS n, SUM(b) AS s FROM test GROUP BY a ORDER BY a")
+		require.NoError(t, err)
+		defer res.Close()
+
+		var buf bytes.Buffer
+		err = testutil.IteratorToJSONArray(&buf, res)
+		require.NoError(t, err)
+		require.JSONEq(t, `[{"a": 1, "n": 3, "s": 10}, {"a": 2, "n": 2, "s": 7}, {"a": 3, "n": 1, "s": 4}]`, buf.String())
+	})
+
+	t.Run("Distinct", func(t *testing.T) {
+		res, err := db.Query("SELECT DISTINCT a FROM test ORDER BY a")
+		require.NoError(t, err)
+		defer res.Close()
+
+		var buf bytes.Buffer
+		err = testutil.IteratorToJSONArray(&buf, res)
+		require.NoError(t, err)
+		require.JSONEq(t, `[{"a": 1}, {"a": 2}, {"a": 3}]`, buf.String())
+	})
+
+	t.Run("Order by", func(t *testing.T) {
+		res, err := db.Query("SELECT b FROM test ORDER BY a DESC, b LIMIT 4")
+		require.NoError(t, err)
+		defer res.Close()
+
+		var buf bytes.Buffer
+		err = testutil.IteratorToJSONArray(&buf, res)
+		require.NoError(t, err)
+		require.JSONEq(t, `[{"b": 4}, {"b": 2}, {"b": 5}, {"b": 1}]`, buf.String())
+	})
+
+	t.Run("Delete with order by", func(t *testing.T) {
+		err := db.Exec("DELETE FROM test ORDER BY b DESC LIMIT 2")
+		require.NoError(t, err)
+
+		res, err := db.Query("SELECT b FROM test")
+		require.NoError(t, err)
+		defer res.Close()
+
+		var buf bytes.Buffer
+		err = testutil.IteratorToJSONArray(&buf, res)
+		require.NoError(t, err)
+		require.JSONEq(t, `[{"b": 1}, {"b": 2}, {"b": 3}, {"b": 4}]`, buf.String())
+	})
+}
+
+func TestMaxParallelWorkersSetting(t *testing.T) {
+	db, err := genji.Open(":memory:")
+	require.NoError(t, err)
+	defer db.Close()
+
+	values := make([]string, 10000)
+	for i := range values {
+		values[i] = fmt.Sprintf("(%d, %d)", i, i%100)
+	}
+	err = db.Exec("CREATE TABLE test (k INTEGER PRIMARY KEY, a INTEGER); INSERT INTO test (k, a) VALUES " + strings.Join(values, ", ") + "; ANALYZE test")
+	require.NoError(t, err)
+
+	d, err := db.QueryDocument("EXPLAIN SELECT k, a * 2 AS b FROM test WHERE a = 42")
+	require.NoError(t, err)
+	testutil.RequireDocJSONEq(t, d, `{"plan": "parallelScan(seqScan(test, paths(a, k)) | filter(a = 42) | project(k, a * 2))"}`)
+
+	query := func() string {
+		res, err := db.Query("SELECT k, a * 2 AS b FROM test WHERE a = 42")
+		require.NoError(t, err)
+		defer res.Close()
+
+		// the projection is found in the parallel scan
+		require.Equal(t, []document.ValueType{document.IntegerValue, 0}, res.FieldTypes())
+
+		var buf bytes.Buffer
+		err = testutil.IteratorToJSONArray(&buf, res)
+		require.NoError(t, err)
+		return buf.String()
+	}
+
+	expected := query()
+	require.Contains(t, expected, `{"k": 42, "b": 84}`)
+
+	err = db.Exec("SET max_parallel_workers = 4")
+	require.NoError(t, err)
+	require.Equal(t, expected, query())
+}
+
+func TestTimeZoneSetting(t *testing.T) {
+	db, err := genji.Open(":memory:")
+	require.NoError(t, err)
+	defer db.Close()
+
+	err = db.Exec(`
+		CREATE TABLE test(a TIMESTAMP);
+		INSERT INTO test (a) VALUES ('2021-06-01T10:30:00Z');
+		SET time_zone = 'Europe/Paris';
+	`)
+	require.NoError(t, err)
+
+	// timestamps are returned in the time zone of the session
+	d, err := db.QueryDocument(`SELECT a, {b: [a]} AS c, time.at_time_zone(a) AS z, time.at_time_zone(a, 'UTC') AS u FROM test`)
+	require.NoError(t, err)
+	testutil.RequireDocJSONEq(t, d, `{"a": "2021-06-01T12:30:00+02:00", "c": {"b": ["2021-06-01T12:30:00+02:00"]}, "z": "2021-06-01T12:30:00+02:00", "u": "2021-06-01T10:30:00Z"}`)
+
+	v, err := d.GetByField("a")
+	require.NoError(t, err)
+	require.True(t, v.V.(time.Time).Equal(time.Date(2021, 6, 1, 10, 30, 0, 0, time.UTC)))
+
+	d, err = db.QueryDocument(`SELECT NOW() AS n FROM test`)
+	require.NoError(t, err)
+	v, err = d.GetByField("n")
+	require.NoError(t, err)
+	_, offset := v.V.(time.Time).Zone()
+	paris, err := time.LoadLocation("Europe/Paris")
+	require.NoError(t, err)
+	_, expected := time.Now().In(paris).Zone()
+	require.Equal(t, expected, offset)
+
+	// timestamps are stored in UTC and compared regardless of the time zone
+	d, err = db.QueryDocument(`SELECT COUNT(*) AS n FROM test WHERE a = TIMESTAMP '2021-06-01T12:30:00+02:00'`)
+	require.NoError(t, err)
+	testutil.RequireDocJSONEq(t, d, `{"n": 1}`)
+
+	err = db.Exec(`SET time_zone = DEFAULT`)
+	require.NoError(t, err)
+	d, err = db.QueryDocument(`SELECT a FROM test`)
+	require.NoError(t, err)
+	testutil.RequireDocJSONEq(t, d, `{"a": "2021-06-01T10:30:00Z"}`)
+}
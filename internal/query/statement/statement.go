@@ -1,11 +1,13 @@
 package statement
 
 import (
+	"context"
 	"errors"
 
 	"github.com/genjidb/genji/document"
 	"github.com/genjidb/genji/internal/database"
 	"github.com/genjidb/genji/internal/environment"
+	"github.com/genjidb/genji/internal/stream"
 )
 
 // A Statement represents a unique action that can be executed against the database.
@@ -15,11 +17,20 @@ type Statement interface {
 }
 
 type Context struct {
-	Tx      *database.Transaction
-	Catalog database.Catalog
-	Params  []environment.Param
+	// Ctx is the context of the query. Once it is canceled, the statements
+	// stop reading documents. If nil, the context of the transaction is used.
+	Ctx      context.Context
+	Tx       *database.Transaction
+	Catalog  database.Catalog
+	Session  *database.Session
+	Params   []environment.Param
+	PlanHook PlanHook
 }
 
+// A PlanHook is called with the stream of each statement before it is optimized.
+// It can modify the stream in place.
+type PlanHook func(s *stream.Stream, session *database.Session) error
+
 type Preparer interface {
 	Prepare(tx *Context) error
 }
@@ -41,6 +52,28 @@ func (r *Result) Iterate(fn func(d document.Document) error) error {
 	return r.err
 }
 
+// IterateContext iterates over the result until ctx is canceled, in which case
+// it returns the error of ctx.
+func (r *Result) IterateContext(ctx context.Context, fn func(d document.Document) error) error {
+	if r.Iterator == nil {
+		return nil
+	}
+
+	if it, ok := r.Iterator.(*StreamStmtIterator); ok {
+		r.err = it.IterateContext(ctx, fn)
+		return r.err
+	}
+
+	r.err = r.Iterator.Iterate(func(d document.Document) error {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		return fn(d)
+	})
+	return r.err
+}
+
 // Close the result stream.
 // After closing the result, Stream is not supposed to be used.
 // If the result stream was already closed, it returns an error.
@@ -1,8 +1,14 @@
 package statement
 
 import (
+	"context"
+	"errors"
+	"time"
+
 	"github.com/genjidb/genji/document"
+	errs "github.com/genjidb/genji/errors"
 	"github.com/genjidb/genji/internal/environment"
+	"github.com/genjidb/genji/internal/expr"
 	"github.com/genjidb/genji/internal/planner"
 	"github.com/genjidb/genji/internal/stream"
 )
@@ -17,8 +23,15 @@ type StreamStmt struct {
 
 // Prepare optimizes the stream and stores it in s.
 func (s *StreamStmt) Prepare(ctx *Context) error {
+	if ctx.PlanHook != nil {
+		err := ctx.PlanHook(s.Stream, ctx.Session)
+		if err != nil {
+			return err
+		}
+	}
+
 	var err error
-	s.PreparedStream, err = planner.Optimize(s.Stream, ctx.Catalog)
+	s.PreparedStream, err = planner.Optimize(s.Stream, ctx.Catalog, ctx.Tx)
 	return err
 }
 
@@ -49,6 +62,70 @@ func (s *StreamStmt) String() string {
 	return s.Stream.String()
 }
 
+// Relations returns the names of the tables and views read or written by the stream,
+// including those of subqueries, in order of appearance.
+func (s *StreamStmt) Relations() []string {
+	var names []string
+	seen := make(map[string]bool)
+	streamRelations(s.Stream, func(name string) {
+		if !seen[name] {
+			seen[name] = true
+			names = append(names, name)
+		}
+	})
+
+	return names
+}
+
+func streamRelations(s *stream.Stream, fn func(name string)) {
+	if s == nil {
+		return
+	}
+
+	var exprs []expr.Expr
+	for op := s.First(); op != nil; op = op.GetNext() {
+		switch t := op.(type) {
+		case *stream.SeqScanOperator:
+			fn(t.TableName)
+		case *stream.TableInsertOperator:
+			fn(t.Name)
+		case *stream.TableUpsertOperator:
+			fn(t.Name)
+		case *stream.ConcatOperator:
+			streamRelations(t.S1, fn)
+			streamRelations(t.S2, fn)
+		case *stream.IntersectOperator:
+			streamRelations(t.S1, fn)
+			streamRelations(t.S2, fn)
+		case *stream.ExceptOperator:
+			streamRelations(t.S1, fn)
+			streamRelations(t.S2, fn)
+		case *stream.JoinOperator:
+			streamRelations(t.Right, fn)
+			exprs = append(exprs, t.On)
+		case *stream.SubqueryOperator:
+			streamRelations(t.S, fn)
+		case *stream.FilterOperator:
+			exprs = append(exprs, t.E)
+		case *stream.ProjectOperator:
+			exprs = append(exprs, t.Exprs...)
+		}
+	}
+
+	// subqueries used as expressions
+	for _, e := range exprs {
+		expr.Walk(e, func(e expr.Expr) bool {
+			switch t := e.(type) {
+			case *Subquery:
+				streamRelations(t.Stmt.Stream, fn)
+			case *ScalarSubquery:
+				streamRelations(t.Stmt.Stream, fn)
+			}
+			return true
+		})
+	}
+}
+
 // StreamStmtIterator iterates over a stream.
 type StreamStmtIterator struct {
 	Stream  *stream.Stream
@@ -56,12 +133,67 @@ type StreamStmtIterator struct {
 }
 
 func (s *StreamStmtIterator) Iterate(fn func(d document.Document) error) error {
+	return s.iterate(nil, fn)
+}
+
+// IterateContext iterates over the stream, until ctx is canceled.
+// The operators reading documents stop as soon as ctx is canceled.
+func (s *StreamStmtIterator) IterateContext(ctx context.Context, fn func(d document.Document) error) error {
+	return s.iterate(ctx, fn)
+}
+
+func (s *StreamStmtIterator) iterate(ctx context.Context, fn func(d document.Document) error) error {
+	if ctx == nil {
+		ctx = s.Context.Ctx
+	}
+
 	var env environment.Environment
+	env.Ctx = ctx
 	env.Tx = s.Context.Tx
 	env.Catalog = s.Context.Catalog
+	env.Session = s.Context.Session
 	env.SetParams(s.Context.Params)
 
-	err := s.Stream.Iterate(&env, func(env *environment.Environment) error {
+	err := checkStreamPrivileges(s.Context.Session, s.Context.Tx, s.Context.Catalog, s.Stream)
+	if err != nil {
+		return err
+	}
+
+	// the statement stops at the deadline of the statement or of the transaction,
+	// whichever comes first, with the corresponding error
+	var deadline time.Time
+	var timeoutErr error
+	if s.Context.Tx != nil && !s.Context.Tx.Deadline.IsZero() {
+		deadline, timeoutErr = s.Context.Tx.Deadline, errs.ErrTransactionTimeout
+	}
+	// timestamps are stored in UTC and returned in the time zone of the session
+	var loc *time.Location
+	if s.Context.Session != nil {
+		if timeout := s.Context.Session.StatementTimeout(); timeout > 0 {
+			if d := time.Now().Add(timeout); deadline.IsZero() || d.Before(deadline) {
+				deadline, timeoutErr = d, errs.ErrStatementTimeout
+			}
+		}
+		if tz := s.Context.Session.TimeZone(); tz != time.UTC {
+			loc = tz
+		}
+	}
+
+	// the operators reading documents stop once the context of the environment is done,
+	// even if they don't output any document
+	var parent context.Context
+	if !deadline.IsZero() {
+		parent = env.GetContext()
+		var cancel context.CancelFunc
+		env.Ctx, cancel = context.WithDeadline(parent, deadline)
+		defer cancel()
+	}
+
+	err = s.Stream.Iterate(&env, func(env *environment.Environment) error {
+		if !deadline.IsZero() && time.Now().After(deadline) {
+			return timeoutErr
+		}
+
 		// if there is no doc in this specific environment,
 		// the last operator is not outputting anything
 		// worth returning to the user.
@@ -69,10 +201,65 @@ func (s *StreamStmtIterator) Iterate(fn func(d document.Document) error) error {
 			return nil
 		}
 
+		if loc != nil {
+			return fn(&zonedDocument{d: env.Doc, loc: loc})
+		}
+
 		return fn(env.Doc)
 	})
 	if err == stream.ErrStreamClosed {
 		err = nil
 	}
+	if errors.Is(err, context.DeadlineExceeded) && parent != nil && parent.Err() == nil {
+		err = timeoutErr
+	}
 	return err
 }
+
+// zonedDocument returns the timestamps of a document, and of its nested
+// documents and arrays, in a given time zone.
+type zonedDocument struct {
+	d   document.Document
+	loc *time.Location
+}
+
+func (z *zonedDocument) Iterate(fn func(field string, value document.Value) error) error {
+	return z.d.Iterate(func(field string, value document.Value) error {
+		return fn(field, inTimeZone(value, z.loc))
+	})
+}
+
+func (z *zonedDocument) GetByField(field string) (document.Value, error) {
+	v, err := z.d.GetByField(field)
+	return inTimeZone(v, z.loc), err
+}
+
+type zonedArray struct {
+	a   document.Array
+	loc *time.Location
+}
+
+func (z *zonedArray) Iterate(fn func(i int, value document.Value) error) error {
+	return z.a.Iterate(func(i int, value document.Value) error {
+		return fn(i, inTimeZone(value, z.loc))
+	})
+}
+
+func (z *zonedArray) GetByIndex(i int) (document.Value, error) {
+	v, err := z.a.GetByIndex(i)
+	return inTimeZone(v, z.loc), err
+}
+
+func inTimeZone(v document.Value, loc *time.Location) document.Value {
+	switch v.Type {
+	case document.TimestampValue:
+		// NewTimestampValue would convert the time back to UTC
+		v.V = v.V.(time.Time).In(loc)
+	case document.DocumentValue:
+		v.V = &zonedDocument{d: v.V.(document.Document), loc: loc}
+	case document.ArrayValue:
+		v.V = &zonedArray{a: v.V.(document.Array), loc: loc}
+	}
+
+	return v
+}
@@ -0,0 +1,175 @@
+package statement
+
+import (
+	"errors"
+
+	"github.com/genjidb/genji/document"
+	"github.com/genjidb/genji/internal/environment"
+	"github.com/genjidb/genji/internal/expr"
+	"github.com/genjidb/genji/internal/stream"
+	"github.com/genjidb/genji/internal/stringutil"
+)
+
+// A Subquery is a SELECT statement used as an expression.
+// It evaluates to an array containing the value of the only projected field
+// of every document returned by the statement.
+type Subquery struct {
+	Stmt *StreamStmt
+	// OuterTable is the name of the table read by the query containing the subquery,
+	// if its documents aren't stored in a field named after the table or its alias.
+	OuterTable string
+}
+
+// Eval runs the statement within the transaction of the environment
+// and returns the selected values as an array.
+func (s *Subquery) Eval(env *environment.Environment) (document.Value, error) {
+	vb := document.NewValueBuffer()
+	err := runSubquery(s.Stmt, s.OuterTable, env, func(v document.Value) error {
+		vb.Append(v)
+		return nil
+	})
+	if err != nil {
+		return expr.NullLiteral, err
+	}
+
+	return document.NewArrayValue(vb), nil
+}
+
+// runSubquery runs the statement within the transaction of the environment
+// and calls fn with the value of the only projected field of every document.
+// The documents of env can be referred to by the statement, using the alias of their table
+// or outerTable if set.
+func runSubquery(stmt *StreamStmt, outerTable string, env *environment.Environment, fn func(v document.Value) error) error {
+	if env.GetTx() == nil {
+		return errors.New("subqueries can only be evaluated within a transaction")
+	}
+
+	if stmt.PreparedStream == nil {
+		err := stmt.Prepare(&Context{Catalog: env.GetCatalog(), Tx: env.GetTx()})
+		if err != nil {
+			return err
+		}
+	}
+
+	err := checkStreamPrivileges(env.GetSession(), env.GetTx(), env.GetCatalog(), stmt.PreparedStream)
+	if err != nil {
+		return err
+	}
+
+	var newEnv environment.Environment
+	newEnv.SetOuterQuery(env, outerTable)
+
+	err = stmt.PreparedStream.Iterate(&newEnv, func(out *environment.Environment) error {
+		if out.Doc == nil {
+			return nil
+		}
+
+		var n int
+		err := out.Doc.Iterate(func(field string, v document.Value) error {
+			n++
+			if n > 1 {
+				return errors.New("subquery must return only one field")
+			}
+
+			return fn(v)
+		})
+		if err != nil {
+			return err
+		}
+		if n == 0 {
+			return fn(document.NewNullValue())
+		}
+
+		return nil
+	})
+	if err == stream.ErrStreamClosed {
+		err = nil
+	}
+
+	return err
+}
+
+// IsSubquery implements the expr.Subquery interface.
+func (s *Subquery) IsSubquery() {}
+
+// IsEqual compares this expression with the other expression and returns
+// true if they are equal.
+func (s *Subquery) IsEqual(other expr.Expr) bool {
+	o, ok := other.(*Subquery)
+	if !ok {
+		return false
+	}
+
+	return s.String() == o.String()
+}
+
+func (s *Subquery) String() string {
+	return stringutil.Sprintf("(%v)", s.Stmt)
+}
+
+// A ScalarSubquery is a SELECT statement used as an expression
+// that evaluates to the value of the only projected field of the
+// only document returned by the statement, or NULL if it returns no document.
+type ScalarSubquery struct {
+	Stmt *StreamStmt
+	// OuterTable is the name of the table read by the query containing the subquery,
+	// if its documents aren't stored in a field named after the table or its alias.
+	OuterTable string
+}
+
+// Eval runs the statement within the transaction of the environment
+// and returns the selected value.
+func (s *ScalarSubquery) Eval(env *environment.Environment) (document.Value, error) {
+	v := document.NewNullValue()
+
+	var n int
+	err := runSubquery(s.Stmt, s.OuterTable, env, func(value document.Value) error {
+		n++
+		if n > 1 {
+			return errors.New("subquery used as an expression must return at most one document")
+		}
+
+		v = value
+		return nil
+	})
+	if err != nil {
+		return expr.NullLiteral, err
+	}
+
+	return v, nil
+}
+
+// IsSubquery implements the expr.Subquery interface.
+func (s *ScalarSubquery) IsSubquery() {}
+
+// IsEqual compares this expression with the other expression and returns
+// true if they are equal.
+func (s *ScalarSubquery) IsEqual(other expr.Expr) bool {
+	o, ok := other.(*ScalarSubquery)
+	if !ok {
+		return false
+	}
+
+	return s.String() == o.String()
+}
+
+func (s *ScalarSubquery) String() string {
+	return stringutil.Sprintf("(%v)", s.Stmt)
+}
+
+// setOuterTable sets the name of the table read by a query without alias nor join
+// on the subqueries of its expressions, which can then refer to its documents
+// using the name of the table, like they would with an alias.
+func setOuterTable(tableName string, exprs ...expr.Expr) {
+	for _, e := range exprs {
+		expr.Walk(e, func(e expr.Expr) bool {
+			switch t := e.(type) {
+			case *Subquery:
+				t.OuterTable = tableName
+			case *ScalarSubquery:
+				t.OuterTable = tableName
+			}
+			return true
+		})
+	}
+}
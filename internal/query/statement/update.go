@@ -31,12 +31,14 @@ type UpdateSetPair struct {
 func (stmt *UpdateStmt) ToStream() *StreamStmt {
 	s := stream.New(stream.SeqScan(stmt.TableName))
 
+	setOuterTable(stmt.TableName, stmt.WhereExpr)
 	if stmt.WhereExpr != nil {
 		s = s.Pipe(stream.Filter(stmt.WhereExpr))
 	}
 
 	if stmt.SetPairs != nil {
 		for _, pair := range stmt.SetPairs {
+			setOuterTable(stmt.TableName, pair.E)
 			s = s.Pipe(stream.Set(pair.Path, pair.E))
 		}
 	} else if stmt.UnsetFields != nil {
@@ -1,7 +1,6 @@
 package query
 
 import (
-	"context"
 	"errors"
 
 	"github.com/genjidb/genji/internal/database"
@@ -13,15 +12,18 @@ type BeginStmt struct {
 	Writable bool
 }
 
-func (stmt BeginStmt) alterQuery(ctx context.Context, db *database.Database, q *Query) error {
+// alterQuery begins a transaction and attaches it to the session of the query,
+// whose following queries use it until it is committed or rolled back.
+func (stmt BeginStmt) alterQuery(c *Context, q *Query) error {
 	if q.tx != nil {
 		return errors.New("cannot begin a transaction within a transaction")
 	}
 
 	var err error
-	q.tx, err = db.BeginTx(ctx, &database.TxOptions{
+	q.tx, err = c.DB.BeginTx(c.Ctx, &database.TxOptions{
 		ReadOnly: !stmt.Writable,
-		Attached: true,
+		Timeout:  c.GetSession().TransactionTimeout(),
+		Session:  c.GetSession(),
 	})
 	q.autoCommit = false
 	return err
@@ -38,7 +40,7 @@ func (stmt BeginStmt) Run(ctx *statement.Context) (statement.Result, error) {
 // RollbackStmt is a statement that rollbacks the current active transaction.
 type RollbackStmt struct{}
 
-func (stmt RollbackStmt) alterQuery(ctx context.Context, db *database.Database, q *Query) error {
+func (stmt RollbackStmt) alterQuery(c *Context, q *Query) error {
 	if q.tx == nil || q.autoCommit {
 		return errors.New("cannot rollback with no active transaction")
 	}
@@ -63,12 +65,18 @@ func (stmt RollbackStmt) Run(ctx *statement.Context) (statement.Result, error) {
 // CommitStmt is a statement that commits the current active transaction.
 type CommitStmt struct{}
 
-func (stmt CommitStmt) alterQuery(ctx context.Context, db *database.Database, q *Query) error {
+func (stmt CommitStmt) alterQuery(c *Context, q *Query) error {
 	if q.tx == nil || q.autoCommit {
 		return errors.New("cannot commit with no active transaction")
 	}
 
-	err := q.tx.Commit()
+	// read-only transactions have nothing to commit
+	var err error
+	if q.tx.Writable {
+		err = q.tx.Commit()
+	} else {
+		err = q.tx.Rollback()
+	}
 	if err != nil {
 		return err
 	}
@@ -20,6 +20,7 @@ func TestTransactionRun(t *testing.T) {
 		{"Same exec/ Begin, select, then rollback", []string{`BEGIN;SELECT 1;ROLLBACK`}, false},
 		{"Multiple execs/ Begin then rollback", []string{`BEGIN`, `ROLLBACK`}, false},
 		{"Multiple execs/ Begin then commit", []string{`BEGIN`, `COMMIT`}, false},
+		{"Multiple execs/ Begin read-only then commit", []string{`BEGIN READ ONLY`, `SELECT 1`, `COMMIT`, `BEGIN`, `COMMIT`}, false},
 		{"Multiple execs/ Double", []string{`BEGIN`, `COMMIT`, `BEGIN`, `COMMIT`}, false},
 		{"Multiple execs/ Begin then begin", []string{`BEGIN`, `BEGIN`}, true},
 		{"Multiple execs/ Nested", []string{`BEGIN`, `BEGIN`, `COMMIT`, `COMMIT`}, true},
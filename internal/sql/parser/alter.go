@@ -1,14 +1,26 @@
 package parser
 
 import (
+	"strings"
+
+	"github.com/genjidb/genji/internal/database"
 	"github.com/genjidb/genji/internal/query/statement"
 	"github.com/genjidb/genji/internal/sql/scanner"
 )
 
-func (p *Parser) parseAlterTableRenameStatement(tableName string) (_ statement.AlterStmt, err error) {
+func (p *Parser) parseAlterTableRenameStatement(tableName string) (_ statement.Statement, err error) {
 	var stmt statement.AlterStmt
 	stmt.TableName = tableName
 
+	// Parse "FIELD" for RENAME FIELD statements.
+	ok, err := p.parseOptional(scanner.FIELD)
+	if err != nil {
+		return nil, err
+	}
+	if ok {
+		return p.parseAlterTableRenameFieldStatement(tableName)
+	}
+
 	// Parse "TO".
 	if err := p.parseTokens(scanner.TO); err != nil {
 		return stmt, err
@@ -23,6 +35,32 @@ func (p *Parser) parseAlterTableRenameStatement(tableName string) (_ statement.A
 	return stmt, nil
 }
 
+// parseAlterTableRenameFieldStatement parses the path of the field to rename and its new name:
+//
+//	RENAME FIELD path TO name
+func (p *Parser) parseAlterTableRenameFieldStatement(tableName string) (_ statement.AlterTableRenameField, err error) {
+	var stmt statement.AlterTableRenameField
+	stmt.TableName = tableName
+
+	stmt.Path, err = p.parsePath()
+	if err != nil {
+		return stmt, err
+	}
+
+	// Parse "TO".
+	if err := p.parseTokens(scanner.TO); err != nil {
+		return stmt, err
+	}
+
+	// Parse new field name.
+	stmt.NewName, err = p.parseIdent()
+	if err != nil {
+		return stmt, err
+	}
+
+	return stmt, nil
+}
+
 func (p *Parser) parseAlterTableAddFieldStatement(tableName string) (_ statement.AlterTableAddField, err error) {
 	var stmt statement.AlterTableAddField
 	stmt.TableName = tableName
@@ -45,6 +83,93 @@ func (p *Parser) parseAlterTableAddFieldStatement(tableName string) (_ statement
 	return stmt, nil
 }
 
+// parseAlterTableAlterFieldStatement parses the changes made to a field:
+//
+//	ALTER [FIELD] path SET NOT NULL
+//	ALTER [FIELD] path DROP NOT NULL
+//	ALTER [FIELD] path [SET DATA] TYPE type
+func (p *Parser) parseAlterTableAlterFieldStatement(tableName string) (_ statement.AlterTableAlterField, err error) {
+	var stmt statement.AlterTableAlterField
+	stmt.TableName = tableName
+
+	// Parse optional "FIELD".
+	if _, err := p.parseOptional(scanner.FIELD); err != nil {
+		return stmt, err
+	}
+
+	stmt.Path, err = p.parsePath()
+	if err != nil {
+		return stmt, err
+	}
+
+	// DATA and TYPE are not keywords, to allow using them as field names.
+	tok, pos, lit := p.ScanIgnoreWhitespace()
+	switch {
+	case tok == scanner.SET:
+		tok, pos, lit := p.ScanIgnoreWhitespace()
+		switch {
+		case tok == scanner.NOT:
+			if err := p.parseTokens(scanner.NULL); err != nil {
+				return stmt, err
+			}
+			stmt.SetNotNull = true
+			return stmt, nil
+		case tok == scanner.IDENT && strings.EqualFold(lit, "data"):
+			if tok, pos, lit := p.ScanIgnoreWhitespace(); tok != scanner.IDENT || !strings.EqualFold(lit, "type") {
+				return stmt, newParseError(scanner.Tokstr(tok, lit), []string{"TYPE"}, pos)
+			}
+		default:
+			return stmt, newParseError(scanner.Tokstr(tok, lit), []string{"NOT", "DATA"}, pos)
+		}
+	case tok == scanner.DROP:
+		if err := p.parseTokens(scanner.NOT, scanner.NULL); err != nil {
+			return stmt, err
+		}
+		stmt.DropNotNull = true
+		return stmt, nil
+	case tok == scanner.IDENT && strings.EqualFold(lit, "type"):
+	default:
+		return stmt, newParseError(scanner.Tokstr(tok, lit), []string{"SET", "DROP", "TYPE"}, pos)
+	}
+
+	var fc database.FieldConstraint
+	var size int
+	fc.Type, size, err = p.parseSizedType()
+	if err != nil {
+		return stmt, err
+	}
+	err = p.parseTypeParameters(&fc, size)
+	if err != nil {
+		return stmt, err
+	}
+
+	stmt.SetType = true
+	stmt.Type = fc.Type
+	stmt.MaxLength = fc.MaxLength
+	stmt.Dimension = fc.Dimension
+	stmt.Precision = fc.Precision
+	stmt.Scale = fc.Scale
+	return stmt, nil
+}
+
+// parseAlterTableDropConstraintStatement parses the name of the constraint following DROP CONSTRAINT.
+func (p *Parser) parseAlterTableDropConstraintStatement(tableName string) (_ statement.AlterTableDropConstraint, err error) {
+	var stmt statement.AlterTableDropConstraint
+	stmt.TableName = tableName
+
+	// CONSTRAINT is not a keyword, to allow using it as a field name.
+	if tok, pos, lit := p.ScanIgnoreWhitespace(); tok != scanner.IDENT || !strings.EqualFold(lit, "constraint") {
+		return stmt, newParseError(scanner.Tokstr(tok, lit), []string{"CONSTRAINT"}, pos)
+	}
+
+	stmt.ConstraintName, err = p.parseIdent()
+	if err != nil {
+		return stmt, err
+	}
+
+	return stmt, nil
+}
+
 // parseAlterStatement parses a Alter query string and returns a Statement AST object.
 // This function assumes the ALTER token has already been consumed.
 func (p *Parser) parseAlterStatement() (statement.Statement, error) {
@@ -69,7 +194,11 @@ func (p *Parser) parseAlterStatement() (statement.Statement, error) {
 		return p.parseAlterTableRenameStatement(tableName)
 	case scanner.ADD_KEYWORD:
 		return p.parseAlterTableAddFieldStatement(tableName)
+	case scanner.ALTER:
+		return p.parseAlterTableAlterFieldStatement(tableName)
+	case scanner.DROP:
+		return p.parseAlterTableDropConstraintStatement(tableName)
 	}
 
-	return nil, newParseError(scanner.Tokstr(tok, lit), []string{"ADD", "RENAME"}, pos)
+	return nil, newParseError(scanner.Tokstr(tok, lit), []string{"ADD", "ALTER", "DROP", "RENAME"}, pos)
 }
@@ -87,3 +87,98 @@ func TestParserAlterTableAddField(t *testing.T) {
 		})
 	}
 }
+
+func TestParserAlterTableAlterField(t *testing.T) {
+	tests := []struct {
+		name     string
+		s        string
+		expected statement.Statement
+		errored  bool
+	}{
+		{"Set not null", "ALTER TABLE foo ALTER FIELD bar SET NOT NULL", statement.AlterTableAlterField{TableName: "foo",
+			Path: document.Path(testutil.ParsePath(t, "bar")), SetNotNull: true,
+		}, false},
+		{"Drop not null", "ALTER TABLE foo ALTER bar.baz DROP NOT NULL", statement.AlterTableAlterField{TableName: "foo",
+			Path: document.Path(testutil.ParsePath(t, "bar.baz")), DropNotNull: true,
+		}, false},
+		{"Type", "ALTER TABLE foo ALTER FIELD bar TYPE VARCHAR(10)", statement.AlterTableAlterField{TableName: "foo",
+			Path: document.Path(testutil.ParsePath(t, "bar")), SetType: true, Type: document.TextValue, MaxLength: 10,
+		}, false},
+		{"Set data type", "ALTER TABLE foo ALTER FIELD bar SET DATA TYPE DECIMAL(5, 2)", statement.AlterTableAlterField{TableName: "foo",
+			Path: document.Path(testutil.ParsePath(t, "bar")), SetType: true, Type: document.DecimalValue, Precision: 5, Scale: 2,
+		}, false},
+		{"With error / missing action", "ALTER TABLE foo ALTER FIELD bar", nil, true},
+		{"With error / missing type", "ALTER TABLE foo ALTER FIELD bar TYPE", nil, true},
+		{"With error / set default", "ALTER TABLE foo ALTER FIELD bar SET DEFAULT 1", nil, true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			q, err := parser.ParseQuery(test.s)
+			if test.errored {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Len(t, q.Statements, 1)
+			require.EqualValues(t, test.expected, q.Statements[0])
+		})
+	}
+}
+
+func TestParserAlterTableDropConstraint(t *testing.T) {
+	tests := []struct {
+		name     string
+		s        string
+		expected statement.Statement
+		errored  bool
+	}{
+		{"Basic", "ALTER TABLE foo DROP CONSTRAINT foo_uniq", statement.AlterTableDropConstraint{TableName: "foo", ConstraintName: "foo_uniq"}, false},
+		{"With error / missing name", "ALTER TABLE foo DROP CONSTRAINT", nil, true},
+		{"With error / missing CONSTRAINT keyword", "ALTER TABLE foo DROP foo_uniq", nil, true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			q, err := parser.ParseQuery(test.s)
+			if test.errored {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Len(t, q.Statements, 1)
+			require.EqualValues(t, test.expected, q.Statements[0])
+		})
+	}
+}
+
+func TestParserAlterTableRenameField(t *testing.T) {
+	tests := []struct {
+		name     string
+		s        string
+		expected statement.Statement
+		errored  bool
+	}{
+		{"Basic", "ALTER TABLE foo RENAME FIELD bar TO baz", statement.AlterTableRenameField{TableName: "foo",
+			Path: document.Path(testutil.ParsePath(t, "bar")), NewName: "baz",
+		}, false},
+		{"Nested", "ALTER TABLE foo RENAME FIELD bar.baz TO qux", statement.AlterTableRenameField{TableName: "foo",
+			Path: document.Path(testutil.ParsePath(t, "bar.baz")), NewName: "qux",
+		}, false},
+		{"With error / missing TO", "ALTER TABLE foo RENAME FIELD bar baz", nil, true},
+		{"With error / path as new name", "ALTER TABLE foo RENAME FIELD bar TO baz.qux", nil, true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			q, err := parser.ParseQuery(test.s)
+			if test.errored {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Len(t, q.Statements, 1)
+			require.EqualValues(t, test.expected, q.Statements[0])
+		})
+	}
+}
@@ -0,0 +1,20 @@
+package parser
+
+import (
+	"github.com/genjidb/genji/internal/query/statement"
+	"github.com/genjidb/genji/internal/sql/scanner"
+)
+
+// parseAnalyzeStatement parses an analyze statement.
+// This function assumes the ANALYZE token has already been consumed.
+func (p *Parser) parseAnalyzeStatement() (statement.Statement, error) {
+	var stmt statement.AnalyzeStmt
+
+	tok, _, lit := p.ScanIgnoreWhitespace()
+	if tok == scanner.IDENT {
+		stmt.TableName = lit
+	} else {
+		p.Unscan()
+	}
+	return stmt, nil
+}
@@ -0,0 +1,36 @@
+package parser_test
+
+import (
+	"testing"
+
+	"github.com/genjidb/genji/internal/query/statement"
+	"github.com/genjidb/genji/internal/sql/parser"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParserAnalyze(t *testing.T) {
+	tests := []struct {
+		name     string
+		s        string
+		expected statement.Statement
+		errored  bool
+	}{
+		{"All", "ANALYZE", statement.AnalyzeStmt{}, false},
+		{"With table", "ANALYZE test", statement.AnalyzeStmt{TableName: "test"}, false},
+		{"With extra", "ANALYZE test test", nil, true},
+		{"Analyze as a table name", "analyze analyze", statement.AnalyzeStmt{TableName: "analyze"}, false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			q, err := parser.ParseQuery(test.s)
+			if test.errored {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Len(t, q.Statements, 1)
+			require.EqualValues(t, test.expected, q.Statements[0])
+		})
+	}
+}
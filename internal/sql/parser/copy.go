@@ -0,0 +1,64 @@
+package parser
+
+import (
+	"github.com/genjidb/genji/internal/expr"
+	"github.com/genjidb/genji/internal/query/statement"
+	"github.com/genjidb/genji/internal/sql/scanner"
+)
+
+// parseCopyStatement parses a copy string and returns a Statement AST object.
+// COPY table FROM 'path' inserts the documents of a JSON file into the table, and
+// COPY table TO 'path' or COPY (SELECT ...) TO 'path' write documents to a JSON Lines file.
+// This function assumes the COPY token has already been consumed.
+func (p *Parser) parseCopyStatement() (statement.Statement, error) {
+	var stmt statement.CopyStmt
+	var err error
+
+	if tok, _, _ := p.ScanIgnoreWhitespace(); tok == scanner.LPAREN {
+		if err := p.parseTokens(scanner.SELECT); err != nil {
+			return nil, err
+		}
+
+		stmt.Query, err = p.parseSelectStatement()
+		if err != nil {
+			return nil, err
+		}
+
+		if err := p.parseTokens(scanner.RPAREN, scanner.TO); err != nil {
+			return nil, err
+		}
+		stmt.To = true
+	} else {
+		p.Unscan()
+
+		stmt.TableName, err = p.parseIdent()
+		if err != nil {
+			return nil, err
+		}
+
+		tok, pos, lit := p.ScanIgnoreWhitespace()
+		switch tok {
+		case scanner.FROM:
+		case scanner.TO:
+			sel := statement.SelectStmt{
+				TableName:       stmt.TableName,
+				ProjectionExprs: []expr.Expr{expr.Wildcard{}},
+			}
+			stmt.Query, err = sel.ToStream()
+			if err != nil {
+				return nil, err
+			}
+			stmt.To = true
+		default:
+			return nil, newParseError(scanner.Tokstr(tok, lit), []string{"FROM", "TO"}, pos)
+		}
+	}
+
+	tok, pos, lit := p.ScanIgnoreWhitespace()
+	if tok != scanner.STRING {
+		return nil, newParseError(scanner.Tokstr(tok, lit), []string{"string"}, pos)
+	}
+	stmt.Path = lit
+
+	return &stmt, nil
+}
@@ -0,0 +1,52 @@
+package parser_test
+
+import (
+	"testing"
+
+	"github.com/genjidb/genji/internal/query/statement"
+	"github.com/genjidb/genji/internal/sql/parser"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParserCopy(t *testing.T) {
+	tests := []struct {
+		name      string
+		s         string
+		tableName string
+		query     string
+		path      string
+		to        bool
+		errored   bool
+	}{
+		{"From", "COPY foo FROM 'foo.json'", "foo", "", "foo.json", false, false},
+		{"Table to", "COPY foo TO 'foo.json'", "foo", "seqScan(foo) | project(*)", "foo.json", true, false},
+		{"Query to", "COPY (SELECT a FROM foo WHERE a > 1) TO 'foo.json'", "", "seqScan(foo) | filter(a > 1) | project(a)", "foo.json", true, false},
+		{"Query from", "COPY (SELECT a FROM foo) FROM 'foo.json'", "", "", "", false, true},
+		{"No path", "COPY foo FROM", "", "", "", false, true},
+		{"Ident path", "COPY foo FROM bar", "", "", "", false, true},
+		{"Lowercase", "copy foo to 'foo.json'", "foo", "seqScan(foo) | project(*)", "foo.json", true, false},
+		{"Copy as a table name", "COPY copy FROM 'copy.json'", "copy", "", "copy.json", false, false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			q, err := parser.ParseQuery(test.s)
+			if test.errored {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Len(t, q.Statements, 1)
+
+			stmt := q.Statements[0].(*statement.CopyStmt)
+			require.Equal(t, test.tableName, stmt.TableName)
+			require.Equal(t, test.path, stmt.Path)
+			require.Equal(t, test.to, stmt.To)
+			if test.query == "" {
+				require.Nil(t, stmt.Query)
+			} else {
+				require.Equal(t, test.query, stmt.Query.String())
+			}
+		})
+	}
+}
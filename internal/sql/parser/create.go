@@ -2,9 +2,12 @@ package parser
 
 import (
 	"math"
+	"strings"
 
+	"github.com/genjidb/genji/document"
 	"github.com/genjidb/genji/internal/database"
 	"github.com/genjidb/genji/internal/expr"
+	"github.com/genjidb/genji/internal/query"
 	"github.com/genjidb/genji/internal/query/statement"
 	"github.com/genjidb/genji/internal/sql/scanner"
 	"github.com/genjidb/genji/internal/stringutil"
@@ -22,14 +25,33 @@ func (p *Parser) parseCreateStatement() (statement.Statement, error) {
 			return nil, newParseError(scanner.Tokstr(tok, lit), []string{"INDEX"}, pos)
 		}
 
-		return p.parseCreateIndexStatement(true)
+		return p.parseCreateIndexOrConcurrently(true)
 	case scanner.INDEX:
-		return p.parseCreateIndexStatement(false)
+		return p.parseCreateIndexOrConcurrently(false)
 	case scanner.SEQUENCE:
 		return p.parseCreateSequenceStatement()
+	case scanner.OR:
+		if err := p.parseTokens(scanner.REPLACE); err != nil {
+			return nil, err
+		}
+		if tok, pos, lit := p.ScanIgnoreWhitespace(); !isViewKeyword(tok, lit) {
+			return nil, newParseError(scanner.Tokstr(tok, lit), []string{"VIEW"}, pos)
+		}
+
+		return p.parseCreateViewStatement(true)
+	case scanner.IDENT:
+		if isRoleKeyword(tok, lit) {
+			return p.parseCreateRoleStatement(strings.EqualFold(lit, "USER"))
+		}
+		if isViewKeyword(tok, lit) {
+			return p.parseCreateViewStatement(false)
+		}
+		if isTriggerKeyword(tok, lit) {
+			return p.parseCreateTriggerStatement()
+		}
 	}
 
-	return nil, newParseError(scanner.Tokstr(tok, lit), []string{"TABLE", "INDEX", "SEQUENCE"}, pos)
+	return nil, newParseError(scanner.Tokstr(tok, lit), []string{"TABLE", "INDEX", "SEQUENCE", "USER", "ROLE", "VIEW", "OR REPLACE VIEW", "TRIGGER"}, pos)
 }
 
 // parseCreateTableStatement parses a create table string and returns a Statement AST object.
@@ -52,18 +74,126 @@ func (p *Parser) parseCreateTableStatement() (*statement.CreateTableStmt, error)
 
 	// parse field constraints
 	err = p.parseConstraints(&stmt)
+	if err != nil {
+		return nil, err
+	}
+
+	// parse table options
+	err = p.parseTableOptions(&stmt)
 	return &stmt, err
 }
 
+// acceptsStringValue reports whether the value of the table option can be a string.
+func acceptsStringValue(option string) bool {
+	return strings.EqualFold(option, "ttl_field") || strings.EqualFold(option, "delete_mode")
+}
+
+// parseTableOptions parses the optional list of table options
+// following the WITH keyword, optionally enclosed in parentheses:
+//   WITH option = value [, option = value ...]
+//   WITH (option = value [, option = value ...])
+func (p *Parser) parseTableOptions(stmt *statement.CreateTableStmt) error {
+	if ok, err := p.parseOptional(scanner.WITH); !ok || err != nil {
+		return err
+	}
+
+	parens, err := p.parseOptional(scanner.LPAREN)
+	if err != nil {
+		return err
+	}
+
+	for {
+		tok, namePos, name := p.ScanIgnoreWhitespace()
+		if tok != scanner.IDENT {
+			return newParseError(scanner.Tokstr(tok, name), []string{"option"}, namePos)
+		}
+
+		if err := p.parseTokens(scanner.EQ); err != nil {
+			return err
+		}
+
+		tok, pos, lit := p.ScanIgnoreWhitespace()
+		if tok != scanner.IDENT && (tok != scanner.STRING || !acceptsStringValue(name)) {
+			return newParseError(scanner.Tokstr(tok, lit), []string{"option value"}, pos)
+		}
+
+		switch strings.ToLower(name) {
+		case "ttl_field":
+			stmt.Info.TTLField, err = ParsePath(lit)
+			if err != nil {
+				return &ParseError{Message: stringutil.Sprintf("invalid ttl field %q", lit), Pos: pos}
+			}
+		case "text_overflow":
+			switch strings.ToLower(lit) {
+			case "error":
+				stmt.Info.TruncateText = false
+			case "truncate":
+				stmt.Info.TruncateText = true
+			default:
+				return newParseError(lit, []string{"error", "truncate"}, pos)
+			}
+		case "delete_mode":
+			switch strings.ToLower(lit) {
+			case "hard":
+				stmt.Info.SoftDelete = false
+			case "soft":
+				stmt.Info.SoftDelete = true
+			default:
+				return newParseError(lit, []string{"hard", "soft"}, pos)
+			}
+		case "type_conversion":
+			switch strings.ToLower(lit) {
+			case "convert":
+				stmt.Info.ConversionPolicy = database.ConvertPolicy
+			case "reject":
+				stmt.Info.ConversionPolicy = database.RejectPolicy
+			case "untyped":
+				stmt.Info.ConversionPolicy = database.UntypedPolicy
+			default:
+				return newParseError(lit, []string{"convert", "reject", "untyped"}, pos)
+			}
+		default:
+			return &ParseError{Message: stringutil.Sprintf("unknown table option %q", name), Pos: namePos}
+		}
+
+		if tok, _, _ := p.ScanIgnoreWhitespace(); tok != scanner.COMMA {
+			p.Unscan()
+			break
+		}
+	}
+
+	if parens {
+		return p.parseTokens(scanner.RPAREN)
+	}
+
+	return nil
+}
+
 func (p *Parser) parseFieldDefinition(fc *database.FieldConstraint) (err error) {
 	fc.Path, err = p.parsePath()
 	if err != nil {
 		return err
 	}
 
-	fc.Type, err = p.parseType()
-	if err != nil {
+	// SERIAL is a shorthand for an integer field whose
+	// default value is generated by a sequence owned by the table.
+	var serial bool
+	if tok, _, _ := p.ScanIgnoreWhitespace(); tok == scanner.TYPESERIAL {
+		serial = true
+		fc.Type = document.IntegerValue
+		fc.Identity = &database.FieldConstraintIdentity{}
+	} else {
 		p.Unscan()
+
+		var size int
+		fc.Type, size, err = p.parseSizedType()
+		if err != nil {
+			p.Unscan()
+		}
+		err = p.parseTypeParameters(fc, size)
+		if err != nil {
+			return err
+		}
 	}
 
 	err = p.parseFieldConstraint(fc)
@@ -71,7 +201,15 @@ func (p *Parser) parseFieldDefinition(fc *database.FieldConstraint) (err error)
 		return err
 	}
 
-	if fc.Type.IsAny() && fc.DefaultValue == nil && !fc.IsNotNull && !fc.IsPrimaryKey && !fc.IsUnique {
+	if serial && fc.DefaultValue != nil {
+		return stringutil.Errorf("field %q cannot be SERIAL and have a default value", fc.Path)
+	}
+
+	if fc.IsGenerated() && (serial || fc.DefaultValue != nil) {
+		return stringutil.Errorf("field %q cannot be generated and have a default value", fc.Path)
+	}
+
+	if fc.Type.IsAny() && fc.DefaultValue == nil && !fc.IsGenerated() && !fc.IsNotNull && !fc.IsPrimaryKey && !fc.IsUnique {
 		tok, pos, lit := p.ScanIgnoreWhitespace()
 		return newParseError(scanner.Tokstr(tok, lit), []string{"CONSTRAINT", "TYPE"}, pos)
 	}
@@ -79,6 +217,21 @@ func (p *Parser) parseFieldDefinition(fc *database.FieldConstraint) (err error)
 	return nil
 }
 
+// parseTypeParameters sets the parameters of the type of fc, given the size parsed with the type
+// name, i.e. the maximum length of VARCHAR(255), and parses the precision of decimals.
+func (p *Parser) parseTypeParameters(fc *database.FieldConstraint, size int) (err error) {
+	switch fc.Type {
+	case document.VectorValue:
+		fc.Dimension = size
+	case document.TextValue:
+		fc.MaxLength = size
+	case document.DecimalValue:
+		fc.Precision, fc.Scale, err = p.parseDecimalPrecision()
+	}
+
+	return err
+}
+
 func (p *Parser) parseConstraints(stmt *statement.CreateTableStmt) error {
 	// Parse ( token.
 	if ok, err := p.parseOptional(scanner.LPAREN); !ok || err != nil {
@@ -147,9 +300,64 @@ func (p *Parser) parseConstraints(stmt *statement.CreateTableStmt) error {
 		}
 	}
 
+	// generated fields cannot depend on other generated fields,
+	// and default values are computed before generated fields and other default values
+	for _, fc := range stmt.Info.FieldConstraints {
+		if fc.IsGenerated() {
+			err := checkFieldReferences(stmt.Info.FieldConstraints, fc.Generated, func(other *database.FieldConstraint) error {
+				if other.IsGenerated() {
+					return stringutil.Errorf("generated field %q cannot refer to generated field %q", fc.Path, other.Path)
+				}
+				return nil
+			})
+			if err != nil {
+				return err
+			}
+		}
+
+		if fc.HasDefaultValue() {
+			err := checkFieldReferences(stmt.Info.FieldConstraints, fc.DefaultValue, func(other *database.FieldConstraint) error {
+				if other.IsGenerated() {
+					return stringutil.Errorf("default value of field %q cannot refer to generated field %q", fc.Path, other.Path)
+				}
+				if other.HasDefaultValue() {
+					return stringutil.Errorf("default value of field %q cannot refer to field %q which has a default value", fc.Path, other.Path)
+				}
+				return nil
+			})
+			if err != nil {
+				return err
+			}
+		}
+	}
+
 	return nil
 }
 
+// checkFieldReferences calls fn for every field constraint whose path is a prefix of a path
+// referred to by the table expression te, and stops at the first error.
+func checkFieldReferences(fcs database.FieldConstraints, te database.TableExpression, fn func(other *database.FieldConstraint) error) error {
+	var err error
+	expr.Walk(te.(*expr.ConstraintExpr).Expr, func(e expr.Expr) bool {
+		path, ok := e.(expr.Path)
+		if !ok {
+			return true
+		}
+
+		for _, other := range fcs {
+			if len(path) >= len(other.Path) && document.Path(path[:len(other.Path)]).IsEqual(other.Path) {
+				err = fn(other)
+				if err != nil {
+					return false
+				}
+			}
+		}
+		return true
+	})
+
+	return err
+}
+
 func (p *Parser) parseFieldConstraint(fc *database.FieldConstraint) error {
 	for {
 		tok, pos, lit := p.ScanIgnoreWhitespace()
@@ -192,6 +400,8 @@ func (p *Parser) parseFieldConstraint(fc *database.FieldConstraint) error {
 				scanner.BITWISEOR,
 				scanner.BITWISEXOR,
 				scanner.BITWISEAND,
+				scanner.SHIFTLEFT,
+				scanner.SHIFTRIGHT,
 				scanner.LT,
 				scanner.LTE,
 				scanner.GT,
@@ -211,12 +421,31 @@ func (p *Parser) parseFieldConstraint(fc *database.FieldConstraint) error {
 				scanner.LPAREN,   // only opening parenthesis are necessary
 				scanner.LBRACKET, // only opening brackets are necessary
 				scanner.NEXT,
+				scanner.IDENT, // only function calls and uuid literals, fields are rejected below
 			)
 			if err != nil {
 				return err
 			}
 
+			// default values can only depend on the document being inserted
+			// if they are enclosed in parentheses, e.g. DEFAULT (lower(name))
+			_, allowPaths := e.(expr.Parentheses)
+			err = checkTableExpr(e, allowPaths, "a default value", pos)
+			if err != nil {
+				return err
+			}
+
 			fc.DefaultValue = expr.Constraint(e)
+		case scanner.AS:
+			if fc.IsGenerated() {
+				return newParseError(scanner.Tokstr(tok, lit), []string{"CONSTRAINT", ")"}, pos)
+			}
+
+			g, err := p.parseGeneratedExpr(pos)
+			if err != nil {
+				return err
+			}
+			fc.Generated = g
 		case scanner.UNIQUE:
 			// if it's already unique we return an error
 			if fc.IsUnique {
@@ -224,6 +453,28 @@ func (p *Parser) parseFieldConstraint(fc *database.FieldConstraint) error {
 			}
 
 			fc.IsUnique = true
+		case scanner.IDENT:
+			// GENERATED ALWAYS AS is the standard form of AS.
+			// GENERATED and ALWAYS are not keywords, to allow using them as field names.
+			if !strings.EqualFold(lit, "generated") {
+				p.Unscan()
+				return nil
+			}
+			if tok, pos, lit := p.ScanIgnoreWhitespace(); tok != scanner.IDENT || !strings.EqualFold(lit, "always") {
+				return newParseError(scanner.Tokstr(tok, lit), []string{"ALWAYS"}, pos)
+			}
+			if err := p.parseTokens(scanner.AS); err != nil {
+				return err
+			}
+			if fc.IsGenerated() {
+				return newParseError(scanner.Tokstr(tok, lit), []string{"CONSTRAINT", ")"}, pos)
+			}
+
+			g, err := p.parseGeneratedExpr(pos)
+			if err != nil {
+				return err
+			}
+			fc.Generated = g
 		default:
 			p.Unscan()
 			return nil
@@ -231,10 +482,80 @@ func (p *Parser) parseFieldConstraint(fc *database.FieldConstraint) error {
 	}
 }
 
+// parseGeneratedExpr parses the expression of a generated field, following the AS keyword:
+//
+//	AS (expr) [STORED]
+//
+// Only stored generated fields are supported, their value is computed each time the document is written.
+func (p *Parser) parseGeneratedExpr(pos scanner.Pos) (database.TableExpression, error) {
+	if err := p.parseTokens(scanner.LPAREN); err != nil {
+		return nil, err
+	}
+
+	e, err := p.ParseExpr()
+	if err != nil {
+		return nil, err
+	}
+
+	if err := p.parseTokens(scanner.RPAREN); err != nil {
+		return nil, err
+	}
+
+	// generated fields are computed from the fields of the document
+	err = checkTableExpr(e, true, "a generated field", pos)
+	if err != nil {
+		return nil, err
+	}
+
+	// STORED and VIRTUAL are not keywords, to allow using them as field names.
+	tok, pos, lit := p.ScanIgnoreWhitespace()
+	switch {
+	case tok == scanner.IDENT && strings.EqualFold(lit, "stored"):
+	case tok == scanner.IDENT && strings.EqualFold(lit, "virtual"):
+		return nil, &ParseError{Message: "virtual generated fields are not supported", Pos: pos}
+	default:
+		p.Unscan()
+	}
+
+	return expr.Constraint(e), nil
+}
+
+// checkTableExpr returns an error if e cannot be stored in the definition of a table:
+// parameters, aggregators and subqueries are never allowed, paths only if allowPaths is true.
+func checkTableExpr(e expr.Expr, allowPaths bool, what string, pos scanner.Pos) error {
+	var invalid expr.Expr
+	expr.Walk(e, func(e expr.Expr) bool {
+		switch e.(type) {
+		case expr.Path:
+			if allowPaths {
+				return true
+			}
+		case expr.PositionalParam, expr.NamedParam, expr.AggregatorBuilder, expr.Subquery:
+		default:
+			return true
+		}
+
+		invalid = e
+		return false
+	})
+	if invalid != nil {
+		return &ParseError{Message: stringutil.Sprintf("%s is not allowed in %s", invalid, what), Pos: pos}
+	}
+
+	return nil
+}
+
 func (p *Parser) parseTableConstraint(stmt *statement.CreateTableStmt) (bool, error) {
 	var err error
 
-	tok, _, _ := p.ScanIgnoreWhitespace()
+	name := p.parseConstraintName()
+
+	tok, pos, lit := p.ScanIgnoreWhitespace()
+	// only unique constraints can be named
+	if name != "" && tok != scanner.UNIQUE {
+		return false, newParseError(scanner.Tokstr(tok, lit), []string{"UNIQUE"}, pos)
+	}
+
 	switch tok {
 	case scanner.PRIMARY:
 		// Parse "KEY ("
@@ -272,36 +593,55 @@ func (p *Parser) parseTableConstraint(stmt *statement.CreateTableStmt) (bool, er
 
 		return true, nil
 	case scanner.UNIQUE:
-		// Parse "("
-		err = p.parseTokens(scanner.LPAREN)
+		paths, err := p.parsePathList()
 		if err != nil {
 			return false, err
 		}
+		if len(paths) == 0 {
+			tok, pos, lit := p.ScanIgnoreWhitespace()
+			return false, newParseError(scanner.Tokstr(tok, lit), []string{"("}, pos)
+		}
 
-		uniquePath, err := p.parsePath()
-		if err != nil {
-			return false, err
+		// unnamed constraints on a single path are field constraints
+		if len(paths) == 1 && name == "" {
+			fc := stmt.Info.FieldConstraints.Get(paths[0])
+			if fc == nil {
+				err = stmt.Info.FieldConstraints.Add(&database.FieldConstraint{
+					Path:     paths[0],
+					IsUnique: true,
+				})
+				if err != nil {
+					return false, err
+				}
+			} else {
+				fc.IsUnique = true
+			}
+
+			return true, nil
 		}
 
-		// Parse ")"
-		err = p.parseTokens(scanner.RPAREN)
-		if err != nil {
-			return false, err
+		for i := range paths {
+			for j := i + 1; j < len(paths); j++ {
+				if paths[i].IsEqual(paths[j]) {
+					return false, stringutil.Errorf("path %q appears more than once in unique constraint", paths[i])
+				}
+			}
 		}
 
-		fc := stmt.Info.FieldConstraints.Get(uniquePath)
-		if fc == nil {
-			err = stmt.Info.FieldConstraints.Add(&database.FieldConstraint{
-				Path:     uniquePath,
-				IsUnique: true,
-			})
-			if err != nil {
-				return false, err
+		for _, uc := range stmt.Info.UniqueConstraints {
+			if name != "" && uc.Name == name {
+				return false, stringutil.Errorf("table %q has more than one constraint named %q", stmt.Info.TableName, name)
+			}
+			if pathsEqual(uc.Paths, paths) {
+				return false, stringutil.Errorf("table %q has more than one unique constraint on the same paths", stmt.Info.TableName)
 			}
-		} else {
-			fc.IsUnique = true
 		}
 
+		stmt.Info.UniqueConstraints = append(stmt.Info.UniqueConstraints, &database.UniqueConstraint{
+			Name:  name,
+			Paths: paths,
+		})
+
 		return true, nil
 	default:
 		p.Unscan()
@@ -309,6 +649,73 @@ func (p *Parser) parseTableConstraint(stmt *statement.CreateTableStmt) (bool, er
 	}
 }
 
+// parseConstraintName parses the optional name of a table constraint:
+//
+//	CONSTRAINT name
+//
+// CONSTRAINT is not a keyword, to allow using it as a field name.
+// It only names a constraint if it is followed by an identifier.
+func (p *Parser) parseConstraintName() string {
+	tok, _, lit := p.ScanIgnoreWhitespace()
+	if tok != scanner.IDENT || !strings.EqualFold(lit, "constraint") {
+		p.Unscan()
+		return ""
+	}
+
+	n := 1
+	tok, _, lit = p.Scan()
+	if tok == scanner.WS {
+		n++
+		tok, _, lit = p.Scan()
+	}
+	if tok == scanner.IDENT {
+		return lit
+	}
+
+	for i := 0; i < n; i++ {
+		p.Unscan()
+	}
+	p.Unscan()
+	return ""
+}
+
+// pathsEqual returns true if both lists contain the same paths, in the same order.
+func pathsEqual(a, b []document.Path) bool {
+	if len(a) != len(b) {
+		return false
+	}
+
+	for i := range a {
+		if !a[i].IsEqual(b[i]) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// parseCreateIndexOrConcurrently parses a create index statement, which builds the index
+// in the background if the CONCURRENTLY keyword follows the INDEX token.
+// This function assumes the CREATE [UNIQUE] INDEX tokens have already been consumed.
+func (p *Parser) parseCreateIndexOrConcurrently(unique bool) (statement.Statement, error) {
+	tok, _, lit := p.ScanIgnoreWhitespace()
+	concurrently := tok == scanner.IDENT && strings.EqualFold(lit, "CONCURRENTLY")
+	if !concurrently {
+		p.Unscan()
+	}
+
+	stmt, err := p.parseCreateIndexStatement(unique)
+	if err != nil {
+		return nil, err
+	}
+
+	if concurrently {
+		return query.CreateIndexConcurrentlyStmt{CreateIndexStmt: *stmt}, nil
+	}
+
+	return stmt, nil
+}
+
 // parseCreateIndexStatement parses a create index string and returns a Statement AST object.
 // This function assumes the CREATE INDEX or CREATE UNIQUE INDEX tokens have already been consumed.
 func (p *Parser) parseCreateIndexStatement(unique bool) (*statement.CreateIndexStmt, error) {
@@ -344,20 +751,132 @@ func (p *Parser) parseCreateIndexStatement(unique bool) (*statement.CreateIndexS
 		return nil, err
 	}
 
-	paths, err := p.parsePathList()
+	err = p.parseIndexPathList(&stmt.Info)
 	if err != nil {
 		return nil, err
 	}
-	if len(paths) == 0 {
+	if len(stmt.Info.Paths) == 0 {
 		tok, pos, lit := p.ScanIgnoreWhitespace()
 		return nil, newParseError(scanner.Tokstr(tok, lit), []string{"("}, pos)
 	}
 
-	stmt.Info.Paths = paths
+	// Parse optional WHERE predicate of partial indexes
+	tok, pos, _ := p.ScanIgnoreWhitespace()
+	if tok != scanner.WHERE {
+		p.Unscan()
+		return &stmt, nil
+	}
+
+	e, err := p.ParseExpr()
+	if err != nil {
+		return nil, err
+	}
+
+	// the predicate is evaluated against the documents being indexed
+	err = checkTableExpr(e, true, "an index predicate", pos)
+	if err != nil {
+		return nil, err
+	}
+	stmt.Info.Predicate = expr.Constraint(e)
 
 	return &stmt, nil
 }
 
+// parseIndexPathList parses a list of paths in the form: (path, path[*] DESC, path ASC NULLS LAST, ...), if exists,
+// and sets the paths of the index, their flags and their orders.
+// Paths followed by [*] are multi-key paths: the elements of the arrays they contain
+// are indexed one by one. The lists of flags and orders are nil if they are all unset.
+func (p *Parser) parseIndexPathList(info *database.IndexInfo) error {
+	// Parse ( token.
+	if ok, err := p.parseOptional(scanner.LPAREN); !ok || err != nil {
+		return err
+	}
+
+	var multiKey []bool
+	var orders []database.IndexOrder
+	var hasMultiKey, hasOrder bool
+	for {
+		path, err := p.parsePath()
+		if err != nil {
+			return err
+		}
+
+		tok, pos, _ := p.Scan()
+		isMultiKey := tok == scanner.LSBRACKET
+		if isMultiKey {
+			if hasMultiKey {
+				return &ParseError{Message: "an index can only have one multi-key path", Pos: pos}
+			}
+			hasMultiKey = true
+
+			if err := p.parseTokens(scanner.MUL, scanner.RSBRACKET); err != nil {
+				return err
+			}
+		} else {
+			p.Unscan()
+		}
+
+		order, err := p.parseIndexOrder()
+		if err != nil {
+			return err
+		}
+		if order != (database.IndexOrder{}) {
+			hasOrder = true
+		}
+
+		info.Paths = append(info.Paths, path)
+		multiKey = append(multiKey, isMultiKey)
+		orders = append(orders, order)
+
+		if tok, _, _ := p.ScanIgnoreWhitespace(); tok != scanner.COMMA {
+			p.Unscan()
+			break
+		}
+	}
+
+	// Parse required ) token.
+	if err := p.parseTokens(scanner.RPAREN); err != nil {
+		return err
+	}
+
+	if hasMultiKey {
+		info.MultiKey = multiKey
+	}
+	if hasOrder {
+		info.Orders = orders
+	}
+
+	return nil
+}
+
+// parseIndexOrder parses the optional order of a path of an index: [ASC|DESC] [NULLS FIRST|NULLS LAST].
+func (p *Parser) parseIndexOrder() (database.IndexOrder, error) {
+	var order database.IndexOrder
+
+	if tok, _, _ := p.ScanIgnoreWhitespace(); tok == scanner.DESC {
+		order.Desc = true
+	} else if tok != scanner.ASC {
+		p.Unscan()
+	}
+
+	if tok, _, lit := p.ScanIgnoreWhitespace(); tok != scanner.IDENT || !strings.EqualFold(lit, "nulls") {
+		p.Unscan()
+		return order, nil
+	}
+
+	tok, pos, lit := p.ScanIgnoreWhitespace()
+	switch {
+	case tok == scanner.IDENT && strings.EqualFold(lit, "first"):
+		order.NullsFirst = true
+	case tok == scanner.IDENT && strings.EqualFold(lit, "last"):
+		order.NullsLast = true
+	default:
+		return order, newParseError(scanner.Tokstr(tok, lit), []string{"FIRST", "LAST"}, pos)
+	}
+
+	return order, nil
+}
+
 // This function assumes the CREATE SEQUENCE tokens have already been consumed.
 func (p *Parser) parseCreateSequenceStatement() (*statement.CreateSequenceStmt, error) {
 	var stmt statement.CreateSequenceStmt
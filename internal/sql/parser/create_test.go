@@ -7,6 +7,7 @@ import (
 	"github.com/genjidb/genji/document"
 	"github.com/genjidb/genji/internal/database"
 	"github.com/genjidb/genji/internal/expr"
+	"github.com/genjidb/genji/internal/query"
 	"github.com/genjidb/genji/internal/query/statement"
 	"github.com/genjidb/genji/internal/sql/parser"
 	"github.com/genjidb/genji/internal/testutil"
@@ -165,6 +166,35 @@ func TestParserCreateTable(t *testing.T) {
 					},
 				},
 			}, false},
+		{"With table constraints / UNIQUE on multiple paths", "CREATE TABLE test(foo INTEGER, UNIQUE (foo, bar.baz), CONSTRAINT foo_uniq UNIQUE (bar))",
+			&statement.CreateTableStmt{
+				Info: database.TableInfo{
+					TableName: "test",
+					FieldConstraints: []*database.FieldConstraint{
+						{Path: document.Path(testutil.ParsePath(t, "foo")), Type: document.IntegerValue},
+					},
+					UniqueConstraints: []*database.UniqueConstraint{
+						{Paths: []document.Path{document.Path(testutil.ParsePath(t, "foo")), document.Path(testutil.ParsePath(t, "bar.baz"))}},
+						{Name: "foo_uniq", Paths: []document.Path{document.Path(testutil.ParsePath(t, "bar"))}},
+					},
+				},
+			}, false},
+		{"With table constraints / field named constraint", "CREATE TABLE test(constraint INTEGER, UNIQUE (constraint, foo))",
+			&statement.CreateTableStmt{
+				Info: database.TableInfo{
+					TableName: "test",
+					FieldConstraints: []*database.FieldConstraint{
+						{Path: document.Path(testutil.ParsePath(t, "constraint")), Type: document.IntegerValue},
+					},
+					UniqueConstraints: []*database.UniqueConstraint{
+						{Paths: []document.Path{document.Path(testutil.ParsePath(t, "constraint")), document.Path(testutil.ParsePath(t, "foo"))}},
+					},
+				},
+			}, false},
+		{"With table constraints / UNIQUE twice on multiple paths", "CREATE TABLE test(foo INTEGER, UNIQUE (foo, bar), UNIQUE (foo, bar))", nil, true},
+		{"With table constraints / UNIQUE with duplicate path", "CREATE TABLE test(foo INTEGER, UNIQUE (foo, foo))", nil, true},
+		{"With table constraints / duplicate constraint name", "CREATE TABLE test(foo INTEGER, CONSTRAINT c UNIQUE (foo), CONSTRAINT c UNIQUE (bar))", nil, true},
+		{"With table constraints / named PRIMARY KEY", "CREATE TABLE test(foo INTEGER, CONSTRAINT c PRIMARY KEY (foo))", nil, true},
 		{"With table constraints / duplicate pk on same path", "CREATE TABLE test(foo INTEGER PRIMARY KEY, PRIMARY KEY (foo))", nil, true},
 		{"With multiple primary keys", "CREATE TABLE test(foo PRIMARY KEY, bar PRIMARY KEY)", nil, true},
 		{"With all supported fixed size data types",
@@ -227,12 +257,180 @@ func TestParserCreateTable(t *testing.T) {
 				Info: database.TableInfo{
 					TableName: "test",
 					FieldConstraints: []*database.FieldConstraint{
-						{Path: document.Path(testutil.ParsePath(t, "v")), Type: document.TextValue},
-						{Path: document.Path(testutil.ParsePath(t, "c")), Type: document.TextValue},
+						{Path: document.Path(testutil.ParsePath(t, "v")), Type: document.TextValue, MaxLength: 255},
+						{Path: document.Path(testutil.ParsePath(t, "c")), Type: document.TextValue, MaxLength: 64},
 						{Path: document.Path(testutil.ParsePath(t, "t")), Type: document.TextValue},
 					},
 				},
 			}, false},
+		{"With vector types",
+			"CREATE TABLE test(v VECTOR, w VECTOR(3))",
+			&statement.CreateTableStmt{
+				Info: database.TableInfo{
+					TableName: "test",
+					FieldConstraints: []*database.FieldConstraint{
+						{Path: document.Path(testutil.ParsePath(t, "v")), Type: document.VectorValue},
+						{Path: document.Path(testutil.ParsePath(t, "w")), Type: document.VectorValue, Dimension: 3},
+					},
+				},
+			}, false},
+		{"With time types",
+			"CREATE TABLE test(d DATE, t TIMESTAMP)",
+			&statement.CreateTableStmt{
+				Info: database.TableInfo{
+					TableName: "test",
+					FieldConstraints: []*database.FieldConstraint{
+						{Path: document.Path(testutil.ParsePath(t, "d")), Type: document.DateValue},
+						{Path: document.Path(testutil.ParsePath(t, "t")), Type: document.TimestampValue},
+					},
+				},
+			}, false},
+		{"With decimal types",
+			"CREATE TABLE test(a DECIMAL, b NUMERIC(10), c DECIMAL(10, 2))",
+			&statement.CreateTableStmt{
+				Info: database.TableInfo{
+					TableName: "test",
+					FieldConstraints: []*database.FieldConstraint{
+						{Path: document.Path(testutil.ParsePath(t, "a")), Type: document.DecimalValue},
+						{Path: document.Path(testutil.ParsePath(t, "b")), Type: document.DecimalValue, Precision: 10},
+						{Path: document.Path(testutil.ParsePath(t, "c")), Type: document.DecimalValue, Precision: 10, Scale: 2},
+					},
+				},
+			}, false},
+		{"With uuid type and default",
+			"CREATE TABLE test(id UUID PRIMARY KEY DEFAULT uuid_v7(), uuid uuid)",
+			&statement.CreateTableStmt{
+				Info: database.TableInfo{
+					TableName: "test",
+					FieldConstraints: []*database.FieldConstraint{
+						{Path: document.Path(testutil.ParsePath(t, "id")), Type: document.UUIDValue, IsPrimaryKey: true, DefaultValue: expr.Constraint(parser.MustParseExpr("uuid_v7()"))},
+						{Path: document.Path(testutil.ParsePath(t, "uuid")), Type: document.UUIDValue},
+					},
+				},
+			}, false},
+		{"With field in default function", "CREATE TABLE test(foo DEFAULT lower(bar))", nil, true},
+		{"With param in default", "CREATE TABLE test(foo DEFAULT coalesce(?, 1))", nil, true},
+		{"With expression in default",
+			"CREATE TABLE test(a TEXT, b TEXT DEFAULT (concat(a, 'x')), c BOOL DEFAULT (1 < 2 AND true))",
+			&statement.CreateTableStmt{
+				Info: database.TableInfo{
+					TableName: "test",
+					FieldConstraints: []*database.FieldConstraint{
+						{Path: document.Path(testutil.ParsePath(t, "a")), Type: document.TextValue},
+						{Path: document.Path(testutil.ParsePath(t, "b")), Type: document.TextValue, DefaultValue: expr.Constraint(parser.MustParseExpr("(concat(a, 'x'))"))},
+						{Path: document.Path(testutil.ParsePath(t, "c")), Type: document.BoolValue, DefaultValue: expr.Constraint(parser.MustParseExpr("(1 < 2 AND true)"))},
+					},
+				},
+			}, false},
+		{"With param in default expression", "CREATE TABLE test(foo DEFAULT (? + 1))", nil, true},
+		{"With default referring to a generated field", "CREATE TABLE test(a INT AS (1), b INT DEFAULT (a + 1))", nil, true},
+		{"With default referring to a default", "CREATE TABLE test(a INT DEFAULT 1, b INT DEFAULT (a + 1))", nil, true},
+		{"With generated fields",
+			"CREATE TABLE test(a INT, b INT AS (a * 2) STORED, c GENERATED ALWAYS AS (a + 1))",
+			&statement.CreateTableStmt{
+				Info: database.TableInfo{
+					TableName: "test",
+					FieldConstraints: []*database.FieldConstraint{
+						{Path: document.Path(testutil.ParsePath(t, "a")), Type: document.IntegerValue},
+						{Path: document.Path(testutil.ParsePath(t, "b")), Type: document.IntegerValue, Generated: expr.Constraint(parser.MustParseExpr("a * 2"))},
+						{Path: document.Path(testutil.ParsePath(t, "c")), Generated: expr.Constraint(parser.MustParseExpr("a + 1"))},
+					},
+				},
+			}, false},
+		{"With virtual generated field", "CREATE TABLE test(a INT, b INT AS (a * 2) VIRTUAL)", nil, true},
+		{"With param in generated field", "CREATE TABLE test(a INT, b INT AS (a + ?))", nil, true},
+		{"With generated field referring to a generated field", "CREATE TABLE test(a INT AS (b + 1), b INT AS (1))", nil, true},
+		{"With generated field and default", "CREATE TABLE test(a INT AS (1) DEFAULT 1)", nil, true},
+		{"With errored decimal scale",
+			"CREATE TABLE test(d DECIMAL(2, 3))",
+			nil, true},
+		{"With errored vector dimension",
+			"CREATE TABLE test(v VECTOR(0))",
+			nil, true},
+		{"With text_overflow option",
+			"CREATE TABLE test(v VARCHAR(3)) WITH text_overflow = truncate",
+			&statement.CreateTableStmt{
+				Info: database.TableInfo{
+					TableName: "test",
+					FieldConstraints: []*database.FieldConstraint{
+						{Path: document.Path(testutil.ParsePath(t, "v")), Type: document.TextValue, MaxLength: 3},
+					},
+					TruncateText: true,
+				},
+			}, false},
+		{"With multiple options",
+			"CREATE TABLE test(v TEXT) WITH type_conversion = reject, text_overflow = error",
+			&statement.CreateTableStmt{
+				Info: database.TableInfo{
+					TableName: "test",
+					FieldConstraints: []*database.FieldConstraint{
+						{Path: document.Path(testutil.ParsePath(t, "v")), Type: document.TextValue},
+					},
+					ConversionPolicy: database.RejectPolicy,
+				},
+			}, false},
+		{"With ttl_field option",
+			"CREATE TABLE test(expires TIMESTAMP) WITH (ttl_field = 'expires')",
+			&statement.CreateTableStmt{
+				Info: database.TableInfo{
+					TableName: "test",
+					FieldConstraints: []*database.FieldConstraint{
+						{Path: document.Path(testutil.ParsePath(t, "expires")), Type: document.TimestampValue},
+					},
+					TTLField: document.Path(testutil.ParsePath(t, "expires")),
+				},
+			}, false},
+		{"With nested ttl_field option",
+			"CREATE TABLE test(a.expires TIMESTAMP) WITH ttl_field = \"a.expires\", text_overflow = truncate",
+			&statement.CreateTableStmt{
+				Info: database.TableInfo{
+					TableName: "test",
+					FieldConstraints: []*database.FieldConstraint{
+						{Path: document.Path(testutil.ParsePath(t, "a.expires")), Type: document.TimestampValue},
+					},
+					TruncateText: true,
+					TTLField:     document.Path(testutil.ParsePath(t, "a.expires")),
+				},
+			}, false},
+		{"With delete_mode option",
+			"CREATE TABLE test(a INT) WITH delete_mode = soft",
+			&statement.CreateTableStmt{
+				Info: database.TableInfo{
+					TableName: "test",
+					FieldConstraints: []*database.FieldConstraint{
+						{Path: document.Path(testutil.ParsePath(t, "a")), Type: document.IntegerValue},
+					},
+					SoftDelete: true,
+				},
+			}, false},
+		{"With delete_mode option as a string",
+			"CREATE TABLE test(a INT) WITH (delete_mode = 'soft')",
+			&statement.CreateTableStmt{
+				Info: database.TableInfo{
+					TableName: "test",
+					FieldConstraints: []*database.FieldConstraint{
+						{Path: document.Path(testutil.ParsePath(t, "a")), Type: document.IntegerValue},
+					},
+					SoftDelete: true,
+				},
+			}, false},
+		{"With unclosed options", "CREATE TABLE test(expires TIMESTAMP) WITH (ttl_field = expires", nil, true},
+		{"With invalid delete_mode option", "CREATE TABLE test(a INT) WITH delete_mode = archive", nil, true},
+		{"With string option value", "CREATE TABLE test(v TEXT) WITH type_conversion = 'reject'", nil, true},
+		{"With invalid type_conversion option", "CREATE TABLE test(v TEXT) WITH type_conversion = foo", nil, true},
+		{"With invalid text_overflow option", "CREATE TABLE test(v VARCHAR(3)) WITH text_overflow = foo", nil, true},
+		{"With unknown option", "CREATE TABLE test(v VARCHAR(3)) WITH foo = bar", nil, true},
+		{"With serial",
+			"CREATE TABLE test(id SERIAL PRIMARY KEY)",
+			&statement.CreateTableStmt{
+				Info: database.TableInfo{
+					TableName: "test",
+					FieldConstraints: []*database.FieldConstraint{
+						{Path: document.Path(testutil.ParsePath(t, "id")), Type: document.IntegerValue, IsPrimaryKey: true, Identity: &database.FieldConstraintIdentity{}},
+					},
+				},
+			}, false},
+		{"With serial and default", "CREATE TABLE test(id SERIAL DEFAULT 10)", nil, true},
 		{"With errored text aliases types",
 			"CREATE TABLE test(v VARCHAR(1 IN [1, 2, 3] AND foo > 4) )",
 			&statement.CreateTableStmt{
@@ -294,6 +492,59 @@ func TestParserCreateIndex(t *testing.T) {
 			},
 			false},
 		{"No fields", "CREATE INDEX idx ON test", nil, true},
+		{"Partial", "CREATE INDEX idx ON test (foo) WHERE bar = false AND baz > 10", &statement.CreateIndexStmt{
+			Info: database.IndexInfo{
+				IndexName: "idx", TableName: "test", Paths: []document.Path{document.Path(testutil.ParsePath(t, "foo"))},
+				Predicate: expr.Constraint(parser.MustParseExpr("bar = false AND baz > 10")),
+			}}, false},
+		{"Partial with param", "CREATE INDEX idx ON test (foo) WHERE bar = ?", nil, true},
+		{"Partial with aggregator", "CREATE INDEX idx ON test (foo) WHERE COUNT(bar) > 1", nil, true},
+		{"Partial with subquery", "CREATE INDEX idx ON test (foo) WHERE bar IN (SELECT a FROM b)", nil, true},
+		{"Partial without predicate", "CREATE INDEX idx ON test (foo) WHERE", nil, true},
+		{"Multi-key", "CREATE INDEX idx ON test (tags[*])", &statement.CreateIndexStmt{
+			Info: database.IndexInfo{
+				IndexName: "idx", TableName: "test", Paths: []document.Path{document.Path(testutil.ParsePath(t, "tags"))},
+				MultiKey: []bool{true},
+			}}, false},
+		{"Multi-key composite", "CREATE INDEX idx ON test (foo, a.tags[*])", &statement.CreateIndexStmt{
+			Info: database.IndexInfo{
+				IndexName: "idx", TableName: "test", Paths: []document.Path{document.Path(testutil.ParsePath(t, "foo")), document.Path(testutil.ParsePath(t, "a.tags"))},
+				MultiKey: []bool{false, true},
+			}}, false},
+		{"Multi-key twice", "CREATE INDEX idx ON test (tags[*], foo[*])", nil, true},
+		{"Orders", "CREATE INDEX idx ON test (foo DESC, bar ASC NULLS LAST, baz NULLS FIRST)", &statement.CreateIndexStmt{
+			Info: database.IndexInfo{
+				IndexName: "idx", TableName: "test", Paths: []document.Path{
+					document.Path(testutil.ParsePath(t, "foo")),
+					document.Path(testutil.ParsePath(t, "bar")),
+					document.Path(testutil.ParsePath(t, "baz")),
+				},
+				Orders: []database.IndexOrder{{Desc: true}, {NullsLast: true}, {NullsFirst: true}},
+			}}, false},
+		{"Orders with ASC only", "CREATE INDEX idx ON test (foo ASC)", &statement.CreateIndexStmt{
+			Info: database.IndexInfo{
+				IndexName: "idx", TableName: "test", Paths: []document.Path{document.Path(testutil.ParsePath(t, "foo"))},
+			}}, false},
+		{"Orders with multi-key", "CREATE INDEX idx ON test (tags[*] DESC)", &statement.CreateIndexStmt{
+			Info: database.IndexInfo{
+				IndexName: "idx", TableName: "test", Paths: []document.Path{document.Path(testutil.ParsePath(t, "tags"))},
+				MultiKey: []bool{true},
+				Orders:   []database.IndexOrder{{Desc: true}},
+			}}, false},
+		{"Orders with invalid NULLS", "CREATE INDEX idx ON test (foo NULLS)", nil, true},
+		{"Orders with DESC before multi-key", "CREATE INDEX idx ON test (tags DESC[*])", nil, true},
+		{"Multi-key unclosed", "CREATE INDEX idx ON test (tags[*)", nil, true},
+		{"Concurrently", "CREATE INDEX CONCURRENTLY idx ON test (foo)", query.CreateIndexConcurrentlyStmt{CreateIndexStmt: statement.CreateIndexStmt{
+			Info: database.IndexInfo{
+				IndexName: "idx", TableName: "test", Paths: []document.Path{document.Path(testutil.ParsePath(t, "foo"))},
+			}}}, false},
+		{"Concurrently unique", "CREATE UNIQUE INDEX CONCURRENTLY IF NOT EXISTS idx ON test (foo)", query.CreateIndexConcurrentlyStmt{CreateIndexStmt: statement.CreateIndexStmt{
+			Info: database.IndexInfo{
+				IndexName: "idx", TableName: "test", Paths: []document.Path{document.Path(testutil.ParsePath(t, "foo"))}, Unique: true,
+			}, IfNotExists: true}}, false},
+		{"Concurrently no name", "CREATE INDEX CONCURRENTLY ON test (foo)", query.CreateIndexConcurrentlyStmt{CreateIndexStmt: statement.CreateIndexStmt{
+			Info: database.IndexInfo{TableName: "test", Paths: []document.Path{document.Path(testutil.ParsePath(t, "foo"))}}}}, false},
+		{"Concurrently after name", "CREATE INDEX idx CONCURRENTLY ON test (foo)", nil, true},
 	}
 
 	for _, test := range tests {
@@ -450,3 +701,93 @@ func TestParserCreateSequence(t *testing.T) {
 		})
 	}
 }
+
+func TestParserCreateView(t *testing.T) {
+	tests := []struct {
+		name        string
+		s           string
+		viewName    string
+		query       string
+		relations   []string
+		replace     bool
+		ifNotExists bool
+		errored     bool
+	}{
+		{"Basic", "CREATE VIEW v AS SELECT * FROM foo", "v", "SELECT * FROM foo", []string{"foo"}, false, false, false},
+		{"If not exists", "CREATE VIEW IF NOT EXISTS v AS SELECT a FROM foo WHERE b > 1", "v", "SELECT a FROM foo WHERE b > 1", []string{"foo"}, false, true, false},
+		{"Or replace", "CREATE OR REPLACE VIEW v AS SELECT 1", "v", "SELECT 1", nil, true, false, false},
+		{"Multiline", "CREATE VIEW v AS\n  SELECT a\n  FROM foo ;", "v", "SELECT a\n  FROM foo", []string{"foo"}, false, false, false},
+		{"Relations", "CREATE VIEW v AS SELECT * FROM foo JOIN bar ON foo.a = bar.a WHERE foo.b IN (SELECT c FROM baz) UNION SELECT * FROM foo",
+			"v", "SELECT * FROM foo JOIN bar ON foo.a = bar.a WHERE foo.b IN (SELECT c FROM baz) UNION SELECT * FROM foo", []string{"foo", "bar", "baz"}, false, false, false},
+		{"With error / params", "CREATE VIEW v AS SELECT * FROM foo WHERE a = ?", "", "", nil, false, false, true},
+		{"With error / no select", "CREATE VIEW v AS INSERT INTO foo (a) VALUES (1)", "", "", nil, false, false, true},
+		{"With error / no AS", "CREATE VIEW v SELECT * FROM foo", "", "", nil, false, false, true},
+		{"With error / or replace if not exists", "CREATE OR REPLACE VIEW IF NOT EXISTS v AS SELECT 1", "", "", nil, false, false, true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			q, err := parser.ParseQuery(test.s)
+			if test.errored {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Len(t, q.Statements, 1)
+
+			stmt := q.Statements[0].(*statement.CreateViewStmt)
+			require.Equal(t, test.viewName, stmt.Info.ViewName)
+			require.Equal(t, test.query, stmt.Info.Query.String())
+			require.Equal(t, test.relations, stmt.Info.Query.Relations())
+			require.Equal(t, test.replace, stmt.OrReplace)
+			require.Equal(t, test.ifNotExists, stmt.IfNotExists)
+		})
+	}
+}
+
+func TestParserCreateTrigger(t *testing.T) {
+	tests := []struct {
+		name        string
+		s           string
+		triggerName string
+		tableName   string
+		timing      database.TriggerTiming
+		event       database.TriggerEvent
+		program     string
+		ifNotExists bool
+		errored     bool
+	}{
+		{"Basic", "CREATE TRIGGER tr AFTER INSERT ON foo BEGIN INSERT INTO bar (a) VALUES (new.a); END", "tr", "foo", database.TriggerAfter, database.TriggerInsert,
+			"BEGIN INSERT INTO bar (a) VALUES (new.a); END", false, false},
+		{"If not exists", "CREATE TRIGGER IF NOT EXISTS tr BEFORE DELETE ON foo FOR EACH ROW BEGIN DELETE FROM bar WHERE a = old.a; END", "tr", "foo", database.TriggerBefore, database.TriggerDelete,
+			"BEGIN DELETE FROM bar WHERE a = old.a; END", true, false},
+		{"When", "CREATE TRIGGER tr after update ON foo WHEN new.a != old.a BEGIN\n  UPDATE bar SET a = new.a WHERE a = old.a;\n  INSERT INTO baz (a) VALUES (old.a);\nend;", "tr", "foo", database.TriggerAfter, database.TriggerUpdate,
+			"WHEN new.a != old.a BEGIN\n  UPDATE bar SET a = new.a WHERE a = old.a;\n  INSERT INTO baz (a) VALUES (old.a);\nend", false, false},
+		{"With error / params", "CREATE TRIGGER tr AFTER INSERT ON foo BEGIN INSERT INTO bar (a) VALUES (?); END", "", "", 0, 0, "", false, true},
+		{"With error / select", "CREATE TRIGGER tr AFTER INSERT ON foo BEGIN SELECT * FROM bar; END", "", "", 0, 0, "", false, true},
+		{"With error / empty", "CREATE TRIGGER tr AFTER INSERT ON foo BEGIN END", "", "", 0, 0, "", false, true},
+		{"With error / missing semicolon", "CREATE TRIGGER tr AFTER INSERT ON foo BEGIN DELETE FROM bar END", "", "", 0, 0, "", false, true},
+		{"With error / timing", "CREATE TRIGGER tr INSERT ON foo BEGIN DELETE FROM bar; END", "", "", 0, 0, "", false, true},
+		{"With error / for each", "CREATE TRIGGER tr AFTER INSERT ON foo FOR EACH BEGIN DELETE FROM bar; END", "", "", 0, 0, "", false, true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			q, err := parser.ParseQuery(test.s)
+			if test.errored {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Len(t, q.Statements, 1)
+
+			stmt := q.Statements[0].(*statement.CreateTriggerStmt)
+			require.Equal(t, test.triggerName, stmt.Info.TriggerName)
+			require.Equal(t, test.tableName, stmt.Info.TableName)
+			require.Equal(t, test.timing, stmt.Info.Timing)
+			require.Equal(t, test.event, stmt.Info.Event)
+			require.Equal(t, test.program, stmt.Info.Program.String())
+			require.Equal(t, test.ifNotExists, stmt.IfNotExists)
+		})
+	}
+}
@@ -30,8 +30,8 @@ func (p *Parser) parseDeleteStatement() (*statement.StreamStmt, error) {
 		return nil, err
 	}
 
-	// Parse order by: "ORDER BY path [ASC|DESC]?"
-	stmt.OrderBy, stmt.OrderByDirection, err = p.parseOrderBy()
+	// Parse order by: "ORDER BY expr [ASC|DESC]?, ..."
+	stmt.OrderBy, err = p.parseOrderBy()
 	if err != nil {
 		return nil, err
 	}
@@ -58,3 +58,40 @@ func TestParserDelete(t *testing.T) {
 		})
 	}
 }
+
+func TestParserPurge(t *testing.T) {
+	purge := func() *stream.TableDeleteOperator {
+		op := stream.TableDelete("test")
+		op.Purge = true
+		return op
+	}
+
+	tests := []struct {
+		name     string
+		s        string
+		expected *stream.Stream
+		fails    bool
+	}{
+		{"NoCond", "PURGE test", stream.New(&stream.SeqScanOperator{TableName: "test", WithDeleted: true}).Pipe(purge()), false},
+		{"WithCond", "PURGE test WHERE age = 10",
+			stream.New(&stream.SeqScanOperator{TableName: "test", WithDeleted: true}).
+				Pipe(stream.Filter(parser.MustParseExpr("age = 10"))).
+				Pipe(purge()),
+			false,
+		},
+		{"NoTable", "PURGE", nil, true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			q, err := parser.ParseQuery(test.s)
+			if test.fails {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Len(t, q.Statements, 1)
+			require.EqualValues(t, &statement.StreamStmt{Stream: test.expected}, q.Statements[0].(*statement.StreamStmt))
+		})
+	}
+}
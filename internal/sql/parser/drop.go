@@ -16,9 +16,19 @@ func (p *Parser) parseDropStatement() (statement.Statement, error) {
 		return p.parseDropIndexStatement()
 	case scanner.SEQUENCE:
 		return p.parseDropSequenceStatement()
+	case scanner.IDENT:
+		if isRoleKeyword(tok, lit) {
+			return p.parseDropRoleStatement()
+		}
+		if isViewKeyword(tok, lit) {
+			return p.parseDropViewStatement()
+		}
+		if isTriggerKeyword(tok, lit) {
+			return p.parseDropTriggerStatement()
+		}
 	}
 
-	return nil, newParseError(scanner.Tokstr(tok, lit), []string{"TABLE", "INDEX", "SEQUENCE"}, pos)
+	return nil, newParseError(scanner.Tokstr(tok, lit), []string{"TABLE", "INDEX", "SEQUENCE", "USER", "ROLE", "VIEW", "TRIGGER"}, pos)
 }
 
 // parseDropTableStatement parses a drop table string and returns a Statement AST object.
@@ -40,6 +50,7 @@ func (p *Parser) parseDropTableStatement() (statement.DropTableStmt, error) {
 		return stmt, pErr
 	}
 
+	stmt.Cascade = p.parseDropBehavior()
 	return stmt, nil
 }
 
@@ -17,10 +17,17 @@ func TestParserDrop(t *testing.T) {
 	}{
 		{"Drop table", "DROP TABLE test", statement.DropTableStmt{TableName: "test"}, false},
 		{"Drop table If not exists", "DROP TABLE IF EXISTS test", statement.DropTableStmt{TableName: "test", IfExists: true}, false},
+		{"Drop table cascade", "DROP TABLE test CASCADE", statement.DropTableStmt{TableName: "test", Cascade: true}, false},
+		{"Drop table restrict", "DROP TABLE test RESTRICT", statement.DropTableStmt{TableName: "test"}, false},
 		{"Drop index", "DROP INDEX test", statement.DropIndexStmt{IndexName: "test"}, false},
 		{"Drop index if exists", "DROP INDEX IF EXISTS test", statement.DropIndexStmt{IndexName: "test", IfExists: true}, false},
 		{"Drop index", "DROP SEQUENCE test", statement.DropSequenceStmt{SequenceName: "test"}, false},
 		{"Drop index if exists", "DROP SEQUENCE IF EXISTS test", statement.DropSequenceStmt{SequenceName: "test", IfExists: true}, false},
+		{"Drop view", "DROP VIEW test", statement.DropViewStmt{ViewName: "test"}, false},
+		{"Drop view if exists cascade", "DROP VIEW IF EXISTS test CASCADE", statement.DropViewStmt{ViewName: "test", IfExists: true, Cascade: true}, false},
+		{"Drop view with error", "DROP VIEW", nil, true},
+		{"Drop trigger", "DROP TRIGGER test", statement.DropTriggerStmt{TriggerName: "test"}, false},
+		{"Drop trigger if exists", "DROP TRIGGER IF EXISTS test", statement.DropTriggerStmt{TriggerName: "test", IfExists: true}, false},
 	}
 
 	for _, test := range tests {
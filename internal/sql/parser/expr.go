@@ -2,12 +2,15 @@ package parser
 
 import (
 	"strconv"
+	"strings"
 
 	"github.com/genjidb/genji/document"
 	"github.com/genjidb/genji/internal/environment"
 	"github.com/genjidb/genji/internal/expr"
 	"github.com/genjidb/genji/internal/expr/functions"
+	"github.com/genjidb/genji/internal/query/statement"
 	"github.com/genjidb/genji/internal/sql/scanner"
+	"github.com/genjidb/genji/internal/stream"
 	"github.com/genjidb/genji/internal/stringutil"
 )
 
@@ -57,7 +60,19 @@ func (p *Parser) parseExprWithMinPrecedence(precedence int, allowed ...scanner.T
 
 		var rhs expr.Expr
 
-		if rhs, err = p.parseUnaryExpr(allowed...); err != nil {
+		// comparison operators can be followed by ANY, SOME or ALL,
+		// e.g. a > ALL (SELECT b FROM foo)
+		quantified, err := p.parseQuantifier(tok)
+		if err != nil {
+			return nil, err
+		}
+		if quantified != nil {
+			op = quantified
+			rhs, err = p.parseQuantifiedOperand()
+		} else {
+			rhs, err = p.parseUnaryExpr(allowed...)
+		}
+		if err != nil {
 			return nil, err
 		}
 
@@ -74,6 +89,11 @@ func (p *Parser) parseExprWithMinPrecedence(precedence int, allowed ...scanner.T
 			}
 			node = p
 		}
+
+		// LIKE and ILIKE patterns can be followed by an ESCAPE clause.
+		if err = p.parseEscapeClause(root); err != nil {
+			return nil, err
+		}
 	}
 }
 
@@ -100,13 +120,15 @@ func (p *Parser) parseOperator(minPrecedence int, allowed ...scanner.Token) (fun
 		if tok.Precedence() >= minPrecedence {
 			switch {
 			case tok == scanner.IN && tok.Precedence() >= minPrecedence:
-				return expr.NotIn, op, nil
+				return inSubquery(expr.NotIn), op, nil
 			case tok == scanner.LIKE && tok.Precedence() >= minPrecedence:
 				return expr.NotLike, op, nil
+			case tok == scanner.ILIKE && tok.Precedence() >= minPrecedence:
+				return expr.NotILike, op, nil
 			}
 		}
 
-		return nil, 0, newParseError(scanner.Tokstr(tok, lit), []string{"IN, LIKE"}, pos)
+		return nil, 0, newParseError(scanner.Tokstr(tok, lit), []string{"IN, LIKE, ILIKE"}, pos)
 	}
 
 	if op.Precedence() < minPrecedence {
@@ -147,8 +169,14 @@ func (p *Parser) parseOperator(minPrecedence int, allowed ...scanner.Token) (fun
 		return expr.BitwiseOr, op, nil
 	case scanner.BITWISEXOR:
 		return expr.BitwiseXor, op, nil
+	case scanner.SHIFTLEFT:
+		return expr.ShiftLeft, op, nil
+	case scanner.SHIFTRIGHT:
+		return expr.ShiftRight, op, nil
 	case scanner.IN:
-		return expr.In, op, nil
+		return inSubquery(expr.In), op, nil
+	case scanner.CONTAINS:
+		return expr.Contains, op, nil
 	case scanner.IS:
 		if tok, _, _ := p.ScanIgnoreWhitespace(); tok == scanner.NOT {
 			return expr.IsNot, op, nil
@@ -157,6 +185,8 @@ func (p *Parser) parseOperator(minPrecedence int, allowed ...scanner.Token) (fun
 		return expr.Is, op, nil
 	case scanner.LIKE:
 		return expr.Like, op, nil
+	case scanner.ILIKE:
+		return expr.ILike, op, nil
 	case scanner.CONCAT:
 		return expr.Concat, op, nil
 	case scanner.BETWEEN:
@@ -177,6 +207,90 @@ func (p *Parser) parseOperator(minPrecedence int, allowed ...scanner.Token) (fun
 	return nil, 0, nil
 }
 
+// parseQuantifier parses an optional ANY, SOME or ALL keyword following the
+// comparison operator tok and returns the constructor of the quantified operator.
+// It returns nil if there is no quantifier.
+func (p *Parser) parseQuantifier(tok scanner.Token) (func(lhs, rhs expr.Expr) expr.Expr, error) {
+	switch tok {
+	case scanner.EQ, scanner.NEQ, scanner.GT, scanner.GTE, scanner.LT, scanner.LTE:
+	default:
+		return nil, nil
+	}
+
+	switch q, _, _ := p.ScanIgnoreWhitespace(); q {
+	case scanner.ANY, scanner.SOME:
+		return expr.Any(tok), nil
+	case scanner.ALL:
+		return expr.All(tok), nil
+	}
+
+	p.Unscan()
+	return nil, nil
+}
+
+// parseQuantifiedOperand parses the right operand of a quantified comparison,
+// which is either a subquery or an expression evaluating to an array.
+func (p *Parser) parseQuantifiedOperand() (expr.Expr, error) {
+	stmt, err := p.parseSubquery()
+	if err != nil {
+		return nil, err
+	}
+	if stmt != nil {
+		return &statement.Subquery{Stmt: stmt}, nil
+	}
+
+	return p.parseUnaryExpr()
+}
+
+// inSubquery returns the constructor of an IN or NOT IN operator
+// whose right operand, if it is a subquery, evaluates to the list of selected values,
+// e.g. a IN (SELECT b FROM foo).
+func inSubquery(op func(lhs, rhs expr.Expr) expr.Expr) func(lhs, rhs expr.Expr) expr.Expr {
+	return func(lhs, rhs expr.Expr) expr.Expr {
+		if sq, ok := rhs.(*statement.ScalarSubquery); ok {
+			rhs = &statement.Subquery{Stmt: sq.Stmt}
+		}
+
+		return op(lhs, rhs)
+	}
+}
+
+// parseEscapeClause parses an optional ESCAPE clause and assigns it
+// to the last LIKE or ILIKE operator whose pattern was parsed.
+// ESCAPE is not a keyword, to allow using it as an identifier.
+func (p *Parser) parseEscapeClause(root expr.Operator) error {
+	tok, pos, lit := p.ScanIgnoreWhitespace()
+	if tok != scanner.IDENT || !strings.EqualFold(lit, "escape") {
+		p.Unscan()
+		return nil
+	}
+
+	// the pattern of the last LIKE operator is always on the right side of the tree
+	var like *expr.LikeOperator
+	for node := root; node != nil; {
+		switch t := node.(type) {
+		case *expr.LikeOperator:
+			like = t
+		case *expr.NotLikeOperator:
+			like = &t.LikeOperator
+		}
+
+		node, _ = node.RightHand().(expr.Operator)
+	}
+
+	if like == nil || like.Escape != nil {
+		return &ParseError{Message: "ESCAPE must follow a LIKE or ILIKE pattern", Pos: pos}
+	}
+
+	esc, err := p.parseUnaryExpr()
+	if err != nil {
+		return err
+	}
+	like.SetEscape(esc)
+
+	return nil
+}
+
 // parseUnaryExpr parses an non-binary expression.
 func (p *Parser) parseUnaryExpr(allowed ...scanner.Token) (expr.Expr, error) {
 	tok, pos, lit := p.ScanIgnoreWhitespace()
@@ -191,6 +305,11 @@ func (p *Parser) parseUnaryExpr(allowed ...scanner.Token) (expr.Expr, error) {
 		p.Unscan()
 		return p.parseCastExpression()
 	case scanner.IDENT:
+		// a type name followed by a string is a typed literal, i.e. DATE '2023-01-01'
+		if e, err := p.parseTypedLiteral(lit); e != nil || err != nil {
+			return e, err
+		}
+
 		tok1, _, _ := p.Scan()
 		// if the next token is a left parenthesis, this is a global function
 		if tok1 == scanner.LPAREN {
@@ -222,23 +341,19 @@ func (p *Parser) parseUnaryExpr(allowed ...scanner.Token) (expr.Expr, error) {
 		if err != nil {
 			return nil, err
 		}
+		if p.inTrigger {
+			// NEW.a and new.a both refer to the document being written
+			switch name := field[0].FieldName; {
+			case strings.EqualFold(name, stream.NewVar):
+				field[0].FieldName = stream.NewVar
+			case strings.EqualFold(name, stream.OldVar):
+				field[0].FieldName = stream.OldVar
+			}
+		}
 		fs := expr.Path(field)
 		return fs, nil
-	case scanner.NAMEDPARAM:
-		if len(lit) == 1 {
-			return nil, &ParseError{Message: "missing param name"}
-		}
-		if p.orderedParams > 0 {
-			return nil, &ParseError{Message: "cannot mix positional arguments with named arguments"}
-		}
-		p.namedParams++
-		return expr.NamedParam(lit[1:]), nil
-	case scanner.POSITIONALPARAM:
-		if p.namedParams > 0 {
-			return nil, &ParseError{Message: "cannot mix positional arguments with named arguments"}
-		}
-		p.orderedParams++
-		return expr.PositionalParam(p.orderedParams), nil
+	case scanner.NAMEDPARAM, scanner.COLON, scanner.POSITIONALPARAM:
+		return p.parseParamToken(tok, lit)
 	case scanner.STRING:
 		return expr.LiteralValue(document.NewTextValue(lit)), nil
 	case scanner.NUMBER:
@@ -269,6 +384,16 @@ func (p *Parser) parseUnaryExpr(allowed ...scanner.Token) (expr.Expr, error) {
 		p.Unscan()
 		return p.parseExprList(scanner.LSBRACKET, scanner.RSBRACKET)
 	case scanner.LPAREN:
+		p.Unscan()
+		stmt, err := p.parseSubquery()
+		if err != nil {
+			return nil, err
+		}
+		if stmt != nil {
+			return &statement.ScalarSubquery{Stmt: stmt}, nil
+		}
+		p.ScanIgnoreWhitespace()
+
 		e, err := p.ParseExpr()
 		if err != nil {
 			return nil, err
@@ -296,6 +421,16 @@ func (p *Parser) parseUnaryExpr(allowed ...scanner.Token) (expr.Expr, error) {
 			return nil, err
 		}
 		return expr.Not(e), nil
+	case scanner.VALUES:
+		// VALUES is a keyword but also the name of a builtin function
+		if tok1, _, _ := p.Scan(); tok1 == scanner.LPAREN {
+			p.Unscan()
+			p.Unscan()
+			return p.parseFunction()
+		}
+		p.Unscan()
+
+		return nil, newParseError(scanner.Tokstr(tok, lit), nil, pos)
 	case scanner.NEXT:
 		err := p.parseTokens(scanner.VALUE, scanner.FOR)
 		if err != nil {
@@ -364,18 +499,25 @@ func (p *Parser) parseIdentList() ([]string, error) {
 // parseParam parses a positional or named param.
 func (p *Parser) parseParam() (expr.Expr, error) {
 	tok, _, lit := p.ScanIgnoreWhitespace()
+	return p.parseParamToken(tok, lit)
+}
+
+// parseParamToken parses the param starting with the given token:
+// ? for positional params, $name or :name for named params.
+// It returns nil if the token doesn't start a param.
+func (p *Parser) parseParamToken(tok scanner.Token, lit string) (expr.Expr, error) {
+	var name string
+
 	switch tok {
 	case scanner.NAMEDPARAM:
-		if len(lit) == 1 {
-			return nil, &ParseError{Message: "missing param name"}
-		}
-		if p.orderedParams > 0 {
-			return nil, &ParseError{Message: "cannot mix positional arguments with named arguments"}
+		name = lit[1:]
+	case scanner.COLON:
+		// the name must immediately follow the colon
+		if tok, _, lit := p.Scan(); tok == scanner.IDENT {
+			name = lit
 		}
-		p.namedParams++
-		return expr.NamedParam(lit[1:]), nil
 	case scanner.POSITIONALPARAM:
-		if p.namedParams > 0 {
+		if len(p.namedParams) > 0 {
 			return nil, &ParseError{Message: "cannot mix positional arguments with named arguments"}
 		}
 		p.orderedParams++
@@ -383,53 +525,202 @@ func (p *Parser) parseParam() (expr.Expr, error) {
 	default:
 		return nil, nil
 	}
+
+	if name == "" {
+		return nil, &ParseError{Message: "missing param name"}
+	}
+	if p.orderedParams > 0 {
+		return nil, &ParseError{Message: "cannot mix positional arguments with named arguments"}
+	}
+	for _, n := range p.namedParams {
+		if n == name {
+			return expr.NamedParam(name), nil
+		}
+	}
+	p.namedParams = append(p.namedParams, name)
+	return expr.NamedParam(name), nil
+}
+
+// parseTypedLiteral parses the string following the name of a type to build a literal of that type,
+// i.e. DATE '2023-01-01', TIMESTAMP '2023-01-01T10:00:00Z', INTERVAL '1 day', DECIMAL '10.50'
+// or UUID '123e4567-e89b-12d3-a456-426614174000'.
+// Most of these type names are not keywords, to allow using them as field or function names,
+// so it returns nil if name is not one of these types or is not followed by a string.
+func (p *Parser) parseTypedLiteral(name string) (expr.Expr, error) {
+	var cast func(v document.Value) (document.Value, error)
+	switch strings.ToLower(name) {
+	case "date":
+		cast = document.Value.CastAsDate
+	case "timestamp":
+		cast = document.Value.CastAsTimestamp
+	case "interval":
+		cast = document.Value.CastAsInterval
+	case "decimal", "numeric":
+		cast = document.Value.CastAsDecimal
+	case "uuid":
+		cast = document.Value.CastAsUUID
+	default:
+		return nil, nil
+	}
+
+	n := 1
+	tok, pos, lit := p.Scan()
+	if tok == scanner.WS {
+		n++
+		tok, pos, lit = p.Scan()
+	}
+	if tok != scanner.STRING {
+		for i := 0; i < n; i++ {
+			p.Unscan()
+		}
+		return nil, nil
+	}
+
+	v, err := cast(document.NewTextValue(lit))
+	if err != nil {
+		return nil, &ParseError{Message: err.Error(), Pos: pos}
+	}
+
+	return expr.LiteralValue(v), nil
 }
 
+// parseType parses a type name and returns the corresponding value type.
+// The size of sized types, i.e. VARCHAR(255), is ignored.
 func (p *Parser) parseType() (document.ValueType, error) {
+	tp, _, err := p.parseSizedType()
+	return tp, err
+}
+
+// parseSizedType parses a type name and returns the corresponding value type,
+// alongside the size given between parentheses, if any.
+// If no size was specified, the returned size is 0.
+func (p *Parser) parseSizedType() (document.ValueType, int, error) {
 	tok, pos, lit := p.ScanIgnoreWhitespace()
 	switch tok {
 	case scanner.TYPEARRAY:
-		return document.ArrayValue, nil
+		return document.ArrayValue, 0, nil
 	case scanner.TYPEBLOB:
-		return document.BlobValue, nil
+		return document.BlobValue, 0, nil
 	case scanner.TYPEBOOL:
-		return document.BoolValue, nil
+		return document.BoolValue, 0, nil
 	case scanner.TYPEBYTES:
-		return document.BlobValue, nil
+		return document.BlobValue, 0, nil
 	case scanner.TYPEDOCUMENT:
-		return document.DocumentValue, nil
+		return document.DocumentValue, 0, nil
 	case scanner.TYPEREAL:
-		return document.DoubleValue, nil
+		return document.DoubleValue, 0, nil
 	case scanner.TYPEDOUBLE:
 		tok, _, _ := p.ScanIgnoreWhitespace()
 		if tok == scanner.PRECISION {
-			return document.DoubleValue, nil
+			return document.DoubleValue, 0, nil
 		}
 		p.Unscan()
-		return document.DoubleValue, nil
+		return document.DoubleValue, 0, nil
 	case scanner.TYPEINTEGER, scanner.TYPEINT, scanner.TYPEINT2, scanner.TYPEINT8, scanner.TYPETINYINT,
 		scanner.TYPEBIGINT, scanner.TYPEMEDIUMINT, scanner.TYPESMALLINT:
-		return document.IntegerValue, nil
+		return document.IntegerValue, 0, nil
 	case scanner.TYPETEXT:
-		return document.TextValue, nil
+		return document.TextValue, 0, nil
 	case scanner.TYPEVARCHAR, scanner.TYPECHARACTER:
 		if tok, pos, lit := p.ScanIgnoreWhitespace(); tok != scanner.LPAREN {
-			return 0, newParseError(scanner.Tokstr(tok, lit), []string{"("}, pos)
+			return 0, 0, newParseError(scanner.Tokstr(tok, lit), []string{"("}, pos)
 		}
+		p.Unscan()
 
-		// The value between parentheses is not used.
-		if tok, pos, lit := p.ScanIgnoreWhitespace(); tok != scanner.INTEGER {
-			return 0, newParseError(scanner.Tokstr(tok, lit), []string{"integer"}, pos)
+		size, err := p.parseTypeSize()
+		return document.TextValue, size, err
+	case scanner.IDENT:
+		// these type names are not keywords, to allow using them as field or function names.
+		switch strings.ToLower(lit) {
+		case "date":
+			return document.DateValue, 0, nil
+		case "timestamp":
+			return document.TimestampValue, 0, nil
+		case "interval":
+			return document.IntervalValue, 0, nil
+		case "decimal", "numeric":
+			return document.DecimalValue, 0, nil
+		case "vector":
+			// the dimension of the vector is optional
+			if tok, _, _ := p.ScanIgnoreWhitespace(); tok != scanner.LPAREN {
+				p.Unscan()
+				return document.VectorValue, 0, nil
+			}
+			p.Unscan()
+
+			size, err := p.parseTypeSize()
+			return document.VectorValue, size, err
+		case "uuid":
+			return document.UUIDValue, 0, nil
 		}
+	}
+
+	return 0, 0, newParseError(scanner.Tokstr(tok, lit), []string{"type"}, pos)
+}
+
+// parseTypeSize parses a strictly positive integer between parentheses.
+func (p *Parser) parseTypeSize() (int, error) {
+	if err := p.parseTokens(scanner.LPAREN); err != nil {
+		return 0, err
+	}
 
-		if tok, pos, lit := p.ScanIgnoreWhitespace(); tok != scanner.RPAREN {
-			return 0, newParseError(scanner.Tokstr(tok, lit), []string{")"}, pos)
+	tok, pos, lit := p.ScanIgnoreWhitespace()
+	if tok != scanner.INTEGER {
+		return 0, newParseError(scanner.Tokstr(tok, lit), []string{"integer"}, pos)
+	}
+
+	size, err := strconv.Atoi(lit)
+	if err != nil || size <= 0 {
+		return 0, &ParseError{Message: stringutil.Sprintf("invalid type size %s", lit), Pos: pos}
+	}
+
+	if err := p.parseTokens(scanner.RPAREN); err != nil {
+		return 0, err
+	}
+
+	return size, nil
+}
+
+// parseDecimalPrecision parses the optional precision and scale of a decimal type,
+// i.e. (10, 2) or (10). If no scale is specified, it is 0.
+func (p *Parser) parseDecimalPrecision() (precision int, scale int, err error) {
+	if tok, _, _ := p.ScanIgnoreWhitespace(); tok != scanner.LPAREN {
+		p.Unscan()
+		return 0, 0, nil
+	}
+	p.Unscan()
+
+	if err := p.parseTokens(scanner.LPAREN); err != nil {
+		return 0, 0, err
+	}
+
+	tok, pos, lit := p.ScanIgnoreWhitespace()
+	if tok != scanner.INTEGER {
+		return 0, 0, newParseError(scanner.Tokstr(tok, lit), []string{"integer"}, pos)
+	}
+	precision, err = strconv.Atoi(lit)
+	if err != nil || precision <= 0 {
+		return 0, 0, &ParseError{Message: stringutil.Sprintf("invalid decimal precision %s", lit), Pos: pos}
+	}
+
+	if tok, _, _ := p.ScanIgnoreWhitespace(); tok == scanner.COMMA {
+		tok, pos, lit := p.ScanIgnoreWhitespace()
+		if tok != scanner.INTEGER {
+			return 0, 0, newParseError(scanner.Tokstr(tok, lit), []string{"integer"}, pos)
+		}
+		scale, err = strconv.Atoi(lit)
+		if err != nil || scale > precision {
+			return 0, 0, &ParseError{Message: stringutil.Sprintf("invalid decimal scale %s", lit), Pos: pos}
 		}
+	} else {
+		p.Unscan()
+	}
 
-		return document.TextValue, nil
+	if err := p.parseTokens(scanner.RPAREN); err != nil {
+		return 0, 0, err
 	}
 
-	return 0, newParseError(scanner.Tokstr(tok, lit), []string{"type"}, pos)
+	return precision, scale, nil
 }
 
 // ParseDocument parses a document
@@ -532,6 +823,12 @@ LOOP:
 		case scanner.LSBRACKET:
 			// scan the next token for an integer
 			tok, pos, lit := p.Scan()
+			if tok == scanner.MUL {
+				// [*] is not part of the path, i.e. multi-key index paths
+				p.Unscan()
+				p.Unscan()
+				break LOOP
+			}
 			if tok != scanner.INTEGER || lit[0] == '-' {
 				return nil, newParseError(lit, []string{"array index"}, pos)
 			}
@@ -596,11 +893,94 @@ func (p *Parser) parseExprList(leftToken, rightToken scanner.Token) (expr.Litera
 // a function is an identifier followed by a parenthesis,
 // an optional coma-separated list of expressions and a closing parenthesis.
 func (p *Parser) parseFunction() (expr.Expr, error) {
-	// Parse function name.
-	funcName, err := p.parseIdent()
+	f, err := p.parseFunctionCall()
+	if err != nil {
+		return nil, err
+	}
+
+	// Parse optional OVER clause.
+	// OVER is not a keyword, to allow using it as an identifier.
+	if tok, pos, lit := p.ScanIgnoreWhitespace(); tok == scanner.IDENT && strings.EqualFold(lit, "over") {
+		switch f.(type) {
+		case expr.WindowFunc, expr.AggregatorBuilder:
+		default:
+			return nil, &ParseError{Message: stringutil.Sprintf("OVER is not allowed in %s", f), Pos: pos}
+		}
+
+		w, err := p.parseWindowSpec()
+		if err != nil {
+			return nil, err
+		}
+
+		return &expr.WindowExpr{Func: f, Window: *w}, nil
+	}
+	p.Unscan()
+
+	return f, nil
+}
+
+// parseWindowSpec parses a window definition of the form ([PARTITION BY expr, ...] [ORDER BY expr [ASC|DESC], ...]).
+func (p *Parser) parseWindowSpec() (*expr.WindowSpec, error) {
+	if err := p.parseTokens(scanner.LPAREN); err != nil {
+		return nil, err
+	}
+
+	var w expr.WindowSpec
+
+	// PARTITION is not a keyword either, it is only expected at the start of the window.
+	if tok, _, lit := p.ScanIgnoreWhitespace(); tok == scanner.IDENT && strings.EqualFold(lit, "partition") {
+		if err := p.parseTokens(scanner.BY); err != nil {
+			return nil, err
+		}
+
+		for {
+			e, err := p.ParseExpr()
+			if err != nil {
+				return nil, err
+			}
+			w.PartitionBy = append(w.PartitionBy, e)
+
+			if tok, _, _ := p.ScanIgnoreWhitespace(); tok != scanner.COMMA {
+				p.Unscan()
+				break
+			}
+		}
+	} else {
+		p.Unscan()
+	}
+
+	ok, err := p.parseOptional(scanner.ORDER, scanner.BY)
 	if err != nil {
 		return nil, err
 	}
+	if ok {
+		w.OrderBy, err = p.parseOrderTerms()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if err := p.parseTokens(scanner.RPAREN); err != nil {
+		return nil, err
+	}
+
+	return &w, nil
+}
+
+// parseFunctionCall parses a function call, without its OVER clause.
+func (p *Parser) parseFunctionCall() (expr.Expr, error) {
+	// Parse function name.
+	var funcName string
+	var err error
+	if tok, _, _ := p.ScanIgnoreWhitespace(); tok == scanner.VALUES {
+		funcName = "values"
+	} else {
+		p.Unscan()
+		funcName, err = p.parseIdent()
+		if err != nil {
+			return nil, err
+		}
+	}
 
 	// Parse optional package name
 	var pkgName string
@@ -619,6 +999,41 @@ func (p *Parser) parseFunction() (expr.Expr, error) {
 		return nil, err
 	}
 
+	// Special case: EXTRACT(unit FROM expr)
+	if pkgName == "" && strings.EqualFold(funcName, "extract") {
+		return p.parseExtract()
+	}
+
+	// Aggregate functions can be called with the DISTINCT qualifier, e.g. COUNT(DISTINCT a)
+	if tok, pos, _ := p.ScanIgnoreWhitespace(); tok == scanner.DISTINCT {
+		e, err := p.ParseExpr()
+		if err != nil {
+			return nil, err
+		}
+
+		if err := p.parseTokens(scanner.RPAREN); err != nil {
+			return nil, err
+		}
+
+		def, err := p.packagesTable.GetFunc(pkgName, funcName)
+		if err != nil {
+			return nil, err
+		}
+		f, err := def.Function(e)
+		if err != nil {
+			return nil, err
+		}
+
+		agg, ok := f.(functions.DistinctAggregatorBuilder)
+		if !ok {
+			return nil, &ParseError{Message: stringutil.Sprintf("DISTINCT is not allowed in %s()", def.Name()), Pos: pos}
+		}
+		agg.SetDistinct()
+
+		return agg, nil
+	}
+	p.Unscan()
+
 	// Special case: If the function is COUNT, support the special case COUNT(*)
 	if tok, pos, lit := p.ScanIgnoreWhitespace(); tok == scanner.MUL {
 		if tok, _, _ := p.ScanIgnoreWhitespace(); tok != scanner.RPAREN {
@@ -668,6 +1083,35 @@ func (p *Parser) parseFunction() (expr.Expr, error) {
 	return def.Function(exprs...)
 }
 
+// parseExtract parses the arguments of EXTRACT(unit FROM expr).
+// This function assumes the EXTRACT and ( tokens have already been consumed.
+func (p *Parser) parseExtract() (expr.Expr, error) {
+	tok, pos, lit := p.ScanIgnoreWhitespace()
+	if tok != scanner.IDENT {
+		return nil, newParseError(scanner.Tokstr(tok, lit), []string{"unit"}, pos)
+	}
+
+	if err := p.parseTokens(scanner.FROM); err != nil {
+		return nil, err
+	}
+
+	e, err := p.ParseExpr()
+	if err != nil {
+		return nil, err
+	}
+
+	if err := p.parseTokens(scanner.RPAREN); err != nil {
+		return nil, err
+	}
+
+	f, err := functions.NewExtract(lit, e)
+	if err != nil {
+		return nil, &ParseError{Message: err.Error(), Pos: pos}
+	}
+
+	return f, nil
+}
+
 // parseCastExpression parses a string of the form CAST(expr AS type).
 func (p *Parser) parseCastExpression() (expr.Expr, error) {
 	// Parse required CAST and ( tokens.
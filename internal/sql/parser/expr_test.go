@@ -3,11 +3,13 @@ package parser_test
 import (
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/genjidb/genji/document"
 	"github.com/genjidb/genji/internal/expr"
 	"github.com/genjidb/genji/internal/expr/functions"
 	"github.com/genjidb/genji/internal/sql/parser"
+	"github.com/genjidb/genji/internal/sql/scanner"
 	"github.com/genjidb/genji/internal/testutil"
 	"github.com/stretchr/testify/require"
 )
@@ -40,6 +42,22 @@ func TestParserExpr(t *testing.T) {
 		{"double quoted string", `"10.0"`, testutil.TextValue("10.0"), false},
 		{"single quoted string", "'-10.0'", testutil.TextValue("-10.0"), false},
 
+		// dates and timestamps
+		{"date", "DATE '2023-01-02'", expr.LiteralValue(document.NewDateValue(time.Date(2023, 1, 2, 0, 0, 0, 0, time.UTC))), false},
+		{"timestamp", "TIMESTAMP '2023-01-02T10:30:00+02:00'", expr.LiteralValue(document.NewTimestampValue(time.Date(2023, 1, 2, 8, 30, 0, 0, time.UTC))), false},
+		{"timestamp without time zone", `TIMESTAMP "2023-01-02 10:30:00"`, expr.LiteralValue(document.NewTimestampValue(time.Date(2023, 1, 2, 10, 30, 0, 0, time.UTC))), false},
+		{"invalid date", "DATE 'foo'", nil, true},
+		{"date field", "date", expr.Path(testutil.ParsePath(t, "date")), false},
+		{"timestamp field", "timestamp", expr.Path(testutil.ParsePath(t, "timestamp")), false},
+		{"interval", "INTERVAL '1 year 2 days 03:00:00'", expr.LiteralValue(document.NewIntervalValue(document.Interval{Months: 12, Days: 2, Nanos: int64(3 * time.Hour)})), false},
+		{"invalid interval", "INTERVAL '1 fortnight'", nil, true},
+		{"decimal", "DECIMAL '10.50'", expr.LiteralValue(document.NewDecimalValue(document.NewDecimal(1050, 2))), false},
+		{"numeric", "NUMERIC '-1e3'", expr.LiteralValue(document.NewDecimalValue(document.NewDecimal(-1000, 0))), false},
+		{"invalid decimal", "DECIMAL '1.2.3'", nil, true},
+		{"uuid", "UUID '018b2c3d-4e5f-7081-92a3-b4c5d6e7f809'", expr.LiteralValue(document.NewUUIDValue(document.UUID{0x01, 0x8b, 0x2c, 0x3d, 0x4e, 0x5f, 0x70, 0x81, 0x92, 0xa3, 0xb4, 0xc5, 0xd6, 0xe7, 0xf8, 0x09})), false},
+		{"invalid uuid", "uuid 'foo'", nil, true},
+		{"uuid field", "uuid", expr.Path(testutil.ParsePath(t, "uuid")), false},
+
 		// documents
 		{"empty document", `{}`, &expr.KVPairs{SelfReferenced: true}, false},
 		{"document values", `{a: 1, b: 1.0, c: true, d: 'string', e: "string", f: {foo: 'bar'}, g: h.i.j, k: [1, 2, 3]}`,
@@ -117,6 +135,9 @@ func TestParserExpr(t *testing.T) {
 		{"/", "age / 10", expr.Div(testutil.ParsePath(t, "age"), testutil.IntegerValue(10)), false},
 		{"%", "age % 10", expr.Mod(testutil.ParsePath(t, "age"), testutil.IntegerValue(10)), false},
 		{"&", "age & 10", expr.BitwiseAnd(testutil.ParsePath(t, "age"), testutil.IntegerValue(10)), false},
+		{"<<", "age << 2", expr.ShiftLeft(testutil.ParsePath(t, "age"), testutil.IntegerValue(2)), false},
+		{">>", "age >> 2", expr.ShiftRight(testutil.ParsePath(t, "age"), testutil.IntegerValue(2)), false},
+		{"<< precedence", "1 + 1 << 2", expr.ShiftLeft(expr.Add(testutil.IntegerValue(1), testutil.IntegerValue(1)), testutil.IntegerValue(2)), false},
 		{"||", "name || 'foo'", expr.Concat(testutil.ParsePath(t, "name"), testutil.TextValue("foo")), false},
 		{"IN", "age IN ages", expr.In(testutil.ParsePath(t, "age"), testutil.ParsePath(t, "ages")), false},
 		{"NOT IN", "age NOT IN ages", expr.NotIn(testutil.ParsePath(t, "age"), testutil.ParsePath(t, "ages")), false},
@@ -124,6 +145,33 @@ func TestParserExpr(t *testing.T) {
 		{"IS NOT", "age IS NOT NULL", expr.IsNot(testutil.ParsePath(t, "age"), testutil.NullValue()), false},
 		{"LIKE", "name LIKE 'foo'", expr.Like(testutil.ParsePath(t, "name"), testutil.TextValue("foo")), false},
 		{"NOT LIKE", "name NOT LIKE 'foo'", expr.NotLike(testutil.ParsePath(t, "name"), testutil.TextValue("foo")), false},
+		{"ILIKE", "name ILIKE 'foo'", expr.ILike(testutil.ParsePath(t, "name"), testutil.TextValue("foo")), false},
+		{"NOT ILIKE", "name NOT ILIKE 'foo'", expr.NotILike(testutil.ParsePath(t, "name"), testutil.TextValue("foo")), false},
+		{"LIKE ESCAPE", "name LIKE 'foo!%' ESCAPE '!'",
+			withEscape(expr.Like(testutil.ParsePath(t, "name"), testutil.TextValue("foo!%")), testutil.TextValue("!")), false},
+		{"NOT ILIKE ESCAPE", "name NOT ILIKE 'foo!%' ESCAPE '!'",
+			withEscape(expr.NotILike(testutil.ParsePath(t, "name"), testutil.TextValue("foo!%")), testutil.TextValue("!")), false},
+		{"LIKE ESCAPE with concatenated pattern", "name LIKE 'foo' || '!%' ESCAPE '!' AND age = 10",
+			expr.And(
+				withEscape(expr.Like(
+					testutil.ParsePath(t, "name"),
+					expr.Concat(testutil.TextValue("foo"), testutil.TextValue("!%")),
+				), testutil.TextValue("!")),
+				expr.Eq(testutil.ParsePath(t, "age"), testutil.IntegerValue(10)),
+			), false},
+		{"LIKE ESCAPE with escape as a field name", "escape LIKE escape ESCAPE '!'",
+			withEscape(expr.Like(testutil.ParsePath(t, "escape"), testutil.ParsePath(t, "escape")), testutil.TextValue("!")), false},
+		{"= ANY", "age = ANY [1, 2]", expr.Any(scanner.EQ)(testutil.ParsePath(t, "age"), testutil.ExprList(t, "[1, 2]")), false},
+		{"> SOME", "age > SOME ages", expr.Any(scanner.GT)(testutil.ParsePath(t, "age"), testutil.ParsePath(t, "ages")), false},
+		{"<= ALL", "age <= ALL (1, 2)", expr.All(scanner.LTE)(testutil.ParsePath(t, "age"), testutil.ExprList(t, "[1, 2]")), false},
+		{"ALL with AND", "age + 1 < ALL ages AND age > 10",
+			expr.And(
+				expr.All(scanner.LT)(expr.Add(testutil.ParsePath(t, "age"), testutil.IntegerValue(1)), testutil.ParsePath(t, "ages")),
+				expr.Gt(testutil.ParsePath(t, "age"), testutil.IntegerValue(10)),
+			), false},
+		{"ANY without comparison", "age IN ANY ages", nil, true},
+		{"ANY without operand", "age = ANY", nil, true},
+		{"ESCAPE without LIKE", "name = 'foo' ESCAPE '!'", nil, true},
 		{"NOT =", "name NOT = 'foo'", nil, true},
 		{"precedence", "4 > 1 + 2", expr.Gt(
 			testutil.IntegerValue(4),
@@ -165,7 +213,20 @@ func TestParserExpr(t *testing.T) {
 		{"pk() function", "pk()", &functions.PK{}, false},
 		{"count(expr) function", "count(a)", &functions.Count{Expr: testutil.ParsePath(t, "a")}, false},
 		{"count(*) function", "count(*)", &functions.Count{Wildcard: true}, false},
+		{"count(DISTINCT expr) function", "count(DISTINCT a)", &functions.Count{Expr: testutil.ParsePath(t, "a"), Distinct: true}, false},
+		{"sum(DISTINCT expr) function", "sum(DISTINCT a + 1)", &functions.Sum{Expr: expr.Add(testutil.ParsePath(t, "a"), testutil.IntegerValue(1)), Distinct: true}, false},
+		{"array_agg(DISTINCT expr) function", "array_agg(DISTINCT a)", &functions.ArrayAgg{Expr: testutil.ParsePath(t, "a"), Distinct: true}, false},
+		{"DISTINCT in scalar function", "math.floor(DISTINCT a)", nil, true},
+		{"DISTINCT with multiple arguments", "count(DISTINCT a, b)", nil, true},
 		{"packaged function", "math.floor(1.2)", testutil.FunctionExpr(t, "math.floor", testutil.DoubleValue(1.2)), false},
+		{"values() function", "values(a)", testutil.FunctionExpr(t, "values", testutil.ParsePath(t, "a")), false},
+		{"VALUES keyword", "VALUES", nil, true},
+		{"variadic function", "coalesce(a, 1, 'b')", testutil.FunctionExpr(t, "coalesce", testutil.ParsePath(t, "a"), testutil.IntegerValue(1), testutil.TextValue("b")), false},
+		{"variadic function without arguments", "coalesce()", nil, true},
+		{"function with too few arguments", "ifnull(a)", nil, true},
+		{"EXTRACT", "EXTRACT(YEAR FROM a)", &functions.Extract{Unit: "year", Expr: testutil.ParsePath(t, "a")}, false},
+		{"EXTRACT with unknown unit", "EXTRACT(fortnight FROM a)", nil, true},
+		{"EXTRACT without FROM", "EXTRACT(YEAR a)", nil, true},
 	}
 
 	for _, test := range tests {
@@ -248,6 +309,15 @@ func TestParserParams(t *testing.T) {
 				expr.Eq(testutil.ParsePath(t, "age"), expr.NamedParam("bar")),
 			), false},
 		{"mixed", "age >= ? AND age > $foo OR age < ?", nil, true},
+		{"colon named", "age = :age", expr.Eq(testutil.ParsePath(t, "age"), expr.NamedParam("age")), false},
+		{"colon and dollar named", "age = :foo OR age = $foo",
+			expr.Or(
+				expr.Eq(testutil.ParsePath(t, "age"), expr.NamedParam("foo")),
+				expr.Eq(testutil.ParsePath(t, "age"), expr.NamedParam("foo")),
+			), false},
+		{"colon named in document", "{a: :foo}", &expr.KVPairs{SelfReferenced: true, Pairs: []expr.KVPair{{K: "a", V: expr.NamedParam("foo")}}}, false},
+		{"colon named with whitespace", "age = : age", nil, true},
+		{"colon named mixed", "age >= ? AND age > :foo", nil, true},
 	}
 
 	for _, test := range tests {
@@ -262,3 +332,14 @@ func TestParserParams(t *testing.T) {
 		})
 	}
 }
+
+func withEscape(e expr.Expr, esc expr.Expr) expr.Expr {
+	switch t := e.(type) {
+	case *expr.LikeOperator:
+		t.SetEscape(esc)
+	case *expr.NotLikeOperator:
+		t.SetEscape(esc)
+	}
+
+	return e
+}
@@ -52,7 +52,7 @@ func (p *Parser) parseInsertStatement() (*statement.StreamStmt, error) {
 	}
 
 	// Parse ON CONFLICT clause
-	stmt.OnConflict, err = p.parseOnConflictClause()
+	err = p.parseOnConflictClause(&stmt)
 	if err != nil {
 		return nil, err
 	}
@@ -198,34 +198,74 @@ func (p *Parser) parseParamOrDocument() (expr.Expr, error) {
 	return p.ParseDocument()
 }
 
-func (p *Parser) parseOnConflictClause() (database.OnInsertConflictAction, error) {
+// parseOnConflictClause parses the ON CONFLICT clause of the insert statement, if it exists:
+// ON CONFLICT [(path, ...)] DO { NOTHING | REPLACE | UPDATE SET path = expr, ... [WHERE expr] }.
+func (p *Parser) parseOnConflictClause(stmt *statement.InsertStmt) error {
 	// Parse ON CONFLICT DO clause: ON CONFLICT DO action
 	if ok, err := p.parseOptional(scanner.ON, scanner.CONFLICT); !ok || err != nil {
-		return nil, err
+		return err
 	}
 
 	tok, pos, lit := p.ScanIgnoreWhitespace()
 	// SQLite compatibility: ON CONFLICT [IGNORE | REPLACE]
 	switch tok {
 	case scanner.IGNORE:
-		return database.OnInsertConflictDoNothing, nil
+		stmt.OnConflict = database.OnInsertConflictDoNothing
+		return nil
 	case scanner.REPLACE:
-		return database.OnInsertConflictDoReplace, nil
+		stmt.OnConflict = database.OnInsertConflictDoReplace
+		return nil
+	case scanner.LPAREN:
+		// conflict target: (path, ...)
+		p.Unscan()
+		var err error
+		stmt.OnConflictTarget, err = p.parsePathList()
+		if err != nil {
+			return err
+		}
+		tok, pos, lit = p.ScanIgnoreWhitespace()
 	}
 
-	// DO [NOTHING | REPLACE]
+	// DO [NOTHING | REPLACE | UPDATE]
 	if tok != scanner.DO {
-		return nil, newParseError(scanner.Tokstr(tok, lit), []string{scanner.DO.String()}, pos)
+		return newParseError(scanner.Tokstr(tok, lit), []string{scanner.DO.String()}, pos)
 	}
 
 	tok, pos, lit = p.ScanIgnoreWhitespace()
 	switch tok {
 	case scanner.NOTHING:
-		return database.OnInsertConflictDoNothing, nil
+		if len(stmt.OnConflictTarget) > 0 {
+			stmt.OnConflictDoNothing = true
+		} else {
+			stmt.OnConflict = database.OnInsertConflictDoNothing
+		}
+		return nil
 	case scanner.REPLACE:
-		return database.OnInsertConflictDoReplace, nil
+		// replacing is not supported with a conflict target
+		if len(stmt.OnConflictTarget) == 0 {
+			stmt.OnConflict = database.OnInsertConflictDoReplace
+			return nil
+		}
+	case scanner.UPDATE:
+		if err := p.parseTokens(scanner.SET); err != nil {
+			return err
+		}
+
+		var err error
+		stmt.OnConflictSet, err = p.parseSetClause()
+		if err != nil {
+			return err
+		}
+
+		stmt.OnConflictWhere, err = p.parseCondition()
+		return err
+	}
+
+	expected := []string{scanner.NOTHING.String(), scanner.REPLACE.String(), scanner.UPDATE.String()}
+	if len(stmt.OnConflictTarget) > 0 {
+		expected = []string{scanner.NOTHING.String(), scanner.UPDATE.String()}
 	}
-	return nil, newParseError(scanner.Tokstr(tok, lit), []string{scanner.NOTHING.String(), scanner.REPLACE.String()}, pos)
+	return newParseError(scanner.Tokstr(tok, lit), expected, pos)
 }
 
 func (p *Parser) parseReturning() ([]expr.Expr, error) {
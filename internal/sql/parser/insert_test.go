@@ -3,6 +3,7 @@ package parser_test
 import (
 	"testing"
 
+	"github.com/genjidb/genji/document"
 	"github.com/genjidb/genji/internal/database"
 	"github.com/genjidb/genji/internal/expr"
 	"github.com/genjidb/genji/internal/query/statement"
@@ -53,6 +54,12 @@ func TestParserInsert(t *testing.T) {
 				expr.NamedParam("bar"),
 			)).Pipe(stream.TableInsert("test", nil)),
 			false},
+		{"Documents / Colon Named Param", "INSERT INTO test VALUES :foo, :bar",
+			stream.New(stream.Expressions(
+				expr.NamedParam("foo"),
+				expr.NamedParam("bar"),
+			)).Pipe(stream.TableInsert("test", nil)),
+			false},
 		{"Values / With fields", "INSERT INTO test (a, b) VALUES ('c', 'd')",
 			stream.New(stream.Expressions(
 				&expr.KVPairs{Pairs: []expr.KVPair{
@@ -124,6 +131,42 @@ func TestParserInsert(t *testing.T) {
 			)).Pipe(stream.TableInsert("test", database.OnInsertConflictDoReplace)).
 				Pipe(stream.Project(expr.Wildcard{})),
 			false},
+		{"Values / ON CONFLICT with target DO NOTHING", "INSERT INTO test (a, b) VALUES ('c', 'd') ON CONFLICT (a, b.c) DO NOTHING",
+			stream.New(stream.Expressions(
+				&expr.KVPairs{Pairs: []expr.KVPair{
+					{K: "a", V: testutil.TextValue("c")},
+					{K: "b", V: testutil.TextValue("d")},
+				}},
+			)).Pipe(stream.TableUpsert("test", []document.Path{document.NewPath("a"), document.NewPath("b", "c")})),
+			false},
+		{"Values / ON CONFLICT DO UPDATE", "INSERT INTO test (a, b) VALUES ('c', 'd') ON CONFLICT DO UPDATE SET b = excluded.b, c = 1 RETURNING *",
+			stream.New(stream.Expressions(
+				&expr.KVPairs{Pairs: []expr.KVPair{
+					{K: "a", V: testutil.TextValue("c")},
+					{K: "b", V: testutil.TextValue("d")},
+				}},
+			)).Pipe(stream.TableUpsertUpdate("test", nil, []stream.UpsertSetPair{
+				{Path: document.NewPath("b"), E: testutil.ParsePath(t, "excluded.b")},
+				{Path: document.NewPath("c"), E: testutil.IntegerValue(1)},
+			}, nil)).
+				Pipe(stream.Project(expr.Wildcard{})),
+			false},
+		{"Values / ON CONFLICT with target DO UPDATE WHERE", "INSERT INTO test (a, b) VALUES ('c', 'd') ON CONFLICT (a) DO UPDATE SET b = excluded.b WHERE b < excluded.b",
+			stream.New(stream.Expressions(
+				&expr.KVPairs{Pairs: []expr.KVPair{
+					{K: "a", V: testutil.TextValue("c")},
+					{K: "b", V: testutil.TextValue("d")},
+				}},
+			)).Pipe(stream.TableUpsertUpdate("test", []document.Path{document.NewPath("a")}, []stream.UpsertSetPair{
+				{Path: document.NewPath("b"), E: testutil.ParsePath(t, "excluded.b")},
+			}, expr.Lt(testutil.ParsePath(t, "b"), testutil.ParsePath(t, "excluded.b")))),
+			false},
+		{"Values / ON CONFLICT with target DO REPLACE", "INSERT INTO test (a, b) VALUES ('c', 'd') ON CONFLICT (a) DO REPLACE",
+			nil, true},
+		{"Values / ON CONFLICT with empty target", "INSERT INTO test (a, b) VALUES ('c', 'd') ON CONFLICT () DO NOTHING",
+			nil, true},
+		{"Values / ON CONFLICT DO UPDATE without SET", "INSERT INTO test (a, b) VALUES ('c', 'd') ON CONFLICT DO UPDATE b = 1",
+			nil, true},
 		{"Values / ON CONFLICT BLA", "INSERT INTO test (a, b) VALUES ('c', 'd') ON CONFLICT BLA RETURNING *",
 			nil, true},
 		{"Values / ON CONFLICT DO BLA", "INSERT INTO test (a, b) VALUES ('c', 'd') ON CONFLICT DO BLA RETURNING *",
@@ -5,26 +5,43 @@ import (
 	"github.com/genjidb/genji/internal/sql/scanner"
 )
 
-func (p *Parser) parseOrderBy() (expr.Path, scanner.Token, error) {
+func (p *Parser) parseOrderBy() ([]expr.OrderTerm, error) {
 	// parse ORDER token
 	ok, err := p.parseOptional(scanner.ORDER, scanner.BY)
 	if err != nil || !ok {
-		return nil, 0, err
+		return nil, err
 	}
 
-	// parse path
-	path, err := p.parsePath()
-	if err != nil {
-		return nil, 0, err
-	}
+	return p.parseOrderTerms()
+}
+
+// parseOrderTerms parses a list of expressions separated by commas,
+// each one followed by an optional ASC or DESC.
+func (p *Parser) parseOrderTerms() ([]expr.OrderTerm, error) {
+	var terms []expr.OrderTerm
+
+	for {
+		e, err := p.ParseExpr()
+		if err != nil {
+			return nil, err
+		}
+
+		// parse optional ASC or DESC
+		term := expr.OrderTerm{E: e}
+		if tok, _, _ := p.ScanIgnoreWhitespace(); tok == scanner.DESC {
+			term.Desc = true
+		} else if tok != scanner.ASC {
+			p.Unscan()
+		}
+		terms = append(terms, term)
 
-	// parse optional ASC or DESC
-	if tok, _, _ := p.ScanIgnoreWhitespace(); tok == scanner.ASC || tok == scanner.DESC {
-		return expr.Path(path), tok, nil
+		if tok, _, _ := p.ScanIgnoreWhitespace(); tok != scanner.COMMA {
+			p.Unscan()
+			break
+		}
 	}
-	p.Unscan()
 
-	return expr.Path(path), 0, nil
+	return terms, nil
 }
 
 func (p *Parser) parseLimit() (expr.Expr, error) {
@@ -3,6 +3,7 @@ package parser
 import (
 	"io"
 	"strings"
+	"unicode/utf8"
 
 	"github.com/genjidb/genji/document"
 	"github.com/genjidb/genji/internal/expr"
@@ -17,8 +18,14 @@ import (
 type Parser struct {
 	s             *scanner.Scanner
 	orderedParams int
-	namedParams   int
+	// names of the named parameters, in order of first appearance
+	namedParams   []string
 	packagesTable functions.Packages
+	// text read by the scanner, used to keep the SQL of views
+	src *sourceRecorder
+	// set while parsing the program of a trigger, whose NEW and OLD
+	// variables are case insensitive
+	inTrigger bool
 }
 
 // NewParser returns a new instance of Parser.
@@ -32,7 +39,90 @@ func NewParserWithOptions(r io.Reader, opts *Options) *Parser {
 		opts = defaultOptions()
 	}
 
-	return &Parser{s: scanner.NewScanner(r), packagesTable: opts.Packages}
+	src := sourceRecorder{r: r}
+	return &Parser{s: scanner.NewScanner(&src), src: &src, packagesTable: opts.Packages}
+}
+
+// sourceRecorder keeps the text read from r.
+type sourceRecorder struct {
+	r   io.Reader
+	buf []byte
+	// position of the first character of buf.
+	base scanner.Pos
+}
+
+func (s *sourceRecorder) Read(p []byte) (int, error) {
+	n, err := s.r.Read(p)
+	s.buf = append(s.buf, p[:n]...)
+	return n, err
+}
+
+// text returns the text read between the start and end positions,
+// counting lines and characters like the scanner.
+func (s *sourceRecorder) text(start, end scanner.Pos) string {
+	from, to := -1, len(s.buf)
+	pos := s.base
+
+	for i := 0; i < len(s.buf); {
+		if pos == start {
+			from = i
+		}
+		if pos == end {
+			to = i
+			break
+		}
+
+		r, size := utf8.DecodeRune(s.buf[i:])
+		i += size
+
+		switch r {
+		case '\r':
+			if i < len(s.buf) && s.buf[i] == '\n' {
+				i++
+			}
+			fallthrough
+		case '\n':
+			pos.Line++
+			pos.Char = 0
+		default:
+			pos.Char++
+		}
+	}
+
+	if from == -1 || from > to {
+		return ""
+	}
+
+	return string(s.buf[from:to])
+}
+
+// discard forgets the text read before the given position.
+func (s *sourceRecorder) discard(upTo scanner.Pos) {
+	pos := s.base
+
+	for i := 0; i < len(s.buf); {
+		if pos == upTo {
+			s.buf = s.buf[i:]
+			s.base = pos
+			return
+		}
+
+		r, size := utf8.DecodeRune(s.buf[i:])
+		i += size
+
+		switch r {
+		case '\r':
+			if i < len(s.buf) && s.buf[i] == '\n' {
+				i++
+			}
+			fallthrough
+		case '\n':
+			pos.Line++
+			pos.Char = 0
+		default:
+			pos.Char++
+		}
+	}
 }
 
 // ParseQuery parses a query string and returns its AST representation.
@@ -68,7 +158,10 @@ func (p *Parser) ParseQuery() (query.Query, error) {
 
 	for {
 		if tok, pos, lit := p.ScanIgnoreWhitespace(); tok == scanner.EOF {
-			return query.New(statements...), nil
+			q := query.New(statements...)
+			q.NumParams = p.orderedParams
+			q.ParamNames = p.namedParams
+			return q, nil
 		} else if tok == scanner.SEMICOLON {
 			semi = true
 		} else {
@@ -86,6 +179,39 @@ func (p *Parser) ParseQuery() (query.Query, error) {
 	}
 }
 
+// NextStatement parses the next statement of a list of statements separated by semicolons.
+// It returns io.EOF once all the statements have been parsed.
+// Unlike ParseQuery, it doesn't keep the text of the previous statements in memory,
+// which allows parsing large inputs one statement at a time.
+func (p *Parser) NextStatement() (statement.Statement, error) {
+	for {
+		tok, pos, _ := p.ScanIgnoreWhitespace()
+		switch tok {
+		case scanner.EOF:
+			return nil, io.EOF
+		case scanner.SEMICOLON:
+			continue
+		}
+		p.Unscan()
+		p.src.discard(pos)
+
+		s, err := p.ParseStatement()
+		if err != nil {
+			return nil, err
+		}
+
+		switch tok, pos, lit := p.ScanIgnoreWhitespace(); tok {
+		case scanner.SEMICOLON:
+		case scanner.EOF:
+			p.Unscan()
+		default:
+			return nil, newParseError(scanner.Tokstr(tok, lit), []string{";"}, pos)
+		}
+
+		return s, nil
+	}
+}
+
 // ParseStatement parses a Genji SQL string and returns a Statement AST object.
 func (p *Parser) ParseStatement() (statement.Statement, error) {
 	tok, pos, lit := p.ScanIgnoreWhitespace()
@@ -110,14 +236,34 @@ func (p *Parser) ParseStatement() (statement.Statement, error) {
 		return p.parseDropStatement()
 	case scanner.EXPLAIN:
 		return p.parseExplainStatement()
+	case scanner.PURGE:
+		return p.parsePurgeStatement()
 	case scanner.REINDEX:
 		return p.parseReIndexStatement()
 	case scanner.ROLLBACK:
 		return p.parseRollbackStatement()
+	case scanner.SET:
+		return p.parseSetStatement()
+	case scanner.IDENT:
+		// these statements don't start with a keyword, to allow using their names as identifiers.
+		switch {
+		case strings.EqualFold(lit, "copy"):
+			return p.parseCopyStatement()
+		case strings.EqualFold(lit, "grant"):
+			return p.parseGrantStatement(false)
+		case strings.EqualFold(lit, "revoke"):
+			return p.parseGrantStatement(true)
+		case strings.EqualFold(lit, "show"):
+			return p.parseShowStatement()
+		case strings.EqualFold(lit, "pragma"):
+			return p.parsePragmaStatement()
+		case strings.EqualFold(lit, "analyze"):
+			return p.parseAnalyzeStatement()
+		}
 	}
 
 	return nil, newParseError(scanner.Tokstr(tok, lit), []string{
-		"ALTER", "BEGIN", "COMMIT", "SELECT", "DELETE", "UPDATE", "INSERT", "CREATE", "DROP", "EXPLAIN", "REINDEX", "ROLLBACK",
+		"ALTER", "ANALYZE", "BEGIN", "COMMIT", "COPY", "SELECT", "DELETE", "UPDATE", "INSERT", "CREATE", "DROP", "EXPLAIN", "GRANT", "PRAGMA", "PURGE", "REINDEX", "REVOKE", "ROLLBACK", "SET", "SHOW",
 	}, pos)
 }
 
@@ -1,6 +1,8 @@
 package parser_test
 
 import (
+	"io"
+	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/require"
@@ -38,9 +40,60 @@ func TestParserMultiStatement(t *testing.T) {
 	}
 }
 
+func TestParserNextStatement(t *testing.T) {
+	p := parser.NewParser(strings.NewReader(`
+		;DELETE FROM foo;;
+		CREATE VIEW v AS SELECT a FROM foo;
+		CREATE VIEW w AS SELECT b FROM foo
+	`))
+
+	s, err := p.NextStatement()
+	require.NoError(t, err)
+	require.IsType(t, &statement.StreamStmt{}, s)
+
+	// the text of views is still available once previous statements are discarded
+	s, err = p.NextStatement()
+	require.NoError(t, err)
+	require.Equal(t, "CREATE VIEW v AS SELECT a FROM foo", s.(*statement.CreateViewStmt).Info.String())
+
+	s, err = p.NextStatement()
+	require.NoError(t, err)
+	require.Equal(t, "CREATE VIEW w AS SELECT b FROM foo", s.(*statement.CreateViewStmt).Info.String())
+
+	_, err = p.NextStatement()
+	require.Equal(t, io.EOF, err)
+
+	// statements must be separated by semicolons
+	p = parser.NewParser(strings.NewReader("DELETE FROM foo DELETE FROM bar"))
+	_, err = p.NextStatement()
+	require.Error(t, err)
+}
+
 func TestParserDivideByZero(t *testing.T) {
 	// See https://github.com/genjidb/genji/issues/268
 	require.NotPanics(t, func() {
 		_, _ = parser.ParseQuery("SELECT * FROM t LIMIT 0 % .5")
 	})
 }
+
+func TestParserQueryParams(t *testing.T) {
+	tests := []struct {
+		name      string
+		s         string
+		numParams int
+		names     []string
+	}{
+		{"None", "SELECT * FROM foo", 0, nil},
+		{"Positional", "SELECT * FROM foo WHERE a = ? AND b > ?; DELETE FROM foo WHERE c = ?", 3, nil},
+		{"Named", "SELECT * FROM foo WHERE a = $b AND b > :a OR c = :b", 0, []string{"b", "a"}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			q, err := parser.ParseQuery(test.s)
+			require.NoError(t, err)
+			require.Equal(t, test.numParams, q.NumParams)
+			require.Equal(t, test.names, q.ParamNames)
+		})
+	}
+}
@@ -0,0 +1,37 @@
+package parser
+
+import (
+	"github.com/genjidb/genji/document"
+	"github.com/genjidb/genji/internal/expr"
+	"github.com/genjidb/genji/internal/query/statement"
+	"github.com/genjidb/genji/internal/sql/scanner"
+)
+
+// parsePragmaStatement parses a PRAGMA statement.
+// This function assumes the PRAGMA token has already been consumed.
+func (p *Parser) parsePragmaStatement() (statement.Statement, error) {
+	var stmt statement.PragmaStmt
+	var err error
+
+	stmt.Name, err = p.parseIdent()
+	if err != nil {
+		return nil, err
+	}
+
+	// Parse optional "=", followed by the new value.
+	if ok, err := p.parseOptional(scanner.EQ); !ok || err != nil {
+		return &stmt, err
+	}
+
+	stmt.Value, err = p.ParseExpr()
+	if err != nil {
+		return nil, err
+	}
+
+	// Values can be written as identifiers, e.g. PRAGMA durability = off
+	if path, ok := stmt.Value.(expr.Path); ok && len(path) == 1 && path[0].FieldName != "" {
+		stmt.Value = expr.LiteralValue(document.NewTextValue(path[0].FieldName))
+	}
+
+	return &stmt, nil
+}
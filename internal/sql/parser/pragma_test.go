@@ -0,0 +1,40 @@
+package parser_test
+
+import (
+	"testing"
+
+	"github.com/genjidb/genji/document"
+	"github.com/genjidb/genji/internal/expr"
+	"github.com/genjidb/genji/internal/query/statement"
+	"github.com/genjidb/genji/internal/sql/parser"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParserPragma(t *testing.T) {
+	tests := []struct {
+		s        string
+		expected statement.Statement
+		errored  bool
+	}{
+		{"PRAGMA durability", &statement.PragmaStmt{Name: "durability"}, false},
+		{"PRAGMA durability = 'off'", &statement.PragmaStmt{Name: "durability", Value: expr.LiteralValue(document.NewTextValue("off"))}, false},
+		{"PRAGMA durability = off", &statement.PragmaStmt{Name: "durability", Value: expr.LiteralValue(document.NewTextValue("off"))}, false},
+		{"PRAGMA durability = ?", &statement.PragmaStmt{Name: "durability", Value: expr.PositionalParam(1)}, false},
+		{"pragma pragma", &statement.PragmaStmt{Name: "pragma"}, false},
+		{"PRAGMA", nil, true},
+		{"PRAGMA durability =", nil, true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.s, func(t *testing.T) {
+			q, err := parser.ParseQuery(test.s)
+			if test.errored {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Len(t, q.Statements, 1)
+			require.EqualValues(t, test.expected, q.Statements[0])
+		})
+	}
+}
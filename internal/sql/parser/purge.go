@@ -0,0 +1,28 @@
+package parser
+
+import (
+	"github.com/genjidb/genji/internal/query/statement"
+)
+
+// parsePurgeStatement parses a purge string and returns a Statement AST object.
+// This function assumes the PURGE token has already been consumed.
+func (p *Parser) parsePurgeStatement() (*statement.StreamStmt, error) {
+	var stmt statement.PurgeStmt
+	var err error
+
+	// Parse table name
+	stmt.TableName, err = p.parseIdent()
+	if err != nil {
+		pErr := err.(*ParseError)
+		pErr.Expected = []string{"table_name"}
+		return nil, pErr
+	}
+
+	// Parse condition: "WHERE EXPR".
+	stmt.WhereExpr, err = p.parseCondition()
+	if err != nil {
+		return nil, err
+	}
+
+	return stmt.ToStream()
+}
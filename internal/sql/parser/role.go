@@ -0,0 +1,157 @@
+package parser
+
+import (
+	"strings"
+
+	"github.com/genjidb/genji/internal/database"
+	"github.com/genjidb/genji/internal/query/statement"
+	"github.com/genjidb/genji/internal/sql/scanner"
+)
+
+// isRoleKeyword reports whether lit is USER or ROLE. These words are not
+// reserved keywords so that they can still be used as identifiers.
+func isRoleKeyword(tok scanner.Token, lit string) bool {
+	return tok == scanner.IDENT && (strings.EqualFold(lit, "USER") || strings.EqualFold(lit, "ROLE"))
+}
+
+// parseCreateRoleStatement parses a create user or create role string and returns a Statement AST object.
+// This function assumes the CREATE USER or CREATE ROLE tokens have already been consumed.
+func (p *Parser) parseCreateRoleStatement(login bool) (*statement.CreateRoleStmt, error) {
+	stmt := statement.CreateRoleStmt{Login: login}
+	var err error
+
+	stmt.IfNotExists, err = p.parseOptional(scanner.IF, scanner.NOT, scanner.EXISTS)
+	if err != nil {
+		return nil, err
+	}
+
+	stmt.Name, err = p.parseIdent()
+	if err != nil {
+		return nil, err
+	}
+
+	// Parse optional WITH PASSWORD clause.
+	if ok, err := p.parseOptional(scanner.WITH); !ok || err != nil {
+		return &stmt, err
+	}
+
+	if tok, pos, lit := p.ScanIgnoreWhitespace(); tok != scanner.IDENT || !strings.EqualFold(lit, "PASSWORD") {
+		return nil, newParseError(scanner.Tokstr(tok, lit), []string{"PASSWORD"}, pos)
+	}
+
+	stmt.Password, err = p.ParseExpr()
+	if err != nil {
+		return nil, err
+	}
+
+	return &stmt, nil
+}
+
+// parseDropRoleStatement parses a drop user or drop role string and returns a Statement AST object.
+// This function assumes the DROP USER or DROP ROLE tokens have already been consumed.
+func (p *Parser) parseDropRoleStatement() (statement.DropRoleStmt, error) {
+	var stmt statement.DropRoleStmt
+	var err error
+
+	stmt.IfExists, err = p.parseOptional(scanner.IF, scanner.EXISTS)
+	if err != nil {
+		return stmt, err
+	}
+
+	stmt.Name, err = p.parseIdent()
+	return stmt, err
+}
+
+// parseGrantStatement parses a grant or a revoke string and returns a Statement AST object.
+// This function assumes the GRANT or REVOKE token has already been consumed.
+//
+//   GRANT privilege [, ...] ON [TABLE] { table_name | * } TO role_name
+//   GRANT role_name [, ...] TO role_name
+//   REVOKE privilege [, ...] ON [TABLE] { table_name | * } FROM role_name
+//   REVOKE role_name [, ...] FROM role_name
+func (p *Parser) parseGrantStatement(revoke bool) (*statement.GrantStmt, error) {
+	stmt := statement.GrantStmt{Revoke: revoke}
+
+	// Parse the list of privileges or roles.
+	var names []string
+	var positions []scanner.Pos
+	for {
+		tok, pos, lit := p.ScanIgnoreWhitespace()
+		switch tok {
+		case scanner.ALL:
+			// parse optional PRIVILEGES keyword
+			if tok, _, lit := p.ScanIgnoreWhitespace(); tok != scanner.IDENT || !strings.EqualFold(lit, "PRIVILEGES") {
+				p.Unscan()
+			}
+			names = append(names, "ALL")
+		case scanner.SELECT, scanner.INSERT, scanner.UPDATE, scanner.DELETE:
+			names = append(names, tok.String())
+		case scanner.IDENT:
+			names = append(names, lit)
+		default:
+			return nil, newParseError(scanner.Tokstr(tok, lit), []string{"privilege", "role name"}, pos)
+		}
+		positions = append(positions, pos)
+
+		if tok, _, _ := p.ScanIgnoreWhitespace(); tok != scanner.COMMA {
+			p.Unscan()
+			break
+		}
+	}
+
+	// If ON follows, the names are privileges
+	if ok, err := p.parseOptional(scanner.ON); err != nil {
+		return nil, err
+	} else if ok {
+		for i, name := range names {
+			if name == "ALL" {
+				stmt.Privileges = append(stmt.Privileges, database.AllPrivileges...)
+				continue
+			}
+
+			priv, err := database.ParsePrivilege(name)
+			if err != nil {
+				return nil, newParseError(name, []string{"SELECT", "INSERT", "UPDATE", "DELETE", "DDL", "ALL"}, positions[i])
+			}
+			stmt.Privileges = append(stmt.Privileges, priv)
+		}
+
+		// parse optional TABLE token
+		_, _ = p.parseOptional(scanner.TABLE)
+
+		if tok, _, _ := p.ScanIgnoreWhitespace(); tok == scanner.MUL {
+			stmt.TableName = database.AllTables
+		} else {
+			p.Unscan()
+			stmt.TableName, err = p.parseIdent()
+			if err != nil {
+				return nil, err
+			}
+		}
+	} else {
+		// privileges must be followed by ON
+		for _, name := range names {
+			if _, err := database.ParsePrivilege(name); err == nil || name == "ALL" {
+				tok, pos, lit := p.ScanIgnoreWhitespace()
+				return nil, newParseError(scanner.Tokstr(tok, lit), []string{"ON"}, pos)
+			}
+		}
+		stmt.Roles = names
+	}
+
+	target, expected := scanner.TO, "TO"
+	if revoke {
+		target, expected = scanner.FROM, "FROM"
+	}
+	if tok, pos, lit := p.ScanIgnoreWhitespace(); tok != target {
+		return nil, newParseError(scanner.Tokstr(tok, lit), []string{expected}, pos)
+	}
+
+	var err error
+	stmt.Grantee, err = p.parseIdent()
+	if err != nil {
+		return nil, err
+	}
+
+	return &stmt, nil
+}
@@ -0,0 +1,54 @@
+package parser_test
+
+import (
+	"testing"
+
+	"github.com/genjidb/genji/document"
+	"github.com/genjidb/genji/internal/database"
+	"github.com/genjidb/genji/internal/expr"
+	"github.com/genjidb/genji/internal/query/statement"
+	"github.com/genjidb/genji/internal/sql/parser"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParserRoles(t *testing.T) {
+	tests := []struct {
+		s        string
+		expected statement.Statement
+		errored  bool
+	}{
+		{"CREATE USER alice", &statement.CreateRoleStmt{Name: "alice", Login: true}, false},
+		{"CREATE USER IF NOT EXISTS alice WITH PASSWORD 'secret'", &statement.CreateRoleStmt{Name: "alice", Login: true, IfNotExists: true, Password: expr.LiteralValue(document.NewTextValue("secret"))}, false},
+		{"create role readers", &statement.CreateRoleStmt{Name: "readers"}, false},
+		{"CREATE USER alice WITH 'secret'", nil, true},
+		{"CREATE USER", nil, true},
+		{"DROP USER alice", statement.DropRoleStmt{Name: "alice"}, false},
+		{"DROP ROLE IF EXISTS readers", statement.DropRoleStmt{Name: "readers", IfExists: true}, false},
+		{"GRANT SELECT ON test TO alice", &statement.GrantStmt{Privileges: []database.Privilege{database.SelectPrivilege}, TableName: "test", Grantee: "alice"}, false},
+		{"GRANT select, insert, ddl ON TABLE test TO alice", &statement.GrantStmt{Privileges: []database.Privilege{database.SelectPrivilege, database.InsertPrivilege, database.DDLPrivilege}, TableName: "test", Grantee: "alice"}, false},
+		{"GRANT ALL PRIVILEGES ON * TO alice", &statement.GrantStmt{Privileges: database.AllPrivileges, TableName: "*", Grantee: "alice"}, false},
+		{"GRANT readers, writers TO alice", &statement.GrantStmt{Roles: []string{"readers", "writers"}, Grantee: "alice"}, false},
+		{"REVOKE UPDATE, DELETE ON test FROM alice", &statement.GrantStmt{Revoke: true, Privileges: []database.Privilege{database.UpdatePrivilege, database.DeletePrivilege}, TableName: "test", Grantee: "alice"}, false},
+		{"REVOKE readers FROM alice", &statement.GrantStmt{Revoke: true, Roles: []string{"readers"}, Grantee: "alice"}, false},
+		{"grant grant TO revoke", &statement.GrantStmt{Roles: []string{"grant"}, Grantee: "revoke"}, false},
+		{"GRANT foo ON test TO alice", nil, true},
+		{"GRANT ALL TO alice", nil, true},
+		{"GRANT SELECT TO alice", nil, true},
+		{"GRANT SELECT ON test FROM alice", nil, true},
+		{"REVOKE SELECT ON test TO alice", nil, true},
+		{"GRANT SELECT ON test TO", nil, true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.s, func(t *testing.T) {
+			q, err := parser.ParseQuery(test.s)
+			if test.errored {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Len(t, q.Statements, 1)
+			require.EqualValues(t, test.expected, q.Statements[0])
+		})
+	}
+}
@@ -1,14 +1,52 @@
 package parser
 
 import (
+	"strings"
+
 	"github.com/genjidb/genji/internal/expr"
 	"github.com/genjidb/genji/internal/query/statement"
 	"github.com/genjidb/genji/internal/sql/scanner"
+	"github.com/genjidb/genji/internal/stream"
 )
 
 // parseSelectStatement parses a select string and returns a Statement AST object.
+// SELECT statements combined with UNION, INTERSECT or EXCEPT are evaluated from left to right.
 // This function assumes the SELECT token has already been consumed.
 func (p *Parser) parseSelectStatement() (*statement.StreamStmt, error) {
+	s, err := p.parseSelectCore()
+	if err != nil {
+		return nil, err
+	}
+
+	for {
+		// Parse set operation: "(UNION | INTERSECT | EXCEPT) [ALL] SELECT ..."
+		op, all, ok, err := p.parseSetOperator()
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			return s, nil
+		}
+
+		if err := p.parseTokens(scanner.SELECT); err != nil {
+			return nil, err
+		}
+
+		right, err := p.parseSelectCore()
+		if err != nil {
+			return nil, err
+		}
+
+		stmt := statement.SetOperationStmt{Operator: op, All: all, Left: s, Right: right}
+		s, err = stmt.ToStream()
+		if err != nil {
+			return nil, err
+		}
+	}
+}
+
+// parseSelectCore parses a single select statement, without set operations.
+func (p *Parser) parseSelectCore() (*statement.StreamStmt, error) {
 	var stmt statement.SelectStmt
 	var err error
 
@@ -25,7 +63,7 @@ func (p *Parser) parseSelectStatement() (*statement.StreamStmt, error) {
 
 	// Parse "FROM".
 	var found bool
-	stmt.TableName, found, err = p.parseFrom()
+	stmt.TableName, stmt.Subquery, found, err = p.parseFrom()
 	if err != nil {
 		return nil, err
 	}
@@ -33,6 +71,38 @@ func (p *Parser) parseSelectStatement() (*statement.StreamStmt, error) {
 		return stmt.ToStream()
 	}
 
+	// Parse time travel: "AS OF expr"
+	stmt.AsOf, stmt.TableAlias, err = p.parseAsOf(stmt.Subquery != nil)
+	if err != nil {
+		return nil, err
+	}
+
+	// Parse alias: "AS alias"
+	if stmt.TableAlias == "" {
+		stmt.TableAlias, err = p.parseTableAlias()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// Parse index hint: "(USE | IGNORE) INDEX (index_name, ...)"
+	stmt.IndexHint, err = p.parseIndexHint(stmt.Subquery != nil)
+	if err != nil {
+		return nil, err
+	}
+
+	// Parse soft deleted documents: "WITH DELETED"
+	stmt.WithDeleted, err = p.parseWithDeleted(stmt.Subquery != nil)
+	if err != nil {
+		return nil, err
+	}
+
+	// Parse joins: "[INNER | LEFT [OUTER]] JOIN (table_name | (SELECT ...)) [AS alias] ON expr"
+	stmt.Joins, err = p.parseJoins()
+	if err != nil {
+		return nil, err
+	}
+
 	// Parse condition: "WHERE expr".
 	stmt.WhereExpr, err = p.parseCondition()
 	if err != nil {
@@ -45,8 +115,8 @@ func (p *Parser) parseSelectStatement() (*statement.StreamStmt, error) {
 		return nil, err
 	}
 
-	// Parse order by: "ORDER BY path [ASC|DESC]?"
-	stmt.OrderBy, stmt.OrderByDirection, err = p.parseOrderBy()
+	// Parse order by: "ORDER BY expr [ASC|DESC]?, ..."
+	stmt.OrderBy, err = p.parseOrderBy()
 	if err != nil {
 		return nil, err
 	}
@@ -63,12 +133,6 @@ func (p *Parser) parseSelectStatement() (*statement.StreamStmt, error) {
 		return nil, err
 	}
 
-	// Parse union: "UNION expr"
-	stmt.Union.SelectStmt, stmt.Union.All, err = p.parseUnion()
-	if err != nil {
-		return nil, err
-	}
-
 	return stmt.ToStream()
 }
 
@@ -104,6 +168,9 @@ func (p *Parser) parseProjectedExpr() (expr.Expr, error) {
 	}
 	p.Unscan()
 
+	_, start, _ := p.ScanIgnoreWhitespace()
+	p.Unscan()
+
 	e, err := p.ParseExpr()
 	if err != nil {
 		return nil, err
@@ -111,6 +178,14 @@ func (p *Parser) parseProjectedExpr() (expr.Expr, error) {
 
 	rf := &expr.NamedExpr{Expr: e, ExprName: e.String()}
 
+	// subqueries are printed as the stream of their statement,
+	// expressions containing them are named after their text instead
+	if hasSubquery(e) {
+		_, end, _ := p.ScanIgnoreWhitespace()
+		p.Unscan()
+		rf.ExprName = strings.TrimSpace(p.src.text(start, end))
+	}
+
 	// Check if the AS token exists.
 	if tok, _, _ := p.ScanIgnoreWhitespace(); tok == scanner.AS {
 		rf.ExprName, err = p.parseIdent()
@@ -125,6 +200,14 @@ func (p *Parser) parseProjectedExpr() (expr.Expr, error) {
 	return rf, nil
 }
 
+// hasSubquery reports whether the expression contains a subquery.
+func hasSubquery(e expr.Expr) bool {
+	return !expr.Walk(e, func(e expr.Expr) bool {
+		_, ok := e.(expr.Subquery)
+		return !ok
+	})
+}
+
 func (p *Parser) parseDistinct() (bool, error) {
 	if tok, _, _ := p.ScanIgnoreWhitespace(); tok != scanner.DISTINCT {
 		p.Unscan()
@@ -134,9 +217,20 @@ func (p *Parser) parseDistinct() (bool, error) {
 	return true, nil
 }
 
-func (p *Parser) parseFrom() (string, bool, error) {
+func (p *Parser) parseFrom() (string, *statement.StreamStmt, bool, error) {
 	if ok, err := p.parseOptional(scanner.FROM); !ok || err != nil {
-		return "", false, err
+		return "", nil, false, err
+	}
+
+	tableName, subquery, err := p.parseTableOrSubquery()
+	return tableName, subquery, true, err
+}
+
+// parseTableOrSubquery parses a table name or a subquery: "(SELECT ...)".
+func (p *Parser) parseTableOrSubquery() (string, *statement.StreamStmt, error) {
+	subquery, err := p.parseSubquery()
+	if err != nil || subquery != nil {
+		return "", subquery, err
 	}
 
 	// Parse table name
@@ -144,10 +238,196 @@ func (p *Parser) parseFrom() (string, bool, error) {
 	if err != nil {
 		pErr := err.(*ParseError)
 		pErr.Expected = []string{"table_name"}
-		return ident, true, pErr
+		return ident, nil, pErr
+	}
+
+	return ident, nil, nil
+}
+
+// parseSubquery parses a SELECT statement between parentheses.
+// It returns nil if the next tokens are not a left parenthesis followed by SELECT.
+func (p *Parser) parseSubquery() (*statement.StreamStmt, error) {
+	// count the tokens, including whitespaces, to unscan them all
+	var n int
+	scan := func() scanner.Token {
+		for {
+			tok, _, _ := p.Scan()
+			n++
+			if tok != scanner.WS && tok != scanner.COMMENT {
+				return tok
+			}
+		}
+	}
+
+	if scan() != scanner.LPAREN || scan() != scanner.SELECT {
+		for ; n > 0; n-- {
+			p.Unscan()
+		}
+		return nil, nil
+	}
+
+	stmt, err := p.parseSelectStatement()
+	if err != nil {
+		return nil, err
+	}
+
+	if err := p.parseTokens(scanner.RPAREN); err != nil {
+		return nil, err
+	}
+
+	return stmt, nil
+}
+
+// parseTableAlias parses the optional alias of a table: "AS alias".
+func (p *Parser) parseTableAlias() (string, error) {
+	if ok, err := p.parseOptional(scanner.AS); !ok || err != nil {
+		return "", err
+	}
+
+	return p.parseIdent()
+}
+
+// parseAsOf parses the optional time at which a table is read: "AS OF expr".
+// The expression must start with a string or a parameter, i.e. AS OF '-5m', to tell it apart from
+// a table aliased "of". If the table is aliased instead, the alias is returned.
+func (p *Parser) parseAsOf(subquery bool) (expr.Expr, string, error) {
+	if ok, err := p.parseOptional(scanner.AS); !ok || err != nil {
+		return nil, "", err
+	}
+
+	tok, pos, lit := p.ScanIgnoreWhitespace()
+	if tok != scanner.IDENT || !strings.EqualFold(lit, "OF") {
+		p.Unscan()
+		alias, err := p.parseIdent()
+		return nil, alias, err
 	}
 
-	return ident, true, nil
+	switch next, _, _ := p.ScanIgnoreWhitespace(); next {
+	case scanner.STRING, scanner.NAMEDPARAM, scanner.POSITIONALPARAM:
+		p.Unscan()
+	default:
+		p.Unscan()
+		return nil, lit, nil
+	}
+
+	if subquery {
+		return nil, "", &ParseError{Message: "AS OF cannot be used on subqueries", Pos: pos}
+	}
+
+	e, err := p.ParseExpr()
+	return e, "", err
+}
+
+// parseWithDeleted parses the optional "WITH DELETED" clause of a table,
+// which also reads its soft deleted documents.
+func (p *Parser) parseWithDeleted(subquery bool) (bool, error) {
+	tok, pos, _ := p.ScanIgnoreWhitespace()
+	if tok != scanner.WITH {
+		p.Unscan()
+		return false, nil
+	}
+
+	if subquery {
+		return false, &ParseError{Message: "WITH DELETED cannot be used on subqueries", Pos: pos}
+	}
+
+	tok, pos, lit := p.ScanIgnoreWhitespace()
+	if tok != scanner.IDENT || !strings.EqualFold(lit, "DELETED") {
+		return false, newParseError(scanner.Tokstr(tok, lit), []string{"DELETED"}, pos)
+	}
+
+	return true, nil
+}
+
+// parseIndexHint parses the optional index hint of a table: "(USE | IGNORE) INDEX (index_name, ...)".
+// Subqueries don't read indexes directly and can't have hints.
+func (p *Parser) parseIndexHint(subquery bool) (*stream.IndexHint, error) {
+	var hint stream.IndexHint
+
+	tok, pos, lit := p.ScanIgnoreWhitespace()
+	switch {
+	case tok == scanner.IGNORE:
+		hint.Ignore = true
+	case tok == scanner.IDENT && strings.EqualFold(lit, "USE"):
+	default:
+		p.Unscan()
+		return nil, nil
+	}
+
+	if subquery {
+		return nil, &ParseError{Message: "index hints cannot be used on subqueries", Pos: pos}
+	}
+
+	if err := p.parseTokens(scanner.INDEX, scanner.LPAREN); err != nil {
+		return nil, err
+	}
+
+	var err error
+	hint.Indexes, err = p.parseIdentList()
+	if err != nil {
+		return nil, err
+	}
+
+	if err := p.parseTokens(scanner.RPAREN); err != nil {
+		return nil, err
+	}
+
+	return &hint, nil
+}
+
+// parseJoins parses the list of tables joined to the table of the FROM clause.
+func (p *Parser) parseJoins() ([]statement.Join, error) {
+	var joins []statement.Join
+
+	for {
+		var j statement.Join
+
+		switch tok, _, _ := p.ScanIgnoreWhitespace(); tok {
+		case scanner.JOIN:
+		case scanner.INNER:
+			if err := p.parseTokens(scanner.JOIN); err != nil {
+				return nil, err
+			}
+		case scanner.LEFT:
+			if _, err := p.parseOptional(scanner.OUTER); err != nil {
+				return nil, err
+			}
+			if err := p.parseTokens(scanner.JOIN); err != nil {
+				return nil, err
+			}
+			j.Left = true
+		default:
+			p.Unscan()
+			return joins, nil
+		}
+
+		var err error
+		j.TableName, j.Subquery, err = p.parseTableOrSubquery()
+		if err != nil {
+			return nil, err
+		}
+
+		j.Alias, err = p.parseTableAlias()
+		if err != nil {
+			return nil, err
+		}
+
+		j.IndexHint, err = p.parseIndexHint(j.Subquery != nil)
+		if err != nil {
+			return nil, err
+		}
+
+		if err := p.parseTokens(scanner.ON); err != nil {
+			return nil, err
+		}
+
+		j.On, err = p.ParseExpr()
+		if err != nil {
+			return nil, err
+		}
+
+		joins = append(joins, j)
+	}
 }
 
 func (p *Parser) parseGroupBy() (expr.Expr, error) {
@@ -161,21 +441,20 @@ func (p *Parser) parseGroupBy() (expr.Expr, error) {
 	return e, err
 }
 
-func (p *Parser) parseUnion() (*statement.StreamStmt, bool, error) {
-	// Only UNION ALL is supported for the moment
-	if ok, err := p.parseOptional(scanner.UNION, scanner.ALL); !ok || err != nil {
-		return nil, false, err
-	}
-
-	err := p.parseTokens(scanner.SELECT)
-	if err != nil {
-		return nil, false, err
+// parseSetOperator parses the operator combining two select statements, if any.
+func (p *Parser) parseSetOperator() (scanner.Token, bool, bool, error) {
+	tok, _, _ := p.ScanIgnoreWhitespace()
+	switch tok {
+	case scanner.UNION, scanner.INTERSECT, scanner.EXCEPT:
+	default:
+		p.Unscan()
+		return 0, false, false, nil
 	}
 
-	otherSelect, err := p.parseSelectStatement()
+	all, err := p.parseOptional(scanner.ALL)
 	if err != nil {
-		return nil, false, err
+		return 0, false, false, err
 	}
 
-	return otherSelect, false, nil
+	return tok, all, true, nil
 }
@@ -119,6 +119,17 @@ func TestParserSelect(t *testing.T) {
 				Pipe(stream.SortReverse(testutil.ParsePath(t, "a.b.c"))),
 			false,
 		},
+		{"WithMultipleOrderBy", "SELECT a AS x FROM test ORDER BY x DESC, b ASC, c + 1",
+			stream.New(stream.SeqScan("test")).
+				Pipe(stream.Project(&expr.NamedExpr{Expr: testutil.ParsePath(t, "a"), ExprName: "x"})).
+				Pipe(stream.SortBy(
+					expr.OrderTerm{E: testutil.ParsePath(t, "x"), Desc: true},
+					expr.OrderTerm{E: testutil.ParsePath(t, "b")},
+					expr.OrderTerm{E: parser.MustParseExpr("c + 1")},
+				)),
+			false,
+		},
+		{"WithOrderByTrailingComma", "SELECT * FROM test ORDER BY a,", nil, true},
 		{"WithLimit", "SELECT * FROM test WHERE age = 10 LIMIT 20",
 			stream.New(stream.SeqScan("test")).
 				Pipe(stream.Filter(parser.MustParseExpr("age = 10"))).
@@ -165,13 +176,178 @@ func TestParserSelect(t *testing.T) {
 		},
 		{"WithUnionAllThenUnionAll", "SELECT * FROM test1 UNION ALL SELECT * FROM test2 UNION ALL SELECT * FROM test3",
 			stream.New(stream.Concat(
-				stream.New(stream.SeqScan("test1")).Pipe(stream.Project(expr.Wildcard{})),
 				stream.New(stream.Concat(
+					stream.New(stream.SeqScan("test1")).Pipe(stream.Project(expr.Wildcard{})),
 					stream.New(stream.SeqScan("test2")).Pipe(stream.Project(expr.Wildcard{})),
-					stream.New(stream.SeqScan("test3")).Pipe(stream.Project(expr.Wildcard{})),
-				)))),
+				)),
+				stream.New(stream.SeqScan("test3")).Pipe(stream.Project(expr.Wildcard{})),
+			)),
+			false,
+		},
+		{"WithUnion", "SELECT * FROM test1 UNION SELECT * FROM test2",
+			stream.New(stream.Concat(
+				stream.New(stream.SeqScan("test1")).Pipe(stream.Project(expr.Wildcard{})),
+				stream.New(stream.SeqScan("test2")).Pipe(stream.Project(expr.Wildcard{})),
+			)).Pipe(stream.Distinct()),
+			false,
+		},
+		{"WithIntersect", "SELECT a FROM test1 INTERSECT SELECT a FROM test2",
+			stream.New(stream.Intersect(
+				stream.New(stream.SeqScan("test1")).Pipe(stream.Project(testutil.ParseNamedExpr(t, "a"))),
+				stream.New(stream.SeqScan("test2")).Pipe(stream.Project(testutil.ParseNamedExpr(t, "a"))),
+			)),
+			false,
+		},
+		{"WithIntersectAll", "SELECT a FROM test1 INTERSECT ALL SELECT a FROM test2",
+			stream.New(stream.IntersectAll(
+				stream.New(stream.SeqScan("test1")).Pipe(stream.Project(testutil.ParseNamedExpr(t, "a"))),
+				stream.New(stream.SeqScan("test2")).Pipe(stream.Project(testutil.ParseNamedExpr(t, "a"))),
+			)),
+			false,
+		},
+		{"WithExceptThenUnionAll", "SELECT a FROM test1 EXCEPT SELECT a FROM test2 UNION ALL SELECT a FROM test3",
+			stream.New(stream.Concat(
+				stream.New(stream.Except(
+					stream.New(stream.SeqScan("test1")).Pipe(stream.Project(testutil.ParseNamedExpr(t, "a"))),
+					stream.New(stream.SeqScan("test2")).Pipe(stream.Project(testutil.ParseNamedExpr(t, "a"))),
+				)),
+				stream.New(stream.SeqScan("test3")).Pipe(stream.Project(testutil.ParseNamedExpr(t, "a"))),
+			)),
+			false,
+		},
+		{"WithExceptAll", "SELECT a FROM test1 EXCEPT ALL SELECT a FROM test2",
+			stream.New(stream.ExceptAll(
+				stream.New(stream.SeqScan("test1")).Pipe(stream.Project(testutil.ParseNamedExpr(t, "a"))),
+				stream.New(stream.SeqScan("test2")).Pipe(stream.Project(testutil.ParseNamedExpr(t, "a"))),
+			)),
+			false,
+		},
+		{"WithExceptWithoutSelect", "SELECT a FROM test1 EXCEPT test2", nil, true},
+		{"WithJoin", "SELECT * FROM test1 JOIN test2 ON test1.a = test2.b WHERE test1.c > 10",
+			stream.New(stream.SeqScan("test1")).
+				Pipe(stream.Wrap("test1")).
+				Pipe(stream.Join("test2", stream.New(stream.SeqScan("test2")), parser.MustParseExpr("test1.a = test2.b"))).
+				Pipe(stream.Filter(parser.MustParseExpr("test1.c > 10"))).
+				Pipe(stream.Project(expr.Wildcard{})),
+			false,
+		},
+		{"WithJoinsAndAliases", "SELECT a.x FROM test AS a INNER JOIN test AS b ON a.x = b.y LEFT OUTER JOIN test2 AS c ON c.z = b.y",
+			stream.New(stream.SeqScan("test")).
+				Pipe(stream.Wrap("a")).
+				Pipe(stream.Join("b", stream.New(stream.SeqScan("test")), parser.MustParseExpr("a.x = b.y"))).
+				Pipe(stream.LeftJoin("c", stream.New(stream.SeqScan("test2")), parser.MustParseExpr("c.z = b.y"))).
+				Pipe(stream.Project(testutil.ParseNamedExpr(t, "a.x"))),
+			false,
+		},
+		{"WithLeftJoin", "SELECT * FROM test1 LEFT JOIN test2 ON test1.a = test2.b",
+			stream.New(stream.SeqScan("test1")).
+				Pipe(stream.Wrap("test1")).
+				Pipe(stream.LeftJoin("test2", stream.New(stream.SeqScan("test2")), parser.MustParseExpr("test1.a = test2.b"))).
+				Pipe(stream.Project(expr.Wildcard{})),
+			false,
+		},
+		{"WithSubqueryInFrom", "SELECT a FROM (SELECT a FROM test WHERE b > 1)",
+			stream.New(stream.Subquery(
+				stream.New(stream.SeqScan("test")).
+					Pipe(stream.Filter(parser.MustParseExpr("b > 1"))).
+					Pipe(stream.Project(testutil.ParseNamedExpr(t, "a"))),
+			)).
+				Pipe(stream.Project(testutil.ParseNamedExpr(t, "a"))),
+			false,
+		},
+		{"WithAliasedSubqueryInFrom", "SELECT t.a FROM ( SELECT a FROM test ) AS t",
+			stream.New(stream.Subquery(
+				stream.New(stream.SeqScan("test")).
+					Pipe(stream.Project(testutil.ParseNamedExpr(t, "a"))),
+			)).
+				Pipe(stream.Wrap("t")).
+				Pipe(stream.Project(testutil.ParseNamedExpr(t, "t.a"))),
+			false,
+		},
+		{"WithJoinedSubquery", "SELECT * FROM test1 JOIN (SELECT * FROM test2) AS t2 ON test1.a = t2.b",
+			stream.New(stream.SeqScan("test1")).
+				Pipe(stream.Wrap("test1")).
+				Pipe(stream.Join("t2", stream.New(stream.Subquery(
+					stream.New(stream.SeqScan("test2")).Pipe(stream.Project(expr.Wildcard{})),
+				)), parser.MustParseExpr("test1.a = t2.b"))).
+				Pipe(stream.Project(expr.Wildcard{})),
+			false,
+		},
+		{"WithUnclosedSubquery", "SELECT * FROM (SELECT * FROM test", nil, true},
+		{"WithSubqueryWithoutSelect", "SELECT * FROM (test)", nil, true},
+		{"WithAlias", "SELECT t.a FROM test AS t",
+			stream.New(stream.SeqScan("test")).
+				Pipe(stream.Wrap("t")).
+				Pipe(stream.Project(testutil.ParseNamedExpr(t, "t.a"))),
+			false,
+		},
+		{"WithJoinWithoutOn", "SELECT * FROM test1 JOIN test2", nil, true},
+		{"WithInnerWithoutJoin", "SELECT * FROM test1 INNER test2 ON test1.a = test2.b", nil, true},
+		{"WithJoinSameTable", "SELECT * FROM test JOIN test ON test.a = test.b", nil, true},
+		{"WithUseIndex", "SELECT * FROM test USE INDEX (idx_a, idx_b) WHERE a = 1",
+			stream.New(&stream.SeqScanOperator{TableName: "test", IndexHint: &stream.IndexHint{Indexes: []string{"idx_a", "idx_b"}}}).
+				Pipe(stream.Filter(parser.MustParseExpr("a = 1"))).
+				Pipe(stream.Project(expr.Wildcard{})),
+			false,
+		},
+		{"WithIgnoreIndexAndAlias", "SELECT t.a FROM test AS t IGNORE INDEX (idx_a)",
+			stream.New(&stream.SeqScanOperator{TableName: "test", IndexHint: &stream.IndexHint{Ignore: true, Indexes: []string{"idx_a"}}}).
+				Pipe(stream.Wrap("t")).
+				Pipe(stream.Project(testutil.ParseNamedExpr(t, "t.a"))),
+			false,
+		},
+		{"WithJoinIndexHint", "SELECT * FROM test1 JOIN test2 USE INDEX (idx_b) ON test1.a = test2.b",
+			stream.New(stream.SeqScan("test1")).
+				Pipe(stream.Wrap("test1")).
+				Pipe(stream.Join("test2", stream.New(&stream.SeqScanOperator{TableName: "test2", IndexHint: &stream.IndexHint{Indexes: []string{"idx_b"}}}), parser.MustParseExpr("test1.a = test2.b"))).
+				Pipe(stream.Project(expr.Wildcard{})),
+			false,
+		},
+		{"WithIndexHintWithoutIndexes", "SELECT * FROM test USE INDEX ()", nil, true},
+		{"WithIndexHintWithoutParens", "SELECT * FROM test IGNORE INDEX idx_a", nil, true},
+		{"WithIndexHintOnSubquery", "SELECT * FROM (SELECT * FROM test) AS t USE INDEX (idx_a)", nil, true},
+		{"WithAsOf", "SELECT * FROM test AS OF '-5m' WHERE a = 1",
+			stream.New(&stream.SeqScanOperator{TableName: "test", AsOf: parser.MustParseExpr("'-5m'")}).
+				Pipe(stream.Filter(parser.MustParseExpr("a = 1"))).
+				Pipe(stream.Project(expr.Wildcard{})),
+			false,
+		},
+		{"WithAsOfAndAlias", "SELECT t.a FROM test AS OF ? AS t",
+			stream.New(&stream.SeqScanOperator{TableName: "test", AsOf: parser.MustParseExpr("?")}).
+				Pipe(stream.Wrap("t")).
+				Pipe(stream.Project(testutil.ParseNamedExpr(t, "t.a"))),
+			false,
+		},
+		{"WithAliasOf", "SELECT of.a FROM test AS of",
+			stream.New(stream.SeqScan("test")).
+				Pipe(stream.Wrap("of")).
+				Pipe(stream.Project(testutil.ParseNamedExpr(t, "of.a"))),
+			false,
+		},
+		{"WithAsOfOnSubquery", "SELECT * FROM (SELECT * FROM test) AS OF '-5m'", nil, true},
+		{"WithDeleted", "SELECT * FROM test AS t USE INDEX (idx_a) WITH DELETED WHERE a = 1",
+			stream.New(&stream.SeqScanOperator{TableName: "test", IndexHint: &stream.IndexHint{Indexes: []string{"idx_a"}}, WithDeleted: true}).
+				Pipe(stream.Wrap("t")).
+				Pipe(stream.Filter(parser.MustParseExpr("a = 1"))).
+				Pipe(stream.Project(expr.Wildcard{})),
 			false,
 		},
+		{"WithDeletedOnSubquery", "SELECT * FROM (SELECT * FROM test) AS t WITH DELETED", nil, true},
+		{"WithWithoutDeleted", "SELECT * FROM test WITH a = 1", nil, true},
+		{"WithWindow", "SELECT ROW_NUMBER() OVER (PARTITION BY a ORDER BY b DESC) FROM test",
+			stream.New(stream.SeqScan("test")).
+				Pipe(stream.Window(parser.MustParseExpr("ROW_NUMBER() OVER (PARTITION BY a ORDER BY b DESC)").(*expr.WindowExpr))).
+				Pipe(stream.Project(testutil.ParseNamedExpr(t, "ROW_NUMBER() OVER (PARTITION BY a ORDER BY b DESC)"))),
+			false,
+		},
+		{"WithWindowOnContextualKeywords", "SELECT ROW_NUMBER() over (partition BY over ORDER BY partition) FROM test",
+			stream.New(stream.SeqScan("test")).
+				Pipe(stream.Window(parser.MustParseExpr("ROW_NUMBER() OVER (PARTITION BY over ORDER BY partition)").(*expr.WindowExpr))).
+				Pipe(stream.Project(testutil.ParseNamedExpr(t, "ROW_NUMBER() OVER (PARTITION BY over ORDER BY partition)"))),
+			false,
+		},
+		{"WithWindowOnNonWindowFunction", "SELECT TYPEOF(a) OVER () FROM test", nil, true},
+		{"WithWindowFunctionWithoutOver", "SELECT ROW_NUMBER() FROM test", nil, true},
 	}
 
 	for _, test := range tests {
@@ -188,6 +364,43 @@ func TestParserSelect(t *testing.T) {
 	}
 }
 
+func TestParserSubqueryExpr(t *testing.T) {
+	tests := []struct {
+		name     string
+		s        string
+		expected string
+	}{
+		{"Scalar", "(SELECT a FROM test)", "(seqScan(test) | project(a))"},
+		{"Scalar with whitespace", "( SELECT a FROM test ) + 1", "(seqScan(test) | project(a)) + 1"},
+		{"Parentheses", "( 1 + 2) * 3", "(1 + 2) * 3"},
+		{"IN", "a IN (SELECT b FROM test)", "a IN (seqScan(test) | project(b))"},
+		{"NOT IN", "a NOT IN (SELECT b FROM test)", "a NOT IN (seqScan(test) | project(b))"},
+		{"Correlated", "(SELECT COUNT(*) FROM test WHERE test.a = t.a) > 1", "(seqScan(test) | filter(test.a = t.a) | hashAggregate(COUNT(*)) | project(COUNT(*))) > 1"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			e, err := parser.ParseExpr(test.s)
+			require.NoError(t, err)
+			require.Equal(t, test.expected, e.String())
+		})
+	}
+
+	t.Run("IN subquery", func(t *testing.T) {
+		e, err := parser.ParseExpr("a IN (SELECT b FROM test)")
+		require.NoError(t, err)
+		_, ok := e.(expr.Operator).RightHand().(*statement.Subquery)
+		require.True(t, ok)
+	})
+
+	t.Run("Scalar subquery", func(t *testing.T) {
+		e, err := parser.ParseExpr("(SELECT b FROM test)")
+		require.NoError(t, err)
+		_, ok := e.(*statement.ScalarSubquery)
+		require.True(t, ok)
+	})
+}
+
 func BenchmarkSelect(b *testing.B) {
 	for i := 0; i < b.N; i++ {
 		_, _ = parser.ParseQuery("SELECT a, b.c[100].d AS `foo` FROM `some table` WHERE d.e[100] >= 12 AND c.d IN ([1, true], [2, false]) GROUP BY d.e[0] LIMIT 10 + 10 OFFSET 20 - 20 ORDER BY d DESC")
@@ -0,0 +1,46 @@
+package parser
+
+import (
+	"github.com/genjidb/genji/internal/query/statement"
+	"github.com/genjidb/genji/internal/sql/scanner"
+)
+
+// parseSetStatement parses a SET statement.
+// This function assumes the SET token has already been consumed.
+func (p *Parser) parseSetStatement() (statement.Statement, error) {
+	var stmt statement.SetStmt
+	var err error
+
+	stmt.Name, err = p.parseIdent()
+	if err != nil {
+		return nil, err
+	}
+
+	// Parse "=" or "TO".
+	if tok, pos, lit := p.ScanIgnoreWhitespace(); tok != scanner.EQ && tok != scanner.TO {
+		return nil, newParseError(scanner.Tokstr(tok, lit), []string{"=", "TO"}, pos)
+	}
+
+	// Parse optional DEFAULT, which resets the setting.
+	if ok, err := p.parseOptional(scanner.DEFAULT); ok || err != nil {
+		return &stmt, err
+	}
+
+	stmt.Value, err = p.ParseExpr()
+	if err != nil {
+		return nil, err
+	}
+
+	return &stmt, nil
+}
+
+// parseShowStatement parses a SHOW statement.
+// This function assumes the SHOW token has already been consumed.
+func (p *Parser) parseShowStatement() (statement.Statement, error) {
+	name, err := p.parseIdent()
+	if err != nil {
+		return nil, err
+	}
+
+	return &statement.ShowStmt{Name: name}, nil
+}
@@ -0,0 +1,43 @@
+package parser_test
+
+import (
+	"testing"
+
+	"github.com/genjidb/genji/document"
+	"github.com/genjidb/genji/internal/expr"
+	"github.com/genjidb/genji/internal/query/statement"
+	"github.com/genjidb/genji/internal/sql/parser"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParserSet(t *testing.T) {
+	tests := []struct {
+		s        string
+		expected statement.Statement
+		errored  bool
+	}{
+		{"SET strict = true", &statement.SetStmt{Name: "strict", Value: expr.LiteralValue(document.NewBoolValue(true))}, false},
+		{"SET time_zone TO 'Europe/Paris'", &statement.SetStmt{Name: "time_zone", Value: expr.LiteralValue(document.NewTextValue("Europe/Paris"))}, false},
+		{"SET statement_timeout = ?", &statement.SetStmt{Name: "statement_timeout", Value: expr.PositionalParam(1)}, false},
+		{"SET strict = DEFAULT", &statement.SetStmt{Name: "strict"}, false},
+		{"SET strict", nil, true},
+		{"SET = 1", nil, true},
+		{"SET strict 1", nil, true},
+		{"SHOW strict", &statement.ShowStmt{Name: "strict"}, false},
+		{"show show", &statement.ShowStmt{Name: "show"}, false},
+		{"SHOW", nil, true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.s, func(t *testing.T) {
+			q, err := parser.ParseQuery(test.s)
+			if test.errored {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Len(t, q.Statements, 1)
+			require.EqualValues(t, test.expected, q.Statements[0])
+		})
+	}
+}
@@ -0,0 +1,232 @@
+package parser
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/genjidb/genji/internal/database"
+	"github.com/genjidb/genji/internal/expr"
+	"github.com/genjidb/genji/internal/planner"
+	"github.com/genjidb/genji/internal/query/statement"
+	"github.com/genjidb/genji/internal/sql/scanner"
+	"github.com/genjidb/genji/internal/stream"
+)
+
+// isTriggerKeyword reports whether lit is TRIGGER. This word is not a
+// reserved keyword so that it can still be used as an identifier.
+func isTriggerKeyword(tok scanner.Token, lit string) bool {
+	return tok == scanner.IDENT && strings.EqualFold(lit, "TRIGGER")
+}
+
+// parseCreateTriggerStatement parses a create trigger string and returns a Statement AST object.
+// This function assumes the CREATE TRIGGER tokens have already been consumed.
+func (p *Parser) parseCreateTriggerStatement() (*statement.CreateTriggerStmt, error) {
+	var stmt statement.CreateTriggerStmt
+	var err error
+
+	stmt.IfNotExists, err = p.parseOptional(scanner.IF, scanner.NOT, scanner.EXISTS)
+	if err != nil {
+		return nil, err
+	}
+
+	stmt.Info.TriggerName, err = p.parseIdent()
+	if err != nil {
+		pErr := err.(*ParseError)
+		pErr.Expected = []string{"trigger_name"}
+		return nil, pErr
+	}
+
+	tok, pos, lit := p.ScanIgnoreWhitespace()
+	switch {
+	case tok == scanner.IDENT && strings.EqualFold(lit, "BEFORE"):
+		stmt.Info.Timing = database.TriggerBefore
+	case tok == scanner.IDENT && strings.EqualFold(lit, "AFTER"):
+		stmt.Info.Timing = database.TriggerAfter
+	default:
+		return nil, newParseError(scanner.Tokstr(tok, lit), []string{"BEFORE", "AFTER"}, pos)
+	}
+
+	tok, pos, lit = p.ScanIgnoreWhitespace()
+	switch tok {
+	case scanner.INSERT:
+		stmt.Info.Event = database.TriggerInsert
+	case scanner.UPDATE:
+		stmt.Info.Event = database.TriggerUpdate
+	case scanner.DELETE:
+		stmt.Info.Event = database.TriggerDelete
+	default:
+		return nil, newParseError(scanner.Tokstr(tok, lit), []string{"INSERT", "UPDATE", "DELETE"}, pos)
+	}
+
+	if err := p.parseTokens(scanner.ON); err != nil {
+		return nil, err
+	}
+
+	stmt.Info.TableName, err = p.parseIdent()
+	if err != nil {
+		pErr := err.(*ParseError)
+		pErr.Expected = []string{"table_name"}
+		return nil, pErr
+	}
+
+	// triggers always run for each document, FOR EACH ROW is optional
+	if ok, err := p.parseOptional(scanner.FOR); err != nil {
+		return nil, err
+	} else if ok {
+		for _, word := range []string{"EACH", "ROW"} {
+			if tok, pos, lit := p.ScanIgnoreWhitespace(); tok != scanner.IDENT || !strings.EqualFold(lit, word) {
+				return nil, newParseError(scanner.Tokstr(tok, lit), []string{word}, pos)
+			}
+		}
+	}
+
+	_, start, _ := p.ScanIgnoreWhitespace()
+	p.Unscan()
+
+	_, _, err = p.parseTriggerProgram()
+	if err != nil {
+		return nil, err
+	}
+
+	// the program ends where the next token starts
+	_, end, _ := p.ScanIgnoreWhitespace()
+	p.Unscan()
+
+	prog := triggerProgram{sql: strings.TrimSpace(p.src.text(start, end))}
+
+	// parse the program on its own to make sure the stored text is valid
+	pp := NewParser(strings.NewReader(prog.sql))
+	_, _, err = pp.parseTriggerProgramOnly()
+	if err != nil {
+		return nil, err
+	}
+	if pp.orderedParams > 0 || len(pp.namedParams) > 0 {
+		return nil, errors.New("triggers cannot use parameters")
+	}
+
+	stmt.Info.Program = &prog
+	return &stmt, nil
+}
+
+// parseTriggerProgram parses the optional WHEN clause of a trigger, followed by
+// the INSERT, UPDATE and DELETE statements it runs, between BEGIN and END.
+func (p *Parser) parseTriggerProgram() (expr.Expr, []*statement.StreamStmt, error) {
+	var cond expr.Expr
+	var err error
+
+	p.inTrigger = true
+	defer func() { p.inTrigger = false }()
+
+	tok, pos, lit := p.ScanIgnoreWhitespace()
+	if tok == scanner.IDENT && strings.EqualFold(lit, "WHEN") {
+		cond, err = p.ParseExpr()
+		if err != nil {
+			return nil, nil, err
+		}
+
+		tok, pos, lit = p.ScanIgnoreWhitespace()
+	}
+
+	if tok != scanner.BEGIN {
+		return nil, nil, newParseError(scanner.Tokstr(tok, lit), []string{"BEGIN"}, pos)
+	}
+
+	var stmts []*statement.StreamStmt
+	for {
+		tok, pos, lit := p.ScanIgnoreWhitespace()
+		if tok == scanner.IDENT && strings.EqualFold(lit, "END") && len(stmts) > 0 {
+			return cond, stmts, nil
+		}
+
+		var s *statement.StreamStmt
+		switch tok {
+		case scanner.INSERT:
+			s, err = p.parseInsertStatement()
+		case scanner.UPDATE:
+			s, err = p.parseUpdateStatement()
+		case scanner.DELETE:
+			s, err = p.parseDeleteStatement()
+		default:
+			expected := []string{"INSERT", "UPDATE", "DELETE"}
+			if len(stmts) > 0 {
+				expected = append(expected, "END")
+			}
+			return nil, nil, newParseError(scanner.Tokstr(tok, lit), expected, pos)
+		}
+		if err != nil {
+			return nil, nil, err
+		}
+
+		if err := p.parseTokens(scanner.SEMICOLON); err != nil {
+			return nil, nil, err
+		}
+
+		stmts = append(stmts, s)
+	}
+}
+
+// parseTriggerProgramOnly parses the program of a trigger, which must be
+// followed by nothing else.
+func (p *Parser) parseTriggerProgramOnly() (expr.Expr, []*statement.StreamStmt, error) {
+	cond, stmts, err := p.parseTriggerProgram()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if tok, pos, lit := p.ScanIgnoreWhitespace(); tok != scanner.EOF {
+		return nil, nil, newParseError(scanner.Tokstr(tok, lit), []string{"EOF"}, pos)
+	}
+
+	return cond, stmts, nil
+}
+
+// parseDropTriggerStatement parses a drop trigger string and returns a Statement AST object.
+// This function assumes the DROP TRIGGER tokens have already been consumed.
+func (p *Parser) parseDropTriggerStatement() (statement.DropTriggerStmt, error) {
+	var stmt statement.DropTriggerStmt
+	var err error
+
+	stmt.IfExists, err = p.parseOptional(scanner.IF, scanner.EXISTS)
+	if err != nil {
+		return stmt, err
+	}
+
+	stmt.TriggerName, err = p.parseIdent()
+	if err != nil {
+		pErr := err.(*ParseError)
+		pErr.Expected = []string{"trigger_name"}
+		return stmt, pErr
+	}
+
+	return stmt, nil
+}
+
+// triggerProgram is the condition and the statements of a trigger.
+// They are parsed every time the trigger is about to be fired.
+type triggerProgram struct {
+	sql string
+}
+
+var _ stream.TriggerProgram = (*triggerProgram)(nil)
+
+// Prepare parses the program and optimizes the stream of every statement.
+func (t *triggerProgram) Prepare(tx *database.Transaction, catalog database.Catalog) (expr.Expr, []*stream.Stream, error) {
+	cond, stmts, err := NewParser(strings.NewReader(t.sql)).parseTriggerProgramOnly()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	streams := make([]*stream.Stream, len(stmts))
+	for i, s := range stmts {
+		streams[i], err = planner.Optimize(s.Stream, catalog, tx)
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+
+	return cond, streams, nil
+}
+
+func (t *triggerProgram) String() string {
+	return t.sql
+}
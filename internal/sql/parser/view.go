@@ -0,0 +1,162 @@
+package parser
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/genjidb/genji/internal/planner"
+	"github.com/genjidb/genji/internal/query/statement"
+	"github.com/genjidb/genji/internal/sql/scanner"
+	"github.com/genjidb/genji/internal/stream"
+)
+
+// isViewKeyword reports whether lit is VIEW. This word is not a
+// reserved keyword so that it can still be used as an identifier.
+func isViewKeyword(tok scanner.Token, lit string) bool {
+	return tok == scanner.IDENT && strings.EqualFold(lit, "VIEW")
+}
+
+// parseCreateViewStatement parses a create view string and returns a Statement AST object.
+// This function assumes the CREATE VIEW or CREATE OR REPLACE VIEW tokens have already been consumed.
+func (p *Parser) parseCreateViewStatement(orReplace bool) (*statement.CreateViewStmt, error) {
+	stmt := statement.CreateViewStmt{OrReplace: orReplace}
+	var err error
+
+	// replacing a view and ignoring an existing one are mutually exclusive
+	if !orReplace {
+		stmt.IfNotExists, err = p.parseOptional(scanner.IF, scanner.NOT, scanner.EXISTS)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	stmt.Info.ViewName, err = p.parseIdent()
+	if err != nil {
+		pErr := err.(*ParseError)
+		pErr.Expected = []string{"view_name"}
+		return nil, pErr
+	}
+
+	if err := p.parseTokens(scanner.AS); err != nil {
+		return nil, err
+	}
+
+	tok, start, lit := p.ScanIgnoreWhitespace()
+	if tok != scanner.SELECT {
+		return nil, newParseError(scanner.Tokstr(tok, lit), []string{"SELECT"}, start)
+	}
+
+	_, err = p.parseSelectStatement()
+	if err != nil {
+		return nil, err
+	}
+
+	// the query ends where the next token starts
+	_, end, _ := p.ScanIgnoreWhitespace()
+	p.Unscan()
+
+	q := viewQuery{sql: strings.TrimSpace(p.src.text(start, end))}
+
+	// parse the query on its own to make sure the stored text is valid
+	qp := NewParser(strings.NewReader(q.sql))
+	s, err := qp.parseViewQuery()
+	if err != nil {
+		return nil, err
+	}
+	if qp.orderedParams > 0 || len(qp.namedParams) > 0 {
+		return nil, errors.New("views cannot use parameters")
+	}
+	if !s.ReadOnly {
+		return nil, errors.New("views must be read-only")
+	}
+
+	q.relations = s.Relations()
+	stmt.Info.Query = &q
+
+	return &stmt, nil
+}
+
+// parseViewQuery parses the SELECT statement of a view, which must be the only statement.
+func (p *Parser) parseViewQuery() (*statement.StreamStmt, error) {
+	if err := p.parseTokens(scanner.SELECT); err != nil {
+		return nil, err
+	}
+
+	s, err := p.parseSelectStatement()
+	if err != nil {
+		return nil, err
+	}
+
+	if tok, pos, lit := p.ScanIgnoreWhitespace(); tok != scanner.EOF {
+		return nil, newParseError(scanner.Tokstr(tok, lit), []string{"EOF"}, pos)
+	}
+
+	return s, nil
+}
+
+// parseDropViewStatement parses a drop view string and returns a Statement AST object.
+// This function assumes the DROP VIEW tokens have already been consumed.
+func (p *Parser) parseDropViewStatement() (statement.DropViewStmt, error) {
+	var stmt statement.DropViewStmt
+	var err error
+
+	stmt.IfExists, err = p.parseOptional(scanner.IF, scanner.EXISTS)
+	if err != nil {
+		return stmt, err
+	}
+
+	stmt.ViewName, err = p.parseIdent()
+	if err != nil {
+		pErr := err.(*ParseError)
+		pErr.Expected = []string{"view_name"}
+		return stmt, pErr
+	}
+
+	stmt.Cascade = p.parseDropBehavior()
+	return stmt, nil
+}
+
+// parseDropBehavior parses the optional CASCADE or RESTRICT keyword
+// ending a DROP statement and reports whether it was CASCADE.
+func (p *Parser) parseDropBehavior() bool {
+	tok, _, lit := p.ScanIgnoreWhitespace()
+	if tok == scanner.IDENT {
+		switch {
+		case strings.EqualFold(lit, "CASCADE"):
+			return true
+		case strings.EqualFold(lit, "RESTRICT"):
+			return false
+		}
+	}
+
+	p.Unscan()
+	return false
+}
+
+// viewQuery is the SELECT statement defining a view.
+// A new stream is parsed every time the view is read.
+type viewQuery struct {
+	sql       string
+	relations []string
+}
+
+var _ planner.ViewQuery = (*viewQuery)(nil)
+
+// Relations returns the names of the tables and views read by the query.
+func (q *viewQuery) Relations() []string {
+	return q.relations
+}
+
+// Stream parses the query and returns its stream.
+func (q *viewQuery) Stream() (*stream.Stream, error) {
+	s, err := NewParser(strings.NewReader(q.sql)).parseViewQuery()
+	if err != nil {
+		return nil, err
+	}
+
+	return s.Stream, nil
+}
+
+func (q *viewQuery) String() string {
+	return q.sql
+}
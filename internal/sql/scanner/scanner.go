@@ -18,7 +18,7 @@ func init() {
 	for tok := keywordBeg + 1; tok < keywordEnd; tok++ {
 		keywords[strings.ToLower(tokens[tok])] = tok
 	}
-	for _, tok := range []Token{AND, OR, TRUE, FALSE, NULL, IN, IS, LIKE, BETWEEN} {
+	for _, tok := range []Token{AND, OR, TRUE, FALSE, NULL, IN, IS, LIKE, ILIKE, BETWEEN, CONTAINS} {
 		keywords[strings.ToLower(tokens[tok])] = tok
 	}
 }
@@ -138,6 +138,8 @@ func (s *scanner) Scan() (tok Token, pos Pos, lit string) {
 	case '>':
 		if ch1, _ := s.r.read(); ch1 == '=' {
 			return GTE, pos, ""
+		} else if ch1 == '>' {
+			return SHIFTRIGHT, pos, ""
 		}
 		s.r.unread()
 		return GT, pos, ""
@@ -146,6 +148,8 @@ func (s *scanner) Scan() (tok Token, pos Pos, lit string) {
 			return LTE, pos, ""
 		} else if ch1 == '>' {
 			return NEQ, pos, ""
+		} else if ch1 == '<' {
+			return SHIFTLEFT, pos, ""
 		}
 		s.r.unread()
 		return LT, pos, ""
@@ -49,6 +49,8 @@ func TestScanner_Scan(t *testing.T) {
 		{s: `<=`, tok: LTE},
 		{s: `>`, tok: GT},
 		{s: `>=`, tok: GTE},
+		{s: `<<`, tok: SHIFTLEFT},
+		{s: `>>`, tok: SHIFTRIGHT},
 		{s: `IN`, tok: IN},
 		{s: `IS`, tok: IS},
 		{s: `LIKE`, tok: LIKE},
@@ -127,6 +129,7 @@ func TestScanner_Scan(t *testing.T) {
 		{s: `CAST`, tok: CAST},
 		{s: `COMMIT`, tok: COMMIT},
 		{s: `CONFLICT`, tok: CONFLICT},
+		{s: `COPY`, tok: IDENT, lit: `COPY`},
 		{s: `CREATE`, tok: CREATE},
 		{s: `CYCLE`, tok: CYCLE},
 		{s: `DEFAULT`, tok: DEFAULT},
@@ -135,6 +138,7 @@ func TestScanner_Scan(t *testing.T) {
 		{s: `DO`, tok: DO},
 		{s: `DISTINCT`, tok: DISTINCT},
 		{s: `DROP`, tok: DROP},
+		{s: `EXCEPT`, tok: EXCEPT},
 		{s: `EXPLAIN`, tok: EXPLAIN},
 		{s: `GROUP`, tok: GROUP},
 		{s: `FIELD`, tok: FIELD},
@@ -143,8 +147,12 @@ func TestScanner_Scan(t *testing.T) {
 		{s: `IGNORE`, tok: IGNORE},
 		{s: `INCREMENT`, tok: INCREMENT},
 		{s: `INDEX`, tok: INDEX},
+		{s: `INNER`, tok: INNER},
 		{s: `INSERT`, tok: INSERT},
+		{s: `INTERSECT`, tok: INTERSECT},
 		{s: `INTO`, tok: INTO},
+		{s: `JOIN`, tok: JOIN},
+		{s: `LEFT`, tok: LEFT},
 		{s: `LIMIT`, tok: LIMIT},
 		{s: `MAXVALUE`, tok: MAXVALUE},
 		{s: `MINVALUE`, tok: MINVALUE},
@@ -155,7 +163,11 @@ func TestScanner_Scan(t *testing.T) {
 		{s: `ONLY`, tok: ONLY},
 		{s: `OFFSET`, tok: OFFSET},
 		{s: `ORDER`, tok: ORDER},
+		{s: `OUTER`, tok: OUTER},
+		{s: `OVER`, tok: IDENT, lit: `OVER`},
+		{s: `PARTITION`, tok: IDENT, lit: `PARTITION`},
 		{s: `PRIMARY`, tok: PRIMARY},
+		{s: `PURGE`, tok: PURGE},
 		{s: `READ`, tok: READ},
 		{s: `REINDEX`, tok: REINDEX},
 		{s: `RENAME`, tok: RENAME},
@@ -184,7 +196,13 @@ func TestScanner_Scan(t *testing.T) {
 		{s: "BOOL", tok: TYPEBOOL},
 		{s: "DOUBLE", tok: TYPEDOUBLE},
 		{s: "INTEGER", tok: TYPEINTEGER},
+		{s: "INTERVAL", tok: IDENT, lit: "INTERVAL"},
 		{s: "TEXT", tok: TYPETEXT},
+		{s: "DATE", tok: IDENT, lit: "DATE"},
+		{s: "DECIMAL", tok: IDENT, lit: "DECIMAL"},
+		{s: "NUMERIC", tok: IDENT, lit: "NUMERIC"},
+		{s: "TIMESTAMP", tok: IDENT, lit: "TIMESTAMP"},
+		{s: "VECTOR", tok: IDENT, lit: "VECTOR"},
 	}
 
 	for i, tt := range tests {
@@ -41,6 +41,8 @@ const (
 	BITWISEAND // &
 	BITWISEOR  // |
 	BITWISEXOR // ^
+	SHIFTLEFT  // <<
+	SHIFTRIGHT // >>
 
 	AND // AND
 	OR  // OR
@@ -58,8 +60,10 @@ const (
 	IS       // IS
 	ISN      // IS NOT
 	LIKE     // LIKE
+	ILIKE    // ILIKE
 	CONCAT   // ||
 	BETWEEN  // BETWEEN
+	CONTAINS // CONTAINS
 	operatorEnd
 
 	LPAREN      // (
@@ -79,6 +83,7 @@ const (
 	ADD_KEYWORD
 	ALL
 	ALTER
+	ANY
 	AS
 	ASC
 	BEGIN
@@ -95,6 +100,7 @@ const (
 	DISTINCT
 	DO
 	DROP
+	EXCEPT
 	EXISTS
 	EXPLAIN
 	FIELD
@@ -105,9 +111,13 @@ const (
 	IGNORE
 	INCREMENT
 	INDEX
+	INNER
 	INSERT
+	INTERSECT
 	INTO
+	JOIN
 	KEY
+	LEFT
 	LIMIT
 	MAXVALUE
 	MINVALUE
@@ -119,8 +129,10 @@ const (
 	ON
 	ONLY
 	ORDER
+	OUTER
 	PRECISION
 	PRIMARY
+	PURGE
 	READ
 	REINDEX
 	RENAME
@@ -130,6 +142,7 @@ const (
 	SELECT
 	SEQUENCE
 	SET
+	SOME
 	START
 	TABLE
 	TO
@@ -162,6 +175,7 @@ const (
 	TYPETEXT
 	TYPETINYINT
 	TYPEREAL
+	TYPESERIAL
 	TYPEVARCHAR
 
 	keywordEnd
@@ -191,7 +205,11 @@ var tokens = [...]string{
 	BITWISEAND: "&",
 	BITWISEOR:  "|",
 	BITWISEXOR: "^",
+	SHIFTLEFT:  "<<",
+	SHIFTRIGHT: ">>",
+	CONCAT:     "||",
 	BETWEEN:    "BETWEEN",
+	CONTAINS:   "CONTAINS",
 
 	AND: "AND",
 	OR:  "OR",
@@ -207,6 +225,7 @@ var tokens = [...]string{
 	IN:       "IN",
 	IS:       "IS",
 	LIKE:     "LIKE",
+	ILIKE:    "ILIKE",
 
 	LPAREN:      "(",
 	RPAREN:      ")",
@@ -223,6 +242,7 @@ var tokens = [...]string{
 	ADD_KEYWORD: "ADD",
 	ALL:         "ALL",
 	ALTER:       "ALTER",
+	ANY:         "ANY",
 	AS:          "AS",
 	ASC:         "ASC",
 	BEGIN:       "BEGIN",
@@ -239,10 +259,12 @@ var tokens = [...]string{
 	DESC:        "DESC",
 	DISTINCT:    "DISTINCT",
 	DROP:        "DROP",
+	EXCEPT:      "EXCEPT",
 	EXISTS:      "EXISTS",
 	EXPLAIN:     "EXPLAIN",
 	GROUP:       "GROUP",
 	KEY:         "KEY",
+	LEFT:        "LEFT",
 	FIELD:       "FIELD",
 	FOR:         "FOR",
 	FROM:        "FROM",
@@ -250,8 +272,11 @@ var tokens = [...]string{
 	IGNORE:      "IGNORE",
 	INCREMENT:   "INCREMENT",
 	INDEX:       "INDEX",
+	INNER:       "INNER",
 	INSERT:      "INSERT",
+	INTERSECT:   "INTERSECT",
 	INTO:        "INTO",
+	JOIN:        "JOIN",
 	LIMIT:       "LIMIT",
 	MAXVALUE:    "MAXVALUE",
 	MINVALUE:    "MINVALUE",
@@ -263,8 +288,10 @@ var tokens = [...]string{
 	ON:          "ON",
 	ONLY:        "ONLY",
 	ORDER:       "ORDER",
+	OUTER:       "OUTER",
 	PRECISION:   "PRECISION",
 	PRIMARY:     "PRIMARY",
+	PURGE:       "PURGE",
 	READ:        "READ",
 	REINDEX:     "REINDEX",
 	RENAME:      "RENAME",
@@ -274,6 +301,7 @@ var tokens = [...]string{
 	START:       "START",
 	SELECT:      "SELECT",
 	SET:         "SET",
+	SOME:        "SOME",
 	SEQUENCE:    "SEQUENCE",
 	TABLE:       "TABLE",
 	TO:          "TO",
@@ -305,6 +333,7 @@ var tokens = [...]string{
 	TYPETEXT:      "TEXT",
 	TYPETINYINT:   "TINYINT",
 	TYPEREAL:      "REAL",
+	TYPESERIAL:    "SERIAL",
 	TYPEVARCHAR:   "VARCHAR",
 }
 
@@ -325,11 +354,11 @@ func (tok Token) Precedence() int {
 		return 1
 	case AND:
 		return 2
-	case EQ, NEQ, IS, IN, LIKE, EQREGEX, NEQREGEX, BETWEEN:
+	case EQ, NEQ, IS, IN, LIKE, ILIKE, EQREGEX, NEQREGEX, BETWEEN, CONTAINS:
 		return 3
 	case LT, LTE, GT, GTE:
 		return 4
-	case BITWISEOR, BITWISEXOR, BITWISEAND:
+	case BITWISEOR, BITWISEXOR, BITWISEAND, SHIFTLEFT, SHIFTRIGHT:
 		return 5
 	case ADD, SUB:
 		return 6
@@ -2,6 +2,7 @@ package stream
 
 import (
 	"bytes"
+	"errors"
 	"strings"
 
 	"github.com/genjidb/genji/document"
@@ -35,14 +36,35 @@ func (op *HashAggregateOperator) Iterate(in *environment.Environment, f func(out
 		return err
 	}
 
+	return op.aggregate(in, encGroup, workMem(in), 0, func(fn func(out *environment.Environment) error) error {
+		return op.Prev.Iterate(in, fn)
+	}, f)
+}
+
+// aggregate groups the documents returned by iterate and outputs one value per group.
+// If the groups don't fit in the memory budget, the documents of the groups that don't fit
+// are written to temporary files, partitioned by group, and aggregated once the groups
+// kept in memory have been returned.
+func (op *HashAggregateOperator) aggregate(in *environment.Environment, encGroup func(env *environment.Environment) (string, error), budget int64, depth int,
+	iterate func(fn func(out *environment.Environment) error) error, f func(out *environment.Environment) error) error {
 	// keep order of groups as they arrive to provide deterministic results.
 	var encGroupNames []string
 
 	// store a groupAggregator per group
 	aggregators := make(map[string]*groupAggregator)
 
+	// estimated memory used by the aggregators
+	var size int64
+
+	var spill *spillPartitioner
+	defer func() {
+		if spill != nil {
+			spill.Close()
+		}
+	}()
+
 	// iterate over s and for each group, aggregate the incoming document
-	err = op.Prev.Iterate(in, func(out *environment.Environment) error {
+	err := iterate(func(out *environment.Environment) error {
 		// we extract the group name from the environment and encode it
 		// to be used as a key to the aggregators map.
 		groupName, err := encGroup(out)
@@ -53,6 +75,19 @@ func (op *HashAggregateOperator) Iterate(in *environment.Environment, f func(out
 		// get the group aggregator from the map or create a new one.
 		a, ok := aggregators[groupName]
 		if !ok {
+			cost := int64(len(groupName) + hashEntryOverhead*(1+len(op.Builders)))
+
+			// if there is not enough memory for a new group,
+			// write the document to disk to aggregate it later.
+			if budget > 0 && depth < maxSpillDepth && len(aggregators) > 0 && size+cost > budget {
+				if spill == nil {
+					spill = newSpillPartitioner(spillCodec(in), depth)
+				}
+
+				return spillGroupedDocument(spill, groupName, out)
+			}
+
+			size += cost
 			a = newGroupAggregator(out, op.Builders)
 			aggregators[groupName] = a
 			encGroupNames = append(encGroupNames, groupName)
@@ -71,13 +106,18 @@ func (op *HashAggregateOperator) Iterate(in *environment.Environment, f func(out
 	// Ex: For `SELECT COUNT(*) FROM foo`, if `foo` is empty
 	// we want the following result:
 	// {"COUNT(*)": 0}
-	if len(aggregators) == 0 {
+	if len(aggregators) == 0 && depth == 0 {
 		aggregators["_"] = newGroupAggregator(nil, op.Builders)
 		encGroupNames = append(encGroupNames, "_")
 	}
 
 	// we loop over the groups in the order they arrived.
+	canceled := contextChecker(in)
 	for _, groupName := range encGroupNames {
+		if err := canceled(); err != nil {
+			return err
+		}
+
 		r := aggregators[groupName]
 		e, err := r.Flush(in)
 		if err != nil {
@@ -89,6 +129,30 @@ func (op *HashAggregateOperator) Iterate(in *environment.Environment, f func(out
 		}
 	}
 
+	if spill == nil {
+		return nil
+	}
+
+	// release the memory used by the groups already returned
+	// and aggregate each partition separately.
+	aggregators = nil
+	for _, p := range spill.Partitions() {
+		p := p
+		err = op.aggregate(in, encGroup, budget, depth+1, func(fn func(out *environment.Environment) error) error {
+			return p.Iterate(in.GetContext(), func(d document.Document) error {
+				env, err := restoreGroupedEnv(in, d)
+				if err != nil {
+					return err
+				}
+
+				return fn(env)
+			})
+		}, f)
+		if err != nil {
+			return err
+		}
+	}
+
 	return nil
 }
 
@@ -105,6 +169,85 @@ func (op *HashAggregateOperator) String() string {
 	return stringutil.Sprintf("hashAggregate(%s)", sb.String())
 }
 
+// A StreamAggregateOperator consumes a stream sorted by group and outputs one value per group.
+type StreamAggregateOperator struct {
+	baseOperator
+	Builders []expr.AggregatorBuilder
+}
+
+// StreamAggregate does the same as HashAggregate but assumes that the documents
+// of each group are contiguous in the stream, which is the case if the stream
+// is sorted by the _group value.
+// Each group is returned as soon as a document of another group arrives,
+// and only one group is kept in memory at a time.
+func StreamAggregate(builders ...expr.AggregatorBuilder) *StreamAggregateOperator {
+	return &StreamAggregateOperator{Builders: builders}
+}
+
+func (op *StreamAggregateOperator) Iterate(in *environment.Environment, f func(out *environment.Environment) error) error {
+	encGroup, err := newGroupEncoder()
+	if err != nil {
+		return err
+	}
+
+	var current *groupAggregator
+	var currentName string
+
+	err = op.Prev.Iterate(in, func(out *environment.Environment) error {
+		groupName, err := encGroup(out)
+		if err != nil {
+			return err
+		}
+
+		if current == nil || groupName != currentName {
+			if current != nil {
+				e, err := current.Flush(in)
+				if err != nil {
+					return err
+				}
+				err = f(e)
+				if err != nil {
+					return err
+				}
+			}
+
+			current = newGroupAggregator(out, op.Builders)
+			currentName = groupName
+		}
+
+		return current.Aggregate(out)
+	})
+	if err != nil {
+		return err
+	}
+
+	// if the stream was empty, return one default group,
+	// like HashAggregate does
+	if current == nil {
+		current = newGroupAggregator(nil, op.Builders)
+	}
+
+	e, err := current.Flush(in)
+	if err != nil {
+		return err
+	}
+
+	return f(e)
+}
+
+func (op *StreamAggregateOperator) String() string {
+	var sb strings.Builder
+
+	for i, agg := range op.Builders {
+		sb.WriteString(agg.(stringutil.Stringer).String())
+		if i+1 < len(op.Builders) {
+			sb.WriteString(", ")
+		}
+	}
+
+	return stringutil.Sprintf("streamAggregate(%s)", sb.String())
+}
+
 // newGroupEncoder returns a function that encodes the _group environment variable using a document.ValueEncoder.
 // If the _group variable doesn't exist, the group is set to null.
 func newGroupEncoder() (func(env *environment.Environment) (string, error), error) {
@@ -117,9 +260,10 @@ func newGroupEncoder() (func(env *environment.Environment) (string, error), erro
 	}
 	nullGroupName := b.String()
 	b.Reset()
+	groupPath := document.NewPath(groupEnvKey)
 
 	return func(env *environment.Environment) (string, error) {
-		groupValue, ok := env.Get(document.NewPath(groupEnvKey))
+		groupValue, ok := env.Get(groupPath)
 		if !ok {
 			return nullGroupName, nil
 		}
@@ -134,6 +278,55 @@ func newGroupEncoder() (func(env *environment.Environment) (string, error), erro
 	}, nil
 }
 
+// spillGroupedDocument writes the document of the environment to disk,
+// alongside the group it belongs to.
+func spillGroupedDocument(spill *spillPartitioner, groupName string, env *environment.Environment) error {
+	d, ok := env.GetDocument()
+	if !ok {
+		return errors.New("missing document")
+	}
+
+	fb := document.NewFieldBuffer()
+	fb.Add("doc", document.NewDocumentValue(d))
+	if group, ok := env.Get(document.NewPath(groupEnvKey)); ok {
+		groupExpr, _ := env.Get(document.NewPath(groupExprEnvKey))
+		fb.Add("group", group)
+		fb.Add("group_expr", groupExpr)
+	}
+
+	return spill.Write(groupName, fb)
+}
+
+// restoreGroupedEnv returns an environment containing a document
+// and its group, as written by spillGroupedDocument.
+func restoreGroupedEnv(in *environment.Environment, d document.Document) (*environment.Environment, error) {
+	var env environment.Environment
+	env.SetOuter(in)
+
+	v, err := d.GetByField("doc")
+	if err != nil {
+		return nil, err
+	}
+	env.SetDocument(v.V.(document.Document))
+
+	group, err := d.GetByField("group")
+	if err == document.ErrFieldNotFound {
+		return &env, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	groupExpr, err := d.GetByField("group_expr")
+	if err != nil {
+		return nil, err
+	}
+
+	env.Set(groupEnvKey, group)
+	env.Set(groupExprEnvKey, groupExpr)
+	return &env, nil
+}
+
 // a groupAggregator is an aggregator for a whole group of documents.
 // It applies all the aggregators for each documents and returns a new document with the
 // result of the aggregation.
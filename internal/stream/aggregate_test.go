@@ -1,9 +1,11 @@
 package stream_test
 
 import (
+	"fmt"
 	"testing"
 
 	"github.com/genjidb/genji/document"
+	"github.com/genjidb/genji/internal/database"
 	"github.com/genjidb/genji/internal/environment"
 	"github.com/genjidb/genji/internal/expr"
 	"github.com/genjidb/genji/internal/expr/functions"
@@ -83,11 +85,103 @@ func TestAggregate(t *testing.T) {
 		})
 	}
 
+	t.Run("Spill", func(t *testing.T) {
+		// a tiny budget forces every group but the first one to be written to disk
+		session := database.NewSession()
+		err := session.Set("work_mem", document.NewIntegerValue(1))
+		require.NoError(t, err)
+
+		s := stream.New(stream.Documents(generateSeqDocs(t, 100)...)).
+			Pipe(stream.GroupBy(parser.MustParseExpr("a % 10"))).
+			Pipe(stream.HashAggregate(&functions.Count{Wildcard: true}, &functions.Sum{Expr: parser.MustParseExpr("a")}))
+
+		got := make(map[int64]string)
+		err = s.Iterate(&environment.Environment{Session: session}, func(env *environment.Environment) error {
+			d, ok := env.GetDocument()
+			require.True(t, ok)
+			group, err := d.GetByField("a % 10")
+			require.NoError(t, err)
+			data, err := document.MarshalJSON(d)
+			require.NoError(t, err)
+			got[group.V.(int64)] = string(data)
+			return nil
+		})
+		require.NoError(t, err)
+
+		require.Len(t, got, 10)
+		for i := int64(0); i < 10; i++ {
+			require.JSONEq(t, fmt.Sprintf(`{"a %% 10": %d, "COUNT(*)": 10, "SUM(a)": %d}`, i, 450+i*10), got[i])
+		}
+	})
+
 	t.Run("String", func(t *testing.T) {
 		require.Equal(t, `hashAggregate(a(), b())`, stream.HashAggregate(makeAggregatorBuilders("a()", "b()")...).String())
 	})
 }
 
+func TestStreamAggregate(t *testing.T) {
+	tests := []struct {
+		name     string
+		groupBy  expr.Expr
+		builders []expr.AggregatorBuilder
+		in       []document.Document
+		want     []document.Document
+	}{
+		{
+			"count",
+			nil,
+			[]expr.AggregatorBuilder{&functions.Count{Wildcard: true}},
+			generateSeqDocs(t, 3),
+			[]document.Document{testutil.MakeDocument(t, `{"COUNT(*)": 3}`)},
+		},
+		{
+			"count/groupBy",
+			parser.MustParseExpr("a / 4"),
+			[]expr.AggregatorBuilder{&functions.Count{Expr: parser.MustParseExpr("a")}, &functions.Sum{Expr: parser.MustParseExpr("a")}},
+			generateSeqDocs(t, 10),
+			[]document.Document{
+				testutil.MakeDocument(t, `{"a / 4": 0, "COUNT(a)": 4, "SUM(a)": 6}`),
+				testutil.MakeDocument(t, `{"a / 4": 1, "COUNT(a)": 4, "SUM(a)": 22}`),
+				testutil.MakeDocument(t, `{"a / 4": 2, "COUNT(a)": 2, "SUM(a)": 17}`),
+			},
+		},
+		{
+			"count/noInput",
+			nil,
+			[]expr.AggregatorBuilder{&functions.Count{Expr: parser.MustParseExpr("a")}},
+			nil,
+			[]document.Document{testutil.MakeDocument(t, `{"COUNT(a)": 0}`)},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			s := stream.New(stream.Documents(test.in...))
+			if test.groupBy != nil {
+				s = s.Pipe(stream.GroupBy(test.groupBy))
+			}
+
+			s = s.Pipe(stream.StreamAggregate(test.builders...))
+
+			var got []document.Document
+			err := s.Iterate(new(environment.Environment), func(env *environment.Environment) error {
+				d, ok := env.GetDocument()
+				require.True(t, ok)
+				var fb document.FieldBuffer
+				fb.Copy(d)
+				got = append(got, &fb)
+				return nil
+			})
+			require.NoError(t, err)
+			require.Equal(t, test.want, got)
+		})
+	}
+
+	t.Run("String", func(t *testing.T) {
+		require.Equal(t, `streamAggregate(a(), b())`, stream.StreamAggregate(makeAggregatorBuilders("a()", "b()")...).String())
+	})
+}
+
 type fakeAggregator struct {
 	count int64
 	name  string
@@ -0,0 +1,805 @@
+package stream
+
+import (
+	"bytes"
+	"strings"
+
+	"github.com/genjidb/genji/document"
+	"github.com/genjidb/genji/internal/database"
+	"github.com/genjidb/genji/internal/environment"
+	"github.com/genjidb/genji/internal/expr"
+	"github.com/genjidb/genji/internal/expr/functions"
+	"github.com/genjidb/genji/internal/sql/scanner"
+	"github.com/genjidb/genji/internal/stringutil"
+)
+
+// A BatchOperator executes a stream made of a seq scan, filters and an aggregation
+// on batches of documents instead of one document at a time.
+// The fields read by the stream are copied into typed columns, the filters are applied
+// to whole columns, keeping a list of the selected rows, and the aggregators are fed
+// directly with the selected values, without evaluating expressions for each document.
+// The batch size is read from the batch_size setting of the session when the stream
+// is iterated. If it is zero, or if the stream can't be executed in batches,
+// the stream is iterated like any other stream.
+type BatchOperator struct {
+	baseOperator
+	// Stream starts with the seq scan reading the table, followed by the filters,
+	// an optional group by and the aggregation.
+	Stream *Stream
+}
+
+// Batch creates a BatchOperator.
+func Batch(s *Stream) *BatchOperator {
+	return &BatchOperator{Stream: s}
+}
+
+// Iterate implements the Operator interface.
+func (op *BatchOperator) Iterate(in *environment.Environment, fn func(out *environment.Environment) error) error {
+	var ops []Operator
+	for n := op.Stream.First(); n != nil; n = n.GetNext() {
+		ops = append(ops, n)
+	}
+
+	p, ok := newBatchPlan(ops)
+	size := batchSize(in)
+	if !ok || size <= 0 {
+		return op.Stream.Iterate(in, fn)
+	}
+
+	a := batchAggregator{plan: p, groups: make(map[string]int), budget: workMem(in)}
+	defer func() {
+		if a.spill != nil {
+			a.spill.Close()
+		}
+	}()
+
+	b := newBatch(len(p.fields), size)
+
+	err := p.scan.Iterate(in, func(out *environment.Environment) error {
+		d, ok := out.GetDocument()
+		if !ok {
+			return ErrInvalidResult
+		}
+
+		err := b.append(p.fields, d)
+		if err != nil {
+			return err
+		}
+		if b.len < size {
+			return nil
+		}
+
+		err = a.process(in, b)
+		b.reset()
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	if b.len > 0 {
+		err = a.process(in, b)
+		if err != nil {
+			return err
+		}
+	}
+
+	// like the aggregate operators, one group is returned if the stream was empty
+	if len(a.aggregators) == 0 {
+		a.aggregators = append(a.aggregators, newGroupAggregator(nil, p.builders))
+	}
+
+	canceled := contextChecker(in)
+	for _, ga := range a.aggregators {
+		if err := canceled(); err != nil {
+			return err
+		}
+
+		out, err := ga.Flush(in)
+		if err != nil {
+			return err
+		}
+
+		err = fn(out)
+		if err != nil {
+			return err
+		}
+	}
+
+	if a.spill == nil {
+		return nil
+	}
+
+	// the groups that didn't fit in memory are aggregated by the hash aggregation,
+	// one partition at a time
+	a.aggregators = nil
+	agg := ops[len(ops)-1].(*HashAggregateOperator)
+	encGroup, err := newGroupEncoder()
+	if err != nil {
+		return err
+	}
+
+	for _, sf := range a.spill.Partitions() {
+		sf := sf
+		err = agg.aggregate(in, encGroup, a.budget, 1, func(fn func(out *environment.Environment) error) error {
+			return sf.Iterate(in.GetContext(), func(d document.Document) error {
+				env, err := restoreGroupedEnv(in, d)
+				if err != nil {
+					return err
+				}
+
+				return fn(env)
+			})
+		}, fn)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (op *BatchOperator) String() string {
+	return stringutil.Sprintf("batch(%s)", op.Stream)
+}
+
+// CanBatch reports whether the operators, which must be consecutive operators of a stream,
+// can be executed in batches by a BatchOperator.
+func CanBatch(ops []Operator) bool {
+	_, ok := newBatchPlan(ops)
+	return ok
+}
+
+// batchSize returns the number of documents processed at once by batch operators.
+func batchSize(env *environment.Environment) int {
+	s := env.GetSession()
+	if s == nil {
+		return int(database.Settings["batch_size"].Default.V.(int64))
+	}
+
+	return s.BatchSize()
+}
+
+// A batchPlan describes how a stream is executed in batches.
+type batchPlan struct {
+	scan *SeqScanOperator
+	// top-level fields copied into the columns of the batches
+	fields []string
+	preds  []batchPredicate
+	// column of the group by, or -1
+	group     int
+	groupExpr string
+	builders  []expr.AggregatorBuilder
+	// column read by each aggregator, or -1 for COUNT(*)
+	args []int
+}
+
+// newBatchPlan returns the plan executing the operators in batches.
+// It returns false if the operators are not a seq scan followed by filters comparing top-level fields
+// with constants, by an optional group by of a top-level field and by an aggregation whose
+// aggregators are COUNT, MIN, MAX, SUM or AVG of top-level fields.
+// Groups computed by stream aggregations are not supported.
+func newBatchPlan(ops []Operator) (*batchPlan, bool) {
+	if len(ops) < 2 {
+		return nil, false
+	}
+
+	scan, ok := ops[0].(*SeqScanOperator)
+	if !ok || scan.Offset > 0 || scan.Limit > 0 {
+		return nil, false
+	}
+
+	p := batchPlan{scan: scan, group: -1}
+
+	var streaming bool
+	switch t := ops[len(ops)-1].(type) {
+	case *HashAggregateOperator:
+		p.builders = t.Builders
+	case *StreamAggregateOperator:
+		p.builders = t.Builders
+		streaming = true
+	default:
+		return nil, false
+	}
+
+	for i, op := range ops[1 : len(ops)-1] {
+		switch t := op.(type) {
+		case *FilterOperator:
+			if p.group >= 0 || !p.addPredicates(t.E) {
+				return nil, false
+			}
+		case *GroupByOperator:
+			// the group by must be the last operator before the aggregation.
+			// groups sorted by the scan are returned one by one by the stream aggregation,
+			// without reading the whole table if the query has a limit
+			f, ok := topLevelField(t.E)
+			if !ok || streaming || i != len(ops)-3 {
+				return nil, false
+			}
+			p.group = p.column(f)
+			p.groupExpr = stringutil.Sprintf("%s", t.E)
+		default:
+			return nil, false
+		}
+	}
+
+	for _, b := range p.builders {
+		var e expr.Expr
+		switch t := b.(type) {
+		case *functions.Count:
+			if t.Wildcard {
+				p.args = append(p.args, -1)
+				continue
+			}
+			e = t.Expr
+			ok = !t.Distinct
+		case *functions.Min:
+			e, ok = t.Expr, !t.Distinct
+		case *functions.Max:
+			e, ok = t.Expr, !t.Distinct
+		case *functions.Sum:
+			e, ok = t.Expr, !t.Distinct
+		case *functions.Avg:
+			e, ok = t.Expr, !t.Distinct
+		default:
+			return nil, false
+		}
+
+		f, isField := topLevelField(e)
+		if !ok || !isField {
+			return nil, false
+		}
+		p.args = append(p.args, p.column(f))
+	}
+
+	return &p, true
+}
+
+// addPredicates adds the comparisons of the filter expression to the plan.
+// It returns false if the expression is not a conjunction of comparisons between
+// a top-level field and a constant.
+func (p *batchPlan) addPredicates(e expr.Expr) bool {
+	if and, ok := e.(*expr.AndOp); ok {
+		return p.addPredicates(and.LeftHand()) && p.addPredicates(and.RightHand())
+	}
+
+	op, ok := e.(expr.Operator)
+	if !ok || !expr.IsComparisonOperator(op) {
+		return false
+	}
+
+	tok := op.Token()
+	switch tok {
+	case scanner.EQ, scanner.NEQ, scanner.GT, scanner.GTE, scanner.LT, scanner.LTE:
+	default:
+		return false
+	}
+
+	f, ok := topLevelField(op.LeftHand())
+	c := op.RightHand()
+	if !ok {
+		// the constant is on the left, i.e. 10 < a, which is the same as a > 10
+		f, ok = topLevelField(op.RightHand())
+		c = op.LeftHand()
+		switch tok {
+		case scanner.GT:
+			tok = scanner.LT
+		case scanner.GTE:
+			tok = scanner.LTE
+		case scanner.LT:
+			tok = scanner.GT
+		case scanner.LTE:
+			tok = scanner.GTE
+		}
+	}
+	if !ok || !isBatchConstant(c) {
+		return false
+	}
+
+	p.preds = append(p.preds, batchPredicate{col: p.column(f), tok: tok, e: c})
+	return true
+}
+
+// column returns the column holding the values of the field, adding it if necessary.
+func (p *batchPlan) column(field string) int {
+	for i, f := range p.fields {
+		if f == field {
+			return i
+		}
+	}
+
+	p.fields = append(p.fields, field)
+	return len(p.fields) - 1
+}
+
+// topLevelField returns the name of the field if e is a path to a top-level field.
+func topLevelField(e expr.Expr) (string, bool) {
+	p, ok := e.(expr.Path)
+	if !ok || len(p) != 1 || p[0].FieldName == "" {
+		return "", false
+	}
+
+	return p[0].FieldName, true
+}
+
+// isBatchConstant returns true if e evaluates to the same value for every document.
+func isBatchConstant(e expr.Expr) bool {
+	switch e.(type) {
+	case expr.LiteralValue, expr.PositionalParam, expr.NamedParam:
+		return true
+	}
+
+	return false
+}
+
+// minBatchCapacity is the number of rows allocated by the columns of a batch when the first document
+// is added, the whole batch being allocated once they are full.
+const minBatchCapacity = 64
+
+// A batch holds the values of the fields read by a stream for a batch of documents,
+// one column per field.
+type batch struct {
+	len  int
+	size int
+	cols []column
+	// rows selected by the filters
+	sel []int
+}
+
+func newBatch(columns, size int) *batch {
+	return &batch{size: size, cols: make([]column, columns)}
+}
+
+// append copies the values of the fields of the document into the columns.
+func (b *batch) append(fields []string, d document.Document) error {
+	for i, f := range fields {
+		v, err := d.GetByField(f)
+		if err == document.ErrFieldNotFound {
+			v = expr.NullLiteral
+		} else if err != nil {
+			return err
+		}
+
+		c := &b.cols[i]
+		if b.len == len(c.types) {
+			c.grow(b.size)
+		}
+		c.set(b.len, v)
+	}
+
+	b.len++
+	return nil
+}
+
+func (b *batch) reset() {
+	b.len = 0
+}
+
+// A column holds the values of one field for the documents of a batch.
+// The values of the most common types are stored unboxed in the slice of their type,
+// at the position of their document, and the other values are stored as is.
+type column struct {
+	types  []document.ValueType
+	ints   []int64
+	floats []float64
+	texts  []string
+	values []document.Value
+}
+
+// grow adds a row to the column, which can hold up to size rows.
+// Small tables don't allocate the whole batch, and the slices are reused by the following batches.
+func (c *column) grow(size int) {
+	if len(c.types) == cap(c.types) {
+		n := size
+		if len(c.types) == 0 && n > minBatchCapacity {
+			n = minBatchCapacity
+		}
+
+		c.types = append(make([]document.ValueType, 0, n), c.types...)
+		c.ints = append(make([]int64, 0, n), c.ints...)
+		c.floats = append(make([]float64, 0, n), c.floats...)
+		c.texts = append(make([]string, 0, n), c.texts...)
+		c.values = append(make([]document.Value, 0, n), c.values...)
+	}
+
+	c.types = append(c.types, document.NullValue)
+	c.ints = append(c.ints, 0)
+	c.floats = append(c.floats, 0)
+	c.texts = append(c.texts, "")
+	c.values = append(c.values, document.Value{})
+}
+
+func (c *column) set(i int, v document.Value) {
+	c.types[i] = v.Type
+
+	switch v.Type {
+	case document.IntegerValue:
+		c.ints[i] = v.V.(int64)
+	case document.DoubleValue:
+		c.floats[i] = v.V.(float64)
+	case document.TextValue:
+		c.texts[i] = v.V.(string)
+	case document.NullValue:
+	default:
+		c.values[i] = v
+	}
+}
+
+// value returns the value of the i-th document.
+func (c *column) value(i int) document.Value {
+	switch c.types[i] {
+	case document.IntegerValue:
+		return document.NewIntegerValue(c.ints[i])
+	case document.DoubleValue:
+		return document.NewDoubleValue(c.floats[i])
+	case document.TextValue:
+		return document.NewTextValue(c.texts[i])
+	case document.NullValue:
+		return expr.NullLiteral
+	}
+
+	return c.values[i]
+}
+
+// A batchPredicate compares the values of a column with a constant.
+type batchPredicate struct {
+	col int
+	tok scanner.Token
+	e   expr.Expr
+	// value of e, evaluated with the first batch
+	v         document.Value
+	evaluated bool
+}
+
+// filter removes the rows whose value doesn't match from the selected rows.
+// Comparing with NULL never matches, like in a WHERE clause.
+func (p *batchPredicate) filter(in *environment.Environment, c *column, sel []int) ([]int, error) {
+	if !p.evaluated {
+		v, err := p.e.Eval(in)
+		if err != nil {
+			return nil, err
+		}
+		p.v, p.evaluated = v, true
+	}
+
+	if p.v.Type == document.NullValue {
+		return sel[:0], nil
+	}
+
+	var i64 int64
+	var f64 float64
+	var s string
+	switch p.v.Type {
+	case document.IntegerValue:
+		i64 = p.v.V.(int64)
+		f64 = float64(i64)
+	case document.DoubleValue:
+		f64 = p.v.V.(float64)
+	case document.TextValue:
+		s = p.v.V.(string)
+	}
+
+	selected := sel[:0]
+	for _, i := range sel {
+		var ok bool
+
+		switch t := c.types[i]; {
+		case t == document.NullValue:
+		case t == document.IntegerValue && p.v.Type == document.IntegerValue:
+			ok = compareBatchIntegers(p.tok, c.ints[i], i64)
+		case t == document.IntegerValue && p.v.Type == document.DoubleValue:
+			ok = compareBatchDoubles(p.tok, float64(c.ints[i]), f64)
+		case t == document.DoubleValue && (p.v.Type == document.IntegerValue || p.v.Type == document.DoubleValue):
+			ok = compareBatchDoubles(p.tok, c.floats[i], f64)
+		case t == document.TextValue && p.v.Type == document.TextValue:
+			ok = compareBatchTexts(p.tok, c.texts[i], s)
+		default:
+			var err error
+			ok, err = compareBatchValues(p.tok, c.value(i), p.v)
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		if ok {
+			selected = append(selected, i)
+		}
+	}
+
+	return selected, nil
+}
+
+func compareBatchIntegers(tok scanner.Token, a, b int64) bool {
+	switch tok {
+	case scanner.EQ:
+		return a == b
+	case scanner.NEQ:
+		return a != b
+	case scanner.GT:
+		return a > b
+	case scanner.GTE:
+		return a >= b
+	case scanner.LT:
+		return a < b
+	}
+
+	return a <= b
+}
+
+func compareBatchDoubles(tok scanner.Token, a, b float64) bool {
+	switch tok {
+	case scanner.EQ:
+		return a == b
+	case scanner.NEQ:
+		return a != b
+	case scanner.GT:
+		return a > b
+	case scanner.GTE:
+		return a >= b
+	case scanner.LT:
+		return a < b
+	}
+
+	return a <= b
+}
+
+func compareBatchTexts(tok scanner.Token, a, b string) bool {
+	switch tok {
+	case scanner.EQ:
+		return a == b
+	case scanner.NEQ:
+		return a != b
+	}
+
+	cmp := strings.Compare(a, b)
+	switch tok {
+	case scanner.GT:
+		return cmp > 0
+	case scanner.GTE:
+		return cmp >= 0
+	case scanner.LT:
+		return cmp < 0
+	}
+
+	return cmp <= 0
+}
+
+func compareBatchValues(tok scanner.Token, a, b document.Value) (bool, error) {
+	switch tok {
+	case scanner.EQ:
+		return a.IsEqual(b)
+	case scanner.NEQ:
+		return a.IsNotEqual(b)
+	case scanner.GT:
+		return a.IsGreaterThan(b)
+	case scanner.GTE:
+		return a.IsGreaterThanOrEqual(b)
+	case scanner.LT:
+		return a.IsLesserThan(b)
+	}
+
+	return a.IsLesserThanOrEqual(b)
+}
+
+// A batchAggregator aggregates the selected rows of the batches, by group.
+type batchAggregator struct {
+	plan *batchPlan
+	// aggregators of each group, in the order the groups arrived
+	aggregators []*groupAggregator
+	// position of each group, by encoded group value
+	groups map[string]int
+	// rows of the current batch, by group
+	rows    [][]int
+	touched []int
+
+	buf bytes.Buffer
+	enc *document.ValueEncoder
+
+	// memory budget of the groups, and estimated memory they use
+	budget int64
+	size   int64
+	// rows of the groups that don't fit in memory
+	spill *spillPartitioner
+}
+
+// process filters the rows of the batch and aggregates the selected ones.
+func (a *batchAggregator) process(in *environment.Environment, b *batch) error {
+	sel := b.sel[:0]
+	for i := 0; i < b.len; i++ {
+		sel = append(sel, i)
+	}
+	b.sel = sel
+
+	var err error
+	for i := range a.plan.preds {
+		p := &a.plan.preds[i]
+		sel, err = p.filter(in, &b.cols[p.col], sel)
+		if err != nil {
+			return err
+		}
+	}
+
+	if a.plan.group < 0 {
+		if len(a.aggregators) == 0 {
+			a.aggregators = append(a.aggregators, newGroupAggregator(nil, a.plan.builders))
+			a.aggregators[0].group = expr.NullLiteral
+		}
+
+		return a.aggregate(a.aggregators[0], b, sel)
+	}
+
+	if a.enc == nil {
+		a.enc = document.NewValueEncoder(&a.buf)
+	}
+
+	// distribute the selected rows to their group
+	c := &b.cols[a.plan.group]
+	for _, i := range sel {
+		v := c.value(i)
+
+		a.buf.Reset()
+		err = a.enc.Encode(v)
+		if err != nil {
+			return err
+		}
+
+		g, ok := a.groups[string(a.buf.Bytes())]
+		if !ok {
+			// if there is not enough memory for a new group, the row is written to disk
+			// to be aggregated later, like the hash aggregation does
+			cost := int64(a.buf.Len() + hashEntryOverhead*(1+len(a.plan.builders)))
+			if a.budget > 0 && len(a.aggregators) > 0 && a.size+cost > a.budget {
+				err = a.spillRow(in, b, i, v)
+				if err != nil {
+					return err
+				}
+				continue
+			}
+			a.size += cost
+
+			ga := newGroupAggregator(nil, a.plan.builders)
+			ga.group = v
+			ga.groupExpr = a.plan.groupExpr
+
+			g = len(a.aggregators)
+			a.groups[a.buf.String()] = g
+			a.aggregators = append(a.aggregators, ga)
+			a.rows = append(a.rows, nil)
+		}
+
+		if len(a.rows[g]) == 0 {
+			a.touched = append(a.touched, g)
+		}
+		a.rows[g] = append(a.rows[g], i)
+	}
+
+	for _, g := range a.touched {
+		err = a.aggregate(a.aggregators[g], b, a.rows[g])
+		if err != nil {
+			return err
+		}
+		a.rows[g] = a.rows[g][:0]
+	}
+	a.touched = a.touched[:0]
+
+	return nil
+}
+
+// spillRow writes the values of the i-th row of the batch to disk, alongside its group,
+// in the format expected by the hash aggregation.
+func (a *batchAggregator) spillRow(in *environment.Environment, b *batch, i int, group document.Value) error {
+	if a.spill == nil {
+		a.spill = newSpillPartitioner(spillCodec(in), 0)
+	}
+
+	fb := document.NewFieldBuffer()
+	for j, f := range a.plan.fields {
+		fb.Add(f, b.cols[j].value(i))
+	}
+
+	var env environment.Environment
+	env.SetDocument(fb)
+	env.Set(groupEnvKey, group)
+	env.Set(groupExprEnvKey, document.NewTextValue(a.plan.groupExpr))
+
+	return spillGroupedDocument(a.spill, a.buf.String(), &env)
+}
+
+// aggregate feeds the aggregators of the group with the values of the rows.
+// The values of the most common types are aggregated directly, the other ones
+// are passed to the aggregators.
+func (a *batchAggregator) aggregate(ga *groupAggregator, b *batch, rows []int) error {
+	for i, agg := range ga.aggregators {
+		if a.plan.args[i] < 0 {
+			agg.(*functions.CountAggregator).Count += int64(len(rows))
+			continue
+		}
+
+		c := &b.cols[a.plan.args[i]]
+
+		var err error
+		switch t := agg.(type) {
+		case *functions.CountAggregator:
+			for _, r := range rows {
+				if c.types[r] != document.NullValue {
+					t.Count++
+				}
+			}
+		case *functions.SumAggregator:
+			err = aggregateBatchSum(t, c, rows)
+		case *functions.AvgAggregator:
+			err = aggregateBatchAvg(t, c, rows)
+		case *functions.MinAggregator:
+			err = aggregateBatchValues(t, c, rows)
+		case *functions.MaxAggregator:
+			err = aggregateBatchValues(t, c, rows)
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func aggregateBatchSum(s *functions.SumAggregator, c *column, rows []int) error {
+	for _, r := range rows {
+		switch {
+		case c.types[r] == document.IntegerValue && s.SumF != nil:
+			*s.SumF += float64(c.ints[r])
+		case c.types[r] == document.IntegerValue && s.SumD == nil:
+			if s.SumI == nil {
+				s.SumI = new(int64)
+			}
+			*s.SumI += c.ints[r]
+		case c.types[r] == document.DoubleValue && s.SumF != nil:
+			*s.SumF += c.floats[r]
+		case c.types[r].IsNumber():
+			// the first double or decimal changes the type of the sum
+			err := s.AggregateValue(c.value(r))
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+func aggregateBatchAvg(s *functions.AvgAggregator, c *column, rows []int) error {
+	for _, r := range rows {
+		switch c.types[r] {
+		case document.IntegerValue:
+			s.Avg += float64(c.ints[r])
+			s.Counter++
+		case document.DoubleValue:
+			s.Avg += c.floats[r]
+			s.Counter++
+		case document.DecimalValue:
+			err := s.AggregateValue(c.values[r])
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// aggregateBatchValues passes the non-null values of the rows to the aggregator.
+func aggregateBatchValues(agg interface {
+	AggregateValue(v document.Value) error
+}, c *column, rows []int) error {
+	for _, r := range rows {
+		if c.types[r] == document.NullValue {
+			continue
+		}
+
+		err := agg.AggregateValue(c.value(r))
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
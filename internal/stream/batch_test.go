@@ -0,0 +1,153 @@
+package stream_test
+
+import (
+	"testing"
+
+	"github.com/genjidb/genji/document"
+	"github.com/genjidb/genji/internal/database"
+	"github.com/genjidb/genji/internal/environment"
+	"github.com/genjidb/genji/internal/expr"
+	"github.com/genjidb/genji/internal/expr/functions"
+	"github.com/genjidb/genji/internal/sql/parser"
+	"github.com/genjidb/genji/internal/stream"
+	"github.com/genjidb/genji/internal/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBatchOperator(t *testing.T) {
+	db, cleanup := testutil.NewTestDB(t)
+	defer cleanup()
+
+	tx, err := db.Begin(true)
+	require.NoError(t, err)
+	testutil.MustExec(t, db, tx, "CREATE TABLE test (k INTEGER PRIMARY KEY); CREATE TABLE empty")
+	for i := 0; i < 100; i++ {
+		testutil.MustExec(t, db, tx, "INSERT INTO test (k, a, b, c) VALUES (?, ?, ?, ?)",
+			environment.Param{Value: i}, environment.Param{Value: i % 5}, environment.Param{Value: float64(i) / 4}, environment.Param{Value: string(rune('a' + i%3))})
+	}
+	// mixed types, nulls and missing fields
+	testutil.MustExec(t, db, tx, `
+		INSERT INTO test (k, a, b, c) VALUES (100, 2.5, 10, 'b'), (101, 'x', NULL, 1), (102, NULL, true, NULL);
+		INSERT INTO test (k) VALUES (103);
+		INSERT INTO test (k, a, b) VALUES (104, [1], {"d": 1});
+	`)
+	require.NoError(t, tx.Commit())
+
+	tx, err = db.Begin(false)
+	require.NoError(t, err)
+	defer tx.Rollback()
+
+	iterate := func(s *stream.Stream, batchSize, workMem int64) ([]string, error) {
+		session := database.NewSession()
+		err := session.Set("batch_size", document.NewIntegerValue(batchSize))
+		require.NoError(t, err)
+		err = session.Set("work_mem", document.NewIntegerValue(workMem))
+		require.NoError(t, err)
+
+		var in environment.Environment
+		in.Tx = tx
+		in.Catalog = db.Catalog
+		in.Session = session
+		in.Params = []environment.Param{{Value: 2}}
+
+		var got []string
+		err = s.Iterate(&in, func(env *environment.Environment) error {
+			d, ok := env.GetDocument()
+			require.True(t, ok)
+			data, err := document.MarshalJSON(d)
+			require.NoError(t, err)
+			got = append(got, string(data))
+			return nil
+		})
+		return got, err
+	}
+
+	aggregators := func() []expr.AggregatorBuilder {
+		var builders []expr.AggregatorBuilder
+		for _, f := range []string{"a", "b", "c"} {
+			e := parser.MustParseExpr(f)
+			builders = append(builders,
+				&functions.Count{Expr: e},
+				&functions.Min{Expr: e},
+				&functions.Max{Expr: e},
+				&functions.Sum{Expr: e},
+				&functions.Avg{Expr: e},
+			)
+		}
+		return append(builders, &functions.Count{Wildcard: true})
+	}
+
+	tests := []struct {
+		name    string
+		table   string
+		filters []string
+		groupBy string
+	}{
+		{"no filter", "test", nil, ""},
+		{"integers", "test", []string{"a >= 2"}, ""},
+		{"doubles", "test", []string{"b < 10.5", "a != 3"}, ""},
+		{"texts", "test", []string{"c > 'a'"}, ""},
+		{"mixed types", "test", []string{"a = 2"}, ""},
+		{"flipped", "test", []string{"10 > b"}, ""},
+		{"param", "test", []string{"a <= ?"}, ""},
+		{"null", "test", []string{"a = NULL"}, ""},
+		{"group by", "test", []string{"b > 3"}, "a"},
+		{"group by text", "test", nil, "c"},
+		{"empty", "empty", []string{"a > 1"}, ""},
+		{"empty group by", "empty", nil, "a"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			newStream := func() *stream.Stream {
+				s := stream.New(stream.SeqScan(test.table))
+				for _, f := range test.filters {
+					s = s.Pipe(stream.Filter(parser.MustParseExpr(f)))
+				}
+				if test.groupBy != "" {
+					s = s.Pipe(stream.GroupBy(parser.MustParseExpr(test.groupBy)))
+				}
+				return s.Pipe(stream.HashAggregate(aggregators()...))
+			}
+			require.True(t, stream.CanBatch(operators(newStream())))
+
+			// groups that don't fit in memory are written to disk
+			for _, workMem := range []int64{0, 1024} {
+				expected, err := iterate(newStream(), 0, workMem)
+				require.NoError(t, err)
+
+				for _, size := range []int64{1, 7, 1024} {
+					got, err := iterate(stream.New(stream.Batch(newStream())), size, workMem)
+					require.NoError(t, err)
+					require.Equal(t, expected, got)
+				}
+			}
+		})
+	}
+
+	t.Run("Errors", func(t *testing.T) {
+		s := stream.New(stream.Batch(stream.New(stream.SeqScan("test")).
+			Pipe(stream.Filter(parser.MustParseExpr("a > ?"))).
+			Pipe(stream.HashAggregate(&functions.Count{Wildcard: true}))))
+
+		var in environment.Environment
+		in.Tx = tx
+		in.Catalog = db.Catalog
+		err := s.Iterate(&in, func(env *environment.Environment) error { return nil })
+		require.Error(t, err)
+	})
+
+	t.Run("String", func(t *testing.T) {
+		s := stream.New(stream.SeqScan("test")).Pipe(stream.Filter(parser.MustParseExpr("a > 1"))).Pipe(stream.HashAggregate(&functions.Count{Wildcard: true}))
+		require.Equal(t, `batch(seqScan(test) | filter(a > 1) | hashAggregate(COUNT(*)))`, stream.Batch(s).String())
+	})
+}
+
+func operators(s *stream.Stream) []stream.Operator {
+	var ops []stream.Operator
+	for op := s.First(); op != nil; op = op.GetNext() {
+		ops = append(ops, op)
+	}
+
+	return ops
+}
@@ -18,12 +18,12 @@ func Concat(s1 *Stream, s2 *Stream) *ConcatOperator {
 }
 
 func (op *ConcatOperator) Iterate(in *environment.Environment, fn func(*environment.Environment) error) error {
-	err := op.S1.Iterate(in, fn)
+	err := iterateSubstream(op.S1, in, fn)
 	if err != nil {
 		return err
 	}
 
-	return op.S2.Iterate(in, fn)
+	return iterateSubstream(op.S2, in, fn)
 }
 
 func (op *ConcatOperator) String() string {
@@ -0,0 +1,171 @@
+package stream
+
+import (
+	"bytes"
+
+	"github.com/genjidb/genji/document"
+	"github.com/genjidb/genji/internal/environment"
+	"github.com/genjidb/genji/internal/stringutil"
+)
+
+// An IntersectOperator returns the documents of a stream that are also
+// returned by another stream.
+// The documents of S2 are kept in memory.
+type IntersectOperator struct {
+	baseOperator
+	S1 *Stream
+	S2 *Stream
+	// All keeps duplicates: a document returned n times by S1 and m times
+	// by S2 is returned min(n, m) times. Otherwise, it is returned once.
+	All bool
+}
+
+// Intersect returns the distinct documents of s1 that are also returned by s2.
+func Intersect(s1, s2 *Stream) *IntersectOperator {
+	return &IntersectOperator{S1: s1, S2: s2}
+}
+
+// IntersectAll returns the documents of s1 that are also returned by s2, including duplicates.
+func IntersectAll(s1, s2 *Stream) *IntersectOperator {
+	return &IntersectOperator{S1: s1, S2: s2, All: true}
+}
+
+// Iterate implements the Operator interface.
+func (op *IntersectOperator) Iterate(in *environment.Environment, fn func(out *environment.Environment) error) error {
+	counts, err := countDocuments(op.S2, in)
+	if err != nil {
+		return err
+	}
+
+	var buf bytes.Buffer
+	return iterateSubstream(op.S1, in, func(out *environment.Environment) error {
+		key, err := documentKey(&buf, out)
+		if err != nil {
+			return err
+		}
+
+		n := counts[key]
+		if n == 0 {
+			return nil
+		}
+
+		if op.All {
+			counts[key] = n - 1
+		} else {
+			// return each document once
+			delete(counts, key)
+		}
+
+		return fn(out)
+	})
+}
+
+func (op *IntersectOperator) String() string {
+	if op.All {
+		return stringutil.Sprintf("intersectAll(%s, %s)", op.S1, op.S2)
+	}
+
+	return stringutil.Sprintf("intersect(%s, %s)", op.S1, op.S2)
+}
+
+// An ExceptOperator returns the documents of a stream that are not
+// returned by another stream.
+// The documents of S2 are kept in memory.
+type ExceptOperator struct {
+	baseOperator
+	S1 *Stream
+	S2 *Stream
+	// All keeps duplicates: a document returned n times by S1 and m times
+	// by S2 is returned max(n - m, 0) times. Otherwise, it is returned at most once.
+	All bool
+}
+
+// Except returns the distinct documents of s1 that are not returned by s2.
+func Except(s1, s2 *Stream) *ExceptOperator {
+	return &ExceptOperator{S1: s1, S2: s2}
+}
+
+// ExceptAll returns the documents of s1 that are not returned by s2, including duplicates.
+func ExceptAll(s1, s2 *Stream) *ExceptOperator {
+	return &ExceptOperator{S1: s1, S2: s2, All: true}
+}
+
+// Iterate implements the Operator interface.
+func (op *ExceptOperator) Iterate(in *environment.Environment, fn func(out *environment.Environment) error) error {
+	counts, err := countDocuments(op.S2, in)
+	if err != nil {
+		return err
+	}
+
+	var buf bytes.Buffer
+	return iterateSubstream(op.S1, in, func(out *environment.Environment) error {
+		key, err := documentKey(&buf, out)
+		if err != nil {
+			return err
+		}
+
+		n, ok := counts[key]
+		if op.All {
+			if n > 0 {
+				counts[key] = n - 1
+				return nil
+			}
+		} else {
+			if ok {
+				return nil
+			}
+			// return each document once
+			counts[key] = 0
+		}
+
+		return fn(out)
+	})
+}
+
+func (op *ExceptOperator) String() string {
+	if op.All {
+		return stringutil.Sprintf("exceptAll(%s, %s)", op.S1, op.S2)
+	}
+
+	return stringutil.Sprintf("except(%s, %s)", op.S1, op.S2)
+}
+
+// countDocuments returns the number of times each document is returned by s,
+// indexed by their key.
+func countDocuments(s *Stream, in *environment.Environment) (map[string]int, error) {
+	var buf bytes.Buffer
+	counts := make(map[string]int)
+
+	err := iterateSubstream(s, in, func(out *environment.Environment) error {
+		key, err := documentKey(&buf, out)
+		if err != nil {
+			return err
+		}
+
+		counts[key]++
+		return nil
+	})
+
+	return counts, err
+}
+
+// documentKey encodes the values of the document of the environment,
+// in order, so that documents with the same values have the same key.
+func documentKey(buf *bytes.Buffer, env *environment.Environment) (string, error) {
+	buf.Reset()
+
+	d, ok := env.GetDocument()
+	if !ok {
+		return "", ErrInvalidResult
+	}
+
+	enc := document.NewValueEncoder(buf)
+	err := d.Iterate(func(field string, value document.Value) error {
+		return enc.Encode(value)
+	})
+	if err != nil {
+		return "", err
+	}
+
+	return buf.String(), nil
+}
@@ -0,0 +1,72 @@
+package stream_test
+
+import (
+	"testing"
+
+	"github.com/genjidb/genji/document"
+	"github.com/genjidb/genji/internal/environment"
+	"github.com/genjidb/genji/internal/stream"
+	"github.com/genjidb/genji/internal/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIntersectExceptOperators(t *testing.T) {
+	docs := func(t *testing.T, jsons ...string) *stream.Stream {
+		return stream.New(stream.Documents(testutil.MakeDocuments(t, jsons...)...))
+	}
+
+	tests := []struct {
+		name     string
+		op       stream.Operator
+		expected []string
+	}{
+		{"intersect",
+			stream.Intersect(docs(t, `{"a": 1}`, `{"a": 2}`, `{"a": 2}`, `{"a": 3}`), docs(t, `{"a": 2}`, `{"a": 2}`, `{"a": 3}`, `{"a": 4}`)),
+			[]string{`{"a": 2}`, `{"a": 3}`}},
+		{"intersect all",
+			stream.IntersectAll(docs(t, `{"a": 1}`, `{"a": 2}`, `{"a": 2}`, `{"a": 2}`), docs(t, `{"a": 2}`, `{"a": 2}`, `{"a": 1}`)),
+			[]string{`{"a": 1}`, `{"a": 2}`, `{"a": 2}`}},
+		{"intersect compares values",
+			stream.Intersect(docs(t, `{"a": 1}`), docs(t, `{"b": 1}`)),
+			[]string{`{"a": 1}`}},
+		{"except",
+			stream.Except(docs(t, `{"a": 1}`, `{"a": 2}`, `{"a": 1}`, `{"a": 3}`), docs(t, `{"a": 3}`)),
+			[]string{`{"a": 1}`, `{"a": 2}`}},
+		{"except all",
+			stream.ExceptAll(docs(t, `{"a": 1}`, `{"a": 2}`, `{"a": 1}`, `{"a": 1}`), docs(t, `{"a": 1}`)),
+			[]string{`{"a": 2}`, `{"a": 1}`, `{"a": 1}`}},
+		{"empty right stream",
+			stream.Except(docs(t, `{"a": 1}`), stream.New(nil)),
+			[]string{`{"a": 1}`}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var got []document.Document
+			err := stream.New(test.op).Iterate(new(environment.Environment), func(env *environment.Environment) error {
+				d, ok := env.GetDocument()
+				require.True(t, ok)
+
+				var fb document.FieldBuffer
+				err := fb.Copy(d)
+				require.NoError(t, err)
+				got = append(got, &fb)
+				return nil
+			})
+			require.NoError(t, err)
+
+			require.Len(t, got, len(test.expected))
+			for i, e := range test.expected {
+				testutil.RequireDocJSONEq(t, got[i], e)
+			}
+		})
+	}
+
+	t.Run("String", func(t *testing.T) {
+		s1, s2 := stream.New(stream.SeqScan("a")), stream.New(stream.SeqScan("b"))
+		require.Equal(t, `intersect(seqScan(a), seqScan(b))`, stream.Intersect(s1, s2).String())
+		require.Equal(t, `intersectAll(seqScan(a), seqScan(b))`, stream.IntersectAll(s1, s2).String())
+		require.Equal(t, `except(seqScan(a), seqScan(b))`, stream.Except(s1, s2).String())
+		require.Equal(t, `exceptAll(seqScan(a), seqScan(b))`, stream.ExceptAll(s1, s2).String())
+	})
+}
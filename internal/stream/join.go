@@ -0,0 +1,159 @@
+package stream
+
+import (
+	"strings"
+
+	"github.com/genjidb/genji/document"
+	"github.com/genjidb/genji/internal/environment"
+	"github.com/genjidb/genji/internal/expr"
+	"github.com/genjidb/genji/internal/stringutil"
+)
+
+// A WrapOperator stores each document of the stream in a field of a new document.
+// It is used to name the documents of a table, so that they can be joined
+// with the documents of other tables.
+type WrapOperator struct {
+	baseOperator
+	Name string
+}
+
+// Wrap creates an operator that stores each document of the stream
+// in the field name of a new document.
+func Wrap(name string) *WrapOperator {
+	return &WrapOperator{Name: name}
+}
+
+// Iterate implements the Operator interface.
+func (op *WrapOperator) Iterate(in *environment.Environment, fn func(out *environment.Environment) error) error {
+	var fb document.FieldBuffer
+	var newEnv environment.Environment
+
+	return op.Prev.Iterate(in, func(out *environment.Environment) error {
+		d, ok := out.GetDocument()
+		if !ok {
+			return ErrInvalidResult
+		}
+
+		fb.Reset()
+		fb.Add(op.Name, document.NewDocumentValue(d))
+
+		newEnv.SetOuter(out)
+		newEnv.SetDocument(&fb)
+		return fn(&newEnv)
+	})
+}
+
+func (op *WrapOperator) String() string {
+	return stringutil.Sprintf("wrap(%s)", op.Name)
+}
+
+// A JoinOperator joins each document of the stream with the documents
+// of another stream.
+// Joined documents contain one field per table, named after the table or its alias,
+// whose value is the document read from that table, e.g. {"a": {...}, "b": {...}}.
+// Incoming documents must already be in that form, see the WrapOperator.
+// For each incoming document, the right stream is iterated with the incoming
+// document as environment, which allows its ranges to depend on the values of the left side:
+// with a seqScan the join is a nested loop join, with a pkScan or an indexScan
+// each document of the left side is used to look up the matching documents of the right side.
+type JoinOperator struct {
+	baseOperator
+	// RightName is the name of the field under which the documents of the right stream are stored.
+	RightName string
+	// Right is the stream of documents joined with each incoming document.
+	Right *Stream
+	// On is the join condition. If nil, all the documents are joined.
+	On expr.Expr
+	// Left indicates a left join: incoming documents matching no document
+	// of the right stream are joined with NULL.
+	Left bool
+}
+
+// Join creates an operator that joins each document of the stream with the
+// documents of the right stream that satisfy the on condition.
+func Join(rightName string, right *Stream, on expr.Expr) *JoinOperator {
+	return &JoinOperator{RightName: rightName, Right: right, On: on}
+}
+
+// LeftJoin creates an operator that joins each document of the stream with the
+// documents of the right stream that satisfy the on condition, or with NULL if there are none.
+func LeftJoin(rightName string, right *Stream, on expr.Expr) *JoinOperator {
+	return &JoinOperator{RightName: rightName, Right: right, On: on, Left: true}
+}
+
+// Iterate implements the Operator interface.
+func (op *JoinOperator) Iterate(in *environment.Environment, fn func(out *environment.Environment) error) error {
+	var newEnv environment.Environment
+	var joined document.FieldBuffer
+
+	return op.Prev.Iterate(in, func(out *environment.Environment) error {
+		d, ok := out.GetDocument()
+		if !ok {
+			return ErrInvalidResult
+		}
+
+		join := func(outer *environment.Environment, v document.Value, check bool) (bool, error) {
+			joined.Reset()
+			err := joined.ScanDocument(d)
+			if err != nil {
+				return false, err
+			}
+			joined.Add(op.RightName, v)
+
+			newEnv.SetOuter(outer)
+			newEnv.SetDocument(&joined)
+
+			if check && op.On != nil {
+				v, err := op.On.Eval(&newEnv)
+				if err != nil {
+					return false, err
+				}
+				ok, err := v.IsTruthy()
+				if err != nil || !ok {
+					return false, err
+				}
+			}
+
+			return true, fn(&newEnv)
+		}
+
+		var matched bool
+		err := op.Right.Iterate(out, func(rout *environment.Environment) error {
+			rd, ok := rout.GetDocument()
+			if !ok {
+				return ErrInvalidResult
+			}
+
+			ok, err := join(rout, document.NewDocumentValue(rd), true)
+			if ok {
+				matched = true
+			}
+			return err
+		})
+		if err != nil || matched || !op.Left {
+			return err
+		}
+
+		// the condition is not evaluated for documents without any match
+		_, err = join(out, document.NewNullValue(), false)
+		return err
+	})
+}
+
+func (op *JoinOperator) String() string {
+	var sb strings.Builder
+
+	if op.Left {
+		sb.WriteString("leftJoin(")
+	} else {
+		sb.WriteString("join(")
+	}
+
+	sb.WriteString(stringutil.Sprintf("%s, %s", op.RightName, op.Right))
+	if op.On != nil {
+		sb.WriteString(stringutil.Sprintf(", %s", op.On))
+	}
+	sb.WriteByte(')')
+
+	return sb.String()
+}
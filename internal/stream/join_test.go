@@ -0,0 +1,89 @@
+package stream_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/genjidb/genji/internal/environment"
+	"github.com/genjidb/genji/internal/sql/parser"
+	"github.com/genjidb/genji/internal/stream"
+	"github.com/genjidb/genji/internal/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+func TestJoinOperator(t *testing.T) {
+	left := testutil.MakeDocuments(t, `{"a": 1}`, `{"a": 2}`, `{"a": 3}`)
+	right := testutil.MakeDocuments(t, `{"b": 1, "c": "x"}`, `{"b": 1, "c": "y"}`, `{"b": 3, "c": "z"}`)
+	other := testutil.MakeDocuments(t, `{"c": "x", "d": true}`)
+
+	tests := []struct {
+		name     string
+		s        *stream.Stream
+		expected string
+	}{
+		{
+			"inner",
+			stream.New(stream.Documents(left...)).
+				Pipe(stream.Wrap("l")).
+				Pipe(stream.Join("r", stream.New(stream.Documents(right...)), parser.MustParseExpr("l.a = r.b"))),
+			`[{"l": {"a": 1}, "r": {"b": 1, "c": "x"}}, {"l": {"a": 1}, "r": {"b": 1, "c": "y"}}, {"l": {"a": 3}, "r": {"b": 3, "c": "z"}}]`,
+		},
+		{
+			"left",
+			stream.New(stream.Documents(left...)).
+				Pipe(stream.Wrap("l")).
+				Pipe(stream.LeftJoin("r", stream.New(stream.Documents(right...)), parser.MustParseExpr("l.a = r.b AND r.c != 'y'"))),
+			`[{"l": {"a": 1}, "r": {"b": 1, "c": "x"}}, {"l": {"a": 2}, "r": null}, {"l": {"a": 3}, "r": {"b": 3, "c": "z"}}]`,
+		},
+		{
+			"no condition",
+			stream.New(stream.Documents(left[:2]...)).
+				Pipe(stream.Wrap("l")).
+				Pipe(stream.Join("o", stream.New(stream.Documents(other...)), nil)),
+			`[{"l": {"a": 1}, "o": {"c": "x", "d": true}}, {"l": {"a": 2}, "o": {"c": "x", "d": true}}]`,
+		},
+		{
+			"multiple joins",
+			stream.New(stream.Documents(left...)).
+				Pipe(stream.Wrap("l")).
+				Pipe(stream.Join("r", stream.New(stream.Documents(right...)), parser.MustParseExpr("l.a = r.b"))).
+				Pipe(stream.Join("o", stream.New(stream.Documents(other...)), parser.MustParseExpr("o.c = r.c"))),
+			`[{"l": {"a": 1}, "r": {"b": 1, "c": "x"}, "o": {"c": "x", "d": true}}]`,
+		},
+		{
+			"right side depends on the left side",
+			stream.New(stream.Documents(left[:2]...)).
+				Pipe(stream.Wrap("l")).
+				Pipe(stream.Join("r", stream.New(stream.Expressions(parser.MustParseExpr("{b: l.a * 10}"))), nil)),
+			`[{"l": {"a": 1}, "r": {"b": 10}}, {"l": {"a": 2}, "r": {"b": 20}}]`,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var got []json.RawMessage
+			err := test.s.Iterate(new(environment.Environment), func(out *environment.Environment) error {
+				d, ok := out.GetDocument()
+				require.True(t, ok)
+
+				data, err := json.Marshal(d)
+				require.NoError(t, err)
+				got = append(got, data)
+				return nil
+			})
+			require.NoError(t, err)
+
+			data, err := json.Marshal(got)
+			require.NoError(t, err)
+			require.JSONEq(t, test.expected, string(data))
+		})
+	}
+
+	t.Run("String", func(t *testing.T) {
+		require.Equal(t, `wrap(a)`, stream.Wrap("a").String())
+		require.Equal(t, `join(b, seqScan(b), a.x = b.y)`,
+			stream.Join("b", stream.New(stream.SeqScan("b")), parser.MustParseExpr("a.x = b.y")).String())
+		require.Equal(t, `leftJoin(b, seqScan(b), a.x = b.y)`,
+			stream.LeftJoin("b", stream.New(stream.SeqScan("b")), parser.MustParseExpr("a.x = b.y")).String())
+	})
+}
@@ -4,6 +4,8 @@ import (
 	"bytes"
 	"container/heap"
 	"errors"
+	"io"
+	"sort"
 	"strings"
 
 	"github.com/genjidb/genji/document"
@@ -53,6 +55,23 @@ func Pipe(ops ...Operator) Operator {
 	return ops[len(ops)-1]
 }
 
+// contextChecker returns a function returning the error of the context
+// of the environment once it is canceled, so that operators reading
+// documents can abort long iterations.
+func contextChecker(in *environment.Environment) func() error {
+	ctx := in.GetContext()
+	done := ctx.Done()
+
+	return func() error {
+		select {
+		case <-done:
+			return ctx.Err()
+		default:
+			return nil
+		}
+	}
+}
+
 type baseOperator struct {
 	Prev Operator
 	Next Operator
@@ -214,6 +233,7 @@ func GroupBy(e expr.Expr) *GroupByOperator {
 // Iterate implements the Operator interface.
 func (op *GroupByOperator) Iterate(in *environment.Environment, f func(out *environment.Environment) error) error {
 	var newEnv environment.Environment
+	groupExpr := document.NewTextValue(stringutil.Sprintf("%s", op.E))
 
 	return op.Prev.Iterate(in, func(out *environment.Environment) error {
 		v, err := op.E.Eval(out)
@@ -222,7 +242,7 @@ func (op *GroupByOperator) Iterate(in *environment.Environment, f func(out *envi
 		}
 
 		newEnv.Set(groupEnvKey, v)
-		newEnv.Set(groupExprEnvKey, document.NewTextValue(stringutil.Sprintf("%s", op.E)))
+		newEnv.Set(groupExprEnvKey, groupExpr)
 		newEnv.SetOuter(out)
 		return f(&newEnv)
 	})
@@ -235,37 +255,70 @@ func (op *GroupByOperator) String() string {
 // A SortOperator consumes every value of the stream and outputs them in order.
 type SortOperator struct {
 	baseOperator
-	Expr expr.Expr
-	Desc bool
+	Terms []expr.OrderTerm
 }
 
 // Sort consumes every value of the stream and outputs them in order.
 // It operates a partial sort on the iterator using a heap.
 // This ensures a O(k+n log n) time complexity, where k is the sum of
 // Take() + Skip() operators, if provided, otherwise k = n.
-// If the sorting is in ascending order, a min-heap will be used
-// otherwise a max-heap will be used instead.
 // Once the heap is filled entirely with the content of the incoming stream, a stream is returned.
 // During iteration, the stream will pop the k-smallest or k-largest elements, depending on
 // the chosen sorting order (ASC or DESC).
-// This function is not memory efficient as it is loading the entire stream in memory before
-// returning the k-smallest or k-largest elements.
+// If the session limits the memory used by operators, values that don't fit in memory
+// are sorted in runs written to temporary files, which are merged during iteration.
 func Sort(e expr.Expr) *SortOperator {
-	return &SortOperator{Expr: e}
+	return SortBy(expr.OrderTerm{E: e})
 }
 
 // SortReverse does the same as Sort but in descending order.
 func SortReverse(e expr.Expr) *SortOperator {
-	return &SortOperator{Expr: e, Desc: true}
+	return SortBy(expr.OrderTerm{E: e, Desc: true})
+}
+
+// SortBy does the same as Sort but orders the values using multiple terms,
+// each one with its own direction. Values whose first terms are equal are ordered
+// using the next ones, and values whose terms are all equal are returned
+// in the order they arrived.
+func SortBy(terms ...expr.OrderTerm) *SortOperator {
+	return &SortOperator{Terms: terms}
 }
 
 func (op *SortOperator) Iterate(in *environment.Environment, f func(out *environment.Environment) error) error {
-	h, err := op.sortStream(op.Prev, in)
+	var runs []*spillFile
+	defer func() {
+		for _, r := range runs {
+			r.Close()
+		}
+	}()
+
+	h, err := op.sortStream(op.Prev, in, workMem(in), &runs)
 	if err != nil {
 		return err
 	}
 
+	if len(runs) > 0 {
+		// the values kept in memory are merged with the runs
+		sort.Sort(h)
+		return op.merge(in, runs, h.nodes, func(node *heapNode, d document.Document) error {
+			if d == nil {
+				return f(node.data)
+			}
+
+			env, err := restoreSortedEnv(in, d)
+			if err != nil {
+				return err
+			}
+			return f(env)
+		})
+	}
+
+	canceled := contextChecker(in)
 	for h.Len() > 0 {
+		if err := canceled(); err != nil {
+			return err
+		}
+
 		node := heap.Pop(h).(heapNode)
 		err := f(node.data)
 		if err != nil {
@@ -276,107 +329,419 @@ func (op *SortOperator) Iterate(in *environment.Environment, f func(out *environ
 	return nil
 }
 
-func (op *SortOperator) sortStream(prev Operator, in *environment.Environment) (heap.Interface, error) {
-	var h heap.Interface
-	if op.Desc {
-		h = new(maxHeap)
-	} else {
-		h = new(minHeap)
-	}
+// sortStream reads the stream into a heap.
+// If the heap exceeds the memory budget, its values are sorted and written
+// to a new run, and the heap is emptied.
+func (op *SortOperator) sortStream(prev Operator, in *environment.Environment, budget int64, runs *[]*spillFile) (*sortHeap, error) {
+	h := &sortHeap{terms: op.Terms}
 
 	heap.Init(h)
 
-	getValue := op.Expr.Eval
-	if p, ok := op.Expr.(expr.Path); ok {
-		getValue = func(env *environment.Environment) (document.Value, error) {
-			for env != nil {
-				d, ok := env.GetDocument()
-				if !ok {
-					env = env.GetOuter()
-					continue
-				}
+	var sortEnv environment.Environment
+	var seq int
 
-				v, err := document.Path(p).GetValueFromDocument(d)
-				if err == document.ErrFieldNotFound {
-					env = env.GetOuter()
-					continue
-				}
-				return v, err
+	// estimated memory used by the heap
+	var size int64
+
+	return h, prev.Iterate(in, func(env *environment.Environment) error {
+		// terms can refer to the fields of the returned documents,
+		// i.e. aliases, as well as to the fields of the documents
+		// they were computed from
+		sortEnv.SetOuter(env)
+		sortEnv.SetDocument(sortDocument{env: env})
+
+		node := heapNode{
+			values: make([][]byte, len(op.Terms)),
+			seq:    seq,
+		}
+		seq++
+
+		cost := int64(hashEntryOverhead)
+		for i, t := range op.Terms {
+			sortV, err := t.E.Eval(&sortEnv)
+			if err != nil {
+				return err
+			}
+
+			// We need to make sure sort behaviour
+			// is the same with or without indexes.
+			// To achieve that, the value must be encoded using the same method
+			// as what the index package would do.
+			var buf bytes.Buffer
+
+			err = document.NewValueEncoder(&buf).Encode(sortV)
+			if err != nil {
+				return err
+			}
+
+			node.values[i] = buf.Bytes()
+			cost += int64(buf.Len())
+		}
+
+		e, err := env.Clone()
+		if err != nil {
+			return err
+		}
+		node.data = e
+
+		if budget > 0 {
+			if e.Doc != nil {
+				cost += estimateSize(document.NewDocumentValue(e.Doc))
 			}
 
-			return document.NewNullValue(), nil
+			if h.Len() > 0 && size+cost > budget {
+				err = op.spillRun(in, h, runs)
+				if err != nil {
+					return err
+				}
+				size = 0
+			}
+			size += cost
 		}
+
+		heap.Push(h, node)
+
+		return nil
+	})
+}
+
+// spillRun sorts the values of the heap, writes them to a new run
+// and empties the heap.
+// Once there are maxSortRuns runs, they are merged into one.
+func (op *SortOperator) spillRun(in *environment.Environment, h *sortHeap, runs *[]*spillFile) error {
+	run, err := newSpillFile(spillCodec(in))
+	if err != nil {
+		return err
 	}
+	*runs = append(*runs, run)
 
-	return h, prev.Iterate(in, func(env *environment.Environment) error {
-		sortV, err := getValue(env)
+	sort.Sort(h)
+	for i := range h.nodes {
+		err = run.Write(spillSortedNode(&h.nodes[i]))
 		if err != nil {
 			return err
 		}
+	}
+	h.nodes = nil
+
+	if len(*runs) < maxSortRuns {
+		return nil
+	}
+
+	merged, err := newSpillFile(spillCodec(in))
+	if err != nil {
+		return err
+	}
 
-		// We need to make sure sort behaviour
-		// is the same with or without indexes.
-		// To achieve that, the value must be encoded using the same method
-		// as what the index package would do.
-		var buf bytes.Buffer
+	err = op.merge(in, *runs, nil, func(_ *heapNode, d document.Document) error {
+		return merged.Write(d)
+	})
+	for _, r := range *runs {
+		r.Close()
+	}
+	*runs = append((*runs)[:0], merged)
+
+	return err
+}
+
+// merge outputs the values of the runs and of the given sorted nodes, in order.
+// fn is called with the node and, if it was read from a run, the document it was read from.
+func (op *SortOperator) merge(in *environment.Environment, runs []*spillFile, nodes []heapNode, fn func(node *heapNode, d document.Document) error) error {
+	mh := mergeHeap{terms: op.Terms}
 
-		err = document.NewValueEncoder(&buf).Encode(sortV)
+	for _, r := range runs {
+		sr, err := r.Reader()
 		if err != nil {
 			return err
 		}
 
-		node := heapNode{
-			value: buf.Bytes(),
+		c := &runCursor{r: sr}
+		ok, err := c.next()
+		if err != nil {
+			return err
 		}
-		e, err := env.Clone()
+		if ok {
+			mh.cursors = append(mh.cursors, c)
+		}
+	}
+
+	if len(nodes) > 0 {
+		mh.cursors = append(mh.cursors, &runCursor{nodes: nodes, node: nodes[0]})
+	}
+
+	heap.Init(&mh)
+
+	canceled := contextChecker(in)
+	for mh.Len() > 0 {
+		if err := canceled(); err != nil {
+			return err
+		}
+
+		c := mh.cursors[0]
+		err := fn(&c.node, c.d)
 		if err != nil {
 			return err
 		}
-		node.data = e
 
-		heap.Push(h, node)
+		ok, err := c.next()
+		if err != nil {
+			return err
+		}
+		if ok {
+			heap.Fix(&mh, 0)
+		} else {
+			heap.Pop(&mh)
+		}
+	}
 
-		return nil
-	})
+	return nil
 }
 
 func (op *SortOperator) String() string {
-	if op.Desc {
-		return stringutil.Sprintf("sortReverse(%s)", op.Expr)
+	if len(op.Terms) == 1 {
+		if op.Terms[0].Desc {
+			return stringutil.Sprintf("sortReverse(%s)", op.Terms[0].E)
+		}
+
+		return stringutil.Sprintf("sort(%s)", op.Terms[0].E)
+	}
+
+	var sb strings.Builder
+
+	for i, t := range op.Terms {
+		if i > 0 {
+			sb.WriteString(", ")
+		}
+		sb.WriteString(t.String())
+	}
+
+	return stringutil.Sprintf("sort(%s)", sb.String())
+}
+
+// sortDocument looks up fields in the document of the environment,
+// then in the documents of its outer environments.
+type sortDocument struct {
+	env *environment.Environment
+}
+
+func (d sortDocument) GetByField(field string) (document.Value, error) {
+	for env := d.env; env != nil; env = env.GetOuter() {
+		if env.Doc == nil {
+			continue
+		}
+
+		v, err := env.Doc.GetByField(field)
+		if err != document.ErrFieldNotFound {
+			return v, err
+		}
+	}
+
+	return document.Value{}, document.ErrFieldNotFound
+}
+
+func (d sortDocument) Iterate(fn func(field string, value document.Value) error) error {
+	doc, ok := d.env.GetDocument()
+	if !ok {
+		return nil
 	}
 
-	return stringutil.Sprintf("sort(%s)", op.Expr)
+	return doc.Iterate(fn)
 }
 
 type heapNode struct {
-	value []byte
-	data  *environment.Environment
+	values [][]byte
+	// seq is the position of the node in the stream
+	seq  int
+	data *environment.Environment
+}
+
+type sortHeap struct {
+	terms []expr.OrderTerm
+	nodes []heapNode
 }
 
-type minHeap []heapNode
+func (h *sortHeap) Len() int      { return len(h.nodes) }
+func (h *sortHeap) Swap(i, j int) { h.nodes[i], h.nodes[j] = h.nodes[j], h.nodes[i] }
 
-func (h minHeap) Len() int           { return len(h) }
-func (h minHeap) Less(i, j int) bool { return bytes.Compare(h[i].value, h[j].value) < 0 }
-func (h minHeap) Swap(i, j int)      { h[i], h[j] = h[j], h[i] }
+func (h *sortHeap) Less(i, j int) bool {
+	return lessNodes(h.terms, &h.nodes[i], &h.nodes[j])
+}
+
+// lessNodes compares the values of the nodes using the direction of each term,
+// then their position in the stream.
+func lessNodes(terms []expr.OrderTerm, a, b *heapNode) bool {
+	for k, t := range terms {
+		c := bytes.Compare(a.values[k], b.values[k])
+		if c == 0 {
+			continue
+		}
+		if t.Desc {
+			return c > 0
+		}
+		return c < 0
+	}
 
-func (h *minHeap) Push(x interface{}) {
-	*h = append(*h, x.(heapNode))
+	return a.seq < b.seq
 }
 
-func (h *minHeap) Pop() interface{} {
-	old := *h
+func (h *sortHeap) Push(x interface{}) {
+	h.nodes = append(h.nodes, x.(heapNode))
+}
+
+func (h *sortHeap) Pop() interface{} {
+	old := h.nodes
 	n := len(old)
 	x := old[n-1]
-	*h = old[0 : n-1]
+	h.nodes = old[0 : n-1]
 	return x
 }
 
-type maxHeap struct {
-	minHeap
+// spillSortedNode returns the document written to a run for the node.
+func spillSortedNode(node *heapNode) document.Document {
+	values := document.NewValueBuffer()
+	for _, v := range node.values {
+		values.Append(document.NewBlobValue(v))
+	}
+
+	fb := document.NewFieldBuffer()
+	fb.Add("values", document.NewArrayValue(values))
+	fb.Add("seq", document.NewIntegerValue(int64(node.seq)))
+
+	d, ok := node.data.GetDocument()
+	if !ok {
+		return fb
+	}
+	fb.Add("doc", document.NewDocumentValue(d))
+
+	// the key is required to modify the document, e.g. when deleting the first sorted documents
+	if k, ok := d.(document.Keyer); ok && k.RawKey() != nil {
+		fb.Add("key", document.NewBlobValue(k.RawKey()))
+		if pk, err := k.Key(); err == nil && !pk.Type.IsAny() {
+			fb.Add("pk", pk)
+		}
+	}
+
+	return fb
 }
 
-func (h maxHeap) Less(i, j int) bool {
-	return bytes.Compare(h.minHeap[i].value, h.minHeap[j].value) > 0
+// restoreSortedEnv returns an environment containing the document
+// written by spillSortedNode.
+func restoreSortedEnv(in *environment.Environment, d document.Document) (*environment.Environment, error) {
+	var env environment.Environment
+	env.SetOuter(in)
+
+	v, err := d.GetByField("doc")
+	if err == document.ErrFieldNotFound {
+		return &env, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	key, err := d.GetByField("key")
+	if err == document.ErrFieldNotFound {
+		env.SetDocument(v.V.(document.Document))
+		return &env, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	fb := document.NewFieldBuffer()
+	err = fb.ScanDocument(v.V.(document.Document))
+	if err != nil {
+		return nil, err
+	}
+	fb.EncodedKey = key.V.([]byte)
+
+	pk, err := d.GetByField("pk")
+	if err != nil && err != document.ErrFieldNotFound {
+		return nil, err
+	}
+	fb.DecodedKey = pk
+
+	env.SetDocument(fb)
+	return &env, nil
+}
+
+// A runCursor returns the nodes of a run, or of a sorted list of nodes, in order.
+type runCursor struct {
+	r     *spillReader
+	nodes []heapNode
+
+	// current node, and the document it was read from
+	node heapNode
+	d    document.Document
+}
+
+// next moves the cursor to the next node. It returns false if there are no more nodes.
+func (c *runCursor) next() (bool, error) {
+	if c.r == nil {
+		if len(c.nodes) > 0 {
+			c.nodes = c.nodes[1:]
+		}
+		if len(c.nodes) == 0 {
+			return false, nil
+		}
+		c.node = c.nodes[0]
+		return true, nil
+	}
+
+	d, err := c.r.Next()
+	if err == io.EOF {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	v, err := d.GetByField("values")
+	if err != nil {
+		return false, err
+	}
+
+	c.node.values = c.node.values[:0]
+	err = v.V.(document.Array).Iterate(func(_ int, v document.Value) error {
+		c.node.values = append(c.node.values, v.V.([]byte))
+		return nil
+	})
+	if err != nil {
+		return false, err
+	}
+
+	v, err = d.GetByField("seq")
+	if err != nil {
+		return false, err
+	}
+	c.node.seq = int(v.V.(int64))
+	c.d = d
+
+	return true, nil
+}
+
+// mergeHeap orders cursors by their current node.
+type mergeHeap struct {
+	terms   []expr.OrderTerm
+	cursors []*runCursor
+}
+
+func (h *mergeHeap) Len() int      { return len(h.cursors) }
+func (h *mergeHeap) Swap(i, j int) { h.cursors[i], h.cursors[j] = h.cursors[j], h.cursors[i] }
+
+func (h *mergeHeap) Less(i, j int) bool {
+	return lessNodes(h.terms, &h.cursors[i].node, &h.cursors[j].node)
+}
+
+func (h *mergeHeap) Push(x interface{}) {
+	h.cursors = append(h.cursors, x.(*runCursor))
+}
+
+func (h *mergeHeap) Pop() interface{} {
+	old := h.cursors
+	n := len(old)
+	x := old[n-1]
+	h.cursors = old[0 : n-1]
+	return x
 }
 
 // A TableInsertOperator inserts incoming documents to the table.
@@ -393,32 +758,204 @@ func TableInsert(tableName string, onConflict database.OnInsertConflictAction) *
 
 // Iterate implements the Operator interface.
 func (op *TableInsertOperator) Iterate(in *environment.Environment, f func(out *environment.Environment) error) error {
+	onConflict := func(*database.Table, *environment.Environment) (database.OnInsertConflictAction, error) {
+		return op.OnConflict, nil
+	}
+
+	// documents of multi-row VALUES clauses are inserted in batches, unless they
+	// are returned, in which case each document is inserted once the previous one is consumed
+	if prev, ok := op.Prev.(*ExprsOperator); ok && len(prev.Exprs) > 1 && op.OnConflict == nil && op.Next == nil {
+		return insertValues(prev, op.Name, in, f, onConflict)
+	}
+
+	return insertDocuments(op.Prev, op.Name, in, f, onConflict)
+}
+
+// insertBatchSize is the maximum number of documents of a VALUES clause
+// inserted at once by insertValues.
+const insertBatchSize = 1000
+
+// insertValues inserts the documents of a VALUES clause using database.Table.InsertBatch,
+// which loads the indexes and the encoder once per batch rather than once per document.
+// Virtual tables and tables with insert triggers, whose documents must be inserted one by one,
+// are handled by insertDocuments.
+func insertValues(prev *ExprsOperator, tableName string, in *environment.Environment, f func(out *environment.Environment) error,
+	onConflict func(table *database.Table, env *environment.Environment) (database.OnInsertConflictAction, error)) error {
+	catalog := in.GetCatalog()
+	if catalog.GetVirtualTable(tableName) != nil {
+		return insertDocuments(prev, tableName, in, f, onConflict)
+	}
+
+	triggers, err := loadTriggers(in, tableName, database.TriggerInsert)
+	if err != nil {
+		return err
+	}
+	if triggers != nil {
+		return insertDocuments(prev, tableName, in, f, onConflict)
+	}
+
+	table, err := catalog.GetTable(in.GetTx(), tableName)
+	if err != nil {
+		return err
+	}
+	table = table.WithSessionSettings(in.GetSession())
+
+	var newEnv environment.Environment
+	newEnv.SetOuter(in)
+
+	var changes int64
+	var lastKey document.Value
+	size := len(prev.Exprs)
+	if size > insertBatchSize {
+		size = insertBatchSize
+	}
+	docs := make([]document.Document, 0, size)
+
+	flush := func() error {
+		inserted, err := table.InsertBatch(docs)
+		changes += int64(len(inserted))
+		docs = docs[:0]
+		if err != nil {
+			return err
+		}
+
+		for _, d := range inserted {
+			newEnv.SetDocument(d)
+			err = f(&newEnv)
+			if err != nil {
+				return err
+			}
+		}
+
+		if n := len(inserted); n > 0 {
+			lastKey, err = inserted[n-1].(document.Keyer).Key()
+		}
+		return err
+	}
+
+	err = prev.Iterate(in, func(env *environment.Environment) error {
+		d, ok := env.GetDocument()
+		if !ok {
+			return errors.New("missing document")
+		}
+
+		docs = append(docs, d)
+		if len(docs) < insertBatchSize {
+			return nil
+		}
+
+		return flush()
+	})
+	if err == nil && len(docs) > 0 {
+		err = flush()
+	}
+
+	session := in.GetSession()
+	if session != nil && changes > 0 && (err == nil || err == ErrStreamClosed) {
+		session.SetLastInsertKey(lastKey)
+	}
+
+	return recordChanges(session, changes, err)
+}
+
+// insertDocuments inserts the documents of prev to the table, using the conflict
+// resolution function returned by onConflict for each document.
+func insertDocuments(prev Operator, tableName string, in *environment.Environment, f func(out *environment.Environment) error,
+	onConflict func(table *database.Table, env *environment.Environment) (database.OnInsertConflictAction, error)) error {
 	var newEnv environment.Environment
 
 	var table *database.Table
-	return op.Prev.Iterate(in, func(env *environment.Environment) error {
+	var triggers *tableTriggers
+	var changes int64
+	var lastKey document.Value
+	err := prev.Iterate(in, func(env *environment.Environment) error {
 		d, ok := env.GetDocument()
 		if !ok {
 			return errors.New("missing document")
 		}
 
 		var err error
+		if t := env.GetCatalog().GetVirtualTable(tableName); t != nil {
+			ins, ok := t.(database.VirtualTableInserter)
+			if !ok {
+				return stringutil.Errorf("cannot insert into virtual table %q", tableName)
+			}
+
+			err = ins.Insert(env.GetTx().Context(), d)
+			if err != nil {
+				return err
+			}
+
+			newEnv.SetDocument(d)
+			newEnv.SetOuter(env)
+			return f(&newEnv)
+		}
+
 		if table == nil {
-			table, err = env.GetCatalog().GetTable(env.GetTx(), op.Name)
+			table, err = env.GetCatalog().GetTable(env.GetTx(), tableName)
 			if err != nil {
 				return err
 			}
+			table = table.WithSessionSettings(env.GetSession())
+
+			triggers, err = loadTriggers(env, tableName, database.TriggerInsert)
+			if err != nil {
+				return err
+			}
+		}
+
+		action, err := onConflict(table, env)
+		if err != nil {
+			return err
 		}
 
-		d, err = table.InsertWithConflictResolution(d, op.OnConflict)
+		err = triggers.fireBefore(env, nil, d)
 		if err != nil {
 			return err
 		}
+
+		d, err = table.InsertWithConflictResolution(d, action)
+		if err != nil {
+			return err
+		}
+
+		// d is nil if the conflict resolution ignored the document
+		if d != nil {
+			changes++
+			if ker, ok := d.(document.Keyer); ok {
+				lastKey, err = ker.Key()
+				if err != nil {
+					return err
+				}
+			}
+
+			err = triggers.fireAfter(env, nil, d)
+			if err != nil {
+				return err
+			}
+		}
 		newEnv.SetDocument(d)
 
 		newEnv.SetOuter(env)
 		return f(&newEnv)
 	})
+
+	session := in.GetSession()
+	if session != nil && changes > 0 && (err == nil || err == ErrStreamClosed) {
+		session.SetLastInsertKey(lastKey)
+	}
+
+	return recordChanges(session, changes, err)
+}
+
+// recordChanges stores the number of documents modified by a statement
+// in the session, if the statement succeeded. It returns err.
+func recordChanges(session *database.Session, changes int64, err error) error {
+	if session != nil && (err == nil || err == ErrStreamClosed) {
+		session.RecordChanges(changes)
+	}
+
+	return err
 }
 
 func (op *TableInsertOperator) String() string {
@@ -443,9 +980,11 @@ func TableReplace(tableName string) *TableReplaceOperator {
 // Iterate implements the Operator interface.
 func (op *TableReplaceOperator) Iterate(in *environment.Environment, f func(out *environment.Environment) error) error {
 	var table *database.Table
+	var triggers *tableTriggers
 	var newEnv environment.Environment
+	var changes int64
 
-	return op.Prev.Iterate(in, func(out *environment.Environment) error {
+	err := op.Prev.Iterate(in, func(out *environment.Environment) error {
 		d, ok := out.GetDocument()
 		if !ok {
 			return errors.New("missing document")
@@ -457,6 +996,12 @@ func (op *TableReplaceOperator) Iterate(in *environment.Environment, f func(out
 			if err != nil {
 				return err
 			}
+			table = table.WithSessionSettings(out.GetSession())
+
+			triggers, err = loadTriggers(out, op.Name, database.TriggerUpdate)
+			if err != nil {
+				return err
+			}
 		}
 
 		ker, ok := d.(document.Keyer)
@@ -469,7 +1014,27 @@ func (op *TableReplaceOperator) Iterate(in *environment.Environment, f func(out
 			return errors.New("missing key")
 		}
 
-		_, err := table.Replace(ker.RawKey(), d)
+		var old document.Document
+		if triggers != nil {
+			var err error
+			old, err = table.GetDocument(k)
+			if err != nil {
+				return err
+			}
+		}
+
+		err := triggers.fireBefore(out, old, d)
+		if err != nil {
+			return err
+		}
+
+		d, err = table.Replace(ker.RawKey(), d)
+		if err != nil {
+			return err
+		}
+		changes++
+
+		err = triggers.fireAfter(out, old, d)
 		if err != nil {
 			return err
 		}
@@ -477,6 +1042,8 @@ func (op *TableReplaceOperator) Iterate(in *environment.Environment, f func(out
 		newEnv.SetOuter(out)
 		return f(&newEnv)
 	})
+
+	return recordChanges(in.GetSession(), changes, err)
 }
 
 func (op *TableReplaceOperator) String() string {
@@ -487,6 +1054,9 @@ func (op *TableReplaceOperator) String() string {
 type TableDeleteOperator struct {
 	baseOperator
 	Name string
+	// Purge permanently deletes the soft deleted documents it receives
+	// and ignores the others, i.e. PURGE foo.
+	Purge bool
 }
 
 // TableDelete deletes documents from the table. Incoming documents must implement the document.Keyer interface.
@@ -497,9 +1067,11 @@ func TableDelete(tableName string) *TableDeleteOperator {
 // Iterate implements the Operator interface.
 func (op *TableDeleteOperator) Iterate(in *environment.Environment, f func(out *environment.Environment) error) error {
 	var table *database.Table
+	var triggers *tableTriggers
 	var newEnv environment.Environment
+	var changes int64
 
-	return op.Prev.Iterate(in, func(out *environment.Environment) error {
+	err := op.Prev.Iterate(in, func(out *environment.Environment) error {
 		d, ok := out.GetDocument()
 		if !ok {
 			return errors.New("missing document")
@@ -511,6 +1083,11 @@ func (op *TableDeleteOperator) Iterate(in *environment.Environment, f func(out *
 			if err != nil {
 				return err
 			}
+
+			triggers, err = loadTriggers(out, op.Name, database.TriggerDelete)
+			if err != nil {
+				return err
+			}
 		}
 
 		ker, ok := d.(document.Keyer)
@@ -523,7 +1100,45 @@ func (op *TableDeleteOperator) Iterate(in *environment.Environment, f func(out *
 			return errors.New("missing key")
 		}
 
-		err := table.Delete(ker.RawKey())
+		// triggers already fired when the documents were soft deleted
+		if op.Purge {
+			purged, err := table.Purge(k)
+			if err != nil || !purged {
+				return err
+			}
+			changes++
+
+			newEnv.SetOuter(out)
+			return f(&newEnv)
+		}
+
+		err := triggers.fireBefore(out, d, nil)
+		if err != nil {
+			return err
+		}
+
+		// the document may not be readable once deleted
+		old := d
+		if triggers.hasAfter() {
+			fb := document.NewFieldBuffer()
+			err = fb.Copy(d)
+			if err != nil {
+				return err
+			}
+			old = fb
+		}
+
+		if table.Info.SoftDelete {
+			err = table.SoftDelete(k)
+		} else {
+			err = table.Delete(k)
+		}
+		if err != nil {
+			return err
+		}
+		changes++
+
+		err = triggers.fireAfter(out, old, nil)
 		if err != nil {
 			return err
 		}
@@ -531,9 +1146,15 @@ func (op *TableDeleteOperator) Iterate(in *environment.Environment, f func(out *
 		newEnv.SetOuter(out)
 		return f(&newEnv)
 	})
+
+	return recordChanges(in.GetSession(), changes, err)
 }
 
 func (op *TableDeleteOperator) String() string {
+	if op.Purge {
+		return stringutil.Sprintf("tableDelete('%s', purge)", op.Name)
+	}
+
 	return stringutil.Sprintf("tableDelete('%s')", op.Name)
 }
 
@@ -549,11 +1170,32 @@ func Distinct() *DistinctOperator {
 
 // Iterate implements the Operator interface.
 func (op *DistinctOperator) Iterate(in *environment.Environment, f func(out *environment.Environment) error) error {
+	return op.distinct(in, workMem(in), 0, func(fn func(out *environment.Environment) error) error {
+		return op.Prev.Iterate(in, fn)
+	}, f)
+}
+
+// distinct filters the duplicate documents returned by iterate.
+// Once the documents already seen don't fit in the memory budget, new documents
+// are written to temporary files, partitioned by value, and filtered once
+// all the documents have been read.
+func (op *DistinctOperator) distinct(in *environment.Environment, budget int64, depth int,
+	iterate func(fn func(out *environment.Environment) error) error, f func(out *environment.Environment) error) error {
 	var buf bytes.Buffer
 	enc := document.NewValueEncoder(&buf)
 	m := make(map[string]struct{})
 
-	return op.Prev.Iterate(in, func(out *environment.Environment) error {
+	// estimated memory used by m
+	var size int64
+
+	var spill *spillPartitioner
+	defer func() {
+		if spill != nil {
+			spill.Close()
+		}
+	}()
+
+	err := iterate(func(out *environment.Environment) error {
 		buf.Reset()
 
 		d, ok := out.GetDocument()
@@ -584,10 +1226,45 @@ func (op *DistinctOperator) Iterate(in *environment.Environment, f func(out *env
 			return nil
 		}
 
+		// if there is not enough memory to remember the value,
+		// write the document to disk to filter it later.
+		cost := int64(buf.Len() + hashEntryOverhead)
+		if budget > 0 && depth < maxSpillDepth && len(m) > 0 && size+cost > budget {
+			if spill == nil {
+				spill = newSpillPartitioner(spillCodec(in), depth)
+			}
+
+			return spill.Write(buf.String(), d)
+		}
+
+		size += cost
 		m[buf.String()] = struct{}{}
 
 		return f(out)
 	})
+	if err != nil || spill == nil {
+		return err
+	}
+
+	// values of different partitions are different,
+	// release the memory and filter each partition separately.
+	m = nil
+	for _, p := range spill.Partitions() {
+		p := p
+		err = op.distinct(in, budget, depth+1, func(fn func(out *environment.Environment) error) error {
+			return p.Iterate(in.GetContext(), func(d document.Document) error {
+				var env environment.Environment
+				env.SetOuter(in)
+				env.SetDocument(d)
+				return fn(&env)
+			})
+		}, f)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
 }
 
 func (op *DistinctOperator) String() string {
@@ -772,3 +1449,54 @@ func (op *IterRenameOperator) Iterate(in *environment.Environment, f func(out *e
 func (op *IterRenameOperator) String() string {
 	return stringutil.Sprintf("iterRename(%s)", strings.Join(op.FieldNames, ", "))
 }
+
+// An IteratorOperator runs a function reading the documents of the stream
+// as an iterator and outputting documents of its own.
+// It is used to run operators defined outside of this package.
+type IteratorOperator struct {
+	baseOperator
+	Name string
+	Fn   func(docs document.Iterator, fn func(d document.Document) error) error
+}
+
+// Iterator creates an IteratorOperator.
+func Iterator(name string, fn func(docs document.Iterator, fn func(d document.Document) error) error) *IteratorOperator {
+	return &IteratorOperator{Name: name, Fn: fn}
+}
+
+// Iterate calls the function of the operator with the documents of the previous operator
+// and passes the documents it outputs to f.
+func (op *IteratorOperator) Iterate(in *environment.Environment, f func(out *environment.Environment) error) error {
+	var newEnv environment.Environment
+	newEnv.SetOuter(in)
+
+	docs := documentIterator(func(fn func(d document.Document) error) error {
+		if op.Prev == nil {
+			return nil
+		}
+
+		return op.Prev.Iterate(in, func(out *environment.Environment) error {
+			// environments without a document are not returned to the user either
+			if out.Doc == nil {
+				return nil
+			}
+
+			return fn(out.Doc)
+		})
+	})
+
+	return op.Fn(docs, func(d document.Document) error {
+		newEnv.SetDocument(d)
+		return f(&newEnv)
+	})
+}
+
+func (op *IteratorOperator) String() string {
+	return stringutil.Sprintf("%s()", op.Name)
+}
+
+type documentIterator func(fn func(d document.Document) error) error
+
+func (it documentIterator) Iterate(fn func(d document.Document) error) error {
+	return it(fn)
+}
@@ -349,6 +349,129 @@ func TestSort(t *testing.T) {
 
 	t.Run("String", func(t *testing.T) {
 		require.Equal(t, `sort(a)`, stream.Sort(parser.MustParseExpr("a")).String())
+		require.Equal(t, `sortReverse(a)`, stream.SortReverse(parser.MustParseExpr("a")).String())
+		require.Equal(t, `sort(a DESC, b + 1)`, stream.SortBy(
+			expr.OrderTerm{E: parser.MustParseExpr("a"), Desc: true},
+			expr.OrderTerm{E: parser.MustParseExpr("b + 1")},
+		).String())
+	})
+}
+
+func TestSortBy(t *testing.T) {
+	in := testutil.MakeDocuments(t,
+		`{"a": 1, "b": 2, "c": 1}`,
+		`{"a": 2, "b": 1, "c": 2}`,
+		`{"a": 1, "b": 1, "c": 3}`,
+		`{"a": 2, "b": 1, "c": 4}`,
+		`{"a": 1, "b": 2, "c": 5}`,
+	)
+
+	tests := []struct {
+		name  string
+		terms []expr.OrderTerm
+		want  []string
+	}{
+		{
+			"ASC, ASC",
+			[]expr.OrderTerm{{E: parser.MustParseExpr("a")}, {E: parser.MustParseExpr("b")}},
+			[]string{`{"c": 3}`, `{"c": 1}`, `{"c": 5}`, `{"c": 2}`, `{"c": 4}`},
+		},
+		{
+			"DESC, ASC",
+			[]expr.OrderTerm{{E: parser.MustParseExpr("a"), Desc: true}, {E: parser.MustParseExpr("b")}},
+			[]string{`{"c": 2}`, `{"c": 4}`, `{"c": 3}`, `{"c": 1}`, `{"c": 5}`},
+		},
+		{
+			"ASC, DESC",
+			[]expr.OrderTerm{{E: parser.MustParseExpr("a")}, {E: parser.MustParseExpr("b"), Desc: true}},
+			[]string{`{"c": 1}`, `{"c": 5}`, `{"c": 3}`, `{"c": 2}`, `{"c": 4}`},
+		},
+		{
+			"Expr",
+			[]expr.OrderTerm{{E: parser.MustParseExpr("a * 10 + c"), Desc: true}},
+			[]string{`{"c": 4}`, `{"c": 2}`, `{"c": 5}`, `{"c": 3}`, `{"c": 1}`},
+		},
+		{
+			"Projected",
+			[]expr.OrderTerm{{E: parser.MustParseExpr("b")}, {E: parser.MustParseExpr("x"), Desc: true}},
+			[]string{`{"c": 4}`, `{"c": 3}`, `{"c": 2}`, `{"c": 5}`, `{"c": 1}`},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			s := stream.New(stream.Documents(in...)).
+				Pipe(stream.Project(
+					&expr.NamedExpr{Expr: parser.MustParseExpr("c"), ExprName: "c"},
+					&expr.NamedExpr{Expr: parser.MustParseExpr("c"), ExprName: "x"},
+				)).
+				Pipe(stream.SortBy(test.terms...))
+
+			// a tiny budget forces every value to be written to its own run
+			session := database.NewSession()
+			err := session.Set("work_mem", document.NewIntegerValue(1))
+			require.NoError(t, err)
+
+			for _, env := range []*environment.Environment{new(environment.Environment), {Session: session}} {
+				var got []document.Document
+				err := s.Iterate(env, func(env *environment.Environment) error {
+					d, ok := env.GetDocument()
+					require.True(t, ok)
+					fb := document.NewFieldBuffer()
+					v, err := d.GetByField("c")
+					require.NoError(t, err)
+					fb.Add("c", v)
+					got = append(got, fb)
+					return nil
+				})
+				require.NoError(t, err)
+
+				testutil.MakeDocuments(t, test.want...).RequireEqual(t, got)
+			}
+		})
+	}
+
+	t.Run("Spill", func(t *testing.T) {
+		session := database.NewSession()
+		err := session.Set("work_mem", document.NewIntegerValue(200))
+		require.NoError(t, err)
+
+		// more runs than can be merged at once
+		var docs []document.Document
+		for i := 0; i < 1000; i++ {
+			docs = append(docs, testutil.MakeDocument(t, `{"a": `+strconv.Itoa((i*7)%10)+`, "b": `+strconv.Itoa(i)+`}`))
+		}
+
+		for _, desc := range []bool{false, true} {
+			s := stream.New(stream.Documents(docs...)).Pipe(stream.SortBy(expr.OrderTerm{E: parser.MustParseExpr("a"), Desc: desc}))
+
+			var a, b []int64
+			err = s.Iterate(&environment.Environment{Session: session}, func(env *environment.Environment) error {
+				d, ok := env.GetDocument()
+				require.True(t, ok)
+				v, err := d.GetByField("a")
+				require.NoError(t, err)
+				a = append(a, v.V.(int64))
+				v, err = d.GetByField("b")
+				require.NoError(t, err)
+				b = append(b, v.V.(int64))
+				return nil
+			})
+			require.NoError(t, err)
+			require.Len(t, a, 1000)
+
+			for i := 1; i < len(a); i++ {
+				if desc {
+					require.GreaterOrEqual(t, a[i-1], a[i])
+				} else {
+					require.LessOrEqual(t, a[i-1], a[i])
+				}
+				// documents with the same value are returned in the order they arrived
+				if a[i-1] == a[i] {
+					require.Less(t, b[i-1], b[i])
+				}
+			}
+		}
 	})
 }
 
@@ -602,6 +725,36 @@ func TestDistinct(t *testing.T) {
 		})
 	}
 
+	t.Run("Spill", func(t *testing.T) {
+		// a tiny budget forces every new value but the first one to be written to disk
+		session := database.NewSession()
+		err := session.Set("work_mem", document.NewIntegerValue(1))
+		require.NoError(t, err)
+
+		var docs []document.Document
+		for i := 0; i < 3; i++ {
+			docs = append(docs, generateSeqDocs(t, 50)...)
+		}
+
+		s := stream.New(stream.Documents(docs...)).Pipe(stream.Distinct())
+
+		got := make(map[int64]int)
+		err = s.Iterate(&environment.Environment{Session: session}, func(env *environment.Environment) error {
+			d, ok := env.GetDocument()
+			require.True(t, ok)
+			v, err := d.GetByField("a")
+			require.NoError(t, err)
+			got[v.V.(int64)]++
+			return nil
+		})
+		require.NoError(t, err)
+
+		require.Len(t, got, 50)
+		for i := int64(0); i < 50; i++ {
+			require.Equal(t, 1, got[i])
+		}
+	})
+
 	t.Run("String", func(t *testing.T) {
 		require.Equal(t, `distinct()`, stream.Distinct().String())
 	})
@@ -740,3 +893,37 @@ func TestIterRename(t *testing.T) {
 		require.Equal(t, stream.IterRename("a", "b", "c").String(), "iterRename(a, b, c)")
 	})
 }
+
+func TestIterator(t *testing.T) {
+	in := []document.Document{
+		testutil.MakeDocument(t, `{"a": 1}`),
+		testutil.MakeDocument(t, `{"a": 2}`),
+		testutil.MakeDocument(t, `{"a": 3}`),
+	}
+
+	// outputs every other document
+	op := stream.Iterator("odd", func(docs document.Iterator, fn func(d document.Document) error) error {
+		var i int
+		return docs.Iterate(func(d document.Document) error {
+			i++
+			if i%2 == 0 {
+				return nil
+			}
+			return fn(d)
+		})
+	})
+
+	s := stream.New(stream.Documents(in...)).Pipe(op)
+
+	var got []document.Document
+	err := s.Iterate(new(environment.Environment), func(out *environment.Environment) error {
+		d, ok := out.GetDocument()
+		require.True(t, ok)
+		got = append(got, d)
+		return nil
+	})
+	require.NoError(t, err)
+	require.Equal(t, []document.Document{in[0], in[2]}, got)
+
+	require.Equal(t, `docs({"a": 1}, {"a": 2}, {"a": 3}) | odd()`, s.String())
+}
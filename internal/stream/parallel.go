@@ -0,0 +1,286 @@
+package stream
+
+import (
+	"context"
+	"sync"
+
+	"github.com/genjidb/genji/document"
+	"github.com/genjidb/genji/internal/database"
+	"github.com/genjidb/genji/internal/environment"
+	"github.com/genjidb/genji/internal/stringutil"
+)
+
+// parallelScanBatchSize is the number of consecutive documents read at once by the workers
+// of a parallel scan.
+const parallelScanBatchSize = 256
+
+// A ParallelScanOperator reads the documents of a table and applies the filters and projections
+// following the scan on multiple goroutines.
+// The key space of the table is split into batches of consecutive documents, which are read
+// one at a time and processed by the first available worker, while the results are returned
+// in key order.
+// The number of workers is read from the max_parallel_workers setting of the session when the stream
+// is iterated. With less than two workers, or in read/write transactions, where documents can be
+// written while the table is read, the stream is iterated like any other stream.
+type ParallelScanOperator struct {
+	baseOperator
+	// Stream starts with the seq scan reading the table, followed by
+	// the filters and projections applied by the workers.
+	Stream *Stream
+}
+
+// ParallelScan creates a ParallelScanOperator.
+func ParallelScan(s *Stream) *ParallelScanOperator {
+	return &ParallelScanOperator{Stream: s}
+}
+
+// a parallelBatch contains the results of a batch of documents processed by a worker.
+type parallelBatch struct {
+	seq  int
+	envs []*environment.Environment
+	err  error
+}
+
+// Iterate implements the Operator interface.
+func (op *ParallelScanOperator) Iterate(in *environment.Environment, fn func(out *environment.Environment) error) error {
+	scan, ok := op.Stream.First().(*SeqScanOperator)
+	workers := maxParallelWorkers(in)
+	tx := in.GetTx()
+	if !ok || workers < 2 || tx == nil || tx.Writable || scan.Offset > 0 || scan.Limit > 0 || !op.isParallelizable() {
+		return op.Stream.Iterate(in, fn)
+	}
+
+	table, err := in.GetCatalog().GetTable(tx, scan.TableName)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithCancel(in.GetContext())
+	defer cancel()
+
+	r := parallelReader{table: table, reverse: scan.Reverse}
+
+	// each worker acquires a token before reading a batch, which is released once the batch
+	// is returned, to limit the number of batches kept in memory while waiting for slower workers
+	tokens := make(chan struct{}, 2*workers)
+	results := make(chan parallelBatch, workers)
+
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			op.work(ctx, in, &r, tokens, results)
+		}()
+	}
+	go func() {
+		wg.Wait()
+		close(results)
+	}()
+
+	err = op.merge(results, tokens, fn)
+
+	// stop the workers and wait for them, the transaction must not be used once Iterate returns
+	cancel()
+	for range results {
+	}
+
+	return err
+}
+
+// work reads batches of documents and processes them until the table is read entirely.
+func (op *ParallelScanOperator) work(ctx context.Context, in *environment.Environment, r *parallelReader, tokens chan struct{}, results chan<- parallelBatch) {
+	for {
+		select {
+		case tokens <- struct{}{}:
+		case <-ctx.Done():
+			return
+		}
+
+		seq, docs, err := r.next(ctx)
+		if seq < 0 {
+			<-tokens
+			return
+		}
+
+		// the documents read before an error are returned as well
+		b := parallelBatch{seq: seq}
+		for _, d := range docs {
+			var out *environment.Environment
+			out, b.err = op.process(in, d)
+			if b.err != nil {
+				break
+			}
+			if out != nil {
+				b.envs = append(b.envs, out)
+			}
+		}
+		if b.err == nil {
+			b.err = err
+		}
+
+		select {
+		case results <- b:
+		case <-ctx.Done():
+			return
+		}
+
+		if b.err != nil {
+			return
+		}
+	}
+}
+
+// merge returns the results of the batches in the order they were read.
+func (op *ParallelScanOperator) merge(results <-chan parallelBatch, tokens chan struct{}, fn func(out *environment.Environment) error) error {
+	pending := make(map[int]parallelBatch)
+	var next int
+
+	for b := range results {
+		pending[b.seq] = b
+
+		for {
+			b, ok := pending[next]
+			if !ok {
+				break
+			}
+			delete(pending, next)
+			next++
+			<-tokens
+
+			for _, env := range b.envs {
+				err := fn(env)
+				if err != nil {
+					return err
+				}
+			}
+			if b.err != nil {
+				return b.err
+			}
+		}
+	}
+
+	return nil
+}
+
+// process applies the filters of the scan and the operators following it to the document.
+// It returns nil if the document is filtered out.
+// Projections are evaluated immediately, to be computed by the worker.
+func (op *ParallelScanOperator) process(in *environment.Environment, d document.Document) (*environment.Environment, error) {
+	env := new(environment.Environment)
+	env.SetOuter(in)
+
+	ok, err := matchFilters(env, d, op.Stream.First().(*SeqScanOperator).Filters)
+	if err != nil || !ok {
+		return nil, err
+	}
+
+	for n := op.Stream.First().GetNext(); n != nil; n = n.GetNext() {
+		switch t := n.(type) {
+		case *FilterOperator:
+			v, err := t.E.Eval(env)
+			if err != nil {
+				return nil, err
+			}
+
+			ok, err := v.IsTruthy()
+			if err != nil || !ok {
+				return nil, err
+			}
+		case *ProjectOperator:
+			fb := document.NewFieldBuffer()
+			err := fb.Copy(&MaskDocument{Env: env, Exprs: t.Exprs})
+			if err != nil {
+				return nil, err
+			}
+
+			newEnv := new(environment.Environment)
+			newEnv.SetOuter(env)
+			newEnv.SetDocument(fb)
+			env = newEnv
+		}
+	}
+
+	return env, nil
+}
+
+// isParallelizable returns true if the workers know how to apply the operators following the scan.
+func (op *ParallelScanOperator) isParallelizable() bool {
+	for n := op.Stream.First().GetNext(); n != nil; n = n.GetNext() {
+		switch n.(type) {
+		case *FilterOperator, *ProjectOperator:
+		default:
+			return false
+		}
+	}
+
+	return true
+}
+
+func (op *ParallelScanOperator) String() string {
+	return stringutil.Sprintf("parallelScan(%s)", op.Stream)
+}
+
+// A parallelReader reads the batches of documents of a parallel scan.
+// Batches are read one at a time, since transactions can't be used concurrently.
+type parallelReader struct {
+	mu      sync.Mutex
+	table   *database.Table
+	reverse bool
+	// key of the last document read
+	last []byte
+	seq  int
+	done bool
+}
+
+// next reads the next batch of documents and returns its sequence number,
+// or -1 if the table was read entirely.
+// Documents that can't be read according to the read policy of the table are left out.
+func (r *parallelReader) next(ctx context.Context) (int, []document.Document, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.done {
+		return -1, nil, nil
+	}
+
+	seq := r.seq
+	r.seq++
+
+	docs, err := r.table.ReadBatch(ctx, r.last, r.reverse, parallelScanBatchSize)
+	if err != nil {
+		r.done = true
+		return seq, nil, err
+	}
+
+	if len(docs) < parallelScanBatchSize {
+		r.done = true
+	}
+	if len(docs) > 0 {
+		r.last = docs[len(docs)-1].(document.Keyer).RawKey()
+	}
+
+	readable := docs[:0]
+	for _, d := range docs {
+		ok, err := r.table.Policy.CanRead(r.table.Tx, d)
+		if err != nil {
+			r.done = true
+			return seq, readable, err
+		}
+		if ok {
+			readable = append(readable, d)
+		}
+	}
+
+	return seq, readable, nil
+}
+
+// maxParallelWorkers returns the number of workers parallel scans may use.
+func maxParallelWorkers(env *environment.Environment) int {
+	s := env.GetSession()
+	if s == nil {
+		return 0
+	}
+
+	return s.MaxParallelWorkers()
+}
@@ -0,0 +1,133 @@
+package stream_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/genjidb/genji/document"
+	"github.com/genjidb/genji/internal/database"
+	"github.com/genjidb/genji/internal/environment"
+	"github.com/genjidb/genji/internal/expr"
+	"github.com/genjidb/genji/internal/sql/parser"
+	"github.com/genjidb/genji/internal/stream"
+	"github.com/genjidb/genji/internal/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParallelScanOperator(t *testing.T) {
+	db, cleanup := testutil.NewTestDB(t)
+	defer cleanup()
+
+	tx, err := db.Begin(true)
+	require.NoError(t, err)
+	testutil.MustExec(t, db, tx, "CREATE TABLE test (k INTEGER PRIMARY KEY, a INTEGER)")
+	for i := 0; i < 1000; i++ {
+		testutil.MustExec(t, db, tx, "INSERT INTO test (k, a) VALUES (?, ?)", environment.Param{Value: i}, environment.Param{Value: i % 7})
+	}
+	require.NoError(t, tx.Commit())
+
+	tx, err = db.Begin(false)
+	require.NoError(t, err)
+	defer tx.Rollback()
+
+	newStream := func(reverse bool) *stream.Stream {
+		scan := stream.SeqScan("test")
+		scan.Reverse = reverse
+		return stream.New(scan).
+			Pipe(stream.Filter(parser.MustParseExpr("a = 3"))).
+			Pipe(stream.Project(parser.MustParseExpr("k"), parser.MustParseExpr("a + k")))
+	}
+
+	iterate := func(s *stream.Stream, workers int64, limit int) ([]string, error) {
+		session := database.NewSession()
+		err := session.Set("max_parallel_workers", document.NewIntegerValue(workers))
+		require.NoError(t, err)
+
+		var in environment.Environment
+		in.Tx = tx
+		in.Catalog = db.Catalog
+		in.Session = session
+
+		var got []string
+		err = s.Iterate(&in, func(env *environment.Environment) error {
+			d, ok := env.GetDocument()
+			require.True(t, ok)
+			data, err := document.MarshalJSON(d)
+			require.NoError(t, err)
+			got = append(got, string(data))
+			if len(got) == limit {
+				return stream.ErrStreamClosed
+			}
+			return nil
+		})
+		return got, err
+	}
+
+	for _, reverse := range []bool{false, true} {
+		expected, err := iterate(newStream(reverse), 0, 0)
+		require.NoError(t, err)
+		require.Len(t, expected, 143)
+
+		// results are returned in key order, whatever the number of workers
+		for _, workers := range []int64{0, 1, 2, 4, 16} {
+			got, err := iterate(stream.New(stream.ParallelScan(newStream(reverse))), workers, 0)
+			require.NoError(t, err)
+			require.Equal(t, expected, got)
+		}
+
+		got, err := iterate(stream.New(stream.ParallelScan(newStream(reverse))), 4, 50)
+		require.Equal(t, stream.ErrStreamClosed, err)
+		require.Equal(t, expected[:50], got)
+	}
+
+	t.Run("Scan filters", func(t *testing.T) {
+		newStream := func() *stream.Stream {
+			scan := stream.SeqScan("test")
+			scan.Filters = []expr.Expr{parser.MustParseExpr("k % 2 = 0")}
+			return stream.New(scan).Pipe(stream.Filter(parser.MustParseExpr("a = 3")))
+		}
+
+		expected, err := iterate(newStream(), 0, 0)
+		require.NoError(t, err)
+		require.Len(t, expected, 71)
+
+		got, err := iterate(stream.New(stream.ParallelScan(newStream())), 4, 0)
+		require.NoError(t, err)
+		require.Equal(t, expected, got)
+	})
+
+	t.Run("Errors", func(t *testing.T) {
+		// the documents preceding the error are returned
+		s := stream.New(stream.ParallelScan(stream.New(stream.SeqScan("test")).
+			Pipe(stream.Filter(parser.MustParseExpr("k < 600 OR CAST('x' AS INTEGER) > 0")))))
+
+		got, err := iterate(s, 4, 0)
+		require.Error(t, err)
+		require.Len(t, got, 600)
+	})
+
+	t.Run("Read policy", func(t *testing.T) {
+		db.Catalog.SetRowPolicy("test", &database.RowPolicy{
+			Read: func(ctx context.Context, d document.Document) (bool, error) {
+				v, err := d.GetByField("k")
+				if err != nil {
+					return false, err
+				}
+				if v.V.(int64) == 900 {
+					return false, errors.New("forbidden")
+				}
+				return v.V.(int64)%2 == 0, nil
+			},
+		})
+		defer db.Catalog.SetRowPolicy("test", nil)
+
+		got, err := iterate(stream.New(stream.ParallelScan(newStream(false))), 4, 0)
+		require.EqualError(t, err, "forbidden")
+		require.Len(t, got, 64)
+	})
+
+	t.Run("String", func(t *testing.T) {
+		require.Equal(t, `parallelScan(seqScan(test) | filter(a = 3) | project(k, a + k))`, stream.ParallelScan(newStream(false)).String())
+	})
+}
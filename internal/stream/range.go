@@ -16,6 +16,11 @@ type Costable interface {
 	Cost() int
 }
 
+// defaultSelectivity is the fraction of the documents or entries assumed to be
+// matched by a range whose number of matches can't be estimated,
+// for example because its boundaries are parameters.
+const defaultSelectivity = 1.0 / 3
+
 type ValueRange struct {
 	Min, Max expr.Expr
 	// Exclude Min and Max from the results.
@@ -26,6 +31,29 @@ type ValueRange struct {
 	// If set to true, Max will be ignored for comparison
 	// and for determining the global upper bound.
 	Exact bool
+	// Used when Min evaluates to an array: the range matches
+	// each value of the array exactly. This is used by IN operators
+	// whose operand is only known at execution time, like parameters.
+	In bool
+}
+
+// expand evaluates Min and returns one exact range per value of the resulting array.
+// NULL values are ignored because they can't be matched by the IN operator.
+func (r *ValueRange) expand(env *environment.Environment) (ValueRanges, error) {
+	v, err := r.Min.Eval(env)
+	if err != nil || v.Type != document.ArrayValue {
+		return nil, err
+	}
+
+	var ranges ValueRanges
+	err = v.V.(document.Array).Iterate(func(i int, v document.Value) error {
+		if v.Type != document.NullValue {
+			ranges = append(ranges, ValueRange{Min: expr.LiteralValue(v), Exact: true})
+		}
+		return nil
+	})
+
+	return ranges, err
 }
 
 func (r *ValueRange) evalRange(table *database.Table, env *environment.Environment) (*encodedValueRange, bool, error) {
@@ -112,6 +140,10 @@ func (r *ValueRange) encode(table *database.Table, env *environment.Environment)
 }
 
 func (r *ValueRange) String() string {
+	if r.In {
+		return stringutil.Sprintf("IN %v", r.Min)
+	}
+
 	if r.Exact {
 		return stringutil.Sprintf("%v", r.Min)
 	}
@@ -137,6 +169,10 @@ func (r *ValueRange) IsEqual(other *ValueRange) bool {
 		return false
 	}
 
+	if r.In != other.In {
+		return false
+	}
+
 	if r.Exclusive != other.Exclusive {
 		return false
 	}
@@ -174,6 +210,33 @@ func (r *encodedValueRange) Convert(v document.Value, isMin bool) (document.Valu
 	// if a number is encountered, try to convert it to the right type if and only if the conversion
 	// is lossless.
 	v, err := r.constraints.ConvertValueAtPath(r.path, v, func(v document.Value, path document.Path, targetType document.ValueType) (document.Value, error) {
+		// decimals are compared with integers and doubles as doubles
+		if v.Type == document.DecimalValue && (targetType == document.IntegerValue || targetType == document.DoubleValue) {
+			v, _ = v.CastAsDouble()
+		}
+
+		// times were stored as texts before the timestamp type existed,
+		// they are looked up in text indexes by their text form
+		if targetType == document.TextValue && v.Type == document.TimestampValue {
+			return v.CastAsText()
+		}
+
+		// and texts representing times are looked up in timestamp indexes as timestamps
+		if targetType == document.TimestampValue && v.Type == document.TextValue {
+			if ts, err := v.CastAsTimestamp(); err == nil {
+				return ts, nil
+			}
+		}
+
+		// integers and doubles are converted to decimals to be looked up in decimal indexes
+		if targetType == document.DecimalValue && (v.Type == document.IntegerValue || v.Type == document.DoubleValue) {
+			d, err := v.CastAsDecimal()
+			if err != nil {
+				return v, nil
+			}
+			return d, nil
+		}
+
 		if v.Type == document.IntegerValue && targetType == document.DoubleValue {
 			return v.CastAsDouble()
 		}
@@ -288,18 +351,69 @@ func (r ValueRanges) Encode(table *database.Table, env *environment.Environment)
 	ranges := make([]*encodedValueRange, 0, len(r))
 
 	for i := range r {
+		if r[i].In {
+			expanded, err := r[i].expand(env)
+			if err != nil {
+				return nil, err
+			}
+
+			encoded, err := expanded.Encode(table, env)
+			if err != nil {
+				return nil, err
+			}
+
+			// the same value can appear multiple times in the array
+			// but must be matched only once.
+			for _, rng := range encoded {
+				if !containsEncodedValueRange(ranges, rng) {
+					ranges = append(ranges, rng)
+				}
+			}
+			continue
+		}
+
 		rng, err := r[i].encode(table, env)
 		if err != nil {
 			return nil, err
 		}
-		if rng != nil {
+		// different expressions, like parameters, can evaluate to the same range
+		if rng != nil && !containsEncodedValueRange(ranges, rng) {
 			ranges = append(ranges, rng)
 		}
+
+		// untyped primary keys can contain times stored as texts
+		// before the timestamp type existed, they are looked up as well
+		if rng != nil && rng.Exact && rng.pkType.IsAny() && rng.Min.Type == document.TimestampValue {
+			text, err := rng.Min.CastAsText()
+			if err != nil {
+				return nil, err
+			}
+
+			alt := ValueRange{Min: expr.LiteralValue(text), Exact: true}
+			rng, err := alt.encode(table, env)
+			if err != nil {
+				return nil, err
+			}
+			if rng != nil && !containsEncodedValueRange(ranges, rng) {
+				ranges = append(ranges, rng)
+			}
+		}
 	}
 
 	return ranges, nil
 }
 
+func containsEncodedValueRange(ranges []*encodedValueRange, rng *encodedValueRange) bool {
+	for _, r := range ranges {
+		if r.Exact == rng.Exact && r.Exclusive == rng.Exclusive &&
+			bytes.Equal(r.EncodedMin, rng.EncodedMin) && bytes.Equal(r.EncodedMax, rng.EncodedMax) {
+			return true
+		}
+	}
+
+	return false
+}
+
 func (r ValueRanges) String() string {
 	var sb strings.Builder
 
@@ -345,6 +459,36 @@ func (r ValueRanges) Cost() int {
 	return cost
 }
 
+// EstimateRows returns the estimated number of documents matched by the ranges
+// in a table containing rowCount documents.
+// Exact ranges match one document, other ranges match a fixed fraction of the table.
+func (r ValueRanges) EstimateRows(rowCount int64) float64 {
+	var env environment.Environment
+	var n float64
+
+	for i := range r {
+		if r[i].In {
+			// parameters can't be evaluated before running the query
+			expanded, err := r[i].expand(&env)
+			if err != nil || expanded == nil {
+				n += float64(rowCount) * defaultSelectivity
+			} else {
+				n += float64(len(expanded))
+			}
+			continue
+		}
+
+		if r[i].Exact {
+			n++
+			continue
+		}
+
+		n += float64(rowCount) * defaultSelectivity
+	}
+
+	return n
+}
+
 // IndexRange represents a range to select indexed values after or before
 // a given boundary. Because indexes can be composites, IndexRange boundaries
 // are composite as well.
@@ -360,9 +504,62 @@ type IndexRange struct {
 	// and for determining the global upper bound.
 	Exact bool
 
-	// IndexArity is the underlying index arity, which can be greater
-	// than the boundaries of this range.
-	IndexArity int
+	// Used when the values of Min at the positions listed in InPositions evaluate to arrays:
+	// the range is replaced by one range per combination of the values of the arrays.
+	// This is used by IN operators whose operand is only known at execution
+	// time, like parameters.
+	In          bool
+	InPositions []int
+}
+
+// expand evaluates the first IN operand of Min and returns one range per value of the
+// resulting array. The returned ranges still have to be expanded if there are other IN operands.
+// NULL values are ignored because they can't be matched by the IN operator.
+func (r *IndexRange) expand(env *environment.Environment) (IndexRanges, error) {
+	pos := r.InPositions[0]
+
+	v, err := r.Min[pos].Eval(env)
+	if err != nil || v.Type != document.ArrayValue {
+		return nil, err
+	}
+
+	var ranges IndexRanges
+	err = v.V.(document.Array).Iterate(func(i int, v document.Value) error {
+		if v.Type == document.NullValue {
+			return nil
+		}
+
+		rng := r.Clone()
+		rng.InPositions = r.InPositions[1:]
+		rng.In = len(rng.InPositions) > 0
+		rng.Min = make(expr.LiteralExprList, len(r.Min))
+		copy(rng.Min, r.Min)
+		rng.Min[pos] = expr.LiteralValue(v)
+
+		// the prefix of composite ranges contains the IN operand as well
+		if len(r.Max) > pos {
+			rng.Max = make(expr.LiteralExprList, len(r.Max))
+			copy(rng.Max, r.Max)
+			rng.Max[pos] = expr.LiteralValue(v)
+		}
+
+		ranges = append(ranges, rng)
+		return nil
+	})
+
+	return ranges, err
+}
+
+// indexRangePath returns the path used to convert the operand at the given position.
+// Operands of multi-key positions are compared with the elements of the array,
+// which are converted like the first element.
+func indexRangePath(index *database.Index, pos int) document.Path {
+	p := index.Info.Paths[pos]
+	if pos != index.Info.MultiKeyPosition() {
+		return p
+	}
+
+	return append(p[:len(p):len(p)], document.PathFragment{ArrayIndex: 0})
 }
 
 func (r *IndexRange) evalRange(index *database.Index, table *database.Table, env *environment.Environment) (*encodedIndexRange, bool, error) {
@@ -371,7 +568,7 @@ func (r *IndexRange) evalRange(index *database.Index, table *database.Table, env
 
 		Exclusive:  r.Exclusive,
 		Exact:      r.Exact,
-		IndexArity: r.IndexArity,
+		IndexArity: index.Arity(),
 	}
 
 	if r.Min != nil {
@@ -383,7 +580,7 @@ func (r *IndexRange) evalRange(index *database.Index, table *database.Table, env
 
 		var ok bool
 		for i := range rng.Min.Values {
-			rng.Min.Values[i], ok, err = rng.Convert(rng.Min.Values[i], index.Info.Paths[i], index.Info.Types[i], true)
+			rng.Min.Values[i], ok, err = rng.Convert(rng.Min.Values[i], indexRangePath(index, i), index.Info.Types[i], true)
 			if err != nil || !ok {
 				return nil, ok, err
 			}
@@ -399,7 +596,7 @@ func (r *IndexRange) evalRange(index *database.Index, table *database.Table, env
 
 		var ok bool
 		for i := range rng.Max.Values {
-			rng.Max.Values[i], ok, err = rng.Convert(rng.Max.Values[i], index.Info.Paths[i], index.Info.Types[i], false)
+			rng.Max.Values[i], ok, err = rng.Convert(rng.Max.Values[i], indexRangePath(index, i), index.Info.Types[i], false)
 			if err != nil || !ok {
 				return nil, ok, err
 			}
@@ -416,7 +613,7 @@ func (r *IndexRange) encode(index *database.Index, table *database.Table, env *e
 	}
 
 	if len(r.Min) > 0 {
-		rng.EncodedMin, err = index.EncodeValueBuffer(rng.Min)
+		rng.EncodedMin, err = rng.encodeBoundary(index, rng.Min)
 		if err != nil {
 			return nil, err
 		}
@@ -424,26 +621,29 @@ func (r *IndexRange) encode(index *database.Index, table *database.Table, env *e
 	}
 
 	if len(r.Max) > 0 {
-		rng.EncodedMax, err = index.EncodeValueBuffer(rng.Max)
+		rng.EncodedMax, err = rng.encodeBoundary(index, rng.Max)
 		if err != nil {
 			return nil, err
 		}
 
+		maxTypes := rng.Max.Types()
 		if len(rng.RangeTypes) > 0 {
-			maxTypes := rng.Max.Types()
-
-			if len(maxTypes) != len(rng.RangeTypes) {
-				panic("range types for max and min differ in size")
+			// the shorter boundary of a composite range is a prefix of the other one
+			n := len(maxTypes)
+			if len(rng.RangeTypes) < n {
+				n = len(rng.RangeTypes)
 			}
 
-			for i, typ := range maxTypes {
+			for i, typ := range maxTypes[:n] {
 				if typ != rng.RangeTypes[i] {
 					panic("range contain values of different types")
 				}
 			}
 		}
 
-		rng.RangeTypes = rng.Max.Types()
+		if len(maxTypes) > len(rng.RangeTypes) {
+			rng.RangeTypes = maxTypes
+		}
 	}
 
 	// Ensure boundaries are typed, at least with the first type
@@ -469,6 +669,24 @@ func (r *IndexRange) encode(index *database.Index, table *database.Table, env *e
 		panic("exclusive and exact cannot both be true")
 	}
 
+	// when a composite range has boundaries of different lengths,
+	// the shorter one is the prefix shared by all the values of the range
+	// and is always inclusive.
+	rng.ExclusiveMin = rng.Exclusive && len(r.Min) >= len(r.Max)
+	rng.ExclusiveMax = rng.Exclusive && len(r.Max) >= len(r.Min)
+
+	// the values of descending paths are stored in reverse order:
+	// if the last path of the range is one of them, the boundaries are swapped
+	n := len(r.Min)
+	if len(r.Max) > n {
+		n = len(r.Max)
+	}
+	if !r.Exact && n > 0 && index.Info.Order(n-1).Desc {
+		rng.Min, rng.Max = rng.Max, rng.Min
+		rng.EncodedMin, rng.EncodedMax = rng.EncodedMax, rng.EncodedMin
+		rng.ExclusiveMin, rng.ExclusiveMax = rng.ExclusiveMax, rng.ExclusiveMin
+	}
+
 	return rng, nil
 }
 
@@ -484,6 +702,10 @@ func (r *IndexRange) String() string {
 		}
 	}
 
+	if r.In {
+		return stringutil.Sprintf("IN %v", format(r.Min))
+	}
+
 	if r.Exact {
 		return stringutil.Sprintf("%v", format(r.Min))
 	}
@@ -500,6 +722,16 @@ func (r *IndexRange) IsEqual(other *IndexRange) bool {
 		return false
 	}
 
+	if r.In != other.In || len(r.InPositions) != len(other.InPositions) {
+		return false
+	}
+
+	for i := range r.InPositions {
+		if r.InPositions[i] != other.InPositions[i] {
+			return false
+		}
+	}
+
 	if r.Exclusive != other.Exclusive {
 		return false
 	}
@@ -536,15 +768,67 @@ type encodedIndexRange struct {
 	Exact      bool
 	IndexArity int
 
+	// exclusivity of each boundary
+	ExclusiveMin, ExclusiveMax bool
+
 	EncodedMin, EncodedMax []byte
 	RangeTypes             []document.ValueType
 }
 
+// encodeBoundary encodes a boundary of the range.
+// Boundaries with fewer values than the index are followed by the delimiter
+// separating the values of the index, so that they only match the values they prefix.
+func (r *encodedIndexRange) encodeBoundary(index *database.Index, vb *document.ValueBuffer) ([]byte, error) {
+	enc, err := index.EncodeValueBuffer(vb)
+	if err != nil || vb.Len() >= r.IndexArity {
+		return enc, err
+	}
+
+	return append(enc, document.ArrayValueDelim), nil
+}
+
+// compareBoundary compares the value with a boundary of the range.
+// Boundaries with fewer values than the index are compared with the beginning of the value.
+func (r *encodedIndexRange) compareBoundary(value, boundary []byte, n int) int {
+	if n < r.IndexArity && len(value) > len(boundary) {
+		value = value[:len(boundary)]
+	}
+
+	return bytes.Compare(value, boundary)
+}
+
 func (r *encodedIndexRange) Convert(v document.Value, p document.Path, t document.ValueType, isMin bool) (document.Value, bool, error) {
 	// ensure the operand satisfies all the constraints, index can work only on exact types.
 	// if a number is encountered, try to convert it to the right type if and only if the conversion
 	// is lossless.
 	v, err := r.constraints.ConvertValueAtPath(p, v, func(v document.Value, path document.Path, targetType document.ValueType) (document.Value, error) {
+		// decimals are compared with integers and doubles as doubles
+		if v.Type == document.DecimalValue && (targetType == document.IntegerValue || targetType == document.DoubleValue) {
+			v, _ = v.CastAsDouble()
+		}
+
+		// times were stored as texts before the timestamp type existed,
+		// they are looked up in text indexes by their text form
+		if targetType == document.TextValue && v.Type == document.TimestampValue {
+			return v.CastAsText()
+		}
+
+		// and texts representing times are looked up in timestamp indexes as timestamps
+		if targetType == document.TimestampValue && v.Type == document.TextValue {
+			if ts, err := v.CastAsTimestamp(); err == nil {
+				return ts, nil
+			}
+		}
+
+		// integers and doubles are converted to decimals to be looked up in decimal indexes
+		if targetType == document.DecimalValue && (v.Type == document.IntegerValue || v.Type == document.DoubleValue) {
+			d, err := v.CastAsDecimal()
+			if err != nil {
+				return v, nil
+			}
+			return d, nil
+		}
+
 		if v.Type == document.IntegerValue && targetType == document.DoubleValue {
 			return v.CastAsDouble()
 		}
@@ -605,7 +889,7 @@ func (r *encodedIndexRange) IsInRange(value []byte) bool {
 
 	// we compare with the lower bound and see if it matches
 	if r.EncodedMin != nil {
-		cmpMin = bytes.Compare(value, r.EncodedMin)
+		cmpMin = r.compareBoundary(value, r.EncodedMin, r.Min.Len())
 	}
 
 	// if exact is true the value has to be equal to the lower bound.
@@ -615,28 +899,39 @@ func (r *encodedIndexRange) IsInRange(value []byte) bool {
 
 	// if exclusive and the value is equal to the lower bound
 	// we can ignore it
-	if r.Exclusive && cmpMin == 0 {
+	if r.ExclusiveMin && cmpMin == 0 {
 		return false
 	}
 
 	// the value is bigger than the lower bound,
 	// see if it matches the upper bound.
 	if r.EncodedMax != nil {
-		if r.Max.Len() < r.IndexArity {
-			cmpMax = bytes.Compare(value[:len(r.EncodedMax)], r.EncodedMax)
-		} else {
-			cmpMax = bytes.Compare(value, r.EncodedMax)
-		}
+		cmpMax = r.compareBoundary(value, r.EncodedMax, r.Max.Len())
 	}
 
 	// if boundaries are strict, ignore values equal to the max
-	if r.Exclusive && cmpMax == 0 {
+	if r.ExclusiveMax && cmpMax == 0 {
 		return false
 	}
 
 	return cmpMin >= 0 && cmpMax <= 0
 }
 
+// IsPastEnd returns whether the value comes after the end of the range,
+// when reading the index in the given direction.
+// Values of the index being sorted, the following values are out of range as well.
+func (r *encodedIndexRange) IsPastEnd(value []byte, reverse bool) bool {
+	if reverse {
+		return r.EncodedMin != nil && r.compareBoundary(value, r.EncodedMin, r.Min.Len()) < 0
+	}
+
+	if r.Exact {
+		return r.compareBoundary(value, r.EncodedMin, r.Min.Len()) > 0
+	}
+
+	return r.EncodedMax != nil && r.compareBoundary(value, r.EncodedMax, r.Max.Len()) > 0
+}
+
 type IndexRanges []IndexRange
 
 // Append rng to r and return the new slice.
@@ -667,18 +962,96 @@ func (r IndexRanges) EncodeBuffer(index *database.Index, table *database.Table,
 	ranges := make([]*encodedIndexRange, 0, len(r))
 
 	for i := range r {
+		if r[i].In {
+			expanded, err := r[i].expand(env)
+			if err != nil {
+				return nil, err
+			}
+
+			encoded, err := expanded.EncodeBuffer(index, table, env)
+			if err != nil {
+				return nil, err
+			}
+
+			// the same value can appear multiple times in the array
+			// but must be matched only once.
+			for _, enc := range encoded {
+				if !containsEncodedIndexRange(ranges, enc) {
+					ranges = append(ranges, enc)
+				}
+			}
+			continue
+		}
+
 		enc, err := r[i].encode(index, table, env)
 		if err != nil {
 			return nil, err
 		}
-		if enc != nil {
+		// different expressions, like parameters, can evaluate to the same range
+		if enc != nil && !containsEncodedIndexRange(ranges, enc) {
 			ranges = append(ranges, enc)
 		}
+
+		// untyped indexes can contain times stored as texts
+		// before the timestamp type existed, they are looked up as well
+		if enc == nil || !enc.Exact {
+			continue
+		}
+		values, err := textTimes(index, enc.Min)
+		if err != nil {
+			return nil, err
+		}
+		if values != nil {
+			alt := IndexRange{Min: values, Exact: true}
+			enc, err := alt.encode(index, table, env)
+			if err != nil {
+				return nil, err
+			}
+			if enc != nil && !containsEncodedIndexRange(ranges, enc) {
+				ranges = append(ranges, enc)
+			}
+		}
 	}
 
 	return ranges, nil
 }
 
+// textTimes returns the values of vb, whose timestamps looked up at untyped positions
+// of the index are replaced by their text form.
+// It returns nil if there is no such timestamp.
+func textTimes(index *database.Index, vb *document.ValueBuffer) (expr.LiteralExprList, error) {
+	var values expr.LiteralExprList
+	var found bool
+
+	for i, v := range vb.Values {
+		if v.Type == document.TimestampValue && index.Info.Types[i].IsAny() {
+			text, err := v.CastAsText()
+			if err != nil {
+				return nil, err
+			}
+			v, found = text, true
+		}
+		values = append(values, expr.LiteralValue(v))
+	}
+
+	if !found {
+		return nil, nil
+	}
+
+	return values, nil
+}
+
+func containsEncodedIndexRange(ranges []*encodedIndexRange, rng *encodedIndexRange) bool {
+	for _, r := range ranges {
+		if r.Exact == rng.Exact && r.Exclusive == rng.Exclusive &&
+			bytes.Equal(r.EncodedMin, rng.EncodedMin) && bytes.Equal(r.EncodedMax, rng.EncodedMax) {
+			return true
+		}
+	}
+
+	return false
+}
+
 func (r IndexRanges) String() string {
 	var sb strings.Builder
 
@@ -693,6 +1066,48 @@ func (r IndexRanges) String() string {
 	return sb.String()
 }
 
+// EstimateRows returns the estimated number of entries of the index matched by the ranges,
+// based on the statistics of the index.
+// Exact lookups of all the paths of the index are assumed to match the average number
+// of entries per value, other ranges are estimated using the histogram of the index.
+// Ranges whose boundaries can't be evaluated before running the query, like parameters,
+// match a fixed fraction of the entries.
+func (r IndexRanges) EstimateRows(index *database.Index, table *database.Table, stats *database.IndexStatistics) float64 {
+	var env environment.Environment
+	var n float64
+
+	for i := range r {
+		rng := &r[i]
+
+		encoded, err := IndexRanges{*rng}.EncodeBuffer(index, table, &env)
+		if err != nil {
+			if rng.Exact && !rng.In && len(rng.Min) == index.Arity() {
+				n += stats.EstimateEqual()
+			} else {
+				n += float64(stats.Entries) * defaultSelectivity
+			}
+			continue
+		}
+
+		for _, enc := range encoded {
+			if enc.Exact {
+				if len(rng.Min) == index.Arity() {
+					n += stats.EstimateEqual()
+				} else {
+					n += stats.EstimateRange(enc.EncodedMin, enc.EncodedMin)
+				}
+				continue
+			}
+
+			// missing boundaries are replaced by the type of the other one
+			// but are not encoded, the histogram only needs the actual ones.
+			n += stats.EstimateRange(enc.EncodedMin, enc.EncodedMax)
+		}
+	}
+
+	return n
+}
+
 // Cost is a best effort function to determine the cost of
 // a range lookup.
 func (r IndexRanges) Cost() int {
@@ -2,10 +2,16 @@ package stream
 
 import (
 	"bytes"
+	"context"
+	"errors"
+	"os"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/genjidb/genji/document"
+	"github.com/genjidb/genji/document/encoding"
+	errs "github.com/genjidb/genji/errors"
 	"github.com/genjidb/genji/internal/database"
 	"github.com/genjidb/genji/internal/environment"
 	"github.com/genjidb/genji/internal/expr"
@@ -103,11 +109,84 @@ func (op *ExprsOperator) String() string {
 	return sb.String()
 }
 
+// A JSONFileOperator iterates over the JSON objects of a file.
+type JSONFileOperator struct {
+	baseOperator
+	Path string
+}
+
+// JSONFile creates an operator that iterates over the objects of the JSON file
+// found at path, which can either hold a stream of objects, such as JSON Lines, or an array of objects.
+func JSONFile(path string) *JSONFileOperator {
+	return &JSONFileOperator{Path: path}
+}
+
+func (op *JSONFileOperator) Iterate(in *environment.Environment, fn func(out *environment.Environment) error) error {
+	f, err := os.Open(op.Path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	var newEnv environment.Environment
+	newEnv.SetOuter(in)
+
+	return document.IterateJSON(f, func(fb *document.FieldBuffer) error {
+		newEnv.SetDocument(fb)
+		return fn(&newEnv)
+	})
+}
+
+func (op *JSONFileOperator) String() string {
+	return stringutil.Sprintf("jsonFile(%q)", op.Path)
+}
+
 // A SeqScanOperator iterates over the documents of a table.
 type SeqScanOperator struct {
 	baseOperator
 	TableName string
 	Reverse   bool
+	// Filters only read the primary key of the documents. They are evaluated
+	// with the key decoded from the iterator, before the documents are decoded.
+	Filters []expr.Expr
+	// Paths lists the only paths read from the documents by the rest of the stream.
+	// If set, documents only contain the values found at these paths, decoded in one pass.
+	Paths []document.Path
+	// Offset and Limit restrict the documents returned by the scan,
+	// like the Skip and Take operators. A Limit of 0 returns every document.
+	Offset, Limit int64
+	// IndexHint restricts the indexes the planner can use to replace the scan.
+	IndexHint *IndexHint
+	// AsOf, if set, evaluates to the time at which the documents are read,
+	// i.e. SELECT * FROM foo AS OF '-5m'. See database.Table.IterateAsOf.
+	AsOf expr.Expr
+	// WithDeleted also returns the soft deleted documents of the table,
+	// i.e. SELECT * FROM foo WITH DELETED.
+	WithDeleted bool
+}
+
+// IndexHint restricts the indexes used to read a table,
+// i.e. SELECT * FROM foo USE INDEX (idx_a, idx_b) or IGNORE INDEX (idx_a).
+type IndexHint struct {
+	// If set to true, the indexes are never used,
+	// otherwise they are the only ones that can be used.
+	Ignore  bool
+	Indexes []string
+}
+
+// Allows returns true if the index can be used to read the table.
+func (h *IndexHint) Allows(indexName string) bool {
+	if h == nil {
+		return true
+	}
+
+	for _, name := range h.Indexes {
+		if name == indexName {
+			return !h.Ignore
+		}
+	}
+
+	return h.Ignore
 }
 
 // SeqScan creates an iterator that iterates over each document of the given table.
@@ -126,27 +205,105 @@ func (it *SeqScanOperator) Iterate(in *environment.Environment, fn func(out *env
 		return err
 	}
 
+	table.ShowDeleted = it.WithDeleted
+
 	var newEnv environment.Environment
 	newEnv.SetOuter(in)
 
-	var iterator func(pivot document.Value, fn func(d document.Document) error) error
-	if !it.Reverse {
+	var iterator func(ctx context.Context, pivot document.Value, fn func(d document.Document) error) error
+	switch {
+	case it.AsOf != nil:
+		at, err := evalAsOf(in, it.AsOf)
+		if err != nil {
+			return err
+		}
+
+		// the results depend on the time the query is run
+		in.GetTx().ReadTracker.SetVolatile()
+		iterator = func(ctx context.Context, _ document.Value, fn func(d document.Document) error) error {
+			return table.IterateAsOf(ctx, at, it.Reverse, fn)
+		}
+	case !it.Reverse:
 		iterator = table.AscendGreaterOrEqual
-	} else {
+	default:
 		iterator = table.DescendLessOrEqual
 	}
 
-	return iterator(document.Value{}, func(d document.Document) error {
+	var kd coveringDocument
+	kd.pk = table.Info.FieldConstraints.GetPrimaryKey()
+	pd := partialDocument{paths: it.Paths}
+
+	l := scanLimiter{offset: it.Offset, limit: it.Limit}
+	err = iterator(in.GetContext(), document.Value{}, func(d document.Document) error {
+		ok, err := readDocument(&newEnv, &kd, table, d, it.Filters)
+		if err != nil || !ok || l.skip() {
+			return err
+		}
+
+		d, err = pd.document(table, d)
+		if err != nil {
+			return err
+		}
+
 		newEnv.SetDocument(d)
-		return fn(&newEnv)
+		return l.call(&newEnv, fn)
 	})
+	if err == errLimitReached {
+		return nil
+	}
+	return err
 }
 
 func (it *SeqScanOperator) String() string {
-	if !it.Reverse {
-		return stringutil.Sprintf("seqScan(%s)", it.TableName)
+	var s strings.Builder
+
+	s.WriteString("seqScan")
+	if it.Reverse {
+		s.WriteString("Reverse")
+	}
+	s.WriteRune('(')
+	s.WriteString(it.TableName)
+	if it.AsOf != nil {
+		s.WriteString(", asOf(")
+		s.WriteString(it.AsOf.(stringutil.Stringer).String())
+		s.WriteRune(')')
+	}
+	if it.WithDeleted {
+		s.WriteString(", withDeleted")
+	}
+	writeScanFilters(&s, it.Filters)
+	writeScanPaths(&s, it.Paths)
+	writeScanLimit(&s, it.Offset, it.Limit)
+	s.WriteRune(')')
+
+	return s.String()
+}
+
+// evalAsOf evaluates the time at which a table is read. Timestamps are used as is, intervals
+// and durations, such as '-5m', are added to the current time, and other texts are cast to timestamps.
+func evalAsOf(env *environment.Environment, e expr.Expr) (time.Time, error) {
+	v, err := e.Eval(env)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	switch v.Type {
+	case document.TimestampValue:
+		return v.V.(time.Time), nil
+	case document.IntervalValue:
+		return document.AddInterval(time.Now(), v.V.(document.Interval)), nil
+	case document.TextValue:
+		if d, err := time.ParseDuration(v.V.(string)); err == nil {
+			return time.Now().Add(d), nil
+		}
+	}
+
+	ts, err := v.CastAsTimestamp()
+	if err != nil {
+		return time.Time{}, stringutil.Errorf("AS OF expects a timestamp or a duration, got %v", v)
 	}
-	return stringutil.Sprintf("seqScanReverse(%s)", it.TableName)
+
+	return ts.V.(time.Time), nil
 }
 
 // A PkScanOperator iterates over the documents of a table.
@@ -155,6 +312,15 @@ type PkScanOperator struct {
 	TableName string
 	Ranges    ValueRanges
 	Reverse   bool
+	// Filters only read the primary key of the documents. They are evaluated
+	// with the key decoded from the iterator, before the documents are decoded.
+	Filters []expr.Expr
+	// Paths lists the only paths read from the documents by the rest of the stream.
+	// If set, documents only contain the values found at these paths, decoded in one pass.
+	Paths []document.Path
+	// Offset and Limit restrict the documents returned by the scan,
+	// like the Skip and Take operators. A Limit of 0 returns every document.
+	Offset, Limit int64
 }
 
 // PkScan creates an iterator that iterates over each document of the given table.
@@ -187,6 +353,9 @@ func (it *PkScanOperator) String() string {
 			}
 		}
 	}
+	writeScanFilters(&s, it.Filters)
+	writeScanPaths(&s, it.Paths)
+	writeScanLimit(&s, it.Offset, it.Limit)
 
 	s.WriteString(")")
 
@@ -200,6 +369,9 @@ func (it *PkScanOperator) Iterate(in *environment.Environment, fn func(out *envi
 	if len(it.Ranges) == 0 {
 		s := SeqScan(it.TableName)
 		s.Reverse = it.Reverse
+		s.Filters = it.Filters
+		s.Paths = it.Paths
+		s.Offset, s.Limit = it.Offset, it.Limit
 		return s.Iterate(in, fn)
 	}
 
@@ -216,7 +388,7 @@ func (it *PkScanOperator) Iterate(in *environment.Environment, fn func(out *envi
 		return err
 	}
 
-	var iterator func(pivot document.Value, fn func(d document.Document) error) error
+	var iterator func(ctx context.Context, pivot document.Value, fn func(d document.Document) error) error
 
 	if !it.Reverse {
 		iterator = table.AscendGreaterOrEqual
@@ -224,6 +396,12 @@ func (it *PkScanOperator) Iterate(in *environment.Environment, fn func(out *envi
 		iterator = table.DescendLessOrEqual
 	}
 
+	var kd coveringDocument
+	kd.pk = table.Info.FieldConstraints.GetPrimaryKey()
+	pd := partialDocument{paths: it.Paths}
+
+	ctx := in.GetContext()
+	l := scanLimiter{offset: it.Offset, limit: it.Limit}
 	for _, rng := range ranges {
 		var start, end document.Value
 		if !it.Reverse {
@@ -242,7 +420,7 @@ func (it *PkScanOperator) Iterate(in *environment.Environment, fn func(out *envi
 			}
 		}
 
-		err = iterator(start, func(d document.Document) error {
+		err = iterator(ctx, start, func(d document.Document) error {
 			key := d.(document.Keyer).RawKey()
 
 			if !rng.IsInRange(key) {
@@ -260,12 +438,25 @@ func (it *PkScanOperator) Iterate(in *environment.Environment, fn func(out *envi
 				return nil
 			}
 
+			ok, err := readDocument(&newEnv, &kd, table, d, it.Filters)
+			if err != nil || !ok || l.skip() {
+				return err
+			}
+
+			d, err = pd.document(table, d)
+			if err != nil {
+				return err
+			}
+
 			newEnv.SetDocument(d)
-			return fn(&newEnv)
+			return l.call(&newEnv, fn)
 		})
 		if err == ErrStreamClosed {
 			err = nil
 		}
+		if err == errLimitReached {
+			return nil
+		}
 		if err != nil {
 			return err
 		}
@@ -284,6 +475,20 @@ type IndexScanOperator struct {
 	Ranges IndexRanges
 	// Reverse indicates the direction used to traverse the index.
 	Reverse bool
+	// Filters only read the indexed paths and the primary key. They are evaluated with the values
+	// decoded from the index entries, before the documents are read from the table, unless the entry
+	// can't be decoded.
+	Filters []expr.Expr
+	// Paths lists the only paths read from the documents by the rest of the stream.
+	// If set, documents only contain the values found at these paths, decoded in one pass.
+	Paths []document.Path
+	// Offset and Limit restrict the documents returned by the scan,
+	// like the Skip and Take operators. A Limit of 0 returns every document.
+	Offset, Limit int64
+	// Covering indicates that the stream only reads the indexed paths and the primary key.
+	// Documents are then built from the index entries, without reading the table,
+	// unless the entry can't be decoded or the table has a read policy.
+	Covering bool
 }
 
 // IndexScan creates an iterator that iterates over each document of the given table.
@@ -311,6 +516,12 @@ func (it *IndexScanOperator) String() string {
 		s.WriteString(", ")
 		s.WriteString(it.Ranges.String())
 	}
+	writeScanFilters(&s, it.Filters)
+	writeScanPaths(&s, it.Paths)
+	if it.Covering {
+		s.WriteString(", covering")
+	}
+	writeScanLimit(&s, it.Offset, it.Limit)
 
 	s.WriteString(")")
 
@@ -334,11 +545,16 @@ func (it *IndexScanOperator) Iterate(in *environment.Environment, fn func(out *e
 	}
 
 	ranges, err := it.Ranges.EncodeBuffer(index, table, in)
-	if err != nil || len(ranges) != len(it.Ranges) {
+	if err != nil {
 		return err
 	}
 
-	var iterator func(pivot database.Pivot, fn func(val, key []byte) error) error
+	// none of the ranges can match the values of the index
+	if len(it.Ranges) > 0 && len(ranges) == 0 {
+		return nil
+	}
+
+	var iterator func(ctx context.Context, pivot database.Pivot, fn func(val, key []byte) error) error
 
 	if !it.Reverse {
 		iterator = index.AscendGreaterOrEqual
@@ -346,71 +562,118 @@ func (it *IndexScanOperator) Iterate(in *environment.Environment, fn func(out *e
 		iterator = index.DescendLessOrEqual
 	}
 
-	// if there are no ranges use a simpler and faster iteration function
-	if len(ranges) == 0 {
-		return iterator(nil, func(val, key []byte) error {
-			d, err := table.GetDocument(key)
+	ctx := in.GetContext()
+	l := scanLimiter{offset: it.Offset, limit: it.Limit}
+	// without read policy, skipped entries don't need to be fetched from the table
+	hasReadPolicy := table.Policy != nil && table.Policy.Read != nil
+
+	var covered coveringDocument
+	if (it.Covering && !hasReadPolicy) || len(it.Filters) > 0 {
+		covered.paths = index.Info.Paths
+		covered.pk = table.Info.FieldConstraints.GetPrimaryKey()
+	}
+
+	pd := partialDocument{paths: it.Paths}
+	fetcher := table.NewDocumentFetcher()
+
+	visit := func(val, key []byte) error {
+		var decoded bool
+		if covered.paths != nil {
+			ok, err := covered.decode(index, table, val, key)
 			if err != nil {
 				return err
 			}
+			decoded = ok
+		}
 
-			newEnv.SetDocument(d)
-			return fn(&newEnv)
-		})
-	}
+		// entries that don't match the filters are not fetched from the table
+		filtered := len(it.Filters) == 0
+		if decoded && !filtered {
+			ok, err := matchFilters(&newEnv, &covered.fb, it.Filters)
+			if err != nil || !ok {
+				return err
+			}
+			filtered = true
+		}
 
-	for _, rng := range ranges {
-		var start, end *document.ValueBuffer
-		if !it.Reverse {
-			start = rng.Min
-			end = rng.Max
-		} else {
-			start = rng.Max
-			end = rng.Min
+		skipped := filtered && !hasReadPolicy
+		if skipped && l.skip() {
+			return nil
 		}
 
-		var encEnd []byte
-		if end.Len() > 0 {
-			encEnd, err = index.EncodeValueBuffer(end)
-			if err != nil {
+		if decoded && it.Covering && !hasReadPolicy {
+			newEnv.SetDocument(&covered.fb)
+			return l.call(&newEnv, fn)
+		}
+
+		d, err := fetcher.GetDocument(key)
+		if err != nil {
+			return err
+		}
+
+		ok, err := table.Policy.CanRead(table.Tx, d)
+		if err != nil || !ok {
+			return err
+		}
+
+		if !filtered {
+			ok, err = matchFilters(&newEnv, d, it.Filters)
+			if err != nil || !ok {
 				return err
 			}
 		}
 
+		if !skipped && l.skip() {
+			return nil
+		}
+
+		d, err = pd.document(table, d)
+		if err != nil {
+			return err
+		}
+
+		newEnv.SetDocument(d)
+		return l.call(&newEnv, fn)
+	}
+
+	// if there are no ranges use a simpler and faster iteration function
+	if len(ranges) == 0 {
+		err = iterator(ctx, nil, visit)
+		if err == errLimitReached {
+			return nil
+		}
+		return err
+	}
+
+	for _, rng := range ranges {
+		start := rng.Min
+		if it.Reverse {
+			start = rng.Max
+		}
+
 		var pivot database.Pivot
 		if start != nil {
 			pivot = start.Values
 		}
 
-		err = iterator(pivot, func(val, key []byte) error {
+		err = iterator(ctx, pivot, func(val, key []byte) error {
 			if !rng.IsInRange(val) {
 				// if we reached the end of our range, we can stop iterating.
-				if encEnd == nil {
-					return nil
-				}
-
-				cmp := bytes.Compare(val, encEnd)
-				if !it.Reverse && cmp > 0 {
-					return ErrStreamClosed
-				}
-				if it.Reverse && cmp < 0 {
+				if rng.IsPastEnd(val, it.Reverse) {
 					return ErrStreamClosed
 				}
 				return nil
 			}
 
-			d, err := table.GetDocument(key)
-			if err != nil {
-				return err
-			}
-
-			newEnv.SetDocument(d)
-			return fn(&newEnv)
+			return visit(val, key)
 		})
 
 		if err == ErrStreamClosed {
 			err = nil
 		}
+		if err == errLimitReached {
+			return nil
+		}
 		if err != nil {
 			return err
 		}
@@ -418,3 +681,406 @@ func (it *IndexScanOperator) Iterate(in *environment.Environment, fn func(out *e
 
 	return nil
 }
+
+// A coveringDocument builds documents from the values of the index entries and the primary key,
+// for covering index scans and for the filters pushed down into scans.
+type coveringDocument struct {
+	paths []document.Path
+	pk    *database.FieldConstraint
+	fb    document.FieldBuffer
+}
+
+// decode replaces the content of the document with the values of the entry.
+// It returns false if the entry can't be decoded.
+func (c *coveringDocument) decode(index *database.Index, table *database.Table, val, key []byte) (bool, error) {
+	values, ok, err := index.DecodeValues(val)
+	if err != nil || !ok {
+		return false, err
+	}
+
+	ok, err = c.decodeKey(table, key)
+	if err != nil || !ok {
+		return false, err
+	}
+
+	for i, p := range c.paths {
+		err = setCoveredValue(&c.fb, p, values[i])
+		if err != nil {
+			return false, err
+		}
+	}
+
+	return true, nil
+}
+
+// decodeKey replaces the content of the document with the primary key decoded from the key.
+// It returns false if the key can't be decoded.
+func (c *coveringDocument) decodeKey(table *database.Table, key []byte) (bool, error) {
+	pk, ok, err := table.DecodeKey(key)
+	if err != nil || !ok {
+		return false, err
+	}
+
+	c.fb.Reset()
+	c.fb.EncodedKey = key
+	c.fb.DecodedKey = pk
+
+	if c.pk != nil {
+		err = setCoveredValue(&c.fb, c.pk.Path, pk)
+		if err != nil {
+			return false, err
+		}
+	}
+
+	return true, nil
+}
+
+// readDocument reports whether the document returned by a table iterator can be read according to
+// the read policy of the table and matches the filters pushed down into the scan.
+// The filters are evaluated first with the primary key decoded from the key of the document,
+// to avoid decoding the documents that don't match.
+func readDocument(env *environment.Environment, kd *coveringDocument, table *database.Table, d document.Document, filters []expr.Expr) (bool, error) {
+	if len(filters) == 0 {
+		return table.Policy.CanRead(table.Tx, d)
+	}
+
+	decoded, err := kd.decodeKey(table, d.(document.Keyer).RawKey())
+	if err != nil {
+		return false, err
+	}
+	if decoded {
+		ok, err := matchFilters(env, &kd.fb, filters)
+		if err != nil || !ok {
+			return false, err
+		}
+	}
+
+	ok, err := table.Policy.CanRead(table.Tx, d)
+	if err != nil || !ok || decoded {
+		return ok, err
+	}
+
+	return matchFilters(env, d, filters)
+}
+
+// matchFilters reports whether the document matches all the filters.
+func matchFilters(env *environment.Environment, d document.Document, filters []expr.Expr) (bool, error) {
+	env.SetDocument(d)
+
+	for _, f := range filters {
+		v, err := f.Eval(env)
+		if err != nil {
+			return false, err
+		}
+
+		ok, err := v.IsTruthy()
+		if err != nil || !ok {
+			return false, err
+		}
+	}
+
+	return true, nil
+}
+
+// A partialDocument builds documents containing only the values found at some paths
+// of the documents read by a scan.
+type partialDocument struct {
+	paths []document.Path
+	fb    document.FieldBuffer
+	// function passed to DecodePaths, created once for the whole scan
+	set func(i int, v document.Value) error
+}
+
+// document returns a document containing the values found at the paths of d, decoded in one pass,
+// or d itself if there are no paths.
+func (p *partialDocument) document(table *database.Table, d document.Document) (document.Document, error) {
+	if len(p.paths) == 0 {
+		return d, nil
+	}
+
+	k := d.(document.Keyer)
+	key := k.RawKey()
+	pk, ok, err := table.DecodeKey(key)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		pk, err = k.Key()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	p.fb.Reset()
+	p.fb.EncodedKey = key
+	p.fb.DecodedKey = pk
+
+	if p.set == nil {
+		p.set = func(i int, v document.Value) error {
+			return setCoveredValue(&p.fb, p.paths[i], v)
+		}
+	}
+
+	err = encoding.DecodePaths(d, p.paths, p.set)
+	if err != nil {
+		return nil, err
+	}
+
+	return &p.fb, nil
+}
+
+// setCoveredValue sets the value at the given path of fb, creating the parent documents if necessary.
+func setCoveredValue(fb *document.FieldBuffer, p document.Path, v document.Value) error {
+	if len(p) == 1 {
+		return fb.Set(p, v)
+	}
+
+	parent, err := fb.GetByField(p[0].FieldName)
+	if err == document.ErrFieldNotFound {
+		child := document.NewFieldBuffer()
+		fb.Add(p[0].FieldName, document.NewDocumentValue(child))
+		return setCoveredValue(child, p[1:], v)
+	}
+	if err != nil {
+		return err
+	}
+
+	return setCoveredValue(parent.V.(*document.FieldBuffer), p[1:], v)
+}
+
+// errLimitReached is returned by the iteration functions of scans to stop
+// iterating once they returned as many documents as their limit.
+var errLimitReached = errors.New("limit reached")
+
+// A scanLimiter applies the offset and the limit of a scan.
+type scanLimiter struct {
+	offset, limit     int64
+	skipped, returned int64
+}
+
+// skip reports whether the next document must be skipped because of the offset.
+func (l *scanLimiter) skip() bool {
+	if l.skipped < l.offset {
+		l.skipped++
+		return true
+	}
+
+	return false
+}
+
+// call passes out to fn and returns errLimitReached once the limit is reached,
+// without reading the next document.
+func (l *scanLimiter) call(out *environment.Environment, fn func(out *environment.Environment) error) error {
+	err := fn(out)
+	if err != nil {
+		return err
+	}
+
+	l.returned++
+	if l.limit > 0 && l.returned >= l.limit {
+		return errLimitReached
+	}
+
+	return nil
+}
+
+func writeScanFilters(s *strings.Builder, filters []expr.Expr) {
+	for _, f := range filters {
+		s.WriteString(stringutil.Sprintf(", filter(%s)", f))
+	}
+}
+
+func writeScanPaths(s *strings.Builder, paths []document.Path) {
+	if len(paths) == 0 {
+		return
+	}
+
+	s.WriteString(", paths(")
+	for i, p := range paths {
+		if i > 0 {
+			s.WriteString(", ")
+		}
+		s.WriteString(p.String())
+	}
+	s.WriteString(")")
+}
+
+func writeScanLimit(s *strings.Builder, offset, limit int64) {
+	if offset > 0 {
+		s.WriteString(stringutil.Sprintf(", offset %d", offset))
+	}
+	if limit > 0 {
+		s.WriteString(stringutil.Sprintf(", limit %d", limit))
+	}
+}
+
+// A IndexNearestOperator iterates over the documents whose vectors, stored in an index,
+// are the closest to a target vector, from the closest to the farthest.
+type IndexNearestOperator struct {
+	baseOperator
+
+	// IndexName references the index of the vectors. It must have an arity of 1.
+	IndexName string
+	// Distance is the name of the function used to compare vectors,
+	// either cosine_distance or euclidean_distance.
+	Distance string
+	// Target evaluates to the vector the indexed vectors are compared with.
+	Target expr.Expr
+	// K is the number of documents to return.
+	K int64
+}
+
+// IndexNearest creates an iterator that iterates over the k documents of the given index
+// whose vectors are the closest to target, according to the distance function.
+func IndexNearest(name string, distance string, target expr.Expr, k int64) *IndexNearestOperator {
+	return &IndexNearestOperator{IndexName: name, Distance: distance, Target: target, K: k}
+}
+
+func (it *IndexNearestOperator) String() string {
+	return stringutil.Sprintf("indexNearest(%q, %s, %s, %d)", it.IndexName, it.Distance, it.Target, it.K)
+}
+
+// Iterate over the nearest documents. Each document is stored in the environment
+// that is passed to the fn function, using SetCurrentValue.
+func (it *IndexNearestOperator) Iterate(in *environment.Environment, fn func(out *environment.Environment) error) error {
+	var newEnv environment.Environment
+	newEnv.SetOuter(in)
+
+	index, err := in.GetCatalog().GetIndex(in.GetTx(), it.IndexName)
+	if err != nil {
+		return err
+	}
+
+	table, err := in.GetCatalog().GetTable(in.GetTx(), index.Info.TableName)
+	if err != nil {
+		return err
+	}
+
+	var dist func(a, b []float64) (float64, error)
+	switch it.Distance {
+	case "cosine_distance":
+		dist = document.CosineDistance
+	case "euclidean_distance":
+		dist = document.EuclideanDistance
+	default:
+		return stringutil.Errorf("unknown distance function %q", it.Distance)
+	}
+
+	v, err := it.Target.Eval(in)
+	if err != nil {
+		return err
+	}
+
+	ctx := in.GetContext()
+	emit := func(d document.Document) error {
+		ok, err := table.Policy.CanRead(table.Tx, d)
+		if err != nil || !ok {
+			return err
+		}
+
+		newEnv.SetDocument(d)
+		return fn(&newEnv)
+	}
+
+	// all the distances are NULL, documents are returned in the order of the table
+	if v.Type == document.NullValue {
+		return table.AscendGreaterOrEqual(ctx, document.Value{}, emit)
+	}
+
+	v, err = v.CastAsVector()
+	if err != nil {
+		return stringutil.Errorf("%s expects its arguments to be vectors", it.Distance)
+	}
+	target := v.V.([]float64)
+
+	// the index ignores vectors of other dimensions, which can't be compared with the target
+	if fc := table.Info.FieldConstraints.Get(index.Info.Paths[0]); fc != nil && fc.Dimension > 0 && fc.Dimension != len(target) {
+		return stringutil.Errorf("cannot compare vectors of dimension %d and %d", len(target), fc.Dimension)
+	}
+
+	keys, err := index.Nearest(ctx, target, int(it.K), dist)
+	if err != nil {
+		return err
+	}
+
+	fetcher := table.NewDocumentFetcher()
+	for _, key := range keys {
+		d, err := fetcher.GetDocument(key)
+		if err != nil {
+			return err
+		}
+
+		err = emit(d)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// A VirtualScanOperator iterates over the documents of a virtual table.
+type VirtualScanOperator struct {
+	baseOperator
+	TableName string
+
+	// If set, only the documents whose field at Path is equal to Value are read,
+	// using the Lookup method of the table.
+	Path  document.Path
+	Value expr.Expr
+}
+
+// VirtualScan creates an iterator that iterates over each document of the given virtual table.
+func VirtualScan(tableName string) *VirtualScanOperator {
+	return &VirtualScanOperator{TableName: tableName}
+}
+
+// VirtualLookup creates an iterator that iterates over the documents of the given virtual table
+// whose field at path is equal to e.
+func VirtualLookup(tableName string, path document.Path, e expr.Expr) *VirtualScanOperator {
+	return &VirtualScanOperator{TableName: tableName, Path: path, Value: e}
+}
+
+func (it *VirtualScanOperator) Iterate(in *environment.Environment, fn func(out *environment.Environment) error) error {
+	t := in.GetCatalog().GetVirtualTable(it.TableName)
+	if t == nil {
+		return errs.NotFoundError{Name: it.TableName}
+	}
+
+	ctx := in.GetContext()
+	if tx := in.GetTx(); tx != nil {
+		// the content of virtual tables can change at any time
+		tx.ReadTracker.SetVolatile()
+	}
+
+	var newEnv environment.Environment
+	newEnv.SetOuter(in)
+
+	f := func(d document.Document) error {
+		newEnv.SetDocument(d)
+		return fn(&newEnv)
+	}
+
+	if it.Path == nil {
+		return t.Iterate(ctx, f)
+	}
+
+	idx, ok := t.(database.VirtualTableIndexer)
+	if !ok {
+		return stringutil.Errorf("virtual table %q doesn't support lookups", it.TableName)
+	}
+
+	v, err := it.Value.Eval(in)
+	if err != nil {
+		return err
+	}
+
+	return idx.Lookup(ctx, it.Path, v, f)
+}
+
+func (it *VirtualScanOperator) String() string {
+	if it.Path == nil {
+		return stringutil.Sprintf("virtualScan(%s)", it.TableName)
+	}
+	return stringutil.Sprintf("virtualLookup(%s, %s, %s)", it.TableName, it.Path, it.Value)
+}
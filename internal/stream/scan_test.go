@@ -1,9 +1,11 @@
 package stream_test
 
 import (
+	"context"
 	"testing"
 
 	"github.com/genjidb/genji/document"
+	"github.com/genjidb/genji/internal/database"
 	"github.com/genjidb/genji/internal/environment"
 	"github.com/genjidb/genji/internal/expr"
 	"github.com/genjidb/genji/internal/sql/parser"
@@ -108,6 +110,28 @@ func TestSeqScan(t *testing.T) {
 		})
 	}
 
+	t.Run("Canceled", func(t *testing.T) {
+		db, tx, cleanup := testutil.NewTestTx(t)
+		defer cleanup()
+
+		testutil.MustExec(t, db, tx, "CREATE TABLE test (a INTEGER); INSERT INTO test (a) VALUES (1), (2), (3)")
+
+		ctx, cancel := context.WithCancel(context.Background())
+		var in environment.Environment
+		in.Tx = tx
+		in.Catalog = db.Catalog
+		in.Ctx = ctx
+
+		var i int
+		err := stream.SeqScan("test").Iterate(&in, func(env *environment.Environment) error {
+			i++
+			cancel()
+			return nil
+		})
+		require.Equal(t, context.Canceled, err)
+		require.Equal(t, 1, i)
+	})
+
 	t.Run("String", func(t *testing.T) {
 		require.Equal(t, `seqScan(test)`, stream.SeqScan("test").String())
 	})
@@ -191,6 +215,24 @@ func TestPkScan(t *testing.T) {
 			},
 			false, false,
 		},
+		{
+			"in",
+			testutil.MakeDocuments(t, `{"a": 1}`, `{"a": 2}`, `{"a": 3}`),
+			testutil.MakeDocuments(t, `{"a": 1}`, `{"a": 3}`),
+			stream.ValueRanges{
+				{Min: testutil.ExprList(t, `[1, 3, 1, null, "a"]`), Exact: true, In: true},
+			},
+			false, false,
+		},
+		{
+			"in/not an array",
+			testutil.MakeDocuments(t, `{"a": 1}`, `{"a": 2}`),
+			nil,
+			stream.ValueRanges{
+				{Min: testutil.IntegerValue(1), Exact: true, In: true},
+			},
+			false, false,
+		},
 		{
 			"reverse/no range",
 			testutil.MakeDocuments(t, `{"a": 1}`, `{"a": 2}`),
@@ -284,6 +326,10 @@ func TestPkScan(t *testing.T) {
 		op.Reverse = true
 
 		require.Equal(t, `pkScanReverse("test", [1, 2, true], 10, [100, -1])`, op.String())
+
+		require.Equal(t, `pkScan("test", IN ?)`, stream.PkScan("test", stream.ValueRange{
+			Min: expr.PositionalParam(1), Exact: true, In: true,
+		}).String())
 	})
 }
 
@@ -444,6 +490,64 @@ func TestIndexScan(t *testing.T) {
 			},
 			false, false,
 		},
+		{
+			"in", "a",
+			testutil.MakeDocuments(t, `{"a": 1}`, `{"a": 2}`, `{"a": 3}`),
+			testutil.MakeDocuments(t, `{"a": 1}`, `{"a": 3}`),
+			stream.IndexRanges{
+				{Min: testutil.ExprList(t, `[[1, 3, 1, null]]`), Exact: true, In: true, InPositions: []int{0}, Paths: []document.Path{testutil.ParseDocumentPath(t, "a")}},
+			},
+			false, false,
+		},
+		{
+			"in", "a, b",
+			testutil.MakeDocuments(t, `{"a": 1, "b": 2}`, `{"a": 2, "b": 2}`, `{"a": 3, "b": 1}`, `{"a": 3, "b": 2}`),
+			testutil.MakeDocuments(t, `{"a": 1, "b": 2}`, `{"a": 3, "b": 2}`),
+			stream.IndexRanges{
+				{
+					Min:         testutil.ExprList(t, `[[1, 3], 2]`),
+					Exact:       true,
+					In:          true,
+					InPositions: []int{0},
+					Paths:       []document.Path{testutil.ParseDocumentPath(t, "a"), testutil.ParseDocumentPath(t, "b")},
+				},
+			},
+			false, false,
+		},
+		{
+			"in/multiple", "a, b",
+			testutil.MakeDocuments(t, `{"a": 1, "b": 2}`, `{"a": 2, "b": 2}`, `{"a": 3, "b": 1}`, `{"a": 3, "b": 4}`),
+			testutil.MakeDocuments(t, `{"a": 1, "b": 2}`, `{"a": 3, "b": 1}`),
+			stream.IndexRanges{
+				{
+					Min:         testutil.ExprList(t, `[[1, 3], [1, 2, 2]]`),
+					Exact:       true,
+					In:          true,
+					InPositions: []int{0, 1},
+					Paths:       []document.Path{testutil.ParseDocumentPath(t, "a"), testutil.ParseDocumentPath(t, "b")},
+				},
+			},
+			false, false,
+		},
+		{
+			"duplicate ranges", "a",
+			testutil.MakeDocuments(t, `{"a": 1}`, `{"a": 2}`),
+			testutil.MakeDocuments(t, `{"a": 1}`),
+			stream.IndexRanges{
+				{Min: testutil.ExprList(t, `[1]`), Exact: true},
+				{Min: testutil.ExprList(t, `[1]`), Exact: true},
+			},
+			false, false,
+		},
+		{
+			"in/empty", "a",
+			testutil.MakeDocuments(t, `{"a": 1}`, `{"a": 2}`),
+			nil,
+			stream.IndexRanges{
+				{Min: testutil.ExprList(t, `[[]]`), Exact: true, In: true, InPositions: []int{0}, Paths: []document.Path{testutil.ParseDocumentPath(t, "a")}},
+			},
+			false, false,
+		},
 		{
 			"reverse/no range", "a",
 			testutil.MakeDocuments(t, `{"a": 1}`, `{"a": 2}`),
@@ -533,9 +637,8 @@ func TestIndexScan(t *testing.T) {
 			testutil.MakeDocuments(t, `{"a": 1, "b": 1}`, `{"a": 1, "b": 9223372036854775807}`),
 			stream.IndexRanges{
 				{
-					IndexArity: 2,
-					Max:        testutil.ExprList(t, `[1]`),
-					Paths:      []document.Path{testutil.ParseDocumentPath(t, "a"), testutil.ParseDocumentPath(t, "b")},
+					Max:   testutil.ExprList(t, `[1]`),
+					Paths: []document.Path{testutil.ParseDocumentPath(t, "a"), testutil.ParseDocumentPath(t, "b")},
 				},
 			},
 			false, false,
@@ -546,11 +649,10 @@ func TestIndexScan(t *testing.T) {
 			testutil.MakeDocuments(t, `{"a": 1, "b": 9223372036854775807}`, `{"a": 1, "b": 1}`),
 			stream.IndexRanges{
 				{
-					Max:        testutil.ExprList(t, `[1]`),
-					Exclusive:  false,
-					Exact:      false,
-					IndexArity: 2,
-					Paths:      []document.Path{testutil.ParseDocumentPath(t, "a"), testutil.ParseDocumentPath(t, "b")},
+					Max:       testutil.ExprList(t, `[1]`),
+					Exclusive: false,
+					Exact:     false,
+					Paths:     []document.Path{testutil.ParseDocumentPath(t, "a"), testutil.ParseDocumentPath(t, "b")},
 				},
 			},
 			true, false,
@@ -560,9 +662,7 @@ func TestIndexScan(t *testing.T) {
 			testutil.MakeDocuments(t, `{"a": 1, "b": 2, "c": 1}`, `{"a": 2, "b": 2, "c":  2}`, `{"a": 1, "b": 2, "c": 9223372036854775807}`),
 			testutil.MakeDocuments(t, `{"a": 1, "b": 2, "c": 1}`, `{"a": 1, "b": 2, "c": 9223372036854775807}`),
 			stream.IndexRanges{
-				{
-					IndexArity: 3, Max: testutil.ExprList(t, `[1, 2]`), Paths: []document.Path{testutil.ParseDocumentPath(t, "a"), testutil.ParseDocumentPath(t, "b"), testutil.ParseDocumentPath(t, "c")},
-				},
+				{Max: testutil.ExprList(t, `[1, 2]`), Paths: []document.Path{testutil.ParseDocumentPath(t, "a"), testutil.ParseDocumentPath(t, "b"), testutil.ParseDocumentPath(t, "c")}},
 			},
 			false, false,
 		},
@@ -599,10 +699,9 @@ func TestIndexScan(t *testing.T) {
 			testutil.MakeDocuments(t, `{"a": 1, "b": -2}`, `{"a": 2, "b": 42}`),
 			stream.IndexRanges{
 				{
-					IndexArity: 2,
-					Min:        testutil.ExprList(t, `[1]`),
-					Max:        testutil.ExprList(t, `[2]`),
-					Paths:      []document.Path{testutil.ParseDocumentPath(t, "a"), testutil.ParseDocumentPath(t, "b")},
+					Min:   testutil.ExprList(t, `[1]`),
+					Max:   testutil.ExprList(t, `[2]`),
+					Paths: []document.Path{testutil.ParseDocumentPath(t, "a"), testutil.ParseDocumentPath(t, "b")},
 				},
 			},
 			false, false,
@@ -613,14 +712,73 @@ func TestIndexScan(t *testing.T) {
 			testutil.MakeDocuments(t, `{"a": 2, "b": 42}`, `{"a": 1, "b": -2}`),
 			stream.IndexRanges{
 				{
-					IndexArity: 2,
-					Min:        testutil.ExprList(t, `[1]`),
-					Max:        testutil.ExprList(t, `[2]`),
-					Paths:      []document.Path{testutil.ParseDocumentPath(t, "a"), testutil.ParseDocumentPath(t, "b")},
+					Min:   testutil.ExprList(t, `[1]`),
+					Max:   testutil.ExprList(t, `[2]`),
+					Paths: []document.Path{testutil.ParseDocumentPath(t, "a"), testutil.ParseDocumentPath(t, "b")},
+				},
+			},
+			true, false,
+		},
+		{
+			"exact:[1]", "a, b",
+			testutil.MakeDocuments(t, `{"a": 1, "b": 2}`, `{"a": 2, "b": 1}`, `{"a": 1, "b": 1}`),
+			testutil.MakeDocuments(t, `{"a": 1, "b": 1}`, `{"a": 1, "b": 2}`),
+			stream.IndexRanges{
+				{Min: testutil.ExprList(t, `[1]`), Exact: true, Paths: []document.Path{testutil.ParseDocumentPath(t, "a"), testutil.ParseDocumentPath(t, "b")}},
+			},
+			false, false,
+		},
+		{
+			"exclusive min:[1]", "a, b",
+			testutil.MakeDocuments(t, `{"a": 1, "b": 2}`, `{"a": 2, "b": 1}`, `{"a": 1, "b": 1}`),
+			testutil.MakeDocuments(t, `{"a": 2, "b": 1}`),
+			stream.IndexRanges{
+				{Min: testutil.ExprList(t, `[1]`), Exclusive: true, Paths: []document.Path{testutil.ParseDocumentPath(t, "a"), testutil.ParseDocumentPath(t, "b")}},
+			},
+			false, false,
+		},
+		{
+			"exclusive min:[1, 1], max:[1]", "a, b",
+			testutil.MakeDocuments(t, `{"a": 1, "b": 2}`, `{"a": 2, "b": 3}`, `{"a": 1, "b": 1}`, `{"a": 1, "b": 3}`, `{"a": 0, "b": 3}`),
+			testutil.MakeDocuments(t, `{"a": 1, "b": 2}`, `{"a": 1, "b": 3}`),
+			stream.IndexRanges{
+				{
+					Min:       testutil.ExprList(t, `[1, 1]`),
+					Max:       testutil.ExprList(t, `[1]`),
+					Exclusive: true,
+					Paths:     []document.Path{testutil.ParseDocumentPath(t, "a"), testutil.ParseDocumentPath(t, "b")},
+				},
+			},
+			false, false,
+		},
+		{
+			"reverse exclusive min:[1, 1], max:[1]", "a, b",
+			testutil.MakeDocuments(t, `{"a": 1, "b": 2}`, `{"a": 2, "b": 3}`, `{"a": 1, "b": 1}`, `{"a": 1, "b": 3}`, `{"a": 0, "b": 3}`),
+			testutil.MakeDocuments(t, `{"a": 1, "b": 3}`, `{"a": 1, "b": 2}`),
+			stream.IndexRanges{
+				{
+					Min:       testutil.ExprList(t, `[1, 1]`),
+					Max:       testutil.ExprList(t, `[1]`),
+					Exclusive: true,
+					Paths:     []document.Path{testutil.ParseDocumentPath(t, "a"), testutil.ParseDocumentPath(t, "b")},
 				},
 			},
 			true, false,
 		},
+		{
+			"min:[1], exclusive max:[1, 3]", "a, b",
+			testutil.MakeDocuments(t, `{"a": 1, "b": 2}`, `{"a": 2, "b": 1}`, `{"a": 1, "b": 1}`, `{"a": 1, "b": 3}`, `{"a": 0, "b": 1}`),
+			testutil.MakeDocuments(t, `{"a": 1, "b": 1}`, `{"a": 1, "b": 2}`),
+			stream.IndexRanges{
+				{
+					Min:       testutil.ExprList(t, `[1]`),
+					Max:       testutil.ExprList(t, `[1, 3]`),
+					Exclusive: true,
+					Paths:     []document.Path{testutil.ParseDocumentPath(t, "a"), testutil.ParseDocumentPath(t, "b")},
+				},
+			},
+			false, false,
+		},
 	}
 
 	for _, test := range tests {
@@ -680,6 +838,10 @@ func TestIndexScan(t *testing.T) {
 			op.Reverse = true
 
 			require.Equal(t, `indexScanReverse("idx_test_a", [1, 2])`, op.String())
+
+			require.Equal(t, `indexScan("idx_test_a", IN ?)`, stream.IndexScan("idx_test_a", stream.IndexRange{
+				Min: expr.LiteralExprList{expr.PositionalParam(1)}, Exact: true, In: true, InPositions: []int{0},
+			}).String())
 		})
 
 		t.Run("idx_test_a_b", func(t *testing.T) {
@@ -698,3 +860,275 @@ func TestIndexScan(t *testing.T) {
 		})
 	})
 }
+
+func TestScanOffsetLimit(t *testing.T) {
+	db, tx, cleanup := testutil.NewTestTx(t)
+	defer cleanup()
+
+	testutil.MustExec(t, db, tx, `
+		CREATE TABLE test (k INTEGER PRIMARY KEY, a INTEGER);
+		CREATE INDEX idx_test_a ON test(a);
+		INSERT INTO test (k, a) VALUES (1, 10), (2, 20), (3, 30), (4, 40), (5, 50), (6, 60);
+	`)
+
+	// the documents read by the scans are counted by the row policy
+	var read int
+	db.Catalog.SetRowPolicy("test", &database.RowPolicy{
+		Read: func(ctx context.Context, d document.Document) (bool, error) {
+			read++
+			return true, nil
+		},
+	})
+
+	tests := []struct {
+		name     string
+		op       stream.Operator
+		expected []int64
+		read     int
+	}{
+		{"seqScan", &stream.SeqScanOperator{TableName: "test", Offset: 1, Limit: 2}, []int64{2, 3}, 3},
+		{"seqScan/offset", &stream.SeqScanOperator{TableName: "test", Offset: 4}, []int64{5, 6}, 6},
+		{"seqScanReverse", &stream.SeqScanOperator{TableName: "test", Reverse: true, Limit: 3}, []int64{6, 5, 4}, 3},
+		{"pkScan/ranges", &stream.PkScanOperator{TableName: "test", Ranges: stream.ValueRanges{
+			{Min: testutil.IntegerValue(2), Exact: true},
+			{Min: testutil.IntegerValue(4)},
+		}, Offset: 1, Limit: 2}, []int64{4, 5}, 3},
+		{"pkScan/no range", &stream.PkScanOperator{TableName: "test", Offset: 5, Limit: 2}, []int64{6}, 6},
+		{"indexScan", &stream.IndexScanOperator{IndexName: "idx_test_a", Ranges: stream.IndexRanges{
+			{Min: testutil.ExprList(t, `[20]`)},
+		}, Offset: 1, Limit: 2}, []int64{3, 4}, 3},
+		{"indexScanReverse", &stream.IndexScanOperator{IndexName: "idx_test_a", Reverse: true, Limit: 1}, []int64{6}, 1},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			read = 0
+
+			var in environment.Environment
+			in.Tx = tx
+			in.Catalog = db.Catalog
+
+			var got []int64
+			err := test.op.Iterate(&in, func(env *environment.Environment) error {
+				d, ok := env.GetDocument()
+				require.True(t, ok)
+				v, err := d.GetByField("k")
+				require.NoError(t, err)
+				got = append(got, v.V.(int64))
+				return nil
+			})
+			require.NoError(t, err)
+			require.Equal(t, test.expected, got)
+			require.Equal(t, test.read, read)
+		})
+	}
+
+	t.Run("String", func(t *testing.T) {
+		require.Equal(t, `seqScan(test, offset 1, limit 2)`, (&stream.SeqScanOperator{TableName: "test", Offset: 1, Limit: 2}).String())
+		require.Equal(t, `pkScanReverse("test", 2, limit 2)`, (&stream.PkScanOperator{TableName: "test", Ranges: stream.ValueRanges{
+			{Min: testutil.IntegerValue(2), Exact: true},
+		}, Reverse: true, Limit: 2}).String())
+		require.Equal(t, `indexScan("idx_test_a", offset 3)`, (&stream.IndexScanOperator{IndexName: "idx_test_a", Offset: 3}).String())
+	})
+}
+
+func TestCoveringIndexScan(t *testing.T) {
+	db, tx, cleanup := testutil.NewTestTx(t)
+	defer cleanup()
+
+	testutil.MustExec(t, db, tx, `
+		CREATE TABLE test (k INTEGER PRIMARY KEY, a INTEGER, b TEXT, c DOUBLE);
+		CREATE INDEX idx_test_a_b ON test(a, b);
+		CREATE INDEX idx_test_n_x ON test(n.x);
+		INSERT INTO test (k, a, b, c, n) VALUES (1, 10, 'foo', 1.5, {x: 'bar'}), (2, 20, 'baz', 2.5, {x: [2.5]});
+	`)
+
+	scan := func(indexName string) []document.Document {
+		var in environment.Environment
+		in.Tx = tx
+		in.Catalog = db.Catalog
+
+		var got []document.Document
+		err := (&stream.IndexScanOperator{IndexName: indexName, Covering: true}).Iterate(&in, func(env *environment.Environment) error {
+			d, ok := env.GetDocument()
+			require.True(t, ok)
+			fb := document.NewFieldBuffer()
+			err := fb.Copy(d)
+			require.NoError(t, err)
+			got = append(got, fb)
+			return nil
+		})
+		require.NoError(t, err)
+		return got
+	}
+
+	testutil.MakeDocuments(t,
+		`{"k": 1, "a": 10, "b": "foo"}`,
+		`{"k": 2, "a": 20, "b": "baz"}`,
+	).RequireEqual(t, scan("idx_test_a_b"))
+
+	// entries that can't be decoded, like arrays, are read from the table
+	testutil.MakeDocuments(t,
+		`{"k": 1, "n": {"x": "bar"}}`,
+		`{"k": 2, "a": 20, "b": "baz", "c": 2.5, "n": {"x": [2.5]}}`,
+	).RequireEqual(t, scan("idx_test_n_x"))
+
+	// documents are read from the table if it has a read policy
+	db.Catalog.SetRowPolicy("test", &database.RowPolicy{
+		Read: func(ctx context.Context, d document.Document) (bool, error) {
+			return true, nil
+		},
+	})
+	testutil.MakeDocuments(t,
+		`{"k": 1, "a": 10, "b": "foo", "c": 1.5, "n": {"x": "bar"}}`,
+		`{"k": 2, "a": 20, "b": "baz", "c": 2.5, "n": {"x": [2.5]}}`,
+	).RequireEqual(t, scan("idx_test_n_x"))
+
+	t.Run("String", func(t *testing.T) {
+		require.Equal(t, `indexScan("idx_test_a_b", covering, limit 2)`, (&stream.IndexScanOperator{IndexName: "idx_test_a_b", Covering: true, Limit: 2}).String())
+	})
+}
+
+func TestScanFilters(t *testing.T) {
+	db, tx, cleanup := testutil.NewTestTx(t)
+	defer cleanup()
+
+	testutil.MustExec(t, db, tx, `
+		CREATE TABLE test (k INTEGER PRIMARY KEY, a INTEGER);
+		CREATE INDEX idx_test_a_b ON test(a, b);
+		INSERT INTO test (k, a, b) VALUES (1, 10, 'x'), (2, 20, 'y'), (3, 30, 'x'), (4, 40, [1]), (5, 50, 'x'), (6, 60, 'y');
+	`)
+
+	// the documents read by the scans are counted by the row policy
+	var read int
+	db.Catalog.SetRowPolicy("test", &database.RowPolicy{
+		Read: func(ctx context.Context, d document.Document) (bool, error) {
+			read++
+			return true, nil
+		},
+	})
+
+	tests := []struct {
+		name     string
+		op       stream.Operator
+		expected []int64
+		read     int
+	}{
+		{"seqScan", &stream.SeqScanOperator{TableName: "test", Filters: []expr.Expr{
+			parser.MustParseExpr("k % 2 = 0"),
+		}, Offset: 1}, []int64{4, 6}, 3},
+		{"pkScan", &stream.PkScanOperator{TableName: "test", Ranges: stream.ValueRanges{
+			{Min: testutil.IntegerValue(2)},
+		}, Filters: []expr.Expr{
+			parser.MustParseExpr("k != 3"),
+		}, Limit: 2}, []int64{2, 4}, 2},
+		// the entry of the document 4 can't be decoded, the filter is evaluated with the document
+		{"indexScan", &stream.IndexScanOperator{IndexName: "idx_test_a_b", Ranges: stream.IndexRanges{
+			{Min: testutil.ExprList(t, `[20]`)},
+		}, Filters: []expr.Expr{
+			parser.MustParseExpr("b = 'x'"),
+		}, Limit: 2}, []int64{3, 5}, 3},
+		{"indexScan/covering", &stream.IndexScanOperator{IndexName: "idx_test_a_b", Filters: []expr.Expr{
+			parser.MustParseExpr("b = 'y'"),
+			parser.MustParseExpr("k > 2"),
+		}, Covering: true}, []int64{6}, 2},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			read = 0
+
+			var in environment.Environment
+			in.Tx = tx
+			in.Catalog = db.Catalog
+
+			var got []int64
+			err := test.op.Iterate(&in, func(env *environment.Environment) error {
+				d, ok := env.GetDocument()
+				require.True(t, ok)
+				v, err := d.GetByField("k")
+				require.NoError(t, err)
+				got = append(got, v.V.(int64))
+				return nil
+			})
+			require.NoError(t, err)
+			require.Equal(t, test.expected, got)
+			require.Equal(t, test.read, read)
+		})
+	}
+
+	t.Run("String", func(t *testing.T) {
+		require.Equal(t, `seqScan(test, filter(k % 2 = 0), offset 1)`, (&stream.SeqScanOperator{TableName: "test", Filters: []expr.Expr{
+			parser.MustParseExpr("k % 2 = 0"),
+		}, Offset: 1}).String())
+		require.Equal(t, `indexScan("idx_test_a_b", filter(b = "x"), covering, limit 2)`, (&stream.IndexScanOperator{IndexName: "idx_test_a_b", Filters: []expr.Expr{
+			parser.MustParseExpr("b = 'x'"),
+		}, Covering: true, Limit: 2}).String())
+	})
+}
+
+func TestScanPaths(t *testing.T) {
+	db, tx, cleanup := testutil.NewTestTx(t)
+	defer cleanup()
+
+	testutil.MustExec(t, db, tx, `
+		CREATE TABLE test (k INTEGER PRIMARY KEY, a INTEGER);
+		CREATE INDEX idx_test_a ON test(a);
+		INSERT INTO test (k, a, b, n) VALUES (1, 10, 'foo', {x: 1, y: [1, 2]}), (2, 20, 'bar', {x: 2}), (3, 30, 'baz', NULL);
+	`)
+
+	paths := []document.Path{document.NewPath("n"), document.NewPath("a"), document.NewPath("z")}
+
+	tests := []struct {
+		name string
+		op   stream.Operator
+	}{
+		{"seqScan", &stream.SeqScanOperator{TableName: "test", Paths: paths}},
+		{"pkScan", &stream.PkScanOperator{TableName: "test", Ranges: stream.ValueRanges{
+			{Min: testutil.IntegerValue(1)},
+		}, Paths: paths}},
+		{"indexScan", &stream.IndexScanOperator{IndexName: "idx_test_a", Paths: paths}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var in environment.Environment
+			in.Tx = tx
+			in.Catalog = db.Catalog
+
+			var got []document.Document
+			var keys []document.Value
+			err := test.op.Iterate(&in, func(env *environment.Environment) error {
+				d, ok := env.GetDocument()
+				require.True(t, ok)
+				fb := document.NewFieldBuffer()
+				err := fb.Copy(d)
+				require.NoError(t, err)
+				got = append(got, fb)
+
+				// the key of the document is kept
+				v, err := parser.MustParseExpr("pk()").Eval(env)
+				require.NoError(t, err)
+				keys = append(keys, v)
+				return nil
+			})
+			require.NoError(t, err)
+
+			// fields are returned in the order of the encoded document, missing paths are left out
+			testutil.MakeDocuments(t,
+				`{"a": 10, "n": {"x": 1, "y": [1, 2]}}`,
+				`{"a": 20, "n": {"x": 2}}`,
+				`{"a": 30, "n": null}`,
+			).RequireEqual(t, got)
+			require.Equal(t, []document.Value{
+				document.NewIntegerValue(1),
+				document.NewIntegerValue(2),
+				document.NewIntegerValue(3),
+			}, keys)
+		})
+	}
+
+	t.Run("String", func(t *testing.T) {
+		require.Equal(t, `seqScan(test, paths(n, a), limit 2)`, (&stream.SeqScanOperator{TableName: "test", Paths: paths[:2], Limit: 2}).String())
+	})
+}
@@ -0,0 +1,268 @@
+package stream
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/binary"
+	"hash/fnv"
+	"io"
+	"io/ioutil"
+	"os"
+
+	"github.com/genjidb/genji/document"
+	"github.com/genjidb/genji/document/encoding"
+	"github.com/genjidb/genji/document/encoding/msgpack"
+	"github.com/genjidb/genji/internal/environment"
+)
+
+const (
+	// number of partitions the documents are distributed into
+	// when an operator exceeds its memory budget.
+	spillPartitions = 16
+
+	// partitions are split again at most this number of times,
+	// beyond which the memory budget is ignored.
+	maxSpillDepth = 4
+
+	// estimated memory used by an entry of a hash table, on top of its key.
+	hashEntryOverhead = 64
+
+	// number of runs written by a sort operator beyond which
+	// they are merged into a single one, to limit the number of open files.
+	maxSortRuns = 64
+
+	// estimated memory used by a value, on top of its content.
+	valueOverhead = 16
+)
+
+// workMem returns the memory budget of the operators of the stream,
+// or zero if they are not limited.
+func workMem(env *environment.Environment) int64 {
+	s := env.GetSession()
+	if s == nil {
+		return 0
+	}
+
+	return s.WorkMem()
+}
+
+// spillCodec returns the codec used to encode the documents written to temporary files.
+func spillCodec(env *environment.Environment) encoding.Codec {
+	if tx := env.GetTx(); tx != nil && tx.Codec != nil {
+		return tx.Codec
+	}
+
+	return msgpack.NewCodec()
+}
+
+// spillPartitioner distributes documents into temporary files, based on the hash of a key,
+// so that documents sharing the same key end up in the same partition.
+// Partitions are created lazily.
+type spillPartitioner struct {
+	codec      encoding.Codec
+	depth      int
+	partitions [spillPartitions]*spillFile
+}
+
+func newSpillPartitioner(codec encoding.Codec, depth int) *spillPartitioner {
+	return &spillPartitioner{codec: codec, depth: depth}
+}
+
+// Write adds the document to the partition of the given key.
+func (p *spillPartitioner) Write(key string, d document.Document) error {
+	// the depth is part of the hash to distribute documents
+	// of the same partition differently when it is split again
+	h := fnv.New32a()
+	h.Write([]byte{byte(p.depth)})
+	h.Write([]byte(key))
+	i := h.Sum32() % spillPartitions
+
+	if p.partitions[i] == nil {
+		sf, err := newSpillFile(p.codec)
+		if err != nil {
+			return err
+		}
+		p.partitions[i] = sf
+	}
+
+	return p.partitions[i].Write(d)
+}
+
+// Partitions returns the partitions that contain at least one document.
+func (p *spillPartitioner) Partitions() []*spillFile {
+	var files []*spillFile
+	for _, sf := range p.partitions {
+		if sf != nil {
+			files = append(files, sf)
+		}
+	}
+
+	return files
+}
+
+// Close removes all the temporary files.
+func (p *spillPartitioner) Close() error {
+	var err error
+	for _, sf := range p.partitions {
+		if sf == nil {
+			continue
+		}
+
+		if e := sf.Close(); e != nil && err == nil {
+			err = e
+		}
+	}
+
+	return err
+}
+
+// A spillFile stores documents in a temporary file.
+// Each document is prefixed with its length.
+type spillFile struct {
+	codec encoding.Codec
+	f     *os.File
+	w     *bufio.Writer
+	buf   bytes.Buffer
+}
+
+func newSpillFile(codec encoding.Codec) (*spillFile, error) {
+	f, err := ioutil.TempFile("", "genji-spill-")
+	if err != nil {
+		return nil, err
+	}
+
+	return &spillFile{
+		codec: codec,
+		f:     f,
+		w:     bufio.NewWriter(f),
+	}, nil
+}
+
+// Write appends the document to the file.
+func (sf *spillFile) Write(d document.Document) error {
+	sf.buf.Reset()
+	enc := sf.codec.NewEncoder(&sf.buf)
+	defer enc.Close()
+
+	err := enc.EncodeDocument(d)
+	if err != nil {
+		return err
+	}
+
+	var size [binary.MaxVarintLen64]byte
+	n := binary.PutUvarint(size[:], uint64(sf.buf.Len()))
+	_, err = sf.w.Write(size[:n])
+	if err != nil {
+		return err
+	}
+
+	_, err = sf.w.Write(sf.buf.Bytes())
+	return err
+}
+
+// Iterate reads the documents of the file in the order they were written.
+// The iteration stops with the error of ctx as soon as it is canceled.
+func (sf *spillFile) Iterate(ctx context.Context, fn func(d document.Document) error) error {
+	r, err := sf.Reader()
+	if err != nil {
+		return err
+	}
+
+	done := ctx.Done()
+	for {
+		select {
+		case <-done:
+			return ctx.Err()
+		default:
+		}
+
+		d, err := r.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		err = fn(d)
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// Reader flushes the documents written so far and returns a reader
+// positioned at the beginning of the file.
+// Only one reader can be used at a time.
+func (sf *spillFile) Reader() (*spillReader, error) {
+	err := sf.w.Flush()
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = sf.f.Seek(0, io.SeekStart)
+	if err != nil {
+		return nil, err
+	}
+
+	return &spillReader{codec: sf.codec, r: bufio.NewReader(sf.f)}, nil
+}
+
+// A spillReader reads the documents of a spillFile in the order they were written.
+type spillReader struct {
+	codec encoding.Codec
+	r     *bufio.Reader
+}
+
+// Next returns the next document of the file, or io.EOF once every document was read.
+func (r *spillReader) Next() (document.Document, error) {
+	size, err := binary.ReadUvarint(r.r)
+	if err != nil {
+		return nil, err
+	}
+
+	data := make([]byte, size)
+	_, err = io.ReadFull(r.r, data)
+	if err != nil {
+		return nil, err
+	}
+
+	return r.codec.NewDecoder(data), nil
+}
+
+// Close removes the file.
+func (sf *spillFile) Close() error {
+	err := sf.f.Close()
+	if e := os.Remove(sf.f.Name()); e != nil && err == nil {
+		err = e
+	}
+
+	return err
+}
+
+// estimateSize returns the approximate amount of memory used by the value.
+func estimateSize(v document.Value) int64 {
+	size := int64(valueOverhead)
+
+	switch v.Type {
+	case document.TextValue:
+		size += int64(len(v.V.(string)))
+	case document.BlobValue:
+		size += int64(len(v.V.([]byte)))
+	case document.VectorValue:
+		size += int64(8 * len(v.V.([]float64)))
+	case document.ArrayValue:
+		_ = v.V.(document.Array).Iterate(func(_ int, v document.Value) error {
+			size += estimateSize(v)
+			return nil
+		})
+	case document.DocumentValue:
+		_ = v.V.(document.Document).Iterate(func(field string, v document.Value) error {
+			size += int64(len(field)) + estimateSize(v)
+			return nil
+		})
+	}
+
+	return size
+}
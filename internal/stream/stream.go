@@ -34,6 +34,26 @@ func (s *Stream) Iterate(in *environment.Environment, fn func(out *environment.E
 	return s.Op.Iterate(in, fn)
 }
 
+// iterateSubstream iterates over a stream used by an operator of another stream, like the
+// streams of a concat or a subquery.
+// The substream can be closed early, e.g. by its own LIMIT clause, without closing
+// the stream that contains it: ErrStreamClosed is only returned if it was returned by fn.
+func iterateSubstream(s *Stream, in *environment.Environment, fn func(out *environment.Environment) error) error {
+	var closed bool
+	err := s.Iterate(in, func(out *environment.Environment) error {
+		err := fn(out)
+		if err == ErrStreamClosed {
+			closed = true
+		}
+		return err
+	})
+	if err == ErrStreamClosed && !closed {
+		return nil
+	}
+
+	return err
+}
+
 func (s *Stream) Remove(op Operator) {
 	if op == nil {
 		return
@@ -0,0 +1,28 @@
+package stream
+
+import (
+	"github.com/genjidb/genji/internal/environment"
+	"github.com/genjidb/genji/internal/stringutil"
+)
+
+// A SubqueryOperator iterates over the documents of another stream.
+// It is used to read from a query as if it were a table, e.g. in SELECT * FROM (SELECT ...).
+// Documents are streamed: the other stream is run every time the operator is iterated.
+type SubqueryOperator struct {
+	baseOperator
+	S *Stream
+}
+
+// Subquery creates an operator that iterates over the documents of s.
+func Subquery(s *Stream) *SubqueryOperator {
+	return &SubqueryOperator{S: s}
+}
+
+// Iterate implements the Operator interface.
+func (op *SubqueryOperator) Iterate(in *environment.Environment, fn func(out *environment.Environment) error) error {
+	return iterateSubstream(op.S, in, fn)
+}
+
+func (op *SubqueryOperator) String() string {
+	return stringutil.Sprintf("subquery(%s)", op.S)
+}
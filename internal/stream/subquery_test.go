@@ -0,0 +1,63 @@
+package stream_test
+
+import (
+	"testing"
+
+	"github.com/genjidb/genji/document"
+	"github.com/genjidb/genji/internal/environment"
+	"github.com/genjidb/genji/internal/stream"
+	"github.com/genjidb/genji/internal/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSubqueryOperator(t *testing.T) {
+	in := testutil.MakeDocuments(t, `{"a": 10}`, `{"a": 11}`, `{"a": 12}`)
+
+	t.Run("documents", func(t *testing.T) {
+		s := stream.New(stream.Subquery(stream.New(stream.Documents(in...))))
+
+		var got []document.Document
+		err := s.Iterate(new(environment.Environment), func(env *environment.Environment) error {
+			d, ok := env.GetDocument()
+			require.True(t, ok)
+			got = append(got, d)
+			return nil
+		})
+		require.NoError(t, err)
+
+		require.Len(t, got, len(in))
+		for i, w := range in {
+			testutil.RequireDocEqual(t, w, got[i])
+		}
+	})
+
+	t.Run("inner limit", func(t *testing.T) {
+		// the subquery is closed by its own LIMIT, the outer stream continues
+		s := stream.New(stream.Subquery(stream.New(stream.Documents(in...)).Pipe(stream.Take(2))))
+
+		var count int
+		err := s.Iterate(new(environment.Environment), func(env *environment.Environment) error {
+			count++
+			return nil
+		})
+		require.NoError(t, err)
+		require.Equal(t, 2, count)
+	})
+
+	t.Run("outer limit", func(t *testing.T) {
+		// the outer LIMIT closes the whole stream
+		s := stream.New(stream.Subquery(stream.New(stream.Documents(in...)))).Pipe(stream.Take(1))
+
+		var count int
+		err := s.Iterate(new(environment.Environment), func(env *environment.Environment) error {
+			count++
+			return nil
+		})
+		require.Equal(t, stream.ErrStreamClosed, err)
+		require.Equal(t, 1, count)
+	})
+
+	t.Run("String", func(t *testing.T) {
+		require.Equal(t, `subquery(seqScan(foo))`, stream.Subquery(stream.New(stream.SeqScan("foo"))).String())
+	})
+}
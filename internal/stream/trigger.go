@@ -0,0 +1,157 @@
+package stream
+
+import (
+	"github.com/genjidb/genji/document"
+	"github.com/genjidb/genji/internal/database"
+	"github.com/genjidb/genji/internal/environment"
+	"github.com/genjidb/genji/internal/expr"
+	"github.com/genjidb/genji/internal/stringutil"
+)
+
+const (
+	// NewVar is the name of the variable holding the inserted document,
+	// or the updated document after the update, when running a trigger.
+	NewVar = "new"
+	// OldVar is the name of the variable holding the deleted document,
+	// or the updated document before the update, when running a trigger.
+	OldVar = "old"
+)
+
+// MaxTriggerDepth is the maximum number of triggers run by other triggers,
+// which prevents triggers from firing each other forever.
+const MaxTriggerDepth = 32
+
+// A TriggerProgram is the program of a trigger run by the operators
+// writing to its table.
+type TriggerProgram interface {
+	database.TriggerProgram
+	// Prepare returns the condition of the trigger, or nil if it doesn't have any,
+	// and the streams of its statements, ready to be run.
+	Prepare(tx *database.Transaction, catalog database.Catalog) (expr.Expr, []*Stream, error)
+}
+
+// tableTriggers are the triggers of a table fired by one kind of write.
+type tableTriggers struct {
+	before, after []preparedTrigger
+}
+
+type preparedTrigger struct {
+	name    string
+	cond    expr.Expr
+	streams []*Stream
+}
+
+// loadTriggers prepares the triggers of the table fired by the given event.
+// It returns nil if there are none.
+func loadTriggers(env *environment.Environment, tableName string, event database.TriggerEvent) (*tableTriggers, error) {
+	tx := env.GetTx()
+	catalog := env.GetCatalog()
+
+	var triggers *tableTriggers
+	for _, info := range catalog.GetTableTriggers(tx, tableName) {
+		if info.Event != event {
+			continue
+		}
+
+		prog, ok := info.Program.(TriggerProgram)
+		if !ok {
+			return nil, stringutil.Errorf("cannot run trigger %q", info.TriggerName)
+		}
+
+		cond, streams, err := prog.Prepare(tx, catalog)
+		if err != nil {
+			return nil, err
+		}
+
+		if triggers == nil {
+			triggers = new(tableTriggers)
+		}
+
+		t := preparedTrigger{name: info.TriggerName, cond: cond, streams: streams}
+		if info.Timing == database.TriggerBefore {
+			triggers.before = append(triggers.before, t)
+		} else {
+			triggers.after = append(triggers.after, t)
+		}
+	}
+
+	return triggers, nil
+}
+
+// fireBefore runs the triggers which must run before the document is written.
+// old or new are nil, depending on the event.
+func (t *tableTriggers) fireBefore(env *environment.Environment, old, new document.Document) error {
+	if t == nil {
+		return nil
+	}
+
+	return fireTriggers(t.before, env, old, new)
+}
+
+// fireAfter runs the triggers which must run once the document is written.
+// old or new are nil, depending on the event.
+func (t *tableTriggers) fireAfter(env *environment.Environment, old, new document.Document) error {
+	if t == nil {
+		return nil
+	}
+
+	return fireTriggers(t.after, env, old, new)
+}
+
+// hasAfter reports whether the written document is needed by triggers
+// once it is written.
+func (t *tableTriggers) hasAfter() bool {
+	return t != nil && len(t.after) > 0
+}
+
+func fireTriggers(triggers []preparedTrigger, env *environment.Environment, old, new document.Document) error {
+	if len(triggers) == 0 {
+		return nil
+	}
+
+	depth := env.GetTriggerDepth() + 1
+	if depth > MaxTriggerDepth {
+		return stringutil.Errorf("too many levels of trigger recursion: trigger %q", triggers[0].name)
+	}
+
+	var newEnv environment.Environment
+	newEnv.SetOuter(env)
+	newEnv.SetTriggerDepth(depth)
+	if new != nil {
+		newEnv.Set(NewVar, document.NewDocumentValue(new))
+		newEnv.SetDocument(new)
+	}
+	if old != nil {
+		newEnv.Set(OldVar, document.NewDocumentValue(old))
+		if new == nil {
+			newEnv.SetDocument(old)
+		}
+	}
+
+	for _, t := range triggers {
+		if t.cond != nil {
+			v, err := t.cond.Eval(&newEnv)
+			if err != nil {
+				return err
+			}
+			ok, err := v.IsTruthy()
+			if err != nil {
+				return err
+			}
+			if !ok {
+				continue
+			}
+		}
+
+		for _, s := range t.streams {
+			err := iterateSubstream(s, &newEnv, func(*environment.Environment) error {
+				return nil
+			})
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,69 @@
+package stream
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/genjidb/genji/document"
+	"github.com/genjidb/genji/internal/environment"
+	"github.com/genjidb/genji/internal/stringutil"
+)
+
+// A UnionScanOperator reads the documents of a table using multiple streams,
+// typically scanning different indexes, and returns each document once.
+// The keys of the documents already returned are kept in memory.
+type UnionScanOperator struct {
+	baseOperator
+	TableName string
+	Streams   []*Stream
+}
+
+// UnionScan returns the documents of the table returned by any of the streams.
+// Documents returned by more than one stream are only returned once.
+func UnionScan(tableName string, streams ...*Stream) *UnionScanOperator {
+	return &UnionScanOperator{TableName: tableName, Streams: streams}
+}
+
+// Iterate implements the Operator interface.
+func (op *UnionScanOperator) Iterate(in *environment.Environment, fn func(out *environment.Environment) error) error {
+	seen := make(map[string]struct{})
+
+	for _, s := range op.Streams {
+		err := iterateSubstream(s, in, func(out *environment.Environment) error {
+			d, ok := out.GetDocument()
+			if !ok {
+				return errors.New("missing document")
+			}
+
+			k, ok := d.(document.Keyer)
+			if !ok || k.RawKey() == nil {
+				return errors.New("missing document key")
+			}
+
+			if _, ok := seen[string(k.RawKey())]; ok {
+				return nil
+			}
+			seen[string(k.RawKey())] = struct{}{}
+
+			return fn(out)
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (op *UnionScanOperator) String() string {
+	var sb strings.Builder
+
+	for i, s := range op.Streams {
+		if i > 0 {
+			sb.WriteString(", ")
+		}
+		sb.WriteString(s.String())
+	}
+
+	return stringutil.Sprintf("unionScan(%s)", sb.String())
+}
@@ -0,0 +1,44 @@
+package stream_test
+
+import (
+	"testing"
+
+	"github.com/genjidb/genji/document"
+	"github.com/genjidb/genji/internal/environment"
+	"github.com/genjidb/genji/internal/stream"
+	"github.com/genjidb/genji/internal/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUnionScanOperator(t *testing.T) {
+	doc := func(k string, data string) document.Document {
+		fb := document.NewFieldBuffer()
+		err := fb.Copy(testutil.MakeDocument(t, data))
+		require.NoError(t, err)
+		fb.EncodedKey = []byte(k)
+		return fb
+	}
+
+	s1 := stream.New(stream.Documents(doc("1", `{"a": 1}`), doc("2", `{"a": 2}`)))
+	s2 := stream.New(stream.Documents(doc("3", `{"a": 2}`), doc("1", `{"a": 1}`), doc("4", `{"a": 4}`)))
+	s := stream.New(stream.UnionScan("test", s1, s2))
+
+	var got []document.Document
+	err := s.Iterate(new(environment.Environment), func(env *environment.Environment) error {
+		d, ok := env.GetDocument()
+		require.True(t, ok)
+		got = append(got, d)
+		return nil
+	})
+	require.NoError(t, err)
+
+	// documents are deduplicated by key, not by content
+	testutil.MakeDocuments(t, `{"a": 1}`, `{"a": 2}`, `{"a": 2}`, `{"a": 4}`).RequireEqual(t, got)
+
+	t.Run("String", func(t *testing.T) {
+		require.Equal(t, `unionScan(docs({"a": 1}), docs({"a": 2}))`, stream.UnionScan("test",
+			stream.New(stream.Documents(testutil.MakeDocument(t, `{"a": 1}`))),
+			stream.New(stream.Documents(testutil.MakeDocument(t, `{"a": 2}`))),
+		).String())
+	})
+}
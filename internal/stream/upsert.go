@@ -0,0 +1,169 @@
+package stream
+
+import (
+	"strings"
+
+	"github.com/genjidb/genji/document"
+	"github.com/genjidb/genji/internal/database"
+	"github.com/genjidb/genji/internal/environment"
+	"github.com/genjidb/genji/internal/expr"
+	"github.com/genjidb/genji/internal/stringutil"
+)
+
+// ExcludedVar is the name of the variable holding the document proposed
+// for insertion, when updating the document it conflicts with.
+const ExcludedVar = "excluded"
+
+// An UpsertSetPair sets the value of a path of the conflicting documents.
+type UpsertSetPair struct {
+	Path document.Path
+	E    expr.Expr
+}
+
+// A TableUpsertOperator inserts incoming documents to the table and resolves
+// the conflicts with the existing documents, either by ignoring the incoming
+// documents or by updating the existing ones.
+type TableUpsertOperator struct {
+	baseOperator
+	Name string
+	// Target lists the paths of the primary key or of the unique index
+	// whose conflicts are resolved. If empty, all conflicts are resolved.
+	Target []document.Path
+	// Set lists the paths of the existing document to update, where
+	// the incoming document can be referred to as "excluded".
+	// If empty, incoming documents are ignored.
+	Set []UpsertSetPair
+	// Where filters the existing documents to update.
+	Where expr.Expr
+}
+
+// TableUpsert inserts incoming documents to the table and ignores the ones conflicting
+// with the primary key or the unique index defined on the target paths.
+func TableUpsert(tableName string, target []document.Path) *TableUpsertOperator {
+	return &TableUpsertOperator{Name: tableName, Target: target}
+}
+
+// TableUpsertUpdate inserts incoming documents to the table and updates the documents
+// they conflict with, if they satisfy the where expression. The where expression is optional.
+func TableUpsertUpdate(tableName string, target []document.Path, set []UpsertSetPair, where expr.Expr) *TableUpsertOperator {
+	return &TableUpsertOperator{Name: tableName, Target: target, Set: set, Where: where}
+}
+
+// Iterate implements the Operator interface.
+func (op *TableUpsertOperator) Iterate(in *environment.Environment, f func(out *environment.Environment) error) error {
+	var checked bool
+
+	return insertDocuments(op.Prev, op.Name, in, f, func(table *database.Table, env *environment.Environment) (database.OnInsertConflictAction, error) {
+		action := database.OnInsertConflictDoNothing
+		if len(op.Set) > 0 {
+			action = op.update(env)
+		}
+
+		if len(op.Target) == 0 {
+			return func(t *database.Table, key []byte, d document.Document, err error) (document.Document, error) {
+				// only resolve duplicates, not the other constraint violations
+				if _, ok := err.(*database.ConflictError); !ok {
+					return nil, err
+				}
+
+				return action(t, key, d, err)
+			}, nil
+		}
+
+		if !checked {
+			ok, err := table.HasConflictTarget(op.Target)
+			if err != nil {
+				return nil, err
+			}
+			if !ok {
+				return nil, stringutil.Errorf("no primary key or unique index of table %q matches the conflict target", op.Name)
+			}
+			checked = true
+		}
+
+		return database.OnInsertConflictTarget(op.Target, action), nil
+	})
+}
+
+// update returns a conflict resolution function updating the existing document.
+func (op *TableUpsertOperator) update(env *environment.Environment) database.OnInsertConflictAction {
+	return func(t *database.Table, key []byte, d document.Document, _ error) (document.Document, error) {
+		old, err := t.GetDocument(key)
+		if err != nil {
+			return nil, err
+		}
+
+		var newEnv environment.Environment
+		newEnv.SetOuter(env)
+		newEnv.SetDocument(old)
+		newEnv.Set(ExcludedVar, document.NewDocumentValue(d))
+
+		if op.Where != nil {
+			v, err := op.Where.Eval(&newEnv)
+			if err != nil {
+				return nil, err
+			}
+			if ok, err := v.IsTruthy(); err != nil || !ok {
+				return nil, err
+			}
+		}
+
+		var fb document.FieldBuffer
+		err = fb.Copy(old)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, pair := range op.Set {
+			v, err := pair.E.Eval(&newEnv)
+			if err != nil && err != document.ErrFieldNotFound {
+				return nil, err
+			}
+
+			err = fb.Set(pair.Path, v)
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		return database.OnInsertConflictDoReplace(t, key, &fb, nil)
+	}
+}
+
+func (op *TableUpsertOperator) String() string {
+	var sb strings.Builder
+
+	stringutil.Fprintf(&sb, "tableUpsert('%s'", op.Name)
+
+	if len(op.Target) > 0 {
+		sb.WriteString(", (")
+		for i, p := range op.Target {
+			if i > 0 {
+				sb.WriteString(", ")
+			}
+			sb.WriteString(p.String())
+		}
+		sb.WriteString(")")
+	}
+
+	if len(op.Set) == 0 {
+		sb.WriteString(", doNothing)")
+		return sb.String()
+	}
+
+	sb.WriteString(", doUpdate(")
+	for i, pair := range op.Set {
+		if i > 0 {
+			sb.WriteString(", ")
+		}
+		stringutil.Fprintf(&sb, "%s = %s", pair.Path, pair.E)
+	}
+	sb.WriteString(")")
+
+	if op.Where != nil {
+		stringutil.Fprintf(&sb, ", where(%s)", op.Where)
+	}
+
+	sb.WriteString(")")
+	return sb.String()
+}
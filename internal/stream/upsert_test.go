@@ -0,0 +1,125 @@
+package stream_test
+
+import (
+	"testing"
+
+	"github.com/genjidb/genji/document"
+	"github.com/genjidb/genji/internal/environment"
+	"github.com/genjidb/genji/internal/expr"
+	"github.com/genjidb/genji/internal/stream"
+	"github.com/genjidb/genji/internal/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTableUpsert(t *testing.T) {
+	tests := []struct {
+		name     string
+		op       *stream.TableUpsertOperator
+		in       testutil.Docs
+		expected testutil.Docs
+		fails    bool
+	}{
+		{
+			"do nothing",
+			stream.TableUpsert("test", nil),
+			testutil.MakeDocuments(t, `{"a": 1, "b": 10, "c": 10}`, `{"a": 3, "b": 30, "c": 30}`),
+			testutil.MakeDocuments(t, `{"a": 1, "b": 1, "c": 1}`, `{"a": 2, "b": 2, "c": 2}`, `{"a": 3, "b": 30, "c": 30}`),
+			false,
+		},
+		{
+			"do nothing / target",
+			stream.TableUpsert("test", []document.Path{document.NewPath("b")}),
+			testutil.MakeDocuments(t, `{"a": 3, "b": 1, "c": 3}`),
+			testutil.MakeDocuments(t, `{"a": 1, "b": 1, "c": 1}`, `{"a": 2, "b": 2, "c": 2}`),
+			false,
+		},
+		{
+			"do nothing / other target",
+			stream.TableUpsert("test", []document.Path{document.NewPath("b")}),
+			testutil.MakeDocuments(t, `{"a": 1, "b": 3, "c": 3}`),
+			nil,
+			true,
+		},
+		{
+			"do nothing / invalid target",
+			stream.TableUpsert("test", []document.Path{document.NewPath("c")}),
+			testutil.MakeDocuments(t, `{"a": 3, "b": 3, "c": 3}`),
+			nil,
+			true,
+		},
+		{
+			"do update",
+			stream.TableUpsertUpdate("test", []document.Path{document.NewPath("a")}, []stream.UpsertSetPair{
+				{Path: document.NewPath("c"), E: expr.Add(testutil.ParsePath(t, "c"), testutil.ParsePath(t, "excluded.c"))},
+			}, nil),
+			testutil.MakeDocuments(t, `{"a": 1, "b": 10, "c": 10}`, `{"a": 3, "b": 30, "c": 30}`),
+			testutil.MakeDocuments(t, `{"a": 1, "b": 1, "c": 11}`, `{"a": 2, "b": 2, "c": 2}`, `{"a": 3, "b": 30, "c": 30}`),
+			false,
+		},
+		{
+			"do update / where",
+			stream.TableUpsertUpdate("test", nil, []stream.UpsertSetPair{
+				{Path: document.NewPath("c"), E: testutil.ParsePath(t, "excluded.c")},
+			}, expr.Gt(testutil.ParsePath(t, "a"), testutil.IntegerValue(1))),
+			testutil.MakeDocuments(t, `{"a": 1, "b": 10, "c": 10}`, `{"a": 4, "b": 2, "c": 20}`),
+			testutil.MakeDocuments(t, `{"a": 1, "b": 1, "c": 1}`, `{"a": 2, "b": 2, "c": 20}`),
+			false,
+		},
+		{
+			"do update / unique violation",
+			stream.TableUpsertUpdate("test", nil, []stream.UpsertSetPair{
+				{Path: document.NewPath("b"), E: testutil.IntegerValue(2)},
+			}, nil),
+			testutil.MakeDocuments(t, `{"a": 1, "b": 10, "c": 10}`),
+			nil,
+			true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			db, tx, cleanup := testutil.NewTestTx(t)
+			defer cleanup()
+
+			testutil.MustExec(t, db, tx, `
+				CREATE TABLE test (a INTEGER PRIMARY KEY, b INTEGER UNIQUE, c INTEGER);
+				INSERT INTO test (a, b, c) VALUES (1, 1, 1), (2, 2, 2);
+			`)
+
+			in := environment.Environment{}
+			in.Tx = tx
+			in.Catalog = db.Catalog
+
+			s := stream.New(stream.Documents(test.in...)).Pipe(test.op)
+			err := s.Iterate(&in, func(out *environment.Environment) error { return nil })
+			if test.fails {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+
+			res := testutil.MustQuery(t, db, tx, "SELECT * FROM test")
+			defer res.Close()
+
+			var got []document.Document
+			err = res.Iterate(func(d document.Document) error {
+				var fb document.FieldBuffer
+				fb.Copy(d)
+				got = append(got, fb)
+				return nil
+			})
+			require.NoError(t, err)
+			test.expected.RequireEqual(t, got)
+		})
+	}
+
+	t.Run("String", func(t *testing.T) {
+		require.Equal(t, "tableUpsert('test', doNothing)", stream.TableUpsert("test", nil).String())
+		require.Equal(t, "tableUpsert('test', (a, b), doNothing)", stream.TableUpsert("test", []document.Path{document.NewPath("a"), document.NewPath("b")}).String())
+		require.Equal(t, "tableUpsert('test', (a), doUpdate(b = excluded.b, c = 1), where(c > 1))",
+			stream.TableUpsertUpdate("test", []document.Path{document.NewPath("a")}, []stream.UpsertSetPair{
+				{Path: document.NewPath("b"), E: testutil.ParsePath(t, "excluded.b")},
+				{Path: document.NewPath("c"), E: testutil.IntegerValue(1)},
+			}, expr.Gt(testutil.ParsePath(t, "c"), testutil.IntegerValue(1))).String())
+	})
+}
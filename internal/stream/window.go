@@ -0,0 +1,186 @@
+package stream
+
+import (
+	"bytes"
+	"errors"
+	"sort"
+	"strings"
+
+	"github.com/genjidb/genji/document"
+	"github.com/genjidb/genji/internal/environment"
+	"github.com/genjidb/genji/internal/expr"
+	"github.com/genjidb/genji/internal/stringutil"
+)
+
+// A WindowOperator evaluates window functions sharing the same window.
+type WindowOperator struct {
+	baseOperator
+	Funcs []*expr.WindowExpr
+}
+
+// Window evaluates window functions sharing the same window.
+// It buffers the documents of the stream in partitions, based on the values
+// of the PARTITION BY clause of the window, and sorts each partition using the ORDER BY clause.
+// Partitions are returned in the order they arrived, and the order of the documents
+// whose ORDER BY values are equal is preserved.
+// The value of each function is stored in a variable of the returned environments,
+// named after the function, where it is read by expr.WindowExpr.
+// Like Sort, it loads the entire stream in memory.
+func Window(funcs ...*expr.WindowExpr) *WindowOperator {
+	return &WindowOperator{Funcs: funcs}
+}
+
+// a windowRow is a document of a partition and its encoded ORDER BY values.
+type windowRow struct {
+	env   *environment.Environment
+	order [][]byte
+}
+
+// Iterate implements the Operator interface.
+func (op *WindowOperator) Iterate(in *environment.Environment, f func(out *environment.Environment) error) error {
+	if len(op.Funcs) == 0 {
+		return errors.New("missing window function")
+	}
+	w := &op.Funcs[0].Window
+
+	// keep order of partitions as they arrive to provide deterministic results.
+	var partitionKeys []string
+	partitions := make(map[string][]windowRow)
+
+	var buf bytes.Buffer
+	enc := document.NewValueEncoder(&buf)
+
+	encode := func(e expr.Expr, env *environment.Environment) ([]byte, error) {
+		v, err := e.Eval(env)
+		if err != nil && err != document.ErrFieldNotFound {
+			return nil, err
+		}
+		if err == document.ErrFieldNotFound {
+			v = expr.NullLiteral
+		}
+
+		buf.Reset()
+		err = enc.Encode(v)
+		if err != nil {
+			return nil, err
+		}
+
+		return append([]byte(nil), buf.Bytes()...), nil
+	}
+
+	err := op.Prev.Iterate(in, func(out *environment.Environment) error {
+		var key []byte
+		for _, e := range w.PartitionBy {
+			b, err := encode(e, out)
+			if err != nil {
+				return err
+			}
+			key = append(key, b...)
+		}
+
+		row := windowRow{order: make([][]byte, len(w.OrderBy))}
+		for i, t := range w.OrderBy {
+			b, err := encode(t.E, out)
+			if err != nil {
+				return err
+			}
+			row.order[i] = b
+		}
+
+		var err error
+		row.env, err = out.Clone()
+		if err != nil {
+			return err
+		}
+
+		k := string(key)
+		if _, ok := partitions[k]; !ok {
+			partitionKeys = append(partitionKeys, k)
+		}
+		partitions[k] = append(partitions[k], row)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	canceled := contextChecker(in)
+	values := make([][]document.Value, len(op.Funcs))
+	for _, k := range partitionKeys {
+		if err := canceled(); err != nil {
+			return err
+		}
+
+		rows := partitions[k]
+		delete(partitions, k)
+
+		sort.SliceStable(rows, func(i, j int) bool {
+			return compareWindowRows(w, &rows[i], &rows[j]) < 0
+		})
+
+		p := expr.Partition{
+			Envs:  make([]*environment.Environment, len(rows)),
+			Peers: make([]int, len(rows)),
+		}
+		for i := range rows {
+			p.Envs[i] = rows[i].env
+			if i > 0 {
+				p.Peers[i] = p.Peers[i-1]
+				if compareWindowRows(w, &rows[i-1], &rows[i]) != 0 {
+					p.Peers[i]++
+				}
+			}
+		}
+
+		for i, fn := range op.Funcs {
+			values[i], err = fn.EvalPartition(&p)
+			if err != nil {
+				return err
+			}
+		}
+
+		for i := range rows {
+			var newEnv environment.Environment
+			newEnv.SetOuter(rows[i].env)
+			for j, fn := range op.Funcs {
+				newEnv.Set(fn.String(), values[j][i])
+			}
+
+			err = f(&newEnv)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// compareWindowRows compares the ORDER BY values of two rows.
+func compareWindowRows(w *expr.WindowSpec, a, b *windowRow) int {
+	for i, t := range w.OrderBy {
+		c := bytes.Compare(a.order[i], b.order[i])
+		if c == 0 {
+			continue
+		}
+		if t.Desc {
+			return -c
+		}
+		return c
+	}
+
+	return 0
+}
+
+func (op *WindowOperator) String() string {
+	var sb strings.Builder
+
+	for i, fn := range op.Funcs {
+		if i > 0 {
+			sb.WriteString(", ")
+		}
+		sb.WriteString(fn.String())
+	}
+
+	return stringutil.Sprintf("window(%s)", sb.String())
+}
@@ -0,0 +1,98 @@
+package stream_test
+
+import (
+	"testing"
+
+	"github.com/genjidb/genji/document"
+	"github.com/genjidb/genji/internal/environment"
+	"github.com/genjidb/genji/internal/expr"
+	"github.com/genjidb/genji/internal/sql/parser"
+	"github.com/genjidb/genji/internal/stream"
+	"github.com/genjidb/genji/internal/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWindow(t *testing.T) {
+	in := testutil.MakeDocuments(t,
+		`{"a": 1, "g": "x", "v": 20}`,
+		`{"a": 2, "g": "y", "v": 10}`,
+		`{"a": 3, "g": "x", "v": 10}`,
+		`{"a": 4, "g": "x", "v": 20}`,
+	)
+
+	tests := []struct {
+		name  string
+		exprs []string
+		want  []string
+	}{
+		{
+			"row_number",
+			[]string{"ROW_NUMBER() OVER ()"},
+			[]string{`{"a": 1, "w0": 1}`, `{"a": 2, "w0": 2}`, `{"a": 3, "w0": 3}`, `{"a": 4, "w0": 4}`},
+		},
+		{
+			"row_number/partition/order",
+			[]string{"ROW_NUMBER() OVER (PARTITION BY g ORDER BY v DESC)"},
+			[]string{`{"a": 1, "w0": 1}`, `{"a": 4, "w0": 2}`, `{"a": 3, "w0": 3}`, `{"a": 2, "w0": 1}`},
+		},
+		{
+			"rank",
+			[]string{"RANK() OVER (ORDER BY v)", "DENSE_RANK() OVER (ORDER BY v)"},
+			[]string{`{"a": 2, "w0": 1, "w1": 1}`, `{"a": 3, "w0": 1, "w1": 1}`, `{"a": 1, "w0": 3, "w1": 2}`, `{"a": 4, "w0": 3, "w1": 2}`},
+		},
+		{
+			"lag/lead",
+			[]string{"LAG(a) OVER (PARTITION BY g)", "LEAD(a, 1, -1) OVER (PARTITION BY g)"},
+			[]string{`{"a": 1, "w0": null, "w1": 3}`, `{"a": 3, "w0": 1, "w1": 4}`, `{"a": 4, "w0": 3, "w1": -1}`, `{"a": 2, "w0": null, "w1": -1}`},
+		},
+		{
+			"sum/running",
+			[]string{"SUM(v) OVER (PARTITION BY g ORDER BY v)"},
+			[]string{`{"a": 3, "w0": 10}`, `{"a": 1, "w0": 50}`, `{"a": 4, "w0": 50}`, `{"a": 2, "w0": 10}`},
+		},
+		{
+			"sum/partition",
+			[]string{"SUM(v) OVER (PARTITION BY g)"},
+			[]string{`{"a": 1, "w0": 50}`, `{"a": 3, "w0": 50}`, `{"a": 4, "w0": 50}`, `{"a": 2, "w0": 10}`},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var funcs []*expr.WindowExpr
+			for _, e := range test.exprs {
+				funcs = append(funcs, parser.MustParseExpr(e).(*expr.WindowExpr))
+			}
+
+			s := stream.New(stream.Documents(in...)).Pipe(stream.Window(funcs...))
+
+			var got []document.Document
+			err := s.Iterate(new(environment.Environment), func(env *environment.Environment) error {
+				fb := document.NewFieldBuffer()
+				a, err := expr.Path(document.NewPath("a")).Eval(env)
+				require.NoError(t, err)
+				fb.Add("a", a)
+
+				for i, fn := range funcs {
+					v, err := fn.Eval(env)
+					require.NoError(t, err)
+					fb.Add("w"+string(rune('0'+i)), v)
+				}
+
+				got = append(got, fb)
+				return nil
+			})
+			require.NoError(t, err)
+
+			testutil.MakeDocuments(t, test.want...).RequireEqual(t, got)
+		})
+	}
+
+	t.Run("String", func(t *testing.T) {
+		require.Equal(t, "window(ROW_NUMBER() OVER (PARTITION BY g ORDER BY v DESC), SUM(v) OVER (PARTITION BY g ORDER BY v DESC))",
+			stream.Window(
+				parser.MustParseExpr("ROW_NUMBER() OVER (PARTITION BY g ORDER BY v DESC)").(*expr.WindowExpr),
+				parser.MustParseExpr("SUM(v) OVER (PARTITION BY g ORDER BY v DESC)").(*expr.WindowExpr),
+			).String())
+	})
+}
@@ -115,6 +115,10 @@ var emptyEnv = environment.New(nil)
 func FunctionExpr(t testing.TB, name string, args ...expr.Expr) expr.Expr {
 	t.Helper()
 	n := strings.Split(name, ".")
+	if len(n) == 1 {
+		// builtin function
+		n = []string{"", n[0]}
+	}
 	def, err := functions.DefaultPackages().GetFunc(n[0], n[1])
 	require.NoError(t, err)
 	require.NotNil(t, def)
@@ -1,6 +1,7 @@
 package testutil
 
 import (
+	"context"
 	"testing"
 
 	"github.com/genjidb/genji/document"
@@ -16,7 +17,7 @@ func GetIndexContent(t testing.TB, tx *database.Transaction, catalog database.Ca
 	require.NoError(t, err)
 
 	var content []KV
-	err = idx.AscendGreaterOrEqual([]document.Value{{}}, func(val, key []byte) error {
+	err = idx.AscendGreaterOrEqual(context.Background(), []document.Value{{}}, func(val, key []byte) error {
 		content = append(content, KV{
 			Key:   append([]byte{}, val...),
 			Value: append([]byte{}, key...),
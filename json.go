@@ -0,0 +1,75 @@
+package genji
+
+import (
+	"bufio"
+	"io"
+
+	"github.com/genjidb/genji/document"
+	"github.com/genjidb/genji/internal/stringutil"
+)
+
+// ImportJSON inserts the JSON objects read from r as documents of the given table,
+// within a single transaction, and returns the number of inserted documents.
+// r can either hold newline-delimited JSON (JSON Lines), or any stream of objects,
+// or an array of objects. Objects are read one at a time, so that r doesn't need to fit in memory.
+// The table is created if it doesn't exist.
+func (db *DB) ImportJSON(tableName string, r io.Reader) (n int, err error) {
+	tx, err := db.Begin(true)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	name := stringutil.NormalizeIdentifier(tableName, '`')
+	err = tx.Exec("CREATE TABLE IF NOT EXISTS " + name)
+	if err != nil {
+		return 0, err
+	}
+
+	stmt, err := tx.Prepare("INSERT INTO " + name + " VALUES ?")
+	if err != nil {
+		return 0, err
+	}
+
+	err = document.IterateJSON(r, func(fb *document.FieldBuffer) error {
+		err := stmt.Exec(fb)
+		if err != nil {
+			return stringutil.Errorf("document %d: %w", n+1, err)
+		}
+		n++
+		return nil
+	})
+	if err != nil {
+		return n, err
+	}
+
+	return n, tx.Commit()
+}
+
+// ExportJSON runs the query and writes the documents it returns to w
+// as newline-delimited JSON (JSON Lines), one document per line.
+func (db *DB) ExportJSON(w io.Writer, q string, args ...interface{}) error {
+	res, err := db.Query(q, args...)
+	if err != nil {
+		return err
+	}
+	defer res.Close()
+
+	bw := bufio.NewWriter(w)
+	err = res.Iterate(func(d document.Document) error {
+		data, err := document.MarshalJSON(d)
+		if err != nil {
+			return err
+		}
+
+		if _, err = bw.Write(data); err != nil {
+			return err
+		}
+		return bw.WriteByte('\n')
+	})
+	if err != nil {
+		return err
+	}
+
+	return bw.Flush()
+}
@@ -0,0 +1,55 @@
+package genji_test
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/genjidb/genji"
+	"github.com/stretchr/testify/require"
+)
+
+func TestImportExportJSON(t *testing.T) {
+	db, err := genji.Open(":memory:")
+	require.NoError(t, err)
+	defer db.Close()
+
+	n, err := db.ImportJSON("foo", strings.NewReader(`{"a": 1, "b": "x"}
+{"a": 2, "b": [1, 2]}
+
+{"a": 3, "b": {"c": true}}
+`))
+	require.NoError(t, err)
+	require.Equal(t, 3, n)
+
+	var buf bytes.Buffer
+	err = db.ExportJSON(&buf, "SELECT * FROM foo WHERE a >= ?", 2)
+	require.NoError(t, err)
+	require.Equal(t, `{"a": 2, "b": [1, 2]}
+{"a": 3, "b": {"c": true}}
+`, buf.String())
+
+	// arrays of objects
+	n, err = db.ImportJSON("foo", strings.NewReader(`[{"a": 4}, {"a": 5}]`))
+	require.NoError(t, err)
+	require.Equal(t, 2, n)
+
+	// the import is atomic
+	err = db.Exec("CREATE TABLE bar (a INT PRIMARY KEY)")
+	require.NoError(t, err)
+	n, err = db.ImportJSON("bar", strings.NewReader(`{"a": 1} {"a": 2} {"a": 1}`))
+	require.EqualError(t, err, "document 3: duplicate document")
+	require.Equal(t, 2, n)
+
+	buf.Reset()
+	err = db.ExportJSON(&buf, "SELECT COUNT(*) AS n FROM bar")
+	require.NoError(t, err)
+	require.Equal(t, "{\"n\": 0}\n", buf.String())
+
+	// the input must hold objects
+	_, err = db.ImportJSON("bar", strings.NewReader(`1`))
+	require.Error(t, err)
+	n, err = db.ImportJSON("bar", strings.NewReader(""))
+	require.NoError(t, err)
+	require.Equal(t, 0, n)
+}
@@ -0,0 +1,87 @@
+package genji
+
+import "container/list"
+
+// An lru keeps a limited number of values in memory,
+// evicting the least recently used ones first.
+// It is not safe for concurrent use.
+type lru struct {
+	size    int
+	entries map[string]*list.Element
+	list    *list.List // most recently used values first
+}
+
+type lruEntry struct {
+	key   string
+	value interface{}
+}
+
+func newLRU() *lru {
+	return &lru{
+		entries: make(map[string]*list.Element),
+		list:    list.New(),
+	}
+}
+
+// setSize sets the maximum number of values kept and evicts the values in excess.
+func (l *lru) setSize(n int) {
+	if n < 0 {
+		n = 0
+	}
+	l.size = n
+	l.evict()
+}
+
+// get returns the value associated with the key and marks it as the most recently used.
+func (l *lru) get(key string) (interface{}, bool) {
+	elem, ok := l.entries[key]
+	if !ok {
+		return nil, false
+	}
+
+	l.list.MoveToFront(elem)
+	return elem.Value.(*lruEntry).value, true
+}
+
+// put associates the value with the key, unless the size is zero.
+func (l *lru) put(key string, value interface{}) {
+	if l.size == 0 {
+		return
+	}
+
+	if elem, ok := l.entries[key]; ok {
+		elem.Value.(*lruEntry).value = value
+		l.list.MoveToFront(elem)
+		return
+	}
+
+	l.entries[key] = l.list.PushFront(&lruEntry{key: key, value: value})
+	l.evict()
+}
+
+// remove removes the value associated with the key, if any.
+func (l *lru) remove(key string) {
+	if elem, ok := l.entries[key]; ok {
+		l.list.Remove(elem)
+		delete(l.entries, key)
+	}
+}
+
+// clear removes every value.
+func (l *lru) clear() {
+	l.entries = make(map[string]*list.Element)
+	l.list.Init()
+}
+
+func (l *lru) len() int {
+	return l.list.Len()
+}
+
+// evict removes the least recently used values until the lru fits its size.
+func (l *lru) evict() {
+	for l.list.Len() > l.size {
+		elem := l.list.Back()
+		l.list.Remove(elem)
+		delete(l.entries, elem.Value.(*lruEntry).key)
+	}
+}
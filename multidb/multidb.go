@@ -0,0 +1,340 @@
+// +build !wasm
+
+// Package multidb manages a directory of Genji databases, one per tenant.
+//
+// Running one database file per customer is a common pattern for embedded databases:
+// it isolates the data of each tenant and makes it easy to back up or remove it.
+// The Manager opens databases lazily, the first time they are used, and closes the least
+// recently used ones when too many are open.
+package multidb
+
+import (
+	"container/list"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/genjidb/genji"
+)
+
+// DefaultMaxOpen is the default maximum number of databases open at the same time.
+const DefaultMaxOpen = 64
+
+// DefaultExtension is the default extension of database files.
+const DefaultExtension = ".db"
+
+// ErrClosed is returned when using a closed manager.
+var ErrClosed = errors.New("multidb: manager closed")
+
+// Options shared by all the databases of a manager.
+type Options struct {
+	// MaxOpen is the maximum number of databases kept open at the same time.
+	// Once the limit is reached, the least recently used database that is not in use is closed.
+	// Databases in use are never closed, the limit may then be temporarily exceeded.
+	// Defaults to DefaultMaxOpen.
+	MaxOpen int
+
+	// Extension of the database files. Defaults to DefaultExtension.
+	Extension string
+
+	// Open opens the database stored at the given path.
+	// It can be used to configure the engine. Defaults to genji.Open.
+	Open func(path string) (*genji.DB, error)
+
+	// OnOpen is called every time the database of a tenant is opened,
+	// before it is used. It can be used to create tables or register row policies.
+	// If it returns an error, the database is closed and the error is returned.
+	OnOpen func(tenant string, db *genji.DB) error
+}
+
+// Stats contains aggregate metrics about the databases of a manager.
+type Stats struct {
+	// Number of databases currently open.
+	Open int
+	// Number of databases currently in use.
+	InUse int
+	// Number of times a database was requested while already open.
+	Hits int64
+	// Number of times a database had to be opened.
+	Misses int64
+	// Number of databases closed because the MaxOpen limit was reached.
+	Evictions int64
+}
+
+// A Manager manages a directory containing one database per tenant.
+// It is safe for concurrent use.
+type Manager struct {
+	dir  string
+	opts Options
+
+	mu     sync.Mutex
+	dbs    map[string]*list.Element
+	lru    *list.List // most recently used databases first
+	stats  Stats
+	closed bool
+}
+
+type entry struct {
+	tenant string
+	db     *genji.DB
+	refs   int
+}
+
+// New creates a manager storing databases in dir.
+// The directory is created if it doesn't exist.
+// If opts is nil, default options are used.
+func New(dir string, opts *Options) (*Manager, error) {
+	m := Manager{
+		dir: dir,
+		dbs: make(map[string]*list.Element),
+		lru: list.New(),
+	}
+
+	if opts != nil {
+		m.opts = *opts
+	}
+	if m.opts.MaxOpen <= 0 {
+		m.opts.MaxOpen = DefaultMaxOpen
+	}
+	if m.opts.Extension == "" {
+		m.opts.Extension = DefaultExtension
+	}
+	if m.opts.Open == nil {
+		m.opts.Open = genji.Open
+	}
+
+	err := os.MkdirAll(dir, 0700)
+	if err != nil {
+		return nil, err
+	}
+
+	return &m, nil
+}
+
+// Path returns the path of the database file of the tenant.
+func (m *Manager) Path(tenant string) (string, error) {
+	err := validateTenant(tenant)
+	if err != nil {
+		return "", err
+	}
+
+	return filepath.Join(m.dir, tenant+m.opts.Extension), nil
+}
+
+// validateTenant ensures the tenant name can safely be used as a file name.
+func validateTenant(tenant string) error {
+	if tenant == "" || tenant == "." || tenant == ".." || strings.ContainsAny(tenant, `/\`) || strings.ContainsRune(tenant, 0) {
+		return errors.New("multidb: invalid tenant name " + tenant)
+	}
+
+	return nil
+}
+
+// Open returns the database of the tenant, creating it if it doesn't exist.
+// The returned release function must be called once the database is no longer used,
+// and the database must not be used afterwards. Databases in use are never closed by the manager.
+func (m *Manager) Open(tenant string) (db *genji.DB, release func(), err error) {
+	path, err := m.Path(tenant)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.closed {
+		return nil, nil, ErrClosed
+	}
+
+	e, err := m.get(tenant, path)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	e.refs++
+
+	var once sync.Once
+	release = func() {
+		once.Do(func() {
+			m.release(e)
+		})
+	}
+
+	return e.db, release, nil
+}
+
+// get returns the entry of the tenant, opening its database if necessary.
+func (m *Manager) get(tenant, path string) (*entry, error) {
+	if elem, ok := m.dbs[tenant]; ok {
+		m.stats.Hits++
+		m.lru.MoveToFront(elem)
+		return elem.Value.(*entry), nil
+	}
+
+	m.stats.Misses++
+
+	db, err := m.opts.Open(path)
+	if err != nil {
+		return nil, err
+	}
+
+	if m.opts.OnOpen != nil {
+		err = m.opts.OnOpen(tenant, db)
+		if err != nil {
+			db.Close()
+			return nil, err
+		}
+	}
+
+	e := entry{tenant: tenant, db: db}
+	m.dbs[tenant] = m.lru.PushFront(&e)
+
+	// close the least recently used databases if there are too many
+	// open databases, ignoring the ones in use.
+	for elem := m.lru.Back(); elem != nil && m.lru.Len() > m.opts.MaxOpen; {
+		prev := elem.Prev()
+
+		if old := elem.Value.(*entry); old.refs == 0 && old != &e {
+			m.remove(elem)
+			m.stats.Evictions++
+		}
+
+		elem = prev
+	}
+
+	return &e, nil
+}
+
+func (m *Manager) release(e *entry) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	e.refs--
+
+	// the database might have been closed by Close or Remove while in use
+	elem, ok := m.dbs[e.tenant]
+	if !ok || elem.Value.(*entry) != e {
+		if e.refs == 0 {
+			_ = e.db.Close()
+		}
+		return
+	}
+
+	if e.refs == 0 && m.lru.Len() > m.opts.MaxOpen {
+		m.remove(elem)
+		m.stats.Evictions++
+	}
+}
+
+// remove closes the database of the entry if it's not in use,
+// and removes it from the list of open databases.
+func (m *Manager) remove(elem *list.Element) error {
+	e := elem.Value.(*entry)
+	m.lru.Remove(elem)
+	delete(m.dbs, e.tenant)
+
+	// databases in use are closed once released
+	if e.refs > 0 {
+		return nil
+	}
+
+	return e.db.Close()
+}
+
+// Do opens the database of the tenant, calls fn and releases the database.
+func (m *Manager) Do(tenant string, fn func(db *genji.DB) error) error {
+	db, release, err := m.Open(tenant)
+	if err != nil {
+		return err
+	}
+	defer release()
+
+	return fn(db)
+}
+
+// Tenants returns the sorted list of tenants whose database exists in the directory.
+func (m *Manager) Tenants() ([]string, error) {
+	files, err := ioutil.ReadDir(m.dir)
+	if err != nil {
+		return nil, err
+	}
+
+	var tenants []string
+	for _, f := range files {
+		name := f.Name()
+		if !f.IsDir() && strings.HasSuffix(name, m.opts.Extension) && len(name) > len(m.opts.Extension) {
+			tenants = append(tenants, strings.TrimSuffix(name, m.opts.Extension))
+		}
+	}
+
+	sort.Strings(tenants)
+	return tenants, nil
+}
+
+// Remove closes the database of the tenant and deletes its file.
+// If the database is in use, it is closed once released.
+func (m *Manager) Remove(tenant string) error {
+	path, err := m.Path(tenant)
+	if err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.closed {
+		return ErrClosed
+	}
+
+	if elem, ok := m.dbs[tenant]; ok {
+		err = m.remove(elem)
+		if err != nil {
+			return err
+		}
+	}
+
+	return os.Remove(path)
+}
+
+// Stats returns metrics about the databases of the manager.
+func (m *Manager) Stats() Stats {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	stats := m.stats
+	stats.Open = m.lru.Len()
+	for elem := m.lru.Front(); elem != nil; elem = elem.Next() {
+		if elem.Value.(*entry).refs > 0 {
+			stats.InUse++
+		}
+	}
+
+	return stats
+}
+
+// Close closes all the open databases. Databases in use are closed once released.
+// The manager can't be used afterwards.
+func (m *Manager) Close() error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.closed {
+		return nil
+	}
+	m.closed = true
+
+	var err error
+	for elem := m.lru.Front(); elem != nil; {
+		next := elem.Next()
+		if e := m.remove(elem); e != nil && err == nil {
+			err = e
+		}
+		elem = next
+	}
+
+	return err
+}
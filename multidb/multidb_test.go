@@ -0,0 +1,170 @@
+package multidb_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/genjidb/genji"
+	"github.com/genjidb/genji/document"
+	"github.com/genjidb/genji/multidb"
+	"github.com/stretchr/testify/require"
+)
+
+func tempDir(t *testing.T) string {
+	dir, err := ioutil.TempDir("", "genji")
+	require.NoError(t, err)
+	t.Cleanup(func() { os.RemoveAll(dir) })
+	return dir
+}
+
+func TestManager(t *testing.T) {
+	t.Run("Lazy opening", func(t *testing.T) {
+		dir := tempDir(t)
+
+		var opened []string
+		m, err := multidb.New(dir, &multidb.Options{
+			OnOpen: func(tenant string, db *genji.DB) error {
+				opened = append(opened, tenant)
+				return db.Exec("CREATE TABLE IF NOT EXISTS test")
+			},
+		})
+		require.NoError(t, err)
+		defer m.Close()
+
+		tenants, err := m.Tenants()
+		require.NoError(t, err)
+		require.Empty(t, tenants)
+
+		for _, tenant := range []string{"b", "a", "b"} {
+			err = m.Do(tenant, func(db *genji.DB) error {
+				return db.Exec("INSERT INTO test (a) VALUES (?)", tenant)
+			})
+			require.NoError(t, err)
+		}
+		require.Equal(t, []string{"b", "a"}, opened)
+
+		tenants, err = m.Tenants()
+		require.NoError(t, err)
+		require.Equal(t, []string{"a", "b"}, tenants)
+
+		err = m.Do("b", func(db *genji.DB) error {
+			d, err := db.QueryDocument("SELECT COUNT(*) AS n FROM test")
+			require.NoError(t, err)
+			v, err := d.GetByField("n")
+			require.NoError(t, err)
+			require.Equal(t, document.NewIntegerValue(2), v)
+			return nil
+		})
+		require.NoError(t, err)
+
+		stats := m.Stats()
+		require.Equal(t, 2, stats.Open)
+		require.Equal(t, 0, stats.InUse)
+		require.EqualValues(t, 2, stats.Hits)
+		require.EqualValues(t, 2, stats.Misses)
+	})
+
+	t.Run("LRU closing", func(t *testing.T) {
+		dir := tempDir(t)
+
+		m, err := multidb.New(dir, &multidb.Options{MaxOpen: 2})
+		require.NoError(t, err)
+		defer m.Close()
+
+		noop := func(db *genji.DB) error { return nil }
+
+		require.NoError(t, m.Do("a", noop))
+		require.NoError(t, m.Do("b", noop))
+		require.NoError(t, m.Do("a", noop))
+		// b is the least recently used
+		require.NoError(t, m.Do("c", noop))
+
+		stats := m.Stats()
+		require.Equal(t, 2, stats.Open)
+		require.EqualValues(t, 1, stats.Evictions)
+
+		// a is still open
+		require.NoError(t, m.Do("a", noop))
+		require.EqualValues(t, 2, m.Stats().Hits)
+
+		// b must be reopened
+		require.NoError(t, m.Do("b", noop))
+		require.EqualValues(t, 4, m.Stats().Misses)
+	})
+
+	t.Run("Databases in use are not closed", func(t *testing.T) {
+		dir := tempDir(t)
+
+		m, err := multidb.New(dir, &multidb.Options{MaxOpen: 1})
+		require.NoError(t, err)
+		defer m.Close()
+
+		dba, releaseA, err := m.Open("a")
+		require.NoError(t, err)
+		_, releaseB, err := m.Open("b")
+		require.NoError(t, err)
+
+		stats := m.Stats()
+		require.Equal(t, 2, stats.Open)
+		require.Equal(t, 2, stats.InUse)
+
+		require.NoError(t, dba.Exec("CREATE TABLE test"))
+
+		releaseA()
+		// releasing twice has no effect
+		releaseA()
+
+		stats = m.Stats()
+		require.Equal(t, 1, stats.Open)
+		require.Equal(t, 1, stats.InUse)
+		require.EqualValues(t, 1, stats.Evictions)
+
+		releaseB()
+		require.Equal(t, 0, m.Stats().InUse)
+	})
+
+	t.Run("Remove", func(t *testing.T) {
+		dir := tempDir(t)
+
+		m, err := multidb.New(dir, nil)
+		require.NoError(t, err)
+		defer m.Close()
+
+		require.NoError(t, m.Do("a", func(db *genji.DB) error { return nil }))
+		require.NoError(t, m.Remove("a"))
+
+		_, err = os.Stat(filepath.Join(dir, "a"+multidb.DefaultExtension))
+		require.True(t, os.IsNotExist(err))
+		require.Equal(t, 0, m.Stats().Open)
+	})
+
+	t.Run("Invalid tenant", func(t *testing.T) {
+		m, err := multidb.New(tempDir(t), nil)
+		require.NoError(t, err)
+		defer m.Close()
+
+		for _, tenant := range []string{"", ".", "..", "../a", "a/b"} {
+			_, _, err = m.Open(tenant)
+			require.Error(t, err, tenant)
+		}
+	})
+
+	t.Run("Closed", func(t *testing.T) {
+		m, err := multidb.New(tempDir(t), nil)
+		require.NoError(t, err)
+
+		db, release, err := m.Open("a")
+		require.NoError(t, err)
+
+		require.NoError(t, m.Close())
+
+		// databases in use remain usable until released
+		require.NoError(t, db.Exec("CREATE TABLE test"))
+		release()
+
+		_, _, err = m.Open("a")
+		require.Equal(t, multidb.ErrClosed, err)
+	})
+}
@@ -13,5 +13,9 @@ import (
 
 // New initializes the DB using the given engine.
 func New(ctx context.Context, ng engine.Engine) (*DB, error) {
-	return newDatabase(ctx, ng, database.Options{Codec: custom.NewCodec(), Catalog: catalog.New()})
+	return newDatabase(ctx, ng, defaultOptions())
+}
+
+func defaultOptions() database.Options {
+	return database.Options{Codec: custom.NewCodec(), Catalog: catalog.New()}
 }
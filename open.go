@@ -4,29 +4,79 @@ package genji
 
 import (
 	"context"
+	"strings"
+	"time"
 
 	"github.com/genjidb/genji/engine"
 	"github.com/genjidb/genji/engine/boltengine"
 	"github.com/genjidb/genji/engine/memoryengine"
+	"github.com/genjidb/genji/internal/stringutil"
 )
 
 // Open creates a Genji database at the given path.
-// If path is equal to ":memory:" it will open an in-memory database,
-// otherwise it will create an on-disk database using the BoltDB engine.
+// If path is equal to ":memory:" it will open an in-memory database.
+// If path is prefixed by the name of a registered engine followed by a colon,
+// i.e. "bbolt:/path/to/db" or "badger:/path/to/dir", it will use that engine,
+// see engine.Register. The bolt, bbolt and memory engines are always available,
+// other engines are registered by importing their package.
+// Otherwise it will create an on-disk database using the BoltDB engine.
 func Open(path string) (*DB, error) {
-	var ng engine.Engine
-	var err error
-
-	switch path {
-	case ":memory:":
-		ng = memoryengine.NewEngine()
-	default:
-		ng, err = boltengine.NewEngine(path, 0660, nil)
+	return OpenWith(path, nil)
+}
+
+// Options configures how a database is opened by OpenWith.
+type Options struct {
+	// Engine is the name of the registered engine used to open the path.
+	// If empty, the engine is selected based on the path, like with Open.
+	Engine string
+
+	// TTLReapInterval is the interval at which the expired documents of the tables
+	// created with the ttl_field option are deleted. Zero means every minute,
+	// and a negative interval disables the deletion: expired documents are then
+	// only hidden from statements.
+	TTLReapInterval time.Duration
+}
+
+// OpenWith creates a Genji database at the given path, using the given options.
+// If opts is nil, it behaves like Open.
+func OpenWith(path string, opts *Options) (*DB, error) {
+	if opts == nil {
+		opts = new(Options)
 	}
+
+	ng, err := openEngine(opts.Engine, path)
 	if err != nil {
 		return nil, err
 	}
 
-	ctx := context.Background()
-	return New(ctx, ng)
+	dbOpts := defaultOptions()
+	dbOpts.TTLReapInterval = opts.TTLReapInterval
+	return newDatabase(context.Background(), ng, dbOpts)
+}
+
+// openEngine opens the engine with the given name at path. If name is empty,
+// the engine is either selected using the prefix of the path, or defaults to BoltDB.
+func openEngine(name, path string) (engine.Engine, error) {
+	if name != "" {
+		opener, ok := engine.Lookup(name)
+		if !ok {
+			return nil, stringutil.Errorf("unknown engine %q", name)
+		}
+
+		return opener(path)
+	}
+
+	if path == ":memory:" {
+		return memoryengine.NewEngine(), nil
+	}
+
+	// paths that don't start with the name of a registered engine,
+	// including Windows paths like C:\foo, are opened with BoltDB.
+	if i := strings.IndexByte(path, ':'); i > 0 {
+		if opener, ok := engine.Lookup(path[:i]); ok {
+			return opener(path[i+1:])
+		}
+	}
+
+	return boltengine.NewEngine(path, 0660, nil)
 }
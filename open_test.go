@@ -109,3 +109,54 @@ func TestOpen(t *testing.T) {
 	require.NoError(t, err)
 	testutil.RequireDocJSONEq(t, d, `{"name": "seqD", "seq": 500}`)
 }
+
+func TestOpenEngine(t *testing.T) {
+	dir, err := ioutil.TempDir("", "genji")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	tests := []struct {
+		name string
+		path string
+		opts *genji.Options
+	}{
+		{"memory prefix", "memory:", nil},
+		{"bbolt prefix", "bbolt:" + filepath.Join(dir, "a.db"), nil},
+		{"bolt prefix", "bolt:" + filepath.Join(dir, "b.db"), nil},
+		{"options", filepath.Join(dir, "c.db"), &genji.Options{Engine: "bbolt"}},
+		{"options without engine", filepath.Join(dir, "d.db"), &genji.Options{}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			db, err := genji.OpenWith(test.path, test.opts)
+			require.NoError(t, err)
+			defer db.Close()
+
+			err = db.Exec("CREATE TABLE test; INSERT INTO test (a) VALUES (1)")
+			require.NoError(t, err)
+		})
+	}
+
+	t.Run("files", func(t *testing.T) {
+		for _, name := range []string{"a.db", "b.db", "c.db", "d.db"} {
+			_, err := os.Stat(filepath.Join(dir, name))
+			require.NoError(t, err)
+		}
+	})
+
+	t.Run("unknown engine", func(t *testing.T) {
+		_, err := genji.OpenWith(filepath.Join(dir, "e.db"), &genji.Options{Engine: "foo"})
+		require.EqualError(t, err, `unknown engine "foo"`)
+	})
+
+	t.Run("unregistered prefix", func(t *testing.T) {
+		// the whole path is used by the default engine
+		db, err := genji.Open(filepath.Join(dir, "foo:f.db"))
+		require.NoError(t, err)
+		require.NoError(t, db.Close())
+
+		_, err = os.Stat(filepath.Join(dir, "foo:f.db"))
+		require.NoError(t, err)
+	})
+}
@@ -0,0 +1,71 @@
+package genji
+
+import (
+	"errors"
+
+	"github.com/genjidb/genji/document"
+	"github.com/genjidb/genji/internal/query/statement"
+	"github.com/genjidb/genji/internal/stream"
+)
+
+// A StreamOperator transforms the documents returned by a query.
+// Iterate reads the documents produced by the query from docs and calls fn
+// for every document it outputs. It can filter, reorder, merge or create documents,
+// for instance to group events into sessions or to remove duplicates.
+//
+// Documents read from docs are only valid during the call to the iteration function
+// and must be copied if they are kept, using document.NewFieldBuffer and Copy.
+// Errors returned by fn must be returned as is by Iterate: they are used
+// to stop the query early.
+// A StreamOperator is used by every call to Query and must not keep state between calls.
+type StreamOperator interface {
+	Iterate(docs document.Iterator, fn func(d document.Document) error) error
+}
+
+// The StreamOperatorFunc type is an adapter to allow the use of ordinary functions as stream operators.
+type StreamOperatorFunc func(docs document.Iterator, fn func(d document.Document) error) error
+
+// Iterate calls f(docs, fn).
+func (f StreamOperatorFunc) Iterate(docs document.Iterator, fn func(d document.Document) error) error {
+	return f(docs, fn)
+}
+
+var errCannotPipe = errors.New("only statements made of a single query returning documents can be piped")
+
+// Pipe returns a statement whose documents are passed through the given operators, in order,
+// after the rest of the query plan ran: operators see documents after projection, sorting and LIMIT.
+// Only statements made of a single SELECT, INSERT, UPDATE or DELETE statement can be piped.
+// Results of piped statements are never cached.
+func (s *Statement) Pipe(ops ...StreamOperator) (*Statement, error) {
+	if len(s.pq.Statements) != 1 {
+		return nil, errCannotPipe
+	}
+
+	if _, ok := s.pq.Statements[0].(*statement.StreamStmt); !ok {
+		return nil, errCannotPipe
+	}
+
+	clone := *s
+	clone.ops = append(append([]StreamOperator{}, s.ops...), ops...)
+	return &clone, nil
+}
+
+// pipe appends the operators of the statement to the stream of the result.
+func (s *Statement) pipe(r *statement.Result) {
+	it, ok := r.Iterator.(*statement.StreamStmtIterator)
+	if !ok {
+		return
+	}
+
+	// the prepared stream is shared by all the calls to Query, its operators
+	// can't be linked to the new ones. The iterator only needs the operators
+	// to be linked to the previous ones.
+	var op stream.Operator = it.Stream.Op
+	for _, o := range s.ops {
+		next := stream.Iterator("pipe", o.Iterate)
+		next.SetPrev(op)
+		op = next
+	}
+
+	it.Stream = stream.New(op)
+}
@@ -0,0 +1,118 @@
+package genji_test
+
+import (
+	"testing"
+
+	"github.com/genjidb/genji"
+	"github.com/genjidb/genji/document"
+	"github.com/genjidb/genji/internal/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+// dedupOperator removes the documents whose field has the same value as the previous document.
+type dedupOperator string
+
+func (op dedupOperator) Iterate(docs document.Iterator, fn func(d document.Document) error) error {
+	var prev *document.Value
+
+	return docs.Iterate(func(d document.Document) error {
+		v, err := d.GetByField(string(op))
+		if err != nil {
+			return err
+		}
+
+		if prev != nil {
+			ok, err := prev.IsEqual(v)
+			if err != nil || ok {
+				return err
+			}
+		}
+		prev = &v
+
+		return fn(d)
+	})
+}
+
+func TestStatementPipe(t *testing.T) {
+	db, err := genji.Open(":memory:")
+	require.NoError(t, err)
+	defer db.Close()
+
+	err = db.Exec(`
+		CREATE TABLE events(ts INTEGER PRIMARY KEY, kind TEXT);
+		INSERT INTO events (ts, kind) VALUES (1, 'a'), (2, 'a'), (3, 'b'), (4, 'a'), (5, 'b'), (6, 'b');
+	`)
+	require.NoError(t, err)
+
+	stmt, err := db.Prepare(`SELECT ts, kind FROM events ORDER BY ts`)
+	require.NoError(t, err)
+
+	t.Run("Operators", func(t *testing.T) {
+		piped, err := stmt.Pipe(dedupOperator("kind"))
+		require.NoError(t, err)
+
+		res, err := piped.Query()
+		require.NoError(t, err)
+		defer res.Close()
+
+		require.Equal(t, []string{"ts", "kind"}, res.Fields())
+		testutil.RequireStreamEq(t, `
+			{"ts": 1, "kind": "a"}
+			{"ts": 3, "kind": "b"}
+			{"ts": 4, "kind": "a"}
+			{"ts": 5, "kind": "b"}
+		`, res)
+
+		// operators are run in order
+		piped, err = piped.Pipe(genji.StreamOperatorFunc(func(docs document.Iterator, fn func(d document.Document) error) error {
+			var fb document.FieldBuffer
+			err := docs.Iterate(func(d document.Document) error {
+				return fb.Copy(d)
+			})
+			if err != nil {
+				return err
+			}
+
+			return fn(&fb)
+		}))
+		require.NoError(t, err)
+
+		d, err := piped.QueryDocument()
+		require.NoError(t, err)
+		testutil.RequireDocJSONEq(t, d, `{"ts": 5, "kind": "b"}`)
+
+		// the original statement is not modified
+		res, err = stmt.Query()
+		require.NoError(t, err)
+		defer res.Close()
+		testutil.RequireStreamEq(t, `
+			{"ts": 1, "kind": "a"}
+			{"ts": 2, "kind": "a"}
+			{"ts": 3, "kind": "b"}
+			{"ts": 4, "kind": "a"}
+			{"ts": 5, "kind": "b"}
+			{"ts": 6, "kind": "b"}
+		`, res)
+	})
+
+	t.Run("Early stop", func(t *testing.T) {
+		piped, err := stmt.Pipe(dedupOperator("kind"))
+		require.NoError(t, err)
+
+		d, err := piped.QueryDocument()
+		require.NoError(t, err)
+		testutil.RequireDocJSONEq(t, d, `{"ts": 1, "kind": "a"}`)
+	})
+
+	t.Run("Invalid statements", func(t *testing.T) {
+		stmt, err := db.Prepare(`SELECT * FROM events; SELECT * FROM events`)
+		require.NoError(t, err)
+		_, err = stmt.Pipe(dedupOperator("kind"))
+		require.Error(t, err)
+
+		stmt, err = db.Prepare(`CREATE TABLE foo`)
+		require.NoError(t, err)
+		_, err = stmt.Pipe(dedupOperator("kind"))
+		require.Error(t, err)
+	})
+}
@@ -0,0 +1,146 @@
+package genji
+
+import (
+	"sync"
+
+	"github.com/genjidb/genji/internal/catalog"
+	"github.com/genjidb/genji/internal/database"
+	"github.com/genjidb/genji/internal/query"
+	"github.com/genjidb/genji/internal/query/statement"
+	"github.com/genjidb/genji/internal/sql/parser"
+)
+
+// defaultPlanCacheSize is the number of plans kept by the plan cache of a new database.
+const defaultPlanCacheSize = 128
+
+// SetPlanCacheSize sets the maximum number of query plans kept by the plan cache.
+// Preparing a query whose plan is in the cache skips parsing and planning it,
+// this applies to queries run using Query, QueryDocument and Exec as well.
+// Queries are identified by their exact SQL. Plans are dropped once the structure
+// of the database is modified, or when a plan hook or a virtual table is added,
+// and are not cached while plan hooks are registered.
+// The cache is shared by all the handles of the database and keeps 128 plans by default.
+// Zero disables the cache and removes every cached plan.
+func (db *DB) SetPlanCacheSize(n int) {
+	db.plans.mu.Lock()
+	defer db.plans.mu.Unlock()
+
+	db.plans.lru.setSize(n)
+}
+
+// planCache keeps the most recently used plans in memory.
+type planCache struct {
+	mu  sync.Mutex
+	lru *lru
+}
+
+// a cachedPlan is a query whose statements are all prepared.
+type cachedPlan struct {
+	pq query.Query
+	// number of modifications of the catalog when the query was prepared.
+	modifications uint64
+	// version of the statistics used to prepare the query.
+	statistics uint64
+}
+
+func newPlanCache() *planCache {
+	c := planCache{lru: newLRU()}
+	c.lru.setSize(defaultPlanCacheSize)
+	return &c
+}
+
+// clear removes every cached plan.
+func (c *planCache) clear() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.lru.clear()
+}
+
+// prepare parses the query and prepares its statements,
+// or returns the query from the plan cache.
+func (db *DB) prepare(q string, tx *Tx) (query.Query, error) {
+	cat, _ := db.db.Catalog.(*catalog.Catalog)
+
+	var n uint64
+	var pending bool
+	sv := database.StatisticsVersion()
+	if cat != nil {
+		n, pending = cat.Modifications()
+		// read-only transactions may read an older version of the catalog
+		if tx != nil && cat.IsStale(tx.tx) {
+			pending = true
+		}
+		if !pending {
+			if pq, ok := db.plans.get(q, n, sv); ok {
+				return pq, nil
+			}
+		}
+	}
+
+	pq, err := parser.ParseQuery(q)
+	if err != nil {
+		return query.Query{}, err
+	}
+
+	err = pq.Prepare(newQueryContext(db, tx, nil))
+	if err != nil {
+		return query.Query{}, err
+	}
+
+	if cat != nil && !pending && isPrepared(pq) {
+		// the plan is only valid if the catalog and the statistics didn't change while preparing the query
+		if m, pending := cat.Modifications(); m == n && !pending && database.StatisticsVersion() == sv {
+			db.plans.put(db.hooks, q, &cachedPlan{pq: pq, modifications: n, statistics: sv})
+		}
+	}
+
+	return pq, nil
+}
+
+// get returns the cached plan of the query, if neither the catalog
+// nor the statistics were modified since it was prepared.
+func (c *planCache) get(q string, modifications, statistics uint64) (query.Query, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	v, ok := c.lru.get(q)
+	if !ok {
+		return query.Query{}, false
+	}
+
+	p := v.(*cachedPlan)
+	if p.modifications != modifications || p.statistics != statistics {
+		c.lru.remove(q)
+		return query.Query{}, false
+	}
+
+	return p.pq, true
+}
+
+// put caches the plan, unless plan hooks are registered.
+// Hooks are checked while holding the lock, so that adding a hook, which clears
+// the cache afterwards, can't race with caching a plan prepared without it.
+func (c *planCache) put(hooks *planHooks, q string, p *cachedPlan) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if !hooks.empty() {
+		return
+	}
+
+	c.lru.put(q, p)
+}
+
+// isPrepared returns whether all the statements of the query were prepared,
+// in which case it can safely be shared by multiple statements.
+func isPrepared(pq query.Query) bool {
+	for _, stmt := range pq.Statements {
+		s, ok := stmt.(*statement.StreamStmt)
+		if !ok || s.PreparedStream == nil {
+			return false
+		}
+	}
+
+	return len(pq.Statements) > 0
+}
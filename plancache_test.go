@@ -0,0 +1,131 @@
+package genji_test
+
+import (
+	"testing"
+
+	"github.com/genjidb/genji"
+	"github.com/genjidb/genji/internal/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPlanCache(t *testing.T) {
+	setup := func(t *testing.T) *genji.DB {
+		t.Helper()
+
+		db, err := genji.Open(":memory:")
+		require.NoError(t, err)
+
+		err = db.Exec(`
+			CREATE TABLE foo(a INTEGER PRIMARY KEY, b INTEGER);
+			INSERT INTO foo (a, b) VALUES (1, 10), (2, 20);
+		`)
+		require.NoError(t, err)
+		return db
+	}
+
+	requireQuery := func(t *testing.T, db *genji.DB, q string, expected string, args ...interface{}) {
+		t.Helper()
+
+		res, err := db.Query(q, args...)
+		require.NoError(t, err)
+		defer res.Close()
+
+		testutil.RequireStreamEq(t, expected, res)
+	}
+
+	t.Run("Hit", func(t *testing.T) {
+		db := setup(t)
+		defer db.Close()
+
+		n := genji.PlanCacheLen(db)
+		requireQuery(t, db, `SELECT a FROM foo WHERE b = ?`, `{"a": 1}`, 10)
+		require.Equal(t, n+1, genji.PlanCacheLen(db))
+
+		requireQuery(t, db, `SELECT a FROM foo WHERE b = ?`, `{"a": 2}`, 20)
+		require.Equal(t, n+1, genji.PlanCacheLen(db))
+	})
+
+	t.Run("Disabled", func(t *testing.T) {
+		db := setup(t)
+		defer db.Close()
+
+		db.SetPlanCacheSize(0)
+		requireQuery(t, db, `SELECT a FROM foo WHERE b = 10`, `{"a": 1}`)
+		require.Equal(t, 0, genji.PlanCacheLen(db))
+	})
+
+	t.Run("Eviction", func(t *testing.T) {
+		db := setup(t)
+		defer db.Close()
+
+		db.SetPlanCacheSize(1)
+		requireQuery(t, db, `SELECT a FROM foo WHERE b = 10`, `{"a": 1}`)
+		requireQuery(t, db, `SELECT a FROM foo WHERE b = 20`, `{"a": 2}`)
+		require.Equal(t, 1, genji.PlanCacheLen(db))
+	})
+
+	t.Run("Schema change", func(t *testing.T) {
+		db := setup(t)
+		defer db.Close()
+
+		requireQuery(t, db, `EXPLAIN SELECT a FROM foo WHERE b = 10`, `{"plan": "seqScan(foo, paths(b, a)) | filter(b = 10) | project(a)"}`)
+
+		err := db.Exec(`CREATE INDEX idx_foo_b ON foo(b)`)
+		require.NoError(t, err)
+
+		requireQuery(t, db, `EXPLAIN SELECT a FROM foo WHERE b = 10`, `{"plan": "indexScan(\"idx_foo_b\", 10, covering) | project(a)"}`)
+
+		// plans are not cached while the catalog is being modified
+		tx, err := db.Begin(true)
+		require.NoError(t, err)
+		err = tx.Exec(`DROP INDEX idx_foo_b`)
+		require.NoError(t, err)
+		d, err := tx.QueryDocument(`EXPLAIN SELECT a FROM foo WHERE b = 10`)
+		require.NoError(t, err)
+		testutil.RequireDocJSONEq(t, d, `{"plan": "seqScan(foo, paths(b, a)) | filter(b = 10) | project(a)"}`)
+		require.NoError(t, tx.Rollback())
+
+		requireQuery(t, db, `EXPLAIN SELECT a FROM foo WHERE b = 10`, `{"plan": "indexScan(\"idx_foo_b\", 10, covering) | project(a)"}`)
+	})
+
+	t.Run("Statistics", func(t *testing.T) {
+		db := setup(t)
+		defer db.Close()
+
+		// scanning a table of two documents is cheaper than using the index
+		err := db.Exec(`CREATE INDEX idx_foo_b ON foo(b); ANALYZE foo`)
+		require.NoError(t, err)
+
+		requireQuery(t, db, `SELECT a FROM foo WHERE b = 10`, `{"a": 1}`)
+		plan, ok := genji.CachedPlan(db, `SELECT a FROM foo WHERE b = 10`)
+		require.True(t, ok)
+		require.Equal(t, "seqScan(foo, paths(b, a)) | filter(b = 10) | project(a)", plan)
+
+		err = db.Exec(`INSERT INTO foo (a, b) VALUES (3, 30), (4, 40), (5, 50), (6, 60); ANALYZE foo`)
+		require.NoError(t, err)
+
+		_, ok = genji.CachedPlan(db, `SELECT a FROM foo WHERE b = 10`)
+		require.False(t, ok)
+
+		requireQuery(t, db, `SELECT a FROM foo WHERE b = 10`, `{"a": 1}`)
+		plan, ok = genji.CachedPlan(db, `SELECT a FROM foo WHERE b = 10`)
+		require.True(t, ok)
+		require.Equal(t, `indexScan("idx_foo_b", 10, covering) | project(a)`, plan)
+	})
+
+	t.Run("Plan hooks", func(t *testing.T) {
+		db := setup(t)
+		defer db.Close()
+
+		requireQuery(t, db, `SELECT a FROM foo`, `{"a": 1} {"a": 2}`)
+		require.NotZero(t, genji.PlanCacheLen(db))
+
+		db.AddPlanHook(func(p *genji.Plan) error {
+			return p.AddFilter("b > 10")
+		})
+		require.Zero(t, genji.PlanCacheLen(db))
+
+		requireQuery(t, db, `SELECT a FROM foo`, `{"a": 2}`)
+		require.Zero(t, genji.PlanCacheLen(db))
+	})
+}
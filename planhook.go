@@ -0,0 +1,171 @@
+package genji
+
+import (
+	"errors"
+	"sync"
+
+	"github.com/genjidb/genji/internal/database"
+	"github.com/genjidb/genji/internal/sql/parser"
+	"github.com/genjidb/genji/internal/stream"
+)
+
+// A PlanHook is called with the plan of every SELECT, INSERT, UPDATE and DELETE statement
+// when it is prepared, before it is optimized, and may rewrite it.
+// It allows to transparently filter soft-deleted documents, redirect queries to other tables
+// or restrict the documents accessible to a user.
+// If it returns an error, the statement fails with that error.
+// Subqueries are not passed to hooks.
+type PlanHook func(p *Plan) error
+
+// AddPlanHook registers a hook called with the plan of every statement prepared by any handle
+// of the database. Hooks are called in the order they were added.
+// Statements prepared before the hook was added are not affected.
+// Hooks are not persisted and must be added every time the database is opened.
+func (db *DB) AddPlanHook(h PlanHook) {
+	db.hooks.mu.Lock()
+	db.hooks.list = append(db.hooks.list, h)
+	db.hooks.mu.Unlock()
+
+	// cached results and plans were computed using the previous hooks
+	db.cache.clear()
+	db.plans.clear()
+}
+
+// planHooks holds the hooks shared by all the handles of the database.
+type planHooks struct {
+	mu   sync.RWMutex
+	list []PlanHook
+}
+
+func (h *planHooks) empty() bool {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	return len(h.list) == 0
+}
+
+func (h *planHooks) run(s *stream.Stream, session *database.Session) error {
+	h.mu.RLock()
+	list := h.list
+	h.mu.RUnlock()
+
+	p := Plan{s: s, session: session}
+	for _, fn := range list {
+		err := fn(&p)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+var errNoTable = errors.New("the plan doesn't read from a table")
+
+// A Plan describes how a statement reads and writes documents.
+// It is a sequence of operations: the documents of a table are scanned, filtered, transformed
+// and possibly written to another table.
+type Plan struct {
+	s       *stream.Stream
+	session *database.Session
+}
+
+// User returns the name of the user the statement is run as,
+// or an empty string if the handle isn't authenticated.
+func (p *Plan) User() string {
+	if p.session == nil {
+		return ""
+	}
+
+	return p.session.User()
+}
+
+// Table returns the name of the table read by the statement,
+// or an empty string if it doesn't read any, like INSERT ... VALUES statements.
+func (p *Plan) Table() string {
+	if st, ok := p.s.First().(*stream.SeqScanOperator); ok {
+		return st.TableName
+	}
+
+	return ""
+}
+
+// SetTable makes the statement read from another table.
+func (p *Plan) SetTable(name string) error {
+	st, ok := p.s.First().(*stream.SeqScanOperator)
+	if !ok {
+		return errNoTable
+	}
+
+	st.TableName = name
+	return nil
+}
+
+// Target returns the name of the table written by the statement, or
+// an empty string if it doesn't write any, like SELECT statements.
+func (p *Plan) Target() string {
+	for op := p.s.First(); op != nil; op = op.GetNext() {
+		switch t := op.(type) {
+		case *stream.TableInsertOperator:
+			return t.Name
+		case *stream.TableUpsertOperator:
+			return t.Name
+		case *stream.TableReplaceOperator:
+			return t.Name
+		case *stream.TableDeleteOperator:
+			return t.Name
+		}
+	}
+
+	return ""
+}
+
+// SetTarget makes the statement write to another table.
+func (p *Plan) SetTarget(name string) error {
+	for op := p.s.First(); op != nil; op = op.GetNext() {
+		switch t := op.(type) {
+		case *stream.TableInsertOperator:
+			t.Name = name
+			return nil
+		case *stream.TableUpsertOperator:
+			t.Name = name
+			return nil
+		case *stream.TableReplaceOperator:
+			t.Name = name
+			return nil
+		case *stream.TableDeleteOperator:
+			t.Name = name
+			return nil
+		}
+	}
+
+	return errors.New("the plan doesn't write to a table")
+}
+
+// AddFilter restricts the documents read from the table to the ones
+// for which the given SQL expression is true, e.g. "deleted_at IS NULL".
+// The filter applies to all the statements reading the table, including
+// UPDATE and DELETE statements, and can use indexes.
+func (p *Plan) AddFilter(expr string) error {
+	e, err := parser.ParseExpr(expr)
+	if err != nil {
+		return err
+	}
+
+	first := p.s.First()
+	if _, ok := first.(*stream.SeqScanOperator); !ok {
+		return errNoTable
+	}
+
+	f := stream.InsertAfter(first, stream.Filter(e))
+	if p.s.Op == first {
+		p.s.Op = f
+	}
+
+	return nil
+}
+
+// String returns a representation of the plan, as returned by EXPLAIN before optimization.
+func (p *Plan) String() string {
+	return p.s.String()
+}
@@ -0,0 +1,132 @@
+package genji_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/genjidb/genji"
+	"github.com/genjidb/genji/internal/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPlanHook(t *testing.T) {
+	setup := func(t *testing.T) *genji.DB {
+		t.Helper()
+
+		db, err := genji.Open(":memory:")
+		require.NoError(t, err)
+
+		err = db.Exec(`
+			CREATE TABLE users(id INTEGER PRIMARY KEY, name TEXT, deleted BOOL);
+			CREATE TABLE users_archive(id INTEGER PRIMARY KEY, name TEXT);
+			INSERT INTO users (id, name, deleted) VALUES (1, 'a', false), (2, 'b', true), (3, 'c', false);
+			INSERT INTO users_archive (id, name) VALUES (10, 'z');
+		`)
+		require.NoError(t, err)
+		return db
+	}
+
+	requireQuery := func(t *testing.T, db *genji.DB, q string, expected string) {
+		t.Helper()
+
+		res, err := db.Query(q)
+		require.NoError(t, err)
+		defer res.Close()
+
+		testutil.RequireStreamEq(t, expected, res)
+	}
+
+	t.Run("Soft delete", func(t *testing.T) {
+		db := setup(t)
+		defer db.Close()
+
+		db.AddPlanHook(func(p *genji.Plan) error {
+			if p.Table() == "users" {
+				return p.AddFilter("deleted = false")
+			}
+			return nil
+		})
+
+		requireQuery(t, db, `SELECT id FROM users`, `{"id": 1} {"id": 3}`)
+		requireQuery(t, db, `SELECT id FROM users WHERE id > 1`, `{"id": 3}`)
+		requireQuery(t, db, `EXPLAIN SELECT id FROM users WHERE id = 2`, `{"plan": "pkScan(\"users\", 2, paths(deleted, id)) | filter(deleted = false) | project(id)"}`)
+
+		// soft-deleted documents can't be updated or deleted either
+		err := db.Exec(`UPDATE users SET name = 'x'; DELETE FROM users WHERE id = 2`)
+		require.NoError(t, err)
+
+		err = db.Exec(`INSERT INTO users (id, name, deleted) VALUES (4, 'd', false)`)
+		require.NoError(t, err)
+
+		requireQuery(t, db, `SELECT name FROM users_archive`, `{"name": "z"}`)
+		requireQuery(t, db, `SELECT name FROM users`, `{"name": "x"} {"name": "x"} {"name": "d"}`)
+	})
+
+	t.Run("Tables", func(t *testing.T) {
+		db := setup(t)
+		defer db.Close()
+
+		var tables, targets []string
+		db.AddPlanHook(func(p *genji.Plan) error {
+			tables = append(tables, p.Table())
+			targets = append(targets, p.Target())
+			return nil
+		})
+
+		err := db.Exec(`
+			SELECT * FROM users;
+			INSERT INTO users_archive (id) VALUES (20);
+			UPDATE users SET name = 'x';
+			INSERT INTO users_archive SELECT id + 100 AS id FROM users;
+		`)
+		require.NoError(t, err)
+		require.Equal(t, []string{"users", "", "users", "users"}, tables)
+		require.Equal(t, []string{"", "users_archive", "users", "users_archive"}, targets)
+	})
+
+	t.Run("Rewrite tables", func(t *testing.T) {
+		db := setup(t)
+		defer db.Close()
+
+		db.AddPlanHook(func(p *genji.Plan) error {
+			if p.Table() == "users" {
+				err := p.SetTable("users_archive")
+				if err != nil {
+					return err
+				}
+			}
+			if p.Target() == "users" {
+				return p.SetTarget("users_archive")
+			}
+			return nil
+		})
+
+		err := db.Exec(`INSERT INTO users (id, name) VALUES (11, 'y')`)
+		require.NoError(t, err)
+		requireQuery(t, db, `SELECT name FROM users`, `{"name": "z"} {"name": "y"}`)
+	})
+
+	t.Run("Errors", func(t *testing.T) {
+		db := setup(t)
+		defer db.Close()
+
+		errDenied := errors.New("denied")
+		db.AddPlanHook(func(p *genji.Plan) error {
+			if p.Target() != "" && p.User() == "" {
+				return errDenied
+			}
+			return nil
+		})
+
+		err := db.Exec(`DELETE FROM users`)
+		require.Equal(t, errDenied, err)
+
+		requireQuery(t, db, `SELECT COUNT(*) FROM users`, `{"COUNT(*)": 3}`)
+
+		db.AddPlanHook(func(p *genji.Plan) error {
+			return p.AddFilter("name = ")
+		})
+		_, err = db.Query(`SELECT * FROM users`)
+		require.Error(t, err)
+	})
+}
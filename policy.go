@@ -0,0 +1,90 @@
+package genji
+
+import (
+	"context"
+
+	"github.com/genjidb/genji/document"
+	"github.com/genjidb/genji/internal/database"
+	"github.com/genjidb/genji/internal/environment"
+	"github.com/genjidb/genji/internal/sql/parser"
+)
+
+// A RowPolicy restricts the documents of a table that queries can read and write,
+// for example to isolate the data of the tenants of an application.
+// Predicates can be Go functions or SQL expressions evaluated against each document.
+// If both are set, documents must satisfy both.
+// The context passed to Go functions is the one used to start the transaction,
+// see DB.WithContext.
+type RowPolicy struct {
+	// Read filters the documents of the table. Documents it rejects
+	// are ignored by SELECT, UPDATE and DELETE statements.
+	Read func(ctx context.Context, d document.Document) (bool, error)
+	// ReadExpr is a SQL expression that must evaluate to true for a document to be read,
+	// e.g. "tenant_id = 10".
+	ReadExpr string
+
+	// Write validates the documents inserted or updated in the table.
+	// Statements writing documents it rejects return an error.
+	Write func(ctx context.Context, d document.Document) (bool, error)
+	// WriteExpr is a SQL expression that must evaluate to true for a document to be written.
+	WriteExpr string
+}
+
+// SetRowPolicy registers the row policy of the given table, replacing any existing one.
+// The policy applies to every query run on the database, whatever the handle used.
+// If p is nil, the policy of the table is removed.
+// Policies are not persisted and must be registered every time the database is opened.
+func (db *DB) SetRowPolicy(tableName string, p *RowPolicy) error {
+	// cached results might contain documents the new policy filters out
+	defer db.cache.clear()
+
+	if p == nil {
+		db.db.Catalog.SetRowPolicy(tableName, nil)
+		return nil
+	}
+
+	read, err := newRowPredicate(p.Read, p.ReadExpr)
+	if err != nil {
+		return err
+	}
+
+	write, err := newRowPredicate(p.Write, p.WriteExpr)
+	if err != nil {
+		return err
+	}
+
+	db.db.Catalog.SetRowPolicy(tableName, &database.RowPolicy{
+		Read:  read,
+		Write: write,
+	})
+	return nil
+}
+
+// newRowPredicate returns a predicate satisfied by documents for which fn returns true
+// and the expression s evaluates to true. Both are optional.
+func newRowPredicate(fn func(ctx context.Context, d document.Document) (bool, error), s string) (database.RowPredicate, error) {
+	if s == "" {
+		return fn, nil
+	}
+
+	e, err := parser.ParseExpr(s)
+	if err != nil {
+		return nil, err
+	}
+
+	return func(ctx context.Context, d document.Document) (bool, error) {
+		if fn != nil {
+			ok, err := fn(ctx, d)
+			if err != nil || !ok {
+				return false, err
+			}
+		}
+
+		v, err := e.Eval(environment.New(d))
+		if err != nil {
+			return false, err
+		}
+
+		return v.IsTruthy()
+	}, nil
+}
@@ -0,0 +1,127 @@
+package genji_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/genjidb/genji"
+	"github.com/genjidb/genji/document"
+	"github.com/genjidb/genji/internal/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+type tenantKey struct{}
+
+func TestRowPolicy(t *testing.T) {
+	setup := func(t *testing.T) *genji.DB {
+		t.Helper()
+
+		db, err := genji.Open(":memory:")
+		require.NoError(t, err)
+
+		err = db.Exec(`
+			CREATE TABLE test(id INTEGER PRIMARY KEY, tenant INTEGER, a INTEGER);
+			CREATE INDEX test_a_idx ON test(a);
+			INSERT INTO test (id, tenant, a) VALUES (1, 1, 10), (2, 2, 20), (3, 1, 30), (4, 2, 40);
+		`)
+		require.NoError(t, err)
+		return db
+	}
+
+	requireIDs := func(t *testing.T, db *genji.DB, q string, expected string) {
+		t.Helper()
+
+		res, err := db.Query(q)
+		require.NoError(t, err)
+		defer res.Close()
+
+		testutil.RequireStreamEq(t, expected, res)
+	}
+
+	t.Run("SQL expressions", func(t *testing.T) {
+		db := setup(t)
+		defer db.Close()
+
+		err := db.SetRowPolicy("test", &genji.RowPolicy{
+			ReadExpr:  "tenant = 1",
+			WriteExpr: "tenant = 1",
+		})
+		require.NoError(t, err)
+
+		// sequential, primary key and index scans
+		requireIDs(t, db, `SELECT id FROM test`, `{"id": 1} {"id": 3}`)
+		requireIDs(t, db, `SELECT id FROM test WHERE id >= 2`, `{"id": 3}`)
+		requireIDs(t, db, `SELECT id FROM test WHERE a > 10`, `{"id": 3}`)
+		requireIDs(t, db, `SELECT COUNT(*) FROM test`, `{"COUNT(*)": 2}`)
+
+		// invisible documents can't be updated or deleted
+		err = db.Exec(`UPDATE test SET a = 0`)
+		require.NoError(t, err)
+		err = db.Exec(`DELETE FROM test WHERE id = 2`)
+		require.NoError(t, err)
+
+		// writes are validated
+		err = db.Exec(`INSERT INTO test (id, tenant, a) VALUES (5, 2, 50)`)
+		require.Error(t, err)
+		err = db.Exec(`UPDATE test SET tenant = 2 WHERE id = 1`)
+		require.Error(t, err)
+		err = db.Exec(`INSERT INTO test (id, tenant, a) VALUES (5, 1, 50)`)
+		require.NoError(t, err)
+
+		// removing the policy makes all the documents visible again
+		err = db.SetRowPolicy("test", nil)
+		require.NoError(t, err)
+		requireIDs(t, db, `SELECT id, a FROM test`, `
+			{"id": 1, "a": 0}
+			{"id": 2, "a": 20}
+			{"id": 3, "a": 0}
+			{"id": 4, "a": 40}
+			{"id": 5, "a": 50}
+		`)
+	})
+
+	t.Run("Go functions", func(t *testing.T) {
+		db := setup(t)
+		defer db.Close()
+
+		belongsToTenant := func(ctx context.Context, d document.Document) (bool, error) {
+			v, err := d.GetByField("tenant")
+			if err != nil {
+				return false, err
+			}
+
+			return v.V == ctx.Value(tenantKey{}), nil
+		}
+
+		err := db.SetRowPolicy("test", &genji.RowPolicy{
+			Read:  belongsToTenant,
+			Write: belongsToTenant,
+		})
+		require.NoError(t, err)
+
+		tenant1 := db.WithContext(context.WithValue(context.Background(), tenantKey{}, int64(1)))
+		tenant2 := db.WithContext(context.WithValue(context.Background(), tenantKey{}, int64(2)))
+
+		requireIDs(t, tenant1, `SELECT id FROM test`, `{"id": 1} {"id": 3}`)
+		requireIDs(t, tenant2, `SELECT id FROM test`, `{"id": 2} {"id": 4}`)
+
+		err = tenant2.Exec(`INSERT INTO test (id, tenant, a) VALUES (5, 1, 50)`)
+		require.Error(t, err)
+
+		err = tenant2.Update(func(tx *genji.Tx) error {
+			return tx.Exec(`DELETE FROM test`)
+		})
+		require.NoError(t, err)
+
+		requireIDs(t, tenant1, `SELECT id FROM test`, `{"id": 1} {"id": 3}`)
+		requireIDs(t, tenant2, `SELECT id FROM test`, ``)
+	})
+
+	t.Run("Invalid expression", func(t *testing.T) {
+		db := setup(t)
+		defer db.Close()
+
+		err := db.SetRowPolicy("test", &genji.RowPolicy{ReadExpr: "tenant = "})
+		require.Error(t, err)
+	})
+}
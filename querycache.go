@@ -0,0 +1,249 @@
+package genji
+
+import (
+	"bytes"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/genjidb/genji/document"
+	"github.com/genjidb/genji/internal/catalog"
+	"github.com/genjidb/genji/internal/database"
+	"github.com/genjidb/genji/internal/environment"
+	"github.com/genjidb/genji/internal/query"
+	"github.com/genjidb/genji/internal/query/statement"
+	"github.com/genjidb/genji/internal/stream"
+)
+
+// SetQueryCacheSize enables the query cache, which memoizes the results of read-only queries
+// run outside of explicit transactions, and sets the maximum number of results it keeps.
+// Queries are identified by their normalized SQL, their parameters and the settings of their session.
+// Cached results are invalidated once a transaction modifying one of the tables read by the query
+// is committed, or when the structure of the database is modified.
+// Queries calling non-deterministic functions such as random() are never cached.
+// Results are kept in memory entirely, the cache is meant for repeated queries returning few documents,
+// on data modified infrequently.
+// The cache is shared by all the handles of the database.
+// Zero, the default, disables the cache and removes every cached result.
+func (db *DB) SetQueryCacheSize(n int) {
+	db.cache.setSize(n)
+}
+
+// queryCache keeps the most recently used results in memory.
+type queryCache struct {
+	mu  sync.Mutex
+	lru *lru
+}
+
+// a cachedResult contains all the documents returned by a query.
+type cachedResult struct {
+	key    string
+	docs   []document.Document
+	fields []string
+	types  []document.ValueType
+	// versions of the tables read by the query, at the time it was run.
+	versions map[string]uint64
+}
+
+func newQueryCache() *queryCache {
+	return &queryCache{lru: newLRU()}
+}
+
+func (c *queryCache) setSize(n int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.lru.setSize(n)
+}
+
+func (c *queryCache) enabled() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.lru.size > 0
+}
+
+// clear removes every cached result.
+func (c *queryCache) clear() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.lru.clear()
+}
+
+// get returns the cached result of the query, if none
+// of the tables it read was modified since then.
+func (c *queryCache) get(db *database.Database, key string) (*cachedResult, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	v, ok := c.lru.get(key)
+	if !ok {
+		return nil, false
+	}
+
+	r := v.(*cachedResult)
+	for tableName, version := range r.versions {
+		if db.TableVersion(tableName) != version {
+			c.lru.remove(key)
+			return nil, false
+		}
+	}
+
+	return r, true
+}
+
+func (c *queryCache) put(r *cachedResult) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.lru.put(r.key, r)
+}
+
+// cacheKey returns the key identifying the result of the statement in the query cache.
+// It returns false if the result of the statement can't be cached.
+func (s *Statement) cacheKey(params []environment.Param) (string, bool) {
+	if s.tx != nil || len(s.ops) > 0 || !s.db.cache.enabled() || s.db.getSession().Tx() != nil {
+		return "", false
+	}
+
+	if len(s.pq.Statements) != 1 {
+		return "", false
+	}
+
+	stmt, ok := s.pq.Statements[0].(*statement.StreamStmt)
+	if !ok || !stmt.ReadOnly {
+		return "", false
+	}
+
+	var sb strings.Builder
+	sb.WriteString(stmt.String())
+	sb.WriteByte(0)
+
+	var buf bytes.Buffer
+	enc := document.NewValueEncoder(&buf)
+	for _, p := range params {
+		v, err := document.NewValue(p.Value)
+		if err != nil {
+			return "", false
+		}
+
+		buf.Reset()
+		err = enc.Encode(v)
+		if err != nil {
+			return "", false
+		}
+
+		sb.WriteString(p.Name)
+		sb.WriteByte(0)
+		sb.Write(buf.Bytes())
+		sb.WriteByte(0)
+	}
+
+	// the result depends on the privileges of the user and on the settings of the session
+	session := s.db.getSession()
+	sb.WriteString(session.User())
+	sb.WriteByte(0)
+
+	settings := session.Settings()
+	names := make([]string, 0, len(settings))
+	for name := range settings {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		buf.Reset()
+		err := enc.Encode(settings[name])
+		if err != nil {
+			return "", false
+		}
+
+		sb.WriteString(name)
+		sb.WriteByte(0)
+		sb.Write(buf.Bytes())
+		sb.WriteByte(0)
+	}
+
+	return sb.String(), true
+}
+
+// queryCached returns the result of the statement from the query cache,
+// or runs the statement and caches its result.
+func (s *Statement) queryCached(key string, params []environment.Param) (*Result, error) {
+	if r, ok := s.db.cache.get(s.db.db, key); ok {
+		return newCachedResult(r), nil
+	}
+
+	tx, err := s.db.db.BeginTx(s.db.ctx, &database.TxOptions{ReadOnly: true})
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	tx.ReadTracker = new(database.ReadTracker)
+
+	res, err := s.pq.Run(&query.Context{
+		Ctx:      s.db.ctx,
+		DB:       s.db.db,
+		Tx:       tx,
+		Session:  s.db.session,
+		Params:   params,
+		PlanHook: s.db.hooks.run,
+	})
+	if err != nil {
+		return nil, err
+	}
+	defer res.Close()
+
+	rr := Result{result: res}
+	r := cachedResult{
+		key:    key,
+		fields: rr.Fields(),
+		types:  rr.FieldTypes(),
+	}
+
+	r.docs, err = copyDocuments(res)
+	if err != nil {
+		return nil, err
+	}
+
+	if !tx.ReadTracker.Volatile() {
+		// the result must also be invalidated if the structure of the database changes
+		r.versions = map[string]uint64{
+			catalog.TableName: s.db.db.TableVersion(catalog.TableName),
+		}
+		for _, tableName := range tx.ReadTracker.Tables() {
+			r.versions[tableName] = s.db.db.TableVersion(tableName)
+		}
+
+		s.db.cache.put(&r)
+	}
+
+	return newCachedResult(&r), nil
+}
+
+func newCachedResult(r *cachedResult) *Result {
+	return &Result{
+		result: &statement.Result{Iterator: documents(r.docs)},
+		fields: r.fields,
+		types:  r.types,
+	}
+}
+
+// documents iterates over a list of documents.
+type documents []document.Document
+
+func (docs documents) Iterate(fn func(d document.Document) error) error {
+	for _, d := range docs {
+		err := fn(d)
+		if err == stream.ErrStreamClosed {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,157 @@
+package genji_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/genjidb/genji"
+	"github.com/genjidb/genji/internal/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+func TestQueryCache(t *testing.T) {
+	errTest := errors.New("test")
+
+	setup := func(t *testing.T, size int) *genji.DB {
+		t.Helper()
+
+		db, err := genji.Open(":memory:")
+		require.NoError(t, err)
+
+		err = db.Exec(`
+			CREATE TABLE foo(a INTEGER PRIMARY KEY, b INTEGER);
+			CREATE TABLE bar(a INTEGER PRIMARY KEY);
+			INSERT INTO foo (a, b) VALUES (1, 10), (2, 20);
+		`)
+		require.NoError(t, err)
+
+		db.SetQueryCacheSize(size)
+		return db
+	}
+
+	requireQuery := func(t *testing.T, db *genji.DB, q string, expected string, args ...interface{}) {
+		t.Helper()
+
+		res, err := db.Query(q, args...)
+		require.NoError(t, err)
+		defer res.Close()
+
+		testutil.RequireStreamEq(t, expected, res)
+	}
+
+	t.Run("Disabled", func(t *testing.T) {
+		db := setup(t, 0)
+		defer db.Close()
+
+		requireQuery(t, db, `SELECT a FROM foo`, `{"a": 1} {"a": 2}`)
+		require.Equal(t, 0, genji.QueryCacheLen(db))
+	})
+
+	t.Run("Hit", func(t *testing.T) {
+		db := setup(t, 10)
+		defer db.Close()
+
+		requireQuery(t, db, `SELECT a FROM foo`, `{"a": 1} {"a": 2}`)
+		require.Equal(t, 1, genji.QueryCacheLen(db))
+
+		// the normalized query is the same
+		requireQuery(t, db, `select   a from foo`, `{"a": 1} {"a": 2}`)
+		require.Equal(t, 1, genji.QueryCacheLen(db))
+
+		res, err := db.Query(`SELECT a FROM foo`)
+		require.NoError(t, err)
+		require.Equal(t, []string{"a"}, res.Fields())
+		require.NoError(t, res.Close())
+
+		d, err := db.QueryDocument(`SELECT b FROM foo`)
+		require.NoError(t, err)
+		testutil.RequireDocJSONEq(t, d, `{"b": 10}`)
+	})
+
+	t.Run("Params", func(t *testing.T) {
+		db := setup(t, 10)
+		defer db.Close()
+
+		requireQuery(t, db, `SELECT a FROM foo WHERE b = ?`, `{"a": 1}`, 10)
+		requireQuery(t, db, `SELECT a FROM foo WHERE b = ?`, `{"a": 2}`, 20)
+		require.Equal(t, 2, genji.QueryCacheLen(db))
+	})
+
+	t.Run("Invalidation", func(t *testing.T) {
+		db := setup(t, 10)
+		defer db.Close()
+
+		requireQuery(t, db, `SELECT COUNT(*) FROM foo`, `{"COUNT(*)": 2}`)
+
+		// writes to other tables keep the result
+		err := db.Exec(`INSERT INTO bar (a) VALUES (1)`)
+		require.NoError(t, err)
+		require.True(t, genji.IsQueryCached(db, `SELECT COUNT(*) FROM foo`))
+
+		err = db.Exec(`INSERT INTO foo (a, b) VALUES (3, 30)`)
+		require.NoError(t, err)
+		require.False(t, genji.IsQueryCached(db, `SELECT COUNT(*) FROM foo`))
+		requireQuery(t, db, `SELECT COUNT(*) FROM foo`, `{"COUNT(*)": 3}`)
+
+		// rolled back transactions keep the result
+		err = db.Update(func(tx *genji.Tx) error {
+			err := tx.Exec(`DELETE FROM foo`)
+			require.NoError(t, err)
+			return errTest
+		})
+		require.Equal(t, errTest, err)
+		require.True(t, genji.IsQueryCached(db, `SELECT COUNT(*) FROM foo`))
+		requireQuery(t, db, `SELECT COUNT(*) FROM foo`, `{"COUNT(*)": 3}`)
+
+		err = db.Exec(`DROP TABLE foo; CREATE TABLE foo; INSERT INTO foo (a) VALUES (1)`)
+		require.NoError(t, err)
+		requireQuery(t, db, `SELECT COUNT(*) FROM foo`, `{"COUNT(*)": 1}`)
+	})
+
+	t.Run("Volatile", func(t *testing.T) {
+		db := setup(t, 10)
+		defer db.Close()
+
+		requireQuery(t, db, `SELECT a FROM foo WHERE random() IS NOT NULL`, `{"a": 1} {"a": 2}`)
+		require.Equal(t, 0, genji.QueryCacheLen(db))
+	})
+
+	t.Run("Transaction", func(t *testing.T) {
+		db := setup(t, 10)
+		defer db.Close()
+
+		err := db.View(func(tx *genji.Tx) error {
+			res, err := tx.Query(`SELECT a FROM foo`)
+			require.NoError(t, err)
+			return res.Close()
+		})
+		require.NoError(t, err)
+		require.Equal(t, 0, genji.QueryCacheLen(db))
+	})
+
+	t.Run("Sessions", func(t *testing.T) {
+		db := setup(t, 10)
+		defer db.Close()
+
+		requireQuery(t, db, `SELECT a FROM foo`, `{"a": 1} {"a": 2}`)
+
+		sdb := db.NewSession()
+		err := sdb.Exec(`SET work_mem = 1024`)
+		require.NoError(t, err)
+		requireQuery(t, sdb, `SELECT a FROM foo`, `{"a": 1} {"a": 2}`)
+		require.Equal(t, 2, genji.QueryCacheLen(db))
+	})
+
+	t.Run("LRU", func(t *testing.T) {
+		db := setup(t, 2)
+		defer db.Close()
+
+		requireQuery(t, db, `SELECT a FROM foo WHERE a = 1`, `{"a": 1}`)
+		requireQuery(t, db, `SELECT a FROM foo WHERE a = 2`, `{"a": 2}`)
+		requireQuery(t, db, `SELECT a FROM foo WHERE a = 3`, ``)
+		require.Equal(t, 2, genji.QueryCacheLen(db))
+
+		db.SetQueryCacheSize(1)
+		require.Equal(t, 1, genji.QueryCacheLen(db))
+	})
+}
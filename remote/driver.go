@@ -0,0 +1,444 @@
+package remote
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"io"
+
+	"github.com/genjidb/genji/document"
+	"github.com/genjidb/genji/document/encoding/msgpack"
+	"github.com/genjidb/genji/remote/pb"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func init() {
+	sql.Register("genji-remote", sqlDriver{})
+}
+
+var (
+	_ driver.Driver        = (*sqlDriver)(nil)
+	_ driver.DriverContext = (*sqlDriver)(nil)
+)
+
+// sqlDriver is a driver.Driver that connects to a server created with NewServer.
+// It is registered against the database/sql package as "genji-remote", and
+// the name of the database is the address of the server.
+// Connections are insecure, use NewConnector to configure the transport.
+type sqlDriver struct{}
+
+func (d sqlDriver) Open(name string) (driver.Conn, error) {
+	c, err := d.OpenConnector(name)
+	if err != nil {
+		return nil, err
+	}
+
+	return c.Connect(context.Background())
+}
+
+func (d sqlDriver) OpenConnector(name string) (driver.Connector, error) {
+	return NewConnector(name, grpc.WithInsecure())
+}
+
+var (
+	_ driver.Connector = (*connector)(nil)
+	_ io.Closer        = (*connector)(nil)
+)
+
+// NewConnector returns a connector to the server listening at addr, for use with sql.OpenDB.
+// The options configure the gRPC client connection shared by all the connections of the connector.
+func NewConnector(addr string, opts ...grpc.DialOption) (driver.Connector, error) {
+	cc, err := grpc.Dial(addr, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	return &connector{cc: cc, client: pb.NewGenjiClient(cc)}, nil
+}
+
+type connector struct {
+	cc     *grpc.ClientConn
+	client pb.GenjiClient
+}
+
+// Connect returns a new connection to the server.
+func (c *connector) Connect(ctx context.Context) (driver.Conn, error) {
+	return &conn{client: c.client}, nil
+}
+
+func (c *connector) Driver() driver.Driver {
+	return sqlDriver{}
+}
+
+// Close closes the gRPC client connection.
+func (c *connector) Close() error {
+	return c.cc.Close()
+}
+
+// conn represents a connection to the server.
+// It implements the database/sql/driver.Conn interface.
+type conn struct {
+	client pb.GenjiClient
+	tx     *tx
+}
+
+// Prepare returns a prepared statement, bound to this connection.
+func (c *conn) Prepare(q string) (driver.Stmt, error) {
+	return c.PrepareContext(context.Background(), q)
+}
+
+// PrepareContext returns a prepared statement, bound to this connection.
+// Statements are parsed by the server every time they are run.
+func (c *conn) PrepareContext(ctx context.Context, q string) (driver.Stmt, error) {
+	return stmt{c: c, q: q}, nil
+}
+
+// Close rolls back any ongoing transaction.
+func (c *conn) Close() error {
+	if c.tx != nil {
+		return c.Rollback()
+	}
+
+	return nil
+}
+
+// Begin starts and returns a new transaction.
+func (c *conn) Begin() (driver.Tx, error) {
+	return c.BeginTx(context.Background(), driver.TxOptions{})
+}
+
+// BeginTx starts and returns a new transaction, which lasts as long as a stream
+// opened with the server. The transaction is rolled back if ctx is canceled.
+// It uses the ReadOnly option to determine whether to start a read-only or read/write transaction.
+// If the Isolation option is non zero, an error is returned.
+func (c *conn) BeginTx(ctx context.Context, opts driver.TxOptions) (driver.Tx, error) {
+	if opts.Isolation != 0 {
+		return nil, errors.New("isolation levels are not supported")
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	stream, err := c.client.Tx(ctx)
+	if err != nil {
+		cancel()
+		return nil, convertError(err)
+	}
+
+	t := tx{stream: stream, cancel: cancel}
+	err = t.do(&pb.TxRequest{Kind: pb.TxRequest_BEGIN, Writable: !opts.ReadOnly})
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+
+	c.tx = &t
+	return c, nil
+}
+
+func (c *conn) Commit() error {
+	err := c.tx.end(pb.TxRequest_COMMIT)
+	c.tx = nil
+	return err
+}
+
+func (c *conn) Rollback() error {
+	err := c.tx.end(pb.TxRequest_ROLLBACK)
+	c.tx = nil
+	return err
+}
+
+// tx is a transaction run by the server for the duration of a stream.
+type tx struct {
+	stream pb.Genji_TxClient
+	cancel func()
+	// rows are the rows being read from the stream, if any.
+	rows *rows
+}
+
+// send sends a request once the rows of the previous one are read.
+func (t *tx) send(req *pb.TxRequest) error {
+	if t.rows != nil {
+		if err := t.rows.Close(); err != nil {
+			return err
+		}
+	}
+
+	return convertError(t.stream.Send(req))
+}
+
+// recv returns the next response of the current request, or io.EOF after the last one.
+func (t *tx) recv() (*pb.QueryResponse, error) {
+	res, err := t.stream.Recv()
+	if err != nil {
+		return nil, convertError(err)
+	}
+	if res.Done {
+		if res.Error != "" {
+			return nil, errors.New(res.Error)
+		}
+		return nil, io.EOF
+	}
+
+	return res, nil
+}
+
+// do sends a request whose only response is done.
+func (t *tx) do(req *pb.TxRequest) error {
+	err := t.send(req)
+	if err != nil {
+		return err
+	}
+
+	_, err = t.recv()
+	if err == io.EOF {
+		return nil
+	}
+	if err == nil {
+		return errors.New("unexpected response")
+	}
+	return err
+}
+
+// end commits or rolls back the transaction and closes the stream.
+func (t *tx) end(kind pb.TxRequest_Kind) error {
+	defer t.cancel()
+
+	return t.do(&pb.TxRequest{Kind: kind})
+}
+
+// Stmt is a prepared statement. It is bound to a Conn and not
+// used by multiple goroutines concurrently.
+type stmt struct {
+	c *conn
+	q string
+}
+
+// NumInput returns -1, the number of placeholder parameters is not known by the client.
+func (s stmt) NumInput() int { return -1 }
+
+// Exec executes a query that doesn't return rows, such
+// as an INSERT or UPDATE.
+func (s stmt) Exec(args []driver.Value) (driver.Result, error) {
+	return nil, errors.New("not implemented")
+}
+
+// CheckNamedValue has the same behaviour as driver.DefaultParameterConverter, except that
+// it allows document.Document to be passed as parameters.
+// It implements the driver.NamedValueChecker interface.
+func (s stmt) CheckNamedValue(nv *driver.NamedValue) error {
+	if _, ok := nv.Value.(document.Document); ok {
+		return nil
+	}
+
+	val, err := driver.DefaultParameterConverter.ConvertValue(nv.Value)
+	if err == nil {
+		nv.Value = val
+	}
+
+	return nil
+}
+
+// ExecContext executes a query that doesn't return rows, such
+// as an INSERT or UPDATE.
+func (s stmt) ExecContext(ctx context.Context, args []driver.NamedValue) (driver.Result, error) {
+	req, err := newQueryRequest(s.q, args)
+	if err != nil {
+		return nil, err
+	}
+
+	if s.c.tx != nil {
+		err = s.c.tx.do(&pb.TxRequest{Kind: pb.TxRequest_EXEC, Query: req})
+	} else {
+		_, err = s.c.client.Exec(ctx, req)
+		err = convertError(err)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return result{}, nil
+}
+
+type result struct{}
+
+// LastInsertId is not supported and returns an error.
+func (r result) LastInsertId() (int64, error) {
+	return 0, errors.New("not supported")
+}
+
+// RowsAffected is not supported and returns an error.
+func (r result) RowsAffected() (int64, error) {
+	return 0, errors.New("not supported")
+}
+
+func (s stmt) Query(args []driver.Value) (driver.Rows, error) {
+	return nil, errors.New("not implemented")
+}
+
+// QueryContext executes a query that may return rows, such as a
+// SELECT.
+func (s stmt) QueryContext(ctx context.Context, args []driver.NamedValue) (driver.Rows, error) {
+	req, err := newQueryRequest(s.q, args)
+	if err != nil {
+		return nil, err
+	}
+
+	var rs *rows
+	if t := s.c.tx; t != nil {
+		err = t.send(&pb.TxRequest{Kind: pb.TxRequest_QUERY, Query: req})
+		if err != nil {
+			return nil, err
+		}
+
+		rs = &rows{recv: t.recv, drain: true}
+		t.rows = rs
+		rs.close = func() {
+			t.rows = nil
+		}
+	} else {
+		ctx, cancel := context.WithCancel(ctx)
+		stream, err := s.c.client.Query(ctx, req)
+		if err != nil {
+			cancel()
+			return nil, convertError(err)
+		}
+
+		rs = &rows{recv: func() (*pb.QueryResponse, error) {
+			res, err := stream.Recv()
+			return res, convertError(err)
+		}, close: cancel}
+	}
+
+	// the first response holds the fields
+	res, err := rs.recv()
+	if err == io.EOF {
+		err = errors.New("unexpected end of results")
+	}
+	if err != nil {
+		rs.close()
+		return nil, err
+	}
+	rs.fields = res.Fields
+
+	return rs, nil
+}
+
+// Close does nothing.
+func (s stmt) Close() error {
+	return nil
+}
+
+// newQueryRequest encodes the parameters of the query.
+func newQueryRequest(q string, args []driver.NamedValue) (*pb.QueryRequest, error) {
+	req := pb.QueryRequest{Query: q}
+	if len(args) == 0 {
+		return &req, nil
+	}
+
+	vb := document.NewValueBuffer()
+	for _, arg := range args {
+		v, err := document.NewValue(arg.Value)
+		if err != nil {
+			return nil, err
+		}
+		vb.Append(v)
+		req.ParamNames = append(req.ParamNames, arg.Name)
+	}
+
+	var buf bytes.Buffer
+	enc := msgpack.NewEncoder(&buf)
+	defer enc.Close()
+
+	err := enc.EncodeArray(vb)
+	if err != nil {
+		return nil, err
+	}
+	req.Params = buf.Bytes()
+
+	return &req, nil
+}
+
+// rows reads the documents streamed by the server.
+type rows struct {
+	recv  func() (*pb.QueryResponse, error)
+	close func()
+	// drain is set if the remaining documents must be read on Close,
+	// because the stream is shared with the next requests.
+	drain  bool
+	fields []string
+	done   bool
+}
+
+// Columns returns the fields selected by the SELECT statement.
+func (rs *rows) Columns() []string {
+	return rs.fields
+}
+
+// Close closes the rows iterator.
+func (rs *rows) Close() error {
+	defer rs.close()
+
+	for rs.drain && !rs.done {
+		_, err := rs.recv()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			rs.done = true
+			return err
+		}
+	}
+	rs.done = true
+
+	return nil
+}
+
+func (rs *rows) Next(dest []driver.Value) error {
+	if rs.done {
+		return io.EOF
+	}
+
+	res, err := rs.recv()
+	if err != nil {
+		rs.done = true
+		return err
+	}
+
+	d := msgpack.NewEncodedDocument(res.Document)
+	for i := range rs.fields {
+		if rs.fields[i] == "*" {
+			dest[i] = d
+			continue
+		}
+
+		v, err := d.GetByField(rs.fields[i])
+		if err == document.ErrFieldNotFound {
+			// missing fields are returned as NULL
+			dest[i] = nil
+			continue
+		}
+		if err != nil {
+			return err
+		}
+
+		dest[i] = v.V
+	}
+
+	return nil
+}
+
+// convertError turns the errors returned by the server into plain errors.
+func convertError(err error) error {
+	if err == nil || err == io.EOF {
+		return err
+	}
+
+	if s, ok := status.FromError(err); ok && s.Code() == codes.Unknown {
+		return errors.New(s.Message())
+	}
+
+	return err
+}
@@ -0,0 +1,27 @@
+module github.com/genjidb/genji/remote
+
+go 1.22
+
+require (
+	github.com/genjidb/genji v0.13.0
+	github.com/stretchr/testify v1.8.4
+	google.golang.org/grpc v1.64.1
+	google.golang.org/protobuf v1.36.6
+)
+
+require (
+	github.com/buger/jsonparser v1.1.1 // indirect
+	github.com/davecgh/go-spew v1.1.1 // indirect
+	github.com/google/btree v1.0.1 // indirect
+	github.com/pmezard/go-difflib v1.0.0 // indirect
+	github.com/vmihailenco/msgpack/v5 v5.3.2 // indirect
+	github.com/vmihailenco/tagparser/v2 v2.0.0 // indirect
+	go.etcd.io/bbolt v1.3.5 // indirect
+	golang.org/x/net v0.26.0 // indirect
+	golang.org/x/sys v0.21.0 // indirect
+	golang.org/x/text v0.16.0 // indirect
+	google.golang.org/genproto/googleapis/rpc v0.0.0-20240318140521-94a12d6c2237 // indirect
+	gopkg.in/yaml.v3 v3.0.1 // indirect
+)
+
+replace github.com/genjidb/genji v0.13.0 => ../
@@ -0,0 +1,404 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.6
+// 	protoc        (unknown)
+// source: genji.proto
+
+package pb
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type TxRequest_Kind int32
+
+const (
+	TxRequest_BEGIN    TxRequest_Kind = 0
+	TxRequest_QUERY    TxRequest_Kind = 1
+	TxRequest_EXEC     TxRequest_Kind = 2
+	TxRequest_COMMIT   TxRequest_Kind = 3
+	TxRequest_ROLLBACK TxRequest_Kind = 4
+)
+
+// Enum value maps for TxRequest_Kind.
+var (
+	TxRequest_Kind_name = map[int32]string{
+		0: "BEGIN",
+		1: "QUERY",
+		2: "EXEC",
+		3: "COMMIT",
+		4: "ROLLBACK",
+	}
+	TxRequest_Kind_value = map[string]int32{
+		"BEGIN":    0,
+		"QUERY":    1,
+		"EXEC":     2,
+		"COMMIT":   3,
+		"ROLLBACK": 4,
+	}
+)
+
+func (x TxRequest_Kind) Enum() *TxRequest_Kind {
+	p := new(TxRequest_Kind)
+	*p = x
+	return p
+}
+
+func (x TxRequest_Kind) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (TxRequest_Kind) Descriptor() protoreflect.EnumDescriptor {
+	return file_genji_proto_enumTypes[0].Descriptor()
+}
+
+func (TxRequest_Kind) Type() protoreflect.EnumType {
+	return &file_genji_proto_enumTypes[0]
+}
+
+func (x TxRequest_Kind) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use TxRequest_Kind.Descriptor instead.
+func (TxRequest_Kind) EnumDescriptor() ([]byte, []int) {
+	return file_genji_proto_rawDescGZIP(), []int{3, 0}
+}
+
+type QueryRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	Query string                 `protobuf:"bytes,1,opt,name=query,proto3" json:"query,omitempty"`
+	// params is the msgpack encoded array of the parameters of the query.
+	Params []byte `protobuf:"bytes,2,opt,name=params,proto3" json:"params,omitempty"`
+	// param_names holds the names of the parameters, empty for positional ones.
+	ParamNames    []string `protobuf:"bytes,3,rep,name=param_names,json=paramNames,proto3" json:"param_names,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *QueryRequest) Reset() {
+	*x = QueryRequest{}
+	mi := &file_genji_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *QueryRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*QueryRequest) ProtoMessage() {}
+
+func (x *QueryRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_genji_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use QueryRequest.ProtoReflect.Descriptor instead.
+func (*QueryRequest) Descriptor() ([]byte, []int) {
+	return file_genji_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *QueryRequest) GetQuery() string {
+	if x != nil {
+		return x.Query
+	}
+	return ""
+}
+
+func (x *QueryRequest) GetParams() []byte {
+	if x != nil {
+		return x.Params
+	}
+	return nil
+}
+
+func (x *QueryRequest) GetParamNames() []string {
+	if x != nil {
+		return x.ParamNames
+	}
+	return nil
+}
+
+type QueryResponse struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// fields is set by the first response of a query, and holds the names of the
+	// fields returned by the query, if they are known.
+	Fields []string `protobuf:"bytes,1,rep,name=fields,proto3" json:"fields,omitempty"`
+	// document is the msgpack encoded document returned by the query.
+	Document []byte `protobuf:"bytes,2,opt,name=document,proto3" json:"document,omitempty"`
+	// done is set by the last response to a request of a Tx stream.
+	Done bool `protobuf:"varint,3,opt,name=done,proto3" json:"done,omitempty"`
+	// error is set by the last response to a request of a Tx stream, if it failed.
+	Error         string `protobuf:"bytes,4,opt,name=error,proto3" json:"error,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *QueryResponse) Reset() {
+	*x = QueryResponse{}
+	mi := &file_genji_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *QueryResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*QueryResponse) ProtoMessage() {}
+
+func (x *QueryResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_genji_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use QueryResponse.ProtoReflect.Descriptor instead.
+func (*QueryResponse) Descriptor() ([]byte, []int) {
+	return file_genji_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *QueryResponse) GetFields() []string {
+	if x != nil {
+		return x.Fields
+	}
+	return nil
+}
+
+func (x *QueryResponse) GetDocument() []byte {
+	if x != nil {
+		return x.Document
+	}
+	return nil
+}
+
+func (x *QueryResponse) GetDone() bool {
+	if x != nil {
+		return x.Done
+	}
+	return false
+}
+
+func (x *QueryResponse) GetError() string {
+	if x != nil {
+		return x.Error
+	}
+	return ""
+}
+
+type ExecResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ExecResponse) Reset() {
+	*x = ExecResponse{}
+	mi := &file_genji_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ExecResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ExecResponse) ProtoMessage() {}
+
+func (x *ExecResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_genji_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ExecResponse.ProtoReflect.Descriptor instead.
+func (*ExecResponse) Descriptor() ([]byte, []int) {
+	return file_genji_proto_rawDescGZIP(), []int{2}
+}
+
+type TxRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	Kind  TxRequest_Kind         `protobuf:"varint,1,opt,name=kind,proto3,enum=genji.remote.TxRequest_Kind" json:"kind,omitempty"`
+	// writable is used by BEGIN requests.
+	Writable bool `protobuf:"varint,2,opt,name=writable,proto3" json:"writable,omitempty"`
+	// query is used by QUERY and EXEC requests.
+	Query         *QueryRequest `protobuf:"bytes,3,opt,name=query,proto3" json:"query,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *TxRequest) Reset() {
+	*x = TxRequest{}
+	mi := &file_genji_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *TxRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*TxRequest) ProtoMessage() {}
+
+func (x *TxRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_genji_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use TxRequest.ProtoReflect.Descriptor instead.
+func (*TxRequest) Descriptor() ([]byte, []int) {
+	return file_genji_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *TxRequest) GetKind() TxRequest_Kind {
+	if x != nil {
+		return x.Kind
+	}
+	return TxRequest_BEGIN
+}
+
+func (x *TxRequest) GetWritable() bool {
+	if x != nil {
+		return x.Writable
+	}
+	return false
+}
+
+func (x *TxRequest) GetQuery() *QueryRequest {
+	if x != nil {
+		return x.Query
+	}
+	return nil
+}
+
+var File_genji_proto protoreflect.FileDescriptor
+
+const file_genji_proto_rawDesc = "" +
+	"\n" +
+	"\vgenji.proto\x12\fgenji.remote\"]\n" +
+	"\fQueryRequest\x12\x14\n" +
+	"\x05query\x18\x01 \x01(\tR\x05query\x12\x16\n" +
+	"\x06params\x18\x02 \x01(\fR\x06params\x12\x1f\n" +
+	"\vparam_names\x18\x03 \x03(\tR\n" +
+	"paramNames\"m\n" +
+	"\rQueryResponse\x12\x16\n" +
+	"\x06fields\x18\x01 \x03(\tR\x06fields\x12\x1a\n" +
+	"\bdocument\x18\x02 \x01(\fR\bdocument\x12\x12\n" +
+	"\x04done\x18\x03 \x01(\bR\x04done\x12\x14\n" +
+	"\x05error\x18\x04 \x01(\tR\x05error\"\x0e\n" +
+	"\fExecResponse\"\xcd\x01\n" +
+	"\tTxRequest\x120\n" +
+	"\x04kind\x18\x01 \x01(\x0e2\x1c.genji.remote.TxRequest.KindR\x04kind\x12\x1a\n" +
+	"\bwritable\x18\x02 \x01(\bR\bwritable\x120\n" +
+	"\x05query\x18\x03 \x01(\v2\x1a.genji.remote.QueryRequestR\x05query\"@\n" +
+	"\x04Kind\x12\t\n" +
+	"\x05BEGIN\x10\x00\x12\t\n" +
+	"\x05QUERY\x10\x01\x12\b\n" +
+	"\x04EXEC\x10\x02\x12\n" +
+	"\n" +
+	"\x06COMMIT\x10\x03\x12\f\n" +
+	"\bROLLBACK\x10\x042\xcb\x01\n" +
+	"\x05Genji\x12B\n" +
+	"\x05Query\x12\x1a.genji.remote.QueryRequest\x1a\x1b.genji.remote.QueryResponse0\x01\x12>\n" +
+	"\x04Exec\x12\x1a.genji.remote.QueryRequest\x1a\x1a.genji.remote.ExecResponse\x12>\n" +
+	"\x02Tx\x12\x17.genji.remote.TxRequest\x1a\x1b.genji.remote.QueryResponse(\x010\x01B$Z\"github.com/genjidb/genji/remote/pbb\x06proto3"
+
+var (
+	file_genji_proto_rawDescOnce sync.Once
+	file_genji_proto_rawDescData []byte
+)
+
+func file_genji_proto_rawDescGZIP() []byte {
+	file_genji_proto_rawDescOnce.Do(func() {
+		file_genji_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_genji_proto_rawDesc), len(file_genji_proto_rawDesc)))
+	})
+	return file_genji_proto_rawDescData
+}
+
+var file_genji_proto_enumTypes = make([]protoimpl.EnumInfo, 1)
+var file_genji_proto_msgTypes = make([]protoimpl.MessageInfo, 4)
+var file_genji_proto_goTypes = []any{
+	(TxRequest_Kind)(0),   // 0: genji.remote.TxRequest.Kind
+	(*QueryRequest)(nil),  // 1: genji.remote.QueryRequest
+	(*QueryResponse)(nil), // 2: genji.remote.QueryResponse
+	(*ExecResponse)(nil),  // 3: genji.remote.ExecResponse
+	(*TxRequest)(nil),     // 4: genji.remote.TxRequest
+}
+var file_genji_proto_depIdxs = []int32{
+	0, // 0: genji.remote.TxRequest.kind:type_name -> genji.remote.TxRequest.Kind
+	1, // 1: genji.remote.TxRequest.query:type_name -> genji.remote.QueryRequest
+	1, // 2: genji.remote.Genji.Query:input_type -> genji.remote.QueryRequest
+	1, // 3: genji.remote.Genji.Exec:input_type -> genji.remote.QueryRequest
+	4, // 4: genji.remote.Genji.Tx:input_type -> genji.remote.TxRequest
+	2, // 5: genji.remote.Genji.Query:output_type -> genji.remote.QueryResponse
+	3, // 6: genji.remote.Genji.Exec:output_type -> genji.remote.ExecResponse
+	2, // 7: genji.remote.Genji.Tx:output_type -> genji.remote.QueryResponse
+	5, // [5:8] is the sub-list for method output_type
+	2, // [2:5] is the sub-list for method input_type
+	2, // [2:2] is the sub-list for extension type_name
+	2, // [2:2] is the sub-list for extension extendee
+	0, // [0:2] is the sub-list for field type_name
+}
+
+func init() { file_genji_proto_init() }
+func file_genji_proto_init() {
+	if File_genji_proto != nil {
+		return
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_genji_proto_rawDesc), len(file_genji_proto_rawDesc)),
+			NumEnums:      1,
+			NumMessages:   4,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_genji_proto_goTypes,
+		DependencyIndexes: file_genji_proto_depIdxs,
+		EnumInfos:         file_genji_proto_enumTypes,
+		MessageInfos:      file_genji_proto_msgTypes,
+	}.Build()
+	File_genji_proto = out.File
+	file_genji_proto_goTypes = nil
+	file_genji_proto_depIdxs = nil
+}
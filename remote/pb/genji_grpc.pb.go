@@ -0,0 +1,257 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.3.0
+// - protoc             (unknown)
+// source: genji.proto
+
+package pb
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.32.0 or later.
+const _ = grpc.SupportPackageIsVersion7
+
+const (
+	Genji_Query_FullMethodName = "/genji.remote.Genji/Query"
+	Genji_Exec_FullMethodName  = "/genji.remote.Genji/Exec"
+	Genji_Tx_FullMethodName    = "/genji.remote.Genji/Tx"
+)
+
+// GenjiClient is the client API for Genji service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type GenjiClient interface {
+	// Query runs the statements of the request, in their own transaction,
+	// and streams the documents returned by the last one.
+	Query(ctx context.Context, in *QueryRequest, opts ...grpc.CallOption) (Genji_QueryClient, error)
+	// Exec runs the statements of the request, in their own transaction.
+	Exec(ctx context.Context, in *QueryRequest, opts ...grpc.CallOption) (*ExecResponse, error)
+	// Tx runs the statements of the requests in a single transaction.
+	// The first request must begin the transaction, which is rolled back
+	// if the stream ends before it is committed.
+	// Each request is answered by one or more responses, the last one being done.
+	Tx(ctx context.Context, opts ...grpc.CallOption) (Genji_TxClient, error)
+}
+
+type genjiClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewGenjiClient(cc grpc.ClientConnInterface) GenjiClient {
+	return &genjiClient{cc}
+}
+
+func (c *genjiClient) Query(ctx context.Context, in *QueryRequest, opts ...grpc.CallOption) (Genji_QueryClient, error) {
+	stream, err := c.cc.NewStream(ctx, &Genji_ServiceDesc.Streams[0], Genji_Query_FullMethodName, opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &genjiQueryClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type Genji_QueryClient interface {
+	Recv() (*QueryResponse, error)
+	grpc.ClientStream
+}
+
+type genjiQueryClient struct {
+	grpc.ClientStream
+}
+
+func (x *genjiQueryClient) Recv() (*QueryResponse, error) {
+	m := new(QueryResponse)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *genjiClient) Exec(ctx context.Context, in *QueryRequest, opts ...grpc.CallOption) (*ExecResponse, error) {
+	out := new(ExecResponse)
+	err := c.cc.Invoke(ctx, Genji_Exec_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *genjiClient) Tx(ctx context.Context, opts ...grpc.CallOption) (Genji_TxClient, error) {
+	stream, err := c.cc.NewStream(ctx, &Genji_ServiceDesc.Streams[1], Genji_Tx_FullMethodName, opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &genjiTxClient{stream}
+	return x, nil
+}
+
+type Genji_TxClient interface {
+	Send(*TxRequest) error
+	Recv() (*QueryResponse, error)
+	grpc.ClientStream
+}
+
+type genjiTxClient struct {
+	grpc.ClientStream
+}
+
+func (x *genjiTxClient) Send(m *TxRequest) error {
+	return x.ClientStream.SendMsg(m)
+}
+
+func (x *genjiTxClient) Recv() (*QueryResponse, error) {
+	m := new(QueryResponse)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// GenjiServer is the server API for Genji service.
+// All implementations must embed UnimplementedGenjiServer
+// for forward compatibility
+type GenjiServer interface {
+	// Query runs the statements of the request, in their own transaction,
+	// and streams the documents returned by the last one.
+	Query(*QueryRequest, Genji_QueryServer) error
+	// Exec runs the statements of the request, in their own transaction.
+	Exec(context.Context, *QueryRequest) (*ExecResponse, error)
+	// Tx runs the statements of the requests in a single transaction.
+	// The first request must begin the transaction, which is rolled back
+	// if the stream ends before it is committed.
+	// Each request is answered by one or more responses, the last one being done.
+	Tx(Genji_TxServer) error
+	mustEmbedUnimplementedGenjiServer()
+}
+
+// UnimplementedGenjiServer must be embedded to have forward compatible implementations.
+type UnimplementedGenjiServer struct {
+}
+
+func (UnimplementedGenjiServer) Query(*QueryRequest, Genji_QueryServer) error {
+	return status.Errorf(codes.Unimplemented, "method Query not implemented")
+}
+func (UnimplementedGenjiServer) Exec(context.Context, *QueryRequest) (*ExecResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Exec not implemented")
+}
+func (UnimplementedGenjiServer) Tx(Genji_TxServer) error {
+	return status.Errorf(codes.Unimplemented, "method Tx not implemented")
+}
+func (UnimplementedGenjiServer) mustEmbedUnimplementedGenjiServer() {}
+
+// UnsafeGenjiServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to GenjiServer will
+// result in compilation errors.
+type UnsafeGenjiServer interface {
+	mustEmbedUnimplementedGenjiServer()
+}
+
+func RegisterGenjiServer(s grpc.ServiceRegistrar, srv GenjiServer) {
+	s.RegisterService(&Genji_ServiceDesc, srv)
+}
+
+func _Genji_Query_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(QueryRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(GenjiServer).Query(m, &genjiQueryServer{stream})
+}
+
+type Genji_QueryServer interface {
+	Send(*QueryResponse) error
+	grpc.ServerStream
+}
+
+type genjiQueryServer struct {
+	grpc.ServerStream
+}
+
+func (x *genjiQueryServer) Send(m *QueryResponse) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func _Genji_Exec_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(QueryRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(GenjiServer).Exec(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Genji_Exec_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(GenjiServer).Exec(ctx, req.(*QueryRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Genji_Tx_Handler(srv interface{}, stream grpc.ServerStream) error {
+	return srv.(GenjiServer).Tx(&genjiTxServer{stream})
+}
+
+type Genji_TxServer interface {
+	Send(*QueryResponse) error
+	Recv() (*TxRequest, error)
+	grpc.ServerStream
+}
+
+type genjiTxServer struct {
+	grpc.ServerStream
+}
+
+func (x *genjiTxServer) Send(m *QueryResponse) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func (x *genjiTxServer) Recv() (*TxRequest, error) {
+	m := new(TxRequest)
+	if err := x.ServerStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// Genji_ServiceDesc is the grpc.ServiceDesc for Genji service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var Genji_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "genji.remote.Genji",
+	HandlerType: (*GenjiServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "Exec",
+			Handler:    _Genji_Exec_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "Query",
+			Handler:       _Genji_Query_Handler,
+			ServerStreams: true,
+		},
+		{
+			StreamName:    "Tx",
+			Handler:       _Genji_Tx_Handler,
+			ServerStreams: true,
+			ClientStreams: true,
+		},
+	},
+	Metadata: "genji.proto",
+}
@@ -0,0 +1,131 @@
+package remote_test
+
+import (
+	"context"
+	"database/sql"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/genjidb/genji"
+	"github.com/genjidb/genji/document"
+	"github.com/genjidb/genji/driver"
+	"github.com/genjidb/genji/remote"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRemote(t *testing.T) {
+	gdb, err := genji.Open(":memory:")
+	require.NoError(t, err)
+	defer gdb.Close()
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	srv := remote.NewServer(gdb)
+	go srv.Serve(l)
+	defer srv.Stop()
+
+	db, err := sql.Open("genji-remote", l.Addr().String())
+	require.NoError(t, err)
+	defer db.Close()
+
+	_, err = db.Exec("CREATE TABLE foo (a INT PRIMARY KEY, b TEXT, c TIMESTAMP)")
+	require.NoError(t, err)
+
+	ts := time.Date(2021, 1, 2, 3, 4, 5, 0, time.UTC)
+	_, err = db.Exec("INSERT INTO foo (a, b, c) VALUES (?, ?, ?)", 1, "x", ts)
+	require.NoError(t, err)
+	_, err = db.Exec("INSERT INTO foo (a, b) SELECT $a, 'y'", sql.Named("a", 2))
+	require.NoError(t, err)
+
+	// the values keep their types
+	var a int
+	var b string
+	var c time.Time
+	err = db.QueryRow("SELECT a, b, c FROM foo WHERE a = ?", 1).Scan(&a, &b, &c)
+	require.NoError(t, err)
+	require.Equal(t, 1, a)
+	require.Equal(t, "x", b)
+	require.True(t, ts.Equal(c))
+
+	// documents can be scanned using the scanner of the embedded driver
+	rows, err := db.Query("SELECT * FROM foo ORDER BY a")
+	require.NoError(t, err)
+	cols, err := rows.Columns()
+	require.NoError(t, err)
+	require.Equal(t, []string{"*"}, cols)
+	type foo struct {
+		A int
+		B string
+	}
+	var docs []foo
+	for rows.Next() {
+		var f foo
+		require.NoError(t, rows.Scan(driver.Scanner(&f)))
+		docs = append(docs, f)
+	}
+	require.NoError(t, rows.Err())
+	require.NoError(t, rows.Close())
+	require.Len(t, docs, 2)
+	require.Equal(t, []foo{{1, "x"}, {2, "y"}}, docs)
+
+	// errors are returned by the server
+	_, err = db.Exec("INSERT INTO foo (a) VALUES (1)")
+	require.EqualError(t, err, "duplicate document")
+	_, err = db.Query("SELECT * FROM bar")
+	require.Error(t, err)
+
+	count := func(q queryer) int {
+		var n int
+		err := q.QueryRow("SELECT COUNT(*) FROM foo").Scan(&n)
+		require.NoError(t, err)
+		return n
+	}
+
+	// transactions
+	tx, err := db.Begin()
+	require.NoError(t, err)
+	_, err = tx.Exec("INSERT INTO foo (a) VALUES (3)")
+	require.NoError(t, err)
+	// rows left open are closed by the next statement
+	_, err = tx.Query("SELECT * FROM foo")
+	require.NoError(t, err)
+	require.Equal(t, 3, count(tx))
+	_, err = tx.Exec("INSERT INTO foo (a) VALUES (1)")
+	require.EqualError(t, err, "duplicate document")
+	require.NoError(t, tx.Rollback())
+	require.Equal(t, 2, count(db))
+
+	tx, err = db.Begin()
+	require.NoError(t, err)
+	_, err = tx.Exec("INSERT INTO foo (a) VALUES (3)")
+	require.NoError(t, err)
+	require.NoError(t, tx.Commit())
+	require.Equal(t, 3, count(db))
+
+	// read-only transactions
+	tx, err = db.BeginTx(context.Background(), &sql.TxOptions{ReadOnly: true})
+	require.NoError(t, err)
+	_, err = tx.Exec("INSERT INTO foo (a) VALUES (4)")
+	require.Error(t, err)
+	require.NoError(t, tx.Rollback())
+
+	// transactions are rolled back when the stream ends
+	ctx, cancel := context.WithCancel(context.Background())
+	tx, err = db.BeginTx(ctx, nil)
+	require.NoError(t, err)
+	_, err = tx.Exec("INSERT INTO foo (a) VALUES (4)")
+	require.NoError(t, err)
+	cancel()
+	require.Eventually(t, func() bool {
+		d, err := gdb.QueryDocument("SELECT COUNT(*) FROM foo")
+		require.NoError(t, err)
+		var n int
+		require.NoError(t, document.Scan(d, &n))
+		return n == 3
+	}, time.Second, 10*time.Millisecond)
+}
+
+type queryer interface {
+	QueryRow(q string, args ...interface{}) *sql.Row
+}
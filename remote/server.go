@@ -0,0 +1,225 @@
+// Package remote gives access to a Genji database over the network, using gRPC.
+// A process serves its database with NewServer, and other processes, on the same host
+// or over the network, access it with the "genji-remote" database/sql driver:
+//
+//	db, err := sql.Open("genji-remote", "localhost:8080")
+//
+// The service is defined in pb/genji.proto.
+package remote
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"io"
+
+	"github.com/genjidb/genji"
+	"github.com/genjidb/genji/document"
+	"github.com/genjidb/genji/document/encoding/msgpack"
+	"github.com/genjidb/genji/remote/pb"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// NewServer returns a gRPC server giving access to db.
+// Statements run by the server are not bound to any session: settings changed
+// with the SET statement only last for the request that changed them.
+func NewServer(db *genji.DB, opts ...grpc.ServerOption) *grpc.Server {
+	s := grpc.NewServer(opts...)
+	pb.RegisterGenjiServer(s, &server{db: db})
+	return s
+}
+
+type server struct {
+	pb.UnimplementedGenjiServer
+
+	db *genji.DB
+}
+
+// Query runs the query and streams the documents it returns.
+// The first response holds the fields returned by the query.
+func (s *server) Query(req *pb.QueryRequest, stream pb.Genji_QueryServer) error {
+	params, err := decodeParams(req)
+	if err != nil {
+		return err
+	}
+
+	res, err := s.db.WithContext(stream.Context()).Query(req.Query, params...)
+	if err != nil {
+		return err
+	}
+
+	qerr, err := sendResult(stream, res)
+	if err != nil {
+		return err
+	}
+	return qerr
+}
+
+// Exec runs the query.
+func (s *server) Exec(ctx context.Context, req *pb.QueryRequest) (*pb.ExecResponse, error) {
+	params, err := decodeParams(req)
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.db.WithContext(ctx).Exec(req.Query, params...)
+	if err != nil {
+		return nil, err
+	}
+
+	return &pb.ExecResponse{}, nil
+}
+
+// Tx runs the requests of the stream in a transaction. The transaction is
+// rolled back if the stream ends before it is committed.
+func (s *server) Tx(stream pb.Genji_TxServer) error {
+	req, err := stream.Recv()
+	if err != nil {
+		return err
+	}
+	if req.Kind != pb.TxRequest_BEGIN {
+		return status.Error(codes.FailedPrecondition, "transaction not begun")
+	}
+
+	tx, err := s.db.WithContext(stream.Context()).Begin(req.Writable)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	err = stream.Send(&pb.QueryResponse{Done: true})
+	if err != nil {
+		return err
+	}
+
+	for {
+		req, err := stream.Recv()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		// errors returned by statements are sent to the client,
+		// the other ones end the stream
+		var qerr error
+		switch req.Kind {
+		case pb.TxRequest_QUERY:
+			qerr, err = s.txQuery(stream, tx, req.Query)
+			if err != nil {
+				return err
+			}
+		case pb.TxRequest_EXEC:
+			var params []interface{}
+			params, qerr = decodeParams(req.Query)
+			if qerr == nil {
+				qerr = tx.Exec(req.Query.Query, params...)
+			}
+		case pb.TxRequest_COMMIT:
+			qerr = tx.Commit()
+		case pb.TxRequest_ROLLBACK:
+			qerr = tx.Rollback()
+		default:
+			return status.Error(codes.FailedPrecondition, "transaction already begun")
+		}
+
+		err = stream.Send(&pb.QueryResponse{Done: true, Error: errorString(qerr)})
+		if err != nil {
+			return err
+		}
+
+		if req.Kind == pb.TxRequest_COMMIT || req.Kind == pb.TxRequest_ROLLBACK {
+			return nil
+		}
+	}
+}
+
+func (s *server) txQuery(stream pb.Genji_TxServer, tx *genji.Tx, req *pb.QueryRequest) (qerr, err error) {
+	params, err := decodeParams(req)
+	if err != nil {
+		return err, nil
+	}
+
+	res, err := tx.Query(req.Query, params...)
+	if err != nil {
+		return err, nil
+	}
+
+	return sendResult(stream, res)
+}
+
+// sendResult sends the fields of the result, then its documents, and closes it.
+// It returns the error returned by the result separately from the one returned
+// by the stream.
+func sendResult(stream interface {
+	Send(*pb.QueryResponse) error
+}, res *genji.Result) (qerr, err error) {
+	defer func() {
+		if cerr := res.Close(); qerr == nil {
+			qerr = cerr
+		}
+	}()
+
+	err = stream.Send(&pb.QueryResponse{Fields: res.Fields()})
+	if err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	qerr = res.Iterate(func(d document.Document) error {
+		buf.Reset()
+		enc := msgpack.NewEncoder(&buf)
+		defer enc.Close()
+
+		if err := enc.EncodeDocument(d); err != nil {
+			return err
+		}
+
+		// the stream copies the message before returning
+		err = stream.Send(&pb.QueryResponse{Document: buf.Bytes()})
+		return err
+	})
+
+	return qerr, err
+}
+
+// decodeParams decodes the parameters of the request.
+func decodeParams(req *pb.QueryRequest) ([]interface{}, error) {
+	if len(req.Params) == 0 {
+		return nil, nil
+	}
+
+	dec := msgpack.NewDecoder(bytes.NewReader(req.Params))
+	defer dec.Close()
+
+	a, err := dec.DecodeArray()
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	var params []interface{}
+	err = a.Iterate(func(i int, v document.Value) error {
+		if i < len(req.ParamNames) && req.ParamNames[i] != "" {
+			params = append(params, sql.Named(req.ParamNames[i], v))
+		} else {
+			params = append(params, v)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	return params, nil
+}
+
+func errorString(err error) string {
+	if err == nil {
+		return ""
+	}
+
+	return err.Error()
+}
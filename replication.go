@@ -0,0 +1,322 @@
+package genji
+
+import (
+	"bufio"
+	"context"
+	"encoding/gob"
+	"errors"
+	"io"
+	"io/ioutil"
+	"net"
+	"sync"
+	"sync/atomic"
+
+	"github.com/genjidb/genji/engine"
+	"github.com/genjidb/genji/engine/memoryengine"
+	"github.com/genjidb/genji/internal/database"
+)
+
+// defaultMaxReplicaLag is the size of the transactions that can be waiting
+// to be sent to a replica before it is disconnected.
+const defaultMaxReplicaLag = 64 << 20
+
+// SetMaxReplicaLag sets the maximum size, in bytes, of the transactions waiting
+// to be sent to a replica served by ServeReplicas. Replicas that don't keep up
+// with the primary, because they stopped reading or because of a slow network,
+// are disconnected once they lag behind by more than n bytes, instead of
+// holding the transactions in memory indefinitely.
+// The size of a transaction is the size of the keys and values it writes.
+// The limit is 64MiB by default and applies to replicas connected afterwards.
+// Zero or a negative size removes the limit.
+func (db *DB) SetMaxReplicaLag(n int) {
+	atomic.StoreInt64(db.maxReplicaLag, int64(n))
+}
+
+// replicationMessage is sent by the primary to its replicas.
+// The primary first sends the snapshot of the database, in one or more messages,
+// followed by an empty message. Then it sends one message per committed transaction.
+type replicationMessage struct {
+	Writes   []database.Write
+	Snapshot bool
+}
+
+// ServeReplicas accepts connections from the replicas opened with OpenReplica
+// on l, and streams them the transactions committed to db.
+// A new replica first receives a copy of the whole database, during which
+// db can be read but not written to.
+// Transactions are then sent asynchronously: db doesn't wait for its replicas
+// to apply a transaction to commit the next one. Replicas that lag too far
+// behind are disconnected, see SetMaxReplicaLag.
+// ServeReplicas blocks until l is closed, and closes the connections to the
+// replicas before returning the error returned by l.Accept.
+func (db *DB) ServeReplicas(l net.Listener) error {
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	conns := make(map[net.Conn]struct{})
+
+	defer func() {
+		mu.Lock()
+		for conn := range conns {
+			conn.Close()
+		}
+		mu.Unlock()
+		wg.Wait()
+	}()
+
+	for {
+		conn, err := l.Accept()
+		if err != nil {
+			return err
+		}
+
+		mu.Lock()
+		conns[conn] = struct{}{}
+		mu.Unlock()
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer func() {
+				mu.Lock()
+				delete(conns, conn)
+				mu.Unlock()
+			}()
+
+			_ = db.serveReplica(conn)
+		}()
+	}
+}
+
+// serveReplica sends the snapshot of the database to the replica, then the
+// committed transactions, until the connection is closed or the replica
+// lags too far behind.
+func (db *DB) serveReplica(conn net.Conn) error {
+	defer conn.Close()
+
+	w := bufio.NewWriter(conn)
+	enc := gob.NewEncoder(w)
+	send := func(msg *replicationMessage) error {
+		err := enc.Encode(msg)
+		if err != nil {
+			return err
+		}
+		return w.Flush()
+	}
+
+	// closing the connection unblocks send if the replica stopped reading
+	q := newWriteQueue(atomic.LoadInt64(db.maxReplicaLag), func() { conn.Close() })
+	cancel, err := db.db.Replicate(func(writes []database.Write) error {
+		return send(&replicationMessage{Writes: writes, Snapshot: true})
+	}, q.push)
+	if err != nil {
+		return err
+	}
+	defer cancel()
+
+	err = send(&replicationMessage{})
+	if err != nil {
+		return err
+	}
+
+	// replicas never send anything, stop once they close the connection
+	go func() {
+		_, _ = io.Copy(ioutil.Discard, conn)
+		q.close()
+	}()
+
+	for {
+		writes, ok := q.pop()
+		if !ok {
+			return q.err
+		}
+
+		err = send(&replicationMessage{Writes: writes})
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// errReplicaLagging is returned when a replica is disconnected because
+// the transactions waiting to be sent to it exceed the maximum lag.
+var errReplicaLagging = errors.New("replica is lagging too far behind")
+
+// writeQueue holds the transactions to send to a replica, so that
+// the transactions committed to the primary don't wait for the network.
+// The queue is closed, and overflow is called, once the size of the
+// transactions it holds exceeds maxSize.
+type writeQueue struct {
+	mu       sync.Mutex
+	cond     *sync.Cond
+	queue    [][]database.Write
+	size     int64
+	maxSize  int64
+	overflow func()
+	closed   bool
+	err      error
+}
+
+func newWriteQueue(maxSize int64, overflow func()) *writeQueue {
+	q := writeQueue{maxSize: maxSize, overflow: overflow}
+	q.cond = sync.NewCond(&q.mu)
+	return &q
+}
+
+func (q *writeQueue) push(writes []database.Write) {
+	q.mu.Lock()
+	if q.closed {
+		q.mu.Unlock()
+		return
+	}
+
+	q.queue = append(q.queue, writes)
+	q.size += writesSize(writes)
+	full := q.maxSize > 0 && q.size > q.maxSize
+	if full {
+		q.queue, q.size = nil, 0
+		q.closed = true
+		q.err = errReplicaLagging
+	}
+	q.mu.Unlock()
+	q.cond.Signal()
+
+	if full {
+		q.overflow()
+	}
+}
+
+// pop waits for the next transaction. It returns false once the queue is closed.
+func (q *writeQueue) pop() ([]database.Write, bool) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	for len(q.queue) == 0 && !q.closed {
+		q.cond.Wait()
+	}
+	if q.closed {
+		return nil, false
+	}
+
+	writes := q.queue[0]
+	q.queue[0] = nil
+	q.queue = q.queue[1:]
+	q.size -= writesSize(writes)
+	return writes, true
+}
+
+func (q *writeQueue) close() {
+	q.mu.Lock()
+	q.closed = true
+	q.mu.Unlock()
+	q.cond.Signal()
+}
+
+// writesSize returns the number of bytes written by a transaction.
+func writesSize(writes []database.Write) int64 {
+	var n int64
+	for _, w := range writes {
+		n += int64(len(w.Store) + len(w.Key) + len(w.Value))
+	}
+	return n
+}
+
+// OpenReplica connects to the primary database served by ServeReplicas at addr,
+// and returns an in-memory, read-only copy of it.
+// The replica applies the transactions committed to the primary in the order they
+// were committed, each one atomically, but asynchronously: it may not have
+// applied the latest transactions of the primary yet.
+// If the connection to the primary is lost, the replica stops following it and
+// keeps the last state it received.
+// Subscribe doesn't report the changes applied by replicas.
+func OpenReplica(addr string) (*DB, error) {
+	conn, err := net.Dial("tcp", addr)
+	if err != nil {
+		return nil, err
+	}
+
+	ng, dec, err := receiveSnapshot(conn)
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+
+	opts := defaultOptions()
+	opts.ReadOnly = true
+	db, err := newDatabase(context.Background(), ng, opts)
+	if err != nil {
+		conn.Close()
+		ng.Close()
+		return nil, err
+	}
+
+	db.replica = &replica{conn: conn, done: make(chan struct{})}
+	go db.replica.follow(db.db, dec)
+
+	return db, nil
+}
+
+// receiveSnapshot copies the snapshot sent by the primary into a new in-memory engine.
+func receiveSnapshot(conn net.Conn) (engine.Engine, *gob.Decoder, error) {
+	ng := memoryengine.NewEngine()
+	dec := gob.NewDecoder(bufio.NewReader(conn))
+
+	for {
+		var msg replicationMessage
+		err := dec.Decode(&msg)
+		if err != nil {
+			ng.Close()
+			return nil, nil, err
+		}
+
+		if !msg.Snapshot {
+			return ng, dec, nil
+		}
+
+		tx, err := ng.Begin(context.Background(), engine.TxOptions{Writable: true})
+		if err != nil {
+			ng.Close()
+			return nil, nil, err
+		}
+
+		err = database.ApplyWrites(tx, msg.Writes)
+		if err == nil {
+			err = tx.Commit()
+		}
+		if err != nil {
+			tx.Rollback()
+			ng.Close()
+			return nil, nil, err
+		}
+	}
+}
+
+// replica applies the transactions sent by the primary.
+type replica struct {
+	conn net.Conn
+	done chan struct{}
+}
+
+func (r *replica) follow(db *database.Database, dec *gob.Decoder) {
+	defer close(r.done)
+
+	for {
+		var msg replicationMessage
+		err := dec.Decode(&msg)
+		if err != nil {
+			return
+		}
+
+		err = db.Apply(msg.Writes)
+		if err != nil {
+			r.conn.Close()
+			return
+		}
+	}
+}
+
+// close disconnects from the primary and waits for the replica
+// to stop applying transactions.
+func (r *replica) close() {
+	r.conn.Close()
+	<-r.done
+}
@@ -0,0 +1,134 @@
+package genji_test
+
+import (
+	"io"
+	"io/ioutil"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/genjidb/genji"
+	"github.com/genjidb/genji/document"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReplication(t *testing.T) {
+	primary, err := genji.Open(":memory:")
+	require.NoError(t, err)
+	defer primary.Close()
+
+	err = primary.Exec(`
+		CREATE TABLE foo (a INT PRIMARY KEY, b TEXT);
+		CREATE INDEX foo_b ON foo (b);
+		INSERT INTO foo (a, b) VALUES (1, 'x'), (2, 'y');
+	`)
+	require.NoError(t, err)
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	done := make(chan error)
+	go func() {
+		done <- primary.ServeReplicas(l)
+	}()
+
+	replica, err := genji.OpenReplica(l.Addr().String())
+	require.NoError(t, err)
+	defer replica.Close()
+
+	count := func(q string) int {
+		var n int
+		d, err := replica.QueryDocument(q)
+		require.NoError(t, err)
+		require.NoError(t, document.Scan(d, &n))
+		return n
+	}
+
+	// the replica starts with a copy of the primary
+	require.Equal(t, 2, count("SELECT COUNT(*) FROM foo"))
+	require.Equal(t, 1, count("SELECT COUNT(*) FROM foo WHERE b = 'y'"))
+
+	// then follows its transactions, including schema changes
+	err = primary.Exec(`
+		INSERT INTO foo (a, b) VALUES (3, 'y');
+		DELETE FROM foo WHERE a = 1;
+		CREATE TABLE bar (a INT);
+		INSERT INTO bar (a) VALUES (1), (2), (3);
+	`)
+	require.NoError(t, err)
+
+	require.Eventually(t, func() bool {
+		return count("SELECT COUNT(*) FROM __genji_catalog WHERE name = 'bar'") == 1 &&
+			count("SELECT COUNT(*) FROM bar") == 3
+	}, time.Second, 10*time.Millisecond)
+	require.Equal(t, 2, count("SELECT COUNT(*) FROM foo"))
+	require.Equal(t, 2, count("SELECT COUNT(*) FROM foo WHERE b = 'y'"))
+
+	err = primary.Exec("DROP TABLE bar")
+	require.NoError(t, err)
+	require.Eventually(t, func() bool {
+		return count("SELECT COUNT(*) FROM __genji_catalog WHERE name = 'bar'") == 0
+	}, time.Second, 10*time.Millisecond)
+
+	// replicas are read-only
+	err = replica.Exec("INSERT INTO foo (a, b) VALUES (10, 'z')")
+	require.Error(t, err)
+
+	// closing the listener disconnects the replicas
+	require.NoError(t, l.Close())
+	require.Error(t, <-done)
+
+	err = primary.Exec("INSERT INTO foo (a, b) VALUES (4, 'z')")
+	require.NoError(t, err)
+	require.Equal(t, 2, count("SELECT COUNT(*) FROM foo"))
+}
+
+func TestReplicationLag(t *testing.T) {
+	primary, err := genji.Open(":memory:")
+	require.NoError(t, err)
+	defer primary.Close()
+
+	primary.SetMaxReplicaLag(1 << 20)
+	err = primary.Exec("CREATE TABLE foo (a INT PRIMARY KEY, b TEXT)")
+	require.NoError(t, err)
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer l.Close()
+	go func() {
+		_ = primary.ServeReplicas(l)
+	}()
+
+	// a replica that stops reading
+	stalled, err := net.Dial("tcp", l.Addr().String())
+	require.NoError(t, err)
+	defer stalled.Close()
+
+	// commit more than what the network buffers and the queue of the stalled replica can hold
+	b := strings.Repeat("x", 64<<10)
+	for i := 0; i < 500; i++ {
+		err = primary.Exec("INSERT INTO foo (a, b) VALUES (?, ?)", i, b)
+		require.NoError(t, err)
+	}
+
+	// the stalled replica was disconnected before receiving every transaction
+	require.NoError(t, stalled.SetReadDeadline(time.Now().Add(5*time.Second)))
+	n, err := io.Copy(ioutil.Discard, stalled)
+	require.NoError(t, err)
+	require.Less(t, n, int64(500*len(b)))
+
+	// other replicas are still served
+	replica, err := genji.OpenReplica(l.Addr().String())
+	require.NoError(t, err)
+	defer replica.Close()
+
+	err = primary.Exec("INSERT INTO foo (a, b) VALUES (500, 'x')")
+	require.NoError(t, err)
+	require.Eventually(t, func() bool {
+		var n int
+		d, err := replica.QueryDocument("SELECT COUNT(*) FROM foo")
+		require.NoError(t, err)
+		require.NoError(t, document.Scan(d, &n))
+		return n == 501
+	}, 5*time.Second, 10*time.Millisecond)
+}
@@ -0,0 +1,97 @@
+package genji_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/genjidb/genji"
+	errs "github.com/genjidb/genji/errors"
+	"github.com/genjidb/genji/internal/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSoftDelete(t *testing.T) {
+	db, err := genji.Open(":memory:")
+	require.NoError(t, err)
+	defer db.Close()
+
+	requireDocs := func(t *testing.T, expected string, q string, args ...interface{}) {
+		t.Helper()
+
+		res, err := db.Query(q, args...)
+		require.NoError(t, err)
+		defer res.Close()
+
+		testutil.RequireStreamEq(t, expected, res)
+	}
+
+	err = db.Exec(`
+		CREATE TABLE foo (a INT PRIMARY KEY, b TEXT UNIQUE, c INT) WITH delete_mode = soft;
+		CREATE INDEX idx_foo_c ON foo (c);
+		INSERT INTO foo (a, b, c) VALUES (1, 'x', 10), (2, 'y', 20), (3, 'z', 30);
+		DELETE FROM foo WHERE a >= 2;
+	`)
+	require.NoError(t, err)
+
+	// deleted documents are hidden, including from primary key and index lookups
+	requireDocs(t, `{"a": 1, "b": "x", "c": 10}`, `SELECT * FROM foo`)
+	requireDocs(t, ``, `SELECT * FROM foo WHERE a = 2`)
+	requireDocs(t, ``, `SELECT * FROM foo WHERE c = 20`)
+	requireDocs(t, `{"COUNT(*)": 1}`, `SELECT COUNT(*) FROM foo`)
+
+	// unless they are requested explicitly
+	requireDocs(t, `{"a": 1} {"a": 2} {"a": 3}`, `SELECT a FROM foo WITH DELETED`)
+	requireDocs(t, `{"a": 2}`, `SELECT a FROM foo WITH DELETED WHERE c = 20`)
+
+	// deleting a deleted document is a no-op
+	err = db.Exec(`DELETE FROM foo WHERE a = 2`)
+	require.NoError(t, err)
+
+	// deleted documents keep their primary key and their unique values until they are purged
+	err = db.Exec(`INSERT INTO foo (a, b, c) VALUES (2, 'w', 21)`)
+	require.ErrorIs(t, err, errs.ErrDuplicateDocument)
+	err = db.Exec(`INSERT INTO foo (a, b, c) VALUES (4, 'z', 40)`)
+	require.ErrorIs(t, err, errs.ErrDuplicateDocument)
+	var cerr *errs.ConstraintViolationError
+	require.True(t, errors.As(err, &cerr))
+	err = db.Exec(`INSERT INTO foo (a, b, c) VALUES (2, 'w', 21) ON CONFLICT DO REPLACE`)
+	require.ErrorIs(t, err, errs.ErrDuplicateDocument)
+	requireDocs(t, `{"a": 1, "b": "x", "c": 10} {"a": 2, "b": "y", "c": 20} {"a": 3, "b": "z", "c": 30}`, `SELECT * FROM foo WITH DELETED`)
+
+	err = db.Exec(`PURGE foo WHERE a = 2; INSERT INTO foo (a, b, c) VALUES (2, 'w', 21)`)
+	require.NoError(t, err)
+	requireDocs(t, `{"a": 1, "b": "x", "c": 10} {"a": 2, "b": "w", "c": 21} {"a": 3, "b": "z", "c": 30}`, `SELECT * FROM foo WITH DELETED`)
+
+	// PURGE only removes deleted documents
+	err = db.Exec(`PURGE foo WHERE c > 1`)
+	require.NoError(t, err)
+	requireDocs(t, `{"a": 1} {"a": 2}`, `SELECT a FROM foo WITH DELETED`)
+	requireDocs(t, ``, `SELECT a FROM foo WHERE c = 30`)
+
+	// the delete mode is stored with the table
+	d, err := db.QueryDocument(`SELECT sql FROM __genji_catalog WHERE name = 'foo'`)
+	require.NoError(t, err)
+	testutil.RequireDocJSONEq(t, d, `{"sql": "CREATE TABLE foo (a INTEGER PRIMARY KEY, b TEXT UNIQUE, c INTEGER) WITH delete_mode = soft"}`)
+
+	err = db.Exec(`DELETE FROM foo; PURGE foo; DROP TABLE foo`)
+	require.NoError(t, err)
+
+	// only tables can be purged
+	err = db.Exec(`PURGE foo`)
+	require.EqualError(t, err, `PURGE can only be used on tables, "foo" is not a table`)
+	err = db.Exec(`CREATE TABLE baz (a INT) WITH (delete_mode = 'soft'); CREATE VIEW v AS SELECT * FROM baz`)
+	require.NoError(t, err)
+	err = db.Exec(`PURGE v`)
+	require.EqualError(t, err, `PURGE can only be used on tables, "v" is not a table`)
+	_, err = db.Query(`SELECT * FROM v WITH DELETED`)
+	require.EqualError(t, err, `WITH DELETED can only be used on tables, "v" is not a table`)
+
+	// hard deletes are the default
+	err = db.Exec(`
+		CREATE TABLE bar (a INT);
+		INSERT INTO bar (a) VALUES (1);
+		DELETE FROM bar;
+	`)
+	require.NoError(t, err)
+	requireDocs(t, ``, `SELECT * FROM bar WITH DELETED`)
+}
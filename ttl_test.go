@@ -0,0 +1,147 @@
+package genji_test
+
+import (
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/genjidb/genji"
+	"github.com/genjidb/genji/document"
+	"github.com/genjidb/genji/internal/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTTL(t *testing.T) {
+	setup := func(t *testing.T) *genji.DB {
+		t.Helper()
+
+		db, err := genji.OpenWith(":memory:", &genji.Options{TTLReapInterval: -1})
+		require.NoError(t, err)
+
+		err = db.Exec(`
+			CREATE TABLE sessions (id TEXT PRIMARY KEY, token TEXT UNIQUE, expires TIMESTAMP) WITH (ttl_field = 'expires');
+			INSERT INTO sessions (id, token, expires) VALUES
+				('a', 'ta', '2000-01-01T00:00:00Z'),
+				('b', 'tb', '2100-01-01T00:00:00Z'),
+				('c', 'tc', '2000-01-02T00:00:00Z'),
+				('d', 'td', NULL);
+		`)
+		require.NoError(t, err)
+		return db
+	}
+
+	requireIDs := func(t *testing.T, db *genji.DB, q string, expected string) {
+		t.Helper()
+
+		res, err := db.Query(q)
+		require.NoError(t, err)
+		defer res.Close()
+
+		testutil.RequireStreamEq(t, expected, res)
+	}
+
+	t.Run("Invalid ttl field", func(t *testing.T) {
+		db, err := genji.Open(":memory:")
+		require.NoError(t, err)
+		defer db.Close()
+
+		err = db.Exec("CREATE TABLE test (expires TEXT) WITH ttl_field = 'expires'")
+		require.EqualError(t, err, `ttl field "expires" must be a TIMESTAMP field`)
+
+		err = db.Exec("CREATE TABLE test (a INT) WITH ttl_field = 'expires'")
+		require.EqualError(t, err, `ttl field "expires" must be a TIMESTAMP field`)
+
+		err = db.Exec("CREATE TABLE test (expires TIMESTAMP) WITH ttl_field = 'expires'")
+		require.NoError(t, err)
+		err = db.Exec("ALTER TABLE test ALTER FIELD expires TYPE TEXT")
+		require.EqualError(t, err, `ttl field "expires" must be a TIMESTAMP field`)
+
+		// renaming the field renames the ttl field
+		err = db.Exec("ALTER TABLE test RENAME FIELD expires TO expires_at")
+		require.NoError(t, err)
+		d, err := db.QueryDocument("SELECT sql FROM __genji_catalog WHERE name = 'test'")
+		require.NoError(t, err)
+		testutil.RequireDocJSONEq(t, d, `{"sql": "CREATE TABLE test (expires_at TIMESTAMP) WITH ttl_field = \"expires_at\""}`)
+	})
+
+	t.Run("Expired documents are hidden", func(t *testing.T) {
+		db := setup(t)
+		defer db.Close()
+
+		// sequential, primary key and index scans
+		requireIDs(t, db, `SELECT id FROM sessions`, `{"id": "b"} {"id": "d"}`)
+		requireIDs(t, db, `SELECT id FROM sessions WHERE id = 'a'`, ``)
+		requireIDs(t, db, `SELECT id FROM sessions WHERE token >= 'ta'`, `{"id": "b"} {"id": "d"}`)
+		requireIDs(t, db, `SELECT COUNT(*) FROM sessions`, `{"COUNT(*)": 2}`)
+
+		// expired documents can't be updated or deleted
+		err := db.Exec(`UPDATE sessions SET expires = NULL`)
+		require.NoError(t, err)
+		err = db.Exec(`DELETE FROM sessions WHERE id = 'a'`)
+		require.NoError(t, err)
+		requireIDs(t, db, `SELECT id FROM sessions`, `{"id": "b"} {"id": "d"}`)
+
+		// documents expire once their ttl field is in the past,
+		// which makes the results of queries impossible to cache
+		db.SetQueryCacheSize(10)
+		err = db.Exec(`UPDATE sessions SET expires = NOW() + INTERVAL '50 ms' WHERE id = 'b'`)
+		require.NoError(t, err)
+		requireIDs(t, db, `SELECT id FROM sessions`, `{"id": "b"} {"id": "d"}`)
+		require.Zero(t, genji.QueryCacheLen(db))
+		time.Sleep(50 * time.Millisecond)
+		requireIDs(t, db, `SELECT id FROM sessions`, `{"id": "d"}`)
+	})
+
+	t.Run("Expired documents are replaced", func(t *testing.T) {
+		db := setup(t)
+		defer db.Close()
+
+		// by primary key and by unique value
+		err := db.Exec(`INSERT INTO sessions (id, token) VALUES ('a', 'new')`)
+		require.NoError(t, err)
+		err = db.Exec(`INSERT INTO sessions (id, token) VALUES ('e', 'tc')`)
+		require.NoError(t, err)
+		requireIDs(t, db, `SELECT id, token FROM sessions`,
+			`{"id": "a", "token": "new"} {"id": "b", "token": "tb"} {"id": "d", "token": "td"} {"id": "e", "token": "tc"}`)
+
+		// documents that haven't expired still conflict
+		err = db.Exec(`INSERT INTO sessions (id, token) VALUES ('b', 'other')`)
+		require.Error(t, err)
+		err = db.Exec(`INSERT INTO sessions (id, token) VALUES ('f', 'tb')`)
+		require.Error(t, err)
+	})
+
+	t.Run("Reaper", func(t *testing.T) {
+		db, err := genji.OpenWith(":memory:", &genji.Options{TTLReapInterval: 10 * time.Millisecond})
+		require.NoError(t, err)
+		defer db.Close()
+
+		err = db.Exec(`CREATE TABLE sessions (id INT PRIMARY KEY, expires TIMESTAMP) WITH ttl_field = 'expires'`)
+		require.NoError(t, err)
+
+		var deleted int64
+		cancel := db.Subscribe("sessions", func(op genji.ChangeOp, old, new document.Document) {
+			if op == genji.ChangeDelete {
+				atomic.AddInt64(&deleted, 1)
+			}
+		})
+		defer cancel()
+
+		// more documents than deleted by each transaction of the reaper
+		err = db.Update(func(tx *genji.Tx) error {
+			for i := 0; i < 250; i++ {
+				err := tx.Exec(`INSERT INTO sessions (id, expires) VALUES (?, NOW() + INTERVAL '50 ms')`, i)
+				if err != nil {
+					return err
+				}
+			}
+			return tx.Exec(`INSERT INTO sessions (id, expires) VALUES (1000, NOW() + INTERVAL '1 hour')`)
+		})
+		require.NoError(t, err)
+
+		require.Eventually(t, func() bool {
+			return atomic.LoadInt64(&deleted) == 250
+		}, 5*time.Second, 10*time.Millisecond)
+		requireIDs(t, db, `SELECT id FROM sessions`, `{"id": 1000}`)
+	})
+}
@@ -0,0 +1,86 @@
+//go:build go1.18 && !wasm
+// +build go1.18,!wasm
+
+// This package is built on the generic helpers of the genji package,
+// which are not available under wasm.
+
+// Package typed provides a typed API on top of Genji tables, mapping documents to Go structs.
+//
+// Documents are converted using the same rules as document.NewFromStruct and document.StructScan:
+// each exported field is stored under its lowercased name, or under the name set in the "genji" tag.
+//
+//	type User struct {
+//		ID   int64
+//		Name string
+//		Age  int
+//	}
+//
+//	users := typed.Table[User](db, "users")
+//	err := users.Insert(User{ID: 10, Name: "foo", Age: 20})
+//	u, err := users.Get(10)
+//	adults, err := users.Query("WHERE age >= ? ORDER BY name", 18)
+//
+// This package requires Go 1.18 or later.
+package typed
+
+import (
+	"github.com/genjidb/genji"
+	"github.com/genjidb/genji/internal/stringutil"
+)
+
+// Queryer runs queries. It is implemented by *genji.DB and *genji.Tx.
+type Queryer interface {
+	genji.Queryer
+	Exec(q string, args ...interface{}) error
+}
+
+var (
+	_ Queryer = (*genji.DB)(nil)
+	_ Queryer = (*genji.Tx)(nil)
+)
+
+// TableOf gives access to the documents of a table as values of type T.
+// T must be a struct type.
+type TableOf[T any] struct {
+	q    Queryer
+	name string
+}
+
+// Table returns a typed handle on the given table. The table is not created
+// and must exist when the handle is used.
+// The handle runs its queries using q, which can be a database or a transaction.
+func Table[T any](q Queryer, name string) *TableOf[T] {
+	return &TableOf[T]{
+		q:    q,
+		name: stringutil.NormalizeIdentifier(name, '`'),
+	}
+}
+
+// Insert stores v in the table.
+func (t *TableOf[T]) Insert(v T) error {
+	return t.q.Exec("INSERT INTO "+t.name+" VALUES ?", &v)
+}
+
+// Get returns the document whose primary key is equal to pk.
+// If there is none, it returns errs.ErrDocumentNotFound.
+func (t *TableOf[T]) Get(pk interface{}) (T, error) {
+	return genji.GetAs[T](t.q, "SELECT * FROM "+t.name+" WHERE pk() = ?", pk)
+}
+
+// Query returns the documents selected by the given clause, appended
+// to "SELECT * FROM <table>", for instance "WHERE age > ? ORDER BY name LIMIT 10".
+// An empty clause returns all the documents of the table.
+func (t *TableOf[T]) Query(clause string, args ...interface{}) ([]T, error) {
+	q := "SELECT * FROM " + t.name
+	if clause != "" {
+		q += " " + clause
+	}
+
+	return genji.QueryAs[T](t.q, q, args...)
+}
+
+// Delete removes the document whose primary key is equal to pk.
+// It doesn't return an error if there is none.
+func (t *TableOf[T]) Delete(pk interface{}) error {
+	return t.q.Exec("DELETE FROM "+t.name+" WHERE pk() = ?", pk)
+}
@@ -0,0 +1,85 @@
+//go:build go1.18 && !wasm
+// +build go1.18,!wasm
+
+package typed_test
+
+import (
+	"testing"
+
+	"github.com/genjidb/genji"
+	errs "github.com/genjidb/genji/errors"
+	"github.com/genjidb/genji/typed"
+	"github.com/stretchr/testify/require"
+)
+
+type user struct {
+	ID      int64
+	Name    string
+	Age     int
+	Address *address `genji:"addr"`
+}
+
+type address struct {
+	City string
+}
+
+func TestTable(t *testing.T) {
+	db, err := genji.Open(":memory:")
+	require.NoError(t, err)
+	defer db.Close()
+
+	err = db.Exec(`CREATE TABLE users(id INTEGER PRIMARY KEY)`)
+	require.NoError(t, err)
+
+	users := typed.Table[user](db, "users")
+
+	err = users.Insert(user{ID: 1, Name: "foo", Age: 30, Address: &address{City: "Lyon"}})
+	require.NoError(t, err)
+	err = users.Insert(user{ID: 2, Name: "bar", Age: 10})
+	require.NoError(t, err)
+	err = users.Insert(user{ID: 3, Name: "baz", Age: 20})
+	require.NoError(t, err)
+
+	t.Run("Get", func(t *testing.T) {
+		u, err := users.Get(1)
+		require.NoError(t, err)
+		require.Equal(t, user{ID: 1, Name: "foo", Age: 30, Address: &address{City: "Lyon"}}, u)
+
+		_, err = users.Get(10)
+		require.Equal(t, errs.ErrDocumentNotFound, err)
+	})
+
+	t.Run("Query", func(t *testing.T) {
+		list, err := users.Query("WHERE age >= ? ORDER BY name", 20)
+		require.NoError(t, err)
+		require.Equal(t, []user{
+			{ID: 3, Name: "baz", Age: 20},
+			{ID: 1, Name: "foo", Age: 30, Address: &address{City: "Lyon"}},
+		}, list)
+
+		list, err = users.Query("")
+		require.NoError(t, err)
+		require.Len(t, list, 3)
+
+		list, err = users.Query("WHERE age > 100")
+		require.NoError(t, err)
+		require.Empty(t, list)
+	})
+
+	t.Run("Transaction", func(t *testing.T) {
+		err := db.Update(func(tx *genji.Tx) error {
+			users := typed.Table[user](tx, "users")
+
+			err := users.Delete(2)
+			require.NoError(t, err)
+
+			_, err = users.Get(2)
+			require.Equal(t, errs.ErrDocumentNotFound, err)
+			return nil
+		})
+		require.NoError(t, err)
+
+		_, err = users.Get(2)
+		require.Equal(t, errs.ErrDocumentNotFound, err)
+	})
+}
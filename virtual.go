@@ -0,0 +1,52 @@
+package genji
+
+import (
+	"context"
+
+	"github.com/genjidb/genji/document"
+)
+
+// A VirtualTable is a table whose documents are provided by the application instead of
+// being stored by the database, such as the rows of a CSV file, the results of an HTTP API
+// or the tables of another database.
+// Virtual tables can be queried using SELECT statements, alongside regular tables.
+// The context is the one used to start the transaction, see DB.WithContext.
+type VirtualTable interface {
+	// Iterate calls fn for every document of the table.
+	// Errors returned by fn must be returned as is.
+	Iterate(ctx context.Context, fn func(d document.Document) error) error
+}
+
+// A VirtualTableInserter is a virtual table accepting documents inserted
+// using INSERT statements.
+type VirtualTableInserter interface {
+	VirtualTable
+
+	// Insert adds d to the table.
+	Insert(ctx context.Context, d document.Document) error
+}
+
+// A VirtualTableIndexer is a virtual table able to efficiently select documents by the value
+// of some of their fields, for example by passing them to a remote API.
+// Queries filtering one of these fields using the = operator and a constant or a parameter
+// call Lookup instead of Iterate.
+type VirtualTableIndexer interface {
+	VirtualTable
+
+	// Indexed reports whether documents can be selected by the value of the field at path.
+	Indexed(path document.Path) bool
+	// Lookup calls fn for every document whose field at path is equal to v.
+	Lookup(ctx context.Context, path document.Path, v document.Value, fn func(d document.Document) error) error
+}
+
+// RegisterVirtualTable registers a virtual table under the given name, replacing any existing one.
+// It returns an error if a table, an index or a sequence already uses that name.
+// If t is nil, the virtual table is removed.
+// Virtual tables are not persisted and must be registered every time the database is opened.
+// Queries reading virtual tables are never cached.
+func (db *DB) RegisterVirtualTable(name string, t VirtualTable) error {
+	// cached plans depend on the registered virtual tables
+	defer db.plans.clear()
+
+	return db.db.Catalog.RegisterVirtualTable(name, t)
+}
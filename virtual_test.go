@@ -0,0 +1,169 @@
+package genji_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/genjidb/genji"
+	"github.com/genjidb/genji/document"
+	"github.com/genjidb/genji/internal/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+// sliceTable is a virtual table storing its documents in memory,
+// indexed by their id field.
+type sliceTable struct {
+	docs    []document.Document
+	lookups int
+}
+
+func (t *sliceTable) Iterate(ctx context.Context, fn func(d document.Document) error) error {
+	for _, d := range t.docs {
+		err := fn(d)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (t *sliceTable) Insert(ctx context.Context, d document.Document) error {
+	fb := document.NewFieldBuffer()
+	err := fb.Copy(d)
+	if err != nil {
+		return err
+	}
+
+	t.docs = append(t.docs, fb)
+	return nil
+}
+
+func (t *sliceTable) Indexed(path document.Path) bool {
+	return path.String() == "id"
+}
+
+func (t *sliceTable) Lookup(ctx context.Context, path document.Path, v document.Value, fn func(d document.Document) error) error {
+	t.lookups++
+
+	return t.Iterate(ctx, func(d document.Document) error {
+		id, err := path.GetValueFromDocument(d)
+		if err != nil {
+			return err
+		}
+
+		ok, err := id.IsEqual(v)
+		if err != nil || !ok {
+			return err
+		}
+
+		return fn(d)
+	})
+}
+
+// readOnlyTable only implements the VirtualTable interface.
+type readOnlyTable struct {
+	t *sliceTable
+}
+
+func (t readOnlyTable) Iterate(ctx context.Context, fn func(d document.Document) error) error {
+	return t.t.Iterate(ctx, fn)
+}
+
+func TestVirtualTable(t *testing.T) {
+	setup := func(t *testing.T) (*genji.DB, *sliceTable) {
+		t.Helper()
+
+		db, err := genji.Open(":memory:")
+		require.NoError(t, err)
+
+		err = db.Exec(`
+			CREATE TABLE native(id INTEGER PRIMARY KEY, label TEXT);
+			INSERT INTO native (id, label) VALUES (1, 'one'), (2, 'two');
+		`)
+		require.NoError(t, err)
+
+		vt := &sliceTable{
+			docs: testutil.MakeDocuments(t, `{"id": 1, "name": "a"}`, `{"id": 2, "name": "b"}`, `{"id": 3, "name": "c"}`),
+		}
+		err = db.RegisterVirtualTable("virt", vt)
+		require.NoError(t, err)
+
+		return db, vt
+	}
+
+	requireQuery := func(t *testing.T, db *genji.DB, q string, expected string, args ...interface{}) {
+		t.Helper()
+
+		res, err := db.Query(q, args...)
+		require.NoError(t, err)
+		defer res.Close()
+
+		testutil.RequireStreamEq(t, expected, res)
+	}
+
+	t.Run("Select", func(t *testing.T) {
+		db, vt := setup(t)
+		defer db.Close()
+
+		requireQuery(t, db, `SELECT name FROM virt WHERE id > 1 ORDER BY name DESC`, `{"name": "c"} {"name": "b"}`)
+		requireQuery(t, db, `SELECT COUNT(*) FROM virt`, `{"COUNT(*)": 3}`)
+		require.Equal(t, 0, vt.lookups)
+
+		// copying documents to a native table
+		err := db.Exec(`INSERT INTO native SELECT id + 10 AS id, name AS label FROM virt WHERE id < 3`)
+		require.NoError(t, err)
+		requireQuery(t, db, `SELECT label FROM native WHERE id > 10`, `{"label": "a"} {"label": "b"}`)
+	})
+
+	t.Run("Lookup", func(t *testing.T) {
+		db, vt := setup(t)
+		defer db.Close()
+
+		requireQuery(t, db, `SELECT name FROM virt WHERE id = 2`, `{"name": "b"}`)
+		requireQuery(t, db, `SELECT name FROM virt WHERE id = ? AND name = 'c'`, `{"name": "c"}`, 3)
+		requireQuery(t, db, `SELECT name FROM virt WHERE name = 'a'`, `{"name": "a"}`)
+		require.Equal(t, 2, vt.lookups)
+
+		requireQuery(t, db, `EXPLAIN SELECT name FROM virt WHERE id = 2`, `{"plan": "virtualLookup(virt, id, 2) | project(name)"}`)
+	})
+
+	t.Run("Insert", func(t *testing.T) {
+		db, vt := setup(t)
+		defer db.Close()
+
+		err := db.Exec(`INSERT INTO virt (id, name) VALUES (4, 'd')`)
+		require.NoError(t, err)
+		require.Len(t, vt.docs, 4)
+		requireQuery(t, db, `SELECT name FROM virt WHERE id = 4`, `{"name": "d"}`)
+
+		err = db.Exec(`INSERT INTO virt SELECT id, label AS name FROM native`)
+		require.NoError(t, err)
+		require.Len(t, vt.docs, 6)
+
+		err = db.RegisterVirtualTable("ro", readOnlyTable{vt})
+		require.NoError(t, err)
+		err = db.Exec(`INSERT INTO ro (id) VALUES (5)`)
+		require.Error(t, err)
+	})
+
+	t.Run("Names", func(t *testing.T) {
+		db, _ := setup(t)
+		defer db.Close()
+
+		err := db.RegisterVirtualTable("native", &sliceTable{})
+		require.Error(t, err)
+
+		err = db.Exec(`CREATE TABLE virt`)
+		require.Error(t, err)
+
+		err = db.RegisterVirtualTable("virt", nil)
+		require.NoError(t, err)
+
+		_, err = db.Query(`SELECT * FROM virt`)
+		require.Error(t, err)
+
+		err = db.Exec(`CREATE TABLE virt`)
+		require.NoError(t, err)
+	})
+}